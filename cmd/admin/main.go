@@ -0,0 +1,412 @@
+// Command admin provisions and repairs accounts directly against the configured
+// database, e.g. to create the first admin of a fresh deployment:
+//
+//	go run ./cmd/admin create-user --username root --email root@example.com --role admin
+//	go run ./cmd/admin reset-password --username root --password-stdin < pw.txt
+//	go run ./cmd/admin mint-token --username root --ttl 1h
+//	go run ./cmd/admin create-store --slug books --name "Book Shop" --domain books.example.com
+//	go run ./cmd/admin replay-events --type "product.*" --since 2026-01-01T00:00:00Z --queue search.products
+//
+// In multi-store mode, --store selects the store of the account commands and
+// of replay-events.
+// Every command also accepts the usual config flags (--env, --database.host, ...).
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/proyuen/go-mall/internal/app"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/tenant"
+	"github.com/proyuen/go-mall/pkg/validation"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// shutdownTimeout bounds closing connections once the command finishes.
+	shutdownTimeout = 15 * time.Second
+	// defaultTokenTTL is how long minted tokens stay valid unless --ttl says otherwise.
+	defaultTokenTTL = time.Hour
+	// generatedPasswordBytes yields a 16-character password, within the
+	// register endpoint's 6-20 character limit.
+	generatedPasswordBytes = 12
+)
+
+const usage = `usage: admin <command> [flags]
+
+commands:
+  create-user      create an account (use --role admin for the first admin)
+  reset-password   set a new password for an existing account
+  mint-token       print an access token for an existing account, for testing
+  create-store     create a store for multi-store mode
+  replay-events    publish domain events from the outbox again, e.g. to backfill a new consumer
+
+Run "admin <command> --help" for the flags of a command.`
+
+// storeInput is validated before a store is created.
+type storeInput struct {
+	Slug     string `json:"slug" binding:"required,max=50,alphanum"`
+	Name     string `json:"name" binding:"required,max=100"`
+	Domain   string `json:"domain" binding:"omitempty,fqdn"`
+	Currency string `json:"currency" binding:"omitempty,iso4217"`
+	Locale   string `json:"locale" binding:"omitempty,bcp47_language_tag"`
+}
+
+// accountInput is validated with the same rules as the register endpoint.
+type accountInput struct {
+	Username string `json:"username" binding:"required,min=3,max=50,username"`
+	Email    string `json:"email" binding:"omitempty,email"` // Only create-user sets it
+	Password string `json:"password" binding:"required,min=6,max=20"`
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "create-user":
+		err = createUser(args)
+	case "reset-password":
+		err = resetPassword(args)
+	case "mint-token":
+		err = mintToken(args)
+	case "create-store":
+		err = createStore(args)
+	case "replay-events":
+		err = replayEvents(args)
+	case "-h", "--help", "help":
+		fmt.Println(usage)
+	default:
+		err = fmt.Errorf("unknown command %q\n\n%s", cmd, usage)
+	}
+	if err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func createUser(args []string) error {
+	flags := config.NewFlagSet("admin create-user")
+	storeFlag(flags)
+	username := flags.String("username", "", "login name of the new account")
+	email := flags.String("email", "", "email address of the new account")
+	role := flags.String("role", model.RoleUser, fmt.Sprintf("%q or %q", model.RoleUser, model.RoleAdmin))
+	password := passwordFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New("--email is required")
+	}
+
+	pw, generated, err := password.resolve()
+	if err != nil {
+		return err
+	}
+	if err := validateInput(accountInput{Username: *username, Email: *email, Password: pw}); err != nil {
+		return err
+	}
+
+	return withContainer(flags, "create-user", func(ctx context.Context, accounts service.AccountService) error {
+		account, err := accounts.CreateUser(ctx, &service.AccountCreateReq{
+			Username: *username,
+			Email:    *email,
+			Password: pw,
+			Role:     *role,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created %s %q (id %d).\n", account.Role, account.Username, account.UserID)
+		if generated {
+			fmt.Printf("Generated password: %s\n", pw)
+		}
+		return nil
+	})
+}
+
+func resetPassword(args []string) error {
+	flags := config.NewFlagSet("admin reset-password")
+	storeFlag(flags)
+	username := flags.String("username", "", "login name of the account")
+	password := passwordFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	pw, generated, err := password.resolve()
+	if err != nil {
+		return err
+	}
+	if err := validateInput(accountInput{Username: *username, Password: pw}); err != nil {
+		return err
+	}
+
+	return withContainer(flags, "reset-password", func(ctx context.Context, accounts service.AccountService) error {
+		account, err := accounts.ResetPassword(ctx, *username, pw)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Password of %q (id %d) reset.\n", account.Username, account.UserID)
+		if generated {
+			fmt.Printf("Generated password: %s\n", pw)
+		}
+		return nil
+	})
+}
+
+func mintToken(args []string) error {
+	flags := config.NewFlagSet("admin mint-token")
+	storeFlag(flags)
+	username := flags.String("username", "", "login name of the account")
+	ttl := flags.Duration("ttl", defaultTokenTTL, "how long the token stays valid")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return errors.New("--username is required")
+	}
+
+	return withContainer(flags, "mint-token", func(ctx context.Context, accounts service.AccountService) error {
+		issued, err := accounts.IssueToken(ctx, *username, *ttl)
+		if err != nil {
+			return err
+		}
+		// Only the token goes to stdout so that it can be captured by scripts.
+		fmt.Fprintf(os.Stderr, "Token for user %d expires at %s.\n", issued.UserID, issued.ExpiresAt.Format(time.RFC3339))
+		fmt.Println(issued.AccessToken)
+		return nil
+	})
+}
+
+func createStore(args []string) error {
+	flags := config.NewFlagSet("admin create-store")
+	slug := flags.String("slug", "", "identifier of the store, sent in the tenancy header")
+	name := flags.String("name", "", "display name of the store")
+	domain := flags.String("domain", "", "host the storefront is served on")
+	currency := flags.String("currency", "", "ISO 4217 currency shown to customers without a preference")
+	locale := flags.String("locale", "", "BCP 47 locale served without an Accept-Language header")
+	adopt := flags.Bool("adopt", false, "move the users, products and orders created before tenancy was enabled into the store")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := validateInput(storeInput{Slug: *slug, Name: *name, Domain: *domain, Currency: *currency, Locale: *locale}); err != nil {
+		return err
+	}
+
+	base, err := app.NewBaseFromFlags(flags)
+	if err != nil {
+		return err
+	}
+	container := app.New(base)
+	defer shutdown(container)
+
+	stores := container.StoreService()
+	if err := container.Err(); err != nil {
+		return err
+	}
+	resp, err := stores.CreateStore(context.Background(), &service.StoreCreateReq{
+		Slug:         *slug,
+		Name:         *name,
+		Domain:       *domain,
+		Currency:     *currency,
+		Locale:       *locale,
+		AdoptUnowned: *adopt,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created store %q (id %d).\n", resp.Store.Slug, resp.Store.ID)
+	if *adopt {
+		fmt.Printf("Moved %d rows into it.\n", resp.Adopted)
+	}
+	return nil
+}
+
+func replayEvents(args []string) error {
+	flags := config.NewFlagSet("admin replay-events")
+	storeFlag(flags)
+	types := flags.StringSlice("type", nil, `event types to replay, e.g. order.paid; "order.*" selects every order event (default all)`)
+	aggregateID := flags.Uint64("aggregate", 0, "ID of the user, SPU or order the events are about; needs --type")
+	since := flags.String("since", "", "replay events recorded at or after this RFC 3339 time")
+	until := flags.String("until", "", "replay events recorded before this RFC 3339 time")
+	queue := flags.String("queue", "", "publish straight into this existing queue rather than to the events exchange")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	req := &service.EventReplayReq{Types: *types, AggregateID: *aggregateID, Queue: *queue}
+	var err error
+	if req.From, err = parseTimeFlag("since", *since); err != nil {
+		return err
+	}
+	if req.To, err = parseTimeFlag("until", *until); err != nil {
+		return err
+	}
+
+	base, err := app.NewBaseFromFlags(flags)
+	if err != nil {
+		return err
+	}
+	container := app.New(base)
+	defer shutdown(container)
+
+	relay, stores := container.EventRelay(), container.StoreService()
+	if err := container.Err(); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if slug, _ := flags.GetString("store"); slug != "" {
+		store, err := stores.Resolve(ctx, slug, "")
+		if err != nil {
+			return err
+		}
+		ctx = tenant.NewContext(ctx, store)
+	}
+	report, err := relay.Replay(ctx, req)
+	if report != nil {
+		// Replayed events stay replayed when a later one fails.
+		fmt.Printf("Replayed %d events.\n", report.Replayed)
+	}
+	return err
+}
+
+// parseTimeFlag parses the RFC 3339 value of a flag; empty is the zero time.
+func parseTimeFlag(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--%s must be an RFC 3339 time: %w", name, err)
+	}
+	return t, nil
+}
+
+// storeFlag adds the --store flag withContainer reads.
+func storeFlag(flags *pflag.FlagSet) {
+	flags.String("store", "", "slug of the store the account belongs to, in multi-store mode")
+}
+
+// withContainer loads the configuration from flags, runs fn against the account
+// service in the store named by --store, if any, closes the connections it
+// needed and records whatever fn audited under the "CLI" method with no actor.
+func withContainer(flags *pflag.FlagSet, command string, fn func(ctx context.Context, accounts service.AccountService) error) error {
+	base, err := app.NewBaseFromFlags(flags)
+	if err != nil {
+		return err
+	}
+	container := app.New(base)
+	defer shutdown(container)
+
+	accounts, audit, stores := container.AccountService(), container.AuditService(), container.StoreService()
+	if err := container.Err(); err != nil {
+		return err
+	}
+
+	ctx, trail := service.WithAuditTrail(context.Background())
+	if slug, _ := flags.GetString("store"); slug != "" {
+		store, err := stores.Resolve(ctx, slug, "")
+		if err != nil {
+			return err
+		}
+		ctx = tenant.NewContext(ctx, store)
+	}
+	if err := fn(ctx, accounts); err != nil {
+		return err
+	}
+
+	if entries := trail.Entries(); len(entries) > 0 {
+		actor := service.AuditActor{Method: "CLI", Path: "admin " + command}
+		if err := audit.Write(ctx, actor, entries); err != nil {
+			slog.Warn("Failed to write audit log", logger.Err(err))
+		}
+	}
+	return nil
+}
+
+// passwordSource lets a password come from stdin rather than the command line,
+// where it would end up in shell history and process listings.
+type passwordSource struct {
+	value     *string
+	fromStdin *bool
+}
+
+func passwordFlags(flags *pflag.FlagSet) passwordSource {
+	return passwordSource{
+		value:     flags.String("password", "", "new password; prefer --password-stdin (generated when neither is given)"),
+		fromStdin: flags.Bool("password-stdin", false, "read the password from the first line of stdin"),
+	}
+}
+
+// resolve returns the password to use and whether it was generated.
+func (p passwordSource) resolve() (string, bool, error) {
+	switch {
+	case *p.fromStdin && *p.value != "":
+		return "", false, errors.New("--password and --password-stdin are mutually exclusive")
+	case *p.fromStdin:
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", false, fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), false, nil
+	case *p.value != "":
+		return *p.value, false, nil
+	}
+
+	buf := make([]byte, generatedPasswordBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", false, fmt.Errorf("failed to generate password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), true, nil
+}
+
+// validateInput applies the register endpoint's rules to an accountInput so
+// that the CLI cannot create accounts the API would have rejected, or the
+// binding rules of a storeInput.
+func validateInput(in any) error {
+	if err := validation.Init(); err != nil {
+		return err
+	}
+	err := binding.Validator.ValidateStruct(in)
+	if err == nil {
+		return nil
+	}
+	fields, ok := validation.Translate(err, validation.LocaleEN)
+	if !ok {
+		return err
+	}
+	msgs := make([]string, 0, len(fields))
+	for _, f := range fields {
+		msgs = append(msgs, f.Message)
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+// shutdown closes the connections the container opened.
+func shutdown(container *app.Container) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := container.Lifecycle.Stop(ctx); err != nil {
+		slog.Error("Failed to release resources", logger.Err(err))
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+// runConfigCommand handles "config print [--redacted] [config flags...]", which prints the
+// effective configuration after file, MALL_* environment and flag overrides are applied.
+func runConfigCommand(args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return fmt.Errorf("usage: server config print [--redacted] [--env=<profile>] [--<section>.<key>=value ...]")
+	}
+
+	flags := config.NewFlagSet("config print")
+	redacted := flags.Bool("redacted", false, "mask passwords, secrets and credential-bearing URLs")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(config.LoadOptions{Flags: flags})
+	if err != nil {
+		return err
+	}
+	return cfg.Print(os.Stdout, *redacted)
+}
@@ -5,132 +5,62 @@ import (
 	"fmt"
 	"log"
 	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
-	"github.com/proyuen/go-mall/internal/handler"
-	"github.com/proyuen/go-mall/internal/repository"
-	"github.com/proyuen/go-mall/internal/router"
-	"github.com/proyuen/go-mall/internal/service"
-	"github.com/proyuen/go-mall/internal/worker"
-	"github.com/proyuen/go-mall/pkg/cache"
-	"github.com/proyuen/go-mall/pkg/config"
-	"github.com/proyuen/go-mall/pkg/database"
-	"github.com/proyuen/go-mall/pkg/hasher"
-	"github.com/proyuen/go-mall/pkg/mq"
-	"github.com/proyuen/go-mall/pkg/snowflake"
-	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/proyuen/go-mall/internal/app"
+	"github.com/proyuen/go-mall/pkg/logger"
 )
 
+// shutdownTimeout bounds draining requests and closing connections on SIGTERM.
+const shutdownTimeout = 15 * time.Second
+
+// @title						go-mall API
+// @version					1.0
+// @description				RESTful API for the go-mall e-commerce backend.
+// @BasePath					/api/v1
+// @securityDefinitions.apikey	BearerAuth
+// @in							header
+// @name						Authorization
+// @description				Type "Bearer" followed by a space and the access token.
 func main() {
-	// 1. Load Configuration
-	cfg, err := config.LoadConfig("./configs")
-	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
-	}
-
-	// 2. Initialize Snowflake ID Generator
-	// In a distributed deployment, this NodeID (1) must be unique per instance (e.g., from config or env).
-	if err := snowflake.Init(1); err != nil {
-		log.Fatalf("Failed to initialize snowflake: %v", err)
-	}
-
-	// 3. Initialize Database (Connect & Migrate)
-	db, err := database.NewPostgresDB(&cfg.Database)
-	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := runConfigCommand(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	// 4. Initialize Redis Cache and Lock
-	// Layer 1: Base Redis Client
-	redisClient, err := cache.NewRedisClient(&cfg.Redis)
+	// Configuration, logging, error reporting and ID generation.
+	// Message consumers and scheduled jobs run in cmd/worker.
+	base, err := app.NewBase("server", os.Args[1:])
 	if err != nil {
-		log.Fatalf("Failed to initialize redis client: %v", err)
+		log.Fatalf("Failed to start: %v", err)
 	}
 
-	baseCache := cache.NewRedisCache(redisClient, "mall")
-
-	// Layer 2: Observability (Tracing & Metrics)
-	instrumentedCache := cache.NewInstrumentedCache(baseCache)
-	// Layer 3: Resilience (Circuit Breaker & Retry)
-	appCache := cache.NewResilientCache(instrumentedCache)
-
-	// Usage Example: Distributed Lock for long-running task
-	go func() {
-		// Simulate a background task that needs a lock
-		lock := cache.NewRedisLock(redisClient, "background-task-lock")
-		ctx := context.Background()
-		ttl := 10 * time.Second
-
-		if acquired, err := lock.Lock(ctx, ttl); err == nil && acquired {
-			log.Println("Lock acquired for background task")
-			// Simulate long work (watchdog will renew lock)
-			time.Sleep(15 * time.Second)
-
-			if err := lock.Unlock(ctx); err != nil {
-				log.Printf("Failed to unlock: %v", err)
-			} else {
-				log.Println("Lock released")
-			}
-		} else {
-			log.Printf("Failed to acquire lock: %v", err)
-		}
-	}()
-
-	// 5. Initialize Repositories, Services, Handlers, and Router
-	txManager := database.NewTransactionManager(db)
-
-	// User Module
-	userRepo := repository.NewUserRepository(db)
-	// Initialize password hasher with default cost
-	passwordHasher := hasher.NewBcryptHasher(0)
-	// Initialize token maker
-
-	tokenMaker, err := token.NewJWTMaker(cfg.JWT.Secret)
+	container := app.New(base)
+	server, err := app.NewServer(container)
 	if err != nil {
-		log.Fatalf("Failed to create token maker: %v", err)
+		shutdown(container)
+		base.Fatal("Failed to initialize server", err)
 	}
-	userService := service.NewUserService(userRepo, passwordHasher, tokenMaker)
-	userHandler := handler.NewUserHandler(userService)
-
-	// Product Module
-	productRepo := repository.NewProductRepository(db)
-	productService := service.NewProductService(productRepo, appCache) // Inject resilient cache
-	productHandler := handler.NewProductHandler(productService)
-
-	// Order Module
-	orderRepo := repository.NewOrderRepository(db)
-	orderService := service.NewOrderService(orderRepo, productRepo, txManager)
-	orderHandler := handler.NewOrderHandler(orderService)
 
-	// Initialize Inventory Service
-	inventoryService := service.NewInventoryService(appCache, redisClient)
-
-	// Initialize RabbitMQ & Worker
-	logger := slog.Default()
-	if cfg.RabbitMQ.URL != "" {
-		mqClient, err := mq.NewRabbitMQ(cfg.RabbitMQ.URL, logger)
-		if err != nil {
-			log.Printf("Failed to connect to RabbitMQ: %v", err)
-		} else {
-			// In a real app, handle graceful shutdown
-			// defer mqClient.Close()
-
-			orderWorker := worker.NewOrderWorker(mqClient, inventoryService, orderService, appCache, logger)
-			go func() {
-				if err := orderWorker.Start(); err != nil {
-					log.Printf("OrderWorker failed: %v", err)
-				}
-			}()
-		}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := server.Run(ctx, shutdownTimeout); err != nil {
+		base.Fatal("Server stopped with error", err)
 	}
+	slog.Info("Server stopped")
+}
 
-	router := router.NewRouter(userHandler, productHandler, orderHandler, tokenMaker)
-	engine := router.InitRoutes()
-
-	// 6. Start Server
-	addr := fmt.Sprintf(":%s", cfg.Server.Port)
-	log.Printf("Server starting on %s in %s mode...\n", addr, cfg.Server.Mode)
-	if err := engine.Run(addr); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+// shutdown releases whatever the container opened before wiring failed.
+func shutdown(container *app.Container) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := container.Lifecycle.Stop(ctx); err != nil {
+		slog.Error("Failed to release resources", logger.Err(err))
 	}
 }
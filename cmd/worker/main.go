@@ -0,0 +1,51 @@
+// Command worker runs the message consumers and scheduled jobs, separately from
+// the API server so each can be scaled and deployed on its own.
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/app"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// shutdownTimeout bounds stopping consumers and closing connections on SIGTERM.
+const shutdownTimeout = 15 * time.Second
+
+func main() {
+	// Configuration, logging, error reporting and ID generation (same layering as cmd/server)
+	base, err := app.NewBase("worker", os.Args[1:])
+	if err != nil {
+		log.Fatalf("Failed to start: %v", err)
+	}
+
+	container := app.New(base)
+	worker, err := app.NewWorker(container)
+	if err != nil {
+		shutdown(container)
+		base.Fatal("Failed to initialize worker", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	slog.Info("Worker starting")
+	if err := worker.Run(ctx, shutdownTimeout); err != nil {
+		base.Fatal("Worker stopped with error", err)
+	}
+	slog.Info("Worker stopped")
+}
+
+// shutdown releases whatever the container opened before wiring failed.
+func shutdown(container *app.Container) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := container.Lifecycle.Stop(ctx); err != nil {
+		slog.Error("Failed to release resources", logger.Err(err))
+	}
+}
@@ -0,0 +1,86 @@
+// Package app is the composition root shared by cmd/server, cmd/worker and tests.
+// Container provides every dependency on first use; Lifecycle starts and stops them.
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/errreport"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/snowflake"
+	"github.com/proyuen/go-mall/pkg/tracing"
+	"github.com/spf13/pflag"
+)
+
+// Base is the process-level setup every entrypoint performs first.
+type Base struct {
+	Config   *config.Config
+	LoadOpts config.LoadOptions // Reused by config.NewWatcher so reloads layer the same files
+	Logger   *slog.Logger
+	Reporter errreport.Reporter
+	// StopTracing exports the spans not sent yet; nil when tracing was not
+	// set up.
+	StopTracing func(context.Context) error
+}
+
+// NewBase parses the config flags in args, loads the configuration
+// (config.yaml < config.{MALL_ENV}.yaml < MALL_* env vars < flags) and
+// initializes logging, error reporting, tracing and the ID generator.
+func NewBase(name string, args []string) (*Base, error) {
+	flags := config.NewFlagSet(name)
+	if err := flags.Parse(args); err != nil {
+		return nil, fmt.Errorf("failed to parse flags: %w", err)
+	}
+	return NewBaseFromFlags(flags)
+}
+
+// NewBaseFromFlags is NewBase for commands that add their own flags to a set
+// created by config.NewFlagSet and parse it themselves.
+func NewBaseFromFlags(flags *pflag.FlagSet) (*Base, error) {
+	loadOpts := config.LoadOptions{Flags: flags}
+	cfg, err := config.LoadConfig(loadOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Structured logging; also captures the standard "log" package from here on
+	appLogger, err := logger.Init(cfg.Log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	// Error reporting is a no-op unless sentry.enabled is set
+	reporter, err := errreport.New(cfg.Sentry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize error reporting: %w", err)
+	}
+
+	// Spans are exported as mall-<command> when tracing.enabled is set
+	stopTracing, err := tracing.Init(cfg.Tracing, "mall-"+flags.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+
+	// In a distributed deployment, this NodeID (1) must be unique per instance (e.g., from config or env).
+	if err := snowflake.Init(1); err != nil {
+		return nil, fmt.Errorf("failed to initialize snowflake: %w", err)
+	}
+
+	return &Base{Config: cfg, LoadOpts: loadOpts, Logger: appLogger, Reporter: reporter, StopTracing: stopTracing}, nil
+}
+
+// fatalFlushTimeout bounds how long Fatal waits for the error report to be sent.
+const fatalFlushTimeout = 2 * time.Second
+
+// Fatal logs and reports a failure that stops the process, then exits.
+func (b *Base) Fatal(msg string, err error) {
+	slog.Error(msg, logger.Err(err))
+	b.Reporter.CaptureError(context.Background(), fmt.Errorf("%s: %w", msg, err), nil)
+	b.Reporter.Flush(fatalFlushTimeout)
+	os.Exit(1)
+}
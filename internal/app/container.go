@@ -0,0 +1,1922 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/address"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/internal/service/payment"
+	"github.com/proyuen/go-mall/internal/service/tax"
+	"github.com/proyuen/go-mall/internal/worker"
+	"github.com/proyuen/go-mall/pkg/cache"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/database"
+	"github.com/proyuen/go-mall/pkg/exchangerate"
+	"github.com/proyuen/go-mall/pkg/hasher"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/mq"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// cacheKeyPrefix namespaces every key written through Container.Cache.
+const cacheKeyPrefix = "mall"
+
+// defaultEmailBrand names the shop in emails when notification.from has no display name.
+const defaultEmailBrand = "Go Mall"
+
+// Option replaces a provider, typically with a test double. Components passed in
+// are owned by the caller and are not closed by the Lifecycle.
+type Option func(*Container)
+
+// WithDB uses db instead of connecting to Postgres.
+func WithDB(db *gorm.DB) Option {
+	return func(c *Container) { c.db = db }
+}
+
+// WithRedisClient uses client instead of connecting to Redis.
+func WithRedisClient(client *redis.Client) Option {
+	return func(c *Container) { c.redisClient = client }
+}
+
+// WithMQ uses client instead of connecting to RabbitMQ.
+func WithMQ(client mq.RabbitMQ) Option {
+	return func(c *Container) { c.mqClient = client }
+}
+
+// Container builds each dependency the first time it is requested and returns the
+// same instance afterwards, so entrypoints only pay for what they use.
+//
+// Getters never return errors: the first provider failure is kept, every later
+// getter returns a zero value, and callers check Err once after resolving what they
+// need and before using any of it — the same pattern as bufio.Scanner.
+type Container struct {
+	Base      *Base
+	Lifecycle *Lifecycle
+
+	err error
+
+	db            *gorm.DB
+	redisClient   *redis.Client
+	cache         cache.Cache
+	txManager     database.TransactionManager
+	mqClient      mq.RabbitMQ
+	configWatcher *config.Watcher
+	tokenMaker    token.Maker
+	tokenRevoker  token.Revoker
+
+	userRepo          repository.UserRepository
+	categoryRepo      repository.CategoryRepository
+	productRepo       repository.ProductRepository
+	orderRepo         repository.OrderRepository
+	auditLogRepo      repository.AuditLogRepository
+	notificationRepo  repository.NotificationRepository
+	webhookRepo       repository.WebhookRepository
+	reviewRepo        repository.ReviewRepository
+	exchangeRateRepo  repository.ExchangeRateRepository
+	translationRepo   repository.TranslationRepository
+	priceTierRepo     repository.PriceTierRepository
+	priceScheduleRepo repository.PriceScheduleRepository
+	storeRepo         repository.StoreRepository
+	paymentMethodRepo repository.PaymentMethodRepository
+	shipmentRepo      repository.ShipmentRepository
+	pickTaskRepo      repository.PickTaskRepository
+	storeCreditRepo   repository.StoreCreditRepository
+	disputeRepo       repository.DisputeRepository
+	cartRepo          repository.CartRepository
+	promotionRepo     repository.PromotionRepository
+	couponRepo        repository.CouponRepository
+	subscriptionRepo  repository.SubscriptionRepository
+	quoteRepo         repository.QuoteRepository
+	supplierRepo      repository.SupplierRepository
+	purchaseOrderRepo repository.PurchaseOrderRepository
+	stockMovementRepo repository.StockMovementRepository
+	stockHoldRepo     repository.StockHoldRepository
+	licenseKeyRepo    repository.LicenseKeyRepository
+	synonymRepo       repository.SynonymRepository
+	searchRepo        repository.SearchRepository
+	broadcastRepo     repository.BroadcastRepository
+	outboxRepo        repository.OutboxRepository
+	failedMessageRepo repository.FailedMessageRepository
+	orderSummaryRepo  repository.OrderSummaryRepository
+	sagaRepo          repository.SagaRepository
+	modificationRepo  repository.OrderModificationRepository
+	restrictionRepo   repository.ShippingRestrictionRepository
+
+	userService          service.UserService
+	accountService       service.AccountService
+	passwordResets       service.PasswordResetService
+	productService       service.ProductService
+	catalogService       service.CatalogService
+	catalogInvalidator   *service.CatalogInvalidator
+	catalogCache         *service.CatalogCache
+	popularityService    service.PopularityService
+	suggestionService    service.SuggestionService
+	synonymService       service.SynonymService
+	merchandisingService service.MerchandisingService
+	reviewService        service.ReviewService
+	inventorySyncService service.InventorySyncService
+	dashboardService     service.DashboardService
+	logLevelService      service.LogLevelService
+	diagnosticsService   service.DiagnosticsService
+	sessionStore         service.SessionStore
+	orderService         service.OrderService
+	inventoryService     *service.InventoryService
+	stockReconciler      service.StockReconciler
+	auditService         service.AuditService
+	ipFilterService      service.IPFilterService
+	abuseDetector        service.AbuseDetector
+	webhookService       service.WebhookService
+	currencyService      service.CurrencyService
+	taxService           service.TaxService
+	translationService   service.TranslationService
+	customerGroupService service.CustomerGroupService
+	priceScheduleService service.PriceScheduleService
+	storeService         service.StoreService
+	paymentMethodService service.PaymentMethodService
+	paymentService       service.PaymentService
+	fulfillmentService   service.FulfillmentService
+	pickingService       service.PickingService
+	supportService       service.SupportService
+	addressService       service.AddressService
+	restrictionService   service.ShippingRestrictionService
+	disputeService       service.DisputeService
+	disputeReminder      service.DisputeReminder
+	cartService          service.CartService
+	cartRecovery         service.CartRecovery
+	promotionService     service.PromotionService
+	couponService        service.CouponService
+	subscriptionService  service.SubscriptionService
+	quoteService         service.QuoteService
+	purchasingService    service.PurchasingService
+	stockHoldService     service.StockHoldService
+	modificationService  service.OrderModificationService
+	licenseKeyService    service.LicenseKeyService
+	digitalService       service.DigitalFulfillmentService
+
+	emailProvider        notification.EmailProvider
+	smsProvider          notification.SMSProvider
+	pushProviders        map[notification.Platform]notification.PushProvider
+	notificationRenderer *notification.Renderer
+	notificationService  notification.Service
+	notifier             notification.Notifier
+	templateService      service.NotificationTemplateService
+	broadcastService     service.BroadcastService
+	broadcastDispatcher  service.BroadcastDispatcher
+	failedMessages       service.FailedMessageService
+	failedMessageReplay  service.FailedMessageReplayer
+	cacheJanitor         service.CacheJanitor
+	mqHealthReporter     service.MQHealthReporter
+	eventPublisher       service.EventPublisher
+	eventRelay           service.EventRelay
+	orderHistoryService  service.OrderHistoryService
+
+	paymentProviders map[string]payment.Provider
+}
+
+// New creates a Container for base.
+func New(base *Base, opts ...Option) *Container {
+	c := &Container{Base: base, Lifecycle: NewLifecycle()}
+	if base.StopTracing != nil {
+		// Stops last, exporting the spans of everything stopped before it
+		c.Lifecycle.Append(Hook{Name: "tracing", OnStop: base.StopTracing})
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Err returns the first provider error, if any.
+func (c *Container) Err() error {
+	return c.err
+}
+
+// provide runs build unless an earlier provider already failed.
+func (c *Container) provide(name string, build func() error) {
+	if c.err != nil {
+		return
+	}
+	if err := build(); err != nil {
+		c.err = fmt.Errorf("failed to provide %s: %w", name, err)
+	}
+}
+
+// Infrastructure
+
+func (c *Container) DB() *gorm.DB {
+	if c.db == nil {
+		c.provide("database", func() error {
+			db, err := database.NewPostgresDB(&c.Base.Config.Database)
+			if err != nil {
+				return err
+			}
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			c.Lifecycle.Append(Hook{Name: "database", OnStop: func(context.Context) error { return sqlDB.Close() }})
+			c.db = db
+			return nil
+		})
+	}
+	return c.db
+}
+
+func (c *Container) RedisClient() *redis.Client {
+	if c.redisClient == nil {
+		c.provide("redis", func() error {
+			client, err := cache.NewRedisClient(&c.Base.Config.Redis)
+			if err != nil {
+				return err
+			}
+			c.Lifecycle.Append(Hook{Name: "redis", OnStop: func(context.Context) error { return client.Close() }})
+			c.redisClient = client
+			return nil
+		})
+	}
+	return c.redisClient
+}
+
+// Cache returns the application cache: Redis, instrumented, behind a circuit breaker.
+func (c *Container) Cache() cache.Cache {
+	if c.cache == nil {
+		redisClient := c.RedisClient()
+		c.provide("cache", func() error {
+			// Layer 1: Base Redis cache
+			baseCache := cache.NewRedisCache(redisClient, cacheKeyPrefix)
+			// Layer 2: Observability (Tracing & Metrics)
+			instrumentedCache := cache.NewInstrumentedCache(baseCache)
+			// Layer 3: Resilience (Circuit Breaker & Retry)
+			c.cache = cache.NewResilientCache(instrumentedCache)
+			return nil
+		})
+	}
+	return c.cache
+}
+
+func (c *Container) TxManager() database.TransactionManager {
+	if c.txManager == nil {
+		db := c.DB()
+		c.provide("transaction manager", func() error {
+			c.txManager = database.NewTransactionManager(db)
+			return nil
+		})
+	}
+	return c.txManager
+}
+
+// MQ connects to RabbitMQ; rabbitmq.url must be set.
+func (c *Container) MQ() mq.RabbitMQ {
+	if c.mqClient == nil {
+		c.provide("rabbitmq", func() error {
+			if c.Base.Config.RabbitMQ.URL == "" {
+				return fmt.Errorf("rabbitmq.url is not set")
+			}
+			client, err := mq.NewRabbitMQ(c.Base.Config.RabbitMQ.URL, c.Base.Logger)
+			if err != nil {
+				return err
+			}
+			c.Lifecycle.Append(Hook{Name: "rabbitmq", OnStop: func(context.Context) error { return client.Close() }})
+			c.mqClient = client
+			return nil
+		})
+	}
+	return c.mqClient
+}
+
+// ConfigWatcher returns the live configuration; it starts watching with the Lifecycle.
+// A watcher that cannot start only disables hot reload. The log level is applied on
+// every reload since logging is process-wide. SIGHUP reloads the config too, and
+// applies log.level even if unchanged, undoing a level set by LogLevelService.
+func (c *Container) ConfigWatcher() *config.Watcher {
+	if c.configWatcher == nil {
+		c.provide("config watcher", func() error {
+			w := config.NewWatcher(c.Base.Config, c.Base.LoadOpts)
+			w.Subscribe(func(e config.ChangeEvent) {
+				if e.Has(config.SectionLog) {
+					applyLogLevel(e.New.Log.Level)
+				}
+			})
+			hangups := make(chan os.Signal, 1)
+			c.Lifecycle.Append(Hook{
+				Name: "config watcher",
+				OnStart: func(context.Context) error {
+					if err := w.Start(); err != nil {
+						slog.Warn("Config hot reload disabled", logger.Err(err))
+					}
+					signal.Notify(hangups, syscall.SIGHUP)
+					go func() {
+						for range hangups {
+							applyLogLevel(w.Reload("SIGHUP").Log.Level)
+						}
+					}()
+					return nil
+				},
+				OnStop: func(context.Context) error {
+					signal.Stop(hangups)
+					close(hangups)
+					return w.Close()
+				},
+			})
+			c.configWatcher = w
+			return nil
+		})
+	}
+	return c.configWatcher
+}
+
+// applyLogLevel sets the level of the process's logs from the config.
+func applyLogLevel(level string) {
+	if err := logger.SetLevel(level); err != nil {
+		slog.Error("Failed to apply log level", logger.Err(err))
+	}
+}
+
+// LogLevelService changes the log level of this process from the admin API.
+func (c *Container) LogLevelService() service.LogLevelService {
+	if c.logLevelService == nil {
+		c.logLevelService = service.NewLogLevelService()
+	}
+	return c.logLevelService
+}
+
+// DiagnosticsService reports the health of Redis and, through the reports of
+// the workers, RabbitMQ to admins.
+func (c *Container) DiagnosticsService() service.DiagnosticsService {
+	if c.diagnosticsService == nil {
+		appCache := c.Cache()
+		c.provide("diagnostics service", func() error {
+			cfg := c.Base.Config.Security.Diagnostics
+			c.diagnosticsService = service.NewDiagnosticsService(appCache, service.DiagnosticsOptions{
+				RateLimit: cfg.RateLimit,
+				Window:    cfg.Window,
+				// The scheduler takes its lock with the raw client, outside the cache prefix.
+				LockPatterns: []string{"lock:*", strings.TrimPrefix(worker.LeaderLockKey, cacheKeyPrefix+":")},
+			})
+			return nil
+		})
+	}
+	return c.diagnosticsService
+}
+
+func (c *Container) SessionStore() service.SessionStore {
+	if c.sessionStore == nil {
+		appCache := c.Cache()
+		c.provide("session store", func() error {
+			cfg := c.Base.Config.Security.Sessions
+			c.sessionStore = service.NewSessionStore(appCache, service.SessionOptions{
+				MaxPerUser: cfg.MaxPerUser,
+				OnLimit:    cfg.OnLimit,
+			})
+			return nil
+		})
+	}
+	return c.sessionStore
+}
+
+// TokenMaker signs with jwt.key_id when it is set, verifying with jwt.keys
+// too, and follows changes to them on reload.
+func (c *Container) TokenMaker() token.Maker {
+	if c.tokenMaker == nil {
+		watcher := c.ConfigWatcher()
+		c.provide("token maker", func() error {
+			cfg := c.Base.Config.JWT
+			var maker token.Maker
+			var err error
+			switch {
+			case c.Base.Config.Token.Type == "paseto":
+				maker, err = token.NewPasetoMaker(cfg.Secret)
+			case cfg.KeyID != "", len(cfg.Keys) > 0:
+				maker, err = newJWTKeyringMaker(cfg, watcher)
+			case cfg.Algorithm == token.AlgRS256, cfg.Algorithm == token.AlgEdDSA:
+				maker, err = token.NewJWTMakerFromPEMFiles(cfg.Algorithm, cfg.PrivateKeyFile, cfg.PublicKeyFile)
+			default:
+				maker, err = token.NewJWTMaker(cfg.Secret)
+			}
+			if err != nil {
+				return err
+			}
+			c.tokenMaker = maker
+			return nil
+		})
+	}
+	return c.tokenMaker
+}
+
+// newJWTKeyringMaker creates a JWTMaker with the keys of cfg, applying them
+// again whenever watcher reloads the jwt section.
+func newJWTKeyringMaker(cfg config.JWTConfig, watcher *config.Watcher) (*token.JWTMaker, error) {
+	current, previous, err := loadJWTKeys(cfg)
+	if err != nil {
+		return nil, err
+	}
+	previous[cfg.KeyID] = current
+	maker, err := token.NewJWTKeyringMaker(cfg.KeyID, previous)
+	if err != nil {
+		return nil, err
+	}
+	watcher.Subscribe(func(e config.ChangeEvent) {
+		if !e.Has(config.SectionJWT) {
+			return
+		}
+		if err := applyJWTKeys(maker, e.New.JWT); err != nil {
+			slog.Error("Failed to apply reloaded JWT keys", logger.Err(err))
+		}
+	})
+	return maker, nil
+}
+
+// loadJWTKeys returns the key named jwt.key_id, from jwt.secret or
+// jwt.private_key_file, and the previous keys of jwt.keys by ID.
+func loadJWTKeys(cfg config.JWTConfig) (*token.JWTKey, map[string]*token.JWTKey, error) {
+	if cfg.KeyID == "" {
+		return nil, nil, fmt.Errorf("jwt.key_id is required with jwt.keys")
+	}
+	var current *token.JWTKey
+	var err error
+	if cfg.Algorithm == token.AlgRS256 || cfg.Algorithm == token.AlgEdDSA {
+		current, err = token.LoadPEMKey(cfg.Algorithm, cfg.PrivateKeyFile, cfg.PublicKeyFile)
+	} else {
+		current, err = token.NewHMACKey(cfg.Secret)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("jwt key %s: %w", cfg.KeyID, err)
+	}
+
+	previous := make(map[string]*token.JWTKey, len(cfg.Keys)+1)
+	for _, k := range cfg.Keys {
+		if k.ID == cfg.KeyID {
+			return nil, nil, fmt.Errorf("jwt.keys: %s is jwt.key_id", k.ID)
+		}
+		var key *token.JWTKey
+		if k.Algorithm == token.AlgRS256 || k.Algorithm == token.AlgEdDSA {
+			key, err = token.LoadPEMKey(k.Algorithm, "", k.PublicKeyFile)
+		} else {
+			key, err = token.NewHMACKey(k.Secret)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("jwt key %s: %w", k.ID, err)
+		}
+		previous[k.ID] = key
+	}
+	return current, previous, nil
+}
+
+// applyJWTKeys makes the keyring of maker that of cfg. The key signing new
+// tokens changes first, so that the key it replaces can become one that
+// only verifies.
+func applyJWTKeys(maker *token.JWTMaker, cfg config.JWTConfig) error {
+	current, previous, err := loadJWTKeys(cfg)
+	if err != nil {
+		return err
+	}
+	if err := maker.AddKey(cfg.KeyID, current); err != nil {
+		return err
+	}
+	if err := maker.UseKey(cfg.KeyID); err != nil {
+		return err
+	}
+	for id, key := range previous {
+		if err := maker.AddKey(id, key); err != nil {
+			return err
+		}
+	}
+	for _, id := range maker.KeyIDs() {
+		if _, ok := previous[id]; !ok && id != cfg.KeyID {
+			if err := maker.RemoveKey(id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Container) TokenRevoker() token.Revoker {
+	if c.tokenRevoker == nil {
+		appCache := c.Cache()
+		c.provide("token revoker", func() error {
+			c.tokenRevoker = token.NewRedisRevoker(appCache)
+			return nil
+		})
+	}
+	return c.tokenRevoker
+}
+
+// Repositories
+
+func (c *Container) UserRepo() repository.UserRepository {
+	if c.userRepo == nil {
+		db := c.DB()
+		c.provide("user repository", func() error {
+			c.userRepo = repository.NewUserRepository(db)
+			return nil
+		})
+	}
+	return c.userRepo
+}
+
+func (c *Container) CategoryRepo() repository.CategoryRepository {
+	if c.categoryRepo == nil {
+		db := c.DB()
+		c.provide("category repository", func() error {
+			c.categoryRepo = repository.NewCategoryRepository(db)
+			return nil
+		})
+	}
+	return c.categoryRepo
+}
+
+func (c *Container) ProductRepo() repository.ProductRepository {
+	if c.productRepo == nil {
+		db := c.DB()
+		c.provide("product repository", func() error {
+			c.productRepo = repository.NewProductRepository(db)
+			return nil
+		})
+	}
+	return c.productRepo
+}
+
+func (c *Container) OrderRepo() repository.OrderRepository {
+	if c.orderRepo == nil {
+		db := c.DB()
+		c.provide("order repository", func() error {
+			c.orderRepo = repository.NewOrderRepository(db)
+			return nil
+		})
+	}
+	return c.orderRepo
+}
+
+func (c *Container) AuditLogRepo() repository.AuditLogRepository {
+	if c.auditLogRepo == nil {
+		db := c.DB()
+		c.provide("audit log repository", func() error {
+			c.auditLogRepo = repository.NewAuditLogRepository(db)
+			return nil
+		})
+	}
+	return c.auditLogRepo
+}
+
+func (c *Container) NotificationRepo() repository.NotificationRepository {
+	if c.notificationRepo == nil {
+		db := c.DB()
+		c.provide("notification repository", func() error {
+			c.notificationRepo = repository.NewNotificationRepository(db)
+			return nil
+		})
+	}
+	return c.notificationRepo
+}
+
+func (c *Container) WebhookRepo() repository.WebhookRepository {
+	if c.webhookRepo == nil {
+		db := c.DB()
+		c.provide("webhook repository", func() error {
+			c.webhookRepo = repository.NewWebhookRepository(db)
+			return nil
+		})
+	}
+	return c.webhookRepo
+}
+
+func (c *Container) ReviewRepo() repository.ReviewRepository {
+	if c.reviewRepo == nil {
+		db := c.DB()
+		c.provide("review repository", func() error {
+			c.reviewRepo = repository.NewReviewRepository(db)
+			return nil
+		})
+	}
+	return c.reviewRepo
+}
+
+func (c *Container) ExchangeRateRepo() repository.ExchangeRateRepository {
+	if c.exchangeRateRepo == nil {
+		db := c.DB()
+		c.provide("exchange rate repository", func() error {
+			c.exchangeRateRepo = repository.NewExchangeRateRepository(db)
+			return nil
+		})
+	}
+	return c.exchangeRateRepo
+}
+
+func (c *Container) TranslationRepo() repository.TranslationRepository {
+	if c.translationRepo == nil {
+		db := c.DB()
+		c.provide("translation repository", func() error {
+			c.translationRepo = repository.NewTranslationRepository(db)
+			return nil
+		})
+	}
+	return c.translationRepo
+}
+
+func (c *Container) PriceTierRepo() repository.PriceTierRepository {
+	if c.priceTierRepo == nil {
+		db := c.DB()
+		c.provide("price tier repository", func() error {
+			c.priceTierRepo = repository.NewPriceTierRepository(db)
+			return nil
+		})
+	}
+	return c.priceTierRepo
+}
+
+func (c *Container) PriceScheduleRepo() repository.PriceScheduleRepository {
+	if c.priceScheduleRepo == nil {
+		db := c.DB()
+		c.provide("price schedule repository", func() error {
+			c.priceScheduleRepo = repository.NewPriceScheduleRepository(db)
+			return nil
+		})
+	}
+	return c.priceScheduleRepo
+}
+
+func (c *Container) StoreRepo() repository.StoreRepository {
+	if c.storeRepo == nil {
+		db := c.DB()
+		c.provide("store repository", func() error {
+			c.storeRepo = repository.NewStoreRepository(db)
+			return nil
+		})
+	}
+	return c.storeRepo
+}
+
+func (c *Container) PaymentMethodRepo() repository.PaymentMethodRepository {
+	if c.paymentMethodRepo == nil {
+		db := c.DB()
+		c.provide("payment method repository", func() error {
+			c.paymentMethodRepo = repository.NewPaymentMethodRepository(db)
+			return nil
+		})
+	}
+	return c.paymentMethodRepo
+}
+
+func (c *Container) ShipmentRepo() repository.ShipmentRepository {
+	if c.shipmentRepo == nil {
+		db := c.DB()
+		c.provide("shipment repository", func() error {
+			c.shipmentRepo = repository.NewShipmentRepository(db)
+			return nil
+		})
+	}
+	return c.shipmentRepo
+}
+
+func (c *Container) PickTaskRepo() repository.PickTaskRepository {
+	if c.pickTaskRepo == nil {
+		db := c.DB()
+		c.provide("pick task repository", func() error {
+			c.pickTaskRepo = repository.NewPickTaskRepository(db)
+			return nil
+		})
+	}
+	return c.pickTaskRepo
+}
+
+func (c *Container) StoreCreditRepo() repository.StoreCreditRepository {
+	if c.storeCreditRepo == nil {
+		db := c.DB()
+		c.provide("store credit repository", func() error {
+			c.storeCreditRepo = repository.NewStoreCreditRepository(db)
+			return nil
+		})
+	}
+	return c.storeCreditRepo
+}
+
+func (c *Container) DisputeRepo() repository.DisputeRepository {
+	if c.disputeRepo == nil {
+		db := c.DB()
+		c.provide("dispute repository", func() error {
+			c.disputeRepo = repository.NewDisputeRepository(db)
+			return nil
+		})
+	}
+	return c.disputeRepo
+}
+
+func (c *Container) CartRepo() repository.CartRepository {
+	if c.cartRepo == nil {
+		db := c.DB()
+		c.provide("cart repository", func() error {
+			c.cartRepo = repository.NewCartRepository(db)
+			return nil
+		})
+	}
+	return c.cartRepo
+}
+
+func (c *Container) PromotionRepo() repository.PromotionRepository {
+	if c.promotionRepo == nil {
+		db := c.DB()
+		c.provide("promotion repository", func() error {
+			c.promotionRepo = repository.NewPromotionRepository(db)
+			return nil
+		})
+	}
+	return c.promotionRepo
+}
+
+func (c *Container) CouponRepo() repository.CouponRepository {
+	if c.couponRepo == nil {
+		db := c.DB()
+		c.provide("coupon repository", func() error {
+			c.couponRepo = repository.NewCouponRepository(db)
+			return nil
+		})
+	}
+	return c.couponRepo
+}
+
+func (c *Container) SubscriptionRepo() repository.SubscriptionRepository {
+	if c.subscriptionRepo == nil {
+		db := c.DB()
+		c.provide("subscription repository", func() error {
+			c.subscriptionRepo = repository.NewSubscriptionRepository(db)
+			return nil
+		})
+	}
+	return c.subscriptionRepo
+}
+
+func (c *Container) QuoteRepo() repository.QuoteRepository {
+	if c.quoteRepo == nil {
+		db := c.DB()
+		c.provide("quote repository", func() error {
+			c.quoteRepo = repository.NewQuoteRepository(db)
+			return nil
+		})
+	}
+	return c.quoteRepo
+}
+
+func (c *Container) SupplierRepo() repository.SupplierRepository {
+	if c.supplierRepo == nil {
+		db := c.DB()
+		c.provide("supplier repository", func() error {
+			c.supplierRepo = repository.NewSupplierRepository(db)
+			return nil
+		})
+	}
+	return c.supplierRepo
+}
+
+func (c *Container) PurchaseOrderRepo() repository.PurchaseOrderRepository {
+	if c.purchaseOrderRepo == nil {
+		db := c.DB()
+		c.provide("purchase order repository", func() error {
+			c.purchaseOrderRepo = repository.NewPurchaseOrderRepository(db)
+			return nil
+		})
+	}
+	return c.purchaseOrderRepo
+}
+
+func (c *Container) StockMovementRepo() repository.StockMovementRepository {
+	if c.stockMovementRepo == nil {
+		db := c.DB()
+		c.provide("stock movement repository", func() error {
+			c.stockMovementRepo = repository.NewStockMovementRepository(db)
+			return nil
+		})
+	}
+	return c.stockMovementRepo
+}
+
+func (c *Container) StockHoldRepo() repository.StockHoldRepository {
+	if c.stockHoldRepo == nil {
+		db := c.DB()
+		c.provide("stock hold repository", func() error {
+			c.stockHoldRepo = repository.NewStockHoldRepository(db)
+			return nil
+		})
+	}
+	return c.stockHoldRepo
+}
+
+func (c *Container) LicenseKeyRepo() repository.LicenseKeyRepository {
+	if c.licenseKeyRepo == nil {
+		db := c.DB()
+		c.provide("license key repository", func() error {
+			c.licenseKeyRepo = repository.NewLicenseKeyRepository(db)
+			return nil
+		})
+	}
+	return c.licenseKeyRepo
+}
+
+func (c *Container) SynonymRepo() repository.SynonymRepository {
+	if c.synonymRepo == nil {
+		db := c.DB()
+		c.provide("synonym repository", func() error {
+			c.synonymRepo = repository.NewSynonymRepository(db)
+			return nil
+		})
+	}
+	return c.synonymRepo
+}
+
+func (c *Container) SearchRepo() repository.SearchRepository {
+	if c.searchRepo == nil {
+		db := c.DB()
+		c.provide("search repository", func() error {
+			c.searchRepo = repository.NewSearchRepository(db)
+			return nil
+		})
+	}
+	return c.searchRepo
+}
+
+func (c *Container) BroadcastRepo() repository.BroadcastRepository {
+	if c.broadcastRepo == nil {
+		db := c.DB()
+		c.provide("broadcast repository", func() error {
+			c.broadcastRepo = repository.NewBroadcastRepository(db)
+			return nil
+		})
+	}
+	return c.broadcastRepo
+}
+
+func (c *Container) OutboxRepo() repository.OutboxRepository {
+	if c.outboxRepo == nil {
+		db := c.DB()
+		c.provide("outbox repository", func() error {
+			c.outboxRepo = repository.NewOutboxRepository(db)
+			return nil
+		})
+	}
+	return c.outboxRepo
+}
+
+func (c *Container) FailedMessageRepo() repository.FailedMessageRepository {
+	if c.failedMessageRepo == nil {
+		db := c.DB()
+		c.provide("failed message repository", func() error {
+			c.failedMessageRepo = repository.NewFailedMessageRepository(db)
+			return nil
+		})
+	}
+	return c.failedMessageRepo
+}
+
+func (c *Container) OrderSummaryRepo() repository.OrderSummaryRepository {
+	if c.orderSummaryRepo == nil {
+		db := c.DB()
+		c.provide("order summary repository", func() error {
+			c.orderSummaryRepo = repository.NewOrderSummaryRepository(db)
+			return nil
+		})
+	}
+	return c.orderSummaryRepo
+}
+
+func (c *Container) SagaRepo() repository.SagaRepository {
+	if c.sagaRepo == nil {
+		db := c.DB()
+		c.provide("saga repository", func() error {
+			c.sagaRepo = repository.NewSagaRepository(db)
+			return nil
+		})
+	}
+	return c.sagaRepo
+}
+
+func (c *Container) OrderModificationRepo() repository.OrderModificationRepository {
+	if c.modificationRepo == nil {
+		db := c.DB()
+		c.provide("order modification repository", func() error {
+			c.modificationRepo = repository.NewOrderModificationRepository(db)
+			return nil
+		})
+	}
+	return c.modificationRepo
+}
+
+func (c *Container) ShippingRestrictionRepo() repository.ShippingRestrictionRepository {
+	if c.restrictionRepo == nil {
+		db := c.DB()
+		c.provide("shipping restriction repository", func() error {
+			c.restrictionRepo = repository.NewShippingRestrictionRepository(db)
+			return nil
+		})
+	}
+	return c.restrictionRepo
+}
+
+// Services
+
+// PasswordResetService is nil, and password resets are disabled, without
+// password_reset.url for the emailed links to open.
+func (c *Container) PasswordResetService() service.PasswordResetService {
+	if c.passwordResets == nil {
+		cfg := c.Base.Config.PasswordReset
+		if cfg.URL == "" {
+			return nil
+		}
+		userRepo, appCache, sessions, notifier := c.UserRepo(), c.Cache(), c.SessionStore(), c.Notifier()
+		c.provide("password reset service", func() error {
+			c.passwordResets = service.NewPasswordResetService(userRepo, hasher.NewBcryptHasher(0), appCache, sessions, notifier, service.PasswordResetOptions{
+				URL:         cfg.URL,
+				TokenTTL:    cfg.TokenTTL,
+				Limit:       cfg.Limit,
+				LimitWindow: cfg.LimitWindow,
+			})
+			return nil
+		})
+	}
+	return c.passwordResets
+}
+
+func (c *Container) UserService() service.UserService {
+	if c.userService == nil {
+		userRepo, txManager, tokenMaker, events, sessions := c.UserRepo(), c.TxManager(), c.TokenMaker(), c.EventPublisher(), c.SessionStore()
+		revoker := c.TokenRevoker()
+		c.provide("user service", func() error {
+			// Initialize password hasher with default cost
+			cfg := c.Base.Config.JWT
+			c.userService = service.NewUserService(userRepo, txManager, hasher.NewBcryptHasher(0), tokenMaker, events, sessions, revoker, service.UserServiceOptions{
+				AccessTokenTTL:  cfg.AccessTokenTTL,
+				RefreshTokenTTL: cfg.RefreshTokenTTL,
+				RememberMeTTL:   cfg.RememberMeTTL,
+			})
+			return nil
+		})
+	}
+	return c.userService
+}
+
+func (c *Container) AccountService() service.AccountService {
+	if c.accountService == nil {
+		userRepo, tokenMaker := c.UserRepo(), c.TokenMaker()
+		c.provide("account service", func() error {
+			c.accountService = service.NewAccountService(userRepo, hasher.NewBcryptHasher(0), tokenMaker)
+			return nil
+		})
+	}
+	return c.accountService
+}
+
+// CurrencyService fetches rates only when currency.rates_url is set; in
+// processes that just convert, the provider is never called.
+func (c *Container) CurrencyService() service.CurrencyService {
+	if c.currencyService == nil {
+		rateRepo, userRepo := c.ExchangeRateRepo(), c.UserRepo()
+		c.provide("currency service", func() error {
+			cfg := c.Base.Config.Currency
+			var provider exchangerate.Provider
+			if cfg.RatesURL != "" {
+				provider = exchangerate.NewHTTPProvider(cfg.RatesURL)
+			}
+			c.currencyService = service.NewCurrencyService(rateRepo, userRepo, provider, service.CurrencyOptions{
+				Base:      cfg.Base,
+				Supported: cfg.Supported,
+				MaxAge:    cfg.RatesMaxAge,
+			})
+			return nil
+		})
+	}
+	return c.currencyService
+}
+
+// TaxService charges tax with tax.strategy; the default charges none.
+func (c *Container) TaxService() service.TaxService {
+	if c.taxService == nil {
+		c.provide("tax service", func() error {
+			cfg := c.Base.Config.Tax
+			var strategy tax.Strategy
+			switch cfg.Strategy {
+			case tax.StrategyFlat:
+				strategy = tax.NewFlat(cfg.Flat.Name, decimal.NewFromFloat(cfg.Flat.Rate))
+			case tax.StrategyRegion:
+				rules := make([]tax.Rule, 0, len(cfg.Regions))
+				for _, spec := range cfg.Regions {
+					rule, err := tax.ParseRule(spec)
+					if err != nil {
+						return err
+					}
+					rules = append(rules, rule)
+				}
+				strategy = tax.NewRegionTable(rules)
+			case tax.StrategyProvider:
+				provider, err := tax.NewHTTPProvider(cfg.Provider)
+				if err != nil {
+					return err
+				}
+				strategy = provider
+			}
+			c.taxService = service.NewTaxService(strategy)
+			return nil
+		})
+	}
+	return c.taxService
+}
+
+// AddressService checks shipping addresses against the format of their
+// country, and looks them up with address.geocoder when its url is set.
+func (c *Container) AddressService() service.AddressService {
+	if c.addressService == nil {
+		c.provide("address service", func() error {
+			var geocoder address.Geocoder
+			if cfg := c.Base.Config.Address.Geocoder; cfg.URL != "" {
+				httpGeocoder, err := address.NewHTTPGeocoder(cfg)
+				if err != nil {
+					return err
+				}
+				geocoder = httpGeocoder
+			}
+			c.addressService = service.NewAddressService(geocoder)
+			return nil
+		})
+	}
+	return c.addressService
+}
+
+func (c *Container) ShippingRestrictionService() service.ShippingRestrictionService {
+	if c.restrictionService == nil {
+		restrictionRepo, productRepo, categoryRepo := c.ShippingRestrictionRepo(), c.ProductRepo(), c.CategoryRepo()
+		c.provide("shipping restriction service", func() error {
+			c.restrictionService = service.NewShippingRestrictionService(restrictionRepo, productRepo, categoryRepo)
+			return nil
+		})
+	}
+	return c.restrictionService
+}
+
+func (c *Container) TranslationService() service.TranslationService {
+	if c.translationService == nil {
+		translationRepo, productRepo := c.TranslationRepo(), c.ProductRepo()
+		c.provide("translation service", func() error {
+			cfg := c.Base.Config.I18n
+			c.translationService = service.NewTranslationService(translationRepo, productRepo, service.TranslationOptions{
+				DefaultLocale: cfg.DefaultLocale,
+				Locales:       cfg.Locales,
+			})
+			return nil
+		})
+	}
+	return c.translationService
+}
+
+func (c *Container) CustomerGroupService() service.CustomerGroupService {
+	if c.customerGroupService == nil {
+		priceTierRepo, userRepo, productRepo := c.PriceTierRepo(), c.UserRepo(), c.ProductRepo()
+		c.provide("customer group service", func() error {
+			c.customerGroupService = service.NewCustomerGroupService(priceTierRepo, userRepo, productRepo)
+			return nil
+		})
+	}
+	return c.customerGroupService
+}
+
+func (c *Container) PriceScheduleService() service.PriceScheduleService {
+	if c.priceScheduleService == nil {
+		scheduleRepo, productRepo, txManager, catalog, events := c.PriceScheduleRepo(), c.ProductRepo(), c.TxManager(), c.CatalogCache(), c.EventPublisher()
+		c.provide("price schedule service", func() error {
+			c.priceScheduleService = service.NewPriceScheduleService(scheduleRepo, productRepo, txManager, catalog, events)
+			return nil
+		})
+	}
+	return c.priceScheduleService
+}
+
+// StoreService is built whether or not tenancy is enabled, so stores can be
+// created before it is; only the server checks tenancy.enabled.
+func (c *Container) StoreService() service.StoreService {
+	if c.storeService == nil {
+		storeRepo := c.StoreRepo()
+		c.provide("store service", func() error {
+			c.storeService = service.NewStoreService(storeRepo, c.Base.Config.Tenancy.DefaultStore)
+			return nil
+		})
+	}
+	return c.storeService
+}
+
+func (c *Container) ProductService() service.ProductService {
+	if c.productService == nil {
+		productRepo, categoryRepo, catalog, currencies, txManager := c.ProductRepo(), c.CategoryRepo(), c.CatalogCache(), c.CurrencyService(), c.TxManager()
+		events := c.EventPublisher()
+		c.provide("product service", func() error {
+			c.productService = service.NewProductService(productRepo, categoryRepo, catalog, currencies, txManager, events)
+			return nil
+		})
+	}
+	return c.productService
+}
+
+func (c *Container) CatalogService() service.CatalogService {
+	if c.catalogService == nil {
+		productRepo, categoryRepo, reviewRepo, userRepo := c.ProductRepo(), c.CategoryRepo(), c.ReviewRepo(), c.UserRepo()
+		c.provide("catalog service", func() error {
+			c.catalogService = service.NewCatalogService(productRepo, categoryRepo, reviewRepo, userRepo)
+			return nil
+		})
+	}
+	return c.catalogService
+}
+
+// CatalogCache caches products with the TTLs configured per class of entry.
+func (c *Container) CatalogCache() *service.CatalogCache {
+	if c.catalogCache == nil {
+		appCache := c.Cache()
+		c.provide("catalog cache", func() error {
+			cfg := c.Base.Config.Cache
+			c.catalogCache = service.NewCatalogCache(appCache, service.CatalogCacheOptions{
+				Product:     service.CacheTTL{Soft: cfg.Product.SoftTTL, Hard: cfg.Product.HardTTL},
+				ProductList: service.CacheTTL{Soft: cfg.ProductList.SoftTTL, Hard: cfg.ProductList.HardTTL},
+			})
+			return nil
+		})
+	}
+	return c.catalogCache
+}
+
+func (c *Container) PopularityService() service.PopularityService {
+	if c.popularityService == nil {
+		productRepo, appCache := c.ProductRepo(), c.Cache()
+		c.provide("popularity service", func() error {
+			c.popularityService = service.NewPopularityService(productRepo, appCache, service.PopularityOptions{
+				HalfLife: c.Base.Config.Popularity.HalfLife,
+			})
+			return nil
+		})
+	}
+	return c.popularityService
+}
+
+func (c *Container) SuggestionService() service.SuggestionService {
+	if c.suggestionService == nil {
+		productRepo, categoryRepo, synonymRepo, searchRepo, appCache := c.ProductRepo(), c.CategoryRepo(), c.SynonymRepo(), c.SearchRepo(), c.Cache()
+		c.provide("suggestion service", func() error {
+			cfg := c.Base.Config.Suggest
+			c.suggestionService = service.NewSuggestionService(productRepo, categoryRepo, synonymRepo, searchRepo, appCache, service.SuggestOptions{
+				TypoTolerance: cfg.TypoTolerance,
+				MinTypoLength: cfg.MinTypoLength,
+			})
+			return nil
+		})
+	}
+	return c.suggestionService
+}
+
+func (c *Container) SynonymService() service.SynonymService {
+	if c.synonymService == nil {
+		synonymRepo := c.SynonymRepo()
+		c.provide("synonym service", func() error {
+			c.synonymService = service.NewSynonymService(synonymRepo)
+			return nil
+		})
+	}
+	return c.synonymService
+}
+
+func (c *Container) MerchandisingService() service.MerchandisingService {
+	if c.merchandisingService == nil {
+		searchRepo, productRepo := c.SearchRepo(), c.ProductRepo()
+		c.provide("merchandising service", func() error {
+			c.merchandisingService = service.NewMerchandisingService(searchRepo, productRepo)
+			return nil
+		})
+	}
+	return c.merchandisingService
+}
+
+func (c *Container) ReviewService() service.ReviewService {
+	if c.reviewService == nil {
+		reviewRepo, appCache, catalog := c.ReviewRepo(), c.Cache(), c.CatalogCache()
+		c.provide("review service", func() error {
+			cfg := c.Base.Config.Reviews
+			c.reviewService = service.NewReviewService(reviewRepo, appCache, catalog, service.ReviewOptions{
+				VoteLimit:   cfg.VoteLimit,
+				ReportLimit: cfg.ReportLimit,
+				LimitWindow: cfg.LimitWindow,
+			})
+			return nil
+		})
+	}
+	return c.reviewService
+}
+
+func (c *Container) InventorySyncService() service.InventorySyncService {
+	if c.inventorySyncService == nil {
+		productRepo, txManager, catalog := c.ProductRepo(), c.TxManager(), c.CatalogCache()
+		c.provide("inventory sync service", func() error {
+			c.inventorySyncService = service.NewInventorySyncService(productRepo, txManager, catalog)
+			return nil
+		})
+	}
+	return c.inventorySyncService
+}
+
+// CatalogInvalidator drops the cached products whose stock changed; the API
+// server runs it.
+func (c *Container) CatalogInvalidator() *service.CatalogInvalidator {
+	if c.catalogInvalidator == nil {
+		appCache, productRepo := c.Cache(), c.ProductRepo()
+		c.provide("catalog invalidator", func() error {
+			c.catalogInvalidator = service.NewCatalogInvalidator(appCache, productRepo)
+			return nil
+		})
+	}
+	return c.catalogInvalidator
+}
+
+func (c *Container) DashboardService() service.DashboardService {
+	if c.dashboardService == nil {
+		orderRepo, productRepo, appCache, currencies := c.OrderRepo(), c.ProductRepo(), c.Cache(), c.CurrencyService()
+		c.provide("dashboard service", func() error {
+			c.dashboardService = service.NewDashboardService(orderRepo, productRepo, appCache, currencies, c.Base.Config.Webhook.LowStockThreshold)
+			return nil
+		})
+	}
+	return c.dashboardService
+}
+
+func (c *Container) OrderService() service.OrderService {
+	if c.orderService == nil {
+		orderRepo, productRepo, txManager, webhookService, currencies, taxes := c.OrderRepo(), c.ProductRepo(), c.TxManager(), c.WebhookService(), c.CurrencyService(), c.TaxService()
+		events, promotions, payments, sagas, appCache, groups := c.EventPublisher(), c.PromotionService(), c.PaymentMethodService(), c.SagaRepo(), c.Cache(), c.CustomerGroupService()
+		addresses, restrictions := c.AddressService(), c.ShippingRestrictionService()
+		c.provide("order service", func() error {
+			c.orderService = service.NewOrderService(orderRepo, productRepo, txManager, webhookService, events, currencies, taxes, promotions, payments, sagas, appCache, groups, addresses, restrictions, service.OrderOptions{
+				LowStockThreshold:  c.Base.Config.Webhook.LowStockThreshold,
+				StockLocking:       c.Base.Config.Order.StockLocking,
+				PaymentCapture:     c.Base.Config.Order.PaymentCapture,
+				CheckoutSessionTTL: c.Base.Config.Order.CheckoutSessionTTL,
+				RestockFailed:      c.Base.Config.Order.RestockFailed,
+			})
+			return nil
+		})
+	}
+	return c.orderService
+}
+
+// PaymentMethodService saves cards with payment.provider. It is nil, and
+// saved payment methods are disabled, when no provider is configured.
+func (c *Container) PaymentMethodService() service.PaymentMethodService {
+	if c.paymentMethodService == nil && c.Base.Config.Payment.Provider != "" {
+		methodRepo, disputeRepo, providers := c.PaymentMethodRepo(), c.DisputeRepo(), c.PaymentProviders()
+		c.provide("payment method service", func() error {
+			name := c.Base.Config.Payment.Provider
+			vault, ok := providers[name].(payment.Vault)
+			if !ok {
+				return fmt.Errorf("payment provider %q is not enabled or cannot save payment methods", name)
+			}
+			if _, ok := vault.(payment.Capturer); !ok && c.Base.Config.Order.PaymentCapture == service.PaymentCaptureShipment {
+				return fmt.Errorf("payment provider %q cannot capture on shipment", name)
+			}
+			c.paymentMethodService = service.NewPaymentMethodService(methodRepo, disputeRepo, vault)
+			return nil
+		})
+	}
+	return c.paymentMethodService
+}
+
+// PaymentService is nil, and orders cannot be paid online, when no payment
+// provider is enabled.
+func (c *Container) PaymentService() service.PaymentService {
+	if c.paymentService == nil {
+		orderRepo, txManager, webhookService, appCache, providers := c.OrderRepo(), c.TxManager(), c.WebhookService(), c.Cache(), c.PaymentProviders()
+		if len(providers) == 0 {
+			return nil
+		}
+		events, disputes := c.EventPublisher(), c.DisputeService()
+		c.provide("payment service", func() error {
+			c.paymentService = service.NewPaymentService(orderRepo, txManager, webhookService, events, disputes, appCache, providers)
+			return nil
+		})
+	}
+	return c.paymentService
+}
+
+func (c *Container) FulfillmentService() service.FulfillmentService {
+	if c.fulfillmentService == nil {
+		orderRepo, shipmentRepo, productRepo, txManager, webhookService, providers := c.OrderRepo(), c.ShipmentRepo(), c.ProductRepo(), c.TxManager(), c.WebhookService(), c.PaymentProviders()
+		events, appCache, catalog := c.EventPublisher(), c.Cache(), c.CatalogCache()
+		c.provide("fulfillment service", func() error {
+			c.fulfillmentService = service.NewFulfillmentService(orderRepo, shipmentRepo, productRepo, txManager, webhookService, events, providers, service.NewPurchaseLimiter(appCache), catalog)
+			return nil
+		})
+	}
+	return c.fulfillmentService
+}
+
+func (c *Container) PickingService() service.PickingService {
+	if c.pickingService == nil {
+		taskRepo, orderRepo, productRepo, fulfillment := c.PickTaskRepo(), c.OrderRepo(), c.ProductRepo(), c.FulfillmentService()
+		c.provide("picking service", func() error {
+			c.pickingService = service.NewPickingService(taskRepo, orderRepo, productRepo, fulfillment)
+			return nil
+		})
+	}
+	return c.pickingService
+}
+
+// SupportService resends notifications right away through
+// NotificationService, over the channels of notification.channels.
+func (c *Container) SupportService() service.SupportService {
+	if c.supportService == nil {
+		orderRepo, shipmentRepo, creditRepo, userRepo, txManager := c.OrderRepo(), c.ShipmentRepo(), c.StoreCreditRepo(), c.UserRepo(), c.TxManager()
+		history, notifications, addresses := c.OrderHistoryService(), c.NotificationService(), c.AddressService()
+		c.provide("support service", func() error {
+			routes, err := notification.NewRoutes(c.Base.Config.Notification.Channels)
+			if err != nil {
+				return fmt.Errorf("invalid notification.channels: %w", err)
+			}
+			c.supportService = service.NewSupportService(orderRepo, shipmentRepo, creditRepo, userRepo, txManager, history, notifications, routes, addresses)
+			return nil
+		})
+	}
+	return c.supportService
+}
+
+func (c *Container) DisputeService() service.DisputeService {
+	if c.disputeService == nil {
+		disputeRepo, orderRepo := c.DisputeRepo(), c.OrderRepo()
+		c.provide("dispute service", func() error {
+			c.disputeService = service.NewDisputeService(disputeRepo, orderRepo)
+			return nil
+		})
+	}
+	return c.disputeService
+}
+
+// DisputeReminder queues reminders of dispute evidence to admins on RabbitMQ
+// for the worker to deliver.
+func (c *Container) DisputeReminder() service.DisputeReminder {
+	if c.disputeReminder == nil {
+		disputeRepo, orderRepo, userRepo, notifier := c.DisputeRepo(), c.OrderRepo(), c.UserRepo(), c.Notifier()
+		c.provide("dispute reminder", func() error {
+			cfg := c.Base.Config.Payment.Disputes
+			c.disputeReminder = service.NewDisputeReminder(disputeRepo, orderRepo, userRepo, notifier, service.DisputeReminderOptions{
+				LeadTime: cfg.ReminderLeadTime,
+				Interval: cfg.ReminderInterval,
+			})
+			return nil
+		})
+	}
+	return c.disputeReminder
+}
+
+func (c *Container) CartService() service.CartService {
+	if c.cartService == nil {
+		cartRepo, productRepo, currencies := c.CartRepo(), c.ProductRepo(), c.CurrencyService()
+		c.provide("cart service", func() error {
+			c.cartService = service.NewCartService(cartRepo, productRepo, currencies)
+			return nil
+		})
+	}
+	return c.cartService
+}
+
+// CartRecovery queues reminders of abandoned carts to customers on RabbitMQ
+// for the worker to deliver.
+func (c *Container) CartRecovery() service.CartRecovery {
+	if c.cartRecovery == nil {
+		cartRepo, productRepo, coupons, notifier := c.CartRepo(), c.ProductRepo(), c.CouponService(), c.Notifier()
+		c.provide("cart recovery", func() error {
+			cfg := c.Base.Config.Cart
+			c.cartRecovery = service.NewCartRecovery(cartRepo, productRepo, coupons, notifier, service.CartRecoveryOptions{
+				AbandonedAfter:   cfg.AbandonedAfter,
+				Window:           cfg.RecoveryWindow,
+				CartURL:          cfg.RecoveryURL,
+				CouponCampaignID: cfg.CouponCampaignID,
+			})
+			return nil
+		})
+	}
+	return c.cartRecovery
+}
+
+func (c *Container) PromotionService() service.PromotionService {
+	if c.promotionService == nil {
+		promotionRepo, couponRepo, productRepo, categoryRepo, currencies := c.PromotionRepo(), c.CouponRepo(), c.ProductRepo(), c.CategoryRepo(), c.CurrencyService()
+		c.provide("promotion service", func() error {
+			c.promotionService = service.NewPromotionService(promotionRepo, couponRepo, productRepo, categoryRepo, currencies)
+			return nil
+		})
+	}
+	return c.promotionService
+}
+
+func (c *Container) CouponService() service.CouponService {
+	if c.couponService == nil {
+		couponRepo, promotionRepo := c.CouponRepo(), c.PromotionRepo()
+		c.provide("coupon service", func() error {
+			c.couponService = service.NewCouponService(couponRepo, promotionRepo, service.CouponOptions{
+				CodeLength: c.Base.Config.Coupon.CodeLength,
+			})
+			return nil
+		})
+	}
+	return c.couponService
+}
+
+// SubscriptionService is nil, and subscriptions are disabled, without
+// saved payment methods to charge their renewals to.
+func (c *Container) SubscriptionService() service.SubscriptionService {
+	if c.subscriptionService == nil {
+		payments := c.PaymentMethodService()
+		if payments == nil {
+			return nil
+		}
+		subscriptionRepo, productRepo, storeRepo, txManager, orders, webhookService := c.SubscriptionRepo(), c.ProductRepo(), c.StoreRepo(), c.TxManager(), c.OrderService(), c.WebhookService()
+		c.provide("subscription service", func() error {
+			c.subscriptionService = service.NewSubscriptionService(subscriptionRepo, productRepo, storeRepo, txManager, orders, payments, webhookService, service.SubscriptionOptions{
+				RetryIntervals: c.Base.Config.Subscription.RetryIntervals,
+			})
+			return nil
+		})
+	}
+	return c.subscriptionService
+}
+
+func (c *Container) QuoteService() service.QuoteService {
+	if c.quoteService == nil {
+		quoteRepo, productRepo, currencies, orders := c.QuoteRepo(), c.ProductRepo(), c.CurrencyService(), c.OrderService()
+		c.provide("quote service", func() error {
+			c.quoteService = service.NewQuoteService(quoteRepo, productRepo, currencies, orders)
+			return nil
+		})
+	}
+	return c.quoteService
+}
+
+func (c *Container) PurchasingService() service.PurchasingService {
+	if c.purchasingService == nil {
+		supplierRepo, poRepo, movementRepo, productRepo, txManager, catalog := c.SupplierRepo(), c.PurchaseOrderRepo(), c.StockMovementRepo(), c.ProductRepo(), c.TxManager(), c.CatalogCache()
+		c.provide("purchasing service", func() error {
+			c.purchasingService = service.NewPurchasingService(supplierRepo, poRepo, movementRepo, productRepo, txManager, catalog)
+			return nil
+		})
+	}
+	return c.purchasingService
+}
+
+func (c *Container) StockHoldService() service.StockHoldService {
+	if c.stockHoldService == nil {
+		holdRepo, movementRepo, productRepo, txManager, catalog := c.StockHoldRepo(), c.StockMovementRepo(), c.ProductRepo(), c.TxManager(), c.CatalogCache()
+		c.provide("stock hold service", func() error {
+			c.stockHoldService = service.NewStockHoldService(holdRepo, movementRepo, productRepo, txManager, catalog)
+			return nil
+		})
+	}
+	return c.stockHoldService
+}
+
+// OrderModificationService settles paid orders through
+// PaymentMethodService; without it, their totals cannot change.
+func (c *Container) OrderModificationService() service.OrderModificationService {
+	if c.modificationService == nil {
+		orderRepo, shipmentRepo, taskRepo, productRepo, modificationRepo, sagaRepo := c.OrderRepo(), c.ShipmentRepo(), c.PickTaskRepo(), c.ProductRepo(), c.OrderModificationRepo(), c.SagaRepo()
+		txManager, events, payments, appCache, catalog, addresses := c.TxManager(), c.EventPublisher(), c.PaymentMethodService(), c.Cache(), c.CatalogCache(), c.AddressService()
+		c.provide("order modification service", func() error {
+			c.modificationService = service.NewOrderModificationService(orderRepo, shipmentRepo, taskRepo, productRepo, modificationRepo, sagaRepo,
+				txManager, events, payments, service.NewPurchaseLimiter(appCache), catalog, addresses)
+			return nil
+		})
+	}
+	return c.modificationService
+}
+
+func (c *Container) LicenseKeyService() service.LicenseKeyService {
+	if c.licenseKeyService == nil {
+		licenseKeyRepo, productRepo := c.LicenseKeyRepo(), c.ProductRepo()
+		c.provide("license key service", func() error {
+			c.licenseKeyService = service.NewLicenseKeyService(licenseKeyRepo, productRepo)
+			return nil
+		})
+	}
+	return c.licenseKeyService
+}
+
+// DigitalFulfillmentService sends the license keys and download links of
+// paid orders through the Notifier.
+func (c *Container) DigitalFulfillmentService() service.DigitalFulfillmentService {
+	if c.digitalService == nil {
+		orderRepo, licenseKeyRepo, productRepo, txManager, notifier := c.OrderRepo(), c.LicenseKeyRepo(), c.ProductRepo(), c.TxManager(), c.Notifier()
+		c.provide("digital fulfillment service", func() error {
+			cfg := c.Base.Config.Digital
+			c.digitalService = service.NewDigitalFulfillmentService(orderRepo, licenseKeyRepo, productRepo, txManager, notifier, service.DigitalOptions{
+				DownloadBaseURL: cfg.DownloadBaseURL,
+				DownloadSecret:  cfg.DownloadSecret,
+				DownloadURLTTL:  cfg.DownloadURLTTL,
+			})
+			return nil
+		})
+	}
+	return c.digitalService
+}
+
+func (c *Container) InventoryService() *service.InventoryService {
+	if c.inventoryService == nil {
+		appCache := c.Cache()
+		c.provide("inventory service", func() error {
+			c.inventoryService = service.NewInventoryService(appCache)
+			return nil
+		})
+	}
+	return c.inventoryService
+}
+
+func (c *Container) StockReconciler() service.StockReconciler {
+	if c.stockReconciler == nil {
+		productRepo, orderRepo, inventoryService := c.ProductRepo(), c.OrderRepo(), c.InventoryService()
+		c.provide("stock reconciler", func() error {
+			c.stockReconciler = service.NewStockReconciler(productRepo, orderRepo, inventoryService)
+			return nil
+		})
+	}
+	return c.stockReconciler
+}
+
+func (c *Container) WebhookService() service.WebhookService {
+	if c.webhookService == nil {
+		webhookRepo := c.WebhookRepo()
+		c.provide("webhook service", func() error {
+			c.webhookService = service.NewWebhookService(webhookRepo)
+			return nil
+		})
+	}
+	return c.webhookService
+}
+
+// EventPublisher records domain events in the outbox; EventRelay publishes
+// them from cmd/worker, so the API server does not need RabbitMQ.
+func (c *Container) EventPublisher() service.EventPublisher {
+	if c.eventPublisher == nil {
+		outboxRepo := c.OutboxRepo()
+		c.provide("event publisher", func() error {
+			c.eventPublisher = service.NewEventPublisher(outboxRepo)
+			return nil
+		})
+	}
+	return c.eventPublisher
+}
+
+func (c *Container) EventRelay() service.EventRelay {
+	if c.eventRelay == nil {
+		outboxRepo, broker := c.OutboxRepo(), c.MQ()
+		c.provide("event relay", func() error {
+			c.eventRelay = service.NewEventRelay(outboxRepo, broker)
+			return nil
+		})
+	}
+	return c.eventRelay
+}
+
+// OrderHistoryService serves the order list read model, which cmd/worker
+// projects from the order events.
+func (c *Container) OrderHistoryService() service.OrderHistoryService {
+	if c.orderHistoryService == nil {
+		summaryRepo, orderRepo := c.OrderSummaryRepo(), c.OrderRepo()
+		c.provide("order history service", func() error {
+			c.orderHistoryService = service.NewOrderHistoryService(summaryRepo, orderRepo)
+			return nil
+		})
+	}
+	return c.orderHistoryService
+}
+
+func (c *Container) AuditService() service.AuditService {
+	if c.auditService == nil {
+		auditLogRepo := c.AuditLogRepo()
+		c.provide("audit service", func() error {
+			c.auditService = service.NewAuditService(auditLogRepo)
+			return nil
+		})
+	}
+	return c.auditService
+}
+
+func (c *Container) IPFilterService() service.IPFilterService {
+	if c.ipFilterService == nil {
+		redisClient := c.RedisClient()
+		c.provide("ip filter service", func() error {
+			c.ipFilterService = service.NewIPFilterService(redisClient)
+			return nil
+		})
+	}
+	return c.ipFilterService
+}
+
+// AbuseDetector follows security.bot_protection limits, including live reloads.
+func (c *Container) AbuseDetector() service.AbuseDetector {
+	if c.abuseDetector == nil {
+		redisClient, watcher := c.RedisClient(), c.ConfigWatcher()
+		c.provide("abuse detector", func() error {
+			limits := c.Base.Config.Security.BotProtection
+			detector := service.NewAbuseDetector(redisClient, limits.MaxFailures, limits.Window)
+			watcher.Subscribe(func(e config.ChangeEvent) {
+				if e.Has(config.SectionSecurity) {
+					detector.SetLimits(e.New.Security.BotProtection.MaxFailures, e.New.Security.BotProtection.Window)
+				}
+			})
+			c.abuseDetector = detector
+			return nil
+		})
+	}
+	return c.abuseDetector
+}
+
+// Notifications
+
+// EmailProvider sends through notification.provider; the default log provider
+// only logs each email.
+func (c *Container) EmailProvider() notification.EmailProvider {
+	if c.emailProvider == nil {
+		c.provide("email provider", func() error {
+			cfg := c.Base.Config.Notification
+			switch cfg.Provider {
+			case notification.ProviderSMTP:
+				provider, err := notification.NewSMTPProvider(cfg.SMTP)
+				if err != nil {
+					return err
+				}
+				c.emailProvider = provider
+			case notification.ProviderSES:
+				var opts []func(*awsconfig.LoadOptions) error
+				if cfg.SES.Region != "" {
+					opts = append(opts, awsconfig.WithRegion(cfg.SES.Region))
+				}
+				awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+				if err != nil {
+					return fmt.Errorf("failed to load aws config: %w", err)
+				}
+				c.emailProvider = notification.NewSESProvider(sesv2.NewFromConfig(awsCfg), cfg.SES.ConfigurationSet)
+			default:
+				c.emailProvider = notification.NewLogProvider(c.Base.Logger)
+			}
+			return nil
+		})
+	}
+	return c.emailProvider
+}
+
+// SMSProvider sends through notification.sms.provider; the default log
+// provider only logs messages.
+func (c *Container) SMSProvider() notification.SMSProvider {
+	if c.smsProvider == nil {
+		c.provide("sms provider", func() error {
+			cfg := c.Base.Config.Notification.SMS
+			switch cfg.Provider {
+			case notification.SMSProviderTwilio:
+				provider, err := notification.NewTwilioProvider(cfg.Twilio)
+				if err != nil {
+					return err
+				}
+				c.smsProvider = provider
+			case notification.SMSProviderAliyun:
+				provider, err := notification.NewAliyunProvider(cfg.Aliyun)
+				if err != nil {
+					return err
+				}
+				c.smsProvider = provider
+			default:
+				c.smsProvider = notification.NewLogSMSProvider(c.Base.Logger)
+			}
+			return nil
+		})
+	}
+	return c.smsProvider
+}
+
+// PushProviders sends to FCM and APNs when their keys are configured, and
+// logs pushes to tokens of an unconfigured platform.
+func (c *Container) PushProviders() map[notification.Platform]notification.PushProvider {
+	if c.pushProviders == nil {
+		c.provide("push providers", func() error {
+			cfg := c.Base.Config.Notification.Push
+			providers := map[notification.Platform]notification.PushProvider{
+				notification.PlatformFCM:  notification.NewLogPushProvider(c.Base.Logger, notification.PlatformFCM),
+				notification.PlatformAPNs: notification.NewLogPushProvider(c.Base.Logger, notification.PlatformAPNs),
+			}
+			if cfg.FCM.CredentialsFile != "" {
+				provider, err := notification.NewFCMProvider(cfg.FCM)
+				if err != nil {
+					return err
+				}
+				providers[notification.PlatformFCM] = provider
+			}
+			if cfg.APNs.KeyFile != "" {
+				provider, err := notification.NewAPNsProvider(cfg.APNs)
+				if err != nil {
+					return err
+				}
+				providers[notification.PlatformAPNs] = provider
+			}
+			c.pushProviders = providers
+			return nil
+		})
+	}
+	return c.pushProviders
+}
+
+// PaymentProviders enables each payment provider whose credentials are
+// configured.
+func (c *Container) PaymentProviders() map[string]payment.Provider {
+	if c.paymentProviders == nil {
+		c.provide("payment providers", func() error {
+			cfg := c.Base.Config.Payment
+			providers := make(map[string]payment.Provider)
+			if cfg.Stripe.SecretKey != "" {
+				provider, err := payment.NewStripeProvider(cfg.Stripe)
+				if err != nil {
+					return err
+				}
+				providers[payment.ProviderStripe] = provider
+			}
+			if cfg.Alipay.AppID != "" {
+				provider, err := payment.NewAlipayProvider(cfg.Alipay)
+				if err != nil {
+					return err
+				}
+				providers[payment.ProviderAlipay] = provider
+			}
+			if cfg.WeChat.MchID != "" {
+				provider, err := payment.NewWeChatPayProvider(cfg.WeChat)
+				if err != nil {
+					return err
+				}
+				providers[payment.ProviderWeChat] = provider
+			}
+			for name, provider := range providers {
+				providers[name] = payment.NewInstrumentedProvider(provider)
+			}
+			c.paymentProviders = providers
+			return nil
+		})
+	}
+	return c.paymentProviders
+}
+
+// NotificationRenderer brands messages with the display name of
+// notification.from and renders them in i18n.default_locale by default.
+func (c *Container) NotificationRenderer() *notification.Renderer {
+	if c.notificationRenderer == nil {
+		c.provide("notification renderer", func() error {
+			sender, err := mail.ParseAddress(c.notificationFrom())
+			if err != nil {
+				return fmt.Errorf("invalid notification.from: %w", err)
+			}
+			brand := sender.Name
+			if brand == "" {
+				brand = defaultEmailBrand
+			}
+			c.notificationRenderer, err = notification.NewRenderer(brand, c.Base.Config.I18n.DefaultLocale)
+			return err
+		})
+	}
+	return c.notificationRenderer
+}
+
+// notificationFrom is the address notification email is sent from.
+func (c *Container) notificationFrom() string {
+	if from := c.Base.Config.Notification.From; from != "" {
+		return from
+	}
+	return notification.DefaultFrom
+}
+
+// NotificationService delivers through the providers of each channel.
+func (c *Container) NotificationService() notification.Service {
+	if c.notificationService == nil {
+		userRepo, notificationRepo, renderer := c.UserRepo(), c.NotificationRepo(), c.NotificationRenderer()
+		providers := notification.Providers{Email: c.EmailProvider(), SMS: c.SMSProvider(), Push: c.PushProviders()}
+		c.provide("notification service", func() error {
+			c.notificationService = notification.NewService(userRepo, notificationRepo, providers, renderer, c.notificationFrom())
+			return nil
+		})
+	}
+	return c.notificationService
+}
+
+// NotificationTemplateService test sends through NotificationService.
+func (c *Container) NotificationTemplateService() service.NotificationTemplateService {
+	if c.templateService == nil {
+		notificationRepo, renderer, notifications := c.NotificationRepo(), c.NotificationRenderer(), c.NotificationService()
+		c.provide("notification template service", func() error {
+			c.templateService = service.NewNotificationTemplateService(notificationRepo, renderer, notifications)
+			return nil
+		})
+	}
+	return c.templateService
+}
+
+// BroadcastService queues broadcasts for BroadcastDispatcher to fan out in
+// the worker, so that the API server does not need RabbitMQ for them.
+func (c *Container) BroadcastService() service.BroadcastService {
+	if c.broadcastService == nil {
+		broadcastRepo := c.BroadcastRepo()
+		c.provide("broadcast service", func() error {
+			c.broadcastService = service.NewBroadcastService(broadcastRepo)
+			return nil
+		})
+	}
+	return c.broadcastService
+}
+
+// BroadcastDispatcher fans queued broadcasts out on RabbitMQ for the
+// notification worker to deliver.
+func (c *Container) BroadcastDispatcher() service.BroadcastDispatcher {
+	if c.broadcastDispatcher == nil {
+		broadcastRepo, broker := c.BroadcastRepo(), c.MQ()
+		c.provide("broadcast dispatcher", func() error {
+			c.broadcastDispatcher = service.NewBroadcastDispatcher(broadcastRepo, broker)
+			return nil
+		})
+	}
+	return c.broadcastDispatcher
+}
+
+// FailedMessageService archives the messages workers reject for good.
+func (c *Container) FailedMessageService() service.FailedMessageService {
+	if c.failedMessages == nil {
+		failedMessageRepo := c.FailedMessageRepo()
+		c.provide("failed message service", func() error {
+			c.failedMessages = service.NewFailedMessageService(failedMessageRepo)
+			return nil
+		})
+	}
+	return c.failedMessages
+}
+
+// FailedMessageReplayer publishes the failed messages an admin replays on
+// RabbitMQ.
+func (c *Container) FailedMessageReplayer() service.FailedMessageReplayer {
+	if c.failedMessageReplay == nil {
+		failedMessageRepo, broker := c.FailedMessageRepo(), c.MQ()
+		c.provide("failed message replayer", func() error {
+			c.failedMessageReplay = service.NewFailedMessageReplayer(failedMessageRepo, broker)
+			return nil
+		})
+	}
+	return c.failedMessageReplay
+}
+
+// CacheJanitor purges the stale keys of the cache for the worker's cache
+// sweeps.
+func (c *Container) CacheJanitor() service.CacheJanitor {
+	if c.cacheJanitor == nil {
+		appCache, productRepo, promotionRepo := c.Cache(), c.ProductRepo(), c.PromotionRepo()
+		c.provide("cache janitor", func() error {
+			c.cacheJanitor = service.NewCacheJanitor(appCache, productRepo, promotionRepo, service.CacheJanitorOptions{
+				RateLimitWindow: c.Base.Config.Reviews.LimitWindow,
+			})
+			return nil
+		})
+	}
+	return c.cacheJanitor
+}
+
+// MQHealthReporter reports the broker health of the worker for
+// DiagnosticsService.
+func (c *Container) MQHealthReporter() service.MQHealthReporter {
+	if c.mqHealthReporter == nil {
+		broker, appCache := c.MQ(), c.Cache()
+		c.provide("mq health reporter", func() error {
+			c.mqHealthReporter = service.NewMQHealthReporter(broker, appCache, worker.Queues, c.Base.Config.Worker.HealthReportInterval)
+			return nil
+		})
+	}
+	return c.mqHealthReporter
+}
+
+// Notifier enqueues one delivery per channel in notification.channels on
+// RabbitMQ for the worker to deliver.
+func (c *Container) Notifier() notification.Notifier {
+	if c.notifier == nil {
+		broker := c.MQ()
+		c.provide("notifier", func() error {
+			routes, err := notification.NewRoutes(c.Base.Config.Notification.Channels)
+			if err != nil {
+				return fmt.Errorf("invalid notification.channels: %w", err)
+			}
+			c.notifier = notification.NewNotifier(broker, routes)
+			return nil
+		})
+	}
+	return c.notifier
+}
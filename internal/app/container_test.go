@@ -0,0 +1,123 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/config/configtest"
+	"github.com/proyuen/go-mall/pkg/errreport"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newTestBase(t *testing.T, overlay string) *Base {
+	return &Base{Config: configtest.Load(t, overlay), Logger: slog.Default(), Reporter: errreport.Nop()}
+}
+
+// lazyDB returns a *gorm.DB that never connects unless a query runs.
+func lazyDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1"}), &gorm.Config{DisableAutomaticPing: true})
+	require.NoError(t, err)
+	return db
+}
+
+func TestContainer_MemoizesProviders(t *testing.T) {
+	redisClient := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"}) // Never dialed
+	c := New(newTestBase(t, ""), WithDB(lazyDB(t)), WithRedisClient(redisClient))
+
+	assert.Same(t, c.RedisClient(), redisClient)
+	assert.Same(t, c.InventoryService(), c.InventoryService())
+	assert.Equal(t, c.UserService(), c.UserService())
+	assert.Equal(t, c.Cache(), c.Cache())
+	require.NoError(t, c.Err())
+
+	// Injected components belong to the caller: stopping must not close them.
+	require.NoError(t, c.Lifecycle.Stop(context.Background()))
+	assert.NotErrorIs(t, redisClient.Conn().Close(), redis.ErrClosed)
+}
+
+func TestContainer_StickyError(t *testing.T) {
+	// Nothing listens on port 1, so the database provider fails.
+	c := New(newTestBase(t, `
+database:
+  host: "127.0.0.1"
+  port: "1"
+`))
+
+	assert.Nil(t, c.UserRepo())
+	require.Error(t, c.Err())
+	assert.Contains(t, c.Err().Error(), "failed to provide database")
+
+	// Later getters do not retry or overwrite the first error.
+	first := c.Err()
+	assert.Nil(t, c.OrderService())
+	assert.Equal(t, first, c.Err())
+
+	_, err := NewServer(c)
+	assert.Error(t, err)
+}
+
+func TestServer_Lifecycle(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	require.NoError(t, l.Close())
+
+	c := New(newTestBase(t, fmt.Sprintf("server:\n  port: \"%d\"\n", port)),
+		WithDB(lazyDB(t)),
+		WithRedisClient(redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"})),
+	)
+	server, err := NewServer(c)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Run(ctx, 5*time.Second) }()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/metrics", port)
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(url)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 20*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+	_, err = http.Get(url)
+	assert.Error(t, err, "listener must be closed after shutdown")
+}
+
+func TestApplyJWTKeys_Rotation(t *testing.T) {
+	oldSecret, newSecret := "12345678901234567890123456789012", "abcdefghijabcdefghijabcdefghijab"
+	maker, err := newJWTKeyringMaker(config.JWTConfig{Secret: oldSecret, KeyID: "old"}, config.NewWatcher(&config.Config{}, config.LoadOptions{}))
+	require.NoError(t, err)
+	oldToken, _, err := maker.CreateToken(101, 1, "test_user", "user", time.Minute)
+	require.NoError(t, err)
+
+	// The old key only verifies once the new one signs
+	require.NoError(t, applyJWTKeys(maker, config.JWTConfig{Secret: newSecret, KeyID: "new", Keys: []config.JWTKeyConfig{{ID: "old", Secret: oldSecret}}}))
+	assert.Equal(t, []string{"new", "old"}, maker.KeyIDs())
+	_, err = maker.VerifyToken(oldToken)
+	require.NoError(t, err)
+
+	// Dropped from jwt.keys: its tokens are rejected
+	require.NoError(t, applyJWTKeys(maker, config.JWTConfig{Secret: newSecret, KeyID: "new"}))
+	assert.Equal(t, []string{"new"}, maker.KeyIDs())
+	_, err = maker.VerifyToken(oldToken)
+	assert.ErrorIs(t, err, token.ErrInvalidToken)
+
+	assert.Error(t, applyJWTKeys(maker, config.JWTConfig{Secret: newSecret, KeyID: "new", Keys: []config.JWTKeyConfig{{ID: "new", Secret: oldSecret}}}))
+}
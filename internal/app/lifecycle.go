@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Hook is a start/stop pair registered by a provider. Either function may be nil;
+// a hook without OnStart is considered started as soon as it is appended, so a
+// connection opened by its provider is still closed if startup fails later.
+type Hook struct {
+	Name    string
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// Lifecycle starts hooks in registration order and stops them in reverse.
+type Lifecycle struct {
+	mu      sync.Mutex
+	hooks   []Hook
+	started []bool
+}
+
+// NewLifecycle creates an empty Lifecycle.
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// Append registers a hook. Providers append as they build, so dependencies
+// start before, and stop after, the components that use them.
+func (l *Lifecycle) Append(h Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, h)
+	l.started = append(l.started, h.OnStart == nil)
+}
+
+// Start runs every OnStart in order. If one fails, the hooks already started are
+// stopped before the error is returned.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := append([]Hook(nil), l.hooks...)
+	l.mu.Unlock()
+
+	for i, h := range hooks {
+		if h.OnStart == nil {
+			continue
+		}
+		if err := h.OnStart(ctx); err != nil {
+			startErr := fmt.Errorf("failed to start %s: %w", h.Name, err)
+			return errors.Join(startErr, l.Stop(ctx))
+		}
+		l.mu.Lock()
+		l.started[i] = true
+		l.mu.Unlock()
+	}
+	return nil
+}
+
+// Stop runs OnStop for every started hook in reverse order, continuing past
+// failures, and returns all errors joined. Each hook is stopped at most once.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	var errs []error
+	for {
+		h, ok := l.popStarted()
+		if !ok {
+			return errors.Join(errs...)
+		}
+		if h.OnStop == nil {
+			continue
+		}
+		if err := h.OnStop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", h.Name, err))
+		}
+	}
+}
+
+// popStarted returns the last started hook and marks it stopped.
+func (l *Lifecycle) popStarted() (Hook, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := len(l.hooks) - 1; i >= 0; i-- {
+		if l.started[i] {
+			l.started[i] = false
+			return l.hooks[i], true
+		}
+	}
+	return Hook{}, false
+}
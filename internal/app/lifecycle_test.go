@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder builds hooks that log their calls.
+type recorder struct {
+	calls []string
+}
+
+func (r *recorder) hook(name string, startErr error) Hook {
+	return Hook{
+		Name: name,
+		OnStart: func(context.Context) error {
+			r.calls = append(r.calls, "start "+name)
+			return startErr
+		},
+		OnStop: func(context.Context) error {
+			r.calls = append(r.calls, "stop "+name)
+			return nil
+		},
+	}
+}
+
+func (r *recorder) closer(name string) Hook {
+	return Hook{Name: name, OnStop: func(context.Context) error {
+		r.calls = append(r.calls, "stop "+name)
+		return nil
+	}}
+}
+
+func TestLifecycle(t *testing.T) {
+	tests := []struct {
+		name      string
+		hooks     func(r *recorder) []Hook
+		start     bool
+		wantErr   string
+		wantCalls []string
+	}{
+		{
+			name: "StartInOrderStopInReverse",
+			hooks: func(r *recorder) []Hook {
+				return []Hook{r.closer("db"), r.hook("watcher", nil), r.hook("http", nil)}
+			},
+			start:     true,
+			wantCalls: []string{"start watcher", "start http", "stop http", "stop watcher", "stop db"},
+		},
+		{
+			name: "FailedStartRollsBack",
+			hooks: func(r *recorder) []Hook {
+				return []Hook{r.closer("db"), r.hook("watcher", nil), r.hook("http", errors.New("address in use")), r.hook("never", nil)}
+			},
+			start:     true,
+			wantErr:   "failed to start http: address in use",
+			wantCalls: []string{"start watcher", "start http", "stop watcher", "stop db"},
+		},
+		{
+			name: "StopWithoutStartClosesConnectionsOnly",
+			hooks: func(r *recorder) []Hook {
+				return []Hook{r.closer("db"), r.hook("http", nil), r.closer("redis")}
+			},
+			wantCalls: []string{"stop redis", "stop db"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{}
+			lc := NewLifecycle()
+			for _, h := range tt.hooks(r) {
+				lc.Append(h)
+			}
+
+			if tt.start {
+				err := lc.Start(context.Background())
+				if tt.wantErr != "" {
+					require.Error(t, err)
+					assert.Contains(t, err.Error(), tt.wantErr)
+				} else {
+					require.NoError(t, err)
+				}
+			}
+			require.NoError(t, lc.Stop(context.Background()))
+			// A second Stop is a no-op.
+			require.NoError(t, lc.Stop(context.Background()))
+
+			assert.Equal(t, tt.wantCalls, r.calls)
+		})
+	}
+}
+
+func TestLifecycle_StopJoinsErrors(t *testing.T) {
+	lc := NewLifecycle()
+	stopped := false
+	lc.Append(Hook{Name: "a", OnStop: func(context.Context) error { stopped = true; return nil }})
+	lc.Append(Hook{Name: "b", OnStop: func(context.Context) error { return errors.New("boom") }})
+
+	err := lc.Stop(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to stop b: boom")
+	assert.True(t, stopped, "a failing hook must not prevent the rest from stopping")
+}
@@ -0,0 +1,321 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/api/swagger"
+	"github.com/proyuen/go-mall/internal/graph"
+	"github.com/proyuen/go-mall/internal/handler"
+	"github.com/proyuen/go-mall/internal/middleware"
+	"github.com/proyuen/go-mall/internal/router"
+	"github.com/proyuen/go-mall/internal/rpc"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/captcha"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/validation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// readHeaderTimeout bounds slow-header clients (Slowloris).
+const readHeaderTimeout = 10 * time.Second
+
+// Server is the HTTP API process, also serving the gRPC API when server.grpc_port is set.
+type Server struct {
+	Engine    *gin.Engine
+	Lifecycle *Lifecycle
+	errs      chan error
+}
+
+// NewServer wires the HTTP API from the container and registers the listener with
+// its Lifecycle: it starts accepting after every dependency started and drains
+// in-flight requests before dependencies are stopped.
+func NewServer(c *Container) (*Server, error) {
+	cfg := c.Base.Config
+
+	// Resolve every dependency first; the container reports the first provider failure.
+	userService, productService, orderService := c.UserService(), c.ProductService(), c.OrderService()
+	userHandler := handler.NewUserHandler(userService)
+	var passwordResetHandler *handler.PasswordResetHandler // Nil without password_reset.url
+	if resets := c.PasswordResetService(); resets != nil {
+		passwordResetHandler = handler.NewPasswordResetHandler(resets)
+	}
+	productHandler := handler.NewProductHandler(productService, c.CurrencyService(), c.TranslationService(), c.PopularityService(), c.SuggestionService(), c.CustomerGroupService())
+	orderHandler := handler.NewOrderHandler(orderService)
+	ipFilterService, auditService := c.IPFilterService(), c.AuditService()
+	adminHandler := handler.NewAdminHandler(ipFilterService, auditService, c.DashboardService(), c.LogLevelService(), c.DiagnosticsService())
+	notificationHandler := handler.NewNotificationHandler(c.NotificationService(), cfg.Notification.SES.WebhookToken)
+	webhookHandler := handler.NewWebhookHandler(c.WebhookService())
+	currencyHandler := handler.NewCurrencyHandler(c.CurrencyService())
+	translationHandler := handler.NewTranslationHandler(c.TranslationService())
+	fulfillmentHandler := handler.NewFulfillmentHandler(c.FulfillmentService())
+	promotionHandler := handler.NewPromotionHandler(c.PromotionService())
+	couponHandler := handler.NewCouponHandler(c.CouponService())
+	var paymentMethodHandler *handler.PaymentMethodHandler // Nil without a provider to save cards with
+	if paymentMethods := c.PaymentMethodService(); paymentMethods != nil {
+		paymentMethodHandler = handler.NewPaymentMethodHandler(paymentMethods)
+	}
+	var subscriptionHandler *handler.SubscriptionHandler // Nil without saved payment methods to charge renewals to
+	if subscriptions := c.SubscriptionService(); subscriptions != nil {
+		subscriptionHandler = handler.NewSubscriptionHandler(subscriptions)
+	}
+	digitalHandler := handler.NewDigitalHandler(c.LicenseKeyService())
+	inventoryHandler := handler.NewInventoryHandler(c.InventorySyncService())
+	synonymHandler := handler.NewSynonymHandler(c.SynonymService())
+	merchandisingHandler := handler.NewMerchandisingHandler(c.MerchandisingService())
+	reviewHandler := handler.NewReviewHandler(c.ReviewService())
+	notificationTemplateHandler := handler.NewNotificationTemplateHandler(c.NotificationTemplateService())
+	broadcastHandler := handler.NewBroadcastHandler(c.BroadcastService())
+	failedMessageHandler := handler.NewFailedMessageHandler(c.FailedMessageService())
+	customerGroupHandler := handler.NewCustomerGroupHandler(c.CustomerGroupService())
+	quoteHandler := handler.NewQuoteHandler(c.QuoteService())
+	purchasingHandler := handler.NewPurchasingHandler(c.PurchasingService())
+	pickingHandler := handler.NewPickingHandler(c.PickingService())
+	supportHandler := handler.NewSupportHandler(c.SupportService())
+	disputeHandler := handler.NewDisputeHandler(c.DisputeService())
+	cartHandler := handler.NewCartHandler(c.CartService())
+	priceScheduleHandler := handler.NewPriceScheduleHandler(c.PriceScheduleService())
+	stockHoldHandler := handler.NewStockHoldHandler(c.StockHoldService())
+	orderModificationHandler := handler.NewOrderModificationHandler(c.OrderModificationService())
+	addressHandler := handler.NewAddressHandler(c.AddressService())
+	restrictionHandler := handler.NewShippingRestrictionHandler(c.ShippingRestrictionService())
+	orderHistoryHandler := handler.NewOrderHistoryHandler(c.OrderHistoryService())
+	var paymentHandler *handler.PaymentHandler // Nil without an enabled payment provider
+	if payments := c.PaymentService(); payments != nil {
+		paymentHandler = handler.NewPaymentHandler(payments)
+	}
+	catalogService, catalogInvalidator := c.CatalogService(), c.CatalogInvalidator()
+	abuseDetector, userRepo, tokenMaker, sessions, watcher := c.AbuseDetector(), c.UserRepo(), c.TokenMaker(), c.SessionStore(), c.ConfigWatcher()
+	revoker := c.TokenRevoker()
+	var stores service.StoreService // Nil serves a single store
+	if cfg.Tenancy.Enabled {
+		stores = c.StoreService()
+	}
+	if err := c.Err(); err != nil {
+		return nil, err
+	}
+
+	// Register localized validation messages before any request is bound
+	if err := validation.Init(); err != nil {
+		return nil, fmt.Errorf("failed to initialize validation: %w", err)
+	}
+
+	// Optional spec-driven request validation
+	specValidationEnabled := &atomic.Bool{}
+	specValidationEnabled.Store(cfg.Server.OpenAPIValidation)
+	specValidator, err := middleware.OpenAPIValidator([]byte(swagger.SwaggerInfo.ReadDoc()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize OpenAPI validation: %w", err)
+	}
+
+	// Request screening: IP allow/deny lists, bot protection and admin access
+	ipFilterEnabled := &atomic.Bool{}
+	ipFilterEnabled.Store(cfg.Security.IPFilter)
+	botProtectionEnabled := &atomic.Bool{}
+	botProtectionEnabled.Store(cfg.Security.BotProtection.Enabled)
+	var captchaVerifier captcha.Verifier
+	if cfg.Security.Captcha.VerifyURL != "" {
+		captchaVerifier = captcha.NewSiteVerifier(cfg.Security.Captcha.VerifyURL, cfg.Security.Captcha.Secret)
+	}
+
+	security := router.Security{
+		TrustedProxies: cfg.Security.TrustedProxies,
+		IPFilter:       middleware.Switchable(ipFilterEnabled, middleware.IPFilter(ipFilterService)),
+		SpecValidator:  middleware.Switchable(specValidationEnabled, specValidator),
+		LoginGuard:     middleware.Switchable(botProtectionEnabled, middleware.BotProtection("login", abuseDetector, captchaVerifier)),
+		RegisterGuard:  middleware.Switchable(botProtectionEnabled, middleware.BotProtection("register", abuseDetector, captchaVerifier)),
+		AdminGuard:     middleware.RequireAdmin(userRepo),
+		AuditTrail:     middleware.AuditTrail(auditService),
+		Sessions:       sessions,
+		Revoker:        revoker,
+	}
+	if stores != nil {
+		security.Tenant = middleware.Tenant(stores, cfg.Tenancy.Header)
+	}
+
+	// Live configuration: sections such as security are re-applied on file changes
+	watcher.Subscribe(func(e config.ChangeEvent) {
+		if e.Has(config.SectionServer) {
+			specValidationEnabled.Store(e.New.Server.OpenAPIValidation)
+		}
+		if e.Has(config.SectionSecurity) {
+			ipFilterEnabled.Store(e.New.Security.IPFilter)
+			botProtectionEnabled.Store(e.New.Security.BotProtection.Enabled)
+		}
+	})
+
+	s := &Server{Lifecycle: c.Lifecycle, errs: make(chan error, 2)} // One per listener
+
+	// Cached products are dropped when orders in any instance change stock
+	invalidatorCtx, stopInvalidator := context.WithCancel(context.Background())
+	invalidatorDone := make(chan struct{})
+	c.Lifecycle.Append(Hook{
+		Name: "catalog invalidator",
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(invalidatorDone)
+				catalogInvalidator.Run(invalidatorCtx)
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			stopInvalidator()
+			select {
+			case <-invalidatorDone:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	// The gRPC API and its REST mapping under /api/v2. The gRPC listener is
+	// registered first so it stops only after the HTTP server has drained.
+	var apiV2 http.Handler
+	if cfg.Server.GRPCPort != "" {
+		grpcServer := rpc.NewServer(userService, productService, orderService, stores, tokenMaker, sessions, revoker, c.Base.Reporter)
+		addr := fmt.Sprintf(":%s", cfg.Server.GRPCPort)
+		// The client connects lazily, on the first gateway call after the listener started.
+		conn, err := grpc.NewClient(fmt.Sprintf("localhost:%s", cfg.Server.GRPCPort), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gRPC gateway client: %w", err)
+		}
+		if apiV2, err = rpc.NewGateway(context.Background(), conn); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		c.Lifecycle.Append(Hook{
+			Name: "grpc server",
+			OnStart: func(context.Context) error {
+				lis, err := net.Listen("tcp", addr)
+				if err != nil {
+					return fmt.Errorf("failed to listen for gRPC on %s: %w", addr, err)
+				}
+				slog.Info("gRPC server starting", "addr", addr)
+				go func() {
+					if err := grpcServer.Serve(lis); err != nil {
+						s.errs <- err
+					}
+				}()
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				slog.Info("gRPC server draining calls")
+				defer conn.Close()
+				done := make(chan struct{})
+				go func() {
+					grpcServer.GracefulStop()
+					close(done)
+				}()
+				select {
+				case <-done:
+					return nil
+				case <-ctx.Done():
+					grpcServer.Stop()
+					return ctx.Err()
+				}
+			},
+		})
+	}
+
+	graphqlHandler, err := graph.NewHandler(catalogService, tokenMaker)
+	if err != nil {
+		return nil, err
+	}
+
+	r := router.NewRouter(router.Handlers{
+		User:                 userHandler,
+		Product:              productHandler,
+		Order:                orderHandler,
+		Admin:                adminHandler,
+		Notification:         notificationHandler,
+		Webhook:              webhookHandler,
+		Currency:             currencyHandler,
+		Translation:          translationHandler,
+		Fulfillment:          fulfillmentHandler,
+		PaymentMethod:        paymentMethodHandler,
+		Payment:              paymentHandler,
+		Promotion:            promotionHandler,
+		Coupon:               couponHandler,
+		Subscription:         subscriptionHandler,
+		Digital:              digitalHandler,
+		Inventory:            inventoryHandler,
+		Synonym:              synonymHandler,
+		Merchandising:        merchandisingHandler,
+		Review:               reviewHandler,
+		NotificationTemplate: notificationTemplateHandler,
+		Broadcast:            broadcastHandler,
+		OrderHistory:         orderHistoryHandler,
+		FailedMessage:        failedMessageHandler,
+		CustomerGroup:        customerGroupHandler,
+		Quote:                quoteHandler,
+		Purchasing:           purchasingHandler,
+		Picking:              pickingHandler,
+		Support:              supportHandler,
+		Dispute:              disputeHandler,
+		Cart:                 cartHandler,
+		PriceSchedule:        priceScheduleHandler,
+		StockHold:            stockHoldHandler,
+		OrderModification:    orderModificationHandler,
+		Address:              addressHandler,
+		ShippingRestriction:  restrictionHandler,
+		PasswordReset:        passwordResetHandler,
+		APIV2:                apiV2,
+		GraphQL:              graphqlHandler,
+	}, tokenMaker, c.Base.Reporter, security)
+	s.Engine = r.InitRoutes()
+	httpServer := &http.Server{
+		Addr:              fmt.Sprintf(":%s", cfg.Server.Port),
+		Handler:           s.Engine,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	c.Lifecycle.Append(Hook{
+		Name: "http server",
+		OnStart: func(context.Context) error {
+			slog.Info("Server starting", "addr", httpServer.Addr, "mode", cfg.Server.Mode)
+			go func() {
+				if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					s.errs <- err
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			slog.Info("Server draining connections")
+			return httpServer.Shutdown(ctx)
+		},
+	})
+	return s, nil
+}
+
+// Run starts the Lifecycle and blocks until ctx is cancelled or the listener fails,
+// then stops everything within shutdownTimeout.
+func (s *Server) Run(ctx context.Context, shutdownTimeout time.Duration) error {
+	return run(ctx, s.Lifecycle, s.errs, shutdownTimeout)
+}
+
+func run(ctx context.Context, lc *Lifecycle, errs <-chan error, shutdownTimeout time.Duration) error {
+	if err := lc.Start(ctx); err != nil {
+		return err
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+	case runErr = <-errs:
+		slog.Error("Shutting down after failure", logger.Err(runErr))
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+	return errors.Join(runErr, lc.Stop(stopCtx))
+}
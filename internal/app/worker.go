@@ -0,0 +1,112 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/worker"
+	"github.com/proyuen/go-mall/pkg/cache"
+)
+
+// Worker is the process running message consumers and scheduled jobs.
+type Worker struct {
+	Lifecycle *Lifecycle
+}
+
+// NewWorker wires the consumers and scheduled jobs from the container. They
+// start after their dependencies and stop before them.
+func NewWorker(c *Container) (*Worker, error) {
+	orderWorker := worker.NewOrderWorker(c.MQ(), c.InventoryService(), c.OrderService(), c.Notifier(), c.FailedMessageService(), c.Cache(), c.Base.Config.Worker.Orders, c.Base.Logger, c.Base.Reporter)
+	notificationWorker := worker.NewNotificationWorker(c.MQ(), c.NotificationService(), c.Cache(), c.Base.Config.Notification.MaxAttempts, c.Base.Config.Worker.Notifications, c.Base.Logger, c.Base.Reporter)
+	scheduler := worker.NewScheduler(cache.NewRedisLock(c.RedisClient(), worker.LeaderLockKey), c.Base.Logger, c.Base.Reporter)
+	orderService, stockReconciler, webhookService, currencyService := c.OrderService(), c.StockReconciler(), c.WebhookService(), c.CurrencyService()
+	couponService, fulfillmentService, subscriptionService := c.CouponService(), c.FulfillmentService(), c.SubscriptionService()
+	digitalService, popularityService, suggestionService := c.DigitalFulfillmentService(), c.PopularityService(), c.SuggestionService()
+	broadcastDispatcher, eventRelay, orderHistory := c.BroadcastDispatcher(), c.EventRelay(), c.OrderHistoryService()
+	failedMessageReplayer, cacheJanitor, disputeReminder := c.FailedMessageReplayer(), c.CacheJanitor(), c.DisputeReminder()
+	cartRecovery, priceSchedules, stockHolds := c.CartRecovery(), c.PriceScheduleService(), c.StockHoldService()
+	orderSummaryWorker := worker.NewOrderSummaryWorker(c.MQ(), orderHistory, c.FailedMessageService(), c.Base.Config.Worker.OrderSummary, c.Base.Logger, c.Base.Reporter)
+	pickTaskWorker := worker.NewPickTaskWorker(c.MQ(), c.PickingService(), c.FailedMessageService(), c.Base.Config.Worker.PickTasks, c.Base.Logger, c.Base.Reporter)
+	cartConversionWorker := worker.NewCartConversionWorker(c.MQ(), c.CartService(), c.FailedMessageService(), c.Base.Config.Worker.Carts, c.Base.Logger, c.Base.Reporter)
+	healthReporter := worker.NewHealthReporter(c.MQHealthReporter(), c.Base.Config.Worker, c.Base.Logger)
+	c.ConfigWatcher() // Hot-reloads the log level
+	if err := c.Err(); err != nil {
+		return nil, err
+	}
+
+	jobs := []worker.Job{
+		worker.NewOrderTimeoutJob(orderService, c.Base.Config.Order, c.Base.Logger),
+		worker.NewStockReconcileJob(stockReconciler, c.Base.Config.Inventory, c.Base.Logger),
+		worker.NewWebhookDispatchJob(webhookService, c.Base.Config.Webhook, c.Base.Logger),
+		worker.NewCouponRollupJob(couponService, c.Base.Config.Coupon, c.Base.Logger),
+		worker.NewBackorderAllocationJob(fulfillmentService, c.Base.Config.Order, c.Base.Logger),
+		worker.NewDigitalDeliveryJob(digitalService, c.Base.Config.Digital, c.Base.Logger),
+		worker.NewPopularityJob(popularityService, c.Base.Config.Popularity, c.Base.Logger),
+		worker.NewSuggestIndexJob(suggestionService, c.Base.Config.Suggest, c.Base.Logger),
+		worker.NewBroadcastDispatchJob(broadcastDispatcher, c.Base.Config.Notification.Broadcast, c.Base.Logger),
+		worker.NewEventRelayJob(eventRelay, c.Base.Config.Events, c.Base.Logger),
+		worker.NewOrderSummaryBackfillJob(orderHistory, c.Base.Config.Order, c.Base.Logger),
+		worker.NewCheckoutSagaJob(orderService, c.Base.Config.Order, c.Base.Logger),
+		worker.NewFailedMessageReplayJob(failedMessageReplayer, c.Base.Config.Worker, c.Base.Logger),
+		worker.NewDisputeReminderJob(disputeReminder, c.Base.Config.Payment.Disputes, c.Base.Logger),
+		worker.NewCartRecoveryJob(cartRecovery, c.Base.Config.Cart, c.Base.Logger),
+		worker.NewPriceScheduleJob(priceSchedules, c.Base.Config.Pricing, c.Base.Logger),
+		worker.NewStockHoldExpiryJob(stockHolds, c.Base.Config.Inventory, c.Base.Logger),
+	}
+	jobs = append(jobs, worker.NewCacheSweepJobs(cacheJanitor, c.Base.Config.Cache.Maintenance, c.Base.Logger)...)
+	if c.Base.Config.Currency.RatesURL != "" {
+		jobs = append(jobs, worker.NewExchangeRateJob(currencyService, c.Base.Config.Currency, c.Base.Logger))
+	}
+	if subscriptionService != nil {
+		jobs = append(jobs, worker.NewSubscriptionRenewalJob(subscriptionService, c.Base.Config.Subscription, c.Base.Logger))
+	}
+	for _, job := range jobs {
+		if err := scheduler.Register(job); err != nil {
+			return nil, err
+		}
+	}
+
+	w := &Worker{Lifecycle: c.Lifecycle}
+	c.Lifecycle.Append(Hook{
+		Name:    "order worker",
+		OnStart: func(context.Context) error { return orderWorker.Start() },
+	})
+	c.Lifecycle.Append(Hook{
+		Name:    "notification worker",
+		OnStart: func(context.Context) error { return notificationWorker.Start() },
+	})
+	c.Lifecycle.Append(Hook{
+		Name:    "event exchange",
+		OnStart: func(context.Context) error { return c.MQ().DeclareExchange(service.EventsExchange) },
+	})
+	c.Lifecycle.Append(Hook{
+		Name:    "order summary worker",
+		OnStart: func(context.Context) error { return orderSummaryWorker.Start() },
+	})
+	c.Lifecycle.Append(Hook{
+		Name:    "pick task worker",
+		OnStart: func(context.Context) error { return pickTaskWorker.Start() },
+	})
+	c.Lifecycle.Append(Hook{
+		Name:    "cart conversion worker",
+		OnStart: func(context.Context) error { return cartConversionWorker.Start() },
+	})
+	c.Lifecycle.Append(Hook{
+		Name:    "health reporter",
+		OnStart: func(context.Context) error { return healthReporter.Start() },
+		OnStop:  healthReporter.Stop,
+	})
+	c.Lifecycle.Append(Hook{
+		Name:    "scheduler",
+		OnStart: func(context.Context) error { return scheduler.Start() },
+		OnStop:  scheduler.Stop,
+	})
+	return w, nil
+}
+
+// Run starts the Lifecycle and blocks until ctx is cancelled, then stops
+// everything within shutdownTimeout.
+func (w *Worker) Run(ctx context.Context, shutdownTimeout time.Duration) error {
+	return run(ctx, w.Lifecycle, nil, shutdownTimeout)
+}
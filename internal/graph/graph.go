@@ -0,0 +1,115 @@
+// Package graph serves a read-only GraphQL view of the catalog for the web
+// storefront, which composes products, categories, reviews and the signed-in
+// user's profile on one page. Related records are fetched in one batch per
+// relation rather than one query per item; see loader.
+package graph
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/tenant"
+	"github.com/proyuen/go-mall/pkg/token"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+const (
+	// maxBodyBytes bounds the size of a request.
+	maxBodyBytes = 1 << 20
+	// maxDepth bounds how deeply selections nest, e.g. category.parent chains.
+	maxDepth = 8
+)
+
+// request is a GraphQL request in the standard JSON encoding.
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+type handler struct {
+	schema     *graphql.Schema
+	catalog    service.CatalogService
+	tokenMaker token.Maker
+}
+
+// NewHandler returns the GraphQL endpoint. It accepts POST requests with a
+// JSON body. A bearer access token is optional: anonymous callers can browse
+// the catalog, and only the me field needs a signed-in user. An invalid token
+// is rejected outright rather than treated as anonymous.
+func NewHandler(catalog service.CatalogService, tokenMaker token.Maker) (http.Handler, error) {
+	schema, err := graphql.ParseSchema(schemaSDL, &queryResolver{catalog: catalog}, graphql.MaxDepth(maxDepth))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GraphQL schema: %w", err)
+	}
+	return &handler{schema: schema, catalog: catalog, tokenMaker: tokenMaker}, nil
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "only POST is supported")
+		return
+	}
+
+	var req request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ctx := r.Context()
+	if header := r.Header.Get("Authorization"); header != "" {
+		payload, err := h.verify(ctx, header)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to verify token", logger.Err(err))
+			writeError(w, http.StatusUnauthorized, "invalid access token")
+			return
+		}
+		ctx = logger.WithUserID(auth.NewContext(ctx, payload), payload.UserID)
+	}
+	ctx = context.WithValue(ctx, loadersKey{}, newLoaders(h.catalog))
+
+	resp := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.WarnContext(ctx, "Failed to write GraphQL response", logger.Err(err))
+	}
+}
+
+// verify returns the payload of the access token in header, which must have
+// been issued for the store ctx is served for.
+func (h *handler) verify(ctx context.Context, header string) (*token.Payload, error) {
+	fields := strings.Fields(header)
+	if len(fields) != 2 || !strings.EqualFold(fields[0], "bearer") {
+		return nil, errors.New("malformed authorization header")
+	}
+	payload, err := h.tokenMaker.VerifyToken(fields[1])
+	if err != nil {
+		return nil, err
+	}
+	if storeID := tenant.StoreID(ctx); payload.StoreID != storeID {
+		return nil, fmt.Errorf("token was issued for store %d, not %d", payload.StoreID, storeID)
+	}
+	return payload, nil
+}
+
+// writeError answers a request that never reached the executor, in the same
+// shape as GraphQL errors.
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"errors": []map[string]string{{"message": message}}})
+}
@@ -0,0 +1,229 @@
+package graph
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func serve(t *testing.T, h http.Handler, method, authorization, body string) (int, response) {
+	t.Helper()
+	req := httptest.NewRequest(method, "/graphql", strings.NewReader(body))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	var resp response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return w.Code, resp
+}
+
+func query(q string) string {
+	body, _ := json.Marshal(request{Query: q})
+	return string(body)
+}
+
+func TestHandler_BatchesRelations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	catalog := mocks.NewMockCatalogService(ctrl)
+	h, err := NewHandler(catalog, mocks.NewMockMaker(ctrl))
+	require.NoError(t, err)
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	catalog.EXPECT().ListProducts(gomock.Any(), 0, 3).Return([]service.CatalogProduct{
+		{ID: 1, Name: "Mug", CategoryID: 10},
+		{ID: 2, Name: "Plate", CategoryID: 10},
+		{ID: 3, Name: "Lamp", CategoryID: 20},
+	}, nil)
+	// One call per relation however many products there are
+	catalog.EXPECT().SKUsByProductIDs(gomock.Any(), gomock.InAnyOrder([]uint64{1, 2, 3})).Return(map[uint64][]service.SKUResp{
+		1: {{ID: 11, Attributes: model.JSONB{"color": "red"}, Price: decimal.RequireFromString("4.5"), Currency: "USD", Stock: 3}},
+	}, nil)
+	catalog.EXPECT().ReviewsByProductIDs(gomock.Any(), gomock.InAnyOrder([]uint64{1, 2, 3}), maxReviewsPerProduct).Return(map[uint64][]service.ReviewResp{
+		1: {{ID: 101, UserID: 7, Rating: 5, Comment: "great", CreatedAt: created, HelpfulVotes: 3, UnhelpfulVotes: 1}, {ID: 100, UserID: 8, Rating: 4, CreatedAt: created}},
+		2: {{ID: 102, UserID: 9, Rating: 1, CreatedAt: created}},
+	}, nil)
+	catalog.EXPECT().CategoriesByIDs(gomock.Any(), gomock.InAnyOrder([]uint64{10, 20})).Return(map[uint64]service.CategoryResp{
+		10: {ID: 10, Name: "Kitchen"},
+	}, nil)
+	catalog.EXPECT().UsernamesByIDs(gomock.Any(), gomock.InAnyOrder([]uint64{7, 8, 9})).Return(map[uint64]string{7: "alice", 8: "bob"}, nil)
+
+	status, resp := serve(t, h, http.MethodPost, "", query(`{
+		products(limit: 3) {
+			id name
+			category { name }
+			skus { id attributes price currency stock }
+			reviews(first: 1) { rating comment author { username } createdAt helpfulVotes unhelpfulVotes }
+		}
+	}`))
+	require.Equal(t, http.StatusOK, status)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"products":[
+		{"id":"1","name":"Mug","category":{"name":"Kitchen"},
+		 "skus":[{"id":"11","attributes":{"color":"red"},"price":"4.50","currency":"USD","stock":3}],
+		 "reviews":[{"rating":5,"comment":"great","author":{"username":"alice"},"createdAt":"2026-01-02T03:04:05Z","helpfulVotes":3,"unhelpfulVotes":1}]},
+		{"id":"2","name":"Plate","category":{"name":"Kitchen"},"skus":[],
+		 "reviews":[{"rating":1,"comment":"","author":null,"createdAt":"2026-01-02T03:04:05Z","helpfulVotes":0,"unhelpfulVotes":0}]},
+		{"id":"3","name":"Lamp","category":null,"skus":[],"reviews":[]}
+	]}`, string(resp.Data))
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		body          string
+		mockSetup     func(catalog *mocks.MockCatalogService, maker *mocks.MockMaker)
+		wantStatus    int
+		wantData      string
+		wantError     string
+	}{
+		{
+			name:   "Product",
+			method: http.MethodPost,
+			body:   query(`{ product(id: "1") { name } }`),
+			mockSetup: func(catalog *mocks.MockCatalogService, _ *mocks.MockMaker) {
+				catalog.EXPECT().GetProduct(gomock.Any(), uint64(1)).Return(&service.CatalogProduct{ID: 1, Name: "Mug"}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantData:   `{"product":{"name":"Mug"}}`,
+		},
+		{
+			name:   "ProductNotFound",
+			method: http.MethodPost,
+			body:   query(`{ product(id: "1") { name } }`),
+			mockSetup: func(catalog *mocks.MockCatalogService, _ *mocks.MockMaker) {
+				catalog.EXPECT().GetProduct(gomock.Any(), uint64(1)).Return(nil, service.ErrProductNotFound)
+			},
+			wantStatus: http.StatusOK,
+			wantData:   `{"product":null}`,
+		},
+		{
+			name:       "InvalidLimit",
+			method:     http.MethodPost,
+			body:       query(`{ products(limit: 1000) { id } }`),
+			wantStatus: http.StatusOK,
+			wantError:  "limit must be between 1 and 100",
+		},
+		{
+			name:   "InternalErrorHidden",
+			method: http.MethodPost,
+			body:   query(`{ categories { name } }`),
+			mockSetup: func(catalog *mocks.MockCatalogService, _ *mocks.MockMaker) {
+				catalog.EXPECT().ListCategories(gomock.Any()).Return(nil, errors.New("pq: connection refused"))
+			},
+			wantStatus: http.StatusOK,
+			wantError:  "internal error",
+		},
+		{
+			name:       "MeAnonymous",
+			method:     http.MethodPost,
+			body:       query(`{ me { username } }`),
+			wantStatus: http.StatusOK,
+			wantData:   `{"me":null}`,
+			wantError:  "authentication required",
+		},
+		{
+			name:          "MeSignedIn",
+			method:        http.MethodPost,
+			authorization: "Bearer valid",
+			body:          query(`{ me { id username email } }`),
+			mockSetup: func(catalog *mocks.MockCatalogService, maker *mocks.MockMaker) {
+				maker.EXPECT().VerifyToken("valid").Return(&token.Payload{UserID: 7}, nil)
+				catalog.EXPECT().GetProfile(gomock.Any(), uint64(7)).Return(&service.UserProfile{ID: 7, Username: "alice", Email: "alice@example.com"}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantData:   `{"me":{"id":"7","username":"alice","email":"alice@example.com"}}`,
+		},
+		{
+			name:          "InvalidToken",
+			method:        http.MethodPost,
+			authorization: "Bearer forged",
+			body:          query(`{ categories { name } }`),
+			mockSetup: func(_ *mocks.MockCatalogService, maker *mocks.MockMaker) {
+				maker.EXPECT().VerifyToken("forged").Return(nil, token.ErrInvalidToken)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantError:  "invalid access token",
+		},
+		{
+			name:          "TokenOfOtherStore",
+			method:        http.MethodPost,
+			authorization: "Bearer other_store",
+			body:          query(`{ me { username } }`),
+			mockSetup: func(_ *mocks.MockCatalogService, maker *mocks.MockMaker) {
+				maker.EXPECT().VerifyToken("other_store").Return(&token.Payload{UserID: 7, StoreID: 3}, nil)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantError:  "invalid access token",
+		},
+		{
+			name:       "TooDeep",
+			method:     http.MethodPost,
+			body:       query(`{ categories { parent { parent { parent { parent { parent { parent { parent { parent { name } } } } } } } } } }`),
+			wantStatus: http.StatusOK,
+			wantError:  "exceeds max depth",
+		},
+		{
+			name:       "MalformedBody",
+			method:     http.MethodPost,
+			body:       `{"query":`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid request body",
+		},
+		{
+			name:       "GetNotAllowed",
+			method:     http.MethodGet,
+			wantStatus: http.StatusMethodNotAllowed,
+			wantError:  "only POST is supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			catalog := mocks.NewMockCatalogService(ctrl)
+			maker := mocks.NewMockMaker(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(catalog, maker)
+			}
+			h, err := NewHandler(catalog, maker)
+			require.NoError(t, err)
+
+			status, resp := serve(t, h, tt.method, tt.authorization, tt.body)
+			require.Equal(t, tt.wantStatus, status)
+			if tt.wantData != "" {
+				assert.JSONEq(t, tt.wantData, string(resp.Data))
+			}
+			if tt.wantError == "" {
+				assert.Empty(t, resp.Errors)
+				return
+			}
+			require.NotEmpty(t, resp.Errors)
+			assert.Contains(t, resp.Errors[0].Message, tt.wantError)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package graph
+
+import (
+	"context"
+	"sync"
+)
+
+// loader batches lookups by key for one request. A parent resolver queues the
+// keys its children will need as soon as it knows them; the first Load then
+// fetches every queued key in a single call and later Loads are served from
+// the results. This turns one query per list item into one per relation,
+// regardless of how many items the executor resolves concurrently.
+type loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	batches map[K]*batch[K, V] // Every key seen, with the batch that fetches it
+	pending *batch[K, V]       // Keys queued but not yet fetched
+}
+
+type batch[K comparable, V any] struct {
+	keys   []K
+	done   chan struct{}
+	values map[K]V
+	err    error
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:   fetch,
+		batches: make(map[K]*batch[K, V]),
+		pending: &batch[K, V]{done: make(chan struct{})},
+	}
+}
+
+// Queue adds keys to the next fetch. Keys already queued or fetched are skipped.
+func (l *loader[K, V]) Queue(keys ...K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.queueLocked(keys...)
+}
+
+func (l *loader[K, V]) queueLocked(keys ...K) {
+	for _, key := range keys {
+		if _, ok := l.batches[key]; ok {
+			continue
+		}
+		l.batches[key] = l.pending
+		l.pending.keys = append(l.pending.keys, key)
+	}
+}
+
+// Load returns the value for key, fetching it together with every queued key
+// unless an earlier fetch covered it. ok is false when fetch returned no value
+// for key.
+func (l *loader[K, V]) Load(ctx context.Context, key K) (value V, ok bool, err error) {
+	l.mu.Lock()
+	l.queueLocked(key)
+	b := l.batches[key]
+	start := b == l.pending
+	if start {
+		l.pending = &batch[K, V]{done: make(chan struct{})}
+	}
+	l.mu.Unlock()
+
+	if start {
+		b.values, b.err = l.fetch(ctx, b.keys)
+		close(b.done)
+	} else {
+		select {
+		case <-b.done:
+		case <-ctx.Done():
+			return value, false, ctx.Err()
+		}
+	}
+	if b.err != nil {
+		return value, false, b.err
+	}
+	value, ok = b.values[key]
+	return value, ok, nil
+}
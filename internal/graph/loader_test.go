@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoader(t *testing.T) {
+	ctx := context.Background()
+	var calls atomic.Int32
+	var batches [][]int
+	var mu sync.Mutex
+	l := newLoader(func(_ context.Context, keys []int) (map[int]string, error) {
+		calls.Add(1)
+		mu.Lock()
+		batches = append(batches, keys)
+		mu.Unlock()
+		values := make(map[int]string, len(keys))
+		for _, k := range keys {
+			if k != 404 {
+				values[k] = string(rune('a' + k))
+			}
+		}
+		return values, nil
+	})
+
+	l.Queue(1, 2, 3, 2)
+	var wg sync.WaitGroup
+	for k := 1; k <= 3; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, ok, err := l.Load(ctx, k)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, string(rune('a'+k)), v)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), calls.Load(), "queued keys are fetched in one batch")
+	assert.Equal(t, []int{1, 2, 3}, batches[0])
+
+	_, ok, err := l.Load(ctx, 404)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	_, _, err = l.Load(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load(), "fetched keys are not fetched again")
+}
+
+func TestLoader_Error(t *testing.T) {
+	fetchErr := errors.New("db down")
+	l := newLoader(func(context.Context, []int) (map[int]string, error) { return nil, fetchErr })
+	l.Queue(1, 2)
+
+	_, _, err := l.Load(context.Background(), 1)
+	assert.ErrorIs(t, err, fetchErr)
+	_, _, err = l.Load(context.Background(), 2)
+	assert.ErrorIs(t, err, fetchErr, "keys of a failed batch share its error")
+}
@@ -0,0 +1,364 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// Argument defaults are declared in schema.graphql.
+const (
+	maxPageSize = 100
+	// maxReviewsPerProduct bounds reviews(first:). Reviews are always fetched
+	// this many per product so one batch serves every first value.
+	maxReviewsPerProduct = 20
+)
+
+var (
+	// errInternal is all clients learn about a server-side failure; the cause is logged.
+	errInternal = errors.New("internal error")
+	// errUnauthenticated answers fields that need a signed-in user.
+	errUnauthenticated = errors.New("authentication required")
+)
+
+// loaders batches the relations of one request.
+type loaders struct {
+	skus       *loader[uint64, []service.SKUResp]
+	reviews    *loader[uint64, []service.ReviewResp]
+	categories *loader[uint64, service.CategoryResp]
+	usernames  *loader[uint64, string]
+}
+
+type loadersKey struct{}
+
+func newLoaders(catalog service.CatalogService) *loaders {
+	l := &loaders{}
+	l.skus = newLoader(func(ctx context.Context, ids []uint64) (map[uint64][]service.SKUResp, error) {
+		skus, err := catalog.SKUsByProductIDs(ctx, ids)
+		if err != nil {
+			return nil, internalError(ctx, "Failed to load SKUs", err, "products", len(ids))
+		}
+		return skus, nil
+	})
+	l.reviews = newLoader(func(ctx context.Context, ids []uint64) (map[uint64][]service.ReviewResp, error) {
+		reviews, err := catalog.ReviewsByProductIDs(ctx, ids, maxReviewsPerProduct)
+		if err != nil {
+			return nil, internalError(ctx, "Failed to load reviews", err, "products", len(ids))
+		}
+		for _, productReviews := range reviews {
+			for _, review := range productReviews {
+				l.usernames.Queue(review.UserID)
+			}
+		}
+		return reviews, nil
+	})
+	l.categories = newLoader(func(ctx context.Context, ids []uint64) (map[uint64]service.CategoryResp, error) {
+		categories, err := catalog.CategoriesByIDs(ctx, ids)
+		if err != nil {
+			return nil, internalError(ctx, "Failed to load categories", err, "categories", len(ids))
+		}
+		return categories, nil
+	})
+	l.usernames = newLoader(func(ctx context.Context, ids []uint64) (map[uint64]string, error) {
+		usernames, err := catalog.UsernamesByIDs(ctx, ids)
+		if err != nil {
+			return nil, internalError(ctx, "Failed to load review authors", err, "users", len(ids))
+		}
+		return usernames, nil
+	})
+	return l
+}
+
+func loadersFromContext(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// internalError logs err and returns the error clients see instead.
+func internalError(ctx context.Context, msg string, err error, args ...any) error {
+	slog.ErrorContext(ctx, msg, append(args, logger.Err(err))...)
+	return errInternal
+}
+
+// queryResolver resolves the fields of Query.
+type queryResolver struct {
+	catalog service.CatalogService
+}
+
+func (r *queryResolver) Products(ctx context.Context, args struct {
+	Offset int32
+	Limit  int32
+}) ([]*productResolver, error) {
+	offset, limit := int(args.Offset), int(args.Limit)
+	if offset < 0 {
+		return nil, errors.New("offset must not be negative")
+	}
+	if limit < 1 || limit > maxPageSize {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+	}
+
+	products, err := r.catalog.ListProducts(ctx, offset, limit)
+	if err != nil {
+		return nil, internalError(ctx, "Failed to list products", err)
+	}
+	return newProductResolvers(ctx, products...), nil
+}
+
+func (r *queryResolver) Product(ctx context.Context, args struct{ ID graphql.ID }) (*productResolver, error) {
+	id, err := strconv.ParseUint(string(args.ID), 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid product id")
+	}
+	product, err := r.catalog.GetProduct(ctx, id)
+	if err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			return nil, nil
+		}
+		return nil, internalError(ctx, "Failed to get product", err, "spu_id", id)
+	}
+	return newProductResolvers(ctx, *product)[0], nil
+}
+
+func (r *queryResolver) Categories(ctx context.Context) ([]*categoryResolver, error) {
+	categories, err := r.catalog.ListCategories(ctx)
+	if err != nil {
+		return nil, internalError(ctx, "Failed to list categories", err)
+	}
+	resolvers := make([]*categoryResolver, 0, len(categories))
+	for _, category := range categories {
+		resolvers = append(resolvers, newCategoryResolver(ctx, category))
+	}
+	return resolvers, nil
+}
+
+func (r *queryResolver) Me(ctx context.Context) (*profileResolver, error) {
+	payload, ok := auth.PayloadFromContext(ctx)
+	if !ok {
+		return nil, errUnauthenticated
+	}
+	profile, err := r.catalog.GetProfile(ctx, payload.UserID)
+	if err != nil {
+		return nil, internalError(ctx, "Failed to get profile", err)
+	}
+	return &profileResolver{profile: profile}, nil
+}
+
+// newProductResolvers queues the relations of products so that resolving
+// them for every product takes one batch each.
+func newProductResolvers(ctx context.Context, products ...service.CatalogProduct) []*productResolver {
+	l := loadersFromContext(ctx)
+	resolvers := make([]*productResolver, 0, len(products))
+	for _, product := range products {
+		l.skus.Queue(product.ID)
+		l.reviews.Queue(product.ID)
+		if product.CategoryID != 0 {
+			l.categories.Queue(product.CategoryID)
+		}
+		resolvers = append(resolvers, &productResolver{product: product})
+	}
+	return resolvers
+}
+
+type productResolver struct {
+	product service.CatalogProduct
+}
+
+func (r *productResolver) ID() graphql.ID {
+	return formatID(r.product.ID)
+}
+
+func (r *productResolver) Name() string {
+	return r.product.Name
+}
+
+func (r *productResolver) Description() string {
+	return r.product.Description
+}
+
+func (r *productResolver) Category(ctx context.Context) (*categoryResolver, error) {
+	return loadCategory(ctx, r.product.CategoryID)
+}
+
+func (r *productResolver) SKUs(ctx context.Context) ([]*skuResolver, error) {
+	skus, _, err := loadersFromContext(ctx).skus.Load(ctx, r.product.ID)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*skuResolver, 0, len(skus))
+	for _, sku := range skus {
+		resolvers = append(resolvers, &skuResolver{sku: sku})
+	}
+	return resolvers, nil
+}
+
+func (r *productResolver) Reviews(ctx context.Context, args struct{ First int32 }) ([]*reviewResolver, error) {
+	first := int(args.First)
+	if first < 1 || first > maxReviewsPerProduct {
+		return nil, fmt.Errorf("first must be between 1 and %d", maxReviewsPerProduct)
+	}
+
+	reviews, _, err := loadersFromContext(ctx).reviews.Load(ctx, r.product.ID)
+	if err != nil {
+		return nil, err
+	}
+	reviews = reviews[:min(first, len(reviews))]
+	resolvers := make([]*reviewResolver, 0, len(reviews))
+	for _, review := range reviews {
+		resolvers = append(resolvers, &reviewResolver{review: review})
+	}
+	return resolvers, nil
+}
+
+type skuResolver struct {
+	sku service.SKUResp
+}
+
+func (r *skuResolver) ID() graphql.ID {
+	return formatID(r.sku.ID)
+}
+
+func (r *skuResolver) Attributes() JSON {
+	return JSON{Value: r.sku.Attributes}
+}
+
+func (r *skuResolver) Price() string {
+	return r.sku.Price.StringFixed(2)
+}
+
+func (r *skuResolver) Currency() string {
+	return r.sku.Currency
+}
+
+func (r *skuResolver) Stock() int32 {
+	return int32(r.sku.Stock)
+}
+
+// newCategoryResolver queues the parent so that resolving every parent in a
+// list takes one batch.
+func newCategoryResolver(ctx context.Context, category service.CategoryResp) *categoryResolver {
+	if category.ParentID != 0 {
+		loadersFromContext(ctx).categories.Queue(category.ParentID)
+	}
+	return &categoryResolver{category: category}
+}
+
+// loadCategory returns nil for ID 0 and for categories that no longer exist.
+func loadCategory(ctx context.Context, id uint64) (*categoryResolver, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	category, ok, err := loadersFromContext(ctx).categories.Load(ctx, id)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return newCategoryResolver(ctx, category), nil
+}
+
+type categoryResolver struct {
+	category service.CategoryResp
+}
+
+func (r *categoryResolver) ID() graphql.ID {
+	return formatID(r.category.ID)
+}
+
+func (r *categoryResolver) Name() string {
+	return r.category.Name
+}
+
+func (r *categoryResolver) Parent(ctx context.Context) (*categoryResolver, error) {
+	return loadCategory(ctx, r.category.ParentID)
+}
+
+type reviewResolver struct {
+	review service.ReviewResp
+}
+
+func (r *reviewResolver) ID() graphql.ID {
+	return formatID(r.review.ID)
+}
+
+func (r *reviewResolver) Rating() int32 {
+	return int32(r.review.Rating)
+}
+
+func (r *reviewResolver) Comment() string {
+	return r.review.Comment
+}
+
+// Author is nil when the reviewer's account no longer exists.
+func (r *reviewResolver) Author(ctx context.Context) (*authorResolver, error) {
+	username, ok, err := loadersFromContext(ctx).usernames.Load(ctx, r.review.UserID)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &authorResolver{username: username}, nil
+}
+
+func (r *reviewResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.review.CreatedAt}
+}
+
+func (r *reviewResolver) HelpfulVotes() int32 {
+	return int32(r.review.HelpfulVotes)
+}
+
+func (r *reviewResolver) UnhelpfulVotes() int32 {
+	return int32(r.review.UnhelpfulVotes)
+}
+
+type authorResolver struct {
+	username string
+}
+
+func (r *authorResolver) Username() string {
+	return r.username
+}
+
+type profileResolver struct {
+	profile *service.UserProfile
+}
+
+func (r *profileResolver) ID() graphql.ID {
+	return formatID(r.profile.ID)
+}
+
+func (r *profileResolver) Username() string {
+	return r.profile.Username
+}
+
+func (r *profileResolver) Email() string {
+	return r.profile.Email
+}
+
+func formatID(id uint64) graphql.ID {
+	return graphql.ID(strconv.FormatUint(id, 10))
+}
+
+// JSON is an output-only scalar for free-form objects such as SKU attributes.
+type JSON struct {
+	Value model.JSONB
+}
+
+func (JSON) ImplementsGraphQLType(name string) bool {
+	return name == "JSON"
+}
+
+func (*JSON) UnmarshalGraphQL(any) error {
+	return errors.New("JSON is an output-only type")
+}
+
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if j.Value == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(j.Value)
+}
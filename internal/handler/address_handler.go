@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// AddressHandler defines the HTTP handlers for checking shipping addresses
+// before they are used.
+type AddressHandler struct {
+	addressService service.AddressService
+}
+
+// NewAddressHandler creates a new AddressHandler instance.
+func NewAddressHandler(addressService service.AddressService) *AddressHandler {
+	return &AddressHandler{addressService: addressService}
+}
+
+// ValidateAddressRequest defines the request body for checking a shipping
+// address.
+type ValidateAddressRequest struct {
+	// Region is where the address is: an ISO 3166-1 alpha-2 country or ISO
+	// 3166-2 subdivision. Without it only line1 and city are required.
+	Region  string         `json:"region" binding:"omitempty,iso3166_1_alpha2|iso3166_2" example:"DE"`
+	Address AddressRequest `json:"address"`
+}
+
+// ValidateAddress checks a shipping address the way checkout does, so that
+// customers can fix it before placing an order.
+//
+//	@Summary		Validate a shipping address
+//	@Description	The address is checked against the format of its country and returned normalized, e.g. with the postal code written the way the country writes it. Warnings, such as that nothing is known to be delivered there, do not reject it.
+//	@Tags			addresses
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		ValidateAddressRequest	true	"Address payload"
+//	@Success		200		{object}	Response{data=service.AddressValidationResp}
+//	@Failure		400		{object}	ErrorResponse	"Address does not have the format of its country; metadata maps each field to what is wrong with it"
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/addresses/validate [post]
+func (h *AddressHandler) ValidateAddress(c *gin.Context) {
+	var req ValidateAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.addressService.Validate(c.Request.Context(), req.Region, req.Address.address())
+	if errors.Is(err, service.ErrInvalidAddress) {
+		respondError(c, err)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to validate address", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+}
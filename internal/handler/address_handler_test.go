@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAddressHandler_ValidateAddress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	addr := model.Address{Line1: "10 Downing St", City: "London", PostalCode: "sw1a2aa"}
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockAddressService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"region":"GB","address":{"line1":"10 Downing St","city":"London","postal_code":"sw1a2aa"}}`,
+			mockSetup: func(mockService *mocks.MockAddressService) {
+				mockService.EXPECT().Validate(gomock.Any(), "GB", addr).Return(&service.AddressValidationResp{
+					Address:     model.Address{Line1: "10 Downing St", City: "London", PostalCode: "SW1A 2AA"},
+					Deliverable: true,
+					Warnings:    []service.AddressWarning{},
+				}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"postal_code":"SW1A 2AA"`,
+		},
+		{
+			name:       "NotARegion",
+			reqBody:    `{"region":"Britain","address":{"line1":"10 Downing St","city":"London"}}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"field":"region"`,
+		},
+		{
+			name:    "Invalid",
+			reqBody: `{"region":"GB","address":{"line1":"10 Downing St","city":"London","postal_code":"sw1a2aa"}}`,
+			mockSetup: func(mockService *mocks.MockAddressService) {
+				mockService.EXPECT().Validate(gomock.Any(), "GB", addr).Return(nil, service.ErrInvalidAddress.With("postal_code", "must be written like SW1A 1AA"))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"metadata":{"postal_code":"must be written like SW1A 1AA"}`,
+		},
+		{
+			name:    "ServiceError",
+			reqBody: `{"region":"GB","address":{"line1":"10 Downing St","city":"London","postal_code":"sw1a2aa"}}`,
+			mockSetup: func(mockService *mocks.MockAddressService) {
+				mockService.EXPECT().Validate(gomock.Any(), "GB", addr).Return(nil, errors.New("boom"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockAddressService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewAddressHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			var err error
+			c.Request, err = http.NewRequest(http.MethodPost, "/addresses/validate", bytes.NewBufferString(tt.reqBody))
+			require.NoError(t, err)
+
+			handler.ValidateAddress(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
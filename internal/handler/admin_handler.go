@@ -0,0 +1,326 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// AdminHandler defines the HTTP handlers for back-office operations.
+type AdminHandler struct {
+	ipFilterService  service.IPFilterService
+	auditService     service.AuditService
+	dashboardService service.DashboardService
+	logLevelService  service.LogLevelService
+	diagnostics      service.DiagnosticsService
+}
+
+// NewAdminHandler creates a new AdminHandler instance.
+func NewAdminHandler(ipFilterService service.IPFilterService, auditService service.AuditService, dashboardService service.DashboardService, logLevelService service.LogLevelService, diagnostics service.DiagnosticsService) *AdminHandler {
+	return &AdminHandler{ipFilterService: ipFilterService, auditService: auditService, dashboardService: dashboardService, logLevelService: logLevelService, diagnostics: diagnostics}
+}
+
+// Dashboard returns today's order activity and the SKUs that need restocking
+// in one response. It may be up to 30 seconds stale; generated_at tells when
+// it was computed.
+//
+//	@Summary	Get the admin dashboard
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Success	200	{object}	Response{data=service.DashboardResp}
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/dashboard [get]
+func (h *AdminHandler) Dashboard(c *gin.Context) {
+	resp, err := h.dashboardService.Get(c.Request.Context())
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to build admin dashboard", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+}
+
+// IPRuleRequest defines the request body for creating or replacing an IP rule.
+type IPRuleRequest struct {
+	CIDR   string `json:"cidr" binding:"required" example:"203.0.113.0/24"`
+	Action string `json:"action" binding:"required,oneof=allow deny" example:"deny"`
+	Reason string `json:"reason" binding:"max=200" example:"credential stuffing"`
+}
+
+// ListIPRules returns every IP allow/deny rule.
+//
+//	@Summary	List IP allow/deny rules
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Success	200	{object}	Response{data=[]service.IPRule}
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/ip-rules [get]
+func (h *AdminHandler) ListIPRules(c *gin.Context) {
+	rules, err := h.ipFilterService.ListRules(c.Request.Context())
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list ip rules", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": rules})
+}
+
+// PutIPRule creates an IP rule, replacing any existing rule for the same CIDR.
+//
+//	@Summary	Create or replace an IP allow/deny rule
+//	@Tags		admin
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		request	body		IPRuleRequest	true	"Rule payload; a single IP is stored as a /32 or /128 prefix"
+//	@Success	200		{object}	Response{data=service.IPRule}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/ip-rules [post]
+func (h *AdminHandler) PutIPRule(c *gin.Context) {
+	var req IPRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	rule, err := h.ipFilterService.PutRule(c.Request.Context(), &service.IPRuleReq{
+		CIDR:   req.CIDR,
+		Action: req.Action,
+		Reason: req.Reason,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCIDR) || errors.Is(err, service.ErrInvalidIPAction) {
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to save ip rule", "cidr", req.CIDR, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "IP rule saved", "data": rule})
+}
+
+// DeleteIPRule removes the rule for a CIDR.
+//
+//	@Summary	Delete an IP allow/deny rule
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		cidr	query		string	true	"IP address or CIDR of the rule"
+//	@Success	200		{object}	Response
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	404		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/ip-rules [delete]
+func (h *AdminHandler) DeleteIPRule(c *gin.Context) {
+	cidr := c.Query("cidr")
+	if cidr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "cidr is required"})
+		return
+	}
+
+	if err := h.ipFilterService.RemoveRule(c.Request.Context(), cidr); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCIDR):
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+		case errors.Is(err, service.ErrIPRuleNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+		default:
+			slog.ErrorContext(c.Request.Context(), "Failed to delete ip rule", "cidr", cidr, logger.Err(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "IP rule deleted"})
+}
+
+// AuditLogQuery defines the filters for listing audit records.
+type AuditLogQuery struct {
+	ActorID    uint64    `form:"actor_id" example:"1735000000000000000"`
+	Action     string    `form:"action" binding:"max=64" example:"ip_rule.put"`
+	Resource   string    `form:"resource" binding:"max=64" example:"ip_rule"`
+	ResourceID string    `form:"resource_id" binding:"max=128" example:"203.0.113.0/24"`
+	From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00" example:"2026-01-01T00:00:00Z"`
+	To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00" example:"2026-02-01T00:00:00Z"`
+	Offset     int       `form:"offset" binding:"min=0"`
+	Limit      int       `form:"limit" binding:"min=0,max=100"`
+}
+
+// ListAuditLogs returns audit records of admin mutations, newest first.
+//
+//	@Summary	List audit log records
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		query	query		AuditLogQuery	false	"Filters; times are RFC 3339, from inclusive and to exclusive"
+//	@Success	200		{object}	Response{data=service.AuditLogListResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/audit-logs [get]
+func (h *AdminHandler) ListAuditLogs(c *gin.Context) {
+	var query AuditLogQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "from must be before to"})
+		return
+	}
+
+	resp, err := h.auditService.List(c.Request.Context(), &service.AuditLogListReq{
+		ActorID:    query.ActorID,
+		Action:     query.Action,
+		Resource:   query.Resource,
+		ResourceID: query.ResourceID,
+		From:       query.From,
+		To:         query.To,
+		Offset:     query.Offset,
+		Limit:      query.Limit,
+	})
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list audit logs", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+}
+
+// LogLevelRequest defines the request body for changing the log level.
+type LogLevelRequest struct {
+	Level string `json:"level" binding:"required" example:"debug"` // debug, info, warn or error
+}
+
+// GetLogLevel returns the level the instance serving the request logs at.
+//
+//	@Summary	Get the log level
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Success	200	{object}	Response{data=service.LogLevelResp}
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Router		/admin/loglevel [get]
+func (h *AdminHandler) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": h.logLevelService.Get(c.Request.Context())})
+}
+
+// PutLogLevel changes the log level without a restart.
+//
+//	@Summary		Change the log level
+//	@Description	Applies to the instance serving the request only. It lasts until log.level is reloaded from the config, when the config file changes or the process receives SIGHUP.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		LogLevelRequest	true	"New level"
+//	@Success		200		{object}	Response{data=service.LogLevelResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/loglevel [put]
+func (h *AdminHandler) PutLogLevel(c *gin.Context) {
+	var req LogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.logLevelService.Set(c.Request.Context(), req.Level)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidLogLevel) {
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to set log level", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Log level changed", "data": resp})
+}
+
+// CacheDiagnostics returns the circuit breaker of the Redis cache and the
+// locks held in Redis with their TTLs.
+//
+//	@Summary		Inspect Redis
+//	@Description	The circuit breaker is that of the instance serving the request, named by host. It is reported even when Redis cannot be reached; locks_error then tells why the locks are missing. Requests are rate limited per admin and audited.
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	Response{data=service.CacheDiagnosticsResp}
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		429	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/diagnostics/cache [get]
+func (h *AdminHandler) CacheDiagnostics(c *gin.Context) {
+	resp, err := h.diagnostics.Cache(c.Request.Context())
+	if err != nil {
+		respondDiagnosticsError(c, "Failed to get cache diagnostics", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+}
+
+// MQDiagnostics returns the queue depths and recent broker reconnects as
+// reported by every running worker.
+//
+//	@Summary		Inspect RabbitMQ
+//	@Description	Each worker reports its connection, recent reconnects and the depth of its queues every worker.health_report_interval; a worker that stopped drops out after three intervals. Requests are rate limited per admin and audited.
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	Response{data=service.MQDiagnosticsResp}
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		429	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/diagnostics/mq [get]
+func (h *AdminHandler) MQDiagnostics(c *gin.Context) {
+	resp, err := h.diagnostics.MQ(c.Request.Context())
+	if err != nil {
+		respondDiagnosticsError(c, "Failed to get broker diagnostics", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+}
+
+// respondDiagnosticsError maps the errors of the diagnostics service to
+// responses, logging unexpected ones with msg.
+func respondDiagnosticsError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, auth.ErrUnauthenticated):
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+	case errors.Is(err, service.ErrDiagnosticsRateLimited):
+		c.JSON(http.StatusTooManyRequests, gin.H{"code": http.StatusTooManyRequests, "message": err.Error()})
+	default:
+		slog.ErrorContext(c.Request.Context(), msg, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
@@ -0,0 +1,379 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAdminHandler_PutIPRule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    gin.H
+		mockSetup  func(mockService *mocks.MockIPFilterService)
+		wantStatus int
+	}{
+		{
+			name:    "Success",
+			reqBody: gin.H{"cidr": "203.0.113.7", "action": "deny", "reason": "credential stuffing"},
+			mockSetup: func(mockService *mocks.MockIPFilterService) {
+				mockService.EXPECT().
+					PutRule(gomock.Any(), &service.IPRuleReq{CIDR: "203.0.113.7", Action: "deny", Reason: "credential stuffing"}).
+					Return(&service.IPRule{CIDR: "203.0.113.7/32", Action: "deny", CreatedAt: time.Now()}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "InvalidAction",
+			reqBody:    gin.H{"cidr": "203.0.113.7", "action": "block"},
+			mockSetup:  func(mockService *mocks.MockIPFilterService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "InvalidCIDR",
+			reqBody: gin.H{"cidr": "not-an-ip", "action": "deny"},
+			mockSetup: func(mockService *mocks.MockIPFilterService) {
+				mockService.EXPECT().PutRule(gomock.Any(), gomock.Any()).Return(nil, service.ErrInvalidCIDR)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "ServiceError",
+			reqBody: gin.H{"cidr": "203.0.113.0/24", "action": "allow"},
+			mockSetup: func(mockService *mocks.MockIPFilterService) {
+				mockService.EXPECT().PutRule(gomock.Any(), gomock.Any()).Return(nil, errors.New("redis down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockIPFilterService(ctrl)
+			tt.mockSetup(mockService)
+			handler := NewAdminHandler(mockService, nil, nil, nil, nil)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			jsonBody, err := json.Marshal(tt.reqBody)
+			require.NoError(t, err)
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/v1/admin/ip-rules", bytes.NewBuffer(jsonBody))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			handler.PutIPRule(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestAdminHandler_DeleteIPRule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		query      string
+		mockSetup  func(mockService *mocks.MockIPFilterService)
+		wantStatus int
+	}{
+		{
+			name:  "Success",
+			query: "?cidr=203.0.113.0/24",
+			mockSetup: func(mockService *mocks.MockIPFilterService) {
+				mockService.EXPECT().RemoveRule(gomock.Any(), "203.0.113.0/24").Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "MissingCIDR",
+			query:      "",
+			mockSetup:  func(mockService *mocks.MockIPFilterService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "NotFound",
+			query: "?cidr=198.51.100.1",
+			mockSetup: func(mockService *mocks.MockIPFilterService) {
+				mockService.EXPECT().RemoveRule(gomock.Any(), "198.51.100.1").Return(service.ErrIPRuleNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockIPFilterService(ctrl)
+			tt.mockSetup(mockService)
+			handler := NewAdminHandler(mockService, nil, nil, nil, nil)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodDelete, "/api/v1/admin/ip-rules"+tt.query, nil)
+
+			handler.DeleteIPRule(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestAdminHandler_ListAuditLogs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		query      string
+		mockSetup  func(mockService *mocks.MockAuditService)
+		wantStatus int
+	}{
+		{
+			name:  "Success",
+			query: "?resource=ip_rule&actor_id=42&from=2026-01-01T00:00:00Z&limit=50",
+			mockSetup: func(mockService *mocks.MockAuditService) {
+				mockService.EXPECT().List(gomock.Any(), &service.AuditLogListReq{
+					ActorID:  42,
+					Resource: "ip_rule",
+					From:     time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+					Limit:    50,
+				}).Return(&service.AuditLogListResp{Items: []model.AuditLog{}, Total: 0}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "LimitTooLarge",
+			query:      "?limit=1000",
+			mockSetup:  func(mockService *mocks.MockAuditService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "InvalidTime",
+			query:      "?from=yesterday",
+			mockSetup:  func(mockService *mocks.MockAuditService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "EmptyRange",
+			query:      "?from=2026-02-01T00:00:00Z&to=2026-01-01T00:00:00Z",
+			mockSetup:  func(mockService *mocks.MockAuditService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "ServiceError",
+			query: "",
+			mockSetup: func(mockService *mocks.MockAuditService) {
+				mockService.EXPECT().List(gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockAuditService(ctrl)
+			tt.mockSetup(mockService)
+			handler := NewAdminHandler(nil, mockService, nil, nil, nil)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodGet, "/api/v1/admin/audit-logs"+tt.query, nil)
+
+			handler.ListAuditLogs(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestAdminHandler_Dashboard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		mockSetup  func(mockService *mocks.MockDashboardService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "Success",
+			mockSetup: func(mockService *mocks.MockDashboardService) {
+				mockService.EXPECT().Get(gomock.Any()).Return(&service.DashboardResp{
+					Orders:   service.DashboardOrders{Total: 3, Paid: 3},
+					LowStock: service.DashboardLowStock{Threshold: 10, SKUs: []service.LowStockSKU{{SKUID: 11, ProductID: 1, ProductName: "Mug"}}},
+				}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"skus":[{"sku_id":"11","product_id":"1","product_name":"Mug","stock":0}]`,
+		},
+		{
+			name: "ServiceError",
+			mockSetup: func(mockService *mocks.MockDashboardService) {
+				mockService.EXPECT().Get(gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockDashboardService(ctrl)
+			tt.mockSetup(mockService)
+			handler := NewAdminHandler(nil, nil, mockService, nil, nil)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			var err error
+			c.Request, err = http.NewRequest(http.MethodGet, "/admin/dashboard", nil)
+			require.NoError(t, err)
+
+			handler.Dashboard(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestAdminHandler_PutLogLevel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockLogLevelService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"level":"debug"}`,
+			mockSetup: func(mockService *mocks.MockLogLevelService) {
+				mockService.EXPECT().Set(gomock.Any(), "debug").Return(&service.LogLevelResp{Level: "debug"}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"level":"debug"`,
+		},
+		{
+			name:       "MissingLevel",
+			reqBody:    `{}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"rule":"required"`,
+		},
+		{
+			name:    "InvalidLevel",
+			reqBody: `{"level":"verbose"}`,
+			mockSetup: func(mockService *mocks.MockLogLevelService) {
+				mockService.EXPECT().Set(gomock.Any(), "verbose").Return(nil, fmt.Errorf("%w: %q", service.ErrInvalidLogLevel, "verbose"))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "log level must be debug, info, warn or error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockLogLevelService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewAdminHandler(nil, nil, nil, mockService, nil)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			var err error
+			c.Request, err = http.NewRequest(http.MethodPut, "/admin/loglevel", bytes.NewBufferString(tt.reqBody))
+			require.NoError(t, err)
+
+			handler.PutLogLevel(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestAdminHandler_CacheDiagnostics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		mockSetup  func(mockService *mocks.MockDiagnosticsService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "Success",
+			mockSetup: func(mockService *mocks.MockDiagnosticsService) {
+				mockService.EXPECT().Cache(gomock.Any()).Return(&service.CacheDiagnosticsResp{
+					Host:    "api-1",
+					Breaker: &service.BreakerResp{Name: "redis-cache", State: "open"},
+					Locks:   []service.LockResp{{Key: "lock:payment:order:42", TTLMs: 30000}},
+				}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"state":"open"`,
+		},
+		{
+			name: "RateLimited",
+			mockSetup: func(mockService *mocks.MockDiagnosticsService) {
+				mockService.EXPECT().Cache(gomock.Any()).Return(nil, service.ErrDiagnosticsRateLimited)
+			},
+			wantStatus: http.StatusTooManyRequests,
+			wantBody:   "too many diagnostics requests",
+		},
+		{
+			name: "Unauthenticated",
+			mockSetup: func(mockService *mocks.MockDiagnosticsService) {
+				mockService.EXPECT().Cache(gomock.Any()).Return(nil, auth.ErrUnauthenticated)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "ServiceError",
+			mockSetup: func(mockService *mocks.MockDiagnosticsService) {
+				mockService.EXPECT().Cache(gomock.Any()).Return(nil, errors.New("boom"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockDiagnosticsService(ctrl)
+			tt.mockSetup(mockService)
+			handler := NewAdminHandler(nil, nil, nil, nil, mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/diagnostics/cache", nil)
+
+			handler.CacheDiagnostics(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
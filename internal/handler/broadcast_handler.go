@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// BroadcastHandler defines the HTTP handlers for admin broadcasts.
+type BroadcastHandler struct {
+	broadcastService service.BroadcastService
+}
+
+// NewBroadcastHandler creates a new BroadcastHandler instance.
+func NewBroadcastHandler(broadcastService service.BroadcastService) *BroadcastHandler {
+	return &BroadcastHandler{broadcastService: broadcastService}
+}
+
+// BroadcastSegmentRequest selects the customers of a broadcast. Filters left
+// out match everyone; orders count when paid, and only those placed between
+// ordered_after and ordered_before if set.
+type BroadcastSegmentRequest struct {
+	SignedUpAfter  *time.Time `json:"signed_up_after" example:"2026-01-01T00:00:00Z"`
+	SignedUpBefore *time.Time `json:"signed_up_before"`
+	MinOrders      int        `json:"min_orders" binding:"min=0" example:"1"`
+	MaxOrders      *int       `json:"max_orders" binding:"omitempty,min=0"` // 0 selects customers who never ordered
+	OrderedAfter   *time.Time `json:"ordered_after"`
+	OrderedBefore  *time.Time `json:"ordered_before"`
+}
+
+// CreateBroadcastRequest defines the request body for sending a broadcast.
+type CreateBroadcastRequest struct {
+	Name     string                  `json:"name" binding:"required,max=100" example:"Spring sale"`
+	Subject  string                  `json:"subject" binding:"required,max=255" example:"20% off this weekend"` // Email subject and push title
+	Message  string                  `json:"message" binding:"required,max=1000" example:"Everything in the shop is 20% off until Sunday."`
+	URL      string                  `json:"url" binding:"omitempty,url,max=500" example:"https://shop.example.com/sale"`
+	Channels []string                `json:"channels" binding:"required,min=1,dive,oneof=email sms push inbox" example:"email,inbox"`
+	Segment  BroadcastSegmentRequest `json:"segment"`
+}
+
+// BroadcastQuery defines the paging of the broadcast list.
+type BroadcastQuery struct {
+	Offset int `form:"offset" binding:"min=0"`
+	Limit  int `form:"limit" binding:"min=0,max=100"`
+}
+
+func (r *BroadcastSegmentRequest) segment() model.BroadcastSegment {
+	return model.BroadcastSegment{
+		SignedUpAfter:  r.SignedUpAfter,
+		SignedUpBefore: r.SignedUpBefore,
+		MinOrders:      r.MinOrders,
+		MaxOrders:      r.MaxOrders,
+		OrderedAfter:   r.OrderedAfter,
+		OrderedBefore:  r.OrderedBefore,
+	}
+}
+
+// CreateBroadcast queues a message to a segment of the store's customers.
+//
+//	@Summary		Send a broadcast
+//	@Description	The broadcast is fanned out by the worker at notification.broadcast.rate deliveries per second. Customers receive it only on the channels they turned promotions on for.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		CreateBroadcastRequest	true	"Broadcast payload"
+//	@Success		202		{object}	Response{data=service.BroadcastResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/broadcasts [post]
+func (h *BroadcastHandler) CreateBroadcast(c *gin.Context) {
+	adminID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	var req CreateBroadcastRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	channels := make([]notification.Channel, len(req.Channels))
+	for i, channel := range req.Channels {
+		channels[i] = notification.Channel(channel)
+	}
+	resp, err := h.broadcastService.Create(c.Request.Context(), adminID, &service.BroadcastCreateReq{
+		Name:     req.Name,
+		Subject:  req.Subject,
+		Message:  req.Message,
+		URL:      req.URL,
+		Channels: channels,
+		Segment:  req.Segment.segment(),
+	})
+	if err != nil {
+		respondBroadcastError(c, "Failed to create broadcast", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"code": http.StatusAccepted, "message": "Broadcast queued", "data": resp})
+}
+
+// CountBroadcastAudience counts the customers of the store in a segment.
+//
+//	@Summary		Count the audience of a broadcast
+//	@Description	Counts every customer in the segment; those without promotions turned on for a channel are left out when the broadcast is sent.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		BroadcastSegmentRequest	true	"Segment"
+//	@Success		200		{object}	Response{data=map[string]int64}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/broadcasts/audience [post]
+func (h *BroadcastHandler) CountBroadcastAudience(c *gin.Context) {
+	var req BroadcastSegmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	segment := req.segment()
+	count, err := h.broadcastService.Audience(c.Request.Context(), &segment)
+	if err != nil {
+		respondBroadcastError(c, "Failed to count broadcast audience", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": gin.H{"customers": count}})
+}
+
+// ListBroadcasts returns the store's broadcasts, newest first.
+//
+//	@Summary	List broadcasts
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		query	query		BroadcastQuery	false	"Paging"
+//	@Success	200		{object}	Response{data=service.BroadcastListResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/broadcasts [get]
+func (h *BroadcastHandler) ListBroadcasts(c *gin.Context) {
+	var query BroadcastQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.broadcastService.List(c.Request.Context(), query.Offset, query.Limit)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list broadcasts", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+}
+
+// GetBroadcast returns a broadcast and how far it was fanned out.
+//
+//	@Summary	Get a broadcast
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Broadcast ID"
+//	@Success	200	{object}	Response{data=service.BroadcastResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/broadcasts/{id} [get]
+func (h *BroadcastHandler) GetBroadcast(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "broadcast")
+	if !ok {
+		return
+	}
+
+	resp, err := h.broadcastService.Get(c.Request.Context(), id)
+	if err != nil {
+		respondBroadcastError(c, "Failed to get broadcast", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+}
+
+// respondBroadcastError maps broadcast service errors to HTTP responses.
+func respondBroadcastError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidBroadcast):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+	case errors.Is(err, service.ErrBroadcastNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+	default:
+		slog.ErrorContext(c.Request.Context(), msg, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
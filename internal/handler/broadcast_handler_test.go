@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestBroadcastHandler_CreateBroadcast(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	signedUpAfter := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	maxOrders := 0
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockBroadcastService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"name":"Win back","subject":"We miss you","message":"Come back for 10% off.","channels":["email","inbox"],"segment":{"signed_up_after":"2026-01-01T00:00:00Z","max_orders":0}}`,
+			mockSetup: func(mockService *mocks.MockBroadcastService) {
+				mockService.EXPECT().Create(gomock.Any(), uint64(1), &service.BroadcastCreateReq{
+					Name:     "Win back",
+					Subject:  "We miss you",
+					Message:  "Come back for 10% off.",
+					Channels: []notification.Channel{notification.ChannelEmail, notification.ChannelInbox},
+					Segment:  model.BroadcastSegment{SignedUpAfter: &signedUpAfter, MaxOrders: &maxOrders},
+				}).Return(&service.BroadcastResp{ID: 7, Status: model.BroadcastStatusQueued}, nil)
+			},
+			wantStatus: http.StatusAccepted,
+			wantBody:   `"status":"queued"`,
+		},
+		{
+			name:       "NoChannels",
+			reqBody:    `{"name":"Sale","subject":"Sale","message":"Sale","channels":[]}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"rule":"min"`,
+		},
+		{
+			name:       "UnknownChannel",
+			reqBody:    `{"name":"Sale","subject":"Sale","message":"Sale","channels":["fax"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"rule":"oneof"`,
+		},
+		{
+			name:    "InvalidSegment",
+			reqBody: `{"name":"Sale","subject":"Sale","message":"Sale","channels":["sms"],"segment":{"min_orders":3,"max_orders":1}}`,
+			mockSetup: func(mockService *mocks.MockBroadcastService) {
+				mockService.EXPECT().Create(gomock.Any(), uint64(1), gomock.Any()).Return(nil, fmt.Errorf("%w: max_orders must be at least min_orders, and both at least 0", service.ErrInvalidBroadcast))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "max_orders must be at least min_orders",
+		},
+		{
+			name:    "ServiceError",
+			reqBody: `{"name":"Sale","subject":"Sale","message":"Sale","channels":["push"]}`,
+			mockSetup: func(mockService *mocks.MockBroadcastService) {
+				mockService.EXPECT().Create(gomock.Any(), uint64(1), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockBroadcastService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewBroadcastHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			var err error
+			c.Request, err = http.NewRequest(http.MethodPost, "/admin/broadcasts", bytes.NewBufferString(tt.reqBody))
+			require.NoError(t, err)
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			handler.CreateBroadcast(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestBroadcastHandler_GetBroadcast(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		id         string
+		mockSetup  func(mockService *mocks.MockBroadcastService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "Success",
+			id:   "7",
+			mockSetup: func(mockService *mocks.MockBroadcastService) {
+				mockService.EXPECT().Get(gomock.Any(), uint64(7)).Return(&service.BroadcastResp{ID: 7, Status: model.BroadcastStatusSending, Recipients: 500}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"recipients":500`,
+		},
+		{
+			name:       "InvalidID",
+			id:         "abc",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid broadcast id",
+		},
+		{
+			name: "NotFound",
+			id:   "8",
+			mockSetup: func(mockService *mocks.MockBroadcastService) {
+				mockService.EXPECT().Get(gomock.Any(), uint64(8)).Return(nil, service.ErrBroadcastNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   "broadcast not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockBroadcastService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewBroadcastHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}}
+
+			var err error
+			c.Request, err = http.NewRequest(http.MethodGet, "/admin/broadcasts/"+tt.id, nil)
+			require.NoError(t, err)
+
+			handler.GetBroadcast(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
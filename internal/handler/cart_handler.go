@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// CartHandler defines the HTTP handlers of customers' carts and of the
+// recovery of abandoned ones.
+type CartHandler struct {
+	cartService service.CartService
+}
+
+// NewCartHandler creates a new CartHandler instance.
+func NewCartHandler(cartService service.CartService) *CartHandler {
+	return &CartHandler{cartService: cartService}
+}
+
+// SetCartItemRequest defines the request body for setting the quantity of a
+// SKU in the cart.
+type SetCartItemRequest struct {
+	Quantity int `json:"quantity" binding:"required,gt=0,max=1000" example:"2"`
+}
+
+// CartRecoveryStatsQuery defines the period of cart recovery stats.
+type CartRecoveryStatsQuery struct {
+	// Since counts the carts whose customers were reminded at or after it;
+	// by default the last 30 days.
+	Since time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00" example:"2026-01-01T00:00:00Z"`
+}
+
+// GetCart returns the caller's cart.
+//
+//	@Summary		Get the cart
+//	@Description	A caller who has not added anything yet has an empty cart. Placing an order with any of the cart's SKUs empties it. A cart left untouched is abandoned, and the customer may be reminded of it, with a coupon code.
+//	@Tags			cart
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	Response{data=service.CartResp}
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/cart [get]
+func (h *CartHandler) GetCart(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+
+	cart, err := h.cartService.Get(c.Request.Context(), userID)
+	if err != nil {
+		respondCartError(c, "Failed to get cart", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": cart})
+}
+
+// SetCartItem sets the quantity of a SKU in the caller's cart, adding it if
+// needed.
+//
+//	@Summary	Set a cart item
+//	@Tags		cart
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		sku_id	path		integer				true	"SKU ID"
+//	@Param		request	body		SetCartItemRequest	true	"Quantity"
+//	@Success	200		{object}	Response{data=service.CartResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	404		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/cart/items/{sku_id} [put]
+func (h *CartHandler) SetCartItem(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	skuID, ok := parseIDParam(c, "sku_id", "sku")
+	if !ok {
+		return
+	}
+	var req SetCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	cart, err := h.cartService.SetItem(c.Request.Context(), userID, skuID, req.Quantity)
+	if err != nil {
+		respondCartError(c, "Failed to set cart item", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Cart updated", "data": cart})
+}
+
+// RemoveCartItem removes a SKU from the caller's cart.
+//
+//	@Summary	Remove a cart item
+//	@Tags		cart
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		sku_id	path		integer	true	"SKU ID"
+//	@Success	200		{object}	Response{data=service.CartResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/cart/items/{sku_id} [delete]
+func (h *CartHandler) RemoveCartItem(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	skuID, ok := parseIDParam(c, "sku_id", "sku")
+	if !ok {
+		return
+	}
+
+	cart, err := h.cartService.RemoveItem(c.Request.Context(), userID, skuID)
+	if err != nil {
+		respondCartError(c, "Failed to remove cart item", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Cart updated", "data": cart})
+}
+
+// ClearCart removes every item of the caller's cart.
+//
+//	@Summary	Clear the cart
+//	@Tags		cart
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Success	200	{object}	Response
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/cart [delete]
+func (h *CartHandler) ClearCart(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+
+	if err := h.cartService.Clear(c.Request.Context(), userID); err != nil {
+		respondCartError(c, "Failed to clear cart", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Cart cleared"})
+}
+
+// CartRecoveryStats returns how many abandoned carts the reminders brought
+// back.
+//
+//	@Summary		Get abandoned cart recovery stats
+//	@Description	Counts the carts whose customers were reminded of them since the given time, those of them ordered afterwards, and the revenue of their paid orders in the base currency. A cart counts as ordered when an order with any of its SKUs is placed.
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			query	query		CartRecoveryStatsQuery	false	"Period"
+//	@Success		200		{object}	Response{data=service.CartRecoveryStatsResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/carts/recovery-stats [get]
+func (h *CartHandler) CartRecoveryStats(c *gin.Context) {
+	var query CartRecoveryStatsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	stats, err := h.cartService.RecoveryStats(c.Request.Context(), query.Since)
+	if err != nil {
+		respondCartError(c, "Failed to get cart recovery stats", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": stats})
+}
+
+func respondCartError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrProductNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+	default:
+		slog.ErrorContext(c.Request.Context(), msg, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
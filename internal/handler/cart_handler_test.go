@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCartHandler_SetCartItem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		skuID      string
+		body       string
+		mockSetup  func(mockService *mocks.MockCartService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:  "Set",
+			skuID: "11",
+			body:  `{"quantity":2}`,
+			mockSetup: func(mockService *mocks.MockCartService) {
+				mockService.EXPECT().SetItem(gomock.Any(), uint64(1), uint64(11), 2).
+					Return(&service.CartResp{ID: 5, Items: []service.CartItemResp{{SKUID: 11, Quantity: 2}}}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"sku_id":"11","quantity":2`,
+		},
+		{
+			name:  "UnknownSKU",
+			skuID: "11",
+			body:  `{"quantity":2}`,
+			mockSetup: func(mockService *mocks.MockCartService) {
+				mockService.EXPECT().SetItem(gomock.Any(), uint64(1), uint64(11), 2).Return(nil, service.ErrProductNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   service.ErrProductNotFound.Error(),
+		},
+		{name: "ZeroQuantity", skuID: "11", body: `{"quantity":0}`, wantStatus: http.StatusBadRequest, wantBody: `"field":"quantity"`},
+		{name: "InvalidSKUID", skuID: "abc", body: `{"quantity":2}`, wantStatus: http.StatusBadRequest, wantBody: "invalid sku id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockCartService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "sku_id", Value: tt.skuID}}
+			c.Request = httptest.NewRequest(http.MethodPut, "/cart/items/"+tt.skuID, strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			NewCartHandler(mockService).SetCartItem(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestCartHandler_CartRecoveryStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		query      string
+		mockSetup  func(mockService *mocks.MockCartService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:  "Since",
+			query: "?since=2026-01-01T00:00:00Z",
+			mockSetup: func(mockService *mocks.MockCartService) {
+				mockService.EXPECT().RecoveryStats(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, got time.Time) (*service.CartRecoveryStatsResp, error) {
+					assert.True(t, since.Equal(got))
+					return &service.CartRecoveryStatsResp{Reminded: 8, Recovered: 2, ConversionRate: 0.25}, nil
+				})
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"conversion_rate":0.25`,
+		},
+		{name: "InvalidSince", query: "?since=yesterday", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockCartService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/carts/recovery-stats"+tt.query, nil)
+
+			NewCartHandler(mockService).CartRecoveryStats(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
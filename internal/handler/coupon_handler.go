@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// CouponHandler defines the HTTP handlers for managing coupon campaigns.
+type CouponHandler struct {
+	couponService service.CouponService
+}
+
+// NewCouponHandler creates a new CouponHandler instance.
+func NewCouponHandler(couponService service.CouponService) *CouponHandler {
+	return &CouponHandler{couponService: couponService}
+}
+
+// CreateCouponCampaignRequest defines the request body for creating a coupon
+// campaign.
+type CreateCouponCampaignRequest struct {
+	Name        string `json:"name" binding:"required,max=100" example:"Newsletter June"`
+	PromotionID uint64 `json:"promotion_id,string" binding:"required" example:"1234567890"` // A coupon_only promotion
+}
+
+// GenerateCouponCodesRequest defines the request body for generating codes.
+type GenerateCouponCodesRequest struct {
+	Count  int    `json:"count" binding:"required,min=1,max=10000" example:"500"`
+	Prefix string `json:"prefix" binding:"omitempty,max=20" example:"SUMMER-"` // Letters, digits and hyphens
+}
+
+// CreateCouponCampaign creates a campaign whose codes unlock a coupon-only
+// promotion.
+//
+//	@Summary	Create a coupon campaign
+//	@Tags		admin
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		request	body		CreateCouponCampaignRequest	true	"Coupon campaign payload"
+//	@Success	201		{object}	Response{data=service.CouponCampaignResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	404		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/coupon-campaigns [post]
+func (h *CouponHandler) CreateCouponCampaign(c *gin.Context) {
+	var req CreateCouponCampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.couponService.CreateCampaign(c.Request.Context(), &service.CouponCampaignCreateReq{Name: req.Name, PromotionID: req.PromotionID})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCouponCampaign):
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+		case errors.Is(err, service.ErrPromotionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+		default:
+			slog.ErrorContext(c.Request.Context(), "Failed to create coupon campaign", "promotion_id", req.PromotionID, logger.Err(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Coupon campaign created", "data": resp})
+}
+
+// ListCouponCampaigns returns every coupon campaign with its redemption stats.
+//
+//	@Summary		List coupon campaigns
+//	@Description	The generated and redeemed counts and the redemption rate are rolled up periodically by the worker, so they lag redemptions by up to the rollup schedule.
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	Response{data=[]service.CouponCampaignResp}
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/coupon-campaigns [get]
+func (h *CouponHandler) ListCouponCampaigns(c *gin.Context) {
+	campaigns, err := h.couponService.ListCampaigns(c.Request.Context())
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list coupon campaigns", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": campaigns})
+}
+
+// GenerateCouponCodes generates a batch of unique single-use codes for a
+// campaign.
+//
+//	@Summary		Generate coupon codes
+//	@Description	Each code is the prefix followed by random characters, unique within the store. Codes are case-insensitive.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		integer						true	"Coupon campaign ID"
+//	@Param			request	body		GenerateCouponCodesRequest	true	"Batch payload"
+//	@Success		201		{object}	Response{data=service.CouponBatchResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/coupon-campaigns/{id}/codes [post]
+func (h *CouponHandler) GenerateCouponCodes(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "coupon campaign")
+	if !ok {
+		return
+	}
+	var req GenerateCouponCodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.couponService.GenerateCodes(c.Request.Context(), id, req.Count, req.Prefix)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCouponBatch):
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+		case errors.Is(err, service.ErrCouponCampaignNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+		default:
+			slog.ErrorContext(c.Request.Context(), "Failed to generate coupon codes", "campaign_id", id, logger.Err(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Coupon codes generated", "data": resp})
+}
+
+// ExportCouponCodes downloads every code of a campaign as CSV.
+//
+//	@Summary		Export coupon codes
+//	@Description	Columns: code, redeemed_at (RFC 3339, empty while unredeemed), order_id.
+//	@Tags			admin
+//	@Produce		text/csv
+//	@Security		BearerAuth
+//	@Param			id	path		integer	true	"Coupon campaign ID"
+//	@Success		200	{string}	string
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/coupon-campaigns/{id}/codes/export [get]
+func (h *CouponHandler) ExportCouponCodes(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "coupon campaign")
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="coupons-%d.csv"`, id))
+	err := h.couponService.ExportCodes(c.Request.Context(), id, c.Writer)
+	if err == nil {
+		return
+	}
+	if c.Writer.Written() {
+		// The status is sent: the client sees a truncated file
+		slog.ErrorContext(c.Request.Context(), "Failed to finish coupon export", "campaign_id", id, logger.Err(err))
+		return
+	}
+	c.Writer.Header().Del("Content-Type")
+	c.Writer.Header().Del("Content-Disposition")
+	if errors.Is(err, service.ErrCouponCampaignNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+		return
+	}
+	slog.ErrorContext(c.Request.Context(), "Failed to export coupon codes", "campaign_id", id, logger.Err(err))
+	c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+}
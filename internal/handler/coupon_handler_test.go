@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCouponHandler_GenerateCouponCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockCouponService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"count":2,"prefix":"SUMMER-"}`,
+			mockSetup: func(mockService *mocks.MockCouponService) {
+				mockService.EXPECT().GenerateCodes(gomock.Any(), uint64(9), 2, "SUMMER-").Return(&service.CouponBatchResp{CampaignID: 9, Codes: []string{"SUMMER-AAAA", "SUMMER-BBBB"}}, nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"codes":["SUMMER-AAAA","SUMMER-BBBB"]`,
+		},
+		{name: "TooMany", reqBody: `{"count":10001}`, wantStatus: http.StatusBadRequest, wantBody: `"field":"count"`},
+		{
+			name:    "InvalidPrefix",
+			reqBody: `{"count":2,"prefix":"50% OFF"}`,
+			mockSetup: func(mockService *mocks.MockCouponService) {
+				mockService.EXPECT().GenerateCodes(gomock.Any(), uint64(9), 2, "50% OFF").Return(nil, fmt.Errorf("%w: prefix must be letters, digits and hyphens", service.ErrInvalidCouponBatch))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "prefix must be",
+		},
+		{
+			name:    "CampaignNotFound",
+			reqBody: `{"count":2}`,
+			mockSetup: func(mockService *mocks.MockCouponService) {
+				mockService.EXPECT().GenerateCodes(gomock.Any(), uint64(9), 2, "").Return(nil, service.ErrCouponCampaignNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:    "ServiceError",
+			reqBody: `{"count":2}`,
+			mockSetup: func(mockService *mocks.MockCouponService) {
+				mockService.EXPECT().GenerateCodes(gomock.Any(), uint64(9), 2, "").Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockCouponService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewCouponHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "9"}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/coupon-campaigns/9/codes", bytes.NewBufferString(tt.reqBody))
+
+			handler.GenerateCouponCodes(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestCouponHandler_ExportCouponCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		err             error
+		wantStatus      int
+		wantContentType string
+	}{
+		{name: "Success", wantStatus: http.StatusOK, wantContentType: "text/csv"},
+		{name: "NotFound", err: service.ErrCouponCampaignNotFound, wantStatus: http.StatusNotFound, wantContentType: "application/json; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockCouponService(ctrl)
+			mockService.EXPECT().ExportCodes(gomock.Any(), uint64(9), gomock.Any()).DoAndReturn(func(_ context.Context, _ uint64, w io.Writer) error {
+				if tt.err != nil {
+					return tt.err
+				}
+				_, err := io.WriteString(w, "code,redeemed_at,order_id\n")
+				return err
+			})
+			handler := NewCouponHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "9"}}
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/coupon-campaigns/9/codes/export", nil)
+
+			handler.ExportCouponCodes(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantContentType, w.Header().Get("Content-Type"))
+		})
+	}
+}
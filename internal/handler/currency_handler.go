@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// CurrencyHandler defines the HTTP handlers for currencies and users'
+// currency preferences.
+type CurrencyHandler struct {
+	currencyService service.CurrencyService
+}
+
+// NewCurrencyHandler creates a new CurrencyHandler instance.
+func NewCurrencyHandler(currencyService service.CurrencyService) *CurrencyHandler {
+	return &CurrencyHandler{currencyService: currencyService}
+}
+
+// CurrencyPreferenceRequest defines the request body for setting the preferred
+// currency. An empty currency clears the preference.
+type CurrencyPreferenceRequest struct {
+	Currency string `json:"currency" binding:"omitempty,iso4217" example:"EUR"`
+}
+
+// ListCurrencies returns the currencies prices can be shown and charged in,
+// with the current exchange rates from the base currency.
+//
+//	@Summary	List currencies
+//	@Tags		currencies
+//	@Produce	json
+//	@Success	200	{object}	Response{data=service.CurrenciesResp}
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/currencies [get]
+func (h *CurrencyHandler) ListCurrencies(c *gin.Context) {
+	resp, err := h.currencyService.List(c.Request.Context())
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list currencies", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+}
+
+// UpdatePreference sets the currency the caller sees prices in and is charged
+// in when a request has no Accept-Currency header.
+//
+//	@Summary	Set the preferred currency
+//	@Tags		users
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		request	body		CurrencyPreferenceRequest	true	"Preference payload"
+//	@Success	200		{object}	Response
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/users/me/currency [put]
+func (h *CurrencyHandler) UpdatePreference(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+
+	var req CurrencyPreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	err = h.currencyService.SetPreference(c.Request.Context(), userID, req.Currency)
+	if errors.Is(err, service.ErrUnsupportedCurrency) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "unsupported currency"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to update currency preference", "user_id", userID, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Currency preference updated"})
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCurrencyHandler_UpdatePreference(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockCurrencyService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"currency":"EUR"}`,
+			mockSetup: func(mockService *mocks.MockCurrencyService) {
+				mockService.EXPECT().SetPreference(gomock.Any(), uint64(1), "EUR").Return(nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "Currency preference updated",
+		},
+		{
+			name:    "Clear",
+			reqBody: `{"currency":""}`,
+			mockSetup: func(mockService *mocks.MockCurrencyService) {
+				mockService.EXPECT().SetPreference(gomock.Any(), uint64(1), "").Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "NotACurrency",
+			reqBody:    `{"currency":"euro"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"field":"currency","rule":"iso4217"`,
+		},
+		{
+			name:    "Unsupported",
+			reqBody: `{"currency":"CHF"}`,
+			mockSetup: func(mockService *mocks.MockCurrencyService) {
+				mockService.EXPECT().SetPreference(gomock.Any(), uint64(1), "CHF").Return(service.ErrUnsupportedCurrency)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "unsupported currency",
+		},
+		{
+			name:    "ServiceError",
+			reqBody: `{"currency":"EUR"}`,
+			mockSetup: func(mockService *mocks.MockCurrencyService) {
+				mockService.EXPECT().SetPreference(gomock.Any(), uint64(1), "EUR").Return(errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockCurrencyService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewCurrencyHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			var err error
+			c.Request, err = http.NewRequest(http.MethodPut, "/users/me/currency", bytes.NewBufferString(tt.reqBody))
+			require.NoError(t, err)
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			handler.UpdatePreference(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
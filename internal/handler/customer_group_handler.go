@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/shopspring/decimal"
+)
+
+// CustomerGroupHandler defines the HTTP handlers for managing customer groups
+// and what they pay for SKUs.
+type CustomerGroupHandler struct {
+	groupService service.CustomerGroupService
+}
+
+// NewCustomerGroupHandler creates a new CustomerGroupHandler instance.
+func NewCustomerGroupHandler(groupService service.CustomerGroupService) *CustomerGroupHandler {
+	return &CustomerGroupHandler{groupService: groupService}
+}
+
+// SetCustomerGroupRequest defines the request body for moving a user into a customer group.
+type SetCustomerGroupRequest struct {
+	CustomerGroup string `json:"customer_group" binding:"required,oneof=retail wholesale vip" example:"wholesale"`
+}
+
+// CustomerGroupQuery defines the paging of a customer group's members.
+type CustomerGroupQuery struct {
+	Offset int `form:"offset" binding:"min=0"`
+	Limit  int `form:"limit" binding:"min=0,max=100"`
+}
+
+// PriceTierPutRequest defines the request body for pricing a SKU for a customer group.
+type PriceTierPutRequest struct {
+	Price decimal.Decimal `json:"price" binding:"required,price" swaggertype:"string" example:"14.50"` // In the SKU's currency
+}
+
+// SetCustomerGroup moves a user into a customer group.
+//
+//	@Summary		Set the customer group of a user
+//	@Description	Wholesale and VIP customers pay their group's price tier for the SKUs that have one, on product pages and at checkout; retail customers pay the SKUs' own prices.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		integer					true	"User ID"
+//	@Param			request	body		SetCustomerGroupRequest	true	"Customer group"
+//	@Success		200		{object}	Response{data=service.CustomerGroupMemberResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/users/{id}/customer-group [put]
+func (h *CustomerGroupHandler) SetCustomerGroup(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "user")
+	if !ok {
+		return
+	}
+	var req SetCustomerGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.groupService.SetMembership(c.Request.Context(), id, req.CustomerGroup)
+	if err != nil {
+		respondCustomerGroupError(c, "Failed to set customer group", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Customer group updated", "data": resp})
+}
+
+// ListCustomerGroupMembers returns the users in a customer group.
+//
+//	@Summary	List the members of a customer group
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		group	path		string				true	"Customer group"	Enums(retail, wholesale, vip)
+//	@Param		query	query		CustomerGroupQuery	false	"Paging"
+//	@Success	200		{object}	Response{data=[]service.CustomerGroupMemberResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/customer-groups/{group}/members [get]
+func (h *CustomerGroupHandler) ListCustomerGroupMembers(c *gin.Context) {
+	var query CustomerGroupQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	members, err := h.groupService.ListMembers(c.Request.Context(), c.Param("group"), query.Offset, query.Limit)
+	if err != nil {
+		respondCustomerGroupError(c, "Failed to list customer group members", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": members})
+}
+
+// ListPriceTiers returns what each customer group pays for a SKU.
+//
+//	@Summary	List the price tiers of a SKU
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"SKU ID"
+//	@Success	200	{object}	Response{data=[]service.PriceTierResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/skus/{id}/price-tiers [get]
+func (h *CustomerGroupHandler) ListPriceTiers(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "SKU")
+	if !ok {
+		return
+	}
+
+	tiers, err := h.groupService.ListPriceTiers(c.Request.Context(), id)
+	if err != nil {
+		respondCustomerGroupError(c, "Failed to list price tiers", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": tiers})
+}
+
+// PutPriceTier creates or replaces what a customer group pays for a SKU.
+//
+//	@Summary		Price a SKU for a customer group
+//	@Description	The price is in the SKU's currency and converted like its own price. Only wholesale and vip can be priced: retail customers pay the SKU's own price.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		integer				true	"SKU ID"
+//	@Param			group	path		string				true	"Customer group"	Enums(wholesale, vip)
+//	@Param			request	body		PriceTierPutRequest	true	"Group price"
+//	@Success		200		{object}	Response{data=service.PriceTierResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/skus/{id}/price-tiers/{group} [put]
+func (h *CustomerGroupHandler) PutPriceTier(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "SKU")
+	if !ok {
+		return
+	}
+	var req PriceTierPutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.groupService.PutPriceTier(c.Request.Context(), id, c.Param("group"), req.Price)
+	if err != nil {
+		respondCustomerGroupError(c, "Failed to save price tier", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Price tier saved", "data": resp})
+}
+
+// DeletePriceTier removes what a customer group pays for a SKU, which its
+// members then buy at the SKU's own price.
+//
+//	@Summary	Delete a price tier of a SKU
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id		path		integer	true	"SKU ID"
+//	@Param		group	path		string	true	"Customer group"	Enums(wholesale, vip)
+//	@Success	200		{object}	Response
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	404		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/skus/{id}/price-tiers/{group} [delete]
+func (h *CustomerGroupHandler) DeletePriceTier(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "SKU")
+	if !ok {
+		return
+	}
+
+	if err := h.groupService.DeletePriceTier(c.Request.Context(), id, c.Param("group")); err != nil {
+		respondCustomerGroupError(c, "Failed to delete price tier", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Price tier deleted"})
+}
+
+// respondCustomerGroupError maps the errors of the customer group service to
+// responses, logging unexpected ones with msg.
+func respondCustomerGroupError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrUnknownCustomerGroup):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrProductNotFound), errors.Is(err, service.ErrPriceTierNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+	default:
+		slog.ErrorContext(c.Request.Context(), msg, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCustomerGroupHandler_SetCustomerGroup(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		id         string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockCustomerGroupService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			id:      "7",
+			reqBody: `{"customer_group":"wholesale"}`,
+			mockSetup: func(mockService *mocks.MockCustomerGroupService) {
+				mockService.EXPECT().SetMembership(gomock.Any(), uint64(7), "wholesale").
+					Return(&service.CustomerGroupMemberResp{UserID: 7, Username: "acme", CustomerGroup: "wholesale"}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"customer_group":"wholesale"`,
+		},
+		{name: "InvalidID", id: "acme", reqBody: `{"customer_group":"vip"}`, wantStatus: http.StatusBadRequest, wantBody: "invalid user id"},
+		{name: "UnknownGroup", id: "7", reqBody: `{"customer_group":"gold"}`, wantStatus: http.StatusBadRequest, wantBody: `{"field":"customer_group","rule":"oneof"`},
+		{
+			name:    "UserNotFound",
+			id:      "7",
+			reqBody: `{"customer_group":"vip"}`,
+			mockSetup: func(mockService *mocks.MockCustomerGroupService) {
+				mockService.EXPECT().SetMembership(gomock.Any(), uint64(7), "vip").Return(nil, service.ErrUserNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   service.ErrUserNotFound.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockCustomerGroupService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}}
+			c.Request = httptest.NewRequest(http.MethodPut, "/admin/users/"+tt.id+"/customer-group", bytes.NewBufferString(tt.reqBody))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			NewCustomerGroupHandler(mockService).SetCustomerGroup(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestCustomerGroupHandler_PutPriceTier(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		group      string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockCustomerGroupService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			group:   "wholesale",
+			reqBody: `{"price":"14.50"}`,
+			mockSetup: func(mockService *mocks.MockCustomerGroupService) {
+				mockService.EXPECT().PutPriceTier(gomock.Any(), uint64(9), "wholesale", decimal.RequireFromString("14.50")).
+					Return(&service.PriceTierResp{SKUID: 9, CustomerGroup: "wholesale", Price: decimal.RequireFromString("14.50"), Currency: "USD"}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"price":"14.5"`,
+		},
+		{name: "SubCentPrice", group: "vip", reqBody: `{"price":"14.505"}`, wantStatus: http.StatusBadRequest, wantBody: `{"field":"price","rule":"price"`},
+		{
+			name:    "RetailGroup",
+			group:   "retail",
+			reqBody: `{"price":"14.50"}`,
+			mockSetup: func(mockService *mocks.MockCustomerGroupService) {
+				mockService.EXPECT().PutPriceTier(gomock.Any(), uint64(9), "retail", gomock.Any()).Return(nil, service.ErrUnknownCustomerGroup)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   service.ErrUnknownCustomerGroup.Error(),
+		},
+		{
+			name:    "SKUNotFound",
+			group:   "vip",
+			reqBody: `{"price":"14.50"}`,
+			mockSetup: func(mockService *mocks.MockCustomerGroupService) {
+				mockService.EXPECT().PutPriceTier(gomock.Any(), uint64(9), "vip", gomock.Any()).Return(nil, service.ErrProductNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:    "ServiceError",
+			group:   "vip",
+			reqBody: `{"price":"14.50"}`,
+			mockSetup: func(mockService *mocks.MockCustomerGroupService) {
+				mockService.EXPECT().PutPriceTier(gomock.Any(), uint64(9), "vip", gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockCustomerGroupService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "9"}, {Key: "group", Value: tt.group}}
+			c.Request = httptest.NewRequest(http.MethodPut, "/admin/skus/9/price-tiers/"+tt.group, bytes.NewBufferString(tt.reqBody))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			NewCustomerGroupHandler(mockService).PutPriceTier(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
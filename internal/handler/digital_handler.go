@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// DigitalHandler defines the HTTP handlers for managing digital goods.
+type DigitalHandler struct {
+	licenseKeyService service.LicenseKeyService
+}
+
+// NewDigitalHandler creates a new DigitalHandler instance.
+func NewDigitalHandler(licenseKeyService service.LicenseKeyService) *DigitalHandler {
+	return &DigitalHandler{licenseKeyService: licenseKeyService}
+}
+
+// AddLicenseKeysRequest defines the request body for adding license keys.
+type AddLicenseKeysRequest struct {
+	Keys []string `json:"keys" binding:"required,min=1,max=10000" example:"XXXX-XXXX-XXXX-XXXX"`
+}
+
+// AddLicenseKeys adds license keys to the pool of a license_key SKU.
+//
+//	@Summary		Add license keys to a SKU
+//	@Description	Each unit ordered takes one key from the pool once the order is paid; the keys are emailed to the customer. Keys already in the pool are skipped. Orders wait while the pool has too few keys, and are delivered once it is topped up.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		integer					true	"SKU ID"
+//	@Param			request	body		AddLicenseKeysRequest	true	"License keys payload"
+//	@Success		201		{object}	Response{data=service.LicenseKeysResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/skus/{id}/license-keys [post]
+func (h *DigitalHandler) AddLicenseKeys(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "SKU")
+	if !ok {
+		return
+	}
+	var req AddLicenseKeysRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.licenseKeyService.AddLicenseKeys(c.Request.Context(), id, req.Keys)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidLicenseKeys):
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+		case errors.Is(err, service.ErrProductNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+		case errors.Is(err, service.ErrNotLicenseKeySKU):
+			c.JSON(http.StatusConflict, gin.H{"code": http.StatusConflict, "message": err.Error()})
+		default:
+			slog.ErrorContext(c.Request.Context(), "Failed to add license keys", "sku_id", id, logger.Err(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "License keys added", "data": resp})
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDigitalHandler_AddLicenseKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockLicenseKeyService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"keys":["AAAA","BBBB"]}`,
+			mockSetup: func(mockService *mocks.MockLicenseKeyService) {
+				mockService.EXPECT().AddLicenseKeys(gomock.Any(), uint64(9), []string{"AAAA", "BBBB"}).Return(&service.LicenseKeysResp{SKUID: 9, Added: 2, Available: 7}, nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"available":7`,
+		},
+		{name: "NoKeys", reqBody: `{"keys":[]}`, wantStatus: http.StatusBadRequest, wantBody: `"field":"keys"`},
+		{
+			name:    "NotLicenseKeySKU",
+			reqBody: `{"keys":["AAAA"]}`,
+			mockSetup: func(mockService *mocks.MockLicenseKeyService) {
+				mockService.EXPECT().AddLicenseKeys(gomock.Any(), uint64(9), []string{"AAAA"}).Return(nil, service.ErrNotLicenseKeySKU)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:    "SKUNotFound",
+			reqBody: `{"keys":["AAAA"]}`,
+			mockSetup: func(mockService *mocks.MockLicenseKeyService) {
+				mockService.EXPECT().AddLicenseKeys(gomock.Any(), uint64(9), []string{"AAAA"}).Return(nil, service.ErrProductNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:    "ServiceError",
+			reqBody: `{"keys":["AAAA"]}`,
+			mockSetup: func(mockService *mocks.MockLicenseKeyService) {
+				mockService.EXPECT().AddLicenseKeys(gomock.Any(), uint64(9), []string{"AAAA"}).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockLicenseKeyService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewDigitalHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "9"}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/skus/9/license-keys", bytes.NewBufferString(tt.reqBody))
+
+			handler.AddLicenseKeys(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
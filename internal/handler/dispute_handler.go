@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// DisputeHandler defines the HTTP handlers admins track payment disputes
+// with.
+type DisputeHandler struct {
+	disputeService service.DisputeService
+}
+
+// NewDisputeHandler creates a new DisputeHandler instance.
+func NewDisputeHandler(disputeService service.DisputeService) *DisputeHandler {
+	return &DisputeHandler{disputeService: disputeService}
+}
+
+// DisputeQuery defines the filters and paging of disputes.
+type DisputeQuery struct {
+	Status  string `form:"status" binding:"omitempty,oneof=needs_response under_review won lost" example:"needs_response"`
+	Open    bool   `form:"open"` // Only disputes that are not decided yet
+	OrderID uint64 `form:"order_id" example:"1234567890"`
+	Offset  int    `form:"offset" binding:"min=0"`
+	Limit   int    `form:"limit" binding:"min=0,max=100"`
+}
+
+// ListDisputes returns payment disputes, most recent first.
+//
+//	@Summary		List payment disputes
+//	@Description	Disputes (chargebacks) arrive with the payment providers' notifications and are linked to the order paid with the disputed payment. Refunds of the order are frozen while a dispute is open, and for good once one is lost. Admins are reminded before the evidence of a dispute that needs a response is due.
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			query	query		DisputeQuery	false	"Filters and paging"
+//	@Success		200		{object}	Response{data=service.DisputeListResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/disputes [get]
+func (h *DisputeHandler) ListDisputes(c *gin.Context) {
+	var query DisputeQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	filter := repository.DisputeFilter{Status: query.Status, Open: query.Open, OrderID: query.OrderID}
+	disputes, err := h.disputeService.List(c.Request.Context(), filter, query.Offset, query.Limit)
+	if err != nil {
+		respondDisputeError(c, "Failed to list disputes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": disputes})
+}
+
+// GetDispute returns one payment dispute.
+//
+//	@Summary	Get a payment dispute
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Dispute ID"
+//	@Success	200	{object}	Response{data=service.DisputeResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/disputes/{id} [get]
+func (h *DisputeHandler) GetDispute(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "dispute")
+	if !ok {
+		return
+	}
+
+	dispute, err := h.disputeService.Get(c.Request.Context(), id)
+	if err != nil {
+		respondDisputeError(c, "Failed to get dispute", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": dispute})
+}
+
+// SubmitDisputeEvidence records that the caller submitted the evidence of a
+// dispute to the payment provider.
+//
+//	@Summary		Record dispute evidence as submitted
+//	@Description	The evidence itself is submitted in the payment provider's dashboard; recording it here stops the reminders. Only disputes that need a response take it.
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		integer	true	"Dispute ID"
+//	@Success		200	{object}	Response{data=service.DisputeResp}
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		409	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/disputes/{id}/evidence [post]
+func (h *DisputeHandler) SubmitDisputeEvidence(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	id, ok := parseIDParam(c, "id", "dispute")
+	if !ok {
+		return
+	}
+
+	dispute, err := h.disputeService.MarkEvidenceSubmitted(c.Request.Context(), userID, id)
+	if err != nil {
+		respondDisputeError(c, "Failed to record dispute evidence", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Dispute evidence recorded", "data": dispute})
+}
+
+func respondDisputeError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrDisputeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+	case errors.Is(err, service.ErrDisputeStatus):
+		c.JSON(http.StatusConflict, gin.H{"code": http.StatusConflict, "message": err.Error()})
+	default:
+		slog.ErrorContext(c.Request.Context(), msg, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
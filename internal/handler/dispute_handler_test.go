@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDisputeHandler_ListDisputes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		query      string
+		mockSetup  func(mockService *mocks.MockDisputeService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:  "Open",
+			query: "?open=true&order_id=5",
+			mockSetup: func(mockService *mocks.MockDisputeService) {
+				mockService.EXPECT().List(gomock.Any(), repository.DisputeFilter{Open: true, OrderID: 5}, 0, 0).
+					Return(&service.DisputeListResp{Disputes: []service.DisputeResp{{ID: 9, Status: "needs_response"}}, Total: 1}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"status":"needs_response"`,
+		},
+		{name: "UnknownStatus", query: "?status=closed", wantStatus: http.StatusBadRequest, wantBody: `"rule":"oneof"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockDisputeService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/disputes"+tt.query, nil)
+
+			NewDisputeHandler(mockService).ListDisputes(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestDisputeHandler_SubmitDisputeEvidence(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		id         string
+		mockSetup  func(mockService *mocks.MockDisputeService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "Recorded",
+			id:   "9",
+			mockSetup: func(mockService *mocks.MockDisputeService) {
+				mockService.EXPECT().MarkEvidenceSubmitted(gomock.Any(), uint64(1), uint64(9)).Return(&service.DisputeResp{ID: 9}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"id":"9"`,
+		},
+		{
+			name: "Decided",
+			id:   "9",
+			mockSetup: func(mockService *mocks.MockDisputeService) {
+				mockService.EXPECT().MarkEvidenceSubmitted(gomock.Any(), uint64(1), uint64(9)).Return(nil, service.ErrDisputeStatus)
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   service.ErrDisputeStatus.Error(),
+		},
+		{
+			name: "NotFound",
+			id:   "9",
+			mockSetup: func(mockService *mocks.MockDisputeService) {
+				mockService.EXPECT().MarkEvidenceSubmitted(gomock.Any(), uint64(1), uint64(9)).Return(nil, service.ErrDisputeNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   service.ErrDisputeNotFound.Error(),
+		},
+		{name: "InvalidID", id: "abc", wantStatus: http.StatusBadRequest, wantBody: "invalid dispute id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockDisputeService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/disputes/"+tt.id+"/evidence", nil)
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			NewDisputeHandler(mockService).SubmitDisputeEvidence(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// FailedMessageHandler defines the HTTP handlers for the messages queue
+// consumers rejected for good.
+type FailedMessageHandler struct {
+	failedMessageService service.FailedMessageService
+}
+
+// NewFailedMessageHandler creates a new FailedMessageHandler instance.
+func NewFailedMessageHandler(failedMessageService service.FailedMessageService) *FailedMessageHandler {
+	return &FailedMessageHandler{failedMessageService: failedMessageService}
+}
+
+// FailedMessageQuery defines the filters and paging of the failed message list.
+type FailedMessageQuery struct {
+	Queue  string `form:"queue" example:"orders.created"`
+	Status string `form:"status" binding:"omitempty,oneof=failed queued replayed"`
+	Offset int    `form:"offset" binding:"min=0"`
+	Limit  int    `form:"limit" binding:"min=0,max=100"`
+}
+
+// UpdateFailedMessageRequest defines the request body for fixing a failed
+// message before it is replayed.
+type UpdateFailedMessageRequest struct {
+	Body json.RawMessage `json:"body" binding:"required" swaggertype:"object"`
+}
+
+// ListFailedMessages returns the rejected messages, newest first.
+//
+//	@Summary	List failed messages
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		query	query		FailedMessageQuery	false	"Filters and paging"
+//	@Success	200		{object}	Response{data=service.FailedMessageListResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/failed-messages [get]
+func (h *FailedMessageHandler) ListFailedMessages(c *gin.Context) {
+	var query FailedMessageQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	filter := repository.FailedMessageFilter{Queue: query.Queue, Status: query.Status}
+	resp, err := h.failedMessageService.List(c.Request.Context(), filter, query.Offset, query.Limit)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list failed messages", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+}
+
+// GetFailedMessage returns a rejected message with its payload and headers.
+//
+//	@Summary	Get a failed message
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Failed message ID"
+//	@Success	200	{object}	Response{data=service.FailedMessageResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/failed-messages/{id} [get]
+func (h *FailedMessageHandler) GetFailedMessage(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "failed message")
+	if !ok {
+		return
+	}
+
+	resp, err := h.failedMessageService.Get(c.Request.Context(), id)
+	if err != nil {
+		respondFailedMessageError(c, "Failed to get failed message", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+}
+
+// UpdateFailedMessage replaces the payload of a rejected message, e.g. to fix
+// what the consumer could not decode, before it is replayed.
+//
+//	@Summary		Edit a failed message
+//	@Description	Messages queued for replay cannot be edited.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		integer						true	"Failed message ID"
+//	@Param			request	body		UpdateFailedMessageRequest	true	"New payload"
+//	@Success		200		{object}	Response{data=service.FailedMessageResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/failed-messages/{id} [put]
+func (h *FailedMessageHandler) UpdateFailedMessage(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "failed message")
+	if !ok {
+		return
+	}
+	var req UpdateFailedMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.failedMessageService.UpdateBody(c.Request.Context(), id, req.Body)
+	if err != nil {
+		respondFailedMessageError(c, "Failed to update failed message", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+}
+
+// ReplayFailedMessage queues a rejected message to be published to its queue
+// again.
+//
+//	@Summary		Replay a failed message
+//	@Description	The worker publishes queued messages every worker.replay_schedule; one the consumer rejects again is archived anew.
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		integer	true	"Failed message ID"
+//	@Success		202	{object}	Response{data=service.FailedMessageResp}
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		409	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/failed-messages/{id}/replay [post]
+func (h *FailedMessageHandler) ReplayFailedMessage(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "failed message")
+	if !ok {
+		return
+	}
+
+	resp, err := h.failedMessageService.Replay(c.Request.Context(), id)
+	if err != nil {
+		respondFailedMessageError(c, "Failed to replay failed message", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"code": http.StatusAccepted, "message": "Failed message queued for replay", "data": resp})
+}
+
+// respondFailedMessageError maps failed message service errors to HTTP responses.
+func respondFailedMessageError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidFailedMessage):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+	case errors.Is(err, service.ErrFailedMessageNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+	case errors.Is(err, service.ErrFailedMessageQueued):
+		c.JSON(http.StatusConflict, gin.H{"code": http.StatusConflict, "message": err.Error()})
+	default:
+		slog.ErrorContext(c.Request.Context(), msg, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFailedMessageHandler_ListFailedMessages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		query      string
+		mockSetup  func(mockService *mocks.MockFailedMessageService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:  "Success",
+			query: "?queue=orders.created&status=failed&limit=10",
+			mockSetup: func(mockService *mocks.MockFailedMessageService) {
+				filter := repository.FailedMessageFilter{Queue: "orders.created", Status: model.FailedMessageStatusFailed}
+				mockService.EXPECT().List(gomock.Any(), filter, 0, 10).Return(&service.FailedMessageListResp{
+					Items: []service.FailedMessageResp{{ID: 7, Queue: "orders.created", Status: model.FailedMessageStatusFailed}},
+					Total: 1,
+				}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"total":1`,
+		},
+		{
+			name:       "UnknownStatus",
+			query:      "?status=lost",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"rule":"oneof"`,
+		},
+		{
+			name: "ServiceError",
+			mockSetup: func(mockService *mocks.MockFailedMessageService) {
+				mockService.EXPECT().List(gomock.Any(), repository.FailedMessageFilter{}, 0, 0).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockFailedMessageService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewFailedMessageHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			var err error
+			c.Request, err = http.NewRequest(http.MethodGet, "/admin/failed-messages"+tt.query, nil)
+			require.NoError(t, err)
+
+			handler.ListFailedMessages(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestFailedMessageHandler_UpdateFailedMessage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		id         string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockFailedMessageService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			id:      "7",
+			reqBody: `{"body":{"sku_id":1,"quantity":2}}`,
+			mockSetup: func(mockService *mocks.MockFailedMessageService) {
+				mockService.EXPECT().UpdateBody(gomock.Any(), uint64(7), json.RawMessage(`{"sku_id":1,"quantity":2}`)).Return(&service.FailedMessageResp{ID: 7, Body: `{"sku_id":1,"quantity":2}`}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"id":"7"`,
+		},
+		{
+			name:       "InvalidID",
+			id:         "abc",
+			reqBody:    `{"body":{}}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid failed message id",
+		},
+		{
+			name:       "MissingBody",
+			id:         "7",
+			reqBody:    `{}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"rule":"required"`,
+		},
+		{
+			name:    "Queued",
+			id:      "7",
+			reqBody: `{"body":{}}`,
+			mockSetup: func(mockService *mocks.MockFailedMessageService) {
+				mockService.EXPECT().UpdateBody(gomock.Any(), uint64(7), gomock.Any()).Return(nil, service.ErrFailedMessageQueued)
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   "already queued for replay",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockFailedMessageService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewFailedMessageHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}}
+
+			var err error
+			c.Request, err = http.NewRequest(http.MethodPut, "/admin/failed-messages/"+tt.id, bytes.NewBufferString(tt.reqBody))
+			require.NoError(t, err)
+
+			handler.UpdateFailedMessage(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestFailedMessageHandler_ReplayFailedMessage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		id         string
+		mockSetup  func(mockService *mocks.MockFailedMessageService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "Success",
+			id:   "7",
+			mockSetup: func(mockService *mocks.MockFailedMessageService) {
+				mockService.EXPECT().Replay(gomock.Any(), uint64(7)).Return(&service.FailedMessageResp{ID: 7, Status: model.FailedMessageStatusQueued}, nil)
+			},
+			wantStatus: http.StatusAccepted,
+			wantBody:   `"status":"queued"`,
+		},
+		{
+			name: "NotFound",
+			id:   "8",
+			mockSetup: func(mockService *mocks.MockFailedMessageService) {
+				mockService.EXPECT().Replay(gomock.Any(), uint64(8)).Return(nil, service.ErrFailedMessageNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   "failed message not found",
+		},
+		{
+			name: "AlreadyQueued",
+			id:   "7",
+			mockSetup: func(mockService *mocks.MockFailedMessageService) {
+				mockService.EXPECT().Replay(gomock.Any(), uint64(7)).Return(nil, service.ErrFailedMessageQueued)
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   "already queued for replay",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockFailedMessageService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewFailedMessageHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}}
+
+			var err error
+			c.Request, err = http.NewRequest(http.MethodPost, "/admin/failed-messages/"+tt.id+"/replay", nil)
+			require.NoError(t, err)
+
+			handler.ReplayFailedMessage(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
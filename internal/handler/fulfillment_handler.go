@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// FulfillmentHandler defines the HTTP handlers for shipping and cancelling
+// orders.
+type FulfillmentHandler struct {
+	fulfillmentService service.FulfillmentService
+}
+
+// NewFulfillmentHandler creates a new FulfillmentHandler instance.
+func NewFulfillmentHandler(fulfillmentService service.FulfillmentService) *FulfillmentHandler {
+	return &FulfillmentHandler{fulfillmentService: fulfillmentService}
+}
+
+// ShipOrderRequest defines the request body for shipping an order.
+type ShipOrderRequest struct {
+	TrackingNumber string                    `json:"tracking_number" binding:"max=100" example:"SF1234567890"`
+	Warehouse      string                    `json:"warehouse" binding:"max=30" example:"east"` // Where it leaves from; with no items, only what is stocked there ships
+	Items          []service.ShipmentItemReq `json:"items"`                                     // Empty ships everything not shipped yet
+}
+
+// ShipmentTrackingRequest defines the request body for setting the tracking
+// number of a shipment.
+type ShipmentTrackingRequest struct {
+	TrackingNumber string `json:"tracking_number" binding:"required,max=64" example:"SF1234567890"`
+}
+
+// ShipOrder records a shipment of some or all of an order's items. For an
+// order paid by authorization, the value of the shipped items is captured
+// from it; the last shipment captures the rest of the total. Orders waiting
+// for stock of pre-ordered items ship once it is allocated to them.
+//
+//	@Summary		Ship an order
+//	@Description	An order stocked in several warehouses is split by shipping from each warehouse in turn. The order's fulfillment moves to partially_shipped, then shipped once nothing is left to ship.
+//	@Tags			admin
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id		path		integer				true	"Order ID"
+//	@Param		request	body		ShipOrderRequest	true	"Shipped items"
+//	@Success	201		{object}	Response{data=service.ShipmentResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	404		{object}	ErrorResponse
+//	@Failure	409		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/orders/{id}/shipments [post]
+func (h *FulfillmentHandler) ShipOrder(c *gin.Context) {
+	orderID, ok := parseIDParam(c, "id", "order")
+	if !ok {
+		return
+	}
+	var req ShipOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.fulfillmentService.Ship(c.Request.Context(), &service.ShipOrderReq{
+		OrderID:        orderID,
+		TrackingNumber: req.TrackingNumber,
+		Warehouse:      req.Warehouse,
+		Items:          req.Items,
+	})
+	if err != nil {
+		h.respondError(c, err, "Failed to ship order", "order_id", orderID)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Order shipped", "data": resp})
+}
+
+// ListShipments returns the shipments of an order, oldest first.
+//
+//	@Summary	List shipments of an order
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Order ID"
+//	@Success	200	{object}	Response{data=[]service.ShipmentResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/orders/{id}/shipments [get]
+func (h *FulfillmentHandler) ListShipments(c *gin.Context) {
+	orderID, ok := parseIDParam(c, "id", "order")
+	if !ok {
+		return
+	}
+
+	shipments, err := h.fulfillmentService.ListShipments(c.Request.Context(), orderID)
+	if err != nil {
+		h.respondError(c, err, "Failed to list shipments", "order_id", orderID)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": shipments})
+}
+
+// SetShipmentTracking replaces the tracking number of a shipment.
+//
+//	@Summary	Set the tracking number of a shipment
+//	@Tags		admin
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id		path		integer					true	"Shipment ID"
+//	@Param		request	body		ShipmentTrackingRequest	true	"Tracking number"
+//	@Success	200		{object}	Response{data=service.ShipmentResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	404		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/shipments/{id}/tracking [put]
+func (h *FulfillmentHandler) SetShipmentTracking(c *gin.Context) {
+	shipmentID, ok := parseIDParam(c, "id", "shipment")
+	if !ok {
+		return
+	}
+	var req ShipmentTrackingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.fulfillmentService.SetTrackingNumber(c.Request.Context(), shipmentID, req.TrackingNumber)
+	if err != nil {
+		h.respondError(c, err, "Failed to set shipment tracking number", "shipment_id", shipmentID)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Tracking number set", "data": resp})
+}
+
+// DeliverShipment records a shipment delivered.
+//
+//	@Summary		Mark a shipment delivered
+//	@Description	The order's fulfillment moves to partially_delivered, then delivered once everything has shipped and every shipment is delivered. Delivering a shipment again changes nothing.
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		integer	true	"Shipment ID"
+//	@Success		200	{object}	Response{data=service.ShipmentResp}
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/shipments/{id}/deliver [post]
+func (h *FulfillmentHandler) DeliverShipment(c *gin.Context) {
+	shipmentID, ok := parseIDParam(c, "id", "shipment")
+	if !ok {
+		return
+	}
+
+	resp, err := h.fulfillmentService.Deliver(c.Request.Context(), shipmentID)
+	if err != nil {
+		h.respondError(c, err, "Failed to deliver shipment", "shipment_id", shipmentID)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Shipment delivered", "data": resp})
+}
+
+// CancelOrder cancels an order that is pending or authorized and has not
+// shipped, restoring its stock and releasing its authorization.
+//
+//	@Summary	Cancel an order
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Order ID"
+//	@Success	200	{object}	Response
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	409	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/orders/{id}/cancel [post]
+func (h *FulfillmentHandler) CancelOrder(c *gin.Context) {
+	orderID, ok := parseIDParam(c, "id", "order")
+	if !ok {
+		return
+	}
+
+	if err := h.fulfillmentService.Cancel(c.Request.Context(), orderID); err != nil {
+		h.respondError(c, err, "Failed to cancel order", "order_id", orderID)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Order cancelled"})
+}
+
+func (h *FulfillmentHandler) respondError(c *gin.Context, err error, msg string, args ...any) {
+	switch {
+	case errors.Is(err, service.ErrInvalidShipmentItem):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+	case errors.Is(err, service.ErrOrderNotFound), errors.Is(err, service.ErrShipmentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+	case errors.Is(err, service.ErrOrderNotShippable), errors.Is(err, service.ErrNothingToShip), errors.Is(err, service.ErrOrderBackordered),
+		errors.Is(err, service.ErrOrderNotCancellable), errors.Is(err, service.ErrOrderShipped):
+		c.JSON(http.StatusConflict, gin.H{"code": http.StatusConflict, "message": err.Error()})
+	default:
+		slog.ErrorContext(c.Request.Context(), msg, append(args, logger.Err(err))...)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFulfillmentHandler_ShipOrder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	captured := money.New(decimal.RequireFromString("33.00"), "USD")
+
+	tests := []struct {
+		name       string
+		id         string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockFulfillmentService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			id:      "5",
+			reqBody: `{"tracking_number":"SF1","items":[{"order_item_id":"11","quantity":1}]}`,
+			mockSetup: func(mockService *mocks.MockFulfillmentService) {
+				mockService.EXPECT().Ship(gomock.Any(), &service.ShipOrderReq{
+					OrderID: 5, TrackingNumber: "SF1", Items: []service.ShipmentItemReq{{OrderItemID: 11, Quantity: 1}},
+				}).Return(&service.ShipmentResp{ID: 9, OrderID: 5, TrackingNumber: "SF1", Captured: &captured}, nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"captured":`,
+		},
+		{
+			name:    "InvalidItem",
+			id:      "5",
+			reqBody: `{"items":[{"order_item_id":"11","quantity":3}]}`,
+			mockSetup: func(mockService *mocks.MockFulfillmentService) {
+				mockService.EXPECT().Ship(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("%w: 3 of order item 11", service.ErrInvalidShipmentItem))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid shipment item",
+		},
+		{
+			name:    "NotShippable",
+			id:      "5",
+			reqBody: `{}`,
+			mockSetup: func(mockService *mocks.MockFulfillmentService) {
+				mockService.EXPECT().Ship(gomock.Any(), gomock.Any()).Return(nil, service.ErrOrderNotShippable)
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   "not awaiting shipment",
+		},
+		{
+			name:    "NotFound",
+			id:      "5",
+			reqBody: `{}`,
+			mockSetup: func(mockService *mocks.MockFulfillmentService) {
+				mockService.EXPECT().Ship(gomock.Any(), gomock.Any()).Return(nil, service.ErrOrderNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   "order not found",
+		},
+		{name: "InvalidID", id: "abc", reqBody: `{}`, wantStatus: http.StatusBadRequest, wantBody: "invalid order id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockFulfillmentService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewFulfillmentHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}}
+
+			var err error
+			c.Request, err = http.NewRequest(http.MethodPost, "/admin/orders/"+tt.id+"/shipments", bytes.NewBufferString(tt.reqBody))
+			require.NoError(t, err)
+
+			handler.ShipOrder(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestFulfillmentHandler_CancelOrder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "Success", wantStatus: http.StatusOK},
+		{name: "Shipped", err: service.ErrOrderShipped, wantStatus: http.StatusConflict},
+		{name: "Paid", err: service.ErrOrderNotCancellable, wantStatus: http.StatusConflict},
+		{name: "NotFound", err: service.ErrOrderNotFound, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockFulfillmentService(ctrl)
+			mockService.EXPECT().Cancel(gomock.Any(), uint64(5)).Return(tt.err)
+			handler := NewFulfillmentHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "5"}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/orders/5/cancel", nil)
+
+			handler.CancelOrder(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestFulfillmentHandler_DeliverShipment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		id         string
+		err        error
+		wantStatus int
+	}{
+		{name: "Success", id: "21", wantStatus: http.StatusOK},
+		{name: "NotFound", id: "21", err: service.ErrShipmentNotFound, wantStatus: http.StatusNotFound},
+		{name: "InvalidID", id: "abc", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockFulfillmentService(ctrl)
+			if tt.id == "21" {
+				var resp *service.ShipmentResp
+				if tt.err == nil {
+					resp = &service.ShipmentResp{ID: 21, Status: "delivered"}
+				}
+				mockService.EXPECT().Deliver(gomock.Any(), uint64(21)).Return(resp, tt.err)
+			}
+			handler := NewFulfillmentHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/shipments/"+tt.id+"/deliver", nil)
+
+			handler.DeliverShipment(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
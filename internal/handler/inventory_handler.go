@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// InventoryHandler defines the HTTP handlers external warehouse systems sync
+// stock with.
+type InventoryHandler struct {
+	inventoryService service.InventorySyncService
+}
+
+// NewInventoryHandler creates a new InventoryHandler instance.
+func NewInventoryHandler(inventoryService service.InventorySyncService) *InventoryHandler {
+	return &InventoryHandler{inventoryService: inventoryService}
+}
+
+// StockSyncRequest defines the request body for syncing stock.
+type StockSyncRequest struct {
+	Corrections []StockCorrectionRequest `json:"corrections" binding:"required,min=1,max=1000,dive"`
+}
+
+// StockCorrectionRequest sets the stock of one SKU.
+type StockCorrectionRequest struct {
+	SKUID         uint64 `json:"sku_id,string" binding:"required,gt=0" example:"1234567890"`
+	Stock         *int   `json:"stock" binding:"required,min=0" example:"12"`
+	ExpectedStock *int   `json:"expected_stock" binding:"omitempty,min=0" example:"10"` // The stock in the snapshot the correction was made against; omitted sets the stock regardless
+}
+
+// StockSnapshot streams the stock of the store's SKUs.
+//
+//	@Summary		Export a stock snapshot
+//	@Description	Streams one JSON object per line (NDJSON) with the stock of each SKU, in SKU ID order. To read the next page, pass the last sku_id received as cursor; a page with fewer than limit lines is the last.
+//	@Tags			admin
+//	@Produce		application/x-ndjson
+//	@Security		BearerAuth
+//	@Param			cursor	query		string	false	"Last SKU ID of the previous page"
+//	@Param			limit	query		integer	false	"SKUs per page"	minimum(1)	maximum(100000)	default(1000)
+//	@Success		200		{object}	service.StockLevel
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/inventory/snapshot [get]
+func (h *InventoryHandler) StockSnapshot(c *gin.Context) {
+	var cursor uint64
+	if raw := c.Query("cursor"); raw != "" {
+		var err error
+		if cursor, err = strconv.ParseUint(raw, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "invalid cursor"})
+			return
+		}
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(service.DefaultStockSnapshotLimit)))
+	if err != nil || limit < 1 || limit > service.MaxStockSnapshotLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "limit must be between 1 and 100000"})
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	err = h.inventoryService.ExportSnapshot(c.Request.Context(), cursor, limit, c.Writer)
+	if err == nil {
+		return
+	}
+	if c.Writer.Written() {
+		// The status is sent: the client sees a truncated page
+		slog.ErrorContext(c.Request.Context(), "Failed to finish stock snapshot", "cursor", cursor, logger.Err(err))
+		return
+	}
+	c.Writer.Header().Del("Content-Type")
+	slog.ErrorContext(c.Request.Context(), "Failed to export stock snapshot", "cursor", cursor, logger.Err(err))
+	c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+}
+
+// SyncStock applies stock corrections from an external warehouse system.
+//
+//	@Summary		Sync stock
+//	@Description	Sets the stock of each listed SKU. A correction with expected_stock is only applied if the SKU still has that stock, so that units ordered since the snapshot are not counted twice; otherwise, or if the SKU does not exist, it is listed under conflicts and the others are still applied. SKUs are synced in chunks of 200, each in its own transaction, and every change is audited. If a chunk fails after others were saved, the response is 207 and counts the SKUs that were synced.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		StockSyncRequest	true	"Stock corrections"
+//	@Success		200		{object}	Response{data=service.StockSyncResp}
+//	@Success		207		{object}	Response{data=service.StockSyncResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/inventory/sync [post]
+func (h *InventoryHandler) SyncStock(c *gin.Context) {
+	var req StockSyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	corrections := make([]service.StockCorrection, 0, len(req.Corrections))
+	for _, correction := range req.Corrections {
+		corrections = append(corrections, service.StockCorrection{
+			SKUID:         correction.SKUID,
+			Stock:         *correction.Stock,
+			ExpectedStock: correction.ExpectedStock,
+		})
+	}
+	resp, err := h.inventoryService.SyncStock(c.Request.Context(), corrections)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+	case errors.Is(err, service.ErrInvalidStockSync):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+	case resp != nil:
+		slog.ErrorContext(c.Request.Context(), "Failed to finish stock sync", "applied", resp.Applied, logger.Err(err))
+		c.JSON(http.StatusMultiStatus, gin.H{"code": http.StatusMultiStatus, "message": "Some corrections were not applied", "data": resp})
+	default:
+		slog.ErrorContext(c.Request.Context(), "Failed to sync stock", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
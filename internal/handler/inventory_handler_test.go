@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestInventoryHandler_StockSnapshot(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		query           string
+		wantCursor      uint64
+		wantLimit       int
+		err             error
+		wantStatus      int
+		wantContentType string
+	}{
+		{name: "FirstPage", wantLimit: service.DefaultStockSnapshotLimit, wantStatus: http.StatusOK, wantContentType: "application/x-ndjson"},
+		{name: "NextPage", query: "?cursor=102&limit=50", wantCursor: 102, wantLimit: 50, wantStatus: http.StatusOK, wantContentType: "application/x-ndjson"},
+		{name: "InvalidCursor", query: "?cursor=abc", wantStatus: http.StatusBadRequest, wantContentType: "application/json; charset=utf-8"},
+		{name: "LimitTooLarge", query: "?limit=100001", wantStatus: http.StatusBadRequest, wantContentType: "application/json; charset=utf-8"},
+		{
+			name:            "ServiceError",
+			wantLimit:       service.DefaultStockSnapshotLimit,
+			err:             errors.New("db down"),
+			wantStatus:      http.StatusInternalServerError,
+			wantContentType: "application/json; charset=utf-8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockInventorySyncService(ctrl)
+			if tt.wantLimit > 0 {
+				mockService.EXPECT().ExportSnapshot(gomock.Any(), tt.wantCursor, tt.wantLimit, gomock.Any()).DoAndReturn(func(_ context.Context, _ uint64, _ int, w io.Writer) error {
+					if tt.err != nil {
+						return tt.err
+					}
+					_, err := io.WriteString(w, "{\"sku_id\":\"103\",\"spu_id\":\"11\",\"stock\":5}\n")
+					return err
+				})
+			}
+			handler := NewInventoryHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/inventory/snapshot"+tt.query, nil)
+
+			handler.StockSnapshot(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantContentType, w.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestInventoryHandler_SyncStock(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	seven := 7
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockInventorySyncService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"corrections":[{"sku_id":"101","stock":9,"expected_stock":7}]}`,
+			mockSetup: func(mockService *mocks.MockInventorySyncService) {
+				mockService.EXPECT().SyncStock(gomock.Any(), []service.StockCorrection{{SKUID: 101, Stock: 9, ExpectedStock: &seven}}).Return(&service.StockSyncResp{Applied: 1, Conflicts: []service.StockConflict{}}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"applied":1`,
+		},
+		{name: "NoCorrections", reqBody: `{"corrections":[]}`, wantStatus: http.StatusBadRequest, wantBody: `"field":"corrections"`},
+		{name: "NegativeStock", reqBody: `{"corrections":[{"sku_id":"101","stock":-1}]}`, wantStatus: http.StatusBadRequest},
+		{name: "MissingStock", reqBody: `{"corrections":[{"sku_id":"101"}]}`, wantStatus: http.StatusBadRequest},
+		{
+			name:    "Duplicate",
+			reqBody: `{"corrections":[{"sku_id":"101","stock":1},{"sku_id":"101","stock":2}]}`,
+			mockSetup: func(mockService *mocks.MockInventorySyncService) {
+				mockService.EXPECT().SyncStock(gomock.Any(), gomock.Len(2)).Return(nil, service.ErrInvalidStockSync)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "PartialFailure",
+			reqBody: `{"corrections":[{"sku_id":"101","stock":0}]}`,
+			mockSetup: func(mockService *mocks.MockInventorySyncService) {
+				mockService.EXPECT().SyncStock(gomock.Any(), gomock.Any()).Return(&service.StockSyncResp{Applied: 200}, errors.New("db down"))
+			},
+			wantStatus: http.StatusMultiStatus,
+			wantBody:   `"applied":200`,
+		},
+		{
+			name:    "ServiceError",
+			reqBody: `{"corrections":[{"sku_id":"101","stock":0}]}`,
+			mockSetup: func(mockService *mocks.MockInventorySyncService) {
+				mockService.EXPECT().SyncStock(gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockInventorySyncService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewInventoryHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/inventory/sync", bytes.NewBufferString(tt.reqBody))
+
+			handler.SyncStock(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"log"
+	"os"
+	"testing"
+
+	"github.com/proyuen/go-mall/pkg/validation"
+)
+
+func TestMain(m *testing.M) {
+	// Handlers rely on JSON field names and message catalogs registered on Gin's validator.
+	if err := validation.Init(); err != nil {
+		log.Printf("FATAL: Failed to initialize validation: %v", err)
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// MerchandisingHandler defines the HTTP handlers for tuning search results.
+type MerchandisingHandler struct {
+	merchandisingService service.MerchandisingService
+}
+
+// NewMerchandisingHandler creates a new MerchandisingHandler instance.
+func NewMerchandisingHandler(merchandisingService service.MerchandisingService) *MerchandisingHandler {
+	return &MerchandisingHandler{merchandisingService: merchandisingService}
+}
+
+// CreateSearchRuleRequest defines the request body for creating a search rule.
+type CreateSearchRuleRequest struct {
+	Query    string `json:"query" binding:"required,max=100" example:"running shoes"`
+	SPUID    uint64 `json:"spu_id,string" binding:"required"`
+	Action   string `json:"action" binding:"required,oneof=boost bury" example:"boost"`
+	Position int    `json:"position" binding:"min=0,max=9999"` // Order among the query's boosts, lowest first
+}
+
+// ZeroResultQuery defines the paging of the queries that found nothing.
+type ZeroResultQuery struct {
+	Offset int `form:"offset" binding:"min=0"`
+	Limit  int `form:"limit" binding:"min=0,max=100"`
+}
+
+// ListSearchRules returns the search rules of the store.
+//
+//	@Summary	List search rules
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Success	200	{object}	Response{data=[]service.SearchRuleResp}
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/search/rules [get]
+func (h *MerchandisingHandler) ListSearchRules(c *gin.Context) {
+	rules, err := h.merchandisingService.ListRules(c.Request.Context())
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list search rules", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": rules})
+}
+
+// CreateSearchRule boosts or buries a product for a search query.
+//
+//	@Summary		Create a search rule
+//	@Description	A boosted product is suggested first for the query, by position, whether or not its name matches; a buried one is never suggested for it. The query matches as typed, ignoring case and extra spaces. Rules apply from the next rebuild of the suggestion index.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		CreateSearchRuleRequest	true	"Search rule payload"
+//	@Success		201		{object}	Response{data=service.SearchRuleResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/search/rules [post]
+func (h *MerchandisingHandler) CreateSearchRule(c *gin.Context) {
+	var req CreateSearchRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.merchandisingService.CreateRule(c.Request.Context(), &service.SearchRuleReq{
+		Query:    req.Query,
+		SPUID:    req.SPUID,
+		Action:   req.Action,
+		Position: req.Position,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidSearchRule):
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+		case errors.Is(err, service.ErrProductNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+		default:
+			slog.ErrorContext(c.Request.Context(), "Failed to create search rule", "spu_id", req.SPUID, logger.Err(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Search rule created", "data": resp})
+}
+
+// DeleteSearchRule deletes a search rule.
+//
+//	@Summary	Delete a search rule
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Search rule ID"
+//	@Success	200	{object}	Response
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/search/rules/{id} [delete]
+func (h *MerchandisingHandler) DeleteSearchRule(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "search rule")
+	if !ok {
+		return
+	}
+
+	if err := h.merchandisingService.DeleteRule(c.Request.Context(), id); err != nil {
+		if errors.Is(err, service.ErrSearchRuleNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to delete search rule", "search_rule_id", id, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Search rule deleted"})
+}
+
+// ListZeroResults returns the search queries of the store that suggested
+// nothing, most searched first.
+//
+//	@Summary		List zero result searches
+//	@Description	Counts are flushed from Redis by cmd/worker every suggest.schedule, so the latest searches show up after the next flush.
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			query	query		ZeroResultQuery	false	"Paging"
+//	@Success		200		{object}	Response{data=service.ZeroResultListResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/search/zero-results [get]
+func (h *MerchandisingHandler) ListZeroResults(c *gin.Context) {
+	var query ZeroResultQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.merchandisingService.ListZeroResults(c.Request.Context(), query.Offset, query.Limit)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list zero result searches", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMerchandisingHandler_CreateSearchRule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockMerchandisingService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"query":"running shoes","spu_id":"11","action":"boost","position":1}`,
+			mockSetup: func(mockService *mocks.MockMerchandisingService) {
+				mockService.EXPECT().CreateRule(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *service.SearchRuleReq) (*service.SearchRuleResp, error) {
+					assert.Equal(t, &service.SearchRuleReq{Query: "running shoes", SPUID: 11, Action: "boost", Position: 1}, req)
+					return &service.SearchRuleResp{ID: 8, Query: req.Query, SPUID: req.SPUID, Action: req.Action, Position: req.Position}, nil
+				})
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"id":"8"`,
+		},
+		{name: "UnknownAction", reqBody: `{"query":"shoes","spu_id":"11","action":"pin"}`, wantStatus: http.StatusBadRequest, wantBody: `"field":"action"`},
+		{
+			name:    "InvalidRule",
+			reqBody: `{"query":"  ","spu_id":"11","action":"bury"}`,
+			mockSetup: func(mockService *mocks.MockMerchandisingService) {
+				mockService.EXPECT().CreateRule(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("%w: query cannot be blank", service.ErrInvalidSearchRule))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "query cannot be blank",
+		},
+		{
+			name:    "UnknownProduct",
+			reqBody: `{"query":"shoes","spu_id":"11","action":"bury"}`,
+			mockSetup: func(mockService *mocks.MockMerchandisingService) {
+				mockService.EXPECT().CreateRule(gomock.Any(), gomock.Any()).Return(nil, service.ErrProductNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:    "ServiceError",
+			reqBody: `{"query":"shoes","spu_id":"11","action":"bury"}`,
+			mockSetup: func(mockService *mocks.MockMerchandisingService) {
+				mockService.EXPECT().CreateRule(gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockMerchandisingService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewMerchandisingHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/search/rules", bytes.NewBufferString(tt.reqBody))
+
+			handler.CreateSearchRule(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestMerchandisingHandler_ListZeroResults(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantCall   bool
+		wantStatus int
+	}{
+		{name: "Success", query: "?offset=20&limit=10", wantCall: true, wantStatus: http.StatusOK},
+		{name: "LimitTooLarge", query: "?limit=101", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockMerchandisingService(ctrl)
+			if tt.wantCall {
+				mockService.EXPECT().ListZeroResults(gomock.Any(), 20, 10).Return(&service.ZeroResultListResp{Total: 0}, nil)
+			}
+			handler := NewMerchandisingHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/search/zero-results"+tt.query, nil)
+
+			handler.ListZeroResults(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
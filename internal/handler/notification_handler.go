@@ -0,0 +1,394 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// maxWebhookBody bounds SNS posts; SES events are a few kilobytes.
+const maxWebhookBody = 256 << 10
+
+// NotificationHandler defines the HTTP handlers for notification preferences,
+// push devices, the in-app inbox, delivery status and provider callbacks.
+type NotificationHandler struct {
+	notificationService notification.Service
+	webhookToken        string
+}
+
+// NewNotificationHandler creates a new NotificationHandler instance. An empty
+// webhookToken disables the SES webhook.
+func NewNotificationHandler(notificationService notification.Service, webhookToken string) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService, webhookToken: webhookToken}
+}
+
+// NotificationPreferencesRequest defines the request body for updating
+// notification preferences. Events maps each category to the channels it is
+// received on; categories left out get their defaults. Account emails such as
+// password resets are always sent; an empty phone turns SMS off.
+type NotificationPreferencesRequest struct {
+	Phone  string              `json:"phone" binding:"omitempty,phone" example:"+8613800138000"`
+	Events map[string][]string `json:"events" binding:"required,dive,keys,oneof=order shipment promotion price_alert,endkeys,dive,oneof=email sms push inbox"`
+}
+
+// PushDeviceRequest defines the request body for registering a push device.
+// Platform is the service that issued the token, not the device OS.
+type PushDeviceRequest struct {
+	Platform string `json:"platform" binding:"required,oneof=fcm apns" example:"fcm"`
+	Token    string `json:"token" binding:"required,max=255" example:"dXNlci1kZXZpY2UtdG9rZW4"`
+}
+
+// PushDeviceQuery defines the query parameters for unregistering a push device.
+type PushDeviceQuery struct {
+	Token string `form:"token" binding:"required,max=255"`
+}
+
+// InboxQuery defines the query parameters for listing the caller's
+// notifications.
+type InboxQuery struct {
+	Unread bool `form:"unread"` // Only unread notifications
+	Offset int  `form:"offset" binding:"min=0"`
+	Limit  int  `form:"limit" binding:"min=0,max=100"`
+}
+
+// DeliveryQuery defines the query parameters for listing notification deliveries.
+type DeliveryQuery struct {
+	UserID uint64 `form:"user_id" binding:"required,min=1" example:"1"`
+	Limit  int    `form:"limit" binding:"min=0,max=100"`
+}
+
+// GetPreferences returns the caller's notification preferences.
+//
+//	@Summary	Get notification preferences
+//	@Tags		users
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Success	200	{object}	Response{data=notification.Preferences}
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/users/me/notification-preferences [get]
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+
+	prefs, err := h.notificationService.Preferences(c.Request.Context(), userID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to get notification preferences", "user_id", userID, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": prefs})
+}
+
+// UpdatePreferences replaces the caller's notification preferences.
+//
+//	@Summary		Update notification preferences
+//	@Description	events maps order, shipment, promotion and price_alert to the channels, of email, sms, push and inbox, they are received on. Categories left out get their defaults: every channel, except none for promotion.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		NotificationPreferencesRequest	true	"Preferences payload"
+//	@Success		200		{object}	Response{data=notification.Preferences}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/users/me/notification-preferences [put]
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+
+	var req NotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	events := make(map[notification.Category][]notification.Channel, len(req.Events))
+	for category, channels := range req.Events {
+		events[notification.Category(category)] = make([]notification.Channel, len(channels))
+		for i, channel := range channels {
+			events[notification.Category(category)][i] = notification.Channel(channel)
+		}
+	}
+	prefs, err := h.notificationService.UpdatePreferences(c.Request.Context(), userID, notification.Preferences{Phone: req.Phone, Events: events})
+	if err != nil {
+		if errors.Is(err, notification.ErrUnknownCategory) || errors.Is(err, notification.ErrUnknownChannel) {
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to update notification preferences", "user_id", userID, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Notification preferences updated", "data": prefs})
+}
+
+// RegisterPushDevice adds a device token to the caller's account. Registering a
+// token that belongs to another account moves it to the caller.
+//
+//	@Summary	Register a push device
+//	@Tags		users
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		request	body		PushDeviceRequest	true	"Device payload"
+//	@Success	201		{object}	Response
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/users/me/push-devices [post]
+func (h *NotificationHandler) RegisterPushDevice(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+
+	var req PushDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	if err := h.notificationService.RegisterPushDevice(c.Request.Context(), userID, notification.Platform(req.Platform), req.Token); err != nil {
+		if errors.Is(err, notification.ErrUnknownPlatform) {
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to register push device", "user_id", userID, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Push device registered"})
+}
+
+// UnregisterPushDevice removes a device token from the caller's account.
+//
+//	@Summary	Unregister a push device
+//	@Tags		users
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		query	query		PushDeviceQuery	true	"Device token"
+//	@Success	200		{object}	Response
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	404		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/users/me/push-devices [delete]
+func (h *NotificationHandler) UnregisterPushDevice(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+
+	var query PushDeviceQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	if err := h.notificationService.UnregisterPushDevice(c.Request.Context(), userID, query.Token); err != nil {
+		if errors.Is(err, notification.ErrPushDeviceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to unregister push device", "user_id", userID, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Push device unregistered"})
+}
+
+// ListNotifications returns the caller's in-app notifications, newest first,
+// with the number unread.
+//
+//	@Summary	List my notifications
+//	@Tags		users
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		query	query		InboxQuery	false	"Filters"
+//	@Success	200		{object}	Response{data=notification.Inbox}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/users/me/notifications [get]
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+
+	var query InboxQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	inbox, err := h.notificationService.Inbox(c.Request.Context(), userID, query.Unread, query.Offset, query.Limit)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list notifications", "user_id", userID, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": inbox})
+}
+
+// MarkNotificationRead marks one of the caller's notifications read.
+//
+//	@Summary	Mark a notification read
+//	@Tags		users
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		string	true	"Notification ID"
+//	@Success	200	{object}	Response
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/users/me/notifications/{id}/read [post]
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	id, ok := parseIDParam(c, "id", "notification")
+	if !ok {
+		return
+	}
+
+	if err := h.notificationService.MarkRead(c.Request.Context(), userID, id); err != nil {
+		if errors.Is(err, notification.ErrNotificationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to mark notification read", "user_id", userID, "notification_id", id, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Notification marked read"})
+}
+
+// MarkAllNotificationsRead marks every notification of the caller read.
+//
+//	@Summary	Mark all my notifications read
+//	@Tags		users
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Success	200	{object}	Response{data=map[string]int64}
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/users/me/notifications/read-all [post]
+func (h *NotificationHandler) MarkAllNotificationsRead(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+
+	marked, err := h.notificationService.MarkAllRead(c.Request.Context(), userID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to mark notifications read", "user_id", userID, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Notifications marked read", "data": gin.H{"marked": marked}})
+}
+
+// ListDeliveries returns the delivery status of a user's notifications, newest first.
+//
+//	@Summary	List notification deliveries
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		query	query		DeliveryQuery	true	"Filters"
+//	@Success	200		{object}	Response{data=[]notification.DeliveryStatus}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/notification-deliveries [get]
+func (h *NotificationHandler) ListDeliveries(c *gin.Context) {
+	var query DeliveryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	deliveries, err := h.notificationService.Deliveries(c.Request.Context(), query.UserID, query.Limit)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list notification deliveries", "user_id", query.UserID, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": deliveries})
+}
+
+// SESWebhook receives SES bounce and complaint events through an SNS HTTPS
+// subscription and suppresses the affected addresses. SNS must call it with
+// ?token= set to notification.ses.webhook_token.
+func (h *NotificationHandler) SESWebhook(c *gin.Context) {
+	if h.webhookToken == "" {
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": "Not Found"})
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(h.webhookToken)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": "invalid webhook token"})
+		return
+	}
+
+	// SNS posts JSON as text/plain, so the body is decoded directly.
+	var envelope notification.SNSEnvelope
+	if err := json.NewDecoder(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBody)).Decode(&envelope); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "invalid request body"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	switch envelope.Type {
+	case notification.SNSTypeSubscriptionConfirmation:
+		// Confirming means visiting the URL; an operator does it once per topic.
+		slog.WarnContext(ctx, "SES webhook subscription needs confirmation", "topic_arn", envelope.TopicArn, "subscribe_url", envelope.SubscribeURL)
+	case notification.SNSTypeNotification:
+		bounces, err := notification.ParseSESEvent(envelope.Message)
+		if err != nil {
+			slog.WarnContext(ctx, "Invalid SES notification", "message_id", envelope.MessageID, logger.Err(err))
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "invalid ses notification"})
+			return
+		}
+		for _, b := range bounces {
+			if err := h.notificationService.RecordBounce(ctx, b.Email, b.Reason); err != nil {
+				// SNS retries on failure, and suppressing an address twice is harmless.
+				slog.ErrorContext(ctx, "Failed to record email bounce", "message_id", envelope.MessageID, logger.Err(err))
+				c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+				return
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success"})
+}
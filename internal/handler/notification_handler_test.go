@@ -0,0 +1,387 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNotificationHandler_UpdatePreferences(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"phone":"+8613800138000","events":{"order":["email","push"],"promotion":[]}}`,
+			mockSetup: func(mockService *mocks.MockService) {
+				prefs := notification.Preferences{Phone: "+8613800138000", Events: map[notification.Category][]notification.Channel{
+					notification.CategoryOrder:     {notification.ChannelEmail, notification.ChannelPush},
+					notification.CategoryPromotion: {},
+				}}
+				mockService.EXPECT().UpdatePreferences(gomock.Any(), uint64(1), prefs).Return(&prefs, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"order":["email","push"]`,
+		},
+		{
+			name:       "MissingField",
+			reqBody:    `{"phone":"+8613800138000"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"field":"events","rule":"required"`,
+		},
+		{
+			name:       "UnknownCategory",
+			reqBody:    `{"events":{"newsletter":["email"]}}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"rule":"oneof"`,
+		},
+		{
+			name:       "UnknownChannel",
+			reqBody:    `{"events":{"order":["fax"]}}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"rule":"oneof"`,
+		},
+		{
+			name:       "InvalidPhone",
+			reqBody:    `{"phone":"call me","events":{}}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"field":"phone","rule":"phone"`,
+		},
+		{
+			name:    "ServiceError",
+			reqBody: `{"events":{"order":["push"]}}`,
+			mockSetup: func(mockService *mocks.MockService) {
+				mockService.EXPECT().UpdatePreferences(gomock.Any(), uint64(1), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewNotificationHandler(mockService, "")
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			var err error
+			c.Request, err = http.NewRequest(http.MethodPut, "/users/me/notification-preferences", bytes.NewBufferString(tt.reqBody))
+			require.NoError(t, err)
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			handler.UpdatePreferences(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestNotificationHandler_PushDevices(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockService)
+		wantStatus int
+	}{
+		{
+			name:    "Register",
+			method:  http.MethodPost,
+			target:  "/users/me/push-devices",
+			reqBody: `{"platform":"apns","token":"abc123"}`,
+			mockSetup: func(mockService *mocks.MockService) {
+				mockService.EXPECT().RegisterPushDevice(gomock.Any(), uint64(1), notification.PlatformAPNs, "abc123").Return(nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "RegisterUnknownPlatform",
+			method:     http.MethodPost,
+			target:     "/users/me/push-devices",
+			reqBody:    `{"platform":"ios","token":"abc123"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Unregister",
+			method: http.MethodDelete,
+			target: "/users/me/push-devices?token=abc123",
+			mockSetup: func(mockService *mocks.MockService) {
+				mockService.EXPECT().UnregisterPushDevice(gomock.Any(), uint64(1), "abc123").Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "UnregisterNotFound",
+			method: http.MethodDelete,
+			target: "/users/me/push-devices?token=abc123",
+			mockSetup: func(mockService *mocks.MockService) {
+				mockService.EXPECT().UnregisterPushDevice(gomock.Any(), uint64(1), "abc123").Return(notification.ErrPushDeviceNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "UnregisterMissingToken",
+			method:     http.MethodDelete,
+			target:     "/users/me/push-devices",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewNotificationHandler(mockService, "")
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			var err error
+			c.Request, err = http.NewRequest(tt.method, tt.target, bytes.NewBufferString(tt.reqBody))
+			require.NoError(t, err)
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			if tt.method == http.MethodPost {
+				handler.RegisterPushDevice(c)
+			} else {
+				handler.UnregisterPushDevice(c)
+			}
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestNotificationHandler_Inbox(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		id         string
+		mockSetup  func(mockService *mocks.MockService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:   "ListUnread",
+			method: http.MethodGet,
+			target: "/users/me/notifications?unread=true&limit=10",
+			mockSetup: func(mockService *mocks.MockService) {
+				mockService.EXPECT().Inbox(gomock.Any(), uint64(1), true, 0, 10).Return(&notification.Inbox{
+					Items:  []notification.InboxItem{{ID: 9, Kind: notification.KindShipment, Title: "Shipped"}},
+					Total:  1,
+					Unread: 1,
+				}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"unread":1`,
+		},
+		{
+			name:       "ListLimitTooLarge",
+			method:     http.MethodGet,
+			target:     "/users/me/notifications?limit=500",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"rule":"max"`,
+		},
+		{
+			name:   "MarkRead",
+			method: http.MethodPost,
+			target: "/users/me/notifications/9/read",
+			id:     "9",
+			mockSetup: func(mockService *mocks.MockService) {
+				mockService.EXPECT().MarkRead(gomock.Any(), uint64(1), uint64(9)).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "MarkReadNotFound",
+			method: http.MethodPost,
+			target: "/users/me/notifications/9/read",
+			id:     "9",
+			mockSetup: func(mockService *mocks.MockService) {
+				mockService.EXPECT().MarkRead(gomock.Any(), uint64(1), uint64(9)).Return(notification.ErrNotificationNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "MarkReadInvalidID",
+			method:     http.MethodPost,
+			target:     "/users/me/notifications/abc/read",
+			id:         "abc",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "MarkAllRead",
+			method: http.MethodPost,
+			target: "/users/me/notifications/read-all",
+			mockSetup: func(mockService *mocks.MockService) {
+				mockService.EXPECT().MarkAllRead(gomock.Any(), uint64(1)).Return(int64(3), nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"marked":3`,
+		},
+		{
+			name:   "MarkAllReadServiceError",
+			method: http.MethodPost,
+			target: "/users/me/notifications/read-all",
+			mockSetup: func(mockService *mocks.MockService) {
+				mockService.EXPECT().MarkAllRead(gomock.Any(), uint64(1)).Return(int64(0), errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewNotificationHandler(mockService, "")
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			var err error
+			c.Request, err = http.NewRequest(tt.method, tt.target, nil)
+			require.NoError(t, err)
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			switch {
+			case tt.method == http.MethodGet:
+				handler.ListNotifications(c)
+			case tt.id != "":
+				c.Params = gin.Params{{Key: "id", Value: tt.id}}
+				handler.MarkNotificationRead(c)
+			default:
+				handler.MarkAllNotificationsRead(c)
+			}
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestNotificationHandler_SESWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	envelope := func(typ, message string) string {
+		body, err := json.Marshal(notification.SNSEnvelope{Type: typ, MessageID: "msg-1", Message: message, SubscribeURL: "https://sns.example.com/confirm"})
+		require.NoError(t, err)
+		return string(body)
+	}
+	bounce := `{"eventType":"Bounce","bounce":{"bounceType":"Permanent","bounceSubType":"General","bouncedRecipients":[{"emailAddress":"gone@example.com"}]}}`
+
+	tests := []struct {
+		name       string
+		token      string
+		query      string
+		body       string
+		mockSetup  func(mockService *mocks.MockService)
+		wantStatus int
+	}{
+		{
+			name:       "Disabled",
+			query:      "?token=",
+			body:       envelope(notification.SNSTypeNotification, bounce),
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "WrongToken",
+			token:      "secret",
+			query:      "?token=guess",
+			body:       envelope(notification.SNSTypeNotification, bounce),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "SubscriptionConfirmation",
+			token:      "secret",
+			query:      "?token=secret",
+			body:       envelope(notification.SNSTypeSubscriptionConfirmation, ""),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "Bounce",
+			token: "secret",
+			query: "?token=secret",
+			body:  envelope(notification.SNSTypeNotification, bounce),
+			mockSetup: func(mockService *mocks.MockService) {
+				mockService.EXPECT().RecordBounce(gomock.Any(), "gone@example.com", gomock.Any()).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "RecordFailure",
+			token: "secret",
+			query: "?token=secret",
+			body:  envelope(notification.SNSTypeNotification, bounce),
+			mockSetup: func(mockService *mocks.MockService) {
+				mockService.EXPECT().RecordBounce(gomock.Any(), "gone@example.com", gomock.Any()).Return(errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "MalformedEvent",
+			token:      "secret",
+			query:      "?token=secret",
+			body:       envelope(notification.SNSTypeNotification, "not json"),
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewNotificationHandler(mockService, tt.token)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			var err error
+			c.Request, err = http.NewRequest(http.MethodPost, "/webhooks/ses"+tt.query, bytes.NewBufferString(tt.body))
+			require.NoError(t, err)
+
+			handler.SESWebhook(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// NotificationTemplateHandler defines the HTTP handlers for managing the
+// templates of transactional messages.
+type NotificationTemplateHandler struct {
+	templateService service.NotificationTemplateService
+}
+
+// NewNotificationTemplateHandler creates a new NotificationTemplateHandler instance.
+func NewNotificationTemplateHandler(templateService service.NotificationTemplateService) *NotificationTemplateHandler {
+	return &NotificationTemplateHandler{templateService: templateService}
+}
+
+// NotificationTemplateRequest defines the request body for saving a
+// notification template, in Go template syntax with {{.Brand}},
+// {{.Username}} and the kind's data as {{.Data}}. Subject is the email
+// subject or push and inbox title and Text the plain text of an email; SMS
+// only has a body.
+type NotificationTemplateRequest struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body" binding:"required"` // Email HTML content, inside the shared layout, or SMS or push text
+	Text    string `json:"text"`
+}
+
+// NotificationPreviewRequest defines the request body for previewing a
+// notification template. Without a body, the template currently sent for
+// the locale is previewed.
+type NotificationPreviewRequest struct {
+	Kind    string          `json:"kind" binding:"required,oneof=order_confirmation order_failed shipment password_reset digital_delivery broadcast" example:"shipment"`
+	Channel string          `json:"channel" binding:"required,oneof=email sms push inbox" example:"email"`
+	Locale  string          `json:"locale" binding:"omitempty,bcp47_language_tag" example:"zh"` // Empty means the default locale
+	Subject string          `json:"subject"`
+	Body    string          `json:"body"`
+	Text    string          `json:"text"`
+	Data    json.RawMessage `json:"data" swaggertype:"object"` // The kind's data; empty uses sample data
+}
+
+// NotificationTestSendRequest defines the request body for sending a
+// notification template to the caller.
+type NotificationTestSendRequest struct {
+	Kind    string          `json:"kind" binding:"required,oneof=order_confirmation order_failed shipment password_reset digital_delivery broadcast" example:"shipment"`
+	Channel string          `json:"channel" binding:"required,oneof=email sms push inbox" example:"email"`
+	Locale  string          `json:"locale" binding:"omitempty,bcp47_language_tag" example:"zh"` // Empty means the default locale
+	Data    json.RawMessage `json:"data" swaggertype:"object"`                                  // The kind's data; empty uses sample data
+}
+
+// ListNotificationTemplates returns the latest version of every stored
+// notification template.
+//
+//	@Summary		List notification templates
+//	@Description	Kinds, channels and locales without a stored template are sent with the one built into the server.
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	Response{data=[]service.NotificationTemplateResp}
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/notification-templates [get]
+func (h *NotificationTemplateHandler) ListNotificationTemplates(c *gin.Context) {
+	templates, err := h.templateService.List(c.Request.Context())
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list notification templates", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": templates})
+}
+
+// ListNotificationTemplateVersions returns every version of a notification
+// template, newest first.
+//
+//	@Summary	List notification template versions
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		kind	path		string	true	"Notification kind, e.g. shipment"
+//	@Param		channel	path		string	true	"email, sms, push or inbox"
+//	@Param		locale	path		string	true	"BCP 47 language tag, e.g. zh or pt-BR"
+//	@Success	200		{object}	Response{data=[]service.NotificationTemplateResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	404		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/notification-templates/{kind}/{channel}/{locale}/versions [get]
+func (h *NotificationTemplateHandler) ListNotificationTemplateVersions(c *gin.Context) {
+	versions, err := h.templateService.Versions(c.Request.Context(), notification.Kind(c.Param("kind")), notification.Channel(c.Param("channel")), c.Param("locale"))
+	if err != nil {
+		respondNotificationTemplateError(c, "Failed to list notification template versions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": versions})
+}
+
+// PutNotificationTemplate saves a new version of a notification template.
+//
+//	@Summary		Save a notification template
+//	@Description	The template must render with the kind's sample data. It becomes the template's latest version, which is sent from then on to deliveries in the locale, in a more specific one without its own template (pt-BR falls back to pt), and, for the default locale, to those in locales with no template.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			kind	path		string						true	"Notification kind, e.g. shipment"
+//	@Param			channel	path		string						true	"email, sms, push or inbox"
+//	@Param			locale	path		string						true	"BCP 47 language tag, e.g. zh or pt-BR"
+//	@Param			request	body		NotificationTemplateRequest	true	"Template payload"
+//	@Success		200		{object}	Response{data=service.NotificationTemplateResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/notification-templates/{kind}/{channel}/{locale} [put]
+func (h *NotificationTemplateHandler) PutNotificationTemplate(c *gin.Context) {
+	var req NotificationTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.templateService.Save(c.Request.Context(), &service.NotificationTemplateReq{
+		Kind:     notification.Kind(c.Param("kind")),
+		Channel:  notification.Channel(c.Param("channel")),
+		Locale:   c.Param("locale"),
+		Template: notification.Template{Subject: req.Subject, Body: req.Body, Text: req.Text},
+	})
+	if err != nil {
+		respondNotificationTemplateError(c, "Failed to save notification template", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Notification template saved", "data": resp})
+}
+
+// DeleteNotificationTemplate deletes every version of a notification
+// template, so that the built-in one is sent again.
+//
+//	@Summary	Delete a notification template
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		kind	path		string	true	"Notification kind, e.g. shipment"
+//	@Param		channel	path		string	true	"email, sms, push or inbox"
+//	@Param		locale	path		string	true	"BCP 47 language tag, e.g. zh or pt-BR"
+//	@Success	200		{object}	Response
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	404		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/notification-templates/{kind}/{channel}/{locale} [delete]
+func (h *NotificationTemplateHandler) DeleteNotificationTemplate(c *gin.Context) {
+	err := h.templateService.Delete(c.Request.Context(), notification.Kind(c.Param("kind")), notification.Channel(c.Param("channel")), c.Param("locale"))
+	if err != nil {
+		respondNotificationTemplateError(c, "Failed to delete notification template", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Notification template deleted"})
+}
+
+// PreviewNotificationTemplate renders a draft or current notification
+// template without sending it.
+//
+//	@Summary		Preview a notification template
+//	@Description	Renders the draft in the request, or without a body the template currently sent for the locale, with the given data or the kind's sample data. Emails render a subject, text and HTML; SMS a text; push and inbox a subject, their title, and a text.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		NotificationPreviewRequest	true	"Preview payload"
+//	@Success		200		{object}	Response{data=notification.Preview}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/notification-templates/preview [post]
+func (h *NotificationTemplateHandler) PreviewNotificationTemplate(c *gin.Context) {
+	var req NotificationPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	preview := &service.NotificationPreviewReq{
+		Kind:    notification.Kind(req.Kind),
+		Channel: notification.Channel(req.Channel),
+		Locale:  req.Locale,
+		Data:    req.Data,
+	}
+	if req.Body != "" {
+		preview.Template = &notification.Template{Subject: req.Subject, Body: req.Body, Text: req.Text}
+	}
+	resp, err := h.templateService.Preview(c.Request.Context(), preview)
+	if err != nil {
+		respondNotificationTemplateError(c, "Failed to preview notification template", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+}
+
+// TestSendNotificationTemplate sends the current notification template to
+// the caller.
+//
+//	@Summary		Test send a notification template
+//	@Description	Delivers the template currently sent for the locale to the caller at once, with the given data or the kind's sample data. The caller's notification preferences, phone number and push devices apply as to any delivery; the result says whether it was sent.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		NotificationTestSendRequest	true	"Test send payload"
+//	@Success		200		{object}	Response
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/notification-templates/test-send [post]
+func (h *NotificationTemplateHandler) TestSendNotificationTemplate(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	var req NotificationTestSendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	result, err := h.templateService.TestSend(c.Request.Context(), userID, notification.Kind(req.Kind), notification.Channel(req.Channel), req.Locale, req.Data)
+	if err != nil {
+		respondNotificationTemplateError(c, "Failed to test send notification template", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Test notification delivered", "data": gin.H{"result": result}})
+}
+
+// respondNotificationTemplateError maps the errors of the notification
+// template service to responses, logging unexpected ones with msg.
+func respondNotificationTemplateError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidNotificationTemplate),
+		errors.Is(err, notification.ErrInvalidData),
+		errors.Is(err, notification.ErrUnknownKind),
+		errors.Is(err, notification.ErrUnknownChannel):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+	case errors.Is(err, service.ErrNotificationTemplateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+	default:
+		slog.ErrorContext(c.Request.Context(), msg, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNotificationTemplateHandler_PutNotificationTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockNotificationTemplateService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"body":"Order {{.Data.OrderNumber}} shipped"}`,
+			mockSetup: func(mockService *mocks.MockNotificationTemplateService) {
+				mockService.EXPECT().Save(gomock.Any(), &service.NotificationTemplateReq{
+					Kind:     notification.KindShipment,
+					Channel:  notification.ChannelSMS,
+					Locale:   "zh",
+					Template: notification.Template{Body: "Order {{.Data.OrderNumber}} shipped"},
+				}).Return(&service.NotificationTemplateResp{Kind: "shipment", Channel: "sms", Locale: "zh", Version: 3}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"version":3`,
+		},
+		{
+			name:       "MissingBody",
+			reqBody:    `{"subject":"Shipped"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"field":"body","rule":"required"`,
+		},
+		{
+			name:    "InvalidTemplate",
+			reqBody: `{"body":"{{.Data.Total}}"}`,
+			mockSetup: func(mockService *mocks.MockNotificationTemplateService) {
+				mockService.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("%w: can't evaluate field Total", service.ErrInvalidNotificationTemplate))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "can't evaluate field Total",
+		},
+		{
+			name:    "ServiceError",
+			reqBody: `{"body":"Shipped"}`,
+			mockSetup: func(mockService *mocks.MockNotificationTemplateService) {
+				mockService.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockNotificationTemplateService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewNotificationTemplateHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "kind", Value: "shipment"}, {Key: "channel", Value: "sms"}, {Key: "locale", Value: "zh"}}
+
+			var err error
+			c.Request, err = http.NewRequest(http.MethodPut, "/admin/notification-templates/shipment/sms/zh", bytes.NewBufferString(tt.reqBody))
+			require.NoError(t, err)
+
+			handler.PutNotificationTemplate(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestNotificationTemplateHandler_PreviewAndTestSend(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		target     string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockNotificationTemplateService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "PreviewDraft",
+			target:  "/preview",
+			reqBody: `{"kind":"shipment","channel":"push","subject":"Shipped","body":"On its way"}`,
+			mockSetup: func(mockService *mocks.MockNotificationTemplateService) {
+				mockService.EXPECT().Preview(gomock.Any(), &service.NotificationPreviewReq{
+					Kind:     notification.KindShipment,
+					Channel:  notification.ChannelPush,
+					Template: &notification.Template{Subject: "Shipped", Body: "On its way"},
+				}).Return(&notification.Preview{Subject: "Shipped", Text: "On its way"}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"text":"On its way"`,
+		},
+		{
+			name:    "PreviewCurrent",
+			target:  "/preview",
+			reqBody: `{"kind":"shipment","channel":"sms","locale":"zh"}`,
+			mockSetup: func(mockService *mocks.MockNotificationTemplateService) {
+				mockService.EXPECT().Preview(gomock.Any(), &service.NotificationPreviewReq{Kind: notification.KindShipment, Channel: notification.ChannelSMS, Locale: "zh"}).
+					Return(&notification.Preview{Text: "Shipped"}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"text":"Shipped"`,
+		},
+		{
+			name:       "PreviewUnknownKind",
+			target:     "/preview",
+			reqBody:    `{"kind":"newsletter","channel":"sms"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"field":"kind","rule":"oneof"`,
+		},
+		{
+			name:    "PreviewInvalidData",
+			target:  "/preview",
+			reqBody: `{"kind":"shipment","channel":"sms","data":{"order_number":"ORD1"}}`,
+			mockSetup: func(mockService *mocks.MockNotificationTemplateService) {
+				mockService.EXPECT().Preview(gomock.Any(), gomock.Any()).Return(nil, notification.ErrInvalidData)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   notification.ErrInvalidData.Error(),
+		},
+		{
+			name:    "TestSend",
+			target:  "/test-send",
+			reqBody: `{"kind":"shipment","channel":"email"}`,
+			mockSetup: func(mockService *mocks.MockNotificationTemplateService) {
+				mockService.EXPECT().TestSend(gomock.Any(), uint64(1), notification.KindShipment, notification.ChannelEmail, "", gomock.Any()).Return(notification.ResultOptedOut, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"result":"opted_out"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockNotificationTemplateService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewNotificationTemplateHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			var err error
+			c.Request, err = http.NewRequest(http.MethodPost, "/admin/notification-templates"+tt.target, bytes.NewBufferString(tt.reqBody))
+			require.NoError(t, err)
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			if tt.target == "/preview" {
+				handler.PreviewNotificationTemplate(c)
+			} else {
+				handler.TestSendNotificationTemplate(c)
+			}
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
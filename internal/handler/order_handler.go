@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/model"
 	"github.com/proyuen/go-mall/internal/service"
-	"github.com/proyuen/go-mall/pkg/utils"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/money"
 )
 
 // OrderHandler defines the HTTP handlers for order-related operations.
@@ -21,17 +24,72 @@ func NewOrderHandler(orderService service.OrderService) *OrderHandler {
 // CreateOrderRequest defines the request body for creating an order.
 type CreateOrderRequest struct {
 	Items []CreateOrderItemRequest `json:"items" binding:"required,min=1,dive"`
+	// Region is where the order ships: an ISO 3166-1 alpha-2 country or ISO
+	// 3166-2 subdivision code. Required when tax depends on it.
+	Region string `json:"region" binding:"omitempty,iso3166_1_alpha2|iso3166_2" example:"DE"`
+	// ShippingAddress is where the order ships to within the region.
+	ShippingAddress AddressRequest `json:"shipping_address"`
+	// PaymentMethodID is one of the caller's saved payment methods to pay
+	// the order with right away. Without it the order waits for payment.
+	PaymentMethodID uint64 `json:"payment_method_id,string" example:"1234567890"`
+	// CouponCode unlocks the promotion of its coupon campaign. The coupon
+	// is redeemed when the order is placed.
+	CouponCode string `json:"coupon_code" binding:"omitempty,max=40" example:"SUMMER-7KQ2M9XD"`
+	// ExpectedTotal is the total shown to the customer. When set, the order
+	// is refused with 409 and the differences unless it totals exactly this.
+	ExpectedTotal *money.Money `json:"expected_total"`
+}
+
+// AddressRequest defines a shipping address.
+type AddressRequest struct {
+	Name       string `json:"name" binding:"max=100" example:"Erika Mustermann"`
+	Phone      string `json:"phone" binding:"max=20" example:"+49 30 1234567"`
+	Line1      string `json:"line1" binding:"max=255" example:"Heidestraße 17"`
+	Line2      string `json:"line2" binding:"max=255"`
+	City       string `json:"city" binding:"max=100" example:"Berlin"`
+	PostalCode string `json:"postal_code" binding:"max=20" example:"10557"`
+}
+
+// address maps the request to the address stored with an order.
+func (r *AddressRequest) address() model.Address {
+	return model.Address{Name: r.Name, Phone: r.Phone, Line1: r.Line1, Line2: r.Line2, City: r.City, PostalCode: r.PostalCode}
 }
 
 // CreateOrderItemRequest defines the request body for an item within an order.
 type CreateOrderItemRequest struct {
-	SKUID    uint64 `json:"sku_id" binding:"required,gt=0"`
-	Quantity int    `json:"quantity" binding:"required,gt=0"`
+	SKUID         uint64       `json:"sku_id" binding:"required,gt=0"`
+	Quantity      int          `json:"quantity" binding:"required,gt=0"`
+	ExpectedPrice *money.Money `json:"expected_price"` // Unit price shown to the customer, options included; checked like expected_total
+	// Options are the product's options chosen for every unit, which add
+	// their prices to the unit price.
+	Options []OrderItemOptionRequest `json:"options" binding:"omitempty,max=20,dive"`
+}
+
+// OrderItemOptionRequest chooses a product option for an order item.
+type OrderItemOptionRequest struct {
+	Code  string `json:"code" binding:"required,max=40" example:"engraving"`
+	Value string `json:"value" binding:"max=255" example:"Happy birthday"` // A text option's; empty for flags
 }
 
 // CreateOrder handles the creation of a new order.
+//
+//	@Summary		Place an order
+//	@Description	The order is charged in the Accept-Currency currency, else the user's preferred currency, else the store's base currency. SKUs priced in another currency are converted at the current exchange rate. Tax is added to the subtotal as the store's tax strategy decides, which may depend on the region. With a payment_method_id the saved payment method is charged once the order is placed; if that fails the order is still created, pending payment, and payment_error says why. With expected_total or an item's expected_price, the order is refused with 409 and the prices that changed unless it is priced exactly so, in the same currency. SKUs and promotions with a purchase_limit cap the units one customer buys across their orders; an order that would pass one is refused with 409, and cancelled orders give their units back. Items of pre_order SKUs are ordered whatever their stock and marked backordered; once paid, such an order is backordered until a worker allocates arrived stock to it, oldest orders first. An order with items restricted from shipping to its region, or with components of its bundles that are, is refused with 400, reason shipping_restricted and the offending items in metadata.items.
+//	@Tags			orders
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request			body		CreateOrderRequest	true	"Order payload"
+//	@Param			Accept-Currency	header		string				false	"ISO 4217 currency code"
+//	@Success		201				{object}	Response{data=service.OrderCreateResp}
+//	@Failure		400				{object}	ErrorResponse
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		409				{object}	Response{data=service.PriceChangedError}
+//	@Failure		500				{object}	ErrorResponse
+//	@Failure		503				{object}	ErrorResponse
+//	@Router		/orders [post]
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
-	userID, err := utils.GetUserIDFromContext(c)
+	userID, err := auth.UserID(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
 		return
@@ -39,29 +97,167 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 
 	var req CreateOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
-	// Map handler request DTO to service request DTO
-	var serviceItems []service.OrderItemReq
-	for _, item := range req.Items {
-		serviceItems = append(serviceItems, service.OrderItemReq{
-			SKUID:    item.SKUID,
-			Quantity: item.Quantity,
-		})
+	serviceReq := newOrderCreateReq(c, userID, &req)
+	resp, err := h.orderService.CreateOrder(c.Request.Context(), serviceReq)
+	if err != nil {
+		respondOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Order created successfully", "data": resp})
+}
+
+// CreateCheckoutSession previews an order: it is priced and taxed like
+// CreateOrder, and its prices and totals are held for a while, so that the
+// order confirmed is the one the customer saw.
+//
+//	@Summary		Start checkout
+//	@Description	Prices are converted and tax is added as for placing an order. The session holds them until expires_at; confirming it places the order at those prices even if the SKUs are repriced meanwhile. Stock is only reserved once it is confirmed.
+//	@Tags			orders
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request			body		CreateOrderRequest	true	"Order payload"
+//	@Param			Accept-Currency	header		string				false	"ISO 4217 currency code"
+//	@Success		201				{object}	Response{data=service.CheckoutSessionResp}
+//	@Failure		400				{object}	ErrorResponse
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		409				{object}	Response{data=service.PriceChangedError}
+//	@Failure		500				{object}	ErrorResponse
+//	@Failure		503				{object}	ErrorResponse
+//	@Router			/checkout/sessions [post]
+func (h *OrderHandler) CreateCheckoutSession(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	var req CreateOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
 	}
 
-	serviceReq := &service.OrderCreateReq{
-		UserID: userID,
-		Items:  serviceItems,
+	resp, err := h.orderService.CreateCheckoutSession(c.Request.Context(), newOrderCreateReq(c, userID, &req))
+	if err != nil {
+		respondOrderError(c, err)
+		return
 	}
 
-	resp, err := h.orderService.CreateOrder(c.Request.Context(), serviceReq)
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "success", "data": resp})
+}
+
+// GetCheckoutSession returns one of the caller's checkout sessions.
+//
+//	@Summary	Get a checkout session
+//	@Tags		orders
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		string	true	"Checkout session ID"
+//	@Success	200	{object}	Response{data=service.CheckoutSessionResp}
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/checkout/sessions/{id} [get]
+func (h *OrderHandler) GetCheckoutSession(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+
+	resp, err := h.orderService.GetCheckoutSession(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		respondOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+}
+
+// ConfirmCheckoutSession places the order of one of the caller's checkout
+// sessions at the prices it holds. A session places one order.
+//
+//	@Summary	Confirm checkout
+//	@Tags		orders
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		string	true	"Checkout session ID"
+//	@Success	201	{object}	Response{data=service.OrderCreateResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	409	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/checkout/sessions/{id}/confirm [post]
+func (h *OrderHandler) ConfirmCheckoutSession(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+
+	resp, err := h.orderService.ConfirmCheckoutSession(c.Request.Context(), userID, c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": err.Error()})
+		respondOrderError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Order created successfully", "data": resp})
-}
\ No newline at end of file
+}
+
+// newOrderCreateReq maps the request body to the service request.
+func newOrderCreateReq(c *gin.Context, userID uint64, req *CreateOrderRequest) *service.OrderCreateReq {
+	items := make([]service.OrderItemReq, 0, len(req.Items))
+	for _, item := range req.Items {
+		options := make([]service.OrderItemOptionReq, len(item.Options))
+		for i, option := range item.Options {
+			options[i] = service.OrderItemOptionReq{Code: option.Code, Value: option.Value}
+		}
+		items = append(items, service.OrderItemReq{
+			SKUID:         item.SKUID,
+			Quantity:      item.Quantity,
+			ExpectedPrice: item.ExpectedPrice,
+			Options:       options,
+		})
+	}
+	return &service.OrderCreateReq{
+		UserID:          userID,
+		Currency:        c.GetHeader(acceptCurrencyHeader),
+		Region:          req.Region,
+		ShippingAddress: req.ShippingAddress.address(),
+		Items:           items,
+		PaymentMethodID: req.PaymentMethodID,
+		CouponCode:      req.CouponCode,
+		ExpectedTotal:   req.ExpectedTotal,
+	}
+}
+
+// respondOrderError writes the response for an error pricing or placing an
+// order.
+func respondOrderError(c *gin.Context, err error) {
+	var changed *service.PriceChangedError
+	switch {
+	case errors.As(err, &changed):
+		c.JSON(http.StatusConflict, gin.H{"code": http.StatusConflict, "message": service.ErrPriceChanged.Error(), "data": changed})
+	case errors.Is(err, service.ErrUnsupportedCurrency):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "unsupported currency"})
+	case errors.Is(err, service.ErrPaymentMethodNotFound), errors.Is(err, service.ErrInvalidProductOption):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+	case errors.Is(err, service.ErrTaxRegionRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "region is required to calculate tax"})
+	case errors.Is(err, service.ErrTaxRegionNotSupported):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "orders cannot ship to this region"})
+	case errors.Is(err, service.ErrTaxUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"code": http.StatusServiceUnavailable, "message": "tax cannot be calculated right now"})
+	case errors.Is(err, service.ErrRatesUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"code": http.StatusServiceUnavailable, "message": "prices cannot be converted into this currency right now"})
+	default:
+		// Coupons, purchase limits, stock, shipping restrictions and expired sessions
+		respondError(c, err)
+	}
+}
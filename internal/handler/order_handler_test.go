@@ -2,8 +2,10 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -11,8 +13,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/proyuen/go-mall/internal/mocks"
 	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/money"
 	"github.com/proyuen/go-mall/pkg/token"
-	"github.com/proyuen/go-mall/pkg/utils"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,6 +24,7 @@ import (
 
 func TestOrderHandler_CreateOrder(t *testing.T) {
 	gin.SetMode(gin.TestMode)
+	expectedPrice := money.New(decimal.NewFromInt(45), "USD")
 
 	type fields struct {
 		mockSetup func(mockService *mocks.MockOrderService)
@@ -51,7 +55,7 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 					mockService.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(&service.OrderCreateResp{
 						OrderID:     1,
 						OrderNumber: "ORD123",
-						TotalAmount: decimal.NewFromFloat(100.0),
+						TotalAmount: money.New(decimal.NewFromFloat(100.0), "USD"),
 					}, nil)
 				},
 			},
@@ -72,7 +76,7 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 				},
 			},
 			wantStatus: http.StatusBadRequest,
-			wantBody:   "Field validation", // Validator error
+			wantBody:   `{"field":"items","rule":"min"`, // Validator error
 		},
 		{
 			name: "InvalidInput_InvalidQuantity",
@@ -90,7 +94,67 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 				},
 			},
 			wantStatus: http.StatusBadRequest,
-			wantBody:   "Field validation",
+			wantBody:   `{"field":"items[0].quantity","rule":"required"`,
+		},
+		{
+			name: "InvalidInput_Region",
+			args: args{
+				userID:  1,
+				reqBody: CreateOrderRequest{Items: []CreateOrderItemRequest{{SKUID: 101, Quantity: 2}}, Region: "Germany"},
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"field":"region"`,
+		},
+		{
+			name: "RegionNotSupported",
+			args: args{
+				userID:  1,
+				reqBody: CreateOrderRequest{Items: []CreateOrderItemRequest{{SKUID: 101, Quantity: 2}}, Region: "US-CA"},
+			},
+			fields: fields{
+				mockSetup: func(mockService *mocks.MockOrderService) {
+					mockService.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *service.OrderCreateReq) (*service.OrderCreateResp, error) {
+						assert.Equal(t, "US-CA", req.Region)
+						return nil, fmt.Errorf("%w: US-CA", service.ErrTaxRegionNotSupported)
+					})
+				},
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "orders cannot ship to this region",
+		},
+		{
+			name: "TaxUnavailable",
+			args: args{
+				userID:  1,
+				reqBody: CreateOrderRequest{Items: []CreateOrderItemRequest{{SKUID: 101, Quantity: 2}}},
+			},
+			fields: fields{
+				mockSetup: func(mockService *mocks.MockOrderService) {
+					mockService.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("%w: timeout", service.ErrTaxUnavailable))
+				},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "tax cannot be calculated right now",
+		},
+		{
+			name: "PriceChanged",
+			args: args{
+				userID:  1,
+				reqBody: CreateOrderRequest{Items: []CreateOrderItemRequest{{SKUID: 101, Quantity: 2, ExpectedPrice: &expectedPrice}}},
+			},
+			fields: fields{
+				mockSetup: func(mockService *mocks.MockOrderService) {
+					mockService.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *service.OrderCreateReq) (*service.OrderCreateResp, error) {
+						require.NotNil(t, req.Items[0].ExpectedPrice)
+						return nil, &service.PriceChangedError{
+							Items:       []service.PriceChange{{SKUID: 101, Expected: *req.Items[0].ExpectedPrice, Actual: money.New(decimal.NewFromInt(50), "USD")}},
+							ActualTotal: money.New(decimal.NewFromInt(100), "USD"),
+						}
+					})
+				},
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   `"items":[{"sku_id":"101","expected":{"amount":"45.00","currency":"USD"},"actual":{"amount":"50.00","currency":"USD"}}]`,
 		},
 		{
 			name: "ServiceError",
@@ -110,6 +174,20 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 			wantStatus: http.StatusInternalServerError,
 			wantBody:   "out of stock",
 		},
+		{
+			name: "InsufficientStock",
+			args: args{
+				userID:  1,
+				reqBody: CreateOrderRequest{Items: []CreateOrderItemRequest{{SKUID: 101, Quantity: 2}}},
+			},
+			fields: fields{
+				mockSetup: func(mockService *mocks.MockOrderService) {
+					mockService.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("failed to deduct stock: %w", service.ErrInsufficientStock.WithSKU(101)))
+				},
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   `"message":"insufficient stock","metadata":{"sku_id":101},"reason":"stock_insufficient"`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -125,13 +203,13 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 
 			// Simulate AuthMiddleware setting context
 			claims := &token.Payload{UserID: tt.args.userID}
-			c.Set(utils.AuthorizationPayloadKey, claims)
 
 			jsonBody, err := json.Marshal(tt.args.reqBody)
 			require.NoError(t, err)
 
 			c.Request, err = http.NewRequest("POST", "/orders", bytes.NewBuffer(jsonBody))
 			require.NoError(t, err)
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), claims))
 
 			if tt.fields.mockSetup != nil {
 				tt.fields.mockSetup(mockService)
@@ -145,4 +223,41 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestOrderHandler_ConfirmCheckoutSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "Success", wantStatus: http.StatusCreated},
+		{name: "Expired", err: service.ErrCheckoutSessionNotFound, wantStatus: http.StatusNotFound},
+		{name: "PaymentMethodRemoved", err: service.ErrPaymentMethodNotFound, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockOrderService(ctrl)
+			var resp *service.OrderCreateResp
+			if tt.err == nil {
+				resp = &service.OrderCreateResp{OrderID: 1, TotalAmount: money.New(decimal.NewFromInt(100), "USD")}
+			}
+			mockService.EXPECT().ConfirmCheckoutSession(gomock.Any(), uint64(1), "abc").Return(resp, tt.err)
+			handler := NewOrderHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "abc"}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/checkout/sessions/abc/confirm", nil)
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			handler.ConfirmCheckoutSession(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// OrderHistoryHandler defines the HTTP handlers for customers' order lists.
+type OrderHistoryHandler struct {
+	orderHistoryService service.OrderHistoryService
+}
+
+// NewOrderHistoryHandler creates a new OrderHistoryHandler instance.
+func NewOrderHistoryHandler(orderHistoryService service.OrderHistoryService) *OrderHistoryHandler {
+	return &OrderHistoryHandler{orderHistoryService: orderHistoryService}
+}
+
+// OrderListQuery defines the query parameters for listing the caller's orders.
+type OrderListQuery struct {
+	Offset int `form:"offset" binding:"min=0"`
+	Limit  int `form:"limit" binding:"min=0,max=100"`
+}
+
+// ListOrders returns the caller's orders, newest first.
+//
+//	@Summary		List my orders
+//	@Description	Orders are listed from a summary kept up to date by a worker, so a change shows a few seconds after it is made. Items are as they were when the order was placed.
+//	@Tags			orders
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			query	query		OrderListQuery	false	"Paging"
+//	@Success		200		{object}	Response{data=service.OrderSummaryListResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/orders [get]
+func (h *OrderHistoryHandler) ListOrders(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+
+	var query OrderListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	orders, err := h.orderHistoryService.List(c.Request.Context(), userID, query.Offset, query.Limit)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list orders", "user_id", userID, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": orders})
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOrderHistoryHandler_ListOrders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		target     string
+		mockSetup  func(mockService *mocks.MockOrderHistoryService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:   "Success",
+			target: "/orders?offset=20&limit=10",
+			mockSetup: func(mockService *mocks.MockOrderHistoryService) {
+				mockService.EXPECT().List(gomock.Any(), uint64(1), 20, 10).Return(&service.OrderSummaryListResp{
+					Orders: []service.OrderSummaryResp{{
+						OrderID:     42,
+						OrderNumber: "ORD-42",
+						Status:      "paid",
+						TotalAmount: money.New(decimal.NewFromInt(30), "USD"),
+						ItemCount:   2,
+						Items:       []service.OrderSummaryItemResp{{SKUID: 101, Name: "Mug", Image: "mug.jpg", Quantity: 2, Price: money.New(decimal.NewFromInt(15), "USD")}},
+					}},
+					Total: 21,
+				}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"name":"Mug"`,
+		},
+		{
+			name:       "LimitTooLarge",
+			target:     "/orders?limit=500",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "ServiceError",
+			target: "/orders",
+			mockSetup: func(mockService *mocks.MockOrderHistoryService) {
+				mockService.EXPECT().List(gomock.Any(), uint64(1), 0, 0).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockOrderHistoryService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewOrderHistoryHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			var err error
+			c.Request, err = http.NewRequest(http.MethodGet, tt.target, nil)
+			require.NoError(t, err)
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			handler.ListOrders(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
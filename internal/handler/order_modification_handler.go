@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// OrderModificationHandler defines the HTTP handlers for customers changing
+// their orders before they ship.
+type OrderModificationHandler struct {
+	modificationService service.OrderModificationService
+}
+
+// NewOrderModificationHandler creates a new OrderModificationHandler instance.
+func NewOrderModificationHandler(modificationService service.OrderModificationService) *OrderModificationHandler {
+	return &OrderModificationHandler{modificationService: modificationService}
+}
+
+// ItemQuantityRequest defines the request body for changing the quantity of
+// an order item.
+type ItemQuantityRequest struct {
+	Quantity int `json:"quantity" binding:"required,min=1" example:"2"`
+}
+
+// ChangeItemQuantity changes the quantity of an item of one of the caller's
+// orders.
+//
+//	@Summary		Change the quantity of an order item
+//	@Description	Only orders that are pending, authorized or paid, and not yet being picked or shipped, can be changed. The item's discount and the order's tax scale with the quantity; the unit price stays what it was. A paid order is settled right away: the difference is charged to the saved payment method it was paid with, or refunded. An authorized order can only go down. Bundles, digital and backordered items cannot change.
+//	@Tags			orders
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		integer				true	"Order ID"
+//	@Param			item_id	path		integer				true	"Order item ID"
+//	@Param			request	body		ItemQuantityRequest	true	"New quantity"
+//	@Success		200		{object}	Response{data=service.OrderModificationResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		402		{object}	ErrorResponse	"Payment method declined the difference"
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse	"Order can no longer change, not enough stock, or purchase limit exceeded"
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/orders/{id}/items/{item_id} [put]
+func (h *OrderModificationHandler) ChangeItemQuantity(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	orderID, ok := parseIDParam(c, "id", "order")
+	if !ok {
+		return
+	}
+	itemID, ok := parseIDParam(c, "item_id", "order item")
+	if !ok {
+		return
+	}
+	var req ItemQuantityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	modification, err := h.modificationService.ChangeQuantity(c.Request.Context(), userID, orderID, itemID, req.Quantity)
+	if err != nil {
+		respondOrderModificationError(c, "Failed to change order item quantity", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Order item quantity changed", "data": modification})
+}
+
+// ChangeShippingAddress changes where one of the caller's orders ships to.
+//
+//	@Summary		Change the shipping address of my order
+//	@Description	Only orders that are still to ship, and have not shipped in part, can be changed. The region, which decided the tax, stays; the address is checked against the format of its country and normalized.
+//	@Tags			orders
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		integer			true	"Order ID"
+//	@Param			request	body		AddressRequest	true	"New shipping address"
+//	@Success		200		{object}	Response{data=service.OrderModificationResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/orders/{id}/shipping-address [put]
+func (h *OrderModificationHandler) ChangeShippingAddress(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	orderID, ok := parseIDParam(c, "id", "order")
+	if !ok {
+		return
+	}
+	var req AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	modification, err := h.modificationService.ChangeShippingAddress(c.Request.Context(), userID, orderID, req.address())
+	if err != nil {
+		respondOrderModificationError(c, "Failed to change shipping address", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Shipping address changed", "data": modification})
+}
+
+// ListOrderModifications returns the changes the caller made to one of
+// their orders, oldest first.
+//
+//	@Summary	List the modifications of my order
+//	@Tags		orders
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Order ID"
+//	@Success	200	{object}	Response{data=[]service.OrderModificationResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/orders/{id}/modifications [get]
+func (h *OrderModificationHandler) ListOrderModifications(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	orderID, ok := parseIDParam(c, "id", "order")
+	if !ok {
+		return
+	}
+
+	modifications, err := h.modificationService.List(c.Request.Context(), userID, orderID)
+	if err != nil {
+		respondOrderModificationError(c, "Failed to list order modifications", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": modifications})
+}
+
+// respondOrderModificationError maps the errors of the order modification
+// service to responses, logging unexpected ones with msg.
+func respondOrderModificationError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidOrderModification):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+	case errors.Is(err, service.ErrPaymentDeclined):
+		c.JSON(http.StatusPaymentRequired, gin.H{"code": http.StatusPaymentRequired, "message": "payment method declined"})
+	case errors.Is(err, service.ErrOrderItemNotFound), errors.Is(err, service.ErrProductNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+	case errors.Is(err, service.ErrOrderNotModifiable), errors.Is(err, service.ErrOrderShipped), errors.Is(err, service.ErrOrderDisputed):
+		c.JSON(http.StatusConflict, gin.H{"code": http.StatusConflict, "message": err.Error()})
+	case errors.Is(err, service.ErrOrderNotFound), errors.Is(err, service.ErrInsufficientStock), errors.Is(err, service.ErrPurchaseLimitExceeded),
+		errors.Is(err, service.ErrInvalidAddress):
+		respondError(c, err)
+	default:
+		slog.ErrorContext(c.Request.Context(), msg, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
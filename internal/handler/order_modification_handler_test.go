@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOrderModificationHandler_ChangeItemQuantity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		itemID     string
+		reqBody    string
+		err        error
+		noCall     bool
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "Success", itemID: "11", reqBody: `{"quantity":1}`, wantStatus: http.StatusOK, wantBody: `"adjustment":"refund"`},
+		{name: "NoQuantity", itemID: "11", reqBody: `{}`, noCall: true, wantStatus: http.StatusBadRequest, wantBody: `"field":"quantity"`},
+		{name: "InvalidItemID", itemID: "abc", reqBody: `{"quantity":1}`, noCall: true, wantStatus: http.StatusBadRequest},
+		{name: "Unchanged", itemID: "11", reqBody: `{"quantity":1}`, err: fmt.Errorf("%w: quantity is unchanged", service.ErrInvalidOrderModification), wantStatus: http.StatusBadRequest},
+		{name: "OrderNotFound", itemID: "11", reqBody: `{"quantity":1}`, err: service.ErrOrderNotFound, wantStatus: http.StatusNotFound},
+		{name: "ItemNotFound", itemID: "11", reqBody: `{"quantity":1}`, err: service.ErrOrderItemNotFound, wantStatus: http.StatusNotFound},
+		{name: "BeingPicked", itemID: "11", reqBody: `{"quantity":1}`, err: fmt.Errorf("%w: it is being picked", service.ErrOrderNotModifiable), wantStatus: http.StatusConflict},
+		{name: "Shipped", itemID: "11", reqBody: `{"quantity":1}`, err: service.ErrOrderShipped, wantStatus: http.StatusConflict},
+		{name: "InsufficientStock", itemID: "11", reqBody: `{"quantity":9}`, err: service.ErrInsufficientStock.WithSKU(5), wantStatus: http.StatusConflict, wantBody: `"reason":"stock_insufficient"`},
+		{name: "Declined", itemID: "11", reqBody: `{"quantity":3}`, err: service.ErrPaymentDeclined, wantStatus: http.StatusPaymentRequired},
+		{name: "ServiceError", itemID: "11", reqBody: `{"quantity":1}`, err: errors.New("db down"), wantStatus: http.StatusInternalServerError, wantBody: "Internal Server Error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockOrderModificationService(ctrl)
+			if !tt.noCall {
+				var resp *service.OrderModificationResp
+				if tt.err == nil {
+					resp = &service.OrderModificationResp{
+						ID: 1, OrderID: 7, Kind: model.OrderModificationQuantity, OrderItemID: 11,
+						AmountDelta: money.New(decimal.RequireFromString("-9.90"), "USD"), Adjustment: model.PaymentAdjustmentRefund,
+					}
+				}
+				mockService.EXPECT().ChangeQuantity(gomock.Any(), uint64(3), uint64(7), uint64(11), gomock.Any()).Return(resp, tt.err)
+			}
+			handler := NewOrderModificationHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "7"}, {Key: "item_id", Value: tt.itemID}}
+			c.Request = httptest.NewRequest(http.MethodPut, "/api/v1/orders/7/items/"+tt.itemID, bytes.NewBufferString(tt.reqBody))
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 3}))
+
+			handler.ChangeItemQuantity(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestOrderModificationHandler_ChangeShippingAddress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "Success", wantStatus: http.StatusOK},
+		{name: "Shipped", err: service.ErrOrderShipped, wantStatus: http.StatusConflict},
+		{name: "Cancelled", err: fmt.Errorf("%w: it is cancelled", service.ErrOrderNotModifiable), wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockOrderModificationService(ctrl)
+			var resp *service.OrderModificationResp
+			if tt.err == nil {
+				resp = &service.OrderModificationResp{ID: 1, OrderID: 7, Kind: model.OrderModificationShippingAddress}
+			}
+			mockService.EXPECT().ChangeShippingAddress(gomock.Any(), uint64(3), uint64(7), model.Address{Name: "Erika Mustermann", City: "Berlin"}).Return(resp, tt.err)
+			handler := NewOrderModificationHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "7"}}
+			c.Request = httptest.NewRequest(http.MethodPut, "/api/v1/orders/7/shipping-address", bytes.NewBufferString(`{"name":"Erika Mustermann","city":"Berlin"}`))
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 3}))
+
+			handler.ChangeShippingAddress(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// PasswordResetHandler defines the HTTP handlers for users who forgot their
+// password.
+type PasswordResetHandler struct {
+	resetService service.PasswordResetService
+}
+
+// NewPasswordResetHandler creates a new PasswordResetHandler instance.
+func NewPasswordResetHandler(resetService service.PasswordResetService) *PasswordResetHandler {
+	return &PasswordResetHandler{resetService: resetService}
+}
+
+// ForgotPasswordRequest defines the request body for requesting a password
+// reset link.
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email,max=100" example:"jane@example.com"`
+}
+
+// ForgotPassword emails a password reset link to the account with an email.
+//
+//	@Summary		Request a password reset link
+//	@Description	The link opens the storefront's reset page with a token, which works once until password_reset.token_ttl passes. The response is the same whether or not an account has the email. Requests are limited per email; past the limit they are refused with 429.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		ForgotPasswordRequest	true	"Email of the account"
+//	@Success		202		{object}	Response
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		429		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/users/forgot-password [post]
+func (h *PasswordResetHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	if err := h.resetService.RequestReset(c.Request.Context(), req.Email); err != nil {
+		if errors.Is(err, service.ErrPasswordResetRateLimited) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"code": http.StatusTooManyRequests, "message": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to request password reset", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"code": http.StatusAccepted, "message": "If an account has this email, a password reset link is on its way"})
+}
+
+// ResetPasswordRequest defines the request body for resetting a password.
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required,max=128"` // From the emailed link
+	Password string `json:"password" binding:"required,min=6,max=20"`
+}
+
+// ResetPassword sets a new password with the token of a reset link.
+//
+//	@Summary		Reset a forgotten password
+//	@Description	The token stops working, and the user is logged out of every session; they log in again with the new password.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		ResetPasswordRequest	true	"Reset token and new password"
+//	@Success		200		{object}	Response
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/users/reset-password [post]
+func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	if err := h.resetService.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		if errors.Is(err, service.ErrInvalidResetToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to reset password", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Password reset"})
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPasswordResetHandler_ForgotPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockPasswordResetService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"email":"jane@example.com"}`,
+			mockSetup: func(mockService *mocks.MockPasswordResetService) {
+				mockService.EXPECT().RequestReset(gomock.Any(), "jane@example.com").Return(nil)
+			},
+			wantStatus: http.StatusAccepted,
+			wantBody:   "password reset link",
+		},
+		{name: "InvalidEmail", reqBody: `{"email":"jane"}`, wantStatus: http.StatusBadRequest, wantBody: `"field":"email"`},
+		{
+			name:    "RateLimited",
+			reqBody: `{"email":"jane@example.com"}`,
+			mockSetup: func(mockService *mocks.MockPasswordResetService) {
+				mockService.EXPECT().RequestReset(gomock.Any(), "jane@example.com").Return(service.ErrPasswordResetRateLimited)
+			},
+			wantStatus: http.StatusTooManyRequests,
+			wantBody:   "too many password reset requests",
+		},
+		{
+			name:    "ServiceError",
+			reqBody: `{"email":"jane@example.com"}`,
+			mockSetup: func(mockService *mocks.MockPasswordResetService) {
+				mockService.EXPECT().RequestReset(gomock.Any(), "jane@example.com").Return(errors.New("mq down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockPasswordResetService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewPasswordResetHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/users/forgot-password", bytes.NewBufferString(tt.reqBody))
+
+			handler.ForgotPassword(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestPasswordResetHandler_ResetPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockPasswordResetService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"token":"abc","password":"new-secret"}`,
+			mockSetup: func(mockService *mocks.MockPasswordResetService) {
+				mockService.EXPECT().ResetPassword(gomock.Any(), "abc", "new-secret").Return(nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "Password reset",
+		},
+		{name: "ShortPassword", reqBody: `{"token":"abc","password":"123"}`, wantStatus: http.StatusBadRequest, wantBody: `"field":"password"`},
+		{
+			name:    "InvalidToken",
+			reqBody: `{"token":"abc","password":"new-secret"}`,
+			mockSetup: func(mockService *mocks.MockPasswordResetService) {
+				mockService.EXPECT().ResetPassword(gomock.Any(), "abc", "new-secret").Return(service.ErrInvalidResetToken)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid or expired password reset token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockPasswordResetService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewPasswordResetHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/users/reset-password", bytes.NewBufferString(tt.reqBody))
+
+			handler.ResetPassword(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
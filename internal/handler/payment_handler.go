@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// maxNotificationBody bounds payment notifications, which are a few
+// kilobytes at most.
+const maxNotificationBody = 64 << 10
+
+// PaymentHandler defines the HTTP handlers for paying orders and for the
+// notifications payment providers send about them.
+type PaymentHandler struct {
+	paymentService service.PaymentService
+}
+
+// NewPaymentHandler creates a new PaymentHandler instance.
+func NewPaymentHandler(paymentService service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{paymentService: paymentService}
+}
+
+// PayOrderRequest defines the request body for paying an order.
+type PayOrderRequest struct {
+	Provider string `json:"provider" binding:"required,oneof=stripe alipay wechat" example:"alipay"`
+}
+
+// PayOrder starts paying one of the caller's pending orders. Alipay and
+// WeChat Pay return a QR code to show the customer; Stripe returns a client
+// secret to confirm the payment with Stripe.js. The order is marked paid
+// once the provider notifies that the customer paid. Paying again returns the
+// payment already started, which must be completed with the same provider.
+//
+//	@Summary	Pay an order
+//	@Tags		orders
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id		path		integer			true	"Order ID"
+//	@Param		request	body		PayOrderRequest	true	"Payment provider"
+//	@Success	200		{object}	Response{data=service.PaymentResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	404		{object}	ErrorResponse
+//	@Failure	409		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/orders/{id}/pay [post]
+func (h *PaymentHandler) PayOrder(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	orderID, ok := parseIDParam(c, "id", "order")
+	if !ok {
+		return
+	}
+
+	var req PayOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.paymentService.Pay(c.Request.Context(), &service.PayOrderReq{UserID: userID, OrderID: orderID, Provider: req.Provider})
+	switch {
+	case errors.Is(err, service.ErrPaymentProviderNotEnabled), errors.Is(err, service.ErrPaymentCurrencyNotSupported):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+		return
+	case errors.Is(err, service.ErrOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+		return
+	case errors.Is(err, service.ErrOrderNotPending), errors.Is(err, service.ErrPaymentInProgress):
+		c.JSON(http.StatusConflict, gin.H{"code": http.StatusConflict, "message": err.Error()})
+		return
+	case err != nil:
+		slog.ErrorContext(c.Request.Context(), "Failed to create payment", "order_id", orderID, "provider", req.Provider, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+}
+
+// Notify receives the asynchronous notifications a payment provider sends
+// once a customer paid. They are authenticated by the provider's signature
+// and answered in the provider's own format, so that it retries failures.
+func (h *PaymentHandler) Notify(c *gin.Context) {
+	name := c.Param("provider")
+	provider, ok := h.paymentService.Provider(name)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": "Not Found"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxNotificationBody))
+	if err == nil {
+		err = h.paymentService.HandleNotification(ctx, name, c.Request.Header, body)
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidPaymentNotification):
+		slog.WarnContext(ctx, "Invalid payment notification", "provider", name, logger.Err(err))
+	case err != nil:
+		slog.ErrorContext(ctx, "Failed to handle payment notification", "provider", name, logger.Err(err))
+	}
+	provider.Acknowledge(c.Writer, err)
+}
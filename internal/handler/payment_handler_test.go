@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/payment"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPaymentHandler_PayOrder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		id         string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockPaymentService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			id:      "5",
+			reqBody: `{"provider":"wechat"}`,
+			mockSetup: func(mockService *mocks.MockPaymentService) {
+				mockService.EXPECT().Pay(gomock.Any(), &service.PayOrderReq{UserID: 1, OrderID: 5, Provider: "wechat"}).
+					Return(&service.PaymentResp{Provider: "wechat", PaymentID: "ORD1", QRCode: "weixin://wxpay/bizpayurl?pr=abc"}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"qr_code":"weixin://wxpay/bizpayurl?pr=abc"`,
+		},
+		{
+			name:       "UnknownProvider",
+			id:         "5",
+			reqBody:    `{"provider":"paypal"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"field":"provider","rule":"oneof"`,
+		},
+		{
+			name:    "CurrencyNotSupported",
+			id:      "5",
+			reqBody: `{"provider":"alipay"}`,
+			mockSetup: func(mockService *mocks.MockPaymentService) {
+				mockService.EXPECT().Pay(gomock.Any(), gomock.Any()).Return(nil, service.ErrPaymentCurrencyNotSupported)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "currency not supported",
+		},
+		{
+			name:    "NotPending",
+			id:      "5",
+			reqBody: `{"provider":"alipay"}`,
+			mockSetup: func(mockService *mocks.MockPaymentService) {
+				mockService.EXPECT().Pay(gomock.Any(), gomock.Any()).Return(nil, service.ErrOrderNotPending)
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   "not pending",
+		},
+		{
+			name:    "InProgress",
+			id:      "5",
+			reqBody: `{"provider":"alipay"}`,
+			mockSetup: func(mockService *mocks.MockPaymentService) {
+				mockService.EXPECT().Pay(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("%w with wechat", service.ErrPaymentInProgress))
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   "payment in progress with wechat",
+		},
+		{
+			name:    "NotFound",
+			id:      "5",
+			reqBody: `{"provider":"alipay"}`,
+			mockSetup: func(mockService *mocks.MockPaymentService) {
+				mockService.EXPECT().Pay(gomock.Any(), gomock.Any()).Return(nil, service.ErrOrderNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   "order not found",
+		},
+		{name: "InvalidID", id: "abc", reqBody: `{"provider":"alipay"}`, wantStatus: http.StatusBadRequest, wantBody: "invalid order id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockPaymentService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewPaymentHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}}
+
+			var err error
+			c.Request, err = http.NewRequest(http.MethodPost, "/orders/"+tt.id+"/pay", bytes.NewBufferString(tt.reqBody))
+			require.NoError(t, err)
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			handler.PayOrder(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestPaymentHandler_Notify(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		provider   string
+		mockSetup  func(mockService *mocks.MockPaymentService, provider *mocks.MockPaymentProvider)
+		wantStatus int
+	}{
+		{
+			name:     "Acknowledged",
+			provider: "alipay",
+			mockSetup: func(mockService *mocks.MockPaymentService, provider *mocks.MockPaymentProvider) {
+				mockService.EXPECT().HandleNotification(gomock.Any(), "alipay", gomock.Any(), []byte("trade_status=TRADE_SUCCESS")).Return(nil)
+				provider.EXPECT().Acknowledge(gomock.Any(), nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:     "Failed",
+			provider: "alipay",
+			mockSetup: func(mockService *mocks.MockPaymentService, provider *mocks.MockPaymentProvider) {
+				failed := errors.New("connection refused")
+				mockService.EXPECT().HandleNotification(gomock.Any(), "alipay", gomock.Any(), gomock.Any()).Return(failed)
+				provider.EXPECT().Acknowledge(gomock.Any(), failed).Do(func(w http.ResponseWriter, _ error) {
+					w.WriteHeader(http.StatusInternalServerError)
+				})
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:     "InvalidSignature",
+			provider: "alipay",
+			mockSetup: func(mockService *mocks.MockPaymentService, provider *mocks.MockPaymentProvider) {
+				mockService.EXPECT().HandleNotification(gomock.Any(), "alipay", gomock.Any(), gomock.Any()).Return(payment.ErrInvalidNotification)
+				provider.EXPECT().Acknowledge(gomock.Any(), payment.ErrInvalidNotification).Do(func(w http.ResponseWriter, _ error) {
+					w.WriteHeader(http.StatusBadRequest)
+				})
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{name: "UnknownProvider", provider: "paypal", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockPaymentService(ctrl)
+			provider := mocks.NewMockPaymentProvider(ctrl)
+			mockService.EXPECT().Provider("alipay").Return(provider, true).AnyTimes()
+			mockService.EXPECT().Provider("paypal").Return(nil, false).AnyTimes()
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService, provider)
+			}
+			handler := NewPaymentHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "provider", Value: tt.provider}}
+
+			var err error
+			c.Request, err = http.NewRequest(http.MethodPost, "/webhooks/payments/"+tt.provider, bytes.NewBufferString("trade_status=TRADE_SUCCESS"))
+			require.NoError(t, err)
+
+			handler.Notify(c)
+
+			// Gin writes a status without a body only once the request ends
+			assert.Equal(t, tt.wantStatus, c.Writer.Status())
+		})
+	}
+}
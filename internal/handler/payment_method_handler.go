@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// PaymentMethodHandler defines the HTTP handlers for users' saved payment methods.
+type PaymentMethodHandler struct {
+	paymentMethodService service.PaymentMethodService
+}
+
+// NewPaymentMethodHandler creates a new PaymentMethodHandler instance.
+func NewPaymentMethodHandler(paymentMethodService service.PaymentMethodService) *PaymentMethodHandler {
+	return &PaymentMethodHandler{paymentMethodService: paymentMethodService}
+}
+
+// SavePaymentMethodRequest defines the request body for saving a payment
+// method. The card is tokenized in the client with the payment provider's
+// SDK; card numbers are rejected.
+type SavePaymentMethodRequest struct {
+	Token string `json:"token" binding:"required,max=255" example:"pm_1NvXYZ2eZvKYlo2C"`
+}
+
+// ListPaymentMethods returns the caller's saved payment methods, most recently
+// saved first.
+//
+//	@Summary	List saved payment methods
+//	@Tags		users
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Success	200	{object}	Response{data=[]service.PaymentMethodResp}
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/users/me/payment-methods [get]
+func (h *PaymentMethodHandler) ListPaymentMethods(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+
+	methods, err := h.paymentMethodService.List(c.Request.Context(), userID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list payment methods", "user_id", userID, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": methods})
+}
+
+// SavePaymentMethod saves a tokenized card to the caller's account, so later
+// orders can be paid with it in one click.
+//
+//	@Summary	Save a payment method
+//	@Tags		users
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		request	body		SavePaymentMethodRequest	true	"Payment method token"
+//	@Success	201		{object}	Response{data=service.PaymentMethodResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	402		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/users/me/payment-methods [post]
+func (h *PaymentMethodHandler) SavePaymentMethod(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+
+	var req SavePaymentMethodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	method, err := h.paymentMethodService.Save(c.Request.Context(), &service.SavePaymentMethodReq{UserID: userID, Token: req.Token})
+	switch {
+	case errors.Is(err, service.ErrCardNumberNotAllowed), errors.Is(err, service.ErrInvalidPaymentToken):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+		return
+	case errors.Is(err, service.ErrPaymentDeclined):
+		c.JSON(http.StatusPaymentRequired, gin.H{"code": http.StatusPaymentRequired, "message": "payment method declined"})
+		return
+	case err != nil:
+		slog.ErrorContext(c.Request.Context(), "Failed to save payment method", "user_id", userID, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Payment method saved", "data": method})
+}
+
+// DeletePaymentMethod removes one of the caller's saved payment methods, here
+// and at the payment provider.
+//
+//	@Summary	Delete a saved payment method
+//	@Tags		users
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Payment method ID"
+//	@Success	200	{object}	Response
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/users/me/payment-methods/{id} [delete]
+func (h *PaymentMethodHandler) DeletePaymentMethod(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	id, ok := parseIDParam(c, "id", "payment method")
+	if !ok {
+		return
+	}
+
+	if err := h.paymentMethodService.Delete(c.Request.Context(), userID, id); err != nil {
+		if errors.Is(err, service.ErrPaymentMethodNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to delete payment method", "user_id", userID, "payment_method_id", id, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Payment method deleted"})
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPaymentMethodHandler_SavePaymentMethod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockPaymentMethodService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"token":"pm_1"}`,
+			mockSetup: func(mockService *mocks.MockPaymentMethodService) {
+				mockService.EXPECT().Save(gomock.Any(), &service.SavePaymentMethodReq{UserID: 1, Token: "pm_1"}).
+					Return(&service.PaymentMethodResp{ID: 9, Provider: "stripe", Brand: "visa", Last4: "4242"}, nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"last4":"4242"`,
+		},
+		{
+			name:       "MissingToken",
+			reqBody:    `{}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"field":"token","rule":"required"`,
+		},
+		{
+			name:    "CardNumber",
+			reqBody: `{"token":"4242424242424242"}`,
+			mockSetup: func(mockService *mocks.MockPaymentMethodService) {
+				mockService.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil, service.ErrCardNumberNotAllowed)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "card numbers are not accepted",
+		},
+		{
+			name:    "Declined",
+			reqBody: `{"token":"pm_1"}`,
+			mockSetup: func(mockService *mocks.MockPaymentMethodService) {
+				mockService.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil, service.ErrPaymentDeclined)
+			},
+			wantStatus: http.StatusPaymentRequired,
+			wantBody:   "payment method declined",
+		},
+		{
+			name:    "ProviderDown",
+			reqBody: `{"token":"pm_1"}`,
+			mockSetup: func(mockService *mocks.MockPaymentMethodService) {
+				mockService.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil, errors.New("status 503"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockPaymentMethodService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewPaymentMethodHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			var err error
+			c.Request, err = http.NewRequest(http.MethodPost, "/users/me/payment-methods", bytes.NewBufferString(tt.reqBody))
+			require.NoError(t, err)
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			handler.SavePaymentMethod(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestPaymentMethodHandler_DeletePaymentMethod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		id         string
+		mockSetup  func(mockService *mocks.MockPaymentMethodService)
+		wantStatus int
+	}{
+		{
+			name: "Success",
+			id:   "9",
+			mockSetup: func(mockService *mocks.MockPaymentMethodService) {
+				mockService.EXPECT().Delete(gomock.Any(), uint64(1), uint64(9)).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "NotFound",
+			id:   "9",
+			mockSetup: func(mockService *mocks.MockPaymentMethodService) {
+				mockService.EXPECT().Delete(gomock.Any(), uint64(1), uint64(9)).Return(service.ErrPaymentMethodNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{name: "InvalidID", id: "abc", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockPaymentMethodService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewPaymentMethodHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}}
+
+			var err error
+			c.Request, err = http.NewRequest(http.MethodDelete, "/users/me/payment-methods/"+tt.id, nil)
+			require.NoError(t, err)
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			handler.DeletePaymentMethod(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// PickingHandler defines the HTTP handlers fulfillment staff pick and pack
+// paid orders with.
+type PickingHandler struct {
+	pickingService service.PickingService
+}
+
+// NewPickingHandler creates a new PickingHandler instance.
+func NewPickingHandler(pickingService service.PickingService) *PickingHandler {
+	return &PickingHandler{pickingService: pickingService}
+}
+
+// PickTaskQuery defines the filters and paging of pick tasks.
+type PickTaskQuery struct {
+	Warehouse string `form:"warehouse" binding:"max=30" example:"east"`
+	Zone      string `form:"zone" binding:"max=30" example:"A"`
+	Status    string `form:"status" binding:"omitempty,oneof=open claimed packed" example:"open"`
+	Mine      bool   `form:"mine"` // Only the tasks the caller claimed
+	OrderID   uint64 `form:"order_id" example:"1234567890"`
+	Offset    int    `form:"offset" binding:"min=0"`
+	Limit     int    `form:"limit" binding:"min=0,max=100"`
+}
+
+// ListPickTasks returns pick tasks, oldest first.
+//
+//	@Summary		List pick tasks
+//	@Description	A paid order gets a pick task for the items stored in each warehouse zone. Staff list the open tasks of their zone, claim one, and complete it once its items are picked and packed.
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			query	query		PickTaskQuery	false	"Filters and paging"
+//	@Success		200		{object}	Response{data=[]service.PickTaskResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/pick-tasks [get]
+func (h *PickingHandler) ListPickTasks(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	var query PickTaskQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	filter := repository.PickTaskFilter{Warehouse: query.Warehouse, Zone: query.Zone, Status: query.Status, OrderID: query.OrderID}
+	if query.Mine {
+		filter.AssigneeID = userID
+	}
+	tasks, err := h.pickingService.List(c.Request.Context(), filter, query.Offset, query.Limit)
+	if err != nil {
+		respondPickingError(c, "Failed to list pick tasks", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": tasks})
+}
+
+// ClaimPickTask assigns an open pick task to the caller.
+//
+//	@Summary	Claim a pick task
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Pick task ID"
+//	@Success	200	{object}	Response{data=service.PickTaskResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	409	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/pick-tasks/{id}/claim [post]
+func (h *PickingHandler) ClaimPickTask(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	id, ok := parseIDParam(c, "id", "pick task")
+	if !ok {
+		return
+	}
+
+	task, err := h.pickingService.Claim(c.Request.Context(), userID, id)
+	if err != nil {
+		respondPickingError(c, "Failed to claim pick task", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Pick task claimed", "data": task})
+}
+
+// CompletePickTask marks a pick task the caller claimed packed.
+//
+//	@Summary		Complete a pick task
+//	@Description	Once every pick task of the order in the task's warehouse is packed, the items packed there ship together, as with POST /admin/orders/{id}/shipments, and shipment_id is set. An order stocked in several warehouses ships from each separately. If the order cannot ship, e.g. it was cancelled meanwhile, the task stays packed without a shipment.
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		integer	true	"Pick task ID"
+//	@Success		200	{object}	Response{data=service.PickTaskResp}
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		409	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/pick-tasks/{id}/complete [post]
+func (h *PickingHandler) CompletePickTask(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	id, ok := parseIDParam(c, "id", "pick task")
+	if !ok {
+		return
+	}
+
+	task, err := h.pickingService.Complete(c.Request.Context(), userID, id)
+	if err != nil {
+		respondPickingError(c, "Failed to complete pick task", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Pick task completed", "data": task})
+}
+
+func respondPickingError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrPickTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+	case errors.Is(err, service.ErrPickTaskStatus):
+		c.JSON(http.StatusConflict, gin.H{"code": http.StatusConflict, "message": err.Error()})
+	default:
+		slog.ErrorContext(c.Request.Context(), msg, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
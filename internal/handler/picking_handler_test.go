@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPickingHandler_ListPickTasks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		query      string
+		mockSetup  func(mockService *mocks.MockPickingService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:  "OpenInZone",
+			query: "?zone=A&status=open",
+			mockSetup: func(mockService *mocks.MockPickingService) {
+				mockService.EXPECT().List(gomock.Any(), repository.PickTaskFilter{Zone: "A", Status: "open"}, 0, 0).
+					Return([]service.PickTaskResp{{ID: 9, Zone: "A", Status: "open"}}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"zone":"A"`,
+		},
+		{
+			name:  "Mine",
+			query: "?mine=true",
+			mockSetup: func(mockService *mocks.MockPickingService) {
+				mockService.EXPECT().List(gomock.Any(), repository.PickTaskFilter{AssigneeID: 1}, 0, 0).Return([]service.PickTaskResp{}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"data":[]`,
+		},
+		{name: "UnknownStatus", query: "?status=shipped", wantStatus: http.StatusBadRequest, wantBody: `"rule":"oneof"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockPickingService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/pick-tasks"+tt.query, nil)
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			NewPickingHandler(mockService).ListPickTasks(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestPickingHandler_CompletePickTask(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		id         string
+		mockSetup  func(mockService *mocks.MockPickingService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "Shipped",
+			id:   "9",
+			mockSetup: func(mockService *mocks.MockPickingService) {
+				mockService.EXPECT().Complete(gomock.Any(), uint64(1), uint64(9)).Return(&service.PickTaskResp{ID: 9, Status: "packed", ShipmentID: 500}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"shipment_id":"500"`,
+		},
+		{
+			name: "ClaimedBySomeoneElse",
+			id:   "9",
+			mockSetup: func(mockService *mocks.MockPickingService) {
+				mockService.EXPECT().Complete(gomock.Any(), uint64(1), uint64(9)).Return(nil, service.ErrPickTaskStatus)
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   service.ErrPickTaskStatus.Error(),
+		},
+		{
+			name: "NotFound",
+			id:   "9",
+			mockSetup: func(mockService *mocks.MockPickingService) {
+				mockService.EXPECT().Complete(gomock.Any(), uint64(1), uint64(9)).Return(nil, service.ErrPickTaskNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   service.ErrPickTaskNotFound.Error(),
+		},
+		{name: "InvalidID", id: "abc", wantStatus: http.StatusBadRequest, wantBody: "invalid pick task id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockPickingService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/pick-tasks/"+tt.id+"/complete", nil)
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			NewPickingHandler(mockService).CompletePickTask(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/shopspring/decimal"
+)
+
+// PriceScheduleHandler defines the HTTP handlers for scheduled price changes
+// and sales of SKUs.
+type PriceScheduleHandler struct {
+	priceScheduleService service.PriceScheduleService
+}
+
+// NewPriceScheduleHandler creates a new PriceScheduleHandler instance.
+func NewPriceScheduleHandler(priceScheduleService service.PriceScheduleService) *PriceScheduleHandler {
+	return &PriceScheduleHandler{priceScheduleService: priceScheduleService}
+}
+
+// CreatePriceScheduleRequest defines the request body for scheduling a price
+// change or sale of a SKU.
+type CreatePriceScheduleRequest struct {
+	Price    decimal.Decimal `json:"price" binding:"required,price" swaggertype:"string" example:"14.50"` // In the SKU's currency
+	StartsAt time.Time       `json:"starts_at"`                                                           // Empty means now
+	EndsAt   *time.Time      `json:"ends_at"`                                                             // Makes it a sale; empty changes the price for good
+}
+
+// ListPriceSchedules returns the scheduled price changes and sales of a SKU,
+// including those applied or cancelled.
+//
+//	@Summary	List price schedules of a SKU
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"SKU ID"
+//	@Success	200	{object}	Response{data=[]service.PriceScheduleResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/skus/{id}/price-schedules [get]
+func (h *PriceScheduleHandler) ListPriceSchedules(c *gin.Context) {
+	skuID, ok := parseIDParam(c, "id", "SKU")
+	if !ok {
+		return
+	}
+
+	schedules, err := h.priceScheduleService.List(c.Request.Context(), skuID)
+	if err != nil {
+		respondPriceScheduleError(c, "Failed to list price schedules", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": schedules})
+}
+
+// CreatePriceSchedule schedules a price change or sale of a SKU.
+//
+//	@Summary		Schedule a price change or sale of a SKU
+//	@Description	Without ends_at the SKU's price changes for good at starts_at. With it the SKU goes on sale at the price until ends_at, showing its regular price alongside, and returns to the regular price after. Sales of a SKU may not overlap. Changes are applied by the worker, within a minute by default.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		integer						true	"SKU ID"
+//	@Param			request	body		CreatePriceScheduleRequest	true	"Price schedule"
+//	@Success		201		{object}	Response{data=service.PriceScheduleResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/skus/{id}/price-schedules [post]
+func (h *PriceScheduleHandler) CreatePriceSchedule(c *gin.Context) {
+	skuID, ok := parseIDParam(c, "id", "SKU")
+	if !ok {
+		return
+	}
+	var req CreatePriceScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.priceScheduleService.Schedule(c.Request.Context(), skuID, &service.PriceScheduleReq{
+		Price:    req.Price,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+	})
+	if err != nil {
+		respondPriceScheduleError(c, "Failed to schedule price change", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Price change scheduled", "data": resp})
+}
+
+// CancelPriceSchedule withdraws a pending price change or sale, or ends a
+// running sale at once.
+//
+//	@Summary	Cancel a price schedule
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Price schedule ID"
+//	@Success	200	{object}	Response
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	409	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/price-schedules/{id}/cancel [post]
+func (h *PriceScheduleHandler) CancelPriceSchedule(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "price schedule")
+	if !ok {
+		return
+	}
+
+	if err := h.priceScheduleService.Cancel(c.Request.Context(), id); err != nil {
+		respondPriceScheduleError(c, "Failed to cancel price schedule", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Price schedule cancelled"})
+}
+
+// respondPriceScheduleError maps the errors of the price schedule service to
+// responses, logging unexpected ones with msg.
+func respondPriceScheduleError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidPriceSchedule):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+	case errors.Is(err, service.ErrProductNotFound), errors.Is(err, service.ErrPriceScheduleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+	case errors.Is(err, service.ErrPriceScheduleConflict), errors.Is(err, service.ErrPriceScheduleClosed):
+		c.JSON(http.StatusConflict, gin.H{"code": http.StatusConflict, "message": err.Error()})
+	default:
+		slog.ErrorContext(c.Request.Context(), msg, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPriceScheduleHandler_CreatePriceSchedule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockPriceScheduleService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Sale",
+			reqBody: `{"price":"14.50","starts_at":"2026-11-27T00:00:00Z","ends_at":"2026-11-30T00:00:00Z"}`,
+			mockSetup: func(mockService *mocks.MockPriceScheduleService) {
+				mockService.EXPECT().Schedule(gomock.Any(), uint64(5), gomock.Any()).DoAndReturn(func(_ context.Context, skuID uint64, req *service.PriceScheduleReq) (*service.PriceScheduleResp, error) {
+					require.NotNil(t, req.EndsAt)
+					assert.Equal(t, "2026-11-30T00:00:00Z", req.EndsAt.Format("2006-01-02T15:04:05Z07:00"))
+					return &service.PriceScheduleResp{ID: 9, SKUID: skuID, Price: money.New(req.Price, "USD"), StartsAt: req.StartsAt, EndsAt: req.EndsAt, Status: "pending"}, nil
+				})
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"id":"9"`,
+		},
+		{name: "SubCentPrice", reqBody: `{"price":"14.505"}`, wantStatus: http.StatusBadRequest, wantBody: `"field":"price"`},
+		{
+			name:    "Overlap",
+			reqBody: `{"price":"14.50","ends_at":"2026-11-30T00:00:00Z"}`,
+			mockSetup: func(mockService *mocks.MockPriceScheduleService) {
+				mockService.EXPECT().Schedule(gomock.Any(), uint64(5), gomock.Any()).Return(nil, service.ErrPriceScheduleConflict)
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   "overlaps",
+		},
+		{
+			name:    "SKUNotFound",
+			reqBody: `{"price":"14.50"}`,
+			mockSetup: func(mockService *mocks.MockPriceScheduleService) {
+				mockService.EXPECT().Schedule(gomock.Any(), uint64(5), gomock.Any()).Return(nil, service.ErrProductNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:    "ServiceError",
+			reqBody: `{"price":"14.50"}`,
+			mockSetup: func(mockService *mocks.MockPriceScheduleService) {
+				mockService.EXPECT().Schedule(gomock.Any(), uint64(5), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockPriceScheduleService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewPriceScheduleHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "5"}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/skus/5/price-schedules", bytes.NewBufferString(tt.reqBody))
+
+			handler.CreatePriceSchedule(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestPriceScheduleHandler_CancelPriceSchedule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "Success", wantStatus: http.StatusOK},
+		{name: "NotFound", err: service.ErrPriceScheduleNotFound, wantStatus: http.StatusNotFound},
+		{name: "AlreadyApplied", err: service.ErrPriceScheduleClosed, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockPriceScheduleService(ctrl)
+			mockService.EXPECT().Cancel(gomock.Any(), uint64(9)).Return(tt.err)
+			handler := NewPriceScheduleHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "9"}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/price-schedules/9/cancel", nil)
+
+			handler.CancelPriceSchedule(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -2,23 +2,41 @@ package handler
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/tenant"
 	"github.com/shopspring/decimal"
 )
 
 // ProductHandler defines the HTTP handlers for product-related operations.
 type ProductHandler struct {
-	productService service.ProductService
+	productService     service.ProductService
+	currencyService    service.CurrencyService
+	translationService service.TranslationService
+	popularityService  service.PopularityService
+	suggestionService  service.SuggestionService
+	groupService       service.CustomerGroupService
 }
 
-// NewProductHandler creates a new ProductHandler instance.
-func NewProductHandler(productService service.ProductService) *ProductHandler {
-	return &ProductHandler{productService: productService}
+// NewProductHandler creates a new ProductHandler instance. Prices are what
+// the signed-in user's customer group pays, from groupService, shown in the
+// currency resolved by currencyService; names and descriptions are in the
+// locale negotiated by translationService. Product views are counted by
+// popularityService, search suggestions made by suggestionService.
+// groupService may be nil, when everyone pays the SKUs' own prices.
+func NewProductHandler(productService service.ProductService, currencyService service.CurrencyService, translationService service.TranslationService,
+	popularityService service.PopularityService, suggestionService service.SuggestionService, groupService service.CustomerGroupService) *ProductHandler {
+	return &ProductHandler{productService: productService, currencyService: currencyService, translationService: translationService,
+		popularityService: popularityService, suggestionService: suggestionService, groupService: groupService}
 }
 
 // CreateProductRequest defines the request body for creating a product.
@@ -27,20 +45,66 @@ type CreateProductRequest struct {
 	Description string       `json:"description"`
 	CategoryID  uint64       `json:"category_id" binding:"required"`
 	SKUs        []SKURequest `json:"skus" binding:"required,dive"` // dive validates items in the slice
+	// Options may be chosen for every unit ordered of any of its SKUs, at a
+	// price on top of the SKU's, e.g. an engraving or an extended warranty.
+	Options []ProductOptionRequest `json:"options" binding:"omitempty,max=20,dive"`
+}
+
+// ProductOptionRequest defines an option of a product.
+type ProductOptionRequest struct {
+	Code      string          `json:"code" binding:"required,max=40" example:"engraving"` // What orders choose it by; unique in the product
+	Name      string          `json:"name" binding:"required,max=100" example:"Engraving"`
+	Kind      string          `json:"kind" binding:"required,oneof=flag text" example:"text"`              // flag, or text for options chosen with a text
+	MaxLength int             `json:"max_length" binding:"gte=0,max=255" example:"30"`                     // Of a text option's value; 0 allows 255 characters
+	Price     decimal.Decimal `json:"price" binding:"omitempty,price" swaggertype:"string" example:"5.00"` // Added to the unit price
+	Currency  string          `json:"currency" binding:"omitempty,iso4217" example:"USD"`                  // Currency of Price; defaults to the store's base currency
 }
 
 type SKURequest struct {
-	Attributes json.RawMessage `json:"attributes" binding:"required"` // Use RawMessage for direct JSON handling
-	Price      float64         `json:"price" binding:"required,gt=0"`
-	Stock      int             `json:"stock" binding:"required,gte=0"`
-	Image      string          `json:"image"`
+	Attributes    json.RawMessage `json:"attributes" binding:"required,sku_attrs" swaggertype:"object"`                               // Use RawMessage for direct JSON handling
+	Price         decimal.Decimal `json:"price" binding:"required,price" swaggertype:"string" example:"19.99"`                        // Accepts "19.99" or 19.99 without float rounding
+	Currency      string          `json:"currency" binding:"omitempty,iso4217" example:"USD"`                                         // Currency of Price; defaults to the store's base currency
+	Stock         int             `json:"stock" binding:"required_without=Components,gte=0"`                                          // Ignored for bundles
+	PurchaseLimit int             `json:"purchase_limit" binding:"gte=0" example:"2"`                                                 // Most units one customer may buy; 0 is unlimited
+	PreOrder      bool            `json:"pre_order"`                                                                                  // Orderable without stock; ordered units are backordered until stock arrives
+	AvailableAt   *time.Time      `json:"available_at"`                                                                               // When a pre-order SKU is expected in stock
+	Delivery      string          `json:"delivery" binding:"omitempty,oneof=license_key download"`                                    // Digital goods, sent once paid instead of shipped
+	DownloadPath  string          `json:"download_path" binding:"required_if=Delivery download,max=512" example:"ebooks/go-mall.pdf"` // download only: the file under digital.download_base_url
+	Channels      []string        `json:"channels" binding:"omitempty,dive,oneof=web app wholesale" example:"web,app"`                // Sales channels it is sold on; empty sells it on all
+	Warehouse     string          `json:"warehouse" binding:"max=30" example:"east"`                                                  // Which warehouse stocks it; an order's items from different warehouses ship separately
+	WarehouseZone string          `json:"warehouse_zone" binding:"max=30" example:"A"`                                                // Where in the warehouse it is stored; its items are picked with the zone's other items
+	Image         string          `json:"image"`
+	// Components make the SKU a bundle, sold at Price and shipped as its
+	// components, whose stock it takes. Each is a SKU of the store other than
+	// a bundle, given once.
+	Components []SKUComponentRequest `json:"components" binding:"omitempty,max=20,dive"`
+}
+
+// SKUComponentRequest is a quantity of a SKU in every unit of a bundle.
+type SKUComponentRequest struct {
+	SKUID    uint64 `json:"sku_id,string" binding:"required" example:"1234567890"`
+	Quantity int    `json:"quantity" binding:"required,gt=0,max=100" example:"2"`
 }
 
-// CreateProduct handles the creation of a new product.
+// CreateProduct handles the creation of a new product. Only admins may
+// create products.
+//
+//	@Summary	Create a product (SPU) with its SKUs
+//	@Tags		products
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		request	body		CreateProductRequest	true	"Product payload"
+//	@Success	201		{object}	Response{data=service.ProductCreateResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/products [post]
 func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	var req CreateProductRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
@@ -48,11 +112,36 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	var skus []service.SKUCreateReq
 	for _, sku := range req.SKUs {
 		skus = append(skus, service.SKUCreateReq{
-			Attributes: sku.Attributes,
-			Price:      decimal.NewFromFloat(sku.Price),
-			Stock:      sku.Stock,
+			Attributes:    sku.Attributes,
+			Price:         sku.Price,
+			Currency:      sku.Currency,
+			Stock:         sku.Stock,
+			PurchaseLimit: sku.PurchaseLimit,
+			PreOrder:      sku.PreOrder,
+			AvailableAt:   sku.AvailableAt,
+			Delivery:      sku.Delivery,
+			DownloadPath:  sku.DownloadPath,
+			Channels:      sku.Channels,
+			Warehouse:     sku.Warehouse,
+			WarehouseZone: sku.WarehouseZone,
+			Components:    make([]service.SKUComponentReq, len(sku.Components)),
 			// Image is not supported in service layer currently
 		})
+		for i, component := range sku.Components {
+			skus[len(skus)-1].Components[i] = service.SKUComponentReq{SKUID: component.SKUID, Quantity: component.Quantity}
+		}
+	}
+
+	options := make([]service.ProductOptionReq, len(req.Options))
+	for i, option := range req.Options {
+		options[i] = service.ProductOptionReq{
+			Code:      option.Code,
+			Name:      option.Name,
+			Kind:      option.Kind,
+			MaxLength: option.MaxLength,
+			Price:     option.Price,
+			Currency:  option.Currency,
+		}
 	}
 
 	serviceReq := &service.ProductCreateReq{
@@ -60,12 +149,25 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		Description: req.Description,
 		CategoryID:  req.CategoryID,
 		SKUs:        skus,
+		Options:     options,
 	}
 
 	resp, err := h.productService.CreateProduct(c.Request.Context(), serviceReq)
+	if errors.Is(err, service.ErrUnsupportedCurrency) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "unsupported currency"})
+		return
+	}
+	if errors.Is(err, service.ErrUnknownChannel) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "unknown sales channel"})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidBundle) || errors.Is(err, service.ErrInvalidProductOption) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
 	if err != nil {
 		// Log the error for debugging but do not expose it to the client
-		log.Printf("Failed to create product: %v", err)
+		slog.ErrorContext(c.Request.Context(), "Failed to create product", logger.Err(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
 		return
 	}
@@ -73,7 +175,91 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Product created successfully", "data": resp})
 }
 
+// BulkPriceRequest defines the request body for repricing SKUs in bulk.
+// Either changes, or category_id with percent, is given.
+type BulkPriceRequest struct {
+	Changes    []SKUPriceRequest `json:"changes" binding:"max=10000,dive"`
+	CategoryID uint64            `json:"category_id,string" example:"1234567890"`    // Reprices the SKUs of the category and its subcategories
+	Percent    decimal.Decimal   `json:"percent" swaggertype:"string" example:"-10"` // Adjustment to the category's prices, e.g. -10 for a tenth off
+	DryRun     bool              `json:"dry_run"`                                    // Report the new prices without saving them
+}
+
+// SKUPriceRequest sets the price of one SKU.
+type SKUPriceRequest struct {
+	SKUID uint64          `json:"sku_id,string" binding:"required,gt=0" example:"1234567890"`
+	Price decimal.Decimal `json:"price" binding:"required,price" swaggertype:"string" example:"19.99"` // In the SKU's currency
+}
+
+// BulkUpdatePrices reprices many SKUs at once.
+//
+//	@Summary		Reprice SKUs in bulk
+//	@Description	Sets each listed SKU to its price, or adjusts the prices of every SKU in a category and its subcategories by a percentage, rounded to the cent. All new prices are validated before any is saved; a dry run stops there and reports them. The SKUs are then repriced in chunks of 200, each in its own transaction, and every change is audited. If a chunk fails after others were saved, the response is 207 and lists the SKUs that were repriced.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		BulkPriceRequest	true	"Bulk price payload"
+//	@Success		200		{object}	Response{data=service.BulkPriceResp}
+//	@Success		207		{object}	Response{data=service.BulkPriceResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/skus/bulk-price [post]
+func (h *ProductHandler) BulkUpdatePrices(c *gin.Context) {
+	var req BulkPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	changes := make([]service.SKUPrice, 0, len(req.Changes))
+	for _, change := range req.Changes {
+		changes = append(changes, service.SKUPrice{SKUID: change.SKUID, Price: change.Price})
+	}
+	resp, err := h.productService.BulkUpdatePrices(c.Request.Context(), &service.BulkPriceReq{
+		Changes:    changes,
+		CategoryID: req.CategoryID,
+		Percent:    req.Percent,
+		DryRun:     req.DryRun,
+	})
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+	case errors.Is(err, service.ErrInvalidPriceChange):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+	case errors.Is(err, service.ErrCategoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+	case resp != nil:
+		slog.ErrorContext(c.Request.Context(), "Failed to finish bulk price update", "updated", resp.Updated, logger.Err(err))
+		c.JSON(http.StatusMultiStatus, gin.H{"code": http.StatusMultiStatus, "message": "Some prices were not updated", "data": resp})
+	default:
+		slog.ErrorContext(c.Request.Context(), "Failed to update prices", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
+
 // GetProduct retrieves a product by its ID.
+//
+//	@Summary		Get a product by ID
+//	@Description	Prices are converted into the Accept-Currency currency, else the signed-in user's preferred currency. Each SKU states the currency its price is in: without a recent exchange rate it stays in the SKU's own currency.
+//	@Description	The name and description are translated into the best match for Accept-Language, which Content-Language states. Untranslated products are served as written.
+//	@Description	Signed-in wholesale and VIP customers see their customer group's prices where a SKU has one.
+//	@Description	rating summarizes the product's reviews: their average stars and how many gave each.
+//	@Description	Only the SKUs sold on the X-Sales-Channel channel are listed; a product with none is not found.
+//	@Tags			products
+//	@Produce		json
+//	@Param			id				path		integer	true	"SPU ID"
+//	@Param			Accept-Currency	header		string	false	"ISO 4217 currency code"
+//	@Param			Accept-Language	header		string	false	"Preferred locales, e.g. zh-CN,zh;q=0.9"
+//	@Param			X-Sales-Channel	header		string	false	"web, app or wholesale"	default(web)
+//	@Success		200				{object}	Response{data=service.ProductResp}
+//	@Failure		400				{object}	ErrorResponse
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		404				{object}	ErrorResponse
+//	@Failure		500				{object}	ErrorResponse
+//	@Router		/products/{id} [get]
 func (h *ProductHandler) GetProduct(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 64)
@@ -82,17 +268,57 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
+	currency, ok := h.resolveCurrency(c)
+	if !ok {
+		return
+	}
+
 	resp, err := h.productService.GetProduct(c.Request.Context(), id)
+	if errors.Is(err, service.ErrProductNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": "product not found"})
+		return
+	}
 	if err != nil {
-		log.Printf("Failed to get product: %v", err)
+		slog.ErrorContext(c.Request.Context(), "Failed to get product", "spu_id", id, logger.Err(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
 		return
 	}
+	// A view that is not counted only makes the product a little less popular
+	if err := h.popularityService.RecordView(c.Request.Context(), id); err != nil {
+		slog.WarnContext(c.Request.Context(), "Failed to count product view", "spu_id", id, logger.Err(err))
+	}
+	h.priceProducts(c, currency, *resp)
+	products := []service.ProductResp{*resp}
+	h.localize(c, products)
 
-	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": products[0]})
+}
+
+// ProductListResponse is the envelope of a product list page, along with the
+// facet counts if asked for.
+type ProductListResponse struct {
+	Response
+	Facets *service.ProductFacets `json:"facets,omitempty"`
 }
 
 // ListProducts retrieves a list of products with pagination.
+//
+//	@Summary		List products
+//	@Description	Prices are converted and content translated as for GET /products/{id}.
+//	@Description	With facets=true, the response also counts the store's products per category, price bucket and SKU attribute value, for filter sidebars. Price buckets are in the currency each SKU is priced in, and only the 20 most common values of each attribute are counted.
+//	@Tags			products
+//	@Produce		json
+//	@Param			offset			query		integer	false	"Pagination offset"	minimum(0)	default(0)
+//	@Param			limit			query		integer	false	"Page size"			minimum(0)	maximum(100)	default(10)
+//	@Param			facets			query		boolean	false	"Include facet counts"	default(false)
+//	@Param			Accept-Currency	header		string	false	"ISO 4217 currency code"
+//	@Param			Accept-Language	header		string	false	"Preferred locales, e.g. zh-CN,zh;q=0.9"
+//	@Param			X-Sales-Channel	header		string	false	"web, app or wholesale"	default(web)
+//	@Success		200				{object}	ProductListResponse{data=[]service.ProductResp}
+//	@Failure		400				{object}	ErrorResponse
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		500				{object}	ErrorResponse
+//	@Router		/products [get]
 func (h *ProductHandler) ListProducts(c *gin.Context) {
 	offsetStr := c.DefaultQuery("offset", "0")
 	limitStr := c.DefaultQuery("limit", "10")
@@ -117,12 +343,170 @@ func (h *ProductHandler) ListProducts(c *gin.Context) {
 		return
 	}
 
+	withFacets, err := strconv.ParseBool(c.DefaultQuery("facets", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "invalid facets"})
+		return
+	}
+
+	currency, ok := h.resolveCurrency(c)
+	if !ok {
+		return
+	}
+
 	resp, err := h.productService.ListProducts(c.Request.Context(), offset, limit)
 	if err != nil {
-		log.Printf("Failed to list products: %v", err)
+		slog.ErrorContext(c.Request.Context(), "Failed to list products", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+	h.priceProducts(c, currency, resp...)
+	h.localize(c, resp)
+
+	if !withFacets {
+		c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+		return
+	}
+	facets, err := h.productService.ListFacets(c.Request.Context())
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to count product facets", logger.Err(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp, "facets": facets})
+}
+
+// ListPopularProducts lists the most viewed products lately.
+//
+//	@Summary		List popular products
+//	@Description	Products are ranked by their views, each counting half as much every popularity.half_life, as of the last rescore by cmd/worker. Products not viewed lately are left out.
+//	@Description	Prices are converted and content translated as for GET /products/{id}.
+//	@Tags			products
+//	@Produce		json
+//	@Param			limit			query		integer	false	"Most products returned"	minimum(1)	maximum(50)	default(10)
+//	@Param			Accept-Currency	header		string	false	"ISO 4217 currency code"
+//	@Param			Accept-Language	header		string	false	"Preferred locales, e.g. zh-CN,zh;q=0.9"
+//	@Param			X-Sales-Channel	header		string	false	"web, app or wholesale"	default(web)
+//	@Success		200				{object}	Response{data=[]service.ProductResp}
+//	@Failure		400				{object}	ErrorResponse
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		500				{object}	ErrorResponse
+//	@Router		/products/popular [get]
+func (h *ProductHandler) ListPopularProducts(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > service.MaxPopularProducts {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "limit must be between 1 and 50"})
+		return
+	}
+
+	currency, ok := h.resolveCurrency(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.popularityService.ListPopular(c.Request.Context(), limit)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list popular products", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+	h.priceProducts(c, currency, resp...)
+	h.localize(c, resp)
 
 	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
-}
\ No newline at end of file
+}
+
+// SuggestProducts completes a search box query.
+//
+//	@Summary		Suggest search completions
+//	@Description	Returns the products and categories of the store with a word of their name starting with q, ignoring case, in alphabetical order of the matched words. Products boosted for q come first and those buried are left out. Queries that suggest nothing are counted for review under GET /admin/search/zero-results. The index is rebuilt by cmd/worker every suggest.schedule, so new and renamed products, synonyms and search rules show up after the next rebuild.
+//	@Tags			products
+//	@Produce		json
+//	@Param			q		query		string	true	"What the customer typed so far"	maxLength(100)
+//	@Param			limit	query		integer	false	"Most suggestions returned"	minimum(1)	maximum(20)	default(10)
+//	@Success		200		{object}	Response{data=[]service.Suggestion}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router		/products/suggest [get]
+func (h *ProductHandler) SuggestProducts(c *gin.Context) {
+	query := c.Query("q")
+	if strings.TrimSpace(query) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "q is required"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(service.DefaultSuggestions)))
+	if err != nil || limit < 1 || limit > service.MaxSuggestions {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "limit must be between 1 and 20"})
+		return
+	}
+
+	suggestions, err := h.suggestionService.Suggest(c.Request.Context(), query, limit)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to suggest products", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+	if len(suggestions) == 0 {
+		// A search that is not counted only leaves merchandisers one fewer to review
+		if err := h.suggestionService.RecordZeroResult(c.Request.Context(), query); err != nil {
+			slog.WarnContext(c.Request.Context(), "Failed to count zero result search", logger.Err(err))
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": suggestions})
+}
+
+// resolveCurrency picks the currency prices are shown in. It writes the error
+// response and returns false when the requested currency is not supported.
+func (h *ProductHandler) resolveCurrency(c *gin.Context) (string, bool) {
+	userID, _ := auth.UserID(c.Request.Context()) // 0 for anonymous requests
+	currency, err := h.currencyService.Resolve(c.Request.Context(), c.GetHeader(acceptCurrencyHeader), userID)
+	if errors.Is(err, service.ErrUnsupportedCurrency) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "unsupported currency"})
+		return "", false
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to resolve currency", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return "", false
+	}
+	return currency, true
+}
+
+// priceProducts gives the SKUs of products the prices the signed-in user's
+// customer group pays and converts them into currency. Without the group's
+// prices SKUs keep their own, and prices that cannot be converted keep their
+// own currency, rather than failing the page, since every SKU states its
+// currency.
+func (h *ProductHandler) priceProducts(c *gin.Context, currency string, products ...service.ProductResp) {
+	if userID, _ := auth.UserID(c.Request.Context()); h.groupService != nil && userID != 0 {
+		for _, product := range products {
+			if err := h.groupService.ApplySKUs(c.Request.Context(), userID, product.SKUs); err != nil {
+				slog.WarnContext(c.Request.Context(), "Failed to apply customer group prices", "user_id", userID, logger.Err(err))
+				break
+			}
+		}
+	}
+	for _, product := range products {
+		if err := h.currencyService.ConvertSKUs(c.Request.Context(), product.SKUs, currency); err != nil {
+			slog.WarnContext(c.Request.Context(), "Failed to convert prices", "currency", currency, logger.Err(err))
+			return
+		}
+	}
+}
+
+// localize translates products into the locale negotiated from
+// Accept-Language, or the store's locale without one, and states it in
+// Content-Language. Products are served as written when their translations
+// cannot be read.
+func (h *ProductHandler) localize(c *gin.Context, products []service.ProductResp) {
+	acceptLanguage := c.GetHeader("Accept-Language")
+	if store := tenant.FromContext(c.Request.Context()); acceptLanguage == "" && store != nil {
+		acceptLanguage = store.Locale
+	}
+	locale := h.translationService.Negotiate(acceptLanguage)
+	if err := h.translationService.Localize(c.Request.Context(), locale, products); err != nil {
+		slog.WarnContext(c.Request.Context(), "Failed to translate products", "locale", locale, logger.Err(err))
+		locale = h.translationService.DefaultLocale()
+	}
+	c.Header("Content-Language", locale)
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/proyuen/go-mall/internal/mocks"
 	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/token"
 	"github.com/proyuen/go-mall/pkg/utils"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
@@ -25,6 +27,7 @@ type TestSKURequest struct {
 	Price      float64                `json:"price"`
 	Stock      int                    `json:"stock"`
 	Image      string                 `json:"image"`
+	Channels   []string               `json:"channels,omitempty"`
 }
 
 type TestCreateProductRequest struct {
@@ -123,7 +126,64 @@ func TestProductHandler_CreateProduct(t *testing.T) {
 				err := json.Unmarshal(w.Body.Bytes(), &resp)
 				require.NoError(t, err)
 				assert.Equal(t, float64(http.StatusBadRequest), resp["code"])
-				assert.Contains(t, resp["message"], "Field validation for 'Name' failed")
+				fieldErrs := resp["errors"].([]interface{})
+				require.Len(t, fieldErrs, 1)
+				assert.Equal(t, "name", fieldErrs[0].(map[string]interface{})["field"])
+				assert.Equal(t, "required", fieldErrs[0].(map[string]interface{})["rule"])
+			},
+		},
+		{
+			name: "InvalidInput_SubCentPrice",
+			args: args{
+				reqBody: TestCreateProductRequest{
+					Name:       productName,
+					CategoryID: 1,
+					SKUs: []TestSKURequest{
+						{Attributes: skuAttrs, Price: 99.999, Stock: 10},
+					},
+				},
+			},
+			fields: fields{
+				mockSetup: func(mockService *mocks.MockProductService) {
+					// Expect NO call to service
+				},
+			},
+			wantStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var resp map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				fieldErrs := resp["errors"].([]interface{})
+				require.Len(t, fieldErrs, 1)
+				assert.Equal(t, "skus[0].price", fieldErrs[0].(map[string]interface{})["field"])
+				assert.Equal(t, "price", fieldErrs[0].(map[string]interface{})["rule"])
+			},
+		},
+		{
+			name: "InvalidInput_UnknownChannel",
+			args: args{
+				reqBody: TestCreateProductRequest{
+					Name:       productName,
+					CategoryID: 1,
+					SKUs: []TestSKURequest{
+						{Attributes: skuAttrs, Price: 100.0, Stock: 10, Channels: []string{"web", "kiosk"}},
+					},
+				},
+			},
+			fields: fields{
+				mockSetup: func(mockService *mocks.MockProductService) {
+					// Expect NO call to service
+				},
+			},
+			wantStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var resp map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				fieldErrs := resp["errors"].([]interface{})
+				require.Len(t, fieldErrs, 1)
+				assert.Equal(t, "skus[0].channels[1]", fieldErrs[0].(map[string]interface{})["field"])
+				assert.Equal(t, "oneof", fieldErrs[0].(map[string]interface{})["rule"])
 			},
 		},
 		{
@@ -162,7 +222,7 @@ func TestProductHandler_CreateProduct(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockService := mocks.NewMockProductService(ctrl)
-			handler := NewProductHandler(mockService)
+			handler := NewProductHandler(mockService, nil, nil, nil, nil, nil)
 
 			w := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(w)
@@ -186,3 +246,360 @@ func TestProductHandler_CreateProduct(t *testing.T) {
 		})
 	}
 }
+
+func TestProductHandler_GetProductLocalized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		localizeErr  error
+		wantName     string
+		wantLanguage string
+	}{
+		{name: "Translated", wantName: "马克杯", wantLanguage: "zh"},
+		{name: "TranslationsUnavailable", localizeErr: errors.New("db down"), wantName: "Mug", wantLanguage: "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockProductService(ctrl)
+			mockCurrencies := mocks.NewMockCurrencyService(ctrl)
+			mockTranslations := mocks.NewMockTranslationService(ctrl)
+			mockPopularity := mocks.NewMockPopularityService(ctrl)
+			mockService.EXPECT().GetProduct(gomock.Any(), uint64(7)).Return(&service.ProductResp{ID: 7, Name: "Mug"}, nil)
+			mockPopularity.EXPECT().RecordView(gomock.Any(), uint64(7)).Return(nil)
+			mockCurrencies.EXPECT().Resolve(gomock.Any(), "", uint64(0)).Return("USD", nil)
+			mockCurrencies.EXPECT().ConvertSKUs(gomock.Any(), gomock.Any(), "USD").Return(nil)
+			mockTranslations.EXPECT().Negotiate("zh-CN,zh;q=0.9").Return("zh")
+			mockTranslations.EXPECT().Localize(gomock.Any(), "zh", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, products []service.ProductResp) error {
+				if tt.localizeErr != nil {
+					return tt.localizeErr
+				}
+				products[0].Name = "马克杯"
+				return nil
+			})
+			if tt.localizeErr != nil {
+				mockTranslations.EXPECT().DefaultLocale().Return("en")
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "7"}}
+			c.Request = httptest.NewRequest(http.MethodGet, "/products/7", nil)
+			c.Request.Header.Set("Accept-Language", "zh-CN,zh;q=0.9")
+
+			NewProductHandler(mockService, mockCurrencies, mockTranslations, mockPopularity, nil, nil).GetProduct(c)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.wantLanguage, w.Header().Get("Content-Language"))
+			var resp struct {
+				Data service.ProductResp `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.wantName, resp.Data.Name)
+		})
+	}
+}
+
+func TestProductHandler_GetProductGroupPrices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockService := mocks.NewMockProductService(ctrl)
+	mockCurrencies := mocks.NewMockCurrencyService(ctrl)
+	mockTranslations := mocks.NewMockTranslationService(ctrl)
+	mockPopularity := mocks.NewMockPopularityService(ctrl)
+	mockGroups := mocks.NewMockCustomerGroupService(ctrl)
+	product := &service.ProductResp{ID: 7, SKUs: []service.SKUResp{{ID: 70, Price: decimal.RequireFromString("20.00"), Currency: "USD"}}}
+	mockService.EXPECT().GetProduct(gomock.Any(), uint64(7)).Return(product, nil)
+	mockPopularity.EXPECT().RecordView(gomock.Any(), uint64(7)).Return(nil)
+	mockCurrencies.EXPECT().Resolve(gomock.Any(), "", uint64(5)).Return("USD", nil)
+	mockGroups.EXPECT().ApplySKUs(gomock.Any(), uint64(5), gomock.Any()).DoAndReturn(func(_ context.Context, _ uint64, skus []service.SKUResp) error {
+		skus[0].Price = decimal.RequireFromString("15.00")
+		return nil
+	})
+	// Group prices are converted like the SKUs' own
+	mockCurrencies.EXPECT().ConvertSKUs(gomock.Any(), gomock.Any(), "USD").DoAndReturn(func(_ context.Context, skus []service.SKUResp, _ string) error {
+		assert.Equal(t, "15", skus[0].Price.String())
+		return nil
+	})
+	mockTranslations.EXPECT().Negotiate("").Return("en")
+	mockTranslations.EXPECT().Localize(gomock.Any(), "en", gomock.Any()).Return(nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: "7"}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/products/7", nil)
+	c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 5}))
+
+	NewProductHandler(mockService, mockCurrencies, mockTranslations, mockPopularity, nil, mockGroups).GetProduct(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"price":"15"`)
+}
+
+func TestProductHandler_ListPopularProducts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantLimit  int
+		listErr    error
+		wantStatus int
+	}{
+		{name: "DefaultLimit", wantLimit: 10, wantStatus: http.StatusOK},
+		{name: "Limit", query: "?limit=3", wantLimit: 3, wantStatus: http.StatusOK},
+		{name: "LimitTooHigh", query: "?limit=51", wantStatus: http.StatusBadRequest},
+		{name: "LimitNotANumber", query: "?limit=ten", wantStatus: http.StatusBadRequest},
+		{name: "ListFails", wantLimit: 10, listErr: errors.New("db down"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockCurrencies := mocks.NewMockCurrencyService(ctrl)
+			mockTranslations := mocks.NewMockTranslationService(ctrl)
+			mockPopularity := mocks.NewMockPopularityService(ctrl)
+			if tt.wantLimit > 0 {
+				mockCurrencies.EXPECT().Resolve(gomock.Any(), "", uint64(0)).Return("USD", nil)
+				mockPopularity.EXPECT().ListPopular(gomock.Any(), tt.wantLimit).Return([]service.ProductResp{{ID: 7, Name: "Mug"}}, tt.listErr)
+			}
+			if tt.wantStatus == http.StatusOK {
+				mockCurrencies.EXPECT().ConvertSKUs(gomock.Any(), gomock.Any(), "USD").Return(nil)
+				mockTranslations.EXPECT().Negotiate("").Return("en")
+				mockTranslations.EXPECT().Localize(gomock.Any(), "en", gomock.Any()).Return(nil)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/products/popular"+tt.query, nil)
+
+			NewProductHandler(nil, mockCurrencies, mockTranslations, mockPopularity, nil, nil).ListPopularProducts(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				var resp struct {
+					Data []service.ProductResp `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				require.Len(t, resp.Data, 1)
+				assert.Equal(t, "Mug", resp.Data[0].Name)
+			}
+		})
+	}
+}
+
+func TestProductHandler_ListProductsFacets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	facets := &service.ProductFacets{Categories: []service.CategoryFacet{{CategoryID: 5, Name: "Shoes", Products: 12}}}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantFacets bool
+		facetsErr  error
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "WithoutFacets", wantStatus: http.StatusOK},
+		{name: "WithFacets", query: "?facets=true", wantFacets: true, wantStatus: http.StatusOK, wantBody: `"facets":{"categories":[{"category_id":"5","name":"Shoes","products":12}]`},
+		{name: "InvalidFacets", query: "?facets=maybe", wantStatus: http.StatusBadRequest},
+		{name: "FacetsFailed", query: "?facets=1", wantFacets: true, facetsErr: errors.New("db down"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockProductService(ctrl)
+			mockCurrencies := mocks.NewMockCurrencyService(ctrl)
+			mockTranslations := mocks.NewMockTranslationService(ctrl)
+			if tt.wantStatus != http.StatusBadRequest {
+				mockCurrencies.EXPECT().Resolve(gomock.Any(), "", uint64(0)).Return("USD", nil)
+				mockService.EXPECT().ListProducts(gomock.Any(), 0, 10).Return([]service.ProductResp{{ID: 7, Name: "Mug"}}, nil)
+				mockCurrencies.EXPECT().ConvertSKUs(gomock.Any(), gomock.Any(), "USD").Return(nil)
+				mockTranslations.EXPECT().Negotiate("").Return("en")
+				mockTranslations.EXPECT().Localize(gomock.Any(), "en", gomock.Any()).Return(nil)
+			}
+			if tt.wantFacets {
+				mockService.EXPECT().ListFacets(gomock.Any()).Return(facets, tt.facetsErr)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/products"+tt.query, nil)
+
+			NewProductHandler(mockService, mockCurrencies, mockTranslations, nil, nil, nil).ListProducts(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			if !tt.wantFacets {
+				assert.NotContains(t, w.Body.String(), `"facets"`)
+			}
+		})
+	}
+}
+
+func TestProductHandler_SuggestProducts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantQuery  string
+		wantLimit  int
+		suggestErr error
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "Success", query: "?q=Run", wantQuery: "Run", wantLimit: service.DefaultSuggestions, wantStatus: http.StatusOK, wantBody: `"kind":"product"`},
+		{name: "Limited", query: "?q=run&limit=5", wantQuery: "run", wantLimit: 5, wantStatus: http.StatusOK},
+		{name: "NoQuery", query: "?q=%20", wantStatus: http.StatusBadRequest},
+		{name: "LimitTooLarge", query: "?q=run&limit=21", wantStatus: http.StatusBadRequest},
+		{
+			name:       "ServiceError",
+			query:      "?q=run",
+			wantQuery:  "run",
+			wantLimit:  service.DefaultSuggestions,
+			suggestErr: errors.New("redis down"),
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockSuggestions := mocks.NewMockSuggestionService(ctrl)
+			if tt.wantLimit > 0 {
+				mockSuggestions.EXPECT().Suggest(gomock.Any(), tt.wantQuery, tt.wantLimit).
+					Return([]service.Suggestion{{Kind: service.SuggestionProduct, ID: 7, Text: "Running Shoes"}}, tt.suggestErr)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/products/suggest"+tt.query, nil)
+
+			NewProductHandler(nil, nil, nil, nil, mockSuggestions, nil).SuggestProducts(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestProductHandler_SuggestProductsZeroResult(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, recordErr := range []error{nil, errors.New("redis down")} {
+		ctrl := gomock.NewController(t)
+		mockSuggestions := mocks.NewMockSuggestionService(ctrl)
+		mockSuggestions.EXPECT().Suggest(gomock.Any(), "unicorn", service.DefaultSuggestions).Return([]service.Suggestion{}, nil)
+		mockSuggestions.EXPECT().RecordZeroResult(gomock.Any(), "unicorn").Return(recordErr)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/products/suggest?q=unicorn", nil)
+
+		NewProductHandler(nil, nil, nil, nil, mockSuggestions, nil).SuggestProducts(c)
+
+		assert.Equal(t, http.StatusOK, w.Code, "a search that is not counted still answers")
+	}
+}
+
+func TestProductHandler_BulkUpdatePrices(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	applied := &service.BulkPriceResp{Matched: 2, Updated: 1, Changes: []service.SKUPriceChange{
+		{SKUID: 9, SPUID: 1, Currency: "USD", OldPrice: decimal.NewFromInt(10), NewPrice: decimal.NewFromInt(12)},
+	}}
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockProductService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Changes",
+			reqBody: `{"changes":[{"sku_id":"9","price":"12.00"}]}`,
+			mockSetup: func(mockService *mocks.MockProductService) {
+				mockService.EXPECT().BulkUpdatePrices(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *service.BulkPriceReq) (*service.BulkPriceResp, error) {
+					require.Len(t, req.Changes, 1)
+					assert.Equal(t, uint64(9), req.Changes[0].SKUID)
+					assert.Equal(t, "12", req.Changes[0].Price.String())
+					return applied, nil
+				})
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"new_price":"12"`,
+		},
+		{
+			name:    "CategoryDryRun",
+			reqBody: `{"category_id":"7","percent":"-10","dry_run":true}`,
+			mockSetup: func(mockService *mocks.MockProductService) {
+				mockService.EXPECT().BulkUpdatePrices(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *service.BulkPriceReq) (*service.BulkPriceResp, error) {
+					assert.Equal(t, uint64(7), req.CategoryID)
+					assert.Equal(t, "-10", req.Percent.String())
+					assert.True(t, req.DryRun)
+					return &service.BulkPriceResp{DryRun: true}, nil
+				})
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"dry_run":true`,
+		},
+		{name: "InvalidPrice", reqBody: `{"changes":[{"sku_id":"9","price":"-1"}]}`, wantStatus: http.StatusBadRequest, wantBody: `price`},
+		{
+			name:    "InvalidChange",
+			reqBody: `{}`,
+			mockSetup: func(mockService *mocks.MockProductService) {
+				mockService.EXPECT().BulkUpdatePrices(gomock.Any(), gomock.Any()).Return(nil, service.ErrInvalidPriceChange)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "CategoryNotFound",
+			reqBody: `{"category_id":"7","percent":"5"}`,
+			mockSetup: func(mockService *mocks.MockProductService) {
+				mockService.EXPECT().BulkUpdatePrices(gomock.Any(), gomock.Any()).Return(nil, service.ErrCategoryNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:    "PartlyApplied",
+			reqBody: `{"category_id":"7","percent":"5"}`,
+			mockSetup: func(mockService *mocks.MockProductService) {
+				mockService.EXPECT().BulkUpdatePrices(gomock.Any(), gomock.Any()).Return(applied, errors.New("db down"))
+			},
+			wantStatus: http.StatusMultiStatus,
+			wantBody:   `"updated":1`,
+		},
+		{
+			name:    "ServiceError",
+			reqBody: `{"category_id":"7","percent":"5"}`,
+			mockSetup: func(mockService *mocks.MockProductService) {
+				mockService.EXPECT().BulkUpdatePrices(gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockProductService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/skus/bulk-price", bytes.NewBufferString(tt.reqBody))
+
+			NewProductHandler(mockService, nil, nil, nil, nil, nil).BulkUpdatePrices(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
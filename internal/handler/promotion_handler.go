@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/shopspring/decimal"
+)
+
+// PromotionHandler defines the HTTP handlers for managing promotions.
+type PromotionHandler struct {
+	promotionService service.PromotionService
+}
+
+// NewPromotionHandler creates a new PromotionHandler instance.
+func NewPromotionHandler(promotionService service.PromotionService) *PromotionHandler {
+	return &PromotionHandler{promotionService: promotionService}
+}
+
+// CreatePromotionRequest defines the request body for creating a promotion.
+type CreatePromotionRequest struct {
+	Name          string                     `json:"name" binding:"required,max=100" example:"Summer sale"`
+	Kind          string                     `json:"kind" binding:"required,oneof=buy_x_get_y tiered category_sale" example:"category_sale"`
+	Stacking      string                     `json:"stacking" binding:"omitempty,oneof=stackable exclusive" example:"stackable"` // Empty means stackable
+	Priority      int                        `json:"priority"`                                                                   // Higher applies first among stackable promotions
+	CategoryID    uint64                     `json:"category_id,string"`                                                         // Covers the category and its subcategories; 0 covers every item
+	BuyQuantity   int                        `json:"buy_quantity" binding:"min=0" example:"2"`                                   // buy_x_get_y only
+	GetQuantity   int                        `json:"get_quantity" binding:"min=0" example:"1"`                                   // buy_x_get_y only
+	Percent       decimal.Decimal            `json:"percent" swaggertype:"string" example:"20"`                                  // Off the free units of buy_x_get_y, or the items of category_sale
+	Currency      string                     `json:"currency" binding:"omitempty,len=3" example:"USD"`                           // Of the tier thresholds; empty means the base currency
+	Tiers         []service.PromotionTierReq `json:"tiers"`                                                                      // tiered only
+	CouponOnly    bool                       `json:"coupon_only"`                                                                // Applies only to orders with a code of its coupon campaigns
+	PurchaseLimit int                        `json:"purchase_limit" binding:"min=0" example:"2"`                                 // Most units of covered items one customer may buy under it; 0 is unlimited
+	StartsAt      time.Time                  `json:"starts_at"`                                                                  // Empty means now
+	EndsAt        *time.Time                 `json:"ends_at"`                                                                    // Empty runs until deleted
+}
+
+// CreatePromotion creates a promotion, applied to orders from when it starts
+// until it ends.
+//
+//	@Summary		Create a promotion
+//	@Description	Stackable promotions combine, highest priority first, each discounting what the ones before it left; an exclusive promotion applies alone. Checkout applies whichever gives the customer the larger discount, and lists each promotion applied with what it took off and why.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		CreatePromotionRequest	true	"Promotion payload"
+//	@Success		201		{object}	Response{data=service.PromotionResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/promotions [post]
+func (h *PromotionHandler) CreatePromotion(c *gin.Context) {
+	var req CreatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.promotionService.Create(c.Request.Context(), &service.PromotionCreateReq{
+		Name:          req.Name,
+		Kind:          req.Kind,
+		Stacking:      req.Stacking,
+		Priority:      req.Priority,
+		CategoryID:    req.CategoryID,
+		BuyQuantity:   req.BuyQuantity,
+		GetQuantity:   req.GetQuantity,
+		Percent:       req.Percent,
+		Currency:      req.Currency,
+		Tiers:         req.Tiers,
+		CouponOnly:    req.CouponOnly,
+		PurchaseLimit: req.PurchaseLimit,
+		StartsAt:      req.StartsAt,
+		EndsAt:        req.EndsAt,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPromotion) || errors.Is(err, service.ErrUnsupportedCurrency) {
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to create promotion", "kind", req.Kind, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Promotion created", "data": resp})
+}
+
+// ListPromotions returns every promotion, including those not running.
+//
+//	@Summary	List promotions
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Success	200	{object}	Response{data=[]service.PromotionResp}
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/promotions [get]
+func (h *PromotionHandler) ListPromotions(c *gin.Context) {
+	promotions, err := h.promotionService.List(c.Request.Context())
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list promotions", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": promotions})
+}
+
+// DeletePromotion ends a promotion. Orders placed already keep its discount.
+//
+//	@Summary	Delete a promotion
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Promotion ID"
+//	@Success	200	{object}	Response
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/promotions/{id} [delete]
+func (h *PromotionHandler) DeletePromotion(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "promotion")
+	if !ok {
+		return
+	}
+
+	if err := h.promotionService.Delete(c.Request.Context(), id); err != nil {
+		if errors.Is(err, service.ErrPromotionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to delete promotion", "promotion_id", id, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Promotion deleted"})
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPromotionHandler_CreatePromotion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockPromotionService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"name":"Spend more","kind":"tiered","tiers":[{"threshold":"100","percent":"10"}]}`,
+			mockSetup: func(mockService *mocks.MockPromotionService) {
+				mockService.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *service.PromotionCreateReq) (*service.PromotionResp, error) {
+					require.Len(t, req.Tiers, 1)
+					assert.Equal(t, "100", req.Tiers[0].Threshold.String())
+					return &service.PromotionResp{ID: 9, Name: req.Name, Kind: req.Kind}, nil
+				})
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"id":"9"`,
+		},
+		{name: "UnknownKind", reqBody: `{"name":"Coupon","kind":"coupon"}`, wantStatus: http.StatusBadRequest, wantBody: `"field":"kind"`},
+		{
+			name:    "InvalidRule",
+			reqBody: `{"name":"3 for 2","kind":"buy_x_get_y","buy_quantity":2}`,
+			mockSetup: func(mockService *mocks.MockPromotionService) {
+				mockService.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("%w: buy and get quantities must be positive", service.ErrInvalidPromotion))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "quantities must be positive",
+		},
+		{
+			name:    "ServiceError",
+			reqBody: `{"name":"Shirts","kind":"category_sale","percent":"20"}`,
+			mockSetup: func(mockService *mocks.MockPromotionService) {
+				mockService.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockPromotionService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewPromotionHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/promotions", bytes.NewBufferString(tt.reqBody))
+
+			handler.CreatePromotion(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestPromotionHandler_DeletePromotion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "Success", wantStatus: http.StatusOK},
+		{name: "NotFound", err: service.ErrPromotionNotFound, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockPromotionService(ctrl)
+			mockService.EXPECT().Delete(gomock.Any(), uint64(9)).Return(tt.err)
+			handler := NewPromotionHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "9"}}
+			c.Request = httptest.NewRequest(http.MethodDelete, "/admin/promotions/9", nil)
+
+			handler.DeletePromotion(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
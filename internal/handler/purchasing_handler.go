@@ -0,0 +1,446 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/shopspring/decimal"
+)
+
+// PurchasingHandler defines the admin HTTP handlers for suppliers and the
+// purchase orders that bring stock in.
+type PurchasingHandler struct {
+	purchasingService service.PurchasingService
+}
+
+// NewPurchasingHandler creates a new PurchasingHandler instance.
+func NewPurchasingHandler(purchasingService service.PurchasingService) *PurchasingHandler {
+	return &PurchasingHandler{purchasingService: purchasingService}
+}
+
+// SupplierRequest defines the request body for creating or updating a
+// supplier.
+type SupplierRequest struct {
+	Name         string `json:"name" binding:"required,max=100" example:"Acme Wholesale"`
+	Email        string `json:"email" binding:"omitempty,email,max=255" example:"orders@acme.example"`
+	Phone        string `json:"phone" binding:"max=30" example:"+49 30 1234567"`
+	LeadTimeDays int    `json:"lead_time_days" binding:"min=0,max=365" example:"14"` // How long its deliveries usually take
+}
+
+// PurchaseOrderRequest defines the request body for ordering stock from a
+// supplier.
+type PurchaseOrderRequest struct {
+	SupplierID uint64                     `json:"supplier_id,string" binding:"required,gt=0" example:"1234567890"`
+	Currency   string                     `json:"currency" binding:"required,iso4217" example:"USD"` // Of the unit costs
+	ExpectedAt *time.Time                 `json:"expected_at"`                                       // When the goods are expected; omitted is the supplier's lead time from now
+	Note       string                     `json:"note" binding:"max=2000" example:"Spring restock"`
+	Items      []PurchaseOrderItemRequest `json:"items" binding:"required,min=1,max=500,dive"`
+}
+
+// PurchaseOrderItemRequest defines a quantity of a SKU ordered from a
+// supplier.
+type PurchaseOrderItemRequest struct {
+	SKUID    uint64          `json:"sku_id,string" binding:"required,gt=0" example:"1234567890"`
+	Quantity int             `json:"quantity" binding:"required,gt=0,max=1000000" example:"500"`
+	UnitCost decimal.Decimal `json:"unit_cost" swaggertype:"string" example:"8.50"`
+}
+
+// PurchaseOrderQuery defines the filters and paging of purchase orders.
+type PurchaseOrderQuery struct {
+	Status     string `form:"status" binding:"omitempty,oneof=open received cancelled" example:"open"`
+	SupplierID uint64 `form:"supplier_id" example:"1234567890"`
+	Offset     int    `form:"offset" binding:"min=0"`
+	Limit      int    `form:"limit" binding:"min=0,max=100"`
+}
+
+// PurchasingPageQuery defines the paging of suppliers and stock movements.
+type PurchasingPageQuery struct {
+	Offset int `form:"offset" binding:"min=0"`
+	Limit  int `form:"limit" binding:"min=0,max=100"`
+}
+
+// RescheduleRequest defines the request body for moving the expected date of
+// a purchase order.
+type RescheduleRequest struct {
+	ExpectedAt time.Time `json:"expected_at" binding:"required"`
+}
+
+// ReceiptRequest defines the request body for receiving goods of a purchase
+// order.
+type ReceiptRequest struct {
+	Items []ReceiptItemRequest `json:"items" binding:"required,min=1,max=500,dive"`
+}
+
+// ReceiptItemRequest defines a quantity of a SKU received.
+type ReceiptItemRequest struct {
+	SKUID    uint64 `json:"sku_id,string" binding:"required,gt=0" example:"1234567890"`
+	Quantity int    `json:"quantity" binding:"required,gt=0" example:"200"`
+}
+
+// CreateSupplier adds a supplier to buy stock from.
+//
+//	@Summary	Create a supplier
+//	@Tags		admin
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		request	body		SupplierRequest	true	"Supplier"
+//	@Success	201		{object}	Response{data=service.SupplierResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/suppliers [post]
+func (h *PurchasingHandler) CreateSupplier(c *gin.Context) {
+	var req SupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	supplier, err := h.purchasingService.CreateSupplier(c.Request.Context(), newSupplierReq(&req))
+	if err != nil {
+		respondPurchasingError(c, "Failed to create supplier", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Supplier created", "data": supplier})
+}
+
+// ListSuppliers returns the suppliers by name.
+//
+//	@Summary	List suppliers
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		offset	query		integer	false	"Offset"
+//	@Param		limit	query		integer	false	"Limit"
+//	@Success	200		{object}	Response{data=[]service.SupplierResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/suppliers [get]
+func (h *PurchasingHandler) ListSuppliers(c *gin.Context) {
+	var query PurchasingPageQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	suppliers, err := h.purchasingService.ListSuppliers(c.Request.Context(), query.Offset, query.Limit)
+	if err != nil {
+		respondPurchasingError(c, "Failed to list suppliers", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": suppliers})
+}
+
+// UpdateSupplier replaces the details of a supplier.
+//
+//	@Summary		Update a supplier
+//	@Description	A new lead time applies to purchase orders created afterwards.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		integer			true	"Supplier ID"
+//	@Param			request	body		SupplierRequest	true	"Supplier"
+//	@Success		200		{object}	Response{data=service.SupplierResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/suppliers/{id} [put]
+func (h *PurchasingHandler) UpdateSupplier(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "supplier")
+	if !ok {
+		return
+	}
+	var req SupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	supplier, err := h.purchasingService.UpdateSupplier(c.Request.Context(), id, newSupplierReq(&req))
+	if err != nil {
+		respondPurchasingError(c, "Failed to update supplier", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Supplier updated", "data": supplier})
+}
+
+// CreatePurchaseOrder orders stock from a supplier.
+//
+//	@Summary		Create a purchase order
+//	@Description	The goods are added to stock as they are received. Until then, pre-order SKUs on open purchase orders are shown available at the earliest date one of those orders is expected.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		PurchaseOrderRequest	true	"Purchase order"
+//	@Success		201		{object}	Response{data=service.PurchaseOrderResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/purchase-orders [post]
+func (h *PurchasingHandler) CreatePurchaseOrder(c *gin.Context) {
+	var req PurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	items := make([]service.PurchaseOrderItemReq, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = service.PurchaseOrderItemReq{SKUID: item.SKUID, Quantity: item.Quantity, UnitCost: item.UnitCost}
+	}
+	po, err := h.purchasingService.CreatePurchaseOrder(c.Request.Context(), &service.PurchaseOrderReq{
+		SupplierID: req.SupplierID,
+		Currency:   req.Currency,
+		ExpectedAt: req.ExpectedAt,
+		Note:       req.Note,
+		Items:      items,
+	})
+	if err != nil {
+		respondPurchasingError(c, "Failed to create purchase order", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Purchase order created", "data": po})
+}
+
+// ListPurchaseOrders returns purchase orders, most recent first.
+//
+//	@Summary	List purchase orders
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		query	query		PurchaseOrderQuery	false	"Filters and paging"
+//	@Success	200		{object}	Response{data=[]service.PurchaseOrderResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/purchase-orders [get]
+func (h *PurchasingHandler) ListPurchaseOrders(c *gin.Context) {
+	var query PurchaseOrderQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	pos, err := h.purchasingService.ListPurchaseOrders(c.Request.Context(), query.Status, query.SupplierID, query.Offset, query.Limit)
+	if err != nil {
+		respondPurchasingError(c, "Failed to list purchase orders", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": pos})
+}
+
+// GetPurchaseOrder returns a purchase order and what was received of it.
+//
+//	@Summary	Get a purchase order
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Purchase order ID"
+//	@Success	200	{object}	Response{data=service.PurchaseOrderResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/purchase-orders/{id} [get]
+func (h *PurchasingHandler) GetPurchaseOrder(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "purchase order")
+	if !ok {
+		return
+	}
+
+	po, err := h.purchasingService.GetPurchaseOrder(c.Request.Context(), id)
+	if err != nil {
+		respondPurchasingError(c, "Failed to get purchase order", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": po})
+}
+
+// ReschedulePurchaseOrder moves the date the goods of an open purchase order
+// are expected.
+//
+//	@Summary		Reschedule a purchase order
+//	@Description	Pre-order SKUs on the purchase order are shown available at the new earliest expected date.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		integer				true	"Purchase order ID"
+//	@Param			request	body		RescheduleRequest	true	"Expected date"
+//	@Success		200		{object}	Response{data=service.PurchaseOrderResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/purchase-orders/{id}/expected-at [put]
+func (h *PurchasingHandler) ReschedulePurchaseOrder(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "purchase order")
+	if !ok {
+		return
+	}
+	var req RescheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	po, err := h.purchasingService.Reschedule(c.Request.Context(), id, req.ExpectedAt)
+	if err != nil {
+		respondPurchasingError(c, "Failed to reschedule purchase order", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Purchase order rescheduled", "data": po})
+}
+
+// ReceivePurchaseOrder adds goods delivered on an open purchase order to
+// stock.
+//
+//	@Summary		Receive goods of a purchase order
+//	@Description	Adds the quantities to the stock of the SKUs and to their stock ledger. A purchase order can be received in several deliveries, each at most what is outstanding of its items; it is received once all of it was. Backorders waiting for the goods get them at the next allocation run.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		integer			true	"Purchase order ID"
+//	@Param			request	body		ReceiptRequest	true	"Goods received"
+//	@Success		200		{object}	Response{data=service.PurchaseOrderResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/purchase-orders/{id}/receipts [post]
+func (h *PurchasingHandler) ReceivePurchaseOrder(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "purchase order")
+	if !ok {
+		return
+	}
+	var req ReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	lines := make([]service.ReceiptLine, len(req.Items))
+	for i, item := range req.Items {
+		lines[i] = service.ReceiptLine{SKUID: item.SKUID, Quantity: item.Quantity}
+	}
+	po, err := h.purchasingService.Receive(c.Request.Context(), id, lines)
+	if err != nil {
+		respondPurchasingError(c, "Failed to receive purchase order", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Goods received", "data": po})
+}
+
+// CancelPurchaseOrder gives up on what was not received of an open purchase
+// order.
+//
+//	@Summary	Cancel a purchase order
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Purchase order ID"
+//	@Success	200	{object}	Response{data=service.PurchaseOrderResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	409	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/purchase-orders/{id}/cancel [post]
+func (h *PurchasingHandler) CancelPurchaseOrder(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "purchase order")
+	if !ok {
+		return
+	}
+
+	po, err := h.purchasingService.CancelPurchaseOrder(c.Request.Context(), id)
+	if err != nil {
+		respondPurchasingError(c, "Failed to cancel purchase order", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Purchase order cancelled", "data": po})
+}
+
+// ListStockMovements returns the stock ledger of a SKU, most recent first.
+//
+//	@Summary	List stock movements of a SKU
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id		path		integer	true	"SKU ID"
+//	@Param		offset	query		integer	false	"Offset"
+//	@Param		limit	query		integer	false	"Limit"
+//	@Success	200		{object}	Response{data=[]service.StockMovementResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/skus/{id}/stock-movements [get]
+func (h *PurchasingHandler) ListStockMovements(c *gin.Context) {
+	skuID, ok := parseIDParam(c, "id", "SKU")
+	if !ok {
+		return
+	}
+	var query PurchasingPageQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	movements, err := h.purchasingService.ListStockMovements(c.Request.Context(), skuID, query.Offset, query.Limit)
+	if err != nil {
+		respondPurchasingError(c, "Failed to list stock movements", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": movements})
+}
+
+func newSupplierReq(req *SupplierRequest) *service.SupplierReq {
+	return &service.SupplierReq{Name: req.Name, Email: req.Email, Phone: req.Phone, LeadTimeDays: req.LeadTimeDays}
+}
+
+func respondPurchasingError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidPurchaseOrder):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+	case errors.Is(err, service.ErrSupplierNotFound), errors.Is(err, service.ErrPurchaseOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+	case errors.Is(err, service.ErrPurchaseOrderStatus):
+		c.JSON(http.StatusConflict, gin.H{"code": http.StatusConflict, "message": err.Error()})
+	default:
+		slog.ErrorContext(c.Request.Context(), msg, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
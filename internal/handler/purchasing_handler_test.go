@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPurchasingHandler_CreatePurchaseOrder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockPurchasingService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"supplier_id":"5","currency":"USD","items":[{"sku_id":"101","quantity":500,"unit_cost":"8.50"}]}`,
+			mockSetup: func(mockService *mocks.MockPurchasingService) {
+				mockService.EXPECT().CreatePurchaseOrder(gomock.Any(), &service.PurchaseOrderReq{SupplierID: 5, Currency: "USD", Items: []service.PurchaseOrderItemReq{
+					{SKUID: 101, Quantity: 500, UnitCost: decimal.RequireFromString("8.50")},
+				}}).Return(&service.PurchaseOrderResp{ID: 9, Status: "open"}, nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"status":"open"`,
+		},
+		{name: "NoItems", reqBody: `{"supplier_id":"5","currency":"USD","items":[]}`, wantStatus: http.StatusBadRequest, wantBody: `{"field":"items","rule":"min"`},
+		{
+			name:    "UnknownSupplier",
+			reqBody: `{"supplier_id":"5","currency":"USD","items":[{"sku_id":"101","quantity":1}]}`,
+			mockSetup: func(mockService *mocks.MockPurchasingService) {
+				mockService.EXPECT().CreatePurchaseOrder(gomock.Any(), gomock.Any()).Return(nil, service.ErrSupplierNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   service.ErrSupplierNotFound.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockPurchasingService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/purchase-orders", bytes.NewBufferString(tt.reqBody))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			NewPurchasingHandler(mockService).CreatePurchaseOrder(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestPurchasingHandler_ReceivePurchaseOrder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		id         string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockPurchasingService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			id:      "9",
+			reqBody: `{"items":[{"sku_id":"101","quantity":200}]}`,
+			mockSetup: func(mockService *mocks.MockPurchasingService) {
+				mockService.EXPECT().Receive(gomock.Any(), uint64(9), []service.ReceiptLine{{SKUID: 101, Quantity: 200}}).
+					Return(&service.PurchaseOrderResp{ID: 9, Status: "received"}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"status":"received"`,
+		},
+		{
+			name:    "MoreThanOutstanding",
+			id:      "9",
+			reqBody: `{"items":[{"sku_id":"101","quantity":200}]}`,
+			mockSetup: func(mockService *mocks.MockPurchasingService) {
+				mockService.EXPECT().Receive(gomock.Any(), uint64(9), gomock.Any()).Return(nil, service.ErrInvalidPurchaseOrder)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   service.ErrInvalidPurchaseOrder.Error(),
+		},
+		{
+			name:    "Cancelled",
+			id:      "9",
+			reqBody: `{"items":[{"sku_id":"101","quantity":200}]}`,
+			mockSetup: func(mockService *mocks.MockPurchasingService) {
+				mockService.EXPECT().Receive(gomock.Any(), uint64(9), gomock.Any()).Return(nil, service.ErrPurchaseOrderStatus)
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   service.ErrPurchaseOrderStatus.Error(),
+		},
+		{name: "ZeroQuantity", id: "9", reqBody: `{"items":[{"sku_id":"101","quantity":0}]}`, wantStatus: http.StatusBadRequest, wantBody: `"rule":"required"`},
+		{name: "InvalidID", id: "abc", reqBody: `{}`, wantStatus: http.StatusBadRequest, wantBody: "invalid purchase order id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockPurchasingService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/purchase-orders/"+tt.id+"/receipts", bytes.NewBufferString(tt.reqBody))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			NewPurchasingHandler(mockService).ReceivePurchaseOrder(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
@@ -0,0 +1,349 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/shopspring/decimal"
+)
+
+// QuoteHandler defines the HTTP handlers for the quotes bulk buyers ask for
+// and admins price.
+type QuoteHandler struct {
+	quoteService service.QuoteService
+}
+
+// NewQuoteHandler creates a new QuoteHandler instance.
+func NewQuoteHandler(quoteService service.QuoteService) *QuoteHandler {
+	return &QuoteHandler{quoteService: quoteService}
+}
+
+// QuoteRequest defines the request body for asking for a quote.
+type QuoteRequest struct {
+	Items []QuoteItemRequest `json:"items" binding:"required,min=1,max=100,dive"`
+	// Currency the items are to be priced in; empty is the caller's
+	// preferred currency.
+	Currency string `json:"currency" binding:"omitempty,iso4217" example:"USD"`
+	Region   string `json:"region" binding:"omitempty,iso3166_1_alpha2|iso3166_2" example:"DE"` // Where the order would ship, for tax
+	Note     string `json:"note" binding:"max=2000" example:"Monthly volume for our 12 stores"`
+}
+
+// QuoteItemRequest defines a quantity of a SKU asked for in a quote.
+type QuoteItemRequest struct {
+	SKUID    uint64 `json:"sku_id" binding:"required,gt=0" example:"1234567890"`
+	Quantity int    `json:"quantity" binding:"required,gt=0,max=1000000" example:"500"`
+}
+
+// AcceptQuoteRequest defines the optional request body for accepting a quote.
+type AcceptQuoteRequest struct {
+	// PaymentMethodID is one of the caller's saved payment methods to pay
+	// the order with right away. Without it the order waits for payment.
+	PaymentMethodID uint64 `json:"payment_method_id,string" example:"1234567890"`
+}
+
+// QuoteQuery defines the paging of quotes, and for admins the status to list.
+type QuoteQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=requested quoted accepted declined" example:"requested"`
+	Offset int    `form:"offset" binding:"min=0"`
+	Limit  int    `form:"limit" binding:"min=0,max=100"`
+}
+
+// RespondQuoteRequest defines the request body for pricing a quote.
+type RespondQuoteRequest struct {
+	Items     []QuotePriceRequest `json:"items" binding:"required,min=1,dive"` // A price for every item of the quote
+	ExpiresAt time.Time           `json:"expires_at" binding:"required"`       // Until when the buyer can accept the quote
+}
+
+// QuotePriceRequest defines the quoted unit price of a SKU.
+type QuotePriceRequest struct {
+	SKUID uint64          `json:"sku_id,string" binding:"required,gt=0" example:"1234567890"`
+	Price decimal.Decimal `json:"price" binding:"required,price" swaggertype:"string" example:"8.50"` // In the quote's currency
+}
+
+// RequestQuote asks for a price on quantities of SKUs.
+//
+//	@Summary		Ask for a quote
+//	@Description	Bulk buyers ask for a price on the quantities they need. An admin answers with a unit price for each SKU and an expiry; the buyer can then accept the quote, which places an order at the quoted prices.
+//	@Tags			quotes
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		QuoteRequest	true	"Quote request"
+//	@Success		201		{object}	Response{data=service.QuoteResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/quotes [post]
+func (h *QuoteHandler) RequestQuote(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	var req QuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	items := make([]service.OrderItemReq, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = service.OrderItemReq{SKUID: item.SKUID, Quantity: item.Quantity}
+	}
+	quote, err := h.quoteService.Request(c.Request.Context(), &service.QuoteReq{
+		UserID:   userID,
+		Currency: req.Currency,
+		Region:   req.Region,
+		Note:     req.Note,
+		Items:    items,
+	})
+	if err != nil {
+		respondQuoteError(c, "Failed to request quote", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Quote requested", "data": quote})
+}
+
+// ListQuotes returns the caller's quotes, most recent first.
+//
+//	@Summary	List quotes
+//	@Tags		quotes
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		offset	query		integer	false	"Offset"
+//	@Param		limit	query		integer	false	"Limit"
+//	@Success	200		{object}	Response{data=[]service.QuoteResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/quotes [get]
+func (h *QuoteHandler) ListQuotes(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	var query QuoteQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	quotes, err := h.quoteService.ListByUser(c.Request.Context(), userID, query.Offset, query.Limit)
+	if err != nil {
+		respondQuoteError(c, "Failed to list quotes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": quotes})
+}
+
+// GetQuote returns one of the caller's quotes.
+//
+//	@Summary	Get a quote
+//	@Tags		quotes
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Quote ID"
+//	@Success	200	{object}	Response{data=service.QuoteResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/quotes/{id} [get]
+func (h *QuoteHandler) GetQuote(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	id, ok := parseIDParam(c, "id", "quote")
+	if !ok {
+		return
+	}
+
+	quote, err := h.quoteService.Get(c.Request.Context(), userID, id)
+	if err != nil {
+		respondQuoteError(c, "Failed to get quote", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": quote})
+}
+
+// AcceptQuote places an order of one of the caller's quotes at its quoted
+// prices.
+//
+//	@Summary		Accept a quote
+//	@Description	Places an order of the quoted items at the quoted prices, in the quote's currency, however the SKUs were repriced since; promotions and customer group prices do not apply. Tax is added as at checkout. A quote places one order, and can be accepted until it expires. When the order cannot be placed, e.g. for lack of stock, the quote can be accepted again.
+//	@Tags			quotes
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		integer				true	"Quote ID"
+//	@Param			request	body		AcceptQuoteRequest	false	"Payment"
+//	@Success		201		{object}	Response{data=service.OrderCreateResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Failure		503		{object}	ErrorResponse
+//	@Router			/quotes/{id}/accept [post]
+func (h *QuoteHandler) AcceptQuote(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	id, ok := parseIDParam(c, "id", "quote")
+	if !ok {
+		return
+	}
+	var req AcceptQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.quoteService.Accept(c.Request.Context(), userID, id, req.PaymentMethodID)
+	switch {
+	case errors.Is(err, service.ErrQuoteNotFound), errors.Is(err, service.ErrQuoteStatus), errors.Is(err, service.ErrQuoteExpired):
+		respondQuoteError(c, "Failed to accept quote", err)
+		return
+	case err != nil:
+		// Placing the order failed, e.g. for lack of stock
+		respondOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Order created successfully", "data": resp})
+}
+
+// ListAllQuotes returns the quotes of all buyers, oldest first.
+//
+//	@Summary		List quotes of all buyers
+//	@Description	Quoted quotes that expired are listed with status quoted and shown as expired.
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			query	query		QuoteQuery	false	"Filter and paging"
+//	@Success		200		{object}	Response{data=[]service.QuoteResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/quotes [get]
+func (h *QuoteHandler) ListAllQuotes(c *gin.Context) {
+	var query QuoteQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	quotes, err := h.quoteService.List(c.Request.Context(), query.Status, query.Offset, query.Limit)
+	if err != nil {
+		respondQuoteError(c, "Failed to list quotes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": quotes})
+}
+
+// RespondToQuote prices a quote for its buyer.
+//
+//	@Summary		Respond to a quote
+//	@Description	Every item of the quote gets a unit price, in the quote's currency, which the buyer can accept until expires_at. A quote that was quoted already, expired or not, can be priced again; accepted and declined quotes are final.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		integer				true	"Quote ID"
+//	@Param			request	body		RespondQuoteRequest	true	"Quoted prices"
+//	@Success		200		{object}	Response{data=service.QuoteResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/quotes/{id}/respond [post]
+func (h *QuoteHandler) RespondToQuote(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "quote")
+	if !ok {
+		return
+	}
+	var req RespondQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	prices := make(map[uint64]decimal.Decimal, len(req.Items))
+	for _, item := range req.Items {
+		prices[item.SKUID] = item.Price
+	}
+	if len(prices) != len(req.Items) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "SKU priced twice"})
+		return
+	}
+	quote, err := h.quoteService.Respond(c.Request.Context(), id, prices, req.ExpiresAt)
+	if err != nil {
+		respondQuoteError(c, "Failed to respond to quote", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Quote sent", "data": quote})
+}
+
+// DeclineQuote turns a quote down for good.
+//
+//	@Summary	Decline a quote
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Quote ID"
+//	@Success	200	{object}	Response{data=service.QuoteResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	409	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/quotes/{id}/decline [post]
+func (h *QuoteHandler) DeclineQuote(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "quote")
+	if !ok {
+		return
+	}
+
+	quote, err := h.quoteService.Decline(c.Request.Context(), id)
+	if err != nil {
+		respondQuoteError(c, "Failed to decline quote", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Quote declined", "data": quote})
+}
+
+func respondQuoteError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidQuote), errors.Is(err, service.ErrUnsupportedCurrency):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+	case errors.Is(err, service.ErrQuoteNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+	case errors.Is(err, service.ErrQuoteStatus), errors.Is(err, service.ErrQuoteExpired):
+		c.JSON(http.StatusConflict, gin.H{"code": http.StatusConflict, "message": err.Error()})
+	default:
+		slog.ErrorContext(c.Request.Context(), msg, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestQuoteHandler_RequestQuote(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockQuoteService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"items":[{"sku_id":101,"quantity":500}],"region":"DE","note":"monthly volume"}`,
+			mockSetup: func(mockService *mocks.MockQuoteService) {
+				mockService.EXPECT().Request(gomock.Any(), &service.QuoteReq{UserID: 1, Region: "DE", Note: "monthly volume", Items: []service.OrderItemReq{{SKUID: 101, Quantity: 500}}}).
+					Return(&service.QuoteResp{ID: 9, Status: "requested"}, nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"status":"requested"`,
+		},
+		{name: "NoItems", reqBody: `{"items":[]}`, wantStatus: http.StatusBadRequest, wantBody: `{"field":"items","rule":"min"`},
+		{
+			name:    "SKUNotFound",
+			reqBody: `{"items":[{"sku_id":101,"quantity":500}]}`,
+			mockSetup: func(mockService *mocks.MockQuoteService) {
+				mockService.EXPECT().Request(gomock.Any(), gomock.Any()).Return(nil, service.ErrInvalidQuote)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   service.ErrInvalidQuote.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockQuoteService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/quotes", bytes.NewBufferString(tt.reqBody))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			NewQuoteHandler(mockService).RequestQuote(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestQuoteHandler_AcceptQuote(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockQuoteService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "WithPaymentMethod",
+			reqBody: `{"payment_method_id":"3"}`,
+			mockSetup: func(mockService *mocks.MockQuoteService) {
+				mockService.EXPECT().Accept(gomock.Any(), uint64(1), uint64(9), uint64(3)).Return(&service.OrderCreateResp{OrderID: 77, Status: "paid"}, nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"order_id":"77"`,
+		},
+		{
+			name: "WithoutBody",
+			mockSetup: func(mockService *mocks.MockQuoteService) {
+				mockService.EXPECT().Accept(gomock.Any(), uint64(1), uint64(9), uint64(0)).Return(&service.OrderCreateResp{OrderID: 77, Status: "pending"}, nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"status":"pending"`,
+		},
+		{
+			name: "Expired",
+			mockSetup: func(mockService *mocks.MockQuoteService) {
+				mockService.EXPECT().Accept(gomock.Any(), uint64(1), uint64(9), uint64(0)).Return(nil, service.ErrQuoteExpired)
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   service.ErrQuoteExpired.Error(),
+		},
+		{
+			name: "OutOfStock",
+			mockSetup: func(mockService *mocks.MockQuoteService) {
+				mockService.EXPECT().Accept(gomock.Any(), uint64(1), uint64(9), uint64(0)).Return(nil, service.ErrInsufficientStock.WithSKU(101))
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   `"reason":"stock_insufficient"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockQuoteService(ctrl)
+			tt.mockSetup(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "9"}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/quotes/9/accept", bytes.NewBufferString(tt.reqBody))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			NewQuoteHandler(mockService).AcceptQuote(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestQuoteHandler_RespondToQuote(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockQuoteService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"items":[{"sku_id":"101","price":"8.50"}],"expires_at":"2030-01-01T00:00:00Z"}`,
+			mockSetup: func(mockService *mocks.MockQuoteService) {
+				mockService.EXPECT().Respond(gomock.Any(), uint64(9), map[uint64]decimal.Decimal{101: decimal.RequireFromString("8.50")}, gomock.Any()).
+					Return(&service.QuoteResp{ID: 9, Status: "quoted"}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"status":"quoted"`,
+		},
+		{
+			name:       "PricedTwice",
+			reqBody:    `{"items":[{"sku_id":"101","price":"8.50"},{"sku_id":"101","price":"9"}],"expires_at":"2030-01-01T00:00:00Z"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "SKU priced twice",
+		},
+		{name: "NoExpiry", reqBody: `{"items":[{"sku_id":"101","price":"8.50"}]}`, wantStatus: http.StatusBadRequest, wantBody: `{"field":"expires_at","rule":"required"`},
+		{
+			name:    "AlreadyAccepted",
+			reqBody: `{"items":[{"sku_id":"101","price":"8.50"}],"expires_at":"2030-01-01T00:00:00Z"}`,
+			mockSetup: func(mockService *mocks.MockQuoteService) {
+				mockService.EXPECT().Respond(gomock.Any(), uint64(9), gomock.Any(), gomock.Any()).Return(nil, service.ErrQuoteStatus)
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   service.ErrQuoteStatus.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockQuoteService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "9"}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/quotes/9/respond", bytes.NewBufferString(tt.reqBody))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			NewQuoteHandler(mockService).RespondToQuote(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
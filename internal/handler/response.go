@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/proyuen/go-mall/pkg/validation"
+)
+
+// acceptCurrencyHeader asks for prices in an ISO 4217 currency, e.g. "EUR".
+const acceptCurrencyHeader = "Accept-Currency"
+
+// respondBindError writes a 400 response for a failed ShouldBind* call.
+// Validation failures are reported as localized {field, rule, message} entries;
+// anything else (e.g. malformed JSON) gets a generic message so parser internals are not leaked.
+func respondBindError(c *gin.Context, err error) {
+	fieldErrs, ok := validation.Translate(err, c.GetHeader("Accept-Language"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "invalid request body"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "invalid request parameters", "errors": fieldErrs})
+}
+
+// parseIDParam reads the path parameter name as an ID of a what, e.g.
+// "product". It writes the error response and returns false when it is not
+// an ID.
+func parseIDParam(c *gin.Context, name, what string) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "invalid " + what + " id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// respondError writes the response for a service error the handler does not
+// report otherwise. An *apperr.Error is reported with the status of its code,
+// and the code as the reason along with its metadata so that clients can
+// tell failures apart; anything else is a 500.
+func respondError(c *gin.Context, err error) {
+	var appErr *apperr.Error
+	if !errors.As(err, &appErr) {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": err.Error()})
+		return
+	}
+	status := appErr.Code.HTTPStatus()
+	body := gin.H{"code": status, "message": appErr.Message, "reason": appErr.Code}
+	if len(appErr.Meta) > 0 {
+		body["metadata"] = appErr.Meta
+	}
+	c.JSON(status, body)
+}
+
+// Response is the JSON envelope returned by every endpoint.
+// Handlers build it with gin.H; the type exists so the OpenAPI spec can describe it.
+type Response struct {
+	Code    int         `json:"code" example:"200"`
+	Message string      `json:"message" example:"success"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// ErrorResponse is the envelope for failed requests.
+type ErrorResponse struct {
+	Code    int                     `json:"code" example:"400"`
+	Message string                  `json:"message" example:"invalid request parameters"`
+	Errors  []validation.FieldError `json:"errors,omitempty"`
+	// Reason is the apperr code of a failure the client may handle, e.g.
+	// "stock_insufficient", and Metadata what it is about.
+	Reason   string         `json:"reason,omitempty" example:"stock_insufficient"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
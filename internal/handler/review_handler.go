@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// ReviewHandler defines the HTTP handlers for feedback on reviews and their
+// moderation.
+type ReviewHandler struct {
+	reviewService service.ReviewService
+}
+
+// NewReviewHandler creates a new ReviewHandler instance.
+func NewReviewHandler(reviewService service.ReviewService) *ReviewHandler {
+	return &ReviewHandler{reviewService: reviewService}
+}
+
+// VoteReviewRequest defines the request body for voting on a review.
+type VoteReviewRequest struct {
+	Helpful *bool `json:"helpful" binding:"required" example:"true"` // False votes the review unhelpful
+}
+
+// ReportReviewRequest defines the request body for reporting a review.
+type ReportReviewRequest struct {
+	Reason  string `json:"reason" binding:"required,oneof=spam offensive off_topic other" example:"spam"`
+	Details string `json:"details" binding:"max=500"`
+}
+
+// ModerateReviewRequest defines the request body for moderating a reported
+// review.
+type ModerateReviewRequest struct {
+	Action string `json:"action" binding:"required,oneof=dismiss remove" example:"dismiss"`
+}
+
+// ReviewQueueQuery defines the paging of the moderation queue.
+type ReviewQueueQuery struct {
+	Offset int `form:"offset" binding:"min=0"`
+	Limit  int `form:"limit" binding:"min=0,max=100"`
+}
+
+// VoteReview records whether the caller found a review helpful.
+//
+//	@Summary		Vote on a review
+//	@Description	Each user has one vote per review; voting again replaces it. Votes are rate limited per user by reviews.vote_limit.
+//	@Tags			reviews
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		integer				true	"Review ID"
+//	@Param			request	body		VoteReviewRequest	true	"Vote payload"
+//	@Success		200		{object}	Response{data=service.ReviewVotesResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		429		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/reviews/{id}/vote [put]
+func (h *ReviewHandler) VoteReview(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	id, ok := parseIDParam(c, "id", "review")
+	if !ok {
+		return
+	}
+	var req VoteReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	votes, err := h.reviewService.Vote(c.Request.Context(), userID, id, *req.Helpful)
+	if err != nil {
+		respondReviewError(c, "Failed to vote on review", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Vote recorded", "data": votes})
+}
+
+// ReportReview reports a review as abusive.
+//
+//	@Summary		Report a review
+//	@Description	The review joins the admin moderation queue until its reports are dismissed or it is removed. Each user reports a review once. Reports are rate limited per user by reviews.report_limit.
+//	@Tags			reviews
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		integer				true	"Review ID"
+//	@Param			request	body		ReportReviewRequest	true	"Report payload"
+//	@Success		201		{object}	Response
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		429		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/reviews/{id}/reports [post]
+func (h *ReviewHandler) ReportReview(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	id, ok := parseIDParam(c, "id", "review")
+	if !ok {
+		return
+	}
+	var req ReportReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	if err := h.reviewService.Report(c.Request.Context(), userID, id, req.Reason, req.Details); err != nil {
+		respondReviewError(c, "Failed to report review", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Review reported"})
+}
+
+// ListModerationQueue returns the reviews of the store with open abuse
+// reports, most reported first.
+//
+//	@Summary	List reported reviews
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		query	query		ReviewQueueQuery	false	"Paging"
+//	@Success	200		{object}	Response{data=service.ReviewModerationListResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/review-reports [get]
+func (h *ReviewHandler) ListModerationQueue(c *gin.Context) {
+	var query ReviewQueueQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.reviewService.ListModerationQueue(c.Request.Context(), query.Offset, query.Limit)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list reported reviews", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+}
+
+// ModerateReview closes the open reports of a review, keeping or removing it.
+//
+//	@Summary		Moderate a reported review
+//	@Description	dismiss keeps the review; remove deletes it. Either way its open reports are closed and it leaves the moderation queue.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		integer					true	"Review ID"
+//	@Param			request	body		ModerateReviewRequest	true	"Moderation payload"
+//	@Success		200		{object}	Response
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/reviews/{id}/moderate [post]
+func (h *ReviewHandler) ModerateReview(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "review")
+	if !ok {
+		return
+	}
+	var req ModerateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resolved, err := h.reviewService.Moderate(c.Request.Context(), id, req.Action)
+	if err != nil {
+		respondReviewError(c, "Failed to moderate review", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Review moderated", "data": gin.H{"resolved_reports": resolved}})
+}
+
+// respondReviewError maps the errors of the review service to responses,
+// logging unexpected ones with msg.
+func respondReviewError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidReviewReport), errors.Is(err, service.ErrInvalidModerationAction):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+	case errors.Is(err, service.ErrOwnReview):
+		c.JSON(http.StatusForbidden, gin.H{"code": http.StatusForbidden, "message": err.Error()})
+	case errors.Is(err, service.ErrReviewNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+	case errors.Is(err, service.ErrReviewAlreadyReported):
+		c.JSON(http.StatusConflict, gin.H{"code": http.StatusConflict, "message": err.Error()})
+	case errors.Is(err, service.ErrReviewRateLimited):
+		c.JSON(http.StatusTooManyRequests, gin.H{"code": http.StatusTooManyRequests, "message": err.Error()})
+	default:
+		slog.ErrorContext(c.Request.Context(), msg, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
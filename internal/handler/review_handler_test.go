@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReviewHandler_VoteReview(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		id         string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockReviewService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			id:      "4",
+			reqBody: `{"helpful":false}`,
+			mockSetup: func(mockService *mocks.MockReviewService) {
+				mockService.EXPECT().Vote(gomock.Any(), uint64(1), uint64(4), false).
+					Return(&service.ReviewVotesResp{ReviewID: 4, HelpfulVotes: 2, UnhelpfulVotes: 1}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"unhelpful_votes":1`,
+		},
+		{name: "InvalidID", id: "x", reqBody: `{"helpful":true}`, wantStatus: http.StatusBadRequest, wantBody: "invalid review id"},
+		{name: "MissingVote", id: "4", reqBody: `{}`, wantStatus: http.StatusBadRequest, wantBody: `"field":"helpful"`},
+		{
+			name:    "OwnReview",
+			id:      "4",
+			reqBody: `{"helpful":true}`,
+			mockSetup: func(mockService *mocks.MockReviewService) {
+				mockService.EXPECT().Vote(gomock.Any(), uint64(1), uint64(4), true).Return(nil, service.ErrOwnReview)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:    "RateLimited",
+			id:      "4",
+			reqBody: `{"helpful":true}`,
+			mockSetup: func(mockService *mocks.MockReviewService) {
+				mockService.EXPECT().Vote(gomock.Any(), uint64(1), uint64(4), true).Return(nil, service.ErrReviewRateLimited)
+			},
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:    "ServiceError",
+			id:      "4",
+			reqBody: `{"helpful":true}`,
+			mockSetup: func(mockService *mocks.MockReviewService) {
+				mockService.EXPECT().Vote(gomock.Any(), uint64(1), uint64(4), true).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockReviewService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewReviewHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}}
+			c.Request = httptest.NewRequest(http.MethodPut, "/reviews/"+tt.id+"/vote", bytes.NewBufferString(tt.reqBody))
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			handler.VoteReview(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestReviewHandler_ReportReview(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockReviewService)
+		wantStatus int
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"reason":"spam","details":"links to a shop"}`,
+			mockSetup: func(mockService *mocks.MockReviewService) {
+				mockService.EXPECT().Report(gomock.Any(), uint64(1), uint64(4), "spam", "links to a shop").Return(nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{name: "UnknownReason", reqBody: `{"reason":"boring"}`, wantStatus: http.StatusBadRequest},
+		{
+			name:    "AlreadyReported",
+			reqBody: `{"reason":"offensive"}`,
+			mockSetup: func(mockService *mocks.MockReviewService) {
+				mockService.EXPECT().Report(gomock.Any(), uint64(1), uint64(4), "offensive", "").Return(service.ErrReviewAlreadyReported)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:    "NotFound",
+			reqBody: `{"reason":"other"}`,
+			mockSetup: func(mockService *mocks.MockReviewService) {
+				mockService.EXPECT().Report(gomock.Any(), uint64(1), uint64(4), "other", "").Return(service.ErrReviewNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockReviewService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewReviewHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "4"}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/reviews/4/reports", bytes.NewBufferString(tt.reqBody))
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			handler.ReportReview(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestReviewHandler_ModerateReview(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	mockService := mocks.NewMockReviewService(ctrl)
+	mockService.EXPECT().Moderate(gomock.Any(), uint64(4), service.ReviewModerationRemove).Return(int64(3), nil)
+	handler := NewReviewHandler(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: "4"}}
+	c.Request = httptest.NewRequest(http.MethodPost, "/admin/reviews/4/moderate", bytes.NewBufferString(`{"action":"remove"}`))
+
+	handler.ModerateReview(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"resolved_reports":3`)
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// ShippingRestrictionHandler defines the HTTP handlers for managing where
+// SKUs cannot ship to.
+type ShippingRestrictionHandler struct {
+	restrictionService service.ShippingRestrictionService
+}
+
+// NewShippingRestrictionHandler creates a new ShippingRestrictionHandler instance.
+func NewShippingRestrictionHandler(restrictionService service.ShippingRestrictionService) *ShippingRestrictionHandler {
+	return &ShippingRestrictionHandler{restrictionService: restrictionService}
+}
+
+// CreateShippingRestrictionRequest defines the request body for creating a
+// shipping restriction. Exactly one of sku_id and category_id is set.
+type CreateShippingRestrictionRequest struct {
+	SKUID      uint64   `json:"sku_id,string"`
+	CategoryID uint64   `json:"category_id,string"`                                                                         // Covers the category and its subcategories
+	Regions    []string `json:"regions" binding:"required,min=1,max=30,dive,iso3166_1_alpha2|iso3166_2" example:"AU,US-HI"` // A country covers its subdivisions
+	Reason     string   `json:"reason" binding:"max=255" example:"Lithium batteries cannot be shipped there"`               // Shown to customers whose orders are refused
+}
+
+// CreateShippingRestriction keeps a SKU, or the SKUs of a category, from
+// shipping to some regions.
+//
+//	@Summary		Create a shipping restriction
+//	@Description	Orders shipping to one of the regions are refused at checkout while they have the SKU, a SKU of the category or of its subcategories, or a bundle with such a component, with 400, reason shipping_restricted and the offending items. Digital SKUs are not shipped and never restricted.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		CreateShippingRestrictionRequest	true	"Shipping restriction payload"
+//	@Success		201		{object}	Response{data=service.ShippingRestrictionResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/shipping-restrictions [post]
+func (h *ShippingRestrictionHandler) CreateShippingRestriction(c *gin.Context) {
+	var req CreateShippingRestrictionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.restrictionService.Create(c.Request.Context(), &service.ShippingRestrictionCreateReq{
+		SKUID:      req.SKUID,
+		CategoryID: req.CategoryID,
+		Regions:    req.Regions,
+		Reason:     req.Reason,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidShippingRestriction) {
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to create shipping restriction", "sku_id", req.SKUID, "category_id", req.CategoryID, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Shipping restriction created", "data": resp})
+}
+
+// ListShippingRestrictions returns every shipping restriction.
+//
+//	@Summary	List shipping restrictions
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Success	200	{object}	Response{data=[]service.ShippingRestrictionResp}
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/shipping-restrictions [get]
+func (h *ShippingRestrictionHandler) ListShippingRestrictions(c *gin.Context) {
+	restrictions, err := h.restrictionService.List(c.Request.Context())
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list shipping restrictions", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": restrictions})
+}
+
+// DeleteShippingRestriction lifts a shipping restriction. Orders refused
+// already are not placed.
+//
+//	@Summary	Delete a shipping restriction
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Shipping restriction ID"
+//	@Success	200	{object}	Response
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/shipping-restrictions/{id} [delete]
+func (h *ShippingRestrictionHandler) DeleteShippingRestriction(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "shipping restriction")
+	if !ok {
+		return
+	}
+
+	if err := h.restrictionService.Delete(c.Request.Context(), id); err != nil {
+		if errors.Is(err, service.ErrShippingRestrictionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to delete shipping restriction", "restriction_id", id, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Shipping restriction deleted"})
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestShippingRestrictionHandler_CreateShippingRestriction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockShippingRestrictionService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"category_id":"12","regions":["AU","US-HI"],"reason":"Lithium batteries cannot be shipped there"}`,
+			mockSetup: func(mockService *mocks.MockShippingRestrictionService) {
+				mockService.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *service.ShippingRestrictionCreateReq) (*service.ShippingRestrictionResp, error) {
+					assert.Equal(t, uint64(12), req.CategoryID)
+					assert.Equal(t, []string{"AU", "US-HI"}, req.Regions)
+					return &service.ShippingRestrictionResp{ID: 9, CategoryID: req.CategoryID, Regions: req.Regions, Reason: req.Reason}, nil
+				})
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"id":"9"`,
+		},
+		{name: "NoRegions", reqBody: `{"sku_id":"101","regions":[]}`, wantStatus: http.StatusBadRequest, wantBody: `"field":"regions"`},
+		{name: "UnknownRegion", reqBody: `{"sku_id":"101","regions":["Hawaii"]}`, wantStatus: http.StatusBadRequest, wantBody: `"field":"regions[0]"`},
+		{
+			name:    "NeitherSKUNorCategory",
+			reqBody: `{"regions":["AU"]}`,
+			mockSetup: func(mockService *mocks.MockShippingRestrictionService) {
+				mockService.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("%w: set exactly one of sku_id and category_id", service.ErrInvalidShippingRestriction))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "exactly one of sku_id and category_id",
+		},
+		{
+			name:    "ServiceError",
+			reqBody: `{"sku_id":"101","regions":["AU"]}`,
+			mockSetup: func(mockService *mocks.MockShippingRestrictionService) {
+				mockService.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockShippingRestrictionService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewShippingRestrictionHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/shipping-restrictions", bytes.NewBufferString(tt.reqBody))
+
+			handler.CreateShippingRestriction(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestShippingRestrictionHandler_DeleteShippingRestriction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "Success", wantStatus: http.StatusOK},
+		{name: "NotFound", err: service.ErrShippingRestrictionNotFound, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockShippingRestrictionService(ctrl)
+			mockService.EXPECT().Delete(gomock.Any(), uint64(9)).Return(tt.err)
+			handler := NewShippingRestrictionHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "9"}}
+			c.Request = httptest.NewRequest(http.MethodDelete, "/admin/shipping-restrictions/9", nil)
+
+			handler.DeleteShippingRestriction(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// StockHoldHandler defines the HTTP handlers for stock holds placed by
+// customer service.
+type StockHoldHandler struct {
+	stockHoldService service.StockHoldService
+}
+
+// NewStockHoldHandler creates a new StockHoldHandler instance.
+func NewStockHoldHandler(stockHoldService service.StockHoldService) *StockHoldHandler {
+	return &StockHoldHandler{stockHoldService: stockHoldService}
+}
+
+// StockHoldRequest defines the request body for placing a stock hold.
+type StockHoldRequest struct {
+	SKUID     uint64     `json:"sku_id,string" binding:"required" example:"1234567890"`
+	Quantity  int        `json:"quantity" binding:"required,min=1" example:"3"`
+	Note      string     `json:"note" binding:"max=500" example:"Reserved for the trade show"`
+	ExpiresAt *time.Time `json:"expires_at"` // Empty holds until released
+}
+
+// StockHoldQuery defines the filters and paging of stock holds.
+type StockHoldQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=active released expired" example:"active"`
+	SKUID  uint64 `form:"sku_id" example:"1234567890"`
+	Offset int    `form:"offset" binding:"min=0"`
+	Limit  int    `form:"limit" binding:"min=0,max=100"`
+}
+
+// StockHoldExpiryRequest defines the request body for moving the expiry of a
+// stock hold.
+type StockHoldExpiryRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"` // Empty holds until released
+}
+
+// PlaceStockHold sets a quantity of a SKU aside without an order.
+//
+//	@Summary		Place a stock hold
+//	@Description	Takes the quantity out of the SKU's stock, so it can no longer be ordered, e.g. for an offline sale. The quantity goes back into stock when the hold is released, or by the worker when it expires. Both are recorded in the SKU's stock ledger.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		StockHoldRequest	true	"Stock hold"
+//	@Success		201		{object}	Response{data=service.StockHoldResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse	"Not enough stock"
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/inventory/holds [post]
+func (h *StockHoldHandler) PlaceStockHold(c *gin.Context) {
+	var req StockHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	hold, err := h.stockHoldService.Place(c.Request.Context(), &service.StockHoldReq{
+		SKUID:     req.SKUID,
+		Quantity:  req.Quantity,
+		Note:      req.Note,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		respondStockHoldError(c, "Failed to place stock hold", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Stock hold placed", "data": hold})
+}
+
+// ListStockHolds returns stock holds, most recent first.
+//
+//	@Summary	List stock holds
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		query	query		StockHoldQuery	false	"Filters and paging"
+//	@Success	200		{object}	Response{data=[]service.StockHoldResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/inventory/holds [get]
+func (h *StockHoldHandler) ListStockHolds(c *gin.Context) {
+	var query StockHoldQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	holds, err := h.stockHoldService.List(c.Request.Context(), query.Status, query.SKUID, query.Offset, query.Limit)
+	if err != nil {
+		respondStockHoldError(c, "Failed to list stock holds", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": holds})
+}
+
+// SetStockHoldExpiry moves the expiry of an active stock hold.
+//
+//	@Summary	Set the expiry of a stock hold
+//	@Tags		admin
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id		path		integer					true	"Stock hold ID"
+//	@Param		request	body		StockHoldExpiryRequest	true	"Expiry"
+//	@Success	200		{object}	Response{data=service.StockHoldResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	404		{object}	ErrorResponse
+//	@Failure	409		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/inventory/holds/{id}/expiry [put]
+func (h *StockHoldHandler) SetStockHoldExpiry(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "stock hold")
+	if !ok {
+		return
+	}
+	var req StockHoldExpiryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	hold, err := h.stockHoldService.SetExpiry(c.Request.Context(), id, req.ExpiresAt)
+	if err != nil {
+		respondStockHoldError(c, "Failed to set stock hold expiry", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Stock hold expiry set", "data": hold})
+}
+
+// ReleaseStockHold puts the quantity of an active stock hold back into
+// stock.
+//
+//	@Summary	Release a stock hold
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Stock hold ID"
+//	@Success	200	{object}	Response{data=service.StockHoldResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	409	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/inventory/holds/{id}/release [post]
+func (h *StockHoldHandler) ReleaseStockHold(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "stock hold")
+	if !ok {
+		return
+	}
+
+	hold, err := h.stockHoldService.Release(c.Request.Context(), id)
+	if err != nil {
+		respondStockHoldError(c, "Failed to release stock hold", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Stock hold released", "data": hold})
+}
+
+// respondStockHoldError maps the errors of the stock hold service to
+// responses, logging unexpected ones with msg.
+func respondStockHoldError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidStockHold):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+	case errors.Is(err, service.ErrProductNotFound), errors.Is(err, service.ErrStockHoldNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+	case errors.Is(err, service.ErrStockHoldReleased):
+		c.JSON(http.StatusConflict, gin.H{"code": http.StatusConflict, "message": err.Error()})
+	case errors.Is(err, service.ErrInsufficientStock):
+		respondError(c, err)
+	default:
+		slog.ErrorContext(c.Request.Context(), msg, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestStockHoldHandler_PlaceStockHold(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockStockHoldService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"sku_id":"5","quantity":3,"note":"Trade show","expires_at":"2026-12-01T00:00:00Z"}`,
+			mockSetup: func(mockService *mocks.MockStockHoldService) {
+				mockService.EXPECT().Place(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *service.StockHoldReq) (*service.StockHoldResp, error) {
+					assert.Equal(t, uint64(5), req.SKUID)
+					require.NotNil(t, req.ExpiresAt)
+					return &service.StockHoldResp{ID: 9, SKUID: req.SKUID, Quantity: req.Quantity, Status: "active"}, nil
+				})
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"id":"9"`,
+		},
+		{name: "NoQuantity", reqBody: `{"sku_id":"5"}`, wantStatus: http.StatusBadRequest, wantBody: `"field":"quantity"`},
+		{
+			name:    "InsufficientStock",
+			reqBody: `{"sku_id":"5","quantity":30}`,
+			mockSetup: func(mockService *mocks.MockStockHoldService) {
+				mockService.EXPECT().Place(gomock.Any(), gomock.Any()).Return(nil, service.ErrInsufficientStock.WithSKU(5).WithMessage("only 4 in stock"))
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   `"reason":"stock_insufficient"`,
+		},
+		{
+			name:    "SKUNotFound",
+			reqBody: `{"sku_id":"5","quantity":1}`,
+			mockSetup: func(mockService *mocks.MockStockHoldService) {
+				mockService.EXPECT().Place(gomock.Any(), gomock.Any()).Return(nil, service.ErrProductNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:    "ServiceError",
+			reqBody: `{"sku_id":"5","quantity":1}`,
+			mockSetup: func(mockService *mocks.MockStockHoldService) {
+				mockService.EXPECT().Place(gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockStockHoldService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewStockHoldHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/inventory/holds", bytes.NewBufferString(tt.reqBody))
+
+			handler.PlaceStockHold(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestStockHoldHandler_ReleaseStockHold(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "Success", wantStatus: http.StatusOK},
+		{name: "NotFound", err: service.ErrStockHoldNotFound, wantStatus: http.StatusNotFound},
+		{name: "AlreadyReleased", err: service.ErrStockHoldReleased, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockStockHoldService(ctrl)
+			var resp *service.StockHoldResp
+			if tt.err == nil {
+				resp = &service.StockHoldResp{ID: 9, Status: "released"}
+			}
+			mockService.EXPECT().Release(gomock.Any(), uint64(9)).Return(resp, tt.err)
+			handler := NewStockHoldHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "9"}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/inventory/holds/9/release", nil)
+
+			handler.ReleaseStockHold(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
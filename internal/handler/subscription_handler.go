@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// SubscriptionHandler defines the HTTP handlers for users' subscriptions.
+type SubscriptionHandler struct {
+	subscriptionService service.SubscriptionService
+}
+
+// NewSubscriptionHandler creates a new SubscriptionHandler instance.
+func NewSubscriptionHandler(subscriptionService service.SubscriptionService) *SubscriptionHandler {
+	return &SubscriptionHandler{subscriptionService: subscriptionService}
+}
+
+// SubscribeRequest defines the request body for subscribing to a SKU.
+type SubscribeRequest struct {
+	SKUID    uint64 `json:"sku_id" binding:"required,gt=0" example:"1234567890"`
+	Quantity int    `json:"quantity" binding:"required,gt=0,max=1000" example:"1"`
+	Interval string `json:"interval" binding:"required,oneof=day week month" example:"month"`
+	// IntervalCount orders every so many intervals, e.g. 2 with week for
+	// every other week. It defaults to 1.
+	IntervalCount int `json:"interval_count" binding:"min=0,max=365" example:"1"`
+	// PaymentMethodID is the saved payment method each renewal is charged to.
+	PaymentMethodID uint64 `json:"payment_method_id,string" binding:"required,gt=0" example:"1234567890"`
+	// Currency renewals are charged in; empty is the caller's preferred
+	// currency at each renewal.
+	Currency string `json:"currency" binding:"omitempty,iso4217" example:"USD"`
+	Region   string `json:"region" binding:"omitempty,iso3166_1_alpha2|iso3166_2" example:"DE"` // Where the orders ship, for tax
+	// StartAt is when the first order is placed; by default it is placed
+	// within minutes.
+	StartAt *time.Time `json:"start_at"`
+}
+
+// ListSubscriptions returns the caller's subscriptions, most recent first,
+// cancelled ones included.
+//
+//	@Summary	List subscriptions
+//	@Tags		users
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Success	200	{object}	Response{data=[]service.SubscriptionResp}
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/users/me/subscriptions [get]
+func (h *SubscriptionHandler) ListSubscriptions(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+
+	subs, err := h.subscriptionService.List(c.Request.Context(), userID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list subscriptions", "user_id", userID, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": subs})
+}
+
+// Subscribe subscribes the caller to a SKU.
+//
+//	@Summary		Subscribe to a SKU
+//	@Description	An order of the SKU is placed every interval_count intervals and charged to the saved payment method. A renewal that cannot be placed or charged is retried on the dunning schedule, during which the subscription is past_due; once the retries run out it is cancelled. Each failure fires the subscription.payment_failed webhook.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		SubscribeRequest	true	"Subscription payload"
+//	@Success		201		{object}	Response{data=service.SubscriptionResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/users/me/subscriptions [post]
+func (h *SubscriptionHandler) Subscribe(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+
+	var req SubscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	sub, err := h.subscriptionService.Subscribe(c.Request.Context(), &service.SubscribeReq{
+		UserID:          userID,
+		SKUID:           req.SKUID,
+		Quantity:        req.Quantity,
+		Interval:        req.Interval,
+		IntervalCount:   req.IntervalCount,
+		PaymentMethodID: req.PaymentMethodID,
+		Currency:        req.Currency,
+		Region:          req.Region,
+		StartAt:         req.StartAt,
+	})
+	if err != nil {
+		respondSubscriptionError(c, "Failed to create subscription", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Subscribed", "data": sub})
+}
+
+// PauseSubscription stops the renewals of one of the caller's subscriptions
+// until it is resumed.
+//
+//	@Summary	Pause a subscription
+//	@Tags		users
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Subscription ID"
+//	@Success	200	{object}	Response{data=service.SubscriptionResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	409	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/users/me/subscriptions/{id}/pause [post]
+func (h *SubscriptionHandler) PauseSubscription(c *gin.Context) {
+	h.change(c, "Subscription paused", h.subscriptionService.Pause)
+}
+
+// ResumeSubscription restarts one of the caller's paused subscriptions. A
+// renewal that fell due while it was paused is placed within minutes.
+//
+//	@Summary	Resume a subscription
+//	@Tags		users
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Subscription ID"
+//	@Success	200	{object}	Response{data=service.SubscriptionResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	409	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/users/me/subscriptions/{id}/resume [post]
+func (h *SubscriptionHandler) ResumeSubscription(c *gin.Context) {
+	h.change(c, "Subscription resumed", h.subscriptionService.Resume)
+}
+
+// SkipSubscription skips the next renewal of one of the caller's active or
+// paused subscriptions.
+//
+//	@Summary	Skip the next renewal of a subscription
+//	@Tags		users
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Subscription ID"
+//	@Success	200	{object}	Response{data=service.SubscriptionResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	409	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/users/me/subscriptions/{id}/skip [post]
+func (h *SubscriptionHandler) SkipSubscription(c *gin.Context) {
+	h.change(c, "Next renewal skipped", h.subscriptionService.Skip)
+}
+
+// CancelSubscription cancels one of the caller's subscriptions for good.
+//
+//	@Summary	Cancel a subscription
+//	@Tags		users
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Subscription ID"
+//	@Success	200	{object}	Response{data=service.SubscriptionResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	409	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/users/me/subscriptions/{id}/cancel [post]
+func (h *SubscriptionHandler) CancelSubscription(c *gin.Context) {
+	h.change(c, "Subscription cancelled", h.subscriptionService.Cancel)
+}
+
+// change applies one of the service's changes to the subscription in the
+// path and responds with the result.
+func (h *SubscriptionHandler) change(c *gin.Context, message string, apply func(ctx context.Context, userID, id uint64) (*service.SubscriptionResp, error)) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	id, ok := parseIDParam(c, "id", "subscription")
+	if !ok {
+		return
+	}
+
+	sub, err := apply(c.Request.Context(), userID, id)
+	if err != nil {
+		respondSubscriptionError(c, "Failed to change subscription", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": message, "data": sub})
+}
+
+// respondSubscriptionError maps the errors of the subscription service to
+// responses, logging unexpected ones with msg.
+func respondSubscriptionError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidSubscription), errors.Is(err, service.ErrPaymentMethodNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+	case errors.Is(err, service.ErrSubscriptionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+	case errors.Is(err, service.ErrSubscriptionStatus), errors.Is(err, service.ErrSubscriptionChanged):
+		c.JSON(http.StatusConflict, gin.H{"code": http.StatusConflict, "message": err.Error()})
+	default:
+		slog.ErrorContext(c.Request.Context(), msg, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSubscriptionHandler_Subscribe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockSubscriptionService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"sku_id":101,"quantity":2,"interval":"week","interval_count":2,"payment_method_id":"5"}`,
+			mockSetup: func(mockService *mocks.MockSubscriptionService) {
+				mockService.EXPECT().Subscribe(gomock.Any(), &service.SubscribeReq{UserID: 1, SKUID: 101, Quantity: 2, Interval: "week", IntervalCount: 2, PaymentMethodID: 5}).
+					Return(&service.SubscriptionResp{ID: 7, Status: "active"}, nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"status":"active"`,
+		},
+		{
+			name:       "InvalidInterval",
+			reqBody:    `{"sku_id":101,"quantity":1,"interval":"year","payment_method_id":"5"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"field":"interval","rule":"oneof"`,
+		},
+		{
+			name:    "UnknownPaymentMethod",
+			reqBody: `{"sku_id":101,"quantity":1,"interval":"month","payment_method_id":"6"}`,
+			mockSetup: func(mockService *mocks.MockSubscriptionService) {
+				mockService.EXPECT().Subscribe(gomock.Any(), gomock.Any()).Return(nil, service.ErrPaymentMethodNotFound)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "payment method not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockSubscriptionService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewSubscriptionHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			var err error
+			c.Request, err = http.NewRequest(http.MethodPost, "/users/me/subscriptions", bytes.NewBufferString(tt.reqBody))
+			require.NoError(t, err)
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			handler.Subscribe(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestSubscriptionHandler_PauseSubscription(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		id         string
+		mockSetup  func(mockService *mocks.MockSubscriptionService)
+		wantStatus int
+	}{
+		{
+			name: "Success",
+			id:   "7",
+			mockSetup: func(mockService *mocks.MockSubscriptionService) {
+				mockService.EXPECT().Pause(gomock.Any(), uint64(1), uint64(7)).Return(&service.SubscriptionResp{ID: 7, Status: "paused"}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "NotFound",
+			id:   "7",
+			mockSetup: func(mockService *mocks.MockSubscriptionService) {
+				mockService.EXPECT().Pause(gomock.Any(), uint64(1), uint64(7)).Return(nil, service.ErrSubscriptionNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "Cancelled",
+			id:   "7",
+			mockSetup: func(mockService *mocks.MockSubscriptionService) {
+				mockService.EXPECT().Pause(gomock.Any(), uint64(1), uint64(7)).Return(nil, service.ErrSubscriptionStatus)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{name: "InvalidID", id: "abc", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockSubscriptionService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewSubscriptionHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}}
+
+			var err error
+			c.Request, err = http.NewRequest(http.MethodPost, "/users/me/subscriptions/"+tt.id+"/pause", nil)
+			require.NoError(t, err)
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			handler.PauseSubscription(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
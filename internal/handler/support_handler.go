@@ -0,0 +1,307 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+)
+
+// SupportHandler defines the HTTP handlers of customer service tooling, and
+// the customer's view of their store credit.
+type SupportHandler struct {
+	supportService service.SupportService
+}
+
+// NewSupportHandler creates a new SupportHandler instance.
+func NewSupportHandler(supportService service.SupportService) *SupportHandler {
+	return &SupportHandler{supportService: supportService}
+}
+
+// StoreCreditRequest defines the request body for granting store credit.
+type StoreCreditRequest struct {
+	Amount   decimal.Decimal `json:"amount" binding:"required,price" swaggertype:"string" example:"10.00"`
+	Currency string          `json:"currency" binding:"required,iso4217" example:"USD"`
+	Reason   string          `json:"reason" binding:"required,max=255" example:"Parcel arrived a week late"`
+	OrderID  uint64          `json:"order_id,string" example:"1234567890"` // The customer's order it makes up for; optional
+}
+
+// StoreCreditQuery defines the paging of a store credit ledger.
+type StoreCreditQuery struct {
+	Offset int `form:"offset" binding:"min=0"`
+	Limit  int `form:"limit" binding:"min=0,max=100"`
+}
+
+// ResendNotificationRequest defines the request body for sending an order
+// notification again.
+type ResendNotificationRequest struct {
+	Kind string `json:"kind" binding:"required,oneof=order_confirmation order_failed" example:"order_confirmation"`
+}
+
+// ListCustomerOrders returns a customer's orders as their order list shows them.
+//
+//	@Summary	List the orders of a customer
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id		path		integer			true	"User ID"
+//	@Param		query	query		OrderListQuery	false	"Paging"
+//	@Success	200		{object}	Response{data=service.OrderSummaryListResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/users/{id}/orders [get]
+func (h *SupportHandler) ListCustomerOrders(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id", "user")
+	if !ok {
+		return
+	}
+	var query OrderListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	orders, err := h.supportService.CustomerOrders(c.Request.Context(), userID, query.Offset, query.Limit)
+	if err != nil {
+		respondSupportError(c, "Failed to list customer orders", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": orders})
+}
+
+// GetCustomerOrder returns an order with its shipping address and shipments.
+//
+//	@Summary	Get an order
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Order ID"
+//	@Success	200	{object}	Response{data=service.CustomerOrderResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/orders/{id} [get]
+func (h *SupportHandler) GetCustomerOrder(c *gin.Context) {
+	orderID, ok := parseIDParam(c, "id", "order")
+	if !ok {
+		return
+	}
+
+	order, err := h.supportService.GetOrder(c.Request.Context(), orderID)
+	if err != nil {
+		respondSupportError(c, "Failed to get order", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": order})
+}
+
+// UpdateShippingAddress corrects where an order ships to.
+//
+//	@Summary		Change the shipping address of an order
+//	@Description	Only orders that are still to ship, and have not shipped in part, can be changed. The region, which decided the tax, stays; the address is checked against the format of its country and normalized.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		integer			true	"Order ID"
+//	@Param			request	body		AddressRequest	true	"New shipping address"
+//	@Success		200		{object}	Response{data=service.CustomerOrderResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/orders/{id}/shipping-address [put]
+func (h *SupportHandler) UpdateShippingAddress(c *gin.Context) {
+	orderID, ok := parseIDParam(c, "id", "order")
+	if !ok {
+		return
+	}
+	var req AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	order, err := h.supportService.UpdateShippingAddress(c.Request.Context(), orderID, req.address())
+	if err != nil {
+		respondSupportError(c, "Failed to update shipping address", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Shipping address updated", "data": order})
+}
+
+// ResendOrderNotification sends a notification of an order to its customer again.
+//
+//	@Summary		Resend an order notification
+//	@Description	The notification is delivered right away over each channel its kind is sent over, subject to the customer's preferences, and shows among their deliveries. A channel that fails is reported in its result; the others are still sent.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		integer						true	"Order ID"
+//	@Param			request	body		ResendNotificationRequest	true	"Notification to resend"
+//	@Success		200		{object}	Response{data=[]service.ResendResult}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/orders/{id}/notifications [post]
+func (h *SupportHandler) ResendOrderNotification(c *gin.Context) {
+	orderID, ok := parseIDParam(c, "id", "order")
+	if !ok {
+		return
+	}
+	var req ResendNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	results, err := h.supportService.ResendNotification(c.Request.Context(), orderID, notification.Kind(req.Kind))
+	if err != nil {
+		respondSupportError(c, "Failed to resend notification", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Notification resent", "data": results})
+}
+
+// GrantStoreCredit gives a customer goodwill store credit.
+//
+//	@Summary	Grant store credit
+//	@Tags		admin
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id		path		integer				true	"User ID"
+//	@Param		request	body		StoreCreditRequest	true	"Credit"
+//	@Success	201		{object}	Response{data=service.StoreCreditResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	404		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/users/{id}/store-credits [post]
+func (h *SupportHandler) GrantStoreCredit(c *gin.Context) {
+	adminID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	userID, ok := parseIDParam(c, "id", "user")
+	if !ok {
+		return
+	}
+	var req StoreCreditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	credit, err := h.supportService.GrantStoreCredit(c.Request.Context(), &service.StoreCreditReq{
+		UserID:    userID,
+		Amount:    money.New(req.Amount, req.Currency),
+		Reason:    req.Reason,
+		OrderID:   req.OrderID,
+		CreatedBy: adminID,
+	})
+	if err != nil {
+		respondSupportError(c, "Failed to grant store credit", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Store credit granted", "data": credit})
+}
+
+// ListCustomerStoreCredits returns a customer's store credit balances and ledger.
+//
+//	@Summary	List the store credit of a customer
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id		path		integer				true	"User ID"
+//	@Param		query	query		StoreCreditQuery	false	"Paging"
+//	@Success	200		{object}	Response{data=service.StoreCreditAccountResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/users/{id}/store-credits [get]
+func (h *SupportHandler) ListCustomerStoreCredits(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id", "user")
+	if !ok {
+		return
+	}
+	h.listStoreCredits(c, userID)
+}
+
+// ListMyStoreCredits returns the caller's store credit balances and ledger.
+//
+//	@Summary	List my store credit
+//	@Tags		users
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		query	query		StoreCreditQuery	false	"Paging"
+//	@Success	200		{object}	Response{data=service.StoreCreditAccountResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/users/me/store-credits [get]
+func (h *SupportHandler) ListMyStoreCredits(c *gin.Context) {
+	userID, err := auth.UserID(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+		return
+	}
+	h.listStoreCredits(c, userID)
+}
+
+func (h *SupportHandler) listStoreCredits(c *gin.Context, userID uint64) {
+	var query StoreCreditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	account, err := h.supportService.StoreCredits(c.Request.Context(), userID, query.Offset, query.Limit)
+	if err != nil {
+		respondSupportError(c, "Failed to list store credits", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": account})
+}
+
+func respondSupportError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidStoreCredit):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+	case errors.Is(err, service.ErrOrderNotFound), errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+	case errors.Is(err, service.ErrOrderNotShippable), errors.Is(err, service.ErrOrderShipped), errors.Is(err, service.ErrNotResendable):
+		c.JSON(http.StatusConflict, gin.H{"code": http.StatusConflict, "message": err.Error()})
+	case errors.Is(err, service.ErrInvalidAddress):
+		respondError(c, err)
+	default:
+		slog.ErrorContext(c.Request.Context(), msg, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
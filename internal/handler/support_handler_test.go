@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSupportHandler_GrantStoreCredit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		id         string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockSupportService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Granted",
+			id:      "7",
+			reqBody: `{"amount":"10.00","currency":"USD","reason":"late delivery","order_id":"42"}`,
+			mockSetup: func(mockService *mocks.MockSupportService) {
+				mockService.EXPECT().GrantStoreCredit(gomock.Any(), &service.StoreCreditReq{
+					UserID: 7, Amount: money.New(decimal.RequireFromString("10.00"), "USD"), Reason: "late delivery", OrderID: 42, CreatedBy: 1,
+				}).Return(&service.StoreCreditResp{ID: 5, Amount: money.New(decimal.NewFromInt(10), "USD")}, nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"id":"5"`,
+		},
+		{
+			name:    "UnknownUser",
+			id:      "7",
+			reqBody: `{"amount":"10.00","currency":"USD","reason":"late delivery"}`,
+			mockSetup: func(mockService *mocks.MockSupportService) {
+				mockService.EXPECT().GrantStoreCredit(gomock.Any(), gomock.Any()).Return(nil, service.ErrUserNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   service.ErrUserNotFound.Error(),
+		},
+		{name: "NoReason", id: "7", reqBody: `{"amount":"10.00","currency":"USD"}`, wantStatus: http.StatusBadRequest, wantBody: `{"field":"reason","rule":"required"`},
+		{name: "InvalidID", id: "abc", reqBody: `{}`, wantStatus: http.StatusBadRequest, wantBody: "invalid user id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockSupportService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/users/"+tt.id+"/store-credits", strings.NewReader(tt.reqBody))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 1}))
+
+			NewSupportHandler(mockService).GrantStoreCredit(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestSupportHandler_UpdateShippingAddress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	address := model.Address{Name: "Erika Mustermann", Line1: "Heidestraße 17", City: "Berlin", PostalCode: "10557"}
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockSupportService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Updated",
+			reqBody: `{"name":"Erika Mustermann","line1":"Heidestraße 17","city":"Berlin","postal_code":"10557"}`,
+			mockSetup: func(mockService *mocks.MockSupportService) {
+				mockService.EXPECT().UpdateShippingAddress(gomock.Any(), uint64(42), address).Return(&service.CustomerOrderResp{ID: 42, ShippingAddress: address}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"city":"Berlin"`,
+		},
+		{
+			name:    "Shipped",
+			reqBody: `{"name":"Erika Mustermann","line1":"Heidestraße 17","city":"Berlin","postal_code":"10557"}`,
+			mockSetup: func(mockService *mocks.MockSupportService) {
+				mockService.EXPECT().UpdateShippingAddress(gomock.Any(), uint64(42), address).Return(nil, service.ErrOrderShipped)
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   service.ErrOrderShipped.Error(),
+		},
+		{name: "PostalCodeTooLong", reqBody: `{"postal_code":"` + strings.Repeat("1", 21) + `"}`, wantStatus: http.StatusBadRequest, wantBody: `{"field":"postal_code","rule":"max"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockSupportService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "42"}}
+			c.Request = httptest.NewRequest(http.MethodPut, "/admin/orders/42/shipping-address", strings.NewReader(tt.reqBody))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			NewSupportHandler(mockService).UpdateShippingAddress(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// SynonymHandler defines the HTTP handlers for managing search synonyms.
+type SynonymHandler struct {
+	synonymService service.SynonymService
+}
+
+// NewSynonymHandler creates a new SynonymHandler instance.
+func NewSynonymHandler(synonymService service.SynonymService) *SynonymHandler {
+	return &SynonymHandler{synonymService: synonymService}
+}
+
+// SynonymRequest defines the request body for creating or replacing a
+// synonym set.
+type SynonymRequest struct {
+	Terms []string `json:"terms" binding:"required,min=2,max=20" example:"sneakers,trainers"` // Single words, matched without case
+}
+
+// ListSynonyms returns the synonym sets of the store's search.
+//
+//	@Summary	List search synonyms
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Success	200	{object}	Response{data=[]service.SynonymResp}
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/search/synonyms [get]
+func (h *SynonymHandler) ListSynonyms(c *gin.Context) {
+	synonyms, err := h.synonymService.List(c.Request.Context())
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list search synonyms", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": synonyms})
+}
+
+// CreateSynonyms adds a set of words that mean the same thing.
+//
+//	@Summary		Create a search synonym set
+//	@Description	A product or category name is suggested for the words of its name and for their synonyms, from the next rebuild of the suggestion index.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		SynonymRequest	true	"Synonym payload"
+//	@Success		201		{object}	Response{data=service.SynonymResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/search/synonyms [post]
+func (h *SynonymHandler) CreateSynonyms(c *gin.Context) {
+	var req SynonymRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.synonymService.Create(c.Request.Context(), req.Terms)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSynonyms) {
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to create search synonyms", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Synonyms created", "data": resp})
+}
+
+// UpdateSynonyms replaces the words of a synonym set.
+//
+//	@Summary	Replace a search synonym set
+//	@Tags		admin
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id		path		integer			true	"Synonym set ID"
+//	@Param		request	body		SynonymRequest	true	"Synonym payload"
+//	@Success	200		{object}	Response{data=service.SynonymResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	404		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/search/synonyms/{id} [put]
+func (h *SynonymHandler) UpdateSynonyms(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "synonym set")
+	if !ok {
+		return
+	}
+	var req SynonymRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.synonymService.Update(c.Request.Context(), id, req.Terms)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidSynonyms):
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+		case errors.Is(err, service.ErrSynonymNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+		default:
+			slog.ErrorContext(c.Request.Context(), "Failed to update search synonyms", "synonym_id", id, logger.Err(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Synonyms updated", "data": resp})
+}
+
+// DeleteSynonyms deletes a synonym set.
+//
+//	@Summary	Delete a search synonym set
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Synonym set ID"
+//	@Success	200	{object}	Response
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/search/synonyms/{id} [delete]
+func (h *SynonymHandler) DeleteSynonyms(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "synonym set")
+	if !ok {
+		return
+	}
+
+	if err := h.synonymService.Delete(c.Request.Context(), id); err != nil {
+		if errors.Is(err, service.ErrSynonymNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to delete search synonyms", "synonym_id", id, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Synonyms deleted"})
+}
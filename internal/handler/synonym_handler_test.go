@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSynonymHandler_UpdateSynonyms(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		id         string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockSynonymService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			id:      "4",
+			reqBody: `{"terms":["Sneakers","trainers"]}`,
+			mockSetup: func(mockService *mocks.MockSynonymService) {
+				mockService.EXPECT().Update(gomock.Any(), uint64(4), []string{"Sneakers", "trainers"}).
+					Return(&service.SynonymResp{ID: 4, Terms: []string{"sneakers", "trainers"}}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"terms":["sneakers","trainers"]`,
+		},
+		{name: "InvalidID", id: "x", reqBody: `{"terms":["a","b"]}`, wantStatus: http.StatusBadRequest, wantBody: "invalid synonym set id"},
+		{name: "OneTerm", id: "4", reqBody: `{"terms":["mug"]}`, wantStatus: http.StatusBadRequest, wantBody: `"field":"terms"`},
+		{
+			name:    "InvalidTerms",
+			id:      "4",
+			reqBody: `{"terms":["tee","t shirt"]}`,
+			mockSetup: func(mockService *mocks.MockSynonymService) {
+				mockService.EXPECT().Update(gomock.Any(), uint64(4), gomock.Any()).Return(nil, fmt.Errorf("%w: \"t shirt\" is not a single word", service.ErrInvalidSynonyms))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "not a single word",
+		},
+		{
+			name:    "NotFound",
+			id:      "4",
+			reqBody: `{"terms":["mug","cup"]}`,
+			mockSetup: func(mockService *mocks.MockSynonymService) {
+				mockService.EXPECT().Update(gomock.Any(), uint64(4), gomock.Any()).Return(nil, service.ErrSynonymNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:    "ServiceError",
+			id:      "4",
+			reqBody: `{"terms":["mug","cup"]}`,
+			mockSetup: func(mockService *mocks.MockSynonymService) {
+				mockService.EXPECT().Update(gomock.Any(), uint64(4), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockSynonymService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewSynonymHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}}
+			c.Request = httptest.NewRequest(http.MethodPut, "/admin/search/synonyms/"+tt.id, bytes.NewBufferString(tt.reqBody))
+
+			handler.UpdateSynonyms(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestSynonymHandler_CreateSynonyms(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	mockService := mocks.NewMockSynonymService(ctrl)
+	mockService.EXPECT().Create(gomock.Any(), []string{"mug", "cup"}).Return(&service.SynonymResp{ID: 5, Terms: []string{"mug", "cup"}}, nil)
+	handler := NewSynonymHandler(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/admin/search/synonyms", bytes.NewBufferString(`{"terms":["mug","cup"]}`))
+
+	handler.CreateSynonyms(c)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":"5"`)
+}
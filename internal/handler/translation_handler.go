@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// TranslationHandler defines the HTTP handlers for managing translated product content.
+type TranslationHandler struct {
+	translationService service.TranslationService
+}
+
+// NewTranslationHandler creates a new TranslationHandler instance.
+func NewTranslationHandler(translationService service.TranslationService) *TranslationHandler {
+	return &TranslationHandler{translationService: translationService}
+}
+
+// TranslationPutRequest defines the request body for translating a product into a locale.
+type TranslationPutRequest struct {
+	Name        string `json:"name" binding:"required,max=100" example:"马克杯"`
+	Description string `json:"description" binding:"max=65535"` // Empty falls back to the product's own description
+}
+
+// ListTranslations returns every translation of a product.
+//
+//	@Summary	List translations of a product
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"SPU ID"
+//	@Success	200	{object}	Response{data=[]service.TranslationResp}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/products/{id}/translations [get]
+func (h *TranslationHandler) ListTranslations(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "product")
+	if !ok {
+		return
+	}
+
+	translations, err := h.translationService.List(c.Request.Context(), id)
+	if err != nil {
+		h.respondError(c, err, "Failed to list translations", id)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": translations})
+}
+
+// PutTranslation creates or replaces the translation of a product into a locale.
+//
+//	@Summary		Translate a product
+//	@Description	The locale must be one of i18n.locales. Translations are served to clients whose Accept-Language matches it best.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		integer					true	"SPU ID"
+//	@Param			locale	path		string					true	"BCP 47 language tag, e.g. zh or pt-BR"
+//	@Param			request	body		TranslationPutRequest	true	"Translated content"
+//	@Success		200		{object}	Response{data=service.TranslationResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/products/{id}/translations/{locale} [put]
+func (h *TranslationHandler) PutTranslation(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "product")
+	if !ok {
+		return
+	}
+	var req TranslationPutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.translationService.Put(c.Request.Context(), id, c.Param("locale"), &service.TranslationPutReq{
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		h.respondError(c, err, "Failed to save translation", id)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Translation saved", "data": resp})
+}
+
+// DeleteTranslation removes the translation of a product into a locale, which
+// is then served as written.
+//
+//	@Summary	Delete a translation of a product
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id		path		integer	true	"SPU ID"
+//	@Param		locale	path		string	true	"BCP 47 language tag"
+//	@Success	200		{object}	Response
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	404		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/products/{id}/translations/{locale} [delete]
+func (h *TranslationHandler) DeleteTranslation(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "product")
+	if !ok {
+		return
+	}
+
+	if err := h.translationService.Delete(c.Request.Context(), id, c.Param("locale")); err != nil {
+		h.respondError(c, err, "Failed to delete translation", id)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Translation deleted"})
+}
+
+func (h *TranslationHandler) respondError(c *gin.Context, err error, msg string, spuID uint64) {
+	switch {
+	case errors.Is(err, service.ErrUnsupportedLocale):
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "unsupported locale"})
+	case errors.Is(err, service.ErrProductNotFound), errors.Is(err, service.ErrTranslationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+	default:
+		slog.ErrorContext(c.Request.Context(), msg, "spu_id", spuID, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+	}
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTranslationHandler_PutTranslation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		id         string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockTranslationService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			id:      "7",
+			reqBody: `{"name":"马克杯","description":"陶瓷"}`,
+			mockSetup: func(mockService *mocks.MockTranslationService) {
+				mockService.EXPECT().
+					Put(gomock.Any(), uint64(7), "zh", &service.TranslationPutReq{Name: "马克杯", Description: "陶瓷"}).
+					Return(&service.TranslationResp{Locale: "zh", Name: "马克杯"}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"locale":"zh"`,
+		},
+		{name: "InvalidID", id: "mug", reqBody: `{"name":"马克杯"}`, wantStatus: http.StatusBadRequest, wantBody: "invalid product id"},
+		{name: "MissingName", id: "7", reqBody: `{}`, wantStatus: http.StatusBadRequest, wantBody: `{"field":"name","rule":"required"`},
+		{
+			name:    "UnsupportedLocale",
+			id:      "7",
+			reqBody: `{"name":"马克杯"}`,
+			mockSetup: func(mockService *mocks.MockTranslationService) {
+				mockService.EXPECT().Put(gomock.Any(), uint64(7), "zh", gomock.Any()).Return(nil, service.ErrUnsupportedLocale)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "unsupported locale",
+		},
+		{
+			name:    "ProductNotFound",
+			id:      "7",
+			reqBody: `{"name":"马克杯"}`,
+			mockSetup: func(mockService *mocks.MockTranslationService) {
+				mockService.EXPECT().Put(gomock.Any(), uint64(7), "zh", gomock.Any()).Return(nil, service.ErrProductNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   service.ErrProductNotFound.Error(),
+		},
+		{
+			name:    "ServiceError",
+			id:      "7",
+			reqBody: `{"name":"马克杯"}`,
+			mockSetup: func(mockService *mocks.MockTranslationService) {
+				mockService.EXPECT().Put(gomock.Any(), uint64(7), "zh", gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockTranslationService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewTranslationHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}, {Key: "locale", Value: "zh"}}
+			c.Request = httptest.NewRequest(http.MethodPut, "/admin/products/"+tt.id+"/translations/zh", bytes.NewBufferString(tt.reqBody))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			handler.PutTranslation(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestTranslationHandler_DeleteTranslation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		serviceErr error
+		wantStatus int
+	}{
+		{name: "Success", wantStatus: http.StatusOK},
+		{name: "NotFound", serviceErr: service.ErrTranslationNotFound, wantStatus: http.StatusNotFound},
+		{name: "ServiceError", serviceErr: errors.New("db down"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockTranslationService(ctrl)
+			mockService.EXPECT().Delete(gomock.Any(), uint64(7), "pt-BR").Return(tt.serviceErr)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "7"}, {Key: "locale", Value: "pt-BR"}}
+			c.Request = httptest.NewRequest(http.MethodDelete, "/admin/products/7/translations/pt-BR", nil)
+
+			NewTranslationHandler(mockService).DeleteTranslation(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
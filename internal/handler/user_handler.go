@@ -2,10 +2,13 @@ package handler
 
 import (
 	"errors"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
 )
 
 // UserHandler defines the HTTP handlers for user-related operations.
@@ -20,16 +23,27 @@ func NewUserHandler(userService service.UserService) *UserHandler {
 
 // RegisterRequest defines the request body for user registration.
 type RegisterRequest struct {
-	Username string `json:"username" binding:"required,min=3,max=50"`
+	Username string `json:"username" binding:"required,min=3,max=50,username"`
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6,max=20"`
 }
 
 // Register handles user registration.
+//
+//	@Summary	Register a new user
+//	@Tags		users
+//	@Accept		json
+//	@Produce	json
+//	@Param		request	body		RegisterRequest	true	"Registration payload"
+//	@Success	200		{object}	Response{data=service.UserRegisterResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	409		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/users/register [post]
 func (h *UserHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
@@ -55,22 +69,36 @@ func (h *UserHandler) Register(c *gin.Context) {
 
 // LoginRequest defines the request body for user login.
 type LoginRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username   string `json:"username" binding:"required"`
+	Password   string `json:"password" binding:"required"`
+	RememberMe bool   `json:"remember_me"` // Issues a longer-lived refresh token
 }
 
 // Login handles user login and returns a JWT token.
+//
+//	@Summary	Log in and obtain an access token
+//	@Tags		users
+//	@Accept		json
+//	@Produce	json
+//	@Param		request	body		LoginRequest	true	"Login credentials"
+//	@Success	200		{object}	Response{data=service.UserLoginResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	409		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/users/login [post]
 func (h *UserHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
 	// Map handler request DTO to service request DTO
 	serviceReq := &service.UserLoginReq{
-		Username: req.Username,
-		Password: req.Password,
+		Username:   req.Username,
+		Password:   req.Password,
+		RememberMe: req.RememberMe,
 	}
 
 	resp, err := h.userService.Login(c.Request.Context(), serviceReq)
@@ -79,9 +107,77 @@ func (h *UserHandler) Login(c *gin.Context) {
 			c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
 			return
 		}
+		if errors.Is(err, service.ErrSessionLimitExceeded) {
+			c.JSON(http.StatusConflict, gin.H{"code": http.StatusConflict, "message": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Login successful", "data": resp})
-}
\ No newline at end of file
+}
+
+// RefreshRequest defines the request body for refreshing an access token.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Refresh exchanges the refresh token of a login for a new access token.
+//
+//	@Summary	Exchange a refresh token for a new access token
+//	@Tags		users
+//	@Accept		json
+//	@Produce	json
+//	@Param		request	body		RefreshRequest	true	"Refresh token returned by login"
+//	@Success	200		{object}	Response{data=service.UserLoginResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/users/refresh [post]
+func (h *UserHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.userService.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefreshToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Token refreshed", "data": resp})
+}
+
+// Logout revokes the caller's access token and ends its login session, so
+// neither it nor the session's refresh token works any longer.
+//
+//	@Summary	Log out
+//	@Tags		users
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Success	200	{object}	Response
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/users/logout [post]
+func (h *UserHandler) Logout(c *gin.Context) {
+	payload, ok := auth.PayloadFromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": "unauthorized"})
+		return
+	}
+
+	if err := h.userService.Logout(c.Request.Context(), payload); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to log out", "user_id", payload.UserID, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Logged out"})
+}
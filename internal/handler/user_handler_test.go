@@ -11,6 +11,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/proyuen/go-mall/internal/mocks"
 	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/token"
 	"github.com/proyuen/go-mall/pkg/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -73,7 +75,7 @@ func TestUserHandler_Register(t *testing.T) {
 				},
 			},
 			wantStatus: http.StatusBadRequest,
-			wantBody:   "Field validation for 'Username' failed on the 'required' tag",
+			wantBody:   `{"field":"username","rule":"required","message":"username is a required field"}`,
 		},
 		{
 			name: "UserAlreadyExists",
@@ -184,6 +186,29 @@ func TestUserHandler_Login(t *testing.T) {
 			wantStatus: http.StatusOK,
 			wantBody:   "mock_token",
 		},
+		{
+			name: "RememberMe",
+			args: args{
+				reqBody: LoginRequest{
+					Username:   successUser,
+					Password:   "password123",
+					RememberMe: true,
+				},
+			},
+			fields: fields{
+				mockSetup: func(mockService *mocks.MockUserService) {
+					want := &service.UserLoginReq{Username: successUser, Password: "password123", RememberMe: true}
+					mockService.EXPECT().Login(gomock.Any(), want).Return(&service.UserLoginResp{
+						UserID:           101,
+						AccessToken:      "mock_token",
+						RefreshToken:     "mock_refresh_token",
+						RefreshExpiresIn: 2592000,
+					}, nil)
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"refresh_expires_in":2592000`,
+		},
 		{
 			name: "InvalidInput",
 			args: args{
@@ -197,7 +222,7 @@ func TestUserHandler_Login(t *testing.T) {
 				},
 			},
 			wantStatus: http.StatusBadRequest,
-			wantBody:   "Field validation for 'Username' failed on the 'required' tag",
+			wantBody:   `{"field":"username","rule":"required","message":"username is a required field"}`,
 		},
 		{
 			name: "InvalidCredentials",
@@ -215,6 +240,22 @@ func TestUserHandler_Login(t *testing.T) {
 			wantStatus: http.StatusUnauthorized,
 			wantBody:   "invalid credentials",
 		},
+		{
+			name: "SessionLimitExceeded",
+			args: args{
+				reqBody: LoginRequest{
+					Username: successUser,
+					Password: "password123",
+				},
+			},
+			fields: fields{
+				mockSetup: func(mockService *mocks.MockUserService) {
+					mockService.EXPECT().Login(gomock.Any(), gomock.Any()).Return(nil, service.ErrSessionLimitExceeded)
+				},
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   "too many active sessions",
+		},
 		{
 			name: "InternalServerError",
 			args: args{
@@ -262,4 +303,117 @@ func TestUserHandler_Login(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestUserHandler_Refresh(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    interface{}
+		mockSetup  func(mockService *mocks.MockUserService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: RefreshRequest{RefreshToken: "refresh"},
+			mockSetup: func(mockService *mocks.MockUserService) {
+				mockService.EXPECT().Refresh(gomock.Any(), "refresh").Return(&service.UserLoginResp{UserID: 101, AccessToken: "new_token", ExpiresIn: 900, TokenType: "Bearer"}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "new_token",
+		},
+		{
+			name:       "MissingToken",
+			reqBody:    RefreshRequest{},
+			mockSetup:  func(mockService *mocks.MockUserService) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "refresh_token",
+		},
+		{
+			name:    "InvalidToken",
+			reqBody: RefreshRequest{RefreshToken: "access"},
+			mockSetup: func(mockService *mocks.MockUserService) {
+				mockService.EXPECT().Refresh(gomock.Any(), "access").Return(nil, service.ErrInvalidRefreshToken)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   "invalid or expired refresh token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockUserService(ctrl)
+			tt.mockSetup(mockService)
+			handler := NewUserHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			jsonBody, err := json.Marshal(tt.reqBody)
+			require.NoError(t, err)
+			c.Request, err = http.NewRequest("POST", "/refresh", bytes.NewBuffer(jsonBody))
+			require.NoError(t, err)
+
+			handler.Refresh(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestUserHandler_Logout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	payload := &token.Payload{UserID: 101, SessionID: "s1"}
+
+	tests := []struct {
+		name       string
+		payload    *token.Payload
+		mockSetup  func(mockService *mocks.MockUserService)
+		wantStatus int
+	}{
+		{
+			name:    "Success",
+			payload: payload,
+			mockSetup: func(mockService *mocks.MockUserService) {
+				mockService.EXPECT().Logout(gomock.Any(), payload).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Anonymous",
+			mockSetup:  func(mockService *mocks.MockUserService) {},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:    "RevokerDown",
+			payload: payload,
+			mockSetup: func(mockService *mocks.MockUserService) {
+				mockService.EXPECT().Logout(gomock.Any(), payload).Return(errors.New("redis down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockUserService(ctrl)
+			tt.mockSetup(mockService)
+			handler := NewUserHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/logout", nil)
+			if tt.payload != nil {
+				c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), tt.payload))
+			}
+
+			handler.Logout(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
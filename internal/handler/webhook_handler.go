@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// WebhookHandler defines the HTTP handlers for managing outbound webhooks.
+type WebhookHandler struct {
+	webhookService service.WebhookService
+}
+
+// NewWebhookHandler creates a new WebhookHandler instance.
+func NewWebhookHandler(webhookService service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// WebhookSubscribeRequest defines the request body for registering a webhook endpoint.
+type WebhookSubscribeRequest struct {
+	Event       string `json:"event" binding:"required,oneof=order.created order.paid stock.low subscription.payment_failed" example:"order.created"`
+	URL         string `json:"url" binding:"required,url,max=2048" example:"https://erp.example.com/hooks/mall"`
+	Description string `json:"description" binding:"max=255" example:"ERP order import"`
+}
+
+// WebhookDeliveryQuery defines the filters for listing webhook deliveries.
+type WebhookDeliveryQuery struct {
+	SubscriptionID uint64 `form:"subscription_id" example:"1735000000000000000"`
+	Event          string `form:"event" binding:"omitempty,oneof=order.created order.paid stock.low subscription.payment_failed" example:"order.created"`
+	Status         string `form:"status" binding:"omitempty,oneof=pending delivered failed" example:"failed"`
+	Offset         int    `form:"offset" binding:"min=0"`
+	Limit          int    `form:"limit" binding:"min=0,max=100"`
+}
+
+// Subscribe registers an endpoint for one event type. The signing secret is
+// only returned here.
+//
+//	@Summary		Register a webhook endpoint
+//	@Description	Deliveries are POSTed as JSON with X-Mall-Event, X-Mall-Delivery and X-Mall-Signature headers. The signature is "t=<unix>,v1=<hex HMAC-SHA256 of "<unix>.<body>">" keyed with the returned secret.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		WebhookSubscribeRequest	true	"Subscription payload"
+//	@Success		201		{object}	Response{data=service.WebhookSubscriptionResp}
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/webhooks [post]
+func (h *WebhookHandler) Subscribe(c *gin.Context) {
+	var req WebhookSubscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.webhookService.Subscribe(c.Request.Context(), &service.WebhookSubscribeReq{
+		Event:       req.Event,
+		URL:         req.URL,
+		Description: req.Description,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWebhookEvent) || errors.Is(err, service.ErrInvalidWebhookURL) {
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to create webhook subscription", "event", req.Event, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "Webhook subscription created", "data": resp})
+}
+
+// ListSubscriptions returns every webhook subscription.
+//
+//	@Summary	List webhook subscriptions
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Success	200	{object}	Response{data=[]service.WebhookSubscriptionResp}
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/webhooks [get]
+func (h *WebhookHandler) ListSubscriptions(c *gin.Context) {
+	subs, err := h.webhookService.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list webhook subscriptions", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": subs})
+}
+
+// Unsubscribe removes a webhook subscription. Its pending deliveries fail
+// on their next attempt.
+//
+//	@Summary	Delete a webhook subscription
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Subscription ID"
+//	@Success	200	{object}	Response
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/webhooks/{id} [delete]
+func (h *WebhookHandler) Unsubscribe(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "subscription")
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.Unsubscribe(c.Request.Context(), id); err != nil {
+		if errors.Is(err, service.ErrWebhookSubscriptionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to delete webhook subscription", "subscription_id", id, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "Webhook subscription deleted"})
+}
+
+// ListDeliveries returns webhook deliveries, newest first.
+//
+//	@Summary	List webhook deliveries
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		query	query		WebhookDeliveryQuery	false	"Filters"
+//	@Success	200		{object}	Response{data=service.WebhookDeliveryListResp}
+//	@Failure	400		{object}	ErrorResponse
+//	@Failure	401		{object}	ErrorResponse
+//	@Failure	403		{object}	ErrorResponse
+//	@Failure	500		{object}	ErrorResponse
+//	@Router		/admin/webhook-deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	var query WebhookDeliveryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	resp, err := h.webhookService.ListDeliveries(c.Request.Context(), &service.WebhookDeliveryListReq{
+		SubscriptionID: query.SubscriptionID,
+		Event:          query.Event,
+		Status:         query.Status,
+		Offset:         query.Offset,
+		Limit:          query.Limit,
+	})
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to list webhook deliveries", logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+}
+
+// GetDelivery returns a webhook delivery with its attempts.
+//
+//	@Summary	Get a webhook delivery
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		integer	true	"Delivery ID"
+//	@Success	200	{object}	Response{data=service.WebhookDeliveryDetail}
+//	@Failure	400	{object}	ErrorResponse
+//	@Failure	401	{object}	ErrorResponse
+//	@Failure	403	{object}	ErrorResponse
+//	@Failure	404	{object}	ErrorResponse
+//	@Failure	500	{object}	ErrorResponse
+//	@Router		/admin/webhook-deliveries/{id} [get]
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "delivery")
+	if !ok {
+		return
+	}
+
+	resp, err := h.webhookService.GetDelivery(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrWebhookDeliveryNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to get webhook delivery", "delivery_id", id, logger.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Internal Server Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "data": resp})
+}
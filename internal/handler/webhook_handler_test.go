@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWebhookHandler_Subscribe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		mockSetup  func(mockService *mocks.MockWebhookService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:    "Success",
+			reqBody: `{"event":"order.created","url":"https://erp.example.com/hooks","description":"ERP"}`,
+			mockSetup: func(mockService *mocks.MockWebhookService) {
+				mockService.EXPECT().
+					Subscribe(gomock.Any(), &service.WebhookSubscribeReq{Event: "order.created", URL: "https://erp.example.com/hooks", Description: "ERP"}).
+					Return(&service.WebhookSubscriptionResp{ID: 7, Event: "order.created", Secret: "whsec_abc"}, nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"secret":"whsec_abc"`,
+		},
+		{
+			name:       "UnknownEvent",
+			reqBody:    `{"event":"order.shipped","url":"https://erp.example.com/hooks"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"field":"event","rule":"oneof"`,
+		},
+		{
+			name:       "InvalidURL",
+			reqBody:    `{"event":"stock.low","url":"not a url"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"field":"url","rule":"url"`,
+		},
+		{
+			name:    "RejectedURL",
+			reqBody: `{"event":"stock.low","url":"ftp://erp.example.com/hooks"}`,
+			mockSetup: func(mockService *mocks.MockWebhookService) {
+				mockService.EXPECT().Subscribe(gomock.Any(), gomock.Any()).Return(nil, service.ErrInvalidWebhookURL)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   service.ErrInvalidWebhookURL.Error(),
+		},
+		{
+			name:    "ServiceError",
+			reqBody: `{"event":"stock.low","url":"https://erp.example.com/hooks"}`,
+			mockSetup: func(mockService *mocks.MockWebhookService) {
+				mockService.EXPECT().Subscribe(gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockWebhookService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewWebhookHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			var err error
+			c.Request, err = http.NewRequest(http.MethodPost, "/api/v1/admin/webhooks", bytes.NewBufferString(tt.reqBody))
+			require.NoError(t, err)
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			handler.Subscribe(c)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestWebhookHandler_ByID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		call       func(h *WebhookHandler, c *gin.Context)
+		id         string
+		mockSetup  func(mockService *mocks.MockWebhookService)
+		wantStatus int
+	}{
+		{
+			name: "Unsubscribe",
+			call: (*WebhookHandler).Unsubscribe,
+			id:   "7",
+			mockSetup: func(mockService *mocks.MockWebhookService) {
+				mockService.EXPECT().Unsubscribe(gomock.Any(), uint64(7)).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "UnsubscribeNotFound",
+			call: (*WebhookHandler).Unsubscribe,
+			id:   "7",
+			mockSetup: func(mockService *mocks.MockWebhookService) {
+				mockService.EXPECT().Unsubscribe(gomock.Any(), uint64(7)).Return(service.ErrWebhookSubscriptionNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "UnsubscribeInvalidID",
+			call:       (*WebhookHandler).Unsubscribe,
+			id:         "abc",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "GetDelivery",
+			call: (*WebhookHandler).GetDelivery,
+			id:   "9",
+			mockSetup: func(mockService *mocks.MockWebhookService) {
+				mockService.EXPECT().GetDelivery(gomock.Any(), uint64(9)).Return(&service.WebhookDeliveryDetail{}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "GetDeliveryNotFound",
+			call: (*WebhookHandler).GetDelivery,
+			id:   "9",
+			mockSetup: func(mockService *mocks.MockWebhookService) {
+				mockService.EXPECT().GetDelivery(gomock.Any(), uint64(9)).Return(nil, service.ErrWebhookDeliveryNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockWebhookService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewWebhookHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}}
+			c.Request, _ = http.NewRequest(http.MethodGet, "/", nil)
+
+			tt.call(handler, c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestWebhookHandler_ListDeliveries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		query      string
+		mockSetup  func(mockService *mocks.MockWebhookService)
+		wantStatus int
+	}{
+		{
+			name:  "Filtered",
+			query: "?subscription_id=7&event=stock.low&status=failed&offset=20&limit=10",
+			mockSetup: func(mockService *mocks.MockWebhookService) {
+				mockService.EXPECT().
+					ListDeliveries(gomock.Any(), &service.WebhookDeliveryListReq{SubscriptionID: 7, Event: "stock.low", Status: "failed", Offset: 20, Limit: 10}).
+					Return(&service.WebhookDeliveryListResp{Items: []service.WebhookDeliveryResp{}}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "UnknownStatus",
+			query:      "?status=lost",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "ServiceError",
+			query: "",
+			mockSetup: func(mockService *mocks.MockWebhookService) {
+				mockService.EXPECT().ListDeliveries(gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockWebhookService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewWebhookHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodGet, "/api/v1/admin/webhook-deliveries"+tt.query, nil)
+
+			handler.ListDeliveries(c)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
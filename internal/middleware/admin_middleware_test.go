@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		withPayload bool
+		mockSetup   func(m *mocks.MockUserRepository)
+		wantStatus  int
+	}{
+		{
+			name:        "Admin",
+			withPayload: true,
+			mockSetup: func(m *mocks.MockUserRepository) {
+				m.EXPECT().GetByID(gomock.Any(), uint64(7)).Return(&model.User{Role: model.RoleAdmin}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "RegularUser",
+			withPayload: true,
+			mockSetup: func(m *mocks.MockUserRepository) {
+				m.EXPECT().GetByID(gomock.Any(), uint64(7)).Return(&model.User{Role: model.RoleUser}, nil)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:        "UserDeleted",
+			withPayload: true,
+			mockSetup: func(m *mocks.MockUserRepository) {
+				m.EXPECT().GetByID(gomock.Any(), uint64(7)).Return(nil, repository.ErrUserNotFound)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:        "RepositoryError",
+			withPayload: true,
+			mockSetup: func(m *mocks.MockUserRepository) {
+				m.EXPECT().GetByID(gomock.Any(), uint64(7)).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:        "NotAuthenticated",
+			withPayload: false,
+			mockSetup:   func(m *mocks.MockUserRepository) {},
+			wantStatus:  http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockRepo := mocks.NewMockUserRepository(ctrl)
+			tt.mockSetup(mockRepo)
+
+			router := gin.New()
+			router.GET("/admin", func(c *gin.Context) {
+				if tt.withPayload {
+					c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 7}))
+				}
+				c.Next()
+			}, RequireAdmin(mockRepo), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// AuditTrail records every successful mutating request on the routes it guards.
+// Services describe their changes through service.RecordAudit; a mutation that
+// recorded nothing still gets a generic entry for its route, so no admin write
+// goes unaudited. Reads are recorded only when the service recorded them, as
+// for diagnostics. It must run after AuthMiddleware.
+func AuditTrail(auditService service.AuditService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, trail := service.WithAuditTrail(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		// Rejected requests changed nothing.
+		if c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+
+		entries := trail.Entries()
+		if len(entries) == 0 {
+			switch c.Request.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				return
+			}
+			entries = []service.AuditEntry{{Action: strings.ToLower(c.Request.Method), Resource: route(c)}}
+		}
+		userID, _ := auth.UserID(c.Request.Context())
+		actor := service.AuditActor{
+			UserID:    userID,
+			IP:        c.ClientIP(),
+			RequestID: logger.RequestIDFromContext(c.Request.Context()),
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+		}
+
+		// The change is already committed, so the record is written even if the client hung up.
+		if err := auditService.Write(context.WithoutCancel(c.Request.Context()), actor, entries); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to write audit log", "entries", len(entries), logger.Err(err))
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuditTrail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		method    string
+		handler   gin.HandlerFunc
+		mockSetup func(m *mocks.MockAuditService)
+	}{
+		{
+			name:      "ReadsNotAudited",
+			method:    http.MethodGet,
+			handler:   func(c *gin.Context) { c.Status(http.StatusOK) },
+			mockSetup: func(m *mocks.MockAuditService) {},
+		},
+		{
+			name:   "RecordedReadAudited",
+			method: http.MethodGet,
+			handler: func(c *gin.Context) {
+				service.RecordAudit(c.Request.Context(), service.AuditEntry{Action: "diagnostics.view", Resource: "diagnostics", ResourceID: "cache"})
+				c.Status(http.StatusOK)
+			},
+			mockSetup: func(m *mocks.MockAuditService) {
+				m.EXPECT().Write(gomock.Any(), gomock.Any(), []service.AuditEntry{{Action: "diagnostics.view", Resource: "diagnostics", ResourceID: "cache"}})
+			},
+		},
+		{
+			name:      "RejectedMutationNotAudited",
+			method:    http.MethodPost,
+			handler:   func(c *gin.Context) { c.Status(http.StatusBadRequest) },
+			mockSetup: func(m *mocks.MockAuditService) {},
+		},
+		{
+			name:   "ServiceHookEntries",
+			method: http.MethodPost,
+			handler: func(c *gin.Context) {
+				service.RecordAudit(c.Request.Context(), service.AuditEntry{Action: "ip_rule.put", Resource: "ip_rule", ResourceID: "10.0.0.0/8"})
+				c.Status(http.StatusOK)
+			},
+			mockSetup: func(m *mocks.MockAuditService) {
+				m.EXPECT().Write(gomock.Any(), service.AuditActor{
+					UserID: 42, IP: "203.0.113.7", Method: http.MethodPost, Path: "/admin/things/9",
+				}, []service.AuditEntry{{Action: "ip_rule.put", Resource: "ip_rule", ResourceID: "10.0.0.0/8"}})
+			},
+		},
+		{
+			name:    "FallbackEntryForUnhookedMutation",
+			method:  http.MethodDelete,
+			handler: func(c *gin.Context) { c.Status(http.StatusOK) },
+			mockSetup: func(m *mocks.MockAuditService) {
+				m.EXPECT().Write(gomock.Any(), gomock.Any(), []service.AuditEntry{{Action: "delete", Resource: "/admin/things/:id"}})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockService := mocks.NewMockAuditService(ctrl)
+			tt.mockSetup(mockService)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &token.Payload{UserID: 42}))
+			}, AuditTrail(mockService))
+			router.Handle(tt.method, "/admin/things/:id", tt.handler)
+
+			req := httptest.NewRequest(tt.method, "/admin/things/9", nil)
+			req.RemoteAddr = "203.0.113.7:54321"
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Less(t, w.Code, http.StatusInternalServerError)
+		})
+	}
+}
@@ -1,13 +1,16 @@
 package middleware
 
 import (
-	"log" // For internal logging
+	"log/slog" // For internal logging
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/tenant"
 	"github.com/proyuen/go-mall/pkg/token" // Import the new token package
-	"github.com/proyuen/go-mall/pkg/utils"  // For AuthorizationPayloadKey
 )
 
 const (
@@ -17,7 +20,13 @@ const (
 
 // AuthMiddleware creates a Gin middleware for JWT authentication.
 // It now takes a token.Maker interface for dependency injection.
-func AuthMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+// Tokens are only accepted for the store they were issued for, so that an
+// account of one store cannot be used on another. Tokens of a login session
+// must also still be open in sessions, and no token may have been revoked
+// through revoker; either check is skipped when its store is nil. If either
+// store cannot be read the request is refused with 503, so that revoked
+// tokens and ended sessions do not work again while Redis is down.
+func AuthMiddleware(tokenMaker token.Maker, sessions service.SessionStore, revoker token.Revoker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authorizationHeader := c.GetHeader(authorizationHeaderKey)
 		if len(authorizationHeader) == 0 {
@@ -44,13 +53,64 @@ func AuthMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		if err != nil {
 			// Security: Do NOT return err.Error() to the client.
 			// Log the actual error internally for debugging.
-			log.Printf("Failed to verify token: %v", err)
+			slog.WarnContext(c.Request.Context(), "Failed to verify token", logger.Err(err))
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			return
 		}
 
-		// Store payload in context for subsequent handlers
-		c.Set(utils.AuthorizationPayloadKey, payload) // Store the actual payload
+		if storeID := tenant.StoreID(c.Request.Context()); payload.StoreID != storeID {
+			slog.WarnContext(c.Request.Context(), "Token was issued for another store", "user_id", payload.UserID, "token_store_id", payload.StoreID, "store_id", storeID)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		// Logged-out and compromised tokens are revoked before they expire
+		if revoker != nil {
+			revoked, err := revoker.IsRevoked(c.Request.Context(), payload.ID)
+			if err != nil {
+				slog.ErrorContext(c.Request.Context(), "Failed to check token revocation", "user_id", payload.UserID, logger.Err(err))
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "authentication is temporarily unavailable"})
+				return
+			}
+			if revoked {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
+				return
+			}
+		}
+
+		// Sessions evicted by the session limit end before their tokens expire
+		if sessions != nil && payload.SessionID != "" {
+			active, err := sessions.Active(c.Request.Context(), payload.UserID, payload.SessionID)
+			if err != nil {
+				slog.ErrorContext(c.Request.Context(), "Failed to check session", "user_id", payload.UserID, logger.Err(err))
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "authentication is temporarily unavailable"})
+				return
+			}
+			if !active {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session has ended"})
+				return
+			}
+		}
+
+		// Store payload in the request context, which handlers pass on to the services,
+		// and tag every later log line of this request with the user
+		ctx := auth.NewContext(c.Request.Context(), payload)
+		c.Request = c.Request.WithContext(logger.WithUserID(ctx, payload.UserID))
 		c.Next()
 	}
 }
+
+// OptionalAuth authenticates requests that carry an Authorization header like
+// AuthMiddleware and lets anonymous requests through, for public routes that
+// personalize their response. A header that does not verify is still rejected,
+// so clients learn that their token expired.
+func OptionalAuth(tokenMaker token.Maker, sessions service.SessionStore, revoker token.Revoker) gin.HandlerFunc {
+	authenticate := AuthMiddleware(tokenMaker, sessions, revoker)
+	return func(c *gin.Context) {
+		if c.GetHeader(authorizationHeaderKey) == "" {
+			c.Next()
+			return
+		}
+		authenticate(c)
+	}
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -9,8 +10,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/pkg/auth"
 	"github.com/proyuen/go-mall/pkg/token"
-	"github.com/proyuen/go-mall/pkg/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
@@ -32,14 +33,16 @@ func TestAuthMiddleware(t *testing.T) {
 	// Generate a valid token for success case
 	testUserID := uint64(1)
 	testUsername := "testuser"
-	validToken, validPayload, err := realTokenMaker.CreateToken(testUserID, testUsername, time.Minute)
+	validToken, validPayload, err := realTokenMaker.CreateToken(testUserID, 0, testUsername, "user", time.Minute)
+	require.NoError(t, err)
+	sessionToken, sessionPayload, err := realTokenMaker.CreateSessionToken(testUserID, 0, testUsername, "user", "s1", time.Minute)
 	require.NoError(t, err)
 
 	type args struct {
 		authHeader string
 	}
 	type fields struct {
-		mockSetup func(mockMaker *mocks.MockMaker)
+		mockSetup func(mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore, mockRevoker *mocks.MockRevoker)
 	}
 	tests := []struct {
 		name       string
@@ -74,7 +77,7 @@ func TestAuthMiddleware(t *testing.T) {
 			name: "InvalidToken",
 			args: args{authHeader: "Bearer invalid_token"},
 			fields: fields{
-				mockSetup: func(mockMaker *mocks.MockMaker) {
+				mockSetup: func(mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore, mockRevoker *mocks.MockRevoker) {
 					mockMaker.EXPECT().VerifyToken("invalid_token").Return(nil, token.ErrInvalidToken)
 				},
 			},
@@ -86,14 +89,89 @@ func TestAuthMiddleware(t *testing.T) {
 			name: "ValidToken",
 			args: args{authHeader: "Bearer " + validToken},
 			fields: fields{
-				mockSetup: func(mockMaker *mocks.MockMaker) {
+				mockSetup: func(mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore, mockRevoker *mocks.MockRevoker) {
 					// Use gomock.Eq() for string comparison
 					mockMaker.EXPECT().VerifyToken(gomock.Eq(validToken)).Return(validPayload, nil)
+					mockRevoker.EXPECT().IsRevoked(gomock.Any(), validPayload.ID).Return(false, nil)
 				},
 			},
 			wantStatus: http.StatusOK,
 			checkCtx:   true,
 		},
+		{
+			name: "OtherStore",
+			args: args{authHeader: "Bearer other_store"},
+			fields: fields{
+				mockSetup: func(mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore, mockRevoker *mocks.MockRevoker) {
+					mockMaker.EXPECT().VerifyToken("other_store").Return(&token.Payload{UserID: testUserID, StoreID: 3}, nil)
+				},
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   "Unauthorized",
+		},
+		{
+			name: "OpenSession",
+			args: args{authHeader: "Bearer " + sessionToken},
+			fields: fields{
+				mockSetup: func(mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore, mockRevoker *mocks.MockRevoker) {
+					mockMaker.EXPECT().VerifyToken(sessionToken).Return(sessionPayload, nil)
+					mockRevoker.EXPECT().IsRevoked(gomock.Any(), sessionPayload.ID).Return(false, nil)
+					mockSessions.EXPECT().Active(gomock.Any(), testUserID, "s1").Return(true, nil)
+				},
+			},
+			wantStatus: http.StatusOK,
+			checkCtx:   true,
+		},
+		{
+			name: "EndedSession",
+			args: args{authHeader: "Bearer " + sessionToken},
+			fields: fields{
+				mockSetup: func(mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore, mockRevoker *mocks.MockRevoker) {
+					mockMaker.EXPECT().VerifyToken(sessionToken).Return(sessionPayload, nil)
+					mockRevoker.EXPECT().IsRevoked(gomock.Any(), sessionPayload.ID).Return(false, nil)
+					mockSessions.EXPECT().Active(gomock.Any(), testUserID, "s1").Return(false, nil)
+				},
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   "session has ended",
+		},
+		{
+			name: "SessionStoreDown",
+			args: args{authHeader: "Bearer " + sessionToken},
+			fields: fields{
+				mockSetup: func(mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore, mockRevoker *mocks.MockRevoker) {
+					mockMaker.EXPECT().VerifyToken(sessionToken).Return(sessionPayload, nil)
+					mockRevoker.EXPECT().IsRevoked(gomock.Any(), sessionPayload.ID).Return(false, nil)
+					mockSessions.EXPECT().Active(gomock.Any(), testUserID, "s1").Return(false, errors.New("redis down"))
+				},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "authentication is temporarily unavailable",
+		},
+		{
+			name: "RevokedToken",
+			args: args{authHeader: "Bearer " + sessionToken},
+			fields: fields{
+				mockSetup: func(mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore, mockRevoker *mocks.MockRevoker) {
+					mockMaker.EXPECT().VerifyToken(sessionToken).Return(sessionPayload, nil)
+					mockRevoker.EXPECT().IsRevoked(gomock.Any(), sessionPayload.ID).Return(true, nil)
+				},
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   "token has been revoked",
+		},
+		{
+			name: "RevokerDown",
+			args: args{authHeader: "Bearer " + validToken},
+			fields: fields{
+				mockSetup: func(mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore, mockRevoker *mocks.MockRevoker) {
+					mockMaker.EXPECT().VerifyToken(validToken).Return(validPayload, nil)
+					mockRevoker.EXPECT().IsRevoked(gomock.Any(), validPayload.ID).Return(false, errors.New("redis down"))
+				},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "authentication is temporarily unavailable",
+		},
 	}
 
 	for _, tt := range tests {
@@ -102,8 +180,10 @@ func TestAuthMiddleware(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockMaker := mocks.NewMockMaker(ctrl)
+			mockSessions := mocks.NewMockSessionStore(ctrl)
+			mockRevoker := mocks.NewMockRevoker(ctrl)
 			if tt.fields.mockSetup != nil {
-				tt.fields.mockSetup(mockMaker)
+				tt.fields.mockSetup(mockMaker, mockSessions, mockRevoker)
 			}
 
 			w := httptest.NewRecorder()
@@ -117,15 +197,12 @@ func TestAuthMiddleware(t *testing.T) {
 			// Dummy handler to check if context was set
 			var contextPayload *token.Payload
 			testHandler := func(c *gin.Context) {
-				payload, exists := c.Get(utils.AuthorizationPayloadKey)
-				if exists {
-					contextPayload = payload.(*token.Payload) // Cast to our Payload type
-				}
+				contextPayload, _ = auth.PayloadFromContext(c.Request.Context())
 				c.Status(http.StatusOK) // Ensure a status is set if not aborted
 			}
 
 			// Execute Middleware
-			handler := AuthMiddleware(mockMaker) // Pass mock maker
+			handler := AuthMiddleware(mockMaker, mockSessions, mockRevoker) // Pass mocks
 			handler(c)
 
 			// If middleware didn't abort, call the next handler to test context setting
@@ -149,3 +226,61 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestOptionalAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		authHeader string
+		mockSetup  func(mockMaker *mocks.MockMaker)
+		wantStatus int
+		wantUserID uint64
+	}{
+		{name: "Anonymous", wantStatus: http.StatusOK},
+		{
+			name:       "ValidToken",
+			authHeader: "Bearer valid",
+			mockSetup: func(mockMaker *mocks.MockMaker) {
+				mockMaker.EXPECT().VerifyToken("valid").Return(&token.Payload{UserID: 7}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantUserID: 7,
+		},
+		{
+			name:       "InvalidToken",
+			authHeader: "Bearer expired",
+			mockSetup: func(mockMaker *mocks.MockMaker) {
+				mockMaker.EXPECT().VerifyToken("expired").Return(nil, token.ErrExpiredToken)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockMaker := mocks.NewMockMaker(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockMaker)
+			}
+
+			var userID uint64
+			engine := gin.New()
+			engine.GET("/", OptionalAuth(mockMaker, nil, nil), func(c *gin.Context) {
+				userID, _ = auth.UserID(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantUserID, userID)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/captcha"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// CaptchaTokenHeader carries the provider token for a solved CAPTCHA challenge.
+const CaptchaTokenHeader = "X-Captcha-Token"
+
+// BotProtection guards an abuse-prone endpoint such as login or register.
+// Failed attempts (4xx responses) are counted per client IP; once the detector flags the IP,
+// further attempts must carry a valid CAPTCHA token. Without a verifier the endpoint is
+// throttled with 429 instead. Like IPFilter, detector errors fail open.
+func BotProtection(scope string, detector service.AbuseDetector, verifier captcha.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		ip := c.ClientIP()
+
+		required, err := detector.ChallengeRequired(ctx, scope, ip)
+		if err != nil {
+			slog.WarnContext(ctx, "Bot protection check failed", "scope", scope, "client_ip", ip, logger.Err(err))
+			required = false
+		}
+
+		if required {
+			if verifier == nil {
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"code": http.StatusTooManyRequests, "message": "too many failed attempts, try again later"})
+				return
+			}
+
+			captchaToken := c.GetHeader(CaptchaTokenHeader)
+			if captchaToken == "" {
+				c.AbortWithStatusJSON(http.StatusPreconditionRequired, gin.H{"code": http.StatusPreconditionRequired, "message": "captcha required"})
+				return
+			}
+			ok, err := verifier.Verify(ctx, captchaToken, ip)
+			if err != nil {
+				slog.ErrorContext(ctx, "Captcha verification failed", "client_ip", ip, logger.Err(err))
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"code": http.StatusServiceUnavailable, "message": "captcha verification unavailable"})
+				return
+			}
+			if !ok {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"code": http.StatusForbidden, "message": "captcha verification failed"})
+				return
+			}
+			// A solved challenge earns a fresh allowance of attempts.
+			if err := detector.Reset(ctx, scope, ip); err != nil {
+				slog.WarnContext(ctx, "Failed to reset failure count", "scope", scope, "client_ip", ip, logger.Err(err))
+			}
+		}
+
+		c.Next()
+
+		if status := c.Writer.Status(); status >= http.StatusBadRequest && status < http.StatusInternalServerError {
+			if err := detector.RecordFailure(ctx, scope, ip); err != nil {
+				slog.WarnContext(ctx, "Failed to record failure", "scope", scope, "client_ip", ip, logger.Err(err))
+			}
+		}
+	}
+}
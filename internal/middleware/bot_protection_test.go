@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestBotProtection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const (
+		scope    = "login"
+		clientIP = "203.0.113.7"
+	)
+
+	tests := []struct {
+		name          string
+		captchaToken  string
+		handlerStatus int
+		withVerifier  bool
+		mockSetup     func(d *mocks.MockAbuseDetector, v *mocks.MockVerifier)
+		wantStatus    int
+	}{
+		{
+			name:          "NotFlagged_Success",
+			handlerStatus: http.StatusOK,
+			withVerifier:  true,
+			mockSetup: func(d *mocks.MockAbuseDetector, v *mocks.MockVerifier) {
+				d.EXPECT().ChallengeRequired(gomock.Any(), scope, clientIP).Return(false, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "NotFlagged_FailureIsRecorded",
+			handlerStatus: http.StatusUnauthorized,
+			withVerifier:  true,
+			mockSetup: func(d *mocks.MockAbuseDetector, v *mocks.MockVerifier) {
+				d.EXPECT().ChallengeRequired(gomock.Any(), scope, clientIP).Return(false, nil)
+				d.EXPECT().RecordFailure(gomock.Any(), scope, clientIP).Return(nil)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "Flagged_MissingToken",
+			handlerStatus: http.StatusOK,
+			withVerifier:  true,
+			mockSetup: func(d *mocks.MockAbuseDetector, v *mocks.MockVerifier) {
+				d.EXPECT().ChallengeRequired(gomock.Any(), scope, clientIP).Return(true, nil)
+			},
+			wantStatus: http.StatusPreconditionRequired,
+		},
+		{
+			name:          "Flagged_WrongToken",
+			captchaToken:  "wrong",
+			handlerStatus: http.StatusOK,
+			withVerifier:  true,
+			mockSetup: func(d *mocks.MockAbuseDetector, v *mocks.MockVerifier) {
+				d.EXPECT().ChallengeRequired(gomock.Any(), scope, clientIP).Return(true, nil)
+				v.EXPECT().Verify(gomock.Any(), "wrong", clientIP).Return(false, nil)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:          "Flagged_SolvedChallengeResetsCount",
+			captchaToken:  "solved",
+			handlerStatus: http.StatusOK,
+			withVerifier:  true,
+			mockSetup: func(d *mocks.MockAbuseDetector, v *mocks.MockVerifier) {
+				d.EXPECT().ChallengeRequired(gomock.Any(), scope, clientIP).Return(true, nil)
+				v.EXPECT().Verify(gomock.Any(), "solved", clientIP).Return(true, nil)
+				d.EXPECT().Reset(gomock.Any(), scope, clientIP).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "Flagged_ProviderUnavailable",
+			captchaToken:  "solved",
+			handlerStatus: http.StatusOK,
+			withVerifier:  true,
+			mockSetup: func(d *mocks.MockAbuseDetector, v *mocks.MockVerifier) {
+				d.EXPECT().ChallengeRequired(gomock.Any(), scope, clientIP).Return(true, nil)
+				v.EXPECT().Verify(gomock.Any(), "solved", clientIP).Return(false, errors.New("timeout"))
+			},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:          "Flagged_NoVerifierThrottles",
+			handlerStatus: http.StatusOK,
+			withVerifier:  false,
+			mockSetup: func(d *mocks.MockAbuseDetector, v *mocks.MockVerifier) {
+				d.EXPECT().ChallengeRequired(gomock.Any(), scope, clientIP).Return(true, nil)
+			},
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:          "DetectorErrorFailsOpen",
+			handlerStatus: http.StatusOK,
+			withVerifier:  true,
+			mockSetup: func(d *mocks.MockAbuseDetector, v *mocks.MockVerifier) {
+				d.EXPECT().ChallengeRequired(gomock.Any(), scope, clientIP).Return(false, errors.New("redis down"))
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockDetector := mocks.NewMockAbuseDetector(ctrl)
+			mockVerifier := mocks.NewMockVerifier(ctrl)
+			tt.mockSetup(mockDetector, mockVerifier)
+
+			guard := BotProtection(scope, mockDetector, nil)
+			if tt.withVerifier {
+				guard = BotProtection(scope, mockDetector, mockVerifier)
+			}
+
+			router := gin.New()
+			router.POST("/login", guard, func(c *gin.Context) { c.Status(tt.handlerStatus) })
+
+			req := httptest.NewRequest(http.MethodPost, "/login", nil)
+			req.RemoteAddr = clientIP + ":54321"
+			if tt.captchaToken != "" {
+				req.Header.Set(CaptchaTokenHeader, tt.captchaToken)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/pkg/channel"
+)
+
+// ChannelHeader names the sales channel a request is for: web, app or
+// wholesale. Requests without it are for the web shop.
+const ChannelHeader = "X-Sales-Channel"
+
+// SalesChannel resolves the sales channel of each request from ChannelHeader,
+// so listings and checkout only offer the SKUs sold on it. Unknown channels
+// are rejected rather than served as web, which could show SKUs that are
+// not launched there.
+func SalesChannel() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ch := strings.ToLower(strings.TrimSpace(c.GetHeader(ChannelHeader)))
+		if ch == "" {
+			ch = channel.Web
+		}
+		if !channel.Valid(ch) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "unknown sales channel"})
+			return
+		}
+		c.Request = c.Request.WithContext(channel.NewContext(c.Request.Context(), ch))
+		c.Next()
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/pkg/channel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSalesChannel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		header      string
+		wantStatus  int
+		wantChannel string
+	}{
+		{name: "Default", header: "", wantStatus: http.StatusOK, wantChannel: channel.Web},
+		{name: "App", header: "App", wantStatus: http.StatusOK, wantChannel: channel.App},
+		{name: "Wholesale", header: "wholesale", wantStatus: http.StatusOK, wantChannel: channel.Wholesale},
+		{name: "Unknown", header: "kiosk", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(SalesChannel())
+			router.GET("/ping", func(c *gin.Context) {
+				assert.Equal(t, tt.wantChannel, channel.FromContext(c.Request.Context()))
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			if tt.header != "" {
+				req.Header.Set(ChannelHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
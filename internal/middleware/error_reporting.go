@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/pkg/errreport"
+)
+
+// ErrorReporting sends panics and 5xx responses to the reporter. It must run inside
+// gin.Recovery: panics are reported and re-raised so Recovery still writes the 500.
+func ErrorReporting(reporter errreport.Reporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(errreport.WithRequest(c.Request.Context(), c.Request))
+
+		defer func() {
+			if rec := recover(); rec != nil {
+				// Aborted handlers are how net/http cancels a response, not a bug.
+				if rec != http.ErrAbortHandler {
+					reporter.CapturePanic(c.Request.Context(), rec, routeTags(c, http.StatusInternalServerError))
+				}
+				panic(rec)
+			}
+		}()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status < http.StatusInternalServerError {
+			return
+		}
+		// Handlers log and hide the cause; use it when attached via c.Error.
+		err := fmt.Errorf("%s %s responded %d", c.Request.Method, route(c), status)
+		if last := c.Errors.Last(); last != nil {
+			err = last.Err
+		}
+		reporter.CaptureError(c.Request.Context(), err, routeTags(c, status))
+	}
+}
+
+// route prefers the registered pattern so reports group by endpoint, not by ID.
+func route(c *gin.Context) string {
+	if r := c.FullPath(); r != "" {
+		return r
+	}
+	return c.Request.URL.Path
+}
+
+func routeTags(c *gin.Context, status int) map[string]string {
+	return map[string]string{
+		"http.method": c.Request.Method,
+		"http.route":  route(c),
+		"http.status": strconv.Itoa(status),
+	}
+}
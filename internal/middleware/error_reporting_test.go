@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestErrorReporting(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		handler    gin.HandlerFunc
+		mockSetup  func(m *mocks.MockReporter)
+		wantStatus int
+	}{
+		{
+			name:       "SuccessNotReported",
+			handler:    func(c *gin.Context) { c.Status(http.StatusOK) },
+			mockSetup:  func(m *mocks.MockReporter) {},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "ClientErrorNotReported",
+			handler:    func(c *gin.Context) { c.Status(http.StatusNotFound) },
+			mockSetup:  func(m *mocks.MockReporter) {},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:    "ServerErrorReported",
+			handler: func(c *gin.Context) { c.Status(http.StatusServiceUnavailable) },
+			mockSetup: func(m *mocks.MockReporter) {
+				m.EXPECT().CaptureError(gomock.Any(), gomock.Any(), map[string]string{
+					"http.method": http.MethodGet,
+					"http.route":  "/items/:id",
+					"http.status": "503",
+				}).Do(func(_ any, err error, _ any) {
+					assert.EqualError(t, err, "GET /items/:id responded 503")
+				})
+			},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "AttachedErrorPreferred",
+			handler: func(c *gin.Context) {
+				_ = c.Error(errors.New("db timeout"))
+				c.Status(http.StatusInternalServerError)
+			},
+			mockSetup: func(m *mocks.MockReporter) {
+				m.EXPECT().CaptureError(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(_ any, err error, _ any) {
+					assert.EqualError(t, err, "db timeout")
+				})
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:    "PanicReportedAndRecovered",
+			handler: func(c *gin.Context) { panic("nil map write") },
+			mockSetup: func(m *mocks.MockReporter) {
+				m.EXPECT().CapturePanic(gomock.Any(), "nil map write", gomock.Any())
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockReporter := mocks.NewMockReporter(ctrl)
+			tt.mockSetup(mockReporter)
+
+			router := gin.New()
+			router.Use(gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, _ any) {
+				c.AbortWithStatus(http.StatusInternalServerError)
+			}), ErrorReporting(mockReporter))
+			router.GET("/items/:id", tt.handler)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items/7", nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
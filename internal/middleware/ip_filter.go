@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// IPFilter rejects requests from client IPs matched by a deny rule (and no allow rule).
+// It fails open: if the rule store is unavailable the request proceeds, so a Redis
+// outage does not take the whole API down with it.
+func IPFilter(filter service.IPFilterService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		blocked, err := filter.IsBlocked(c.Request.Context(), c.ClientIP())
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "IP filter check failed", "client_ip", c.ClientIP(), logger.Err(err))
+			c.Next()
+			return
+		}
+		if blocked {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"code": http.StatusForbidden, "message": "access denied"})
+			return
+		}
+		c.Next()
+	}
+}
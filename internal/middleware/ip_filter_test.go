@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestIPFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		mockSetup  func(m *mocks.MockIPFilterService)
+		wantStatus int
+	}{
+		{
+			name: "Allowed",
+			mockSetup: func(m *mocks.MockIPFilterService) {
+				m.EXPECT().IsBlocked(gomock.Any(), "203.0.113.7").Return(false, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "Denied",
+			mockSetup: func(m *mocks.MockIPFilterService) {
+				m.EXPECT().IsBlocked(gomock.Any(), "203.0.113.7").Return(true, nil)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "StoreErrorFailsOpen",
+			mockSetup: func(m *mocks.MockIPFilterService) {
+				m.EXPECT().IsBlocked(gomock.Any(), "203.0.113.7").Return(false, errors.New("redis down"))
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockFilter := mocks.NewMockIPFilterService(ctrl)
+			tt.mockSetup(mockFilter)
+
+			router := gin.New()
+			router.Use(IPFilter(mockFilter))
+			router.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			req.RemoteAddr = "203.0.113.7:54321"
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/validation"
+)
+
+// OpenAPIValidator builds a middleware that rejects requests whose parameters or body
+// do not match the generated Swagger 2.0 spec, keeping handlers and docs from drifting apart.
+// Authentication is left to AuthMiddleware; only the request shape is checked here.
+func OpenAPIValidator(swaggerJSON []byte) (gin.HandlerFunc, error) {
+	var doc2 openapi2.T
+	if err := json.Unmarshal(swaggerJSON, &doc2); err != nil {
+		return nil, fmt.Errorf("failed to parse swagger spec: %w", err)
+	}
+
+	doc, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert swagger spec to openapi3: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid openapi spec: %w", err)
+	}
+
+	// The converted spec carries basePath as a relative server URL, which kin-openapi
+	// cannot match against server-side requests. Fold it into the paths instead.
+	if doc2.BasePath != "" {
+		paths := openapi3.NewPaths()
+		for path, item := range doc.Paths.Map() {
+			paths.Set(strings.TrimSuffix(doc2.BasePath, "/")+path, item)
+		}
+		doc.Paths = paths
+		doc.Servers = nil
+	}
+
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build openapi router: %w", err)
+	}
+
+	options := &openapi3filter.Options{
+		MultiError:         true,
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(c *gin.Context) {
+		route, pathParams, err := router.FindRoute(c.Request)
+		if err != nil {
+			// Undocumented routes are caught by the router drift test; do not block traffic here.
+			slog.WarnContext(c.Request.Context(), "OpenAPI: no spec entry for route", "method", c.Request.Method, "path", c.Request.URL.Path, logger.Err(err))
+			c.Next()
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		}
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": "request does not match API specification",
+				"errors":  specFieldErrors(err),
+			})
+			return
+		}
+		c.Next()
+	}, nil
+}
+
+// specFieldErrors flattens kin-openapi errors into the same {field, rule, message}
+// shape used for binding validation failures.
+func specFieldErrors(err error) []validation.FieldError {
+	var multi openapi3.MultiError
+	if !errors.As(err, &multi) {
+		multi = openapi3.MultiError{err}
+	}
+
+	fieldErrs := make([]validation.FieldError, 0, len(multi))
+	for _, e := range multi {
+		fieldErrs = append(fieldErrs, specFieldError(e))
+	}
+	return fieldErrs
+}
+
+func specFieldError(err error) validation.FieldError {
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		field := strings.Join(schemaErr.JSONPointer(), ".")
+		var reqErr *openapi3filter.RequestError
+		if errors.As(err, &reqErr) && reqErr.Parameter != nil {
+			field = reqErr.Parameter.Name
+		}
+		return validation.FieldError{Field: field, Rule: schemaErr.SchemaField, Message: schemaErr.Reason}
+	}
+
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) {
+		field := "body"
+		if reqErr.Parameter != nil {
+			field = reqErr.Parameter.Name
+		}
+		return validation.FieldError{Field: field, Rule: "openapi", Message: reqErr.Reason}
+	}
+
+	return validation.FieldError{Field: "request", Rule: "openapi", Message: "request is invalid"}
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/api/swagger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPIValidator(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	validator, err := OpenAPIValidator([]byte(swagger.SwaggerInfo.ReadDoc()))
+	require.NoError(t, err)
+
+	engine := gin.New()
+	v1 := engine.Group("/api/v1", validator)
+	v1.POST("/users/login", func(c *gin.Context) { c.Status(http.StatusOK) })
+	v1.GET("/products", func(c *gin.Context) { c.Status(http.StatusOK) })
+	v1.GET("/undocumented", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantField  string
+	}{
+		{
+			name:       "ValidBody",
+			method:     http.MethodPost,
+			path:       "/api/v1/users/login",
+			body:       `{"username":"alice","password":"secret123"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "MissingRequiredProperty",
+			method:     http.MethodPost,
+			path:       "/api/v1/users/login",
+			body:       `{"username":"alice"}`,
+			wantStatus: http.StatusBadRequest,
+			wantField:  "password",
+		},
+		{
+			name:       "WrongPropertyType",
+			method:     http.MethodPost,
+			path:       "/api/v1/users/login",
+			body:       `{"username":42,"password":"secret123"}`,
+			wantStatus: http.StatusBadRequest,
+			wantField:  "username",
+		},
+		{
+			name:       "QueryOutOfRange",
+			method:     http.MethodGet,
+			path:       "/api/v1/products?limit=1000",
+			wantStatus: http.StatusBadRequest,
+			wantField:  "limit",
+		},
+		{
+			name:       "UndocumentedRoutePassesThrough",
+			method:     http.MethodGet,
+			path:       "/api/v1/undocumented",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			require.NoError(t, err)
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+
+			engine.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantField != "" {
+				var resp struct {
+					Errors []struct {
+						Field string `json:"field"`
+					} `json:"errors"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				require.NotEmpty(t, resp.Errors)
+				assert.Equal(t, tt.wantField, resp.Errors[0].Field)
+			}
+		})
+	}
+}
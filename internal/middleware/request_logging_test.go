@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		incoming string
+		wantSame bool
+	}{
+		{name: "Generated", incoming: ""},
+		{name: "PropagatedFromClient", incoming: "edge-7f3a.1", wantSame: true},
+		{name: "UnsafeValueReplaced", incoming: "bad id\nwith newline"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ctxID string
+			router := gin.New()
+			router.Use(RequestID())
+			router.GET("/ping", func(c *gin.Context) {
+				ctxID = logger.RequestIDFromContext(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			got := w.Header().Get(RequestIDHeader)
+			assert.Equal(t, got, ctxID, "response header and log context must agree")
+			if tt.wantSame {
+				assert.Equal(t, tt.incoming, got)
+			} else {
+				_, err := uuid.Parse(got)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
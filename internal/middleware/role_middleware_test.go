@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		roles      []string
+		payload    *token.Payload
+		wantStatus int
+	}{
+		{name: "Admin", roles: []string{model.RoleAdmin}, payload: &token.Payload{UserID: 7, Role: model.RoleAdmin}, wantStatus: http.StatusOK},
+		{name: "AnyOfRoles", roles: []string{"support", model.RoleAdmin}, payload: &token.Payload{UserID: 7, Role: "support"}, wantStatus: http.StatusOK},
+		{name: "RegularUser", roles: []string{model.RoleAdmin}, payload: &token.Payload{UserID: 7, Role: model.RoleUser}, wantStatus: http.StatusForbidden},
+		{name: "TokenWithoutRole", roles: []string{model.RoleAdmin}, payload: &token.Payload{UserID: 7}, wantStatus: http.StatusForbidden},
+		{name: "AdminOfOtherStore", roles: []string{model.RoleAdmin}, payload: &token.Payload{UserID: 7, StoreID: 3, Role: model.RoleAdmin}, wantStatus: http.StatusForbidden},
+		{name: "NoRolesAllowed", payload: &token.Payload{UserID: 7, Role: model.RoleAdmin}, wantStatus: http.StatusForbidden},
+		{name: "NotAuthenticated", roles: []string{model.RoleAdmin}, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/products", func(c *gin.Context) {
+				if tt.payload != nil {
+					c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), tt.payload))
+				}
+				c.Next()
+			}, RequireRoles(tt.roles...), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/products", nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Switchable runs h only while enabled is true, so a feature can be toggled
+// by a config reload without rebuilding the route table.
+func Switchable(enabled *atomic.Bool, h gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enabled.Load() {
+			c.Next()
+			return
+		}
+		h(c)
+	}
+}
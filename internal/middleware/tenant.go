@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/tenant"
+)
+
+// DefaultStoreHeader names the store a request is for, by slug, when the
+// host does not decide it.
+const DefaultStoreHeader = "X-Store"
+
+// Tenant resolves the store of each request from the header, else the Host,
+// and serves the request for it. Unlike IPFilter it fails closed: without a
+// store, data would not be scoped.
+func Tenant(stores service.StoreService, header string) gin.HandlerFunc {
+	if header == "" {
+		header = DefaultStoreHeader
+	}
+	return func(c *gin.Context) {
+		store, err := stores.Resolve(c.Request.Context(), c.GetHeader(header), c.Request.Host)
+		if errors.Is(err, service.ErrStoreNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": "unknown store"})
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to resolve store", "host", c.Request.Host, logger.Err(err))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"code": http.StatusServiceUnavailable, "message": "service unavailable"})
+			return
+		}
+		c.Request = c.Request.WithContext(tenant.NewContext(c.Request.Context(), store))
+		c.Next()
+	}
+}
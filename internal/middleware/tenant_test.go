@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/tenant"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		header     string
+		mockSetup  func(m *mocks.MockStoreService)
+		wantStatus int
+		wantStore  uint64
+	}{
+		{
+			name:   "ByHost",
+			header: "",
+			mockSetup: func(m *mocks.MockStoreService) {
+				m.EXPECT().Resolve(gomock.Any(), "", "toys.example.com").Return(&model.Store{Base: model.Base{ID: 2}, Slug: "toys"}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantStore:  2,
+		},
+		{
+			name:   "ByHeader",
+			header: "books",
+			mockSetup: func(m *mocks.MockStoreService) {
+				m.EXPECT().Resolve(gomock.Any(), "books", "toys.example.com").Return(&model.Store{Base: model.Base{ID: 1}, Slug: "books"}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantStore:  1,
+		},
+		{
+			name:   "UnknownStore",
+			header: "games",
+			mockSetup: func(m *mocks.MockStoreService) {
+				m.EXPECT().Resolve(gomock.Any(), "games", gomock.Any()).Return(nil, service.ErrStoreNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "StoresUnavailable",
+			mockSetup: func(m *mocks.MockStoreService) {
+				m.EXPECT().Resolve(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockStores := mocks.NewMockStoreService(ctrl)
+			tt.mockSetup(mockStores)
+
+			router := gin.New()
+			router.Use(Tenant(mockStores, ""))
+			router.GET("/ping", func(c *gin.Context) {
+				assert.Equal(t, tt.wantStore, tenant.StoreID(c.Request.Context()))
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "http://toys.example.com/ping", nil)
+			if tt.header != "" {
+				req.Header.Set(DefaultStoreHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
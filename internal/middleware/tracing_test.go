@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a tracer provider recording every span for the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+		_ = provider.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	tests := []struct {
+		name        string
+		path        string
+		traceParent string
+		status      int
+		wantName    string
+		wantError   bool
+	}{
+		{name: "NewTrace", path: "/orders/42", status: http.StatusOK, wantName: "GET /orders/:id"},
+		{name: "ContinuesClientTrace", path: "/orders/42", traceParent: "00-" + parentTraceID + "-00f067aa0ba902b7-01", status: http.StatusCreated, wantName: "GET /orders/:id"},
+		{name: "ServerError", path: "/orders/42", status: http.StatusBadGateway, wantName: "GET /orders/:id", wantError: true},
+		{name: "UnknownRoute", path: "/nowhere", status: http.StatusNotFound, wantName: "GET"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := recordSpans(t)
+			var logTraceID string
+			router := gin.New()
+			router.Use(Tracing())
+			router.GET("/orders/:id", func(c *gin.Context) {
+				logTraceID = logger.TraceIDFromContext(c.Request.Context())
+				c.Status(tt.status)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.traceParent != "" {
+				req.Header.Set("traceparent", tt.traceParent)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			span := spans[0]
+			assert.Equal(t, tt.wantName, span.Name())
+			assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+			assert.Contains(t, span.Attributes(), attribute.Int("http.response.status_code", tt.status))
+			if tt.wantError {
+				assert.Equal(t, codes.Error, span.Status().Code)
+			} else {
+				assert.Equal(t, codes.Unset, span.Status().Code)
+			}
+			if tt.traceParent != "" {
+				assert.Equal(t, parentTraceID, span.SpanContext().TraceID().String())
+				assert.True(t, span.Parent().IsRemote())
+			}
+			if tt.path != "/nowhere" {
+				assert.Equal(t, span.SpanContext().TraceID().String(), logTraceID, "handlers must log the span's trace ID")
+			}
+		})
+	}
+}
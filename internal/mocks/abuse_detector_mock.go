@@ -0,0 +1,97 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/abuse_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/abuse_service.go -destination=internal/mocks/abuse_detector_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAbuseDetector is a mock of AbuseDetector interface.
+type MockAbuseDetector struct {
+	ctrl     *gomock.Controller
+	recorder *MockAbuseDetectorMockRecorder
+	isgomock struct{}
+}
+
+// MockAbuseDetectorMockRecorder is the mock recorder for MockAbuseDetector.
+type MockAbuseDetectorMockRecorder struct {
+	mock *MockAbuseDetector
+}
+
+// NewMockAbuseDetector creates a new mock instance.
+func NewMockAbuseDetector(ctrl *gomock.Controller) *MockAbuseDetector {
+	mock := &MockAbuseDetector{ctrl: ctrl}
+	mock.recorder = &MockAbuseDetectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAbuseDetector) EXPECT() *MockAbuseDetectorMockRecorder {
+	return m.recorder
+}
+
+// ChallengeRequired mocks base method.
+func (m *MockAbuseDetector) ChallengeRequired(ctx context.Context, scope, ip string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChallengeRequired", ctx, scope, ip)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChallengeRequired indicates an expected call of ChallengeRequired.
+func (mr *MockAbuseDetectorMockRecorder) ChallengeRequired(ctx, scope, ip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChallengeRequired", reflect.TypeOf((*MockAbuseDetector)(nil).ChallengeRequired), ctx, scope, ip)
+}
+
+// RecordFailure mocks base method.
+func (m *MockAbuseDetector) RecordFailure(ctx context.Context, scope, ip string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordFailure", ctx, scope, ip)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordFailure indicates an expected call of RecordFailure.
+func (mr *MockAbuseDetectorMockRecorder) RecordFailure(ctx, scope, ip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordFailure", reflect.TypeOf((*MockAbuseDetector)(nil).RecordFailure), ctx, scope, ip)
+}
+
+// Reset mocks base method.
+func (m *MockAbuseDetector) Reset(ctx context.Context, scope, ip string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", ctx, scope, ip)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockAbuseDetectorMockRecorder) Reset(ctx, scope, ip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockAbuseDetector)(nil).Reset), ctx, scope, ip)
+}
+
+// SetLimits mocks base method.
+func (m *MockAbuseDetector) SetLimits(maxFailures int, window time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetLimits", maxFailures, window)
+}
+
+// SetLimits indicates an expected call of SetLimits.
+func (mr *MockAbuseDetectorMockRecorder) SetLimits(maxFailures, window any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLimits", reflect.TypeOf((*MockAbuseDetector)(nil).SetLimits), maxFailures, window)
+}
@@ -0,0 +1,58 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/address_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/address_service.go -destination=internal/mocks/address_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAddressService is a mock of AddressService interface.
+type MockAddressService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAddressServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockAddressServiceMockRecorder is the mock recorder for MockAddressService.
+type MockAddressServiceMockRecorder struct {
+	mock *MockAddressService
+}
+
+// NewMockAddressService creates a new mock instance.
+func NewMockAddressService(ctrl *gomock.Controller) *MockAddressService {
+	mock := &MockAddressService{ctrl: ctrl}
+	mock.recorder = &MockAddressServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAddressService) EXPECT() *MockAddressServiceMockRecorder {
+	return m.recorder
+}
+
+// Validate mocks base method.
+func (m *MockAddressService) Validate(ctx context.Context, region string, addr model.Address) (*service.AddressValidationResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Validate", ctx, region, addr)
+	ret0, _ := ret[0].(*service.AddressValidationResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Validate indicates an expected call of Validate.
+func (mr *MockAddressServiceMockRecorder) Validate(ctx, region, addr any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockAddressService)(nil).Validate), ctx, region, addr)
+}
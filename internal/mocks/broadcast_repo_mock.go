@@ -0,0 +1,146 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/broadcast_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/broadcast_repo.go -destination=internal/mocks/broadcast_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBroadcastRepository is a mock of BroadcastRepository interface.
+type MockBroadcastRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockBroadcastRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockBroadcastRepositoryMockRecorder is the mock recorder for MockBroadcastRepository.
+type MockBroadcastRepositoryMockRecorder struct {
+	mock *MockBroadcastRepository
+}
+
+// NewMockBroadcastRepository creates a new mock instance.
+func NewMockBroadcastRepository(ctrl *gomock.Controller) *MockBroadcastRepository {
+	mock := &MockBroadcastRepository{ctrl: ctrl}
+	mock.recorder = &MockBroadcastRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBroadcastRepository) EXPECT() *MockBroadcastRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CountRecipients mocks base method.
+func (m *MockBroadcastRepository) CountRecipients(ctx context.Context, storeID uint64, segment *model.BroadcastSegment) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountRecipients", ctx, storeID, segment)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountRecipients indicates an expected call of CountRecipients.
+func (mr *MockBroadcastRepositoryMockRecorder) CountRecipients(ctx, storeID, segment any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRecipients", reflect.TypeOf((*MockBroadcastRepository)(nil).CountRecipients), ctx, storeID, segment)
+}
+
+// Create mocks base method.
+func (m *MockBroadcastRepository) Create(ctx context.Context, broadcast *model.Broadcast) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, broadcast)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockBroadcastRepositoryMockRecorder) Create(ctx, broadcast any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockBroadcastRepository)(nil).Create), ctx, broadcast)
+}
+
+// GetByID mocks base method.
+func (m *MockBroadcastRepository) GetByID(ctx context.Context, id uint64) (*model.Broadcast, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*model.Broadcast)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockBroadcastRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockBroadcastRepository)(nil).GetByID), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockBroadcastRepository) List(ctx context.Context, offset, limit int) ([]model.Broadcast, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, offset, limit)
+	ret0, _ := ret[0].([]model.Broadcast)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockBroadcastRepositoryMockRecorder) List(ctx, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockBroadcastRepository)(nil).List), ctx, offset, limit)
+}
+
+// ListPending mocks base method.
+func (m *MockBroadcastRepository) ListPending(ctx context.Context) ([]model.Broadcast, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPending", ctx)
+	ret0, _ := ret[0].([]model.Broadcast)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPending indicates an expected call of ListPending.
+func (mr *MockBroadcastRepositoryMockRecorder) ListPending(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPending", reflect.TypeOf((*MockBroadcastRepository)(nil).ListPending), ctx)
+}
+
+// ListRecipients mocks base method.
+func (m *MockBroadcastRepository) ListRecipients(ctx context.Context, storeID uint64, segment *model.BroadcastSegment, afterID uint64, limit int) ([]uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRecipients", ctx, storeID, segment, afterID, limit)
+	ret0, _ := ret[0].([]uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRecipients indicates an expected call of ListRecipients.
+func (mr *MockBroadcastRepositoryMockRecorder) ListRecipients(ctx, storeID, segment, afterID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRecipients", reflect.TypeOf((*MockBroadcastRepository)(nil).ListRecipients), ctx, storeID, segment, afterID, limit)
+}
+
+// SaveProgress mocks base method.
+func (m *MockBroadcastRepository) SaveProgress(ctx context.Context, broadcast *model.Broadcast) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveProgress", ctx, broadcast)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveProgress indicates an expected call of SaveProgress.
+func (mr *MockBroadcastRepositoryMockRecorder) SaveProgress(ctx, broadcast any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveProgress", reflect.TypeOf((*MockBroadcastRepository)(nil).SaveProgress), ctx, broadcast)
+}
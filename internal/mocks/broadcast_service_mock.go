@@ -0,0 +1,142 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/broadcast_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/broadcast_service.go -destination=internal/mocks/broadcast_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBroadcastService is a mock of BroadcastService interface.
+type MockBroadcastService struct {
+	ctrl     *gomock.Controller
+	recorder *MockBroadcastServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockBroadcastServiceMockRecorder is the mock recorder for MockBroadcastService.
+type MockBroadcastServiceMockRecorder struct {
+	mock *MockBroadcastService
+}
+
+// NewMockBroadcastService creates a new mock instance.
+func NewMockBroadcastService(ctrl *gomock.Controller) *MockBroadcastService {
+	mock := &MockBroadcastService{ctrl: ctrl}
+	mock.recorder = &MockBroadcastServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBroadcastService) EXPECT() *MockBroadcastServiceMockRecorder {
+	return m.recorder
+}
+
+// Audience mocks base method.
+func (m *MockBroadcastService) Audience(ctx context.Context, segment *model.BroadcastSegment) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Audience", ctx, segment)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Audience indicates an expected call of Audience.
+func (mr *MockBroadcastServiceMockRecorder) Audience(ctx, segment any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Audience", reflect.TypeOf((*MockBroadcastService)(nil).Audience), ctx, segment)
+}
+
+// Create mocks base method.
+func (m *MockBroadcastService) Create(ctx context.Context, adminID uint64, req *service.BroadcastCreateReq) (*service.BroadcastResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, adminID, req)
+	ret0, _ := ret[0].(*service.BroadcastResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockBroadcastServiceMockRecorder) Create(ctx, adminID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockBroadcastService)(nil).Create), ctx, adminID, req)
+}
+
+// Get mocks base method.
+func (m *MockBroadcastService) Get(ctx context.Context, id uint64) (*service.BroadcastResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*service.BroadcastResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockBroadcastServiceMockRecorder) Get(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockBroadcastService)(nil).Get), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockBroadcastService) List(ctx context.Context, offset, limit int) (*service.BroadcastListResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, offset, limit)
+	ret0, _ := ret[0].(*service.BroadcastListResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockBroadcastServiceMockRecorder) List(ctx, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockBroadcastService)(nil).List), ctx, offset, limit)
+}
+
+// MockBroadcastDispatcher is a mock of BroadcastDispatcher interface.
+type MockBroadcastDispatcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockBroadcastDispatcherMockRecorder
+	isgomock struct{}
+}
+
+// MockBroadcastDispatcherMockRecorder is the mock recorder for MockBroadcastDispatcher.
+type MockBroadcastDispatcherMockRecorder struct {
+	mock *MockBroadcastDispatcher
+}
+
+// NewMockBroadcastDispatcher creates a new mock instance.
+func NewMockBroadcastDispatcher(ctrl *gomock.Controller) *MockBroadcastDispatcher {
+	mock := &MockBroadcastDispatcher{ctrl: ctrl}
+	mock.recorder = &MockBroadcastDispatcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBroadcastDispatcher) EXPECT() *MockBroadcastDispatcherMockRecorder {
+	return m.recorder
+}
+
+// Dispatch mocks base method.
+func (m *MockBroadcastDispatcher) Dispatch(ctx context.Context, opts service.BroadcastDispatchOptions) (*service.BroadcastDispatchReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Dispatch", ctx, opts)
+	ret0, _ := ret[0].(*service.BroadcastDispatchReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Dispatch indicates an expected call of Dispatch.
+func (mr *MockBroadcastDispatcherMockRecorder) Dispatch(ctx, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dispatch", reflect.TypeOf((*MockBroadcastDispatcher)(nil).Dispatch), ctx, opts)
+}
@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/cache_janitor.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/cache_janitor.go -destination=internal/mocks/cache_janitor_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCacheJanitor is a mock of CacheJanitor interface.
+type MockCacheJanitor struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheJanitorMockRecorder
+	isgomock struct{}
+}
+
+// MockCacheJanitorMockRecorder is the mock recorder for MockCacheJanitor.
+type MockCacheJanitorMockRecorder struct {
+	mock *MockCacheJanitor
+}
+
+// NewMockCacheJanitor creates a new mock instance.
+func NewMockCacheJanitor(ctrl *gomock.Controller) *MockCacheJanitor {
+	mock := &MockCacheJanitor{ctrl: ctrl}
+	mock.recorder = &MockCacheJanitorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCacheJanitor) EXPECT() *MockCacheJanitorMockRecorder {
+	return m.recorder
+}
+
+// Sweep mocks base method.
+func (m *MockCacheJanitor) Sweep(ctx context.Context, sweep string, dryRun bool) (*service.CacheSweepReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sweep", ctx, sweep, dryRun)
+	ret0, _ := ret[0].(*service.CacheSweepReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sweep indicates an expected call of Sweep.
+func (mr *MockCacheJanitorMockRecorder) Sweep(ctx, sweep, dryRun any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sweep", reflect.TypeOf((*MockCacheJanitor)(nil).Sweep), ctx, sweep, dryRun)
+}
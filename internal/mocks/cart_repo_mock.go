@@ -0,0 +1,160 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/cart_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/cart_repo.go -destination=internal/mocks/cart_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	repository "github.com/proyuen/go-mall/internal/repository"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCartRepository is a mock of CartRepository interface.
+type MockCartRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCartRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockCartRepositoryMockRecorder is the mock recorder for MockCartRepository.
+type MockCartRepositoryMockRecorder struct {
+	mock *MockCartRepository
+}
+
+// NewMockCartRepository creates a new mock instance.
+func NewMockCartRepository(ctrl *gomock.Controller) *MockCartRepository {
+	mock := &MockCartRepository{ctrl: ctrl}
+	mock.recorder = &MockCartRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCartRepository) EXPECT() *MockCartRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Clear mocks base method.
+func (m *MockCartRepository) Clear(ctx context.Context, userID uint64, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clear", ctx, userID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Clear indicates an expected call of Clear.
+func (mr *MockCartRepositoryMockRecorder) Clear(ctx, userID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clear", reflect.TypeOf((*MockCartRepository)(nil).Clear), ctx, userID, at)
+}
+
+// Convert mocks base method.
+func (m *MockCartRepository) Convert(ctx context.Context, userID, orderID uint64, skuIDs []uint64, at time.Time) (*model.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Convert", ctx, userID, orderID, skuIDs, at)
+	ret0, _ := ret[0].(*model.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Convert indicates an expected call of Convert.
+func (mr *MockCartRepositoryMockRecorder) Convert(ctx, userID, orderID, skuIDs, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Convert", reflect.TypeOf((*MockCartRepository)(nil).Convert), ctx, userID, orderID, skuIDs, at)
+}
+
+// GetOpen mocks base method.
+func (m *MockCartRepository) GetOpen(ctx context.Context, userID uint64) (*model.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOpen", ctx, userID)
+	ret0, _ := ret[0].(*model.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOpen indicates an expected call of GetOpen.
+func (mr *MockCartRepositoryMockRecorder) GetOpen(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOpen", reflect.TypeOf((*MockCartRepository)(nil).GetOpen), ctx, userID)
+}
+
+// ListAbandoned mocks base method.
+func (m *MockCartRepository) ListAbandoned(ctx context.Context, activeAfter, activeBefore time.Time, afterID uint64, limit int) ([]model.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAbandoned", ctx, activeAfter, activeBefore, afterID, limit)
+	ret0, _ := ret[0].([]model.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAbandoned indicates an expected call of ListAbandoned.
+func (mr *MockCartRepositoryMockRecorder) ListAbandoned(ctx, activeAfter, activeBefore, afterID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAbandoned", reflect.TypeOf((*MockCartRepository)(nil).ListAbandoned), ctx, activeAfter, activeBefore, afterID, limit)
+}
+
+// MarkRecoveryNotified mocks base method.
+func (m *MockCartRepository) MarkRecoveryNotified(ctx context.Context, id uint64, coupon string, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRecoveryNotified", ctx, id, coupon, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRecoveryNotified indicates an expected call of MarkRecoveryNotified.
+func (mr *MockCartRepositoryMockRecorder) MarkRecoveryNotified(ctx, id, coupon, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRecoveryNotified", reflect.TypeOf((*MockCartRepository)(nil).MarkRecoveryNotified), ctx, id, coupon, at)
+}
+
+// RecoveryStats mocks base method.
+func (m *MockCartRepository) RecoveryStats(ctx context.Context, since time.Time) (*repository.CartRecoveryStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecoveryStats", ctx, since)
+	ret0, _ := ret[0].(*repository.CartRecoveryStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecoveryStats indicates an expected call of RecoveryStats.
+func (mr *MockCartRepositoryMockRecorder) RecoveryStats(ctx, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecoveryStats", reflect.TypeOf((*MockCartRepository)(nil).RecoveryStats), ctx, since)
+}
+
+// RemoveItem mocks base method.
+func (m *MockCartRepository) RemoveItem(ctx context.Context, userID, skuID uint64, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveItem", ctx, userID, skuID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveItem indicates an expected call of RemoveItem.
+func (mr *MockCartRepositoryMockRecorder) RemoveItem(ctx, userID, skuID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveItem", reflect.TypeOf((*MockCartRepository)(nil).RemoveItem), ctx, userID, skuID, at)
+}
+
+// SetItem mocks base method.
+func (m *MockCartRepository) SetItem(ctx context.Context, userID, skuID uint64, quantity int, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetItem", ctx, userID, skuID, quantity, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetItem indicates an expected call of SetItem.
+func (mr *MockCartRepositoryMockRecorder) SetItem(ctx, userID, skuID, quantity, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetItem", reflect.TypeOf((*MockCartRepository)(nil).SetItem), ctx, userID, skuID, quantity, at)
+}
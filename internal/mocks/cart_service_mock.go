@@ -0,0 +1,170 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/cart_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/cart_service.go -destination=internal/mocks/cart_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCartService is a mock of CartService interface.
+type MockCartService struct {
+	ctrl     *gomock.Controller
+	recorder *MockCartServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockCartServiceMockRecorder is the mock recorder for MockCartService.
+type MockCartServiceMockRecorder struct {
+	mock *MockCartService
+}
+
+// NewMockCartService creates a new mock instance.
+func NewMockCartService(ctrl *gomock.Controller) *MockCartService {
+	mock := &MockCartService{ctrl: ctrl}
+	mock.recorder = &MockCartServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCartService) EXPECT() *MockCartServiceMockRecorder {
+	return m.recorder
+}
+
+// Clear mocks base method.
+func (m *MockCartService) Clear(ctx context.Context, userID uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clear", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Clear indicates an expected call of Clear.
+func (mr *MockCartServiceMockRecorder) Clear(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clear", reflect.TypeOf((*MockCartService)(nil).Clear), ctx, userID)
+}
+
+// Convert mocks base method.
+func (m *MockCartService) Convert(ctx context.Context, userID, orderID uint64, skuIDs []uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Convert", ctx, userID, orderID, skuIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Convert indicates an expected call of Convert.
+func (mr *MockCartServiceMockRecorder) Convert(ctx, userID, orderID, skuIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Convert", reflect.TypeOf((*MockCartService)(nil).Convert), ctx, userID, orderID, skuIDs)
+}
+
+// Get mocks base method.
+func (m *MockCartService) Get(ctx context.Context, userID uint64) (*service.CartResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, userID)
+	ret0, _ := ret[0].(*service.CartResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockCartServiceMockRecorder) Get(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCartService)(nil).Get), ctx, userID)
+}
+
+// RecoveryStats mocks base method.
+func (m *MockCartService) RecoveryStats(ctx context.Context, since time.Time) (*service.CartRecoveryStatsResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecoveryStats", ctx, since)
+	ret0, _ := ret[0].(*service.CartRecoveryStatsResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecoveryStats indicates an expected call of RecoveryStats.
+func (mr *MockCartServiceMockRecorder) RecoveryStats(ctx, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecoveryStats", reflect.TypeOf((*MockCartService)(nil).RecoveryStats), ctx, since)
+}
+
+// RemoveItem mocks base method.
+func (m *MockCartService) RemoveItem(ctx context.Context, userID, skuID uint64) (*service.CartResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveItem", ctx, userID, skuID)
+	ret0, _ := ret[0].(*service.CartResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveItem indicates an expected call of RemoveItem.
+func (mr *MockCartServiceMockRecorder) RemoveItem(ctx, userID, skuID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveItem", reflect.TypeOf((*MockCartService)(nil).RemoveItem), ctx, userID, skuID)
+}
+
+// SetItem mocks base method.
+func (m *MockCartService) SetItem(ctx context.Context, userID, skuID uint64, quantity int) (*service.CartResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetItem", ctx, userID, skuID, quantity)
+	ret0, _ := ret[0].(*service.CartResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetItem indicates an expected call of SetItem.
+func (mr *MockCartServiceMockRecorder) SetItem(ctx, userID, skuID, quantity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetItem", reflect.TypeOf((*MockCartService)(nil).SetItem), ctx, userID, skuID, quantity)
+}
+
+// MockCartRecovery is a mock of CartRecovery interface.
+type MockCartRecovery struct {
+	ctrl     *gomock.Controller
+	recorder *MockCartRecoveryMockRecorder
+	isgomock struct{}
+}
+
+// MockCartRecoveryMockRecorder is the mock recorder for MockCartRecovery.
+type MockCartRecoveryMockRecorder struct {
+	mock *MockCartRecovery
+}
+
+// NewMockCartRecovery creates a new mock instance.
+func NewMockCartRecovery(ctrl *gomock.Controller) *MockCartRecovery {
+	mock := &MockCartRecovery{ctrl: ctrl}
+	mock.recorder = &MockCartRecoveryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCartRecovery) EXPECT() *MockCartRecoveryMockRecorder {
+	return m.recorder
+}
+
+// RecoverAbandoned mocks base method.
+func (m *MockCartRecovery) RecoverAbandoned(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecoverAbandoned", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecoverAbandoned indicates an expected call of RecoverAbandoned.
+func (mr *MockCartRecoveryMockRecorder) RecoverAbandoned(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecoverAbandoned", reflect.TypeOf((*MockCartRecovery)(nil).RecoverAbandoned), ctx)
+}
@@ -0,0 +1,162 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/catalog_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/catalog_service.go -destination=internal/mocks/catalog_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCatalogService is a mock of CatalogService interface.
+type MockCatalogService struct {
+	ctrl     *gomock.Controller
+	recorder *MockCatalogServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockCatalogServiceMockRecorder is the mock recorder for MockCatalogService.
+type MockCatalogServiceMockRecorder struct {
+	mock *MockCatalogService
+}
+
+// NewMockCatalogService creates a new mock instance.
+func NewMockCatalogService(ctrl *gomock.Controller) *MockCatalogService {
+	mock := &MockCatalogService{ctrl: ctrl}
+	mock.recorder = &MockCatalogServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCatalogService) EXPECT() *MockCatalogServiceMockRecorder {
+	return m.recorder
+}
+
+// CategoriesByIDs mocks base method.
+func (m *MockCatalogService) CategoriesByIDs(ctx context.Context, ids []uint64) (map[uint64]service.CategoryResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CategoriesByIDs", ctx, ids)
+	ret0, _ := ret[0].(map[uint64]service.CategoryResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CategoriesByIDs indicates an expected call of CategoriesByIDs.
+func (mr *MockCatalogServiceMockRecorder) CategoriesByIDs(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CategoriesByIDs", reflect.TypeOf((*MockCatalogService)(nil).CategoriesByIDs), ctx, ids)
+}
+
+// GetProduct mocks base method.
+func (m *MockCatalogService) GetProduct(ctx context.Context, id uint64) (*service.CatalogProduct, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProduct", ctx, id)
+	ret0, _ := ret[0].(*service.CatalogProduct)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProduct indicates an expected call of GetProduct.
+func (mr *MockCatalogServiceMockRecorder) GetProduct(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProduct", reflect.TypeOf((*MockCatalogService)(nil).GetProduct), ctx, id)
+}
+
+// GetProfile mocks base method.
+func (m *MockCatalogService) GetProfile(ctx context.Context, userID uint64) (*service.UserProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfile", ctx, userID)
+	ret0, _ := ret[0].(*service.UserProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfile indicates an expected call of GetProfile.
+func (mr *MockCatalogServiceMockRecorder) GetProfile(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfile", reflect.TypeOf((*MockCatalogService)(nil).GetProfile), ctx, userID)
+}
+
+// ListCategories mocks base method.
+func (m *MockCatalogService) ListCategories(ctx context.Context) ([]service.CategoryResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCategories", ctx)
+	ret0, _ := ret[0].([]service.CategoryResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCategories indicates an expected call of ListCategories.
+func (mr *MockCatalogServiceMockRecorder) ListCategories(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCategories", reflect.TypeOf((*MockCatalogService)(nil).ListCategories), ctx)
+}
+
+// ListProducts mocks base method.
+func (m *MockCatalogService) ListProducts(ctx context.Context, offset, limit int) ([]service.CatalogProduct, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListProducts", ctx, offset, limit)
+	ret0, _ := ret[0].([]service.CatalogProduct)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListProducts indicates an expected call of ListProducts.
+func (mr *MockCatalogServiceMockRecorder) ListProducts(ctx, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProducts", reflect.TypeOf((*MockCatalogService)(nil).ListProducts), ctx, offset, limit)
+}
+
+// ReviewsByProductIDs mocks base method.
+func (m *MockCatalogService) ReviewsByProductIDs(ctx context.Context, productIDs []uint64, perProduct int) (map[uint64][]service.ReviewResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReviewsByProductIDs", ctx, productIDs, perProduct)
+	ret0, _ := ret[0].(map[uint64][]service.ReviewResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReviewsByProductIDs indicates an expected call of ReviewsByProductIDs.
+func (mr *MockCatalogServiceMockRecorder) ReviewsByProductIDs(ctx, productIDs, perProduct any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReviewsByProductIDs", reflect.TypeOf((*MockCatalogService)(nil).ReviewsByProductIDs), ctx, productIDs, perProduct)
+}
+
+// SKUsByProductIDs mocks base method.
+func (m *MockCatalogService) SKUsByProductIDs(ctx context.Context, productIDs []uint64) (map[uint64][]service.SKUResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SKUsByProductIDs", ctx, productIDs)
+	ret0, _ := ret[0].(map[uint64][]service.SKUResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SKUsByProductIDs indicates an expected call of SKUsByProductIDs.
+func (mr *MockCatalogServiceMockRecorder) SKUsByProductIDs(ctx, productIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SKUsByProductIDs", reflect.TypeOf((*MockCatalogService)(nil).SKUsByProductIDs), ctx, productIDs)
+}
+
+// UsernamesByIDs mocks base method.
+func (m *MockCatalogService) UsernamesByIDs(ctx context.Context, userIDs []uint64) (map[uint64]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UsernamesByIDs", ctx, userIDs)
+	ret0, _ := ret[0].(map[uint64]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UsernamesByIDs indicates an expected call of UsernamesByIDs.
+func (mr *MockCatalogServiceMockRecorder) UsernamesByIDs(ctx, userIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UsernamesByIDs", reflect.TypeOf((*MockCatalogService)(nil).UsernamesByIDs), ctx, userIDs)
+}
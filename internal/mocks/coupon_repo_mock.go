@@ -0,0 +1,175 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/coupon_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/coupon_repo.go -destination=internal/mocks/coupon_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCouponRepository is a mock of CouponRepository interface.
+type MockCouponRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCouponRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockCouponRepositoryMockRecorder is the mock recorder for MockCouponRepository.
+type MockCouponRepositoryMockRecorder struct {
+	mock *MockCouponRepository
+}
+
+// NewMockCouponRepository creates a new mock instance.
+func NewMockCouponRepository(ctrl *gomock.Controller) *MockCouponRepository {
+	mock := &MockCouponRepository{ctrl: ctrl}
+	mock.recorder = &MockCouponRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCouponRepository) EXPECT() *MockCouponRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateCampaign mocks base method.
+func (m *MockCouponRepository) CreateCampaign(ctx context.Context, campaign *model.CouponCampaign) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCampaign", ctx, campaign)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCampaign indicates an expected call of CreateCampaign.
+func (mr *MockCouponRepositoryMockRecorder) CreateCampaign(ctx, campaign any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCampaign", reflect.TypeOf((*MockCouponRepository)(nil).CreateCampaign), ctx, campaign)
+}
+
+// CreateCoupons mocks base method.
+func (m *MockCouponRepository) CreateCoupons(ctx context.Context, coupons []model.Coupon) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCoupons", ctx, coupons)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCoupons indicates an expected call of CreateCoupons.
+func (mr *MockCouponRepositoryMockRecorder) CreateCoupons(ctx, coupons any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCoupons", reflect.TypeOf((*MockCouponRepository)(nil).CreateCoupons), ctx, coupons)
+}
+
+// ExistingCodes mocks base method.
+func (m *MockCouponRepository) ExistingCodes(ctx context.Context, codes []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExistingCodes", ctx, codes)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExistingCodes indicates an expected call of ExistingCodes.
+func (mr *MockCouponRepositoryMockRecorder) ExistingCodes(ctx, codes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExistingCodes", reflect.TypeOf((*MockCouponRepository)(nil).ExistingCodes), ctx, codes)
+}
+
+// GetByCode mocks base method.
+func (m *MockCouponRepository) GetByCode(ctx context.Context, code string) (*model.Coupon, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByCode", ctx, code)
+	ret0, _ := ret[0].(*model.Coupon)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByCode indicates an expected call of GetByCode.
+func (mr *MockCouponRepositoryMockRecorder) GetByCode(ctx, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByCode", reflect.TypeOf((*MockCouponRepository)(nil).GetByCode), ctx, code)
+}
+
+// GetCampaign mocks base method.
+func (m *MockCouponRepository) GetCampaign(ctx context.Context, id uint64) (*model.CouponCampaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCampaign", ctx, id)
+	ret0, _ := ret[0].(*model.CouponCampaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCampaign indicates an expected call of GetCampaign.
+func (mr *MockCouponRepositoryMockRecorder) GetCampaign(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCampaign", reflect.TypeOf((*MockCouponRepository)(nil).GetCampaign), ctx, id)
+}
+
+// ListCampaigns mocks base method.
+func (m *MockCouponRepository) ListCampaigns(ctx context.Context) ([]model.CouponCampaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCampaigns", ctx)
+	ret0, _ := ret[0].([]model.CouponCampaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCampaigns indicates an expected call of ListCampaigns.
+func (mr *MockCouponRepositoryMockRecorder) ListCampaigns(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCampaigns", reflect.TypeOf((*MockCouponRepository)(nil).ListCampaigns), ctx)
+}
+
+// ListCoupons mocks base method.
+func (m *MockCouponRepository) ListCoupons(ctx context.Context, campaignID, afterID uint64, limit int) ([]model.Coupon, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCoupons", ctx, campaignID, afterID, limit)
+	ret0, _ := ret[0].([]model.Coupon)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCoupons indicates an expected call of ListCoupons.
+func (mr *MockCouponRepositoryMockRecorder) ListCoupons(ctx, campaignID, afterID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCoupons", reflect.TypeOf((*MockCouponRepository)(nil).ListCoupons), ctx, campaignID, afterID, limit)
+}
+
+// Redeem mocks base method.
+func (m *MockCouponRepository) Redeem(ctx context.Context, code string, orderID, userID uint64, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Redeem", ctx, code, orderID, userID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Redeem indicates an expected call of Redeem.
+func (mr *MockCouponRepositoryMockRecorder) Redeem(ctx, code, orderID, userID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redeem", reflect.TypeOf((*MockCouponRepository)(nil).Redeem), ctx, code, orderID, userID, at)
+}
+
+// RollupStats mocks base method.
+func (m *MockCouponRepository) RollupStats(ctx context.Context, at time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollupStats", ctx, at)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RollupStats indicates an expected call of RollupStats.
+func (mr *MockCouponRepositoryMockRecorder) RollupStats(ctx, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollupStats", reflect.TypeOf((*MockCouponRepository)(nil).RollupStats), ctx, at)
+}
@@ -0,0 +1,117 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/coupon_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/coupon_service.go -destination=internal/mocks/coupon_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCouponService is a mock of CouponService interface.
+type MockCouponService struct {
+	ctrl     *gomock.Controller
+	recorder *MockCouponServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockCouponServiceMockRecorder is the mock recorder for MockCouponService.
+type MockCouponServiceMockRecorder struct {
+	mock *MockCouponService
+}
+
+// NewMockCouponService creates a new mock instance.
+func NewMockCouponService(ctrl *gomock.Controller) *MockCouponService {
+	mock := &MockCouponService{ctrl: ctrl}
+	mock.recorder = &MockCouponServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCouponService) EXPECT() *MockCouponServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateCampaign mocks base method.
+func (m *MockCouponService) CreateCampaign(ctx context.Context, req *service.CouponCampaignCreateReq) (*service.CouponCampaignResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCampaign", ctx, req)
+	ret0, _ := ret[0].(*service.CouponCampaignResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCampaign indicates an expected call of CreateCampaign.
+func (mr *MockCouponServiceMockRecorder) CreateCampaign(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCampaign", reflect.TypeOf((*MockCouponService)(nil).CreateCampaign), ctx, req)
+}
+
+// ExportCodes mocks base method.
+func (m *MockCouponService) ExportCodes(ctx context.Context, campaignID uint64, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportCodes", ctx, campaignID, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportCodes indicates an expected call of ExportCodes.
+func (mr *MockCouponServiceMockRecorder) ExportCodes(ctx, campaignID, w any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportCodes", reflect.TypeOf((*MockCouponService)(nil).ExportCodes), ctx, campaignID, w)
+}
+
+// GenerateCodes mocks base method.
+func (m *MockCouponService) GenerateCodes(ctx context.Context, campaignID uint64, count int, prefix string) (*service.CouponBatchResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateCodes", ctx, campaignID, count, prefix)
+	ret0, _ := ret[0].(*service.CouponBatchResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateCodes indicates an expected call of GenerateCodes.
+func (mr *MockCouponServiceMockRecorder) GenerateCodes(ctx, campaignID, count, prefix any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateCodes", reflect.TypeOf((*MockCouponService)(nil).GenerateCodes), ctx, campaignID, count, prefix)
+}
+
+// ListCampaigns mocks base method.
+func (m *MockCouponService) ListCampaigns(ctx context.Context) ([]service.CouponCampaignResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCampaigns", ctx)
+	ret0, _ := ret[0].([]service.CouponCampaignResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCampaigns indicates an expected call of ListCampaigns.
+func (mr *MockCouponServiceMockRecorder) ListCampaigns(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCampaigns", reflect.TypeOf((*MockCouponService)(nil).ListCampaigns), ctx)
+}
+
+// RollupStats mocks base method.
+func (m *MockCouponService) RollupStats(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollupStats", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RollupStats indicates an expected call of RollupStats.
+func (mr *MockCouponServiceMockRecorder) RollupStats(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollupStats", reflect.TypeOf((*MockCouponService)(nil).RollupStats), ctx)
+}
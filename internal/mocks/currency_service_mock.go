@@ -0,0 +1,158 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/currency_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/currency_service.go -destination=internal/mocks/currency_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCurrencyService is a mock of CurrencyService interface.
+type MockCurrencyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockCurrencyServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockCurrencyServiceMockRecorder is the mock recorder for MockCurrencyService.
+type MockCurrencyServiceMockRecorder struct {
+	mock *MockCurrencyService
+}
+
+// NewMockCurrencyService creates a new mock instance.
+func NewMockCurrencyService(ctrl *gomock.Controller) *MockCurrencyService {
+	mock := &MockCurrencyService{ctrl: ctrl}
+	mock.recorder = &MockCurrencyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCurrencyService) EXPECT() *MockCurrencyServiceMockRecorder {
+	return m.recorder
+}
+
+// Base mocks base method.
+func (m *MockCurrencyService) Base() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Base")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Base indicates an expected call of Base.
+func (mr *MockCurrencyServiceMockRecorder) Base() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Base", reflect.TypeOf((*MockCurrencyService)(nil).Base))
+}
+
+// ConvertSKUs mocks base method.
+func (m *MockCurrencyService) ConvertSKUs(ctx context.Context, skus []service.SKUResp, currency string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConvertSKUs", ctx, skus, currency)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConvertSKUs indicates an expected call of ConvertSKUs.
+func (mr *MockCurrencyServiceMockRecorder) ConvertSKUs(ctx, skus, currency any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConvertSKUs", reflect.TypeOf((*MockCurrencyService)(nil).ConvertSKUs), ctx, skus, currency)
+}
+
+// IsSupported mocks base method.
+func (m *MockCurrencyService) IsSupported(currency string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsSupported", currency)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsSupported indicates an expected call of IsSupported.
+func (mr *MockCurrencyServiceMockRecorder) IsSupported(currency any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSupported", reflect.TypeOf((*MockCurrencyService)(nil).IsSupported), currency)
+}
+
+// List mocks base method.
+func (m *MockCurrencyService) List(ctx context.Context) (*service.CurrenciesResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].(*service.CurrenciesResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockCurrencyServiceMockRecorder) List(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockCurrencyService)(nil).List), ctx)
+}
+
+// Rates mocks base method.
+func (m *MockCurrencyService) Rates(ctx context.Context) (*service.Rates, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rates", ctx)
+	ret0, _ := ret[0].(*service.Rates)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rates indicates an expected call of Rates.
+func (mr *MockCurrencyServiceMockRecorder) Rates(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rates", reflect.TypeOf((*MockCurrencyService)(nil).Rates), ctx)
+}
+
+// RefreshRates mocks base method.
+func (m *MockCurrencyService) RefreshRates(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshRates", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshRates indicates an expected call of RefreshRates.
+func (mr *MockCurrencyServiceMockRecorder) RefreshRates(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshRates", reflect.TypeOf((*MockCurrencyService)(nil).RefreshRates), ctx)
+}
+
+// Resolve mocks base method.
+func (m *MockCurrencyService) Resolve(ctx context.Context, requested string, userID uint64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resolve", ctx, requested, userID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resolve indicates an expected call of Resolve.
+func (mr *MockCurrencyServiceMockRecorder) Resolve(ctx, requested, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resolve", reflect.TypeOf((*MockCurrencyService)(nil).Resolve), ctx, requested, userID)
+}
+
+// SetPreference mocks base method.
+func (m *MockCurrencyService) SetPreference(ctx context.Context, userID uint64, currency string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPreference", ctx, userID, currency)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPreference indicates an expected call of SetPreference.
+func (mr *MockCurrencyServiceMockRecorder) SetPreference(ctx, userID, currency any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPreference", reflect.TypeOf((*MockCurrencyService)(nil).SetPreference), ctx, userID, currency)
+}
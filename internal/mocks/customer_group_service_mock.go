@@ -0,0 +1,146 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/customer_group_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/customer_group_service.go -destination=internal/mocks/customer_group_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	decimal "github.com/shopspring/decimal"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCustomerGroupService is a mock of CustomerGroupService interface.
+type MockCustomerGroupService struct {
+	ctrl     *gomock.Controller
+	recorder *MockCustomerGroupServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockCustomerGroupServiceMockRecorder is the mock recorder for MockCustomerGroupService.
+type MockCustomerGroupServiceMockRecorder struct {
+	mock *MockCustomerGroupService
+}
+
+// NewMockCustomerGroupService creates a new mock instance.
+func NewMockCustomerGroupService(ctrl *gomock.Controller) *MockCustomerGroupService {
+	mock := &MockCustomerGroupService{ctrl: ctrl}
+	mock.recorder = &MockCustomerGroupServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCustomerGroupService) EXPECT() *MockCustomerGroupServiceMockRecorder {
+	return m.recorder
+}
+
+// ApplySKUs mocks base method.
+func (m *MockCustomerGroupService) ApplySKUs(ctx context.Context, userID uint64, skus []service.SKUResp) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplySKUs", ctx, userID, skus)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApplySKUs indicates an expected call of ApplySKUs.
+func (mr *MockCustomerGroupServiceMockRecorder) ApplySKUs(ctx, userID, skus any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplySKUs", reflect.TypeOf((*MockCustomerGroupService)(nil).ApplySKUs), ctx, userID, skus)
+}
+
+// DeletePriceTier mocks base method.
+func (m *MockCustomerGroupService) DeletePriceTier(ctx context.Context, skuID uint64, group string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePriceTier", ctx, skuID, group)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePriceTier indicates an expected call of DeletePriceTier.
+func (mr *MockCustomerGroupServiceMockRecorder) DeletePriceTier(ctx, skuID, group any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePriceTier", reflect.TypeOf((*MockCustomerGroupService)(nil).DeletePriceTier), ctx, skuID, group)
+}
+
+// ListMembers mocks base method.
+func (m *MockCustomerGroupService) ListMembers(ctx context.Context, group string, offset, limit int) ([]service.CustomerGroupMemberResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMembers", ctx, group, offset, limit)
+	ret0, _ := ret[0].([]service.CustomerGroupMemberResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMembers indicates an expected call of ListMembers.
+func (mr *MockCustomerGroupServiceMockRecorder) ListMembers(ctx, group, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMembers", reflect.TypeOf((*MockCustomerGroupService)(nil).ListMembers), ctx, group, offset, limit)
+}
+
+// ListPriceTiers mocks base method.
+func (m *MockCustomerGroupService) ListPriceTiers(ctx context.Context, skuID uint64) ([]service.PriceTierResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPriceTiers", ctx, skuID)
+	ret0, _ := ret[0].([]service.PriceTierResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPriceTiers indicates an expected call of ListPriceTiers.
+func (mr *MockCustomerGroupServiceMockRecorder) ListPriceTiers(ctx, skuID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPriceTiers", reflect.TypeOf((*MockCustomerGroupService)(nil).ListPriceTiers), ctx, skuID)
+}
+
+// Prices mocks base method.
+func (m *MockCustomerGroupService) Prices(ctx context.Context, userID uint64, skuIDs []uint64) (map[uint64]decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Prices", ctx, userID, skuIDs)
+	ret0, _ := ret[0].(map[uint64]decimal.Decimal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Prices indicates an expected call of Prices.
+func (mr *MockCustomerGroupServiceMockRecorder) Prices(ctx, userID, skuIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prices", reflect.TypeOf((*MockCustomerGroupService)(nil).Prices), ctx, userID, skuIDs)
+}
+
+// PutPriceTier mocks base method.
+func (m *MockCustomerGroupService) PutPriceTier(ctx context.Context, skuID uint64, group string, price decimal.Decimal) (*service.PriceTierResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutPriceTier", ctx, skuID, group, price)
+	ret0, _ := ret[0].(*service.PriceTierResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutPriceTier indicates an expected call of PutPriceTier.
+func (mr *MockCustomerGroupServiceMockRecorder) PutPriceTier(ctx, skuID, group, price any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutPriceTier", reflect.TypeOf((*MockCustomerGroupService)(nil).PutPriceTier), ctx, skuID, group, price)
+}
+
+// SetMembership mocks base method.
+func (m *MockCustomerGroupService) SetMembership(ctx context.Context, userID uint64, group string) (*service.CustomerGroupMemberResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMembership", ctx, userID, group)
+	ret0, _ := ret[0].(*service.CustomerGroupMemberResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetMembership indicates an expected call of SetMembership.
+func (mr *MockCustomerGroupServiceMockRecorder) SetMembership(ctx, userID, group any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMembership", reflect.TypeOf((*MockCustomerGroupService)(nil).SetMembership), ctx, userID, group)
+}
@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/dashboard_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/dashboard_service.go -destination=internal/mocks/dashboard_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDashboardService is a mock of DashboardService interface.
+type MockDashboardService struct {
+	ctrl     *gomock.Controller
+	recorder *MockDashboardServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockDashboardServiceMockRecorder is the mock recorder for MockDashboardService.
+type MockDashboardServiceMockRecorder struct {
+	mock *MockDashboardService
+}
+
+// NewMockDashboardService creates a new mock instance.
+func NewMockDashboardService(ctrl *gomock.Controller) *MockDashboardService {
+	mock := &MockDashboardService{ctrl: ctrl}
+	mock.recorder = &MockDashboardServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDashboardService) EXPECT() *MockDashboardServiceMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockDashboardService) Get(ctx context.Context) (*service.DashboardResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx)
+	ret0, _ := ret[0].(*service.DashboardResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockDashboardServiceMockRecorder) Get(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockDashboardService)(nil).Get), ctx)
+}
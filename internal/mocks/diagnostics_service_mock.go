@@ -0,0 +1,164 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/diagnostics_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/diagnostics_service.go -destination=internal/mocks/diagnostics_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	mq "github.com/proyuen/go-mall/pkg/mq"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDiagnosticsService is a mock of DiagnosticsService interface.
+type MockDiagnosticsService struct {
+	ctrl     *gomock.Controller
+	recorder *MockDiagnosticsServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockDiagnosticsServiceMockRecorder is the mock recorder for MockDiagnosticsService.
+type MockDiagnosticsServiceMockRecorder struct {
+	mock *MockDiagnosticsService
+}
+
+// NewMockDiagnosticsService creates a new mock instance.
+func NewMockDiagnosticsService(ctrl *gomock.Controller) *MockDiagnosticsService {
+	mock := &MockDiagnosticsService{ctrl: ctrl}
+	mock.recorder = &MockDiagnosticsServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDiagnosticsService) EXPECT() *MockDiagnosticsServiceMockRecorder {
+	return m.recorder
+}
+
+// Cache mocks base method.
+func (m *MockDiagnosticsService) Cache(ctx context.Context) (*service.CacheDiagnosticsResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cache", ctx)
+	ret0, _ := ret[0].(*service.CacheDiagnosticsResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Cache indicates an expected call of Cache.
+func (mr *MockDiagnosticsServiceMockRecorder) Cache(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cache", reflect.TypeOf((*MockDiagnosticsService)(nil).Cache), ctx)
+}
+
+// MQ mocks base method.
+func (m *MockDiagnosticsService) MQ(ctx context.Context) (*service.MQDiagnosticsResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MQ", ctx)
+	ret0, _ := ret[0].(*service.MQDiagnosticsResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MQ indicates an expected call of MQ.
+func (mr *MockDiagnosticsServiceMockRecorder) MQ(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MQ", reflect.TypeOf((*MockDiagnosticsService)(nil).MQ), ctx)
+}
+
+// MockMQHealthReporter is a mock of MQHealthReporter interface.
+type MockMQHealthReporter struct {
+	ctrl     *gomock.Controller
+	recorder *MockMQHealthReporterMockRecorder
+	isgomock struct{}
+}
+
+// MockMQHealthReporterMockRecorder is the mock recorder for MockMQHealthReporter.
+type MockMQHealthReporterMockRecorder struct {
+	mock *MockMQHealthReporter
+}
+
+// NewMockMQHealthReporter creates a new mock instance.
+func NewMockMQHealthReporter(ctrl *gomock.Controller) *MockMQHealthReporter {
+	mock := &MockMQHealthReporter{ctrl: ctrl}
+	mock.recorder = &MockMQHealthReporterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMQHealthReporter) EXPECT() *MockMQHealthReporterMockRecorder {
+	return m.recorder
+}
+
+// Report mocks base method.
+func (m *MockMQHealthReporter) Report(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Report", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Report indicates an expected call of Report.
+func (mr *MockMQHealthReporterMockRecorder) Report(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Report", reflect.TypeOf((*MockMQHealthReporter)(nil).Report), ctx)
+}
+
+// MockBrokerInspector is a mock of BrokerInspector interface.
+type MockBrokerInspector struct {
+	ctrl     *gomock.Controller
+	recorder *MockBrokerInspectorMockRecorder
+	isgomock struct{}
+}
+
+// MockBrokerInspectorMockRecorder is the mock recorder for MockBrokerInspector.
+type MockBrokerInspectorMockRecorder struct {
+	mock *MockBrokerInspector
+}
+
+// NewMockBrokerInspector creates a new mock instance.
+func NewMockBrokerInspector(ctrl *gomock.Controller) *MockBrokerInspector {
+	mock := &MockBrokerInspector{ctrl: ctrl}
+	mock.recorder = &MockBrokerInspectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBrokerInspector) EXPECT() *MockBrokerInspectorMockRecorder {
+	return m.recorder
+}
+
+// Health mocks base method.
+func (m *MockBrokerInspector) Health() mq.Health {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Health")
+	ret0, _ := ret[0].(mq.Health)
+	return ret0
+}
+
+// Health indicates an expected call of Health.
+func (mr *MockBrokerInspectorMockRecorder) Health() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Health", reflect.TypeOf((*MockBrokerInspector)(nil).Health))
+}
+
+// InspectQueue mocks base method.
+func (m *MockBrokerInspector) InspectQueue(name string) (mq.QueueStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InspectQueue", name)
+	ret0, _ := ret[0].(mq.QueueStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InspectQueue indicates an expected call of InspectQueue.
+func (mr *MockBrokerInspectorMockRecorder) InspectQueue(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InspectQueue", reflect.TypeOf((*MockBrokerInspector)(nil).InspectQueue), name)
+}
@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/digital_fulfillment_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/digital_fulfillment_service.go -destination=internal/mocks/digital_fulfillment_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLicenseKeyService is a mock of LicenseKeyService interface.
+type MockLicenseKeyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockLicenseKeyServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockLicenseKeyServiceMockRecorder is the mock recorder for MockLicenseKeyService.
+type MockLicenseKeyServiceMockRecorder struct {
+	mock *MockLicenseKeyService
+}
+
+// NewMockLicenseKeyService creates a new mock instance.
+func NewMockLicenseKeyService(ctrl *gomock.Controller) *MockLicenseKeyService {
+	mock := &MockLicenseKeyService{ctrl: ctrl}
+	mock.recorder = &MockLicenseKeyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLicenseKeyService) EXPECT() *MockLicenseKeyServiceMockRecorder {
+	return m.recorder
+}
+
+// AddLicenseKeys mocks base method.
+func (m *MockLicenseKeyService) AddLicenseKeys(ctx context.Context, skuID uint64, keys []string) (*service.LicenseKeysResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLicenseKeys", ctx, skuID, keys)
+	ret0, _ := ret[0].(*service.LicenseKeysResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddLicenseKeys indicates an expected call of AddLicenseKeys.
+func (mr *MockLicenseKeyServiceMockRecorder) AddLicenseKeys(ctx, skuID, keys any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLicenseKeys", reflect.TypeOf((*MockLicenseKeyService)(nil).AddLicenseKeys), ctx, skuID, keys)
+}
+
+// MockDigitalFulfillmentService is a mock of DigitalFulfillmentService interface.
+type MockDigitalFulfillmentService struct {
+	ctrl     *gomock.Controller
+	recorder *MockDigitalFulfillmentServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockDigitalFulfillmentServiceMockRecorder is the mock recorder for MockDigitalFulfillmentService.
+type MockDigitalFulfillmentServiceMockRecorder struct {
+	mock *MockDigitalFulfillmentService
+}
+
+// NewMockDigitalFulfillmentService creates a new mock instance.
+func NewMockDigitalFulfillmentService(ctrl *gomock.Controller) *MockDigitalFulfillmentService {
+	mock := &MockDigitalFulfillmentService{ctrl: ctrl}
+	mock.recorder = &MockDigitalFulfillmentServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDigitalFulfillmentService) EXPECT() *MockDigitalFulfillmentServiceMockRecorder {
+	return m.recorder
+}
+
+// DeliverPaid mocks base method.
+func (m *MockDigitalFulfillmentService) DeliverPaid(ctx context.Context, batchSize int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeliverPaid", ctx, batchSize)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeliverPaid indicates an expected call of DeliverPaid.
+func (mr *MockDigitalFulfillmentServiceMockRecorder) DeliverPaid(ctx, batchSize any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeliverPaid", reflect.TypeOf((*MockDigitalFulfillmentService)(nil).DeliverPaid), ctx, batchSize)
+}
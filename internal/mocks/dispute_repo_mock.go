@@ -0,0 +1,176 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/dispute_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/dispute_repo.go -destination=internal/mocks/dispute_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	repository "github.com/proyuen/go-mall/internal/repository"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDisputeRepository is a mock of DisputeRepository interface.
+type MockDisputeRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockDisputeRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockDisputeRepositoryMockRecorder is the mock recorder for MockDisputeRepository.
+type MockDisputeRepositoryMockRecorder struct {
+	mock *MockDisputeRepository
+}
+
+// NewMockDisputeRepository creates a new mock instance.
+func NewMockDisputeRepository(ctrl *gomock.Controller) *MockDisputeRepository {
+	mock := &MockDisputeRepository{ctrl: ctrl}
+	mock.recorder = &MockDisputeRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDisputeRepository) EXPECT() *MockDisputeRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockDisputeRepository) Create(ctx context.Context, dispute *model.Dispute) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, dispute)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockDisputeRepositoryMockRecorder) Create(ctx, dispute any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockDisputeRepository)(nil).Create), ctx, dispute)
+}
+
+// GetByID mocks base method.
+func (m *MockDisputeRepository) GetByID(ctx context.Context, id uint64) (*model.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*model.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockDisputeRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockDisputeRepository)(nil).GetByID), ctx, id)
+}
+
+// GetByRef mocks base method.
+func (m *MockDisputeRepository) GetByRef(ctx context.Context, provider, disputeRef string) (*model.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByRef", ctx, provider, disputeRef)
+	ret0, _ := ret[0].(*model.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByRef indicates an expected call of GetByRef.
+func (mr *MockDisputeRepositoryMockRecorder) GetByRef(ctx, provider, disputeRef any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByRef", reflect.TypeOf((*MockDisputeRepository)(nil).GetByRef), ctx, provider, disputeRef)
+}
+
+// List mocks base method.
+func (m *MockDisputeRepository) List(ctx context.Context, filter repository.DisputeFilter, offset, limit int) ([]model.Dispute, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter, offset, limit)
+	ret0, _ := ret[0].([]model.Dispute)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockDisputeRepositoryMockRecorder) List(ctx, filter, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockDisputeRepository)(nil).List), ctx, filter, offset, limit)
+}
+
+// ListAwaitingEvidence mocks base method.
+func (m *MockDisputeRepository) ListAwaitingEvidence(ctx context.Context, now, dueBefore, remindedBefore time.Time, afterID uint64, limit int) ([]model.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAwaitingEvidence", ctx, now, dueBefore, remindedBefore, afterID, limit)
+	ret0, _ := ret[0].([]model.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAwaitingEvidence indicates an expected call of ListAwaitingEvidence.
+func (mr *MockDisputeRepositoryMockRecorder) ListAwaitingEvidence(ctx, now, dueBefore, remindedBefore, afterID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAwaitingEvidence", reflect.TypeOf((*MockDisputeRepository)(nil).ListAwaitingEvidence), ctx, now, dueBefore, remindedBefore, afterID, limit)
+}
+
+// ListByOrder mocks base method.
+func (m *MockDisputeRepository) ListByOrder(ctx context.Context, orderID uint64) ([]model.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByOrder", ctx, orderID)
+	ret0, _ := ret[0].([]model.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByOrder indicates an expected call of ListByOrder.
+func (mr *MockDisputeRepositoryMockRecorder) ListByOrder(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByOrder", reflect.TypeOf((*MockDisputeRepository)(nil).ListByOrder), ctx, orderID)
+}
+
+// MarkEvidenceSubmitted mocks base method.
+func (m *MockDisputeRepository) MarkEvidenceSubmitted(ctx context.Context, id, by uint64, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEvidenceSubmitted", ctx, id, by, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEvidenceSubmitted indicates an expected call of MarkEvidenceSubmitted.
+func (mr *MockDisputeRepositoryMockRecorder) MarkEvidenceSubmitted(ctx, id, by, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEvidenceSubmitted", reflect.TypeOf((*MockDisputeRepository)(nil).MarkEvidenceSubmitted), ctx, id, by, at)
+}
+
+// MarkReminded mocks base method.
+func (m *MockDisputeRepository) MarkReminded(ctx context.Context, id uint64, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkReminded", ctx, id, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkReminded indicates an expected call of MarkReminded.
+func (mr *MockDisputeRepositoryMockRecorder) MarkReminded(ctx, id, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkReminded", reflect.TypeOf((*MockDisputeRepository)(nil).MarkReminded), ctx, id, at)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockDisputeRepository) UpdateStatus(ctx context.Context, dispute *model.Dispute) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, dispute)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockDisputeRepositoryMockRecorder) UpdateStatus(ctx, dispute any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockDisputeRepository)(nil).UpdateStatus), ctx, dispute)
+}
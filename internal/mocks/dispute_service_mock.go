@@ -0,0 +1,142 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/dispute_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/dispute_service.go -destination=internal/mocks/dispute_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	repository "github.com/proyuen/go-mall/internal/repository"
+	service "github.com/proyuen/go-mall/internal/service"
+	payment "github.com/proyuen/go-mall/internal/service/payment"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDisputeService is a mock of DisputeService interface.
+type MockDisputeService struct {
+	ctrl     *gomock.Controller
+	recorder *MockDisputeServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockDisputeServiceMockRecorder is the mock recorder for MockDisputeService.
+type MockDisputeServiceMockRecorder struct {
+	mock *MockDisputeService
+}
+
+// NewMockDisputeService creates a new mock instance.
+func NewMockDisputeService(ctrl *gomock.Controller) *MockDisputeService {
+	mock := &MockDisputeService{ctrl: ctrl}
+	mock.recorder = &MockDisputeServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDisputeService) EXPECT() *MockDisputeServiceMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockDisputeService) Get(ctx context.Context, id uint64) (*service.DisputeResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*service.DisputeResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockDisputeServiceMockRecorder) Get(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockDisputeService)(nil).Get), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockDisputeService) List(ctx context.Context, filter repository.DisputeFilter, offset, limit int) (*service.DisputeListResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter, offset, limit)
+	ret0, _ := ret[0].(*service.DisputeListResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockDisputeServiceMockRecorder) List(ctx, filter, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockDisputeService)(nil).List), ctx, filter, offset, limit)
+}
+
+// MarkEvidenceSubmitted mocks base method.
+func (m *MockDisputeService) MarkEvidenceSubmitted(ctx context.Context, userID, id uint64) (*service.DisputeResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEvidenceSubmitted", ctx, userID, id)
+	ret0, _ := ret[0].(*service.DisputeResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkEvidenceSubmitted indicates an expected call of MarkEvidenceSubmitted.
+func (mr *MockDisputeServiceMockRecorder) MarkEvidenceSubmitted(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEvidenceSubmitted", reflect.TypeOf((*MockDisputeService)(nil).MarkEvidenceSubmitted), ctx, userID, id)
+}
+
+// Record mocks base method.
+func (m *MockDisputeService) Record(ctx context.Context, provider string, dispute *payment.Dispute) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", ctx, provider, dispute)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockDisputeServiceMockRecorder) Record(ctx, provider, dispute any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockDisputeService)(nil).Record), ctx, provider, dispute)
+}
+
+// MockDisputeReminder is a mock of DisputeReminder interface.
+type MockDisputeReminder struct {
+	ctrl     *gomock.Controller
+	recorder *MockDisputeReminderMockRecorder
+	isgomock struct{}
+}
+
+// MockDisputeReminderMockRecorder is the mock recorder for MockDisputeReminder.
+type MockDisputeReminderMockRecorder struct {
+	mock *MockDisputeReminder
+}
+
+// NewMockDisputeReminder creates a new mock instance.
+func NewMockDisputeReminder(ctrl *gomock.Controller) *MockDisputeReminder {
+	mock := &MockDisputeReminder{ctrl: ctrl}
+	mock.recorder = &MockDisputeReminderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDisputeReminder) EXPECT() *MockDisputeReminderMockRecorder {
+	return m.recorder
+}
+
+// RemindDue mocks base method.
+func (m *MockDisputeReminder) RemindDue(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemindDue", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemindDue indicates an expected call of RemindDue.
+func (mr *MockDisputeReminderMockRecorder) RemindDue(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemindDue", reflect.TypeOf((*MockDisputeReminder)(nil).RemindDue), ctx)
+}
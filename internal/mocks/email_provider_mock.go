@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/notification/provider.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/notification/provider.go -destination=internal/mocks/email_provider_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	notification "github.com/proyuen/go-mall/internal/service/notification"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockEmailProvider is a mock of EmailProvider interface.
+type MockEmailProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmailProviderMockRecorder
+	isgomock struct{}
+}
+
+// MockEmailProviderMockRecorder is the mock recorder for MockEmailProvider.
+type MockEmailProviderMockRecorder struct {
+	mock *MockEmailProvider
+}
+
+// NewMockEmailProvider creates a new mock instance.
+func NewMockEmailProvider(ctrl *gomock.Controller) *MockEmailProvider {
+	mock := &MockEmailProvider{ctrl: ctrl}
+	mock.recorder = &MockEmailProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmailProvider) EXPECT() *MockEmailProviderMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method.
+func (m *MockEmailProvider) Send(ctx context.Context, msg *notification.Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", ctx, msg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockEmailProviderMockRecorder) Send(ctx, msg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockEmailProvider)(nil).Send), ctx, msg)
+}
@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/errreport/errreport.go
+//
+// Generated by this command:
+//
+//	mockgen -source=pkg/errreport/errreport.go -destination=internal/mocks/error_reporter_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockReporter is a mock of Reporter interface.
+type MockReporter struct {
+	ctrl     *gomock.Controller
+	recorder *MockReporterMockRecorder
+	isgomock struct{}
+}
+
+// MockReporterMockRecorder is the mock recorder for MockReporter.
+type MockReporterMockRecorder struct {
+	mock *MockReporter
+}
+
+// NewMockReporter creates a new mock instance.
+func NewMockReporter(ctrl *gomock.Controller) *MockReporter {
+	mock := &MockReporter{ctrl: ctrl}
+	mock.recorder = &MockReporterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReporter) EXPECT() *MockReporterMockRecorder {
+	return m.recorder
+}
+
+// CaptureError mocks base method.
+func (m *MockReporter) CaptureError(ctx context.Context, err error, tags map[string]string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CaptureError", ctx, err, tags)
+}
+
+// CaptureError indicates an expected call of CaptureError.
+func (mr *MockReporterMockRecorder) CaptureError(ctx, err, tags any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaptureError", reflect.TypeOf((*MockReporter)(nil).CaptureError), ctx, err, tags)
+}
+
+// CapturePanic mocks base method.
+func (m *MockReporter) CapturePanic(ctx context.Context, recovered any, tags map[string]string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CapturePanic", ctx, recovered, tags)
+}
+
+// CapturePanic indicates an expected call of CapturePanic.
+func (mr *MockReporterMockRecorder) CapturePanic(ctx, recovered, tags any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CapturePanic", reflect.TypeOf((*MockReporter)(nil).CapturePanic), ctx, recovered, tags)
+}
+
+// Flush mocks base method.
+func (m *MockReporter) Flush(timeout time.Duration) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Flush", timeout)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Flush indicates an expected call of Flush.
+func (mr *MockReporterMockRecorder) Flush(timeout any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockReporter)(nil).Flush), timeout)
+}
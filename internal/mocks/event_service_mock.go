@@ -0,0 +1,110 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/event_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/event_service.go -destination=internal/mocks/event_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockEventPublisher is a mock of EventPublisher interface.
+type MockEventPublisher struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventPublisherMockRecorder
+	isgomock struct{}
+}
+
+// MockEventPublisherMockRecorder is the mock recorder for MockEventPublisher.
+type MockEventPublisherMockRecorder struct {
+	mock *MockEventPublisher
+}
+
+// NewMockEventPublisher creates a new mock instance.
+func NewMockEventPublisher(ctrl *gomock.Controller) *MockEventPublisher {
+	mock := &MockEventPublisher{ctrl: ctrl}
+	mock.recorder = &MockEventPublisherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventPublisher) EXPECT() *MockEventPublisherMockRecorder {
+	return m.recorder
+}
+
+// Publish mocks base method.
+func (m *MockEventPublisher) Publish(ctx context.Context, event string, data any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", ctx, event, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockEventPublisherMockRecorder) Publish(ctx, event, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockEventPublisher)(nil).Publish), ctx, event, data)
+}
+
+// MockEventRelay is a mock of EventRelay interface.
+type MockEventRelay struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventRelayMockRecorder
+	isgomock struct{}
+}
+
+// MockEventRelayMockRecorder is the mock recorder for MockEventRelay.
+type MockEventRelayMockRecorder struct {
+	mock *MockEventRelay
+}
+
+// NewMockEventRelay creates a new mock instance.
+func NewMockEventRelay(ctrl *gomock.Controller) *MockEventRelay {
+	mock := &MockEventRelay{ctrl: ctrl}
+	mock.recorder = &MockEventRelayMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventRelay) EXPECT() *MockEventRelayMockRecorder {
+	return m.recorder
+}
+
+// Relay mocks base method.
+func (m *MockEventRelay) Relay(ctx context.Context, opts service.EventRelayOptions) (*service.EventRelayReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Relay", ctx, opts)
+	ret0, _ := ret[0].(*service.EventRelayReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Relay indicates an expected call of Relay.
+func (mr *MockEventRelayMockRecorder) Relay(ctx, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Relay", reflect.TypeOf((*MockEventRelay)(nil).Relay), ctx, opts)
+}
+
+// Replay mocks base method.
+func (m *MockEventRelay) Replay(ctx context.Context, req *service.EventReplayReq) (*service.EventReplayReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Replay", ctx, req)
+	ret0, _ := ret[0].(*service.EventReplayReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Replay indicates an expected call of Replay.
+func (mr *MockEventRelayMockRecorder) Replay(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replay", reflect.TypeOf((*MockEventRelay)(nil).Replay), ctx, req)
+}
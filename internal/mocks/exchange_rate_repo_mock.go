@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/exchange_rate_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/exchange_rate_repo.go -destination=internal/mocks/exchange_rate_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockExchangeRateRepository is a mock of ExchangeRateRepository interface.
+type MockExchangeRateRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockExchangeRateRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockExchangeRateRepositoryMockRecorder is the mock recorder for MockExchangeRateRepository.
+type MockExchangeRateRepositoryMockRecorder struct {
+	mock *MockExchangeRateRepository
+}
+
+// NewMockExchangeRateRepository creates a new mock instance.
+func NewMockExchangeRateRepository(ctrl *gomock.Controller) *MockExchangeRateRepository {
+	mock := &MockExchangeRateRepository{ctrl: ctrl}
+	mock.recorder = &MockExchangeRateRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExchangeRateRepository) EXPECT() *MockExchangeRateRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ListByBase mocks base method.
+func (m *MockExchangeRateRepository) ListByBase(ctx context.Context, baseCurrency string) ([]model.ExchangeRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByBase", ctx, baseCurrency)
+	ret0, _ := ret[0].([]model.ExchangeRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByBase indicates an expected call of ListByBase.
+func (mr *MockExchangeRateRepositoryMockRecorder) ListByBase(ctx, baseCurrency any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByBase", reflect.TypeOf((*MockExchangeRateRepository)(nil).ListByBase), ctx, baseCurrency)
+}
+
+// SaveRates mocks base method.
+func (m *MockExchangeRateRepository) SaveRates(ctx context.Context, rates []model.ExchangeRate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveRates", ctx, rates)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveRates indicates an expected call of SaveRates.
+func (mr *MockExchangeRateRepositoryMockRecorder) SaveRates(ctx, rates any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveRates", reflect.TypeOf((*MockExchangeRateRepository)(nil).SaveRates), ctx, rates)
+}
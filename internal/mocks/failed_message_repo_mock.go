@@ -0,0 +1,146 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/failed_message_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/failed_message_repo.go -destination=internal/mocks/failed_message_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	repository "github.com/proyuen/go-mall/internal/repository"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFailedMessageRepository is a mock of FailedMessageRepository interface.
+type MockFailedMessageRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockFailedMessageRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockFailedMessageRepositoryMockRecorder is the mock recorder for MockFailedMessageRepository.
+type MockFailedMessageRepositoryMockRecorder struct {
+	mock *MockFailedMessageRepository
+}
+
+// NewMockFailedMessageRepository creates a new mock instance.
+func NewMockFailedMessageRepository(ctrl *gomock.Controller) *MockFailedMessageRepository {
+	mock := &MockFailedMessageRepository{ctrl: ctrl}
+	mock.recorder = &MockFailedMessageRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFailedMessageRepository) EXPECT() *MockFailedMessageRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockFailedMessageRepository) Create(ctx context.Context, msg *model.FailedMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, msg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockFailedMessageRepositoryMockRecorder) Create(ctx, msg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockFailedMessageRepository)(nil).Create), ctx, msg)
+}
+
+// GetByID mocks base method.
+func (m *MockFailedMessageRepository) GetByID(ctx context.Context, id uint64) (*model.FailedMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*model.FailedMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockFailedMessageRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockFailedMessageRepository)(nil).GetByID), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockFailedMessageRepository) List(ctx context.Context, filter repository.FailedMessageFilter, offset, limit int) ([]model.FailedMessage, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter, offset, limit)
+	ret0, _ := ret[0].([]model.FailedMessage)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockFailedMessageRepositoryMockRecorder) List(ctx, filter, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockFailedMessageRepository)(nil).List), ctx, filter, offset, limit)
+}
+
+// ListQueued mocks base method.
+func (m *MockFailedMessageRepository) ListQueued(ctx context.Context, limit int) ([]model.FailedMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListQueued", ctx, limit)
+	ret0, _ := ret[0].([]model.FailedMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListQueued indicates an expected call of ListQueued.
+func (mr *MockFailedMessageRepositoryMockRecorder) ListQueued(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListQueued", reflect.TypeOf((*MockFailedMessageRepository)(nil).ListQueued), ctx, limit)
+}
+
+// MarkReplayed mocks base method.
+func (m *MockFailedMessageRepository) MarkReplayed(ctx context.Context, id uint64, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkReplayed", ctx, id, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkReplayed indicates an expected call of MarkReplayed.
+func (mr *MockFailedMessageRepositoryMockRecorder) MarkReplayed(ctx, id, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkReplayed", reflect.TypeOf((*MockFailedMessageRepository)(nil).MarkReplayed), ctx, id, at)
+}
+
+// UpdateBody mocks base method.
+func (m *MockFailedMessageRepository) UpdateBody(ctx context.Context, id uint64, body string, from []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBody", ctx, id, body, from)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBody indicates an expected call of UpdateBody.
+func (mr *MockFailedMessageRepositoryMockRecorder) UpdateBody(ctx, id, body, from any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBody", reflect.TypeOf((*MockFailedMessageRepository)(nil).UpdateBody), ctx, id, body, from)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockFailedMessageRepository) UpdateStatus(ctx context.Context, id uint64, from []string, to string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, id, from, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockFailedMessageRepositoryMockRecorder) UpdateStatus(ctx, id, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockFailedMessageRepository)(nil).UpdateStatus), ctx, id, from, to)
+}
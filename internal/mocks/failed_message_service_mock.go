@@ -0,0 +1,157 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/failed_message_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/failed_message_service.go -destination=internal/mocks/failed_message_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	json "encoding/json"
+	reflect "reflect"
+
+	repository "github.com/proyuen/go-mall/internal/repository"
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFailedMessageService is a mock of FailedMessageService interface.
+type MockFailedMessageService struct {
+	ctrl     *gomock.Controller
+	recorder *MockFailedMessageServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockFailedMessageServiceMockRecorder is the mock recorder for MockFailedMessageService.
+type MockFailedMessageServiceMockRecorder struct {
+	mock *MockFailedMessageService
+}
+
+// NewMockFailedMessageService creates a new mock instance.
+func NewMockFailedMessageService(ctrl *gomock.Controller) *MockFailedMessageService {
+	mock := &MockFailedMessageService{ctrl: ctrl}
+	mock.recorder = &MockFailedMessageServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFailedMessageService) EXPECT() *MockFailedMessageServiceMockRecorder {
+	return m.recorder
+}
+
+// Archive mocks base method.
+func (m *MockFailedMessageService) Archive(ctx context.Context, msg *service.RejectedMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Archive", ctx, msg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Archive indicates an expected call of Archive.
+func (mr *MockFailedMessageServiceMockRecorder) Archive(ctx, msg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Archive", reflect.TypeOf((*MockFailedMessageService)(nil).Archive), ctx, msg)
+}
+
+// Get mocks base method.
+func (m *MockFailedMessageService) Get(ctx context.Context, id uint64) (*service.FailedMessageResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*service.FailedMessageResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockFailedMessageServiceMockRecorder) Get(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockFailedMessageService)(nil).Get), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockFailedMessageService) List(ctx context.Context, filter repository.FailedMessageFilter, offset, limit int) (*service.FailedMessageListResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter, offset, limit)
+	ret0, _ := ret[0].(*service.FailedMessageListResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockFailedMessageServiceMockRecorder) List(ctx, filter, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockFailedMessageService)(nil).List), ctx, filter, offset, limit)
+}
+
+// Replay mocks base method.
+func (m *MockFailedMessageService) Replay(ctx context.Context, id uint64) (*service.FailedMessageResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Replay", ctx, id)
+	ret0, _ := ret[0].(*service.FailedMessageResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Replay indicates an expected call of Replay.
+func (mr *MockFailedMessageServiceMockRecorder) Replay(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replay", reflect.TypeOf((*MockFailedMessageService)(nil).Replay), ctx, id)
+}
+
+// UpdateBody mocks base method.
+func (m *MockFailedMessageService) UpdateBody(ctx context.Context, id uint64, body json.RawMessage) (*service.FailedMessageResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBody", ctx, id, body)
+	ret0, _ := ret[0].(*service.FailedMessageResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateBody indicates an expected call of UpdateBody.
+func (mr *MockFailedMessageServiceMockRecorder) UpdateBody(ctx, id, body any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBody", reflect.TypeOf((*MockFailedMessageService)(nil).UpdateBody), ctx, id, body)
+}
+
+// MockFailedMessageReplayer is a mock of FailedMessageReplayer interface.
+type MockFailedMessageReplayer struct {
+	ctrl     *gomock.Controller
+	recorder *MockFailedMessageReplayerMockRecorder
+	isgomock struct{}
+}
+
+// MockFailedMessageReplayerMockRecorder is the mock recorder for MockFailedMessageReplayer.
+type MockFailedMessageReplayerMockRecorder struct {
+	mock *MockFailedMessageReplayer
+}
+
+// NewMockFailedMessageReplayer creates a new mock instance.
+func NewMockFailedMessageReplayer(ctrl *gomock.Controller) *MockFailedMessageReplayer {
+	mock := &MockFailedMessageReplayer{ctrl: ctrl}
+	mock.recorder = &MockFailedMessageReplayerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFailedMessageReplayer) EXPECT() *MockFailedMessageReplayerMockRecorder {
+	return m.recorder
+}
+
+// Replay mocks base method.
+func (m *MockFailedMessageReplayer) Replay(ctx context.Context, batchSize int) (*service.FailedMessageReplayReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Replay", ctx, batchSize)
+	ret0, _ := ret[0].(*service.FailedMessageReplayReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Replay indicates an expected call of Replay.
+func (mr *MockFailedMessageReplayerMockRecorder) Replay(ctx, batchSize any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replay", reflect.TypeOf((*MockFailedMessageReplayer)(nil).Replay), ctx, batchSize)
+}
@@ -0,0 +1,131 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/fulfillment_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/fulfillment_service.go -destination=internal/mocks/fulfillment_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFulfillmentService is a mock of FulfillmentService interface.
+type MockFulfillmentService struct {
+	ctrl     *gomock.Controller
+	recorder *MockFulfillmentServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockFulfillmentServiceMockRecorder is the mock recorder for MockFulfillmentService.
+type MockFulfillmentServiceMockRecorder struct {
+	mock *MockFulfillmentService
+}
+
+// NewMockFulfillmentService creates a new mock instance.
+func NewMockFulfillmentService(ctrl *gomock.Controller) *MockFulfillmentService {
+	mock := &MockFulfillmentService{ctrl: ctrl}
+	mock.recorder = &MockFulfillmentServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFulfillmentService) EXPECT() *MockFulfillmentServiceMockRecorder {
+	return m.recorder
+}
+
+// AllocateBackorders mocks base method.
+func (m *MockFulfillmentService) AllocateBackorders(ctx context.Context, batchSize int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllocateBackorders", ctx, batchSize)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllocateBackorders indicates an expected call of AllocateBackorders.
+func (mr *MockFulfillmentServiceMockRecorder) AllocateBackorders(ctx, batchSize any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocateBackorders", reflect.TypeOf((*MockFulfillmentService)(nil).AllocateBackorders), ctx, batchSize)
+}
+
+// Cancel mocks base method.
+func (m *MockFulfillmentService) Cancel(ctx context.Context, orderID uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", ctx, orderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockFulfillmentServiceMockRecorder) Cancel(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockFulfillmentService)(nil).Cancel), ctx, orderID)
+}
+
+// Deliver mocks base method.
+func (m *MockFulfillmentService) Deliver(ctx context.Context, shipmentID uint64) (*service.ShipmentResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Deliver", ctx, shipmentID)
+	ret0, _ := ret[0].(*service.ShipmentResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Deliver indicates an expected call of Deliver.
+func (mr *MockFulfillmentServiceMockRecorder) Deliver(ctx, shipmentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deliver", reflect.TypeOf((*MockFulfillmentService)(nil).Deliver), ctx, shipmentID)
+}
+
+// ListShipments mocks base method.
+func (m *MockFulfillmentService) ListShipments(ctx context.Context, orderID uint64) ([]service.ShipmentResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListShipments", ctx, orderID)
+	ret0, _ := ret[0].([]service.ShipmentResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListShipments indicates an expected call of ListShipments.
+func (mr *MockFulfillmentServiceMockRecorder) ListShipments(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListShipments", reflect.TypeOf((*MockFulfillmentService)(nil).ListShipments), ctx, orderID)
+}
+
+// SetTrackingNumber mocks base method.
+func (m *MockFulfillmentService) SetTrackingNumber(ctx context.Context, shipmentID uint64, trackingNumber string) (*service.ShipmentResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTrackingNumber", ctx, shipmentID, trackingNumber)
+	ret0, _ := ret[0].(*service.ShipmentResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetTrackingNumber indicates an expected call of SetTrackingNumber.
+func (mr *MockFulfillmentServiceMockRecorder) SetTrackingNumber(ctx, shipmentID, trackingNumber any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTrackingNumber", reflect.TypeOf((*MockFulfillmentService)(nil).SetTrackingNumber), ctx, shipmentID, trackingNumber)
+}
+
+// Ship mocks base method.
+func (m *MockFulfillmentService) Ship(ctx context.Context, req *service.ShipOrderReq) (*service.ShipmentResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ship", ctx, req)
+	ret0, _ := ret[0].(*service.ShipmentResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Ship indicates an expected call of Ship.
+func (mr *MockFulfillmentServiceMockRecorder) Ship(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ship", reflect.TypeOf((*MockFulfillmentService)(nil).Ship), ctx, req)
+}
@@ -0,0 +1,72 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/inventory_sync_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/inventory_sync_service.go -destination=internal/mocks/inventory_sync_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockInventorySyncService is a mock of InventorySyncService interface.
+type MockInventorySyncService struct {
+	ctrl     *gomock.Controller
+	recorder *MockInventorySyncServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockInventorySyncServiceMockRecorder is the mock recorder for MockInventorySyncService.
+type MockInventorySyncServiceMockRecorder struct {
+	mock *MockInventorySyncService
+}
+
+// NewMockInventorySyncService creates a new mock instance.
+func NewMockInventorySyncService(ctrl *gomock.Controller) *MockInventorySyncService {
+	mock := &MockInventorySyncService{ctrl: ctrl}
+	mock.recorder = &MockInventorySyncServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInventorySyncService) EXPECT() *MockInventorySyncServiceMockRecorder {
+	return m.recorder
+}
+
+// ExportSnapshot mocks base method.
+func (m *MockInventorySyncService) ExportSnapshot(ctx context.Context, cursor uint64, limit int, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportSnapshot", ctx, cursor, limit, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportSnapshot indicates an expected call of ExportSnapshot.
+func (mr *MockInventorySyncServiceMockRecorder) ExportSnapshot(ctx, cursor, limit, w any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportSnapshot", reflect.TypeOf((*MockInventorySyncService)(nil).ExportSnapshot), ctx, cursor, limit, w)
+}
+
+// SyncStock mocks base method.
+func (m *MockInventorySyncService) SyncStock(ctx context.Context, corrections []service.StockCorrection) (*service.StockSyncResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncStock", ctx, corrections)
+	ret0, _ := ret[0].(*service.StockSyncResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncStock indicates an expected call of SyncStock.
+func (mr *MockInventorySyncServiceMockRecorder) SyncStock(ctx, corrections any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncStock", reflect.TypeOf((*MockInventorySyncService)(nil).SyncStock), ctx, corrections)
+}
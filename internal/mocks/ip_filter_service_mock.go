@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/ip_filter_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/ip_filter_service.go -destination=internal/mocks/ip_filter_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIPFilterService is a mock of IPFilterService interface.
+type MockIPFilterService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIPFilterServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockIPFilterServiceMockRecorder is the mock recorder for MockIPFilterService.
+type MockIPFilterServiceMockRecorder struct {
+	mock *MockIPFilterService
+}
+
+// NewMockIPFilterService creates a new mock instance.
+func NewMockIPFilterService(ctrl *gomock.Controller) *MockIPFilterService {
+	mock := &MockIPFilterService{ctrl: ctrl}
+	mock.recorder = &MockIPFilterServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIPFilterService) EXPECT() *MockIPFilterServiceMockRecorder {
+	return m.recorder
+}
+
+// IsBlocked mocks base method.
+func (m *MockIPFilterService) IsBlocked(ctx context.Context, ip string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsBlocked", ctx, ip)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsBlocked indicates an expected call of IsBlocked.
+func (mr *MockIPFilterServiceMockRecorder) IsBlocked(ctx, ip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsBlocked", reflect.TypeOf((*MockIPFilterService)(nil).IsBlocked), ctx, ip)
+}
+
+// ListRules mocks base method.
+func (m *MockIPFilterService) ListRules(ctx context.Context) ([]service.IPRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRules", ctx)
+	ret0, _ := ret[0].([]service.IPRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRules indicates an expected call of ListRules.
+func (mr *MockIPFilterServiceMockRecorder) ListRules(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRules", reflect.TypeOf((*MockIPFilterService)(nil).ListRules), ctx)
+}
+
+// PutRule mocks base method.
+func (m *MockIPFilterService) PutRule(ctx context.Context, req *service.IPRuleReq) (*service.IPRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutRule", ctx, req)
+	ret0, _ := ret[0].(*service.IPRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutRule indicates an expected call of PutRule.
+func (mr *MockIPFilterServiceMockRecorder) PutRule(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutRule", reflect.TypeOf((*MockIPFilterService)(nil).PutRule), ctx, req)
+}
+
+// RemoveRule mocks base method.
+func (m *MockIPFilterService) RemoveRule(ctx context.Context, cidr string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveRule", ctx, cidr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveRule indicates an expected call of RemoveRule.
+func (mr *MockIPFilterServiceMockRecorder) RemoveRule(ctx, cidr any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveRule", reflect.TypeOf((*MockIPFilterService)(nil).RemoveRule), ctx, cidr)
+}
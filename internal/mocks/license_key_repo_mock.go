@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/license_key_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/license_key_repo.go -destination=internal/mocks/license_key_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLicenseKeyRepository is a mock of LicenseKeyRepository interface.
+type MockLicenseKeyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockLicenseKeyRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockLicenseKeyRepositoryMockRecorder is the mock recorder for MockLicenseKeyRepository.
+type MockLicenseKeyRepositoryMockRecorder struct {
+	mock *MockLicenseKeyRepository
+}
+
+// NewMockLicenseKeyRepository creates a new mock instance.
+func NewMockLicenseKeyRepository(ctrl *gomock.Controller) *MockLicenseKeyRepository {
+	mock := &MockLicenseKeyRepository{ctrl: ctrl}
+	mock.recorder = &MockLicenseKeyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLicenseKeyRepository) EXPECT() *MockLicenseKeyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddKeys mocks base method.
+func (m *MockLicenseKeyRepository) AddKeys(ctx context.Context, skuID uint64, keys []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddKeys", ctx, skuID, keys)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddKeys indicates an expected call of AddKeys.
+func (mr *MockLicenseKeyRepositoryMockRecorder) AddKeys(ctx, skuID, keys any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddKeys", reflect.TypeOf((*MockLicenseKeyRepository)(nil).AddKeys), ctx, skuID, keys)
+}
+
+// Allocate mocks base method.
+func (m *MockLicenseKeyRepository) Allocate(ctx context.Context, skuID, orderItemID uint64, quantity int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Allocate", ctx, skuID, orderItemID, quantity)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Allocate indicates an expected call of Allocate.
+func (mr *MockLicenseKeyRepositoryMockRecorder) Allocate(ctx, skuID, orderItemID, quantity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Allocate", reflect.TypeOf((*MockLicenseKeyRepository)(nil).Allocate), ctx, skuID, orderItemID, quantity)
+}
+
+// CountAvailable mocks base method.
+func (m *MockLicenseKeyRepository) CountAvailable(ctx context.Context, skuID uint64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountAvailable", ctx, skuID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountAvailable indicates an expected call of CountAvailable.
+func (mr *MockLicenseKeyRepositoryMockRecorder) CountAvailable(ctx, skuID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountAvailable", reflect.TypeOf((*MockLicenseKeyRepository)(nil).CountAvailable), ctx, skuID)
+}
@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/log_level_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/log_level_service.go -destination=internal/mocks/log_level_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLogLevelService is a mock of LogLevelService interface.
+type MockLogLevelService struct {
+	ctrl     *gomock.Controller
+	recorder *MockLogLevelServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockLogLevelServiceMockRecorder is the mock recorder for MockLogLevelService.
+type MockLogLevelServiceMockRecorder struct {
+	mock *MockLogLevelService
+}
+
+// NewMockLogLevelService creates a new mock instance.
+func NewMockLogLevelService(ctrl *gomock.Controller) *MockLogLevelService {
+	mock := &MockLogLevelService{ctrl: ctrl}
+	mock.recorder = &MockLogLevelServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLogLevelService) EXPECT() *MockLogLevelServiceMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockLogLevelService) Get(ctx context.Context) *service.LogLevelResp {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx)
+	ret0, _ := ret[0].(*service.LogLevelResp)
+	return ret0
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockLogLevelServiceMockRecorder) Get(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockLogLevelService)(nil).Get), ctx)
+}
+
+// Set mocks base method.
+func (m *MockLogLevelService) Set(ctx context.Context, level string) (*service.LogLevelResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, level)
+	ret0, _ := ret[0].(*service.LogLevelResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockLogLevelServiceMockRecorder) Set(ctx, level any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockLogLevelService)(nil).Set), ctx, level)
+}
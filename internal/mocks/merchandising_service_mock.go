@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/merchandising_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/merchandising_service.go -destination=internal/mocks/merchandising_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMerchandisingService is a mock of MerchandisingService interface.
+type MockMerchandisingService struct {
+	ctrl     *gomock.Controller
+	recorder *MockMerchandisingServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockMerchandisingServiceMockRecorder is the mock recorder for MockMerchandisingService.
+type MockMerchandisingServiceMockRecorder struct {
+	mock *MockMerchandisingService
+}
+
+// NewMockMerchandisingService creates a new mock instance.
+func NewMockMerchandisingService(ctrl *gomock.Controller) *MockMerchandisingService {
+	mock := &MockMerchandisingService{ctrl: ctrl}
+	mock.recorder = &MockMerchandisingServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMerchandisingService) EXPECT() *MockMerchandisingServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateRule mocks base method.
+func (m *MockMerchandisingService) CreateRule(ctx context.Context, req *service.SearchRuleReq) (*service.SearchRuleResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRule", ctx, req)
+	ret0, _ := ret[0].(*service.SearchRuleResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRule indicates an expected call of CreateRule.
+func (mr *MockMerchandisingServiceMockRecorder) CreateRule(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRule", reflect.TypeOf((*MockMerchandisingService)(nil).CreateRule), ctx, req)
+}
+
+// DeleteRule mocks base method.
+func (m *MockMerchandisingService) DeleteRule(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRule", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRule indicates an expected call of DeleteRule.
+func (mr *MockMerchandisingServiceMockRecorder) DeleteRule(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRule", reflect.TypeOf((*MockMerchandisingService)(nil).DeleteRule), ctx, id)
+}
+
+// ListRules mocks base method.
+func (m *MockMerchandisingService) ListRules(ctx context.Context) ([]service.SearchRuleResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRules", ctx)
+	ret0, _ := ret[0].([]service.SearchRuleResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRules indicates an expected call of ListRules.
+func (mr *MockMerchandisingServiceMockRecorder) ListRules(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRules", reflect.TypeOf((*MockMerchandisingService)(nil).ListRules), ctx)
+}
+
+// ListZeroResults mocks base method.
+func (m *MockMerchandisingService) ListZeroResults(ctx context.Context, offset, limit int) (*service.ZeroResultListResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListZeroResults", ctx, offset, limit)
+	ret0, _ := ret[0].(*service.ZeroResultListResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListZeroResults indicates an expected call of ListZeroResults.
+func (mr *MockMerchandisingServiceMockRecorder) ListZeroResults(ctx, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListZeroResults", reflect.TypeOf((*MockMerchandisingService)(nil).ListZeroResults), ctx, offset, limit)
+}
@@ -0,0 +1,334 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/notification_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/notification_repo.go -destination=internal/mocks/notification_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockNotificationRepository is a mock of NotificationRepository interface.
+type MockNotificationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockNotificationRepositoryMockRecorder is the mock recorder for MockNotificationRepository.
+type MockNotificationRepositoryMockRecorder struct {
+	mock *MockNotificationRepository
+}
+
+// NewMockNotificationRepository creates a new mock instance.
+func NewMockNotificationRepository(ctrl *gomock.Controller) *MockNotificationRepository {
+	mock := &MockNotificationRepository{ctrl: ctrl}
+	mock.recorder = &MockNotificationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationRepository) EXPECT() *MockNotificationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddToInbox mocks base method.
+func (m *MockNotificationRepository) AddToInbox(ctx context.Context, n *model.Notification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddToInbox", ctx, n)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddToInbox indicates an expected call of AddToInbox.
+func (mr *MockNotificationRepositoryMockRecorder) AddToInbox(ctx, n any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToInbox", reflect.TypeOf((*MockNotificationRepository)(nil).AddToInbox), ctx, n)
+}
+
+// CountUnread mocks base method.
+func (m *MockNotificationRepository) CountUnread(ctx context.Context, userID uint64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnread", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnread indicates an expected call of CountUnread.
+func (mr *MockNotificationRepositoryMockRecorder) CountUnread(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnread", reflect.TypeOf((*MockNotificationRepository)(nil).CountUnread), ctx, userID)
+}
+
+// CreateTemplateVersion mocks base method.
+func (m *MockNotificationRepository) CreateTemplateVersion(ctx context.Context, tmpl *model.NotificationTemplate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTemplateVersion", ctx, tmpl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTemplateVersion indicates an expected call of CreateTemplateVersion.
+func (mr *MockNotificationRepositoryMockRecorder) CreateTemplateVersion(ctx, tmpl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTemplateVersion", reflect.TypeOf((*MockNotificationRepository)(nil).CreateTemplateVersion), ctx, tmpl)
+}
+
+// DeletePushDevice mocks base method.
+func (m *MockNotificationRepository) DeletePushDevice(ctx context.Context, userID uint64, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePushDevice", ctx, userID, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePushDevice indicates an expected call of DeletePushDevice.
+func (mr *MockNotificationRepositoryMockRecorder) DeletePushDevice(ctx, userID, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePushDevice", reflect.TypeOf((*MockNotificationRepository)(nil).DeletePushDevice), ctx, userID, token)
+}
+
+// DeleteTemplate mocks base method.
+func (m *MockNotificationRepository) DeleteTemplate(ctx context.Context, kind, channel, locale string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTemplate", ctx, kind, channel, locale)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTemplate indicates an expected call of DeleteTemplate.
+func (mr *MockNotificationRepositoryMockRecorder) DeleteTemplate(ctx, kind, channel, locale any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTemplate", reflect.TypeOf((*MockNotificationRepository)(nil).DeleteTemplate), ctx, kind, channel, locale)
+}
+
+// GetPreference mocks base method.
+func (m *MockNotificationRepository) GetPreference(ctx context.Context, userID uint64) (*model.NotificationPreference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPreference", ctx, userID)
+	ret0, _ := ret[0].(*model.NotificationPreference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPreference indicates an expected call of GetPreference.
+func (mr *MockNotificationRepositoryMockRecorder) GetPreference(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPreference", reflect.TypeOf((*MockNotificationRepository)(nil).GetPreference), ctx, userID)
+}
+
+// GetTemplate mocks base method.
+func (m *MockNotificationRepository) GetTemplate(ctx context.Context, kind, channel, locale string) (*model.NotificationTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTemplate", ctx, kind, channel, locale)
+	ret0, _ := ret[0].(*model.NotificationTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTemplate indicates an expected call of GetTemplate.
+func (mr *MockNotificationRepositoryMockRecorder) GetTemplate(ctx, kind, channel, locale any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTemplate", reflect.TypeOf((*MockNotificationRepository)(nil).GetTemplate), ctx, kind, channel, locale)
+}
+
+// IsEmailSuppressed mocks base method.
+func (m *MockNotificationRepository) IsEmailSuppressed(ctx context.Context, email string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsEmailSuppressed", ctx, email)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsEmailSuppressed indicates an expected call of IsEmailSuppressed.
+func (mr *MockNotificationRepositoryMockRecorder) IsEmailSuppressed(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsEmailSuppressed", reflect.TypeOf((*MockNotificationRepository)(nil).IsEmailSuppressed), ctx, email)
+}
+
+// ListDeliveries mocks base method.
+func (m *MockNotificationRepository) ListDeliveries(ctx context.Context, userID uint64, limit int) ([]model.NotificationDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeliveries", ctx, userID, limit)
+	ret0, _ := ret[0].([]model.NotificationDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeliveries indicates an expected call of ListDeliveries.
+func (mr *MockNotificationRepositoryMockRecorder) ListDeliveries(ctx, userID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeliveries", reflect.TypeOf((*MockNotificationRepository)(nil).ListDeliveries), ctx, userID, limit)
+}
+
+// ListInbox mocks base method.
+func (m *MockNotificationRepository) ListInbox(ctx context.Context, userID uint64, unreadOnly bool, offset, limit int) ([]model.Notification, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInbox", ctx, userID, unreadOnly, offset, limit)
+	ret0, _ := ret[0].([]model.Notification)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListInbox indicates an expected call of ListInbox.
+func (mr *MockNotificationRepositoryMockRecorder) ListInbox(ctx, userID, unreadOnly, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInbox", reflect.TypeOf((*MockNotificationRepository)(nil).ListInbox), ctx, userID, unreadOnly, offset, limit)
+}
+
+// ListPushDevices mocks base method.
+func (m *MockNotificationRepository) ListPushDevices(ctx context.Context, userID uint64) ([]model.PushDevice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPushDevices", ctx, userID)
+	ret0, _ := ret[0].([]model.PushDevice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPushDevices indicates an expected call of ListPushDevices.
+func (mr *MockNotificationRepositoryMockRecorder) ListPushDevices(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPushDevices", reflect.TypeOf((*MockNotificationRepository)(nil).ListPushDevices), ctx, userID)
+}
+
+// ListTemplateVersions mocks base method.
+func (m *MockNotificationRepository) ListTemplateVersions(ctx context.Context, kind, channel, locale string) ([]model.NotificationTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTemplateVersions", ctx, kind, channel, locale)
+	ret0, _ := ret[0].([]model.NotificationTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTemplateVersions indicates an expected call of ListTemplateVersions.
+func (mr *MockNotificationRepositoryMockRecorder) ListTemplateVersions(ctx, kind, channel, locale any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTemplateVersions", reflect.TypeOf((*MockNotificationRepository)(nil).ListTemplateVersions), ctx, kind, channel, locale)
+}
+
+// ListTemplates mocks base method.
+func (m *MockNotificationRepository) ListTemplates(ctx context.Context) ([]model.NotificationTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTemplates", ctx)
+	ret0, _ := ret[0].([]model.NotificationTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTemplates indicates an expected call of ListTemplates.
+func (mr *MockNotificationRepositoryMockRecorder) ListTemplates(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTemplates", reflect.TypeOf((*MockNotificationRepository)(nil).ListTemplates), ctx)
+}
+
+// MarkAllRead mocks base method.
+func (m *MockNotificationRepository) MarkAllRead(ctx context.Context, userID uint64, at time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkAllRead", ctx, userID, at)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkAllRead indicates an expected call of MarkAllRead.
+func (mr *MockNotificationRepositoryMockRecorder) MarkAllRead(ctx, userID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAllRead", reflect.TypeOf((*MockNotificationRepository)(nil).MarkAllRead), ctx, userID, at)
+}
+
+// MarkRead mocks base method.
+func (m *MockNotificationRepository) MarkRead(ctx context.Context, userID, id uint64, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", ctx, userID, id, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRead indicates an expected call of MarkRead.
+func (mr *MockNotificationRepositoryMockRecorder) MarkRead(ctx, userID, id, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockNotificationRepository)(nil).MarkRead), ctx, userID, id, at)
+}
+
+// PrunePushDevice mocks base method.
+func (m *MockNotificationRepository) PrunePushDevice(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PrunePushDevice", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PrunePushDevice indicates an expected call of PrunePushDevice.
+func (mr *MockNotificationRepositoryMockRecorder) PrunePushDevice(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrunePushDevice", reflect.TypeOf((*MockNotificationRepository)(nil).PrunePushDevice), ctx, token)
+}
+
+// SaveDelivery mocks base method.
+func (m *MockNotificationRepository) SaveDelivery(ctx context.Context, delivery *model.NotificationDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveDelivery indicates an expected call of SaveDelivery.
+func (mr *MockNotificationRepositoryMockRecorder) SaveDelivery(ctx, delivery any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveDelivery", reflect.TypeOf((*MockNotificationRepository)(nil).SaveDelivery), ctx, delivery)
+}
+
+// SavePreference mocks base method.
+func (m *MockNotificationRepository) SavePreference(ctx context.Context, pref *model.NotificationPreference) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePreference", ctx, pref)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SavePreference indicates an expected call of SavePreference.
+func (mr *MockNotificationRepositoryMockRecorder) SavePreference(ctx, pref any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePreference", reflect.TypeOf((*MockNotificationRepository)(nil).SavePreference), ctx, pref)
+}
+
+// SavePushDevice mocks base method.
+func (m *MockNotificationRepository) SavePushDevice(ctx context.Context, device *model.PushDevice) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePushDevice", ctx, device)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SavePushDevice indicates an expected call of SavePushDevice.
+func (mr *MockNotificationRepositoryMockRecorder) SavePushDevice(ctx, device any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePushDevice", reflect.TypeOf((*MockNotificationRepository)(nil).SavePushDevice), ctx, device)
+}
+
+// SuppressEmail mocks base method.
+func (m *MockNotificationRepository) SuppressEmail(ctx context.Context, email, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuppressEmail", ctx, email, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SuppressEmail indicates an expected call of SuppressEmail.
+func (mr *MockNotificationRepositoryMockRecorder) SuppressEmail(ctx, email, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuppressEmail", reflect.TypeOf((*MockNotificationRepository)(nil).SuppressEmail), ctx, email, reason)
+}
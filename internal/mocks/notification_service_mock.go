@@ -0,0 +1,202 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/notification/notification.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/notification/notification.go -destination=internal/mocks/notification_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	notification "github.com/proyuen/go-mall/internal/service/notification"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockService is a mock of Service interface.
+type MockService struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockServiceMockRecorder is the mock recorder for MockService.
+type MockServiceMockRecorder struct {
+	mock *MockService
+}
+
+// NewMockService creates a new mock instance.
+func NewMockService(ctrl *gomock.Controller) *MockService {
+	mock := &MockService{ctrl: ctrl}
+	mock.recorder = &MockServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockService) EXPECT() *MockServiceMockRecorder {
+	return m.recorder
+}
+
+// Deliver mocks base method.
+func (m *MockService) Deliver(ctx context.Context, d *notification.Delivery) (notification.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Deliver", ctx, d)
+	ret0, _ := ret[0].(notification.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Deliver indicates an expected call of Deliver.
+func (mr *MockServiceMockRecorder) Deliver(ctx, d any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deliver", reflect.TypeOf((*MockService)(nil).Deliver), ctx, d)
+}
+
+// Deliveries mocks base method.
+func (m *MockService) Deliveries(ctx context.Context, userID uint64, limit int) ([]notification.DeliveryStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Deliveries", ctx, userID, limit)
+	ret0, _ := ret[0].([]notification.DeliveryStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Deliveries indicates an expected call of Deliveries.
+func (mr *MockServiceMockRecorder) Deliveries(ctx, userID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deliveries", reflect.TypeOf((*MockService)(nil).Deliveries), ctx, userID, limit)
+}
+
+// Inbox mocks base method.
+func (m *MockService) Inbox(ctx context.Context, userID uint64, unreadOnly bool, offset, limit int) (*notification.Inbox, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Inbox", ctx, userID, unreadOnly, offset, limit)
+	ret0, _ := ret[0].(*notification.Inbox)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Inbox indicates an expected call of Inbox.
+func (mr *MockServiceMockRecorder) Inbox(ctx, userID, unreadOnly, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Inbox", reflect.TypeOf((*MockService)(nil).Inbox), ctx, userID, unreadOnly, offset, limit)
+}
+
+// MarkAllRead mocks base method.
+func (m *MockService) MarkAllRead(ctx context.Context, userID uint64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkAllRead", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkAllRead indicates an expected call of MarkAllRead.
+func (mr *MockServiceMockRecorder) MarkAllRead(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAllRead", reflect.TypeOf((*MockService)(nil).MarkAllRead), ctx, userID)
+}
+
+// MarkRead mocks base method.
+func (m *MockService) MarkRead(ctx context.Context, userID, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRead indicates an expected call of MarkRead.
+func (mr *MockServiceMockRecorder) MarkRead(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockService)(nil).MarkRead), ctx, userID, id)
+}
+
+// Preferences mocks base method.
+func (m *MockService) Preferences(ctx context.Context, userID uint64) (*notification.Preferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Preferences", ctx, userID)
+	ret0, _ := ret[0].(*notification.Preferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Preferences indicates an expected call of Preferences.
+func (mr *MockServiceMockRecorder) Preferences(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Preferences", reflect.TypeOf((*MockService)(nil).Preferences), ctx, userID)
+}
+
+// RecordBounce mocks base method.
+func (m *MockService) RecordBounce(ctx context.Context, email, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordBounce", ctx, email, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordBounce indicates an expected call of RecordBounce.
+func (mr *MockServiceMockRecorder) RecordBounce(ctx, email, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordBounce", reflect.TypeOf((*MockService)(nil).RecordBounce), ctx, email, reason)
+}
+
+// RegisterPushDevice mocks base method.
+func (m *MockService) RegisterPushDevice(ctx context.Context, userID uint64, platform notification.Platform, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterPushDevice", ctx, userID, platform, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterPushDevice indicates an expected call of RegisterPushDevice.
+func (mr *MockServiceMockRecorder) RegisterPushDevice(ctx, userID, platform, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterPushDevice", reflect.TypeOf((*MockService)(nil).RegisterPushDevice), ctx, userID, platform, token)
+}
+
+// Track mocks base method.
+func (m *MockService) Track(ctx context.Context, d *notification.Delivery, status notification.Result, cause error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Track", ctx, d, status, cause)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Track indicates an expected call of Track.
+func (mr *MockServiceMockRecorder) Track(ctx, d, status, cause any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Track", reflect.TypeOf((*MockService)(nil).Track), ctx, d, status, cause)
+}
+
+// UnregisterPushDevice mocks base method.
+func (m *MockService) UnregisterPushDevice(ctx context.Context, userID uint64, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnregisterPushDevice", ctx, userID, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnregisterPushDevice indicates an expected call of UnregisterPushDevice.
+func (mr *MockServiceMockRecorder) UnregisterPushDevice(ctx, userID, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnregisterPushDevice", reflect.TypeOf((*MockService)(nil).UnregisterPushDevice), ctx, userID, token)
+}
+
+// UpdatePreferences mocks base method.
+func (m *MockService) UpdatePreferences(ctx context.Context, userID uint64, prefs notification.Preferences) (*notification.Preferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePreferences", ctx, userID, prefs)
+	ret0, _ := ret[0].(*notification.Preferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePreferences indicates an expected call of UpdatePreferences.
+func (mr *MockServiceMockRecorder) UpdatePreferences(ctx, userID, prefs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePreferences", reflect.TypeOf((*MockService)(nil).UpdatePreferences), ctx, userID, prefs)
+}
@@ -0,0 +1,133 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/notification_template_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/notification_template_service.go -destination=internal/mocks/notification_template_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	json "encoding/json"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	notification "github.com/proyuen/go-mall/internal/service/notification"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockNotificationTemplateService is a mock of NotificationTemplateService interface.
+type MockNotificationTemplateService struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationTemplateServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockNotificationTemplateServiceMockRecorder is the mock recorder for MockNotificationTemplateService.
+type MockNotificationTemplateServiceMockRecorder struct {
+	mock *MockNotificationTemplateService
+}
+
+// NewMockNotificationTemplateService creates a new mock instance.
+func NewMockNotificationTemplateService(ctrl *gomock.Controller) *MockNotificationTemplateService {
+	mock := &MockNotificationTemplateService{ctrl: ctrl}
+	mock.recorder = &MockNotificationTemplateServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationTemplateService) EXPECT() *MockNotificationTemplateServiceMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockNotificationTemplateService) Delete(ctx context.Context, kind notification.Kind, channel notification.Channel, locale string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, kind, channel, locale)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockNotificationTemplateServiceMockRecorder) Delete(ctx, kind, channel, locale any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockNotificationTemplateService)(nil).Delete), ctx, kind, channel, locale)
+}
+
+// List mocks base method.
+func (m *MockNotificationTemplateService) List(ctx context.Context) ([]service.NotificationTemplateResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]service.NotificationTemplateResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockNotificationTemplateServiceMockRecorder) List(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockNotificationTemplateService)(nil).List), ctx)
+}
+
+// Preview mocks base method.
+func (m *MockNotificationTemplateService) Preview(ctx context.Context, req *service.NotificationPreviewReq) (*notification.Preview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Preview", ctx, req)
+	ret0, _ := ret[0].(*notification.Preview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Preview indicates an expected call of Preview.
+func (mr *MockNotificationTemplateServiceMockRecorder) Preview(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Preview", reflect.TypeOf((*MockNotificationTemplateService)(nil).Preview), ctx, req)
+}
+
+// Save mocks base method.
+func (m *MockNotificationTemplateService) Save(ctx context.Context, req *service.NotificationTemplateReq) (*service.NotificationTemplateResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, req)
+	ret0, _ := ret[0].(*service.NotificationTemplateResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockNotificationTemplateServiceMockRecorder) Save(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockNotificationTemplateService)(nil).Save), ctx, req)
+}
+
+// TestSend mocks base method.
+func (m *MockNotificationTemplateService) TestSend(ctx context.Context, userID uint64, kind notification.Kind, channel notification.Channel, locale string, data json.RawMessage) (notification.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TestSend", ctx, userID, kind, channel, locale, data)
+	ret0, _ := ret[0].(notification.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TestSend indicates an expected call of TestSend.
+func (mr *MockNotificationTemplateServiceMockRecorder) TestSend(ctx, userID, kind, channel, locale, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TestSend", reflect.TypeOf((*MockNotificationTemplateService)(nil).TestSend), ctx, userID, kind, channel, locale, data)
+}
+
+// Versions mocks base method.
+func (m *MockNotificationTemplateService) Versions(ctx context.Context, kind notification.Kind, channel notification.Channel, locale string) ([]service.NotificationTemplateResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Versions", ctx, kind, channel, locale)
+	ret0, _ := ret[0].([]service.NotificationTemplateResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Versions indicates an expected call of Versions.
+func (mr *MockNotificationTemplateServiceMockRecorder) Versions(ctx, kind, channel, locale any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Versions", reflect.TypeOf((*MockNotificationTemplateService)(nil).Versions), ctx, kind, channel, locale)
+}
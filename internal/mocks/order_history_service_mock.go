@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/order_history.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/order_history.go -destination=internal/mocks/order_history_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOrderHistoryService is a mock of OrderHistoryService interface.
+type MockOrderHistoryService struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderHistoryServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockOrderHistoryServiceMockRecorder is the mock recorder for MockOrderHistoryService.
+type MockOrderHistoryServiceMockRecorder struct {
+	mock *MockOrderHistoryService
+}
+
+// NewMockOrderHistoryService creates a new mock instance.
+func NewMockOrderHistoryService(ctrl *gomock.Controller) *MockOrderHistoryService {
+	mock := &MockOrderHistoryService{ctrl: ctrl}
+	mock.recorder = &MockOrderHistoryServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderHistoryService) EXPECT() *MockOrderHistoryServiceMockRecorder {
+	return m.recorder
+}
+
+// Backfill mocks base method.
+func (m *MockOrderHistoryService) Backfill(ctx context.Context, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Backfill", ctx, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Backfill indicates an expected call of Backfill.
+func (mr *MockOrderHistoryServiceMockRecorder) Backfill(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Backfill", reflect.TypeOf((*MockOrderHistoryService)(nil).Backfill), ctx, limit)
+}
+
+// List mocks base method.
+func (m *MockOrderHistoryService) List(ctx context.Context, userID uint64, offset, limit int) (*service.OrderSummaryListResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, userID, offset, limit)
+	ret0, _ := ret[0].(*service.OrderSummaryListResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockOrderHistoryServiceMockRecorder) List(ctx, userID, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockOrderHistoryService)(nil).List), ctx, userID, offset, limit)
+}
+
+// Project mocks base method.
+func (m *MockOrderHistoryService) Project(ctx context.Context, orderID uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Project", ctx, orderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Project indicates an expected call of Project.
+func (mr *MockOrderHistoryServiceMockRecorder) Project(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Project", reflect.TypeOf((*MockOrderHistoryService)(nil).Project), ctx, orderID)
+}
@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/order_modification_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/order_modification_repo.go -destination=internal/mocks/order_modification_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOrderModificationRepository is a mock of OrderModificationRepository interface.
+type MockOrderModificationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderModificationRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockOrderModificationRepositoryMockRecorder is the mock recorder for MockOrderModificationRepository.
+type MockOrderModificationRepositoryMockRecorder struct {
+	mock *MockOrderModificationRepository
+}
+
+// NewMockOrderModificationRepository creates a new mock instance.
+func NewMockOrderModificationRepository(ctrl *gomock.Controller) *MockOrderModificationRepository {
+	mock := &MockOrderModificationRepository{ctrl: ctrl}
+	mock.recorder = &MockOrderModificationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderModificationRepository) EXPECT() *MockOrderModificationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockOrderModificationRepository) Create(ctx context.Context, modification *model.OrderModification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, modification)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockOrderModificationRepositoryMockRecorder) Create(ctx, modification any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOrderModificationRepository)(nil).Create), ctx, modification)
+}
+
+// ListByOrder mocks base method.
+func (m *MockOrderModificationRepository) ListByOrder(ctx context.Context, orderID uint64) ([]model.OrderModification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByOrder", ctx, orderID)
+	ret0, _ := ret[0].([]model.OrderModification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByOrder indicates an expected call of ListByOrder.
+func (mr *MockOrderModificationRepositoryMockRecorder) ListByOrder(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByOrder", reflect.TypeOf((*MockOrderModificationRepository)(nil).ListByOrder), ctx, orderID)
+}
@@ -0,0 +1,88 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/order_modification.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/order_modification.go -destination=internal/mocks/order_modification_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOrderModificationService is a mock of OrderModificationService interface.
+type MockOrderModificationService struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderModificationServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockOrderModificationServiceMockRecorder is the mock recorder for MockOrderModificationService.
+type MockOrderModificationServiceMockRecorder struct {
+	mock *MockOrderModificationService
+}
+
+// NewMockOrderModificationService creates a new mock instance.
+func NewMockOrderModificationService(ctrl *gomock.Controller) *MockOrderModificationService {
+	mock := &MockOrderModificationService{ctrl: ctrl}
+	mock.recorder = &MockOrderModificationServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderModificationService) EXPECT() *MockOrderModificationServiceMockRecorder {
+	return m.recorder
+}
+
+// ChangeQuantity mocks base method.
+func (m *MockOrderModificationService) ChangeQuantity(ctx context.Context, userID, orderID, itemID uint64, quantity int) (*service.OrderModificationResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeQuantity", ctx, userID, orderID, itemID, quantity)
+	ret0, _ := ret[0].(*service.OrderModificationResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeQuantity indicates an expected call of ChangeQuantity.
+func (mr *MockOrderModificationServiceMockRecorder) ChangeQuantity(ctx, userID, orderID, itemID, quantity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeQuantity", reflect.TypeOf((*MockOrderModificationService)(nil).ChangeQuantity), ctx, userID, orderID, itemID, quantity)
+}
+
+// ChangeShippingAddress mocks base method.
+func (m *MockOrderModificationService) ChangeShippingAddress(ctx context.Context, userID, orderID uint64, address model.Address) (*service.OrderModificationResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeShippingAddress", ctx, userID, orderID, address)
+	ret0, _ := ret[0].(*service.OrderModificationResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeShippingAddress indicates an expected call of ChangeShippingAddress.
+func (mr *MockOrderModificationServiceMockRecorder) ChangeShippingAddress(ctx, userID, orderID, address any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeShippingAddress", reflect.TypeOf((*MockOrderModificationService)(nil).ChangeShippingAddress), ctx, userID, orderID, address)
+}
+
+// List mocks base method.
+func (m *MockOrderModificationService) List(ctx context.Context, userID, orderID uint64) ([]service.OrderModificationResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, userID, orderID)
+	ret0, _ := ret[0].([]service.OrderModificationResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockOrderModificationServiceMockRecorder) List(ctx, userID, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockOrderModificationService)(nil).List), ctx, userID, orderID)
+}
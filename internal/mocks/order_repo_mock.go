@@ -12,8 +12,11 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/proyuen/go-mall/internal/model"
+	repository "github.com/proyuen/go-mall/internal/repository"
+	decimal "github.com/shopspring/decimal"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -41,6 +44,49 @@ func (m *MockOrderRepository) EXPECT() *MockOrderRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AddCapture mocks base method.
+func (m *MockOrderRepository) AddCapture(ctx context.Context, orderID uint64, amount decimal.Decimal, final bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCapture", ctx, orderID, amount, final)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddCapture indicates an expected call of AddCapture.
+func (mr *MockOrderRepositoryMockRecorder) AddCapture(ctx, orderID, amount, final any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCapture", reflect.TypeOf((*MockOrderRepository)(nil).AddCapture), ctx, orderID, amount, final)
+}
+
+// AllocateItems mocks base method.
+func (m *MockOrderRepository) AllocateItems(ctx context.Context, orderID uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllocateItems", ctx, orderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AllocateItems indicates an expected call of AllocateItems.
+func (mr *MockOrderRepositoryMockRecorder) AllocateItems(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocateItems", reflect.TypeOf((*MockOrderRepository)(nil).AllocateItems), ctx, orderID)
+}
+
+// CountByStatus mocks base method.
+func (m *MockOrderRepository) CountByStatus(ctx context.Context, filter repository.OrderFilter) (map[string]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByStatus", ctx, filter)
+	ret0, _ := ret[0].(map[string]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByStatus indicates an expected call of CountByStatus.
+func (mr *MockOrderRepositoryMockRecorder) CountByStatus(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByStatus", reflect.TypeOf((*MockOrderRepository)(nil).CountByStatus), ctx, filter)
+}
+
 // CreateOrder mocks base method.
 func (m *MockOrderRepository) CreateOrder(ctx context.Context, order *model.Order, items []model.OrderItem) error {
 	m.ctrl.T.Helper()
@@ -54,3 +100,266 @@ func (mr *MockOrderRepositoryMockRecorder) CreateOrder(ctx, order, items any) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockOrderRepository)(nil).CreateOrder), ctx, order, items)
 }
+
+// GetByID mocks base method.
+func (m *MockOrderRepository) GetByID(ctx context.Context, id uint64) (*model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockOrderRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockOrderRepository)(nil).GetByID), ctx, id)
+}
+
+// GetByIDForUpdate mocks base method.
+func (m *MockOrderRepository) GetByIDForUpdate(ctx context.Context, id uint64) (*model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDForUpdate", ctx, id)
+	ret0, _ := ret[0].(*model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDForUpdate indicates an expected call of GetByIDForUpdate.
+func (mr *MockOrderRepositoryMockRecorder) GetByIDForUpdate(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDForUpdate", reflect.TypeOf((*MockOrderRepository)(nil).GetByIDForUpdate), ctx, id)
+}
+
+// GetByOrderNumber mocks base method.
+func (m *MockOrderRepository) GetByOrderNumber(ctx context.Context, orderNumber string) (*model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByOrderNumber", ctx, orderNumber)
+	ret0, _ := ret[0].(*model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByOrderNumber indicates an expected call of GetByOrderNumber.
+func (mr *MockOrderRepositoryMockRecorder) GetByOrderNumber(ctx, orderNumber any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByOrderNumber", reflect.TypeOf((*MockOrderRepository)(nil).GetByOrderNumber), ctx, orderNumber)
+}
+
+// GetByPaymentRef mocks base method.
+func (m *MockOrderRepository) GetByPaymentRef(ctx context.Context, provider, paymentRef string) (*model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByPaymentRef", ctx, provider, paymentRef)
+	ret0, _ := ret[0].(*model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByPaymentRef indicates an expected call of GetByPaymentRef.
+func (mr *MockOrderRepositoryMockRecorder) GetByPaymentRef(ctx, provider, paymentRef any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByPaymentRef", reflect.TypeOf((*MockOrderRepository)(nil).GetByPaymentRef), ctx, provider, paymentRef)
+}
+
+// ListBackordered mocks base method.
+func (m *MockOrderRepository) ListBackordered(ctx context.Context, afterID uint64, limit int) ([]model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBackordered", ctx, afterID, limit)
+	ret0, _ := ret[0].([]model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBackordered indicates an expected call of ListBackordered.
+func (mr *MockOrderRepositoryMockRecorder) ListBackordered(ctx, afterID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBackordered", reflect.TypeOf((*MockOrderRepository)(nil).ListBackordered), ctx, afterID, limit)
+}
+
+// ListOrders mocks base method.
+func (m *MockOrderRepository) ListOrders(ctx context.Context, filter repository.OrderFilter, offset, limit int) ([]model.Order, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrders", ctx, filter, offset, limit)
+	ret0, _ := ret[0].([]model.Order)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListOrders indicates an expected call of ListOrders.
+func (mr *MockOrderRepositoryMockRecorder) ListOrders(ctx, filter, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrders", reflect.TypeOf((*MockOrderRepository)(nil).ListOrders), ctx, filter, offset, limit)
+}
+
+// ListPendingBefore mocks base method.
+func (m *MockOrderRepository) ListPendingBefore(ctx context.Context, before time.Time, afterID uint64, limit int) ([]model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingBefore", ctx, before, afterID, limit)
+	ret0, _ := ret[0].([]model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingBefore indicates an expected call of ListPendingBefore.
+func (mr *MockOrderRepositoryMockRecorder) ListPendingBefore(ctx, before, afterID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingBefore", reflect.TypeOf((*MockOrderRepository)(nil).ListPendingBefore), ctx, before, afterID, limit)
+}
+
+// ListSKUIDsChangedSince mocks base method.
+func (m *MockOrderRepository) ListSKUIDsChangedSince(ctx context.Context, skuIDs []uint64, since time.Time) ([]uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSKUIDsChangedSince", ctx, skuIDs, since)
+	ret0, _ := ret[0].([]uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSKUIDsChangedSince indicates an expected call of ListSKUIDsChangedSince.
+func (mr *MockOrderRepositoryMockRecorder) ListSKUIDsChangedSince(ctx, skuIDs, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSKUIDsChangedSince", reflect.TypeOf((*MockOrderRepository)(nil).ListSKUIDsChangedSince), ctx, skuIDs, since)
+}
+
+// ListUndelivered mocks base method.
+func (m *MockOrderRepository) ListUndelivered(ctx context.Context, afterID uint64, limit int) ([]model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUndelivered", ctx, afterID, limit)
+	ret0, _ := ret[0].([]model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUndelivered indicates an expected call of ListUndelivered.
+func (mr *MockOrderRepositoryMockRecorder) ListUndelivered(ctx, afterID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUndelivered", reflect.TypeOf((*MockOrderRepository)(nil).ListUndelivered), ctx, afterID, limit)
+}
+
+// MarkAuthorized mocks base method.
+func (m *MockOrderRepository) MarkAuthorized(ctx context.Context, orderID uint64, provider, paymentRef string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkAuthorized", ctx, orderID, provider, paymentRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkAuthorized indicates an expected call of MarkAuthorized.
+func (mr *MockOrderRepositoryMockRecorder) MarkAuthorized(ctx, orderID, provider, paymentRef any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAuthorized", reflect.TypeOf((*MockOrderRepository)(nil).MarkAuthorized), ctx, orderID, provider, paymentRef)
+}
+
+// MarkDelivered mocks base method.
+func (m *MockOrderRepository) MarkDelivered(ctx context.Context, orderID uint64, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDelivered", ctx, orderID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkDelivered indicates an expected call of MarkDelivered.
+func (mr *MockOrderRepositoryMockRecorder) MarkDelivered(ctx, orderID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDelivered", reflect.TypeOf((*MockOrderRepository)(nil).MarkDelivered), ctx, orderID, at)
+}
+
+// MarkPaid mocks base method.
+func (m *MockOrderRepository) MarkPaid(ctx context.Context, orderID uint64, provider, paymentRef string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkPaid", ctx, orderID, provider, paymentRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkPaid indicates an expected call of MarkPaid.
+func (mr *MockOrderRepositoryMockRecorder) MarkPaid(ctx, orderID, provider, paymentRef any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPaid", reflect.TypeOf((*MockOrderRepository)(nil).MarkPaid), ctx, orderID, provider, paymentRef)
+}
+
+// SetFulfillment mocks base method.
+func (m *MockOrderRepository) SetFulfillment(ctx context.Context, orderID uint64, fulfillment string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFulfillment", ctx, orderID, fulfillment)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetFulfillment indicates an expected call of SetFulfillment.
+func (mr *MockOrderRepositoryMockRecorder) SetFulfillment(ctx, orderID, fulfillment any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFulfillment", reflect.TypeOf((*MockOrderRepository)(nil).SetFulfillment), ctx, orderID, fulfillment)
+}
+
+// SetPaymentIntent mocks base method.
+func (m *MockOrderRepository) SetPaymentIntent(ctx context.Context, orderID uint64, provider string, intent model.PaymentIntent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPaymentIntent", ctx, orderID, provider, intent)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPaymentIntent indicates an expected call of SetPaymentIntent.
+func (mr *MockOrderRepositoryMockRecorder) SetPaymentIntent(ctx, orderID, provider, intent any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPaymentIntent", reflect.TypeOf((*MockOrderRepository)(nil).SetPaymentIntent), ctx, orderID, provider, intent)
+}
+
+// SummarizeSince mocks base method.
+func (m *MockOrderRepository) SummarizeSince(ctx context.Context, since time.Time) ([]repository.OrderStatusSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SummarizeSince", ctx, since)
+	ret0, _ := ret[0].([]repository.OrderStatusSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SummarizeSince indicates an expected call of SummarizeSince.
+func (mr *MockOrderRepositoryMockRecorder) SummarizeSince(ctx, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SummarizeSince", reflect.TypeOf((*MockOrderRepository)(nil).SummarizeSince), ctx, since)
+}
+
+// UpdateItemQuantity mocks base method.
+func (m *MockOrderRepository) UpdateItemQuantity(ctx context.Context, order *model.Order, item *model.OrderItem) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateItemQuantity", ctx, order, item)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateItemQuantity indicates an expected call of UpdateItemQuantity.
+func (mr *MockOrderRepositoryMockRecorder) UpdateItemQuantity(ctx, order, item any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateItemQuantity", reflect.TypeOf((*MockOrderRepository)(nil).UpdateItemQuantity), ctx, order, item)
+}
+
+// UpdateShippingAddress mocks base method.
+func (m *MockOrderRepository) UpdateShippingAddress(ctx context.Context, orderID uint64, address model.Address) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateShippingAddress", ctx, orderID, address)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateShippingAddress indicates an expected call of UpdateShippingAddress.
+func (mr *MockOrderRepositoryMockRecorder) UpdateShippingAddress(ctx, orderID, address any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateShippingAddress", reflect.TypeOf((*MockOrderRepository)(nil).UpdateShippingAddress), ctx, orderID, address)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockOrderRepository) UpdateStatus(ctx context.Context, orderID uint64, from, to string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, orderID, from, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockOrderRepositoryMockRecorder) UpdateStatus(ctx, orderID, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockOrderRepository)(nil).UpdateStatus), ctx, orderID, from, to)
+}
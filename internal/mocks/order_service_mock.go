@@ -12,7 +12,9 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
+	model "github.com/proyuen/go-mall/internal/model"
 	service "github.com/proyuen/go-mall/internal/service"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -41,6 +43,51 @@ func (m *MockOrderService) EXPECT() *MockOrderServiceMockRecorder {
 	return m.recorder
 }
 
+// CancelExpiredOrders mocks base method.
+func (m *MockOrderService) CancelExpiredOrders(ctx context.Context, createdBefore time.Time, batchSize int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelExpiredOrders", ctx, createdBefore, batchSize)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelExpiredOrders indicates an expected call of CancelExpiredOrders.
+func (mr *MockOrderServiceMockRecorder) CancelExpiredOrders(ctx, createdBefore, batchSize any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelExpiredOrders", reflect.TypeOf((*MockOrderService)(nil).CancelExpiredOrders), ctx, createdBefore, batchSize)
+}
+
+// ConfirmCheckoutSession mocks base method.
+func (m *MockOrderService) ConfirmCheckoutSession(ctx context.Context, userID uint64, id string) (*service.OrderCreateResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmCheckoutSession", ctx, userID, id)
+	ret0, _ := ret[0].(*service.OrderCreateResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmCheckoutSession indicates an expected call of ConfirmCheckoutSession.
+func (mr *MockOrderServiceMockRecorder) ConfirmCheckoutSession(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmCheckoutSession", reflect.TypeOf((*MockOrderService)(nil).ConfirmCheckoutSession), ctx, userID, id)
+}
+
+// CreateCheckoutSession mocks base method.
+func (m *MockOrderService) CreateCheckoutSession(ctx context.Context, req *service.OrderCreateReq) (*service.CheckoutSessionResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCheckoutSession", ctx, req)
+	ret0, _ := ret[0].(*service.CheckoutSessionResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCheckoutSession indicates an expected call of CreateCheckoutSession.
+func (mr *MockOrderServiceMockRecorder) CreateCheckoutSession(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCheckoutSession", reflect.TypeOf((*MockOrderService)(nil).CreateCheckoutSession), ctx, req)
+}
+
 // CreateOrder mocks base method.
 func (m *MockOrderService) CreateOrder(ctx context.Context, req *service.OrderCreateReq) (*service.OrderCreateResp, error) {
 	m.ctrl.T.Helper()
@@ -55,3 +102,48 @@ func (mr *MockOrderServiceMockRecorder) CreateOrder(ctx, req any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockOrderService)(nil).CreateOrder), ctx, req)
 }
+
+// FailOrder mocks base method.
+func (m *MockOrderService) FailOrder(ctx context.Context, orderID uint64) (*model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailOrder", ctx, orderID)
+	ret0, _ := ret[0].(*model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FailOrder indicates an expected call of FailOrder.
+func (mr *MockOrderServiceMockRecorder) FailOrder(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailOrder", reflect.TypeOf((*MockOrderService)(nil).FailOrder), ctx, orderID)
+}
+
+// GetCheckoutSession mocks base method.
+func (m *MockOrderService) GetCheckoutSession(ctx context.Context, userID uint64, id string) (*service.CheckoutSessionResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCheckoutSession", ctx, userID, id)
+	ret0, _ := ret[0].(*service.CheckoutSessionResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCheckoutSession indicates an expected call of GetCheckoutSession.
+func (mr *MockOrderServiceMockRecorder) GetCheckoutSession(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCheckoutSession", reflect.TypeOf((*MockOrderService)(nil).GetCheckoutSession), ctx, userID, id)
+}
+
+// ResumeCheckoutSagas mocks base method.
+func (m *MockOrderService) ResumeCheckoutSagas(ctx context.Context, opts service.CheckoutSagaOptions) (*service.CheckoutSagaReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResumeCheckoutSagas", ctx, opts)
+	ret0, _ := ret[0].(*service.CheckoutSagaReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResumeCheckoutSagas indicates an expected call of ResumeCheckoutSagas.
+func (mr *MockOrderServiceMockRecorder) ResumeCheckoutSagas(ctx, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeCheckoutSagas", reflect.TypeOf((*MockOrderService)(nil).ResumeCheckoutSagas), ctx, opts)
+}
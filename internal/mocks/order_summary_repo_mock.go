@@ -0,0 +1,87 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/order_summary_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/order_summary_repo.go -destination=internal/mocks/order_summary_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOrderSummaryRepository is a mock of OrderSummaryRepository interface.
+type MockOrderSummaryRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderSummaryRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockOrderSummaryRepositoryMockRecorder is the mock recorder for MockOrderSummaryRepository.
+type MockOrderSummaryRepositoryMockRecorder struct {
+	mock *MockOrderSummaryRepository
+}
+
+// NewMockOrderSummaryRepository creates a new mock instance.
+func NewMockOrderSummaryRepository(ctrl *gomock.Controller) *MockOrderSummaryRepository {
+	mock := &MockOrderSummaryRepository{ctrl: ctrl}
+	mock.recorder = &MockOrderSummaryRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderSummaryRepository) EXPECT() *MockOrderSummaryRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ListByUser mocks base method.
+func (m *MockOrderSummaryRepository) ListByUser(ctx context.Context, userID uint64, offset, limit int) ([]model.OrderSummary, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUser", ctx, userID, offset, limit)
+	ret0, _ := ret[0].([]model.OrderSummary)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListByUser indicates an expected call of ListByUser.
+func (mr *MockOrderSummaryRepositoryMockRecorder) ListByUser(ctx, userID, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockOrderSummaryRepository)(nil).ListByUser), ctx, userID, offset, limit)
+}
+
+// ListMissing mocks base method.
+func (m *MockOrderSummaryRepository) ListMissing(ctx context.Context, limit int) ([]uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMissing", ctx, limit)
+	ret0, _ := ret[0].([]uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMissing indicates an expected call of ListMissing.
+func (mr *MockOrderSummaryRepositoryMockRecorder) ListMissing(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMissing", reflect.TypeOf((*MockOrderSummaryRepository)(nil).ListMissing), ctx, limit)
+}
+
+// Save mocks base method.
+func (m *MockOrderSummaryRepository) Save(ctx context.Context, summary *model.OrderSummary) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, summary)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockOrderSummaryRepositoryMockRecorder) Save(ctx, summary any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockOrderSummaryRepository)(nil).Save), ctx, summary)
+}
@@ -0,0 +1,115 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/payment_method_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/payment_method_repo.go -destination=internal/mocks/payment_method_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPaymentMethodRepository is a mock of PaymentMethodRepository interface.
+type MockPaymentMethodRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentMethodRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockPaymentMethodRepositoryMockRecorder is the mock recorder for MockPaymentMethodRepository.
+type MockPaymentMethodRepositoryMockRecorder struct {
+	mock *MockPaymentMethodRepository
+}
+
+// NewMockPaymentMethodRepository creates a new mock instance.
+func NewMockPaymentMethodRepository(ctrl *gomock.Controller) *MockPaymentMethodRepository {
+	mock := &MockPaymentMethodRepository{ctrl: ctrl}
+	mock.recorder = &MockPaymentMethodRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentMethodRepository) EXPECT() *MockPaymentMethodRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockPaymentMethodRepository) Create(ctx context.Context, method *model.PaymentMethod) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, method)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPaymentMethodRepositoryMockRecorder) Create(ctx, method any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPaymentMethodRepository)(nil).Create), ctx, method)
+}
+
+// Delete mocks base method.
+func (m *MockPaymentMethodRepository) Delete(ctx context.Context, userID, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockPaymentMethodRepositoryMockRecorder) Delete(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPaymentMethodRepository)(nil).Delete), ctx, userID, id)
+}
+
+// GetByID mocks base method.
+func (m *MockPaymentMethodRepository) GetByID(ctx context.Context, userID, id uint64) (*model.PaymentMethod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, userID, id)
+	ret0, _ := ret[0].(*model.PaymentMethod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockPaymentMethodRepositoryMockRecorder) GetByID(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockPaymentMethodRepository)(nil).GetByID), ctx, userID, id)
+}
+
+// GetCustomerRef mocks base method.
+func (m *MockPaymentMethodRepository) GetCustomerRef(ctx context.Context, userID uint64, provider string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCustomerRef", ctx, userID, provider)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCustomerRef indicates an expected call of GetCustomerRef.
+func (mr *MockPaymentMethodRepositoryMockRecorder) GetCustomerRef(ctx, userID, provider any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCustomerRef", reflect.TypeOf((*MockPaymentMethodRepository)(nil).GetCustomerRef), ctx, userID, provider)
+}
+
+// ListByUser mocks base method.
+func (m *MockPaymentMethodRepository) ListByUser(ctx context.Context, userID uint64) ([]model.PaymentMethod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUser", ctx, userID)
+	ret0, _ := ret[0].([]model.PaymentMethod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByUser indicates an expected call of ListByUser.
+func (mr *MockPaymentMethodRepositoryMockRecorder) ListByUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockPaymentMethodRepository)(nil).ListByUser), ctx, userID)
+}
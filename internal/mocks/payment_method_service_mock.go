@@ -0,0 +1,191 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/payment_method_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/payment_method_service.go -destination=internal/mocks/payment_method_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	service "github.com/proyuen/go-mall/internal/service"
+	payment "github.com/proyuen/go-mall/internal/service/payment"
+	money "github.com/proyuen/go-mall/pkg/money"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPaymentMethodService is a mock of PaymentMethodService interface.
+type MockPaymentMethodService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentMethodServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockPaymentMethodServiceMockRecorder is the mock recorder for MockPaymentMethodService.
+type MockPaymentMethodServiceMockRecorder struct {
+	mock *MockPaymentMethodService
+}
+
+// NewMockPaymentMethodService creates a new mock instance.
+func NewMockPaymentMethodService(ctrl *gomock.Controller) *MockPaymentMethodService {
+	mock := &MockPaymentMethodService{ctrl: ctrl}
+	mock.recorder = &MockPaymentMethodServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentMethodService) EXPECT() *MockPaymentMethodServiceMockRecorder {
+	return m.recorder
+}
+
+// Authorize mocks base method.
+func (m *MockPaymentMethodService) Authorize(ctx context.Context, method *model.PaymentMethod, order *model.Order) (*payment.Charge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authorize", ctx, method, order)
+	ret0, _ := ret[0].(*payment.Charge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authorize indicates an expected call of Authorize.
+func (mr *MockPaymentMethodServiceMockRecorder) Authorize(ctx, method, order any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorize", reflect.TypeOf((*MockPaymentMethodService)(nil).Authorize), ctx, method, order)
+}
+
+// Charge mocks base method.
+func (m *MockPaymentMethodService) Charge(ctx context.Context, method *model.PaymentMethod, order *model.Order) (*payment.Charge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Charge", ctx, method, order)
+	ret0, _ := ret[0].(*payment.Charge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Charge indicates an expected call of Charge.
+func (mr *MockPaymentMethodServiceMockRecorder) Charge(ctx, method, order any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Charge", reflect.TypeOf((*MockPaymentMethodService)(nil).Charge), ctx, method, order)
+}
+
+// ChargeExtra mocks base method.
+func (m *MockPaymentMethodService) ChargeExtra(ctx context.Context, method *model.PaymentMethod, order *model.Order, amount money.Money, reference string) (*payment.Charge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChargeExtra", ctx, method, order, amount, reference)
+	ret0, _ := ret[0].(*payment.Charge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChargeExtra indicates an expected call of ChargeExtra.
+func (mr *MockPaymentMethodServiceMockRecorder) ChargeExtra(ctx, method, order, amount, reference any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChargeExtra", reflect.TypeOf((*MockPaymentMethodService)(nil).ChargeExtra), ctx, method, order, amount, reference)
+}
+
+// Delete mocks base method.
+func (m *MockPaymentMethodService) Delete(ctx context.Context, userID, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockPaymentMethodServiceMockRecorder) Delete(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPaymentMethodService)(nil).Delete), ctx, userID, id)
+}
+
+// Get mocks base method.
+func (m *MockPaymentMethodService) Get(ctx context.Context, userID, id uint64) (*model.PaymentMethod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, userID, id)
+	ret0, _ := ret[0].(*model.PaymentMethod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockPaymentMethodServiceMockRecorder) Get(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockPaymentMethodService)(nil).Get), ctx, userID, id)
+}
+
+// List mocks base method.
+func (m *MockPaymentMethodService) List(ctx context.Context, userID uint64) ([]service.PaymentMethodResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, userID)
+	ret0, _ := ret[0].([]service.PaymentMethodResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockPaymentMethodServiceMockRecorder) List(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockPaymentMethodService)(nil).List), ctx, userID)
+}
+
+// Refund mocks base method.
+func (m *MockPaymentMethodService) Refund(ctx context.Context, order *model.Order, paymentRef string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refund", ctx, order, paymentRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Refund indicates an expected call of Refund.
+func (mr *MockPaymentMethodServiceMockRecorder) Refund(ctx, order, paymentRef any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refund", reflect.TypeOf((*MockPaymentMethodService)(nil).Refund), ctx, order, paymentRef)
+}
+
+// RefundPart mocks base method.
+func (m *MockPaymentMethodService) RefundPart(ctx context.Context, order *model.Order, paymentRef string, amount money.Money, reference string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefundPart", ctx, order, paymentRef, amount, reference)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RefundPart indicates an expected call of RefundPart.
+func (mr *MockPaymentMethodServiceMockRecorder) RefundPart(ctx, order, paymentRef, amount, reference any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefundPart", reflect.TypeOf((*MockPaymentMethodService)(nil).RefundPart), ctx, order, paymentRef, amount, reference)
+}
+
+// Save mocks base method.
+func (m *MockPaymentMethodService) Save(ctx context.Context, req *service.SavePaymentMethodReq) (*service.PaymentMethodResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, req)
+	ret0, _ := ret[0].(*service.PaymentMethodResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockPaymentMethodServiceMockRecorder) Save(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockPaymentMethodService)(nil).Save), ctx, req)
+}
+
+// Void mocks base method.
+func (m *MockPaymentMethodService) Void(ctx context.Context, paymentRef string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Void", ctx, paymentRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Void indicates an expected call of Void.
+func (mr *MockPaymentMethodServiceMockRecorder) Void(ctx, paymentRef any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Void", reflect.TypeOf((*MockPaymentMethodService)(nil).Void), ctx, paymentRef)
+}
@@ -0,0 +1,418 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/payment/payment.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/payment/payment.go -destination=internal/mocks/payment_provider_mock.go -package=mocks -mock_names=Provider=MockPaymentProvider,Vault=MockPaymentVault,Capturer=MockPaymentCapturer
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	http "net/http"
+	reflect "reflect"
+
+	payment "github.com/proyuen/go-mall/internal/service/payment"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPaymentProvider is a mock of Provider interface.
+type MockPaymentProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentProviderMockRecorder
+	isgomock struct{}
+}
+
+// MockPaymentProviderMockRecorder is the mock recorder for MockPaymentProvider.
+type MockPaymentProviderMockRecorder struct {
+	mock *MockPaymentProvider
+}
+
+// NewMockPaymentProvider creates a new mock instance.
+func NewMockPaymentProvider(ctrl *gomock.Controller) *MockPaymentProvider {
+	mock := &MockPaymentProvider{ctrl: ctrl}
+	mock.recorder = &MockPaymentProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentProvider) EXPECT() *MockPaymentProviderMockRecorder {
+	return m.recorder
+}
+
+// Acknowledge mocks base method.
+func (m *MockPaymentProvider) Acknowledge(w http.ResponseWriter, err error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Acknowledge", w, err)
+}
+
+// Acknowledge indicates an expected call of Acknowledge.
+func (mr *MockPaymentProviderMockRecorder) Acknowledge(w, err any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Acknowledge", reflect.TypeOf((*MockPaymentProvider)(nil).Acknowledge), w, err)
+}
+
+// CreatePayment mocks base method.
+func (m *MockPaymentProvider) CreatePayment(ctx context.Context, req *payment.PaymentRequest) (*payment.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePayment", ctx, req)
+	ret0, _ := ret[0].(*payment.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePayment indicates an expected call of CreatePayment.
+func (mr *MockPaymentProviderMockRecorder) CreatePayment(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePayment", reflect.TypeOf((*MockPaymentProvider)(nil).CreatePayment), ctx, req)
+}
+
+// Name mocks base method.
+func (m *MockPaymentProvider) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockPaymentProviderMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockPaymentProvider)(nil).Name))
+}
+
+// ParseNotification mocks base method.
+func (m *MockPaymentProvider) ParseNotification(header http.Header, body []byte) (*payment.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseNotification", header, body)
+	ret0, _ := ret[0].(*payment.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParseNotification indicates an expected call of ParseNotification.
+func (mr *MockPaymentProviderMockRecorder) ParseNotification(header, body any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseNotification", reflect.TypeOf((*MockPaymentProvider)(nil).ParseNotification), header, body)
+}
+
+// MockPaymentVault is a mock of Vault interface.
+type MockPaymentVault struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentVaultMockRecorder
+	isgomock struct{}
+}
+
+// MockPaymentVaultMockRecorder is the mock recorder for MockPaymentVault.
+type MockPaymentVaultMockRecorder struct {
+	mock *MockPaymentVault
+}
+
+// NewMockPaymentVault creates a new mock instance.
+func NewMockPaymentVault(ctrl *gomock.Controller) *MockPaymentVault {
+	mock := &MockPaymentVault{ctrl: ctrl}
+	mock.recorder = &MockPaymentVaultMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentVault) EXPECT() *MockPaymentVaultMockRecorder {
+	return m.recorder
+}
+
+// Acknowledge mocks base method.
+func (m *MockPaymentVault) Acknowledge(w http.ResponseWriter, err error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Acknowledge", w, err)
+}
+
+// Acknowledge indicates an expected call of Acknowledge.
+func (mr *MockPaymentVaultMockRecorder) Acknowledge(w, err any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Acknowledge", reflect.TypeOf((*MockPaymentVault)(nil).Acknowledge), w, err)
+}
+
+// AttachMethod mocks base method.
+func (m *MockPaymentVault) AttachMethod(ctx context.Context, req *payment.AttachRequest) (*payment.Method, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AttachMethod", ctx, req)
+	ret0, _ := ret[0].(*payment.Method)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AttachMethod indicates an expected call of AttachMethod.
+func (mr *MockPaymentVaultMockRecorder) AttachMethod(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachMethod", reflect.TypeOf((*MockPaymentVault)(nil).AttachMethod), ctx, req)
+}
+
+// Charge mocks base method.
+func (m *MockPaymentVault) Charge(ctx context.Context, req *payment.ChargeRequest) (*payment.Charge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Charge", ctx, req)
+	ret0, _ := ret[0].(*payment.Charge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Charge indicates an expected call of Charge.
+func (mr *MockPaymentVaultMockRecorder) Charge(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Charge", reflect.TypeOf((*MockPaymentVault)(nil).Charge), ctx, req)
+}
+
+// CreatePayment mocks base method.
+func (m *MockPaymentVault) CreatePayment(ctx context.Context, req *payment.PaymentRequest) (*payment.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePayment", ctx, req)
+	ret0, _ := ret[0].(*payment.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePayment indicates an expected call of CreatePayment.
+func (mr *MockPaymentVaultMockRecorder) CreatePayment(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePayment", reflect.TypeOf((*MockPaymentVault)(nil).CreatePayment), ctx, req)
+}
+
+// DetachMethod mocks base method.
+func (m *MockPaymentVault) DetachMethod(ctx context.Context, methodRef string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DetachMethod", ctx, methodRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DetachMethod indicates an expected call of DetachMethod.
+func (mr *MockPaymentVaultMockRecorder) DetachMethod(ctx, methodRef any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetachMethod", reflect.TypeOf((*MockPaymentVault)(nil).DetachMethod), ctx, methodRef)
+}
+
+// Name mocks base method.
+func (m *MockPaymentVault) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockPaymentVaultMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockPaymentVault)(nil).Name))
+}
+
+// ParseNotification mocks base method.
+func (m *MockPaymentVault) ParseNotification(header http.Header, body []byte) (*payment.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseNotification", header, body)
+	ret0, _ := ret[0].(*payment.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParseNotification indicates an expected call of ParseNotification.
+func (mr *MockPaymentVaultMockRecorder) ParseNotification(header, body any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseNotification", reflect.TypeOf((*MockPaymentVault)(nil).ParseNotification), header, body)
+}
+
+// Refund mocks base method.
+func (m *MockPaymentVault) Refund(ctx context.Context, req *payment.RefundRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refund", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Refund indicates an expected call of Refund.
+func (mr *MockPaymentVaultMockRecorder) Refund(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refund", reflect.TypeOf((*MockPaymentVault)(nil).Refund), ctx, req)
+}
+
+// MockPaymentCapturer is a mock of Capturer interface.
+type MockPaymentCapturer struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentCapturerMockRecorder
+	isgomock struct{}
+}
+
+// MockPaymentCapturerMockRecorder is the mock recorder for MockPaymentCapturer.
+type MockPaymentCapturerMockRecorder struct {
+	mock *MockPaymentCapturer
+}
+
+// NewMockPaymentCapturer creates a new mock instance.
+func NewMockPaymentCapturer(ctrl *gomock.Controller) *MockPaymentCapturer {
+	mock := &MockPaymentCapturer{ctrl: ctrl}
+	mock.recorder = &MockPaymentCapturerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentCapturer) EXPECT() *MockPaymentCapturerMockRecorder {
+	return m.recorder
+}
+
+// Acknowledge mocks base method.
+func (m *MockPaymentCapturer) Acknowledge(w http.ResponseWriter, err error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Acknowledge", w, err)
+}
+
+// Acknowledge indicates an expected call of Acknowledge.
+func (mr *MockPaymentCapturerMockRecorder) Acknowledge(w, err any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Acknowledge", reflect.TypeOf((*MockPaymentCapturer)(nil).Acknowledge), w, err)
+}
+
+// AttachMethod mocks base method.
+func (m *MockPaymentCapturer) AttachMethod(ctx context.Context, req *payment.AttachRequest) (*payment.Method, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AttachMethod", ctx, req)
+	ret0, _ := ret[0].(*payment.Method)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AttachMethod indicates an expected call of AttachMethod.
+func (mr *MockPaymentCapturerMockRecorder) AttachMethod(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachMethod", reflect.TypeOf((*MockPaymentCapturer)(nil).AttachMethod), ctx, req)
+}
+
+// Authorize mocks base method.
+func (m *MockPaymentCapturer) Authorize(ctx context.Context, req *payment.ChargeRequest) (*payment.Charge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authorize", ctx, req)
+	ret0, _ := ret[0].(*payment.Charge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authorize indicates an expected call of Authorize.
+func (mr *MockPaymentCapturerMockRecorder) Authorize(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorize", reflect.TypeOf((*MockPaymentCapturer)(nil).Authorize), ctx, req)
+}
+
+// Capture mocks base method.
+func (m *MockPaymentCapturer) Capture(ctx context.Context, req *payment.CaptureRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Capture", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Capture indicates an expected call of Capture.
+func (mr *MockPaymentCapturerMockRecorder) Capture(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Capture", reflect.TypeOf((*MockPaymentCapturer)(nil).Capture), ctx, req)
+}
+
+// Charge mocks base method.
+func (m *MockPaymentCapturer) Charge(ctx context.Context, req *payment.ChargeRequest) (*payment.Charge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Charge", ctx, req)
+	ret0, _ := ret[0].(*payment.Charge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Charge indicates an expected call of Charge.
+func (mr *MockPaymentCapturerMockRecorder) Charge(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Charge", reflect.TypeOf((*MockPaymentCapturer)(nil).Charge), ctx, req)
+}
+
+// CreatePayment mocks base method.
+func (m *MockPaymentCapturer) CreatePayment(ctx context.Context, req *payment.PaymentRequest) (*payment.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePayment", ctx, req)
+	ret0, _ := ret[0].(*payment.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePayment indicates an expected call of CreatePayment.
+func (mr *MockPaymentCapturerMockRecorder) CreatePayment(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePayment", reflect.TypeOf((*MockPaymentCapturer)(nil).CreatePayment), ctx, req)
+}
+
+// DetachMethod mocks base method.
+func (m *MockPaymentCapturer) DetachMethod(ctx context.Context, methodRef string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DetachMethod", ctx, methodRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DetachMethod indicates an expected call of DetachMethod.
+func (mr *MockPaymentCapturerMockRecorder) DetachMethod(ctx, methodRef any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetachMethod", reflect.TypeOf((*MockPaymentCapturer)(nil).DetachMethod), ctx, methodRef)
+}
+
+// Name mocks base method.
+func (m *MockPaymentCapturer) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockPaymentCapturerMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockPaymentCapturer)(nil).Name))
+}
+
+// ParseNotification mocks base method.
+func (m *MockPaymentCapturer) ParseNotification(header http.Header, body []byte) (*payment.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseNotification", header, body)
+	ret0, _ := ret[0].(*payment.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParseNotification indicates an expected call of ParseNotification.
+func (mr *MockPaymentCapturerMockRecorder) ParseNotification(header, body any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseNotification", reflect.TypeOf((*MockPaymentCapturer)(nil).ParseNotification), header, body)
+}
+
+// Refund mocks base method.
+func (m *MockPaymentCapturer) Refund(ctx context.Context, req *payment.RefundRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refund", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Refund indicates an expected call of Refund.
+func (mr *MockPaymentCapturerMockRecorder) Refund(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refund", reflect.TypeOf((*MockPaymentCapturer)(nil).Refund), ctx, req)
+}
+
+// Void mocks base method.
+func (m *MockPaymentCapturer) Void(ctx context.Context, paymentRef string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Void", ctx, paymentRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Void indicates an expected call of Void.
+func (mr *MockPaymentCapturerMockRecorder) Void(ctx, paymentRef any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Void", reflect.TypeOf((*MockPaymentCapturer)(nil).Void), ctx, paymentRef)
+}
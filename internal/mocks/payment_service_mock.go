@@ -0,0 +1,88 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/payment_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/payment_service.go -destination=internal/mocks/payment_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	http "net/http"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	payment "github.com/proyuen/go-mall/internal/service/payment"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPaymentService is a mock of PaymentService interface.
+type MockPaymentService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockPaymentServiceMockRecorder is the mock recorder for MockPaymentService.
+type MockPaymentServiceMockRecorder struct {
+	mock *MockPaymentService
+}
+
+// NewMockPaymentService creates a new mock instance.
+func NewMockPaymentService(ctrl *gomock.Controller) *MockPaymentService {
+	mock := &MockPaymentService{ctrl: ctrl}
+	mock.recorder = &MockPaymentServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentService) EXPECT() *MockPaymentServiceMockRecorder {
+	return m.recorder
+}
+
+// HandleNotification mocks base method.
+func (m *MockPaymentService) HandleNotification(ctx context.Context, provider string, header http.Header, body []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleNotification", ctx, provider, header, body)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HandleNotification indicates an expected call of HandleNotification.
+func (mr *MockPaymentServiceMockRecorder) HandleNotification(ctx, provider, header, body any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleNotification", reflect.TypeOf((*MockPaymentService)(nil).HandleNotification), ctx, provider, header, body)
+}
+
+// Pay mocks base method.
+func (m *MockPaymentService) Pay(ctx context.Context, req *service.PayOrderReq) (*service.PaymentResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pay", ctx, req)
+	ret0, _ := ret[0].(*service.PaymentResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Pay indicates an expected call of Pay.
+func (mr *MockPaymentServiceMockRecorder) Pay(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pay", reflect.TypeOf((*MockPaymentService)(nil).Pay), ctx, req)
+}
+
+// Provider mocks base method.
+func (m *MockPaymentService) Provider(name string) (payment.Provider, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Provider", name)
+	ret0, _ := ret[0].(payment.Provider)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Provider indicates an expected call of Provider.
+func (mr *MockPaymentServiceMockRecorder) Provider(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Provider", reflect.TypeOf((*MockPaymentService)(nil).Provider), name)
+}
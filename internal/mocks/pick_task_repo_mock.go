@@ -0,0 +1,159 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/pick_task_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/pick_task_repo.go -destination=internal/mocks/pick_task_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	repository "github.com/proyuen/go-mall/internal/repository"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPickTaskRepository is a mock of PickTaskRepository interface.
+type MockPickTaskRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPickTaskRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockPickTaskRepositoryMockRecorder is the mock recorder for MockPickTaskRepository.
+type MockPickTaskRepositoryMockRecorder struct {
+	mock *MockPickTaskRepository
+}
+
+// NewMockPickTaskRepository creates a new mock instance.
+func NewMockPickTaskRepository(ctrl *gomock.Controller) *MockPickTaskRepository {
+	mock := &MockPickTaskRepository{ctrl: ctrl}
+	mock.recorder = &MockPickTaskRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPickTaskRepository) EXPECT() *MockPickTaskRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Claim mocks base method.
+func (m *MockPickTaskRepository) Claim(ctx context.Context, id, assigneeID uint64, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", ctx, id, assigneeID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockPickTaskRepositoryMockRecorder) Claim(ctx, id, assigneeID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockPickTaskRepository)(nil).Claim), ctx, id, assigneeID, at)
+}
+
+// Create mocks base method.
+func (m *MockPickTaskRepository) Create(ctx context.Context, tasks []model.PickTask) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, tasks)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPickTaskRepositoryMockRecorder) Create(ctx, tasks any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPickTaskRepository)(nil).Create), ctx, tasks)
+}
+
+// GetByID mocks base method.
+func (m *MockPickTaskRepository) GetByID(ctx context.Context, id uint64) (*model.PickTask, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*model.PickTask)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockPickTaskRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockPickTaskRepository)(nil).GetByID), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockPickTaskRepository) List(ctx context.Context, filter repository.PickTaskFilter, offset, limit int) ([]model.PickTask, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter, offset, limit)
+	ret0, _ := ret[0].([]model.PickTask)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockPickTaskRepositoryMockRecorder) List(ctx, filter, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockPickTaskRepository)(nil).List), ctx, filter, offset, limit)
+}
+
+// ListByOrder mocks base method.
+func (m *MockPickTaskRepository) ListByOrder(ctx context.Context, orderID uint64) ([]model.PickTask, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByOrder", ctx, orderID)
+	ret0, _ := ret[0].([]model.PickTask)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByOrder indicates an expected call of ListByOrder.
+func (mr *MockPickTaskRepositoryMockRecorder) ListByOrder(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByOrder", reflect.TypeOf((*MockPickTaskRepository)(nil).ListByOrder), ctx, orderID)
+}
+
+// MarkPacked mocks base method.
+func (m *MockPickTaskRepository) MarkPacked(ctx context.Context, id, assigneeID uint64, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkPacked", ctx, id, assigneeID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkPacked indicates an expected call of MarkPacked.
+func (mr *MockPickTaskRepositoryMockRecorder) MarkPacked(ctx, id, assigneeID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPacked", reflect.TypeOf((*MockPickTaskRepository)(nil).MarkPacked), ctx, id, assigneeID, at)
+}
+
+// SetItemQuantity mocks base method.
+func (m *MockPickTaskRepository) SetItemQuantity(ctx context.Context, orderItemID uint64, quantity int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetItemQuantity", ctx, orderItemID, quantity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetItemQuantity indicates an expected call of SetItemQuantity.
+func (mr *MockPickTaskRepositoryMockRecorder) SetItemQuantity(ctx, orderItemID, quantity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetItemQuantity", reflect.TypeOf((*MockPickTaskRepository)(nil).SetItemQuantity), ctx, orderItemID, quantity)
+}
+
+// SetShipment mocks base method.
+func (m *MockPickTaskRepository) SetShipment(ctx context.Context, ids []uint64, shipmentID uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetShipment", ctx, ids, shipmentID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetShipment indicates an expected call of SetShipment.
+func (mr *MockPickTaskRepositoryMockRecorder) SetShipment(ctx, ids, shipmentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetShipment", reflect.TypeOf((*MockPickTaskRepository)(nil).SetShipment), ctx, ids, shipmentID)
+}
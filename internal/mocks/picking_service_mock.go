@@ -0,0 +1,103 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/picking_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/picking_service.go -destination=internal/mocks/picking_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	repository "github.com/proyuen/go-mall/internal/repository"
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPickingService is a mock of PickingService interface.
+type MockPickingService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPickingServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockPickingServiceMockRecorder is the mock recorder for MockPickingService.
+type MockPickingServiceMockRecorder struct {
+	mock *MockPickingService
+}
+
+// NewMockPickingService creates a new mock instance.
+func NewMockPickingService(ctrl *gomock.Controller) *MockPickingService {
+	mock := &MockPickingService{ctrl: ctrl}
+	mock.recorder = &MockPickingServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPickingService) EXPECT() *MockPickingServiceMockRecorder {
+	return m.recorder
+}
+
+// Claim mocks base method.
+func (m *MockPickingService) Claim(ctx context.Context, userID, id uint64) (*service.PickTaskResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", ctx, userID, id)
+	ret0, _ := ret[0].(*service.PickTaskResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockPickingServiceMockRecorder) Claim(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockPickingService)(nil).Claim), ctx, userID, id)
+}
+
+// Complete mocks base method.
+func (m *MockPickingService) Complete(ctx context.Context, userID, id uint64) (*service.PickTaskResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", ctx, userID, id)
+	ret0, _ := ret[0].(*service.PickTaskResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockPickingServiceMockRecorder) Complete(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockPickingService)(nil).Complete), ctx, userID, id)
+}
+
+// GenerateTasks mocks base method.
+func (m *MockPickingService) GenerateTasks(ctx context.Context, orderID uint64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateTasks", ctx, orderID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateTasks indicates an expected call of GenerateTasks.
+func (mr *MockPickingServiceMockRecorder) GenerateTasks(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateTasks", reflect.TypeOf((*MockPickingService)(nil).GenerateTasks), ctx, orderID)
+}
+
+// List mocks base method.
+func (m *MockPickingService) List(ctx context.Context, filter repository.PickTaskFilter, offset, limit int) ([]service.PickTaskResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter, offset, limit)
+	ret0, _ := ret[0].([]service.PickTaskResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockPickingServiceMockRecorder) List(ctx, filter, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockPickingService)(nil).List), ctx, filter, offset, limit)
+}
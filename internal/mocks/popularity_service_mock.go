@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/popularity_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/popularity_service.go -destination=internal/mocks/popularity_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPopularityService is a mock of PopularityService interface.
+type MockPopularityService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPopularityServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockPopularityServiceMockRecorder is the mock recorder for MockPopularityService.
+type MockPopularityServiceMockRecorder struct {
+	mock *MockPopularityService
+}
+
+// NewMockPopularityService creates a new mock instance.
+func NewMockPopularityService(ctrl *gomock.Controller) *MockPopularityService {
+	mock := &MockPopularityService{ctrl: ctrl}
+	mock.recorder = &MockPopularityServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPopularityService) EXPECT() *MockPopularityServiceMockRecorder {
+	return m.recorder
+}
+
+// FlushViews mocks base method.
+func (m *MockPopularityService) FlushViews(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushViews", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FlushViews indicates an expected call of FlushViews.
+func (mr *MockPopularityServiceMockRecorder) FlushViews(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushViews", reflect.TypeOf((*MockPopularityService)(nil).FlushViews), ctx)
+}
+
+// ListPopular mocks base method.
+func (m *MockPopularityService) ListPopular(ctx context.Context, limit int) ([]service.ProductResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPopular", ctx, limit)
+	ret0, _ := ret[0].([]service.ProductResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPopular indicates an expected call of ListPopular.
+func (mr *MockPopularityServiceMockRecorder) ListPopular(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPopular", reflect.TypeOf((*MockPopularityService)(nil).ListPopular), ctx, limit)
+}
+
+// RecordView mocks base method.
+func (m *MockPopularityService) RecordView(ctx context.Context, spuID uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordView", ctx, spuID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordView indicates an expected call of RecordView.
+func (mr *MockPopularityServiceMockRecorder) RecordView(ctx, spuID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordView", reflect.TypeOf((*MockPopularityService)(nil).RecordView), ctx, spuID)
+}
+
+// Rescore mocks base method.
+func (m *MockPopularityService) Rescore(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rescore", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rescore indicates an expected call of Rescore.
+func (mr *MockPopularityServiceMockRecorder) Rescore(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rescore", reflect.TypeOf((*MockPopularityService)(nil).Rescore), ctx)
+}
@@ -0,0 +1,131 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/price_schedule_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/price_schedule_repo.go -destination=internal/mocks/price_schedule_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPriceScheduleRepository is a mock of PriceScheduleRepository interface.
+type MockPriceScheduleRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPriceScheduleRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockPriceScheduleRepositoryMockRecorder is the mock recorder for MockPriceScheduleRepository.
+type MockPriceScheduleRepositoryMockRecorder struct {
+	mock *MockPriceScheduleRepository
+}
+
+// NewMockPriceScheduleRepository creates a new mock instance.
+func NewMockPriceScheduleRepository(ctrl *gomock.Controller) *MockPriceScheduleRepository {
+	mock := &MockPriceScheduleRepository{ctrl: ctrl}
+	mock.recorder = &MockPriceScheduleRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPriceScheduleRepository) EXPECT() *MockPriceScheduleRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockPriceScheduleRepository) Create(ctx context.Context, schedule *model.PriceSchedule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, schedule)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPriceScheduleRepositoryMockRecorder) Create(ctx, schedule any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPriceScheduleRepository)(nil).Create), ctx, schedule)
+}
+
+// GetByID mocks base method.
+func (m *MockPriceScheduleRepository) GetByID(ctx context.Context, id uint64) (*model.PriceSchedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*model.PriceSchedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockPriceScheduleRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockPriceScheduleRepository)(nil).GetByID), ctx, id)
+}
+
+// HasOverlappingSale mocks base method.
+func (m *MockPriceScheduleRepository) HasOverlappingSale(ctx context.Context, skuID uint64, startsAt, endsAt time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasOverlappingSale", ctx, skuID, startsAt, endsAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasOverlappingSale indicates an expected call of HasOverlappingSale.
+func (mr *MockPriceScheduleRepositoryMockRecorder) HasOverlappingSale(ctx, skuID, startsAt, endsAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasOverlappingSale", reflect.TypeOf((*MockPriceScheduleRepository)(nil).HasOverlappingSale), ctx, skuID, startsAt, endsAt)
+}
+
+// ListBySKU mocks base method.
+func (m *MockPriceScheduleRepository) ListBySKU(ctx context.Context, skuID uint64) ([]model.PriceSchedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBySKU", ctx, skuID)
+	ret0, _ := ret[0].([]model.PriceSchedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBySKU indicates an expected call of ListBySKU.
+func (mr *MockPriceScheduleRepositoryMockRecorder) ListBySKU(ctx, skuID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBySKU", reflect.TypeOf((*MockPriceScheduleRepository)(nil).ListBySKU), ctx, skuID)
+}
+
+// ListDue mocks base method.
+func (m *MockPriceScheduleRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]model.PriceSchedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDue", ctx, now, limit)
+	ret0, _ := ret[0].([]model.PriceSchedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDue indicates an expected call of ListDue.
+func (mr *MockPriceScheduleRepositoryMockRecorder) ListDue(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDue", reflect.TypeOf((*MockPriceScheduleRepository)(nil).ListDue), ctx, now, limit)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockPriceScheduleRepository) UpdateStatus(ctx context.Context, id uint64, from, to string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, id, from, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockPriceScheduleRepositoryMockRecorder) UpdateStatus(ctx, id, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockPriceScheduleRepository)(nil).UpdateStatus), ctx, id, from, to)
+}
@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/price_schedule_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/price_schedule_service.go -destination=internal/mocks/price_schedule_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPriceScheduleService is a mock of PriceScheduleService interface.
+type MockPriceScheduleService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPriceScheduleServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockPriceScheduleServiceMockRecorder is the mock recorder for MockPriceScheduleService.
+type MockPriceScheduleServiceMockRecorder struct {
+	mock *MockPriceScheduleService
+}
+
+// NewMockPriceScheduleService creates a new mock instance.
+func NewMockPriceScheduleService(ctrl *gomock.Controller) *MockPriceScheduleService {
+	mock := &MockPriceScheduleService{ctrl: ctrl}
+	mock.recorder = &MockPriceScheduleServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPriceScheduleService) EXPECT() *MockPriceScheduleServiceMockRecorder {
+	return m.recorder
+}
+
+// ApplyDue mocks base method.
+func (m *MockPriceScheduleService) ApplyDue(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyDue", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyDue indicates an expected call of ApplyDue.
+func (mr *MockPriceScheduleServiceMockRecorder) ApplyDue(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyDue", reflect.TypeOf((*MockPriceScheduleService)(nil).ApplyDue), ctx)
+}
+
+// Cancel mocks base method.
+func (m *MockPriceScheduleService) Cancel(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockPriceScheduleServiceMockRecorder) Cancel(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockPriceScheduleService)(nil).Cancel), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockPriceScheduleService) List(ctx context.Context, skuID uint64) ([]service.PriceScheduleResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, skuID)
+	ret0, _ := ret[0].([]service.PriceScheduleResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockPriceScheduleServiceMockRecorder) List(ctx, skuID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockPriceScheduleService)(nil).List), ctx, skuID)
+}
+
+// Schedule mocks base method.
+func (m *MockPriceScheduleService) Schedule(ctx context.Context, skuID uint64, req *service.PriceScheduleReq) (*service.PriceScheduleResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Schedule", ctx, skuID, req)
+	ret0, _ := ret[0].(*service.PriceScheduleResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Schedule indicates an expected call of Schedule.
+func (mr *MockPriceScheduleServiceMockRecorder) Schedule(ctx, skuID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Schedule", reflect.TypeOf((*MockPriceScheduleService)(nil).Schedule), ctx, skuID, req)
+}
@@ -0,0 +1,100 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/price_tier_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/price_tier_repo.go -destination=internal/mocks/price_tier_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPriceTierRepository is a mock of PriceTierRepository interface.
+type MockPriceTierRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPriceTierRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockPriceTierRepositoryMockRecorder is the mock recorder for MockPriceTierRepository.
+type MockPriceTierRepositoryMockRecorder struct {
+	mock *MockPriceTierRepository
+}
+
+// NewMockPriceTierRepository creates a new mock instance.
+func NewMockPriceTierRepository(ctrl *gomock.Controller) *MockPriceTierRepository {
+	mock := &MockPriceTierRepository{ctrl: ctrl}
+	mock.recorder = &MockPriceTierRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPriceTierRepository) EXPECT() *MockPriceTierRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockPriceTierRepository) Delete(ctx context.Context, skuID uint64, group string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, skuID, group)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockPriceTierRepositoryMockRecorder) Delete(ctx, skuID, group any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPriceTierRepository)(nil).Delete), ctx, skuID, group)
+}
+
+// ListBySKU mocks base method.
+func (m *MockPriceTierRepository) ListBySKU(ctx context.Context, skuID uint64) ([]model.SKUPriceTier, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBySKU", ctx, skuID)
+	ret0, _ := ret[0].([]model.SKUPriceTier)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBySKU indicates an expected call of ListBySKU.
+func (mr *MockPriceTierRepositoryMockRecorder) ListBySKU(ctx, skuID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBySKU", reflect.TypeOf((*MockPriceTierRepository)(nil).ListBySKU), ctx, skuID)
+}
+
+// ListBySKUIDs mocks base method.
+func (m *MockPriceTierRepository) ListBySKUIDs(ctx context.Context, skuIDs []uint64, group string) ([]model.SKUPriceTier, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBySKUIDs", ctx, skuIDs, group)
+	ret0, _ := ret[0].([]model.SKUPriceTier)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBySKUIDs indicates an expected call of ListBySKUIDs.
+func (mr *MockPriceTierRepositoryMockRecorder) ListBySKUIDs(ctx, skuIDs, group any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBySKUIDs", reflect.TypeOf((*MockPriceTierRepository)(nil).ListBySKUIDs), ctx, skuIDs, group)
+}
+
+// Save mocks base method.
+func (m *MockPriceTierRepository) Save(ctx context.Context, tier *model.SKUPriceTier) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, tier)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockPriceTierRepositoryMockRecorder) Save(ctx, tier any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockPriceTierRepository)(nil).Save), ctx, tier)
+}
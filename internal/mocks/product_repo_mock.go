@@ -12,8 +12,11 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/proyuen/go-mall/internal/model"
+	repository "github.com/proyuen/go-mall/internal/repository"
+	decimal "github.com/shopspring/decimal"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -41,6 +44,66 @@ func (m *MockProductRepository) EXPECT() *MockProductRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AddSPUViews mocks base method.
+func (m *MockProductRepository) AddSPUViews(ctx context.Context, views map[uint64]int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddSPUViews", ctx, views)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddSPUViews indicates an expected call of AddSPUViews.
+func (mr *MockProductRepositoryMockRecorder) AddSPUViews(ctx, views any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSPUViews", reflect.TypeOf((*MockProductRepository)(nil).AddSPUViews), ctx, views)
+}
+
+// CountSPUsByAttribute mocks base method.
+func (m *MockProductRepository) CountSPUsByAttribute(ctx context.Context) ([]repository.AttributeValueCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountSPUsByAttribute", ctx)
+	ret0, _ := ret[0].([]repository.AttributeValueCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountSPUsByAttribute indicates an expected call of CountSPUsByAttribute.
+func (mr *MockProductRepositoryMockRecorder) CountSPUsByAttribute(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountSPUsByAttribute", reflect.TypeOf((*MockProductRepository)(nil).CountSPUsByAttribute), ctx)
+}
+
+// CountSPUsByCategory mocks base method.
+func (m *MockProductRepository) CountSPUsByCategory(ctx context.Context) ([]repository.CategoryCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountSPUsByCategory", ctx)
+	ret0, _ := ret[0].([]repository.CategoryCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountSPUsByCategory indicates an expected call of CountSPUsByCategory.
+func (mr *MockProductRepositoryMockRecorder) CountSPUsByCategory(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountSPUsByCategory", reflect.TypeOf((*MockProductRepository)(nil).CountSPUsByCategory), ctx)
+}
+
+// CountSPUsByPrice mocks base method.
+func (m *MockProductRepository) CountSPUsByPrice(ctx context.Context, bounds []decimal.Decimal) ([]repository.PriceBucketCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountSPUsByPrice", ctx, bounds)
+	ret0, _ := ret[0].([]repository.PriceBucketCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountSPUsByPrice indicates an expected call of CountSPUsByPrice.
+func (mr *MockProductRepositoryMockRecorder) CountSPUsByPrice(ctx, bounds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountSPUsByPrice", reflect.TypeOf((*MockProductRepository)(nil).CountSPUsByPrice), ctx, bounds)
+}
+
 // CreateSKU mocks base method.
 func (m *MockProductRepository) CreateSKU(ctx context.Context, sku *model.SKU) error {
 	m.ctrl.T.Helper()
@@ -84,6 +147,51 @@ func (mr *MockProductRepositoryMockRecorder) GetSKUByID(ctx, id any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSKUByID", reflect.TypeOf((*MockProductRepository)(nil).GetSKUByID), ctx, id)
 }
 
+// GetSKUForUpdate mocks base method.
+func (m *MockProductRepository) GetSKUForUpdate(ctx context.Context, id uint64) (*model.SKU, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSKUForUpdate", ctx, id)
+	ret0, _ := ret[0].(*model.SKU)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSKUForUpdate indicates an expected call of GetSKUForUpdate.
+func (mr *MockProductRepositoryMockRecorder) GetSKUForUpdate(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSKUForUpdate", reflect.TypeOf((*MockProductRepository)(nil).GetSKUForUpdate), ctx, id)
+}
+
+// GetSKUsByIDs mocks base method.
+func (m *MockProductRepository) GetSKUsByIDs(ctx context.Context, ids []uint64) ([]model.SKU, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSKUsByIDs", ctx, ids)
+	ret0, _ := ret[0].([]model.SKU)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSKUsByIDs indicates an expected call of GetSKUsByIDs.
+func (mr *MockProductRepositoryMockRecorder) GetSKUsByIDs(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSKUsByIDs", reflect.TypeOf((*MockProductRepository)(nil).GetSKUsByIDs), ctx, ids)
+}
+
+// GetSKUsForUpdate mocks base method.
+func (m *MockProductRepository) GetSKUsForUpdate(ctx context.Context, ids []uint64) ([]model.SKU, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSKUsForUpdate", ctx, ids)
+	ret0, _ := ret[0].([]model.SKU)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSKUsForUpdate indicates an expected call of GetSKUsForUpdate.
+func (mr *MockProductRepositoryMockRecorder) GetSKUsForUpdate(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSKUsForUpdate", reflect.TypeOf((*MockProductRepository)(nil).GetSKUsForUpdate), ctx, ids)
+}
+
 // GetSPUByID mocks base method.
 func (m *MockProductRepository) GetSPUByID(ctx context.Context, id uint64) (*model.SPU, error) {
 	m.ctrl.T.Helper()
@@ -99,6 +207,141 @@ func (mr *MockProductRepositoryMockRecorder) GetSPUByID(ctx, id any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSPUByID", reflect.TypeOf((*MockProductRepository)(nil).GetSPUByID), ctx, id)
 }
 
+// GetSPUsByIDs mocks base method.
+func (m *MockProductRepository) GetSPUsByIDs(ctx context.Context, ids []uint64) ([]model.SPU, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSPUsByIDs", ctx, ids)
+	ret0, _ := ret[0].([]model.SPU)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSPUsByIDs indicates an expected call of GetSPUsByIDs.
+func (mr *MockProductRepositoryMockRecorder) GetSPUsByIDs(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSPUsByIDs", reflect.TypeOf((*MockProductRepository)(nil).GetSPUsByIDs), ctx, ids)
+}
+
+// ListLowStockSKUs mocks base method.
+func (m *MockProductRepository) ListLowStockSKUs(ctx context.Context, threshold, limit int) ([]model.SKU, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLowStockSKUs", ctx, threshold, limit)
+	ret0, _ := ret[0].([]model.SKU)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLowStockSKUs indicates an expected call of ListLowStockSKUs.
+func (mr *MockProductRepositoryMockRecorder) ListLowStockSKUs(ctx, threshold, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLowStockSKUs", reflect.TypeOf((*MockProductRepository)(nil).ListLowStockSKUs), ctx, threshold, limit)
+}
+
+// ListPopularSPUs mocks base method.
+func (m *MockProductRepository) ListPopularSPUs(ctx context.Context, limit int) ([]model.SPU, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPopularSPUs", ctx, limit)
+	ret0, _ := ret[0].([]model.SPU)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPopularSPUs indicates an expected call of ListPopularSPUs.
+func (mr *MockProductRepositoryMockRecorder) ListPopularSPUs(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPopularSPUs", reflect.TypeOf((*MockProductRepository)(nil).ListPopularSPUs), ctx, limit)
+}
+
+// ListProductOptions mocks base method.
+func (m *MockProductRepository) ListProductOptions(ctx context.Context, spuIDs []uint64) ([]model.ProductOption, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListProductOptions", ctx, spuIDs)
+	ret0, _ := ret[0].([]model.ProductOption)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListProductOptions indicates an expected call of ListProductOptions.
+func (mr *MockProductRepositoryMockRecorder) ListProductOptions(ctx, spuIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProductOptions", reflect.TypeOf((*MockProductRepository)(nil).ListProductOptions), ctx, spuIDs)
+}
+
+// ListSKUComponents mocks base method.
+func (m *MockProductRepository) ListSKUComponents(ctx context.Context, bundleSKUIDs []uint64) ([]model.SKUComponent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSKUComponents", ctx, bundleSKUIDs)
+	ret0, _ := ret[0].([]model.SKUComponent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSKUComponents indicates an expected call of ListSKUComponents.
+func (mr *MockProductRepositoryMockRecorder) ListSKUComponents(ctx, bundleSKUIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSKUComponents", reflect.TypeOf((*MockProductRepository)(nil).ListSKUComponents), ctx, bundleSKUIDs)
+}
+
+// ListSKUIDsByCategories mocks base method.
+func (m *MockProductRepository) ListSKUIDsByCategories(ctx context.Context, categoryIDs []uint64) ([]uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSKUIDsByCategories", ctx, categoryIDs)
+	ret0, _ := ret[0].([]uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSKUIDsByCategories indicates an expected call of ListSKUIDsByCategories.
+func (mr *MockProductRepositoryMockRecorder) ListSKUIDsByCategories(ctx, categoryIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSKUIDsByCategories", reflect.TypeOf((*MockProductRepository)(nil).ListSKUIDsByCategories), ctx, categoryIDs)
+}
+
+// ListSKUStock mocks base method.
+func (m *MockProductRepository) ListSKUStock(ctx context.Context, afterID uint64, limit int) ([]model.SKU, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSKUStock", ctx, afterID, limit)
+	ret0, _ := ret[0].([]model.SKU)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSKUStock indicates an expected call of ListSKUStock.
+func (mr *MockProductRepositoryMockRecorder) ListSKUStock(ctx, afterID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSKUStock", reflect.TypeOf((*MockProductRepository)(nil).ListSKUStock), ctx, afterID, limit)
+}
+
+// ListSKUsBySPUIDs mocks base method.
+func (m *MockProductRepository) ListSKUsBySPUIDs(ctx context.Context, spuIDs []uint64) ([]model.SKU, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSKUsBySPUIDs", ctx, spuIDs)
+	ret0, _ := ret[0].([]model.SKU)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSKUsBySPUIDs indicates an expected call of ListSKUsBySPUIDs.
+func (mr *MockProductRepositoryMockRecorder) ListSKUsBySPUIDs(ctx, spuIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSKUsBySPUIDs", reflect.TypeOf((*MockProductRepository)(nil).ListSKUsBySPUIDs), ctx, spuIDs)
+}
+
+// ListSPUNames mocks base method.
+func (m *MockProductRepository) ListSPUNames(ctx context.Context, afterID uint64, limit int) ([]model.SPU, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSPUNames", ctx, afterID, limit)
+	ret0, _ := ret[0].([]model.SPU)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSPUNames indicates an expected call of ListSPUNames.
+func (mr *MockProductRepositoryMockRecorder) ListSPUNames(ctx, afterID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSPUNames", reflect.TypeOf((*MockProductRepository)(nil).ListSPUNames), ctx, afterID, limit)
+}
+
 // ListSPUs mocks base method.
 func (m *MockProductRepository) ListSPUs(ctx context.Context, offset, limit int) ([]model.SPU, error) {
 	m.ctrl.T.Helper()
@@ -114,6 +357,63 @@ func (mr *MockProductRepositoryMockRecorder) ListSPUs(ctx, offset, limit any) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSPUs", reflect.TypeOf((*MockProductRepository)(nil).ListSPUs), ctx, offset, limit)
 }
 
+// RescoreSPUs mocks base method.
+func (m *MockProductRepository) RescoreSPUs(ctx context.Context, halfLife time.Duration, at time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RescoreSPUs", ctx, halfLife, at)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RescoreSPUs indicates an expected call of RescoreSPUs.
+func (mr *MockProductRepositoryMockRecorder) RescoreSPUs(ctx, halfLife, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RescoreSPUs", reflect.TypeOf((*MockProductRepository)(nil).RescoreSPUs), ctx, halfLife, at)
+}
+
+// UpdateSKUAvailableAt mocks base method.
+func (m *MockProductRepository) UpdateSKUAvailableAt(ctx context.Context, skuID uint64, availableAt *time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSKUAvailableAt", ctx, skuID, availableAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSKUAvailableAt indicates an expected call of UpdateSKUAvailableAt.
+func (mr *MockProductRepositoryMockRecorder) UpdateSKUAvailableAt(ctx, skuID, availableAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSKUAvailableAt", reflect.TypeOf((*MockProductRepository)(nil).UpdateSKUAvailableAt), ctx, skuID, availableAt)
+}
+
+// UpdateSKUPrice mocks base method.
+func (m *MockProductRepository) UpdateSKUPrice(ctx context.Context, skuID uint64, price decimal.Decimal) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSKUPrice", ctx, skuID, price)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSKUPrice indicates an expected call of UpdateSKUPrice.
+func (mr *MockProductRepositoryMockRecorder) UpdateSKUPrice(ctx, skuID, price any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSKUPrice", reflect.TypeOf((*MockProductRepository)(nil).UpdateSKUPrice), ctx, skuID, price)
+}
+
+// UpdateSKUSale mocks base method.
+func (m *MockProductRepository) UpdateSKUSale(ctx context.Context, skuID uint64, price *decimal.Decimal, endsAt *time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSKUSale", ctx, skuID, price, endsAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSKUSale indicates an expected call of UpdateSKUSale.
+func (mr *MockProductRepositoryMockRecorder) UpdateSKUSale(ctx, skuID, price, endsAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSKUSale", reflect.TypeOf((*MockProductRepository)(nil).UpdateSKUSale), ctx, skuID, price, endsAt)
+}
+
 // UpdateSKUStock mocks base method.
 func (m *MockProductRepository) UpdateSKUStock(ctx context.Context, skuID uint64, quantity int) error {
 	m.ctrl.T.Helper()
@@ -41,6 +41,21 @@ func (m *MockProductService) EXPECT() *MockProductServiceMockRecorder {
 	return m.recorder
 }
 
+// BulkUpdatePrices mocks base method.
+func (m *MockProductService) BulkUpdatePrices(ctx context.Context, req *service.BulkPriceReq) (*service.BulkPriceResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkUpdatePrices", ctx, req)
+	ret0, _ := ret[0].(*service.BulkPriceResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BulkUpdatePrices indicates an expected call of BulkUpdatePrices.
+func (mr *MockProductServiceMockRecorder) BulkUpdatePrices(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkUpdatePrices", reflect.TypeOf((*MockProductService)(nil).BulkUpdatePrices), ctx, req)
+}
+
 // CreateProduct mocks base method.
 func (m *MockProductService) CreateProduct(ctx context.Context, req *service.ProductCreateReq) (*service.ProductCreateResp, error) {
 	m.ctrl.T.Helper()
@@ -71,6 +86,21 @@ func (mr *MockProductServiceMockRecorder) GetProduct(ctx, spuID any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProduct", reflect.TypeOf((*MockProductService)(nil).GetProduct), ctx, spuID)
 }
 
+// ListFacets mocks base method.
+func (m *MockProductService) ListFacets(ctx context.Context) (*service.ProductFacets, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFacets", ctx)
+	ret0, _ := ret[0].(*service.ProductFacets)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFacets indicates an expected call of ListFacets.
+func (mr *MockProductServiceMockRecorder) ListFacets(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFacets", reflect.TypeOf((*MockProductService)(nil).ListFacets), ctx)
+}
+
 // ListProducts mocks base method.
 func (m *MockProductService) ListProducts(ctx context.Context, offset, limit int) ([]service.ProductResp, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,116 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/promotion_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/promotion_service.go -destination=internal/mocks/promotion_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPromotionService is a mock of PromotionService interface.
+type MockPromotionService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPromotionServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockPromotionServiceMockRecorder is the mock recorder for MockPromotionService.
+type MockPromotionServiceMockRecorder struct {
+	mock *MockPromotionService
+}
+
+// NewMockPromotionService creates a new mock instance.
+func NewMockPromotionService(ctrl *gomock.Controller) *MockPromotionService {
+	mock := &MockPromotionService{ctrl: ctrl}
+	mock.recorder = &MockPromotionServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPromotionService) EXPECT() *MockPromotionServiceMockRecorder {
+	return m.recorder
+}
+
+// Apply mocks base method.
+func (m *MockPromotionService) Apply(ctx context.Context, currency, couponCode string, items []model.OrderItem, skus []model.SKU) ([]model.OrderPromotion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Apply", ctx, currency, couponCode, items, skus)
+	ret0, _ := ret[0].([]model.OrderPromotion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Apply indicates an expected call of Apply.
+func (mr *MockPromotionServiceMockRecorder) Apply(ctx, currency, couponCode, items, skus any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Apply", reflect.TypeOf((*MockPromotionService)(nil).Apply), ctx, currency, couponCode, items, skus)
+}
+
+// Create mocks base method.
+func (m *MockPromotionService) Create(ctx context.Context, req *service.PromotionCreateReq) (*service.PromotionResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, req)
+	ret0, _ := ret[0].(*service.PromotionResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPromotionServiceMockRecorder) Create(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPromotionService)(nil).Create), ctx, req)
+}
+
+// Delete mocks base method.
+func (m *MockPromotionService) Delete(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockPromotionServiceMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPromotionService)(nil).Delete), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockPromotionService) List(ctx context.Context) ([]service.PromotionResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]service.PromotionResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockPromotionServiceMockRecorder) List(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockPromotionService)(nil).List), ctx)
+}
+
+// RedeemCoupons mocks base method.
+func (m *MockPromotionService) RedeemCoupons(ctx context.Context, applied []model.OrderPromotion, orderID, userID uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedeemCoupons", ctx, applied, orderID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RedeemCoupons indicates an expected call of RedeemCoupons.
+func (mr *MockPromotionServiceMockRecorder) RedeemCoupons(ctx, applied, orderID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeemCoupons", reflect.TypeOf((*MockPromotionService)(nil).RedeemCoupons), ctx, applied, orderID, userID)
+}
@@ -0,0 +1,131 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/purchase_order_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/purchase_order_repo.go -destination=internal/mocks/purchase_order_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPurchaseOrderRepository is a mock of PurchaseOrderRepository interface.
+type MockPurchaseOrderRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPurchaseOrderRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockPurchaseOrderRepositoryMockRecorder is the mock recorder for MockPurchaseOrderRepository.
+type MockPurchaseOrderRepositoryMockRecorder struct {
+	mock *MockPurchaseOrderRepository
+}
+
+// NewMockPurchaseOrderRepository creates a new mock instance.
+func NewMockPurchaseOrderRepository(ctrl *gomock.Controller) *MockPurchaseOrderRepository {
+	mock := &MockPurchaseOrderRepository{ctrl: ctrl}
+	mock.recorder = &MockPurchaseOrderRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPurchaseOrderRepository) EXPECT() *MockPurchaseOrderRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockPurchaseOrderRepository) Create(ctx context.Context, po *model.PurchaseOrder) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, po)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPurchaseOrderRepositoryMockRecorder) Create(ctx, po any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPurchaseOrderRepository)(nil).Create), ctx, po)
+}
+
+// EarliestExpected mocks base method.
+func (m *MockPurchaseOrderRepository) EarliestExpected(ctx context.Context, skuIDs []uint64) (map[uint64]time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EarliestExpected", ctx, skuIDs)
+	ret0, _ := ret[0].(map[uint64]time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EarliestExpected indicates an expected call of EarliestExpected.
+func (mr *MockPurchaseOrderRepositoryMockRecorder) EarliestExpected(ctx, skuIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EarliestExpected", reflect.TypeOf((*MockPurchaseOrderRepository)(nil).EarliestExpected), ctx, skuIDs)
+}
+
+// GetByID mocks base method.
+func (m *MockPurchaseOrderRepository) GetByID(ctx context.Context, id uint64) (*model.PurchaseOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*model.PurchaseOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockPurchaseOrderRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockPurchaseOrderRepository)(nil).GetByID), ctx, id)
+}
+
+// GetByIDForUpdate mocks base method.
+func (m *MockPurchaseOrderRepository) GetByIDForUpdate(ctx context.Context, id uint64) (*model.PurchaseOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDForUpdate", ctx, id)
+	ret0, _ := ret[0].(*model.PurchaseOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDForUpdate indicates an expected call of GetByIDForUpdate.
+func (mr *MockPurchaseOrderRepositoryMockRecorder) GetByIDForUpdate(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDForUpdate", reflect.TypeOf((*MockPurchaseOrderRepository)(nil).GetByIDForUpdate), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockPurchaseOrderRepository) List(ctx context.Context, status string, supplierID uint64, offset, limit int) ([]model.PurchaseOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, status, supplierID, offset, limit)
+	ret0, _ := ret[0].([]model.PurchaseOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockPurchaseOrderRepositoryMockRecorder) List(ctx, status, supplierID, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockPurchaseOrderRepository)(nil).List), ctx, status, supplierID, offset, limit)
+}
+
+// Update mocks base method.
+func (m *MockPurchaseOrderRepository) Update(ctx context.Context, po *model.PurchaseOrder) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, po)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockPurchaseOrderRepositoryMockRecorder) Update(ctx, po any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockPurchaseOrderRepository)(nil).Update), ctx, po)
+}
@@ -0,0 +1,193 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/purchasing_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/purchasing_service.go -destination=internal/mocks/purchasing_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPurchasingService is a mock of PurchasingService interface.
+type MockPurchasingService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPurchasingServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockPurchasingServiceMockRecorder is the mock recorder for MockPurchasingService.
+type MockPurchasingServiceMockRecorder struct {
+	mock *MockPurchasingService
+}
+
+// NewMockPurchasingService creates a new mock instance.
+func NewMockPurchasingService(ctrl *gomock.Controller) *MockPurchasingService {
+	mock := &MockPurchasingService{ctrl: ctrl}
+	mock.recorder = &MockPurchasingServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPurchasingService) EXPECT() *MockPurchasingServiceMockRecorder {
+	return m.recorder
+}
+
+// CancelPurchaseOrder mocks base method.
+func (m *MockPurchasingService) CancelPurchaseOrder(ctx context.Context, id uint64) (*service.PurchaseOrderResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelPurchaseOrder", ctx, id)
+	ret0, _ := ret[0].(*service.PurchaseOrderResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelPurchaseOrder indicates an expected call of CancelPurchaseOrder.
+func (mr *MockPurchasingServiceMockRecorder) CancelPurchaseOrder(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelPurchaseOrder", reflect.TypeOf((*MockPurchasingService)(nil).CancelPurchaseOrder), ctx, id)
+}
+
+// CreatePurchaseOrder mocks base method.
+func (m *MockPurchasingService) CreatePurchaseOrder(ctx context.Context, req *service.PurchaseOrderReq) (*service.PurchaseOrderResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePurchaseOrder", ctx, req)
+	ret0, _ := ret[0].(*service.PurchaseOrderResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePurchaseOrder indicates an expected call of CreatePurchaseOrder.
+func (mr *MockPurchasingServiceMockRecorder) CreatePurchaseOrder(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePurchaseOrder", reflect.TypeOf((*MockPurchasingService)(nil).CreatePurchaseOrder), ctx, req)
+}
+
+// CreateSupplier mocks base method.
+func (m *MockPurchasingService) CreateSupplier(ctx context.Context, req *service.SupplierReq) (*service.SupplierResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSupplier", ctx, req)
+	ret0, _ := ret[0].(*service.SupplierResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSupplier indicates an expected call of CreateSupplier.
+func (mr *MockPurchasingServiceMockRecorder) CreateSupplier(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSupplier", reflect.TypeOf((*MockPurchasingService)(nil).CreateSupplier), ctx, req)
+}
+
+// GetPurchaseOrder mocks base method.
+func (m *MockPurchasingService) GetPurchaseOrder(ctx context.Context, id uint64) (*service.PurchaseOrderResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPurchaseOrder", ctx, id)
+	ret0, _ := ret[0].(*service.PurchaseOrderResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPurchaseOrder indicates an expected call of GetPurchaseOrder.
+func (mr *MockPurchasingServiceMockRecorder) GetPurchaseOrder(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPurchaseOrder", reflect.TypeOf((*MockPurchasingService)(nil).GetPurchaseOrder), ctx, id)
+}
+
+// ListPurchaseOrders mocks base method.
+func (m *MockPurchasingService) ListPurchaseOrders(ctx context.Context, status string, supplierID uint64, offset, limit int) ([]service.PurchaseOrderResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPurchaseOrders", ctx, status, supplierID, offset, limit)
+	ret0, _ := ret[0].([]service.PurchaseOrderResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPurchaseOrders indicates an expected call of ListPurchaseOrders.
+func (mr *MockPurchasingServiceMockRecorder) ListPurchaseOrders(ctx, status, supplierID, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPurchaseOrders", reflect.TypeOf((*MockPurchasingService)(nil).ListPurchaseOrders), ctx, status, supplierID, offset, limit)
+}
+
+// ListStockMovements mocks base method.
+func (m *MockPurchasingService) ListStockMovements(ctx context.Context, skuID uint64, offset, limit int) ([]service.StockMovementResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStockMovements", ctx, skuID, offset, limit)
+	ret0, _ := ret[0].([]service.StockMovementResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStockMovements indicates an expected call of ListStockMovements.
+func (mr *MockPurchasingServiceMockRecorder) ListStockMovements(ctx, skuID, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStockMovements", reflect.TypeOf((*MockPurchasingService)(nil).ListStockMovements), ctx, skuID, offset, limit)
+}
+
+// ListSuppliers mocks base method.
+func (m *MockPurchasingService) ListSuppliers(ctx context.Context, offset, limit int) ([]service.SupplierResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSuppliers", ctx, offset, limit)
+	ret0, _ := ret[0].([]service.SupplierResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSuppliers indicates an expected call of ListSuppliers.
+func (mr *MockPurchasingServiceMockRecorder) ListSuppliers(ctx, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSuppliers", reflect.TypeOf((*MockPurchasingService)(nil).ListSuppliers), ctx, offset, limit)
+}
+
+// Receive mocks base method.
+func (m *MockPurchasingService) Receive(ctx context.Context, id uint64, lines []service.ReceiptLine) (*service.PurchaseOrderResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Receive", ctx, id, lines)
+	ret0, _ := ret[0].(*service.PurchaseOrderResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Receive indicates an expected call of Receive.
+func (mr *MockPurchasingServiceMockRecorder) Receive(ctx, id, lines any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Receive", reflect.TypeOf((*MockPurchasingService)(nil).Receive), ctx, id, lines)
+}
+
+// Reschedule mocks base method.
+func (m *MockPurchasingService) Reschedule(ctx context.Context, id uint64, expectedAt time.Time) (*service.PurchaseOrderResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reschedule", ctx, id, expectedAt)
+	ret0, _ := ret[0].(*service.PurchaseOrderResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reschedule indicates an expected call of Reschedule.
+func (mr *MockPurchasingServiceMockRecorder) Reschedule(ctx, id, expectedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reschedule", reflect.TypeOf((*MockPurchasingService)(nil).Reschedule), ctx, id, expectedAt)
+}
+
+// UpdateSupplier mocks base method.
+func (m *MockPurchasingService) UpdateSupplier(ctx context.Context, id uint64, req *service.SupplierReq) (*service.SupplierResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSupplier", ctx, id, req)
+	ret0, _ := ret[0].(*service.SupplierResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateSupplier indicates an expected call of UpdateSupplier.
+func (mr *MockPurchasingServiceMockRecorder) UpdateSupplier(ctx, id, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSupplier", reflect.TypeOf((*MockPurchasingService)(nil).UpdateSupplier), ctx, id, req)
+}
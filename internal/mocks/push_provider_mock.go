@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/notification/push.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/notification/push.go -destination=internal/mocks/push_provider_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	notification "github.com/proyuen/go-mall/internal/service/notification"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPushProvider is a mock of PushProvider interface.
+type MockPushProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockPushProviderMockRecorder
+	isgomock struct{}
+}
+
+// MockPushProviderMockRecorder is the mock recorder for MockPushProvider.
+type MockPushProviderMockRecorder struct {
+	mock *MockPushProvider
+}
+
+// NewMockPushProvider creates a new mock instance.
+func NewMockPushProvider(ctrl *gomock.Controller) *MockPushProvider {
+	mock := &MockPushProvider{ctrl: ctrl}
+	mock.recorder = &MockPushProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPushProvider) EXPECT() *MockPushProviderMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method.
+func (m *MockPushProvider) Send(ctx context.Context, msg *notification.PushMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", ctx, msg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockPushProviderMockRecorder) Send(ctx, msg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockPushProvider)(nil).Send), ctx, msg)
+}
@@ -0,0 +1,115 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/quote_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/quote_repo.go -destination=internal/mocks/quote_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockQuoteRepository is a mock of QuoteRepository interface.
+type MockQuoteRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockQuoteRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockQuoteRepositoryMockRecorder is the mock recorder for MockQuoteRepository.
+type MockQuoteRepositoryMockRecorder struct {
+	mock *MockQuoteRepository
+}
+
+// NewMockQuoteRepository creates a new mock instance.
+func NewMockQuoteRepository(ctrl *gomock.Controller) *MockQuoteRepository {
+	mock := &MockQuoteRepository{ctrl: ctrl}
+	mock.recorder = &MockQuoteRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQuoteRepository) EXPECT() *MockQuoteRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockQuoteRepository) Create(ctx context.Context, quote *model.Quote) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, quote)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockQuoteRepositoryMockRecorder) Create(ctx, quote any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockQuoteRepository)(nil).Create), ctx, quote)
+}
+
+// GetByID mocks base method.
+func (m *MockQuoteRepository) GetByID(ctx context.Context, id uint64) (*model.Quote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*model.Quote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockQuoteRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockQuoteRepository)(nil).GetByID), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockQuoteRepository) List(ctx context.Context, status string, offset, limit int) ([]model.Quote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, status, offset, limit)
+	ret0, _ := ret[0].([]model.Quote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockQuoteRepositoryMockRecorder) List(ctx, status, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockQuoteRepository)(nil).List), ctx, status, offset, limit)
+}
+
+// ListByUser mocks base method.
+func (m *MockQuoteRepository) ListByUser(ctx context.Context, userID uint64, offset, limit int) ([]model.Quote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUser", ctx, userID, offset, limit)
+	ret0, _ := ret[0].([]model.Quote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByUser indicates an expected call of ListByUser.
+func (mr *MockQuoteRepositoryMockRecorder) ListByUser(ctx, userID, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockQuoteRepository)(nil).ListByUser), ctx, userID, offset, limit)
+}
+
+// Update mocks base method.
+func (m *MockQuoteRepository) Update(ctx context.Context, quote *model.Quote, fromStatus string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, quote, fromStatus)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockQuoteRepositoryMockRecorder) Update(ctx, quote, fromStatus any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockQuoteRepository)(nil).Update), ctx, quote, fromStatus)
+}
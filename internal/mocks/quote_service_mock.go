@@ -0,0 +1,149 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/quote_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/quote_service.go -destination=internal/mocks/quote_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	decimal "github.com/shopspring/decimal"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockQuoteService is a mock of QuoteService interface.
+type MockQuoteService struct {
+	ctrl     *gomock.Controller
+	recorder *MockQuoteServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockQuoteServiceMockRecorder is the mock recorder for MockQuoteService.
+type MockQuoteServiceMockRecorder struct {
+	mock *MockQuoteService
+}
+
+// NewMockQuoteService creates a new mock instance.
+func NewMockQuoteService(ctrl *gomock.Controller) *MockQuoteService {
+	mock := &MockQuoteService{ctrl: ctrl}
+	mock.recorder = &MockQuoteServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQuoteService) EXPECT() *MockQuoteServiceMockRecorder {
+	return m.recorder
+}
+
+// Accept mocks base method.
+func (m *MockQuoteService) Accept(ctx context.Context, userID, id, paymentMethodID uint64) (*service.OrderCreateResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Accept", ctx, userID, id, paymentMethodID)
+	ret0, _ := ret[0].(*service.OrderCreateResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Accept indicates an expected call of Accept.
+func (mr *MockQuoteServiceMockRecorder) Accept(ctx, userID, id, paymentMethodID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Accept", reflect.TypeOf((*MockQuoteService)(nil).Accept), ctx, userID, id, paymentMethodID)
+}
+
+// Decline mocks base method.
+func (m *MockQuoteService) Decline(ctx context.Context, id uint64) (*service.QuoteResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Decline", ctx, id)
+	ret0, _ := ret[0].(*service.QuoteResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Decline indicates an expected call of Decline.
+func (mr *MockQuoteServiceMockRecorder) Decline(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decline", reflect.TypeOf((*MockQuoteService)(nil).Decline), ctx, id)
+}
+
+// Get mocks base method.
+func (m *MockQuoteService) Get(ctx context.Context, userID, id uint64) (*service.QuoteResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, userID, id)
+	ret0, _ := ret[0].(*service.QuoteResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockQuoteServiceMockRecorder) Get(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockQuoteService)(nil).Get), ctx, userID, id)
+}
+
+// List mocks base method.
+func (m *MockQuoteService) List(ctx context.Context, status string, offset, limit int) ([]service.QuoteResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, status, offset, limit)
+	ret0, _ := ret[0].([]service.QuoteResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockQuoteServiceMockRecorder) List(ctx, status, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockQuoteService)(nil).List), ctx, status, offset, limit)
+}
+
+// ListByUser mocks base method.
+func (m *MockQuoteService) ListByUser(ctx context.Context, userID uint64, offset, limit int) ([]service.QuoteResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUser", ctx, userID, offset, limit)
+	ret0, _ := ret[0].([]service.QuoteResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByUser indicates an expected call of ListByUser.
+func (mr *MockQuoteServiceMockRecorder) ListByUser(ctx, userID, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockQuoteService)(nil).ListByUser), ctx, userID, offset, limit)
+}
+
+// Request mocks base method.
+func (m *MockQuoteService) Request(ctx context.Context, req *service.QuoteReq) (*service.QuoteResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Request", ctx, req)
+	ret0, _ := ret[0].(*service.QuoteResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Request indicates an expected call of Request.
+func (mr *MockQuoteServiceMockRecorder) Request(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Request", reflect.TypeOf((*MockQuoteService)(nil).Request), ctx, req)
+}
+
+// Respond mocks base method.
+func (m *MockQuoteService) Respond(ctx context.Context, id uint64, prices map[uint64]decimal.Decimal, expiresAt time.Time) (*service.QuoteResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Respond", ctx, id, prices, expiresAt)
+	ret0, _ := ret[0].(*service.QuoteResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Respond indicates an expected call of Respond.
+func (mr *MockQuoteServiceMockRecorder) Respond(ctx, id, prices, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Respond", reflect.TypeOf((*MockQuoteService)(nil).Respond), ctx, id, prices, expiresAt)
+}
@@ -0,0 +1,162 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/review_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/review_repo.go -destination=internal/mocks/review_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockReviewRepository is a mock of ReviewRepository interface.
+type MockReviewRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockReviewRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockReviewRepositoryMockRecorder is the mock recorder for MockReviewRepository.
+type MockReviewRepositoryMockRecorder struct {
+	mock *MockReviewRepository
+}
+
+// NewMockReviewRepository creates a new mock instance.
+func NewMockReviewRepository(ctrl *gomock.Controller) *MockReviewRepository {
+	mock := &MockReviewRepository{ctrl: ctrl}
+	mock.recorder = &MockReviewRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReviewRepository) EXPECT() *MockReviewRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockReviewRepository) Create(ctx context.Context, review *model.Review) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, review)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockReviewRepositoryMockRecorder) Create(ctx, review any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockReviewRepository)(nil).Create), ctx, review)
+}
+
+// GetByID mocks base method.
+func (m *MockReviewRepository) GetByID(ctx context.Context, id uint64) (*model.Review, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*model.Review)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockReviewRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockReviewRepository)(nil).GetByID), ctx, id)
+}
+
+// ListBySPUIDs mocks base method.
+func (m *MockReviewRepository) ListBySPUIDs(ctx context.Context, spuIDs []uint64, perSPU int) ([]model.Review, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBySPUIDs", ctx, spuIDs, perSPU)
+	ret0, _ := ret[0].([]model.Review)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBySPUIDs indicates an expected call of ListBySPUIDs.
+func (mr *MockReviewRepositoryMockRecorder) ListBySPUIDs(ctx, spuIDs, perSPU any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBySPUIDs", reflect.TypeOf((*MockReviewRepository)(nil).ListBySPUIDs), ctx, spuIDs, perSPU)
+}
+
+// ListOpenReports mocks base method.
+func (m *MockReviewRepository) ListOpenReports(ctx context.Context, reviewIDs []uint64) ([]model.ReviewReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOpenReports", ctx, reviewIDs)
+	ret0, _ := ret[0].([]model.ReviewReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOpenReports indicates an expected call of ListOpenReports.
+func (mr *MockReviewRepositoryMockRecorder) ListOpenReports(ctx, reviewIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOpenReports", reflect.TypeOf((*MockReviewRepository)(nil).ListOpenReports), ctx, reviewIDs)
+}
+
+// ListReported mocks base method.
+func (m *MockReviewRepository) ListReported(ctx context.Context, offset, limit int) ([]model.Review, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReported", ctx, offset, limit)
+	ret0, _ := ret[0].([]model.Review)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListReported indicates an expected call of ListReported.
+func (mr *MockReviewRepositoryMockRecorder) ListReported(ctx, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReported", reflect.TypeOf((*MockReviewRepository)(nil).ListReported), ctx, offset, limit)
+}
+
+// Report mocks base method.
+func (m *MockReviewRepository) Report(ctx context.Context, report *model.ReviewReport) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Report", ctx, report)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Report indicates an expected call of Report.
+func (mr *MockReviewRepositoryMockRecorder) Report(ctx, report any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Report", reflect.TypeOf((*MockReviewRepository)(nil).Report), ctx, report)
+}
+
+// ResolveReports mocks base method.
+func (m *MockReviewRepository) ResolveReports(ctx context.Context, reviewID uint64, status string, at time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveReports", ctx, reviewID, status, at)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveReports indicates an expected call of ResolveReports.
+func (mr *MockReviewRepositoryMockRecorder) ResolveReports(ctx, reviewID, status, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveReports", reflect.TypeOf((*MockReviewRepository)(nil).ResolveReports), ctx, reviewID, status, at)
+}
+
+// Vote mocks base method.
+func (m *MockReviewRepository) Vote(ctx context.Context, vote *model.ReviewVote) (*model.Review, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Vote", ctx, vote)
+	ret0, _ := ret[0].(*model.Review)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Vote indicates an expected call of Vote.
+func (mr *MockReviewRepositoryMockRecorder) Vote(ctx, vote any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Vote", reflect.TypeOf((*MockReviewRepository)(nil).Vote), ctx, vote)
+}
@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/review_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/review_service.go -destination=internal/mocks/review_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockReviewService is a mock of ReviewService interface.
+type MockReviewService struct {
+	ctrl     *gomock.Controller
+	recorder *MockReviewServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockReviewServiceMockRecorder is the mock recorder for MockReviewService.
+type MockReviewServiceMockRecorder struct {
+	mock *MockReviewService
+}
+
+// NewMockReviewService creates a new mock instance.
+func NewMockReviewService(ctrl *gomock.Controller) *MockReviewService {
+	mock := &MockReviewService{ctrl: ctrl}
+	mock.recorder = &MockReviewServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReviewService) EXPECT() *MockReviewServiceMockRecorder {
+	return m.recorder
+}
+
+// ListModerationQueue mocks base method.
+func (m *MockReviewService) ListModerationQueue(ctx context.Context, offset, limit int) (*service.ReviewModerationListResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListModerationQueue", ctx, offset, limit)
+	ret0, _ := ret[0].(*service.ReviewModerationListResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListModerationQueue indicates an expected call of ListModerationQueue.
+func (mr *MockReviewServiceMockRecorder) ListModerationQueue(ctx, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListModerationQueue", reflect.TypeOf((*MockReviewService)(nil).ListModerationQueue), ctx, offset, limit)
+}
+
+// Moderate mocks base method.
+func (m *MockReviewService) Moderate(ctx context.Context, reviewID uint64, action string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Moderate", ctx, reviewID, action)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Moderate indicates an expected call of Moderate.
+func (mr *MockReviewServiceMockRecorder) Moderate(ctx, reviewID, action any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Moderate", reflect.TypeOf((*MockReviewService)(nil).Moderate), ctx, reviewID, action)
+}
+
+// Report mocks base method.
+func (m *MockReviewService) Report(ctx context.Context, userID, reviewID uint64, reason, details string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Report", ctx, userID, reviewID, reason, details)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Report indicates an expected call of Report.
+func (mr *MockReviewServiceMockRecorder) Report(ctx, userID, reviewID, reason, details any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Report", reflect.TypeOf((*MockReviewService)(nil).Report), ctx, userID, reviewID, reason, details)
+}
+
+// Vote mocks base method.
+func (m *MockReviewService) Vote(ctx context.Context, userID, reviewID uint64, helpful bool) (*service.ReviewVotesResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Vote", ctx, userID, reviewID, helpful)
+	ret0, _ := ret[0].(*service.ReviewVotesResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Vote indicates an expected call of Vote.
+func (mr *MockReviewServiceMockRecorder) Vote(ctx, userID, reviewID, helpful any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Vote", reflect.TypeOf((*MockReviewService)(nil).Vote), ctx, userID, reviewID, helpful)
+}
@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/saga_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/saga_repo.go -destination=internal/mocks/saga_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSagaRepository is a mock of SagaRepository interface.
+type MockSagaRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSagaRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockSagaRepositoryMockRecorder is the mock recorder for MockSagaRepository.
+type MockSagaRepositoryMockRecorder struct {
+	mock *MockSagaRepository
+}
+
+// NewMockSagaRepository creates a new mock instance.
+func NewMockSagaRepository(ctrl *gomock.Controller) *MockSagaRepository {
+	mock := &MockSagaRepository{ctrl: ctrl}
+	mock.recorder = &MockSagaRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSagaRepository) EXPECT() *MockSagaRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockSagaRepository) Create(ctx context.Context, saga *model.SagaState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, saga)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockSagaRepositoryMockRecorder) Create(ctx, saga any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSagaRepository)(nil).Create), ctx, saga)
+}
+
+// GetByOrderID mocks base method.
+func (m *MockSagaRepository) GetByOrderID(ctx context.Context, orderID uint64) (*model.SagaState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByOrderID", ctx, orderID)
+	ret0, _ := ret[0].(*model.SagaState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByOrderID indicates an expected call of GetByOrderID.
+func (mr *MockSagaRepositoryMockRecorder) GetByOrderID(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByOrderID", reflect.TypeOf((*MockSagaRepository)(nil).GetByOrderID), ctx, orderID)
+}
+
+// ListDue mocks base method.
+func (m *MockSagaRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]model.SagaState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDue", ctx, now, limit)
+	ret0, _ := ret[0].([]model.SagaState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDue indicates an expected call of ListDue.
+func (mr *MockSagaRepositoryMockRecorder) ListDue(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDue", reflect.TypeOf((*MockSagaRepository)(nil).ListDue), ctx, now, limit)
+}
+
+// Save mocks base method.
+func (m *MockSagaRepository) Save(ctx context.Context, saga *model.SagaState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, saga)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockSagaRepositoryMockRecorder) Save(ctx, saga any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockSagaRepository)(nil).Save), ctx, saga)
+}
@@ -0,0 +1,116 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/search_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/search_repo.go -destination=internal/mocks/search_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSearchRepository is a mock of SearchRepository interface.
+type MockSearchRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSearchRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockSearchRepositoryMockRecorder is the mock recorder for MockSearchRepository.
+type MockSearchRepositoryMockRecorder struct {
+	mock *MockSearchRepository
+}
+
+// NewMockSearchRepository creates a new mock instance.
+func NewMockSearchRepository(ctrl *gomock.Controller) *MockSearchRepository {
+	mock := &MockSearchRepository{ctrl: ctrl}
+	mock.recorder = &MockSearchRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSearchRepository) EXPECT() *MockSearchRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddZeroResults mocks base method.
+func (m *MockSearchRepository) AddZeroResults(ctx context.Context, searches []model.SearchZeroResult, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddZeroResults", ctx, searches, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddZeroResults indicates an expected call of AddZeroResults.
+func (mr *MockSearchRepositoryMockRecorder) AddZeroResults(ctx, searches, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddZeroResults", reflect.TypeOf((*MockSearchRepository)(nil).AddZeroResults), ctx, searches, at)
+}
+
+// CreateRule mocks base method.
+func (m *MockSearchRepository) CreateRule(ctx context.Context, rule *model.SearchRule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRule", ctx, rule)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRule indicates an expected call of CreateRule.
+func (mr *MockSearchRepositoryMockRecorder) CreateRule(ctx, rule any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRule", reflect.TypeOf((*MockSearchRepository)(nil).CreateRule), ctx, rule)
+}
+
+// DeleteRule mocks base method.
+func (m *MockSearchRepository) DeleteRule(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRule", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRule indicates an expected call of DeleteRule.
+func (mr *MockSearchRepositoryMockRecorder) DeleteRule(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRule", reflect.TypeOf((*MockSearchRepository)(nil).DeleteRule), ctx, id)
+}
+
+// ListRules mocks base method.
+func (m *MockSearchRepository) ListRules(ctx context.Context) ([]model.SearchRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRules", ctx)
+	ret0, _ := ret[0].([]model.SearchRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRules indicates an expected call of ListRules.
+func (mr *MockSearchRepositoryMockRecorder) ListRules(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRules", reflect.TypeOf((*MockSearchRepository)(nil).ListRules), ctx)
+}
+
+// ListZeroResults mocks base method.
+func (m *MockSearchRepository) ListZeroResults(ctx context.Context, offset, limit int) ([]model.SearchZeroResult, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListZeroResults", ctx, offset, limit)
+	ret0, _ := ret[0].([]model.SearchZeroResult)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListZeroResults indicates an expected call of ListZeroResults.
+func (mr *MockSearchRepositoryMockRecorder) ListZeroResults(ctx, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListZeroResults", reflect.TypeOf((*MockSearchRepository)(nil).ListZeroResults), ctx, offset, limit)
+}
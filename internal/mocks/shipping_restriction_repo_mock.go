@@ -0,0 +1,85 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/shipping_restriction_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/shipping_restriction_repo.go -destination=internal/mocks/shipping_restriction_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockShippingRestrictionRepository is a mock of ShippingRestrictionRepository interface.
+type MockShippingRestrictionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockShippingRestrictionRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockShippingRestrictionRepositoryMockRecorder is the mock recorder for MockShippingRestrictionRepository.
+type MockShippingRestrictionRepositoryMockRecorder struct {
+	mock *MockShippingRestrictionRepository
+}
+
+// NewMockShippingRestrictionRepository creates a new mock instance.
+func NewMockShippingRestrictionRepository(ctrl *gomock.Controller) *MockShippingRestrictionRepository {
+	mock := &MockShippingRestrictionRepository{ctrl: ctrl}
+	mock.recorder = &MockShippingRestrictionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockShippingRestrictionRepository) EXPECT() *MockShippingRestrictionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockShippingRestrictionRepository) Create(ctx context.Context, restriction *model.ShippingRestriction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, restriction)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockShippingRestrictionRepositoryMockRecorder) Create(ctx, restriction any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockShippingRestrictionRepository)(nil).Create), ctx, restriction)
+}
+
+// Delete mocks base method.
+func (m *MockShippingRestrictionRepository) Delete(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockShippingRestrictionRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockShippingRestrictionRepository)(nil).Delete), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockShippingRestrictionRepository) List(ctx context.Context) ([]model.ShippingRestriction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]model.ShippingRestriction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockShippingRestrictionRepositoryMockRecorder) List(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockShippingRestrictionRepository)(nil).List), ctx)
+}
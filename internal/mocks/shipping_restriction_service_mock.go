@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/shipping_restriction_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/shipping_restriction_service.go -destination=internal/mocks/shipping_restriction_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockShippingRestrictionService is a mock of ShippingRestrictionService interface.
+type MockShippingRestrictionService struct {
+	ctrl     *gomock.Controller
+	recorder *MockShippingRestrictionServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockShippingRestrictionServiceMockRecorder is the mock recorder for MockShippingRestrictionService.
+type MockShippingRestrictionServiceMockRecorder struct {
+	mock *MockShippingRestrictionService
+}
+
+// NewMockShippingRestrictionService creates a new mock instance.
+func NewMockShippingRestrictionService(ctrl *gomock.Controller) *MockShippingRestrictionService {
+	mock := &MockShippingRestrictionService{ctrl: ctrl}
+	mock.recorder = &MockShippingRestrictionServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockShippingRestrictionService) EXPECT() *MockShippingRestrictionServiceMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockShippingRestrictionService) Check(ctx context.Context, region string, items []model.OrderItem) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx, region, items)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockShippingRestrictionServiceMockRecorder) Check(ctx, region, items any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockShippingRestrictionService)(nil).Check), ctx, region, items)
+}
+
+// Create mocks base method.
+func (m *MockShippingRestrictionService) Create(ctx context.Context, req *service.ShippingRestrictionCreateReq) (*service.ShippingRestrictionResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, req)
+	ret0, _ := ret[0].(*service.ShippingRestrictionResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockShippingRestrictionServiceMockRecorder) Create(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockShippingRestrictionService)(nil).Create), ctx, req)
+}
+
+// Delete mocks base method.
+func (m *MockShippingRestrictionService) Delete(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockShippingRestrictionServiceMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockShippingRestrictionService)(nil).Delete), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockShippingRestrictionService) List(ctx context.Context) ([]service.ShippingRestrictionResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]service.ShippingRestrictionResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockShippingRestrictionServiceMockRecorder) List(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockShippingRestrictionService)(nil).List), ctx)
+}
@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/notification/sms.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/notification/sms.go -destination=internal/mocks/sms_provider_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	notification "github.com/proyuen/go-mall/internal/service/notification"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSMSProvider is a mock of SMSProvider interface.
+type MockSMSProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockSMSProviderMockRecorder
+	isgomock struct{}
+}
+
+// MockSMSProviderMockRecorder is the mock recorder for MockSMSProvider.
+type MockSMSProviderMockRecorder struct {
+	mock *MockSMSProvider
+}
+
+// NewMockSMSProvider creates a new mock instance.
+func NewMockSMSProvider(ctrl *gomock.Controller) *MockSMSProvider {
+	mock := &MockSMSProvider{ctrl: ctrl}
+	mock.recorder = &MockSMSProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSMSProvider) EXPECT() *MockSMSProviderMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method.
+func (m *MockSMSProvider) Send(ctx context.Context, msg *notification.SMSMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", ctx, msg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockSMSProviderMockRecorder) Send(ctx, msg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockSMSProvider)(nil).Send), ctx, msg)
+}
@@ -0,0 +1,131 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/stock_hold_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/stock_hold_repo.go -destination=internal/mocks/stock_hold_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockStockHoldRepository is a mock of StockHoldRepository interface.
+type MockStockHoldRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockStockHoldRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockStockHoldRepositoryMockRecorder is the mock recorder for MockStockHoldRepository.
+type MockStockHoldRepositoryMockRecorder struct {
+	mock *MockStockHoldRepository
+}
+
+// NewMockStockHoldRepository creates a new mock instance.
+func NewMockStockHoldRepository(ctrl *gomock.Controller) *MockStockHoldRepository {
+	mock := &MockStockHoldRepository{ctrl: ctrl}
+	mock.recorder = &MockStockHoldRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStockHoldRepository) EXPECT() *MockStockHoldRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockStockHoldRepository) Create(ctx context.Context, hold *model.StockHold) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, hold)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockStockHoldRepositoryMockRecorder) Create(ctx, hold any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockStockHoldRepository)(nil).Create), ctx, hold)
+}
+
+// GetByID mocks base method.
+func (m *MockStockHoldRepository) GetByID(ctx context.Context, id uint64) (*model.StockHold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*model.StockHold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockStockHoldRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockStockHoldRepository)(nil).GetByID), ctx, id)
+}
+
+// GetByIDForUpdate mocks base method.
+func (m *MockStockHoldRepository) GetByIDForUpdate(ctx context.Context, id uint64) (*model.StockHold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDForUpdate", ctx, id)
+	ret0, _ := ret[0].(*model.StockHold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDForUpdate indicates an expected call of GetByIDForUpdate.
+func (mr *MockStockHoldRepositoryMockRecorder) GetByIDForUpdate(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDForUpdate", reflect.TypeOf((*MockStockHoldRepository)(nil).GetByIDForUpdate), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockStockHoldRepository) List(ctx context.Context, status string, skuID uint64, offset, limit int) ([]model.StockHold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, status, skuID, offset, limit)
+	ret0, _ := ret[0].([]model.StockHold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockStockHoldRepositoryMockRecorder) List(ctx, status, skuID, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockStockHoldRepository)(nil).List), ctx, status, skuID, offset, limit)
+}
+
+// ListExpired mocks base method.
+func (m *MockStockHoldRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]model.StockHold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpired", ctx, now, limit)
+	ret0, _ := ret[0].([]model.StockHold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpired indicates an expected call of ListExpired.
+func (mr *MockStockHoldRepositoryMockRecorder) ListExpired(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpired", reflect.TypeOf((*MockStockHoldRepository)(nil).ListExpired), ctx, now, limit)
+}
+
+// Update mocks base method.
+func (m *MockStockHoldRepository) Update(ctx context.Context, hold *model.StockHold) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, hold)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockStockHoldRepositoryMockRecorder) Update(ctx, hold any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockStockHoldRepository)(nil).Update), ctx, hold)
+}
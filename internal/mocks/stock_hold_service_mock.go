@@ -0,0 +1,118 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/stock_hold_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/stock_hold_service.go -destination=internal/mocks/stock_hold_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockStockHoldService is a mock of StockHoldService interface.
+type MockStockHoldService struct {
+	ctrl     *gomock.Controller
+	recorder *MockStockHoldServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockStockHoldServiceMockRecorder is the mock recorder for MockStockHoldService.
+type MockStockHoldServiceMockRecorder struct {
+	mock *MockStockHoldService
+}
+
+// NewMockStockHoldService creates a new mock instance.
+func NewMockStockHoldService(ctrl *gomock.Controller) *MockStockHoldService {
+	mock := &MockStockHoldService{ctrl: ctrl}
+	mock.recorder = &MockStockHoldServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStockHoldService) EXPECT() *MockStockHoldServiceMockRecorder {
+	return m.recorder
+}
+
+// List mocks base method.
+func (m *MockStockHoldService) List(ctx context.Context, status string, skuID uint64, offset, limit int) ([]service.StockHoldResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, status, skuID, offset, limit)
+	ret0, _ := ret[0].([]service.StockHoldResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockStockHoldServiceMockRecorder) List(ctx, status, skuID, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockStockHoldService)(nil).List), ctx, status, skuID, offset, limit)
+}
+
+// Place mocks base method.
+func (m *MockStockHoldService) Place(ctx context.Context, req *service.StockHoldReq) (*service.StockHoldResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Place", ctx, req)
+	ret0, _ := ret[0].(*service.StockHoldResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Place indicates an expected call of Place.
+func (mr *MockStockHoldServiceMockRecorder) Place(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Place", reflect.TypeOf((*MockStockHoldService)(nil).Place), ctx, req)
+}
+
+// Release mocks base method.
+func (m *MockStockHoldService) Release(ctx context.Context, id uint64) (*service.StockHoldResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, id)
+	ret0, _ := ret[0].(*service.StockHoldResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockStockHoldServiceMockRecorder) Release(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockStockHoldService)(nil).Release), ctx, id)
+}
+
+// ReleaseExpired mocks base method.
+func (m *MockStockHoldService) ReleaseExpired(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseExpired", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseExpired indicates an expected call of ReleaseExpired.
+func (mr *MockStockHoldServiceMockRecorder) ReleaseExpired(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseExpired", reflect.TypeOf((*MockStockHoldService)(nil).ReleaseExpired), ctx)
+}
+
+// SetExpiry mocks base method.
+func (m *MockStockHoldService) SetExpiry(ctx context.Context, id uint64, expiresAt *time.Time) (*service.StockHoldResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetExpiry", ctx, id, expiresAt)
+	ret0, _ := ret[0].(*service.StockHoldResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetExpiry indicates an expected call of SetExpiry.
+func (mr *MockStockHoldServiceMockRecorder) SetExpiry(ctx, id, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetExpiry", reflect.TypeOf((*MockStockHoldService)(nil).SetExpiry), ctx, id, expiresAt)
+}
@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/stock_movement_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/stock_movement_repo.go -destination=internal/mocks/stock_movement_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockStockMovementRepository is a mock of StockMovementRepository interface.
+type MockStockMovementRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockStockMovementRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockStockMovementRepositoryMockRecorder is the mock recorder for MockStockMovementRepository.
+type MockStockMovementRepositoryMockRecorder struct {
+	mock *MockStockMovementRepository
+}
+
+// NewMockStockMovementRepository creates a new mock instance.
+func NewMockStockMovementRepository(ctrl *gomock.Controller) *MockStockMovementRepository {
+	mock := &MockStockMovementRepository{ctrl: ctrl}
+	mock.recorder = &MockStockMovementRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStockMovementRepository) EXPECT() *MockStockMovementRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockStockMovementRepository) Create(ctx context.Context, movements []model.StockMovement) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, movements)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockStockMovementRepositoryMockRecorder) Create(ctx, movements any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockStockMovementRepository)(nil).Create), ctx, movements)
+}
+
+// ListBySKU mocks base method.
+func (m *MockStockMovementRepository) ListBySKU(ctx context.Context, skuID uint64, offset, limit int) ([]model.StockMovement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBySKU", ctx, skuID, offset, limit)
+	ret0, _ := ret[0].([]model.StockMovement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBySKU indicates an expected call of ListBySKU.
+func (mr *MockStockMovementRepositoryMockRecorder) ListBySKU(ctx, skuID, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBySKU", reflect.TypeOf((*MockStockMovementRepository)(nil).ListBySKU), ctx, skuID, offset, limit)
+}
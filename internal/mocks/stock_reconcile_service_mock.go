@@ -0,0 +1,111 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/stock_reconcile_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/stock_reconcile_service.go -destination=internal/mocks/stock_reconcile_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockStockStore is a mock of StockStore interface.
+type MockStockStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockStockStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockStockStoreMockRecorder is the mock recorder for MockStockStore.
+type MockStockStoreMockRecorder struct {
+	mock *MockStockStore
+}
+
+// NewMockStockStore creates a new mock instance.
+func NewMockStockStore(ctrl *gomock.Controller) *MockStockStore {
+	mock := &MockStockStore{ctrl: ctrl}
+	mock.recorder = &MockStockStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStockStore) EXPECT() *MockStockStoreMockRecorder {
+	return m.recorder
+}
+
+// SetStockIfUnchanged mocks base method.
+func (m *MockStockStore) SetStockIfUnchanged(ctx context.Context, skuID uint64, observed string, stock int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStockIfUnchanged", ctx, skuID, observed, stock)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetStockIfUnchanged indicates an expected call of SetStockIfUnchanged.
+func (mr *MockStockStoreMockRecorder) SetStockIfUnchanged(ctx, skuID, observed, stock any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStockIfUnchanged", reflect.TypeOf((*MockStockStore)(nil).SetStockIfUnchanged), ctx, skuID, observed, stock)
+}
+
+// StockCounters mocks base method.
+func (m *MockStockStore) StockCounters(ctx context.Context, skuIDs []uint64) (map[uint64]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StockCounters", ctx, skuIDs)
+	ret0, _ := ret[0].(map[uint64]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StockCounters indicates an expected call of StockCounters.
+func (mr *MockStockStoreMockRecorder) StockCounters(ctx, skuIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StockCounters", reflect.TypeOf((*MockStockStore)(nil).StockCounters), ctx, skuIDs)
+}
+
+// MockStockReconciler is a mock of StockReconciler interface.
+type MockStockReconciler struct {
+	ctrl     *gomock.Controller
+	recorder *MockStockReconcilerMockRecorder
+	isgomock struct{}
+}
+
+// MockStockReconcilerMockRecorder is the mock recorder for MockStockReconciler.
+type MockStockReconcilerMockRecorder struct {
+	mock *MockStockReconciler
+}
+
+// NewMockStockReconciler creates a new mock instance.
+func NewMockStockReconciler(ctrl *gomock.Controller) *MockStockReconciler {
+	mock := &MockStockReconciler{ctrl: ctrl}
+	mock.recorder = &MockStockReconcilerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStockReconciler) EXPECT() *MockStockReconcilerMockRecorder {
+	return m.recorder
+}
+
+// Reconcile mocks base method.
+func (m *MockStockReconciler) Reconcile(ctx context.Context, opts service.StockReconcileOptions) (*service.StockReconcileReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reconcile", ctx, opts)
+	ret0, _ := ret[0].(*service.StockReconcileReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reconcile indicates an expected call of Reconcile.
+func (mr *MockStockReconcilerMockRecorder) Reconcile(ctx, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reconcile", reflect.TypeOf((*MockStockReconciler)(nil).Reconcile), ctx, opts)
+}
@@ -0,0 +1,88 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/store_credit_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/store_credit_repo.go -destination=internal/mocks/store_credit_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	repository "github.com/proyuen/go-mall/internal/repository"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockStoreCreditRepository is a mock of StoreCreditRepository interface.
+type MockStoreCreditRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockStoreCreditRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockStoreCreditRepositoryMockRecorder is the mock recorder for MockStoreCreditRepository.
+type MockStoreCreditRepositoryMockRecorder struct {
+	mock *MockStoreCreditRepository
+}
+
+// NewMockStoreCreditRepository creates a new mock instance.
+func NewMockStoreCreditRepository(ctrl *gomock.Controller) *MockStoreCreditRepository {
+	mock := &MockStoreCreditRepository{ctrl: ctrl}
+	mock.recorder = &MockStoreCreditRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStoreCreditRepository) EXPECT() *MockStoreCreditRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Balances mocks base method.
+func (m *MockStoreCreditRepository) Balances(ctx context.Context, userID uint64) ([]repository.StoreCreditBalance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Balances", ctx, userID)
+	ret0, _ := ret[0].([]repository.StoreCreditBalance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Balances indicates an expected call of Balances.
+func (mr *MockStoreCreditRepositoryMockRecorder) Balances(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Balances", reflect.TypeOf((*MockStoreCreditRepository)(nil).Balances), ctx, userID)
+}
+
+// Create mocks base method.
+func (m *MockStoreCreditRepository) Create(ctx context.Context, credit *model.StoreCredit) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, credit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockStoreCreditRepositoryMockRecorder) Create(ctx, credit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockStoreCreditRepository)(nil).Create), ctx, credit)
+}
+
+// ListByUser mocks base method.
+func (m *MockStoreCreditRepository) ListByUser(ctx context.Context, userID uint64, offset, limit int) ([]model.StoreCredit, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUser", ctx, userID, offset, limit)
+	ret0, _ := ret[0].([]model.StoreCredit)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListByUser indicates an expected call of ListByUser.
+func (mr *MockStoreCreditRepositoryMockRecorder) ListByUser(ctx, userID, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockStoreCreditRepository)(nil).ListByUser), ctx, userID, offset, limit)
+}
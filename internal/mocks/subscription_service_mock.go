@@ -0,0 +1,147 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/subscription_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/subscription_service.go -destination=internal/mocks/subscription_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSubscriptionService is a mock of SubscriptionService interface.
+type MockSubscriptionService struct {
+	ctrl     *gomock.Controller
+	recorder *MockSubscriptionServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockSubscriptionServiceMockRecorder is the mock recorder for MockSubscriptionService.
+type MockSubscriptionServiceMockRecorder struct {
+	mock *MockSubscriptionService
+}
+
+// NewMockSubscriptionService creates a new mock instance.
+func NewMockSubscriptionService(ctrl *gomock.Controller) *MockSubscriptionService {
+	mock := &MockSubscriptionService{ctrl: ctrl}
+	mock.recorder = &MockSubscriptionServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSubscriptionService) EXPECT() *MockSubscriptionServiceMockRecorder {
+	return m.recorder
+}
+
+// Cancel mocks base method.
+func (m *MockSubscriptionService) Cancel(ctx context.Context, userID, id uint64) (*service.SubscriptionResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", ctx, userID, id)
+	ret0, _ := ret[0].(*service.SubscriptionResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockSubscriptionServiceMockRecorder) Cancel(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockSubscriptionService)(nil).Cancel), ctx, userID, id)
+}
+
+// List mocks base method.
+func (m *MockSubscriptionService) List(ctx context.Context, userID uint64) ([]service.SubscriptionResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, userID)
+	ret0, _ := ret[0].([]service.SubscriptionResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockSubscriptionServiceMockRecorder) List(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockSubscriptionService)(nil).List), ctx, userID)
+}
+
+// Pause mocks base method.
+func (m *MockSubscriptionService) Pause(ctx context.Context, userID, id uint64) (*service.SubscriptionResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pause", ctx, userID, id)
+	ret0, _ := ret[0].(*service.SubscriptionResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Pause indicates an expected call of Pause.
+func (mr *MockSubscriptionServiceMockRecorder) Pause(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pause", reflect.TypeOf((*MockSubscriptionService)(nil).Pause), ctx, userID, id)
+}
+
+// RenewDue mocks base method.
+func (m *MockSubscriptionService) RenewDue(ctx context.Context, batchSize int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenewDue", ctx, batchSize)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenewDue indicates an expected call of RenewDue.
+func (mr *MockSubscriptionServiceMockRecorder) RenewDue(ctx, batchSize any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenewDue", reflect.TypeOf((*MockSubscriptionService)(nil).RenewDue), ctx, batchSize)
+}
+
+// Resume mocks base method.
+func (m *MockSubscriptionService) Resume(ctx context.Context, userID, id uint64) (*service.SubscriptionResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resume", ctx, userID, id)
+	ret0, _ := ret[0].(*service.SubscriptionResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resume indicates an expected call of Resume.
+func (mr *MockSubscriptionServiceMockRecorder) Resume(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resume", reflect.TypeOf((*MockSubscriptionService)(nil).Resume), ctx, userID, id)
+}
+
+// Skip mocks base method.
+func (m *MockSubscriptionService) Skip(ctx context.Context, userID, id uint64) (*service.SubscriptionResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Skip", ctx, userID, id)
+	ret0, _ := ret[0].(*service.SubscriptionResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Skip indicates an expected call of Skip.
+func (mr *MockSubscriptionServiceMockRecorder) Skip(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Skip", reflect.TypeOf((*MockSubscriptionService)(nil).Skip), ctx, userID, id)
+}
+
+// Subscribe mocks base method.
+func (m *MockSubscriptionService) Subscribe(ctx context.Context, req *service.SubscribeReq) (*service.SubscriptionResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx, req)
+	ret0, _ := ret[0].(*service.SubscriptionResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockSubscriptionServiceMockRecorder) Subscribe(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockSubscriptionService)(nil).Subscribe), ctx, req)
+}
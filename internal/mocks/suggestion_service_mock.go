@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/suggestion_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/suggestion_service.go -destination=internal/mocks/suggestion_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSuggestionService is a mock of SuggestionService interface.
+type MockSuggestionService struct {
+	ctrl     *gomock.Controller
+	recorder *MockSuggestionServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockSuggestionServiceMockRecorder is the mock recorder for MockSuggestionService.
+type MockSuggestionServiceMockRecorder struct {
+	mock *MockSuggestionService
+}
+
+// NewMockSuggestionService creates a new mock instance.
+func NewMockSuggestionService(ctrl *gomock.Controller) *MockSuggestionService {
+	mock := &MockSuggestionService{ctrl: ctrl}
+	mock.recorder = &MockSuggestionServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSuggestionService) EXPECT() *MockSuggestionServiceMockRecorder {
+	return m.recorder
+}
+
+// FlushZeroResults mocks base method.
+func (m *MockSuggestionService) FlushZeroResults(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushZeroResults", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FlushZeroResults indicates an expected call of FlushZeroResults.
+func (mr *MockSuggestionServiceMockRecorder) FlushZeroResults(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushZeroResults", reflect.TypeOf((*MockSuggestionService)(nil).FlushZeroResults), ctx)
+}
+
+// RebuildIndex mocks base method.
+func (m *MockSuggestionService) RebuildIndex(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RebuildIndex", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RebuildIndex indicates an expected call of RebuildIndex.
+func (mr *MockSuggestionServiceMockRecorder) RebuildIndex(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RebuildIndex", reflect.TypeOf((*MockSuggestionService)(nil).RebuildIndex), ctx)
+}
+
+// RecordZeroResult mocks base method.
+func (m *MockSuggestionService) RecordZeroResult(ctx context.Context, query string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordZeroResult", ctx, query)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordZeroResult indicates an expected call of RecordZeroResult.
+func (mr *MockSuggestionServiceMockRecorder) RecordZeroResult(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordZeroResult", reflect.TypeOf((*MockSuggestionService)(nil).RecordZeroResult), ctx, query)
+}
+
+// Suggest mocks base method.
+func (m *MockSuggestionService) Suggest(ctx context.Context, query string, limit int) ([]service.Suggestion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Suggest", ctx, query, limit)
+	ret0, _ := ret[0].([]service.Suggestion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Suggest indicates an expected call of Suggest.
+func (mr *MockSuggestionServiceMockRecorder) Suggest(ctx, query, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Suggest", reflect.TypeOf((*MockSuggestionService)(nil).Suggest), ctx, query, limit)
+}
@@ -0,0 +1,100 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/supplier_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/supplier_repo.go -destination=internal/mocks/supplier_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSupplierRepository is a mock of SupplierRepository interface.
+type MockSupplierRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSupplierRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockSupplierRepositoryMockRecorder is the mock recorder for MockSupplierRepository.
+type MockSupplierRepositoryMockRecorder struct {
+	mock *MockSupplierRepository
+}
+
+// NewMockSupplierRepository creates a new mock instance.
+func NewMockSupplierRepository(ctrl *gomock.Controller) *MockSupplierRepository {
+	mock := &MockSupplierRepository{ctrl: ctrl}
+	mock.recorder = &MockSupplierRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSupplierRepository) EXPECT() *MockSupplierRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockSupplierRepository) Create(ctx context.Context, supplier *model.Supplier) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, supplier)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockSupplierRepositoryMockRecorder) Create(ctx, supplier any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSupplierRepository)(nil).Create), ctx, supplier)
+}
+
+// GetByID mocks base method.
+func (m *MockSupplierRepository) GetByID(ctx context.Context, id uint64) (*model.Supplier, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*model.Supplier)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockSupplierRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockSupplierRepository)(nil).GetByID), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockSupplierRepository) List(ctx context.Context, offset, limit int) ([]model.Supplier, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, offset, limit)
+	ret0, _ := ret[0].([]model.Supplier)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockSupplierRepositoryMockRecorder) List(ctx, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockSupplierRepository)(nil).List), ctx, offset, limit)
+}
+
+// Update mocks base method.
+func (m *MockSupplierRepository) Update(ctx context.Context, supplier *model.Supplier) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, supplier)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockSupplierRepositoryMockRecorder) Update(ctx, supplier any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockSupplierRepository)(nil).Update), ctx, supplier)
+}
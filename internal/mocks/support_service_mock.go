@@ -0,0 +1,134 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/support_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/support_service.go -destination=internal/mocks/support_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	service "github.com/proyuen/go-mall/internal/service"
+	notification "github.com/proyuen/go-mall/internal/service/notification"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSupportService is a mock of SupportService interface.
+type MockSupportService struct {
+	ctrl     *gomock.Controller
+	recorder *MockSupportServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockSupportServiceMockRecorder is the mock recorder for MockSupportService.
+type MockSupportServiceMockRecorder struct {
+	mock *MockSupportService
+}
+
+// NewMockSupportService creates a new mock instance.
+func NewMockSupportService(ctrl *gomock.Controller) *MockSupportService {
+	mock := &MockSupportService{ctrl: ctrl}
+	mock.recorder = &MockSupportServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSupportService) EXPECT() *MockSupportServiceMockRecorder {
+	return m.recorder
+}
+
+// CustomerOrders mocks base method.
+func (m *MockSupportService) CustomerOrders(ctx context.Context, userID uint64, offset, limit int) (*service.OrderSummaryListResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CustomerOrders", ctx, userID, offset, limit)
+	ret0, _ := ret[0].(*service.OrderSummaryListResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CustomerOrders indicates an expected call of CustomerOrders.
+func (mr *MockSupportServiceMockRecorder) CustomerOrders(ctx, userID, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CustomerOrders", reflect.TypeOf((*MockSupportService)(nil).CustomerOrders), ctx, userID, offset, limit)
+}
+
+// GetOrder mocks base method.
+func (m *MockSupportService) GetOrder(ctx context.Context, orderID uint64) (*service.CustomerOrderResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrder", ctx, orderID)
+	ret0, _ := ret[0].(*service.CustomerOrderResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrder indicates an expected call of GetOrder.
+func (mr *MockSupportServiceMockRecorder) GetOrder(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockSupportService)(nil).GetOrder), ctx, orderID)
+}
+
+// GrantStoreCredit mocks base method.
+func (m *MockSupportService) GrantStoreCredit(ctx context.Context, req *service.StoreCreditReq) (*service.StoreCreditResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantStoreCredit", ctx, req)
+	ret0, _ := ret[0].(*service.StoreCreditResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GrantStoreCredit indicates an expected call of GrantStoreCredit.
+func (mr *MockSupportServiceMockRecorder) GrantStoreCredit(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantStoreCredit", reflect.TypeOf((*MockSupportService)(nil).GrantStoreCredit), ctx, req)
+}
+
+// ResendNotification mocks base method.
+func (m *MockSupportService) ResendNotification(ctx context.Context, orderID uint64, kind notification.Kind) ([]service.ResendResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResendNotification", ctx, orderID, kind)
+	ret0, _ := ret[0].([]service.ResendResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResendNotification indicates an expected call of ResendNotification.
+func (mr *MockSupportServiceMockRecorder) ResendNotification(ctx, orderID, kind any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResendNotification", reflect.TypeOf((*MockSupportService)(nil).ResendNotification), ctx, orderID, kind)
+}
+
+// StoreCredits mocks base method.
+func (m *MockSupportService) StoreCredits(ctx context.Context, userID uint64, offset, limit int) (*service.StoreCreditAccountResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreCredits", ctx, userID, offset, limit)
+	ret0, _ := ret[0].(*service.StoreCreditAccountResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StoreCredits indicates an expected call of StoreCredits.
+func (mr *MockSupportServiceMockRecorder) StoreCredits(ctx, userID, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreCredits", reflect.TypeOf((*MockSupportService)(nil).StoreCredits), ctx, userID, offset, limit)
+}
+
+// UpdateShippingAddress mocks base method.
+func (m *MockSupportService) UpdateShippingAddress(ctx context.Context, orderID uint64, address model.Address) (*service.CustomerOrderResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateShippingAddress", ctx, orderID, address)
+	ret0, _ := ret[0].(*service.CustomerOrderResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateShippingAddress indicates an expected call of UpdateShippingAddress.
+func (mr *MockSupportServiceMockRecorder) UpdateShippingAddress(ctx, orderID, address any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateShippingAddress", reflect.TypeOf((*MockSupportService)(nil).UpdateShippingAddress), ctx, orderID, address)
+}
@@ -0,0 +1,114 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/synonym_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/synonym_repo.go -destination=internal/mocks/synonym_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSynonymRepository is a mock of SynonymRepository interface.
+type MockSynonymRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSynonymRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockSynonymRepositoryMockRecorder is the mock recorder for MockSynonymRepository.
+type MockSynonymRepositoryMockRecorder struct {
+	mock *MockSynonymRepository
+}
+
+// NewMockSynonymRepository creates a new mock instance.
+func NewMockSynonymRepository(ctrl *gomock.Controller) *MockSynonymRepository {
+	mock := &MockSynonymRepository{ctrl: ctrl}
+	mock.recorder = &MockSynonymRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSynonymRepository) EXPECT() *MockSynonymRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockSynonymRepository) Create(ctx context.Context, synonym *model.SearchSynonym) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, synonym)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockSynonymRepositoryMockRecorder) Create(ctx, synonym any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSynonymRepository)(nil).Create), ctx, synonym)
+}
+
+// Delete mocks base method.
+func (m *MockSynonymRepository) Delete(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockSynonymRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSynonymRepository)(nil).Delete), ctx, id)
+}
+
+// GetByID mocks base method.
+func (m *MockSynonymRepository) GetByID(ctx context.Context, id uint64) (*model.SearchSynonym, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*model.SearchSynonym)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockSynonymRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockSynonymRepository)(nil).GetByID), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockSynonymRepository) List(ctx context.Context) ([]model.SearchSynonym, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]model.SearchSynonym)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockSynonymRepositoryMockRecorder) List(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockSynonymRepository)(nil).List), ctx)
+}
+
+// UpdateTerms mocks base method.
+func (m *MockSynonymRepository) UpdateTerms(ctx context.Context, synonym *model.SearchSynonym) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTerms", ctx, synonym)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTerms indicates an expected call of UpdateTerms.
+func (mr *MockSynonymRepositoryMockRecorder) UpdateTerms(ctx, synonym any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTerms", reflect.TypeOf((*MockSynonymRepository)(nil).UpdateTerms), ctx, synonym)
+}
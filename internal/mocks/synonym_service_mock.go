@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/synonym_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/synonym_service.go -destination=internal/mocks/synonym_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSynonymService is a mock of SynonymService interface.
+type MockSynonymService struct {
+	ctrl     *gomock.Controller
+	recorder *MockSynonymServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockSynonymServiceMockRecorder is the mock recorder for MockSynonymService.
+type MockSynonymServiceMockRecorder struct {
+	mock *MockSynonymService
+}
+
+// NewMockSynonymService creates a new mock instance.
+func NewMockSynonymService(ctrl *gomock.Controller) *MockSynonymService {
+	mock := &MockSynonymService{ctrl: ctrl}
+	mock.recorder = &MockSynonymServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSynonymService) EXPECT() *MockSynonymServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockSynonymService) Create(ctx context.Context, terms []string) (*service.SynonymResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, terms)
+	ret0, _ := ret[0].(*service.SynonymResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockSynonymServiceMockRecorder) Create(ctx, terms any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSynonymService)(nil).Create), ctx, terms)
+}
+
+// Delete mocks base method.
+func (m *MockSynonymService) Delete(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockSynonymServiceMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSynonymService)(nil).Delete), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockSynonymService) List(ctx context.Context) ([]service.SynonymResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]service.SynonymResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockSynonymServiceMockRecorder) List(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockSynonymService)(nil).List), ctx)
+}
+
+// Update mocks base method.
+func (m *MockSynonymService) Update(ctx context.Context, id uint64, terms []string) (*service.SynonymResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, id, terms)
+	ret0, _ := ret[0].(*service.SynonymResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockSynonymServiceMockRecorder) Update(ctx, id, terms any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockSynonymService)(nil).Update), ctx, id, terms)
+}
@@ -41,10 +41,42 @@ func (m *MockMaker) EXPECT() *MockMakerMockRecorder {
 	return m.recorder
 }
 
+// CreateRefreshToken mocks base method.
+func (m *MockMaker) CreateRefreshToken(userID, storeID uint64, username string, duration time.Duration) (string, *token.Payload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRefreshToken", userID, storeID, username, duration)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(*token.Payload)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateRefreshToken indicates an expected call of CreateRefreshToken.
+func (mr *MockMakerMockRecorder) CreateRefreshToken(userID, storeID, username, duration any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefreshToken", reflect.TypeOf((*MockMaker)(nil).CreateRefreshToken), userID, storeID, username, duration)
+}
+
+// CreateSessionToken mocks base method.
+func (m *MockMaker) CreateSessionToken(userID, storeID uint64, username, role, sessionID string, duration time.Duration) (string, *token.Payload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSessionToken", userID, storeID, username, role, sessionID, duration)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(*token.Payload)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateSessionToken indicates an expected call of CreateSessionToken.
+func (mr *MockMakerMockRecorder) CreateSessionToken(userID, storeID, username, role, sessionID, duration any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSessionToken", reflect.TypeOf((*MockMaker)(nil).CreateSessionToken), userID, storeID, username, role, sessionID, duration)
+}
+
 // CreateToken mocks base method.
-func (m *MockMaker) CreateToken(userID uint64, username string, duration time.Duration) (string, *token.Payload, error) {
+func (m *MockMaker) CreateToken(userID, storeID uint64, username, role string, duration time.Duration) (string, *token.Payload, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateToken", userID, username, duration)
+	ret := m.ctrl.Call(m, "CreateToken", userID, storeID, username, role, duration)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(*token.Payload)
 	ret2, _ := ret[2].(error)
@@ -52,9 +84,24 @@ func (m *MockMaker) CreateToken(userID uint64, username string, duration time.Du
 }
 
 // CreateToken indicates an expected call of CreateToken.
-func (mr *MockMakerMockRecorder) CreateToken(userID, username, duration any) *gomock.Call {
+func (mr *MockMakerMockRecorder) CreateToken(userID, storeID, username, role, duration any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateToken", reflect.TypeOf((*MockMaker)(nil).CreateToken), userID, storeID, username, role, duration)
+}
+
+// VerifyRefreshToken mocks base method.
+func (m *MockMaker) VerifyRefreshToken(arg0 string) (*token.Payload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyRefreshToken", arg0)
+	ret0, _ := ret[0].(*token.Payload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyRefreshToken indicates an expected call of VerifyRefreshToken.
+func (mr *MockMakerMockRecorder) VerifyRefreshToken(arg0 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateToken", reflect.TypeOf((*MockMaker)(nil).CreateToken), userID, username, duration)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyRefreshToken", reflect.TypeOf((*MockMaker)(nil).VerifyRefreshToken), arg0)
 }
 
 // VerifyToken mocks base method.
@@ -0,0 +1,100 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/translation_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/translation_repo.go -destination=internal/mocks/translation_repo_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/proyuen/go-mall/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTranslationRepository is a mock of TranslationRepository interface.
+type MockTranslationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockTranslationRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockTranslationRepositoryMockRecorder is the mock recorder for MockTranslationRepository.
+type MockTranslationRepositoryMockRecorder struct {
+	mock *MockTranslationRepository
+}
+
+// NewMockTranslationRepository creates a new mock instance.
+func NewMockTranslationRepository(ctrl *gomock.Controller) *MockTranslationRepository {
+	mock := &MockTranslationRepository{ctrl: ctrl}
+	mock.recorder = &MockTranslationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTranslationRepository) EXPECT() *MockTranslationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockTranslationRepository) Delete(ctx context.Context, spuID uint64, locale string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, spuID, locale)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockTranslationRepositoryMockRecorder) Delete(ctx, spuID, locale any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTranslationRepository)(nil).Delete), ctx, spuID, locale)
+}
+
+// ListBySPU mocks base method.
+func (m *MockTranslationRepository) ListBySPU(ctx context.Context, spuID uint64) ([]model.SPUTranslation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBySPU", ctx, spuID)
+	ret0, _ := ret[0].([]model.SPUTranslation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBySPU indicates an expected call of ListBySPU.
+func (mr *MockTranslationRepositoryMockRecorder) ListBySPU(ctx, spuID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBySPU", reflect.TypeOf((*MockTranslationRepository)(nil).ListBySPU), ctx, spuID)
+}
+
+// ListBySPUIDs mocks base method.
+func (m *MockTranslationRepository) ListBySPUIDs(ctx context.Context, spuIDs []uint64, locale string) ([]model.SPUTranslation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBySPUIDs", ctx, spuIDs, locale)
+	ret0, _ := ret[0].([]model.SPUTranslation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBySPUIDs indicates an expected call of ListBySPUIDs.
+func (mr *MockTranslationRepositoryMockRecorder) ListBySPUIDs(ctx, spuIDs, locale any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBySPUIDs", reflect.TypeOf((*MockTranslationRepository)(nil).ListBySPUIDs), ctx, spuIDs, locale)
+}
+
+// Save mocks base method.
+func (m *MockTranslationRepository) Save(ctx context.Context, translation *model.SPUTranslation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, translation)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockTranslationRepositoryMockRecorder) Save(ctx, translation any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockTranslationRepository)(nil).Save), ctx, translation)
+}
@@ -0,0 +1,128 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/translation_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/translation_service.go -destination=internal/mocks/translation_service_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/proyuen/go-mall/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTranslationService is a mock of TranslationService interface.
+type MockTranslationService struct {
+	ctrl     *gomock.Controller
+	recorder *MockTranslationServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockTranslationServiceMockRecorder is the mock recorder for MockTranslationService.
+type MockTranslationServiceMockRecorder struct {
+	mock *MockTranslationService
+}
+
+// NewMockTranslationService creates a new mock instance.
+func NewMockTranslationService(ctrl *gomock.Controller) *MockTranslationService {
+	mock := &MockTranslationService{ctrl: ctrl}
+	mock.recorder = &MockTranslationServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTranslationService) EXPECT() *MockTranslationServiceMockRecorder {
+	return m.recorder
+}
+
+// DefaultLocale mocks base method.
+func (m *MockTranslationService) DefaultLocale() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DefaultLocale")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// DefaultLocale indicates an expected call of DefaultLocale.
+func (mr *MockTranslationServiceMockRecorder) DefaultLocale() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DefaultLocale", reflect.TypeOf((*MockTranslationService)(nil).DefaultLocale))
+}
+
+// Delete mocks base method.
+func (m *MockTranslationService) Delete(ctx context.Context, spuID uint64, locale string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, spuID, locale)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockTranslationServiceMockRecorder) Delete(ctx, spuID, locale any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTranslationService)(nil).Delete), ctx, spuID, locale)
+}
+
+// List mocks base method.
+func (m *MockTranslationService) List(ctx context.Context, spuID uint64) ([]service.TranslationResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, spuID)
+	ret0, _ := ret[0].([]service.TranslationResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockTranslationServiceMockRecorder) List(ctx, spuID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockTranslationService)(nil).List), ctx, spuID)
+}
+
+// Localize mocks base method.
+func (m *MockTranslationService) Localize(ctx context.Context, locale string, products []service.ProductResp) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Localize", ctx, locale, products)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Localize indicates an expected call of Localize.
+func (mr *MockTranslationServiceMockRecorder) Localize(ctx, locale, products any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Localize", reflect.TypeOf((*MockTranslationService)(nil).Localize), ctx, locale, products)
+}
+
+// Negotiate mocks base method.
+func (m *MockTranslationService) Negotiate(acceptLanguage string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Negotiate", acceptLanguage)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Negotiate indicates an expected call of Negotiate.
+func (mr *MockTranslationServiceMockRecorder) Negotiate(acceptLanguage any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Negotiate", reflect.TypeOf((*MockTranslationService)(nil).Negotiate), acceptLanguage)
+}
+
+// Put mocks base method.
+func (m *MockTranslationService) Put(ctx context.Context, spuID uint64, locale string, req *service.TranslationPutReq) (*service.TranslationResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Put", ctx, spuID, locale, req)
+	ret0, _ := ret[0].(*service.TranslationResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Put indicates an expected call of Put.
+func (mr *MockTranslationServiceMockRecorder) Put(ctx, spuID, locale, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockTranslationService)(nil).Put), ctx, spuID, locale, req)
+}
@@ -14,6 +14,7 @@ import (
 	reflect "reflect"
 
 	service "github.com/proyuen/go-mall/internal/service"
+	token "github.com/proyuen/go-mall/pkg/token"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -56,6 +57,35 @@ func (mr *MockUserServiceMockRecorder) Login(ctx, req any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockUserService)(nil).Login), ctx, req)
 }
 
+// Logout mocks base method.
+func (m *MockUserService) Logout(ctx context.Context, payload *token.Payload) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", ctx, payload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockUserServiceMockRecorder) Logout(ctx, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockUserService)(nil).Logout), ctx, payload)
+}
+
+// Refresh mocks base method.
+func (m *MockUserService) Refresh(ctx context.Context, refreshToken string) (*service.UserLoginResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refresh", ctx, refreshToken)
+	ret0, _ := ret[0].(*service.UserLoginResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Refresh indicates an expected call of Refresh.
+func (mr *MockUserServiceMockRecorder) Refresh(ctx, refreshToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*MockUserService)(nil).Refresh), ctx, refreshToken)
+}
+
 // Register mocks base method.
 func (m *MockUserService) Register(ctx context.Context, req *service.UserRegisterReq) (*service.UserRegisterResp, error) {
 	m.ctrl.T.Helper()
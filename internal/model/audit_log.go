@@ -0,0 +1,48 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/proyuen/go-mall/pkg/snowflake"
+	"gorm.io/gorm"
+)
+
+// ErrAuditLogImmutable is returned when code tries to change a written audit record.
+var ErrAuditLogImmutable = errors.New("audit log records are append-only")
+
+// AuditLog records one admin mutation. It deliberately does not embed Base:
+// rows are never updated or soft-deleted, so there is no UpdatedAt or DeletedAt.
+type AuditLog struct {
+	ID         uint64    `gorm:"primaryKey;autoIncrement:false" json:"id,string"`
+	CreatedAt  time.Time `gorm:"not null;index" json:"created_at"`
+	ActorID    uint64    `gorm:"not null;index" json:"actor_id,string"`
+	Action     string    `gorm:"not null;type:varchar(64);index" json:"action"` // e.g. "ip_rule.put"
+	Resource   string    `gorm:"not null;type:varchar(64);index:idx_audit_logs_resource" json:"resource"`
+	ResourceID string    `gorm:"type:varchar(128);index:idx_audit_logs_resource" json:"resource_id"`
+	Before     JSONB     `gorm:"type:jsonb" json:"before,omitempty"`
+	After      JSONB     `gorm:"type:jsonb" json:"after,omitempty"`
+	Diff       JSONB     `gorm:"type:jsonb" json:"diff,omitempty"` // field -> {"from": ..., "to": ...}
+	IP         string    `gorm:"type:varchar(45)" json:"ip"`
+	RequestID  string    `gorm:"type:varchar(64)" json:"request_id"`
+	Method     string    `gorm:"type:varchar(10)" json:"method"`
+	Path       string    `gorm:"type:varchar(255)" json:"path"`
+}
+
+// BeforeCreate assigns a Snowflake ID, mirroring Base.
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == 0 {
+		a.ID = snowflake.GenID()
+	}
+	return nil
+}
+
+// BeforeUpdate rejects updates so the trail cannot be rewritten through the ORM.
+func (a *AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+// BeforeDelete rejects deletes for the same reason.
+func (a *AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// Cart is a customer's shopping cart, kept server side so that carts left
+// without an order can be recovered. A customer has one open cart per store;
+// an order with any of its items converts it, and the next item added opens
+// a new one.
+type Cart struct {
+	Base
+	StoreID            uint64     `gorm:"not null;default:0;uniqueIndex:idx_carts_open,where:converted_at IS NULL" json:"store_id"`
+	UserID             uint64     `gorm:"not null;uniqueIndex:idx_carts_open,where:converted_at IS NULL" json:"user_id,string"`
+	ActiveAt           time.Time  `gorm:"index;not null" json:"active_at"` // Last change of its items
+	RecoveryNotifiedAt *time.Time `json:"recovery_notified_at"`            // When the customer was reminded of it, once abandoned
+	RecoveryCoupon     string     `gorm:"type:varchar(40);not null;default:''" json:"recovery_coupon"`
+	ConvertedAt        *time.Time `gorm:"index" json:"converted_at"`
+	OrderID            uint64     `gorm:"not null;default:0" json:"order_id,string"` // The order that converted it
+	Items              []CartItem `gorm:"foreignKey:CartID" json:"items"`
+}
+
+// Recovered reports whether the cart was converted after the customer was
+// reminded of it.
+func (c *Cart) Recovered() bool {
+	return c.RecoveryNotifiedAt != nil && c.ConvertedAt != nil && !c.ConvertedAt.Before(*c.RecoveryNotifiedAt)
+}
+
+// CartItem is one SKU in a cart. Items are deleted for good when removed, so
+// that a SKU can be added again.
+type CartItem struct {
+	Base
+	CartID   uint64 `gorm:"not null;uniqueIndex:idx_cart_items_sku" json:"cart_id,string"`
+	SKUID    uint64 `gorm:"not null;uniqueIndex:idx_cart_items_sku" json:"sku_id,string"`
+	Quantity int    `gorm:"not null;check:quantity > 0" json:"quantity"`
+}
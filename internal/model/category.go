@@ -0,0 +1,8 @@
+package model
+
+// Category groups SPUs; SPU.CategoryID refers to it. Top-level categories have ParentID 0.
+type Category struct {
+	Base
+	Name     string `gorm:"not null;type:varchar(100)" json:"name"`
+	ParentID uint64 `gorm:"index;not null;default:0" json:"parent_id,string"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+)
+
+// CouponCampaign is a set of single-use coupon codes, each unlocking the
+// campaign's coupon-only promotion for one order.
+type CouponCampaign struct {
+	Base
+	StoreID     uint64               `gorm:"index;not null;default:0" json:"store_id"`
+	Name        string               `gorm:"type:varchar(100);not null" json:"name"`
+	PromotionID uint64               `gorm:"index;not null" json:"promotion_id,string"`
+	Stats       *CouponCampaignStats `gorm:"foreignKey:CampaignID" json:"stats,omitempty"` // Nil until the first rollup after codes are generated
+}
+
+// Coupon is one code of a campaign. It is redeemed by the order placed with it.
+type Coupon struct {
+	Base
+	StoreID    uint64          `gorm:"not null;default:0;uniqueIndex:idx_coupons_store_code" json:"store_id"`
+	CampaignID uint64          `gorm:"index;not null" json:"campaign_id,string"`
+	Code       string          `gorm:"type:varchar(40);not null;uniqueIndex:idx_coupons_store_code" json:"code"` // Upper case
+	RedeemedAt *time.Time      `gorm:"index" json:"redeemed_at"`
+	OrderID    uint64          `gorm:"not null;default:0" json:"order_id,string"` // The order that redeemed it
+	UserID     uint64          `gorm:"not null;default:0" json:"user_id,string"`
+	Campaign   *CouponCampaign `gorm:"foreignKey:CampaignID" json:"-"`
+}
+
+// CouponCampaignStats is the redemption of a campaign's codes, rolled up
+// periodically by cmd/worker rather than counted on every read.
+type CouponCampaignStats struct {
+	CampaignID     uint64     `gorm:"primaryKey;autoIncrement:false" json:"campaign_id,string"`
+	StoreID        uint64     `gorm:"index;not null;default:0" json:"store_id"`
+	Generated      int64      `gorm:"not null;default:0" json:"generated"`
+	Redeemed       int64      `gorm:"not null;default:0" json:"redeemed"`
+	LastRedeemedAt *time.Time `json:"last_redeemed_at"`
+	RolledUpAt     time.Time  `gorm:"not null" json:"rolled_up_at"`
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Dispute status values, as in payment.Dispute. A dispute is open until it
+// is won or lost.
+const (
+	DisputeStatusNeedsResponse = "needs_response" // Evidence is due by EvidenceDueBy
+	DisputeStatusUnderReview   = "under_review"   // The bank is deciding
+	DisputeStatusWon           = "won"
+	DisputeStatusLost          = "lost" // The customer got the money back
+)
+
+// DisputeOpenStatuses are the statuses of disputes that are not decided yet.
+var DisputeOpenStatuses = []string{DisputeStatusNeedsResponse, DisputeStatusUnderReview}
+
+// Dispute is a chargeback of an order's payment, as the payment provider
+// reported it. Refunds of the order are frozen while it is open.
+type Dispute struct {
+	Base
+	StoreID    uint64          `gorm:"index;not null;default:0" json:"store_id"`
+	OrderID    uint64          `gorm:"index;not null" json:"order_id,string"`
+	Provider   string          `gorm:"type:varchar(20);not null;uniqueIndex:idx_dispute_provider_ref" json:"provider"`
+	DisputeRef string          `gorm:"type:varchar(255);not null;uniqueIndex:idx_dispute_provider_ref" json:"dispute_ref"` // The provider's ID of the dispute
+	PaymentRef string          `gorm:"type:varchar(255);not null" json:"payment_ref"`
+	Amount     decimal.Decimal `gorm:"type:numeric(10,2);not null" json:"amount"`
+	Currency   string          `gorm:"type:char(3);not null" json:"currency"`
+	Reason     string          `gorm:"type:varchar(50);not null;default:''" json:"reason"` // The provider's reason code
+	Status     string          `gorm:"type:varchar(20);not null;index" json:"status"`
+	// EvidenceDueBy is when the provider stops accepting evidence; nil if it
+	// did not say.
+	EvidenceDueBy       *time.Time `gorm:"index" json:"evidence_due_by"`
+	EvidenceSubmittedAt *time.Time `json:"evidence_submitted_at"`       // When an admin recorded submitting the evidence
+	EvidenceSubmittedBy uint64     `gorm:"not null;default:0" json:"-"` // Admin user ID
+	RemindedAt          *time.Time `json:"reminded_at"`                 // Last evidence reminder sent to admins
+	ClosedAt            *time.Time `json:"closed_at"`                   // When it was won or lost
+}
+
+// Open reports whether the dispute is not decided yet.
+func (d *Dispute) Open() bool {
+	return d.Status == DisputeStatusNeedsResponse || d.Status == DisputeStatusUnderReview
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// OutboxEvent is a domain event waiting to be published to the message bus.
+// Rows are written in the transaction that produced the event, and the relay
+// publishes them afterwards, so an event is published if and only if its
+// change committed.
+type OutboxEvent struct {
+	Base
+	StoreID     uint64     `gorm:"not null;default:0" json:"store_id,string"`
+	EventID     string     `gorm:"type:varchar(36);not null;uniqueIndex" json:"event_id"`
+	Type        string     `gorm:"type:varchar(32);not null;index:idx_outbox_aggregate,priority:1" json:"type"`
+	AggregateID uint64     `gorm:"not null;default:0;index:idx_outbox_aggregate,priority:2" json:"aggregate_id,string"` // The user, SPU or order the event is about
+	Payload     string     `gorm:"type:text;not null" json:"payload"`                                                   // Exact message body, so republishing is byte-identical
+	PublishedAt *time.Time `gorm:"index" json:"published_at,omitempty"`
+	// TraceParent is the W3C traceparent of the span that recorded the event,
+	// so that the relay publishes it in the trace of the request behind it.
+	TraceParent string `gorm:"type:varchar(55);not null;default:''" json:"trace_parent,omitempty"`
+}
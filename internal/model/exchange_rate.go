@@ -0,0 +1,14 @@
+package model
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// ExchangeRate is how many units of Currency one unit of BaseCurrency buys,
+// as last fetched. UpdatedAt is when it was fetched.
+type ExchangeRate struct {
+	Base
+	BaseCurrency string          `gorm:"type:char(3);not null;uniqueIndex:idx_exchange_rates_pair" json:"base_currency"`
+	Currency     string          `gorm:"type:char(3);not null;uniqueIndex:idx_exchange_rates_pair" json:"currency"`
+	Rate         decimal.Decimal `gorm:"type:numeric(20,10);not null;check:rate > 0" json:"rate"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// Failed message statuses.
+const (
+	FailedMessageStatusFailed   = "failed"   // Waiting for an admin to look into it
+	FailedMessageStatusQueued   = "queued"   // To be published to its queue again by cmd/worker
+	FailedMessageStatusReplayed = "replayed" // Published to its queue again
+)
+
+// FailedMessage is a message a consumer rejected for good, e.g. one it could
+// not decode, kept with its headers and the error so that an admin can fix
+// its body and replay it instead of the message only being logged.
+type FailedMessage struct {
+	Base
+	Queue         string            `gorm:"type:varchar(64);not null;index" json:"queue"`
+	MessageID     string            `gorm:"type:varchar(64);not null;default:''" json:"message_id"`
+	CorrelationID string            `gorm:"type:varchar(64);not null;default:''" json:"correlation_id"`
+	Headers       map[string]string `gorm:"type:jsonb;serializer:json;not null;default:'{}'" json:"headers"`
+	Body          string            `gorm:"type:text;not null" json:"body"` // Raw payload, as edited by an admin if it was
+	Error         string            `gorm:"type:text;not null" json:"error"`
+	Status        string            `gorm:"type:varchar(16);not null;default:'failed';index" json:"status"`
+	Replays       int               `gorm:"not null;default:0" json:"replays"`
+	ReplayedAt    *time.Time        `json:"replayed_at,omitempty"`
+}
@@ -0,0 +1,108 @@
+package model
+
+import "time"
+
+// NotificationPreference records which optional notifications a user receives,
+// and on which channels. Users without a row get the defaults of
+// notification.DefaultPreferences.
+type NotificationPreference struct {
+	Base
+	UserID uint64 `gorm:"uniqueIndex;not null" json:"user_id,string"`
+	Phone  string `gorm:"type:varchar(20);not null;default:''" json:"phone"` // SMS recipient; empty disables SMS
+	// Muted lists the channels the user turned off per notification category.
+	// Stored negated so that categories and channels added later are on.
+	Muted map[string][]string `gorm:"type:jsonb;serializer:json;not null;default:'{}'" json:"muted"`
+}
+
+// EmailSuppression is an address that hard-bounced; nothing is sent to it again.
+type EmailSuppression struct {
+	Base
+	Email  string `gorm:"uniqueIndex;not null;type:varchar(100)" json:"email"`
+	Reason string `gorm:"type:varchar(255)" json:"reason"`
+}
+
+// PushDevice is a device token a user's app registered for push notifications.
+type PushDevice struct {
+	Base
+	UserID   uint64 `gorm:"index;not null" json:"user_id,string"`
+	Platform string `gorm:"type:varchar(10);not null" json:"platform"` // Push service that issued the token: fcm or apns
+	Token    string `gorm:"uniqueIndex;not null;type:varchar(255)" json:"token"`
+}
+
+// NotificationDelivery tracks one notification over one channel across its
+// delivery attempts.
+type NotificationDelivery struct {
+	Base
+	DeliveryID string `gorm:"uniqueIndex;not null;type:varchar(36)" json:"delivery_id"`
+	UserID     uint64 `gorm:"index;not null" json:"user_id,string"`
+	Kind       string `gorm:"type:varchar(32);not null" json:"kind"`
+	Channel    string `gorm:"type:varchar(10);not null" json:"channel"`
+	Status     string `gorm:"type:varchar(20);not null" json:"status"`
+	Attempts   int    `gorm:"not null" json:"attempts"`
+	LastError  string `gorm:"type:varchar(255)" json:"last_error"`
+}
+
+// NotificationTemplate is one version of the template of a notification kind
+// on a channel in a locale, which overrides the template built into the
+// binary. Saving a template adds a version; the latest one is sent.
+type NotificationTemplate struct {
+	Base
+	Kind    string `gorm:"type:varchar(32);not null;uniqueIndex:idx_notification_templates_version" json:"kind"`
+	Channel string `gorm:"type:varchar(10);not null;uniqueIndex:idx_notification_templates_version" json:"channel"`
+	Locale  string `gorm:"type:varchar(35);not null;uniqueIndex:idx_notification_templates_version" json:"locale"` // BCP 47 tag, e.g. "zh" or "pt-BR"
+	Version int    `gorm:"not null;uniqueIndex:idx_notification_templates_version" json:"version"`
+	Subject string `gorm:"type:text;not null;default:''" json:"subject"` // Email subject or push title
+	Body    string `gorm:"type:text;not null" json:"body"`               // Email HTML content, SMS text or push body
+	Text    string `gorm:"type:text;not null;default:''" json:"text"`    // Email plain text
+}
+
+// Notification is a message in a user's in-app inbox, delivered over the
+// inbox channel like any other.
+type Notification struct {
+	Base
+	UserID     uint64            `gorm:"index:idx_notifications_user;not null" json:"user_id,string"`
+	DeliveryID string            `gorm:"uniqueIndex;not null;type:varchar(36)" json:"-"` // Keeps redeliveries from adding it twice
+	Kind       string            `gorm:"type:varchar(32);not null" json:"kind"`
+	Title      string            `gorm:"type:varchar(255);not null" json:"title"`
+	Body       string            `gorm:"type:text;not null" json:"body"`
+	Data       map[string]string `gorm:"type:jsonb;serializer:json;not null;default:'{}'" json:"data"` // Fields of the notification, e.g. order_number, for linking to it
+	ReadAt     *time.Time        `gorm:"index" json:"read_at"`
+}
+
+// Broadcast statuses. A broadcast is fanned out to its segment a batch of
+// recipients at a time, so it stays sending across several runs.
+const (
+	BroadcastStatusQueued  = "queued"
+	BroadcastStatusSending = "sending"
+	BroadcastStatusSent    = "sent"
+)
+
+// Broadcast is a marketing message an admin sends to a segment of the
+// store's customers over the chosen channels.
+type Broadcast struct {
+	Base
+	StoreID    uint64           `gorm:"index;not null;default:0" json:"store_id"`
+	Name       string           `gorm:"type:varchar(100);not null" json:"name"` // For admins only
+	Subject    string           `gorm:"type:varchar(255);not null" json:"subject"`
+	Message    string           `gorm:"type:text;not null" json:"message"`
+	URL        string           `gorm:"type:varchar(500);not null;default:''" json:"url"`
+	Channels   []string         `gorm:"type:jsonb;serializer:json;not null" json:"channels"`
+	Segment    BroadcastSegment `gorm:"type:jsonb;serializer:json;not null" json:"segment"`
+	Status     string           `gorm:"type:varchar(20);not null;index" json:"status"`
+	Cursor     uint64           `gorm:"not null;default:0" json:"-"`          // ID of the last user fanned out to
+	Recipients int64            `gorm:"not null;default:0" json:"recipients"` // Users fanned out to so far
+	CreatedBy  uint64           `gorm:"not null" json:"created_by,string"`    // Admin user ID
+	FinishedAt *time.Time       `json:"finished_at"`                          // When the last recipient was queued
+}
+
+// BroadcastSegment selects the customers a broadcast goes to. Unset filters
+// match everyone; orders count when they are paid, and only those placed
+// within OrderedAfter and OrderedBefore if set.
+type BroadcastSegment struct {
+	SignedUpAfter  *time.Time `json:"signed_up_after,omitempty"`
+	SignedUpBefore *time.Time `json:"signed_up_before,omitempty"`
+	MinOrders      int        `json:"min_orders,omitempty"`
+	MaxOrders      *int       `json:"max_orders,omitempty"` // 0 selects customers who never ordered
+	OrderedAfter   *time.Time `json:"ordered_after,omitempty"`
+	OrderedBefore  *time.Time `json:"ordered_before,omitempty"`
+}
@@ -1,16 +1,70 @@
 package model
 
 import (
+	"time"
+
 	"github.com/shopspring/decimal"
 )
 
+// Order statuses.
+const (
+	OrderStatusPending     = "pending"
+	OrderStatusAuthorized  = "authorized" // Payment held, captured as the order ships
+	OrderStatusPaid        = "paid"
+	OrderStatusBackordered = "backordered" // Paid, waiting for stock of pre-ordered items
+	OrderStatusCompleted   = "completed"
+	OrderStatusCancelled   = "cancelled"
+	OrderStatusFailed      = "failed" // Its stock could not be allocated once it was placed
+)
+
+// Fulfillment statuses of an order, which tell how far its shipments got
+// whatever its Status. Orders with nothing shipped have none.
+const (
+	FulfillmentPartiallyShipped   = "partially_shipped"
+	FulfillmentShipped            = "shipped"
+	FulfillmentPartiallyDelivered = "partially_delivered"
+	FulfillmentDelivered          = "delivered"
+)
+
 type Order struct {
 	Base
-	UserID      uint64          `gorm:"index;not null" json:"user_id"`
-	OrderNumber string          `gorm:"uniqueIndex;not null;type:varchar(64)" json:"order_number"`
-	TotalAmount decimal.Decimal `gorm:"type:numeric(10,2);not null" json:"total_amount"`
-	Status      string          `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
-	Items       []OrderItem     `gorm:"foreignKey:OrderID" json:"items"`
+	StoreID         uint64           `gorm:"index;not null;default:0" json:"store_id"`
+	UserID          uint64           `gorm:"index;not null" json:"user_id"`
+	OrderNumber     string           `gorm:"uniqueIndex;not null;type:varchar(64)" json:"order_number"`
+	TotalAmount     decimal.Decimal  `gorm:"type:numeric(10,2);not null" json:"total_amount"` // Items less discounts, plus tax
+	DiscountAmount  decimal.Decimal  `gorm:"type:numeric(10,2);not null;default:0" json:"discount_amount"`
+	TaxAmount       decimal.Decimal  `gorm:"type:numeric(10,2);not null;default:0" json:"tax_amount"`
+	Currency        string           `gorm:"type:char(3);not null;default:'USD'" json:"currency"` // ISO 4217 code the order is charged in; item prices are in it too
+	Region          string           `gorm:"type:varchar(6);not null;default:''" json:"region"`   // ISO 3166 code of where the order ships, which decides its tax
+	Status          string           `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	Fulfillment     string           `gorm:"type:varchar(20);not null;default:''" json:"fulfillment"`        // How far its shipments got; empty until one ships
+	PaymentProvider string           `gorm:"type:varchar(20);not null;default:''" json:"payment_provider"`   // Provider the order is paid through; empty until payment starts
+	PaymentRef      string           `gorm:"type:varchar(255);not null;default:'';index" json:"payment_ref"` // The provider's ID of the payment
+	PaymentIntent   PaymentIntent    `gorm:"embedded;embeddedPrefix:payment_intent_" json:"-"`
+	CapturedAmount  decimal.Decimal  `gorm:"type:numeric(10,2);not null;default:0" json:"captured_amount"` // Captured so far of an authorized payment
+	ShippingAddress Address          `gorm:"embedded;embeddedPrefix:shipping_" json:"shipping_address"`
+	Items           []OrderItem      `gorm:"foreignKey:OrderID" json:"items"`
+	TaxLines        []OrderTaxLine   `gorm:"foreignKey:OrderID" json:"tax_lines"`  // Created with the order
+	Promotions      []OrderPromotion `gorm:"foreignKey:OrderID" json:"promotions"` // Created with the order
+}
+
+// PaymentIntent is the payment started for an order with PaymentProvider,
+// kept so that paying again resumes it instead of starting another.
+type PaymentIntent struct {
+	ID           string `gorm:"type:varchar(255);not null;default:''"` // Empty until payment starts
+	QRCode       string `gorm:"type:varchar(512);not null;default:''"`
+	ClientSecret string `gorm:"type:varchar(255);not null;default:''"`
+}
+
+// Address is where an order ships to within its Region. It can be changed
+// until the order ships, but not the region, which decided the tax.
+type Address struct {
+	Name       string `gorm:"type:varchar(100);not null;default:''" json:"name"`
+	Phone      string `gorm:"type:varchar(20);not null;default:''" json:"phone"`
+	Line1      string `gorm:"type:varchar(255);not null;default:''" json:"line1"`
+	Line2      string `gorm:"type:varchar(255);not null;default:''" json:"line2"`
+	City       string `gorm:"type:varchar(100);not null;default:''" json:"city"`
+	PostalCode string `gorm:"type:varchar(20);not null;default:''" json:"postal_code"`
 }
 
 type OrderItem struct {
@@ -19,6 +73,53 @@ type OrderItem struct {
 	SKUID         uint64          `gorm:"index;not null" json:"sku_id"`
 	SnapshotName  string          `gorm:"not null;type:varchar(255)" json:"snapshot_name"`
 	SnapshotImage string          `gorm:"type:varchar(255)" json:"snapshot_image"`
-	Price         decimal.Decimal `gorm:"type:numeric(10,2);not null" json:"price"` // Price at the time of order
+	Price         decimal.Decimal `gorm:"type:numeric(10,2);not null" json:"price"` // Price at the time of order, with its options'
 	Quantity      int             `gorm:"not null;check:quantity > 0" json:"quantity"`
+	Discount      decimal.Decimal `gorm:"type:numeric(10,2);not null;default:0" json:"discount"` // Taken off the line by promotions, before tax
+	Backordered   bool            `gorm:"not null;default:false" json:"backordered"`             // Pre-ordered, and no stock allocated to it yet
+	Delivery      string          `gorm:"type:varchar(20);not null;default:''" json:"delivery"`  // The SKU's, when the order was placed; empty ships
+	DeliveredAt   *time.Time      `json:"delivered_at"`                                          // When a digital item was sent to the customer
+	// BundleItemID is set on the component lines of a bundle, to the bundle's
+	// line. They are priced at zero and fulfilled in its stead, while the
+	// customer is shown the bundle line.
+	BundleItemID *uint64 `gorm:"index" json:"bundle_item_id,string,omitempty"`
+	// Options are the product options chosen for every unit of the item.
+	Options []OrderItemOption `gorm:"foreignKey:OrderItemID" json:"options,omitempty"`
+}
+
+// MaxOrderItemOptionValue is the longest value of an OrderItemOption.
+const MaxOrderItemOptionValue = 255
+
+// OrderItemOption is a product option chosen for an order item: a modifier
+// line of it, whose price is part of the item's price.
+type OrderItemOption struct {
+	Base
+	OrderItemID uint64          `gorm:"index;not null" json:"order_item_id,string"`
+	Code        string          `gorm:"type:varchar(40);not null" json:"code"`
+	Name        string          `gorm:"type:varchar(100);not null" json:"name"`             // The option's, when the order was placed
+	Value       string          `gorm:"type:varchar(255);not null;default:''" json:"value"` // A text option's
+	Price       decimal.Decimal `gorm:"type:numeric(10,2);not null" json:"price"`           // Per unit, in the order's currency
+}
+
+// OrderTaxLine is one tax charged on an order, kept for invoices and tax reports.
+type OrderTaxLine struct {
+	Base
+	OrderID uint64          `gorm:"index;not null" json:"order_id"`
+	Name    string          `gorm:"type:varchar(64);not null" json:"name"`
+	Rate    decimal.Decimal `gorm:"type:numeric(7,6);not null" json:"rate"` // Fraction of the subtotal; informational for provider lines
+	Amount  decimal.Decimal `gorm:"type:numeric(10,2);not null;check:amount >= 0" json:"amount"`
+}
+
+// OrderPromotion is a promotion applied to an order, kept with what it took
+// off and why.
+type OrderPromotion struct {
+	Base
+	OrderID       uint64          `gorm:"index;not null" json:"order_id"`
+	PromotionID   uint64          `gorm:"index;not null" json:"promotion_id"`
+	Name          string          `gorm:"type:varchar(100);not null" json:"name"`
+	Amount        decimal.Decimal `gorm:"type:numeric(10,2);not null;check:amount > 0" json:"amount"`
+	Detail        string          `gorm:"type:varchar(255);not null;default:''" json:"detail"`
+	CouponCode    string          `gorm:"type:varchar(40);not null;default:''" json:"coupon_code"` // The code that unlocked a coupon-only promotion
+	Units         int             `gorm:"not null;default:0" json:"units"`                         // Of the items it covered
+	PurchaseLimit int             `gorm:"not null;default:0" json:"purchase_limit"`                // The promotion's, when the order was placed
 }
@@ -0,0 +1,33 @@
+package model
+
+import "github.com/shopspring/decimal"
+
+// Order modification kinds
+const (
+	OrderModificationQuantity        = "quantity"         // The quantity of an item changed
+	OrderModificationShippingAddress = "shipping_address" // The shipping address changed
+)
+
+// Payment adjustments of an order modification
+const (
+	PaymentAdjustmentNone   = ""       // Nothing to settle: unpaid, or the total stayed
+	PaymentAdjustmentCharge = "charge" // The difference was charged to the order's payment method
+	PaymentAdjustmentRefund = "refund" // The difference was refunded to the order's payment
+)
+
+// OrderModification is a change the customer made to their order before it
+// shipped, kept as the order's modification history.
+type OrderModification struct {
+	Base
+	StoreID     uint64          `gorm:"index;not null;default:0" json:"store_id"`
+	OrderID     uint64          `gorm:"index;not null" json:"order_id,string"`
+	UserID      uint64          `gorm:"not null" json:"user_id,string"` // Who made it
+	Kind        string          `gorm:"type:varchar(20);not null" json:"kind"`
+	OrderItemID uint64          `gorm:"not null;default:0" json:"order_item_id,string"` // The item whose quantity changed
+	Before      JSONB           `gorm:"type:jsonb" json:"before"`
+	After       JSONB           `gorm:"type:jsonb" json:"after"`
+	AmountDelta decimal.Decimal `gorm:"type:numeric(10,2);not null;default:0" json:"amount_delta"` // Added to the order total; negative when it went down
+	Currency    string          `gorm:"type:char(3);not null" json:"currency"`
+	Adjustment  string          `gorm:"type:varchar(20);not null;default:''" json:"adjustment"`
+	PaymentRef  string          `gorm:"type:varchar(255);not null;default:''" json:"payment_ref"` // The provider's ID of the extra charge, or the reference of the refund
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// OrderSummary is the read model of a customer's order list: one row per
+// order with what the list shows, so it is read without joining the items.
+// cmd/worker rebuilds it from the order whenever an order event arrives, so
+// it lags the order by the event relay.
+type OrderSummary struct {
+	OrderID        uint64             `gorm:"primaryKey;autoIncrement:false" json:"order_id,string"`
+	StoreID        uint64             `gorm:"index;not null;default:0" json:"store_id"`
+	UserID         uint64             `gorm:"not null;index:idx_order_summaries_user,priority:1" json:"user_id,string"`
+	OrderNumber    string             `gorm:"type:varchar(64);not null" json:"order_number"`
+	Status         string             `gorm:"type:varchar(20);not null" json:"status"`
+	TotalAmount    decimal.Decimal    `gorm:"type:numeric(10,2);not null" json:"total_amount"`
+	Currency       string             `gorm:"type:char(3);not null" json:"currency"`
+	ItemCount      int                `gorm:"not null" json:"item_count"` // Units across the lines
+	Items          []OrderSummaryItem `gorm:"type:jsonb;serializer:json;not null" json:"items"`
+	OrderedAt      time.Time          `gorm:"not null;index:idx_order_summaries_user,priority:2,sort:desc" json:"ordered_at"`
+	OrderUpdatedAt time.Time          `gorm:"not null" json:"-"` // Of the order it was built from; older rebuilds are discarded
+}
+
+// OrderSummaryItem is one line of an OrderSummary.
+type OrderSummaryItem struct {
+	SKUID    uint64          `json:"sku_id,string"`
+	Name     string          `json:"name"`
+	Image    string          `json:"image"`
+	Quantity int             `json:"quantity"`
+	Price    decimal.Decimal `json:"price"`
+	// Options are the product options chosen for every unit, included in
+	// Price.
+	Options []OrderSummaryOption `json:"options,omitempty"`
+}
+
+// OrderSummaryOption is a product option chosen for an OrderSummaryItem.
+type OrderSummaryOption struct {
+	Code  string          `json:"code"`
+	Name  string          `json:"name"`
+	Value string          `json:"value,omitempty"`
+	Price decimal.Decimal `json:"price"`
+}
@@ -0,0 +1,17 @@
+package model
+
+// PaymentMethod is a card a user saved with the payment provider for one-click
+// checkout. Only the provider's references and what is needed to tell saved
+// cards apart are kept; card numbers never reach the store.
+type PaymentMethod struct {
+	Base
+	StoreID     uint64 `gorm:"index;not null;default:0" json:"store_id"`
+	UserID      uint64 `gorm:"index;not null" json:"user_id,string"`
+	Provider    string `gorm:"type:varchar(20);not null;uniqueIndex:idx_payment_methods_method_ref" json:"provider"` // Provider the method is saved with, e.g. stripe
+	CustomerRef string `gorm:"type:varchar(255);not null" json:"-"`                                                  // The user's customer ID at the provider, shared by their methods
+	MethodRef   string `gorm:"type:varchar(255);not null;uniqueIndex:idx_payment_methods_method_ref" json:"-"`       // The provider's ID of the method, e.g. pm_...
+	Brand       string `gorm:"type:varchar(32);not null;default:''" json:"brand"`
+	Last4       string `gorm:"type:varchar(4);not null;default:''" json:"last4"`
+	ExpMonth    int    `gorm:"not null;default:0" json:"exp_month"`
+	ExpYear     int    `gorm:"not null;default:0" json:"exp_year"`
+}
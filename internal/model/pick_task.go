@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Pick task status values
+const (
+	PickTaskStatusOpen    = "open"    // Waiting for someone to pick it
+	PickTaskStatusClaimed = "claimed" // Being picked by AssigneeID
+	PickTaskStatusPacked  = "packed"  // Picked and packed; ships with the rest of the order
+)
+
+// PickTask is the items of a paid order stored in one warehouse zone, for
+// fulfillment staff to pick and pack. Once every task of the order in the
+// warehouse is packed they ship together.
+type PickTask struct {
+	Base
+	StoreID    uint64         `gorm:"index;not null;default:0" json:"store_id"`
+	OrderID    uint64         `gorm:"index;not null" json:"order_id,string"`
+	Warehouse  string         `gorm:"type:varchar(30);not null;default:''" json:"warehouse"`  // The SKUs' warehouse; empty when they have none
+	Zone       string         `gorm:"type:varchar(30);not null;default:'';index" json:"zone"` // The SKUs' warehouse zone; empty when they have none
+	Status     string         `gorm:"type:varchar(20);not null;index" json:"status"`
+	AssigneeID uint64         `gorm:"not null;default:0" json:"assignee_id,string"` // Who claimed it
+	ClaimedAt  *time.Time     `json:"claimed_at"`
+	PackedAt   *time.Time     `json:"packed_at"`
+	ShipmentID uint64         `gorm:"not null;default:0" json:"shipment_id,string"` // The shipment it went out with; 0 until it ships
+	Items      []PickTaskItem `gorm:"foreignKey:PickTaskID" json:"items"`
+}
+
+// PickTaskItem is an order item to pick. An order item is on one task only.
+type PickTaskItem struct {
+	Base
+	PickTaskID  uint64 `gorm:"index;not null" json:"pick_task_id,string"`
+	OrderItemID uint64 `gorm:"uniqueIndex;not null" json:"order_item_id,string"`
+	SKUID       uint64 `gorm:"not null" json:"sku_id,string"`
+	Quantity    int    `gorm:"not null;check:quantity > 0" json:"quantity"`
+}
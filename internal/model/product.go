@@ -1,24 +1,176 @@
 package model
 
 import (
+	"time"
+
 	"github.com/shopspring/decimal"
 )
 
 // SPU (Standard Product Unit) represents a product aggregation.
 type SPU struct {
 	Base
-	Name        string `gorm:"not null;type:varchar(100)" json:"name"`
-	Description string `gorm:"type:text" json:"description"`
-	CategoryID  uint64 `gorm:"index;not null" json:"category_id"`
-	SKUs        []SKU  `gorm:"foreignKey:SPUID" json:"skus"`
+	StoreID     uint64     `gorm:"index;not null;default:0" json:"store_id"`
+	Name        string     `gorm:"not null;type:varchar(100)" json:"name"`
+	Description string     `gorm:"type:text" json:"description"`
+	CategoryID  uint64     `gorm:"index;not null" json:"category_id"`
+	SKUs        []SKU      `gorm:"foreignKey:SPUID" json:"skus"`
+	Rating      *SPURating `gorm:"foreignKey:SPUID" json:"-"` // Loaded with the SPU by ID only; nil until it is reviewed
+	// Options are what customers may choose for every unit of its SKUs, at
+	// a price on top of the SKU's; they are created with it.
+	Options []ProductOption `gorm:"foreignKey:SPUID" json:"options,omitempty"`
+}
+
+// Kinds of product options.
+const (
+	ProductOptionFlag = "flag" // Chosen or not, e.g. an extended warranty
+	ProductOptionText = "text" // Chosen with a text of the customer's, e.g. an engraving
+)
+
+// ProductOption is an option of an SPU, which prices a unit of any of its
+// SKUs up by Price. The options of an SPU are the schema the options chosen
+// for its order items are checked against.
+type ProductOption struct {
+	Base
+	StoreID   uint64          `gorm:"index;not null;default:0" json:"store_id"`
+	SPUID     uint64          `gorm:"not null;uniqueIndex:idx_product_options_code" json:"spu_id,string"`
+	Code      string          `gorm:"type:varchar(40);not null;uniqueIndex:idx_product_options_code" json:"code"` // What orders choose it by
+	Name      string          `gorm:"type:varchar(100);not null" json:"name"`
+	Kind      string          `gorm:"type:varchar(10);not null" json:"kind"`
+	MaxLength int             `gorm:"not null;default:0" json:"max_length"` // Of a text option's value; 0 is the most an order item option holds
+	Price     decimal.Decimal `gorm:"type:numeric(10,2);not null;check:price >= 0" json:"price"`
+	Currency  string          `gorm:"type:char(3);not null;default:'USD'" json:"currency"` // ISO 4217 code Price is in
 }
 
 // SKU (Stock Keeping Unit) represents a specific product variant.
 type SKU struct {
 	Base
-	SPUID      uint64          `gorm:"index;not null" json:"spu_id"`
-	Attributes JSONB           `gorm:"type:jsonb" json:"attributes"` // Dynamic attributes (Color, Size)
-	Price      decimal.Decimal `gorm:"type:numeric(10,2);not null" json:"price"`
-	Stock      int             `gorm:"not null;check:stock >= 0" json:"stock"`
-	SPU        SPU             `gorm:"foreignKey:SPUID" json:"-"`
+	StoreID       uint64          `gorm:"index;not null;default:0" json:"store_id"` // Same as its SPU's, so SKU queries are scoped too
+	SPUID         uint64          `gorm:"index;not null" json:"spu_id"`
+	Attributes    JSONB           `gorm:"type:jsonb" json:"attributes"` // Dynamic attributes (Color, Size)
+	Price         decimal.Decimal `gorm:"type:numeric(10,2);not null" json:"price"`
+	Currency      string          `gorm:"type:char(3);not null;default:'USD'" json:"currency"` // ISO 4217 code Price is in
+	Stock         int             `gorm:"not null;check:stock >= 0" json:"stock"`
+	PurchaseLimit int             `gorm:"not null;default:0;check:purchase_limit >= 0" json:"purchase_limit"` // Most units one customer may buy; 0 is unlimited
+	PreOrder      bool            `gorm:"not null;default:false" json:"pre_order"`                            // Orderable without stock: ordered units are backordered until stock arrives
+	AvailableAt   *time.Time      `json:"available_at"`                                                       // When a pre-order SKU is expected in stock
+	Delivery      string          `gorm:"type:varchar(20);not null;default:''" json:"delivery"`               // license_key or download for digital goods, bundle for kits; empty ships
+	DownloadPath  string          `gorm:"type:varchar(512);not null;default:''" json:"-"`                     // download only: the file's path under digital.download_base_url
+	Channels      string          `gorm:"type:varchar(64);not null;default:''" json:"channels"`               // Comma-separated sales channels it is sold on (web, app, wholesale); empty is all
+	Warehouse     string          `gorm:"type:varchar(30);not null;default:''" json:"warehouse"`              // Which warehouse stocks it; an order's items from different warehouses ship separately
+	WarehouseZone string          `gorm:"type:varchar(30);not null;default:''" json:"warehouse_zone"`         // Where in the warehouse it is stored; its items are picked with the zone's other items
+	// SalePrice is what the SKU sells for during a sale started by a
+	// PriceSchedule, until SaleEndsAt; nil outside sales. Price stays the
+	// regular price.
+	SalePrice  *decimal.Decimal `gorm:"type:numeric(10,2)" json:"sale_price"`
+	SaleEndsAt *time.Time       `json:"sale_ends_at"`
+	SPU        SPU              `gorm:"foreignKey:SPUID" json:"-"`
+	// Components are what a bundle SKU is made of; they are created with it.
+	Components []SKUComponent `gorm:"foreignKey:BundleSKUID" json:"components,omitempty"`
+}
+
+// OnSale reports whether the SKU sells for its sale price at now. A sale
+// that ended is over even before cmd/worker clears it.
+func (s *SKU) OnSale(now time.Time) bool {
+	return s.SalePrice != nil && (s.SaleEndsAt == nil || now.Before(*s.SaleEndsAt))
+}
+
+// EffectivePrice is what the SKU sells for at now: its sale price during a
+// sale, its price otherwise.
+func (s *SKU) EffectivePrice(now time.Time) decimal.Decimal {
+	if s.OnSale(now) {
+		return *s.SalePrice
+	}
+	return s.Price
+}
+
+// SKUDeliveryBundle is the delivery of a bundle SKU: a kit of other SKUs,
+// its components, sold at its own price. The bundle has no stock of its own;
+// ordering it takes its components' stock, and its order items are fulfilled
+// through the component lines added for them.
+const SKUDeliveryBundle = "bundle"
+
+// SKUComponent is Quantity units of a SKU in every unit of a bundle SKU.
+type SKUComponent struct {
+	Base
+	StoreID        uint64 `gorm:"index;not null;default:0" json:"store_id"`
+	BundleSKUID    uint64 `gorm:"not null;uniqueIndex:idx_sku_components_component" json:"bundle_sku_id,string"`
+	ComponentSKUID uint64 `gorm:"not null;uniqueIndex:idx_sku_components_component;index" json:"component_sku_id,string"`
+	Quantity       int    `gorm:"not null;check:quantity > 0" json:"quantity"`
+}
+
+// SKUPriceTier is the price customers in a group other than retail pay for a
+// SKU instead of its own price, in the SKU's currency.
+type SKUPriceTier struct {
+	Base
+	StoreID       uint64          `gorm:"index;not null;default:0" json:"store_id"`
+	SKUID         uint64          `gorm:"not null;uniqueIndex:idx_sku_price_tiers_group" json:"sku_id,string"`
+	CustomerGroup string          `gorm:"type:varchar(20);not null;uniqueIndex:idx_sku_price_tiers_group" json:"customer_group"`
+	Price         decimal.Decimal `gorm:"type:numeric(10,2);not null" json:"price"`
+}
+
+// Statuses of a PriceSchedule.
+const (
+	PriceSchedulePending   = "pending"   // Waits for StartsAt
+	PriceScheduleActive    = "active"    // A sale in progress until EndsAt
+	PriceScheduleDone      = "done"      // Applied, or a sale that ended
+	PriceScheduleCancelled = "cancelled" // Withdrawn by an admin
+)
+
+// PriceSchedule changes the price of a SKU at StartsAt. Without EndsAt the
+// change is permanent and replaces the SKU's price; with it, Price is a sale
+// price the SKU sells for until EndsAt, when its own price applies again.
+// cmd/worker applies schedules once they are due.
+type PriceSchedule struct {
+	Base
+	StoreID  uint64          `gorm:"index;not null;default:0" json:"store_id"`
+	SKUID    uint64          `gorm:"index;not null" json:"sku_id,string"`
+	Price    decimal.Decimal `gorm:"type:numeric(10,2);not null" json:"price"` // In the SKU's currency
+	StartsAt time.Time       `gorm:"not null" json:"starts_at"`
+	EndsAt   *time.Time      `json:"ends_at"`
+	Status   string          `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+}
+
+// IsSale reports whether the schedule is a sale rather than a permanent
+// price change.
+func (p *PriceSchedule) IsSale() bool {
+	return p.EndsAt != nil
+}
+
+// SPUStats is how often an SPU was viewed and how popular it is. Views are
+// counted in Redis and flushed here by cmd/worker, which also rescores them.
+type SPUStats struct {
+	SPUID        uint64     `gorm:"primaryKey;autoIncrement:false" json:"spu_id,string"`
+	StoreID      uint64     `gorm:"index;not null;default:0" json:"store_id"`
+	Views        int64      `gorm:"not null;default:0" json:"views"`
+	PendingViews int64      `gorm:"not null;default:0" json:"-"`           // Flushed since the last rescore
+	Score        float64    `gorm:"not null;default:0;index" json:"score"` // Views decayed by their age, halving every popularity.half_life
+	ScoredAt     *time.Time `json:"scored_at"`
+}
+
+// Delivery of digital SKUs, which are sent to the customer once paid instead
+// of shipped.
+const (
+	SKUDeliveryLicenseKey = "license_key" // A key from the SKU's LicenseKey pool per unit
+	SKUDeliveryDownload   = "download"    // A signed link to the SKU's file
+)
+
+// LicenseKey is one key in the pool of a license_key SKU. It is allocated to
+// an order item once the order is paid.
+type LicenseKey struct {
+	Base
+	StoreID     uint64     `gorm:"index;not null;default:0" json:"store_id"`
+	SKUID       uint64     `gorm:"not null;uniqueIndex:idx_license_keys_sku_key;index:idx_license_keys_available,where:order_item_id = 0" json:"sku_id,string"`
+	Key         string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_license_keys_sku_key" json:"-"`
+	OrderItemID uint64     `gorm:"not null;default:0;index" json:"order_item_id,string"` // 0 while available
+	AllocatedAt *time.Time `json:"allocated_at"`
+}
+
+// SPUTranslation is an SPU's name and description in a locale other than the
+// catalog's default, which the SPU itself is written in.
+type SPUTranslation struct {
+	Base
+	SPUID       uint64 `gorm:"not null;uniqueIndex:idx_spu_translations_locale" json:"spu_id"`
+	Locale      string `gorm:"type:varchar(35);not null;uniqueIndex:idx_spu_translations_locale" json:"locale"` // BCP 47 tag, e.g. "zh" or "pt-BR"
+	Name        string `gorm:"not null;type:varchar(100)" json:"name"`
+	Description string `gorm:"type:text" json:"description"` // Empty falls back to the SPU's description
 }
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Promotion is a discount rule evaluated at checkout; see the promotion
+// package for how the kinds and stacking policies work.
+type Promotion struct {
+	Base
+	StoreID       uint64          `gorm:"index;not null;default:0" json:"store_id"`
+	Name          string          `gorm:"type:varchar(100);not null" json:"name"`
+	Kind          string          `gorm:"type:varchar(20);not null" json:"kind"`                              // buy_x_get_y, tiered or category_sale
+	Stacking      string          `gorm:"type:varchar(20);not null;default:'stackable'" json:"stacking"`      // stackable or exclusive
+	Priority      int             `gorm:"not null;default:0" json:"priority"`                                 // Higher applies first among stackable promotions
+	CategoryID    uint64          `gorm:"not null;default:0" json:"category_id,string"`                       // Covers the category and its subcategories; 0 covers every item
+	BuyQuantity   int             `gorm:"not null;default:0" json:"buy_quantity"`                             // buy_x_get_y only
+	GetQuantity   int             `gorm:"not null;default:0" json:"get_quantity"`                             // buy_x_get_y only
+	Percent       decimal.Decimal `gorm:"type:numeric(5,2);not null;default:0" json:"percent"`                // Off the free units of buy_x_get_y, or the items of category_sale
+	Currency      string          `gorm:"type:char(3);not null;default:''" json:"currency"`                   // ISO 4217 code tier thresholds are in
+	CouponOnly    bool            `gorm:"not null;default:false" json:"coupon_only"`                          // Only applies to orders redeeming a code of one of its coupon campaigns
+	PurchaseLimit int             `gorm:"not null;default:0;check:purchase_limit >= 0" json:"purchase_limit"` // Most units of covered items one customer may buy under it; 0 is unlimited
+	StartsAt      time.Time       `gorm:"index;not null" json:"starts_at"`
+	EndsAt        *time.Time      `gorm:"index" json:"ends_at"`                // Nil runs until deleted
+	Tiers         []PromotionTier `gorm:"foreignKey:PromotionID" json:"tiers"` // tiered only; created with the promotion
+}
+
+// PromotionTier is one step of a tiered promotion: Percent off once
+// Threshold is spent on the items it covers.
+type PromotionTier struct {
+	Base
+	PromotionID uint64          `gorm:"index;not null" json:"promotion_id,string"`
+	Threshold   decimal.Decimal `gorm:"type:numeric(10,2);not null" json:"threshold"`
+	Percent     decimal.Decimal `gorm:"type:numeric(5,2);not null" json:"percent"`
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Supplier is a vendor the store buys stock from.
+type Supplier struct {
+	Base
+	StoreID      uint64 `gorm:"index;not null;default:0" json:"store_id"`
+	Name         string `gorm:"type:varchar(100);not null" json:"name"`
+	Email        string `gorm:"type:varchar(255);not null;default:''" json:"email"`
+	Phone        string `gorm:"type:varchar(30);not null;default:''" json:"phone"`
+	LeadTimeDays int    `gorm:"not null;default:0" json:"lead_time_days"` // How long its deliveries usually take
+}
+
+// Purchase order status values
+const (
+	PurchaseOrderStatusOpen      = "open"      // Ordered; waiting for some or all of the goods
+	PurchaseOrderStatusReceived  = "received"  // Every item was received in full
+	PurchaseOrderStatusCancelled = "cancelled" // What was not received by then never will be
+)
+
+// PurchaseOrder is stock ordered from a supplier. Its items are received,
+// in one or more deliveries, into the stock of their SKUs.
+type PurchaseOrder struct {
+	Base
+	StoreID    uint64              `gorm:"index;not null;default:0" json:"store_id"`
+	SupplierID uint64              `gorm:"index;not null" json:"supplier_id,string"`
+	Status     string              `gorm:"type:varchar(20);not null;index" json:"status"`
+	Currency   string              `gorm:"type:char(3);not null" json:"currency"` // Of the unit costs
+	ExpectedAt *time.Time          `json:"expected_at"`                           // When the goods are expected; pre-order SKUs are shown available then
+	Note       string              `gorm:"type:text;not null;default:''" json:"note"`
+	Items      []PurchaseOrderItem `gorm:"foreignKey:PurchaseOrderID" json:"items"`
+}
+
+// PurchaseOrderItem is a quantity of a SKU ordered from a supplier, and how
+// much of it was received so far.
+type PurchaseOrderItem struct {
+	Base
+	PurchaseOrderID uint64          `gorm:"index;not null" json:"purchase_order_id,string"`
+	SKUID           uint64          `gorm:"index;not null" json:"sku_id,string"`
+	Quantity        int             `gorm:"not null;check:quantity > 0" json:"quantity"`
+	Received        int             `gorm:"not null;default:0;check:received >= 0" json:"received"`
+	UnitCost        decimal.Decimal `gorm:"type:numeric(10,2);not null;default:0" json:"unit_cost"`
+}
+
+// Outstanding returns how much of the item is still to be received.
+func (i *PurchaseOrderItem) Outstanding() int {
+	return max(i.Quantity-i.Received, 0)
+}
+
+// Stock movement reasons
+const (
+	StockMovementReceipt     = "purchase_receipt" // Goods of a purchase order received; ReferenceID is the purchase order
+	StockMovementHold        = "hold"             // Stock set aside by a stock hold; ReferenceID is the hold
+	StockMovementHoldRelease = "hold_release"     // Stock of a released or expired hold put back; ReferenceID is the hold
+)
+
+// StockMovement is one entry of the stock ledger: a change to the stock of a
+// SKU, why it happened and what the stock was after it.
+type StockMovement struct {
+	Base
+	StoreID     uint64 `gorm:"index;not null;default:0" json:"store_id"`
+	SKUID       uint64 `gorm:"index;not null" json:"sku_id,string"`
+	Quantity    int    `gorm:"not null" json:"quantity"` // Added to the stock; negative when taken out
+	StockAfter  int    `gorm:"not null" json:"stock_after"`
+	Reason      string `gorm:"type:varchar(30);not null" json:"reason"`
+	ReferenceID uint64 `gorm:"not null;default:0" json:"reference_id,string"` // What caused it, e.g. the purchase order
+}
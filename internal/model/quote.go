@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Quote status values
+const (
+	QuoteStatusRequested = "requested" // Waiting for an admin to price it
+	QuoteStatusQuoted    = "quoted"    // Priced; the buyer may accept it until it expires
+	QuoteStatusAccepted  = "accepted"  // Placed as OrderID at the quoted prices
+	QuoteStatusDeclined  = "declined"  // Turned down by an admin
+	// QuoteStatusExpired is shown for quoted quotes past their ExpiresAt. It
+	// is never stored: the quote stays quoted, so that it can be quoted again.
+	QuoteStatusExpired = "expired"
+)
+
+// Quote is a bulk buyer's request for a price on quantities of SKUs, and the
+// prices an admin offers for them.
+type Quote struct {
+	Base
+	StoreID   uint64      `gorm:"index;not null;default:0" json:"store_id"`
+	UserID    uint64      `gorm:"index;not null" json:"user_id,string"`
+	Status    string      `gorm:"type:varchar(20);not null;index" json:"status"`
+	Currency  string      `gorm:"type:char(3);not null" json:"currency"` // What the items are priced in
+	Region    string      `gorm:"type:varchar(10);not null;default:''" json:"region"`
+	Note      string      `gorm:"type:text;not null;default:''" json:"note"` // The buyer's message to the admins
+	ExpiresAt *time.Time  `json:"expires_at"`                                // Until when a quoted quote can be accepted
+	OrderID   uint64      `gorm:"not null;default:0" json:"order_id,string"`
+	Items     []QuoteItem `gorm:"foreignKey:QuoteID" json:"items"`
+}
+
+// QuoteItem is a quantity of a SKU asked for in a quote, and its quoted unit
+// price.
+type QuoteItem struct {
+	Base
+	QuoteID  uint64          `gorm:"index;not null" json:"quote_id,string"`
+	SKUID    uint64          `gorm:"not null" json:"sku_id,string"`
+	Quantity int             `gorm:"not null;check:quantity > 0" json:"quantity"`
+	Price    decimal.Decimal `gorm:"type:numeric(10,2);not null;default:0" json:"price"` // Zero until quoted
+}
+
+// Expired reports whether the quote was quoted and can no longer be accepted
+// at now.
+func (q *Quote) Expired(now time.Time) bool {
+	return q.Status == QuoteStatusQuoted && q.ExpiresAt != nil && !now.Before(*q.ExpiresAt)
+}
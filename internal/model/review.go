@@ -0,0 +1,67 @@
+package model
+
+import "time"
+
+// Review is a customer's rating of an SPU.
+type Review struct {
+	Base
+	StoreID        uint64 `gorm:"index;not null;default:0" json:"store_id"` // Same as its SPU's, so review queries are scoped too
+	SPUID          uint64 `gorm:"index;not null" json:"spu_id,string"`
+	UserID         uint64 `gorm:"index;not null" json:"user_id,string"`
+	Rating         int    `gorm:"not null;check:rating BETWEEN 1 AND 5" json:"rating"`
+	Comment        string `gorm:"type:text" json:"comment"`
+	HelpfulVotes   int    `gorm:"not null;default:0" json:"helpful_votes"`
+	UnhelpfulVotes int    `gorm:"not null;default:0" json:"unhelpful_votes"`
+	OpenReports    int    `gorm:"not null;default:0;index" json:"open_reports"` // Abuse reports awaiting moderation
+}
+
+// SPURating counts the reviews of an SPU per star. It is kept up to date as
+// reviews are added and removed, rather than counted when a product is read.
+type SPURating struct {
+	SPUID   uint64 `gorm:"primaryKey;autoIncrement:false" json:"spu_id,string"`
+	StoreID uint64 `gorm:"index;not null;default:0" json:"store_id"`
+	Stars1  int64  `gorm:"not null;default:0" json:"stars_1"`
+	Stars2  int64  `gorm:"not null;default:0" json:"stars_2"`
+	Stars3  int64  `gorm:"not null;default:0" json:"stars_3"`
+	Stars4  int64  `gorm:"not null;default:0" json:"stars_4"`
+	Stars5  int64  `gorm:"not null;default:0" json:"stars_5"`
+}
+
+// ReviewVote is a customer's vote on whether a review helped them. Each
+// customer has one vote per review, which they may change.
+type ReviewVote struct {
+	ReviewID  uint64    `gorm:"primaryKey;autoIncrement:false" json:"review_id,string"`
+	UserID    uint64    `gorm:"primaryKey;autoIncrement:false" json:"user_id,string"`
+	Helpful   bool      `gorm:"not null" json:"helpful"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time `gorm:"not null" json:"updated_at"`
+}
+
+// Why a review was reported.
+const (
+	ReviewReportSpam      = "spam"
+	ReviewReportOffensive = "offensive"
+	ReviewReportOffTopic  = "off_topic"
+	ReviewReportOther     = "other"
+)
+
+// Review report statuses.
+const (
+	ReviewReportOpen      = "open"
+	ReviewReportDismissed = "dismissed" // The review was kept
+	ReviewReportUpheld    = "upheld"    // The review was removed
+)
+
+// ReviewReport is a customer's report of an abusive review, which waits in
+// the moderation queue until an admin keeps or removes the review. Each
+// customer reports a review once.
+type ReviewReport struct {
+	Base
+	StoreID    uint64     `gorm:"index;not null;default:0" json:"store_id"`
+	ReviewID   uint64     `gorm:"not null;uniqueIndex:idx_review_reports_review_user" json:"review_id,string"`
+	UserID     uint64     `gorm:"not null;uniqueIndex:idx_review_reports_review_user" json:"user_id,string"`
+	Reason     string     `gorm:"size:20;not null" json:"reason"`
+	Details    string     `gorm:"size:500" json:"details"`
+	Status     string     `gorm:"size:20;not null;default:open;index" json:"status"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+// Saga types.
+const (
+	SagaTypeCheckout = "checkout" // Reserve stock, charge payment, confirm order
+)
+
+// Steps of a checkout saga, in order.
+const (
+	SagaStepReserveStock  = "reserve_stock"  // Stock deducted for a pending order; undone by cancelling it
+	SagaStepChargePayment = "charge_payment" // Saved payment method charged; undone by refunding it
+	SagaStepConfirmOrder  = "confirm_order"  // Order marked paid
+)
+
+// Saga statuses.
+const (
+	SagaStatusRunning      = "running"      // Running its steps forward
+	SagaStatusCompensating = "compensating" // Undoing the steps it ran, last first
+	SagaStatusCompleted    = "completed"
+	SagaStatusCompensated  = "compensated"
+	SagaStatusFailed       = "failed" // Out of attempts at a step; needs an operator
+)
+
+// SagaState is the progress of a saga: a process whose steps commit
+// separately, because they span the database and services outside it, and
+// which is undone by compensating the steps that ran when a later one fails.
+// Each step is recorded as it finishes, so a saga that stops halfway is
+// resumed from its state, forwards or backwards.
+type SagaState struct {
+	Base
+	StoreID         uint64 `gorm:"not null;default:0" json:"store_id,string"`
+	Type            string `gorm:"type:varchar(32);not null" json:"type"`
+	OrderID         uint64 `gorm:"not null;uniqueIndex" json:"order_id,string"`
+	PaymentMethodID uint64 `gorm:"not null;default:0" json:"payment_method_id,string"`
+	// Step is the step to run next, or to compensate next while compensating.
+	Step   string `gorm:"type:varchar(32);not null" json:"step"`
+	Status string `gorm:"type:varchar(16);not null;index:idx_saga_states_due,priority:1" json:"status"`
+	// Authorized is set when the payment was authorized rather than charged,
+	// so it is voided rather than refunded.
+	Authorized      bool      `gorm:"not null;default:false" json:"authorized"`
+	PaymentProvider string    `gorm:"type:varchar(20);not null;default:''" json:"payment_provider"` // Set once the payment step ran
+	PaymentRef      string    `gorm:"type:varchar(255);not null;default:''" json:"payment_ref"`
+	Attempts        int       `gorm:"not null;default:0" json:"attempts"` // Failed attempts at the current step
+	NextAttemptAt   time.Time `gorm:"not null;index:idx_saga_states_due,priority:2" json:"next_attempt_at"`
+	LastError       string    `gorm:"type:varchar(255);not null;default:''" json:"last_error"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// SearchSynonym is a set of words a store's customers use for the same
+// thing, e.g. sneakers and trainers. Typing any of them in the search box
+// suggests the products named with the others.
+type SearchSynonym struct {
+	Base
+	StoreID uint64   `gorm:"index;not null;default:0" json:"store_id"`
+	Terms   []string `gorm:"type:jsonb;serializer:json;not null" json:"terms"` // Lowercase single words
+}
+
+// Search rule actions.
+const (
+	SearchRuleBoost = "boost" // Pins the product to the top of the query's suggestions
+	SearchRuleBury  = "bury"  // Keeps the product out of the query's suggestions
+)
+
+// SearchRule is a merchandising rule for a search query of a store: its
+// product is boosted to the top of what the query suggests, or buried.
+type SearchRule struct {
+	Base
+	StoreID  uint64 `gorm:"index;not null;default:0" json:"store_id"`
+	Query    string `gorm:"size:255;not null" json:"query"` // Lowercase, single spaced, as typed in the search box
+	SPUID    uint64 `gorm:"not null" json:"spu_id,string"`
+	Action   string `gorm:"size:10;not null" json:"action"`
+	Position int    `gorm:"not null;default:0" json:"position"` // Order among the query's boosts, lowest first
+}
+
+// SearchZeroResult counts the searches of a store for a query that found
+// nothing, for merchandisers to review. Searches are counted in Redis and
+// flushed here by cmd/worker.
+type SearchZeroResult struct {
+	StoreID        uint64    `gorm:"primaryKey;autoIncrement:false" json:"store_id"`
+	Query          string    `gorm:"primaryKey;size:255" json:"query"` // Lowercase, single spaced
+	Searches       int64     `gorm:"not null;default:0;index" json:"searches"`
+	LastSearchedAt time.Time `gorm:"not null" json:"last_searched_at"` // As of the flush that counted the last search
+}
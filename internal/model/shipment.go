@@ -0,0 +1,50 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Shipment statuses.
+const (
+	ShipmentStatusShipped   = "shipped"
+	ShipmentStatusDelivered = "delivered"
+)
+
+// Shipment is part or all of an order sent to the customer. An order paid by
+// authorization is captured shipment by shipment, each capture recorded with
+// the shipment it pays for.
+type Shipment struct {
+	Base
+	StoreID        uint64          `gorm:"index;not null;default:0" json:"store_id"`
+	OrderID        uint64          `gorm:"index;not null" json:"order_id,string"`
+	Warehouse      string          `gorm:"type:varchar(30);not null;default:''" json:"warehouse"` // Where it shipped from; empty when not known
+	TrackingNumber string          `gorm:"type:varchar(64);not null;default:''" json:"tracking_number"`
+	Status         string          `gorm:"type:varchar(20);not null;default:'shipped'" json:"status"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	Items          []ShipmentItem  `gorm:"foreignKey:ShipmentID" json:"items"`
+	Capture        *PaymentCapture `gorm:"foreignKey:ShipmentID" json:"capture,omitempty"` // Nil when the order was paid up front
+}
+
+// ShipmentItem is a quantity of one order item in a shipment.
+type ShipmentItem struct {
+	Base
+	ShipmentID  uint64 `gorm:"index;not null" json:"shipment_id,string"`
+	OrderItemID uint64 `gorm:"index;not null" json:"order_item_id,string"`
+	Quantity    int    `gorm:"not null;check:quantity > 0" json:"quantity"`
+}
+
+// PaymentCapture is an amount captured from an order's authorized payment
+// when a shipment went out.
+type PaymentCapture struct {
+	Base
+	StoreID    uint64          `gorm:"index;not null;default:0" json:"store_id"`
+	OrderID    uint64          `gorm:"index;not null" json:"order_id,string"`
+	ShipmentID uint64          `gorm:"uniqueIndex;not null" json:"shipment_id,string"`
+	Provider   string          `gorm:"type:varchar(20);not null" json:"provider"`
+	PaymentRef string          `gorm:"type:varchar(255);not null" json:"payment_ref"` // The authorization captured from
+	Amount     decimal.Decimal `gorm:"type:numeric(10,2);not null;check:amount > 0" json:"amount"`
+	Currency   string          `gorm:"type:char(3);not null" json:"currency"`
+	Final      bool            `gorm:"not null;default:false" json:"final"` // Released the rest of the authorization
+}
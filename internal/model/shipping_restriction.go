@@ -0,0 +1,17 @@
+package model
+
+// ShippingRestriction keeps a SKU, or the SKUs of a category, from shipping
+// to some regions, e.g. lithium batteries to places only reached by air.
+// Checkout refuses orders with restricted items for the regions. Exactly one
+// of SKUID and CategoryID is set.
+type ShippingRestriction struct {
+	Base
+	StoreID    uint64 `gorm:"index;not null;default:0" json:"store_id"`
+	SKUID      uint64 `gorm:"index;not null;default:0" json:"sku_id,string"`      // 0 for a category's restriction
+	CategoryID uint64 `gorm:"index;not null;default:0" json:"category_id,string"` // Covers the category and its subcategories; 0 for a SKU's restriction
+	// Regions are the comma-separated ISO 3166-1 alpha-2 countries and ISO
+	// 3166-2 subdivisions it cannot ship to; a country covers its
+	// subdivisions, e.g. "AU,US-HI".
+	Regions string `gorm:"type:varchar(255);not null" json:"regions"`
+	Reason  string `gorm:"type:varchar(255);not null" json:"reason"` // Shown to customers, e.g. "Lithium batteries cannot be shipped there"
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Stock hold status values
+const (
+	StockHoldActive   = "active"   // The quantity is out of the SKU's stock
+	StockHoldReleased = "released" // Released by hand; the quantity went back into stock
+	StockHoldExpired  = "expired"  // Released by cmd/worker at ExpiresAt
+)
+
+// StockHold is a quantity of a SKU set aside by hand without an order, e.g.
+// for an offline sale. While active the quantity is taken out of the SKU's
+// stock, so it cannot be ordered.
+type StockHold struct {
+	Base
+	StoreID    uint64     `gorm:"index;not null;default:0" json:"store_id"`
+	SKUID      uint64     `gorm:"index;not null" json:"sku_id,string"`
+	Quantity   int        `gorm:"not null;check:quantity > 0" json:"quantity"`
+	Status     string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	Note       string     `gorm:"type:text;not null;default:''" json:"note"` // Why it was placed, e.g. the offline customer
+	ExpiresAt  *time.Time `gorm:"index" json:"expires_at"`                   // Nil holds until released
+	ReleasedAt *time.Time `json:"released_at"`
+}
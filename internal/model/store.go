@@ -0,0 +1,13 @@
+package model
+
+// Store is one shop of a multi-store deployment. Users, products and orders
+// belong to the store they were created in; rows created before tenancy was
+// enabled have StoreID 0.
+type Store struct {
+	Base
+	Slug     string `gorm:"type:varchar(50);not null;uniqueIndex" json:"slug"` // Selects the store in the tenancy header
+	Name     string `gorm:"type:varchar(100);not null" json:"name"`
+	Domain   string `gorm:"type:varchar(255);not null;default:'';uniqueIndex:idx_stores_domain,where:domain <> ''" json:"domain"` // Host the storefront is served on; empty when selected by header only
+	Currency string `gorm:"type:char(3);not null;default:''" json:"currency"`                                                     // Currency shown to customers without a preference; empty means the base currency
+	Locale   string `gorm:"type:varchar(35);not null;default:''" json:"locale"`                                                   // Locale served without an Accept-Language header; empty means the default locale
+}
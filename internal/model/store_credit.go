@@ -0,0 +1,17 @@
+package model
+
+import "github.com/shopspring/decimal"
+
+// StoreCredit is one entry of a customer's store credit ledger, e.g. a
+// goodwill credit customer service granted. A customer's balance in a
+// currency is the sum of their entries in it.
+type StoreCredit struct {
+	Base
+	StoreID   uint64          `gorm:"index;not null;default:0" json:"store_id"`
+	UserID    uint64          `gorm:"index;not null" json:"user_id,string"`
+	Amount    decimal.Decimal `gorm:"type:numeric(10,2);not null;check:amount <> 0" json:"amount"`
+	Currency  string          `gorm:"type:char(3);not null" json:"currency"`
+	Reason    string          `gorm:"type:varchar(255);not null" json:"reason"`
+	OrderID   uint64          `gorm:"not null;default:0" json:"order_id,string"` // The order it makes up for, if any
+	CreatedBy uint64          `gorm:"not null" json:"created_by,string"`         // Admin user ID
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+)
+
+// Subscription status values
+const (
+	SubscriptionStatusActive    = "active"
+	SubscriptionStatusPastDue   = "past_due" // The last renewal could not be charged; retried until the dunning schedule runs out
+	SubscriptionStatusPaused    = "paused"
+	SubscriptionStatusCancelled = "cancelled"
+)
+
+// Subscription interval units
+const (
+	SubscriptionIntervalDay   = "day"
+	SubscriptionIntervalWeek  = "week"
+	SubscriptionIntervalMonth = "month"
+)
+
+// Subscription is a user's standing order of a SKU, placed every
+// IntervalCount Intervals and charged to a saved payment method.
+type Subscription struct {
+	Base
+	StoreID         uint64    `gorm:"index;not null;default:0" json:"store_id"`
+	UserID          uint64    `gorm:"index;not null" json:"user_id,string"`
+	SKUID           uint64    `gorm:"index;not null" json:"sku_id,string"`
+	Quantity        int       `gorm:"not null;check:quantity > 0" json:"quantity"`
+	Interval        string    `gorm:"type:varchar(10);not null" json:"interval"` // day, week or month
+	IntervalCount   int       `gorm:"not null;default:1;check:interval_count >= 1" json:"interval_count"`
+	PaymentMethodID uint64    `gorm:"not null" json:"payment_method_id,string"`
+	Currency        string    `gorm:"type:char(3);not null;default:''" json:"currency"` // Empty charges the user's preferred currency, as at checkout
+	Region          string    `gorm:"type:varchar(10);not null;default:''" json:"region"`
+	Status          string    `gorm:"type:varchar(20);not null;index" json:"status"`
+	NextOrderAt     time.Time `gorm:"index;not null" json:"next_order_at"`            // When the next renewal is due, or retried while past due
+	FailedAttempts  int       `gorm:"not null;default:0" json:"failed_attempts"`      // Renewals failed in a row
+	LastOrderID     uint64    `gorm:"not null;default:0" json:"last_order_id,string"` // The order of the latest renewal, paid or not
+}
+
+// Next returns when the period after from begins. Monthly periods keep the
+// day of the month where they can, else end on the last day: a month after
+// January 31 is February 28 or 29.
+func (s *Subscription) Next(from time.Time) time.Time {
+	n := s.IntervalCount
+	if n < 1 {
+		n = 1
+	}
+	switch s.Interval {
+	case SubscriptionIntervalDay:
+		return from.AddDate(0, 0, n)
+	case SubscriptionIntervalWeek:
+		return from.AddDate(0, 0, 7*n)
+	default:
+		next := from.AddDate(0, n, 0)
+		if next.Day() != from.Day() { // Overflowed into the month after
+			next = next.AddDate(0, 0, -next.Day())
+		}
+		return next
+	}
+}
@@ -1,9 +1,26 @@
 package model
 
+// User roles.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Customer groups. Retail customers pay the SKUs' own prices; the others pay
+// the SKUPriceTier of their group where a SKU has one.
+const (
+	CustomerGroupRetail    = "retail"
+	CustomerGroupWholesale = "wholesale"
+	CustomerGroupVIP       = "vip"
+)
+
 type User struct {
 	Base
-	Username     string `gorm:"uniqueIndex;not null;type:varchar(50)" json:"username"`
-	PasswordHash string `gorm:"not null;type:varchar(255)" json:"-"`
-	Email        string `gorm:"uniqueIndex;not null;type:varchar(100)" json:"email"`
-	Role         string `gorm:"default:'user';type:varchar(20)" json:"role"`
+	StoreID       uint64 `gorm:"not null;default:0;uniqueIndex:idx_users_store_username;uniqueIndex:idx_users_store_email" json:"store_id"`
+	Username      string `gorm:"uniqueIndex:idx_users_store_username;not null;type:varchar(50)" json:"username"` // Unique per store
+	PasswordHash  string `gorm:"not null;type:varchar(255)" json:"-"`
+	Email         string `gorm:"uniqueIndex:idx_users_store_email;not null;type:varchar(100)" json:"email"` // Unique per store
+	Role          string `gorm:"default:'user';type:varchar(20)" json:"role"`
+	Currency      string `gorm:"type:char(3);not null;default:''" json:"currency"`                 // Preferred display currency; empty means the store's base currency
+	CustomerGroup string `gorm:"type:varchar(20);not null;default:'retail'" json:"customer_group"` // One of the CustomerGroup constants
 }
@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+// Webhook delivery statuses.
+const (
+	WebhookStatusPending   = "pending"   // Waiting for its first or next attempt
+	WebhookStatusDelivered = "delivered" // The endpoint answered 2xx
+	WebhookStatusFailed    = "failed"    // Out of attempts, or the subscription was removed
+)
+
+// WebhookSubscription is an endpoint an integrator registered for one event type.
+type WebhookSubscription struct {
+	Base
+	Event       string `gorm:"type:varchar(32);not null;index" json:"event"`
+	URL         string `gorm:"type:varchar(2048);not null" json:"url"`
+	Secret      string `gorm:"type:varchar(128);not null" json:"-"` // HMAC key; only returned when the subscription is created
+	Description string `gorm:"type:varchar(255);not null;default:''" json:"description"`
+}
+
+// WebhookDelivery is one event queued for one subscription. Rows are written
+// in the transaction that produced the event, and the dispatcher sends them
+// afterwards, so an event is delivered if and only if its change committed.
+type WebhookDelivery struct {
+	Base
+	SubscriptionID uint64               `gorm:"not null;index" json:"subscription_id,string"`
+	Subscription   *WebhookSubscription `gorm:"foreignKey:SubscriptionID" json:"-"`
+	Event          string               `gorm:"type:varchar(32);not null" json:"event"`
+	EventID        string               `gorm:"type:varchar(36);not null;index" json:"event_id"` // Shared by the deliveries of one event, for receivers to deduplicate
+	Payload        string               `gorm:"type:text;not null" json:"payload"`               // Exact request body, so retries are byte-identical
+	Status         string               `gorm:"type:varchar(16);not null;default:'pending';index:idx_webhook_deliveries_due,priority:1" json:"status"`
+	Attempts       int                  `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time            `gorm:"not null;index:idx_webhook_deliveries_due,priority:2" json:"next_attempt_at"`
+	LastStatusCode int                  `gorm:"not null;default:0" json:"last_status_code"` // 0 when no response was received
+	LastError      string               `gorm:"type:varchar(255);not null;default:''" json:"last_error"`
+	DeliveredAt    *time.Time           `json:"delivered_at,omitempty"`
+}
+
+// WebhookAttempt records one HTTP call made for a delivery.
+type WebhookAttempt struct {
+	Base
+	DeliveryID uint64 `gorm:"not null;index" json:"delivery_id,string"`
+	Attempt    int    `gorm:"not null" json:"attempt"` // 1-based
+	StatusCode int    `gorm:"not null;default:0" json:"status_code"`
+	Error      string `gorm:"type:varchar(255);not null;default:''" json:"error"`
+	DurationMS int64  `gorm:"not null;default:0" json:"duration_ms"`
+}
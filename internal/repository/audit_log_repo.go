@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+// AuditLogFilter narrows an audit log query. Zero values match everything.
+type AuditLogFilter struct {
+	ActorID    uint64
+	Action     string
+	Resource   string
+	ResourceID string
+	From       time.Time // Inclusive
+	To         time.Time // Exclusive
+}
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/audit_log_repo_mock.go -package=mocks
+// AuditLogRepository stores audit records. It offers no update or delete.
+type AuditLogRepository interface {
+	Create(ctx context.Context, logs []*model.AuditLog) error
+	// List returns matching records, newest first, and the total number of matches.
+	List(ctx context.Context, filter AuditLogFilter, offset, limit int) ([]model.AuditLog, int64, error)
+}
+
+// auditLogRepository implements AuditLogRepository using GORM.
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepository creates a new AuditLogRepository instance.
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+// Create inserts the records of one request in a single statement.
+func (r *auditLogRepository) Create(ctx context.Context, logs []*model.AuditLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(logs).Error; err != nil {
+		return fmt.Errorf("failed to create audit logs: %w", err)
+	}
+	return nil
+}
+
+// List retrieves audit records matching filter.
+func (r *auditLogRepository) List(ctx context.Context, filter AuditLogFilter, offset, limit int) ([]model.AuditLog, int64, error) {
+	query := database.GetDBFromContext(ctx, r.db).Model(&model.AuditLog{})
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Resource != "" {
+		query = query.Where("resource = ?", filter.Resource)
+	}
+	if filter.ResourceID != "" {
+		query = query.Where("resource_id = ?", filter.ResourceID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+
+	var logs []model.AuditLog
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&logs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	return logs, total, nil
+}
@@ -0,0 +1,50 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLogRepository(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	repo := repository.NewAuditLogRepository(tx)
+	ctx := context.Background()
+
+	logs := []*model.AuditLog{
+		{ActorID: 1, Action: "ip_rule.put", Resource: "ip_rule", ResourceID: "10.0.0.0/8", After: model.JSONB{"action": "deny"}},
+		{ActorID: 1, Action: "ip_rule.delete", Resource: "ip_rule", ResourceID: "10.0.0.0/8"},
+		{ActorID: 2, Action: "post", Resource: "/api/v1/admin/things"},
+	}
+	require.NoError(t, repo.Create(ctx, logs))
+	for _, l := range logs {
+		require.NotZero(t, l.ID)
+	}
+
+	got, total, err := repo.List(ctx, repository.AuditLogFilter{ActorID: 1, Resource: "ip_rule"}, 0, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	assert.Len(t, got, 2)
+
+	got, total, err = repo.List(ctx, repository.AuditLogFilter{ActorID: 1}, 1, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	assert.Len(t, got, 1)
+
+	_, total, err = repo.List(ctx, repository.AuditLogFilter{From: time.Now().Add(time.Hour)}, 0, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	// Records are append-only.
+	assert.ErrorIs(t, tx.Model(logs[0]).Update("action", "tampered").Error, model.ErrAuditLogImmutable)
+	assert.ErrorIs(t, tx.Delete(logs[0]).Error, model.ErrAuditLogImmutable)
+}
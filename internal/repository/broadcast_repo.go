@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+// ErrBroadcastNotFound is returned when a broadcast does not exist.
+var ErrBroadcastNotFound = errors.New("broadcast not found")
+
+// purchasedStatuses are the statuses of orders counted as purchases.
+var purchasedStatuses = []string{model.OrderStatusPaid, model.OrderStatusBackordered, model.OrderStatusCompleted}
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/broadcast_repo_mock.go -package=mocks
+// BroadcastRepository defines the interface for broadcast data operations.
+type BroadcastRepository interface {
+	Create(ctx context.Context, broadcast *model.Broadcast) error
+	GetByID(ctx context.Context, id uint64) (*model.Broadcast, error)
+	// List returns a page of broadcasts, newest first, and how many there are.
+	List(ctx context.Context, offset, limit int) ([]model.Broadcast, int64, error)
+	// ListPending returns the broadcasts of every store not fanned out yet,
+	// oldest first.
+	ListPending(ctx context.Context) ([]model.Broadcast, error)
+	// SaveProgress stores the status, cursor and recipient count of a broadcast.
+	SaveProgress(ctx context.Context, broadcast *model.Broadcast) error
+	// ListRecipients returns the IDs of the next limit customers of the store
+	// in the segment after afterID, in ID order.
+	ListRecipients(ctx context.Context, storeID uint64, segment *model.BroadcastSegment, afterID uint64, limit int) ([]uint64, error)
+	CountRecipients(ctx context.Context, storeID uint64, segment *model.BroadcastSegment) (int64, error)
+}
+
+// broadcastRepository implements BroadcastRepository using GORM.
+type broadcastRepository struct {
+	db *gorm.DB
+}
+
+// NewBroadcastRepository creates a new BroadcastRepository instance.
+func NewBroadcastRepository(db *gorm.DB) BroadcastRepository {
+	return &broadcastRepository{db: db}
+}
+
+// Create saves a new broadcast to the database.
+func (r *broadcastRepository) Create(ctx context.Context, broadcast *model.Broadcast) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(broadcast).Error; err != nil {
+		return fmt.Errorf("failed to create broadcast: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a broadcast by its ID.
+func (r *broadcastRepository) GetByID(ctx context.Context, id uint64) (*model.Broadcast, error) {
+	var broadcast model.Broadcast
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.First(&broadcast, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBroadcastNotFound
+		}
+		return nil, fmt.Errorf("failed to get broadcast '%d': %w", id, err)
+	}
+	return &broadcast, nil
+}
+
+func (r *broadcastRepository) List(ctx context.Context, offset, limit int) ([]model.Broadcast, int64, error) {
+	var total int64
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Model(&model.Broadcast{}).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count broadcasts: %w", err)
+	}
+	var broadcasts []model.Broadcast
+	if err := db.Order("id DESC").Offset(offset).Limit(limit).Find(&broadcasts).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list broadcasts: %w", err)
+	}
+	return broadcasts, total, nil
+}
+
+func (r *broadcastRepository) ListPending(ctx context.Context) ([]model.Broadcast, error) {
+	var broadcasts []model.Broadcast
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Where("status IN ?", []string{model.BroadcastStatusQueued, model.BroadcastStatusSending}).
+		Order("id").
+		Find(&broadcasts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending broadcasts: %w", err)
+	}
+	return broadcasts, nil
+}
+
+func (r *broadcastRepository) SaveProgress(ctx context.Context, broadcast *model.Broadcast) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Model(broadcast).Select("status", "cursor", "recipients", "finished_at").Updates(broadcast).Error
+	if err != nil {
+		return fmt.Errorf("failed to save progress of broadcast '%d': %w", broadcast.ID, err)
+	}
+	return nil
+}
+
+func (r *broadcastRepository) ListRecipients(ctx context.Context, storeID uint64, segment *model.BroadcastSegment, afterID uint64, limit int) ([]uint64, error) {
+	var ids []uint64
+	db := database.GetDBFromContext(ctx, r.db)
+	err := r.segment(db, storeID, segment).
+		Where("users.id > ?", afterID).
+		Order("users.id").
+		Limit(limit).
+		Pluck("users.id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list broadcast recipients: %w", err)
+	}
+	return ids, nil
+}
+
+func (r *broadcastRepository) CountRecipients(ctx context.Context, storeID uint64, segment *model.BroadcastSegment) (int64, error) {
+	var count int64
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := r.segment(db, storeID, segment).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count broadcast recipients: %w", err)
+	}
+	return count, nil
+}
+
+// segment selects the customers of the store in the segment. The store is
+// filtered explicitly, as the dispatcher runs without one in its context.
+func (r *broadcastRepository) segment(db *gorm.DB, storeID uint64, segment *model.BroadcastSegment) *gorm.DB {
+	query := db.Model(&model.User{}).Where("users.store_id = ? AND users.role = ?", storeID, model.RoleUser)
+	if segment.SignedUpAfter != nil {
+		query = query.Where("users.created_at >= ?", *segment.SignedUpAfter)
+	}
+	if segment.SignedUpBefore != nil {
+		query = query.Where("users.created_at < ?", *segment.SignedUpBefore)
+	}
+	if segment.MinOrders <= 0 && segment.MaxOrders == nil {
+		return query
+	}
+
+	orders := db.Session(&gorm.Session{NewDB: true}).Model(&model.Order{}).
+		Select("COUNT(*)").
+		Where("orders.user_id = users.id AND orders.status IN ?", purchasedStatuses)
+	if segment.OrderedAfter != nil {
+		orders = orders.Where("orders.created_at >= ?", *segment.OrderedAfter)
+	}
+	if segment.OrderedBefore != nil {
+		orders = orders.Where("orders.created_at < ?", *segment.OrderedBefore)
+	}
+	if segment.MinOrders > 0 {
+		query = query.Where("(?) >= ?", orders, segment.MinOrders)
+	}
+	if segment.MaxOrders != nil {
+		query = query.Where("(?) <= ?", orders, *segment.MaxOrders)
+	}
+	return query
+}
@@ -0,0 +1,116 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroadcastRecipients(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewBroadcastRepository(tx)
+	orderRepo := repository.NewOrderRepository(tx)
+
+	// Only the users created here signed up after start.
+	start := time.Now().Add(-time.Second)
+	never := createRandomUser(t, repository.NewUserRepository(tx))
+	once := createRandomUser(t, repository.NewUserRepository(tx))
+	twice := createRandomUser(t, repository.NewUserRepository(tx))
+	spu, err := createRandomSPU(ctx, repository.NewProductRepository(tx))
+	require.NoError(t, err)
+	skuID := spu.SKUs[0].ID
+	now := time.Now()
+	createOrderAt(t, orderRepo, never.ID, skuID, model.OrderStatusPending, now) // Unpaid orders are not purchases
+	createOrderAt(t, orderRepo, once.ID, skuID, model.OrderStatusPaid, now.Add(-48*time.Hour))
+	createOrderAt(t, orderRepo, twice.ID, skuID, model.OrderStatusCompleted, now.Add(-48*time.Hour))
+	createOrderAt(t, orderRepo, twice.ID, skuID, model.OrderStatusPaid, now)
+
+	zero, two := 0, 2
+	lastHour := now.Add(-time.Hour)
+	tests := []struct {
+		name    string
+		segment model.BroadcastSegment
+		want    []uint64
+	}{
+		{name: "Everyone", segment: model.BroadcastSegment{}, want: []uint64{never.ID, once.ID, twice.ID}},
+		{name: "NeverOrdered", segment: model.BroadcastSegment{MaxOrders: &zero}, want: []uint64{never.ID}},
+		{name: "Repeat", segment: model.BroadcastSegment{MinOrders: 2, MaxOrders: &two}, want: []uint64{twice.ID}},
+		{name: "OrderedRecently", segment: model.BroadcastSegment{MinOrders: 1, OrderedAfter: &lastHour}, want: []uint64{twice.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segment := tt.segment
+			segment.SignedUpAfter = &start
+			ids, err := repo.ListRecipients(ctx, 0, &segment, 0, 10)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ids)
+
+			count, err := repo.CountRecipients(ctx, 0, &segment)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(tt.want)), count)
+		})
+	}
+
+	// Pages continue after the last ID of the previous one.
+	segment := model.BroadcastSegment{SignedUpAfter: &start}
+	ids, err := repo.ListRecipients(ctx, 0, &segment, once.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{twice.ID}, ids)
+	ids, err = repo.ListRecipients(ctx, 1, &segment, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, ids, "customers of another store are left out")
+}
+
+func TestBroadcastProgress(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewBroadcastRepository(tx)
+
+	broadcast := &model.Broadcast{Name: "Sale", Subject: "Sale", Message: "20% off", Channels: []string{"email"}, Status: model.BroadcastStatusQueued, CreatedBy: 1}
+	require.NoError(t, repo.Create(ctx, broadcast))
+
+	pending, err := repo.ListPending(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, broadcastIDs(pending), broadcast.ID)
+
+	finished := time.Now()
+	broadcast.Status, broadcast.Cursor, broadcast.Recipients, broadcast.FinishedAt = model.BroadcastStatusSent, 42, 3, &finished
+	require.NoError(t, repo.SaveProgress(ctx, broadcast))
+
+	got, err := repo.GetByID(ctx, broadcast.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.BroadcastStatusSent, got.Status)
+	assert.Equal(t, uint64(42), got.Cursor)
+	assert.Equal(t, int64(3), got.Recipients)
+	assert.Equal(t, []string{"email"}, got.Channels)
+	require.NotNil(t, got.FinishedAt)
+
+	pending, err = repo.ListPending(ctx)
+	require.NoError(t, err)
+	assert.NotContains(t, broadcastIDs(pending), broadcast.ID)
+
+	_, err = repo.GetByID(ctx, broadcast.ID+1000)
+	assert.ErrorIs(t, err, repository.ErrBroadcastNotFound)
+}
+
+func broadcastIDs(broadcasts []model.Broadcast) []uint64 {
+	ids := make([]uint64, len(broadcasts))
+	for i := range broadcasts {
+		ids[i] = broadcasts[i].ID
+	}
+	return ids
+}
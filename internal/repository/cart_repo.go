@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrCartNotFound is returned when a customer has no open cart.
+var ErrCartNotFound = errors.New("cart not found")
+
+// CartRecoveryStats counts the carts whose customers were reminded of them
+// since a time, and those of them converted afterwards.
+type CartRecoveryStats struct {
+	Reminded  int64
+	Recovered int64
+	Revenue   []CartRecoveryRevenue // Of the recovered carts' paid orders, by currency
+}
+
+// CartRecoveryRevenue totals the paid orders of recovered carts charged in
+// one currency.
+type CartRecoveryRevenue struct {
+	Currency string
+	Amount   decimal.Decimal // Including tax
+}
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/cart_repo_mock.go -package=mocks
+// CartRepository defines the interface for shopping cart data operations.
+type CartRepository interface {
+	// GetOpen retrieves the customer's open cart with its items, oldest
+	// first.
+	GetOpen(ctx context.Context, userID uint64) (*model.Cart, error)
+	// SetItem sets the quantity of a SKU in the customer's open cart,
+	// opening one if needed.
+	SetItem(ctx context.Context, userID, skuID uint64, quantity int, at time.Time) error
+	// RemoveItem removes a SKU from the customer's open cart. Removing a SKU
+	// that is not in it is not an error.
+	RemoveItem(ctx context.Context, userID, skuID uint64, at time.Time) error
+	// Clear removes every item of the customer's open cart.
+	Clear(ctx context.Context, userID uint64, at time.Time) error
+	// ListAbandoned returns up to limit open carts with IDs above afterID, in
+	// ID order, with items, last changed between activeAfter and
+	// activeBefore, whose customers were not reminded of them yet. It spans
+	// all stores.
+	ListAbandoned(ctx context.Context, activeAfter, activeBefore time.Time, afterID uint64, limit int) ([]model.Cart, error)
+	// MarkRecoveryNotified records that the customer was reminded of a cart,
+	// and with which coupon, if any.
+	MarkRecoveryNotified(ctx context.Context, id uint64, coupon string, at time.Time) error
+	// Convert closes the customer's open cart for the order if it holds any
+	// of skuIDs, and returns it. It returns ErrCartNotFound if the customer
+	// has no such cart.
+	Convert(ctx context.Context, userID, orderID uint64, skuIDs []uint64, at time.Time) (*model.Cart, error)
+	// RecoveryStats counts the carts whose customers were reminded of them
+	// at or after since.
+	RecoveryStats(ctx context.Context, since time.Time) (*CartRecoveryStats, error)
+}
+
+// cartRepository implements CartRepository using GORM.
+type cartRepository struct {
+	db *gorm.DB
+}
+
+// NewCartRepository creates a new CartRepository instance.
+func NewCartRepository(db *gorm.DB) CartRepository {
+	return &cartRepository{db: db}
+}
+
+func (r *cartRepository) GetOpen(ctx context.Context, userID uint64) (*model.Cart, error) {
+	var cart model.Cart
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("user_id = ? AND converted_at IS NULL", userID).
+		First(&cart).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCartNotFound
+		}
+		return nil, fmt.Errorf("failed to get cart of user '%d': %w", userID, err)
+	}
+	return &cart, nil
+}
+
+// SetItem upserts the cart and then the item, so that two requests racing
+// to open the customer's cart end up in the same one.
+func (r *cartRepository) SetItem(ctx context.Context, userID, skuID uint64, quantity int, at time.Time) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		cart := model.Cart{UserID: userID, ActiveAt: at}
+		err := tx.Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "store_id"}, {Name: "user_id"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "converted_at IS NULL"}}},
+			DoUpdates:   clause.AssignmentColumns([]string{"active_at", "updated_at"}),
+		}).Create(&cart).Error
+		if err != nil {
+			return err
+		}
+		// On conflict the new ID was not saved: look the open cart up
+		if err := tx.Where("user_id = ? AND converted_at IS NULL", userID).First(&cart).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "cart_id"}, {Name: "sku_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"quantity", "updated_at"}),
+		}).Create(&model.CartItem{CartID: cart.ID, SKUID: skuID, Quantity: quantity}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set SKU '%d' in cart of user '%d': %w", skuID, userID, err)
+	}
+	return nil
+}
+
+func (r *cartRepository) RemoveItem(ctx context.Context, userID, skuID uint64, at time.Time) error {
+	if err := r.removeItems(ctx, userID, skuID, at); err != nil {
+		return fmt.Errorf("failed to remove SKU '%d' from cart of user '%d': %w", skuID, userID, err)
+	}
+	return nil
+}
+
+func (r *cartRepository) Clear(ctx context.Context, userID uint64, at time.Time) error {
+	if err := r.removeItems(ctx, userID, 0, at); err != nil {
+		return fmt.Errorf("failed to clear cart of user '%d': %w", userID, err)
+	}
+	return nil
+}
+
+// removeItems deletes the item of skuID, or every item with skuID 0, of the
+// customer's open cart for good and touches the cart, if the customer has
+// one.
+func (r *cartRepository) removeItems(ctx context.Context, userID, skuID uint64, at time.Time) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	return db.Transaction(func(tx *gorm.DB) error {
+		var cart model.Cart
+		result := tx.Model(&cart).Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+			Where("user_id = ? AND converted_at IS NULL", userID).
+			Update("active_at", at)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		query := tx.Unscoped().Where("cart_id = ?", cart.ID)
+		if skuID != 0 {
+			query = query.Where("sku_id = ?", skuID)
+		}
+		return query.Delete(&model.CartItem{}).Error
+	})
+}
+
+func (r *cartRepository) ListAbandoned(ctx context.Context, activeAfter, activeBefore time.Time, afterID uint64, limit int) ([]model.Cart, error) {
+	var carts []model.Cart
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("converted_at IS NULL AND recovery_notified_at IS NULL").
+		Where("active_at > ? AND active_at <= ?", activeAfter, activeBefore).
+		Where("EXISTS (SELECT 1 FROM cart_items WHERE cart_items.cart_id = carts.id AND cart_items.deleted_at IS NULL)").
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&carts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list abandoned carts: %w", err)
+	}
+	return carts, nil
+}
+
+func (r *cartRepository) MarkRecoveryNotified(ctx context.Context, id uint64, coupon string, at time.Time) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Model(&model.Cart{}).Where("id = ?", id).
+		Updates(map[string]any{"recovery_notified_at": at, "recovery_coupon": coupon}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record recovery of cart '%d': %w", id, err)
+	}
+	return nil
+}
+
+func (r *cartRepository) Convert(ctx context.Context, userID, orderID uint64, skuIDs []uint64, at time.Time) (*model.Cart, error) {
+	var carts []model.Cart
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&carts).Clauses(clause.Returning{}).
+		Where("user_id = ? AND converted_at IS NULL", userID).
+		Where("EXISTS (SELECT 1 FROM cart_items WHERE cart_items.cart_id = carts.id AND cart_items.sku_id IN ? AND cart_items.deleted_at IS NULL)", skuIDs).
+		Updates(map[string]any{"converted_at": at, "order_id": orderID})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to convert cart of user '%d': %w", userID, result.Error)
+	}
+	if len(carts) == 0 {
+		return nil, ErrCartNotFound
+	}
+	return &carts[0], nil
+}
+
+func (r *cartRepository) RecoveryStats(ctx context.Context, since time.Time) (*CartRecoveryStats, error) {
+	db := database.GetDBFromContext(ctx, r.db)
+	recovered := "carts.converted_at IS NOT NULL AND carts.converted_at >= carts.recovery_notified_at"
+
+	var stats CartRecoveryStats
+	err := db.Model(&model.Cart{}).
+		Select("COUNT(*) AS reminded, COUNT(*) FILTER (WHERE "+recovered+") AS recovered").
+		Where("recovery_notified_at >= ?", since).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count recovered carts: %w", err)
+	}
+
+	err = db.Model(&model.Cart{}).
+		Select("orders.currency AS currency, SUM(orders.total_amount) AS amount").
+		Joins("JOIN orders ON orders.id = carts.order_id AND orders.deleted_at IS NULL").
+		Where("carts.recovery_notified_at >= ? AND "+recovered, since).
+		Where("orders.status IN ?", []string{model.OrderStatusPaid, model.OrderStatusBackordered, model.OrderStatusCompleted}).
+		Group("orders.currency").
+		Order("orders.currency").
+		Scan(&stats.Revenue).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to total recovered cart revenue: %w", err)
+	}
+	return &stats, nil
+}
@@ -0,0 +1,78 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCarts(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewCartRepository(tx)
+	now := time.Now()
+	const userID = 910001
+
+	_, err := repo.GetOpen(ctx, userID)
+	assert.ErrorIs(t, err, repository.ErrCartNotFound)
+
+	require.NoError(t, repo.SetItem(ctx, userID, 101, 1, now.Add(-3*time.Hour)))
+	require.NoError(t, repo.SetItem(ctx, userID, 102, 2, now.Add(-3*time.Hour)))
+	require.NoError(t, repo.SetItem(ctx, userID, 101, 3, now.Add(-2*time.Hour)), "the same cart and item")
+	cart, err := repo.GetOpen(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, cart.Items, 2)
+	assert.Equal(t, uint64(101), cart.Items[0].SKUID)
+	assert.Equal(t, 3, cart.Items[0].Quantity)
+
+	require.NoError(t, repo.RemoveItem(ctx, userID, 102, now.Add(-2*time.Hour)))
+	require.NoError(t, repo.RemoveItem(ctx, userID, 999, now.Add(-2*time.Hour)), "not in the cart")
+	require.NoError(t, repo.SetItem(ctx, userID, 102, 1, now.Add(-2*time.Hour)), "added again")
+
+	abandoned, err := repo.ListAbandoned(ctx, now.Add(-24*time.Hour), now.Add(-time.Hour), 0, 10)
+	require.NoError(t, err)
+	require.Len(t, abandoned, 1)
+	assert.Equal(t, cart.ID, abandoned[0].ID)
+	assert.Len(t, abandoned[0].Items, 2)
+	abandoned, err = repo.ListAbandoned(ctx, now.Add(-24*time.Hour), now.Add(-4*time.Hour), 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, abandoned, "active too recently")
+
+	require.NoError(t, repo.MarkRecoveryNotified(ctx, cart.ID, "CART-ABC", now.Add(-time.Hour)))
+	abandoned, err = repo.ListAbandoned(ctx, now.Add(-24*time.Hour), now.Add(-time.Hour), 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, abandoned, "reminded already")
+
+	_, err = repo.Convert(ctx, userID, 1, []uint64{555}, now)
+	assert.ErrorIs(t, err, repository.ErrCartNotFound, "none of the cart's SKUs")
+	converted, err := repo.Convert(ctx, userID, 1, []uint64{555, 102}, now)
+	require.NoError(t, err)
+	assert.Equal(t, cart.ID, converted.ID)
+	assert.True(t, converted.Recovered())
+	assert.Equal(t, "CART-ABC", converted.RecoveryCoupon)
+	_, err = repo.GetOpen(ctx, userID)
+	assert.ErrorIs(t, err, repository.ErrCartNotFound)
+
+	require.NoError(t, repo.SetItem(ctx, userID, 101, 1, now), "opens a new cart")
+	reopened, err := repo.GetOpen(ctx, userID)
+	require.NoError(t, err)
+	assert.NotEqual(t, cart.ID, reopened.ID)
+	require.NoError(t, repo.Clear(ctx, userID, now))
+	reopened, err = repo.GetOpen(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, reopened.Items)
+
+	stats, err := repo.RecoveryStats(ctx, now.Add(-2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Reminded)
+	assert.Equal(t, int64(1), stats.Recovered)
+	assert.Empty(t, stats.Revenue, "order 1 does not exist")
+}
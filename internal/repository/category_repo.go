@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/category_repo_mock.go -package=mocks
+// CategoryRepository defines the interface for category data operations.
+type CategoryRepository interface {
+	Create(ctx context.Context, category *model.Category) error
+	List(ctx context.Context) ([]model.Category, error)
+	GetByIDs(ctx context.Context, ids []uint64) ([]model.Category, error)
+}
+
+// categoryRepository implements CategoryRepository using GORM.
+type categoryRepository struct {
+	db *gorm.DB
+}
+
+// NewCategoryRepository creates a new CategoryRepository instance.
+func NewCategoryRepository(db *gorm.DB) CategoryRepository {
+	return &categoryRepository{db: db}
+}
+
+// Create saves a new category to the database.
+func (r *categoryRepository) Create(ctx context.Context, category *model.Category) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(category).Error; err != nil {
+		return fmt.Errorf("failed to create category: %w", err)
+	}
+	return nil
+}
+
+// List retrieves every category, parents before children.
+func (r *categoryRepository) List(ctx context.Context) ([]model.Category, error) {
+	var categories []model.Category
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Order("parent_id, name").Find(&categories).Error; err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	return categories, nil
+}
+
+// GetByIDs retrieves the categories with the given IDs in one query. IDs
+// without a category are skipped.
+func (r *categoryRepository) GetByIDs(ctx context.Context, ids []uint64) ([]model.Category, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var categories []model.Category
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("id IN ?", ids).Find(&categories).Error; err != nil {
+		return nil, fmt.Errorf("failed to get categories by IDs: %w", err)
+	}
+	return categories, nil
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCouponCampaignNotFound is returned when a coupon campaign does not exist.
+	ErrCouponCampaignNotFound = errors.New("coupon campaign not found")
+	// ErrCouponNotFound is returned when no coupon has the code.
+	ErrCouponNotFound = errors.New("coupon not found")
+	// ErrCouponRedeemed is returned when a coupon was redeemed already.
+	ErrCouponRedeemed = errors.New("coupon already redeemed")
+)
+
+// couponInsertBatchSize bounds the rows of one INSERT when codes are generated.
+const couponInsertBatchSize = 1000
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/coupon_repo_mock.go -package=mocks
+// CouponRepository defines the interface for coupon data operations.
+type CouponRepository interface {
+	CreateCampaign(ctx context.Context, campaign *model.CouponCampaign) error
+	// ListCampaigns returns every campaign with its rolled up stats.
+	ListCampaigns(ctx context.Context) ([]model.CouponCampaign, error)
+	GetCampaign(ctx context.Context, id uint64) (*model.CouponCampaign, error)
+	// CreateCoupons saves coupons in one transaction; a code taken meanwhile
+	// fails them all.
+	CreateCoupons(ctx context.Context, coupons []model.Coupon) error
+	// ExistingCodes returns which of codes are taken.
+	ExistingCodes(ctx context.Context, codes []string) ([]string, error)
+	// ListCoupons returns up to limit coupons of a campaign with IDs above
+	// afterID, by ID, for paging through all of them.
+	ListCoupons(ctx context.Context, campaignID, afterID uint64, limit int) ([]model.Coupon, error)
+	// GetByCode returns the coupon with code, with its campaign.
+	GetByCode(ctx context.Context, code string) (*model.Coupon, error)
+	// Redeem marks the coupon with code redeemed by an order, unless it was
+	// already.
+	Redeem(ctx context.Context, code string, orderID, userID uint64, at time.Time) error
+	// RollupStats recounts the codes of every campaign into its stats. It
+	// returns how many campaigns were updated.
+	RollupStats(ctx context.Context, at time.Time) (int64, error)
+}
+
+// couponRepository implements CouponRepository using GORM.
+type couponRepository struct {
+	db *gorm.DB
+}
+
+// NewCouponRepository creates a new CouponRepository instance.
+func NewCouponRepository(db *gorm.DB) CouponRepository {
+	return &couponRepository{db: db}
+}
+
+// CreateCampaign saves a new coupon campaign to the database.
+func (r *couponRepository) CreateCampaign(ctx context.Context, campaign *model.CouponCampaign) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(campaign).Error; err != nil {
+		return fmt.Errorf("failed to create coupon campaign %q: %w", campaign.Name, err)
+	}
+	return nil
+}
+
+// ListCampaigns retrieves every campaign, most recently created first.
+func (r *couponRepository) ListCampaigns(ctx context.Context) ([]model.CouponCampaign, error) {
+	var campaigns []model.CouponCampaign
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Preload("Stats").Order("id DESC").Find(&campaigns).Error; err != nil {
+		return nil, fmt.Errorf("failed to list coupon campaigns: %w", err)
+	}
+	return campaigns, nil
+}
+
+// GetCampaign retrieves a campaign with its rolled up stats.
+func (r *couponRepository) GetCampaign(ctx context.Context, id uint64) (*model.CouponCampaign, error) {
+	var campaign model.CouponCampaign
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Preload("Stats").First(&campaign, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCouponCampaignNotFound
+		}
+		return nil, fmt.Errorf("failed to get coupon campaign '%d': %w", id, err)
+	}
+	return &campaign, nil
+}
+
+// CreateCoupons inserts the coupons in batches.
+func (r *couponRepository) CreateCoupons(ctx context.Context, coupons []model.Coupon) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(coupons, couponInsertBatchSize).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create %d coupons: %w", len(coupons), err)
+	}
+	return nil
+}
+
+// ExistingCodes looks codes up in one query, including those of deleted
+// coupons, which still hold their codes.
+func (r *couponRepository) ExistingCodes(ctx context.Context, codes []string) ([]string, error) {
+	if len(codes) == 0 {
+		return nil, nil
+	}
+	var existing []string
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Unscoped().Model(&model.Coupon{}).Where("code IN ?", codes).Pluck("code", &existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to look up coupon codes: %w", err)
+	}
+	return existing, nil
+}
+
+// ListCoupons retrieves one page of a campaign's coupons.
+func (r *couponRepository) ListCoupons(ctx context.Context, campaignID, afterID uint64, limit int) ([]model.Coupon, error) {
+	var coupons []model.Coupon
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Where("campaign_id = ? AND id > ?", campaignID, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&coupons).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list coupons of campaign '%d': %w", campaignID, err)
+	}
+	return coupons, nil
+}
+
+// GetByCode retrieves a coupon by its code.
+func (r *couponRepository) GetByCode(ctx context.Context, code string) (*model.Coupon, error) {
+	var coupon model.Coupon
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Preload("Campaign").Where("code = ?", code).First(&coupon).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCouponNotFound
+		}
+		return nil, fmt.Errorf("failed to get coupon: %w", err)
+	}
+	return &coupon, nil
+}
+
+// Redeem updates the coupon only while it is unredeemed, so that two orders
+// racing for the same code cannot both redeem it.
+func (r *couponRepository) Redeem(ctx context.Context, code string, orderID, userID uint64, at time.Time) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.Coupon{}).
+		Where("code = ? AND redeemed_at IS NULL", code).
+		Updates(map[string]any{"redeemed_at": at, "order_id": orderID, "user_id": userID})
+	if result.Error != nil {
+		return fmt.Errorf("failed to redeem coupon: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.GetByCode(ctx, code); err != nil {
+			return err
+		}
+		return ErrCouponRedeemed
+	}
+	return nil
+}
+
+// RollupStats upserts the stats of every campaign with codes in one
+// statement. It runs outside any store, so it covers every store's campaigns.
+func (r *couponRepository) RollupStats(ctx context.Context, at time.Time) (int64, error) {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Exec(`
+INSERT INTO coupon_campaign_stats (campaign_id, store_id, generated, redeemed, last_redeemed_at, rolled_up_at)
+SELECT campaign_id, MAX(store_id), COUNT(*), COUNT(redeemed_at), MAX(redeemed_at), ?
+FROM coupons
+WHERE deleted_at IS NULL
+GROUP BY campaign_id
+ON CONFLICT (campaign_id) DO UPDATE SET
+	generated = EXCLUDED.generated,
+	redeemed = EXCLUDED.redeemed,
+	last_redeemed_at = EXCLUDED.last_redeemed_at,
+	rolled_up_at = EXCLUDED.rolled_up_at`, at)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to roll up coupon stats: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
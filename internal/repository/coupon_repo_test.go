@@ -0,0 +1,65 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoupons(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewCouponRepository(tx)
+	now := time.Now()
+
+	campaign := &model.CouponCampaign{Name: "Newsletter", PromotionID: 7}
+	require.NoError(t, repo.CreateCampaign(ctx, campaign))
+	require.NoError(t, repo.CreateCoupons(ctx, []model.Coupon{
+		{CampaignID: campaign.ID, Code: "NEWS-AAAA"},
+		{CampaignID: campaign.ID, Code: "NEWS-BBBB"},
+		{CampaignID: campaign.ID, Code: "NEWS-CCCC"},
+	}))
+
+	existing, err := repo.ExistingCodes(ctx, []string{"NEWS-AAAA", "NEWS-ZZZZ"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"NEWS-AAAA"}, existing)
+
+	coupon, err := repo.GetByCode(ctx, "NEWS-BBBB")
+	require.NoError(t, err)
+	require.NotNil(t, coupon.Campaign)
+	assert.Equal(t, uint64(7), coupon.Campaign.PromotionID)
+	_, err = repo.GetByCode(ctx, "NEWS-ZZZZ")
+	assert.ErrorIs(t, err, repository.ErrCouponNotFound)
+
+	require.NoError(t, repo.Redeem(ctx, "NEWS-BBBB", 100, 5, now))
+	assert.ErrorIs(t, repo.Redeem(ctx, "NEWS-BBBB", 101, 6, now), repository.ErrCouponRedeemed)
+	assert.ErrorIs(t, repo.Redeem(ctx, "NEWS-ZZZZ", 101, 6, now), repository.ErrCouponNotFound)
+
+	page, err := repo.ListCoupons(ctx, campaign.ID, 0, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	page, err = repo.ListCoupons(ctx, campaign.ID, page[1].ID, 2)
+	require.NoError(t, err)
+	assert.Len(t, page, 1)
+
+	updated, err := repo.RollupStats(ctx, now)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, updated, int64(1))
+	got, err := repo.GetCampaign(ctx, campaign.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.Stats)
+	assert.Equal(t, int64(3), got.Stats.Generated)
+	assert.Equal(t, int64(1), got.Stats.Redeemed)
+
+	_, err = repo.GetCampaign(ctx, campaign.ID+1)
+	assert.ErrorIs(t, err, repository.ErrCouponCampaignNotFound)
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrDisputeNotFound is returned when a dispute does not exist.
+	ErrDisputeNotFound = errors.New("dispute not found")
+	// ErrDisputeChanged is returned when a dispute no longer waits for
+	// evidence, e.g. it was decided meanwhile.
+	ErrDisputeChanged = errors.New("dispute changed")
+)
+
+// DisputeFilter narrows a dispute query. Zero values match everything.
+type DisputeFilter struct {
+	Status  string
+	Open    bool // Only disputes that are not decided yet
+	OrderID uint64
+}
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/dispute_repo_mock.go -package=mocks
+// DisputeRepository defines the interface for payment dispute data operations.
+type DisputeRepository interface {
+	// Create saves a new dispute. It fails if the provider reported it
+	// already.
+	Create(ctx context.Context, dispute *model.Dispute) error
+	GetByID(ctx context.Context, id uint64) (*model.Dispute, error)
+	// GetByRef finds a dispute by the provider's ID of it.
+	GetByRef(ctx context.Context, provider, disputeRef string) (*model.Dispute, error)
+	// UpdateStatus saves what the provider reports about a dispute: its
+	// amount, reason, status, evidence due date and when it closed.
+	UpdateStatus(ctx context.Context, dispute *model.Dispute) error
+	// List returns one page of the matching disputes, most recent first.
+	List(ctx context.Context, filter DisputeFilter, offset, limit int) ([]model.Dispute, int64, error)
+	// ListByOrder returns the disputes of an order, oldest first.
+	ListByOrder(ctx context.Context, orderID uint64) ([]model.Dispute, error)
+	// MarkEvidenceSubmitted records that an admin submitted the evidence of
+	// a dispute. It returns ErrDisputeChanged if the dispute does not wait
+	// for evidence, or it was recorded already.
+	MarkEvidenceSubmitted(ctx context.Context, id, by uint64, at time.Time) error
+	// ListAwaitingEvidence returns up to limit disputes with IDs above
+	// afterID, in ID order, that still wait for evidence due between now and
+	// dueBefore, and whose admins were last reminded before remindedBefore,
+	// if ever. It spans all stores.
+	ListAwaitingEvidence(ctx context.Context, now, dueBefore, remindedBefore time.Time, afterID uint64, limit int) ([]model.Dispute, error)
+	// MarkReminded records when admins were reminded of a dispute.
+	MarkReminded(ctx context.Context, id uint64, at time.Time) error
+}
+
+// disputeRepository implements DisputeRepository using GORM.
+type disputeRepository struct {
+	db *gorm.DB
+}
+
+// NewDisputeRepository creates a new DisputeRepository instance.
+func NewDisputeRepository(db *gorm.DB) DisputeRepository {
+	return &disputeRepository{db: db}
+}
+
+func (r *disputeRepository) Create(ctx context.Context, dispute *model.Dispute) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(dispute).Error; err != nil {
+		return fmt.Errorf("failed to create %s dispute '%s': %w", dispute.Provider, dispute.DisputeRef, err)
+	}
+	return nil
+}
+
+func (r *disputeRepository) GetByID(ctx context.Context, id uint64) (*model.Dispute, error) {
+	var dispute model.Dispute
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.First(&dispute, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDisputeNotFound
+		}
+		return nil, fmt.Errorf("failed to get dispute '%d': %w", id, err)
+	}
+	return &dispute, nil
+}
+
+func (r *disputeRepository) GetByRef(ctx context.Context, provider, disputeRef string) (*model.Dispute, error) {
+	var dispute model.Dispute
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("provider = ? AND dispute_ref = ?", provider, disputeRef).First(&dispute).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDisputeNotFound
+		}
+		return nil, fmt.Errorf("failed to get %s dispute '%s': %w", provider, disputeRef, err)
+	}
+	return &dispute, nil
+}
+
+// UpdateStatus selects the columns, so that zero values, such as a nil
+// EvidenceDueBy, are saved too.
+func (r *disputeRepository) UpdateStatus(ctx context.Context, dispute *model.Dispute) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(dispute).
+		Select("amount", "currency", "reason", "status", "evidence_due_by", "closed_at").
+		Updates(dispute)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update dispute '%d': %w", dispute.ID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrDisputeNotFound
+	}
+	return nil
+}
+
+func (r *disputeRepository) List(ctx context.Context, filter DisputeFilter, offset, limit int) ([]model.Dispute, int64, error) {
+	query := database.GetDBFromContext(ctx, r.db).Model(&model.Dispute{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Open {
+		query = query.Where("status IN ?", model.DisputeOpenStatuses)
+	}
+	if filter.OrderID != 0 {
+		query = query.Where("order_id = ?", filter.OrderID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count disputes: %w", err)
+	}
+
+	var disputes []model.Dispute
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&disputes).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list disputes: %w", err)
+	}
+	return disputes, total, nil
+}
+
+func (r *disputeRepository) ListByOrder(ctx context.Context, orderID uint64) ([]model.Dispute, error) {
+	var disputes []model.Dispute
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("order_id = ?", orderID).Order("id").Find(&disputes).Error; err != nil {
+		return nil, fmt.Errorf("failed to list disputes of order '%d': %w", orderID, err)
+	}
+	return disputes, nil
+}
+
+func (r *disputeRepository) MarkEvidenceSubmitted(ctx context.Context, id, by uint64, at time.Time) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.Dispute{}).
+		Where("id = ? AND status = ? AND evidence_submitted_at IS NULL", id, model.DisputeStatusNeedsResponse).
+		Updates(map[string]any{"evidence_submitted_at": at, "evidence_submitted_by": by})
+	if result.Error != nil {
+		return fmt.Errorf("failed to record evidence of dispute '%d': %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrDisputeChanged
+	}
+	return nil
+}
+
+func (r *disputeRepository) ListAwaitingEvidence(ctx context.Context, now, dueBefore, remindedBefore time.Time, afterID uint64, limit int) ([]model.Dispute, error) {
+	var disputes []model.Dispute
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Where("status = ? AND evidence_submitted_at IS NULL", model.DisputeStatusNeedsResponse).
+		Where("evidence_due_by > ? AND evidence_due_by <= ?", now, dueBefore).
+		Where("reminded_at IS NULL OR reminded_at < ?", remindedBefore).
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&disputes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disputes awaiting evidence: %w", err)
+	}
+	return disputes, nil
+}
+
+func (r *disputeRepository) MarkReminded(ctx context.Context, id uint64, at time.Time) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Model(&model.Dispute{}).Where("id = ?", id).Update("reminded_at", at).Error; err != nil {
+		return fmt.Errorf("failed to record reminder of dispute '%d': %w", id, err)
+	}
+	return nil
+}
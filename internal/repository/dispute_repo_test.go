@@ -0,0 +1,71 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisputes(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewDisputeRepository(tx)
+	now := time.Now()
+	dueSoon, dueLater := now.Add(24*time.Hour), now.Add(30*24*time.Hour)
+
+	soon := &model.Dispute{OrderID: 900001, Provider: "stripe", DisputeRef: "dp_repo_1", PaymentRef: "pi_1", Amount: decimal.NewFromInt(10), Currency: "USD",
+		Status: model.DisputeStatusNeedsResponse, EvidenceDueBy: &dueSoon}
+	later := &model.Dispute{OrderID: 900002, Provider: "stripe", DisputeRef: "dp_repo_2", PaymentRef: "pi_2", Amount: decimal.NewFromInt(20), Currency: "USD",
+		Status: model.DisputeStatusNeedsResponse, EvidenceDueBy: &dueLater}
+	require.NoError(t, repo.Create(ctx, soon))
+	require.NoError(t, repo.Create(ctx, later))
+	assert.Error(t, repo.Create(ctx, &model.Dispute{OrderID: 900001, Provider: "stripe", DisputeRef: "dp_repo_1", Currency: "USD", Status: model.DisputeStatusWon}), "reported twice")
+
+	got, err := repo.GetByRef(ctx, "stripe", "dp_repo_1")
+	require.NoError(t, err)
+	assert.Equal(t, soon.ID, got.ID)
+	_, err = repo.GetByRef(ctx, "alipay", "dp_repo_1")
+	assert.ErrorIs(t, err, repository.ErrDisputeNotFound)
+
+	due, err := repo.ListAwaitingEvidence(ctx, now, now.Add(72*time.Hour), now.Add(-24*time.Hour), 0, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, soon.ID, due[0].ID)
+
+	require.NoError(t, repo.MarkReminded(ctx, soon.ID, now))
+	due, err = repo.ListAwaitingEvidence(ctx, now, now.Add(72*time.Hour), now.Add(-24*time.Hour), 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, due, "reminded within the interval")
+
+	require.NoError(t, repo.MarkEvidenceSubmitted(ctx, later.ID, 1, now))
+	assert.ErrorIs(t, repo.MarkEvidenceSubmitted(ctx, later.ID, 1, now), repository.ErrDisputeChanged)
+
+	closedAt := now
+	soon.Status, soon.EvidenceDueBy, soon.ClosedAt = model.DisputeStatusLost, nil, &closedAt
+	require.NoError(t, repo.UpdateStatus(ctx, soon))
+	got, err = repo.GetByID(ctx, soon.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.DisputeStatusLost, got.Status)
+	assert.Nil(t, got.EvidenceDueBy)
+
+	open, total, err := repo.List(ctx, repository.DisputeFilter{Open: true, OrderID: 900002}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, open, 1)
+	assert.Equal(t, later.ID, open[0].ID)
+
+	disputes, err := repo.ListByOrder(ctx, 900001)
+	require.NoError(t, err)
+	require.Len(t, disputes, 1)
+	assert.Equal(t, model.DisputeStatusLost, disputes[0].Status)
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/exchange_rate_repo_mock.go -package=mocks
+// ExchangeRateRepository defines the interface for exchange rate data operations.
+type ExchangeRateRepository interface {
+	SaveRates(ctx context.Context, rates []model.ExchangeRate) error
+	ListByBase(ctx context.Context, baseCurrency string) ([]model.ExchangeRate, error)
+}
+
+// exchangeRateRepository implements ExchangeRateRepository using GORM.
+type exchangeRateRepository struct {
+	db *gorm.DB
+}
+
+// NewExchangeRateRepository creates a new ExchangeRateRepository instance.
+func NewExchangeRateRepository(db *gorm.DB) ExchangeRateRepository {
+	return &exchangeRateRepository{db: db}
+}
+
+// SaveRates creates or replaces the rates of each currency pair in one statement.
+func (r *exchangeRateRepository) SaveRates(ctx context.Context, rates []model.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "base_currency"}, {Name: "currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+	}).Create(&rates).Error
+	if err != nil {
+		return fmt.Errorf("failed to save exchange rates: %w", err)
+	}
+	return nil
+}
+
+// ListByBase retrieves the rates from baseCurrency to every other currency.
+func (r *exchangeRateRepository) ListByBase(ctx context.Context, baseCurrency string) ([]model.ExchangeRate, error) {
+	var rates []model.ExchangeRate
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("base_currency = ?", baseCurrency).Order("currency").Find(&rates).Error; err != nil {
+		return nil, fmt.Errorf("failed to list exchange rates from %s: %w", baseCurrency, err)
+	}
+	return rates, nil
+}
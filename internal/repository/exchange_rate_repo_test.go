@@ -0,0 +1,38 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExchangeRatesSaveAndList(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewExchangeRateRepository(tx)
+
+	// XTS is the ISO 4217 code reserved for testing, so no real rates collide
+	require.NoError(t, repo.SaveRates(ctx, []model.ExchangeRate{
+		{BaseCurrency: "XTS", Currency: "EUR", Rate: decimal.RequireFromString("0.9")},
+		{BaseCurrency: "XTS", Currency: "JPY", Rate: decimal.NewFromInt(150)},
+	}))
+	require.NoError(t, repo.SaveRates(ctx, []model.ExchangeRate{
+		{BaseCurrency: "XTS", Currency: "EUR", Rate: decimal.RequireFromString("0.95")}, // Replaces the first
+	}))
+
+	rates, err := repo.ListByBase(ctx, "XTS")
+	require.NoError(t, err)
+	require.Len(t, rates, 2)
+	assert.Equal(t, "EUR", rates[0].Currency)
+	assert.True(t, decimal.RequireFromString("0.95").Equal(rates[0].Rate))
+	assert.Equal(t, "JPY", rates[1].Currency)
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrFailedMessageNotFound is returned when a failed message does not exist.
+	ErrFailedMessageNotFound = errors.New("failed message not found")
+	// ErrFailedMessageStatusChanged is returned when a failed message is no
+	// longer in the status an update expects.
+	ErrFailedMessageStatusChanged = errors.New("failed message status changed")
+)
+
+// FailedMessageFilter narrows a failed message query. Zero values match
+// everything.
+type FailedMessageFilter struct {
+	Queue  string
+	Status string
+}
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/failed_message_repo_mock.go -package=mocks
+// FailedMessageRepository stores the messages consumers rejected for good.
+type FailedMessageRepository interface {
+	Create(ctx context.Context, msg *model.FailedMessage) error
+	GetByID(ctx context.Context, id uint64) (*model.FailedMessage, error)
+	// List returns a page of matching messages, newest first, and how many
+	// match.
+	List(ctx context.Context, filter FailedMessageFilter, offset, limit int) ([]model.FailedMessage, int64, error)
+	// UpdateBody replaces the body of a message in one of the statuses from.
+	UpdateBody(ctx context.Context, id uint64, body string, from []string) error
+	// UpdateStatus moves a message from one of the statuses from to to.
+	UpdateStatus(ctx context.Context, id uint64, from []string, to string) error
+	// ListQueued returns up to limit messages queued for replay, oldest first.
+	ListQueued(ctx context.Context, limit int) ([]model.FailedMessage, error)
+	// MarkReplayed records a queued message published again at at.
+	MarkReplayed(ctx context.Context, id uint64, at time.Time) error
+}
+
+// failedMessageRepository implements FailedMessageRepository using GORM.
+type failedMessageRepository struct {
+	db *gorm.DB
+}
+
+// NewFailedMessageRepository creates a new FailedMessageRepository instance.
+func NewFailedMessageRepository(db *gorm.DB) FailedMessageRepository {
+	return &failedMessageRepository{db: db}
+}
+
+// Create saves a new failed message to the database.
+func (r *failedMessageRepository) Create(ctx context.Context, msg *model.FailedMessage) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(msg).Error; err != nil {
+		return fmt.Errorf("failed to create failed message: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a failed message by its ID.
+func (r *failedMessageRepository) GetByID(ctx context.Context, id uint64) (*model.FailedMessage, error) {
+	var msg model.FailedMessage
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.First(&msg, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFailedMessageNotFound
+		}
+		return nil, fmt.Errorf("failed to get failed message '%d': %w", id, err)
+	}
+	return &msg, nil
+}
+
+func (r *failedMessageRepository) List(ctx context.Context, filter FailedMessageFilter, offset, limit int) ([]model.FailedMessage, int64, error) {
+	db := database.GetDBFromContext(ctx, r.db)
+	query := db.Model(&model.FailedMessage{})
+	if filter.Queue != "" {
+		query = query.Where("queue = ?", filter.Queue)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count failed messages: %w", err)
+	}
+	var msgs []model.FailedMessage
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&msgs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list failed messages: %w", err)
+	}
+	return msgs, total, nil
+}
+
+// UpdateBody returns ErrFailedMessageStatusChanged if the message is not in
+// one of the statuses from, and ErrFailedMessageNotFound if it does not exist.
+func (r *failedMessageRepository) UpdateBody(ctx context.Context, id uint64, body string, from []string) error {
+	return r.update(ctx, id, from, "body", body)
+}
+
+// UpdateStatus returns ErrFailedMessageStatusChanged if the message is not in
+// one of the statuses from, and ErrFailedMessageNotFound if it does not exist.
+func (r *failedMessageRepository) UpdateStatus(ctx context.Context, id uint64, from []string, to string) error {
+	return r.update(ctx, id, from, "status", to)
+}
+
+// update sets column of a message in one of the statuses from.
+func (r *failedMessageRepository) update(ctx context.Context, id uint64, from []string, column string, value any) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.FailedMessage{}).
+		Where("id = ? AND status IN ?", id, from).
+		Update(column, value)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update %s of failed message '%d': %w", column, id, result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return ErrFailedMessageStatusChanged
+}
+
+func (r *failedMessageRepository) ListQueued(ctx context.Context, limit int) ([]model.FailedMessage, error) {
+	var msgs []model.FailedMessage
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Where("status = ?", model.FailedMessageStatusQueued).
+		Order("id").
+		Limit(limit).
+		Find(&msgs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queued failed messages: %w", err)
+	}
+	return msgs, nil
+}
+
+// MarkReplayed only applies to queued messages, so that two replayers racing
+// on a message count it once.
+func (r *failedMessageRepository) MarkReplayed(ctx context.Context, id uint64, at time.Time) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Model(&model.FailedMessage{}).
+		Where("id = ? AND status = ?", id, model.FailedMessageStatusQueued).
+		Updates(map[string]any{
+			"status":      model.FailedMessageStatusReplayed,
+			"replays":     gorm.Expr("replays + 1"),
+			"replayed_at": at,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark failed message '%d' replayed: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailedMessageReplay(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewFailedMessageRepository(tx)
+
+	msg := &model.FailedMessage{
+		Queue:   "orders.created",
+		Headers: map[string]string{"traceparent": "00-abc-def-01"},
+		Body:    `{"sku_id":`,
+		Error:   "unexpected end of JSON input",
+		Status:  model.FailedMessageStatusFailed,
+	}
+	require.NoError(t, repo.Create(ctx, msg))
+	replayable := []string{model.FailedMessageStatusFailed, model.FailedMessageStatusReplayed}
+
+	msgs, total, err := repo.List(ctx, repository.FailedMessageFilter{Queue: "orders.created", Status: model.FailedMessageStatusFailed}, 0, 10)
+	require.NoError(t, err)
+	assert.Positive(t, total)
+	assert.Equal(t, msg.ID, msgs[0].ID, "newest first")
+
+	require.NoError(t, repo.UpdateBody(ctx, msg.ID, `{"sku_id":1}`, replayable))
+	require.NoError(t, repo.UpdateStatus(ctx, msg.ID, replayable, model.FailedMessageStatusQueued))
+	assert.ErrorIs(t, repo.UpdateBody(ctx, msg.ID, `{}`, replayable), repository.ErrFailedMessageStatusChanged, "queued messages cannot be edited")
+	assert.ErrorIs(t, repo.UpdateStatus(ctx, msg.ID+1000, replayable, model.FailedMessageStatusQueued), repository.ErrFailedMessageNotFound)
+
+	queued, err := repo.ListQueued(ctx, 10)
+	require.NoError(t, err)
+	require.NotEmpty(t, queued)
+	assert.Equal(t, `{"sku_id":1}`, queued[len(queued)-1].Body)
+
+	require.NoError(t, repo.MarkReplayed(ctx, msg.ID, time.Now()))
+	require.NoError(t, repo.MarkReplayed(ctx, msg.ID, time.Now()), "a second replayer is a no-op")
+	got, err := repo.GetByID(ctx, msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.FailedMessageStatusReplayed, got.Status)
+	assert.Equal(t, 1, got.Replays)
+	assert.NotNil(t, got.ReplayedAt)
+	assert.Equal(t, "00-abc-def-01", got.Headers["traceparent"])
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrLicenseKeysExhausted is returned when a SKU's pool has fewer available
+// license keys than an order item needs.
+var ErrLicenseKeysExhausted = errors.New("not enough license keys available")
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/license_key_repo_mock.go -package=mocks
+// LicenseKeyRepository defines the interface for license key data operations.
+type LicenseKeyRepository interface {
+	// AddKeys adds keys to the pool of a SKU and returns how many were new;
+	// keys the pool has already are skipped.
+	AddKeys(ctx context.Context, skuID uint64, keys []string) (int, error)
+	// CountAvailable returns how many keys of a SKU are not allocated yet.
+	CountAvailable(ctx context.Context, skuID uint64) (int64, error)
+	// Allocate makes sure quantity keys of a SKU are allocated to an order
+	// item and returns them. Keys allocated to it before count, so it can be
+	// retried; ErrLicenseKeysExhausted means the pool ran dry.
+	Allocate(ctx context.Context, skuID, orderItemID uint64, quantity int) ([]string, error)
+}
+
+// licenseKeyRepository implements LicenseKeyRepository using GORM.
+type licenseKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewLicenseKeyRepository creates a new LicenseKeyRepository instance.
+func NewLicenseKeyRepository(db *gorm.DB) LicenseKeyRepository {
+	return &licenseKeyRepository{db: db}
+}
+
+func (r *licenseKeyRepository) AddKeys(ctx context.Context, skuID uint64, keys []string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	rows := make([]model.LicenseKey, len(keys))
+	for i, key := range keys {
+		rows[i] = model.LicenseKey{SKUID: skuID, Key: key}
+	}
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&rows, 1000)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to add license keys of SKU '%d': %w", skuID, result.Error)
+	}
+	return int(result.RowsAffected), nil
+}
+
+func (r *licenseKeyRepository) CountAvailable(ctx context.Context, skuID uint64) (int64, error) {
+	var count int64
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Model(&model.LicenseKey{}).Where("sku_id = ? AND order_item_id = 0", skuID).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count license keys of SKU '%d': %w", skuID, err)
+	}
+	return count, nil
+}
+
+// Allocate takes the oldest available keys, skipping those another
+// transaction is allocating, so that concurrent orders neither wait on nor
+// share a key. It should run in a transaction, which ErrLicenseKeysExhausted
+// rolls back.
+func (r *licenseKeyRepository) Allocate(ctx context.Context, skuID, orderItemID uint64, quantity int) ([]string, error) {
+	db := database.GetDBFromContext(ctx, r.db)
+	var allocated []model.LicenseKey
+	if err := db.Where("order_item_id = ?", orderItemID).Order("id").Find(&allocated).Error; err != nil {
+		return nil, fmt.Errorf("failed to get license keys of order item '%d': %w", orderItemID, err)
+	}
+
+	if missing := quantity - len(allocated); missing > 0 {
+		var available []model.LicenseKey
+		err := db.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked}).
+			Where("sku_id = ? AND order_item_id = 0", skuID).
+			Order("id").
+			Limit(missing).
+			Find(&available).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to get license keys of SKU '%d': %w", skuID, err)
+		}
+		if len(available) < missing {
+			return nil, ErrLicenseKeysExhausted
+		}
+
+		ids := make([]uint64, len(available))
+		for i, key := range available {
+			ids[i] = key.ID
+		}
+		err = db.Model(&model.LicenseKey{}).
+			Where("id IN ?", ids).
+			Updates(map[string]any{"order_item_id": orderItemID, "allocated_at": time.Now()}).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to allocate license keys to order item '%d': %w", orderItemID, err)
+		}
+		allocated = append(allocated, available...)
+	}
+
+	keys := make([]string, len(allocated))
+	for i, key := range allocated {
+		keys[i] = key.Key
+	}
+	return keys, nil
+}
@@ -0,0 +1,48 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLicenseKeys(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewLicenseKeyRepository(tx)
+	const skuID, otherSKUID = 101, 102
+
+	added, err := repo.AddKeys(ctx, skuID, []string{"AAAA", "BBBB", "CCCC"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, added)
+	added, err = repo.AddKeys(ctx, skuID, []string{"CCCC", "DDDD"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, added, "keys in the pool are skipped")
+	_, err = repo.AddKeys(ctx, otherSKUID, []string{"AAAA"})
+	require.NoError(t, err)
+
+	keys, err := repo.Allocate(ctx, skuID, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AAAA", "BBBB"}, keys)
+	available, err := repo.CountAvailable(ctx, skuID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), available)
+
+	// A retry returns the keys it has; a larger quantity tops them up
+	keys, err = repo.Allocate(ctx, skuID, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AAAA", "BBBB"}, keys)
+	keys, err = repo.Allocate(ctx, skuID, 1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AAAA", "BBBB", "CCCC"}, keys)
+
+	_, err = repo.Allocate(ctx, skuID, 2, 2)
+	assert.ErrorIs(t, err, repository.ErrLicenseKeysExhausted)
+}
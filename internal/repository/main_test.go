@@ -6,7 +6,7 @@ import (
 	"testing"
 
 	"github.com/proyuen/go-mall/internal/model" // Import model for AutoMigrate
-	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/config/configtest"
 	"github.com/proyuen/go-mall/pkg/database"
 	"github.com/proyuen/go-mall/pkg/snowflake" // Import snowflake package
 	"gorm.io/gorm"
@@ -15,27 +15,19 @@ import (
 var testDB *gorm.DB
 
 func TestMain(m *testing.M) {
-	// Setup test database configuration
-	cfg := &config.DatabaseConfig{
-		Host:     "localhost",
-		Port:     "5432",
-		User:     "postgres",
-		Password: "password",
-		SSLMode:  "disable",
-		TimeZone: "Asia/Shanghai",
-	}
-
-	// Prioritize MALL_DATABASE_DBNAME environment variable for DBName
-	// Fallback to "go_mall_test" if the environment variable is not set.
-	dbNameFromEnv := os.Getenv("MALL_DATABASE_DBNAME")
-	if dbNameFromEnv != "" {
-		cfg.DBName = dbNameFromEnv
-	} else {
-		cfg.DBName = "go_mall_test"
+	// Setup test database configuration from an ephemeral profile.
+	// MALL_DATABASE_* environment variables (e.g. MALL_DATABASE_DBNAME in CI) still take precedence.
+	appCfg, err := configtest.LoadProfile(`
+database:
+  dbname: "go_mall_test"
+`)
+	if err != nil {
+		log.Printf("FATAL: Failed to load test config: %v", err)
+		os.Exit(1)
 	}
+	cfg := &appCfg.Database
 
 	// Initialize database connection
-	var err error
 	testDB, err = database.NewPostgresDB(cfg)
 	if err != nil {
 		log.Printf("FATAL: Failed to connect to test database with DBNAME='%s': %v", cfg.DBName, err)
@@ -47,10 +39,45 @@ func TestMain(m *testing.M) {
 	// especially in environments where NewPostgresDB's internal AutoMigrate might be skipped or insufficient.
 	err = testDB.AutoMigrate(
 		&model.User{},
+		&model.Category{},
 		&model.SPU{},
 		&model.SKU{},
+		&model.SPUTranslation{},
+		&model.SPUStats{},
+		&model.SearchSynonym{}, &model.SearchRule{}, &model.SearchZeroResult{},
 		&model.Order{},
 		&model.OrderItem{},
+		&model.OrderTaxLine{},
+		&model.AuditLog{},
+		&model.NotificationPreference{},
+		&model.EmailSuppression{},
+		&model.PushDevice{},
+		&model.NotificationDelivery{},
+		&model.NotificationTemplate{},
+		&model.Notification{},
+		&model.Broadcast{},
+		&model.OutboxEvent{},
+		&model.FailedMessage{},
+		&model.OrderSummary{},
+		&model.SagaState{},
+		&model.WebhookSubscription{},
+		&model.WebhookDelivery{},
+		&model.WebhookAttempt{},
+		&model.Review{}, &model.ReviewVote{}, &model.ReviewReport{}, &model.SPURating{},
+		&model.ExchangeRate{},
+		&model.Store{},
+		&model.PaymentMethod{},
+		&model.Shipment{},
+		&model.ShipmentItem{},
+		&model.PaymentCapture{},
+		&model.Promotion{},
+		&model.PromotionTier{},
+		&model.OrderPromotion{},
+		&model.CouponCampaign{},
+		&model.Coupon{},
+		&model.CouponCampaignStats{},
+		&model.Subscription{},
+		&model.LicenseKey{},
 	)
 	if err != nil {
 		log.Printf("FATAL: Failed to auto migrate test database: %v", err)
@@ -0,0 +1,331 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrPreferenceNotFound is returned when a user has not saved notification preferences.
+	ErrPreferenceNotFound = errors.New("notification preference not found")
+	// ErrPushDeviceNotFound is returned when a user has no device with the given token.
+	ErrPushDeviceNotFound = errors.New("push device not found")
+	// ErrTemplateNotFound is returned when no template of a kind, channel and locale is stored.
+	ErrTemplateNotFound = errors.New("notification template not found")
+	// ErrNotificationNotFound is returned when a user's inbox has no notification with the given ID.
+	ErrNotificationNotFound = errors.New("notification not found")
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/notification_repo_mock.go -package=mocks
+// NotificationRepository defines the interface for notification preferences,
+// suppressed email addresses, push devices, delivery records, templates and
+// the in-app inbox.
+type NotificationRepository interface {
+	GetPreference(ctx context.Context, userID uint64) (*model.NotificationPreference, error)
+	SavePreference(ctx context.Context, pref *model.NotificationPreference) error
+	SuppressEmail(ctx context.Context, email, reason string) error
+	IsEmailSuppressed(ctx context.Context, email string) (bool, error)
+	SavePushDevice(ctx context.Context, device *model.PushDevice) error
+	ListPushDevices(ctx context.Context, userID uint64) ([]model.PushDevice, error)
+	DeletePushDevice(ctx context.Context, userID uint64, token string) error
+	// PrunePushDevice removes a token the push service no longer accepts, whoever owns it.
+	PrunePushDevice(ctx context.Context, token string) error
+	SaveDelivery(ctx context.Context, delivery *model.NotificationDelivery) error
+	// ListDeliveries returns a user's most recent deliveries, newest first.
+	ListDeliveries(ctx context.Context, userID uint64, limit int) ([]model.NotificationDelivery, error)
+	// GetTemplate returns the latest version of a template.
+	GetTemplate(ctx context.Context, kind, channel, locale string) (*model.NotificationTemplate, error)
+	// ListTemplates returns the latest version of every template.
+	ListTemplates(ctx context.Context) ([]model.NotificationTemplate, error)
+	// ListTemplateVersions returns every version of a template, newest first.
+	ListTemplateVersions(ctx context.Context, kind, channel, locale string) ([]model.NotificationTemplate, error)
+	// CreateTemplateVersion saves tmpl as the next version of its template.
+	CreateTemplateVersion(ctx context.Context, tmpl *model.NotificationTemplate) error
+	// DeleteTemplate deletes every version of a template.
+	DeleteTemplate(ctx context.Context, kind, channel, locale string) error
+	// AddToInbox stores a notification once per delivery ID.
+	AddToInbox(ctx context.Context, n *model.Notification) error
+	// ListInbox returns a page of a user's notifications, newest first, and
+	// how many there are.
+	ListInbox(ctx context.Context, userID uint64, unreadOnly bool, offset, limit int) ([]model.Notification, int64, error)
+	CountUnread(ctx context.Context, userID uint64) (int64, error)
+	// MarkRead marks one of the user's notifications read at.
+	MarkRead(ctx context.Context, userID, id uint64, at time.Time) error
+	// MarkAllRead marks every unread notification of the user read at and
+	// returns how many it marked.
+	MarkAllRead(ctx context.Context, userID uint64, at time.Time) (int64, error)
+}
+
+// notificationRepository implements NotificationRepository using GORM.
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository creates a new NotificationRepository instance.
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// GetPreference retrieves the notification preferences of a user.
+func (r *notificationRepository) GetPreference(ctx context.Context, userID uint64) (*model.NotificationPreference, error) {
+	var pref model.NotificationPreference
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("user_id = ?", userID).First(&pref).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPreferenceNotFound
+		}
+		return nil, fmt.Errorf("failed to get notification preference of user '%d': %w", userID, err)
+	}
+	return &pref, nil
+}
+
+// SavePreference creates or replaces the notification preferences of pref.UserID.
+func (r *notificationRepository) SavePreference(ctx context.Context, pref *model.NotificationPreference) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"phone", "muted", "updated_at"}),
+	}).Create(pref).Error
+	if err != nil {
+		return fmt.Errorf("failed to save notification preference of user '%d': %w", pref.UserID, err)
+	}
+	return nil
+}
+
+// SuppressEmail records that email must not be sent to again. Suppressing an
+// address twice keeps the latest reason.
+func (r *notificationRepository) SuppressEmail(ctx context.Context, email, reason string) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "email"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reason", "updated_at"}),
+	}).Create(&model.EmailSuppression{Email: email, Reason: reason}).Error
+	if err != nil {
+		return fmt.Errorf("failed to suppress email: %w", err)
+	}
+	return nil
+}
+
+// IsEmailSuppressed reports whether email has been suppressed.
+func (r *notificationRepository) IsEmailSuppressed(ctx context.Context, email string) (bool, error) {
+	var count int64
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Model(&model.EmailSuppression{}).Where("email = ?", email).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check email suppression: %w", err)
+	}
+	return count > 0, nil
+}
+
+// SavePushDevice registers device.Token for device.UserID. A token registered
+// before, e.g. by another account on the same device, moves to the new owner.
+func (r *notificationRepository) SavePushDevice(ctx context.Context, device *model.PushDevice) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "platform", "updated_at"}),
+	}).Create(device).Error
+	if err != nil {
+		return fmt.Errorf("failed to save push device of user '%d': %w", device.UserID, err)
+	}
+	return nil
+}
+
+// ListPushDevices retrieves the push devices of a user.
+func (r *notificationRepository) ListPushDevices(ctx context.Context, userID uint64) ([]model.PushDevice, error) {
+	var devices []model.PushDevice
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("failed to list push devices of user '%d': %w", userID, err)
+	}
+	return devices, nil
+}
+
+// DeletePushDevice unregisters one of the user's devices. Devices are deleted
+// outright, since a soft-deleted row would keep holding the unique token.
+func (r *notificationRepository) DeletePushDevice(ctx context.Context, userID uint64, token string) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Unscoped().Where("user_id = ? AND token = ?", userID, token).Delete(&model.PushDevice{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete push device of user '%d': %w", userID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrPushDeviceNotFound
+	}
+	return nil
+}
+
+// PrunePushDevice deletes token; a token that is already gone is not an error.
+func (r *notificationRepository) PrunePushDevice(ctx context.Context, token string) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Unscoped().Where("token = ?", token).Delete(&model.PushDevice{}).Error; err != nil {
+		return fmt.Errorf("failed to prune push device: %w", err)
+	}
+	return nil
+}
+
+// SaveDelivery creates or updates the record of delivery.DeliveryID.
+func (r *notificationRepository) SaveDelivery(ctx context.Context, delivery *model.NotificationDelivery) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "delivery_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "attempts", "last_error", "updated_at"}),
+	}).Create(delivery).Error
+	if err != nil {
+		return fmt.Errorf("failed to save notification delivery '%s': %w", delivery.DeliveryID, err)
+	}
+	return nil
+}
+
+// ListDeliveries retrieves the latest deliveries of a user.
+func (r *notificationRepository) ListDeliveries(ctx context.Context, userID uint64, limit int) ([]model.NotificationDelivery, error) {
+	var deliveries []model.NotificationDelivery
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("user_id = ?", userID).Order("created_at DESC, id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("failed to list notification deliveries of user '%d': %w", userID, err)
+	}
+	return deliveries, nil
+}
+
+// GetTemplate retrieves the latest version of the template of kind on channel in locale.
+func (r *notificationRepository) GetTemplate(ctx context.Context, kind, channel, locale string) (*model.NotificationTemplate, error) {
+	var tmpl model.NotificationTemplate
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Where("kind = ? AND channel = ? AND locale = ?", kind, channel, locale).Order("version DESC").First(&tmpl).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, fmt.Errorf("failed to get %s %s template in %s: %w", kind, channel, locale, err)
+	}
+	return &tmpl, nil
+}
+
+// ListTemplates retrieves the latest version of every template, by kind, channel and locale.
+func (r *notificationRepository) ListTemplates(ctx context.Context) ([]model.NotificationTemplate, error) {
+	var templates []model.NotificationTemplate
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Select("DISTINCT ON (kind, channel, locale) *").
+		Order("kind, channel, locale, version DESC").
+		Find(&templates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification templates: %w", err)
+	}
+	return templates, nil
+}
+
+// ListTemplateVersions retrieves every version of a template, newest first.
+func (r *notificationRepository) ListTemplateVersions(ctx context.Context, kind, channel, locale string) ([]model.NotificationTemplate, error) {
+	var templates []model.NotificationTemplate
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Where("kind = ? AND channel = ? AND locale = ?", kind, channel, locale).Order("version DESC").Find(&templates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions of %s %s template in %s: %w", kind, channel, locale, err)
+	}
+	return templates, nil
+}
+
+// CreateTemplateVersion numbers tmpl after every earlier version of its
+// template, deleted ones included, and creates it. Of two versions saved at
+// once, one fails on the unique version index.
+func (r *notificationRepository) CreateTemplateVersion(ctx context.Context, tmpl *model.NotificationTemplate) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		err := tx.Unscoped().Model(&model.NotificationTemplate{}).
+			Where("kind = ? AND channel = ? AND locale = ?", tmpl.Kind, tmpl.Channel, tmpl.Locale).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error
+		if err != nil {
+			return err
+		}
+		tmpl.Version = latest + 1
+		return tx.Create(tmpl).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save %s %s template in %s: %w", tmpl.Kind, tmpl.Channel, tmpl.Locale, err)
+	}
+	return nil
+}
+
+// DeleteTemplate soft-deletes every version of a template, so that the
+// built-in one is sent again.
+func (r *notificationRepository) DeleteTemplate(ctx context.Context, kind, channel, locale string) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Where("kind = ? AND channel = ? AND locale = ?", kind, channel, locale).Delete(&model.NotificationTemplate{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete %s %s template in %s: %w", kind, channel, locale, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
+
+// AddToInbox creates n unless a notification of n.DeliveryID exists.
+func (r *notificationRepository) AddToInbox(ctx context.Context, n *model.Notification) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "delivery_id"}}, DoNothing: true}).Create(n).Error
+	if err != nil {
+		return fmt.Errorf("failed to add notification '%s' to inbox of user '%d': %w", n.DeliveryID, n.UserID, err)
+	}
+	return nil
+}
+
+// ListInbox retrieves a page of the notifications of a user, newest first.
+func (r *notificationRepository) ListInbox(ctx context.Context, userID uint64, unreadOnly bool, offset, limit int) ([]model.Notification, int64, error) {
+	db := database.GetDBFromContext(ctx, r.db)
+	query := db.Model(&model.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count notifications of user '%d': %w", userID, err)
+	}
+	var notifications []model.Notification
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&notifications).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list notifications of user '%d': %w", userID, err)
+	}
+	return notifications, total, nil
+}
+
+// CountUnread counts the unread notifications of a user.
+func (r *notificationRepository) CountUnread(ctx context.Context, userID uint64) (int64, error) {
+	var count int64
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Model(&model.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications of user '%d': %w", userID, err)
+	}
+	return count, nil
+}
+
+// MarkRead marks a notification of the user read; one read before keeps
+// when it was first read.
+func (r *notificationRepository) MarkRead(ctx context.Context, userID, id uint64, at time.Time) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.Notification{}).Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", at))
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark notification '%d' read: %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead marks every unread notification of the user read.
+func (r *notificationRepository) MarkAllRead(ctx context.Context, userID uint64, at time.Time) (int64, error) {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Update("read_at", at)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to mark notifications of user '%d' read: %w", userID, result.Error)
+	}
+	return result.RowsAffected, nil
+}
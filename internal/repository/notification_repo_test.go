@@ -0,0 +1,198 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationPreference(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewNotificationRepository(tx)
+	user := createRandomUser(t, repository.NewUserRepository(tx))
+
+	_, err := repo.GetPreference(ctx, user.ID)
+	assert.ErrorIs(t, err, repository.ErrPreferenceNotFound)
+
+	// Saving twice updates the row rather than failing on the unique user ID.
+	require.NoError(t, repo.SavePreference(ctx, &model.NotificationPreference{UserID: user.ID, Muted: map[string][]string{}}))
+	require.NoError(t, repo.SavePreference(ctx, &model.NotificationPreference{UserID: user.ID, Phone: "+15005550006", Muted: map[string][]string{"order": {"email", "push"}}}))
+
+	pref, err := repo.GetPreference(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"order": {"email", "push"}}, pref.Muted)
+	assert.Equal(t, "+15005550006", pref.Phone)
+}
+
+func TestEmailSuppression(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewNotificationRepository(tx)
+	email := utils.RandomEmail("")
+
+	suppressed, err := repo.IsEmailSuppressed(ctx, email)
+	require.NoError(t, err)
+	assert.False(t, suppressed)
+
+	require.NoError(t, repo.SuppressEmail(ctx, email, "550 mailbox unavailable"))
+	require.NoError(t, repo.SuppressEmail(ctx, email, "ses: Permanent/General"))
+
+	suppressed, err = repo.IsEmailSuppressed(ctx, email)
+	require.NoError(t, err)
+	assert.True(t, suppressed)
+}
+
+func TestPushDevices(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewNotificationRepository(tx)
+	userRepo := repository.NewUserRepository(tx)
+	alice, bob := createRandomUser(t, userRepo), createRandomUser(t, userRepo)
+	token := utils.RandomString(64)
+
+	require.NoError(t, repo.SavePushDevice(ctx, &model.PushDevice{UserID: alice.ID, Platform: "fcm", Token: token}))
+	// Signing in as another account on the same device moves the token.
+	require.NoError(t, repo.SavePushDevice(ctx, &model.PushDevice{UserID: bob.ID, Platform: "fcm", Token: token}))
+
+	devices, err := repo.ListPushDevices(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Empty(t, devices)
+	devices, err = repo.ListPushDevices(ctx, bob.ID)
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	assert.Equal(t, token, devices[0].Token)
+
+	assert.ErrorIs(t, repo.DeletePushDevice(ctx, alice.ID, token), repository.ErrPushDeviceNotFound)
+	require.NoError(t, repo.PrunePushDevice(ctx, token))
+	require.NoError(t, repo.PrunePushDevice(ctx, token))
+	assert.ErrorIs(t, repo.DeletePushDevice(ctx, bob.ID, token), repository.ErrPushDeviceNotFound)
+}
+
+func TestNotificationDeliveries(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewNotificationRepository(tx)
+	user := createRandomUser(t, repository.NewUserRepository(tx))
+
+	deliveryID := utils.RandomString(36)
+	require.NoError(t, repo.SaveDelivery(ctx, &model.NotificationDelivery{DeliveryID: deliveryID, UserID: user.ID, Kind: "shipment", Channel: "sms", Status: "retrying", Attempts: 1, LastError: "timeout"}))
+	require.NoError(t, repo.SaveDelivery(ctx, &model.NotificationDelivery{DeliveryID: deliveryID, UserID: user.ID, Kind: "shipment", Channel: "sms", Status: "sent", Attempts: 2}))
+
+	deliveries, err := repo.ListDeliveries(ctx, user.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, "sent", deliveries[0].Status)
+	assert.Equal(t, 2, deliveries[0].Attempts)
+	assert.Empty(t, deliveries[0].LastError)
+}
+
+func TestNotificationTemplates(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewNotificationRepository(tx)
+
+	_, err := repo.GetTemplate(ctx, "shipment", "sms", "zh")
+	assert.ErrorIs(t, err, repository.ErrTemplateNotFound)
+
+	for _, body := range []string{"v1", "v2"} {
+		require.NoError(t, repo.CreateTemplateVersion(ctx, &model.NotificationTemplate{Kind: "shipment", Channel: "sms", Locale: "zh", Body: body}))
+	}
+	require.NoError(t, repo.CreateTemplateVersion(ctx, &model.NotificationTemplate{Kind: "shipment", Channel: "push", Locale: "zh", Subject: "Shipped", Body: "v1"}))
+
+	latest, err := repo.GetTemplate(ctx, "shipment", "sms", "zh")
+	require.NoError(t, err)
+	assert.Equal(t, 2, latest.Version)
+	assert.Equal(t, "v2", latest.Body)
+
+	versions, err := repo.ListTemplateVersions(ctx, "shipment", "sms", "zh")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, 2, versions[0].Version)
+
+	templates, err := repo.ListTemplates(ctx)
+	require.NoError(t, err)
+	require.Len(t, templates, 2)
+	assert.Equal(t, "push", templates[0].Channel)
+	assert.Equal(t, 2, templates[1].Version)
+
+	// Versions keep counting after the template is deleted.
+	require.NoError(t, repo.DeleteTemplate(ctx, "shipment", "sms", "zh"))
+	assert.ErrorIs(t, repo.DeleteTemplate(ctx, "shipment", "sms", "zh"), repository.ErrTemplateNotFound)
+	tmpl := &model.NotificationTemplate{Kind: "shipment", Channel: "sms", Locale: "zh", Body: "v3"}
+	require.NoError(t, repo.CreateTemplateVersion(ctx, tmpl))
+	assert.Equal(t, 3, tmpl.Version)
+}
+
+func TestNotificationInbox(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewNotificationRepository(tx)
+	user := createRandomUser(t, repository.NewUserRepository(tx))
+	other := createRandomUser(t, repository.NewUserRepository(tx))
+
+	var ids []uint64
+	for _, title := range []string{"Confirmed", "Shipped"} {
+		n := &model.Notification{UserID: user.ID, DeliveryID: utils.RandomString(36), Kind: "shipment", Title: title, Data: map[string]string{"order_number": "ORD1"}}
+		require.NoError(t, repo.AddToInbox(ctx, n))
+		ids = append(ids, n.ID)
+	}
+	// A redelivered message is added once.
+	duplicate := &model.Notification{UserID: user.ID, DeliveryID: utils.RandomString(36), Kind: "shipment", Title: "Once"}
+	require.NoError(t, repo.AddToInbox(ctx, duplicate))
+	require.NoError(t, repo.AddToInbox(ctx, &model.Notification{UserID: user.ID, DeliveryID: duplicate.DeliveryID, Kind: "shipment", Title: "Once"}))
+
+	items, total, err := repo.ListInbox(ctx, user.ID, false, 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, items, 2)
+	assert.Equal(t, "Once", items[0].Title)
+	assert.Equal(t, "ORD1", items[1].Data["order_number"])
+
+	now := time.Now()
+	require.NoError(t, repo.MarkRead(ctx, user.ID, ids[0], now))
+	require.NoError(t, repo.MarkRead(ctx, user.ID, ids[0], now), "marking read twice is fine")
+	assert.ErrorIs(t, repo.MarkRead(ctx, other.ID, ids[1], now), repository.ErrNotificationNotFound)
+
+	unread, err := repo.CountUnread(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), unread)
+
+	marked, err := repo.MarkAllRead(ctx, user.ID, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), marked)
+	items, total, err = repo.ListInbox(ctx, user.ID, true, 0, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, items)
+}
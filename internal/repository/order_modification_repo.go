@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/order_modification_repo_mock.go -package=mocks
+// OrderModificationRepository keeps the modification history of orders.
+type OrderModificationRepository interface {
+	Create(ctx context.Context, modification *model.OrderModification) error
+	// ListByOrder returns the modifications of an order, oldest first.
+	ListByOrder(ctx context.Context, orderID uint64) ([]model.OrderModification, error)
+}
+
+// orderModificationRepository implements OrderModificationRepository using GORM.
+type orderModificationRepository struct {
+	db *gorm.DB
+}
+
+// NewOrderModificationRepository creates a new OrderModificationRepository instance.
+func NewOrderModificationRepository(db *gorm.DB) OrderModificationRepository {
+	return &orderModificationRepository{db: db}
+}
+
+func (r *orderModificationRepository) Create(ctx context.Context, modification *model.OrderModification) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(modification).Error; err != nil {
+		return fmt.Errorf("failed to create modification of order '%d': %w", modification.OrderID, err)
+	}
+	return nil
+}
+
+func (r *orderModificationRepository) ListByOrder(ctx context.Context, orderID uint64) ([]model.OrderModification, error) {
+	var modifications []model.OrderModification
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("order_id = ?", orderID).Order("created_at, id").Find(&modifications).Error; err != nil {
+		return nil, fmt.Errorf("failed to list modifications of order '%d': %w", orderID, err)
+	}
+	return modifications, nil
+}
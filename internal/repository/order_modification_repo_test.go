@@ -0,0 +1,48 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderModifications(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewOrderModificationRepository(tx)
+
+	first := &model.OrderModification{
+		OrderID: 900001, UserID: 1, Kind: model.OrderModificationQuantity, OrderItemID: 5,
+		Before: model.JSONB{"quantity": 2}, After: model.JSONB{"quantity": 1},
+		AmountDelta: decimal.RequireFromString("-11.90"), Currency: "EUR", Adjustment: model.PaymentAdjustmentRefund,
+	}
+	second := &model.OrderModification{
+		OrderID: 900001, UserID: 1, Kind: model.OrderModificationShippingAddress,
+		After: model.JSONB{"city": "Berlin"}, Currency: "EUR",
+	}
+	other := &model.OrderModification{OrderID: 900002, UserID: 2, Kind: model.OrderModificationShippingAddress, Currency: "EUR"}
+	for _, modification := range []*model.OrderModification{first, second, other} {
+		require.NoError(t, repo.Create(ctx, modification))
+	}
+
+	modifications, err := repo.ListByOrder(ctx, 900001)
+	require.NoError(t, err)
+	require.Len(t, modifications, 2)
+	assert.Equal(t, first.ID, modifications[0].ID)
+	assert.Equal(t, second.ID, modifications[1].ID)
+	assert.True(t, decimal.RequireFromString("-11.90").Equal(modifications[0].AmountDelta))
+	assert.Equal(t, "Berlin", modifications[1].After["city"])
+
+	modifications, err = repo.ListByOrder(ctx, 900003)
+	require.NoError(t, err)
+	assert.Empty(t, modifications)
+}
@@ -2,17 +2,96 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/proyuen/go-mall/internal/model"
 	"github.com/proyuen/go-mall/pkg/database"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var (
+	// ErrOrderNotFound is returned when an order does not exist.
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrOrderStatusChanged is returned when an order is no longer in the
+	// status a transition expects, e.g. it was paid while being cancelled.
+	ErrOrderStatusChanged = errors.New("order status changed")
+)
+
+// OrderFilter narrows an order query. Zero values match everything.
+type OrderFilter struct {
+	Status    string
+	UserID    uint64
+	From      time.Time       // Created at or after; inclusive
+	To        time.Time       // Created before; exclusive
+	MinAmount decimal.Decimal // Total amount at least this; inclusive
+	MaxAmount decimal.Decimal // Total amount at most this; inclusive
+}
+
 //go:generate mockgen -source=$GOFILE -destination=../mocks/order_repo_mock.go -package=mocks
 // OrderRepository defines the interface for order data operations.
 type OrderRepository interface {
 	CreateOrder(ctx context.Context, order *model.Order, items []model.OrderItem) error
+	GetByID(ctx context.Context, id uint64) (*model.Order, error)
+	// GetByIDForUpdate is GetByID that also locks the order row until the
+	// transaction in ctx ends.
+	GetByIDForUpdate(ctx context.Context, id uint64) (*model.Order, error)
+	// GetByOrderNumber finds the order a payment notification refers to.
+	GetByOrderNumber(ctx context.Context, orderNumber string) (*model.Order, error)
+	// GetByPaymentRef finds the order a dispute of a payment refers to.
+	GetByPaymentRef(ctx context.Context, provider, paymentRef string) (*model.Order, error)
+	ListPendingBefore(ctx context.Context, before time.Time, afterID uint64, limit int) ([]model.Order, error)
+	UpdateStatus(ctx context.Context, orderID uint64, from, to string) error
+	// MarkPaid moves a pending order to paid, or to backordered while any of
+	// its items is, and records the payment.
+	MarkPaid(ctx context.Context, orderID uint64, provider, paymentRef string) error
+	// SetPaymentIntent records the payment started for a pending order that
+	// has none yet.
+	SetPaymentIntent(ctx context.Context, orderID uint64, provider string, intent model.PaymentIntent) error
+	// MarkAuthorized moves a pending order to authorized and records the
+	// authorization.
+	MarkAuthorized(ctx context.Context, orderID uint64, provider, paymentRef string) error
+	// AddCapture adds amount to what was captured of an authorized order,
+	// and moves it to paid when final.
+	AddCapture(ctx context.Context, orderID uint64, amount decimal.Decimal, final bool) error
+	// ListBackordered returns up to limit orders waiting for stock with IDs
+	// greater than afterID, in ID order and with their items.
+	ListBackordered(ctx context.Context, afterID uint64, limit int) ([]model.Order, error)
+	// AllocateItems marks every backordered item of an order allocated.
+	AllocateItems(ctx context.Context, orderID uint64) error
+	// ListUndelivered returns up to limit paid orders with digital items not
+	// delivered yet and IDs greater than afterID, in ID order and with their
+	// items.
+	ListUndelivered(ctx context.Context, afterID uint64, limit int) ([]model.Order, error)
+	// MarkDelivered records the digital items of an order delivered at at.
+	MarkDelivered(ctx context.Context, orderID uint64, at time.Time) error
+	// UpdateShippingAddress replaces the shipping address of an order.
+	UpdateShippingAddress(ctx context.Context, orderID uint64, address model.Address) error
+	// SetFulfillment records how far the shipments of an order got.
+	SetFulfillment(ctx context.Context, orderID uint64, fulfillment string) error
+	// UpdateItemQuantity saves the quantity and discount of item, and the
+	// amounts of order and of its tax lines they changed.
+	UpdateItemQuantity(ctx context.Context, order *model.Order, item *model.OrderItem) error
+	ListSKUIDsChangedSince(ctx context.Context, skuIDs []uint64, since time.Time) ([]uint64, error)
+	SummarizeSince(ctx context.Context, since time.Time) ([]OrderStatusSummary, error)
+	// ListOrders returns matching orders, newest first and without their
+	// items, and the total number of matches.
+	ListOrders(ctx context.Context, filter OrderFilter, offset, limit int) ([]model.Order, int64, error)
+	// CountByStatus counts the matching orders in each status. Statuses
+	// without orders are left out.
+	CountByStatus(ctx context.Context, filter OrderFilter) (map[string]int64, error)
+}
+
+// OrderStatusSummary totals the orders in one status charged in one currency.
+type OrderStatusSummary struct {
+	Status   string
+	Currency string
+	Orders   int64
+	Amount   decimal.Decimal // Including tax
+	Tax      decimal.Decimal
 }
 
 // orderRepository implements OrderRepository using GORM.
@@ -25,11 +104,11 @@ func NewOrderRepository(db *gorm.DB) OrderRepository {
 	return &orderRepository{db: db}
 }
 
-// CreateOrder saves a new Order and its associated OrderItems in a single transaction.
+// CreateOrder saves a new Order, its TaxLines and its associated OrderItems in a single transaction.
 func (r *orderRepository) CreateOrder(ctx context.Context, order *model.Order, items []model.OrderItem) error {
 	db := database.GetDBFromContext(ctx, r.db)
 	
-	// Create the order
+	// Create the order; GORM creates its tax lines with it
 	if err := db.Create(order).Error; err != nil {
 		return fmt.Errorf("failed to create order: %w", err)
 	}
@@ -42,4 +121,388 @@ func (r *orderRepository) CreateOrder(ctx context.Context, order *model.Order, i
 		}
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// GetByID returns an order with its items, their options, and tax lines.
+func (r *orderRepository) GetByID(ctx context.Context, id uint64) (*model.Order, error) {
+	var order model.Order
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Preload("Items.Options").Preload("TaxLines").First(&order, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get order '%d': %w", id, err)
+	}
+	return &order, nil
+}
+
+// GetByIDForUpdate locks with SELECT ... FOR UPDATE, so it must run in a
+// transaction. Items, tax lines and promotions are loaded without locks.
+func (r *orderRepository) GetByIDForUpdate(ctx context.Context, id uint64) (*model.Order, error) {
+	var order model.Order
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Preload("Items").Preload("TaxLines").Preload("Promotions").
+		First(&order, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to lock order '%d': %w", id, err)
+	}
+	return &order, nil
+}
+
+// GetByOrderNumber returns an order with its items and tax lines.
+func (r *orderRepository) GetByOrderNumber(ctx context.Context, orderNumber string) (*model.Order, error) {
+	var order model.Order
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Preload("Items").Preload("TaxLines").Where("order_number = ?", orderNumber).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get order '%s': %w", orderNumber, err)
+	}
+	return &order, nil
+}
+
+// GetByPaymentRef returns the order paid with a payment, without its items.
+func (r *orderRepository) GetByPaymentRef(ctx context.Context, provider, paymentRef string) (*model.Order, error) {
+	var order model.Order
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("payment_provider = ? AND payment_ref = ?", provider, paymentRef).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get order paid with %s payment '%s': %w", provider, paymentRef, err)
+	}
+	return &order, nil
+}
+
+// ListPendingBefore returns up to limit pending orders created before the given
+// time with IDs greater than afterID, in ID order and with their items and
+// promotions, so that callers can page through them with the last ID of each
+// batch.
+func (r *orderRepository) ListPendingBefore(ctx context.Context, before time.Time, afterID uint64, limit int) ([]model.Order, error) {
+	var orders []model.Order
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Preload("Items").Preload("Promotions").
+		Where("status = ? AND created_at < ? AND id > ?", model.OrderStatusPending, before, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&orders).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending orders: %w", err)
+	}
+	return orders, nil
+}
+
+// ListBackordered returns the paid orders waiting for stock, and the
+// authorized orders with items waiting for it, which ship only once it is
+// allocated.
+func (r *orderRepository) ListBackordered(ctx context.Context, afterID uint64, limit int) ([]model.Order, error) {
+	var orders []model.Order
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Preload("Items").
+		Where("id > ? AND (status = ? OR (status = ? AND EXISTS (SELECT 1 FROM order_items WHERE order_items.order_id = orders.id AND order_items.backordered AND order_items.deleted_at IS NULL)))",
+			afterID, model.OrderStatusBackordered, model.OrderStatusAuthorized).
+		Order("id").
+		Limit(limit).
+		Find(&orders).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backordered orders: %w", err)
+	}
+	return orders, nil
+}
+
+func (r *orderRepository) AllocateItems(ctx context.Context, orderID uint64) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Model(&model.OrderItem{}).
+		Where("order_id = ? AND backordered", orderID).
+		UpdateColumn("backordered", false).Error
+	if err != nil {
+		return fmt.Errorf("failed to allocate items of order '%d': %w", orderID, err)
+	}
+	return nil
+}
+
+// undeliveredExcluded are the deliveries of order items that are not sent to
+// the customer: shipped items, and bundles, whose components are sent instead.
+var undeliveredExcluded = []string{"", model.SKUDeliveryBundle}
+
+// ListUndelivered leaves out backordered orders: their digital items are
+// delivered along with the rest once the order is allocated and paid.
+func (r *orderRepository) ListUndelivered(ctx context.Context, afterID uint64, limit int) ([]model.Order, error) {
+	var orders []model.Order
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Preload("Items").
+		Where("id > ? AND status IN ? AND EXISTS (SELECT 1 FROM order_items WHERE order_items.order_id = orders.id AND order_items.delivery NOT IN ? AND order_items.delivered_at IS NULL AND order_items.deleted_at IS NULL)",
+			afterID, []string{model.OrderStatusPaid, model.OrderStatusCompleted}, undeliveredExcluded).
+		Order("id").
+		Limit(limit).
+		Find(&orders).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list undelivered orders: %w", err)
+	}
+	return orders, nil
+}
+
+func (r *orderRepository) MarkDelivered(ctx context.Context, orderID uint64, at time.Time) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Model(&model.OrderItem{}).
+		Where("order_id = ? AND delivery NOT IN ? AND delivered_at IS NULL", orderID, undeliveredExcluded).
+		UpdateColumn("delivered_at", at).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark items of order '%d' delivered: %w", orderID, err)
+	}
+	return nil
+}
+
+func (r *orderRepository) UpdateShippingAddress(ctx context.Context, orderID uint64, address model.Address) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	// A map, so that fields cleared in the new address are cleared too
+	result := db.Model(&model.Order{}).Where("id = ?", orderID).UpdateColumns(map[string]any{
+		"shipping_name":        address.Name,
+		"shipping_phone":       address.Phone,
+		"shipping_line1":       address.Line1,
+		"shipping_line2":       address.Line2,
+		"shipping_city":        address.City,
+		"shipping_postal_code": address.PostalCode,
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update shipping address of order '%d': %w", orderID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrOrderNotFound
+	}
+	return nil
+}
+
+// UpdateItemQuantity saves everything in one transaction.
+func (r *orderRepository) UpdateItemQuantity(ctx context.Context, order *model.Order, item *model.OrderItem) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.OrderItem{}).Where("id = ? AND order_id = ?", item.ID, order.ID).
+			UpdateColumns(map[string]any{"quantity": item.Quantity, "discount": item.Discount})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrOrderNotFound
+		}
+		for _, line := range order.TaxLines {
+			if err := tx.Model(&model.OrderTaxLine{}).Where("id = ?", line.ID).UpdateColumn("amount", line.Amount).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&model.Order{}).Where("id = ?", order.ID).Updates(map[string]any{
+			"total_amount":    order.TotalAmount,
+			"discount_amount": order.DiscountAmount,
+			"tax_amount":      order.TaxAmount,
+		}).Error
+	})
+	if errors.Is(err, ErrOrderNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update quantity of item '%d' of order '%d': %w", item.ID, order.ID, err)
+	}
+	return nil
+}
+
+func (r *orderRepository) SetFulfillment(ctx context.Context, orderID uint64, fulfillment string) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.Order{}).Where("id = ?", orderID).Update("fulfillment", fulfillment)
+	if result.Error != nil {
+		return fmt.Errorf("failed to set fulfillment of order '%d': %w", orderID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrOrderNotFound
+	}
+	return nil
+}
+
+// UpdateStatus moves an order from one status to another. It returns
+// ErrOrderStatusChanged if the order is not currently in the from status.
+func (r *orderRepository) UpdateStatus(ctx context.Context, orderID uint64, from, to string) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.Order{}).
+		Where("id = ? AND status = ?", orderID, from).
+		Update("status", to)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update status of order '%d': %w", orderID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrOrderStatusChanged
+	}
+	return nil
+}
+
+// MarkPaid returns ErrOrderStatusChanged if the order is no longer pending,
+// e.g. it was cancelled while being charged.
+func (r *orderRepository) MarkPaid(ctx context.Context, orderID uint64, provider, paymentRef string) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	status := gorm.Expr("CASE WHEN EXISTS (SELECT 1 FROM order_items WHERE order_items.order_id = orders.id AND order_items.backordered AND order_items.deleted_at IS NULL) THEN ? ELSE ? END",
+		model.OrderStatusBackordered, model.OrderStatusPaid)
+	result := db.Model(&model.Order{}).
+		Where("id = ? AND status = ?", orderID, model.OrderStatusPending).
+		Updates(map[string]any{"status": status, "payment_provider": provider, "payment_ref": paymentRef})
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark order '%d' paid: %w", orderID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrOrderStatusChanged
+	}
+	return nil
+}
+
+// SetPaymentIntent returns ErrOrderStatusChanged if the order is no longer
+// pending or already has a payment intent.
+func (r *orderRepository) SetPaymentIntent(ctx context.Context, orderID uint64, provider string, intent model.PaymentIntent) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.Order{}).
+		Where("id = ? AND status = ? AND payment_intent_id = ''", orderID, model.OrderStatusPending).
+		Updates(map[string]any{
+			"payment_provider":             provider,
+			"payment_intent_id":            intent.ID,
+			"payment_intent_qr_code":       intent.QRCode,
+			"payment_intent_client_secret": intent.ClientSecret,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to set payment intent of order '%d': %w", orderID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrOrderStatusChanged
+	}
+	return nil
+}
+
+// MarkAuthorized returns ErrOrderStatusChanged if the order is no longer
+// pending.
+func (r *orderRepository) MarkAuthorized(ctx context.Context, orderID uint64, provider, paymentRef string) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.Order{}).
+		Where("id = ? AND status = ?", orderID, model.OrderStatusPending).
+		Updates(map[string]any{"status": model.OrderStatusAuthorized, "payment_provider": provider, "payment_ref": paymentRef})
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark order '%d' authorized: %w", orderID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrOrderStatusChanged
+	}
+	return nil
+}
+
+// AddCapture returns ErrOrderStatusChanged if the order is no longer
+// authorized.
+func (r *orderRepository) AddCapture(ctx context.Context, orderID uint64, amount decimal.Decimal, final bool) error {
+	updates := map[string]any{"captured_amount": gorm.Expr("captured_amount + ?", amount)}
+	if final {
+		updates["status"] = model.OrderStatusPaid
+	}
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.Order{}).
+		Where("id = ? AND status = ?", orderID, model.OrderStatusAuthorized).
+		Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to record capture of order '%d': %w", orderID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrOrderStatusChanged
+	}
+	return nil
+}
+
+// ListSKUIDsChangedSince returns those of the given SKUs that appear in an order
+// created or updated at or after since, i.e. SKUs whose stock may still be
+// moving through the order pipeline.
+func (r *orderRepository) ListSKUIDsChangedSince(ctx context.Context, skuIDs []uint64, since time.Time) ([]uint64, error) {
+	if len(skuIDs) == 0 {
+		return nil, nil
+	}
+	var ids []uint64
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Model(&model.OrderItem{}).
+		Distinct().
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("order_items.sku_id IN ? AND orders.updated_at >= ?", skuIDs, since).
+		Pluck("order_items.sku_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SKUs with recent orders: %w", err)
+	}
+	return ids, nil
+}
+
+// SummarizeSince counts the orders created at or after since and totals their
+// amounts and tax, per status and currency. Statuses without orders are left out.
+func (r *orderRepository) SummarizeSince(ctx context.Context, since time.Time) ([]OrderStatusSummary, error) {
+	var summaries []OrderStatusSummary
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Model(&model.Order{}).
+		Select("status, currency, COUNT(*) AS orders, COALESCE(SUM(total_amount), 0) AS amount, COALESCE(SUM(tax_amount), 0) AS tax").
+		Where("created_at >= ?", since).
+		Group("status, currency").
+		Order("status, currency").
+		Scan(&summaries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize orders: %w", err)
+	}
+	return summaries, nil
+}
+
+// ListOrders retrieves orders matching filter. Amounts are compared as
+// numbers, regardless of the currency the orders are charged in.
+func (r *orderRepository) ListOrders(ctx context.Context, filter OrderFilter, offset, limit int) ([]model.Order, int64, error) {
+	query := filterOrders(database.GetDBFromContext(ctx, r.db).Model(&model.Order{}), filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	var orders []model.Order
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&orders).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list orders: %w", err)
+	}
+	return orders, total, nil
+}
+
+// CountByStatus counts orders matching filter, per status.
+func (r *orderRepository) CountByStatus(ctx context.Context, filter OrderFilter) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Orders int64
+	}
+	query := filterOrders(database.GetDBFromContext(ctx, r.db).Model(&model.Order{}), filter)
+	if err := query.Select("status, COUNT(*) AS orders").Group("status").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to count orders by status: %w", err)
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Orders
+	}
+	return counts, nil
+}
+
+// filterOrders narrows query to the orders matching filter.
+func filterOrders(query *gorm.DB, filter OrderFilter) *gorm.DB {
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if !filter.MinAmount.IsZero() {
+		query = query.Where("total_amount >= ?", filter.MinAmount)
+	}
+	if !filter.MaxAmount.IsZero() {
+		query = query.Where("total_amount <= ?", filter.MaxAmount)
+	}
+	return query
+}
@@ -0,0 +1,383 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/utils"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createOrderAt creates an order with one item for sku, backdated to createdAt.
+func createOrderAt(t *testing.T, repo repository.OrderRepository, userID, skuID uint64, status string, createdAt time.Time) *model.Order {
+	order := &model.Order{
+		UserID:      userID,
+		OrderNumber: utils.RandomString(20),
+		TotalAmount: decimal.NewFromInt(10),
+		Status:      status,
+	}
+	order.CreatedAt = createdAt
+	order.UpdatedAt = createdAt
+	items := []model.OrderItem{{SKUID: skuID, SnapshotName: "item", Price: decimal.NewFromInt(10), Quantity: 1}}
+	require.NoError(t, repo.CreateOrder(context.Background(), order, items))
+	return order
+}
+
+func TestCreateOrderWithTaxLines(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewOrderRepository(tx)
+
+	user := createRandomUser(t, repository.NewUserRepository(tx))
+	spu, err := createRandomSPU(ctx, repository.NewProductRepository(tx))
+	require.NoError(t, err)
+
+	order := &model.Order{
+		UserID:      user.ID,
+		OrderNumber: utils.RandomString(20),
+		TotalAmount: decimal.RequireFromString("11.90"),
+		TaxAmount:   decimal.RequireFromString("1.90"),
+		Region:      "DE",
+		Status:      model.OrderStatusPending,
+		TaxLines:    []model.OrderTaxLine{{Name: "VAT", Rate: decimal.RequireFromString("0.19"), Amount: decimal.RequireFromString("1.90")}},
+	}
+	items := []model.OrderItem{{SKUID: spu.SKUs[0].ID, SnapshotName: "item", Price: decimal.NewFromInt(10), Quantity: 1}}
+	require.NoError(t, repo.CreateOrder(ctx, order, items))
+
+	var lines []model.OrderTaxLine
+	require.NoError(t, tx.Where("order_id = ?", order.ID).Find(&lines).Error)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "VAT", lines[0].Name)
+	assert.True(t, decimal.RequireFromString("1.90").Equal(lines[0].Amount))
+}
+
+func TestListPendingBeforeAndUpdateStatus(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewOrderRepository(tx)
+
+	user := createRandomUser(t, repository.NewUserRepository(tx))
+	spu, err := createRandomSPU(ctx, repository.NewProductRepository(tx))
+	require.NoError(t, err)
+	skuID := spu.SKUs[0].ID
+
+	now := time.Now()
+	cutoff := now.Add(-30 * time.Minute)
+	expired1 := createOrderAt(t, repo, user.ID, skuID, model.OrderStatusPending, now.Add(-2*time.Hour))
+	expired2 := createOrderAt(t, repo, user.ID, skuID, model.OrderStatusPending, now.Add(-time.Hour))
+	createOrderAt(t, repo, user.ID, skuID, model.OrderStatusPending, now)                // Not yet expired
+	createOrderAt(t, repo, user.ID, skuID, model.OrderStatusPaid, now.Add(-2*time.Hour)) // Already paid
+
+	first, err := repo.ListPendingBefore(ctx, cutoff, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+	assert.Equal(t, expired1.ID, first[0].ID)
+	require.Len(t, first[0].Items, 1)
+	assert.Equal(t, skuID, first[0].Items[0].SKUID)
+
+	rest, err := repo.ListPendingBefore(ctx, cutoff, first[0].ID, 10)
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+	assert.Equal(t, expired2.ID, rest[0].ID)
+
+	require.NoError(t, repo.UpdateStatus(ctx, expired1.ID, model.OrderStatusPending, model.OrderStatusCancelled))
+	err = repo.UpdateStatus(ctx, expired1.ID, model.OrderStatusPending, model.OrderStatusCancelled)
+	assert.ErrorIs(t, err, repository.ErrOrderStatusChanged)
+
+	remaining, err := repo.ListPendingBefore(ctx, cutoff, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, expired2.ID, remaining[0].ID)
+
+	intent := model.PaymentIntent{ID: "pi_123", ClientSecret: "pi_123_secret_1"}
+	require.NoError(t, repo.SetPaymentIntent(ctx, expired2.ID, "stripe", intent))
+	err = repo.SetPaymentIntent(ctx, expired2.ID, "stripe", model.PaymentIntent{ID: "pi_456"})
+	assert.ErrorIs(t, err, repository.ErrOrderStatusChanged, "payment already started")
+	started, err := repo.GetByID(ctx, expired2.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "stripe", started.PaymentProvider)
+	assert.Equal(t, intent, started.PaymentIntent)
+
+	require.NoError(t, repo.MarkPaid(ctx, expired2.ID, "stripe", "pi_123"))
+	err = repo.MarkPaid(ctx, expired2.ID, "stripe", "pi_456")
+	assert.ErrorIs(t, err, repository.ErrOrderStatusChanged)
+	paid, err := repo.GetByID(ctx, expired2.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusPaid, paid.Status)
+	assert.Equal(t, "pi_123", paid.PaymentRef)
+
+	byNumber, err := repo.GetByOrderNumber(ctx, expired2.OrderNumber)
+	require.NoError(t, err)
+	assert.Equal(t, expired2.ID, byNumber.ID)
+	_, err = repo.GetByOrderNumber(ctx, "missing")
+	assert.ErrorIs(t, err, repository.ErrOrderNotFound)
+	_, err = repo.GetByID(ctx, 0)
+	assert.ErrorIs(t, err, repository.ErrOrderNotFound)
+}
+
+func TestListSKUIDsChangedSince(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewOrderRepository(tx)
+	productRepo := repository.NewProductRepository(tx)
+
+	user := createRandomUser(t, repository.NewUserRepository(tx))
+	var skuIDs []uint64
+	for range 3 {
+		spu, err := createRandomSPU(ctx, productRepo)
+		require.NoError(t, err)
+		skuIDs = append(skuIDs, spu.SKUs[0].ID)
+	}
+
+	now := time.Now()
+	createOrderAt(t, repo, user.ID, skuIDs[0], model.OrderStatusPending, now)
+	createOrderAt(t, repo, user.ID, skuIDs[0], model.OrderStatusPending, now) // Listed once
+	old := createOrderAt(t, repo, user.ID, skuIDs[1], model.OrderStatusPending, now.Add(-time.Hour))
+	createOrderAt(t, repo, user.ID, skuIDs[2], model.OrderStatusPaid, now.Add(-time.Hour))
+
+	since := now.Add(-time.Minute)
+	ids, err := repo.ListSKUIDsChangedSince(ctx, skuIDs, since)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint64{skuIDs[0]}, ids)
+
+	// Cancelling an old order touches it, so its SKU is listed too.
+	require.NoError(t, repo.UpdateStatus(ctx, old.ID, model.OrderStatusPending, model.OrderStatusCancelled))
+	ids, err = repo.ListSKUIDsChangedSince(ctx, skuIDs, since)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint64{skuIDs[0], skuIDs[1]}, ids)
+}
+
+func TestSummarizeSince(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewOrderRepository(tx)
+
+	user := createRandomUser(t, repository.NewUserRepository(tx))
+	spu, err := createRandomSPU(ctx, repository.NewProductRepository(tx))
+	require.NoError(t, err)
+	skuID := spu.SKUs[0].ID
+
+	// Far in the future so rows left by other tests are not counted
+	since := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+	createOrderAt(t, repo, user.ID, skuID, model.OrderStatusPaid, since)
+	createOrderAt(t, repo, user.ID, skuID, model.OrderStatusPaid, since.Add(time.Hour))
+	createOrderAt(t, repo, user.ID, skuID, model.OrderStatusPending, since.Add(time.Hour))
+	createOrderAt(t, repo, user.ID, skuID, model.OrderStatusPaid, since.Add(-time.Second)) // Before since
+	euroOrder := createOrderAt(t, repo, user.ID, skuID, model.OrderStatusPaid, since.Add(time.Hour))
+	require.NoError(t, tx.Model(euroOrder).Updates(map[string]any{"currency": "EUR", "tax_amount": decimal.RequireFromString("1.90")}).Error)
+
+	summaries, err := repo.SummarizeSince(ctx, since)
+	require.NoError(t, err)
+	require.Len(t, summaries, 3)
+	assert.Equal(t, model.OrderStatusPaid, summaries[0].Status)
+	assert.Equal(t, "EUR", summaries[0].Currency)
+	assert.Equal(t, int64(1), summaries[0].Orders)
+	assert.True(t, decimal.RequireFromString("1.90").Equal(summaries[0].Tax))
+	assert.Equal(t, model.OrderStatusPaid, summaries[1].Status)
+	assert.Equal(t, "USD", summaries[1].Currency)
+	assert.Equal(t, int64(2), summaries[1].Orders)
+	assert.True(t, decimal.NewFromInt(20).Equal(summaries[1].Amount))
+	assert.Equal(t, model.OrderStatusPending, summaries[2].Status)
+	assert.Equal(t, int64(1), summaries[2].Orders)
+}
+
+func TestListOrdersAndCountByStatus(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewOrderRepository(tx)
+
+	user := createRandomUser(t, repository.NewUserRepository(tx))
+	spu, err := createRandomSPU(ctx, repository.NewProductRepository(tx))
+	require.NoError(t, err)
+	skuID := spu.SKUs[0].ID
+
+	start := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+	oldest := createOrderAt(t, repo, user.ID, skuID, model.OrderStatusPaid, start)
+	middle := createOrderAt(t, repo, user.ID, skuID, model.OrderStatusPending, start.Add(time.Hour))
+	newest := createOrderAt(t, repo, user.ID, skuID, model.OrderStatusPaid, start.Add(2*time.Hour))
+	require.NoError(t, tx.Model(newest).Update("total_amount", decimal.NewFromInt(250)).Error)
+
+	orders, total, err := repo.ListOrders(ctx, repository.OrderFilter{UserID: user.ID}, 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, orders, 2)
+	assert.Equal(t, newest.ID, orders[0].ID, "newest first")
+	assert.Equal(t, middle.ID, orders[1].ID)
+
+	orders, total, err = repo.ListOrders(ctx, repository.OrderFilter{UserID: user.ID, Status: model.OrderStatusPaid, To: start.Add(2 * time.Hour)}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, orders, 1)
+	assert.Equal(t, oldest.ID, orders[0].ID)
+
+	orders, _, err = repo.ListOrders(ctx, repository.OrderFilter{UserID: user.ID, MinAmount: decimal.NewFromInt(100)}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, newest.ID, orders[0].ID)
+
+	orders, _, err = repo.ListOrders(ctx, repository.OrderFilter{UserID: user.ID, From: start.Add(time.Minute), MaxAmount: decimal.NewFromInt(100)}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, middle.ID, orders[0].ID)
+
+	counts, err := repo.CountByStatus(ctx, repository.OrderFilter{UserID: user.ID})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{model.OrderStatusPaid: 2, model.OrderStatusPending: 1}, counts)
+}
+
+func TestBackorderedOrders(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewOrderRepository(tx)
+
+	user := createRandomUser(t, repository.NewUserRepository(tx))
+	spu, err := createRandomSPU(ctx, repository.NewProductRepository(tx))
+	require.NoError(t, err)
+
+	order := &model.Order{UserID: user.ID, OrderNumber: utils.RandomString(20), TotalAmount: decimal.NewFromInt(20), Status: model.OrderStatusPending}
+	items := []model.OrderItem{
+		{SKUID: spu.SKUs[0].ID, SnapshotName: "in stock", Price: decimal.NewFromInt(10), Quantity: 1},
+		{SKUID: spu.SKUs[0].ID, SnapshotName: "pre-order", Price: decimal.NewFromInt(10), Quantity: 1, Backordered: true},
+	}
+	require.NoError(t, repo.CreateOrder(ctx, order, items))
+	createOrderAt(t, repo, user.ID, spu.SKUs[0].ID, model.OrderStatusPaid, time.Now()) // Nothing backordered
+
+	// Paying an order with backordered items leaves it waiting for stock
+	require.NoError(t, repo.MarkPaid(ctx, order.ID, "stripe", "pi_123"))
+	backordered, err := repo.ListBackordered(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, backordered, 1)
+	assert.Equal(t, order.ID, backordered[0].ID)
+	assert.Equal(t, model.OrderStatusBackordered, backordered[0].Status)
+	require.Len(t, backordered[0].Items, 2)
+
+	require.NoError(t, repo.AllocateItems(ctx, order.ID))
+	require.NoError(t, repo.UpdateStatus(ctx, order.ID, model.OrderStatusBackordered, model.OrderStatusPaid))
+	allocated, err := repo.GetByID(ctx, order.ID)
+	require.NoError(t, err)
+	for _, item := range allocated.Items {
+		assert.False(t, item.Backordered)
+	}
+	backordered, err = repo.ListBackordered(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, backordered)
+}
+
+func TestUndeliveredOrders(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewOrderRepository(tx)
+
+	user := createRandomUser(t, repository.NewUserRepository(tx))
+	spu, err := createRandomSPU(ctx, repository.NewProductRepository(tx))
+	require.NoError(t, err)
+	skuID := spu.SKUs[0].ID
+
+	createDigital := func(status string) *model.Order {
+		order := &model.Order{UserID: user.ID, OrderNumber: utils.RandomString(20), TotalAmount: decimal.NewFromInt(20), Status: status}
+		items := []model.OrderItem{
+			{SKUID: skuID, SnapshotName: "boxed", Price: decimal.NewFromInt(10), Quantity: 1},
+			{SKUID: skuID, SnapshotName: "key", Price: decimal.NewFromInt(10), Quantity: 1, Delivery: model.SKUDeliveryLicenseKey},
+		}
+		require.NoError(t, repo.CreateOrder(ctx, order, items))
+		return order
+	}
+	paid := createDigital(model.OrderStatusPaid)
+	createDigital(model.OrderStatusPending)
+	createOrderAt(t, repo, user.ID, skuID, model.OrderStatusPaid, time.Now()) // Nothing digital
+
+	undelivered, err := repo.ListUndelivered(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, undelivered, 1)
+	assert.Equal(t, paid.ID, undelivered[0].ID)
+	require.Len(t, undelivered[0].Items, 2)
+
+	require.NoError(t, repo.MarkDelivered(ctx, paid.ID, time.Now()))
+	delivered, err := repo.GetByID(ctx, paid.ID)
+	require.NoError(t, err)
+	for _, item := range delivered.Items {
+		assert.Equal(t, item.Delivery != "", item.DeliveredAt != nil, item.SnapshotName)
+	}
+	undelivered, err = repo.ListUndelivered(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, undelivered)
+}
+
+func TestUpdateItemQuantity(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewOrderRepository(tx)
+
+	user := createRandomUser(t, repository.NewUserRepository(tx))
+	spu, err := createRandomSPU(ctx, repository.NewProductRepository(tx))
+	require.NoError(t, err)
+
+	order := &model.Order{
+		UserID:      user.ID,
+		OrderNumber: utils.RandomString(20),
+		TotalAmount: decimal.RequireFromString("23.80"),
+		TaxAmount:   decimal.RequireFromString("3.80"),
+		Region:      "DE",
+		Status:      model.OrderStatusPaid,
+		TaxLines:    []model.OrderTaxLine{{Name: "VAT", Rate: decimal.RequireFromString("0.19"), Amount: decimal.RequireFromString("3.80")}},
+	}
+	items := []model.OrderItem{{SKUID: spu.SKUs[0].ID, SnapshotName: "item", Price: decimal.NewFromInt(10), Quantity: 2}}
+	require.NoError(t, repo.CreateOrder(ctx, order, items))
+
+	item := items[0]
+	item.Quantity = 1
+	order.TotalAmount = decimal.RequireFromString("11.90")
+	order.TaxAmount = decimal.RequireFromString("1.90")
+	order.TaxLines[0].Amount = decimal.RequireFromString("1.90")
+	require.NoError(t, repo.UpdateItemQuantity(ctx, order, &item))
+
+	var gotItem model.OrderItem
+	require.NoError(t, tx.First(&gotItem, item.ID).Error)
+	assert.Equal(t, 1, gotItem.Quantity)
+	var gotOrder model.Order
+	require.NoError(t, tx.Preload("TaxLines").First(&gotOrder, order.ID).Error)
+	assert.True(t, decimal.RequireFromString("11.90").Equal(gotOrder.TotalAmount))
+	assert.True(t, decimal.RequireFromString("1.90").Equal(gotOrder.TaxAmount))
+	require.Len(t, gotOrder.TaxLines, 1)
+	assert.True(t, decimal.RequireFromString("1.90").Equal(gotOrder.TaxLines[0].Amount))
+
+	item.ID = 0
+	assert.ErrorIs(t, repo.UpdateItemQuantity(ctx, order, &item), repository.ErrOrderNotFound)
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/order_summary_repo_mock.go -package=mocks
+// OrderSummaryRepository stores the order list read model.
+type OrderSummaryRepository interface {
+	// Save creates or replaces the summary of summary.OrderID, unless the
+	// one stored was built from a newer version of the order.
+	Save(ctx context.Context, summary *model.OrderSummary) error
+	// ListByUser returns the summaries of a user's orders, newest first, and
+	// how many there are.
+	ListByUser(ctx context.Context, userID uint64, offset, limit int) ([]model.OrderSummary, int64, error)
+	// ListMissing returns the IDs of up to limit orders without a summary, in
+	// ID order.
+	ListMissing(ctx context.Context, limit int) ([]uint64, error)
+}
+
+// orderSummaryRepository implements OrderSummaryRepository using GORM.
+type orderSummaryRepository struct {
+	db *gorm.DB
+}
+
+// NewOrderSummaryRepository creates a new OrderSummaryRepository instance.
+func NewOrderSummaryRepository(db *gorm.DB) OrderSummaryRepository {
+	return &orderSummaryRepository{db: db}
+}
+
+func (r *orderSummaryRepository) Save(ctx context.Context, summary *model.OrderSummary) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "order_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"status", "total_amount", "currency", "item_count", "items", "order_updated_at",
+		}),
+		// Events may be handled out of order; the newest order wins.
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "order_summaries.order_updated_at <= excluded.order_updated_at"},
+		}},
+	}).Create(summary).Error
+	if err != nil {
+		return fmt.Errorf("failed to save summary of order '%d': %w", summary.OrderID, err)
+	}
+	return nil
+}
+
+func (r *orderSummaryRepository) ListByUser(ctx context.Context, userID uint64, offset, limit int) ([]model.OrderSummary, int64, error) {
+	query := database.GetDBFromContext(ctx, r.db).Model(&model.OrderSummary{}).Where("user_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count order summaries: %w", err)
+	}
+
+	var summaries []model.OrderSummary
+	if err := query.Order("ordered_at DESC, order_id DESC").Offset(offset).Limit(limit).Find(&summaries).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list order summaries: %w", err)
+	}
+	return summaries, total, nil
+}
+
+func (r *orderSummaryRepository) ListMissing(ctx context.Context, limit int) ([]uint64, error) {
+	var ids []uint64
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Model(&model.Order{}).
+		Where("NOT EXISTS (SELECT 1 FROM order_summaries WHERE order_summaries.order_id = orders.id)").
+		Order("orders.id").
+		Limit(limit).
+		Pluck("orders.id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders without a summary: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,75 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderSummaryRepository(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewOrderSummaryRepository(tx)
+	orderRepo := repository.NewOrderRepository(tx)
+
+	user := createRandomUser(t, repository.NewUserRepository(tx))
+	spu, err := createRandomSPU(ctx, repository.NewProductRepository(tx))
+	require.NoError(t, err)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	older := createOrderAt(t, orderRepo, user.ID, spu.SKUs[0].ID, model.OrderStatusPaid, now.Add(-time.Hour))
+	newer := createOrderAt(t, orderRepo, user.ID, spu.SKUs[0].ID, model.OrderStatusPending, now)
+
+	missing, err := repo.ListMissing(ctx, 1000)
+	require.NoError(t, err)
+	assert.Contains(t, missing, older.ID)
+	assert.Contains(t, missing, newer.ID)
+
+	summary := func(order *model.Order, status string, updatedAt time.Time) *model.OrderSummary {
+		return &model.OrderSummary{
+			OrderID:        order.ID,
+			UserID:         user.ID,
+			OrderNumber:    order.OrderNumber,
+			Status:         status,
+			TotalAmount:    decimal.NewFromInt(10),
+			ItemCount:      1,
+			Items:          []model.OrderSummaryItem{{SKUID: spu.SKUs[0].ID, Name: "item", Quantity: 1, Price: decimal.NewFromInt(10)}},
+			OrderedAt:      order.CreatedAt,
+			OrderUpdatedAt: updatedAt,
+		}
+	}
+	require.NoError(t, repo.Save(ctx, summary(older, model.OrderStatusPaid, now.Add(-time.Hour))))
+	require.NoError(t, repo.Save(ctx, summary(newer, model.OrderStatusPending, now)))
+	// A newer version replaces the summary, an older one is ignored.
+	require.NoError(t, repo.Save(ctx, summary(newer, model.OrderStatusPaid, now.Add(time.Minute))))
+	require.NoError(t, repo.Save(ctx, summary(newer, model.OrderStatusPending, now)))
+
+	missing, err = repo.ListMissing(ctx, 1000)
+	require.NoError(t, err)
+	assert.NotContains(t, missing, older.ID)
+	assert.NotContains(t, missing, newer.ID)
+
+	summaries, total, err := repo.ListByUser(ctx, user.ID, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, summaries, 2)
+	assert.Equal(t, newer.ID, summaries[0].OrderID, "newest first")
+	assert.Equal(t, model.OrderStatusPaid, summaries[0].Status)
+	assert.Equal(t, "item", summaries[0].Items[0].Name)
+	assert.Equal(t, older.ID, summaries[1].OrderID)
+
+	summaries, total, err = repo.ListByUser(ctx, user.ID, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, older.ID, summaries[0].OrderID)
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+// OutboxFilter narrows an outbox query. Zero values match everything.
+type OutboxFilter struct {
+	Types       []string
+	AggregateID uint64
+	From        time.Time // Inclusive
+	To          time.Time // Exclusive
+}
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/outbox_repo_mock.go -package=mocks
+// OutboxRepository stores the domain events waiting to be published.
+type OutboxRepository interface {
+	Create(ctx context.Context, event *model.OutboxEvent) error
+	// ListUnpublished returns up to limit events not published yet, oldest first.
+	ListUnpublished(ctx context.Context, limit int) ([]model.OutboxEvent, error)
+	MarkPublished(ctx context.Context, ids []uint64, at time.Time) error
+	// List returns up to limit matching events, published or not, with an ID
+	// greater than afterID, in ID order.
+	List(ctx context.Context, filter OutboxFilter, afterID uint64, limit int) ([]model.OutboxEvent, error)
+	// DeletePublishedBefore removes the events published before the given
+	// time and returns how many there were.
+	DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// outboxRepository implements OutboxRepository using GORM.
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository creates a new OutboxRepository instance.
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// Create saves a new event to the outbox.
+func (r *outboxRepository) Create(ctx context.Context, event *model.OutboxEvent) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(event).Error; err != nil {
+		return fmt.Errorf("failed to create outbox event: %w", err)
+	}
+	return nil
+}
+
+func (r *outboxRepository) ListUnpublished(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+	var events []model.OutboxEvent
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Where("published_at IS NULL").
+		Order("id").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unpublished outbox events: %w", err)
+	}
+	return events, nil
+}
+
+func (r *outboxRepository) MarkPublished(ctx context.Context, ids []uint64, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Model(&model.OutboxEvent{}).Where("id IN ?", ids).Update("published_at", at).Error; err != nil {
+		return fmt.Errorf("failed to mark outbox events published: %w", err)
+	}
+	return nil
+}
+
+func (r *outboxRepository) List(ctx context.Context, filter OutboxFilter, afterID uint64, limit int) ([]model.OutboxEvent, error) {
+	query := database.GetDBFromContext(ctx, r.db).Where("id > ?", afterID)
+	if len(filter.Types) > 0 {
+		query = query.Where("type IN ?", filter.Types)
+	}
+	if filter.AggregateID != 0 {
+		query = query.Where("aggregate_id = ?", filter.AggregateID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+
+	var events []model.OutboxEvent
+	if err := query.Order("id").Limit(limit).Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to list outbox events: %w", err)
+	}
+	return events, nil
+}
+
+func (r *outboxRepository) DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Unscoped().Where("published_at < ?", before).Delete(&model.OutboxEvent{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete published outbox events: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
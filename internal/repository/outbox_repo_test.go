@@ -0,0 +1,70 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxRepository(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewOutboxRepository(tx)
+
+	// Events recorded before this test would come first.
+	require.NoError(t, tx.Model(&model.OutboxEvent{}).Where("published_at IS NULL").Update("published_at", time.Now()).Error)
+
+	events := make([]*model.OutboxEvent, 3)
+	for i := range events {
+		events[i] = &model.OutboxEvent{EventID: uuid.NewString(), Type: "order.paid", AggregateID: uint64(i % 2), Payload: `{}`}
+		require.NoError(t, repo.Create(ctx, events[i]))
+	}
+
+	got, err := repo.ListUnpublished(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, events[0].EventID, got[0].EventID)
+	assert.Equal(t, events[1].EventID, got[1].EventID)
+
+	published := time.Now().Add(-time.Hour)
+	require.NoError(t, repo.MarkPublished(ctx, []uint64{events[0].ID, events[1].ID}, published))
+	require.NoError(t, repo.MarkPublished(ctx, nil, published))
+	got, err = repo.ListUnpublished(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, events[2].EventID, got[0].EventID)
+
+	// Published events can still be listed for replaying.
+	start := events[0].CreatedAt
+	listed, err := repo.List(ctx, repository.OutboxFilter{Types: []string{"order.paid"}, From: start}, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, listed, 3)
+	listed, err = repo.List(ctx, repository.OutboxFilter{AggregateID: 1, From: start}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, events[1].EventID, listed[0].EventID)
+	listed, err = repo.List(ctx, repository.OutboxFilter{From: start}, events[1].ID, 10)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, events[2].EventID, listed[0].EventID)
+	listed, err = repo.List(ctx, repository.OutboxFilter{Types: []string{"user.registered"}, From: start}, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, listed)
+
+	purged, err := repo.DeletePublishedBefore(ctx, published.Add(time.Minute))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, purged, int64(2))
+	got, err = repo.ListUnpublished(ctx, 10)
+	require.NoError(t, err)
+	assert.Len(t, got, 1, "unpublished events are kept")
+}
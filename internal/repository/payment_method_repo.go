@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+// ErrPaymentMethodNotFound is returned when a payment method does not exist
+// or belongs to another user.
+var ErrPaymentMethodNotFound = errors.New("payment method not found")
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/payment_method_repo_mock.go -package=mocks
+// PaymentMethodRepository defines the interface for saved payment method data operations.
+type PaymentMethodRepository interface {
+	Create(ctx context.Context, method *model.PaymentMethod) error
+	ListByUser(ctx context.Context, userID uint64) ([]model.PaymentMethod, error)
+	GetByID(ctx context.Context, userID, id uint64) (*model.PaymentMethod, error)
+	// GetCustomerRef returns the user's customer ID at provider, or "" if they
+	// have not saved a method with it yet.
+	GetCustomerRef(ctx context.Context, userID uint64, provider string) (string, error)
+	Delete(ctx context.Context, userID, id uint64) error
+}
+
+// paymentMethodRepository implements PaymentMethodRepository using GORM.
+type paymentMethodRepository struct {
+	db *gorm.DB
+}
+
+// NewPaymentMethodRepository creates a new PaymentMethodRepository instance.
+func NewPaymentMethodRepository(db *gorm.DB) PaymentMethodRepository {
+	return &paymentMethodRepository{db: db}
+}
+
+// Create saves a new payment method to the database.
+func (r *paymentMethodRepository) Create(ctx context.Context, method *model.PaymentMethod) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(method).Error; err != nil {
+		return fmt.Errorf("failed to save payment method of user '%d': %w", method.UserID, err)
+	}
+	return nil
+}
+
+// ListByUser retrieves the payment methods of a user, most recently saved first.
+func (r *paymentMethodRepository) ListByUser(ctx context.Context, userID uint64) ([]model.PaymentMethod, error) {
+	var methods []model.PaymentMethod
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("user_id = ?", userID).Order("id DESC").Find(&methods).Error; err != nil {
+		return nil, fmt.Errorf("failed to list payment methods of user '%d': %w", userID, err)
+	}
+	return methods, nil
+}
+
+// GetByID retrieves one of the user's payment methods.
+func (r *paymentMethodRepository) GetByID(ctx context.Context, userID, id uint64) (*model.PaymentMethod, error) {
+	var method model.PaymentMethod
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("id = ? AND user_id = ?", id, userID).First(&method).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentMethodNotFound
+		}
+		return nil, fmt.Errorf("failed to get payment method '%d': %w", id, err)
+	}
+	return &method, nil
+}
+
+// GetCustomerRef reads the customer of the user's latest method with
+// provider. Deleted methods count too, so a user who removed every card keeps
+// their customer at the provider.
+func (r *paymentMethodRepository) GetCustomerRef(ctx context.Context, userID uint64, provider string) (string, error) {
+	var refs []string
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Unscoped().Model(&model.PaymentMethod{}).
+		Where("user_id = ? AND provider = ?", userID, provider).
+		Order("id DESC").Limit(1).
+		Pluck("customer_ref", &refs).Error
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s customer of user '%d': %w", provider, userID, err)
+	}
+	if len(refs) == 0 {
+		return "", nil
+	}
+	return refs[0], nil
+}
+
+// Delete removes one of the user's payment methods. Methods are deleted with
+// a soft delete, so GetCustomerRef still finds their customer.
+func (r *paymentMethodRepository) Delete(ctx context.Context, userID, id uint64) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Where("id = ? AND user_id = ?", id, userID).Delete(&model.PaymentMethod{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete payment method '%d': %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrPaymentMethodNotFound
+	}
+	return nil
+}
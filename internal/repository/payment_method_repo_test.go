@@ -0,0 +1,56 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentMethods(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewPaymentMethodRepository(tx)
+	users := repository.NewUserRepository(tx)
+	owner, other := createRandomUser(t, users), createRandomUser(t, users)
+
+	ref, err := repo.GetCustomerRef(ctx, owner.ID, "stripe")
+	require.NoError(t, err)
+	assert.Empty(t, ref, "no customer before the first method")
+
+	first := &model.PaymentMethod{UserID: owner.ID, Provider: "stripe", CustomerRef: "cus_1", MethodRef: "pm_" + utils.RandomString(8), Brand: "visa", Last4: "4242", ExpMonth: 12, ExpYear: 2030}
+	second := &model.PaymentMethod{UserID: owner.ID, Provider: "stripe", CustomerRef: "cus_1", MethodRef: "pm_" + utils.RandomString(8), Brand: "mastercard", Last4: "4444"}
+	require.NoError(t, repo.Create(ctx, first))
+	require.NoError(t, repo.Create(ctx, second))
+
+	methods, err := repo.ListByUser(ctx, owner.ID)
+	require.NoError(t, err)
+	require.Len(t, methods, 2)
+	assert.Equal(t, second.ID, methods[0].ID, "most recent first")
+
+	got, err := repo.GetByID(ctx, owner.ID, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, first.MethodRef, got.MethodRef)
+	_, err = repo.GetByID(ctx, other.ID, first.ID)
+	assert.ErrorIs(t, err, repository.ErrPaymentMethodNotFound)
+
+	assert.ErrorIs(t, repo.Delete(ctx, other.ID, first.ID), repository.ErrPaymentMethodNotFound)
+	require.NoError(t, repo.Delete(ctx, owner.ID, first.ID))
+	require.NoError(t, repo.Delete(ctx, owner.ID, second.ID))
+	assert.ErrorIs(t, repo.Delete(ctx, owner.ID, second.ID), repository.ErrPaymentMethodNotFound)
+
+	methods, err = repo.ListByUser(ctx, owner.ID)
+	require.NoError(t, err)
+	assert.Empty(t, methods)
+	ref, err = repo.GetCustomerRef(ctx, owner.ID, "stripe")
+	require.NoError(t, err)
+	assert.Equal(t, "cus_1", ref, "the customer outlives its methods")
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPickTaskNotFound is returned when a pick task does not exist.
+	ErrPickTaskNotFound = errors.New("pick task not found")
+	// ErrPickTaskChanged is returned when a pick task is no longer in the
+	// status a transition expects, e.g. someone else claimed it first.
+	ErrPickTaskChanged = errors.New("pick task changed")
+)
+
+// PickTaskFilter narrows a pick task query. Zero values match everything.
+type PickTaskFilter struct {
+	Warehouse  string
+	Zone       string
+	Status     string
+	AssigneeID uint64
+	OrderID    uint64
+}
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/pick_task_repo_mock.go -package=mocks
+// PickTaskRepository defines the interface for pick task data operations.
+type PickTaskRepository interface {
+	// Create saves new pick tasks with their items. It fails if an order
+	// item is on a task already.
+	Create(ctx context.Context, tasks []model.PickTask) error
+	GetByID(ctx context.Context, id uint64) (*model.PickTask, error)
+	// ListByOrder returns the pick tasks of an order with their items,
+	// oldest first.
+	ListByOrder(ctx context.Context, orderID uint64) ([]model.PickTask, error)
+	// List returns the matching pick tasks with their items, oldest first.
+	List(ctx context.Context, filter PickTaskFilter, offset, limit int) ([]model.PickTask, error)
+	// Claim assigns an open pick task to assigneeID. It returns
+	// ErrPickTaskChanged if the task is not open.
+	Claim(ctx context.Context, id, assigneeID uint64, at time.Time) error
+	// MarkPacked moves a pick task claimed by assigneeID to packed. It
+	// returns ErrPickTaskChanged if the task is not claimed by them.
+	MarkPacked(ctx context.Context, id, assigneeID uint64, at time.Time) error
+	// SetShipment records the shipment pick tasks went out with.
+	SetShipment(ctx context.Context, ids []uint64, shipmentID uint64) error
+	// SetItemQuantity changes the quantity to pick of an order item. It
+	// returns ErrPickTaskChanged if the item's task is not open.
+	SetItemQuantity(ctx context.Context, orderItemID uint64, quantity int) error
+}
+
+// pickTaskRepository implements PickTaskRepository using GORM.
+type pickTaskRepository struct {
+	db *gorm.DB
+}
+
+// NewPickTaskRepository creates a new PickTaskRepository instance.
+func NewPickTaskRepository(db *gorm.DB) PickTaskRepository {
+	return &pickTaskRepository{db: db}
+}
+
+// Create saves the tasks in one transaction, their items included.
+func (r *pickTaskRepository) Create(ctx context.Context, tasks []model.PickTask) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Transaction(func(tx *gorm.DB) error { return tx.Create(&tasks).Error }); err != nil {
+		return fmt.Errorf("failed to create pick tasks of order '%d': %w", tasks[0].OrderID, err)
+	}
+	return nil
+}
+
+// GetByID retrieves a pick task with its items.
+func (r *pickTaskRepository) GetByID(ctx context.Context, id uint64) (*model.PickTask, error) {
+	var task model.PickTask
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Preload("Items", orderPickTaskItems).First(&task, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPickTaskNotFound
+		}
+		return nil, fmt.Errorf("failed to get pick task '%d': %w", id, err)
+	}
+	return &task, nil
+}
+
+func (r *pickTaskRepository) ListByOrder(ctx context.Context, orderID uint64) ([]model.PickTask, error) {
+	var tasks []model.PickTask
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Preload("Items", orderPickTaskItems).
+		Where("order_id = ?", orderID).
+		Order("created_at, id").
+		Find(&tasks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pick tasks of order '%d': %w", orderID, err)
+	}
+	return tasks, nil
+}
+
+func (r *pickTaskRepository) List(ctx context.Context, filter PickTaskFilter, offset, limit int) ([]model.PickTask, error) {
+	var tasks []model.PickTask
+	db := database.GetDBFromContext(ctx, r.db).Preload("Items", orderPickTaskItems)
+	if filter.Warehouse != "" {
+		db = db.Where("warehouse = ?", filter.Warehouse)
+	}
+	if filter.Zone != "" {
+		db = db.Where("zone = ?", filter.Zone)
+	}
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	if filter.AssigneeID != 0 {
+		db = db.Where("assignee_id = ?", filter.AssigneeID)
+	}
+	if filter.OrderID != 0 {
+		db = db.Where("order_id = ?", filter.OrderID)
+	}
+	if err := db.Order("created_at, id").Offset(offset).Limit(limit).Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to list pick tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+func (r *pickTaskRepository) Claim(ctx context.Context, id, assigneeID uint64, at time.Time) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.PickTask{}).
+		Where("id = ? AND status = ?", id, model.PickTaskStatusOpen).
+		Updates(map[string]any{"status": model.PickTaskStatusClaimed, "assignee_id": assigneeID, "claimed_at": at})
+	if result.Error != nil {
+		return fmt.Errorf("failed to claim pick task '%d': %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrPickTaskChanged
+	}
+	return nil
+}
+
+func (r *pickTaskRepository) MarkPacked(ctx context.Context, id, assigneeID uint64, at time.Time) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.PickTask{}).
+		Where("id = ? AND status = ? AND assignee_id = ?", id, model.PickTaskStatusClaimed, assigneeID).
+		Updates(map[string]any{"status": model.PickTaskStatusPacked, "packed_at": at})
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark pick task '%d' packed: %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrPickTaskChanged
+	}
+	return nil
+}
+
+func (r *pickTaskRepository) SetShipment(ctx context.Context, ids []uint64, shipmentID uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Model(&model.PickTask{}).Where("id IN ?", ids).Update("shipment_id", shipmentID).Error; err != nil {
+		return fmt.Errorf("failed to record shipment '%d' of pick tasks: %w", shipmentID, err)
+	}
+	return nil
+}
+
+func (r *pickTaskRepository) SetItemQuantity(ctx context.Context, orderItemID uint64, quantity int) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.PickTaskItem{}).
+		Where("order_item_id = ? AND pick_task_id IN (?)", orderItemID,
+			db.Model(&model.PickTask{}).Select("id").Where("status = ?", model.PickTaskStatusOpen)).
+		Update("quantity", quantity)
+	if result.Error != nil {
+		return fmt.Errorf("failed to set pick quantity of order item '%d': %w", orderItemID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrPickTaskChanged
+	}
+	return nil
+}
+
+// orderPickTaskItems lists a pick task's items in the order they were added.
+func orderPickTaskItems(db *gorm.DB) *gorm.DB {
+	return db.Order("id")
+}
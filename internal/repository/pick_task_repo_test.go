@@ -0,0 +1,62 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestPickTasks(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewPickTaskRepository(tx)
+
+	tasks := []model.PickTask{
+		{OrderID: 42, Zone: "A", Status: model.PickTaskStatusOpen, Items: []model.PickTaskItem{{OrderItemID: 900001, SKUID: 1, Quantity: 2}}},
+		{OrderID: 42, Zone: "B", Status: model.PickTaskStatusOpen, Items: []model.PickTaskItem{{OrderItemID: 900002, SKUID: 2, Quantity: 1}}},
+	}
+	require.NoError(t, repo.Create(ctx, tasks))
+
+	// An order item is on one task only
+	again := []model.PickTask{{OrderID: 42, Zone: "A", Status: model.PickTaskStatusOpen, Items: []model.PickTaskItem{{OrderItemID: 900001, SKUID: 1, Quantity: 2}}}}
+	assert.Error(t, tx.Transaction(func(inner *gorm.DB) error { return repository.NewPickTaskRepository(inner).Create(ctx, again) }))
+
+	// The first to claim a task gets it; only they can pack it
+	now := time.Now()
+	require.NoError(t, repo.Claim(ctx, tasks[0].ID, 7, now))
+	assert.ErrorIs(t, repo.Claim(ctx, tasks[0].ID, 8, now), repository.ErrPickTaskChanged)
+	assert.ErrorIs(t, repo.MarkPacked(ctx, tasks[0].ID, 8, now), repository.ErrPickTaskChanged)
+	require.NoError(t, repo.MarkPacked(ctx, tasks[0].ID, 7, now))
+
+	open, err := repo.List(ctx, repository.PickTaskFilter{OrderID: 42, Status: model.PickTaskStatusOpen}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	assert.Equal(t, "B", open[0].Zone)
+
+	// Only items of open tasks can change
+	require.NoError(t, repo.SetItemQuantity(ctx, 900002, 3))
+	assert.ErrorIs(t, repo.SetItemQuantity(ctx, 900001, 1), repository.ErrPickTaskChanged)
+
+	require.NoError(t, repo.SetShipment(ctx, []uint64{tasks[0].ID}, 500))
+	byOrder, err := repo.ListByOrder(ctx, 42)
+	require.NoError(t, err)
+	require.Len(t, byOrder, 2)
+	assert.Equal(t, model.PickTaskStatusPacked, byOrder[0].Status)
+	assert.Equal(t, uint64(500), byOrder[0].ShipmentID)
+	require.Len(t, byOrder[0].Items, 1)
+	require.Len(t, byOrder[1].Items, 1)
+	assert.Equal(t, 3, byOrder[1].Items[0].Quantity)
+
+	_, err = repo.GetByID(ctx, 1)
+	assert.ErrorIs(t, err, repository.ErrPickTaskNotFound)
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPriceScheduleNotFound is returned when a price schedule does not exist.
+	ErrPriceScheduleNotFound = errors.New("price schedule not found")
+	// ErrPriceScheduleChanged is returned when a price schedule no longer has
+	// the status it was expected to have, e.g. it was cancelled meanwhile.
+	ErrPriceScheduleChanged = errors.New("price schedule changed")
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/price_schedule_repo_mock.go -package=mocks
+// PriceScheduleRepository defines the interface for scheduled price change data operations.
+type PriceScheduleRepository interface {
+	Create(ctx context.Context, schedule *model.PriceSchedule) error
+	GetByID(ctx context.Context, id uint64) (*model.PriceSchedule, error)
+	// ListBySKU returns every schedule of a SKU, in the order they start.
+	ListBySKU(ctx context.Context, skuID uint64) ([]model.PriceSchedule, error)
+	// HasOverlappingSale reports whether a pending or active sale of the SKU
+	// runs at any time between startsAt and endsAt.
+	HasOverlappingSale(ctx context.Context, skuID uint64, startsAt, endsAt time.Time) (bool, error)
+	// ListDue returns up to limit schedules with a boundary at or before now:
+	// pending ones that start and active sales that end. They come in the
+	// order of those boundaries, so that a sale ends before the next one of
+	// its SKU starts. It spans all stores.
+	ListDue(ctx context.Context, now time.Time, limit int) ([]model.PriceSchedule, error)
+	// UpdateStatus moves a schedule from status from to status to. It returns
+	// ErrPriceScheduleChanged if the schedule does not have status from.
+	UpdateStatus(ctx context.Context, id uint64, from, to string) error
+}
+
+// priceScheduleRepository implements PriceScheduleRepository using GORM.
+type priceScheduleRepository struct {
+	db *gorm.DB
+}
+
+// NewPriceScheduleRepository creates a new PriceScheduleRepository instance.
+func NewPriceScheduleRepository(db *gorm.DB) PriceScheduleRepository {
+	return &priceScheduleRepository{db: db}
+}
+
+func (r *priceScheduleRepository) Create(ctx context.Context, schedule *model.PriceSchedule) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(schedule).Error; err != nil {
+		return fmt.Errorf("failed to create price schedule of SKU '%d': %w", schedule.SKUID, err)
+	}
+	return nil
+}
+
+func (r *priceScheduleRepository) GetByID(ctx context.Context, id uint64) (*model.PriceSchedule, error) {
+	var schedule model.PriceSchedule
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.First(&schedule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPriceScheduleNotFound
+		}
+		return nil, fmt.Errorf("failed to get price schedule '%d': %w", id, err)
+	}
+	return &schedule, nil
+}
+
+func (r *priceScheduleRepository) ListBySKU(ctx context.Context, skuID uint64) ([]model.PriceSchedule, error) {
+	var schedules []model.PriceSchedule
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("sku_id = ?", skuID).Order("starts_at, id").Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to list price schedules of SKU '%d': %w", skuID, err)
+	}
+	return schedules, nil
+}
+
+func (r *priceScheduleRepository) HasOverlappingSale(ctx context.Context, skuID uint64, startsAt, endsAt time.Time) (bool, error) {
+	var count int64
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Model(&model.PriceSchedule{}).
+		Where("sku_id = ? AND status IN ? AND ends_at IS NOT NULL", skuID, []string{model.PriceSchedulePending, model.PriceScheduleActive}).
+		Where("starts_at < ? AND ends_at > ?", endsAt, startsAt).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check sales of SKU '%d': %w", skuID, err)
+	}
+	return count > 0, nil
+}
+
+func (r *priceScheduleRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]model.PriceSchedule, error) {
+	var schedules []model.PriceSchedule
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Where("(status = ? AND starts_at <= ?) OR (status = ? AND ends_at <= ?)",
+		model.PriceSchedulePending, now, model.PriceScheduleActive, now).
+		Order(gorm.Expr("CASE WHEN status = ? THEN ends_at ELSE starts_at END, id", model.PriceScheduleActive)).
+		Limit(limit).
+		Find(&schedules).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due price schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+func (r *priceScheduleRepository) UpdateStatus(ctx context.Context, id uint64, from, to string) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.PriceSchedule{}).Where("id = ? AND status = ?", id, from).Update("status", to)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update status of price schedule '%d': %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrPriceScheduleChanged
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceSchedules(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	spu, err := createRandomSPU(ctx, repository.NewProductRepository(tx))
+	require.NoError(t, err)
+	skuID := spu.SKUs[0].ID
+	repo := repository.NewPriceScheduleRepository(tx)
+
+	now := time.Now().Truncate(time.Second)
+	at := func(d time.Duration) *time.Time { t := now.Add(d); return &t }
+	change := &model.PriceSchedule{SKUID: skuID, Price: decimal.NewFromInt(20), StartsAt: now.Add(-time.Minute), Status: model.PriceSchedulePending}
+	endingSale := &model.PriceSchedule{SKUID: skuID, Price: decimal.NewFromInt(8), StartsAt: now.Add(-2 * time.Hour), EndsAt: at(-time.Hour), Status: model.PriceScheduleActive}
+	nextSale := &model.PriceSchedule{SKUID: skuID, Price: decimal.NewFromInt(9), StartsAt: now.Add(-30 * time.Minute), EndsAt: at(time.Hour), Status: model.PriceSchedulePending}
+	future := &model.PriceSchedule{SKUID: skuID, Price: decimal.NewFromInt(7), StartsAt: now.Add(2 * time.Hour), EndsAt: at(3 * time.Hour), Status: model.PriceSchedulePending}
+	for _, schedule := range []*model.PriceSchedule{change, endingSale, nextSale, future} {
+		require.NoError(t, repo.Create(ctx, schedule))
+	}
+
+	due, err := repo.ListDue(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 3)
+	// The sale that ended comes before the one that starts after it
+	assert.Equal(t, []uint64{endingSale.ID, nextSale.ID, change.ID}, []uint64{due[0].ID, due[1].ID, due[2].ID})
+
+	overlaps, err := repo.HasOverlappingSale(ctx, skuID, now.Add(30*time.Minute), now.Add(90*time.Minute))
+	require.NoError(t, err)
+	assert.True(t, overlaps)
+	overlaps, err = repo.HasOverlappingSale(ctx, skuID, now.Add(time.Hour), now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.False(t, overlaps, "sales may follow each other")
+
+	require.NoError(t, repo.UpdateStatus(ctx, future.ID, model.PriceSchedulePending, model.PriceScheduleCancelled))
+	assert.ErrorIs(t, repo.UpdateStatus(ctx, future.ID, model.PriceSchedulePending, model.PriceScheduleActive), repository.ErrPriceScheduleChanged)
+	got, err := repo.GetByID(ctx, future.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.PriceScheduleCancelled, got.Status)
+	_, err = repo.GetByID(ctx, nonExistentID)
+	assert.ErrorIs(t, err, repository.ErrPriceScheduleNotFound)
+
+	schedules, err := repo.ListBySKU(ctx, skuID)
+	require.NoError(t, err)
+	require.Len(t, schedules, 4)
+	assert.Equal(t, endingSale.ID, schedules[0].ID)
+}
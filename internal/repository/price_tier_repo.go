@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrPriceTierNotFound is returned when a SKU has no price for a customer group.
+var ErrPriceTierNotFound = errors.New("price tier not found")
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/price_tier_repo_mock.go -package=mocks
+// PriceTierRepository defines the interface for SKU price tier data operations.
+type PriceTierRepository interface {
+	Save(ctx context.Context, tier *model.SKUPriceTier) error
+	Delete(ctx context.Context, skuID uint64, group string) error
+	ListBySKU(ctx context.Context, skuID uint64) ([]model.SKUPriceTier, error)
+	ListBySKUIDs(ctx context.Context, skuIDs []uint64, group string) ([]model.SKUPriceTier, error)
+}
+
+// priceTierRepository implements PriceTierRepository using GORM.
+type priceTierRepository struct {
+	db *gorm.DB
+}
+
+// NewPriceTierRepository creates a new PriceTierRepository instance.
+func NewPriceTierRepository(db *gorm.DB) PriceTierRepository {
+	return &priceTierRepository{db: db}
+}
+
+// Save creates the price of a SKU for a customer group, or replaces it.
+func (r *priceTierRepository) Save(ctx context.Context, tier *model.SKUPriceTier) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "sku_id"}, {Name: "customer_group"}},
+		DoUpdates: clause.AssignmentColumns([]string{"price", "updated_at"}),
+	}).Create(tier).Error
+	if err != nil {
+		return fmt.Errorf("failed to save %s price of SKU '%d': %w", tier.CustomerGroup, tier.SKUID, err)
+	}
+	return nil
+}
+
+// Delete removes the price of a SKU for a customer group. The row is deleted
+// outright so the group can be priced again.
+func (r *priceTierRepository) Delete(ctx context.Context, skuID uint64, group string) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Unscoped().Where("sku_id = ? AND customer_group = ?", skuID, group).Delete(&model.SKUPriceTier{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete %s price of SKU '%d': %w", group, skuID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrPriceTierNotFound
+	}
+	return nil
+}
+
+// ListBySKU retrieves every price tier of a SKU, by customer group.
+func (r *priceTierRepository) ListBySKU(ctx context.Context, skuID uint64) ([]model.SKUPriceTier, error) {
+	var tiers []model.SKUPriceTier
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("sku_id = ?", skuID).Order("customer_group").Find(&tiers).Error; err != nil {
+		return nil, fmt.Errorf("failed to list price tiers of SKU '%d': %w", skuID, err)
+	}
+	return tiers, nil
+}
+
+// ListBySKUIDs retrieves the prices of the given SKUs for one customer group.
+// SKUs without one are left out.
+func (r *priceTierRepository) ListBySKUIDs(ctx context.Context, skuIDs []uint64, group string) ([]model.SKUPriceTier, error) {
+	if len(skuIDs) == 0 {
+		return nil, nil
+	}
+	var tiers []model.SKUPriceTier
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("sku_id IN ? AND customer_group = ?", skuIDs, group).Find(&tiers).Error; err != nil {
+		return nil, fmt.Errorf("failed to list %s price tiers: %w", group, err)
+	}
+	return tiers, nil
+}
@@ -0,0 +1,46 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceTiersSaveListAndDelete(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	spu, err := createRandomSPU(ctx, repository.NewProductRepository(tx))
+	require.NoError(t, err)
+	sku, other := spu.SKUs[0].ID, spu.SKUs[1].ID
+	repo := repository.NewPriceTierRepository(tx)
+
+	require.NoError(t, repo.Save(ctx, &model.SKUPriceTier{SKUID: sku, CustomerGroup: model.CustomerGroupWholesale, Price: decimal.NewFromInt(8)}))
+	require.NoError(t, repo.Save(ctx, &model.SKUPriceTier{SKUID: sku, CustomerGroup: model.CustomerGroupVIP, Price: decimal.NewFromInt(9)}))
+	require.NoError(t, repo.Save(ctx, &model.SKUPriceTier{SKUID: other, CustomerGroup: model.CustomerGroupVIP, Price: decimal.NewFromInt(5)}))
+	require.NoError(t, repo.Save(ctx, &model.SKUPriceTier{SKUID: sku, CustomerGroup: model.CustomerGroupWholesale, Price: decimal.NewFromInt(7)})) // Replaces the first
+
+	tiers, err := repo.ListBySKU(ctx, sku)
+	require.NoError(t, err)
+	require.Len(t, tiers, 2)
+	assert.Equal(t, model.CustomerGroupVIP, tiers[0].CustomerGroup)
+	assert.True(t, decimal.NewFromInt(7).Equal(tiers[1].Price))
+
+	tiers, err = repo.ListBySKUIDs(ctx, []uint64{sku, other}, model.CustomerGroupWholesale)
+	require.NoError(t, err)
+	require.Len(t, tiers, 1)
+	assert.Equal(t, sku, tiers[0].SKUID)
+
+	require.NoError(t, repo.Delete(ctx, sku, model.CustomerGroupVIP))
+	assert.ErrorIs(t, repo.Delete(ctx, sku, model.CustomerGroupVIP), repository.ErrPriceTierNotFound)
+	// Deleted outright, so the group can be priced again
+	require.NoError(t, repo.Save(ctx, &model.SKUPriceTier{SKUID: sku, CustomerGroup: model.CustomerGroupVIP, Price: decimal.NewFromInt(6)}))
+}
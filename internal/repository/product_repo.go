@@ -4,10 +4,15 @@ import (
 	"context"
 	"errors" // Import errors package
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/apperr"
 	"github.com/proyuen/go-mall/pkg/database"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ErrSPUNotFound is returned when an SPU record is not found.
@@ -16,15 +21,89 @@ var ErrSPUNotFound = errors.New("SPU not found")
 // ErrSKUNotFound is returned when an SKU record is not found.
 var ErrSKUNotFound = errors.New("SKU not found")
 
+// minPopularityScore is what RescoreSPUs rounds down to zero. One view
+// scores 1, and less than this once eight half-lives old.
+const minPopularityScore = 0.005
+
 //go:generate mockgen -source=$GOFILE -destination=../mocks/product_repo_mock.go -package=mocks
 // ProductRepository defines the interface for product data operations.
 type ProductRepository interface {
 	CreateSPU(ctx context.Context, spu *model.SPU) error
 	CreateSKU(ctx context.Context, sku *model.SKU) error
+	// GetSPUByID returns an SPU with its rating counts and options, without
+	// its SKUs.
 	GetSPUByID(ctx context.Context, id uint64) (*model.SPU, error)
+	GetSPUsByIDs(ctx context.Context, ids []uint64) ([]model.SPU, error)
 	GetSKUByID(ctx context.Context, id uint64) (*model.SKU, error)
+	GetSKUsByIDs(ctx context.Context, ids []uint64) ([]model.SKU, error)
+	GetSKUForUpdate(ctx context.Context, id uint64) (*model.SKU, error)
+	// GetSKUsForUpdate is GetSKUsByIDs that also locks the rows, in ID order,
+	// until the transaction in ctx ends. Missing IDs are skipped.
+	GetSKUsForUpdate(ctx context.Context, ids []uint64) ([]model.SKU, error)
+	// ListSKUIDsByCategories returns the IDs of the SKUs of every SPU in the
+	// categories, in ID order.
+	ListSKUIDsByCategories(ctx context.Context, categoryIDs []uint64) ([]uint64, error)
+	UpdateSKUPrice(ctx context.Context, skuID uint64, price decimal.Decimal) error
+	// UpdateSKUSale starts a sale of a SKU at price until endsAt; a nil price
+	// ends the sale.
+	UpdateSKUSale(ctx context.Context, skuID uint64, price *decimal.Decimal, endsAt *time.Time) error
+	// UpdateSKUAvailableAt sets when a SKU is expected in stock; nil clears it.
+	UpdateSKUAvailableAt(ctx context.Context, skuID uint64, availableAt *time.Time) error
 	ListSPUs(ctx context.Context, offset, limit int) ([]model.SPU, error)
+	ListSKUsBySPUIDs(ctx context.Context, spuIDs []uint64) ([]model.SKU, error)
+	// ListSKUComponents returns the components of the given bundle SKUs,
+	// ordered by bundle and then component.
+	ListSKUComponents(ctx context.Context, bundleSKUIDs []uint64) ([]model.SKUComponent, error)
+	// ListProductOptions returns the options of the given SPUs, ordered by
+	// SPU and then code.
+	ListProductOptions(ctx context.Context, spuIDs []uint64) ([]model.ProductOption, error)
+	ListLowStockSKUs(ctx context.Context, threshold, limit int) ([]model.SKU, error)
 	UpdateSKUStock(ctx context.Context, skuID uint64, quantity int) error
+	ListSKUStock(ctx context.Context, afterID uint64, limit int) ([]model.SKU, error)
+	// AddSPUViews adds views, by SPU ID, to the SPUs' stats. Views of SPUs
+	// that do not exist are dropped. It returns how many SPUs it updated.
+	AddSPUViews(ctx context.Context, views map[uint64]int64) (int64, error)
+	// RescoreSPUs decays the popularity of every SPU with views to at,
+	// halving it every halfLife, and adds the views flushed since the last
+	// rescore. It returns how many SPUs it rescored.
+	RescoreSPUs(ctx context.Context, halfLife time.Duration, at time.Time) (int64, error)
+	// ListPopularSPUs returns the most popular SPUs with their SKUs, most
+	// popular first. SPUs that were never viewed are left out.
+	ListPopularSPUs(ctx context.Context, limit int) ([]model.SPU, error)
+	// ListSPUNames returns the ID, store ID, name and category ID of up to
+	// limit SPUs with IDs greater than afterID, in ID order. Other fields are
+	// left zero.
+	ListSPUNames(ctx context.Context, afterID uint64, limit int) ([]model.SPU, error)
+	// CountSPUsByCategory counts the SPUs in each category that has any.
+	CountSPUsByCategory(ctx context.Context) ([]CategoryCount, error)
+	// CountSPUsByPrice counts the SPUs with a SKU priced in each bucket of
+	// bounds, per currency. Bucket 0 is below bounds[0], bucket i from
+	// bounds[i-1] up to bounds[i], and bucket len(bounds) from the last bound
+	// up. Empty buckets are left out.
+	CountSPUsByPrice(ctx context.Context, bounds []decimal.Decimal) ([]PriceBucketCount, error)
+	// CountSPUsByAttribute counts the SPUs with a SKU having each attribute
+	// value, by attribute name and then most common value first.
+	CountSPUsByAttribute(ctx context.Context) ([]AttributeValueCount, error)
+}
+
+// CategoryCount is how many SPUs are in a category.
+type CategoryCount struct {
+	CategoryID uint64
+	Products   int64
+}
+
+// PriceBucketCount is how many SPUs have a SKU priced in a bucket.
+type PriceBucketCount struct {
+	Currency string
+	Bucket   int
+	Products int64
+}
+
+// AttributeValueCount is how many SPUs have a SKU with an attribute value.
+type AttributeValueCount struct {
+	Name     string
+	Value    string
+	Products int64
 }
 
 // productRepository implements ProductRepository using GORM.
@@ -59,7 +138,7 @@ func (r *productRepository) CreateSKU(ctx context.Context, sku *model.SKU) error
 func (r *productRepository) GetSPUByID(ctx context.Context, id uint64) (*model.SPU, error) {
 	var spu model.SPU
 	db := database.GetDBFromContext(ctx, r.db)
-	if err := db.First(&spu, id).Error; err != nil {
+	if err := db.Preload("Rating").Preload("Options", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).First(&spu, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrSPUNotFound
 		}
@@ -68,6 +147,20 @@ func (r *productRepository) GetSPUByID(ctx context.Context, id uint64) (*model.S
 	return &spu, nil
 }
 
+// GetSPUsByIDs retrieves the SPUs with the given IDs in one query, without
+// their SKUs. IDs without an SPU are skipped.
+func (r *productRepository) GetSPUsByIDs(ctx context.Context, ids []uint64) ([]model.SPU, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var spus []model.SPU
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("id IN ?", uniqueIDs(ids)).Find(&spus).Error; err != nil {
+		return nil, fmt.Errorf("failed to get SPUs by IDs: %w", err)
+	}
+	return spus, nil
+}
+
 // GetSKUByID retrieves an SKU by its ID. It also preloads the associated SPU.
 func (r *productRepository) GetSKUByID(ctx context.Context, id uint64) (*model.SKU, error) {
 	var sku model.SKU
@@ -82,6 +175,255 @@ func (r *productRepository) GetSKUByID(ctx context.Context, id uint64) (*model.S
 	return &sku, nil
 }
 
+// GetSKUsByIDs retrieves several SKUs in one query, without their SPU. The
+// result lines up with ids: the SKU of ids[i] is at index i, so an ID given
+// twice yields the SKU twice. If any ID does not exist it returns
+// ErrSKUNotFound naming the first one missing.
+func (r *productRepository) GetSKUsByIDs(ctx context.Context, ids []uint64) ([]model.SKU, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var found []model.SKU
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("id IN ?", uniqueIDs(ids)).Find(&found).Error; err != nil {
+		return nil, fmt.Errorf("failed to get SKUs by IDs: %w", err)
+	}
+	byID := make(map[uint64]model.SKU, len(found))
+	for _, sku := range found {
+		byID[sku.ID] = sku
+	}
+	skus := make([]model.SKU, 0, len(ids))
+	for _, id := range ids {
+		sku, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %d", ErrSKUNotFound, id)
+		}
+		skus = append(skus, sku)
+	}
+	return skus, nil
+}
+
+// GetSKUForUpdate retrieves an SKU with SELECT ... FOR UPDATE, without its
+// SPU. It must run in a transaction (see database.TransactionManager): the
+// row stays locked until it ends, so stock read here cannot change before it
+// is deducted.
+func (r *productRepository) GetSKUForUpdate(ctx context.Context, id uint64) (*model.SKU, error) {
+	var sku model.SKU
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).First(&sku, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSKUNotFound
+		}
+		return nil, fmt.Errorf("failed to lock SKU '%d': %w", id, err)
+	}
+	return &sku, nil
+}
+
+func (r *productRepository) GetSKUsForUpdate(ctx context.Context, ids []uint64) ([]model.SKU, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var skus []model.SKU
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Where("id IN ?", uniqueIDs(ids)).
+		Order("id").
+		Find(&skus).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock SKUs: %w", err)
+	}
+	return skus, nil
+}
+
+func (r *productRepository) ListSKUIDsByCategories(ctx context.Context, categoryIDs []uint64) ([]uint64, error) {
+	if len(categoryIDs) == 0 {
+		return nil, nil
+	}
+	var ids []uint64
+	db := database.GetDBFromContext(ctx, r.db)
+	spus := db.Model(&model.SPU{}).Select("id").Where("category_id IN ?", categoryIDs)
+	if err := db.Model(&model.SKU{}).Where("spu_id IN (?)", spus).Order("id").Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to list SKUs by categories: %w", err)
+	}
+	return ids, nil
+}
+
+func (r *productRepository) UpdateSKUPrice(ctx context.Context, skuID uint64, price decimal.Decimal) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.SKU{}).Where("id = ?", skuID).Update("price", price)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update price of SKU '%d': %w", skuID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrSKUNotFound
+	}
+	return nil
+}
+
+func (r *productRepository) UpdateSKUSale(ctx context.Context, skuID uint64, price *decimal.Decimal, endsAt *time.Time) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.SKU{}).Where("id = ?", skuID).
+		Updates(map[string]any{"sale_price": price, "sale_ends_at": endsAt})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update sale of SKU '%d': %w", skuID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrSKUNotFound
+	}
+	return nil
+}
+
+func (r *productRepository) UpdateSKUAvailableAt(ctx context.Context, skuID uint64, availableAt *time.Time) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.SKU{}).Where("id = ?", skuID).Update("available_at", availableAt)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update expected availability of SKU '%d': %w", skuID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrSKUNotFound
+	}
+	return nil
+}
+
+// AddSPUViews upserts the stats of every viewed SPU in one statement. It runs
+// outside any store, and takes each SPU's store from the SPU.
+func (r *productRepository) AddSPUViews(ctx context.Context, views map[uint64]int64) (int64, error) {
+	if len(views) == 0 {
+		return 0, nil
+	}
+	ids := make([]int64, 0, len(views))
+	counts := make([]int64, 0, len(views))
+	for id, count := range views {
+		ids = append(ids, int64(id))
+		counts = append(counts, count)
+	}
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Exec(`
+INSERT INTO spu_stats (spu_id, store_id, views, pending_views, score)
+SELECT spus.id, spus.store_id, v.views, v.views, 0
+FROM unnest(ARRAY[?]::bigint[], ARRAY[?]::bigint[]) AS v(spu_id, views)
+JOIN spus ON spus.id = v.spu_id AND spus.deleted_at IS NULL
+ON CONFLICT (spu_id) DO UPDATE SET
+	views = spu_stats.views + EXCLUDED.views,
+	pending_views = spu_stats.pending_views + EXCLUDED.pending_views`, ids, counts)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to add SPU views: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// RescoreSPUs rescores every SPU in one statement, across all stores. Scores
+// that decay below minPopularityScore drop to zero, so that SPUs no longer
+// viewed stop being rescored.
+func (r *productRepository) RescoreSPUs(ctx context.Context, halfLife time.Duration, at time.Time) (int64, error) {
+	const decayed = `score * power(0.5, extract(epoch FROM CAST(@at AS timestamptz) - COALESCE(scored_at, CAST(@at AS timestamptz))) / @half_life) + pending_views`
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Exec(`
+UPDATE spu_stats SET
+	score = CASE WHEN `+decayed+` < @min_score THEN 0 ELSE `+decayed+` END,
+	pending_views = 0,
+	scored_at = @at
+WHERE score > 0 OR pending_views > 0`, map[string]any{
+		"at":        at,
+		"half_life": halfLife.Seconds(),
+		"min_score": minPopularityScore,
+	})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to rescore SPUs: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *productRepository) ListPopularSPUs(ctx context.Context, limit int) ([]model.SPU, error) {
+	var spus []model.SPU
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Preload("SKUs").
+		Joins("JOIN spu_stats ON spu_stats.spu_id = spus.id").
+		Where("spu_stats.score > 0").
+		Order("spu_stats.score DESC, spus.id").
+		Limit(limit).
+		Find(&spus).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list popular SPUs: %w", err)
+	}
+	return spus, nil
+}
+
+func (r *productRepository) ListSPUNames(ctx context.Context, afterID uint64, limit int) ([]model.SPU, error) {
+	var spus []model.SPU
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Select("id", "store_id", "name", "category_id").
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&spus).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SPU names: %w", err)
+	}
+	return spus, nil
+}
+
+func (r *productRepository) CountSPUsByCategory(ctx context.Context) ([]CategoryCount, error) {
+	var counts []CategoryCount
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Model(&model.SPU{}).
+		Select("category_id, COUNT(*) AS products").
+		Group("category_id").
+		Order("category_id").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count SPUs by category: %w", err)
+	}
+	return counts, nil
+}
+
+func (r *productRepository) CountSPUsByPrice(ctx context.Context, bounds []decimal.Decimal) ([]PriceBucketCount, error) {
+	thresholds := make([]string, len(bounds))
+	for i, bound := range bounds {
+		thresholds[i] = bound.String()
+	}
+	var counts []PriceBucketCount
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Model(&model.SKU{}).
+		Select("currency, width_bucket(price, ?::numeric[]) AS bucket, COUNT(DISTINCT spu_id) AS products", "{"+strings.Join(thresholds, ",")+"}").
+		Group("currency, bucket").
+		Order("currency, bucket").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count SPUs by price: %w", err)
+	}
+	return counts, nil
+}
+
+// CountSPUsByAttribute skips SKUs whose attributes are not a JSON object.
+func (r *productRepository) CountSPUsByAttribute(ctx context.Context) ([]AttributeValueCount, error) {
+	var counts []AttributeValueCount
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Model(&model.SKU{}).
+		Select("kv.key AS name, kv.value AS value, COUNT(DISTINCT skus.spu_id) AS products").
+		Joins("CROSS JOIN LATERAL jsonb_each_text(CASE WHEN jsonb_typeof(skus.attributes) = 'object' THEN skus.attributes ELSE '{}'::jsonb END) AS kv").
+		Group("kv.key, kv.value").
+		Order("kv.key, products DESC, kv.value").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count SPUs by attribute: %w", err)
+	}
+	return counts, nil
+}
+
+// uniqueIDs returns ids without repeats, in their first order.
+func uniqueIDs(ids []uint64) []uint64 {
+	seen := make(map[uint64]struct{}, len(ids))
+	unique := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
 // ListSPUs retrieves a list of SPUs with pagination.
 func (r *productRepository) ListSPUs(ctx context.Context, offset, limit int) ([]model.SPU, error) {
 	// Limit cap protection to prevent OOM
@@ -98,9 +440,68 @@ func (r *productRepository) ListSPUs(ctx context.Context, offset, limit int) ([]
 	return spuList, nil
 }
 
+// ListSKUsBySPUIDs retrieves the SKUs of several SPUs in one query, ordered
+// by SPU and then SKU ID.
+func (r *productRepository) ListSKUsBySPUIDs(ctx context.Context, spuIDs []uint64) ([]model.SKU, error) {
+	if len(spuIDs) == 0 {
+		return nil, nil
+	}
+	var skus []model.SKU
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("spu_id IN ?", spuIDs).Order("spu_id, id").Find(&skus).Error; err != nil {
+		return nil, fmt.Errorf("failed to list SKUs by SPU IDs: %w", err)
+	}
+	return skus, nil
+}
+
+func (r *productRepository) ListSKUComponents(ctx context.Context, bundleSKUIDs []uint64) ([]model.SKUComponent, error) {
+	if len(bundleSKUIDs) == 0 {
+		return nil, nil
+	}
+	var components []model.SKUComponent
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Where("bundle_sku_id IN ?", uniqueIDs(bundleSKUIDs)).
+		Order("bundle_sku_id, component_sku_id").
+		Find(&components).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SKU components: %w", err)
+	}
+	return components, nil
+}
+
+func (r *productRepository) ListProductOptions(ctx context.Context, spuIDs []uint64) ([]model.ProductOption, error) {
+	if len(spuIDs) == 0 {
+		return nil, nil
+	}
+	var options []model.ProductOption
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("spu_id IN ?", uniqueIDs(spuIDs)).Order("spu_id, code").Find(&options).Error; err != nil {
+		return nil, fmt.Errorf("failed to list product options: %w", err)
+	}
+	return options, nil
+}
+
+// ListLowStockSKUs returns up to limit SKUs with stock at or below threshold,
+// scarcest first, with their SPU preloaded for its name.
+func (r *productRepository) ListLowStockSKUs(ctx context.Context, threshold, limit int) ([]model.SKU, error) {
+	var skus []model.SKU
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Preload("SPU").
+		Where("stock <= ?", threshold).
+		Order("stock, id").
+		Limit(limit).
+		Find(&skus).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list low-stock SKUs: %w", err)
+	}
+	return skus, nil
+}
+
 // UpdateSKUStock deducts/adds stock for a given SKU.
 // quantity can be negative for deduction, positive for addition.
-// It ensures stock does not go below zero.
+// It ensures stock does not go below zero: a deduction of more than the stock
+// left, or from a SKU that does not exist, fails with
+// apperr.CodeStockInsufficient.
 func (r *productRepository) UpdateSKUStock(ctx context.Context, skuID uint64, quantity int) error {
 	db := database.GetDBFromContext(ctx, r.db)
 	result := db.Model(&model.SKU{}).
@@ -111,7 +512,24 @@ func (r *productRepository) UpdateSKUStock(ctx context.Context, skuID uint64, qu
 		return fmt.Errorf("failed to update SKU stock for ID '%d': %w", skuID, result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("not enough stock or SKU ID '%d' not found", skuID)
+		return apperr.New(apperr.CodeStockInsufficient).WithSKU(skuID).WithMessage("insufficient stock or SKU not found")
 	}
 	return nil
 }
+
+// ListSKUStock returns the ID, SPU ID and stock of up to limit SKUs with IDs
+// greater than afterID, in ID order, so that callers can page through every
+// SKU with the last ID of each batch. Other fields are left zero.
+func (r *productRepository) ListSKUStock(ctx context.Context, afterID uint64, limit int) ([]model.SKU, error) {
+	var skus []model.SKU
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Select("id", "spu_id", "stock").
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&skus).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SKU stock: %w", err)
+	}
+	return skus, nil
+}
@@ -3,6 +3,7 @@ package repository_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/proyuen/go-mall/internal/model"
 	"github.com/proyuen/go-mall/internal/repository"
@@ -172,4 +173,399 @@ func TestGetSKUByID(t *testing.T) {
 		assert.ErrorIs(t, err, repository.ErrSKUNotFound) // Assert sentinel error
 		require.Nil(t, sku3)
 	})
-}
\ No newline at end of file
+}
+func TestListSKUStock(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewProductRepository(tx)
+
+	for range 3 {
+		_, err := createRandomSPU(ctx, repo)
+		require.NoError(t, err)
+	}
+
+	first, err := repo.ListSKUStock(ctx, 0, 2)
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	assert.Less(t, first[0].ID, first[1].ID)
+	assert.True(t, first[0].Price.IsZero()) // Only id, spu_id and stock are loaded
+
+	rest, err := repo.ListSKUStock(ctx, first[1].ID, 100)
+	require.NoError(t, err)
+	require.NotEmpty(t, rest)
+	assert.Greater(t, rest[0].ID, first[1].ID)
+
+	sku, err := repo.GetSKUByID(ctx, first[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, sku.Stock, first[0].Stock)
+	assert.Equal(t, sku.SPUID, first[0].SPUID)
+}
+
+func TestListSPUNames(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewProductRepository(tx)
+
+	created, err := createRandomSPU(ctx, repo)
+	require.NoError(t, err)
+
+	spus, err := repo.ListSPUNames(ctx, created.ID-1, 1)
+	require.NoError(t, err)
+	require.Len(t, spus, 1)
+	assert.Equal(t, created.ID, spus[0].ID)
+	assert.Equal(t, created.Name, spus[0].Name)
+	assert.Equal(t, created.CategoryID, spus[0].CategoryID)
+	assert.Empty(t, spus[0].Description) // Only id, store_id, name and category_id are loaded
+}
+
+func TestGetSKUsByIDs(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewProductRepository(tx)
+
+	spu1, err := createRandomSPU(ctx, repo)
+	require.NoError(t, err)
+	spu2, err := createRandomSPU(ctx, repo)
+	require.NoError(t, err)
+	a, b := spu1.SKUs[0], spu2.SKUs[1]
+
+	t.Run("InRequestedOrder", func(t *testing.T) {
+		skus, err := repo.GetSKUsByIDs(ctx, []uint64{b.ID, a.ID, b.ID})
+		require.NoError(t, err)
+		require.Len(t, skus, 3)
+		assert.Equal(t, []uint64{b.ID, a.ID, b.ID}, []uint64{skus[0].ID, skus[1].ID, skus[2].ID})
+		assert.Equal(t, b.Price.String(), skus[0].Price.String())
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		skus, err := repo.GetSKUsByIDs(ctx, []uint64{a.ID, nonExistentID})
+		assert.ErrorIs(t, err, repository.ErrSKUNotFound)
+		assert.Nil(t, skus)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		skus, err := repo.GetSKUsByIDs(ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, skus)
+	})
+}
+
+func TestGetSPUsByIDs(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewProductRepository(tx)
+
+	spu1, err := createRandomSPU(ctx, repo)
+	require.NoError(t, err)
+	spu2, err := createRandomSPU(ctx, repo)
+	require.NoError(t, err)
+
+	spus, err := repo.GetSPUsByIDs(ctx, []uint64{spu1.ID, spu2.ID, spu1.ID, nonExistentID})
+	require.NoError(t, err)
+	require.Len(t, spus, 2)
+	assert.ElementsMatch(t, []uint64{spu1.ID, spu2.ID}, []uint64{spus[0].ID, spus[1].ID})
+	assert.Equal(t, testCategoryID, spus[0].CategoryID)
+	assert.Empty(t, spus[0].SKUs)
+}
+
+func TestGetSKUForUpdate(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewProductRepository(tx) // Locks are held until tx ends
+
+	spu, err := createRandomSPU(ctx, repo)
+	require.NoError(t, err)
+
+	sku, err := repo.GetSKUForUpdate(ctx, spu.SKUs[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, spu.SKUs[0].Stock, sku.Stock)
+	require.NoError(t, repo.UpdateSKUStock(ctx, sku.ID, -1))
+
+	_, err = repo.GetSKUForUpdate(ctx, nonExistentID)
+	assert.ErrorIs(t, err, repository.ErrSKUNotFound)
+}
+
+func TestListSKUsBySPUIDs(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewProductRepository(tx)
+
+	spu1, err := createRandomSPU(ctx, repo)
+	require.NoError(t, err)
+	spu2, err := createRandomSPU(ctx, repo)
+	require.NoError(t, err)
+	_, err = createRandomSPU(ctx, repo)
+	require.NoError(t, err)
+
+	skus, err := repo.ListSKUsBySPUIDs(ctx, []uint64{spu1.ID, spu2.ID, nonExistentID})
+	require.NoError(t, err)
+	require.Len(t, skus, 4)
+	for _, sku := range skus {
+		assert.Contains(t, []uint64{spu1.ID, spu2.ID}, sku.SPUID)
+	}
+
+	skus, err = repo.ListSKUsBySPUIDs(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, skus)
+}
+
+func TestListSKUComponents(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewProductRepository(tx)
+
+	parts, err := createRandomSPU(ctx, repo)
+	require.NoError(t, err)
+	kit := &model.SPU{
+		Name:       utils.RandomString(10),
+		CategoryID: testCategoryID,
+		SKUs: []model.SKU{{
+			Price:    decimal.NewFromInt(50),
+			Delivery: model.SKUDeliveryBundle,
+			Components: []model.SKUComponent{
+				{ComponentSKUID: parts.SKUs[1].ID, Quantity: 1},
+				{ComponentSKUID: parts.SKUs[0].ID, Quantity: 2},
+			},
+		}},
+	}
+	require.NoError(t, repo.CreateSPU(ctx, kit), "components are created with their bundle")
+	bundleID := kit.SKUs[0].ID
+
+	components, err := repo.ListSKUComponents(ctx, []uint64{bundleID, parts.SKUs[0].ID})
+	require.NoError(t, err)
+	require.Len(t, components, 2)
+	for _, c := range components {
+		assert.Equal(t, bundleID, c.BundleSKUID)
+	}
+	assert.ElementsMatch(t, []uint64{parts.SKUs[0].ID, parts.SKUs[1].ID}, []uint64{components[0].ComponentSKUID, components[1].ComponentSKUID})
+
+	components, err = repo.ListSKUComponents(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, components)
+}
+
+func TestListProductOptions(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewProductRepository(tx)
+
+	spu := &model.SPU{
+		Name:       utils.RandomString(10),
+		CategoryID: testCategoryID,
+		SKUs:       []model.SKU{{Price: decimal.NewFromInt(30), Stock: 5}},
+		Options: []model.ProductOption{
+			{Code: "warranty", Name: "Extended warranty", Kind: model.ProductOptionFlag, Price: decimal.NewFromInt(20)},
+			{Code: "engraving", Name: "Engraving", Kind: model.ProductOptionText, MaxLength: 30, Price: decimal.NewFromInt(5)},
+		},
+	}
+	require.NoError(t, repo.CreateSPU(ctx, spu), "options are created with their SPU")
+	other, err := createRandomSPU(ctx, repo)
+	require.NoError(t, err)
+
+	options, err := repo.ListProductOptions(ctx, []uint64{spu.ID, other.ID})
+	require.NoError(t, err)
+	require.Len(t, options, 2)
+	assert.Equal(t, "engraving", options[0].Code)
+	assert.Equal(t, "warranty", options[1].Code)
+
+	got, err := repo.GetSPUByID(ctx, spu.ID)
+	require.NoError(t, err)
+	assert.Len(t, got.Options, 2)
+}
+
+func TestListLowStockSKUs(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewProductRepository(tx)
+
+	spu, err := createRandomSPU(ctx, repo)
+	require.NoError(t, err)
+	soldOut := spu.SKUs[0]
+	require.NoError(t, repo.UpdateSKUStock(ctx, soldOut.ID, -soldOut.Stock))
+
+	skus, err := repo.ListLowStockSKUs(ctx, 0, 1000)
+	require.NoError(t, err)
+	var found *model.SKU
+	for i := range skus {
+		assert.Zero(t, skus[i].Stock)
+		if skus[i].ID == soldOut.ID {
+			found = &skus[i]
+		}
+	}
+	require.NotNil(t, found)
+	assert.Equal(t, spu.Name, found.SPU.Name)
+}
+
+func TestBulkSKUPrices(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewProductRepository(tx)
+
+	spu, err := createRandomSPU(ctx, repo)
+	require.NoError(t, err)
+	other := &model.SPU{Name: utils.RandomString(10), CategoryID: testCategoryID + 1, SKUs: []model.SKU{{Price: decimal.NewFromInt(5), Stock: 1}}}
+	require.NoError(t, repo.CreateSPU(ctx, other))
+
+	ids, err := repo.ListSKUIDsByCategories(ctx, []uint64{testCategoryID + 1})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{other.SKUs[0].ID}, ids)
+
+	require.NoError(t, repo.UpdateSKUPrice(ctx, spu.SKUs[0].ID, decimal.RequireFromString("12.34")))
+	assert.ErrorIs(t, repo.UpdateSKUPrice(ctx, nonExistentID, decimal.NewFromInt(1)), repository.ErrSKUNotFound)
+
+	locked, err := repo.GetSKUsForUpdate(ctx, []uint64{spu.SKUs[1].ID, spu.SKUs[0].ID, nonExistentID})
+	require.NoError(t, err)
+	require.Len(t, locked, 2, "missing IDs are skipped")
+	assert.Less(t, locked[0].ID, locked[1].ID)
+	for _, sku := range locked {
+		if sku.ID == spu.SKUs[0].ID {
+			assert.Equal(t, "12.34", sku.Price.StringFixed(2))
+		}
+	}
+
+	salePrice, endsAt := decimal.RequireFromString("9.99"), time.Now().Add(time.Hour).Truncate(time.Second)
+	require.NoError(t, repo.UpdateSKUSale(ctx, spu.SKUs[0].ID, &salePrice, &endsAt))
+	sku, err := repo.GetSKUByID(ctx, spu.SKUs[0].ID)
+	require.NoError(t, err)
+	require.NotNil(t, sku.SalePrice)
+	assert.Equal(t, "9.99", sku.SalePrice.StringFixed(2))
+	assert.True(t, sku.OnSale(time.Now()))
+	assert.Equal(t, "12.34", sku.Price.StringFixed(2), "the regular price is kept")
+	require.NoError(t, repo.UpdateSKUSale(ctx, spu.SKUs[0].ID, nil, nil))
+	sku, err = repo.GetSKUByID(ctx, spu.SKUs[0].ID)
+	require.NoError(t, err)
+	assert.Nil(t, sku.SalePrice)
+	assert.Nil(t, sku.SaleEndsAt)
+	assert.ErrorIs(t, repo.UpdateSKUSale(ctx, nonExistentID, nil, nil), repository.ErrSKUNotFound)
+}
+
+func TestSPUPopularity(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewProductRepository(tx)
+
+	viewed, err := createRandomSPU(ctx, repo)
+	require.NoError(t, err)
+	trending, err := createRandomSPU(ctx, repo)
+	require.NoError(t, err)
+
+	updated, err := repo.AddSPUViews(ctx, map[uint64]int64{viewed.ID: 4, trending.ID: 1, nonExistentID: 9})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated, "views of unknown SPUs are dropped")
+	now := time.Now()
+	_, err = repo.RescoreSPUs(ctx, time.Hour, now)
+	require.NoError(t, err)
+
+	// Two half-lives later the older views count a quarter
+	_, err = repo.AddSPUViews(ctx, map[uint64]int64{trending.ID: 3})
+	require.NoError(t, err)
+	_, err = repo.RescoreSPUs(ctx, time.Hour, now.Add(2*time.Hour))
+	require.NoError(t, err)
+
+	popular, err := repo.ListPopularSPUs(ctx, 100)
+	require.NoError(t, err)
+	ranks := map[uint64]int{}
+	for i, spu := range popular {
+		ranks[spu.ID] = i
+	}
+	require.Contains(t, ranks, viewed.ID)
+	require.Contains(t, ranks, trending.ID)
+	assert.Less(t, ranks[trending.ID], ranks[viewed.ID], "3.25 outranks 1")
+	assert.NotEmpty(t, popular[ranks[trending.ID]].SKUs)
+
+	var stats model.SPUStats
+	require.NoError(t, tx.First(&stats, "spu_id = ?", trending.ID).Error)
+	assert.Equal(t, int64(4), stats.Views)
+	assert.Zero(t, stats.PendingViews)
+	assert.InDelta(t, 3.25, stats.Score, 0.001)
+}
+
+func TestCountSPUFacets(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewProductRepository(tx)
+
+	// Counted apart from other products by their unusual category, currency and attribute
+	categoryID := nonExistentID - 1
+	brand := utils.RandomString(12)
+	for _, price := range []int64{5, 30, 45} {
+		spu := &model.SPU{
+			Name:       utils.RandomString(10),
+			CategoryID: categoryID,
+			SKUs: []model.SKU{
+				{Attributes: model.JSONB{"brand": brand}, Price: decimal.NewFromInt(price), Currency: "XTS", Stock: 1},
+				{Attributes: model.JSONB{"brand": brand}, Price: decimal.NewFromInt(price + 1), Currency: "XTS", Stock: 1},
+			},
+		}
+		require.NoError(t, repo.CreateSPU(ctx, spu))
+	}
+
+	categories, err := repo.CountSPUsByCategory(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, categories, repository.CategoryCount{CategoryID: categoryID, Products: 3})
+
+	prices, err := repo.CountSPUsByPrice(ctx, []decimal.Decimal{decimal.NewFromInt(10), decimal.NewFromInt(50)})
+	require.NoError(t, err)
+	var xts []repository.PriceBucketCount
+	for _, count := range prices {
+		if count.Currency == "XTS" {
+			xts = append(xts, count)
+		}
+	}
+	assert.Equal(t, []repository.PriceBucketCount{
+		{Currency: "XTS", Bucket: 0, Products: 1},
+		{Currency: "XTS", Bucket: 1, Products: 2}, // Each SPU once, though both its SKUs are in the bucket
+	}, xts)
+
+	attributes, err := repo.CountSPUsByAttribute(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, attributes, repository.AttributeValueCount{Name: "brand", Value: brand, Products: 3})
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+// ErrPromotionNotFound is returned when a promotion does not exist.
+var ErrPromotionNotFound = errors.New("promotion not found")
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/promotion_repo_mock.go -package=mocks
+// PromotionRepository defines the interface for promotion data operations.
+type PromotionRepository interface {
+	Create(ctx context.Context, promotion *model.Promotion) error
+	List(ctx context.Context) ([]model.Promotion, error)
+	GetByID(ctx context.Context, id uint64) (*model.Promotion, error)
+	// ListActive returns the promotions running at the given time.
+	ListActive(ctx context.Context, at time.Time) ([]model.Promotion, error)
+	Delete(ctx context.Context, id uint64) error
+}
+
+// promotionRepository implements PromotionRepository using GORM.
+type promotionRepository struct {
+	db *gorm.DB
+}
+
+// NewPromotionRepository creates a new PromotionRepository instance.
+func NewPromotionRepository(db *gorm.DB) PromotionRepository {
+	return &promotionRepository{db: db}
+}
+
+// Create saves a new promotion with its tiers.
+func (r *promotionRepository) Create(ctx context.Context, promotion *model.Promotion) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(promotion).Error; err != nil {
+		return fmt.Errorf("failed to create promotion %q: %w", promotion.Name, err)
+	}
+	return nil
+}
+
+// List retrieves every promotion with its tiers, most recently created first.
+func (r *promotionRepository) List(ctx context.Context) ([]model.Promotion, error) {
+	var promotions []model.Promotion
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Preload("Tiers").Order("id DESC").Find(&promotions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list promotions: %w", err)
+	}
+	return promotions, nil
+}
+
+// GetByID retrieves a promotion with its tiers.
+func (r *promotionRepository) GetByID(ctx context.Context, id uint64) (*model.Promotion, error) {
+	var promotion model.Promotion
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Preload("Tiers").First(&promotion, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPromotionNotFound
+		}
+		return nil, fmt.Errorf("failed to get promotion '%d': %w", id, err)
+	}
+	return &promotion, nil
+}
+
+// ListActive retrieves the promotions started by at and not yet ended, with
+// their tiers, oldest first.
+func (r *promotionRepository) ListActive(ctx context.Context, at time.Time) ([]model.Promotion, error) {
+	var promotions []model.Promotion
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Preload("Tiers").
+		Where("starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)", at, at).
+		Order("id").
+		Find(&promotions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active promotions: %w", err)
+	}
+	return promotions, nil
+}
+
+// Delete soft-deletes a promotion; orders keep the discounts it gave.
+func (r *promotionRepository) Delete(ctx context.Context, id uint64) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Delete(&model.Promotion{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete promotion '%d': %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrPromotionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromotions(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewPromotionRepository(tx)
+	now := time.Now()
+	ended := now.Add(-time.Hour)
+
+	running := &model.Promotion{Name: "Spend more", Kind: "tiered", Stacking: "stackable", Currency: "USD", StartsAt: now.Add(-time.Hour), Tiers: []model.PromotionTier{
+		{Threshold: decimal.NewFromInt(50), Percent: decimal.NewFromInt(5)},
+		{Threshold: decimal.NewFromInt(100), Percent: decimal.NewFromInt(10)},
+	}}
+	past := &model.Promotion{Name: "Summer sale", Kind: "category_sale", Stacking: "exclusive", CategoryID: 1, Percent: decimal.NewFromInt(20), StartsAt: now.Add(-2 * time.Hour), EndsAt: &ended}
+	upcoming := &model.Promotion{Name: "3 for 2", Kind: "buy_x_get_y", Stacking: "stackable", BuyQuantity: 2, GetQuantity: 1, Percent: decimal.NewFromInt(100), StartsAt: now.Add(time.Hour)}
+	for _, promotion := range []*model.Promotion{running, past, upcoming} {
+		require.NoError(t, repo.Create(ctx, promotion))
+	}
+
+	active, err := repo.ListActive(ctx, now)
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, running.ID, active[0].ID)
+	assert.Len(t, active[0].Tiers, 2)
+
+	got, err := repo.GetByID(ctx, past.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Summer sale", got.Name)
+
+	all, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, upcoming.ID, all[0].ID, "most recent first")
+
+	require.NoError(t, repo.Delete(ctx, running.ID))
+	assert.ErrorIs(t, repo.Delete(ctx, running.ID), repository.ErrPromotionNotFound)
+	active, err = repo.ListActive(ctx, now)
+	require.NoError(t, err)
+	assert.Empty(t, active)
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrPurchaseOrderNotFound is returned when a purchase order does not exist.
+var ErrPurchaseOrderNotFound = errors.New("purchase order not found")
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/purchase_order_repo_mock.go -package=mocks
+// PurchaseOrderRepository defines the interface for purchase order data operations.
+type PurchaseOrderRepository interface {
+	// Create saves a new purchase order with its items.
+	Create(ctx context.Context, po *model.PurchaseOrder) error
+	GetByID(ctx context.Context, id uint64) (*model.PurchaseOrder, error)
+	// GetByIDForUpdate is GetByID that also locks the purchase order until
+	// the transaction in ctx ends.
+	GetByIDForUpdate(ctx context.Context, id uint64) (*model.PurchaseOrder, error)
+	// List returns the purchase orders with status, of supplierID, most
+	// recent first. An empty status or a zero supplierID matches all.
+	List(ctx context.Context, status string, supplierID uint64, offset, limit int) ([]model.PurchaseOrder, error)
+	// Update saves the status and expected date of po, and what was received
+	// of its items.
+	Update(ctx context.Context, po *model.PurchaseOrder) error
+	// EarliestExpected returns, by SKU ID, the earliest expected date of the
+	// open purchase orders with some of the SKU still to be received. SKUs
+	// without one are left out.
+	EarliestExpected(ctx context.Context, skuIDs []uint64) (map[uint64]time.Time, error)
+}
+
+// purchaseOrderRepository implements PurchaseOrderRepository using GORM.
+type purchaseOrderRepository struct {
+	db *gorm.DB
+}
+
+// NewPurchaseOrderRepository creates a new PurchaseOrderRepository instance.
+func NewPurchaseOrderRepository(db *gorm.DB) PurchaseOrderRepository {
+	return &purchaseOrderRepository{db: db}
+}
+
+// Create saves a new purchase order to the database, its items included.
+func (r *purchaseOrderRepository) Create(ctx context.Context, po *model.PurchaseOrder) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(po).Error; err != nil {
+		return fmt.Errorf("failed to create purchase order from supplier '%d': %w", po.SupplierID, err)
+	}
+	return nil
+}
+
+// GetByID retrieves a purchase order with its items.
+func (r *purchaseOrderRepository) GetByID(ctx context.Context, id uint64) (*model.PurchaseOrder, error) {
+	return r.get(database.GetDBFromContext(ctx, r.db), id)
+}
+
+func (r *purchaseOrderRepository) GetByIDForUpdate(ctx context.Context, id uint64) (*model.PurchaseOrder, error) {
+	db := database.GetDBFromContext(ctx, r.db)
+	return r.get(db.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}), id)
+}
+
+func (r *purchaseOrderRepository) get(db *gorm.DB, id uint64) (*model.PurchaseOrder, error) {
+	var po model.PurchaseOrder
+	if err := db.Preload("Items", orderPurchaseOrderItems).First(&po, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPurchaseOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get purchase order '%d': %w", id, err)
+	}
+	return &po, nil
+}
+
+func (r *purchaseOrderRepository) List(ctx context.Context, status string, supplierID uint64, offset, limit int) ([]model.PurchaseOrder, error) {
+	var pos []model.PurchaseOrder
+	db := database.GetDBFromContext(ctx, r.db).Preload("Items", orderPurchaseOrderItems)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if supplierID != 0 {
+		db = db.Where("supplier_id = ?", supplierID)
+	}
+	if err := db.Order("id DESC").Offset(offset).Limit(limit).Find(&pos).Error; err != nil {
+		return nil, fmt.Errorf("failed to list purchase orders: %w", err)
+	}
+	return pos, nil
+}
+
+func (r *purchaseOrderRepository) Update(ctx context.Context, po *model.PurchaseOrder) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.PurchaseOrder{}).
+			Where("id = ?", po.ID).
+			Updates(map[string]any{"status": po.Status, "expected_at": po.ExpectedAt})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPurchaseOrderNotFound
+		}
+		for _, item := range po.Items {
+			if err := tx.Model(&model.PurchaseOrderItem{}).Where("id = ?", item.ID).Update("received", item.Received).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, ErrPurchaseOrderNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update purchase order '%d': %w", po.ID, err)
+	}
+	return nil
+}
+
+func (r *purchaseOrderRepository) EarliestExpected(ctx context.Context, skuIDs []uint64) (map[uint64]time.Time, error) {
+	if len(skuIDs) == 0 {
+		return nil, nil
+	}
+	var rows []struct {
+		SKUID      uint64
+		ExpectedAt time.Time
+	}
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Model(&model.PurchaseOrder{}).
+		Select("purchase_order_items.sku_id AS sku_id, MIN(purchase_orders.expected_at) AS expected_at").
+		Joins("JOIN purchase_order_items ON purchase_order_items.purchase_order_id = purchase_orders.id AND purchase_order_items.deleted_at IS NULL").
+		Where("purchase_orders.status = ? AND purchase_orders.expected_at IS NOT NULL", model.PurchaseOrderStatusOpen).
+		Where("purchase_order_items.sku_id IN ? AND purchase_order_items.received < purchase_order_items.quantity", skuIDs).
+		Group("purchase_order_items.sku_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expected dates of SKUs: %w", err)
+	}
+	expected := make(map[uint64]time.Time, len(rows))
+	for _, row := range rows {
+		expected[row.SKUID] = row.ExpectedAt
+	}
+	return expected, nil
+}
+
+// orderPurchaseOrderItems lists a purchase order's items in the order they
+// were added.
+func orderPurchaseOrderItems(db *gorm.DB) *gorm.DB {
+	return db.Order("id")
+}
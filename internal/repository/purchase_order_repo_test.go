@@ -0,0 +1,70 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurchaseOrders(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	suppliers := repository.NewSupplierRepository(tx)
+	repo := repository.NewPurchaseOrderRepository(tx)
+
+	supplier := &model.Supplier{Name: "Acme Wholesale", LeadTimeDays: 14}
+	require.NoError(t, suppliers.Create(ctx, supplier))
+
+	soon := time.Now().Add(24 * time.Hour).Truncate(time.Microsecond)
+	later := soon.Add(7 * 24 * time.Hour)
+	first := &model.PurchaseOrder{SupplierID: supplier.ID, Status: model.PurchaseOrderStatusOpen, Currency: "USD", ExpectedAt: &later, Items: []model.PurchaseOrderItem{
+		{SKUID: 900001, Quantity: 500},
+		{SKUID: 900002, Quantity: 10},
+	}}
+	second := &model.PurchaseOrder{SupplierID: supplier.ID, Status: model.PurchaseOrderStatusOpen, Currency: "USD", ExpectedAt: &soon, Items: []model.PurchaseOrderItem{
+		{SKUID: 900001, Quantity: 100},
+	}}
+	require.NoError(t, repo.Create(ctx, first))
+	require.NoError(t, repo.Create(ctx, second))
+
+	expected, err := repo.EarliestExpected(ctx, []uint64{900001, 900002, 900003})
+	require.NoError(t, err)
+	require.Len(t, expected, 2, "nothing is expected of the SKU on no purchase order")
+	assert.True(t, soon.Equal(expected[900001]), "the earliest of the open purchase orders")
+	assert.True(t, later.Equal(expected[900002]))
+
+	// Receiving the second in full leaves the first expected
+	got, err := repo.GetByIDForUpdate(ctx, second.ID)
+	require.NoError(t, err)
+	got.Items[0].Received = 100
+	got.Status = model.PurchaseOrderStatusReceived
+	require.NoError(t, repo.Update(ctx, got))
+
+	expected, err = repo.EarliestExpected(ctx, []uint64{900001})
+	require.NoError(t, err)
+	assert.True(t, later.Equal(expected[900001]))
+
+	got, err = repo.GetByID(ctx, second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.PurchaseOrderStatusReceived, got.Status)
+	assert.Equal(t, 100, got.Items[0].Received)
+
+	open, err := repo.List(ctx, model.PurchaseOrderStatusOpen, supplier.ID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	assert.Equal(t, first.ID, open[0].ID)
+	require.Len(t, open[0].Items, 2)
+	assert.Equal(t, uint64(900001), open[0].Items[0].SKUID, "items in the order they were added")
+
+	_, err = repo.GetByID(ctx, 1)
+	assert.ErrorIs(t, err, repository.ErrPurchaseOrderNotFound)
+}
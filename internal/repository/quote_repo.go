@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrQuoteNotFound is returned when a quote does not exist.
+	ErrQuoteNotFound = errors.New("quote not found")
+	// ErrQuoteChanged is returned when a quote was updated by someone else
+	// since it was read.
+	ErrQuoteChanged = errors.New("quote changed concurrently")
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/quote_repo_mock.go -package=mocks
+// QuoteRepository defines the interface for quote data operations.
+type QuoteRepository interface {
+	// Create saves a new quote with its items.
+	Create(ctx context.Context, quote *model.Quote) error
+	GetByID(ctx context.Context, id uint64) (*model.Quote, error)
+	ListByUser(ctx context.Context, userID uint64, offset, limit int) ([]model.Quote, error)
+	// List returns the quotes with status, or all quotes when it is empty,
+	// oldest first so that admins answer them in turn.
+	List(ctx context.Context, status string, offset, limit int) ([]model.Quote, error)
+	// Update saves the status, expiry, order and item prices of quote,
+	// provided it still has fromStatus; else it returns ErrQuoteChanged.
+	Update(ctx context.Context, quote *model.Quote, fromStatus string) error
+}
+
+// quoteRepository implements QuoteRepository using GORM.
+type quoteRepository struct {
+	db *gorm.DB
+}
+
+// NewQuoteRepository creates a new QuoteRepository instance.
+func NewQuoteRepository(db *gorm.DB) QuoteRepository {
+	return &quoteRepository{db: db}
+}
+
+// Create saves a new quote to the database, its items included.
+func (r *quoteRepository) Create(ctx context.Context, quote *model.Quote) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(quote).Error; err != nil {
+		return fmt.Errorf("failed to create quote of user '%d': %w", quote.UserID, err)
+	}
+	return nil
+}
+
+// GetByID retrieves a quote with its items.
+func (r *quoteRepository) GetByID(ctx context.Context, id uint64) (*model.Quote, error) {
+	var quote model.Quote
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Preload("Items", orderQuoteItems).First(&quote, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQuoteNotFound
+		}
+		return nil, fmt.Errorf("failed to get quote '%d': %w", id, err)
+	}
+	return &quote, nil
+}
+
+// ListByUser retrieves one page of a user's quotes, most recent first.
+func (r *quoteRepository) ListByUser(ctx context.Context, userID uint64, offset, limit int) ([]model.Quote, error) {
+	var quotes []model.Quote
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Preload("Items", orderQuoteItems).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&quotes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quotes of user '%d': %w", userID, err)
+	}
+	return quotes, nil
+}
+
+func (r *quoteRepository) List(ctx context.Context, status string, offset, limit int) ([]model.Quote, error) {
+	var quotes []model.Quote
+	db := database.GetDBFromContext(ctx, r.db).Preload("Items", orderQuoteItems)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if err := db.Order("id").Offset(offset).Limit(limit).Find(&quotes).Error; err != nil {
+		return nil, fmt.Errorf("failed to list quotes: %w", err)
+	}
+	return quotes, nil
+}
+
+// Update saves what an admin's response or the buyer's acceptance changed.
+// Matching on the status the quote was read with keeps an acceptance and a
+// new response, or two acceptances, from both going through.
+func (r *quoteRepository) Update(ctx context.Context, quote *model.Quote, fromStatus string) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Quote{}).
+			Where("id = ? AND status = ?", quote.ID, fromStatus).
+			Updates(map[string]any{
+				"status":     quote.Status,
+				"expires_at": quote.ExpiresAt,
+				"order_id":   quote.OrderID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrQuoteChanged
+		}
+		for _, item := range quote.Items {
+			if err := tx.Model(&model.QuoteItem{}).Where("id = ?", item.ID).Update("price", item.Price).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, ErrQuoteChanged) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update quote '%d': %w", quote.ID, err)
+	}
+	return nil
+}
+
+// orderQuoteItems lists a quote's items in the order they were asked for.
+func orderQuoteItems(db *gorm.DB) *gorm.DB {
+	return db.Order("id")
+}
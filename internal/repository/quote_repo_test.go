@@ -0,0 +1,66 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotes(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewQuoteRepository(tx)
+	users := repository.NewUserRepository(tx)
+	buyer := createRandomUser(t, users)
+
+	first := &model.Quote{UserID: buyer.ID, Status: model.QuoteStatusRequested, Currency: "USD", Items: []model.QuoteItem{{SKUID: 1, Quantity: 500}, {SKUID: 2, Quantity: 200}}}
+	second := &model.Quote{UserID: buyer.ID, Status: model.QuoteStatusRequested, Currency: "EUR", Items: []model.QuoteItem{{SKUID: 3, Quantity: 100}}}
+	require.NoError(t, repo.Create(ctx, first))
+	require.NoError(t, repo.Create(ctx, second))
+
+	quotes, err := repo.ListByUser(ctx, buyer.ID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, quotes, 2)
+	assert.Equal(t, second.ID, quotes[0].ID, "most recent first")
+
+	got, err := repo.GetByID(ctx, first.ID)
+	require.NoError(t, err)
+	require.Len(t, got.Items, 2)
+	assert.Equal(t, uint64(1), got.Items[0].SKUID, "items in the order asked for")
+
+	// Responding prices the items; a second response to the same request fails
+	expiresAt := time.Now().Add(24 * time.Hour).Truncate(time.Microsecond)
+	got.Status, got.ExpiresAt = model.QuoteStatusQuoted, &expiresAt
+	got.Items[0].Price, got.Items[1].Price = decimal.RequireFromString("8.50"), decimal.NewFromInt(12)
+	require.NoError(t, repo.Update(ctx, got, model.QuoteStatusRequested))
+	assert.ErrorIs(t, repo.Update(ctx, got, model.QuoteStatusRequested), repository.ErrQuoteChanged)
+
+	got, err = repo.GetByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.QuoteStatusQuoted, got.Status)
+	assert.True(t, decimal.RequireFromString("8.5").Equal(got.Items[0].Price))
+	require.NotNil(t, got.ExpiresAt)
+	assert.True(t, expiresAt.Equal(*got.ExpiresAt))
+
+	requested, err := repo.List(ctx, model.QuoteStatusRequested, 0, 100)
+	require.NoError(t, err)
+	var ids []uint64
+	for _, quote := range requested {
+		ids = append(ids, quote.ID)
+	}
+	assert.Contains(t, ids, second.ID)
+	assert.NotContains(t, ids, first.ID, "already quoted")
+
+	_, err = repo.GetByID(ctx, 1)
+	assert.ErrorIs(t, err, repository.ErrQuoteNotFound)
+}
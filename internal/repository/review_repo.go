@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrReviewNotFound is returned when a review does not exist.
+	ErrReviewNotFound = errors.New("review not found")
+	// ErrReviewAlreadyReported is returned when a user reports a review twice.
+	ErrReviewAlreadyReported = errors.New("review already reported")
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/review_repo_mock.go -package=mocks
+// ReviewRepository defines the interface for review data operations.
+type ReviewRepository interface {
+	// Create saves a new review and counts its rating on its SPU.
+	Create(ctx context.Context, review *model.Review) error
+	GetByID(ctx context.Context, id uint64) (*model.Review, error)
+	ListBySPUIDs(ctx context.Context, spuIDs []uint64, perSPU int) ([]model.Review, error)
+	// Vote records a user's vote on a review, replacing their earlier one,
+	// and updates the review's vote counts. It returns the review as updated.
+	Vote(ctx context.Context, vote *model.ReviewVote) (*model.Review, error)
+	// Report files an abuse report on a review and counts it open on the
+	// review.
+	Report(ctx context.Context, report *model.ReviewReport) error
+	// ListReported returns a page of the reviews with open reports, most
+	// reported first, and how many there are.
+	ListReported(ctx context.Context, offset, limit int) ([]model.Review, int64, error)
+	// ListOpenReports returns the open reports of the reviews, by review and
+	// then oldest first.
+	ListOpenReports(ctx context.Context, reviewIDs []uint64) ([]model.ReviewReport, error)
+	// ResolveReports closes the open reports of a review with status, and
+	// deletes the review, and its rating from its SPU's, if they were upheld.
+	// It returns how many reports it closed.
+	ResolveReports(ctx context.Context, reviewID uint64, status string, at time.Time) (int64, error)
+}
+
+// reviewRepository implements ReviewRepository using GORM.
+type reviewRepository struct {
+	db *gorm.DB
+}
+
+// NewReviewRepository creates a new ReviewRepository instance.
+func NewReviewRepository(db *gorm.DB) ReviewRepository {
+	return &reviewRepository{db: db}
+}
+
+// Create saves a new review to the database.
+func (r *reviewRepository) Create(ctx context.Context, review *model.Review) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(review).Error; err != nil {
+			return err
+		}
+		return countRating(tx, review, 1)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create review: %w", err)
+	}
+	return nil
+}
+
+// countRating adds delta to the reviews of the review's SPU with its stars.
+func countRating(tx *gorm.DB, review *model.Review, delta int64) error {
+	if review.Rating < 1 || review.Rating > 5 {
+		return fmt.Errorf("invalid rating %d", review.Rating)
+	}
+	rating := model.SPURating{SPUID: review.SPUID, StoreID: review.StoreID}
+	stars := []*int64{&rating.Stars1, &rating.Stars2, &rating.Stars3, &rating.Stars4, &rating.Stars5}
+	*stars[review.Rating-1] = max(delta, 0) // The count of a new row
+	column := fmt.Sprintf("stars%d", review.Rating)
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "spu_id"}},
+		DoUpdates: clause.Assignments(map[string]any{column: gorm.Expr("GREATEST(spu_ratings."+column+" + ?, 0)", delta)}),
+	}).Create(&rating).Error
+}
+
+// ListBySPUIDs returns the newest perSPU reviews of each of the given SPUs in
+// one query, newest first within each SPU.
+func (r *reviewRepository) ListBySPUIDs(ctx context.Context, spuIDs []uint64, perSPU int) ([]model.Review, error) {
+	if len(spuIDs) == 0 {
+		return nil, nil
+	}
+	var reviews []model.Review
+	db := database.GetDBFromContext(ctx, r.db)
+	ranked := db.Model(&model.Review{}).
+		Select("*, ROW_NUMBER() OVER (PARTITION BY spu_id ORDER BY id DESC) AS spu_rank").
+		Where("spu_id IN ?", spuIDs)
+	// Unscoped: the subquery already excludes soft-deleted rows
+	err := db.Unscoped().Table("(?) AS ranked", ranked).
+		Where("spu_rank <= ?", perSPU).
+		Order("spu_id, id DESC").
+		Find(&reviews).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reviews: %w", err)
+	}
+	return reviews, nil
+}
+
+// GetByID retrieves a review by its ID.
+func (r *reviewRepository) GetByID(ctx context.Context, id uint64) (*model.Review, error) {
+	var review model.Review
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.First(&review, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, fmt.Errorf("failed to get review '%d': %w", id, err)
+	}
+	return &review, nil
+}
+
+// Vote locks the review first, so that the counts of concurrent votes on it
+// add up.
+func (r *reviewRepository) Vote(ctx context.Context, vote *model.ReviewVote) (*model.Review, error) {
+	var review model.Review
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&review, vote.ReviewID).Error; err != nil {
+			return err
+		}
+		helpful, unhelpful := 0, 0
+		var previous model.ReviewVote
+		err := tx.Where("review_id = ? AND user_id = ?", vote.ReviewID, vote.UserID).Take(&previous).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Create(vote).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case previous.Helpful == vote.Helpful:
+			return nil
+		default:
+			if err := tx.Model(&previous).Update("helpful", vote.Helpful).Error; err != nil {
+				return err
+			}
+			if previous.Helpful {
+				helpful--
+			} else {
+				unhelpful--
+			}
+		}
+		if vote.Helpful {
+			helpful++
+		} else {
+			unhelpful++
+		}
+		review.HelpfulVotes += helpful
+		review.UnhelpfulVotes += unhelpful
+		return tx.Model(&review).UpdateColumns(map[string]any{
+			"helpful_votes":   gorm.Expr("helpful_votes + ?", helpful),
+			"unhelpful_votes": gorm.Expr("unhelpful_votes + ?", unhelpful),
+		}).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, fmt.Errorf("failed to vote on review '%d': %w", vote.ReviewID, err)
+	}
+	return &review, nil
+}
+
+func (r *reviewRepository) Report(ctx context.Context, report *model.ReviewReport) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Review{}).Where("id = ?", report.ReviewID).UpdateColumn("open_reports", gorm.Expr("open_reports + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrReviewNotFound
+		}
+		result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(report)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrReviewAlreadyReported
+		}
+		return nil
+	})
+	if errors.Is(err, ErrReviewNotFound) || errors.Is(err, ErrReviewAlreadyReported) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to report review '%d': %w", report.ReviewID, err)
+	}
+	return nil
+}
+
+func (r *reviewRepository) ListReported(ctx context.Context, offset, limit int) ([]model.Review, int64, error) {
+	db := database.GetDBFromContext(ctx, r.db)
+	query := db.Model(&model.Review{}).Where("open_reports > 0")
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count reported reviews: %w", err)
+	}
+	var reviews []model.Review
+	if err := query.Order("open_reports DESC, id").Offset(offset).Limit(limit).Find(&reviews).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list reported reviews: %w", err)
+	}
+	return reviews, total, nil
+}
+
+func (r *reviewRepository) ListOpenReports(ctx context.Context, reviewIDs []uint64) ([]model.ReviewReport, error) {
+	if len(reviewIDs) == 0 {
+		return nil, nil
+	}
+	var reports []model.ReviewReport
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Where("review_id IN ? AND status = ?", reviewIDs, model.ReviewReportOpen).
+		Order("review_id, id").
+		Find(&reports).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list review reports: %w", err)
+	}
+	return reports, nil
+}
+
+// ResolveReports locks the review, so that a report filed meanwhile is
+// either closed with the others or counted open after.
+func (r *reviewRepository) ResolveReports(ctx context.Context, reviewID uint64, status string, at time.Time) (int64, error) {
+	var resolved int64
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var review model.Review
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&review, reviewID).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&model.ReviewReport{}).
+			Where("review_id = ? AND status = ?", reviewID, model.ReviewReportOpen).
+			Updates(map[string]any{"status": status, "resolved_at": at})
+		if result.Error != nil {
+			return result.Error
+		}
+		resolved = result.RowsAffected
+		if err := tx.Model(&review).UpdateColumn("open_reports", 0).Error; err != nil {
+			return err
+		}
+		if status == model.ReviewReportUpheld {
+			if err := tx.Delete(&review).Error; err != nil {
+				return err
+			}
+			return countRating(tx, &review, -1)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrReviewNotFound
+		}
+		return 0, fmt.Errorf("failed to resolve reports of review '%d': %w", reviewID, err)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,176 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReviewsListBySPUIDs(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewReviewRepository(tx)
+	products := repository.NewProductRepository(tx)
+
+	spu1, err := createRandomSPU(ctx, products)
+	require.NoError(t, err)
+	spu2, err := createRandomSPU(ctx, products)
+	require.NoError(t, err)
+
+	var spu1Reviews []*model.Review
+	for rating := 1; rating <= 3; rating++ {
+		review := &model.Review{SPUID: spu1.ID, UserID: 1, Rating: rating, Comment: "ok"}
+		require.NoError(t, repo.Create(ctx, review))
+		spu1Reviews = append(spu1Reviews, review)
+	}
+	require.NoError(t, repo.Create(ctx, &model.Review{SPUID: spu2.ID, UserID: 1, Rating: 5}))
+	require.NoError(t, tx.Delete(spu1Reviews[2]).Error) // Soft-deleted reviews are skipped
+
+	reviews, err := repo.ListBySPUIDs(ctx, []uint64{spu1.ID, spu2.ID}, 1)
+	require.NoError(t, err)
+	require.Len(t, reviews, 2)
+	byID := map[uint64]model.Review{reviews[0].SPUID: reviews[0], reviews[1].SPUID: reviews[1]}
+	assert.Equal(t, spu1Reviews[1].ID, byID[spu1.ID].ID, "newest live review of spu1")
+	assert.Equal(t, 5, byID[spu2.ID].Rating)
+
+	reviews, err = repo.ListBySPUIDs(ctx, []uint64{spu1.ID}, 10)
+	require.NoError(t, err)
+	require.Len(t, reviews, 2)
+	assert.Greater(t, reviews[0].ID, reviews[1].ID)
+}
+
+func TestReviewsVote(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewReviewRepository(tx)
+	products := repository.NewProductRepository(tx)
+
+	spu, err := createRandomSPU(ctx, products)
+	require.NoError(t, err)
+	review := &model.Review{SPUID: spu.ID, UserID: 1, Rating: 4}
+	require.NoError(t, repo.Create(ctx, review))
+
+	steps := []struct {
+		userID        uint64
+		helpful       bool
+		wantHelpful   int
+		wantUnhelpful int
+	}{
+		{userID: 2, helpful: true, wantHelpful: 1},
+		{userID: 3, helpful: false, wantHelpful: 1, wantUnhelpful: 1},
+		{userID: 2, helpful: true, wantHelpful: 1, wantUnhelpful: 1}, // Same vote again
+		{userID: 3, helpful: true, wantHelpful: 2},                   // Changed
+	}
+	for _, step := range steps {
+		updated, err := repo.Vote(ctx, &model.ReviewVote{ReviewID: review.ID, UserID: step.userID, Helpful: step.helpful})
+		require.NoError(t, err)
+		assert.Equal(t, step.wantHelpful, updated.HelpfulVotes)
+		assert.Equal(t, step.wantUnhelpful, updated.UnhelpfulVotes)
+	}
+	stored, err := repo.GetByID(ctx, review.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.HelpfulVotes)
+	assert.Zero(t, stored.UnhelpfulVotes)
+
+	_, err = repo.Vote(ctx, &model.ReviewVote{ReviewID: review.ID + 1000, UserID: 2, Helpful: true})
+	assert.ErrorIs(t, err, repository.ErrReviewNotFound)
+}
+
+func TestReviewsReportAndResolve(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewReviewRepository(tx)
+	products := repository.NewProductRepository(tx)
+
+	spu, err := createRandomSPU(ctx, products)
+	require.NoError(t, err)
+	kept := &model.Review{SPUID: spu.ID, UserID: 1, Rating: 1, Comment: "meh"}
+	removed := &model.Review{SPUID: spu.ID, UserID: 1, Rating: 1, Comment: "buy followers at ..."}
+	require.NoError(t, repo.Create(ctx, kept))
+	require.NoError(t, repo.Create(ctx, removed))
+
+	require.NoError(t, repo.Report(ctx, &model.ReviewReport{ReviewID: kept.ID, UserID: 2, Reason: model.ReviewReportOffTopic}))
+	for userID := uint64(2); userID <= 3; userID++ {
+		require.NoError(t, repo.Report(ctx, &model.ReviewReport{ReviewID: removed.ID, UserID: userID, Reason: model.ReviewReportSpam}))
+	}
+	err = repo.Report(ctx, &model.ReviewReport{ReviewID: removed.ID, UserID: 2, Reason: model.ReviewReportSpam})
+	assert.ErrorIs(t, err, repository.ErrReviewAlreadyReported)
+	err = repo.Report(ctx, &model.ReviewReport{ReviewID: removed.ID + 1000, UserID: 2, Reason: model.ReviewReportSpam})
+	assert.ErrorIs(t, err, repository.ErrReviewNotFound)
+
+	reviews, total, err := repo.ListReported(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, reviews, 2)
+	assert.Equal(t, removed.ID, reviews[0].ID, "most reported first")
+	assert.Equal(t, 2, reviews[0].OpenReports)
+	reports, err := repo.ListOpenReports(ctx, []uint64{kept.ID, removed.ID})
+	require.NoError(t, err)
+	assert.Len(t, reports, 3)
+
+	at := time.Now()
+	resolved, err := repo.ResolveReports(ctx, kept.ID, model.ReviewReportDismissed, at)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), resolved)
+	resolved, err = repo.ResolveReports(ctx, removed.ID, model.ReviewReportUpheld, at)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), resolved)
+
+	_, total, err = repo.ListReported(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	stored, err := repo.GetByID(ctx, kept.ID)
+	require.NoError(t, err)
+	assert.Zero(t, stored.OpenReports)
+	_, err = repo.GetByID(ctx, removed.ID)
+	assert.ErrorIs(t, err, repository.ErrReviewNotFound, "upheld reports remove the review")
+}
+
+func TestReviewsRatingCounts(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewReviewRepository(tx)
+	products := repository.NewProductRepository(tx)
+
+	spu, err := createRandomSPU(ctx, products)
+	require.NoError(t, err)
+	stored, err := products.GetSPUByID(ctx, spu.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.Rating, "not reviewed yet")
+
+	var removed *model.Review
+	for _, rating := range []int{5, 4, 5, 1} {
+		review := &model.Review{SPUID: spu.ID, UserID: 1, Rating: rating}
+		require.NoError(t, repo.Create(ctx, review))
+		removed = review
+	}
+	require.NoError(t, repo.Report(ctx, &model.ReviewReport{ReviewID: removed.ID, UserID: 2, Reason: model.ReviewReportSpam}))
+	_, err = repo.ResolveReports(ctx, removed.ID, model.ReviewReportUpheld, time.Now())
+	require.NoError(t, err)
+
+	stored, err = products.GetSPUByID(ctx, spu.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.Rating)
+	assert.Equal(t, model.SPURating{SPUID: spu.ID, StoreID: spu.StoreID, Stars4: 1, Stars5: 2}, *stored.Rating)
+}
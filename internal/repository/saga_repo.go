@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+// ErrSagaNotFound is returned when an order has no saga.
+var ErrSagaNotFound = errors.New("saga not found")
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/saga_repo_mock.go -package=mocks
+// SagaRepository stores the state of running and finished sagas.
+type SagaRepository interface {
+	Create(ctx context.Context, saga *model.SagaState) error
+	// GetByOrderID returns the saga of an order, e.g. to find the payment
+	// method it was paid with.
+	GetByOrderID(ctx context.Context, orderID uint64) (*model.SagaState, error)
+	// Save records the progress of saga: its step, status, payment and
+	// attempts.
+	Save(ctx context.Context, saga *model.SagaState) error
+	// ListDue returns up to limit running or compensating sagas due by now,
+	// oldest first.
+	ListDue(ctx context.Context, now time.Time, limit int) ([]model.SagaState, error)
+}
+
+// sagaRepository implements SagaRepository using GORM.
+type sagaRepository struct {
+	db *gorm.DB
+}
+
+// NewSagaRepository creates a new SagaRepository instance.
+func NewSagaRepository(db *gorm.DB) SagaRepository {
+	return &sagaRepository{db: db}
+}
+
+func (r *sagaRepository) Create(ctx context.Context, saga *model.SagaState) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(saga).Error; err != nil {
+		return fmt.Errorf("failed to create %s saga of order '%d': %w", saga.Type, saga.OrderID, err)
+	}
+	return nil
+}
+
+func (r *sagaRepository) GetByOrderID(ctx context.Context, orderID uint64) (*model.SagaState, error) {
+	db := database.GetDBFromContext(ctx, r.db)
+	var saga model.SagaState
+	if err := db.Where("order_id = ?", orderID).First(&saga).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSagaNotFound
+		}
+		return nil, fmt.Errorf("failed to get saga of order '%d': %w", orderID, err)
+	}
+	return &saga, nil
+}
+
+func (r *sagaRepository) Save(ctx context.Context, saga *model.SagaState) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Model(saga).
+		Select("step", "status", "authorized", "payment_provider", "payment_ref", "attempts", "next_attempt_at", "last_error").
+		Updates(saga).Error
+	if err != nil {
+		return fmt.Errorf("failed to save %s saga of order '%d': %w", saga.Type, saga.OrderID, err)
+	}
+	return nil
+}
+
+func (r *sagaRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]model.SagaState, error) {
+	db := database.GetDBFromContext(ctx, r.db)
+	var sagas []model.SagaState
+	err := db.Where("status IN ? AND next_attempt_at <= ?", []string{model.SagaStatusRunning, model.SagaStatusCompensating}, now).
+		Order("next_attempt_at, id").
+		Limit(limit).
+		Find(&sagas).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due sagas: %w", err)
+	}
+	return sagas, nil
+}
@@ -0,0 +1,67 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSagaRepository(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewSagaRepository(tx)
+
+	now := time.Now()
+	due := &model.SagaState{Type: model.SagaTypeCheckout, OrderID: 1001, Step: model.SagaStepChargePayment, Status: model.SagaStatusRunning, NextAttemptAt: now.Add(-time.Minute)}
+	leased := &model.SagaState{Type: model.SagaTypeCheckout, OrderID: 1002, Step: model.SagaStepChargePayment, Status: model.SagaStatusRunning, NextAttemptAt: now.Add(time.Minute)}
+	done := &model.SagaState{Type: model.SagaTypeCheckout, OrderID: 1003, Step: model.SagaStepConfirmOrder, Status: model.SagaStatusCompleted, NextAttemptAt: now.Add(-time.Hour)}
+	for _, saga := range []*model.SagaState{due, leased, done} {
+		require.NoError(t, repo.Create(ctx, saga))
+	}
+	assert.Error(t, repo.Create(ctx, &model.SagaState{Type: model.SagaTypeCheckout, OrderID: 1001, Step: model.SagaStepChargePayment, Status: model.SagaStatusRunning}), "one saga per order")
+
+	sagas, err := repo.ListDue(ctx, now, 100)
+	require.NoError(t, err)
+	ids := sagaIDs(sagas)
+	assert.Contains(t, ids, due.ID)
+	assert.NotContains(t, ids, leased.ID)
+	assert.NotContains(t, ids, done.ID)
+
+	due.Step, due.Status, due.PaymentProvider, due.PaymentRef = model.SagaStepChargePayment, model.SagaStatusCompensating, "stripe", "pi_1"
+	due.Attempts, due.NextAttemptAt, due.LastError = 2, now.Add(time.Hour), "stripe returned status 503"
+	require.NoError(t, repo.Save(ctx, due))
+
+	var got model.SagaState
+	require.NoError(t, tx.First(&got, due.ID).Error)
+	assert.Equal(t, model.SagaStatusCompensating, got.Status)
+	assert.Equal(t, "pi_1", got.PaymentRef)
+	assert.Equal(t, 2, got.Attempts)
+	assert.Equal(t, "stripe returned status 503", got.LastError)
+
+	sagas, err = repo.ListDue(ctx, now, 100)
+	require.NoError(t, err)
+	assert.NotContains(t, sagaIDs(sagas), due.ID, "the retry waits")
+
+	byOrder, err := repo.GetByOrderID(ctx, 1001)
+	require.NoError(t, err)
+	assert.Equal(t, due.ID, byOrder.ID)
+	_, err = repo.GetByOrderID(ctx, 1004)
+	assert.ErrorIs(t, err, repository.ErrSagaNotFound)
+}
+
+func sagaIDs(sagas []model.SagaState) []uint64 {
+	ids := make([]uint64, len(sagas))
+	for i := range sagas {
+		ids[i] = sagas[i].ID
+	}
+	return ids
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+// ErrSearchRuleNotFound is returned when a search rule does not exist.
+var ErrSearchRuleNotFound = errors.New("search rule not found")
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/search_repo_mock.go -package=mocks
+// SearchRepository defines the interface for search merchandising data
+// operations: the rules boosting and burying products, and the queries that
+// found nothing.
+type SearchRepository interface {
+	CreateRule(ctx context.Context, rule *model.SearchRule) error
+	DeleteRule(ctx context.Context, id uint64) error
+	// ListRules returns the search rules of the store in ctx, or of every
+	// store outside one, by query and then position.
+	ListRules(ctx context.Context) ([]model.SearchRule, error)
+	// AddZeroResults adds the searches of each query to its count, across
+	// stores, and sets when it was last searched to at.
+	AddZeroResults(ctx context.Context, searches []model.SearchZeroResult, at time.Time) error
+	// ListZeroResults returns a page of the queries of the store in ctx that
+	// found nothing, most searched first, and how many there are.
+	ListZeroResults(ctx context.Context, offset, limit int) ([]model.SearchZeroResult, int64, error)
+}
+
+// searchRepository implements SearchRepository using GORM.
+type searchRepository struct {
+	db *gorm.DB
+}
+
+// NewSearchRepository creates a new SearchRepository instance.
+func NewSearchRepository(db *gorm.DB) SearchRepository {
+	return &searchRepository{db: db}
+}
+
+// CreateRule saves a new search rule.
+func (r *searchRepository) CreateRule(ctx context.Context, rule *model.SearchRule) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(rule).Error; err != nil {
+		return fmt.Errorf("failed to create search rule: %w", err)
+	}
+	return nil
+}
+
+// DeleteRule removes a search rule.
+func (r *searchRepository) DeleteRule(ctx context.Context, id uint64) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Delete(&model.SearchRule{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete search rule '%d': %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrSearchRuleNotFound
+	}
+	return nil
+}
+
+func (r *searchRepository) ListRules(ctx context.Context) ([]model.SearchRule, error) {
+	var rules []model.SearchRule
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Order("query, position, id").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to list search rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (r *searchRepository) AddZeroResults(ctx context.Context, searches []model.SearchZeroResult, at time.Time) error {
+	if len(searches) == 0 {
+		return nil
+	}
+	storeIDs := make([]int64, len(searches))
+	queries := make([]string, len(searches))
+	counts := make([]int64, len(searches))
+	for i, search := range searches {
+		storeIDs[i] = int64(search.StoreID)
+		queries[i] = search.Query
+		counts[i] = search.Searches
+	}
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Exec(`
+INSERT INTO search_zero_results (store_id, query, searches, last_searched_at)
+SELECT v.store_id, v.query, v.searches, ?
+FROM unnest(ARRAY[?]::bigint[], ARRAY[?]::text[], ARRAY[?]::bigint[]) AS v(store_id, query, searches)
+ON CONFLICT (store_id, query) DO UPDATE SET
+	searches = search_zero_results.searches + EXCLUDED.searches,
+	last_searched_at = EXCLUDED.last_searched_at`, at, storeIDs, queries, counts).Error
+	if err != nil {
+		return fmt.Errorf("failed to add zero result searches: %w", err)
+	}
+	return nil
+}
+
+func (r *searchRepository) ListZeroResults(ctx context.Context, offset, limit int) ([]model.SearchZeroResult, int64, error) {
+	db := database.GetDBFromContext(ctx, r.db)
+	var total int64
+	if err := db.Model(&model.SearchZeroResult{}).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count zero result queries: %w", err)
+	}
+	var results []model.SearchZeroResult
+	if err := db.Order("searches DESC, query").Offset(offset).Limit(limit).Find(&results).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list zero result queries: %w", err)
+	}
+	return results, total, nil
+}
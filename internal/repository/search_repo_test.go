@@ -0,0 +1,62 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchRulesCreateListAndDelete(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewSearchRepository(tx)
+
+	second := &model.SearchRule{Query: "zz shoes", SPUID: 11, Action: model.SearchRuleBoost, Position: 2}
+	first := &model.SearchRule{Query: "zz shoes", SPUID: 12, Action: model.SearchRuleBoost, Position: 1}
+	require.NoError(t, repo.CreateRule(ctx, second))
+	require.NoError(t, repo.CreateRule(ctx, first))
+
+	rules, err := repo.ListRules(ctx)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(rules), 2)
+	assert.Equal(t, []uint64{first.ID, second.ID}, []uint64{rules[len(rules)-2].ID, rules[len(rules)-1].ID}, "by position within a query")
+
+	require.NoError(t, repo.DeleteRule(ctx, first.ID))
+	assert.ErrorIs(t, repo.DeleteRule(ctx, first.ID), repository.ErrSearchRuleNotFound)
+}
+
+func TestAddAndListZeroResults(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewSearchRepository(tx)
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.AddZeroResults(ctx, []model.SearchZeroResult{
+		{Query: "unicorn slippers", Searches: 3},
+		{Query: "flux capacitor", Searches: 5},
+	}, at))
+	require.NoError(t, repo.AddZeroResults(ctx, []model.SearchZeroResult{{Query: "unicorn slippers", Searches: 4}}, at.Add(time.Hour)))
+	require.NoError(t, repo.AddZeroResults(ctx, nil, at))
+
+	results, total, err := repo.ListZeroResults(ctx, 0, 2)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, total, int64(2))
+	require.Len(t, results, 2)
+	assert.Equal(t, "unicorn slippers", results[0].Query)
+	assert.Equal(t, int64(7), results[0].Searches)
+	assert.True(t, results[0].LastSearchedAt.Equal(at.Add(time.Hour)))
+	assert.Equal(t, "flux capacitor", results[1].Query)
+}
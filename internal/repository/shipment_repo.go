@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+// ErrShipmentNotFound is returned when a shipment does not exist.
+var ErrShipmentNotFound = errors.New("shipment not found")
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/shipment_repo_mock.go -package=mocks
+// ShipmentRepository defines the interface for shipment data operations.
+type ShipmentRepository interface {
+	// Create saves a shipment with its items and capture, if any.
+	Create(ctx context.Context, shipment *model.Shipment) error
+	// GetByID retrieves a shipment with its items and capture.
+	GetByID(ctx context.Context, id uint64) (*model.Shipment, error)
+	ListByOrder(ctx context.Context, orderID uint64) ([]model.Shipment, error)
+	// Update saves the tracking number and delivery of a shipment.
+	Update(ctx context.Context, shipment *model.Shipment) error
+}
+
+// shipmentRepository implements ShipmentRepository using GORM.
+type shipmentRepository struct {
+	db *gorm.DB
+}
+
+// NewShipmentRepository creates a new ShipmentRepository instance.
+func NewShipmentRepository(db *gorm.DB) ShipmentRepository {
+	return &shipmentRepository{db: db}
+}
+
+// Create relies on GORM creating the associations with the shipment, so call
+// it with a transaction context to keep them together.
+func (r *shipmentRepository) Create(ctx context.Context, shipment *model.Shipment) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(shipment).Error; err != nil {
+		return fmt.Errorf("failed to create shipment for order '%d': %w", shipment.OrderID, err)
+	}
+	return nil
+}
+
+func (r *shipmentRepository) GetByID(ctx context.Context, id uint64) (*model.Shipment, error) {
+	var shipment model.Shipment
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Preload("Items").Preload("Capture").First(&shipment, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShipmentNotFound
+		}
+		return nil, fmt.Errorf("failed to get shipment '%d': %w", id, err)
+	}
+	return &shipment, nil
+}
+
+// ListByOrder retrieves the shipments of an order with their items and
+// captures, oldest first.
+func (r *shipmentRepository) ListByOrder(ctx context.Context, orderID uint64) ([]model.Shipment, error) {
+	var shipments []model.Shipment
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Preload("Items").Preload("Capture").
+		Where("order_id = ?", orderID).
+		Order("created_at, id").
+		Find(&shipments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shipments of order '%d': %w", orderID, err)
+	}
+	return shipments, nil
+}
+
+func (r *shipmentRepository) Update(ctx context.Context, shipment *model.Shipment) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(shipment).Select("tracking_number", "status", "delivered_at").Updates(shipment)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update shipment '%d': %w", shipment.ID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrShipmentNotFound
+	}
+	return nil
+}
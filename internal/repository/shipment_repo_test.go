@@ -0,0 +1,108 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShipmentsAndCaptures(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	orders := repository.NewOrderRepository(tx)
+	repo := repository.NewShipmentRepository(tx)
+
+	user := createRandomUser(t, repository.NewUserRepository(tx))
+	spu, err := createRandomSPU(ctx, repository.NewProductRepository(tx))
+	require.NoError(t, err)
+	order := createOrderAt(t, orders, user.ID, spu.SKUs[0].ID, model.OrderStatusPending, time.Now())
+
+	require.NoError(t, orders.MarkAuthorized(ctx, order.ID, "stripe", "pi_1"))
+	assert.ErrorIs(t, orders.MarkAuthorized(ctx, order.ID, "stripe", "pi_2"), repository.ErrOrderStatusChanged)
+
+	locked, err := orders.GetByIDForUpdate(ctx, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusAuthorized, locked.Status)
+	require.Len(t, locked.Items, 1)
+
+	shipment := &model.Shipment{
+		OrderID:        order.ID,
+		TrackingNumber: "1Z999",
+		Items:          []model.ShipmentItem{{OrderItemID: locked.Items[0].ID, Quantity: 1}},
+		Capture:        &model.PaymentCapture{OrderID: order.ID, Provider: "stripe", PaymentRef: "pi_1", Amount: decimal.RequireFromString("4.00"), Currency: "USD"},
+	}
+	require.NoError(t, repo.Create(ctx, shipment))
+	require.NoError(t, orders.AddCapture(ctx, order.ID, decimal.RequireFromString("4.00"), false))
+	require.NoError(t, orders.AddCapture(ctx, order.ID, decimal.RequireFromString("6.00"), true))
+	assert.ErrorIs(t, orders.AddCapture(ctx, order.ID, decimal.RequireFromString("1.00"), true), repository.ErrOrderStatusChanged, "already paid")
+
+	paid, err := orders.GetByID(ctx, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusPaid, paid.Status)
+	assert.True(t, decimal.NewFromInt(10).Equal(paid.CapturedAmount))
+
+	shipments, err := repo.ListByOrder(ctx, order.ID)
+	require.NoError(t, err)
+	require.Len(t, shipments, 1)
+	assert.Equal(t, "1Z999", shipments[0].TrackingNumber)
+	require.Len(t, shipments[0].Items, 1)
+	require.NotNil(t, shipments[0].Capture)
+	assert.Equal(t, shipment.ID, shipments[0].Capture.ShipmentID)
+	assert.True(t, decimal.RequireFromString("4.00").Equal(shipments[0].Capture.Amount))
+}
+
+func TestShipmentDelivery(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	orders := repository.NewOrderRepository(tx)
+	repo := repository.NewShipmentRepository(tx)
+
+	user := createRandomUser(t, repository.NewUserRepository(tx))
+	spu, err := createRandomSPU(ctx, repository.NewProductRepository(tx))
+	require.NoError(t, err)
+	order := createOrderAt(t, orders, user.ID, spu.SKUs[0].ID, model.OrderStatusPaid, time.Now())
+	full, err := orders.GetByID(ctx, order.ID)
+	require.NoError(t, err)
+
+	shipment := &model.Shipment{
+		OrderID:   order.ID,
+		Warehouse: "east",
+		Items:     []model.ShipmentItem{{OrderItemID: full.Items[0].ID, Quantity: 1}},
+	}
+	require.NoError(t, repo.Create(ctx, shipment))
+	got, err := repo.GetByID(ctx, shipment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ShipmentStatusShipped, got.Status, "defaults to shipped")
+	assert.Equal(t, "east", got.Warehouse)
+
+	now := time.Now().Truncate(time.Microsecond)
+	got.TrackingNumber, got.Status, got.DeliveredAt = "1Z999", model.ShipmentStatusDelivered, &now
+	require.NoError(t, repo.Update(ctx, got))
+	got, err = repo.GetByID(ctx, shipment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "1Z999", got.TrackingNumber)
+	assert.Equal(t, model.ShipmentStatusDelivered, got.Status)
+	require.NotNil(t, got.DeliveredAt)
+
+	require.NoError(t, orders.SetFulfillment(ctx, order.ID, model.FulfillmentDelivered))
+	full, err = orders.GetByID(ctx, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.FulfillmentDelivered, full.Fulfillment)
+
+	_, err = repo.GetByID(ctx, nonExistentID)
+	assert.ErrorIs(t, err, repository.ErrShipmentNotFound)
+}
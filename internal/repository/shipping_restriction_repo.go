@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+// ErrShippingRestrictionNotFound is returned when a shipping restriction does not exist.
+var ErrShippingRestrictionNotFound = errors.New("shipping restriction not found")
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/shipping_restriction_repo_mock.go -package=mocks
+// ShippingRestrictionRepository defines the interface for shipping restriction data operations.
+type ShippingRestrictionRepository interface {
+	Create(ctx context.Context, restriction *model.ShippingRestriction) error
+	List(ctx context.Context) ([]model.ShippingRestriction, error)
+	Delete(ctx context.Context, id uint64) error
+}
+
+// shippingRestrictionRepository implements ShippingRestrictionRepository using GORM.
+type shippingRestrictionRepository struct {
+	db *gorm.DB
+}
+
+// NewShippingRestrictionRepository creates a new ShippingRestrictionRepository instance.
+func NewShippingRestrictionRepository(db *gorm.DB) ShippingRestrictionRepository {
+	return &shippingRestrictionRepository{db: db}
+}
+
+// Create saves a new shipping restriction.
+func (r *shippingRestrictionRepository) Create(ctx context.Context, restriction *model.ShippingRestriction) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(restriction).Error; err != nil {
+		return fmt.Errorf("failed to create shipping restriction: %w", err)
+	}
+	return nil
+}
+
+// List retrieves every shipping restriction, oldest first.
+func (r *shippingRestrictionRepository) List(ctx context.Context) ([]model.ShippingRestriction, error) {
+	var restrictions []model.ShippingRestriction
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Order("id").Find(&restrictions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list shipping restrictions: %w", err)
+	}
+	return restrictions, nil
+}
+
+// Delete removes a shipping restriction outright, so that its items ship to
+// its regions again.
+func (r *shippingRestrictionRepository) Delete(ctx context.Context, id uint64) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Unscoped().Delete(&model.ShippingRestriction{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete shipping restriction '%d': %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrShippingRestrictionNotFound
+	}
+	return nil
+}
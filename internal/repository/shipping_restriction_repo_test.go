@@ -0,0 +1,40 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShippingRestrictions(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewShippingRestrictionRepository(tx)
+
+	batteries := &model.ShippingRestriction{CategoryID: 4, Regions: "AU,US-HI", Reason: "Lithium batteries cannot be shipped there"}
+	knife := &model.ShippingRestriction{SKUID: 101, Regions: "GB", Reason: "Knives cannot be shipped there"}
+	for _, restriction := range []*model.ShippingRestriction{batteries, knife} {
+		require.NoError(t, repo.Create(ctx, restriction))
+	}
+
+	all, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, batteries.ID, all[0].ID, "oldest first")
+	assert.Equal(t, "AU,US-HI", all[0].Regions)
+
+	require.NoError(t, repo.Delete(ctx, batteries.ID))
+	assert.ErrorIs(t, repo.Delete(ctx, batteries.ID), repository.ErrShippingRestrictionNotFound)
+	all, err = repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, knife.ID, all[0].ID)
+}
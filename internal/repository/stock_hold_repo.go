@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrStockHoldNotFound is returned when a stock hold does not exist.
+var ErrStockHoldNotFound = errors.New("stock hold not found")
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/stock_hold_repo_mock.go -package=mocks
+// StockHoldRepository defines the interface for stock hold data operations.
+type StockHoldRepository interface {
+	Create(ctx context.Context, hold *model.StockHold) error
+	GetByID(ctx context.Context, id uint64) (*model.StockHold, error)
+	// GetByIDForUpdate is GetByID that also locks the hold until the
+	// transaction in ctx ends.
+	GetByIDForUpdate(ctx context.Context, id uint64) (*model.StockHold, error)
+	// List returns the holds with status, of skuID, most recent first. An
+	// empty status or a zero skuID matches all.
+	List(ctx context.Context, status string, skuID uint64, offset, limit int) ([]model.StockHold, error)
+	// ListExpired returns up to limit active holds that expire at or before
+	// now, the earliest first. It spans all stores.
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]model.StockHold, error)
+	// Update saves the status, expiry and release time of hold.
+	Update(ctx context.Context, hold *model.StockHold) error
+}
+
+// stockHoldRepository implements StockHoldRepository using GORM.
+type stockHoldRepository struct {
+	db *gorm.DB
+}
+
+// NewStockHoldRepository creates a new StockHoldRepository instance.
+func NewStockHoldRepository(db *gorm.DB) StockHoldRepository {
+	return &stockHoldRepository{db: db}
+}
+
+func (r *stockHoldRepository) Create(ctx context.Context, hold *model.StockHold) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(hold).Error; err != nil {
+		return fmt.Errorf("failed to create stock hold of SKU '%d': %w", hold.SKUID, err)
+	}
+	return nil
+}
+
+func (r *stockHoldRepository) GetByID(ctx context.Context, id uint64) (*model.StockHold, error) {
+	return r.get(database.GetDBFromContext(ctx, r.db), id)
+}
+
+func (r *stockHoldRepository) GetByIDForUpdate(ctx context.Context, id uint64) (*model.StockHold, error) {
+	db := database.GetDBFromContext(ctx, r.db)
+	return r.get(db.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}), id)
+}
+
+func (r *stockHoldRepository) get(db *gorm.DB, id uint64) (*model.StockHold, error) {
+	var hold model.StockHold
+	if err := db.First(&hold, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStockHoldNotFound
+		}
+		return nil, fmt.Errorf("failed to get stock hold '%d': %w", id, err)
+	}
+	return &hold, nil
+}
+
+func (r *stockHoldRepository) List(ctx context.Context, status string, skuID uint64, offset, limit int) ([]model.StockHold, error) {
+	var holds []model.StockHold
+	db := database.GetDBFromContext(ctx, r.db)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if skuID != 0 {
+		db = db.Where("sku_id = ?", skuID)
+	}
+	if err := db.Order("id DESC").Offset(offset).Limit(limit).Find(&holds).Error; err != nil {
+		return nil, fmt.Errorf("failed to list stock holds: %w", err)
+	}
+	return holds, nil
+}
+
+func (r *stockHoldRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]model.StockHold, error) {
+	var holds []model.StockHold
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Where("status = ? AND expires_at <= ?", model.StockHoldActive, now).
+		Order("expires_at, id").
+		Limit(limit).
+		Find(&holds).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired stock holds: %w", err)
+	}
+	return holds, nil
+}
+
+func (r *stockHoldRepository) Update(ctx context.Context, hold *model.StockHold) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.StockHold{}).
+		Where("id = ?", hold.ID).
+		Updates(map[string]any{"status": hold.Status, "expires_at": hold.ExpiresAt, "released_at": hold.ReleasedAt})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update stock hold '%d': %w", hold.ID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrStockHoldNotFound
+	}
+	return nil
+}
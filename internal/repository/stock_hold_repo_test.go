@@ -0,0 +1,55 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStockHolds(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewStockHoldRepository(tx)
+
+	now := time.Now().Truncate(time.Microsecond)
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+	expired := &model.StockHold{SKUID: 900001, Quantity: 2, Status: model.StockHoldActive, ExpiresAt: &past}
+	running := &model.StockHold{SKUID: 900001, Quantity: 1, Status: model.StockHoldActive, ExpiresAt: &future}
+	open := &model.StockHold{SKUID: 900002, Quantity: 5, Status: model.StockHoldActive, Note: "Trade show"}
+	for _, hold := range []*model.StockHold{expired, running, open} {
+		require.NoError(t, repo.Create(ctx, hold))
+	}
+
+	due, err := repo.ListExpired(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1, "holds without an expiry never expire")
+	assert.Equal(t, expired.ID, due[0].ID)
+
+	got, err := repo.GetByIDForUpdate(ctx, expired.ID)
+	require.NoError(t, err)
+	got.Status, got.ReleasedAt = model.StockHoldExpired, &now
+	require.NoError(t, repo.Update(ctx, got))
+	due, err = repo.ListExpired(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	holds, err := repo.List(ctx, model.StockHoldActive, 900001, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, holds, 1)
+	assert.Equal(t, running.ID, holds[0].ID)
+	holds, err = repo.List(ctx, "", 0, 0, 10)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, len(holds), 3)
+
+	_, err = repo.GetByID(ctx, nonExistentID)
+	assert.ErrorIs(t, err, repository.ErrStockHoldNotFound)
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/stock_movement_repo_mock.go -package=mocks
+// StockMovementRepository defines the interface for the stock ledger.
+type StockMovementRepository interface {
+	Create(ctx context.Context, movements []model.StockMovement) error
+	// ListBySKU returns one page of the movements of a SKU, most recent first.
+	ListBySKU(ctx context.Context, skuID uint64, offset, limit int) ([]model.StockMovement, error)
+}
+
+// stockMovementRepository implements StockMovementRepository using GORM.
+type stockMovementRepository struct {
+	db *gorm.DB
+}
+
+// NewStockMovementRepository creates a new StockMovementRepository instance.
+func NewStockMovementRepository(db *gorm.DB) StockMovementRepository {
+	return &stockMovementRepository{db: db}
+}
+
+// Create appends movements to the ledger.
+func (r *stockMovementRepository) Create(ctx context.Context, movements []model.StockMovement) error {
+	if len(movements) == 0 {
+		return nil
+	}
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(&movements).Error; err != nil {
+		return fmt.Errorf("failed to record %d stock movements: %w", len(movements), err)
+	}
+	return nil
+}
+
+func (r *stockMovementRepository) ListBySKU(ctx context.Context, skuID uint64, offset, limit int) ([]model.StockMovement, error) {
+	var movements []model.StockMovement
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Where("sku_id = ?", skuID).
+		Order("id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&movements).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stock movements of SKU '%d': %w", skuID, err)
+	}
+	return movements, nil
+}
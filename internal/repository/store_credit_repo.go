@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// StoreCreditBalance is what a customer has in store credit in one currency.
+type StoreCreditBalance struct {
+	Currency string
+	Amount   decimal.Decimal
+}
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/store_credit_repo_mock.go -package=mocks
+// StoreCreditRepository defines the interface for the store credit ledger.
+type StoreCreditRepository interface {
+	Create(ctx context.Context, credit *model.StoreCredit) error
+	// ListByUser returns one page of the entries of a user, most recent first.
+	ListByUser(ctx context.Context, userID uint64, offset, limit int) ([]model.StoreCredit, int64, error)
+	// Balances sums the entries of a user per currency, ordered by currency.
+	Balances(ctx context.Context, userID uint64) ([]StoreCreditBalance, error)
+}
+
+// storeCreditRepository implements StoreCreditRepository using GORM.
+type storeCreditRepository struct {
+	db *gorm.DB
+}
+
+// NewStoreCreditRepository creates a new StoreCreditRepository instance.
+func NewStoreCreditRepository(db *gorm.DB) StoreCreditRepository {
+	return &storeCreditRepository{db: db}
+}
+
+func (r *storeCreditRepository) Create(ctx context.Context, credit *model.StoreCredit) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(credit).Error; err != nil {
+		return fmt.Errorf("failed to record store credit of user '%d': %w", credit.UserID, err)
+	}
+	return nil
+}
+
+func (r *storeCreditRepository) ListByUser(ctx context.Context, userID uint64, offset, limit int) ([]model.StoreCredit, int64, error) {
+	query := database.GetDBFromContext(ctx, r.db).Model(&model.StoreCredit{}).Where("user_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count store credits of user '%d': %w", userID, err)
+	}
+
+	var credits []model.StoreCredit
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&credits).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list store credits of user '%d': %w", userID, err)
+	}
+	return credits, total, nil
+}
+
+func (r *storeCreditRepository) Balances(ctx context.Context, userID uint64) ([]StoreCreditBalance, error) {
+	var balances []StoreCreditBalance
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Model(&model.StoreCredit{}).
+		Select("currency, SUM(amount) AS amount").
+		Where("user_id = ?", userID).
+		Group("currency").
+		Order("currency").
+		Scan(&balances).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum store credits of user '%d': %w", userID, err)
+	}
+	return balances, nil
+}
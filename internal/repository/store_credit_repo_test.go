@@ -0,0 +1,45 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreCredits(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewStoreCreditRepository(tx)
+
+	for _, credit := range []model.StoreCredit{
+		{UserID: 900001, Amount: decimal.NewFromInt(10), Currency: "USD", Reason: "late delivery", CreatedBy: 1},
+		{UserID: 900001, Amount: decimal.RequireFromString("2.50"), Currency: "USD", Reason: "damaged box", CreatedBy: 1},
+		{UserID: 900001, Amount: decimal.NewFromInt(5), Currency: "EUR", Reason: "late delivery", CreatedBy: 1},
+		{UserID: 900002, Amount: decimal.NewFromInt(99), Currency: "USD", Reason: "someone else", CreatedBy: 1},
+	} {
+		require.NoError(t, repo.Create(ctx, &credit))
+	}
+
+	balances, err := repo.Balances(ctx, 900001)
+	require.NoError(t, err)
+	require.Len(t, balances, 2)
+	assert.Equal(t, "EUR", balances[0].Currency)
+	assert.True(t, balances[0].Amount.Equal(decimal.NewFromInt(5)))
+	assert.Equal(t, "USD", balances[1].Currency)
+	assert.True(t, balances[1].Amount.Equal(decimal.RequireFromString("12.50")))
+
+	credits, total, err := repo.ListByUser(ctx, 900001, 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, credits, 2)
+	assert.Equal(t, "EUR", credits[0].Currency, "most recent first")
+}
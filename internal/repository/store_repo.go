@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/store_repo_mock.go -package=mocks
+// StoreRepository defines the interface for store data operations.
+type StoreRepository interface {
+	Create(ctx context.Context, store *model.Store) error
+	List(ctx context.Context) ([]model.Store, error)
+	// AdoptUnowned moves the users, products and orders created before tenancy
+	// was enabled into a store. It returns how many rows moved.
+	AdoptUnowned(ctx context.Context, storeID uint64) (int64, error)
+}
+
+// storeRepository implements StoreRepository using GORM.
+type storeRepository struct {
+	db *gorm.DB
+}
+
+// NewStoreRepository creates a new StoreRepository instance.
+func NewStoreRepository(db *gorm.DB) StoreRepository {
+	return &storeRepository{db: db}
+}
+
+// Create saves a new store to the database.
+func (r *storeRepository) Create(ctx context.Context, store *model.Store) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(store).Error; err != nil {
+		return fmt.Errorf("failed to create store %q: %w", store.Slug, err)
+	}
+	return nil
+}
+
+// List retrieves every store, by slug.
+func (r *storeRepository) List(ctx context.Context) ([]model.Store, error) {
+	var stores []model.Store
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Order("slug").Find(&stores).Error; err != nil {
+		return nil, fmt.Errorf("failed to list stores: %w", err)
+	}
+	return stores, nil
+}
+
+// AdoptUnowned updates every store-owned table in one transaction, including
+// soft-deleted rows.
+func (r *storeRepository) AdoptUnowned(ctx context.Context, storeID uint64) (int64, error) {
+	var adopted int64
+	err := database.GetDBFromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for _, table := range []any{&model.User{}, &model.SPU{}, &model.SKU{}, &model.Order{}} {
+			result := tx.Unscoped().Model(table).Where("store_id = ?", 0).Update("store_id", storeID)
+			if result.Error != nil {
+				return result.Error
+			}
+			adopted += result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to move unowned rows into store '%d': %w", storeID, err)
+	}
+	return adopted, nil
+}
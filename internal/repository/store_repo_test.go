@@ -0,0 +1,52 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/tenant"
+	"github.com/proyuen/go-mall/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoresScopeProducts(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	stores := repository.NewStoreRepository(tx)
+	products := repository.NewProductRepository(tx)
+
+	unowned, err := createRandomSPU(ctx, products)
+	require.NoError(t, err)
+
+	first := &model.Store{Slug: utils.RandomString(8), Name: "First"}
+	second := &model.Store{Slug: utils.RandomString(8), Name: "Second"}
+	require.NoError(t, stores.Create(ctx, first))
+	require.NoError(t, stores.Create(ctx, second))
+	list, err := stores.List(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, len(list), 2)
+
+	adopted, err := stores.AdoptUnowned(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Positive(t, adopted)
+
+	firstCtx := tenant.NewContext(ctx, first)
+	secondCtx := tenant.NewContext(ctx, second)
+	spu, err := products.GetSPUByID(firstCtx, unowned.ID)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, spu.StoreID)
+	_, err = products.GetSPUByID(secondCtx, unowned.ID)
+	assert.ErrorIs(t, err, repository.ErrSPUNotFound)
+
+	created, err := createRandomSPU(secondCtx, products)
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, created.StoreID)
+	assert.Equal(t, second.ID, created.SKUs[0].StoreID)
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSubscriptionNotFound is returned when a subscription does not exist
+	// or belongs to another user.
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	// ErrSubscriptionChanged is returned when a subscription was updated by
+	// someone else since it was read.
+	ErrSubscriptionChanged = errors.New("subscription changed concurrently")
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/subscription_repo_mock.go -package=mocks
+// SubscriptionRepository defines the interface for subscription data operations.
+type SubscriptionRepository interface {
+	Create(ctx context.Context, sub *model.Subscription) error
+	GetByID(ctx context.Context, userID, id uint64) (*model.Subscription, error)
+	ListByUser(ctx context.Context, userID uint64) ([]model.Subscription, error)
+	// ListDue returns the active and past due subscriptions due by before
+	// with an ID above afterID, in ID order.
+	ListDue(ctx context.Context, before time.Time, afterID uint64, limit int) ([]model.Subscription, error)
+	// Update saves the status and schedule of sub, provided it still has
+	// fromStatus and fromNextOrderAt; else it returns ErrSubscriptionChanged.
+	Update(ctx context.Context, sub *model.Subscription, fromStatus string, fromNextOrderAt time.Time) error
+}
+
+// subscriptionRepository implements SubscriptionRepository using GORM.
+type subscriptionRepository struct {
+	db *gorm.DB
+}
+
+// NewSubscriptionRepository creates a new SubscriptionRepository instance.
+func NewSubscriptionRepository(db *gorm.DB) SubscriptionRepository {
+	return &subscriptionRepository{db: db}
+}
+
+// Create saves a new subscription to the database.
+func (r *subscriptionRepository) Create(ctx context.Context, sub *model.Subscription) error {
+	sub.NextOrderAt = sub.NextOrderAt.Truncate(time.Microsecond) // As stored, so Update can match it
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(sub).Error; err != nil {
+		return fmt.Errorf("failed to create subscription of user '%d': %w", sub.UserID, err)
+	}
+	return nil
+}
+
+// GetByID retrieves one of the user's subscriptions.
+func (r *subscriptionRepository) GetByID(ctx context.Context, userID, id uint64) (*model.Subscription, error) {
+	var sub model.Subscription
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("id = ? AND user_id = ?", id, userID).First(&sub).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSubscriptionNotFound
+		}
+		return nil, fmt.Errorf("failed to get subscription '%d': %w", id, err)
+	}
+	return &sub, nil
+}
+
+// ListByUser retrieves the subscriptions of a user, most recent first.
+// Cancelled subscriptions are included.
+func (r *subscriptionRepository) ListByUser(ctx context.Context, userID uint64) ([]model.Subscription, error) {
+	var subs []model.Subscription
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("user_id = ?", userID).Order("id DESC").Find(&subs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions of user '%d': %w", userID, err)
+	}
+	return subs, nil
+}
+
+func (r *subscriptionRepository) ListDue(ctx context.Context, before time.Time, afterID uint64, limit int) ([]model.Subscription, error) {
+	var subs []model.Subscription
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Where("id > ? AND status IN ? AND next_order_at <= ?",
+		afterID, []string{model.SubscriptionStatusActive, model.SubscriptionStatusPastDue}, before).
+		Order("id").
+		Limit(limit).
+		Find(&subs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// Update saves the status, schedule and renewal outcome of sub. Matching on
+// the status and schedule it was read with keeps a renewal and the user
+// pausing or skipping it, or two renewals, from both going through.
+func (r *subscriptionRepository) Update(ctx context.Context, sub *model.Subscription, fromStatus string, fromNextOrderAt time.Time) error {
+	sub.NextOrderAt = sub.NextOrderAt.Truncate(time.Microsecond)
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.Subscription{}).
+		Where("id = ? AND status = ? AND next_order_at = ?", sub.ID, fromStatus, fromNextOrderAt).
+		Updates(map[string]any{
+			"status":          sub.Status,
+			"next_order_at":   sub.NextOrderAt,
+			"failed_attempts": sub.FailedAttempts,
+			"last_order_id":   sub.LastOrderID,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update subscription '%d': %w", sub.ID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrSubscriptionChanged
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptions(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewSubscriptionRepository(tx)
+	users := repository.NewUserRepository(tx)
+	owner, other := createRandomUser(t, users), createRandomUser(t, users)
+	now := time.Now()
+
+	due := &model.Subscription{UserID: owner.ID, SKUID: 1, Quantity: 2, Interval: model.SubscriptionIntervalMonth, IntervalCount: 1, PaymentMethodID: 1, Status: model.SubscriptionStatusActive, NextOrderAt: now.Add(-time.Minute)}
+	later := &model.Subscription{UserID: owner.ID, SKUID: 2, Quantity: 1, Interval: model.SubscriptionIntervalWeek, IntervalCount: 2, PaymentMethodID: 1, Status: model.SubscriptionStatusActive, NextOrderAt: now.Add(time.Hour)}
+	paused := &model.Subscription{UserID: owner.ID, SKUID: 3, Quantity: 1, Interval: model.SubscriptionIntervalDay, IntervalCount: 1, PaymentMethodID: 1, Status: model.SubscriptionStatusPaused, NextOrderAt: now.Add(-time.Hour)}
+	for _, sub := range []*model.Subscription{due, later, paused} {
+		require.NoError(t, repo.Create(ctx, sub))
+	}
+
+	subs, err := repo.ListByUser(ctx, owner.ID)
+	require.NoError(t, err)
+	require.Len(t, subs, 3)
+	assert.Equal(t, paused.ID, subs[0].ID, "most recent first")
+
+	_, err = repo.GetByID(ctx, other.ID, due.ID)
+	assert.ErrorIs(t, err, repository.ErrSubscriptionNotFound)
+	got, err := repo.GetByID(ctx, owner.ID, due.ID)
+	require.NoError(t, err)
+
+	dueSubs, err := repo.ListDue(ctx, now, 0, 100)
+	require.NoError(t, err)
+	var ids []uint64
+	for _, sub := range dueSubs {
+		ids = append(ids, sub.ID)
+	}
+	assert.Contains(t, ids, due.ID)
+	assert.NotContains(t, ids, later.ID, "not due yet")
+	assert.NotContains(t, ids, paused.ID, "paused subscriptions are not renewed")
+
+	// Claiming the renewal moves it on; a second claim of the same period fails
+	fromStatus, fromNext := got.Status, got.NextOrderAt
+	got.NextOrderAt = got.Next(got.NextOrderAt)
+	require.NoError(t, repo.Update(ctx, got, fromStatus, fromNext))
+	assert.ErrorIs(t, repo.Update(ctx, got, fromStatus, fromNext), repository.ErrSubscriptionChanged)
+
+	// The in-memory schedule matches what was stored
+	got.Status = model.SubscriptionStatusPastDue
+	got.FailedAttempts = 1
+	require.NoError(t, repo.Update(ctx, got, fromStatus, got.NextOrderAt))
+	reloaded, err := repo.GetByID(ctx, owner.ID, due.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.SubscriptionStatusPastDue, reloaded.Status)
+	assert.Equal(t, 1, reloaded.FailedAttempts)
+	assert.True(t, got.NextOrderAt.Equal(reloaded.NextOrderAt))
+}
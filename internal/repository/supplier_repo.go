@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+// ErrSupplierNotFound is returned when a supplier does not exist.
+var ErrSupplierNotFound = errors.New("supplier not found")
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/supplier_repo_mock.go -package=mocks
+// SupplierRepository defines the interface for supplier data operations.
+type SupplierRepository interface {
+	Create(ctx context.Context, supplier *model.Supplier) error
+	GetByID(ctx context.Context, id uint64) (*model.Supplier, error)
+	List(ctx context.Context, offset, limit int) ([]model.Supplier, error)
+	Update(ctx context.Context, supplier *model.Supplier) error
+}
+
+// supplierRepository implements SupplierRepository using GORM.
+type supplierRepository struct {
+	db *gorm.DB
+}
+
+// NewSupplierRepository creates a new SupplierRepository instance.
+func NewSupplierRepository(db *gorm.DB) SupplierRepository {
+	return &supplierRepository{db: db}
+}
+
+// Create saves a new supplier to the database.
+func (r *supplierRepository) Create(ctx context.Context, supplier *model.Supplier) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(supplier).Error; err != nil {
+		return fmt.Errorf("failed to create supplier '%s': %w", supplier.Name, err)
+	}
+	return nil
+}
+
+// GetByID retrieves a supplier by its ID.
+func (r *supplierRepository) GetByID(ctx context.Context, id uint64) (*model.Supplier, error) {
+	var supplier model.Supplier
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.First(&supplier, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSupplierNotFound
+		}
+		return nil, fmt.Errorf("failed to get supplier '%d': %w", id, err)
+	}
+	return &supplier, nil
+}
+
+// List retrieves one page of suppliers, by name.
+func (r *supplierRepository) List(ctx context.Context, offset, limit int) ([]model.Supplier, error) {
+	var suppliers []model.Supplier
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Order("name, id").Offset(offset).Limit(limit).Find(&suppliers).Error; err != nil {
+		return nil, fmt.Errorf("failed to list suppliers: %w", err)
+	}
+	return suppliers, nil
+}
+
+// Update saves the name, contact details and lead time of a supplier.
+func (r *supplierRepository) Update(ctx context.Context, supplier *model.Supplier) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(supplier).Select("name", "email", "phone", "lead_time_days").Updates(supplier)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update supplier '%d': %w", supplier.ID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrSupplierNotFound
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+// ErrSynonymNotFound is returned when a synonym set does not exist.
+var ErrSynonymNotFound = errors.New("synonym set not found")
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/synonym_repo_mock.go -package=mocks
+// SynonymRepository defines the interface for search synonym data operations.
+type SynonymRepository interface {
+	Create(ctx context.Context, synonym *model.SearchSynonym) error
+	GetByID(ctx context.Context, id uint64) (*model.SearchSynonym, error)
+	// UpdateTerms replaces the terms of a synonym set.
+	UpdateTerms(ctx context.Context, synonym *model.SearchSynonym) error
+	Delete(ctx context.Context, id uint64) error
+	// List returns the synonym sets of the store in ctx, or of every store
+	// outside one, in ID order.
+	List(ctx context.Context) ([]model.SearchSynonym, error)
+}
+
+// synonymRepository implements SynonymRepository using GORM.
+type synonymRepository struct {
+	db *gorm.DB
+}
+
+// NewSynonymRepository creates a new SynonymRepository instance.
+func NewSynonymRepository(db *gorm.DB) SynonymRepository {
+	return &synonymRepository{db: db}
+}
+
+// Create saves a new synonym set.
+func (r *synonymRepository) Create(ctx context.Context, synonym *model.SearchSynonym) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(synonym).Error; err != nil {
+		return fmt.Errorf("failed to create synonym set: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a synonym set by its ID.
+func (r *synonymRepository) GetByID(ctx context.Context, id uint64) (*model.SearchSynonym, error) {
+	var synonym model.SearchSynonym
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.First(&synonym, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSynonymNotFound
+		}
+		return nil, fmt.Errorf("failed to get synonym set '%d': %w", id, err)
+	}
+	return &synonym, nil
+}
+
+func (r *synonymRepository) UpdateTerms(ctx context.Context, synonym *model.SearchSynonym) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(synonym).Select("terms", "updated_at").Updates(synonym)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update synonym set '%d': %w", synonym.ID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrSynonymNotFound
+	}
+	return nil
+}
+
+// Delete removes a synonym set.
+func (r *synonymRepository) Delete(ctx context.Context, id uint64) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Delete(&model.SearchSynonym{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete synonym set '%d': %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrSynonymNotFound
+	}
+	return nil
+}
+
+func (r *synonymRepository) List(ctx context.Context) ([]model.SearchSynonym, error) {
+	var synonyms []model.SearchSynonym
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Order("id").Find(&synonyms).Error; err != nil {
+		return nil, fmt.Errorf("failed to list synonym sets: %w", err)
+	}
+	return synonyms, nil
+}
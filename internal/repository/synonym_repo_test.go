@@ -0,0 +1,42 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSynonymsCreateUpdateAndDelete(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewSynonymRepository(tx)
+
+	synonym := &model.SearchSynonym{Terms: []string{"sneakers", "trainers"}}
+	require.NoError(t, repo.Create(ctx, synonym))
+	require.NotZero(t, synonym.ID)
+
+	synonym.Terms = []string{"sneakers", "trainers", "kicks"}
+	require.NoError(t, repo.UpdateTerms(ctx, synonym))
+	got, err := repo.GetByID(ctx, synonym.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sneakers", "trainers", "kicks"}, got.Terms)
+
+	synonyms, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, synonyms)
+	assert.Equal(t, synonym.ID, synonyms[len(synonyms)-1].ID)
+
+	require.NoError(t, repo.Delete(ctx, synonym.ID))
+	_, err = repo.GetByID(ctx, synonym.ID)
+	assert.ErrorIs(t, err, repository.ErrSynonymNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, synonym.ID), repository.ErrSynonymNotFound)
+	assert.ErrorIs(t, repo.UpdateTerms(ctx, synonym), repository.ErrSynonymNotFound)
+}
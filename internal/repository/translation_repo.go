@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTranslationNotFound is returned when an SPU has no translation into a locale.
+var ErrTranslationNotFound = errors.New("translation not found")
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/translation_repo_mock.go -package=mocks
+// TranslationRepository defines the interface for SPU translation data operations.
+type TranslationRepository interface {
+	Save(ctx context.Context, translation *model.SPUTranslation) error
+	Delete(ctx context.Context, spuID uint64, locale string) error
+	ListBySPU(ctx context.Context, spuID uint64) ([]model.SPUTranslation, error)
+	ListBySPUIDs(ctx context.Context, spuIDs []uint64, locale string) ([]model.SPUTranslation, error)
+}
+
+// translationRepository implements TranslationRepository using GORM.
+type translationRepository struct {
+	db *gorm.DB
+}
+
+// NewTranslationRepository creates a new TranslationRepository instance.
+func NewTranslationRepository(db *gorm.DB) TranslationRepository {
+	return &translationRepository{db: db}
+}
+
+// Save creates the translation of an SPU into a locale, or replaces it.
+func (r *translationRepository) Save(ctx context.Context, translation *model.SPUTranslation) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "spu_id"}, {Name: "locale"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "description", "updated_at"}),
+	}).Create(translation).Error
+	if err != nil {
+		return fmt.Errorf("failed to save translation of SPU '%d' into %s: %w", translation.SPUID, translation.Locale, err)
+	}
+	return nil
+}
+
+// Delete removes the translation of an SPU into a locale. The row is deleted
+// outright so the locale can be translated again.
+func (r *translationRepository) Delete(ctx context.Context, spuID uint64, locale string) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Unscoped().Where("spu_id = ? AND locale = ?", spuID, locale).Delete(&model.SPUTranslation{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete translation of SPU '%d' into %s: %w", spuID, locale, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrTranslationNotFound
+	}
+	return nil
+}
+
+// ListBySPU retrieves every translation of an SPU, by locale.
+func (r *translationRepository) ListBySPU(ctx context.Context, spuID uint64) ([]model.SPUTranslation, error) {
+	var translations []model.SPUTranslation
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("spu_id = ?", spuID).Order("locale").Find(&translations).Error; err != nil {
+		return nil, fmt.Errorf("failed to list translations of SPU '%d': %w", spuID, err)
+	}
+	return translations, nil
+}
+
+// ListBySPUIDs retrieves the translations of the given SPUs into one locale.
+// SPUs without one are left out.
+func (r *translationRepository) ListBySPUIDs(ctx context.Context, spuIDs []uint64, locale string) ([]model.SPUTranslation, error) {
+	if len(spuIDs) == 0 {
+		return nil, nil
+	}
+	var translations []model.SPUTranslation
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("spu_id IN ? AND locale = ?", spuIDs, locale).Find(&translations).Error; err != nil {
+		return nil, fmt.Errorf("failed to list %s translations: %w", locale, err)
+	}
+	return translations, nil
+}
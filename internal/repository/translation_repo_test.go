@@ -0,0 +1,44 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslationsSaveListAndDelete(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	spu, err := createRandomSPU(ctx, repository.NewProductRepository(tx))
+	require.NoError(t, err)
+	repo := repository.NewTranslationRepository(tx)
+
+	require.NoError(t, repo.Save(ctx, &model.SPUTranslation{SPUID: spu.ID, Locale: "zh", Name: "杯子"}))
+	require.NoError(t, repo.Save(ctx, &model.SPUTranslation{SPUID: spu.ID, Locale: "de", Name: "Tasse"}))
+	require.NoError(t, repo.Save(ctx, &model.SPUTranslation{SPUID: spu.ID, Locale: "zh", Name: "马克杯", Description: "陶瓷"})) // Replaces the first
+
+	translations, err := repo.ListBySPU(ctx, spu.ID)
+	require.NoError(t, err)
+	require.Len(t, translations, 2)
+	assert.Equal(t, "de", translations[0].Locale)
+	assert.Equal(t, "马克杯", translations[1].Name)
+	assert.Equal(t, "陶瓷", translations[1].Description)
+
+	translations, err = repo.ListBySPUIDs(ctx, []uint64{spu.ID, spu.ID + 1}, "de")
+	require.NoError(t, err)
+	require.Len(t, translations, 1)
+	assert.Equal(t, "Tasse", translations[0].Name)
+
+	require.NoError(t, repo.Delete(ctx, spu.ID, "de"))
+	assert.ErrorIs(t, repo.Delete(ctx, spu.ID, "de"), repository.ErrTranslationNotFound)
+	// Deleted outright, so the locale can be translated again
+	require.NoError(t, repo.Save(ctx, &model.SPUTranslation{SPUID: spu.ID, Locale: "de", Name: "Becher"}))
+}
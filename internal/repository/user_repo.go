@@ -18,7 +18,17 @@ var ErrUserNotFound = errors.New("user not found")
 type UserRepository interface {
 	Create(ctx context.Context, user *model.User) error
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	// GetByEmail returns the user with an email, which is unique per store.
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
 	GetByID(ctx context.Context, id uint64) (*model.User, error) // Changed to uint64
+	GetByIDs(ctx context.Context, ids []uint64) ([]model.User, error)
+	UpdatePasswordHash(ctx context.Context, id uint64, passwordHash string) error
+	UpdateCurrency(ctx context.Context, id uint64, currency string) error
+	UpdateCustomerGroup(ctx context.Context, id uint64, group string) error
+	// ListByCustomerGroup returns a page of the users in a customer group, in ID order.
+	ListByCustomerGroup(ctx context.Context, group string, offset, limit int) ([]model.User, error)
+	// ListByRole returns the users with a role, e.g. the admins to alert, in ID order.
+	ListByRole(ctx context.Context, role string) ([]model.User, error)
 }
 
 // userRepository implements UserRepository using GORM.
@@ -53,6 +63,19 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*m
 	return &user, nil
 }
 
+// GetByEmail retrieves a user by their email.
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	var user model.User
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+	return &user, nil
+}
+
 // GetByID retrieves a user by their ID.
 func (r *userRepository) GetByID(ctx context.Context, id uint64) (*model.User, error) { // Changed to uint64
 	var user model.User
@@ -64,4 +87,77 @@ func (r *userRepository) GetByID(ctx context.Context, id uint64) (*model.User, e
 		return nil, fmt.Errorf("failed to get user by ID '%d': %w", id, err)
 	}
 	return &user, nil
-}
\ No newline at end of file
+}
+
+// GetByIDs retrieves the users with the given IDs in one query. IDs without a
+// user are skipped.
+func (r *userRepository) GetByIDs(ctx context.Context, ids []uint64) ([]model.User, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var users []model.User
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to get users by IDs: %w", err)
+	}
+	return users, nil
+}
+
+// UpdatePasswordHash replaces the stored password hash of a user.
+func (r *userRepository) UpdatePasswordHash(ctx context.Context, id uint64, passwordHash string) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.User{}).Where("id = ?", id).Update("password_hash", passwordHash)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update password for user '%d': %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateCurrency sets the preferred display currency of a user; empty clears it.
+func (r *userRepository) UpdateCurrency(ctx context.Context, id uint64, currency string) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.User{}).Where("id = ?", id).Update("currency", currency)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update currency for user '%d': %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateCustomerGroup moves a user into a customer group.
+func (r *userRepository) UpdateCustomerGroup(ctx context.Context, id uint64, group string) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Model(&model.User{}).Where("id = ?", id).Update("customer_group", group)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update customer group for user '%d': %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// ListByCustomerGroup retrieves a page of the users in a customer group.
+func (r *userRepository) ListByCustomerGroup(ctx context.Context, group string, offset, limit int) ([]model.User, error) {
+	var users []model.User
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("customer_group = ?", group).Order("id").Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to list users in customer group %s: %w", group, err)
+	}
+	return users, nil
+}
+
+// ListByRole retrieves every user with a role.
+func (r *userRepository) ListByRole(ctx context.Context, role string) ([]model.User, error) {
+	var users []model.User
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Where("role = ?", role).Order("id").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to list users with role %s: %w", role, err)
+	}
+	return users, nil
+}
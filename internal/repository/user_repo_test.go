@@ -102,6 +102,25 @@ func TestGetUserByUsername(t *testing.T) {
 	}
 }
 
+func TestGetUserByEmail(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewUserRepository(tx)
+
+	user := createRandomUser(t, repo)
+
+	found, err := repo.GetByEmail(ctx, user.Email)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+
+	_, err = repo.GetByEmail(ctx, "nobody@example.com")
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+}
+
 func TestGetUserByID(t *testing.T) {
 	if testDB == nil {
 		t.Skip("Skipping test because testDB is not initialized")
@@ -163,3 +182,101 @@ func TestGetUserByID(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdatePasswordHash(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	repo := repository.NewUserRepository(tx)
+
+	user := createRandomUser(t, repo)
+
+	tests := []struct {
+		name        string
+		id          uint64
+		expectError error
+	}{
+		{name: "Success", id: user.ID},
+		{name: "NotFound", id: 999999999999999999, expectError: repository.ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			newHash := utils.RandomString(32)
+			err := repo.UpdatePasswordHash(ctx, tt.id, newHash)
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+
+			updated, err := repo.GetByID(ctx, tt.id)
+			require.NoError(t, err)
+			assert.Equal(t, newHash, updated.PasswordHash)
+		})
+	}
+}
+
+func TestUpdateCurrency(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewUserRepository(tx)
+
+	user := createRandomUser(t, repo)
+	assert.Empty(t, user.Currency)
+
+	require.NoError(t, repo.UpdateCurrency(ctx, user.ID, "EUR"))
+	updated, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", updated.Currency)
+
+	assert.ErrorIs(t, repo.UpdateCurrency(ctx, 999999999999999999, "EUR"), repository.ErrUserNotFound)
+}
+
+func TestUpdateCustomerGroup(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewUserRepository(tx)
+
+	user := createRandomUser(t, repo)
+	created, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.CustomerGroupRetail, created.CustomerGroup)
+
+	require.NoError(t, repo.UpdateCustomerGroup(ctx, user.ID, model.CustomerGroupWholesale))
+	users, err := repo.ListByCustomerGroup(ctx, model.CustomerGroupWholesale, 0, 100)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, user.ID, users[0].ID)
+
+	assert.ErrorIs(t, repo.UpdateCustomerGroup(ctx, 999999999999999999, model.CustomerGroupVIP), repository.ErrUserNotFound)
+}
+
+func TestGetUsersByIDs(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	repo := repository.NewUserRepository(tx)
+
+	user1 := createRandomUser(t, repo)
+	user2 := createRandomUser(t, repo)
+	createRandomUser(t, repo)
+
+	users, err := repo.GetByIDs(context.Background(), []uint64{user1.ID, user2.ID, 999999999})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.ElementsMatch(t, []uint64{user1.ID, user2.ID}, []uint64{users[0].ID, users[1].ID})
+}
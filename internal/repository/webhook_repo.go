@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/database"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrWebhookSubscriptionNotFound is returned when no live subscription has the given ID.
+	ErrWebhookSubscriptionNotFound = errors.New("webhook subscription not found")
+	// ErrWebhookDeliveryNotFound is returned when no delivery has the given ID.
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// WebhookDeliveryFilter narrows a delivery query. Zero values match everything.
+type WebhookDeliveryFilter struct {
+	SubscriptionID uint64
+	Event          string
+	Status         string
+}
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/webhook_repo_mock.go -package=mocks
+// WebhookRepository stores webhook subscriptions, the deliveries queued for
+// them and the attempts made to send each delivery.
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *model.WebhookSubscription) error
+	// ListSubscriptions returns the subscriptions to event, or all of them if event is empty.
+	ListSubscriptions(ctx context.Context, event string) ([]model.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uint64) error
+	CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error
+	// ListDueDeliveries returns pending deliveries due by now, oldest first, with
+	// their subscription loaded; it is nil for removed subscriptions.
+	ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]model.WebhookDelivery, error)
+	// RecordAttempt saves attempt and the new state of delivery together.
+	RecordAttempt(ctx context.Context, delivery *model.WebhookDelivery, attempt *model.WebhookAttempt) error
+	// ListDeliveries returns matching deliveries, newest first, and the total number of matches.
+	ListDeliveries(ctx context.Context, filter WebhookDeliveryFilter, offset, limit int) ([]model.WebhookDelivery, int64, error)
+	GetDelivery(ctx context.Context, id uint64) (*model.WebhookDelivery, error)
+	// ListAttempts returns the attempts of a delivery in order.
+	ListAttempts(ctx context.Context, deliveryID uint64) ([]model.WebhookAttempt, error)
+}
+
+// webhookRepository implements WebhookRepository using GORM.
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository creates a new WebhookRepository instance.
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+// CreateSubscription saves a new subscription.
+func (r *webhookRepository) CreateSubscription(ctx context.Context, sub *model.WebhookSubscription) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(sub).Error; err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	return nil
+}
+
+// ListSubscriptions retrieves live subscriptions in creation order.
+func (r *webhookRepository) ListSubscriptions(ctx context.Context, event string) ([]model.WebhookSubscription, error) {
+	query := database.GetDBFromContext(ctx, r.db)
+	if event != "" {
+		query = query.Where("event = ?", event)
+	}
+	var subs []model.WebhookSubscription
+	if err := query.Order("id").Find(&subs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// DeleteSubscription soft-deletes a subscription; its delivery history is kept.
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id uint64) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	result := db.Delete(&model.WebhookSubscription{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete webhook subscription '%d': %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebhookSubscriptionNotFound
+	}
+	return nil
+}
+
+// CreateDeliveries inserts the deliveries of one event in a single statement.
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.Create(deliveries).Error; err != nil {
+		return fmt.Errorf("failed to create webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// ListDueDeliveries retrieves deliveries whose next attempt is due.
+func (r *webhookRepository) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	db := database.GetDBFromContext(ctx, r.db)
+	var deliveries []model.WebhookDelivery
+	err := db.Preload("Subscription").
+		Where("status = ? AND next_attempt_at <= ?", model.WebhookStatusPending, now).
+		Order("next_attempt_at, id").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// RecordAttempt inserts attempt and updates the delivery in one transaction.
+func (r *webhookRepository) RecordAttempt(ctx context.Context, delivery *model.WebhookDelivery, attempt *model.WebhookAttempt) error {
+	db := database.GetDBFromContext(ctx, r.db)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+		return tx.Model(delivery).
+			Select("status", "attempts", "next_attempt_at", "last_status_code", "last_error", "delivered_at", "updated_at").
+			Updates(delivery).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record attempt of webhook delivery '%d': %w", delivery.ID, err)
+	}
+	return nil
+}
+
+// ListDeliveries retrieves deliveries matching filter.
+func (r *webhookRepository) ListDeliveries(ctx context.Context, filter WebhookDeliveryFilter, offset, limit int) ([]model.WebhookDelivery, int64, error) {
+	query := database.GetDBFromContext(ctx, r.db).Model(&model.WebhookDelivery{})
+	if filter.SubscriptionID != 0 {
+		query = query.Where("subscription_id = ?", filter.SubscriptionID)
+	}
+	if filter.Event != "" {
+		query = query.Where("event = ?", filter.Event)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	var deliveries []model.WebhookDelivery
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	return deliveries, total, nil
+}
+
+// GetDelivery retrieves a delivery by its ID.
+func (r *webhookRepository) GetDelivery(ctx context.Context, id uint64) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	db := database.GetDBFromContext(ctx, r.db)
+	if err := db.First(&delivery, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery '%d': %w", id, err)
+	}
+	return &delivery, nil
+}
+
+// ListAttempts retrieves the attempts of a delivery.
+func (r *webhookRepository) ListAttempts(ctx context.Context, deliveryID uint64) ([]model.WebhookAttempt, error) {
+	db := database.GetDBFromContext(ctx, r.db)
+	var attempts []model.WebhookAttempt
+	if err := db.Where("delivery_id = ?", deliveryID).Order("attempt, id").Find(&attempts).Error; err != nil {
+		return nil, fmt.Errorf("failed to list attempts of webhook delivery '%d': %w", deliveryID, err)
+	}
+	return attempts, nil
+}
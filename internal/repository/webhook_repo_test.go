@@ -0,0 +1,103 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSubscriptions(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewWebhookRepository(tx)
+
+	created := &model.WebhookSubscription{Event: "order.created", URL: "https://erp.example.com/hooks", Secret: "whsec_1"}
+	require.NoError(t, repo.CreateSubscription(ctx, created))
+	require.NoError(t, repo.CreateSubscription(ctx, &model.WebhookSubscription{Event: "stock.low", URL: "https://erp.example.com/stock", Secret: "whsec_2"}))
+
+	subs, err := repo.ListSubscriptions(ctx, "order.created")
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Equal(t, created.ID, subs[0].ID)
+	assert.Equal(t, "whsec_1", subs[0].Secret)
+
+	require.NoError(t, repo.DeleteSubscription(ctx, created.ID))
+	assert.ErrorIs(t, repo.DeleteSubscription(ctx, created.ID), repository.ErrWebhookSubscriptionNotFound)
+
+	subs, err = repo.ListSubscriptions(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Equal(t, "stock.low", subs[0].Event)
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	if testDB == nil {
+		t.Skip("Skipping test because testDB is not initialized")
+	}
+	tx := testDB.Begin()
+	defer tx.Rollback()
+	ctx := context.Background()
+	repo := repository.NewWebhookRepository(tx)
+	now := time.Now()
+
+	live := &model.WebhookSubscription{Event: "order.created", URL: "https://erp.example.com/hooks", Secret: "whsec_1"}
+	removed := &model.WebhookSubscription{Event: "order.created", URL: "https://old.example.com/hooks", Secret: "whsec_2"}
+	require.NoError(t, repo.CreateSubscription(ctx, live))
+	require.NoError(t, repo.CreateSubscription(ctx, removed))
+
+	eventID := uuid.NewString()
+	due := &model.WebhookDelivery{SubscriptionID: live.ID, Event: "order.created", EventID: eventID, Payload: `{}`, Status: model.WebhookStatusPending, NextAttemptAt: now.Add(-time.Minute)}
+	orphan := &model.WebhookDelivery{SubscriptionID: removed.ID, Event: "order.created", EventID: eventID, Payload: `{}`, Status: model.WebhookStatusPending, NextAttemptAt: now.Add(-time.Second)}
+	later := &model.WebhookDelivery{SubscriptionID: live.ID, Event: "order.created", EventID: uuid.NewString(), Payload: `{}`, Status: model.WebhookStatusPending, NextAttemptAt: now.Add(time.Hour)}
+	require.NoError(t, repo.CreateDeliveries(ctx, []*model.WebhookDelivery{due, orphan, later}))
+	require.NoError(t, repo.DeleteSubscription(ctx, removed.ID))
+
+	// Only due deliveries are returned, oldest first; removed subscriptions load as nil.
+	deliveries, err := repo.ListDueDeliveries(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, due.ID, deliveries[0].ID)
+	require.NotNil(t, deliveries[0].Subscription)
+	assert.Equal(t, live.URL, deliveries[0].Subscription.URL)
+	assert.Equal(t, orphan.ID, deliveries[1].ID)
+	assert.Nil(t, deliveries[1].Subscription)
+
+	delivered := deliveries[0]
+	deliveredAt := now
+	delivered.Status = model.WebhookStatusDelivered
+	delivered.Attempts = 1
+	delivered.LastStatusCode = 204
+	delivered.DeliveredAt = &deliveredAt
+	require.NoError(t, repo.RecordAttempt(ctx, &delivered, &model.WebhookAttempt{DeliveryID: delivered.ID, Attempt: 1, StatusCode: 204, DurationMS: 12}))
+
+	got, err := repo.GetDelivery(ctx, due.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.WebhookStatusDelivered, got.Status)
+	assert.Equal(t, 1, got.Attempts)
+	assert.Equal(t, 204, got.LastStatusCode)
+	assert.NotNil(t, got.DeliveredAt)
+
+	attempts, err := repo.ListAttempts(ctx, due.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, 204, attempts[0].StatusCode)
+
+	list, total, err := repo.ListDeliveries(ctx, repository.WebhookDeliveryFilter{SubscriptionID: live.ID, Status: model.WebhookStatusPending}, 0, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	require.Len(t, list, 1)
+	assert.Equal(t, later.ID, list[0].ID)
+
+	_, err = repo.GetDelivery(ctx, 1)
+	assert.ErrorIs(t, err, repository.ErrWebhookDeliveryNotFound)
+}
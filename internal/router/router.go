@@ -1,66 +1,466 @@
 package router
 
 import (
+	"log/slog"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	_ "github.com/proyuen/go-mall/api/swagger" // Registers the generated spec served under /swagger
 	"github.com/proyuen/go-mall/internal/handler"
 	"github.com/proyuen/go-mall/internal/middleware"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/errreport"
+	"github.com/proyuen/go-mall/pkg/logger"
 	"github.com/proyuen/go-mall/pkg/token"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
+// Security bundles optional request-screening middleware. Nil fields are skipped.
+type Security struct {
+	// TrustedProxies lists proxy IPs/CIDRs allowed to set X-Forwarded-For.
+	// Empty means the TCP peer address is the client IP, so it cannot be spoofed
+	// to dodge IP rules or bot protection.
+	TrustedProxies []string
+	IPFilter       gin.HandlerFunc // Rejects denied client IPs on every route
+	SpecValidator  gin.HandlerFunc // OpenAPI request validation for /api/v1
+	LoginGuard     gin.HandlerFunc // Bot protection for login
+	RegisterGuard  gin.HandlerFunc // Bot protection for register
+	AdminGuard     gin.HandlerFunc // Role check for /admin after the token's role claim; admin routes are not registered without it
+	AuditTrail     gin.HandlerFunc // Records admin mutations and audited reads; runs after AdminGuard
+	Tenant         gin.HandlerFunc // Resolves the store of API requests in multi-store mode
+	// Sessions ends the tokens of sessions evicted by the session limit; nil
+	// accepts every valid token.
+	Sessions service.SessionStore
+	// Revoker rejects tokens revoked before they expire, such as those of
+	// users who logged out; nil accepts every valid token.
+	Revoker token.Revoker
+}
+
+// Handlers are the handlers the router serves. User, Product and Order are
+// required; the routes of any other handler are not registered while it is
+// nil, so that optional features can be left out.
+type Handlers struct {
+	User                 *handler.UserHandler
+	Product              *handler.ProductHandler
+	Order                *handler.OrderHandler
+	Admin                *handler.AdminHandler
+	Notification         *handler.NotificationHandler
+	Webhook              *handler.WebhookHandler
+	Currency             *handler.CurrencyHandler
+	Translation          *handler.TranslationHandler
+	Fulfillment          *handler.FulfillmentHandler
+	PaymentMethod        *handler.PaymentMethodHandler
+	Payment              *handler.PaymentHandler
+	Promotion            *handler.PromotionHandler
+	Coupon               *handler.CouponHandler
+	Subscription         *handler.SubscriptionHandler
+	Digital              *handler.DigitalHandler
+	Inventory            *handler.InventoryHandler
+	Synonym              *handler.SynonymHandler
+	Merchandising        *handler.MerchandisingHandler
+	Review               *handler.ReviewHandler
+	NotificationTemplate *handler.NotificationTemplateHandler
+	Broadcast            *handler.BroadcastHandler
+	OrderHistory         *handler.OrderHistoryHandler
+	FailedMessage        *handler.FailedMessageHandler
+	CustomerGroup        *handler.CustomerGroupHandler
+	Quote                *handler.QuoteHandler
+	Purchasing           *handler.PurchasingHandler
+	Picking              *handler.PickingHandler
+	Support              *handler.SupportHandler
+	Dispute              *handler.DisputeHandler
+	Cart                 *handler.CartHandler
+	PriceSchedule        *handler.PriceScheduleHandler
+	StockHold            *handler.StockHoldHandler
+	OrderModification    *handler.OrderModificationHandler
+	Address              *handler.AddressHandler
+	ShippingRestriction  *handler.ShippingRestrictionHandler
+	PasswordReset        *handler.PasswordResetHandler
+	APIV2                http.Handler // /api/v2, served by the gRPC gateway
+	GraphQL              http.Handler // /graphql
+}
+
 // Router struct holds dependencies for routing.
 type Router struct {
-	userHandler    *handler.UserHandler
-	productHandler *handler.ProductHandler
-	orderHandler   *handler.OrderHandler
-	tokenMaker     token.Maker
+	handlers   Handlers
+	tokenMaker token.Maker
+	reporter   errreport.Reporter
+	security   Security
 }
 
 // NewRouter creates a new Router instance.
-func NewRouter(userHandler *handler.UserHandler, productHandler *handler.ProductHandler, orderHandler *handler.OrderHandler, tokenMaker token.Maker) *Router {
+func NewRouter(handlers Handlers, tokenMaker token.Maker, reporter errreport.Reporter, security Security) *Router {
+	if reporter == nil {
+		reporter = errreport.Nop()
+	}
 	return &Router{
-		userHandler:    userHandler,
-		productHandler: productHandler,
-		orderHandler:   orderHandler,
-		tokenMaker:     tokenMaker,
+		handlers:   handlers,
+		tokenMaker: tokenMaker,
+		reporter:   reporter,
+		security:   security,
 	}
 }
 
 // InitRoutes initializes all application routes.
 func (r *Router) InitRoutes() *gin.Engine {
-	engine := gin.Default()
+	// gin.New instead of gin.Default: access logs go through slog with the request and trace IDs attached
+	engine := gin.New()
+	engine.Use(gin.Recovery(), middleware.Tracing(), middleware.RequestID(), middleware.AccessLog(), middleware.ErrorReporting(r.reporter))
+	if err := engine.SetTrustedProxies(r.security.TrustedProxies); err != nil {
+		slog.Error("Invalid trusted proxies, trusting none", "trusted_proxies", r.security.TrustedProxies, logger.Err(err))
+		_ = engine.SetTrustedProxies(nil)
+	}
+	if r.security.IPFilter != nil {
+		engine.Use(r.security.IPFilter)
+	}
 
 	// Metrics endpoint
 	engine.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
+	// API documentation: Swagger UI at /swagger/index.html, raw spec at /swagger/doc.json
+	engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	// Read-only catalog queries for the storefront; authenticates optional tokens itself
+	if r.handlers.GraphQL != nil {
+		engine.POST("/graphql", append(withGuard(r.security.Tenant, middleware.SalesChannel()), gin.WrapH(r.handlers.GraphQL))...)
+	}
+
+	// Provider callbacks, authenticated by a shared token or the provider's
+	// signature instead of a JWT
+	if r.handlers.Notification != nil {
+		engine.POST("/webhooks/ses", r.handlers.Notification.SESWebhook)
+	}
+	if r.handlers.Payment != nil {
+		engine.POST("/webhooks/payments/:provider", r.handlers.Payment.Notify)
+	}
+
 	// API Group for version 1
 	v1 := engine.Group("/api/v1")
+	if r.security.SpecValidator != nil {
+		v1.Use(r.security.SpecValidator)
+	}
+	if r.security.Tenant != nil {
+		v1.Use(r.security.Tenant)
+	}
+	// Listings and checkout only offer the SKUs sold on the caller's channel
+	v1.Use(middleware.SalesChannel())
 	{
 		// User routes
 		userRoutes := v1.Group("/users")
 		{
-			userRoutes.POST("/register", r.userHandler.Register)
-			userRoutes.POST("/login", r.userHandler.Login)
+			userRoutes.POST("/register", withGuard(r.security.RegisterGuard, r.handlers.User.Register)...)
+			userRoutes.POST("/login", withGuard(r.security.LoginGuard, r.handlers.User.Login)...)
+			userRoutes.POST("/refresh", r.handlers.User.Refresh)
+			userRoutes.POST("/logout", middleware.AuthMiddleware(r.tokenMaker, r.security.Sessions, r.security.Revoker), r.handlers.User.Logout)
+			if r.handlers.PasswordReset != nil {
+				userRoutes.POST("/forgot-password", r.handlers.PasswordReset.ForgotPassword)
+				userRoutes.POST("/reset-password", r.handlers.PasswordReset.ResetPassword)
+			}
+
+			// Protected routes
+			meRoutes := userRoutes.Group("/me", middleware.AuthMiddleware(r.tokenMaker, r.security.Sessions, r.security.Revoker))
+			if r.handlers.Notification != nil {
+				meRoutes.GET("/notification-preferences", r.handlers.Notification.GetPreferences)
+				meRoutes.PUT("/notification-preferences", r.handlers.Notification.UpdatePreferences)
+				meRoutes.POST("/push-devices", r.handlers.Notification.RegisterPushDevice)
+				meRoutes.DELETE("/push-devices", r.handlers.Notification.UnregisterPushDevice)
+				meRoutes.GET("/notifications", r.handlers.Notification.ListNotifications)
+				meRoutes.POST("/notifications/read-all", r.handlers.Notification.MarkAllNotificationsRead)
+				meRoutes.POST("/notifications/:id/read", r.handlers.Notification.MarkNotificationRead)
+			}
+			if r.handlers.Currency != nil {
+				meRoutes.PUT("/currency", r.handlers.Currency.UpdatePreference)
+			}
+			if r.handlers.PaymentMethod != nil {
+				meRoutes.GET("/payment-methods", r.handlers.PaymentMethod.ListPaymentMethods)
+				meRoutes.POST("/payment-methods", r.handlers.PaymentMethod.SavePaymentMethod)
+				meRoutes.DELETE("/payment-methods/:id", r.handlers.PaymentMethod.DeletePaymentMethod)
+			}
+			if r.handlers.Support != nil {
+				meRoutes.GET("/store-credits", r.handlers.Support.ListMyStoreCredits)
+			}
+			if r.handlers.Subscription != nil {
+				meRoutes.GET("/subscriptions", r.handlers.Subscription.ListSubscriptions)
+				meRoutes.POST("/subscriptions", r.handlers.Subscription.Subscribe)
+				meRoutes.POST("/subscriptions/:id/pause", r.handlers.Subscription.PauseSubscription)
+				meRoutes.POST("/subscriptions/:id/resume", r.handlers.Subscription.ResumeSubscription)
+				meRoutes.POST("/subscriptions/:id/skip", r.handlers.Subscription.SkipSubscription)
+				meRoutes.POST("/subscriptions/:id/cancel", r.handlers.Subscription.CancelSubscription)
+			}
 		}
 
 		// Product routes
 		productRoutes := v1.Group("/products")
 		{
-			// Protected routes
-			productRoutes.POST("", middleware.AuthMiddleware(r.tokenMaker), r.productHandler.CreateProduct)
-			
-			// Public routes
-			productRoutes.GET("/:id", r.productHandler.GetProduct)
-			productRoutes.GET("", r.productHandler.ListProducts)
+			// Admin-only routes; the role is the one claimed by the access token
+			productRoutes.POST("", middleware.AuthMiddleware(r.tokenMaker, r.security.Sessions, r.security.Revoker), middleware.RequireRoles(model.RoleAdmin), r.handlers.Product.CreateProduct)
+
+			// Public routes; a token only selects the caller's preferred currency
+			productRoutes.GET("/popular", middleware.OptionalAuth(r.tokenMaker, r.security.Sessions, r.security.Revoker), r.handlers.Product.ListPopularProducts)
+			productRoutes.GET("/suggest", r.handlers.Product.SuggestProducts)
+			productRoutes.GET("/:id", middleware.OptionalAuth(r.tokenMaker, r.security.Sessions, r.security.Revoker), r.handlers.Product.GetProduct)
+			productRoutes.GET("", middleware.OptionalAuth(r.tokenMaker, r.security.Sessions, r.security.Revoker), r.handlers.Product.ListProducts)
+		}
+
+		if r.handlers.Currency != nil {
+			v1.GET("/currencies", r.handlers.Currency.ListCurrencies)
 		}
 
 		// Order routes (All protected)
 		orderRoutes := v1.Group("/orders")
-		orderRoutes.Use(middleware.AuthMiddleware(r.tokenMaker))
+		orderRoutes.Use(middleware.AuthMiddleware(r.tokenMaker, r.security.Sessions, r.security.Revoker))
+		{
+			orderRoutes.POST("", r.handlers.Order.CreateOrder)
+			if r.handlers.OrderHistory != nil {
+				orderRoutes.GET("", r.handlers.OrderHistory.ListOrders)
+			}
+			if r.handlers.Payment != nil {
+				orderRoutes.POST("/:id/pay", r.handlers.Payment.PayOrder)
+			}
+			if r.handlers.OrderModification != nil {
+				orderRoutes.PUT("/:id/items/:item_id", r.handlers.OrderModification.ChangeItemQuantity)
+				orderRoutes.PUT("/:id/shipping-address", r.handlers.OrderModification.ChangeShippingAddress)
+				orderRoutes.GET("/:id/modifications", r.handlers.OrderModification.ListOrderModifications)
+			}
+		}
+
+		if r.handlers.Address != nil {
+			v1.POST("/addresses/validate", middleware.AuthMiddleware(r.tokenMaker, r.security.Sessions, r.security.Revoker), r.handlers.Address.ValidateAddress)
+		}
+
+		// Quote routes for bulk buyers (All protected)
+		if r.handlers.Quote != nil {
+			quoteRoutes := v1.Group("/quotes")
+			quoteRoutes.Use(middleware.AuthMiddleware(r.tokenMaker, r.security.Sessions, r.security.Revoker))
+			{
+				quoteRoutes.POST("", r.handlers.Quote.RequestQuote)
+				quoteRoutes.GET("", r.handlers.Quote.ListQuotes)
+				quoteRoutes.GET("/:id", r.handlers.Quote.GetQuote)
+				quoteRoutes.POST("/:id/accept", r.handlers.Quote.AcceptQuote)
+			}
+		}
+
+		// Cart routes (All protected)
+		if r.handlers.Cart != nil {
+			cartRoutes := v1.Group("/cart")
+			cartRoutes.Use(middleware.AuthMiddleware(r.tokenMaker, r.security.Sessions, r.security.Revoker))
+			{
+				cartRoutes.GET("", r.handlers.Cart.GetCart)
+				cartRoutes.DELETE("", r.handlers.Cart.ClearCart)
+				cartRoutes.PUT("/items/:sku_id", r.handlers.Cart.SetCartItem)
+				cartRoutes.DELETE("/items/:sku_id", r.handlers.Cart.RemoveCartItem)
+			}
+		}
+
+		// Review feedback routes (All protected)
+		if r.handlers.Review != nil {
+			reviewRoutes := v1.Group("/reviews")
+			reviewRoutes.Use(middleware.AuthMiddleware(r.tokenMaker, r.security.Sessions, r.security.Revoker))
+			{
+				reviewRoutes.PUT("/:id/vote", r.handlers.Review.VoteReview)
+				reviewRoutes.POST("/:id/reports", r.handlers.Review.ReportReview)
+			}
+		}
+
+		checkoutRoutes := v1.Group("/checkout/sessions")
+		checkoutRoutes.Use(middleware.AuthMiddleware(r.tokenMaker, r.security.Sessions, r.security.Revoker))
 		{
-			orderRoutes.POST("", r.orderHandler.CreateOrder)
+			checkoutRoutes.POST("", r.handlers.Order.CreateCheckoutSession)
+			checkoutRoutes.GET("/:id", r.handlers.Order.GetCheckoutSession)
+			checkoutRoutes.POST("/:id/confirm", r.handlers.Order.ConfirmCheckoutSession)
+		}
+
+		// Admin routes (Authenticated + admin role)
+		if r.handlers.Admin != nil && r.security.AdminGuard != nil {
+			adminRoutes := v1.Group("/admin")
+			adminRoutes.Use(middleware.AuthMiddleware(r.tokenMaker, r.security.Sessions, r.security.Revoker), middleware.RequireRoles(model.RoleAdmin), r.security.AdminGuard)
+			if r.security.AuditTrail != nil {
+				adminRoutes.Use(r.security.AuditTrail)
+			}
+			{
+				adminRoutes.GET("/dashboard", r.handlers.Admin.Dashboard)
+				adminRoutes.GET("/audit-logs", r.handlers.Admin.ListAuditLogs)
+				adminRoutes.GET("/loglevel", r.handlers.Admin.GetLogLevel)
+				adminRoutes.PUT("/loglevel", r.handlers.Admin.PutLogLevel)
+				adminRoutes.GET("/diagnostics/cache", r.handlers.Admin.CacheDiagnostics)
+				adminRoutes.GET("/diagnostics/mq", r.handlers.Admin.MQDiagnostics)
+				adminRoutes.GET("/ip-rules", r.handlers.Admin.ListIPRules)
+				adminRoutes.POST("/ip-rules", r.handlers.Admin.PutIPRule)
+				adminRoutes.DELETE("/ip-rules", r.handlers.Admin.DeleteIPRule)
+				adminRoutes.POST("/skus/bulk-price", r.handlers.Product.BulkUpdatePrices)
+				if r.handlers.Notification != nil {
+					adminRoutes.GET("/notification-deliveries", r.handlers.Notification.ListDeliveries)
+				}
+				if r.handlers.NotificationTemplate != nil {
+					adminRoutes.GET("/notification-templates", r.handlers.NotificationTemplate.ListNotificationTemplates)
+					adminRoutes.POST("/notification-templates/preview", r.handlers.NotificationTemplate.PreviewNotificationTemplate)
+					adminRoutes.POST("/notification-templates/test-send", r.handlers.NotificationTemplate.TestSendNotificationTemplate)
+					adminRoutes.GET("/notification-templates/:kind/:channel/:locale/versions", r.handlers.NotificationTemplate.ListNotificationTemplateVersions)
+					adminRoutes.PUT("/notification-templates/:kind/:channel/:locale", r.handlers.NotificationTemplate.PutNotificationTemplate)
+					adminRoutes.DELETE("/notification-templates/:kind/:channel/:locale", r.handlers.NotificationTemplate.DeleteNotificationTemplate)
+				}
+				if r.handlers.Broadcast != nil {
+					adminRoutes.GET("/broadcasts", r.handlers.Broadcast.ListBroadcasts)
+					adminRoutes.POST("/broadcasts", r.handlers.Broadcast.CreateBroadcast)
+					adminRoutes.POST("/broadcasts/audience", r.handlers.Broadcast.CountBroadcastAudience)
+					adminRoutes.GET("/broadcasts/:id", r.handlers.Broadcast.GetBroadcast)
+				}
+				if r.handlers.FailedMessage != nil {
+					adminRoutes.GET("/failed-messages", r.handlers.FailedMessage.ListFailedMessages)
+					adminRoutes.GET("/failed-messages/:id", r.handlers.FailedMessage.GetFailedMessage)
+					adminRoutes.PUT("/failed-messages/:id", r.handlers.FailedMessage.UpdateFailedMessage)
+					adminRoutes.POST("/failed-messages/:id/replay", r.handlers.FailedMessage.ReplayFailedMessage)
+				}
+				if r.handlers.Webhook != nil {
+					adminRoutes.GET("/webhooks", r.handlers.Webhook.ListSubscriptions)
+					adminRoutes.POST("/webhooks", r.handlers.Webhook.Subscribe)
+					adminRoutes.DELETE("/webhooks/:id", r.handlers.Webhook.Unsubscribe)
+					adminRoutes.GET("/webhook-deliveries", r.handlers.Webhook.ListDeliveries)
+					adminRoutes.GET("/webhook-deliveries/:id", r.handlers.Webhook.GetDelivery)
+				}
+				if r.handlers.Translation != nil {
+					adminRoutes.GET("/products/:id/translations", r.handlers.Translation.ListTranslations)
+					adminRoutes.PUT("/products/:id/translations/:locale", r.handlers.Translation.PutTranslation)
+					adminRoutes.DELETE("/products/:id/translations/:locale", r.handlers.Translation.DeleteTranslation)
+				}
+				if r.handlers.Fulfillment != nil {
+					adminRoutes.GET("/orders/:id/shipments", r.handlers.Fulfillment.ListShipments)
+					adminRoutes.POST("/orders/:id/shipments", r.handlers.Fulfillment.ShipOrder)
+					adminRoutes.POST("/orders/:id/cancel", r.handlers.Fulfillment.CancelOrder)
+					adminRoutes.PUT("/shipments/:id/tracking", r.handlers.Fulfillment.SetShipmentTracking)
+					adminRoutes.POST("/shipments/:id/deliver", r.handlers.Fulfillment.DeliverShipment)
+				}
+				if r.handlers.Promotion != nil {
+					adminRoutes.GET("/promotions", r.handlers.Promotion.ListPromotions)
+					adminRoutes.POST("/promotions", r.handlers.Promotion.CreatePromotion)
+					adminRoutes.DELETE("/promotions/:id", r.handlers.Promotion.DeletePromotion)
+				}
+				if r.handlers.ShippingRestriction != nil {
+					adminRoutes.GET("/shipping-restrictions", r.handlers.ShippingRestriction.ListShippingRestrictions)
+					adminRoutes.POST("/shipping-restrictions", r.handlers.ShippingRestriction.CreateShippingRestriction)
+					adminRoutes.DELETE("/shipping-restrictions/:id", r.handlers.ShippingRestriction.DeleteShippingRestriction)
+				}
+				if r.handlers.Coupon != nil {
+					adminRoutes.GET("/coupon-campaigns", r.handlers.Coupon.ListCouponCampaigns)
+					adminRoutes.POST("/coupon-campaigns", r.handlers.Coupon.CreateCouponCampaign)
+					adminRoutes.POST("/coupon-campaigns/:id/codes", r.handlers.Coupon.GenerateCouponCodes)
+					adminRoutes.GET("/coupon-campaigns/:id/codes/export", r.handlers.Coupon.ExportCouponCodes)
+				}
+				if r.handlers.Digital != nil {
+					adminRoutes.POST("/skus/:id/license-keys", r.handlers.Digital.AddLicenseKeys)
+				}
+				if r.handlers.CustomerGroup != nil {
+					adminRoutes.PUT("/users/:id/customer-group", r.handlers.CustomerGroup.SetCustomerGroup)
+					adminRoutes.GET("/customer-groups/:group/members", r.handlers.CustomerGroup.ListCustomerGroupMembers)
+					adminRoutes.GET("/skus/:id/price-tiers", r.handlers.CustomerGroup.ListPriceTiers)
+					adminRoutes.PUT("/skus/:id/price-tiers/:group", r.handlers.CustomerGroup.PutPriceTier)
+					adminRoutes.DELETE("/skus/:id/price-tiers/:group", r.handlers.CustomerGroup.DeletePriceTier)
+				}
+				if r.handlers.PriceSchedule != nil {
+					adminRoutes.GET("/skus/:id/price-schedules", r.handlers.PriceSchedule.ListPriceSchedules)
+					adminRoutes.POST("/skus/:id/price-schedules", r.handlers.PriceSchedule.CreatePriceSchedule)
+					adminRoutes.POST("/price-schedules/:id/cancel", r.handlers.PriceSchedule.CancelPriceSchedule)
+				}
+				if r.handlers.Quote != nil {
+					adminRoutes.GET("/quotes", r.handlers.Quote.ListAllQuotes)
+					adminRoutes.POST("/quotes/:id/respond", r.handlers.Quote.RespondToQuote)
+					adminRoutes.POST("/quotes/:id/decline", r.handlers.Quote.DeclineQuote)
+				}
+				if r.handlers.Purchasing != nil {
+					adminRoutes.GET("/suppliers", r.handlers.Purchasing.ListSuppliers)
+					adminRoutes.POST("/suppliers", r.handlers.Purchasing.CreateSupplier)
+					adminRoutes.PUT("/suppliers/:id", r.handlers.Purchasing.UpdateSupplier)
+					adminRoutes.GET("/purchase-orders", r.handlers.Purchasing.ListPurchaseOrders)
+					adminRoutes.POST("/purchase-orders", r.handlers.Purchasing.CreatePurchaseOrder)
+					adminRoutes.GET("/purchase-orders/:id", r.handlers.Purchasing.GetPurchaseOrder)
+					adminRoutes.PUT("/purchase-orders/:id/expected-at", r.handlers.Purchasing.ReschedulePurchaseOrder)
+					adminRoutes.POST("/purchase-orders/:id/receipts", r.handlers.Purchasing.ReceivePurchaseOrder)
+					adminRoutes.POST("/purchase-orders/:id/cancel", r.handlers.Purchasing.CancelPurchaseOrder)
+					adminRoutes.GET("/skus/:id/stock-movements", r.handlers.Purchasing.ListStockMovements)
+				}
+				if r.handlers.Picking != nil {
+					adminRoutes.GET("/pick-tasks", r.handlers.Picking.ListPickTasks)
+					adminRoutes.POST("/pick-tasks/:id/claim", r.handlers.Picking.ClaimPickTask)
+					adminRoutes.POST("/pick-tasks/:id/complete", r.handlers.Picking.CompletePickTask)
+				}
+				if r.handlers.Support != nil {
+					adminRoutes.GET("/users/:id/orders", r.handlers.Support.ListCustomerOrders)
+					adminRoutes.GET("/users/:id/store-credits", r.handlers.Support.ListCustomerStoreCredits)
+					adminRoutes.POST("/users/:id/store-credits", r.handlers.Support.GrantStoreCredit)
+					adminRoutes.GET("/orders/:id", r.handlers.Support.GetCustomerOrder)
+					adminRoutes.PUT("/orders/:id/shipping-address", r.handlers.Support.UpdateShippingAddress)
+					adminRoutes.POST("/orders/:id/notifications", r.handlers.Support.ResendOrderNotification)
+				}
+				if r.handlers.Dispute != nil {
+					adminRoutes.GET("/disputes", r.handlers.Dispute.ListDisputes)
+					adminRoutes.GET("/disputes/:id", r.handlers.Dispute.GetDispute)
+					adminRoutes.POST("/disputes/:id/evidence", r.handlers.Dispute.SubmitDisputeEvidence)
+				}
+				if r.handlers.Cart != nil {
+					adminRoutes.GET("/carts/recovery-stats", r.handlers.Cart.CartRecoveryStats)
+				}
+				if r.handlers.Inventory != nil {
+					adminRoutes.GET("/inventory/snapshot", r.handlers.Inventory.StockSnapshot)
+					adminRoutes.POST("/inventory/sync", r.handlers.Inventory.SyncStock)
+				}
+				if r.handlers.StockHold != nil {
+					adminRoutes.GET("/inventory/holds", r.handlers.StockHold.ListStockHolds)
+					adminRoutes.POST("/inventory/holds", r.handlers.StockHold.PlaceStockHold)
+					adminRoutes.PUT("/inventory/holds/:id/expiry", r.handlers.StockHold.SetStockHoldExpiry)
+					adminRoutes.POST("/inventory/holds/:id/release", r.handlers.StockHold.ReleaseStockHold)
+				}
+				if r.handlers.Synonym != nil {
+					adminRoutes.GET("/search/synonyms", r.handlers.Synonym.ListSynonyms)
+					adminRoutes.POST("/search/synonyms", r.handlers.Synonym.CreateSynonyms)
+					adminRoutes.PUT("/search/synonyms/:id", r.handlers.Synonym.UpdateSynonyms)
+					adminRoutes.DELETE("/search/synonyms/:id", r.handlers.Synonym.DeleteSynonyms)
+				}
+				if r.handlers.Merchandising != nil {
+					adminRoutes.GET("/search/rules", r.handlers.Merchandising.ListSearchRules)
+					adminRoutes.POST("/search/rules", r.handlers.Merchandising.CreateSearchRule)
+					adminRoutes.DELETE("/search/rules/:id", r.handlers.Merchandising.DeleteSearchRule)
+					adminRoutes.GET("/search/zero-results", r.handlers.Merchandising.ListZeroResults)
+				}
+				if r.handlers.Review != nil {
+					adminRoutes.GET("/review-reports", r.handlers.Review.ListModerationQueue)
+					adminRoutes.POST("/reviews/:id/moderate", r.handlers.Review.ModerateReview)
+				}
+			}
 		}
 	}
 
+	// API version 2: the REST mapping of the gRPC API, which authenticates and
+	// validates calls itself. Only the bot protection guards run here, after
+	// the store is resolved for the gateway to pass on.
+	if r.handlers.APIV2 != nil {
+		guards := map[string]gin.HandlerFunc{
+			"/users/register": r.security.RegisterGuard,
+			"/users/login":    r.security.LoginGuard,
+		}
+		engine.Any("/api/v2/*path", append(withGuard(r.security.Tenant, pathGuards(guards)), gin.WrapH(r.handlers.APIV2))...)
+	}
+
 	return engine
-}
\ No newline at end of file
+}
+
+// pathGuards runs the guard registered for a POST to the wildcard path, if any.
+func pathGuards(guards map[string]gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if guard := guards[c.Param("path")]; guard != nil && c.Request.Method == http.MethodPost {
+			guard(c) // Calls c.Next itself unless it aborts
+			return
+		}
+		c.Next()
+	}
+}
+
+// withGuard prepends an optional middleware to a handler chain.
+func withGuard(guard gin.HandlerFunc, h gin.HandlerFunc) []gin.HandlerFunc {
+	if guard == nil {
+		return []gin.HandlerFunc{h}
+	}
+	return []gin.HandlerFunc{guard, h}
+}
@@ -0,0 +1,152 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/proyuen/go-mall/api/swagger"
+	"github.com/proyuen/go-mall/internal/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ginParam = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// TestRoutesMatchSpec fails when a route is added or removed without regenerating
+// the Swagger spec (make swagger), or vice versa.
+func TestRoutesMatchSpec(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var spec struct {
+		BasePath string                                `json:"basePath"`
+		Paths    map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(swagger.SwaggerInfo.ReadDoc()), &spec))
+
+	documented := make(map[string]bool)
+	for path, ops := range spec.Paths {
+		for method := range ops {
+			documented[strings.ToUpper(method)+" "+spec.BasePath+path] = true
+		}
+	}
+
+	r := NewRouter(Handlers{
+		User:                 &handler.UserHandler{},
+		Product:              &handler.ProductHandler{},
+		Order:                &handler.OrderHandler{},
+		Admin:                &handler.AdminHandler{},
+		Notification:         &handler.NotificationHandler{},
+		Webhook:              &handler.WebhookHandler{},
+		Currency:             &handler.CurrencyHandler{},
+		Translation:          &handler.TranslationHandler{},
+		Fulfillment:          &handler.FulfillmentHandler{},
+		PaymentMethod:        &handler.PaymentMethodHandler{},
+		Payment:              &handler.PaymentHandler{},
+		Promotion:            &handler.PromotionHandler{},
+		Coupon:               &handler.CouponHandler{},
+		Subscription:         &handler.SubscriptionHandler{},
+		Digital:              &handler.DigitalHandler{},
+		Inventory:            &handler.InventoryHandler{},
+		Synonym:              &handler.SynonymHandler{},
+		Merchandising:        &handler.MerchandisingHandler{},
+		Review:               &handler.ReviewHandler{},
+		NotificationTemplate: &handler.NotificationTemplateHandler{},
+		Broadcast:            &handler.BroadcastHandler{},
+		OrderHistory:         &handler.OrderHistoryHandler{},
+		FailedMessage:        &handler.FailedMessageHandler{},
+		CustomerGroup:        &handler.CustomerGroupHandler{},
+		Quote:                &handler.QuoteHandler{},
+		Purchasing:           &handler.PurchasingHandler{},
+		Picking:              &handler.PickingHandler{},
+		Support:              &handler.SupportHandler{},
+		Dispute:              &handler.DisputeHandler{},
+		Cart:                 &handler.CartHandler{},
+		PriceSchedule:        &handler.PriceScheduleHandler{},
+		StockHold:            &handler.StockHoldHandler{},
+		OrderModification:    &handler.OrderModificationHandler{},
+		Address:              &handler.AddressHandler{},
+		ShippingRestriction:  &handler.ShippingRestrictionHandler{},
+		PasswordReset:        &handler.PasswordResetHandler{},
+	}, nil, nil, Security{
+		AdminGuard: func(c *gin.Context) {},
+	})
+	registered := make(map[string]bool)
+	for _, route := range r.InitRoutes().Routes() {
+		if !strings.HasPrefix(route.Path, spec.BasePath+"/") && route.Path != spec.BasePath {
+			continue
+		}
+		registered[route.Method+" "+ginParam.ReplaceAllString(route.Path, "{$1}")] = true
+	}
+
+	for route := range registered {
+		assert.True(t, documented[route], "route %s is not documented in the OpenAPI spec", route)
+	}
+	for route := range documented {
+		assert.True(t, registered[route], "spec documents %s but no such route is registered", route)
+	}
+}
+
+func TestAPIV2Guards(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	apiV2 := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	deny := func(c *gin.Context) { c.AbortWithStatus(http.StatusTooManyRequests) }
+	r := NewRouter(Handlers{User: &handler.UserHandler{}, Product: &handler.ProductHandler{}, Order: &handler.OrderHandler{}, APIV2: apiV2}, nil, nil, Security{
+		LoginGuard: deny,
+	})
+	engine := r.InitRoutes()
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+	}{
+		{"GuardedLogin", http.MethodPost, "/api/v2/users/login", http.StatusTooManyRequests},
+		{"UnguardedRegister", http.MethodPost, "/api/v2/users/register", http.StatusOK},
+		{"OtherMethod", http.MethodGet, "/api/v2/users/login", http.StatusOK},
+		{"OtherRoute", http.MethodGet, "/api/v2/products", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestTenantScopesAPIs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	apiV2 := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	unknownStore := func(c *gin.Context) { c.AbortWithStatus(http.StatusNotFound) }
+	r := NewRouter(Handlers{User: &handler.UserHandler{}, Product: &handler.ProductHandler{}, Order: &handler.OrderHandler{}, APIV2: apiV2, GraphQL: apiV2}, nil, nil, Security{
+		Tenant: unknownStore,
+	})
+	engine := r.InitRoutes()
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+	}{
+		{"V1", http.MethodGet, "/api/v1/products", http.StatusNotFound},
+		{"V2", http.MethodGet, "/api/v2/products", http.StatusNotFound},
+		{"GraphQL", http.MethodPost, "/graphql", http.StatusNotFound},
+		{"Metrics", http.MethodGet, "/metrics", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
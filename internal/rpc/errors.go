@@ -0,0 +1,96 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/validation"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// acceptLanguageKey selects the language of validation messages, like the
+// HTTP Accept-Language header.
+const acceptLanguageKey = "accept-language"
+
+// internalMessage is all callers learn about a server-side failure; the
+// cause is logged and reported.
+const internalMessage = "internal error"
+
+// validate checks req against its binding tags, the rules the HTTP API
+// applies to the same request. Failures become InvalidArgument with a
+// BadRequest detail listing each field.
+func validate(ctx context.Context, req any) error {
+	err := binding.Validator.ValidateStruct(req)
+	if err == nil {
+		return nil
+	}
+	fieldErrs, ok := validation.Translate(err, firstValue(ctx, acceptLanguageKey))
+	if !ok {
+		return status.Error(codes.InvalidArgument, "invalid request")
+	}
+
+	badRequest := &errdetails.BadRequest{}
+	for _, fe := range fieldErrs {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       fe.Field,
+			Description: fe.Message,
+			Reason:      fe.Rule,
+		})
+	}
+	st, detailErr := status.New(codes.InvalidArgument, "invalid request parameters").WithDetails(badRequest)
+	if detailErr != nil {
+		return status.Error(codes.InvalidArgument, "invalid request parameters")
+	}
+	return st.Err()
+}
+
+// internalError logs err and returns the Internal error callers see instead.
+func internalError(ctx context.Context, msg string, err error, args ...any) error {
+	slog.ErrorContext(ctx, msg, append(args, logger.Err(err))...)
+	return status.Error(codes.Internal, internalMessage)
+}
+
+// statusCodes are the gRPC codes of the HTTP statuses apperr codes map to.
+var statusCodes = map[int]codes.Code{
+	http.StatusBadRequest:         codes.InvalidArgument,
+	http.StatusUnauthorized:       codes.Unauthenticated,
+	http.StatusNotFound:           codes.NotFound,
+	http.StatusConflict:           codes.FailedPrecondition,
+	http.StatusServiceUnavailable: codes.Unavailable,
+}
+
+// serviceError returns the error callers see for a service error the method
+// does not report otherwise. An *apperr.Error keeps its message, with an
+// ErrorInfo detail carrying its code as the reason along with its metadata;
+// anything else is an internalError.
+func serviceError(ctx context.Context, msg string, err error, args ...any) error {
+	var appErr *apperr.Error
+	code, ok := codes.Internal, errors.As(err, &appErr)
+	if ok {
+		code, ok = statusCodes[appErr.Code.HTTPStatus()]
+	}
+	if !ok {
+		return internalError(ctx, msg, err, args...)
+	}
+
+	info := &errdetails.ErrorInfo{Reason: string(appErr.Code), Domain: "go-mall"}
+	if len(appErr.Meta) > 0 {
+		info.Metadata = make(map[string]string, len(appErr.Meta))
+		for key, value := range appErr.Meta {
+			info.Metadata[key] = fmt.Sprint(value)
+		}
+	}
+	st, detailErr := status.New(code, appErr.Message).WithDetails(info)
+	if detailErr != nil {
+		return status.Error(code, appErr.Message)
+	}
+	return st.Err()
+}
@@ -0,0 +1,158 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/channel"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/proyuen/go-mall/pkg/tenant"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGateway(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		header     map[string]string
+		mockSetup  func(deps testDeps)
+		wantStatus int
+		wantBody   []string
+	}{
+		{
+			name:   "Register",
+			method: http.MethodPost,
+			target: "/api/v2/users/register",
+			body:   `{"username":"alice","email":"alice@example.com","password":"secret123","unknown":1}`,
+			mockSetup: func(deps testDeps) {
+				deps.user.EXPECT().
+					Register(gomock.Any(), &service.UserRegisterReq{Username: "alice", Email: "alice@example.com", Password: "secret123"}).
+					DoAndReturn(func(ctx context.Context, _ *service.UserRegisterReq) (*service.UserRegisterResp, error) {
+						assert.Equal(t, "req-1", logger.RequestIDFromContext(ctx), "request ID carried over to gRPC")
+						return &service.UserRegisterResp{UserID: 42, Username: "alice", Email: "alice@example.com"}, nil
+					})
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`"user_id":"42"`, `"username":"alice"`},
+		},
+		{
+			name:       "RegisterInvalidEmailLocalized",
+			method:     http.MethodPost,
+			target:     "/api/v2/users/register",
+			body:       `{"username":"alice","email":"not-an-email","password":"secret123"}`,
+			header:     map[string]string{"Accept-Language": "zh-CN"},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   []string{`"field":"email"`, `"reason":"email"`},
+		},
+		{
+			name:       "MalformedJSON",
+			method:     http.MethodPost,
+			target:     "/api/v2/users/register",
+			body:       `{"username":`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "OrderWithoutToken",
+			method:     http.MethodPost,
+			target:     "/api/v2/orders",
+			body:       `{"items":[{"sku_id":"101","quantity":2}]}`,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "OrderWithToken",
+			method: http.MethodPost,
+			target: "/api/v2/orders",
+			body:   `{"items":[{"sku_id":"101","quantity":2}]}`,
+			header: map[string]string{"Authorization": "Bearer valid"},
+			mockSetup: func(deps testDeps) {
+				deps.maker.EXPECT().VerifyToken("valid").Return(&token.Payload{UserID: 7}, nil)
+				deps.order.EXPECT().
+					CreateOrder(gomock.Any(), &service.OrderCreateReq{UserID: 7, Items: []service.OrderItemReq{{SKUID: 101, Quantity: 2}}}).
+					Return(&service.OrderCreateResp{OrderID: 1, OrderNumber: "ORD1", TotalAmount: money.New(decimal.RequireFromString("99.90"), "USD")}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`"order_number":"ORD1"`, `"total_amount":"99.90"`},
+		},
+		{
+			name:   "SalesChannelForwarded",
+			method: http.MethodGet,
+			target: "/api/v2/products/101",
+			header: map[string]string{"X-Sales-Channel": "app"},
+			mockSetup: func(deps testDeps) {
+				deps.product.EXPECT().GetProduct(gomock.Any(), uint64(101)).DoAndReturn(func(ctx context.Context, _ uint64) (*service.ProductResp, error) {
+					assert.Equal(t, channel.App, channel.FromContext(ctx))
+					return &service.ProductResp{ID: 101}, nil
+				})
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "InvalidPathParameter",
+			method:     http.MethodGet,
+			target:     "/api/v2/products/abc",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "UnknownRoute",
+			method:     http.MethodGet,
+			target:     "/api/v2/carts",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, deps := newTestClient(t)
+			if tt.mockSetup != nil {
+				tt.mockSetup(deps)
+			}
+			gateway, err := NewGateway(context.Background(), conn)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req = req.WithContext(logger.WithRequestID(req.Context(), "req-1"))
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			gateway.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			for _, s := range tt.wantBody {
+				assert.Contains(t, w.Body.String(), s)
+			}
+			for k := range w.Header() {
+				assert.NotContains(t, k, "Grpc-Metadata", "gRPC metadata is not exposed")
+			}
+		})
+	}
+}
+
+func TestGatewayForwardsStore(t *testing.T) {
+	conn, deps := newMultiStoreTestClient(t)
+	toys := &model.Store{Base: model.Base{ID: 2}, Slug: "toys"}
+	deps.stores.EXPECT().Resolve(gomock.Any(), "toys", "").Return(toys, nil)
+	deps.product.EXPECT().GetProduct(gomock.Any(), uint64(101)).Return(&service.ProductResp{ID: 101}, nil)
+	gateway, err := NewGateway(context.Background(), conn)
+	require.NoError(t, err)
+
+	// As resolved by the HTTP tenant middleware
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/products/101", nil)
+	req = req.WithContext(tenant.NewContext(req.Context(), toys))
+	w := httptest.NewRecorder()
+	gateway.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
@@ -0,0 +1,215 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/proyuen/go-mall/internal/middleware"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/channel"
+	"github.com/proyuen/go-mall/pkg/errreport"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/tenant"
+	"github.com/proyuen/go-mall/pkg/token"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// authorizationKey carries "Bearer <access_token>", as the HTTP Authorization header does.
+	authorizationKey        = "authorization"
+	authorizationTypeBearer = "bearer"
+	// requestIDKey carries the request ID in both directions, like X-Request-ID over HTTP.
+	requestIDKey = "x-request-id"
+	// storeKey selects the store by slug in multi-store mode, like the HTTP store header.
+	storeKey = "x-store"
+	// channelKey names the sales channel, like the HTTP X-Sales-Channel header.
+	channelKey = "x-sales-channel"
+)
+
+// unaryRequestID reuses a well-formed x-request-id from the caller or
+// generates one, returns it in the response header and attaches it to the
+// context so every log line for the call carries it.
+func unaryRequestID(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	id := firstValue(ctx, requestIDKey)
+	if !middleware.ValidRequestID(id) {
+		id = uuid.NewString()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+	return handler(logger.WithRequestID(ctx, id), req)
+}
+
+// unaryAccessLog writes one structured line per call.
+func unaryAccessLog(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+
+	code := status.Code(err)
+	lvl := slog.LevelInfo
+	switch {
+	case isServerError(code):
+		lvl = slog.LevelError
+	case code != codes.OK:
+		lvl = slog.LevelWarn
+	}
+	clientAddr := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		clientAddr = p.Addr.String()
+	}
+	slog.Log(ctx, lvl, "gRPC request",
+		"method", info.FullMethod,
+		"code", code.String(),
+		"latency_ms", time.Since(start).Milliseconds(),
+		"peer", clientAddr,
+	)
+	return resp, err
+}
+
+// unaryErrorReporting turns panics into Internal errors and sends them, and
+// every other server-side failure, to the reporter.
+func unaryErrorReporting(reporter errreport.Reporter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				reporter.CapturePanic(ctx, rec, methodTags(info, codes.Internal))
+				slog.ErrorContext(ctx, "Recovered from panic in gRPC handler", "method", info.FullMethod, "panic", fmt.Sprint(rec))
+				resp, err = nil, status.Error(codes.Internal, internalMessage)
+			}
+		}()
+
+		resp, err = handler(ctx, req)
+		if code := status.Code(err); isServerError(code) {
+			reporter.CaptureError(ctx, fmt.Errorf("%s: %w", info.FullMethod, err), methodTags(info, code))
+		}
+		return resp, err
+	}
+}
+
+// unaryTenant serves each call for the store named by x-store, else the
+// default store. Without stores, calls are not scoped.
+func unaryTenant(stores service.StoreService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if stores == nil || strings.HasPrefix(info.FullMethod, "/grpc.health.") {
+			return handler(ctx, req)
+		}
+		store, err := stores.Resolve(ctx, firstValue(ctx, storeKey), "")
+		if errors.Is(err, service.ErrStoreNotFound) {
+			return nil, status.Error(codes.NotFound, "unknown store")
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to resolve store", "method", info.FullMethod, logger.Err(err))
+			return nil, status.Error(codes.Unavailable, "service unavailable")
+		}
+		return handler(tenant.NewContext(ctx, store), req)
+	}
+}
+
+// unaryChannel serves each call for the sales channel named by
+// x-sales-channel, else the web.
+func unaryChannel(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	name := strings.ToLower(strings.TrimSpace(firstValue(ctx, channelKey)))
+	if name == "" {
+		return handler(ctx, req)
+	}
+	if !channel.Valid(name) {
+		return nil, status.Error(codes.InvalidArgument, "unknown sales channel")
+	}
+	return handler(channel.NewContext(ctx, name), req)
+}
+
+// unaryAuth verifies the bearer token of every method not listed in public
+// and attaches its payload to the context. Like middleware.AuthMiddleware it
+// rejects tokens issued for another store than the call's, revoked tokens, unless revoker is nil, and tokens of sessions that
+// were evicted, unless sessions is nil. Calls fail with Unavailable if the
+// revocation list or the session store cannot be read. Like middleware.RequireRoles it
+// rejects tokens without one of the roles listed for the method in roles.
+func unaryAuth(tokenMaker token.Maker, sessions service.SessionStore, revoker token.Revoker, public map[string]bool, roles map[string][]string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if public[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		authorization := firstValue(ctx, authorizationKey)
+		if authorization == "" {
+			return nil, status.Error(codes.Unauthenticated, "authorization metadata is not provided")
+		}
+		fields := strings.Fields(authorization)
+		if len(fields) < 2 || !strings.EqualFold(fields[0], authorizationTypeBearer) {
+			return nil, status.Error(codes.Unauthenticated, "invalid authorization metadata format")
+		}
+
+		payload, err := tokenMaker.VerifyToken(fields[1])
+		if err != nil {
+			// Security: the cause stays in the log, not in the response.
+			slog.WarnContext(ctx, "Failed to verify token", "method", info.FullMethod, logger.Err(err))
+			return nil, status.Error(codes.Unauthenticated, "Unauthorized")
+		}
+		if storeID := tenant.StoreID(ctx); payload.StoreID != storeID {
+			slog.WarnContext(ctx, "Token was issued for another store", "method", info.FullMethod, "token_store_id", payload.StoreID, "store_id", storeID)
+			return nil, status.Error(codes.Unauthenticated, "Unauthorized")
+		}
+		if revoker != nil {
+			revoked, err := revoker.IsRevoked(ctx, payload.ID)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to check token revocation", "method", info.FullMethod, logger.Err(err))
+				return nil, status.Error(codes.Unavailable, "authentication is temporarily unavailable")
+			}
+			if revoked {
+				return nil, status.Error(codes.Unauthenticated, "token has been revoked")
+			}
+		}
+		if sessions != nil && payload.SessionID != "" {
+			active, err := sessions.Active(ctx, payload.UserID, payload.SessionID)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to check session", "method", info.FullMethod, logger.Err(err))
+				return nil, status.Error(codes.Unavailable, "authentication is temporarily unavailable")
+			}
+			if !active {
+				return nil, status.Error(codes.Unauthenticated, "session has ended")
+			}
+		}
+		if allowed, ok := roles[info.FullMethod]; ok && (payload.Role == "" || !slices.Contains(allowed, payload.Role)) {
+			return nil, status.Error(codes.PermissionDenied, "forbidden")
+		}
+
+		return handler(logger.WithUserID(auth.NewContext(ctx, payload), payload.UserID), req)
+	}
+}
+
+func firstValue(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// isServerError reports whether code blames the server rather than the
+// caller, the gRPC counterpart of a 5xx status.
+func isServerError(code codes.Code) bool {
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unimplemented:
+		return true
+	}
+	return false
+}
+
+func methodTags(info *grpc.UnaryServerInfo, code codes.Code) map[string]string {
+	return map[string]string{
+		"grpc.method": info.FullMethod,
+		"grpc.code":   code.String(),
+	}
+}
@@ -0,0 +1,56 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	mallv1 "github.com/proyuen/go-mall/api/proto/mall/v1"
+	"github.com/proyuen/go-mall/internal/handler"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/money"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type orderServer struct {
+	mallv1.UnimplementedOrderServiceServer
+	orderService service.OrderService
+}
+
+func (s *orderServer) CreateOrder(ctx context.Context, req *mallv1.CreateOrderRequest) (*mallv1.CreateOrderResponse, error) {
+	payload, ok := auth.PayloadFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authorization payload not found")
+	}
+
+	in := handler.CreateOrderRequest{Items: make([]handler.CreateOrderItemRequest, 0, len(req.GetItems())), Region: req.GetRegion()}
+	for _, item := range req.GetItems() {
+		in.Items = append(in.Items, handler.CreateOrderItemRequest{SKUID: item.GetSkuId(), Quantity: int(item.GetQuantity())})
+	}
+	if err := validate(ctx, &in); err != nil {
+		return nil, err
+	}
+
+	items := make([]service.OrderItemReq, 0, len(in.Items))
+	for _, item := range in.Items {
+		items = append(items, service.OrderItemReq{SKUID: item.SKUID, Quantity: item.Quantity})
+	}
+	resp, err := s.orderService.CreateOrder(ctx, &service.OrderCreateReq{UserID: payload.UserID, Region: in.Region, Items: items})
+	if err != nil {
+		if errors.Is(err, service.ErrTaxRegionRequired) || errors.Is(err, service.ErrTaxRegionNotSupported) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, service.ErrTaxUnavailable) {
+			return nil, status.Error(codes.Unavailable, "tax cannot be calculated right now")
+		}
+		return nil, serviceError(ctx, "Failed to create order", err, "user_id", payload.UserID)
+	}
+
+	return &mallv1.CreateOrderResponse{
+		OrderId:     resp.OrderID,
+		OrderNumber: resp.OrderNumber,
+		TotalAmount: resp.TotalAmount.Amount().StringFixed(money.Scale),
+		TaxAmount:   resp.TaxAmount.Amount().StringFixed(money.Scale),
+	}, nil
+}
@@ -0,0 +1,136 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	mallv1 "github.com/proyuen/go-mall/api/proto/mall/v1"
+	"github.com/proyuen/go-mall/internal/handler"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// defaultProductPageSize matches the HTTP API's default limit.
+const defaultProductPageSize = 10
+
+type productServer struct {
+	mallv1.UnimplementedProductServiceServer
+	productService service.ProductService
+}
+
+func (s *productServer) CreateProduct(ctx context.Context, req *mallv1.CreateProductRequest) (*mallv1.CreateProductResponse, error) {
+	in := handler.CreateProductRequest{
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+		CategoryID:  req.GetCategoryId(),
+		SKUs:        make([]handler.SKURequest, 0, len(req.GetSkus())),
+	}
+	for i, sku := range req.GetSkus() {
+		price, err := decimal.NewFromString(sku.GetPrice())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "skus[%d].price must be a decimal number", i)
+		}
+		var attributes json.RawMessage
+		if sku.GetAttributes() != nil {
+			if attributes, err = json.Marshal(sku.GetAttributes().AsMap()); err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "skus[%d].attributes: %v", i, err)
+			}
+		}
+		in.SKUs = append(in.SKUs, handler.SKURequest{Attributes: attributes, Price: price, Stock: int(sku.GetStock())})
+	}
+	if err := validate(ctx, &in); err != nil {
+		return nil, err
+	}
+
+	skus := make([]service.SKUCreateReq, 0, len(in.SKUs))
+	for _, sku := range in.SKUs {
+		skus = append(skus, service.SKUCreateReq{Attributes: sku.Attributes, Price: sku.Price, Stock: sku.Stock})
+	}
+	resp, err := s.productService.CreateProduct(ctx, &service.ProductCreateReq{
+		Name:        in.Name,
+		Description: in.Description,
+		CategoryID:  in.CategoryID,
+		SKUs:        skus,
+	})
+	if err != nil {
+		return nil, internalError(ctx, "Failed to create product", err)
+	}
+
+	return &mallv1.CreateProductResponse{SpuId: resp.SPUID}, nil
+}
+
+func (s *productServer) GetProduct(ctx context.Context, req *mallv1.GetProductRequest) (*mallv1.Product, error) {
+	if req.GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid product id")
+	}
+
+	resp, err := s.productService.GetProduct(ctx, req.GetId())
+	if errors.Is(err, service.ErrProductNotFound) {
+		return nil, status.Error(codes.NotFound, "product not found")
+	}
+	if err != nil {
+		return nil, internalError(ctx, "Failed to get product", err, "spu_id", req.GetId())
+	}
+
+	product, err := toProduct(resp)
+	if err != nil {
+		return nil, internalError(ctx, "Failed to encode product", err, "spu_id", req.GetId())
+	}
+	return product, nil
+}
+
+func (s *productServer) ListProducts(ctx context.Context, req *mallv1.ListProductsRequest) (*mallv1.ListProductsResponse, error) {
+	if req.GetOffset() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "offset cannot be negative")
+	}
+	if req.GetLimit() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit cannot be negative")
+	}
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = defaultProductPageSize
+	}
+
+	resp, err := s.productService.ListProducts(ctx, int(req.GetOffset()), limit)
+	if err != nil {
+		return nil, internalError(ctx, "Failed to list products", err)
+	}
+
+	out := &mallv1.ListProductsResponse{Products: make([]*mallv1.Product, 0, len(resp))}
+	for i := range resp {
+		product, err := toProduct(&resp[i])
+		if err != nil {
+			return nil, internalError(ctx, "Failed to encode product", err, "spu_id", resp[i].ID)
+		}
+		out.Products = append(out.Products, product)
+	}
+	return out, nil
+}
+
+func toProduct(p *service.ProductResp) (*mallv1.Product, error) {
+	product := &mallv1.Product{
+		Id:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		CategoryId:  p.CategoryID,
+		Skus:        make([]*mallv1.SKU, 0, len(p.SKUs)),
+	}
+	for _, sku := range p.SKUs {
+		attributes, err := structpb.NewStruct(sku.Attributes)
+		if err != nil {
+			return nil, fmt.Errorf("sku %d attributes: %w", sku.ID, err)
+		}
+		product.Skus = append(product.Skus, &mallv1.SKU{
+			Id:         sku.ID,
+			Attributes: attributes,
+			Price:      sku.Price.String(),
+			Stock:      int32(sku.Stock),
+		})
+	}
+	return product, nil
+}
@@ -0,0 +1,61 @@
+// Package rpc serves the user, product and order operations over gRPC for
+// internal callers. It reuses the service layer and the HTTP API's request
+// validation, so both transports accept the same input.
+package rpc
+
+import (
+	mallv1 "github.com/proyuen/go-mall/api/proto/mall/v1"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/errreport"
+	"github.com/proyuen/go-mall/pkg/token"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// publicMethods can be called without an access token, matching the public
+// HTTP routes.
+var publicMethods = map[string]bool{
+	mallv1.UserService_Register_FullMethodName:        true,
+	mallv1.UserService_Login_FullMethodName:           true,
+	mallv1.ProductService_GetProduct_FullMethodName:   true,
+	mallv1.ProductService_ListProducts_FullMethodName: true,
+	healthpb.Health_Check_FullMethodName:              true,
+	healthpb.Health_List_FullMethodName:               true,
+}
+
+// roleMethods can only be called with an access token claiming one of their
+// roles, matching the HTTP routes behind middleware.RequireRoles.
+var roleMethods = map[string][]string{
+	mallv1.ProductService_CreateProduct_FullMethodName: {model.RoleAdmin},
+}
+
+// NewServer creates a gRPC server exposing the user, product and order
+// services and the standard health service. Every call gets a request ID and
+// an access log line; panics and server-side errors go to reporter; calls are
+// served for the store stores resolves, unless stores is nil, and the sales
+// channel named by x-sales-channel; methods outside publicMethods need a
+// bearer token verified by tokenMaker and not revoked through revoker, and
+// methods in roleMethods one claiming their role. The interceptors are unary
+// only: the one streaming method, health Watch, is public.
+func NewServer(userService service.UserService, productService service.ProductService, orderService service.OrderService, stores service.StoreService, tokenMaker token.Maker, sessions service.SessionStore, revoker token.Revoker, reporter errreport.Reporter, opts ...grpc.ServerOption) *grpc.Server {
+	if reporter == nil {
+		reporter = errreport.Nop()
+	}
+	opts = append(opts, grpc.ChainUnaryInterceptor(
+		unaryRequestID,
+		unaryAccessLog,
+		unaryErrorReporting(reporter),
+		unaryTenant(stores),
+		unaryChannel,
+		unaryAuth(tokenMaker, sessions, revoker, publicMethods, roleMethods),
+	))
+
+	s := grpc.NewServer(opts...)
+	mallv1.RegisterUserServiceServer(s, &userServer{userService: userService})
+	mallv1.RegisterProductServiceServer(s, &productServer{productService: productService})
+	mallv1.RegisterOrderServiceServer(s, &orderServer{orderService: orderService})
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	return s
+}
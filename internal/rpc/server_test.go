@@ -0,0 +1,487 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+
+	mallv1 "github.com/proyuen/go-mall/api/proto/mall/v1"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/channel"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/proyuen/go-mall/pkg/tenant"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/proyuen/go-mall/pkg/validation"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestMain(m *testing.M) {
+	if err := validation.Init(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+type testDeps struct {
+	user     *mocks.MockUserService
+	product  *mocks.MockProductService
+	order    *mocks.MockOrderService
+	stores   *mocks.MockStoreService // Only used by newMultiStoreTestClient
+	maker    *mocks.MockMaker
+	sessions *mocks.MockSessionStore
+	reporter *mocks.MockReporter
+}
+
+// newTestClient serves NewServer over an in-memory listener and returns a
+// connection to it.
+func newTestClient(t *testing.T) (*grpc.ClientConn, testDeps) {
+	return newTestClientWithStores(t, false)
+}
+
+// newMultiStoreTestClient is newTestClient with tenancy enabled.
+func newMultiStoreTestClient(t *testing.T) (*grpc.ClientConn, testDeps) {
+	return newTestClientWithStores(t, true)
+}
+
+func newTestClientWithStores(t *testing.T, multiStore bool) (*grpc.ClientConn, testDeps) {
+	ctrl := gomock.NewController(t)
+	deps := testDeps{
+		user:     mocks.NewMockUserService(ctrl),
+		product:  mocks.NewMockProductService(ctrl),
+		order:    mocks.NewMockOrderService(ctrl),
+		stores:   mocks.NewMockStoreService(ctrl),
+		maker:    mocks.NewMockMaker(ctrl),
+		sessions: mocks.NewMockSessionStore(ctrl),
+		reporter: mocks.NewMockReporter(ctrl),
+	}
+	var stores service.StoreService
+	if multiStore {
+		stores = deps.stores
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(deps.user, deps.product, deps.order, stores, deps.maker, deps.sessions, nil, deps.reporter)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn, deps
+}
+
+func withToken(ctx context.Context, accessToken string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+accessToken)
+}
+
+func TestAuth(t *testing.T) {
+	tests := []struct {
+		name      string
+		token     string
+		mockSetup func(deps testDeps)
+		wantCode  codes.Code
+	}{
+		{
+			name:     "MissingToken",
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:  "InvalidToken",
+			token: "forged",
+			mockSetup: func(deps testDeps) {
+				deps.maker.EXPECT().VerifyToken("forged").Return(nil, token.ErrInvalidToken)
+			},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:  "EndedSession",
+			token: "evicted",
+			mockSetup: func(deps testDeps) {
+				deps.maker.EXPECT().VerifyToken("evicted").Return(&token.Payload{UserID: 7, SessionID: "s1"}, nil)
+				deps.sessions.EXPECT().Active(gomock.Any(), uint64(7), "s1").Return(false, nil)
+			},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:  "SessionStoreDown",
+			token: "session",
+			mockSetup: func(deps testDeps) {
+				deps.maker.EXPECT().VerifyToken("session").Return(&token.Payload{UserID: 7, SessionID: "s1"}, nil)
+				deps.sessions.EXPECT().Active(gomock.Any(), uint64(7), "s1").Return(false, errors.New("redis down"))
+			},
+			wantCode: codes.Unavailable,
+		},
+		{
+			name:  "OtherStore",
+			token: "other_store",
+			mockSetup: func(deps testDeps) {
+				deps.maker.EXPECT().VerifyToken("other_store").Return(&token.Payload{UserID: 7, StoreID: 3}, nil)
+			},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:  "OrderPlacedForTokenOwner",
+			token: "valid",
+			mockSetup: func(deps testDeps) {
+				deps.maker.EXPECT().VerifyToken("valid").Return(&token.Payload{UserID: 7}, nil)
+				deps.order.EXPECT().
+					CreateOrder(gomock.Any(), &service.OrderCreateReq{UserID: 7, Items: []service.OrderItemReq{{SKUID: 101, Quantity: 2}}}).
+					Return(&service.OrderCreateResp{OrderID: 1, OrderNumber: "ORD1", TotalAmount: money.New(decimal.RequireFromString("99.90"), "USD")}, nil)
+			},
+			wantCode: codes.OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, deps := newTestClient(t)
+			if tt.mockSetup != nil {
+				tt.mockSetup(deps)
+			}
+			ctx := context.Background()
+			if tt.token != "" {
+				ctx = withToken(ctx, tt.token)
+			}
+
+			resp, err := mallv1.NewOrderServiceClient(conn).CreateOrder(ctx, &mallv1.CreateOrderRequest{
+				Items: []*mallv1.OrderItem{{SkuId: 101, Quantity: 2}},
+			})
+			require.Equal(t, tt.wantCode, status.Code(err), "%v", err)
+			if tt.wantCode == codes.OK {
+				assert.Equal(t, "99.90", resp.GetTotalAmount())
+			}
+		})
+	}
+}
+
+func TestUserServer_Register(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       *mallv1.RegisterRequest
+		mockSetup func(deps testDeps)
+		wantCode  codes.Code
+		wantField string
+	}{
+		{
+			name: "Success",
+			req:  &mallv1.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "secret123"},
+			mockSetup: func(deps testDeps) {
+				deps.user.EXPECT().
+					Register(gomock.Any(), &service.UserRegisterReq{Username: "alice", Email: "alice@example.com", Password: "secret123"}).
+					Return(&service.UserRegisterResp{UserID: 7, Username: "alice", Email: "alice@example.com"}, nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:      "InvalidEmail",
+			req:       &mallv1.RegisterRequest{Username: "alice", Email: "not-an-email", Password: "secret123"},
+			wantCode:  codes.InvalidArgument,
+			wantField: "email",
+		},
+		{
+			name: "UserExists",
+			req:  &mallv1.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "secret123"},
+			mockSetup: func(deps testDeps) {
+				deps.user.EXPECT().Register(gomock.Any(), gomock.Any()).Return(nil, service.ErrUserExists)
+			},
+			wantCode: codes.AlreadyExists,
+		},
+		{
+			name: "ServiceError",
+			req:  &mallv1.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "secret123"},
+			mockSetup: func(deps testDeps) {
+				deps.user.EXPECT().Register(gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+				deps.reporter.EXPECT().CaptureError(gomock.Any(), gomock.Any(), map[string]string{
+					"grpc.method": mallv1.UserService_Register_FullMethodName,
+					"grpc.code":   codes.Internal.String(),
+				})
+			},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, deps := newTestClient(t)
+			if tt.mockSetup != nil {
+				tt.mockSetup(deps)
+			}
+
+			var header metadata.MD
+			resp, err := mallv1.NewUserServiceClient(conn).Register(context.Background(), tt.req, grpc.Header(&header))
+			require.Equal(t, tt.wantCode, status.Code(err), "%v", err)
+			assert.NotEmpty(t, header.Get("x-request-id"))
+			switch tt.wantCode {
+			case codes.OK:
+				assert.Equal(t, uint64(7), resp.GetUserId())
+			case codes.Internal:
+				assert.Equal(t, internalMessage, status.Convert(err).Message(), "causes must not leak")
+			}
+
+			if tt.wantField != "" {
+				details := status.Convert(err).Details()
+				require.Len(t, details, 1)
+				badRequest, ok := details[0].(*errdetails.BadRequest)
+				require.True(t, ok)
+				require.Len(t, badRequest.GetFieldViolations(), 1)
+				assert.Equal(t, tt.wantField, badRequest.GetFieldViolations()[0].GetField())
+			}
+		})
+	}
+}
+
+func TestProductServer(t *testing.T) {
+	attributes, err := structpb.NewStruct(map[string]any{"color": "red"})
+	require.NoError(t, err)
+
+	t.Run("CreateProduct", func(t *testing.T) {
+		conn, deps := newTestClient(t)
+		deps.maker.EXPECT().VerifyToken("valid").Return(&token.Payload{UserID: 7, Role: model.RoleAdmin}, nil)
+		deps.product.EXPECT().CreateProduct(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *service.ProductCreateReq) (*service.ProductCreateResp, error) {
+			require.Len(t, req.SKUs, 1)
+			assert.JSONEq(t, `{"color":"red"}`, string(req.SKUs[0].Attributes))
+			assert.True(t, decimal.RequireFromString("19.99").Equal(req.SKUs[0].Price))
+			return &service.ProductCreateResp{SPUID: 11}, nil
+		})
+
+		resp, err := mallv1.NewProductServiceClient(conn).CreateProduct(withToken(context.Background(), "valid"), &mallv1.CreateProductRequest{
+			Name:       "Mug",
+			CategoryId: 3,
+			Skus:       []*mallv1.CreateSKU{{Attributes: attributes, Price: "19.99", Stock: 5}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(11), resp.GetSpuId())
+	})
+
+	t.Run("CreateProductRequiresAdmin", func(t *testing.T) {
+		conn, deps := newTestClient(t)
+		deps.maker.EXPECT().VerifyToken("valid").Return(&token.Payload{UserID: 7, Role: model.RoleUser}, nil)
+
+		_, err := mallv1.NewProductServiceClient(conn).CreateProduct(withToken(context.Background(), "valid"), &mallv1.CreateProductRequest{
+			Name:       "Mug",
+			CategoryId: 3,
+			Skus:       []*mallv1.CreateSKU{{Attributes: attributes, Price: "19.99", Stock: 5}},
+		})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("CreateProductInvalidPrice", func(t *testing.T) {
+		conn, deps := newTestClient(t)
+		deps.maker.EXPECT().VerifyToken("valid").Return(&token.Payload{UserID: 7, Role: model.RoleAdmin}, nil)
+
+		_, err := mallv1.NewProductServiceClient(conn).CreateProduct(withToken(context.Background(), "valid"), &mallv1.CreateProductRequest{
+			Name:       "Mug",
+			CategoryId: 3,
+			Skus:       []*mallv1.CreateSKU{{Attributes: attributes, Price: "cheap", Stock: 5}},
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("ListProductsIsPublic", func(t *testing.T) {
+		conn, deps := newTestClient(t)
+		deps.product.EXPECT().ListProducts(gomock.Any(), 0, defaultProductPageSize).Return([]service.ProductResp{{
+			ID:   11,
+			Name: "Mug",
+			SKUs: []service.SKUResp{{ID: 12, Attributes: map[string]any{"color": "red"}, Price: decimal.RequireFromString("19.99"), Stock: 5}},
+		}}, nil)
+
+		resp, err := mallv1.NewProductServiceClient(conn).ListProducts(context.Background(), &mallv1.ListProductsRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.GetProducts(), 1)
+		sku := resp.GetProducts()[0].GetSkus()[0]
+		assert.Equal(t, "19.99", sku.GetPrice())
+		assert.Equal(t, "red", sku.GetAttributes().AsMap()["color"])
+	})
+
+	t.Run("PanicIsRecovered", func(t *testing.T) {
+		conn, deps := newTestClient(t)
+		deps.product.EXPECT().GetProduct(gomock.Any(), uint64(11)).DoAndReturn(func(context.Context, uint64) (*service.ProductResp, error) {
+			panic("boom")
+		})
+		deps.reporter.EXPECT().CapturePanic(gomock.Any(), "boom", gomock.Any())
+
+		_, err := mallv1.NewProductServiceClient(conn).GetProduct(context.Background(), &mallv1.GetProductRequest{Id: 11})
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+}
+
+func TestOrderServer_CreateOrderTax(t *testing.T) {
+	tests := []struct {
+		name      string
+		region    string
+		mockSetup func(deps testDeps)
+		wantCode  codes.Code
+	}{
+		{
+			name:   "Taxed",
+			region: "DE",
+			mockSetup: func(deps testDeps) {
+				deps.order.EXPECT().
+					CreateOrder(gomock.Any(), &service.OrderCreateReq{UserID: 7, Region: "DE", Items: []service.OrderItemReq{{SKUID: 101, Quantity: 1}}}).
+					Return(&service.OrderCreateResp{OrderID: 1, TotalAmount: money.New(decimal.RequireFromString("11.90"), "EUR"), TaxAmount: money.New(decimal.RequireFromString("1.90"), "EUR")}, nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "InvalidRegion",
+			region:   "Germany",
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "RegionRequired",
+			mockSetup: func(deps testDeps) {
+				deps.order.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(nil, service.ErrTaxRegionRequired)
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:   "TaxUnavailable",
+			region: "DE",
+			mockSetup: func(deps testDeps) {
+				deps.order.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(nil, service.ErrTaxUnavailable)
+			},
+			wantCode: codes.Unavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, deps := newTestClient(t)
+			deps.maker.EXPECT().VerifyToken("valid").Return(&token.Payload{UserID: 7}, nil)
+			if tt.mockSetup != nil {
+				tt.mockSetup(deps)
+			}
+
+			resp, err := mallv1.NewOrderServiceClient(conn).CreateOrder(withToken(context.Background(), "valid"), &mallv1.CreateOrderRequest{
+				Items:  []*mallv1.OrderItem{{SkuId: 101, Quantity: 1}},
+				Region: tt.region,
+			})
+			require.Equal(t, tt.wantCode, status.Code(err), "%v", err)
+			if tt.wantCode == codes.OK {
+				assert.Equal(t, "11.90", resp.GetTotalAmount())
+				assert.Equal(t, "1.90", resp.GetTaxAmount())
+			}
+		})
+	}
+}
+
+func TestOrderServer_CreateOrderInsufficientStock(t *testing.T) {
+	conn, deps := newTestClient(t)
+	deps.maker.EXPECT().VerifyToken("valid").Return(&token.Payload{UserID: 7}, nil)
+	deps.order.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("failed to deduct stock: %w", service.ErrInsufficientStock.WithSKU(101)))
+
+	_, err := mallv1.NewOrderServiceClient(conn).CreateOrder(withToken(context.Background(), "valid"), &mallv1.CreateOrderRequest{
+		Items: []*mallv1.OrderItem{{SkuId: 101, Quantity: 1}},
+	})
+	st := status.Convert(err)
+	assert.Equal(t, codes.FailedPrecondition, st.Code())
+	assert.Equal(t, "insufficient stock", st.Message(), "the cause is not leaked")
+	require.Len(t, st.Details(), 1)
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	assert.Equal(t, "stock_insufficient", info.GetReason())
+	assert.Equal(t, map[string]string{"sku_id": "101"}, info.GetMetadata())
+}
+
+func TestTenant(t *testing.T) {
+	tests := []struct {
+		name      string
+		store     string
+		mockSetup func(deps testDeps)
+		wantCode  codes.Code
+	}{
+		{
+			name:  "ServedForStore",
+			store: "toys",
+			mockSetup: func(deps testDeps) {
+				deps.stores.EXPECT().Resolve(gomock.Any(), "toys", "").Return(&model.Store{Base: model.Base{ID: 2}, Slug: "toys"}, nil)
+				deps.product.EXPECT().GetProduct(gomock.Any(), uint64(101)).DoAndReturn(func(ctx context.Context, _ uint64) (*service.ProductResp, error) {
+					assert.Equal(t, uint64(2), tenant.StoreID(ctx))
+					return &service.ProductResp{ID: 101}, nil
+				})
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:  "UnknownStore",
+			store: "games",
+			mockSetup: func(deps testDeps) {
+				deps.stores.EXPECT().Resolve(gomock.Any(), "games", "").Return(nil, service.ErrStoreNotFound)
+			},
+			wantCode: codes.NotFound,
+		},
+		{
+			name: "StoresUnavailable",
+			mockSetup: func(deps testDeps) {
+				deps.stores.EXPECT().Resolve(gomock.Any(), "", "").Return(nil, errors.New("db down"))
+			},
+			wantCode: codes.Unavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, deps := newMultiStoreTestClient(t)
+			tt.mockSetup(deps)
+			ctx := context.Background()
+			if tt.store != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "x-store", tt.store)
+			}
+
+			_, err := mallv1.NewProductServiceClient(conn).GetProduct(ctx, &mallv1.GetProductRequest{Id: 101})
+			assert.Equal(t, tt.wantCode, status.Code(err), "%v", err)
+		})
+	}
+}
+
+func TestSalesChannel(t *testing.T) {
+	t.Run("DefaultsToWeb", func(t *testing.T) {
+		conn, deps := newTestClient(t)
+		deps.product.EXPECT().GetProduct(gomock.Any(), uint64(101)).DoAndReturn(func(ctx context.Context, _ uint64) (*service.ProductResp, error) {
+			assert.Equal(t, channel.Web, channel.FromContext(ctx))
+			return &service.ProductResp{ID: 101}, nil
+		})
+
+		_, err := mallv1.NewProductServiceClient(conn).GetProduct(context.Background(), &mallv1.GetProductRequest{Id: 101})
+		require.NoError(t, err)
+	})
+
+	t.Run("Wholesale", func(t *testing.T) {
+		conn, deps := newTestClient(t)
+		deps.product.EXPECT().GetProduct(gomock.Any(), uint64(101)).DoAndReturn(func(ctx context.Context, _ uint64) (*service.ProductResp, error) {
+			assert.Equal(t, channel.Wholesale, channel.FromContext(ctx))
+			return &service.ProductResp{ID: 101}, nil
+		})
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-sales-channel", "Wholesale")
+		_, err := mallv1.NewProductServiceClient(conn).GetProduct(ctx, &mallv1.GetProductRequest{Id: 101})
+		require.NoError(t, err)
+	})
+
+	t.Run("UnknownChannel", func(t *testing.T) {
+		conn, _ := newTestClient(t)
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-sales-channel", "kiosk")
+		_, err := mallv1.NewProductServiceClient(conn).GetProduct(ctx, &mallv1.GetProductRequest{Id: 101})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -0,0 +1,67 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	mallv1 "github.com/proyuen/go-mall/api/proto/mall/v1"
+	"github.com/proyuen/go-mall/internal/handler"
+	"github.com/proyuen/go-mall/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type userServer struct {
+	mallv1.UnimplementedUserServiceServer
+	userService service.UserService
+}
+
+func (s *userServer) Register(ctx context.Context, req *mallv1.RegisterRequest) (*mallv1.RegisterResponse, error) {
+	in := handler.RegisterRequest{
+		Username: req.GetUsername(),
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+	}
+	if err := validate(ctx, &in); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.userService.Register(ctx, &service.UserRegisterReq{
+		Username: in.Username,
+		Email:    in.Email,
+		Password: in.Password,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrUserExists) {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+		return nil, internalError(ctx, "Failed to register user", err)
+	}
+
+	return &mallv1.RegisterResponse{UserId: resp.UserID, Username: resp.Username, Email: resp.Email}, nil
+}
+
+func (s *userServer) Login(ctx context.Context, req *mallv1.LoginRequest) (*mallv1.LoginResponse, error) {
+	in := handler.LoginRequest{Username: req.GetUsername(), Password: req.GetPassword()}
+	if err := validate(ctx, &in); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.userService.Login(ctx, &service.UserLoginReq{Username: in.Username, Password: in.Password})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if errors.Is(err, service.ErrSessionLimitExceeded) {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
+		return nil, internalError(ctx, "Failed to log in", err)
+	}
+
+	return &mallv1.LoginResponse{
+		UserId:      resp.UserID,
+		AccessToken: resp.AccessToken,
+		ExpiresIn:   resp.ExpiresIn,
+		TokenType:   resp.TokenType,
+	}, nil
+}
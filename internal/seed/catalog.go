@@ -0,0 +1,137 @@
+package seed
+
+import (
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/shopspring/decimal"
+)
+
+// categoryNode describes a top-level category and the leaf categories
+// products are attached to.
+type categoryNode struct {
+	Name     string
+	Children []leafCategory
+}
+
+// leafCategory holds everything needed to invent a plausible product in it.
+type leafCategory struct {
+	Name     string
+	Nouns    []string
+	Brands   []string
+	MinPrice int // whole currency units
+	MaxPrice int
+	Axes     []attributeAxis
+}
+
+// attributeAxis is one dimension SKUs of a product vary along, e.g. colour.
+type attributeAxis struct {
+	Key    string
+	Values []string
+}
+
+var (
+	colours      = attributeAxis{Key: "color", Values: []string{"Black", "White", "Navy", "Grey", "Red", "Olive", "Sand"}}
+	apparelSizes = attributeAxis{Key: "size", Values: []string{"XS", "S", "M", "L", "XL", "XXL"}}
+	shoeSizes    = attributeAxis{Key: "size", Values: []string{"38", "39", "40", "41", "42", "43", "44", "45"}}
+	storage      = attributeAxis{Key: "storage", Values: []string{"64GB", "128GB", "256GB", "512GB", "1TB"}}
+	volumes      = attributeAxis{Key: "volume", Values: []string{"250ml", "500ml", "1L"}}
+	packSizes    = attributeAxis{Key: "pack", Values: []string{"1-pack", "2-pack", "6-pack", "12-pack"}}
+)
+
+// catalog is the category tree the seeder creates and draws products from.
+var catalog = []categoryNode{
+	{
+		Name: "Electronics",
+		Children: []leafCategory{
+			{Name: "Smartphones", Nouns: []string{"Phone", "Phone Pro", "Phone Lite", "Phone Max"}, Brands: []string{"Nova", "Pixelis", "Orbit", "Lumen"}, MinPrice: 199, MaxPrice: 1299, Axes: []attributeAxis{colours, storage}},
+			{Name: "Laptops", Nouns: []string{"Notebook", "Ultrabook", "Workstation", "Chromebook"}, Brands: []string{"Vertex", "Kestrel", "Arcadia"}, MinPrice: 349, MaxPrice: 2999, Axes: []attributeAxis{colours, storage}},
+			{Name: "Headphones", Nouns: []string{"Earbuds", "Over-Ear Headphones", "Studio Monitors", "Sport Earphones"}, Brands: []string{"Sonique", "Bassline", "Quietude"}, MinPrice: 19, MaxPrice: 449, Axes: []attributeAxis{colours}},
+		},
+	},
+	{
+		Name: "Fashion",
+		Children: []leafCategory{
+			{Name: "T-Shirts", Nouns: []string{"Crew Tee", "V-Neck Tee", "Pocket Tee", "Graphic Tee"}, Brands: []string{"Threadline", "Northway", "Basics Co."}, MinPrice: 9, MaxPrice: 49, Axes: []attributeAxis{colours, apparelSizes}},
+			{Name: "Jackets", Nouns: []string{"Parka", "Bomber Jacket", "Rain Shell", "Denim Jacket"}, Brands: []string{"Northway", "Summit", "Harbor"}, MinPrice: 49, MaxPrice: 399, Axes: []attributeAxis{colours, apparelSizes}},
+			{Name: "Sneakers", Nouns: []string{"Runner", "Court Sneaker", "Trail Shoe", "Slip-On"}, Brands: []string{"Stride", "Apex", "Fleet"}, MinPrice: 39, MaxPrice: 249, Axes: []attributeAxis{colours, shoeSizes}},
+		},
+	},
+	{
+		Name: "Home & Kitchen",
+		Children: []leafCategory{
+			{Name: "Cookware", Nouns: []string{"Frying Pan", "Stock Pot", "Dutch Oven", "Saucepan"}, Brands: []string{"Hearth", "Copperleaf", "Ironside"}, MinPrice: 15, MaxPrice: 299, Axes: []attributeAxis{colours}},
+			{Name: "Bedding", Nouns: []string{"Duvet Cover", "Sheet Set", "Pillow", "Throw Blanket"}, Brands: []string{"Dreamwell", "Linenry"}, MinPrice: 19, MaxPrice: 199, Axes: []attributeAxis{colours}},
+		},
+	},
+	{
+		Name: "Grocery",
+		Children: []leafCategory{
+			{Name: "Beverages", Nouns: []string{"Sparkling Water", "Cold Brew Coffee", "Green Tea", "Orange Juice"}, Brands: []string{"Brookside", "Morning Co.", "Clearspring"}, MinPrice: 1, MaxPrice: 19, Axes: []attributeAxis{volumes, packSizes}},
+			{Name: "Snacks", Nouns: []string{"Trail Mix", "Potato Chips", "Granola Bar", "Dark Chocolate"}, Brands: []string{"Crunchly", "Harvest", "Goodbite"}, MinPrice: 1, MaxPrice: 15, Axes: []attributeAxis{packSizes}},
+		},
+	},
+}
+
+var firstNames = []string{"alice", "bob", "carol", "dave", "erin", "frank", "grace", "heidi", "ivan", "judy", "mallory", "niaj", "olivia", "peggy", "rupert", "sybil", "trent", "victor", "wendy"}
+
+var adjectives = []string{"Classic", "Essential", "Premium", "Everyday", "Signature", "Urban", "Eco", "Compact"}
+
+// product is a generated SPU before it is persisted.
+type product struct {
+	Name        string
+	Description string
+	SKUs        []variant
+}
+
+// variant is a generated SKU before it is persisted.
+type variant struct {
+	Attributes map[string]interface{}
+	Price      decimal.Decimal
+	Stock      int
+}
+
+// newProduct invents a product in leaf with up to maxSKUs distinct variants.
+func newProduct(rng *rand.Rand, leaf leafCategory, maxSKUs int) product {
+	brand := pick(rng, leaf.Brands)
+	name := fmt.Sprintf("%s %s %s", brand, pick(rng, adjectives), pick(rng, leaf.Nouns))
+	p := product{
+		Name:        name,
+		Description: fmt.Sprintf("%s from %s's %s range.", name, brand, leaf.Name),
+	}
+
+	base := leaf.MinPrice + rng.IntN(leaf.MaxPrice-leaf.MinPrice+1)
+	seen := make(map[string]bool)
+	n := 1 + rng.IntN(maxSKUs)
+	// Bounded attempts: a leaf with few attribute values cannot yield n
+	// distinct combinations, and the product simply gets fewer SKUs.
+	for attempt := 0; len(p.SKUs) < n && attempt < n*4; attempt++ {
+		attrs := make(map[string]interface{}, len(leaf.Axes))
+		key := ""
+		for _, axis := range leaf.Axes {
+			v := pick(rng, axis.Values)
+			attrs[axis.Key] = v
+			key += axis.Key + "=" + v + ";"
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		// Variants cost within ±20% of the product's base price, ending in .99.
+		cents := base*100 + (rng.IntN(41)-20)*base - 1
+		if cents < 99 {
+			cents = 99
+		}
+		p.SKUs = append(p.SKUs, variant{
+			Attributes: attrs,
+			Price:      decimal.New(int64(cents), -2),
+			Stock:      rng.IntN(500),
+		})
+	}
+	return p
+}
+
+func pick(rng *rand.Rand, values []string) string {
+	return values[rng.IntN(len(values))]
+}
@@ -0,0 +1,396 @@
+// Package seed fills a development database with realistic categories,
+// products, users and orders through the regular repositories.
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/database"
+	"github.com/proyuen/go-mall/pkg/hasher"
+	"github.com/proyuen/go-mall/pkg/utils"
+	"github.com/shopspring/decimal"
+)
+
+// Defaults used when an Options field is zero.
+const (
+	DefaultUsers     = 50
+	DefaultProducts  = 200
+	DefaultMaxSKUs   = 4
+	DefaultOrders    = 500
+	DefaultBatchSize = 100
+	DefaultPassword  = "password123"
+
+	// orderHistory is how far back generated orders are spread.
+	orderHistory = 90 * 24 * time.Hour
+	// maxOrderItems and maxItemQuantity bound the size of a generated order.
+	maxOrderItems   = 4
+	maxItemQuantity = 3
+)
+
+// orderStatuses weights the statuses of generated orders so that most of the
+// history looks settled.
+var orderStatuses = []struct {
+	Status string
+	Weight int
+}{
+	{model.OrderStatusCompleted, 60},
+	{model.OrderStatusPaid, 15},
+	{model.OrderStatusPending, 15},
+	{model.OrderStatusCancelled, 10},
+}
+
+// Options controls the volume and shape of the generated data.
+type Options struct {
+	Users     int
+	Products  int
+	MaxSKUs   int // per product; each product gets between 1 and MaxSKUs
+	Orders    int
+	Password  string // shared by every seeded user
+	Seed      int64  // makes the generated data reproducible; 0 picks one at random
+	BatchSize int    // rows written per transaction
+	// RunTag is appended to usernames and emails so that repeated runs against
+	// the same database do not collide. Empty picks a random one.
+	RunTag string
+}
+
+func (o Options) withDefaults() Options {
+	if o.Users <= 0 {
+		o.Users = DefaultUsers
+	}
+	if o.Products <= 0 {
+		o.Products = DefaultProducts
+	}
+	if o.MaxSKUs <= 0 {
+		o.MaxSKUs = DefaultMaxSKUs
+	}
+	if o.Orders < 0 {
+		o.Orders = 0
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultBatchSize
+	}
+	if o.Password == "" {
+		o.Password = DefaultPassword
+	}
+	if o.Seed == 0 {
+		o.Seed = time.Now().UnixNano()
+	}
+	if o.RunTag == "" {
+		o.RunTag = utils.RandomString(6)
+	}
+	return o
+}
+
+// Summary reports what a run created.
+type Summary struct {
+	Categories int
+	Products   int
+	SKUs       int
+	Users      int
+	Orders     int
+	Seed       int64
+	RunTag     string
+}
+
+// Seeder writes generated data through the application's repositories.
+type Seeder struct {
+	categories repository.CategoryRepository
+	users      repository.UserRepository
+	products   repository.ProductRepository
+	orders     repository.OrderRepository
+	tx         database.TransactionManager
+	hasher     hasher.PasswordHasher
+}
+
+// NewSeeder creates a new Seeder.
+func NewSeeder(
+	categories repository.CategoryRepository,
+	users repository.UserRepository,
+	products repository.ProductRepository,
+	orders repository.OrderRepository,
+	tx database.TransactionManager,
+	hasher hasher.PasswordHasher,
+) *Seeder {
+	return &Seeder{
+		categories: categories,
+		users:      users,
+		products:   products,
+		orders:     orders,
+		tx:         tx,
+		hasher:     hasher,
+	}
+}
+
+// seededSKU is what orders need to know about a SKU created by the run.
+type seededSKU struct {
+	ID    uint64
+	Name  string
+	Price decimal.Decimal
+}
+
+// leafRef pairs a persisted leaf category with its generator definition.
+type leafRef struct {
+	ID   uint64
+	Leaf leafCategory
+}
+
+// Run generates and stores one batch of data. Categories are reused when they
+// already exist; everything else is new on every run. Orders reference only
+// the users and SKUs created by the same run and do not deduct stock.
+func (s *Seeder) Run(ctx context.Context, opts Options) (*Summary, error) {
+	opts = opts.withDefaults()
+	rng := rand.New(rand.NewPCG(uint64(opts.Seed), uint64(opts.Seed)>>1|1))
+	summary := &Summary{Seed: opts.Seed, RunTag: opts.RunTag}
+
+	leaves, created, err := s.seedCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summary.Categories = created
+	slog.InfoContext(ctx, "Seeded categories", slog.Int("created", created), slog.Int("leaves", len(leaves)))
+
+	userIDs, err := s.seedUsers(ctx, rng, opts)
+	if err != nil {
+		return nil, err
+	}
+	summary.Users = len(userIDs)
+
+	skus, err := s.seedProducts(ctx, rng, leaves, opts)
+	if err != nil {
+		return nil, err
+	}
+	summary.Products = opts.Products
+	summary.SKUs = len(skus)
+
+	orders, err := s.seedOrders(ctx, rng, userIDs, skus, opts)
+	if err != nil {
+		return nil, err
+	}
+	summary.Orders = orders
+	return summary, nil
+}
+
+// seedCategories creates the missing parts of the catalog tree and returns
+// every leaf with its ID, along with how many categories were created.
+func (s *Seeder) seedCategories(ctx context.Context) ([]leafRef, int, error) {
+	existing, err := s.categories.List(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load categories: %w", err)
+	}
+	type key struct {
+		parent uint64
+		name   string
+	}
+	ids := make(map[key]uint64, len(existing))
+	for _, c := range existing {
+		ids[key{c.ParentID, c.Name}] = c.ID
+	}
+
+	created := 0
+	ensure := func(ctx context.Context, parent uint64, name string) (uint64, error) {
+		if id, ok := ids[key{parent, name}]; ok {
+			return id, nil
+		}
+		c := &model.Category{Name: name, ParentID: parent}
+		if err := s.categories.Create(ctx, c); err != nil {
+			return 0, err
+		}
+		ids[key{parent, name}] = c.ID
+		created++
+		return c.ID, nil
+	}
+
+	var leaves []leafRef
+	err = s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		for _, node := range catalog {
+			parentID, err := ensure(ctx, 0, node.Name)
+			if err != nil {
+				return err
+			}
+			for _, leaf := range node.Children {
+				id, err := ensure(ctx, parentID, leaf.Name)
+				if err != nil {
+					return err
+				}
+				leaves = append(leaves, leafRef{ID: id, Leaf: leaf})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to seed categories: %w", err)
+	}
+	return leaves, created, nil
+}
+
+// seedUsers creates opts.Users customers sharing one password hash.
+func (s *Seeder) seedUsers(ctx context.Context, rng *rand.Rand, opts Options) ([]uint64, error) {
+	// Hashing is deliberately slow; every seeded user gets the same hash.
+	hash, err := s.hasher.Hash(opts.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash seed password: %w", err)
+	}
+
+	ids := make([]uint64, 0, opts.Users)
+	err = s.inBatches(ctx, opts.Users, opts.BatchSize, "users", func(ctx context.Context, i int) error {
+		username := fmt.Sprintf("%s_%s_%d", pick(rng, firstNames), opts.RunTag, i)
+		user := &model.User{
+			Username:     username,
+			Email:        username + "@example.com",
+			PasswordHash: hash,
+			Role:         model.RoleUser,
+		}
+		if err := s.users.Create(ctx, user); err != nil {
+			return err
+		}
+		ids = append(ids, user.ID)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to seed users: %w", err)
+	}
+	return ids, nil
+}
+
+// seedProducts creates opts.Products SPUs spread over the leaf categories and
+// returns every SKU created with them.
+func (s *Seeder) seedProducts(ctx context.Context, rng *rand.Rand, leaves []leafRef, opts Options) ([]seededSKU, error) {
+	if len(leaves) == 0 {
+		return nil, errors.New("no categories to attach products to")
+	}
+
+	var skus []seededSKU
+	err := s.inBatches(ctx, opts.Products, opts.BatchSize, "products", func(ctx context.Context, i int) error {
+		ref := leaves[rng.IntN(len(leaves))]
+		p := newProduct(rng, ref.Leaf, opts.MaxSKUs)
+
+		spu := &model.SPU{
+			Name:        p.Name,
+			Description: p.Description,
+			CategoryID:  ref.ID,
+		}
+		for _, v := range p.SKUs {
+			spu.SKUs = append(spu.SKUs, model.SKU{
+				Attributes: model.JSONB(v.Attributes),
+				Price:      v.Price,
+				Stock:      v.Stock,
+			})
+		}
+		if err := s.products.CreateSPU(ctx, spu); err != nil {
+			return err
+		}
+		for _, sku := range spu.SKUs {
+			skus = append(skus, seededSKU{ID: sku.ID, Name: spu.Name, Price: sku.Price})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to seed products: %w", err)
+	}
+	return skus, nil
+}
+
+// seedOrders creates opts.Orders orders placed by the given users for the
+// given SKUs, backdated across orderHistory.
+func (s *Seeder) seedOrders(ctx context.Context, rng *rand.Rand, userIDs []uint64, skus []seededSKU, opts Options) (int, error) {
+	if opts.Orders == 0 {
+		return 0, nil
+	}
+	if len(userIDs) == 0 || len(skus) == 0 {
+		return 0, errors.New("orders need at least one user and one SKU")
+	}
+
+	now := time.Now()
+	created := 0
+	err := s.inBatches(ctx, opts.Orders, opts.BatchSize, "orders", func(ctx context.Context, i int) error {
+		placedAt := now.Add(-time.Duration(rng.Int64N(int64(orderHistory))))
+		order := &model.Order{
+			UserID:      userIDs[rng.IntN(len(userIDs))],
+			OrderNumber: fmt.Sprintf("%d%s", placedAt.UnixNano(), utils.RandomString(6)),
+			Status:      pickStatus(rng),
+		}
+		order.CreatedAt = placedAt
+		order.UpdatedAt = placedAt
+
+		var items []model.OrderItem
+		total := decimal.Zero
+		used := make(map[uint64]bool)
+		for n := 1 + rng.IntN(maxOrderItems); len(items) < n; {
+			sku := skus[rng.IntN(len(skus))]
+			if used[sku.ID] {
+				// Small catalogs may not have n distinct SKUs.
+				if len(used) == len(skus) {
+					break
+				}
+				continue
+			}
+			used[sku.ID] = true
+			qty := 1 + rng.IntN(maxItemQuantity)
+			item := model.OrderItem{
+				SKUID:        sku.ID,
+				SnapshotName: sku.Name,
+				Price:        sku.Price,
+				Quantity:     qty,
+			}
+			item.CreatedAt = placedAt
+			item.UpdatedAt = placedAt
+			items = append(items, item)
+			total = total.Add(sku.Price.Mul(decimal.NewFromInt(int64(qty))))
+		}
+		order.TotalAmount = total
+
+		if err := s.orders.CreateOrder(ctx, order, items); err != nil {
+			return err
+		}
+		created++
+		return nil
+	})
+	if err != nil {
+		return created, fmt.Errorf("failed to seed orders: %w", err)
+	}
+	return created, nil
+}
+
+// inBatches calls fn for 0..total-1, committing every batchSize calls in their
+// own transaction so that a large run neither holds one huge transaction nor
+// pays for one per row.
+func (s *Seeder) inBatches(ctx context.Context, total, batchSize int, what string, fn func(ctx context.Context, i int) error) error {
+	for start := 0; start < total; start += batchSize {
+		end := min(start+batchSize, total)
+		err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+			for i := start; i < end; i++ {
+				if err := fn(ctx, i); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "Seeding progress", slog.String("entity", what), slog.Int("done", end), slog.Int("total", total))
+	}
+	return nil
+}
+
+func pickStatus(rng *rand.Rand) string {
+	total := 0
+	for _, s := range orderStatuses {
+		total += s.Weight
+	}
+	n := rng.IntN(total)
+	for _, s := range orderStatuses {
+		if n < s.Weight {
+			return s.Status
+		}
+		n -= s.Weight
+	}
+	return orderStatuses[0].Status
+}
@@ -0,0 +1,153 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type seederMocks struct {
+	categories *mocks.MockCategoryRepository
+	users      *mocks.MockUserRepository
+	products   *mocks.MockProductRepository
+	orders     *mocks.MockOrderRepository
+	tx         *mocks.MockTransactionManager
+	hasher     *mocks.MockPasswordHasher
+}
+
+func TestSeeder_Run(t *testing.T) {
+	opts := Options{Users: 3, Products: 5, MaxSKUs: 2, Orders: 7, Seed: 42, BatchSize: 2, RunTag: "t"}
+
+	tests := []struct {
+		name      string
+		mockSetup func(m seederMocks, nextID func() uint64)
+		wantErr   string
+		check     func(t *testing.T, s *Summary)
+	}{
+		{
+			name: "Success",
+			mockSetup: func(m seederMocks, nextID func() uint64) {
+				// "Electronics" already exists and is reused.
+				m.categories.EXPECT().List(gomock.Any()).Return([]model.Category{{Base: model.Base{ID: 1}, Name: "Electronics"}}, nil)
+				m.categories.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, c *model.Category) error {
+					assert.NotEqual(t, "Electronics", c.Name)
+					c.ID = nextID()
+					return nil
+				}).Times(countCategories() - 1)
+				m.hasher.EXPECT().Hash("password123").Return("hashed", nil).Times(1)
+				m.users.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u *model.User) error {
+					assert.Equal(t, "hashed", u.PasswordHash)
+					assert.Contains(t, u.Username, "_t_")
+					u.ID = nextID()
+					return nil
+				}).Times(opts.Users)
+				m.products.EXPECT().CreateSPU(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, spu *model.SPU) error {
+					assert.NotZero(t, spu.CategoryID)
+					assert.NotEmpty(t, spu.SKUs)
+					assert.LessOrEqual(t, len(spu.SKUs), opts.MaxSKUs)
+					for i := range spu.SKUs {
+						spu.SKUs[i].ID = nextID()
+					}
+					return nil
+				}).Times(opts.Products)
+				m.orders.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, o *model.Order, items []model.OrderItem) error {
+					require.NotEmpty(t, items)
+					assert.NotZero(t, o.UserID)
+					assert.False(t, o.CreatedAt.IsZero())
+					total := items[0].Price.Mul(decimal.NewFromInt(int64(items[0].Quantity)))
+					for _, it := range items[1:] {
+						total = total.Add(it.Price.Mul(decimal.NewFromInt(int64(it.Quantity))))
+					}
+					assert.True(t, total.Equal(o.TotalAmount))
+					return nil
+				}).Times(opts.Orders)
+			},
+			check: func(t *testing.T, s *Summary) {
+				assert.Equal(t, countCategories()-1, s.Categories)
+				assert.Equal(t, opts.Users, s.Users)
+				assert.Equal(t, opts.Products, s.Products)
+				assert.GreaterOrEqual(t, s.SKUs, opts.Products)
+				assert.Equal(t, opts.Orders, s.Orders)
+				assert.Equal(t, int64(42), s.Seed)
+			},
+		},
+		{
+			name: "UserCreateFails",
+			mockSetup: func(m seederMocks, nextID func() uint64) {
+				m.categories.EXPECT().List(gomock.Any()).Return(nil, nil)
+				m.categories.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, c *model.Category) error {
+					c.ID = nextID()
+					return nil
+				}).AnyTimes()
+				m.hasher.EXPECT().Hash(gomock.Any()).Return("hashed", nil)
+				m.users.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("duplicate key"))
+			},
+			wantErr: "failed to seed users",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			m := seederMocks{
+				categories: mocks.NewMockCategoryRepository(ctrl),
+				users:      mocks.NewMockUserRepository(ctrl),
+				products:   mocks.NewMockProductRepository(ctrl),
+				orders:     mocks.NewMockOrderRepository(ctrl),
+				tx:         mocks.NewMockTransactionManager(ctrl),
+				hasher:     mocks.NewMockPasswordHasher(ctrl),
+			}
+			m.tx.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			}).AnyTimes()
+			var id uint64 = 100
+			tt.mockSetup(m, func() uint64 { id++; return id })
+
+			seeder := NewSeeder(m.categories, m.users, m.products, m.orders, m.tx, m.hasher)
+			summary, err := seeder.Run(context.Background(), opts)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, summary)
+		})
+	}
+}
+
+func TestNewProduct_DistinctVariants(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for _, node := range catalog {
+		for _, leaf := range node.Children {
+			p := newProduct(rng, leaf, 6)
+			require.NotEmpty(t, p.SKUs, leaf.Name)
+			seen := make(map[string]bool)
+			for _, sku := range p.SKUs {
+				key := ""
+				for _, axis := range leaf.Axes {
+					key += sku.Attributes[axis.Key].(string) + ";"
+				}
+				assert.False(t, seen[key], "duplicate variant %s in %s", key, leaf.Name)
+				seen[key] = true
+				assert.True(t, sku.Price.IsPositive())
+			}
+		}
+	}
+}
+
+func countCategories() int {
+	n := 0
+	for _, node := range catalog {
+		n += 1 + len(node.Children)
+	}
+	return n
+}
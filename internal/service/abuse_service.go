@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Default bot protection thresholds used when the configuration leaves them unset.
+const (
+	DefaultAbuseMaxFailures = 5
+	DefaultAbuseWindow      = 10 * time.Minute
+)
+
+// incrWithTTL increments a counter and starts its expiry window on the first hit,
+// atomically so a crash between INCR and EXPIRE cannot leave a counter that never resets.
+var incrWithTTL = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return count
+`)
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/abuse_detector_mock.go -package=mocks
+// AbuseDetector tracks failed attempts per client IP and scope (e.g. "login")
+// and decides when further attempts must pass a CAPTCHA challenge.
+type AbuseDetector interface {
+	ChallengeRequired(ctx context.Context, scope, ip string) (bool, error)
+	RecordFailure(ctx context.Context, scope, ip string) error
+	// Reset clears the failure count, e.g. after the client solved a challenge.
+	Reset(ctx context.Context, scope, ip string) error
+	// SetLimits changes the thresholds at runtime; non-positive values fall back to defaults.
+	SetLimits(maxFailures int, window time.Duration)
+}
+
+type redisAbuseDetector struct {
+	redisClient *redis.Client
+	maxFailures atomic.Int64
+	window      atomic.Int64 // time.Duration
+}
+
+// NewAbuseDetector creates a Redis-backed AbuseDetector. Clients are challenged once they
+// reach maxFailures failed attempts within window; non-positive values fall back to defaults.
+func NewAbuseDetector(redisClient *redis.Client, maxFailures int, window time.Duration) AbuseDetector {
+	d := &redisAbuseDetector{redisClient: redisClient}
+	d.SetLimits(maxFailures, window)
+	return d
+}
+
+func (d *redisAbuseDetector) SetLimits(maxFailures int, window time.Duration) {
+	if maxFailures <= 0 {
+		maxFailures = DefaultAbuseMaxFailures
+	}
+	if window <= 0 {
+		window = DefaultAbuseWindow
+	}
+	d.maxFailures.Store(int64(maxFailures))
+	d.window.Store(int64(window))
+}
+
+func (d *redisAbuseDetector) ChallengeRequired(ctx context.Context, scope, ip string) (bool, error) {
+	val, err := d.redisClient.Get(ctx, abuseKey(scope, ip)).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read failure count: %w", err)
+	}
+	count, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("data corruption: invalid failure count '%s': %w", val, err)
+	}
+	return count >= d.maxFailures.Load(), nil
+}
+
+func (d *redisAbuseDetector) RecordFailure(ctx context.Context, scope, ip string) error {
+	err := incrWithTTL.Run(ctx, d.redisClient, []string{abuseKey(scope, ip)}, time.Duration(d.window.Load()).Milliseconds()).Err()
+	if err != nil {
+		return fmt.Errorf("failed to record failure: %w", err)
+	}
+	return nil
+}
+
+func (d *redisAbuseDetector) Reset(ctx context.Context, scope, ip string) error {
+	if err := d.redisClient.Del(ctx, abuseKey(scope, ip)).Err(); err != nil {
+		return fmt.Errorf("failed to reset failure count: %w", err)
+	}
+	return nil
+}
+
+func abuseKey(scope, ip string) string {
+	return fmt.Sprintf("mall:security:failures:%s:%s", scope, ip)
+}
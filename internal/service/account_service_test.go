@@ -0,0 +1,207 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type accountMocks struct {
+	repo   *mocks.MockUserRepository
+	hasher *mocks.MockPasswordHasher
+	maker  *mocks.MockMaker
+}
+
+func newAccountService(t *testing.T) (service.AccountService, accountMocks) {
+	ctrl := gomock.NewController(t)
+	m := accountMocks{
+		repo:   mocks.NewMockUserRepository(ctrl),
+		hasher: mocks.NewMockPasswordHasher(ctrl),
+		maker:  mocks.NewMockMaker(ctrl),
+	}
+	return service.NewAccountService(m.repo, m.hasher, m.maker), m
+}
+
+func TestAccountService_CreateUser(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       *service.AccountCreateReq
+		mockSetup func(m accountMocks)
+		wantErr   error
+		wantAudit string
+	}{
+		{
+			name: "AdminCreated",
+			req:  &service.AccountCreateReq{Username: "root", Email: "root@example.com", Password: "s3cret-pass", Role: model.RoleAdmin},
+			mockSetup: func(m accountMocks) {
+				m.repo.EXPECT().GetByUsername(gomock.Any(), "root").Return(nil, repository.ErrUserNotFound)
+				m.hasher.EXPECT().Hash("s3cret-pass").Return("hashed", nil)
+				m.repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u *model.User) error {
+					assert.Equal(t, model.RoleAdmin, u.Role)
+					assert.Equal(t, "hashed", u.PasswordHash)
+					u.ID = 7
+					return nil
+				})
+			},
+			wantAudit: "user.create",
+		},
+		{
+			name:      "InvalidRole",
+			req:       &service.AccountCreateReq{Username: "root", Password: "s3cret-pass", Role: "superuser"},
+			mockSetup: func(m accountMocks) {},
+			wantErr:   service.ErrInvalidRole,
+		},
+		{
+			name: "UsernameTaken",
+			req:  &service.AccountCreateReq{Username: "root", Password: "s3cret-pass", Role: model.RoleAdmin},
+			mockSetup: func(m accountMocks) {
+				m.repo.EXPECT().GetByUsername(gomock.Any(), "root").Return(&model.User{Username: "root"}, nil)
+			},
+			wantErr: service.ErrUserExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, m := newAccountService(t)
+			tt.mockSetup(m)
+			ctx, trail := service.WithAuditTrail(context.Background())
+
+			resp, err := svc.CreateUser(ctx, tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, resp)
+				assert.Empty(t, trail.Entries())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, uint64(7), resp.UserID)
+			assert.Equal(t, tt.req.Role, resp.Role)
+			require.Len(t, trail.Entries(), 1)
+			assert.Equal(t, tt.wantAudit, trail.Entries()[0].Action)
+			assert.Equal(t, "7", trail.Entries()[0].ResourceID)
+		})
+	}
+}
+
+func TestAccountService_ResetPassword(t *testing.T) {
+	user := &model.User{Base: model.Base{ID: 7}, Username: "root", Role: model.RoleAdmin}
+
+	tests := []struct {
+		name      string
+		mockSetup func(m accountMocks)
+		wantErr   error
+		errStr    string
+	}{
+		{
+			name: "Success",
+			mockSetup: func(m accountMocks) {
+				m.repo.EXPECT().GetByUsername(gomock.Any(), "root").Return(user, nil)
+				m.hasher.EXPECT().Hash("new-password").Return("new-hash", nil)
+				m.repo.EXPECT().UpdatePasswordHash(gomock.Any(), uint64(7), "new-hash").Return(nil)
+			},
+		},
+		{
+			name: "UserNotFound",
+			mockSetup: func(m accountMocks) {
+				m.repo.EXPECT().GetByUsername(gomock.Any(), "root").Return(nil, repository.ErrUserNotFound)
+			},
+			wantErr: repository.ErrUserNotFound,
+		},
+		{
+			name: "UpdateFails",
+			mockSetup: func(m accountMocks) {
+				m.repo.EXPECT().GetByUsername(gomock.Any(), "root").Return(user, nil)
+				m.hasher.EXPECT().Hash("new-password").Return("new-hash", nil)
+				m.repo.EXPECT().UpdatePasswordHash(gomock.Any(), uint64(7), "new-hash").Return(errors.New("db down"))
+			},
+			errStr: "failed to update password",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, m := newAccountService(t)
+			tt.mockSetup(m)
+			ctx, trail := service.WithAuditTrail(context.Background())
+
+			resp, err := svc.ResetPassword(ctx, "root", "new-password")
+			if tt.wantErr != nil || tt.errStr != "" {
+				require.Error(t, err)
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				}
+				assert.Contains(t, err.Error(), tt.errStr)
+				assert.Empty(t, trail.Entries())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "root", resp.Username)
+			require.Len(t, trail.Entries(), 1)
+			assert.Equal(t, "user.password_reset", trail.Entries()[0].Action)
+		})
+	}
+}
+
+func TestAccountService_IssueToken(t *testing.T) {
+	user := &model.User{Base: model.Base{ID: 7}, Username: "root"}
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		mockSetup func(m accountMocks)
+		wantErr   error
+	}{
+		{
+			name: "Success",
+			ttl:  time.Hour,
+			mockSetup: func(m accountMocks) {
+				m.repo.EXPECT().GetByUsername(gomock.Any(), "root").Return(user, nil)
+				m.maker.EXPECT().CreateToken(uint64(7), user.StoreID, "root", user.Role, time.Hour).Return("tok", &token.Payload{ExpiredAt: expiresAt}, nil)
+			},
+		},
+		{
+			name:      "NonPositiveTTL",
+			ttl:       0,
+			mockSetup: func(m accountMocks) {},
+			wantErr:   service.ErrInvalidTokenTTL,
+		},
+		{
+			name: "UserNotFound",
+			ttl:  time.Hour,
+			mockSetup: func(m accountMocks) {
+				m.repo.EXPECT().GetByUsername(gomock.Any(), "root").Return(nil, repository.ErrUserNotFound)
+			},
+			wantErr: repository.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, m := newAccountService(t)
+			tt.mockSetup(m)
+
+			resp, err := svc.IssueToken(context.Background(), "root", tt.ttl)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, resp)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "tok", resp.AccessToken)
+			assert.Equal(t, "Bearer", resp.TokenType)
+			assert.Equal(t, expiresAt, resp.ExpiresAt)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Package address validates and normalizes shipping addresses. Rules checks
+// an address against the format of its country and puts it in that
+// country's canonical form; a Geocoder, such as HTTPGeocoder, can then look
+// it up to tell whether anything is delivered there.
+package address
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Warning codes. Warnings never reject an address: the customer may know
+// better, e.g. for a building the geocoder does not know yet.
+const (
+	// WarningUndeliverable means the geocoder knows of no place to deliver
+	// to at the address.
+	WarningUndeliverable = "undeliverable"
+	// WarningCorrected means the geocoder changed the address to the one it
+	// knows, e.g. fixing the spelling of the city.
+	WarningCorrected = "corrected"
+	// WarningUnverified means the address could not be looked up, e.g. the
+	// geocoder is down.
+	WarningUnverified = "unverified"
+)
+
+// ErrInvalid means an address does not have the format of its country. It is
+// wrapped by *InvalidError.
+var ErrInvalid = errors.New("invalid address")
+
+// Address is the part of a shipping address that says where it is.
+type Address struct {
+	Line1      string
+	Line2      string
+	City       string
+	PostalCode string
+}
+
+// Problem is a field of an address that does not have the format it should.
+type Problem struct {
+	Field   string // line1, line2, city or postal_code
+	Message string
+}
+
+// InvalidError lists what makes an address invalid.
+type InvalidError struct {
+	Problems []Problem
+}
+
+func (e *InvalidError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		problems[i] = fmt.Sprintf("%s %s", p.Field, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalid, strings.Join(problems, "; "))
+}
+
+func (e *InvalidError) Unwrap() error {
+	return ErrInvalid
+}
+
+// Warning is something about an address the customer should check.
+type Warning struct {
+	Code    string
+	Field   string // Empty when about the whole address
+	Message string
+}
+
+// Result is an address in its normalized form, with what to check about it.
+type Result struct {
+	Address  Address
+	Warnings []Warning
+}
+
+// Geocoder looks addresses up, e.g. with a maps API.
+//
+//go:generate mockgen -source=$GOFILE -destination=../../mocks/address_geocoder_mock.go -package=mocks
+type Geocoder interface {
+	// Geocode returns the address as the geocoder knows it, with a
+	// WarningUndeliverable if nothing is delivered there. region is an
+	// ISO 3166-1 alpha-2 country or ISO 3166-2 subdivision, and may be
+	// empty. An error means the address could not be looked up right now.
+	Geocode(ctx context.Context, region string, addr Address) (*Result, error)
+}
+
+// country returns the country part of an ISO 3166-2 subdivision code.
+func country(region string) string {
+	code, _, _ := strings.Cut(strings.ToUpper(region), "-")
+	return code
+}
@@ -0,0 +1,125 @@
+package address
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+// DefaultGeocoderTimeout bounds one call to the geocoder when
+// address.geocoder.timeout is zero. Checkout waits for it.
+const DefaultGeocoderTimeout = 3 * time.Second
+
+// maxGeocoderResponse bounds how much of a response is read.
+const maxGeocoderResponse = 64 << 10
+
+// HTTPGeocoder asks an external address service about each address. It POSTs
+// a geocoderAddress as JSON and expects a geocoderResponse back; a 404 or 422
+// answer means the service knows no such address.
+type HTTPGeocoder struct {
+	client *http.Client
+	url    string
+	apiKey string
+}
+
+type geocoderAddress struct {
+	Region     string `json:"region,omitempty"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	PostalCode string `json:"postal_code,omitempty"`
+}
+
+type geocoderResponse struct {
+	Deliverable bool             `json:"deliverable"`
+	Address     *geocoderAddress `json:"address"` // Omitted when the service has no better form
+	Warnings    []struct {
+		Code    string `json:"code"`
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	} `json:"warnings"`
+}
+
+// NewHTTPGeocoder creates an HTTPGeocoder for the service in cfg.
+func NewHTTPGeocoder(cfg config.AddressGeocoderConfig) (*HTTPGeocoder, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("address geocoder url is required")
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultGeocoderTimeout
+	}
+	return &HTTPGeocoder{
+		client: &http.Client{Timeout: timeout},
+		url:    cfg.URL,
+		apiKey: cfg.APIKey,
+	}, nil
+}
+
+// Geocode returns the address the service answered with, or addr if it gave
+// none, with a WarningCorrected if it differs.
+func (g *HTTPGeocoder) Geocode(ctx context.Context, region string, addr Address) (*Result, error) {
+	encoded, err := json.Marshal(geocoderAddress{Region: region, Line1: addr.Line1, Line2: addr.Line2, City: addr.City, PostalCode: addr.PostalCode})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode geocoder request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url, bytes.NewReader(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build geocoder request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	if g.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+g.apiKey)
+	}
+
+	resp, err := g.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call geocoder: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusUnprocessableEntity:
+		return &Result{Address: addr, Warnings: []Warning{undeliverable()}}, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("geocoder returned status %d", resp.StatusCode)
+	}
+
+	var answer geocoderResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxGeocoderResponse)).Decode(&answer); err != nil {
+		return nil, fmt.Errorf("failed to decode geocoder response: %w", err)
+	}
+	result := &Result{Address: addr}
+	if answer.Address != nil {
+		result.Address = Address{Line1: answer.Address.Line1, Line2: answer.Address.Line2, City: answer.Address.City, PostalCode: answer.Address.PostalCode}
+		if result.Address.Line1 == "" || result.Address.City == "" {
+			return nil, fmt.Errorf("geocoder returned an address without line1 or city")
+		}
+		if result.Address != addr {
+			result.Warnings = append(result.Warnings, Warning{Code: WarningCorrected, Message: "address was corrected to the one the geocoder knows"})
+		}
+	}
+	for _, w := range answer.Warnings {
+		if w.Code == "" {
+			continue
+		}
+		result.Warnings = append(result.Warnings, Warning{Code: w.Code, Field: w.Field, Message: w.Message})
+	}
+	if !answer.Deliverable && !slices.ContainsFunc(result.Warnings, func(w Warning) bool { return w.Code == WarningUndeliverable }) {
+		result.Warnings = append(result.Warnings, undeliverable())
+	}
+	return result, nil
+}
+
+func undeliverable() Warning {
+	return Warning{Code: WarningUndeliverable, Message: "nothing is known to be delivered to this address"}
+}
@@ -0,0 +1,83 @@
+package address
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPGeocoder_Geocode(t *testing.T) {
+	addr := Address{Line1: "Heidestrasse 17", City: "Berlin", PostalCode: "10557"}
+
+	tests := []struct {
+		name         string
+		status       int
+		response     string
+		wantAddress  Address
+		wantWarnings []string
+		wantErr      bool
+	}{
+		{name: "Deliverable", status: http.StatusOK, response: `{"deliverable":true}`, wantAddress: addr},
+		{
+			name:         "Corrected",
+			status:       http.StatusOK,
+			response:     `{"deliverable":true,"address":{"line1":"Heidestraße 17","city":"Berlin","postal_code":"10557"}}`,
+			wantAddress:  Address{Line1: "Heidestraße 17", City: "Berlin", PostalCode: "10557"},
+			wantWarnings: []string{WarningCorrected},
+		},
+		{name: "NotDeliverable", status: http.StatusOK, response: `{"deliverable":false}`, wantAddress: addr, wantWarnings: []string{WarningUndeliverable}},
+		{
+			name:         "NotDeliverableWithWarning",
+			status:       http.StatusOK,
+			response:     `{"deliverable":false,"warnings":[{"code":"undeliverable","field":"line1","message":"no such house number"}]}`,
+			wantAddress:  addr,
+			wantWarnings: []string{WarningUndeliverable},
+		},
+		{name: "UnknownAddress", status: http.StatusUnprocessableEntity, wantAddress: addr, wantWarnings: []string{WarningUndeliverable}},
+		{name: "GeocoderError", status: http.StatusBadGateway, wantErr: true},
+		{name: "AddressWithoutCity", status: http.StatusOK, response: `{"deliverable":true,"address":{"line1":"Heidestraße 17"}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+				var body geocoderAddress
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				assert.Equal(t, geocoderAddress{Region: "DE", Line1: addr.Line1, City: addr.City, PostalCode: addr.PostalCode}, body)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			g, err := NewHTTPGeocoder(config.AddressGeocoderConfig{URL: server.URL, APIKey: "key"})
+			require.NoError(t, err)
+
+			result, err := g.Geocode(context.Background(), "DE", addr)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAddress, result.Address)
+			codes := make([]string, len(result.Warnings))
+			for i, w := range result.Warnings {
+				codes[i] = w.Code
+			}
+			assert.Equal(t, len(tt.wantWarnings), len(codes))
+			assert.Subset(t, codes, tt.wantWarnings)
+		})
+	}
+}
+
+func TestNewHTTPGeocoder_RequiresURL(t *testing.T) {
+	_, err := NewHTTPGeocoder(config.AddressGeocoderConfig{})
+	assert.Error(t, err)
+}
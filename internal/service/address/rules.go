@@ -0,0 +1,96 @@
+package address
+
+import (
+	"regexp"
+	"strings"
+)
+
+// postalFormat is how the postal codes of a country are written. Codes are
+// matched with spaces and hyphens removed, then written in their canonical
+// form by inserting sep before the last tail characters, e.g. "SW1A1AA" as
+// "SW1A 1AA". With length set, only codes that long get sep, e.g. ZIP+4
+// codes but not plain ZIP codes.
+type postalFormat struct {
+	pattern *regexp.Regexp
+	sep     string
+	tail    int
+	length  int
+	example string
+}
+
+func (f postalFormat) format(code string) string {
+	if f.sep == "" || len(code) <= f.tail || f.length > 0 && len(code) != f.length {
+		return code
+	}
+	return code[:len(code)-f.tail] + f.sep + code[len(code)-f.tail:]
+}
+
+// postalFormats are the countries whose postal codes are checked. A nil
+// format is a country without postal codes, whose addresses need none.
+// Countries not listed accept any postal code.
+var postalFormats = map[string]*postalFormat{
+	"AE": nil,
+	"AU": {pattern: regexp.MustCompile(`^\d{4}$`), example: "2000"},
+	"BR": {pattern: regexp.MustCompile(`^\d{8}$`), sep: "-", tail: 3, example: "01310-100"},
+	"CA": {pattern: regexp.MustCompile(`^[A-Z]\d[A-Z]\d[A-Z]\d$`), sep: " ", tail: 3, example: "K1A 0B1"},
+	"CN": {pattern: regexp.MustCompile(`^\d{6}$`), example: "100000"},
+	"DE": {pattern: regexp.MustCompile(`^\d{5}$`), example: "10115"},
+	"ES": {pattern: regexp.MustCompile(`^\d{5}$`), example: "28001"},
+	"FR": {pattern: regexp.MustCompile(`^\d{5}$`), example: "75001"},
+	"GB": {pattern: regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]?\d[A-Z]{2}$`), sep: " ", tail: 3, example: "SW1A 1AA"},
+	"HK": nil,
+	"IN": {pattern: regexp.MustCompile(`^\d{6}$`), example: "110001"},
+	"IT": {pattern: regexp.MustCompile(`^\d{5}$`), example: "00118"},
+	"JP": {pattern: regexp.MustCompile(`^\d{7}$`), sep: "-", tail: 4, example: "100-0001"},
+	"NL": {pattern: regexp.MustCompile(`^\d{4}[A-Z]{2}$`), sep: " ", tail: 2, example: "1012 AB"},
+	"SG": {pattern: regexp.MustCompile(`^\d{6}$`), example: "018956"},
+	"US": {pattern: regexp.MustCompile(`^\d{5}(\d{4})?$`), sep: "-", tail: 4, length: 9, example: "94105 or 94105-1234"},
+}
+
+// Rules checks addresses against the format of their country and normalizes
+// them: surplus whitespace is removed and postal codes are written the way
+// the country writes them. Addresses of countries it does not know are only
+// checked for the fields every address needs.
+type Rules struct{}
+
+// Validate returns addr in its normalized form, or an *InvalidError.
+func (Rules) Validate(region string, addr Address) (*Result, error) {
+	addr = Address{
+		Line1:      collapseSpaces(addr.Line1),
+		Line2:      collapseSpaces(addr.Line2),
+		City:       collapseSpaces(addr.City),
+		PostalCode: strings.ToUpper(collapseSpaces(addr.PostalCode)),
+	}
+
+	var problems []Problem
+	if addr.Line1 == "" {
+		problems = append(problems, Problem{Field: "line1", Message: "is required"})
+	}
+	if addr.City == "" {
+		problems = append(problems, Problem{Field: "city", Message: "is required"})
+	}
+
+	format, known := postalFormats[country(region)]
+	switch {
+	case !known, format == nil:
+	case addr.PostalCode == "":
+		problems = append(problems, Problem{Field: "postal_code", Message: "is required"})
+	default:
+		code := strings.NewReplacer(" ", "", "-", "").Replace(addr.PostalCode)
+		if !format.pattern.MatchString(code) {
+			problems = append(problems, Problem{Field: "postal_code", Message: "must be written like " + format.example})
+		} else {
+			addr.PostalCode = format.format(code)
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &InvalidError{Problems: problems}
+	}
+	return &Result{Address: addr}, nil
+}
+
+// collapseSpaces trims s and turns each run of whitespace into one space.
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
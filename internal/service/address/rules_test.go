@@ -0,0 +1,57 @@
+package address
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		region     string
+		addr       Address
+		wantPostal string
+		wantFields []string
+	}{
+		{name: "Germany", region: "DE", addr: Address{Line1: "Heidestraße 17", City: "Berlin", PostalCode: "10557"}, wantPostal: "10557"},
+		{name: "UKCanonical", region: "GB", addr: Address{Line1: "10 Downing St", City: "London", PostalCode: "sw1a2aa"}, wantPostal: "SW1A 2AA"},
+		{name: "CanadaSpaced", region: "CA-ON", addr: Address{Line1: "1 Main St", City: "Ottawa", PostalCode: "k1a 0b1"}, wantPostal: "K1A 0B1"},
+		{name: "JapanHyphen", region: "JP", addr: Address{Line1: "1-1 Chiyoda", City: "Tokyo", PostalCode: "1000001"}, wantPostal: "100-0001"},
+		{name: "USZipPlusFour", region: "US-CA", addr: Address{Line1: "1 Market St", City: "San Francisco", PostalCode: "941051234"}, wantPostal: "94105-1234"},
+		{name: "USZip", region: "US", addr: Address{Line1: "1 Market St", City: "San Francisco", PostalCode: "94105"}, wantPostal: "94105"},
+		{name: "NoPostalCodes", region: "HK", addr: Address{Line1: "1 Queen's Rd", City: "Hong Kong"}},
+		{name: "UnknownCountry", region: "ZZ", addr: Address{Line1: "1 Road", City: "Town", PostalCode: "anything"}, wantPostal: "ANYTHING"},
+		{name: "NoRegion", addr: Address{Line1: "1 Road", City: "Town"}},
+		{name: "WrongPostalCode", region: "DE", addr: Address{Line1: "Heidestraße 17", City: "Berlin", PostalCode: "1055"}, wantFields: []string{"postal_code"}},
+		{name: "MissingPostalCode", region: "FR", addr: Address{Line1: "1 Rue de Rivoli", City: "Paris"}, wantFields: []string{"postal_code"}},
+		{name: "MissingLines", region: "DE", addr: Address{Line1: "  ", PostalCode: "10557"}, wantFields: []string{"line1", "city"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Rules{}.Validate(tt.region, tt.addr)
+			if tt.wantFields != nil {
+				var invalid *InvalidError
+				require.ErrorAs(t, err, &invalid)
+				assert.ErrorIs(t, err, ErrInvalid)
+				fields := make([]string, len(invalid.Problems))
+				for i, p := range invalid.Problems {
+					fields[i] = p.Field
+				}
+				assert.Equal(t, tt.wantFields, fields)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPostal, result.Address.PostalCode)
+			assert.Empty(t, result.Warnings)
+		})
+	}
+}
+
+func TestRules_Validate_CollapsesWhitespace(t *testing.T) {
+	result, err := Rules{}.Validate("DE", Address{Line1: "  Heidestraße   17 ", Line2: " ", City: "Berlin\t", PostalCode: " 10557 "})
+	require.NoError(t, err)
+	assert.Equal(t, Address{Line1: "Heidestraße 17", City: "Berlin", PostalCode: "10557"}, result.Address)
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service/address"
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// ErrInvalidAddress means an address does not have the format of the country
+// it is in. Its metadata maps each field at fault to what is wrong with it.
+var ErrInvalidAddress = apperr.New(apperr.CodeAddressInvalid)
+
+// AddressWarning is something about an address the customer should check,
+// e.g. that nothing is known to be delivered there. It does not reject the
+// address.
+type AddressWarning struct {
+	Code    string `json:"code" example:"undeliverable"` // undeliverable, corrected, unverified, or one of the geocoder's
+	Field   string `json:"field,omitempty" example:"postal_code"`
+	Message string `json:"message" example:"nothing is known to be delivered to this address"`
+}
+
+// AddressValidationResp is an address in its normalized form.
+type AddressValidationResp struct {
+	Address     model.Address    `json:"address"`
+	Deliverable bool             `json:"deliverable"` // False with an undeliverable warning
+	Warnings    []AddressWarning `json:"warnings"`
+}
+
+// AddressService validates and normalizes shipping addresses with the rules
+// of address.Rules and, if configured, a geocoder.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/address_service_mock.go -package=mocks
+type AddressService interface {
+	// Validate checks addr, shipped to region, against the format of its
+	// country and returns it normalized. region is an ISO 3166 code and may
+	// be empty, when only the fields every address needs are checked. It
+	// returns ErrInvalidAddress for an address that does not have the
+	// format. A geocoder that cannot be reached does not fail it: the
+	// address gets an unverified warning instead.
+	Validate(ctx context.Context, region string, addr model.Address) (*AddressValidationResp, error)
+}
+
+type addressService struct {
+	rules    address.Rules
+	geocoder address.Geocoder // Nil without a geocoder
+}
+
+// NewAddressService creates a new AddressService. geocoder may be nil.
+func NewAddressService(geocoder address.Geocoder) AddressService {
+	return &addressService{geocoder: geocoder}
+}
+
+func (s *addressService) Validate(ctx context.Context, region string, addr model.Address) (*AddressValidationResp, error) {
+	region = strings.ToUpper(region)
+	result, err := s.rules.Validate(region, address.Address{Line1: addr.Line1, Line2: addr.Line2, City: addr.City, PostalCode: addr.PostalCode})
+	var invalid *address.InvalidError
+	if errors.As(err, &invalid) {
+		appErr := ErrInvalidAddress
+		for _, p := range invalid.Problems {
+			appErr = appErr.With(p.Field, p.Message)
+		}
+		return nil, appErr.Wrap(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if s.geocoder != nil {
+		geocoded, err := s.geocoder.Geocode(ctx, region, result.Address)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to geocode address", "region", region, logger.Err(err))
+			result.Warnings = append(result.Warnings, address.Warning{Code: address.WarningUnverified, Message: "address could not be verified"})
+		} else {
+			result = geocoded
+		}
+	}
+
+	resp := &AddressValidationResp{
+		Address: model.Address{
+			Name:       strings.Join(strings.Fields(addr.Name), " "),
+			Phone:      strings.TrimSpace(addr.Phone),
+			Line1:      result.Address.Line1,
+			Line2:      result.Address.Line2,
+			City:       result.Address.City,
+			PostalCode: result.Address.PostalCode,
+		},
+		Deliverable: true,
+		Warnings:    make([]AddressWarning, len(result.Warnings)),
+	}
+	for i, w := range result.Warnings {
+		resp.Warnings[i] = AddressWarning{Code: w.Code, Field: w.Field, Message: w.Message}
+		if w.Code == address.WarningUndeliverable {
+			resp.Deliverable = false
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,88 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/address"
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAddressService_Validate(t *testing.T) {
+	addr := model.Address{Name: " Jane  Doe ", Phone: " +44 20 7946 0000 ", Line1: "10 Downing St", City: "London", PostalCode: "sw1a2aa"}
+	normalized := address.Address{Line1: "10 Downing St", City: "London", PostalCode: "SW1A 2AA"}
+
+	tests := []struct {
+		name            string
+		mockSetup       func(geocoder *mocks.MockGeocoder)
+		wantAddress     model.Address
+		wantDeliverable bool
+		wantWarnings    []string
+	}{
+		{
+			name: "Deliverable",
+			mockSetup: func(geocoder *mocks.MockGeocoder) {
+				geocoder.EXPECT().Geocode(gomock.Any(), "GB", normalized).Return(&address.Result{Address: normalized}, nil)
+			},
+			wantAddress:     model.Address{Name: "Jane Doe", Phone: "+44 20 7946 0000", Line1: "10 Downing St", City: "London", PostalCode: "SW1A 2AA"},
+			wantDeliverable: true,
+			wantWarnings:    []string{},
+		},
+		{
+			name: "Undeliverable",
+			mockSetup: func(geocoder *mocks.MockGeocoder) {
+				geocoder.EXPECT().Geocode(gomock.Any(), "GB", normalized).Return(&address.Result{
+					Address:  normalized,
+					Warnings: []address.Warning{{Code: address.WarningUndeliverable, Message: "nothing is known to be delivered to this address"}},
+				}, nil)
+			},
+			wantAddress:  model.Address{Name: "Jane Doe", Phone: "+44 20 7946 0000", Line1: "10 Downing St", City: "London", PostalCode: "SW1A 2AA"},
+			wantWarnings: []string{address.WarningUndeliverable},
+		},
+		{
+			name: "GeocoderDown",
+			mockSetup: func(geocoder *mocks.MockGeocoder) {
+				geocoder.EXPECT().Geocode(gomock.Any(), "GB", normalized).Return(nil, errors.New("geocoder returned status 503"))
+			},
+			wantAddress:     model.Address{Name: "Jane Doe", Phone: "+44 20 7946 0000", Line1: "10 Downing St", City: "London", PostalCode: "SW1A 2AA"},
+			wantDeliverable: true,
+			wantWarnings:    []string{address.WarningUnverified},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			geocoder := mocks.NewMockGeocoder(ctrl)
+			tt.mockSetup(geocoder)
+
+			resp, err := service.NewAddressService(geocoder).Validate(context.Background(), "gb", addr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAddress, resp.Address)
+			assert.Equal(t, tt.wantDeliverable, resp.Deliverable)
+			codes := make([]string, len(resp.Warnings))
+			for i, w := range resp.Warnings {
+				codes[i] = w.Code
+			}
+			assert.Equal(t, tt.wantWarnings, codes)
+		})
+	}
+}
+
+func TestAddressService_Validate_Invalid(t *testing.T) {
+	// The geocoder is never asked about an address of the wrong format
+	svc := service.NewAddressService(mocks.NewMockGeocoder(gomock.NewController(t)))
+
+	_, err := svc.Validate(context.Background(), "DE", model.Address{Line1: "Heidestraße 17", PostalCode: "ABC"})
+	require.ErrorIs(t, err, service.ErrInvalidAddress)
+	var appErr *apperr.Error
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, map[string]any{"city": "is required", "postal_code": "must be written like 10115"}, appErr.Meta)
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+)
+
+// Audit log paging bounds.
+const (
+	DefaultAuditPageSize = 20
+	MaxAuditPageSize     = 100
+)
+
+// AuditEntry describes one change a service made on behalf of an admin.
+type AuditEntry struct {
+	Action     string // e.g. "ip_rule.put"
+	Resource   string // e.g. "ip_rule"
+	ResourceID string
+	Before     any // State before the change; nil for creations
+	After      any // State after the change; nil for deletions
+}
+
+// AuditActor identifies the admin request that caused the entries.
+type AuditActor struct {
+	UserID    uint64
+	IP        string
+	RequestID string
+	Method    string
+	Path      string
+}
+
+// AuditTrail collects the entries recorded while one request runs.
+type AuditTrail struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+}
+
+// Entries returns a copy of the recorded entries.
+func (t *AuditTrail) Entries() []AuditEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]AuditEntry(nil), t.entries...)
+}
+
+type auditTrailKey struct{}
+
+// WithAuditTrail starts collecting entries recorded through RecordAudit on ctx.
+func WithAuditTrail(ctx context.Context) (context.Context, *AuditTrail) {
+	trail := &AuditTrail{}
+	return context.WithValue(ctx, auditTrailKey{}, trail), trail
+}
+
+// RecordAudit is the hook services call after a successful mutation. Outside an
+// audited request (e.g. background jobs) it does nothing.
+func RecordAudit(ctx context.Context, entry AuditEntry) {
+	trail, ok := ctx.Value(auditTrailKey{}).(*AuditTrail)
+	if !ok {
+		return
+	}
+	trail.mu.Lock()
+	defer trail.mu.Unlock()
+	trail.entries = append(trail.entries, entry)
+}
+
+// AuditLogListReq filters and pages the audit log. Zero values match everything.
+type AuditLogListReq struct {
+	ActorID    uint64
+	Action     string
+	Resource   string
+	ResourceID string
+	From       time.Time
+	To         time.Time
+	Offset     int
+	Limit      int // 0 means DefaultAuditPageSize; capped at MaxAuditPageSize
+}
+
+// AuditLogListResp is one page of audit records, newest first.
+type AuditLogListResp struct {
+	Items []model.AuditLog `json:"items"`
+	Total int64            `json:"total"`
+}
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/audit_service_mock.go -package=mocks
+// AuditService writes and queries the append-only audit log.
+type AuditService interface {
+	Write(ctx context.Context, actor AuditActor, entries []AuditEntry) error
+	List(ctx context.Context, req *AuditLogListReq) (*AuditLogListResp, error)
+}
+
+type auditService struct {
+	auditRepo repository.AuditLogRepository
+}
+
+// NewAuditService creates a new AuditService.
+func NewAuditService(auditRepo repository.AuditLogRepository) AuditService {
+	return &auditService{auditRepo: auditRepo}
+}
+
+func (s *auditService) Write(ctx context.Context, actor AuditActor, entries []AuditEntry) error {
+	logs := make([]*model.AuditLog, 0, len(entries))
+	for _, entry := range entries {
+		before, err := toJSONB(entry.Before)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit state for %s: %w", entry.Action, err)
+		}
+		after, err := toJSONB(entry.After)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit state for %s: %w", entry.Action, err)
+		}
+		logs = append(logs, &model.AuditLog{
+			ActorID:    actor.UserID,
+			Action:     entry.Action,
+			Resource:   entry.Resource,
+			ResourceID: entry.ResourceID,
+			Before:     before,
+			After:      after,
+			Diff:       diffJSONB(before, after),
+			IP:         actor.IP,
+			RequestID:  actor.RequestID,
+			Method:     actor.Method,
+			Path:       actor.Path,
+		})
+	}
+	return s.auditRepo.Create(ctx, logs)
+}
+
+func (s *auditService) List(ctx context.Context, req *AuditLogListReq) (*AuditLogListResp, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultAuditPageSize
+	}
+	if limit > MaxAuditPageSize {
+		limit = MaxAuditPageSize
+	}
+	offset := req.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	logs, total, err := s.auditRepo.List(ctx, repository.AuditLogFilter{
+		ActorID:    req.ActorID,
+		Action:     req.Action,
+		Resource:   req.Resource,
+		ResourceID: req.ResourceID,
+		From:       req.From,
+		To:         req.To,
+	}, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	if logs == nil {
+		logs = []model.AuditLog{}
+	}
+	return &AuditLogListResp{Items: logs, Total: total}, nil
+}
+
+// toJSONB converts a state snapshot to its JSON object form, so the stored diff uses
+// the same field names as the API. Non-object values are stored under "value".
+func toJSONB(v any) (model.JSONB, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var obj model.JSONB
+	if err := json.Unmarshal(data, &obj); err != nil {
+		var scalar any
+		if err := json.Unmarshal(data, &scalar); err != nil {
+			return nil, err
+		}
+		return model.JSONB{"value": scalar}, nil
+	}
+	return obj, nil
+}
+
+// diffJSONB returns the top-level fields that differ as {field: {"from": x, "to": y}}.
+func diffJSONB(before, after model.JSONB) model.JSONB {
+	diff := model.JSONB{}
+	for key, old := range before {
+		if val, ok := after[key]; !ok || !reflect.DeepEqual(old, val) {
+			diff[key] = map[string]interface{}{"from": old, "to": after[key]}
+		}
+	}
+	for key, val := range after {
+		if _, ok := before[key]; !ok {
+			diff[key] = map[string]interface{}{"from": nil, "to": val}
+		}
+	}
+	if len(diff) == 0 {
+		return nil
+	}
+	return diff
+}
@@ -0,0 +1,133 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRecordAudit(t *testing.T) {
+	// Outside an audited request the hook is a no-op.
+	service.RecordAudit(context.Background(), service.AuditEntry{Action: "ignored"})
+
+	ctx, trail := service.WithAuditTrail(context.Background())
+	service.RecordAudit(ctx, service.AuditEntry{Action: "ip_rule.put"})
+	service.RecordAudit(ctx, service.AuditEntry{Action: "ip_rule.delete"})
+
+	entries := trail.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "ip_rule.put", entries[0].Action)
+	assert.Equal(t, "ip_rule.delete", entries[1].Action)
+}
+
+func TestAuditService_Write(t *testing.T) {
+	type rule struct {
+		CIDR   string `json:"cidr"`
+		Action string `json:"action"`
+		Reason string `json:"reason,omitempty"`
+	}
+	var nilRule *rule
+	actor := service.AuditActor{UserID: 7, IP: "198.51.100.4", RequestID: "req-1", Method: "POST", Path: "/api/v1/admin/ip-rules"}
+
+	tests := []struct {
+		name     string
+		entry    service.AuditEntry
+		wantDiff model.JSONB
+		check    func(t *testing.T, log *model.AuditLog)
+	}{
+		{
+			name: "Update",
+			entry: service.AuditEntry{
+				Action: "ip_rule.put", Resource: "ip_rule", ResourceID: "203.0.113.0/24",
+				Before: &rule{CIDR: "203.0.113.0/24", Action: "allow"},
+				After:  &rule{CIDR: "203.0.113.0/24", Action: "deny", Reason: "abuse"},
+			},
+			wantDiff: model.JSONB{
+				"action": map[string]interface{}{"from": "allow", "to": "deny"},
+				"reason": map[string]interface{}{"from": nil, "to": "abuse"},
+			},
+		},
+		{
+			name:  "CreateWithTypedNilBefore",
+			entry: service.AuditEntry{Action: "ip_rule.put", Before: nilRule, After: rule{CIDR: "10.0.0.0/8", Action: "deny"}},
+			wantDiff: model.JSONB{
+				"cidr":   map[string]interface{}{"from": nil, "to": "10.0.0.0/8"},
+				"action": map[string]interface{}{"from": nil, "to": "deny"},
+			},
+			check: func(t *testing.T, log *model.AuditLog) { assert.Nil(t, log.Before) },
+		},
+		{
+			name:     "ScalarState",
+			entry:    service.AuditEntry{Action: "sku.stock", Before: 5, After: 3},
+			wantDiff: model.JSONB{"value": map[string]interface{}{"from": float64(5), "to": float64(3)}},
+		},
+		{
+			name:  "NoChange",
+			entry: service.AuditEntry{Action: "ip_rule.put", Before: rule{CIDR: "a"}, After: rule{CIDR: "a"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockRepo := mocks.NewMockAuditLogRepository(ctrl)
+			mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, logs []*model.AuditLog) error {
+				require.Len(t, logs, 1)
+				log := logs[0]
+				assert.Equal(t, uint64(7), log.ActorID)
+				assert.Equal(t, "198.51.100.4", log.IP)
+				assert.Equal(t, "req-1", log.RequestID)
+				assert.Equal(t, tt.entry.Action, log.Action)
+				assert.Equal(t, tt.wantDiff, log.Diff)
+				if tt.check != nil {
+					tt.check(t, log)
+				}
+				return nil
+			})
+
+			err := service.NewAuditService(mockRepo).Write(context.Background(), actor, []service.AuditEntry{tt.entry})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuditService_List(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        *service.AuditLogListReq
+		wantOffset int
+		wantLimit  int
+		repoErr    error
+	}{
+		{name: "Defaults", req: &service.AuditLogListReq{}, wantLimit: service.DefaultAuditPageSize},
+		{name: "LimitCapped", req: &service.AuditLogListReq{Offset: 40, Limit: 1000}, wantOffset: 40, wantLimit: service.MaxAuditPageSize},
+		{name: "RepoError", req: &service.AuditLogListReq{Action: "ip_rule.put"}, wantLimit: service.DefaultAuditPageSize, repoErr: errors.New("db down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockRepo := mocks.NewMockAuditLogRepository(ctrl)
+			mockRepo.EXPECT().
+				List(gomock.Any(), repository.AuditLogFilter{Action: tt.req.Action}, tt.wantOffset, tt.wantLimit).
+				Return(nil, int64(0), tt.repoErr)
+
+			resp, err := service.NewAuditService(mockRepo).List(context.Background(), tt.req)
+			if tt.repoErr != nil {
+				assert.ErrorIs(t, err, tt.repoErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, resp.Items, "an empty page must encode as [] not null")
+			assert.Zero(t, resp.Total)
+		})
+	}
+}
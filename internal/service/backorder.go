@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/proyuen/go-mall/internal/model"
+)
+
+// DefaultBackorderBatchSize is how many backordered orders AllocateBackorders
+// loads per query when the caller does not say.
+const DefaultBackorderBatchSize = 100
+
+// ErrOrderBackordered means items of the order wait for stock, so it cannot
+// ship yet.
+var ErrOrderBackordered = errors.New("order is waiting for stock")
+
+// AllocateBackorders goes through the waiting orders oldest first, so that
+// stock goes to the customers who ordered first. An order is allocated
+// whole or not at all: one whose items do not all have the stock yet is
+// skipped, and a later order may take what it leaves.
+func (s *fulfillmentService) AllocateBackorders(ctx context.Context, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultBackorderBatchSize
+	}
+	allocated := 0
+	var errs []error
+	for afterID := uint64(0); ; {
+		orders, err := s.orderRepo.ListBackordered(ctx, afterID, batchSize)
+		if err != nil {
+			return allocated, errors.Join(append(errs, err)...)
+		}
+
+		for i := range orders {
+			ok, err := s.allocateBackorder(ctx, orders[i].ID)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if ok {
+				allocated++
+			}
+		}
+
+		if len(orders) < batchSize {
+			return allocated, errors.Join(errs...)
+		}
+		afterID = orders[len(orders)-1].ID
+	}
+}
+
+// allocateBackorder deducts the stock of the backordered items of one order,
+// and moves a backordered order to paid. It reports false if there is too
+// little stock, or the order is no longer waiting. The order row is locked
+// before the SKUs, in ID order, as Cancel and CreateOrder lock them.
+func (s *fulfillmentService) allocateBackorder(ctx context.Context, orderID uint64) (bool, error) {
+	allocated := false
+	var items []model.OrderItem
+	err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		order, err := s.orderRepo.GetByIDForUpdate(txCtx, orderID)
+		if err != nil {
+			return err
+		}
+		if order.Status != model.OrderStatusBackordered && order.Status != model.OrderStatusAuthorized {
+			return nil
+		}
+
+		quantities := make(map[uint64]int)
+		for _, item := range order.Items {
+			if item.Backordered {
+				quantities[item.SKUID] += item.Quantity
+			}
+		}
+		skuIDs := slices.Sorted(maps.Keys(quantities))
+		for _, skuID := range skuIDs {
+			sku, err := s.productRepo.GetSKUForUpdate(txCtx, skuID)
+			if err != nil {
+				return fmt.Errorf("failed to lock SKU %d: %w", skuID, err)
+			}
+			if sku.Stock < quantities[skuID] {
+				return nil
+			}
+		}
+		for _, skuID := range skuIDs {
+			if err := s.productRepo.UpdateSKUStock(txCtx, skuID, -quantities[skuID]); err != nil {
+				return fmt.Errorf("failed to deduct stock for SKU %d: %w", skuID, err)
+			}
+		}
+		if err := s.orderRepo.AllocateItems(txCtx, order.ID); err != nil {
+			return err
+		}
+		if order.Status == model.OrderStatusBackordered {
+			if err := s.orderRepo.UpdateStatus(txCtx, order.ID, model.OrderStatusBackordered, model.OrderStatusPaid); err != nil {
+				return err
+			}
+			paid := *order
+			paid.Status = model.OrderStatusPaid
+			if err := s.events.Publish(txCtx, EventOrderUpdated, newOrderWebhookData(&paid, order.Items)); err != nil {
+				return err
+			}
+		}
+		allocated, items = true, order.Items
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to allocate stock to order %d: %w", orderID, err)
+	}
+	if allocated {
+		publishStockChanged(ctx, s.catalog, slices.DeleteFunc(items, func(item model.OrderItem) bool { return !item.Backordered }))
+	}
+	return allocated, nil
+}
+
+// stockedItems returns the items whose stock is deducted when the order is
+// placed, and restored when it is cancelled: all but the backordered ones,
+// and bundles, whose component lines hold their stock.
+func stockedItems(items []model.OrderItem) []model.OrderItem {
+	return slices.DeleteFunc(slices.Clone(items), func(item model.OrderItem) bool {
+		return item.Backordered || item.Delivery == model.SKUDeliveryBundle
+	})
+}
+
+// paidStatus is the status of a paid order with items: backordered until
+// every item has stock.
+func paidStatus(items []model.OrderItem) string {
+	if slices.ContainsFunc(items, func(item model.OrderItem) bool { return item.Backordered }) {
+		return model.OrderStatusBackordered
+	}
+	return model.OrderStatusPaid
+}
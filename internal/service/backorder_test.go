@@ -0,0 +1,130 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// backorderedOrder is an order of 3 units of pre-order SKU 101, in two
+// lines, and one of SKU 102 that was in stock.
+func backorderedOrder(id uint64, status string) *model.Order {
+	return &model.Order{
+		Base:   model.Base{ID: id},
+		Status: status,
+		Items: []model.OrderItem{
+			{SKUID: 101, Quantity: 1, Backordered: true},
+			{SKUID: 102, Quantity: 1},
+			{SKUID: 101, Quantity: 2, Backordered: true},
+		},
+	}
+}
+
+func TestFulfillmentService_AllocateBackorders(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        string
+		stock         int
+		wantAllocated int
+	}{
+		{name: "Paid", status: model.OrderStatusBackordered, stock: 3, wantAllocated: 1},
+		{name: "Authorized", status: model.OrderStatusAuthorized, stock: 5, wantAllocated: 1},
+		{name: "NotEnoughStock", status: model.OrderStatusBackordered, stock: 2},
+		{name: "NoLongerWaiting", status: model.OrderStatusCancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			events := mocks.NewMockEventPublisher(ctrl)
+			txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			})
+
+			orderRepo.EXPECT().ListBackordered(gomock.Any(), uint64(0), 10).Return([]model.Order{*backorderedOrder(5, tt.status)}, nil)
+			orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(5)).Return(backorderedOrder(5, tt.status), nil)
+			if tt.status != model.OrderStatusCancelled {
+				// Only the backordered lines take stock, together
+				productRepo.EXPECT().GetSKUForUpdate(gomock.Any(), uint64(101)).Return(&model.SKU{Stock: tt.stock}, nil)
+			}
+			if tt.wantAllocated > 0 {
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), -3).Return(nil)
+				orderRepo.EXPECT().AllocateItems(gomock.Any(), uint64(5)).Return(nil)
+				if tt.status == model.OrderStatusBackordered {
+					orderRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(5), model.OrderStatusBackordered, model.OrderStatusPaid).Return(nil)
+					events.EXPECT().Publish(gomock.Any(), service.EventOrderUpdated, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, data any) error {
+						assert.Equal(t, model.OrderStatusPaid, data.(service.OrderWebhookData).Status)
+						return nil
+					})
+				}
+			}
+
+			fulfillment := service.NewFulfillmentService(orderRepo, nil, productRepo, txManager, nil, events, nil, nil, nil)
+			allocated, err := fulfillment.AllocateBackorders(context.Background(), 10)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAllocated, allocated)
+		})
+	}
+}
+
+func TestFulfillmentService_ShipBackordered(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	orderRepo := mocks.NewMockOrderRepository(ctrl)
+	txManager := mocks.NewMockTransactionManager(ctrl)
+	txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+	orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(5)).Return(backorderedOrder(5, model.OrderStatusAuthorized), nil)
+
+	fulfillment := service.NewFulfillmentService(orderRepo, nil, nil, txManager, nil, nil, nil, nil, nil)
+	_, err := fulfillment.Ship(context.Background(), &service.ShipOrderReq{OrderID: 5})
+	assert.ErrorIs(t, err, service.ErrOrderBackordered)
+}
+
+func TestOrderService_CreateOrderPreOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	orderRepo := mocks.NewMockOrderRepository(ctrl)
+	productRepo := mocks.NewMockProductRepository(ctrl)
+	txManager := mocks.NewMockTransactionManager(ctrl)
+	webhooks := mocks.NewMockWebhookEmitter(ctrl)
+	events := mocks.NewMockEventPublisher(ctrl)
+	events.EXPECT().Publish(gomock.Any(), service.EventOrderCreated, gomock.Any()).Return(nil).AnyTimes()
+
+	productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101, 102}).Return([]model.SKU{
+		{Base: model.Base{ID: 101}, Price: decimal.NewFromInt(10), Currency: "USD", PreOrder: true}, // Out of stock
+		{Base: model.Base{ID: 102}, Price: decimal.NewFromInt(10), Currency: "USD", Stock: 100},
+	}, nil)
+	txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+	// Only the SKU in stock is deducted
+	productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(102), -1).Return(nil)
+	productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(102)).Return(&model.SKU{Stock: 99}, nil)
+	orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *model.Order, items []model.OrderItem) error {
+		require.Len(t, items, 2)
+		assert.True(t, items[0].Backordered)
+		assert.False(t, items[1].Backordered)
+		return nil
+	})
+	webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderCreated, gomock.Any()).Return(nil)
+
+	currencies := service.NewCurrencyService(nil, nil, nil, service.CurrencyOptions{Base: "USD"})
+	orderService := service.NewOrderService(orderRepo, productRepo, txManager, webhooks, events, currencies, service.NewTaxService(nil), nil, nil, nil, nil, nil, nil, nil, service.OrderOptions{})
+	resp, err := orderService.CreateOrder(context.Background(), &service.OrderCreateReq{
+		UserID:   1,
+		Currency: "USD",
+		Items:    []service.OrderItemReq{{SKUID: 101, Quantity: 2}, {SKUID: 102, Quantity: 1}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusPending, resp.Status)
+}
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/tenant"
+)
+
+// Broadcast defaults, which apply when the configuration leaves them unset.
+const (
+	DefaultBroadcastRate      = 20 // Deliveries queued per second
+	DefaultBroadcastBatchSize = 500
+
+	DefaultBroadcastPageSize = 20
+	MaxBroadcastPageSize     = 100
+)
+
+var (
+	// ErrBroadcastNotFound is returned when a broadcast does not exist.
+	ErrBroadcastNotFound = repository.ErrBroadcastNotFound
+	// ErrInvalidBroadcast means a broadcast's message, channels or segment are
+	// malformed.
+	ErrInvalidBroadcast = errors.New("invalid broadcast")
+)
+
+// broadcastNamespace derives the delivery IDs of broadcasts, so that a batch
+// queued again after an interrupted run is recognised by the notification
+// worker and not delivered twice.
+var broadcastNamespace = uuid.MustParse("6f1c2a7e-3d0b-4c59-9a8e-2b7d4f1e0c93")
+
+// BroadcastCreateReq is a broadcast to queue; see notification.BroadcastData
+// for the message.
+type BroadcastCreateReq struct {
+	Name     string
+	Subject  string
+	Message  string
+	URL      string
+	Channels []notification.Channel
+	Segment  model.BroadcastSegment
+}
+
+// BroadcastResp is a broadcast and how far it was fanned out.
+type BroadcastResp struct {
+	ID         uint64                 `json:"id,string"`
+	Name       string                 `json:"name"`
+	Subject    string                 `json:"subject"`
+	Message    string                 `json:"message"`
+	URL        string                 `json:"url"`
+	Channels   []string               `json:"channels"`
+	Segment    model.BroadcastSegment `json:"segment"`
+	Status     string                 `json:"status" example:"sending"`
+	Recipients int64                  `json:"recipients"` // Users queued so far
+	CreatedBy  uint64                 `json:"created_by,string"`
+	CreatedAt  time.Time              `json:"created_at"`
+	FinishedAt *time.Time             `json:"finished_at"`
+}
+
+// BroadcastListResp is one page of broadcasts, newest first.
+type BroadcastListResp struct {
+	Items []BroadcastResp `json:"items"`
+	Total int64           `json:"total"`
+}
+
+// BroadcastDispatchOptions controls one Dispatch run.
+type BroadcastDispatchOptions struct {
+	Rate      int // Deliveries queued per second, below the providers' rate limits
+	BatchSize int // Recipients loaded per query
+}
+
+// BroadcastDispatchReport summarises one Dispatch run.
+type BroadcastDispatchReport struct {
+	Queued   int // Deliveries queued
+	Finished int // Broadcasts fanned out to their last recipient
+}
+
+// BroadcastService sends marketing messages to a segment of a store's
+// customers. Creating a broadcast only queues it; BroadcastDispatcher, run
+// by cmd/worker, fans it out as one notification delivery per recipient and
+// channel through the notification queue, at a steady rate. Broadcasts are
+// of the promotion category, so only customers who turned promotions on for
+// a channel receive them there.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/broadcast_service_mock.go -package=mocks
+type BroadcastService interface {
+	// Create queues a broadcast of the context's store from the admin.
+	Create(ctx context.Context, adminID uint64, req *BroadcastCreateReq) (*BroadcastResp, error)
+	Get(ctx context.Context, id uint64) (*BroadcastResp, error)
+	List(ctx context.Context, offset, limit int) (*BroadcastListResp, error)
+	// Audience counts the customers of the context's store in a segment,
+	// before their preferences are applied.
+	Audience(ctx context.Context, segment *model.BroadcastSegment) (int64, error)
+}
+
+// BroadcastDispatcher fans queued broadcasts out on RabbitMQ. It is apart from
+// BroadcastService so the API server does not need RabbitMQ.
+type BroadcastDispatcher interface {
+	// Dispatch fans the queued broadcasts out, oldest first, until they are
+	// done or ctx ends. Progress is saved after each batch of recipients, so
+	// the next run resumes where this one stopped.
+	Dispatch(ctx context.Context, opts BroadcastDispatchOptions) (*BroadcastDispatchReport, error)
+}
+
+type broadcastService struct {
+	repo repository.BroadcastRepository
+}
+
+// NewBroadcastService creates a new BroadcastService instance.
+func NewBroadcastService(repo repository.BroadcastRepository) BroadcastService {
+	return &broadcastService{repo: repo}
+}
+
+func (s *broadcastService) Create(ctx context.Context, adminID uint64, req *BroadcastCreateReq) (*BroadcastResp, error) {
+	if len(req.Channels) == 0 {
+		return nil, fmt.Errorf("%w: no channels", ErrInvalidBroadcast)
+	}
+	channels := make([]string, 0, len(req.Channels))
+	for _, channel := range req.Channels {
+		if !slices.Contains(notification.Channels, channel) {
+			return nil, fmt.Errorf("%w: %w: %q", ErrInvalidBroadcast, notification.ErrUnknownChannel, channel)
+		}
+		if !slices.Contains(channels, string(channel)) {
+			channels = append(channels, string(channel))
+		}
+	}
+	if err := validateSegment(&req.Segment); err != nil {
+		return nil, err
+	}
+	data := &notification.BroadcastData{Subject: req.Subject, Message: req.Message, URL: req.URL}
+	if _, err := notification.EncodeData(notification.KindBroadcast, data); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBroadcast, err)
+	}
+
+	broadcast := &model.Broadcast{
+		Name:      req.Name,
+		Subject:   req.Subject,
+		Message:   req.Message,
+		URL:       req.URL,
+		Channels:  channels,
+		Segment:   req.Segment,
+		Status:    model.BroadcastStatusQueued,
+		CreatedBy: adminID,
+	}
+	if err := s.repo.Create(ctx, broadcast); err != nil {
+		return nil, err
+	}
+
+	resp := newBroadcastResp(broadcast)
+	RecordAudit(ctx, AuditEntry{Action: "broadcast.create", Resource: "broadcast", ResourceID: strconv.FormatUint(broadcast.ID, 10), After: resp})
+	return &resp, nil
+}
+
+// validateSegment rejects empty date ranges, order counts and order windows
+// without a count to apply to.
+func validateSegment(segment *model.BroadcastSegment) error {
+	switch {
+	case segment.SignedUpAfter != nil && segment.SignedUpBefore != nil && !segment.SignedUpAfter.Before(*segment.SignedUpBefore):
+		return fmt.Errorf("%w: signed_up_after must be before signed_up_before", ErrInvalidBroadcast)
+	case segment.OrderedAfter != nil && segment.OrderedBefore != nil && !segment.OrderedAfter.Before(*segment.OrderedBefore):
+		return fmt.Errorf("%w: ordered_after must be before ordered_before", ErrInvalidBroadcast)
+	case segment.MinOrders < 0 || segment.MaxOrders != nil && *segment.MaxOrders < max(segment.MinOrders, 0):
+		return fmt.Errorf("%w: max_orders must be at least min_orders, and both at least 0", ErrInvalidBroadcast)
+	case (segment.OrderedAfter != nil || segment.OrderedBefore != nil) && segment.MinOrders == 0 && segment.MaxOrders == nil:
+		return fmt.Errorf("%w: ordered_after and ordered_before need min_orders or max_orders", ErrInvalidBroadcast)
+	}
+	return nil
+}
+
+func (s *broadcastService) Get(ctx context.Context, id uint64) (*BroadcastResp, error) {
+	broadcast, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := newBroadcastResp(broadcast)
+	return &resp, nil
+}
+
+func (s *broadcastService) List(ctx context.Context, offset, limit int) (*BroadcastListResp, error) {
+	if limit <= 0 {
+		limit = DefaultBroadcastPageSize
+	}
+	limit = min(limit, MaxBroadcastPageSize)
+	offset = max(offset, 0)
+
+	broadcasts, total, err := s.repo.List(ctx, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]BroadcastResp, len(broadcasts))
+	for i := range broadcasts {
+		items[i] = newBroadcastResp(&broadcasts[i])
+	}
+	return &BroadcastListResp{Items: items, Total: total}, nil
+}
+
+func (s *broadcastService) Audience(ctx context.Context, segment *model.BroadcastSegment) (int64, error) {
+	if err := validateSegment(segment); err != nil {
+		return 0, err
+	}
+	return s.repo.CountRecipients(ctx, tenant.StoreID(ctx), segment)
+}
+
+type broadcastDispatcher struct {
+	repo      repository.BroadcastRepository
+	publisher notification.Publisher
+}
+
+// NewBroadcastDispatcher creates a new BroadcastDispatcher instance queueing
+// deliveries with publisher.
+func NewBroadcastDispatcher(repo repository.BroadcastRepository, publisher notification.Publisher) BroadcastDispatcher {
+	return &broadcastDispatcher{repo: repo, publisher: publisher}
+}
+
+func (s *broadcastDispatcher) Dispatch(ctx context.Context, opts BroadcastDispatchOptions) (*BroadcastDispatchReport, error) {
+	if opts.Rate <= 0 {
+		opts.Rate = DefaultBroadcastRate
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBroadcastBatchSize
+	}
+
+	broadcasts, err := s.repo.ListPending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &BroadcastDispatchReport{}
+	pace := &throttle{interval: time.Second / time.Duration(opts.Rate)}
+	for i := range broadcasts {
+		if err := s.fanOut(ctx, &broadcasts[i], opts.BatchSize, pace, report); err != nil {
+			if ctx.Err() != nil {
+				// Out of time; the next run resumes after the last saved batch.
+				return report, nil
+			}
+			return report, fmt.Errorf("failed to fan out broadcast '%d': %w", broadcasts[i].ID, err)
+		}
+	}
+	return report, nil
+}
+
+// fanOut queues the deliveries of a broadcast a batch of recipients at a
+// time, until none are left.
+func (s *broadcastDispatcher) fanOut(ctx context.Context, broadcast *model.Broadcast, batchSize int, pace *throttle, report *BroadcastDispatchReport) error {
+	data, err := notification.EncodeData(notification.KindBroadcast, &notification.BroadcastData{
+		BroadcastID: broadcast.ID,
+		Subject:     broadcast.Subject,
+		Message:     broadcast.Message,
+		URL:         broadcast.URL,
+	})
+	if err != nil {
+		return err
+	}
+	broadcast.Status = model.BroadcastStatusSending
+	for {
+		userIDs, err := s.repo.ListRecipients(ctx, broadcast.StoreID, &broadcast.Segment, broadcast.Cursor, batchSize)
+		if err != nil {
+			return err
+		}
+		if len(userIDs) == 0 {
+			now := time.Now()
+			broadcast.Status, broadcast.FinishedAt = model.BroadcastStatusSent, &now
+			if err := s.repo.SaveProgress(ctx, broadcast); err != nil {
+				return err
+			}
+			report.Finished++
+			return nil
+		}
+
+		for _, userID := range userIDs {
+			for _, channel := range broadcast.Channels {
+				if err := pace.wait(ctx); err != nil {
+					return err
+				}
+				if err := s.queue(ctx, broadcast.ID, userID, notification.Channel(channel), data); err != nil {
+					return err
+				}
+				report.Queued++
+			}
+		}
+		broadcast.Cursor = userIDs[len(userIDs)-1]
+		broadcast.Recipients += int64(len(userIDs))
+		if err := s.repo.SaveProgress(ctx, broadcast); err != nil {
+			return err
+		}
+	}
+}
+
+// queue publishes the delivery of a broadcast to one user on one channel.
+func (s *broadcastDispatcher) queue(ctx context.Context, broadcastID, userID uint64, channel notification.Channel, data json.RawMessage) error {
+	id := uuid.NewSHA1(broadcastNamespace, fmt.Appendf(nil, "%d/%d/%s", broadcastID, userID, channel))
+	body, err := json.Marshal(notification.Delivery{
+		ID:      id.String(),
+		UserID:  userID,
+		Kind:    notification.KindBroadcast,
+		Channel: channel,
+		Data:    data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode delivery: %w", err)
+	}
+	if err := s.publisher.Publish(ctx, "", notification.Queue, body); err != nil {
+		return fmt.Errorf("failed to queue broadcast %s: %w", channel, err)
+	}
+	return nil
+}
+
+// throttle spaces events out to one per interval.
+type throttle struct {
+	interval time.Duration
+	next     time.Time
+}
+
+// wait blocks until the next event may happen, or ctx ends.
+func (t *throttle) wait(ctx context.Context) error {
+	now := time.Now()
+	if t.next.After(now) {
+		timer := time.NewTimer(t.next.Sub(now))
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+		now = t.next
+	}
+	t.next = now.Add(t.interval)
+	return nil
+}
+
+func newBroadcastResp(broadcast *model.Broadcast) BroadcastResp {
+	return BroadcastResp{
+		ID:         broadcast.ID,
+		Name:       broadcast.Name,
+		Subject:    broadcast.Subject,
+		Message:    broadcast.Message,
+		URL:        broadcast.URL,
+		Channels:   broadcast.Channels,
+		Segment:    broadcast.Segment,
+		Status:     broadcast.Status,
+		Recipients: broadcast.Recipients,
+		CreatedBy:  broadcast.CreatedBy,
+		CreatedAt:  broadcast.CreatedAt,
+		FinishedAt: broadcast.FinishedAt,
+	}
+}
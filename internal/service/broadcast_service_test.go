@@ -0,0 +1,188 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestBroadcastService_Create(t *testing.T) {
+	signup := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	zero := 0
+
+	tests := []struct {
+		name    string
+		req     *service.BroadcastCreateReq
+		wantErr error
+	}{
+		{
+			name: "Success",
+			req: &service.BroadcastCreateReq{
+				Name:     "Spring sale",
+				Subject:  "20% off",
+				Message:  "Everything is 20% off this weekend.",
+				Channels: []notification.Channel{notification.ChannelEmail, notification.ChannelInbox, notification.ChannelEmail},
+				Segment:  model.BroadcastSegment{SignedUpAfter: &signup, MaxOrders: &zero},
+			},
+		},
+		{
+			name:    "NoChannels",
+			req:     &service.BroadcastCreateReq{Subject: "20% off", Message: "Sale"},
+			wantErr: service.ErrInvalidBroadcast,
+		},
+		{
+			name:    "UnknownChannel",
+			req:     &service.BroadcastCreateReq{Subject: "20% off", Message: "Sale", Channels: []notification.Channel{"fax"}},
+			wantErr: notification.ErrUnknownChannel,
+		},
+		{
+			name:    "MissingMessage",
+			req:     &service.BroadcastCreateReq{Subject: "20% off", Channels: []notification.Channel{notification.ChannelPush}},
+			wantErr: service.ErrInvalidBroadcast,
+		},
+		{
+			name: "OrderWindowWithoutCount",
+			req: &service.BroadcastCreateReq{
+				Subject:  "20% off",
+				Message:  "Sale",
+				Channels: []notification.Channel{notification.ChannelPush},
+				Segment:  model.BroadcastSegment{OrderedAfter: &signup},
+			},
+			wantErr: service.ErrInvalidBroadcast,
+		},
+		{
+			name: "MaxBelowMin",
+			req: &service.BroadcastCreateReq{
+				Subject:  "20% off",
+				Message:  "Sale",
+				Channels: []notification.Channel{notification.ChannelPush},
+				Segment:  model.BroadcastSegment{MinOrders: 2, MaxOrders: &zero},
+			},
+			wantErr: service.ErrInvalidBroadcast,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockBroadcastRepository(ctrl)
+			if tt.wantErr == nil {
+				repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, b *model.Broadcast) error {
+					assert.Equal(t, []string{"email", "inbox"}, b.Channels)
+					assert.Equal(t, model.BroadcastStatusQueued, b.Status)
+					assert.Equal(t, uint64(9), b.CreatedBy)
+					b.ID = 42
+					return nil
+				})
+			}
+			ctx, trail := service.WithAuditTrail(context.Background())
+
+			resp, err := service.NewBroadcastService(repo).Create(ctx, 9, tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, trail.Entries())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, uint64(42), resp.ID)
+			require.Len(t, trail.Entries(), 1)
+			assert.Equal(t, "broadcast.create", trail.Entries()[0].Action)
+			assert.Equal(t, "42", trail.Entries()[0].ResourceID)
+		})
+	}
+}
+
+func TestBroadcastDispatcher_Dispatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockBroadcastRepository(ctrl)
+	publisher := mocks.NewMockPublisher(ctrl)
+	svc := service.NewBroadcastDispatcher(repo, publisher)
+
+	broadcast := model.Broadcast{
+		Base:     model.Base{ID: 5},
+		StoreID:  2,
+		Subject:  "20% off",
+		Message:  "Sale",
+		Channels: []string{"email", "push"},
+		Status:   model.BroadcastStatusQueued,
+	}
+	repo.EXPECT().ListPending(gomock.Any()).Return([]model.Broadcast{broadcast}, nil)
+	gomock.InOrder(
+		repo.EXPECT().ListRecipients(gomock.Any(), uint64(2), gomock.Any(), uint64(0), 2).Return([]uint64{10, 11}, nil),
+		repo.EXPECT().SaveProgress(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, b *model.Broadcast) error {
+			assert.Equal(t, model.BroadcastStatusSending, b.Status)
+			assert.Equal(t, uint64(11), b.Cursor)
+			assert.Equal(t, int64(2), b.Recipients)
+			return nil
+		}),
+		repo.EXPECT().ListRecipients(gomock.Any(), uint64(2), gomock.Any(), uint64(11), 2).Return([]uint64{12}, nil),
+		repo.EXPECT().SaveProgress(gomock.Any(), gomock.Any()).Return(nil),
+		repo.EXPECT().ListRecipients(gomock.Any(), uint64(2), gomock.Any(), uint64(12), 2).Return(nil, nil),
+		repo.EXPECT().SaveProgress(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, b *model.Broadcast) error {
+			assert.Equal(t, model.BroadcastStatusSent, b.Status)
+			assert.Equal(t, int64(3), b.Recipients)
+			assert.NotNil(t, b.FinishedAt)
+			return nil
+		}),
+	)
+
+	var deliveries []notification.Delivery
+	publisher.EXPECT().Publish(gomock.Any(), "", notification.Queue, gomock.Any()).DoAndReturn(func(_ context.Context, _, _ string, body []byte) error {
+		var d notification.Delivery
+		require.NoError(t, json.Unmarshal(body, &d))
+		deliveries = append(deliveries, d)
+		return nil
+	}).Times(6)
+
+	start := time.Now()
+	report, err := svc.Dispatch(context.Background(), service.BroadcastDispatchOptions{Rate: 100, BatchSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, &service.BroadcastDispatchReport{Queued: 6, Finished: 1}, report)
+	// Six deliveries at 100 per second are spaced over at least 50ms.
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	require.Len(t, deliveries, 6)
+	assert.Equal(t, uint64(10), deliveries[0].UserID)
+	assert.Equal(t, notification.KindBroadcast, deliveries[0].Kind)
+	assert.Equal(t, notification.ChannelPush, deliveries[1].Channel)
+	assert.JSONEq(t, `{"broadcast_id":"5","subject":"20% off","message":"Sale","url":""}`, string(deliveries[0].Data))
+	ids := map[string]bool{}
+	for _, d := range deliveries {
+		ids[d.ID] = true
+	}
+	assert.Len(t, ids, 6)
+}
+
+func TestBroadcastDispatcher_DispatchStopsWhenContextEnds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockBroadcastRepository(ctrl)
+	publisher := mocks.NewMockPublisher(ctrl)
+	svc := service.NewBroadcastDispatcher(repo, publisher)
+
+	repo.EXPECT().ListPending(gomock.Any()).Return([]model.Broadcast{{Base: model.Base{ID: 5}, Subject: "Sale", Message: "Sale", Channels: []string{"sms"}}}, nil)
+	repo.EXPECT().ListRecipients(gomock.Any(), gomock.Any(), gomock.Any(), uint64(0), gomock.Any()).Return([]uint64{10, 11, 12}, nil)
+	publisher.EXPECT().Publish(gomock.Any(), "", notification.Queue, gomock.Any()).Return(nil)
+
+	// At one delivery per second, the run ends before the second one and
+	// saves nothing: the batch is queued again by the next run.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	report, err := svc.Dispatch(ctx, service.BroadcastDispatchOptions{Rate: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Queued)
+
+	// Failures other than running out of time are returned.
+	repo.EXPECT().ListPending(gomock.Any()).Return(nil, errors.New("db down"))
+	_, err = svc.Dispatch(context.Background(), service.BroadcastDispatchOptions{})
+	assert.EqualError(t, err, "db down")
+}
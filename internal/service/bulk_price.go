@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultBulkPriceChunkSize is how many SKUs BulkUpdatePrices reprices per
+// transaction.
+const DefaultBulkPriceChunkSize = 200
+
+var (
+	// ErrInvalidPriceChange means a bulk price update is malformed or would
+	// leave a SKU with a price it cannot have.
+	ErrInvalidPriceChange = errors.New("invalid price change")
+	// ErrCategoryNotFound is returned when a category does not exist.
+	ErrCategoryNotFound = errors.New("category not found")
+
+	// maxSKUPrice is the smallest price that no longer fits numeric(10,2).
+	maxSKUPrice = decimal.New(1, 8)
+	// maxPercentIncrease bounds a percentage adjustment, so that a typo
+	// does not multiply a whole category's prices a hundredfold.
+	maxPercentIncrease = decimal.NewFromInt(1000)
+	hundred            = decimal.NewFromInt(100)
+)
+
+// SKUPrice is the new price of one SKU.
+type SKUPrice struct {
+	SKUID uint64
+	Price decimal.Decimal
+}
+
+// BulkPriceReq reprices SKUs, either each to its own price or all those of a
+// category by a percentage. Exactly one of Changes and CategoryID is set.
+type BulkPriceReq struct {
+	Changes []SKUPrice
+	// CategoryID adjusts the prices of the SKUs in the category and its
+	// subcategories by Percent, e.g. -10 for a tenth off.
+	CategoryID uint64
+	Percent    decimal.Decimal
+	// DryRun computes the new prices without saving them.
+	DryRun bool
+}
+
+// SKUPriceChange is the repricing of one SKU.
+type SKUPriceChange struct {
+	SKUID    uint64          `json:"sku_id,string"`
+	SPUID    uint64          `json:"spu_id,string"`
+	Currency string          `json:"currency" example:"USD"`
+	OldPrice decimal.Decimal `json:"old_price" swaggertype:"string" example:"19.99"`
+	NewPrice decimal.Decimal `json:"new_price" swaggertype:"string" example:"17.99"`
+}
+
+// BulkPriceResp reports a bulk price update.
+type BulkPriceResp struct {
+	DryRun  bool             `json:"dry_run"`
+	Matched int              `json:"matched"` // SKUs the request selected
+	Updated int              `json:"updated"` // SKUs whose price changed; 0 on a dry run
+	Changes []SKUPriceChange `json:"changes"` // The SKUs whose price changes, or would on a dry run
+}
+
+// BulkUpdatePrices validates every change before saving any, then applies
+// them DefaultBulkPriceChunkSize SKUs per transaction, so that a large
+// category does not hold its rows locked at once. A percentage is applied
+// to the price each SKU has when its chunk is locked. If a chunk fails, the
+// chunks before it stay applied: the response lists them along with the
+// error.
+func (s *productService) BulkUpdatePrices(ctx context.Context, req *BulkPriceReq) (*BulkPriceResp, error) {
+	skuIDs, prices, err := s.selectRepricedSKUs(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	reprice := func(sku *model.SKU) (decimal.Decimal, error) {
+		price := prices[sku.ID]
+		if req.CategoryID != 0 {
+			price = sku.Price.Mul(hundred.Add(req.Percent)).Div(hundred).Round(money.Scale)
+		}
+		if !price.IsPositive() || price.GreaterThanOrEqual(maxSKUPrice) {
+			return decimal.Zero, fmt.Errorf("%w: SKU %d would cost %s", ErrInvalidPriceChange, sku.ID, price)
+		}
+		return price, nil
+	}
+
+	// Validate everything up front, which is all a dry run does
+	planned := &BulkPriceResp{DryRun: req.DryRun, Matched: len(skuIDs), Changes: []SKUPriceChange{}}
+	for chunk := range slices.Chunk(skuIDs, DefaultBulkPriceChunkSize) {
+		skus, err := s.repo.GetSKUsByIDs(ctx, chunk)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get SKUs: %w", err)
+		}
+		if len(skus) != len(chunk) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPriceChange, missingSKUs(chunk, skus))
+		}
+		for i := range skus {
+			price, err := reprice(&skus[i])
+			if err != nil {
+				return nil, err
+			}
+			if !price.Equal(skus[i].Price) {
+				planned.Changes = append(planned.Changes, SKUPriceChange{
+					SKUID: skus[i].ID, SPUID: skus[i].SPUID, Currency: skus[i].Currency,
+					OldPrice: skus[i].Price, NewPrice: price,
+				})
+			}
+		}
+	}
+	if req.DryRun {
+		return planned, nil
+	}
+
+	resp := &BulkPriceResp{Matched: len(skuIDs), Changes: []SKUPriceChange{}}
+	for chunk := range slices.Chunk(skuIDs, DefaultBulkPriceChunkSize) {
+		var applied []SKUPriceChange
+		err := s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+			applied = applied[:0]
+			skus, err := s.repo.GetSKUsForUpdate(ctx, chunk)
+			if err != nil {
+				return fmt.Errorf("failed to lock SKUs: %w", err)
+			}
+			for i := range skus {
+				price, err := reprice(&skus[i])
+				if err != nil {
+					return err
+				}
+				if price.Equal(skus[i].Price) {
+					continue
+				}
+				if err := s.repo.UpdateSKUPrice(ctx, skus[i].ID, price); err != nil {
+					return fmt.Errorf("failed to update price of SKU %d: %w", skus[i].ID, err)
+				}
+				applied = append(applied, SKUPriceChange{
+					SKUID: skus[i].ID, SPUID: skus[i].SPUID, Currency: skus[i].Currency,
+					OldPrice: skus[i].Price, NewPrice: price,
+				})
+			}
+			return s.publishProductsUpdated(ctx, applied)
+		})
+		if err != nil {
+			if resp.Updated == 0 {
+				return nil, err
+			}
+			return resp, fmt.Errorf("repriced %d of %d SKUs before failing: %w", resp.Updated, len(skuIDs), err)
+		}
+
+		spuIDs := make([]uint64, 0, len(applied))
+		for _, change := range applied {
+			spuIDs = append(spuIDs, change.SPUID)
+			RecordAudit(ctx, AuditEntry{
+				Action:     "sku.price",
+				Resource:   "sku",
+				ResourceID: strconv.FormatUint(change.SKUID, 10),
+				Before:     map[string]any{"price": change.OldPrice, "currency": change.Currency},
+				After:      map[string]any{"price": change.NewPrice, "currency": change.Currency},
+			})
+		}
+		// The products show their old prices until they expire if this fails
+		if len(applied) > 0 {
+			if err := s.catalog.Invalidate(ctx, spuIDs...); err != nil {
+				slog.WarnContext(ctx, "Failed to invalidate cached products", "spu_ids", spuIDs, logger.Err(err))
+			}
+		}
+		resp.Updated += len(applied)
+		resp.Changes = append(resp.Changes, applied...)
+	}
+	return resp, nil
+}
+
+// publishProductsUpdated publishes product.updated once per product of the
+// repriced SKUs.
+func (s *productService) publishProductsUpdated(ctx context.Context, changes []SKUPriceChange) error {
+	var products []ProductEventData
+	for _, change := range changes {
+		i := slices.IndexFunc(products, func(p ProductEventData) bool { return p.SPUID == change.SPUID })
+		if i < 0 {
+			i = len(products)
+			products = append(products, ProductEventData{SPUID: change.SPUID})
+		}
+		products[i].SKUIDs = append(products[i].SKUIDs, change.SKUID)
+	}
+	for _, data := range products {
+		if err := s.events.Publish(ctx, EventProductUpdated, data); err != nil {
+			return fmt.Errorf("failed to publish product event: %w", err)
+		}
+	}
+	return nil
+}
+
+// selectRepricedSKUs validates req and returns the IDs of the SKUs it
+// reprices in ascending order, and for explicit changes their new prices.
+func (s *productService) selectRepricedSKUs(ctx context.Context, req *BulkPriceReq) ([]uint64, map[uint64]decimal.Decimal, error) {
+	switch {
+	case len(req.Changes) > 0 && req.CategoryID != 0:
+		return nil, nil, fmt.Errorf("%w: give either changes or a category, not both", ErrInvalidPriceChange)
+	case len(req.Changes) > 0:
+		prices := make(map[uint64]decimal.Decimal, len(req.Changes))
+		for _, change := range req.Changes {
+			if _, ok := prices[change.SKUID]; ok {
+				return nil, nil, fmt.Errorf("%w: SKU %d is listed twice", ErrInvalidPriceChange, change.SKUID)
+			}
+			if !change.Price.Equal(change.Price.Truncate(money.Scale)) {
+				return nil, nil, fmt.Errorf("%w: price %s of SKU %d has sub-cent precision", ErrInvalidPriceChange, change.Price, change.SKUID)
+			}
+			prices[change.SKUID] = change.Price
+		}
+		skuIDs := make([]uint64, 0, len(prices))
+		for id := range prices {
+			skuIDs = append(skuIDs, id)
+		}
+		slices.Sort(skuIDs)
+		return skuIDs, prices, nil
+	case req.CategoryID != 0:
+		if req.Percent.IsZero() || req.Percent.LessThanOrEqual(hundred.Neg()) || req.Percent.GreaterThan(maxPercentIncrease) {
+			return nil, nil, fmt.Errorf("%w: percent must be non-zero, above -100 and at most %s", ErrInvalidPriceChange, maxPercentIncrease)
+		}
+		categoryIDs, err := s.categoryTree(ctx, req.CategoryID)
+		if err != nil {
+			return nil, nil, err
+		}
+		skuIDs, err := s.repo.ListSKUIDsByCategories(ctx, categoryIDs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list SKUs of category %d: %w", req.CategoryID, err)
+		}
+		return skuIDs, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: give changes or a category", ErrInvalidPriceChange)
+	}
+}
+
+// categoryTree returns the ID of the category and of all its descendants.
+func (s *productService) categoryTree(ctx context.Context, categoryID uint64) ([]uint64, error) {
+	categories, err := s.categoryRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	children := make(map[uint64][]uint64, len(categories))
+	found := false
+	for _, category := range categories {
+		children[category.ParentID] = append(children[category.ParentID], category.ID)
+		found = found || category.ID == categoryID
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %d", ErrCategoryNotFound, categoryID)
+	}
+
+	// The seen set guards against cycles
+	tree := []uint64{categoryID}
+	seen := map[uint64]bool{categoryID: true}
+	for i := 0; i < len(tree); i++ {
+		for _, child := range children[tree[i]] {
+			if !seen[child] {
+				seen[child] = true
+				tree = append(tree, child)
+			}
+		}
+	}
+	return tree, nil
+}
+
+// missingSKUs describes which of ids are not among skus.
+func missingSKUs(ids []uint64, skus []model.SKU) string {
+	found := make(map[uint64]bool, len(skus))
+	for _, sku := range skus {
+		found[sku.ID] = true
+	}
+	var missing []uint64
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return fmt.Sprintf("SKUs %v do not exist", missing)
+}
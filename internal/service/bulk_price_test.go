@@ -0,0 +1,170 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestProductService_BulkUpdatePrices(t *testing.T) {
+	skus := func() []model.SKU {
+		return []model.SKU{
+			{Base: model.Base{ID: 101}, SPUID: 1, Price: decimal.RequireFromString("10.00"), Currency: "USD"},
+			{Base: model.Base{ID: 102}, SPUID: 2, Price: decimal.RequireFromString("20.00"), Currency: "USD"},
+		}
+	}
+	categories := []model.Category{
+		{Base: model.Base{ID: 7}},
+		{Base: model.Base{ID: 8}, ParentID: 7},
+		{Base: model.Base{ID: 9}},
+	}
+
+	tests := []struct {
+		name        string
+		req         service.BulkPriceReq
+		categoryIDs []uint64 // The category tree the SKUs are listed from
+		found       []model.SKU
+		wantErrIs   error
+		wantChanges map[uint64]string
+		wantUpdated int
+	}{
+		{
+			name: "Changes",
+			req: service.BulkPriceReq{Changes: []service.SKUPrice{
+				{SKUID: 102, Price: decimal.RequireFromString("20")},
+				{SKUID: 101, Price: decimal.RequireFromString("12.50")},
+			}},
+			found:       skus(),
+			wantChanges: map[uint64]string{101: "12.5"},
+			wantUpdated: 1,
+		},
+		{
+			name:        "CategoryPercent",
+			req:         service.BulkPriceReq{CategoryID: 7, Percent: decimal.RequireFromString("-12.5")},
+			categoryIDs: []uint64{7, 8},
+			found:       skus(),
+			wantChanges: map[uint64]string{101: "8.75", 102: "17.5"},
+			wantUpdated: 2,
+		},
+		{
+			name:        "DryRun",
+			req:         service.BulkPriceReq{CategoryID: 7, Percent: decimal.NewFromInt(10), DryRun: true},
+			categoryIDs: []uint64{7, 8},
+			found:       skus(),
+			wantChanges: map[uint64]string{101: "11", 102: "22"},
+		},
+		{
+			name:      "NothingSelected",
+			req:       service.BulkPriceReq{},
+			wantErrIs: service.ErrInvalidPriceChange,
+		},
+		{
+			name: "BothModes",
+			req: service.BulkPriceReq{
+				Changes:    []service.SKUPrice{{SKUID: 101, Price: decimal.NewFromInt(1)}},
+				CategoryID: 7, Percent: decimal.NewFromInt(10),
+			},
+			wantErrIs: service.ErrInvalidPriceChange,
+		},
+		{
+			name: "DuplicateSKU",
+			req: service.BulkPriceReq{Changes: []service.SKUPrice{
+				{SKUID: 101, Price: decimal.NewFromInt(1)},
+				{SKUID: 101, Price: decimal.NewFromInt(2)},
+			}},
+			wantErrIs: service.ErrInvalidPriceChange,
+		},
+		{
+			name:      "SubCentPrice",
+			req:       service.BulkPriceReq{Changes: []service.SKUPrice{{SKUID: 101, Price: decimal.RequireFromString("1.001")}}},
+			wantErrIs: service.ErrInvalidPriceChange,
+		},
+		{
+			name:      "UnknownSKU",
+			req:       service.BulkPriceReq{Changes: []service.SKUPrice{{SKUID: 101, Price: decimal.NewFromInt(1)}, {SKUID: 103, Price: decimal.NewFromInt(1)}}},
+			found:     skus()[:1],
+			wantErrIs: service.ErrInvalidPriceChange,
+		},
+		{
+			name:      "PercentWipesOutPrice",
+			req:       service.BulkPriceReq{CategoryID: 7, Percent: decimal.NewFromInt(-100)},
+			wantErrIs: service.ErrInvalidPriceChange,
+		},
+		{
+			name:      "UnknownCategory",
+			req:       service.BulkPriceReq{CategoryID: 10, Percent: decimal.NewFromInt(10)},
+			wantErrIs: service.ErrCategoryNotFound,
+		},
+		{
+			name:        "RoundsToZero",
+			req:         service.BulkPriceReq{CategoryID: 9, Percent: decimal.RequireFromString("-99.99")},
+			categoryIDs: []uint64{9},
+			found:       skus(),
+			wantErrIs:   service.ErrInvalidPriceChange,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			categoryRepo := mocks.NewMockCategoryRepository(ctrl)
+			mockCache := mocks.NewMockCache(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			events := mocks.NewMockEventPublisher(ctrl)
+
+			if tt.req.CategoryID != 0 && tt.req.Changes == nil && !tt.req.Percent.LessThanOrEqual(decimal.NewFromInt(-100)) {
+				categoryRepo.EXPECT().List(gomock.Any()).Return(categories, nil)
+			}
+			if tt.categoryIDs != nil {
+				productRepo.EXPECT().ListSKUIDsByCategories(gomock.Any(), tt.categoryIDs).Return([]uint64{101, 102}, nil)
+			}
+			if tt.found != nil {
+				productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), gomock.Any()).Return(tt.found, nil)
+			}
+			if tt.wantUpdated > 0 {
+				txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+				productRepo.EXPECT().GetSKUsForUpdate(gomock.Any(), []uint64{101, 102}).Return(skus(), nil)
+				for id, price := range tt.wantChanges {
+					productRepo.EXPECT().UpdateSKUPrice(gomock.Any(), id, gomock.Cond(func(d decimal.Decimal) bool {
+						return d.Equal(decimal.RequireFromString(price))
+					})).Return(nil)
+					// Each SKU is of its own product
+					spuID := id - 100
+					events.EXPECT().Publish(gomock.Any(), service.EventProductUpdated, service.ProductEventData{SPUID: spuID, SKUIDs: []uint64{id}}).Return(nil)
+				}
+				mockCache.EXPECT().Del(gomock.Any(), gomock.Any()).Return(nil)
+				mockCache.EXPECT().Set(gomock.Any(), "mall:product:list:tag", gomock.Any(), time.Duration(0)).Return(nil)
+			}
+
+			svc := service.NewProductService(productRepo, categoryRepo, service.NewCatalogCache(mockCache, service.CatalogCacheOptions{}), nil, txManager, events)
+			ctx, trail := service.WithAuditTrail(context.Background())
+			req := tt.req
+			resp, err := svc.BulkUpdatePrices(ctx, &req)
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 2, resp.Matched)
+			assert.Equal(t, tt.req.DryRun, resp.DryRun)
+			assert.Equal(t, tt.wantUpdated, resp.Updated)
+			changes := map[uint64]string{}
+			for _, change := range resp.Changes {
+				changes[change.SKUID] = change.NewPrice.String()
+			}
+			assert.Equal(t, tt.wantChanges, changes)
+			assert.Len(t, trail.Entries(), tt.wantUpdated, "one audit entry per repriced SKU")
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/snowflake"
+	"github.com/shopspring/decimal"
+)
+
+// ErrInvalidBundle is returned for a bundle SKU that cannot be made of the
+// components it is given.
+var ErrInvalidBundle = errors.New("invalid bundle")
+
+// SKUComponentReq is Quantity units of a SKU in every unit of a bundle.
+type SKUComponentReq struct {
+	SKUID    uint64 `json:"sku_id,string"`
+	Quantity int    `json:"quantity"`
+}
+
+// bundleComponents checks the components of a bundle SKU about to be
+// created. They must be SKUs of the store other than bundles, each given
+// once. A bundle is delivered as its components and has no stock of its own,
+// so it can be neither digital nor pre-ordered.
+func bundleComponents(ctx context.Context, repo repository.ProductRepository, req *SKUCreateReq) ([]model.SKUComponent, error) {
+	if req.Delivery != "" || req.PreOrder {
+		return nil, fmt.Errorf("%w: a bundle is delivered as its components", ErrInvalidBundle)
+	}
+	ids := make([]uint64, len(req.Components))
+	components := make([]model.SKUComponent, len(req.Components))
+	for i, c := range req.Components {
+		if c.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: invalid quantity %d of SKU %d", ErrInvalidBundle, c.Quantity, c.SKUID)
+		}
+		if slices.Contains(ids[:i], c.SKUID) {
+			return nil, fmt.Errorf("%w: SKU %d given twice", ErrInvalidBundle, c.SKUID)
+		}
+		ids[i] = c.SKUID
+		components[i] = model.SKUComponent{ComponentSKUID: c.SKUID, Quantity: c.Quantity}
+	}
+	skus, err := repo.GetSKUsByIDs(ctx, ids)
+	if errors.Is(err, repository.ErrSKUNotFound) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBundle, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bundle components: %w", err)
+	}
+	for _, sku := range skus {
+		if sku.Delivery == model.SKUDeliveryBundle {
+			return nil, fmt.Errorf("%w: SKU %d is a bundle itself", ErrInvalidBundle, sku.ID)
+		}
+	}
+	return components, nil
+}
+
+// expandBundles adds the component lines of the bundles among items, each
+// after its bundle's line. They take the components' stock and are priced at
+// zero: the bundle's line carries its price, discount and tax. A component
+// short of stock fails the order naming the bundle, which is what the
+// customer ordered; pre-order components are backordered.
+func (s *orderService) expandBundles(ctx context.Context, items []model.OrderItem) ([]model.OrderItem, error) {
+	var bundleIDs []uint64
+	for _, item := range items {
+		if item.Delivery == model.SKUDeliveryBundle {
+			bundleIDs = append(bundleIDs, item.SKUID)
+		}
+	}
+	if len(bundleIDs) == 0 {
+		return items, nil
+	}
+
+	components, err := s.productRepo.ListSKUComponents(ctx, bundleIDs)
+	if err != nil {
+		return nil, err
+	}
+	byBundle := make(map[uint64][]model.SKUComponent)
+	componentIDs := make([]uint64, len(components))
+	for i, c := range components {
+		byBundle[c.BundleSKUID] = append(byBundle[c.BundleSKUID], c)
+		componentIDs[i] = c.ComponentSKUID
+	}
+	skus, err := s.productRepo.GetSKUsByIDs(ctx, componentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bundle components: %w", err)
+	}
+	bySKU := make(map[uint64]*model.SKU, len(skus))
+	for i := range skus {
+		bySKU[skus[i].ID] = &skus[i]
+	}
+
+	expanded := make([]model.OrderItem, 0, len(items)+len(components))
+	needed := make(map[uint64]int) // Units of each component across the bundles
+	for _, item := range items {
+		if item.Delivery != model.SKUDeliveryBundle {
+			expanded = append(expanded, item)
+			continue
+		}
+		parts := byBundle[item.SKUID]
+		if len(parts) == 0 {
+			return nil, fmt.Errorf("%w: SKU %d has no components", ErrInvalidBundle, item.SKUID)
+		}
+		// The component lines point at the bundle's line before it is created
+		if item.ID == 0 {
+			item.ID = snowflake.GenID()
+		}
+		bundleItemID := item.ID
+		expanded = append(expanded, item)
+		for _, c := range parts {
+			sku := bySKU[c.ComponentSKUID]
+			quantity := item.Quantity * c.Quantity
+			needed[sku.ID] += quantity
+			if !sku.PreOrder && sku.Stock < needed[sku.ID] {
+				return nil, ErrInsufficientStock.WithSKU(item.SKUID)
+			}
+			expanded = append(expanded, model.OrderItem{
+				SKUID:        sku.ID,
+				Quantity:     quantity,
+				Price:        decimal.Zero,
+				Backordered:  sku.PreOrder,
+				Delivery:     sku.Delivery,
+				BundleItemID: &bundleItemID,
+			})
+		}
+	}
+	return expanded, nil
+}
+
+// customerItems returns the items the customer ordered, leaving out the
+// component lines of bundles.
+func customerItems(items []model.OrderItem) []model.OrderItem {
+	return slices.DeleteFunc(slices.Clone(items), func(item model.OrderItem) bool { return item.BundleItemID != nil })
+}
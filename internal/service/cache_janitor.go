@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache sweeps, the kinds of stale keys CacheJanitor purges.
+const (
+	// CacheSweepIdempotency purges the message idempotency keys of the order
+	// and notification workers that never expire.
+	CacheSweepIdempotency = "idempotency"
+	// CacheSweepCheckoutSessions purges checkout sessions that never expire.
+	CacheSweepCheckoutSessions = "checkout_sessions"
+	// CacheSweepPurchaseCounters purges the purchase counters of SKUs and
+	// promotions whose limit was lifted, or that were deleted.
+	CacheSweepPurchaseCounters = "purchase_counters"
+	// CacheSweepRateLimits purges review vote and report windows that never
+	// expire or outlive the configured window, e.g. after it was shortened.
+	CacheSweepRateLimits = "rate_limits"
+)
+
+// cacheSweepPageSize is about how many keys one SCAN returns.
+const cacheSweepPageSize = 200
+
+// ErrUnknownCacheSweep is returned for a sweep CacheJanitor does not know.
+var ErrUnknownCacheSweep = errors.New("unknown cache sweep")
+
+// purgeOutliving deletes the keys in KEYS that never expire or, with ARGV[1]
+// above 0, expire more than ARGV[1] milliseconds from now; with ARGV[2] set to
+// 1 it only counts them. Checking as it deletes keeps a key recreated since
+// it was scanned. It returns how many keys were stale.
+var purgeOutliving = redis.NewScript(`
+local stale = 0
+local max = tonumber(ARGV[1])
+for _, key in ipairs(KEYS) do
+	local ttl = redis.call("PTTL", key)
+	if ttl == -1 or (max > 0 and ttl > max) then
+		if ARGV[2] ~= "1" then
+			redis.call("DEL", key)
+		end
+		stale = stale + 1
+	end
+end
+return stale
+`)
+
+// CacheSweepReport summarises one Sweep.
+type CacheSweepReport struct {
+	Scanned int
+	Stale   int // Purged, or only counted with a dry run
+}
+
+// CacheJanitor purges keys Redis would otherwise keep forever. Most keys of
+// the shop expire by themselves; these are the ones that lost or never had
+// their TTL, and counters outliving what they count.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/cache_janitor_mock.go -package=mocks
+type CacheJanitor interface {
+	// Sweep scans the keys of sweep, one of the CacheSweep constants, and
+	// purges the stale ones. With dryRun they are only counted.
+	Sweep(ctx context.Context, sweep string, dryRun bool) (*CacheSweepReport, error)
+}
+
+// CacheJanitorOptions configures a CacheJanitor. Zero values fall back to
+// the defaults of the services owning the keys.
+type CacheJanitorOptions struct {
+	RateLimitWindow time.Duration // reviews.limit_window
+}
+
+type cacheJanitor struct {
+	cache      cache.Cache
+	products   repository.ProductRepository
+	promotions repository.PromotionRepository
+	opts       CacheJanitorOptions
+}
+
+// NewCacheJanitor creates a new CacheJanitor instance.
+func NewCacheJanitor(c cache.Cache, products repository.ProductRepository, promotions repository.PromotionRepository, opts CacheJanitorOptions) CacheJanitor {
+	if opts.RateLimitWindow <= 0 {
+		opts.RateLimitWindow = DefaultReviewLimitWindow
+	}
+	return &cacheJanitor{cache: c, products: products, promotions: promotions, opts: opts}
+}
+
+func (j *cacheJanitor) Sweep(ctx context.Context, sweep string, dryRun bool) (*CacheSweepReport, error) {
+	var patterns []string
+	var purge func(ctx context.Context, keys []string, dryRun bool) (int, error)
+	switch sweep {
+	case CacheSweepIdempotency:
+		// Set by worker.OrderWorker and worker.NotificationWorker
+		patterns = []string{"processed:order:*", "notification:processed:*"}
+		purge = j.purgePersistent(0)
+	case CacheSweepCheckoutSessions:
+		patterns = []string{"mall:checkout:session:*"}
+		purge = j.purgePersistent(0)
+	case CacheSweepRateLimits:
+		patterns = []string{"mall:review:*"}
+		purge = j.purgePersistent(j.opts.RateLimitWindow)
+	case CacheSweepPurchaseCounters:
+		patterns = []string{"mall:purchases:store:*"}
+		purge = j.orphanedPurchases()
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCacheSweep, sweep)
+	}
+
+	report := &CacheSweepReport{}
+	for _, pattern := range patterns {
+		var cursor uint64
+		for {
+			keys, next, err := j.cache.Scan(ctx, cursor, pattern, cacheSweepPageSize)
+			if err != nil {
+				return report, fmt.Errorf("failed to scan %s: %w", pattern, err)
+			}
+			report.Scanned += len(keys)
+			if len(keys) > 0 {
+				stale, err := purge(ctx, keys, dryRun)
+				report.Stale += stale
+				if err != nil {
+					return report, err
+				}
+			}
+			if next == 0 {
+				break
+			}
+			cursor = next
+		}
+	}
+	return report, nil
+}
+
+// purgePersistent purges the keys that never expire or, with maxTTL set,
+// expire later than maxTTL from now.
+func (j *cacheJanitor) purgePersistent(maxTTL time.Duration) func(ctx context.Context, keys []string, dryRun bool) (int, error) {
+	return func(ctx context.Context, keys []string, dryRun bool) (int, error) {
+		countOnly := 0
+		if dryRun {
+			countOnly = 1
+		}
+		res, err := j.cache.Eval(ctx, purgeOutliving, keys, maxTTL.Milliseconds(), countOnly)
+		if err != nil {
+			return 0, fmt.Errorf("failed to purge stale keys: %w", err)
+		}
+		stale, ok := res.(int64)
+		if !ok {
+			return 0, fmt.Errorf("unexpected stale key count %v", res)
+		}
+		return int(stale), nil
+	}
+}
+
+// orphanedPurchases purges the purchase counters of SKUs and promotions that
+// are no longer limited. Limits are looked up once per sweep.
+func (j *cacheJanitor) orphanedPurchases() func(ctx context.Context, keys []string, dryRun bool) (int, error) {
+	limited := make(map[string]bool) // "sku:ID" or "promotion:ID"
+	return func(ctx context.Context, keys []string, dryRun bool) (int, error) {
+		var stale []string
+		for _, key := range keys {
+			subject, ok := purchaseKeySubject(key)
+			if !ok {
+				continue
+			}
+			isLimited, seen := limited[subject]
+			if !seen {
+				var err error
+				if isLimited, err = j.limited(ctx, subject); err != nil {
+					return 0, err
+				}
+				limited[subject] = isLimited
+			}
+			if !isLimited {
+				stale = append(stale, key)
+			}
+		}
+		if dryRun || len(stale) == 0 {
+			return len(stale), nil
+		}
+		if err := j.cache.Del(ctx, stale...); err != nil {
+			return 0, fmt.Errorf("failed to purge purchase counters: %w", err)
+		}
+		return len(stale), nil
+	}
+}
+
+// limited reports whether the SKU or promotion of subject still has a
+// purchase limit.
+func (j *cacheJanitor) limited(ctx context.Context, subject string) (bool, error) {
+	kind, rawID, _ := strings.Cut(subject, ":")
+	id, _ := strconv.ParseUint(rawID, 10, 64)
+	if kind == "sku" {
+		sku, err := j.products.GetSKUByID(ctx, id)
+		if errors.Is(err, repository.ErrSKUNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return sku.PurchaseLimit > 0, nil
+	}
+	promotion, err := j.promotions.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrPromotionNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return promotion.PurchaseLimit > 0, nil
+}
+
+// purchaseKeySubject returns "sku:ID" or "promotion:ID" of a key built by
+// purchaseKey, and false for any other key.
+func purchaseKeySubject(key string) (string, bool) {
+	parts := strings.Split(key, ":")
+	if len(parts) != 8 || parts[6] != "user" || (parts[4] != "sku" && parts[4] != "promotion") {
+		return "", false
+	}
+	if _, err := strconv.ParseUint(parts[5], 10, 64); err != nil {
+		return "", false
+	}
+	return parts[4] + ":" + parts[5], true
+}
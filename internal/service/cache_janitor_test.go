@@ -0,0 +1,119 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCacheJanitor_Sweep(t *testing.T) {
+	errRedis := errors.New("redis down")
+
+	tests := []struct {
+		name      string
+		sweep     string
+		dryRun    bool
+		mockSetup func(c *mocks.MockCache, products *mocks.MockProductRepository, promotions *mocks.MockPromotionRepository)
+		want      *service.CacheSweepReport
+		wantErr   error
+	}{
+		{
+			name:  "Idempotency",
+			sweep: service.CacheSweepIdempotency,
+			mockSetup: func(c *mocks.MockCache, _ *mocks.MockProductRepository, _ *mocks.MockPromotionRepository) {
+				c.EXPECT().Scan(gomock.Any(), uint64(0), "processed:order:*", gomock.Any()).Return([]string{"processed:order:1"}, uint64(9), nil)
+				c.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"processed:order:1"}, int64(0), 0).Return(int64(1), nil)
+				c.EXPECT().Scan(gomock.Any(), uint64(9), "processed:order:*", gomock.Any()).Return([]string{"processed:order:2"}, uint64(0), nil)
+				c.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"processed:order:2"}, int64(0), 0).Return(int64(0), nil)
+				c.EXPECT().Scan(gomock.Any(), uint64(0), "notification:processed:*", gomock.Any()).Return(nil, uint64(0), nil)
+			},
+			want: &service.CacheSweepReport{Scanned: 2, Stale: 1},
+		},
+		{
+			name:   "RateLimitsDryRun",
+			sweep:  service.CacheSweepRateLimits,
+			dryRun: true,
+			mockSetup: func(c *mocks.MockCache, _ *mocks.MockProductRepository, _ *mocks.MockPromotionRepository) {
+				keys := []string{"mall:review:votes:1", "mall:review:reports:1"}
+				c.EXPECT().Scan(gomock.Any(), uint64(0), "mall:review:*", gomock.Any()).Return(keys, uint64(0), nil)
+				c.EXPECT().Eval(gomock.Any(), gomock.Any(), keys, time.Hour.Milliseconds(), 1).Return(int64(2), nil)
+			},
+			want: &service.CacheSweepReport{Scanned: 2, Stale: 2},
+		},
+		{
+			name:  "PurchaseCounters",
+			sweep: service.CacheSweepPurchaseCounters,
+			mockSetup: func(c *mocks.MockCache, products *mocks.MockProductRepository, promotions *mocks.MockPromotionRepository) {
+				c.EXPECT().Scan(gomock.Any(), uint64(0), "mall:purchases:store:*", gomock.Any()).Return([]string{
+					"mall:purchases:store:0:sku:1:user:7",
+					"mall:purchases:store:0:sku:1:user:8",
+					"mall:purchases:store:0:sku:2:user:7",
+					"mall:purchases:store:0:sku:3:user:7",
+					"mall:purchases:store:0:promotion:4:user:7",
+					"mall:purchases:store:0:promotion:5:user:7",
+				}, uint64(0), nil)
+				products.EXPECT().GetSKUByID(gomock.Any(), uint64(1)).Return(&model.SKU{PurchaseLimit: 2}, nil)
+				products.EXPECT().GetSKUByID(gomock.Any(), uint64(2)).Return(&model.SKU{}, nil)
+				products.EXPECT().GetSKUByID(gomock.Any(), uint64(3)).Return(nil, repository.ErrSKUNotFound)
+				promotions.EXPECT().GetByID(gomock.Any(), uint64(4)).Return(&model.Promotion{PurchaseLimit: 1}, nil)
+				promotions.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(nil, repository.ErrPromotionNotFound)
+				c.EXPECT().Del(gomock.Any(), "mall:purchases:store:0:sku:2:user:7", "mall:purchases:store:0:sku:3:user:7", "mall:purchases:store:0:promotion:5:user:7").Return(nil)
+			},
+			want: &service.CacheSweepReport{Scanned: 6, Stale: 3},
+		},
+		{
+			name:   "PurchaseCountersDryRun",
+			sweep:  service.CacheSweepPurchaseCounters,
+			dryRun: true,
+			mockSetup: func(c *mocks.MockCache, products *mocks.MockProductRepository, _ *mocks.MockPromotionRepository) {
+				c.EXPECT().Scan(gomock.Any(), uint64(0), "mall:purchases:store:*", gomock.Any()).Return([]string{"mall:purchases:store:0:sku:2:user:7"}, uint64(0), nil)
+				products.EXPECT().GetSKUByID(gomock.Any(), uint64(2)).Return(&model.SKU{}, nil)
+			},
+			want: &service.CacheSweepReport{Scanned: 1, Stale: 1},
+		},
+		{
+			name:  "ScanFails",
+			sweep: service.CacheSweepCheckoutSessions,
+			mockSetup: func(c *mocks.MockCache, _ *mocks.MockProductRepository, _ *mocks.MockPromotionRepository) {
+				c.EXPECT().Scan(gomock.Any(), uint64(0), "mall:checkout:session:*", gomock.Any()).Return(nil, uint64(0), errRedis)
+			},
+			want:    &service.CacheSweepReport{},
+			wantErr: errRedis,
+		},
+		{
+			name:    "UnknownSweep",
+			sweep:   "carts",
+			wantErr: service.ErrUnknownCacheSweep,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			c := mocks.NewMockCache(ctrl)
+			products := mocks.NewMockProductRepository(ctrl)
+			promotions := mocks.NewMockPromotionRepository(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(c, products, promotions)
+			}
+
+			janitor := service.NewCacheJanitor(c, products, promotions, service.CacheJanitorOptions{})
+			report, err := janitor.Sweep(context.Background(), tt.sweep, tt.dryRun)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, report)
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/proyuen/go-mall/pkg/tenant"
+)
+
+// Defaults of CartRecoveryOptions.
+const (
+	DefaultCartAbandonedAfter = 24 * time.Hour
+	DefaultCartRecoveryWindow = 7 * 24 * time.Hour
+)
+
+// DefaultCartRecoveryStatsPeriod is how far back RecoveryStats looks when no
+// start is given.
+const DefaultCartRecoveryStatsPeriod = 30 * 24 * time.Hour
+
+const (
+	// cartRecoveryBatchSize is how many carts RecoverAbandoned loads at a time.
+	cartRecoveryBatchSize = 100
+	// cartRecoveryCouponPrefix starts the codes attached to reminders.
+	cartRecoveryCouponPrefix = "CART-"
+)
+
+// CartItemResp is one SKU in a cart.
+type CartItemResp struct {
+	SKUID    uint64 `json:"sku_id,string"`
+	Quantity int    `json:"quantity" example:"2"`
+}
+
+// CartResp is a customer's open cart. A customer without one has an empty
+// cart with ID 0.
+type CartResp struct {
+	ID             uint64         `json:"id,string"`
+	Items          []CartItemResp `json:"items"`
+	ActiveAt       *time.Time     `json:"active_at,omitempty"`                               // Last change of its items
+	RecoveryCoupon string         `json:"recovery_coupon,omitempty" example:"CART-7KQ2M9XD"` // Sent with the reminder of the cart, if any
+}
+
+// CartRecoveryStatsResp measures how many abandoned carts the reminders
+// brought back.
+type CartRecoveryStatsResp struct {
+	Since          time.Time `json:"since"`
+	Reminded       int64     `json:"reminded"`                       // Carts whose customers were reminded of them since then
+	Recovered      int64     `json:"recovered"`                      // Of those, the carts ordered afterwards
+	ConversionRate float64   `json:"conversion_rate" example:"0.12"` // Recovered over reminded
+	// Revenue totals the paid orders of the recovered carts, converted into
+	// Currency.
+	Revenue  money.Money `json:"revenue"`
+	Currency string      `json:"currency" example:"USD"` // The base currency
+}
+
+// CartService keeps customers' carts server side, so that the carts they
+// abandon can be recovered; see CartRecovery.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/cart_service_mock.go -package=mocks
+type CartService interface {
+	Get(ctx context.Context, userID uint64) (*CartResp, error)
+	// SetItem sets the quantity of a SKU in the customer's cart.
+	SetItem(ctx context.Context, userID, skuID uint64, quantity int) (*CartResp, error)
+	RemoveItem(ctx context.Context, userID, skuID uint64) (*CartResp, error)
+	Clear(ctx context.Context, userID uint64) error
+	// Convert closes the customer's cart for an order placed with any of its
+	// SKUs; the next item added opens a new cart. An order with none of them,
+	// such as a subscription renewal, leaves the cart open.
+	Convert(ctx context.Context, userID, orderID uint64, skuIDs []uint64) error
+	// RecoveryStats counts the carts whose customers were reminded of them
+	// at or after since, or in the last DefaultCartRecoveryStatsPeriod if
+	// since is zero, and those of them ordered afterwards.
+	RecoveryStats(ctx context.Context, since time.Time) (*CartRecoveryStatsResp, error)
+}
+
+// CartRecoveryOptions controls which carts are abandoned and what their
+// reminders carry. Zero durations use the defaults.
+type CartRecoveryOptions struct {
+	AbandonedAfter   time.Duration // A cart untouched this long is abandoned
+	Window           time.Duration // Carts abandoned longer than this are left alone
+	CartURL          string        // Linked from the reminders; empty leaves the link out
+	CouponCampaignID uint64        // Campaign a code of is attached to each reminder; 0 attaches none
+}
+
+// CartRecovery reminds customers of the carts they abandoned. It is apart
+// from CartService so the API server does not need RabbitMQ.
+type CartRecovery interface {
+	// RecoverAbandoned notifies the customers of the carts abandoned within
+	// the window that were not reminded of yet, across all stores. It
+	// returns how many it reminded.
+	RecoverAbandoned(ctx context.Context) (int, error)
+}
+
+type cartService struct {
+	cartRepo    repository.CartRepository
+	productRepo repository.ProductRepository
+	currencies  CurrencyService
+	now         func() time.Time
+}
+
+// NewCartService creates a new CartService.
+func NewCartService(cartRepo repository.CartRepository, productRepo repository.ProductRepository, currencies CurrencyService) CartService {
+	return &cartService{cartRepo: cartRepo, productRepo: productRepo, currencies: currencies, now: time.Now}
+}
+
+func (s *cartService) Get(ctx context.Context, userID uint64) (*CartResp, error) {
+	cart, err := s.cartRepo.GetOpen(ctx, userID)
+	if errors.Is(err, repository.ErrCartNotFound) {
+		return &CartResp{Items: []CartItemResp{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newCartResp(cart), nil
+}
+
+func (s *cartService) SetItem(ctx context.Context, userID, skuID uint64, quantity int) (*CartResp, error) {
+	if _, err := s.productRepo.GetSKUByID(ctx, skuID); err != nil {
+		if errors.Is(err, repository.ErrSKUNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to get SKU by ID %d: %w", skuID, err)
+	}
+	if err := s.cartRepo.SetItem(ctx, userID, skuID, quantity, s.now()); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, userID)
+}
+
+func (s *cartService) RemoveItem(ctx context.Context, userID, skuID uint64) (*CartResp, error) {
+	if err := s.cartRepo.RemoveItem(ctx, userID, skuID, s.now()); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, userID)
+}
+
+func (s *cartService) Clear(ctx context.Context, userID uint64) error {
+	return s.cartRepo.Clear(ctx, userID, s.now())
+}
+
+// Convert runs in the worker without a store in the context; user IDs are
+// unique across stores.
+func (s *cartService) Convert(ctx context.Context, userID, orderID uint64, skuIDs []uint64) error {
+	if len(skuIDs) == 0 {
+		return nil
+	}
+	cart, err := s.cartRepo.Convert(ctx, userID, orderID, skuIDs, s.now())
+	if errors.Is(err, repository.ErrCartNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if cart.Recovered() {
+		cartsRecovered.Inc()
+		slog.InfoContext(ctx, "Abandoned cart recovered", "cart_id", cart.ID, "order_id", orderID, "coupon", cart.RecoveryCoupon)
+	}
+	return nil
+}
+
+func (s *cartService) RecoveryStats(ctx context.Context, since time.Time) (*CartRecoveryStatsResp, error) {
+	if since.IsZero() {
+		since = s.now().Add(-DefaultCartRecoveryStatsPeriod)
+	}
+	stats, err := s.cartRepo.RecoveryStats(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	base := s.currencies.Base()
+	resp := &CartRecoveryStatsResp{
+		Since:     since,
+		Reminded:  stats.Reminded,
+		Recovered: stats.Recovered,
+		Revenue:   money.Zero(base),
+		Currency:  base,
+	}
+	if stats.Reminded > 0 {
+		resp.ConversionRate = float64(stats.Recovered) / float64(stats.Reminded)
+	}
+	var rates *Rates // Loaded for the first revenue in another currency
+	for _, revenue := range stats.Revenue {
+		amount := revenue.Amount
+		if revenue.Currency != base {
+			if rates == nil {
+				if rates, err = s.currencies.Rates(ctx); err != nil {
+					return nil, err
+				}
+			}
+			if amount, err = rates.Convert(amount, revenue.Currency, base); err != nil {
+				return nil, fmt.Errorf("failed to convert %s revenue: %w", revenue.Currency, err)
+			}
+		}
+		if resp.Revenue, err = resp.Revenue.Add(money.New(amount, base)); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+func newCartResp(cart *model.Cart) *CartResp {
+	resp := &CartResp{ID: cart.ID, Items: make([]CartItemResp, 0, len(cart.Items)), ActiveAt: &cart.ActiveAt, RecoveryCoupon: cart.RecoveryCoupon}
+	for _, item := range cart.Items {
+		resp.Items = append(resp.Items, CartItemResp{SKUID: item.SKUID, Quantity: item.Quantity})
+	}
+	return resp
+}
+
+type cartRecovery struct {
+	cartRepo    repository.CartRepository
+	productRepo repository.ProductRepository
+	coupons     CouponService
+	notifier    notification.Notifier
+	opts        CartRecoveryOptions
+	now         func() time.Time
+}
+
+// NewCartRecovery creates a CartRecovery. coupons is only used when
+// opts.CouponCampaignID is set.
+func NewCartRecovery(cartRepo repository.CartRepository, productRepo repository.ProductRepository, coupons CouponService,
+	notifier notification.Notifier, opts CartRecoveryOptions) CartRecovery {
+	if opts.AbandonedAfter <= 0 {
+		opts.AbandonedAfter = DefaultCartAbandonedAfter
+	}
+	if opts.Window <= 0 {
+		opts.Window = DefaultCartRecoveryWindow
+	}
+	return &cartRecovery{cartRepo: cartRepo, productRepo: productRepo, coupons: coupons, notifier: notifier, opts: opts, now: time.Now}
+}
+
+// RecoverAbandoned stops at the first cart it fails to remind of; the next
+// run starts over with it.
+func (r *cartRecovery) RecoverAbandoned(ctx context.Context) (int, error) {
+	now := r.now()
+	activeBefore := now.Add(-r.opts.AbandonedAfter)
+	activeAfter := activeBefore.Add(-r.opts.Window)
+	var afterID uint64
+	reminded := 0
+	for {
+		carts, err := r.cartRepo.ListAbandoned(ctx, activeAfter, activeBefore, afterID, cartRecoveryBatchSize)
+		if err != nil {
+			return reminded, err
+		}
+		for i := range carts {
+			sent, err := r.remind(ctx, &carts[i], now)
+			if err != nil {
+				return reminded, fmt.Errorf("failed to remind of cart %d: %w", carts[i].ID, err)
+			}
+			if sent {
+				reminded++
+			}
+			afterID = carts[i].ID
+		}
+		if len(carts) < cartRecoveryBatchSize {
+			return reminded, nil
+		}
+	}
+}
+
+// remind notifies the customer of the cart, with a new coupon code if a
+// campaign is configured. Carts whose products were all deleted are skipped.
+// A code generated for a reminder that then fails to queue stays unused.
+func (r *cartRecovery) remind(ctx context.Context, cart *model.Cart, now time.Time) (bool, error) {
+	storeCtx := ctx
+	if cart.StoreID != 0 {
+		storeCtx = tenant.NewContext(ctx, &model.Store{Base: model.Base{ID: cart.StoreID}})
+	}
+	data := &notification.CartRecoveryData{Items: make([]notification.CartLine, 0, len(cart.Items)), CartURL: r.opts.CartURL}
+	for _, item := range cart.Items {
+		sku, err := r.productRepo.GetSKUByID(storeCtx, item.SKUID)
+		if errors.Is(err, repository.ErrSKUNotFound) {
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to get SKU by ID %d: %w", item.SKUID, err)
+		}
+		data.Items = append(data.Items, notification.CartLine{Name: sku.SPU.Name, Quantity: item.Quantity})
+	}
+	if len(data.Items) == 0 {
+		slog.DebugContext(ctx, "Skipping abandoned cart without products", "cart_id", cart.ID)
+		return false, nil
+	}
+
+	if r.opts.CouponCampaignID != 0 {
+		batch, err := r.coupons.GenerateCodes(storeCtx, r.opts.CouponCampaignID, 1, cartRecoveryCouponPrefix)
+		switch {
+		case errors.Is(err, ErrCouponCampaignNotFound):
+			// The campaign belongs to another store
+		case err != nil:
+			return false, err
+		default:
+			data.CouponCode = batch.Codes[0]
+		}
+	}
+
+	if err := r.notifier.Notify(ctx, cart.UserID, notification.KindCartRecovery, data); err != nil {
+		return false, err
+	}
+	if err := r.cartRepo.MarkRecoveryNotified(ctx, cart.ID, data.CouponCode, now); err != nil {
+		return false, err
+	}
+	cartRecoveryReminders.Inc()
+	return true, nil
+}
@@ -0,0 +1,212 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCartService_SetItem(t *testing.T) {
+	tests := []struct {
+		name      string
+		mockSetup func(cartRepo *mocks.MockCartRepository, productRepo *mocks.MockProductRepository)
+		wantItems int
+		wantErrIs error
+	}{
+		{
+			name: "Set",
+			mockSetup: func(cartRepo *mocks.MockCartRepository, productRepo *mocks.MockProductRepository) {
+				productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(11)).Return(&model.SKU{Base: model.Base{ID: 11}}, nil)
+				cartRepo.EXPECT().SetItem(gomock.Any(), uint64(1), uint64(11), 2, gomock.Any()).Return(nil)
+				cartRepo.EXPECT().GetOpen(gomock.Any(), uint64(1)).Return(&model.Cart{Base: model.Base{ID: 5}, Items: []model.CartItem{{SKUID: 11, Quantity: 2}}}, nil)
+			},
+			wantItems: 1,
+		},
+		{
+			name: "UnknownSKU",
+			mockSetup: func(_ *mocks.MockCartRepository, productRepo *mocks.MockProductRepository) {
+				productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(11)).Return(nil, repository.ErrSKUNotFound)
+			},
+			wantErrIs: service.ErrProductNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			cartRepo := mocks.NewMockCartRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			tt.mockSetup(cartRepo, productRepo)
+
+			resp, err := service.NewCartService(cartRepo, productRepo, nil).SetItem(context.Background(), 1, 11, 2)
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, resp.Items, tt.wantItems)
+		})
+	}
+}
+
+func TestCartService_Get(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cartRepo := mocks.NewMockCartRepository(ctrl)
+	cartRepo.EXPECT().GetOpen(gomock.Any(), uint64(1)).Return(nil, repository.ErrCartNotFound)
+
+	resp, err := service.NewCartService(cartRepo, nil, nil).Get(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, &service.CartResp{Items: []service.CartItemResp{}}, resp, "an empty cart")
+}
+
+func TestCartService_Convert(t *testing.T) {
+	reminded := time.Now().Add(-time.Hour)
+	converted := time.Now()
+
+	tests := []struct {
+		name      string
+		skuIDs    []uint64
+		mockSetup func(cartRepo *mocks.MockCartRepository)
+	}{
+		{
+			name:   "Recovered",
+			skuIDs: []uint64{11},
+			mockSetup: func(cartRepo *mocks.MockCartRepository) {
+				cartRepo.EXPECT().Convert(gomock.Any(), uint64(1), uint64(7), []uint64{11}, gomock.Any()).
+					Return(&model.Cart{Base: model.Base{ID: 5}, RecoveryNotifiedAt: &reminded, ConvertedAt: &converted, OrderID: 7}, nil)
+			},
+		},
+		{
+			name:   "NoCart",
+			skuIDs: []uint64{11},
+			mockSetup: func(cartRepo *mocks.MockCartRepository) {
+				cartRepo.EXPECT().Convert(gomock.Any(), uint64(1), uint64(7), []uint64{11}, gomock.Any()).Return(nil, repository.ErrCartNotFound)
+			},
+		},
+		{name: "NoItems", mockSetup: func(*mocks.MockCartRepository) {}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			cartRepo := mocks.NewMockCartRepository(ctrl)
+			tt.mockSetup(cartRepo)
+
+			err := service.NewCartService(cartRepo, nil, nil).Convert(context.Background(), 1, 7, tt.skuIDs)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCartService_RecoveryStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cartRepo := mocks.NewMockCartRepository(ctrl)
+	rateRepo := mocks.NewMockExchangeRateRepository(ctrl)
+	cartRepo.EXPECT().RecoveryStats(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, since time.Time) (*repository.CartRecoveryStats, error) {
+		assert.WithinDuration(t, time.Now().Add(-service.DefaultCartRecoveryStatsPeriod), since, time.Minute)
+		return &repository.CartRecoveryStats{Reminded: 8, Recovered: 2, Revenue: []repository.CartRecoveryRevenue{
+			{Currency: "EUR", Amount: decimal.NewFromInt(18)},
+			{Currency: "USD", Amount: decimal.RequireFromString("30.50")},
+		}}, nil
+	})
+	rateRepo.EXPECT().ListByBase(gomock.Any(), "USD").Return([]model.ExchangeRate{
+		{Base: model.Base{UpdatedAt: time.Now()}, BaseCurrency: "USD", Currency: "EUR", Rate: decimal.RequireFromString("0.9")},
+	}, nil)
+
+	currencies := service.NewCurrencyService(rateRepo, nil, nil, service.CurrencyOptions{Base: "USD", Supported: []string{"EUR"}})
+	resp, err := service.NewCartService(cartRepo, nil, currencies).RecoveryStats(context.Background(), time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), resp.Reminded)
+	assert.Equal(t, int64(2), resp.Recovered)
+	assert.InDelta(t, 0.25, resp.ConversionRate, 0.0001)
+	assert.Equal(t, "50.50 USD", resp.Revenue.String(), "EUR 18 counts as USD 20")
+}
+
+func TestCartRecovery_RecoverAbandoned(t *testing.T) {
+	cart := model.Cart{Base: model.Base{ID: 5}, StoreID: 2, UserID: 1, Items: []model.CartItem{{SKUID: 11, Quantity: 2}, {SKUID: 12, Quantity: 1}}}
+
+	tests := []struct {
+		name       string
+		campaignID uint64
+		mockSetup  func(cartRepo *mocks.MockCartRepository, coupons *mocks.MockCouponService, notifier *mocks.MockNotifier)
+		want       int
+		wantErr    bool
+	}{
+		{
+			name:       "WithCoupon",
+			campaignID: 3,
+			mockSetup: func(cartRepo *mocks.MockCartRepository, coupons *mocks.MockCouponService, notifier *mocks.MockNotifier) {
+				coupons.EXPECT().GenerateCodes(gomock.Any(), uint64(3), 1, "CART-").Return(&service.CouponBatchResp{CampaignID: 3, Codes: []string{"CART-AB12"}}, nil)
+				notifier.EXPECT().Notify(gomock.Any(), uint64(1), notification.KindCartRecovery, gomock.Any()).DoAndReturn(func(_ context.Context, _ uint64, _ notification.Kind, data any) error {
+					recovery := data.(*notification.CartRecoveryData)
+					assert.Equal(t, []notification.CartLine{{Name: "Mug", Quantity: 2}}, recovery.Items, "the deleted product left out")
+					assert.Equal(t, "https://shop.example.com/cart", recovery.CartURL)
+					assert.Equal(t, "CART-AB12", recovery.CouponCode)
+					return nil
+				})
+				cartRepo.EXPECT().MarkRecoveryNotified(gomock.Any(), uint64(5), "CART-AB12", gomock.Any()).Return(nil)
+			},
+			want: 1,
+		},
+		{
+			name:       "CampaignOfAnotherStore",
+			campaignID: 3,
+			mockSetup: func(cartRepo *mocks.MockCartRepository, coupons *mocks.MockCouponService, notifier *mocks.MockNotifier) {
+				coupons.EXPECT().GenerateCodes(gomock.Any(), uint64(3), 1, "CART-").Return(nil, service.ErrCouponCampaignNotFound)
+				notifier.EXPECT().Notify(gomock.Any(), uint64(1), notification.KindCartRecovery, gomock.Any()).Return(nil)
+				cartRepo.EXPECT().MarkRecoveryNotified(gomock.Any(), uint64(5), "", gomock.Any()).Return(nil)
+			},
+			want: 1,
+		},
+		{
+			name: "QueueDown",
+			mockSetup: func(_ *mocks.MockCartRepository, _ *mocks.MockCouponService, notifier *mocks.MockNotifier) {
+				notifier.EXPECT().Notify(gomock.Any(), uint64(1), notification.KindCartRecovery, gomock.Any()).Return(errors.New("connection refused"))
+				// Not marked reminded, so the next run tries again
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			cartRepo := mocks.NewMockCartRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			coupons := mocks.NewMockCouponService(ctrl)
+			notifier := mocks.NewMockNotifier(ctrl)
+			cartRepo.EXPECT().ListAbandoned(gomock.Any(), gomock.Any(), gomock.Any(), uint64(0), gomock.Any()).
+				DoAndReturn(func(_ context.Context, activeAfter, activeBefore time.Time, _ uint64, _ int) ([]model.Cart, error) {
+					assert.WithinDuration(t, time.Now().Add(-service.DefaultCartAbandonedAfter), activeBefore, time.Minute)
+					assert.Equal(t, service.DefaultCartRecoveryWindow, activeBefore.Sub(activeAfter))
+					return []model.Cart{cart}, nil
+				})
+			productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(11)).Return(&model.SKU{SPU: model.SPU{Name: "Mug"}}, nil)
+			productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(12)).Return(nil, repository.ErrSKUNotFound)
+			tt.mockSetup(cartRepo, coupons, notifier)
+
+			recovery := service.NewCartRecovery(cartRepo, productRepo, coupons, notifier, service.CartRecoveryOptions{
+				CartURL:          "https://shop.example.com/cart",
+				CouponCampaignID: tt.campaignID,
+			})
+			reminded, err := recovery.RecoverAbandoned(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, reminded)
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/cache"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/tenant"
+	"golang.org/x/sync/singleflight"
+)
+
+// CatalogEventsChannel is the pub/sub channel on which stock changes are
+// announced to CatalogInvalidator.
+const CatalogEventsChannel = "mall:catalog:events"
+
+const (
+	// DefaultCatalogCacheTTL is how long catalog entries are cached when their
+	// class has no hard TTL.
+	DefaultCatalogCacheTTL = time.Hour
+	// revalidateTimeout bounds the background reload of a stale entry.
+	revalidateTimeout = 5 * time.Second
+	// productListTagKey holds the tag a store's list pages are cached under.
+	productListTagKey = "mall:product:list:tag"
+)
+
+// CatalogEvent announces that the stock of SKUs changed.
+type CatalogEvent struct {
+	SKUIDs []uint64 `json:"sku_ids"`
+}
+
+// CacheTTL is how long one class of entries is cached. An entry older than
+// Soft is still served, but reloaded in the background; one older than Hard
+// is gone. A zero Soft, or one not below Hard, serves entries as they are
+// until they expire. A zero Hard means DefaultCatalogCacheTTL.
+type CacheTTL struct {
+	Soft time.Duration
+	Hard time.Duration
+}
+
+// CatalogCacheOptions sets the TTLs of each class of catalog entries.
+type CatalogCacheOptions struct {
+	Product     CacheTTL // Product detail
+	ProductList CacheTTL // List pages
+}
+
+// CatalogCache keeps the products of each store in the cache, by ID and by
+// list page. A page may hold any product, so list pages are cached under a
+// tag that every product change replaces, rather than deleted one by one.
+type CatalogCache struct {
+	cache       cache.Cache
+	product     CacheTTL
+	productList CacheTTL
+	// revalidating lets one reader per instance reload a stale entry
+	revalidating singleflight.Group
+}
+
+// NewCatalogCache creates a CatalogCache backed by c.
+func NewCatalogCache(c cache.Cache, opts CatalogCacheOptions) *CatalogCache {
+	return &CatalogCache{cache: c, product: opts.Product.withDefaults(), productList: opts.ProductList.withDefaults()}
+}
+
+func (t CacheTTL) withDefaults() CacheTTL {
+	if t.Hard <= 0 {
+		t.Hard = DefaultCatalogCacheTTL
+	}
+	if t.Soft <= 0 || t.Soft >= t.Hard {
+		t.Soft = t.Hard
+	}
+	return t
+}
+
+// catalogEntry is how values are stored, so that readers can tell stale
+// ones from fresh ones.
+type catalogEntry struct {
+	FreshUntil time.Time       `json:"fresh_until"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// productCacheKey is the key of a product of the store in ctx.
+func productCacheKey(ctx context.Context, spuID uint64) string {
+	return storeCacheKey(ctx, fmt.Sprintf("mall:product:spu:%d", spuID))
+}
+
+// Product returns the cached product, or the one load returns, which is
+// cached. A stale product is returned as it is while it is reloaded in the
+// background.
+func (c *CatalogCache) Product(ctx context.Context, spuID uint64, load func(context.Context) (*ProductResp, error)) (*ProductResp, error) {
+	return readThrough(ctx, c, productCacheKey(ctx, spuID), c.product, load)
+}
+
+// List returns the cached list page, or the one load returns, which is
+// cached like a product.
+func (c *CatalogCache) List(ctx context.Context, offset, limit int, load func(context.Context) ([]ProductResp, error)) ([]ProductResp, error) {
+	return listThrough(ctx, c, func(tag string) string { return listCacheKey(ctx, tag, offset, limit) }, load)
+}
+
+// Facets returns the cached facet counts, or those load returns, which are
+// cached like a list page and replaced along with them.
+func (c *CatalogCache) Facets(ctx context.Context, load func(context.Context) (*ProductFacets, error)) (*ProductFacets, error) {
+	return listThrough(ctx, c, func(tag string) string { return facetsCacheKey(ctx, tag) }, load)
+}
+
+// listThrough is readThrough for entries cached under the list tag, at the
+// key keyFor returns for the tag.
+func listThrough[T any](ctx context.Context, c *CatalogCache, keyFor func(tag string) string, load func(context.Context) (T, error)) (T, error) {
+	tag, created := c.listTag(ctx)
+	switch {
+	case tag == "":
+		return load(ctx)
+	case created:
+		// Nothing is cached under a new tag yet
+		return loadThrough(ctx, c, keyFor(tag), c.productList, load)
+	}
+	return readThrough(ctx, c, keyFor(tag), c.productList, load)
+}
+
+// listTag returns the tag the list pages of the store in ctx are cached
+// under, and whether it was just created. It is empty if the cache cannot be
+// read, in which case pages are not cached.
+func (c *CatalogCache) listTag(ctx context.Context) (string, bool) {
+	tagKey := storeCacheKey(ctx, productListTagKey)
+	tag, err := c.cache.Get(ctx, tagKey)
+	if err != nil {
+		return "", false
+	}
+	if tag != "" {
+		return tag, false
+	}
+	// No page of the store is cached yet. If another reader sets the tag
+	// first, pages are cached under it next time.
+	tag = newListTag()
+	if ok, err := c.cache.SetNX(ctx, tagKey, tag, 0); err != nil || !ok {
+		return "", false
+	}
+	return tag, true
+}
+
+// readThrough serves key from the cache, loading and caching it on a miss.
+// Failing to read or write the cache is not an error: the value is loaded
+// from the database instead.
+func readThrough[T any](ctx context.Context, c *CatalogCache, key string, ttl CacheTTL, load func(context.Context) (T, error)) (T, error) {
+	if value, fresh, ok := getEntry[T](ctx, c.cache, key); ok {
+		if !fresh {
+			c.revalidate(ctx, key, func(ctx context.Context) error {
+				value, err := load(ctx)
+				if err != nil {
+					return err
+				}
+				return setEntry(ctx, c.cache, key, ttl, value)
+			})
+		}
+		return value, nil
+	}
+	return loadThrough(ctx, c, key, ttl, load)
+}
+
+// loadThrough loads the value of key and caches it.
+func loadThrough[T any](ctx context.Context, c *CatalogCache, key string, ttl CacheTTL, load func(context.Context) (T, error)) (T, error) {
+	value, err := load(ctx)
+	if err != nil {
+		return value, err
+	}
+	_ = setEntry(ctx, c.cache, key, ttl, value)
+	return value, nil
+}
+
+// revalidate reloads a stale entry in the background, detached from the
+// request but keeping its store. Readers that find the entry stale while it
+// is being reloaded are served it without reloading it again.
+func (c *CatalogCache) revalidate(ctx context.Context, key string, reload func(context.Context) error) {
+	ctx = context.WithoutCancel(ctx)
+	c.revalidating.DoChan(key, func() (any, error) {
+		ctx, cancel := context.WithTimeout(ctx, revalidateTimeout)
+		defer cancel()
+		if err := reload(ctx); err != nil {
+			slog.WarnContext(ctx, "Failed to revalidate cache entry", "key", key, logger.Err(err))
+		}
+		return nil, nil
+	})
+}
+
+// getEntry reads key, reporting whether it is cached and still fresh.
+// Entries that cannot be decoded count as missing.
+func getEntry[T any](ctx context.Context, c cache.Cache, key string) (value T, fresh, ok bool) {
+	cached, err := c.Get(ctx, key)
+	if err != nil || cached == "" {
+		return value, false, false
+	}
+	var entry catalogEntry
+	if err := json.Unmarshal([]byte(cached), &entry); err != nil || len(entry.Data) == 0 {
+		return value, false, false
+	}
+	if err := json.Unmarshal(entry.Data, &value); err != nil {
+		return value, false, false
+	}
+	return value, time.Now().Before(entry.FreshUntil), true
+}
+
+func setEntry[T any](ctx context.Context, c cache.Cache, key string, ttl CacheTTL, value T) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	entry, err := json.Marshal(catalogEntry{FreshUntil: time.Now().Add(ttl.Soft), Data: data})
+	if err != nil {
+		return err
+	}
+	return c.Set(ctx, key, string(entry), ttl.Hard)
+}
+
+// Invalidate drops the cached products and every cached list page and facet
+// count of the store in ctx. It is called once the change is committed; a reader that
+// loaded the product before may still cache it again, until it expires.
+func (c *CatalogCache) Invalidate(ctx context.Context, spuIDs ...uint64) error {
+	if err := c.InvalidateDetail(ctx, spuIDs...); err != nil {
+		return err
+	}
+	// Pages cached under the old tag are no longer read, and expire
+	if err := c.cache.Set(ctx, storeCacheKey(ctx, productListTagKey), newListTag(), 0); err != nil {
+		return fmt.Errorf("failed to replace product list tag: %w", err)
+	}
+	return nil
+}
+
+// InvalidateDetail drops the cached products of the store in ctx but keeps
+// the list pages, for changes only product detail shows.
+func (c *CatalogCache) InvalidateDetail(ctx context.Context, spuIDs ...uint64) error {
+	if len(spuIDs) == 0 {
+		return nil
+	}
+	keys := make([]string, len(spuIDs))
+	for i, id := range spuIDs {
+		keys[i] = productCacheKey(ctx, id)
+	}
+	if err := c.cache.Del(ctx, keys...); err != nil {
+		return fmt.Errorf("failed to delete cached products: %w", err)
+	}
+	return nil
+}
+
+// PublishStockChanged announces that the stock of the SKUs changed, for
+// CatalogInvalidator to drop their products. It is cheaper than Invalidate
+// for callers that do not know the SKUs' products.
+func (c *CatalogCache) PublishStockChanged(ctx context.Context, skuIDs []uint64) error {
+	if len(skuIDs) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(CatalogEvent{SKUIDs: skuIDs})
+	if err != nil {
+		return err
+	}
+	if err := c.cache.Publish(ctx, CatalogEventsChannel, payload); err != nil {
+		return fmt.Errorf("failed to publish catalog event: %w", err)
+	}
+	return nil
+}
+
+func listCacheKey(ctx context.Context, tag string, offset, limit int) string {
+	return storeCacheKey(ctx, fmt.Sprintf("mall:product:list:%s:%d:%d", tag, offset, limit))
+}
+
+func facetsCacheKey(ctx context.Context, tag string) string {
+	return storeCacheKey(ctx, "mall:product:facets:"+tag)
+}
+
+func newListTag() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36)
+}
+
+// publishStockChanged announces the stock change of the items' SKUs. The
+// change is committed already, so failing to is only logged: the products
+// show the old stock until their cache entries expire.
+func publishStockChanged(ctx context.Context, c *CatalogCache, items []model.OrderItem) {
+	if c == nil || len(items) == 0 {
+		return
+	}
+	seen := make(map[uint64]bool, len(items))
+	for _, item := range items {
+		seen[item.SKUID] = true
+	}
+	skuIDs := slices.Sorted(maps.Keys(seen))
+	if err := c.PublishStockChanged(ctx, skuIDs); err != nil {
+		slog.WarnContext(ctx, "Failed to announce stock change", "sku_ids", skuIDs, logger.Err(err))
+	}
+}
+
+// CatalogInvalidator drops the cached products whose stock changed, as
+// announced on CatalogEventsChannel by orders, cancellations and backorder
+// allocation in any instance or the worker. Every API instance runs one:
+// each event is handled by all of them, which is harmless since dropping an
+// entry twice does nothing more.
+type CatalogInvalidator struct {
+	cache       cache.Cache
+	catalog     *CatalogCache
+	productRepo repository.ProductRepository
+}
+
+// NewCatalogInvalidator creates a CatalogInvalidator that looks up the
+// products of SKUs in productRepo.
+func NewCatalogInvalidator(c cache.Cache, productRepo repository.ProductRepository) *CatalogInvalidator {
+	return &CatalogInvalidator{cache: c, catalog: NewCatalogCache(c, CatalogCacheOptions{}), productRepo: productRepo}
+}
+
+// Run handles catalog events until ctx is cancelled. Events published while
+// Redis is unreachable are lost; their products expire after their hard TTL.
+func (i *CatalogInvalidator) Run(ctx context.Context) {
+	pubsub := i.cache.Subscribe(ctx, CatalogEventsChannel)
+	defer pubsub.Close()
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			if err := i.Handle(ctx, []byte(msg.Payload)); err != nil {
+				slog.ErrorContext(ctx, "Failed to invalidate cached products", "event", msg.Payload, logger.Err(err))
+			}
+		}
+	}
+}
+
+// Handle drops the cached products of the SKUs in one event, in the stores
+// they belong to.
+func (i *CatalogInvalidator) Handle(ctx context.Context, payload []byte) error {
+	var event CatalogEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("invalid catalog event: %w", err)
+	}
+	if len(event.SKUIDs) == 0 {
+		return nil
+	}
+	// ctx has no store, so SKUs of every store are found
+	skus, err := i.productRepo.GetSKUsByIDs(ctx, event.SKUIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get SKUs: %w", err)
+	}
+
+	spuIDs := map[uint64][]uint64{} // By store
+	for _, sku := range skus {
+		spuIDs[sku.StoreID] = append(spuIDs[sku.StoreID], sku.SPUID)
+	}
+	for storeID, ids := range spuIDs {
+		storeCtx := ctx
+		if storeID != 0 {
+			storeCtx = tenant.NewContext(ctx, &model.Store{Base: model.Base{ID: storeID}})
+		}
+		if err := i.catalog.Invalidate(storeCtx, ids...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
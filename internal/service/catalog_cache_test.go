@@ -0,0 +1,85 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCatalogCache_PublishStockChanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache(ctrl)
+	mockCache.EXPECT().Publish(gomock.Any(), service.CatalogEventsChannel, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, message interface{}) error {
+		var event service.CatalogEvent
+		require.NoError(t, json.Unmarshal(message.([]byte), &event))
+		assert.Equal(t, []uint64{101, 102}, event.SKUIDs)
+		return nil
+	})
+
+	catalog := service.NewCatalogCache(mockCache, service.CatalogCacheOptions{})
+	require.NoError(t, catalog.PublishStockChanged(context.Background(), []uint64{101, 102}))
+	require.NoError(t, catalog.PublishStockChanged(context.Background(), nil), "nothing to announce")
+}
+
+func TestCatalogInvalidator_Handle(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		skus     []model.SKU
+		wantDels [][]string
+		wantTags []string
+		wantErr  bool
+	}{
+		{
+			name:    "SingleStore",
+			payload: `{"sku_ids":[101,102]}`,
+			skus: []model.SKU{
+				{Base: model.Base{ID: 101}, SPUID: 1},
+				{Base: model.Base{ID: 102}, SPUID: 2},
+			},
+			wantDels: [][]string{{"mall:product:spu:1", "mall:product:spu:2"}},
+			wantTags: []string{"mall:product:list:tag"},
+		},
+		{
+			name:     "PerStore",
+			payload:  `{"sku_ids":[101]}`,
+			skus:     []model.SKU{{Base: model.Base{ID: 101}, SPUID: 1, StoreID: 7}},
+			wantDels: [][]string{{"mall:product:spu:1:store:7"}},
+			wantTags: []string{"mall:product:list:tag:store:7"},
+		},
+		{name: "Empty", payload: `{"sku_ids":[]}`},
+		{name: "Malformed", payload: `{`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockCache := mocks.NewMockCache(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			if tt.skus != nil {
+				productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), gomock.Any()).Return(tt.skus, nil)
+			}
+			for _, keys := range tt.wantDels {
+				mockCache.EXPECT().Del(gomock.Any(), keys).Return(nil)
+			}
+			for _, key := range tt.wantTags {
+				mockCache.EXPECT().Set(gomock.Any(), key, gomock.Any(), time.Duration(0)).Return(nil)
+			}
+
+			err := service.NewCatalogInvalidator(mockCache, productRepo).Handle(context.Background(), []byte(tt.payload))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/channel"
+)
+
+// ErrProductNotFound is returned when a product does not exist.
+var ErrProductNotFound = errors.New("product not found")
+
+// CatalogProduct is a product without its SKUs, which are fetched separately
+// so that listing pages only load what they display.
+type CatalogProduct struct {
+	ID          uint64
+	Name        string
+	Description string
+	CategoryID  uint64
+}
+
+// CategoryResp describes a product category.
+type CategoryResp struct {
+	ID       uint64 `json:"id,string"`
+	Name     string `json:"name"`
+	ParentID uint64 `json:"parent_id,string"` // 0 for top-level categories
+}
+
+// ReviewResp is a customer's rating of a product.
+type ReviewResp struct {
+	ID        uint64    `json:"id,string"`
+	ProductID uint64    `json:"product_id,string"`
+	UserID    uint64    `json:"user_id,string"`
+	Rating    int       `json:"rating"`
+	Comment   string    `json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+
+	HelpfulVotes   int `json:"helpful_votes"`
+	UnhelpfulVotes int `json:"unhelpful_votes"`
+}
+
+// UserProfile is what a user sees about their own account.
+type UserProfile struct {
+	ID       uint64 `json:"id,string"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// CatalogService answers the read-only catalog queries of the storefront.
+// Besides single lookups it resolves related records for many parents at
+// once, so callers composing a page make one call per relation instead of one
+// per item.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/catalog_service_mock.go -package=mocks
+type CatalogService interface {
+	ListProducts(ctx context.Context, offset, limit int) ([]CatalogProduct, error)
+	GetProduct(ctx context.Context, id uint64) (*CatalogProduct, error)
+	ListCategories(ctx context.Context) ([]CategoryResp, error)
+	GetProfile(ctx context.Context, userID uint64) (*UserProfile, error)
+
+	SKUsByProductIDs(ctx context.Context, productIDs []uint64) (map[uint64][]SKUResp, error)
+	CategoriesByIDs(ctx context.Context, ids []uint64) (map[uint64]CategoryResp, error)
+	ReviewsByProductIDs(ctx context.Context, productIDs []uint64, perProduct int) (map[uint64][]ReviewResp, error)
+	// UsernamesByIDs returns only usernames: other users' profiles are not public.
+	UsernamesByIDs(ctx context.Context, userIDs []uint64) (map[uint64]string, error)
+}
+
+type catalogService struct {
+	productRepo  repository.ProductRepository
+	categoryRepo repository.CategoryRepository
+	reviewRepo   repository.ReviewRepository
+	userRepo     repository.UserRepository
+}
+
+// NewCatalogService creates a new CatalogService instance.
+func NewCatalogService(productRepo repository.ProductRepository, categoryRepo repository.CategoryRepository, reviewRepo repository.ReviewRepository, userRepo repository.UserRepository) CatalogService {
+	return &catalogService{
+		productRepo:  productRepo,
+		categoryRepo: categoryRepo,
+		reviewRepo:   reviewRepo,
+		userRepo:     userRepo,
+	}
+}
+
+// ListProducts returns a page of products, newest first.
+func (s *catalogService) ListProducts(ctx context.Context, offset, limit int) ([]CatalogProduct, error) {
+	spus, err := s.productRepo.ListSPUs(ctx, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+	products := make([]CatalogProduct, 0, len(spus))
+	for i := range spus {
+		products = append(products, toCatalogProduct(&spus[i]))
+	}
+	return products, nil
+}
+
+// GetProduct returns one product, or ErrProductNotFound.
+func (s *catalogService) GetProduct(ctx context.Context, id uint64) (*CatalogProduct, error) {
+	spu, err := s.productRepo.GetSPUByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrSPUNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to get product %d: %w", id, err)
+	}
+	product := toCatalogProduct(spu)
+	return &product, nil
+}
+
+// ListCategories returns every category, parents before children.
+func (s *catalogService) ListCategories(ctx context.Context) ([]CategoryResp, error) {
+	categories, err := s.categoryRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	resps := make([]CategoryResp, 0, len(categories))
+	for i := range categories {
+		resps = append(resps, toCategoryResp(&categories[i]))
+	}
+	return resps, nil
+}
+
+// GetProfile returns the profile of userID, or repository.ErrUserNotFound.
+func (s *catalogService) GetProfile(ctx context.Context, userID uint64) (*UserProfile, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profile of user %d: %w", userID, err)
+	}
+	return &UserProfile{ID: user.ID, Username: user.Username, Email: user.Email}, nil
+}
+
+// SKUsByProductIDs returns the SKUs of each product sold on the sales
+// channel of ctx, keyed by product ID. Products without such SKUs have no
+// entry.
+func (s *catalogService) SKUsByProductIDs(ctx context.Context, productIDs []uint64) (map[uint64][]SKUResp, error) {
+	skus, err := s.productRepo.ListSKUsBySPUIDs(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SKUs: %w", err)
+	}
+	ch, now := channel.FromContext(ctx), time.Now()
+	byProduct := make(map[uint64][]SKUResp, len(productIDs))
+	for _, sku := range skus {
+		if !channel.Includes(sku.Channels, ch) {
+			continue
+		}
+		byProduct[sku.SPUID] = append(byProduct[sku.SPUID], newSKUResp(&sku, now))
+	}
+	return byProduct, nil
+}
+
+// CategoriesByIDs returns the categories with the given IDs, keyed by ID.
+func (s *catalogService) CategoriesByIDs(ctx context.Context, ids []uint64) (map[uint64]CategoryResp, error) {
+	categories, err := s.categoryRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	byID := make(map[uint64]CategoryResp, len(categories))
+	for i := range categories {
+		byID[categories[i].ID] = toCategoryResp(&categories[i])
+	}
+	return byID, nil
+}
+
+// ReviewsByProductIDs returns the newest perProduct reviews of each product,
+// keyed by product ID.
+func (s *catalogService) ReviewsByProductIDs(ctx context.Context, productIDs []uint64, perProduct int) (map[uint64][]ReviewResp, error) {
+	reviews, err := s.reviewRepo.ListBySPUIDs(ctx, productIDs, perProduct)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reviews: %w", err)
+	}
+	byProduct := make(map[uint64][]ReviewResp, len(productIDs))
+	for _, review := range reviews {
+		byProduct[review.SPUID] = append(byProduct[review.SPUID], newReviewResp(&review))
+	}
+	return byProduct, nil
+}
+
+func newReviewResp(review *model.Review) ReviewResp {
+	return ReviewResp{
+		ID:             review.ID,
+		ProductID:      review.SPUID,
+		UserID:         review.UserID,
+		Rating:         review.Rating,
+		Comment:        review.Comment,
+		CreatedAt:      review.CreatedAt,
+		HelpfulVotes:   review.HelpfulVotes,
+		UnhelpfulVotes: review.UnhelpfulVotes,
+	}
+}
+
+// UsernamesByIDs returns the username of each user, keyed by user ID.
+func (s *catalogService) UsernamesByIDs(ctx context.Context, userIDs []uint64) (map[uint64]string, error) {
+	users, err := s.userRepo.GetByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	usernames := make(map[uint64]string, len(users))
+	for _, user := range users {
+		usernames[user.ID] = user.Username
+	}
+	return usernames, nil
+}
+
+func toCatalogProduct(spu *model.SPU) CatalogProduct {
+	return CatalogProduct{
+		ID:          spu.ID,
+		Name:        spu.Name,
+		Description: spu.Description,
+		CategoryID:  spu.CategoryID,
+	}
+}
+
+func toCategoryResp(category *model.Category) CategoryResp {
+	return CategoryResp{ID: category.ID, Name: category.Name, ParentID: category.ParentID}
+}
@@ -0,0 +1,112 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type catalogRepos struct {
+	product  *mocks.MockProductRepository
+	category *mocks.MockCategoryRepository
+	review   *mocks.MockReviewRepository
+	user     *mocks.MockUserRepository
+}
+
+func newCatalogService(t *testing.T) (service.CatalogService, catalogRepos) {
+	ctrl := gomock.NewController(t)
+	repos := catalogRepos{
+		product:  mocks.NewMockProductRepository(ctrl),
+		category: mocks.NewMockCategoryRepository(ctrl),
+		review:   mocks.NewMockReviewRepository(ctrl),
+		user:     mocks.NewMockUserRepository(ctrl),
+	}
+	return service.NewCatalogService(repos.product, repos.category, repos.review, repos.user), repos
+}
+
+func TestCatalogService_GetProduct(t *testing.T) {
+	tests := []struct {
+		name      string
+		repoErr   error
+		wantErrIs error
+		wantErr   bool
+	}{
+		{name: "Success"},
+		{name: "NotFound", repoErr: repository.ErrSPUNotFound, wantErrIs: service.ErrProductNotFound, wantErr: true},
+		{name: "RepoError", repoErr: errors.New("db down"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repos := newCatalogService(t)
+			spu := &model.SPU{Base: model.Base{ID: 1}, Name: "Mug", CategoryID: 3}
+			if tt.repoErr != nil {
+				spu = nil
+			}
+			repos.product.EXPECT().GetSPUByID(gomock.Any(), uint64(1)).Return(spu, tt.repoErr)
+
+			product, err := svc.GetProduct(context.Background(), 1)
+			if tt.wantErr {
+				require.Error(t, err)
+				if tt.wantErrIs != nil {
+					assert.ErrorIs(t, err, tt.wantErrIs)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &service.CatalogProduct{ID: 1, Name: "Mug", CategoryID: 3}, product)
+		})
+	}
+}
+
+func TestCatalogService_Batches(t *testing.T) {
+	ctx := context.Background()
+	svc, repos := newCatalogService(t)
+
+	repos.product.EXPECT().ListSKUsBySPUIDs(gomock.Any(), []uint64{1, 2, 3}).Return([]model.SKU{
+		{Base: model.Base{ID: 11}, SPUID: 1, Price: decimal.RequireFromString("4.50"), Stock: 3},
+		{Base: model.Base{ID: 12}, SPUID: 1, Price: decimal.RequireFromString("5"), Stock: 0},
+		{Base: model.Base{ID: 21}, SPUID: 2, Price: decimal.RequireFromString("9.99"), Stock: 1},
+	}, nil)
+	skus, err := svc.SKUsByProductIDs(ctx, []uint64{1, 2, 3})
+	require.NoError(t, err)
+	assert.Len(t, skus[1], 2)
+	assert.Equal(t, uint64(21), skus[2][0].ID)
+	assert.Empty(t, skus[3])
+
+	repos.review.EXPECT().ListBySPUIDs(gomock.Any(), []uint64{1, 2}, 5).Return([]model.Review{
+		{Base: model.Base{ID: 7}, SPUID: 2, UserID: 9, Rating: 4, Comment: "good"},
+	}, nil)
+	reviews, err := svc.ReviewsByProductIDs(ctx, []uint64{1, 2}, 5)
+	require.NoError(t, err)
+	assert.Empty(t, reviews[1])
+	require.Len(t, reviews[2], 1)
+	assert.Equal(t, service.ReviewResp{ID: 7, ProductID: 2, UserID: 9, Rating: 4, Comment: "good"}, reviews[2][0])
+
+	repos.category.EXPECT().GetByIDs(gomock.Any(), []uint64{3, 4}).Return([]model.Category{
+		{Base: model.Base{ID: 3}, Name: "Kitchen"},
+	}, nil)
+	categories, err := svc.CategoriesByIDs(ctx, []uint64{3, 4})
+	require.NoError(t, err)
+	assert.Equal(t, map[uint64]service.CategoryResp{3: {ID: 3, Name: "Kitchen"}}, categories)
+
+	repos.user.EXPECT().GetByIDs(gomock.Any(), []uint64{9}).Return([]model.User{
+		{Base: model.Base{ID: 9}, Username: "alice", Email: "alice@example.com"},
+	}, nil)
+	usernames, err := svc.UsernamesByIDs(ctx, []uint64{9})
+	require.NoError(t, err)
+	assert.Equal(t, map[uint64]string{9: "alice"}, usernames)
+
+	repos.user.EXPECT().GetByIDs(gomock.Any(), []uint64{9}).Return(nil, errors.New("db down"))
+	_, err = svc.UsernamesByIDs(ctx, []uint64{9})
+	assert.Error(t, err)
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service/payment"
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/proyuen/go-mall/pkg/tenant"
+)
+
+// Checkout saga defaults, used where callers leave a value zero.
+const (
+	DefaultCheckoutSagaBatchSize   = 50
+	DefaultCheckoutSagaMaxAttempts = 8
+)
+
+const (
+	// checkoutSagaLease is how long ResumeCheckoutSagas leaves alone a saga
+	// that was just started or moved on, so that it never runs twice at once.
+	checkoutSagaLease = 2 * time.Minute
+	// checkoutSagaRetryBase is the delay before the second attempt at a step;
+	// it doubles per attempt up to checkoutSagaRetryMax.
+	checkoutSagaRetryBase = 30 * time.Second
+	checkoutSagaRetryMax  = time.Hour
+	// maxSagaError bounds the error recorded with a saga.
+	maxSagaError = 255
+)
+
+// CheckoutSagaOptions controls one ResumeCheckoutSagas run.
+type CheckoutSagaOptions struct {
+	BatchSize int
+	// MaxAttempts is how many times a step is tried before the saga gives
+	// up on it: a charge that keeps failing is compensated, other steps
+	// leave the saga failed.
+	MaxAttempts int
+}
+
+// CheckoutSagaReport summarises one ResumeCheckoutSagas run.
+type CheckoutSagaReport struct {
+	Resumed     int
+	Completed   int
+	Compensated int
+	Retrying    int // Stopped at a failed step with another attempt scheduled
+	Failed      int // Out of attempts; see model.SagaStatusFailed
+}
+
+// A checkout paid with a saved payment method runs as a saga of three steps,
+// each committed on its own, since no transaction spans the payment provider:
+//
+//  1. reserve stock: placeOrder deducts stock and creates the pending order,
+//     in the transaction that records the saga;
+//  2. charge payment: the saved method is charged, or authorized with
+//     PaymentCaptureShipment, and the payment recorded with the saga;
+//  3. confirm order: the order is marked paid, in the transaction that
+//     completes the saga.
+//
+// A declined payment compensates the steps that ran, last first: the
+// payment is refunded, or its authorization voided, then the order cancelled
+// and its stock released. A step that fails otherwise is retried by
+// ResumeCheckoutSagas with backoff. Charges and refunds are keyed by the
+// order number, so running a step again never charges or refunds twice.
+
+// startCheckoutSaga records the saga of an order placed with method, in the
+// transaction that reserved its stock.
+func (s *orderService) startCheckoutSaga(txCtx context.Context, order *model.Order, method *model.PaymentMethod) (*model.SagaState, error) {
+	saga := &model.SagaState{
+		StoreID:         tenant.StoreID(txCtx),
+		Type:            model.SagaTypeCheckout,
+		OrderID:         order.ID,
+		PaymentMethodID: method.ID,
+		Step:            model.SagaStepChargePayment,
+		Status:          model.SagaStatusRunning,
+		NextAttemptAt:   time.Now().Add(checkoutSagaLease),
+	}
+	if err := s.sagas.Create(txCtx, saga); err != nil {
+		return nil, err
+	}
+	return saga, nil
+}
+
+// ResumeCheckoutSagas runs the sagas that are due, oldest first: those that
+// stopped halfway, and those waiting to retry a step. A saga that cannot be
+// loaded or recorded does not stop the others; the report is returned even
+// on error.
+func (s *orderService) ResumeCheckoutSagas(ctx context.Context, opts CheckoutSagaOptions) (*CheckoutSagaReport, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultCheckoutSagaBatchSize
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultCheckoutSagaMaxAttempts
+	}
+
+	report := &CheckoutSagaReport{}
+	var errs []error
+	// A saga whose progress could not be recorded is still due; it is not
+	// run again until the next run.
+	seen := make(map[uint64]bool)
+	for {
+		sagas, err := s.sagas.ListDue(ctx, time.Now(), opts.BatchSize)
+		if err != nil {
+			return report, errors.Join(append(errs, err)...)
+		}
+
+		fresh := 0
+		for i := range sagas {
+			saga := &sagas[i]
+			if seen[saga.ID] {
+				continue
+			}
+			seen[saga.ID] = true
+			fresh++
+			if err := ctx.Err(); err != nil {
+				return report, errors.Join(append(errs, err)...)
+			}
+			if err := s.resumeCheckoutSaga(ctx, saga, opts.MaxAttempts); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			report.Resumed++
+			switch saga.Status {
+			case model.SagaStatusCompleted:
+				report.Completed++
+			case model.SagaStatusCompensated:
+				report.Compensated++
+			case model.SagaStatusFailed:
+				report.Failed++
+			default:
+				report.Retrying++
+			}
+		}
+
+		if len(sagas) < opts.BatchSize || fresh == 0 {
+			return report, errors.Join(errs...)
+		}
+	}
+}
+
+// resumeCheckoutSaga loads the order and payment method of saga and runs it
+// in the order's store. It returns an error only if the saga could not be
+// loaded or its progress recorded.
+func (s *orderService) resumeCheckoutSaga(ctx context.Context, saga *model.SagaState, maxAttempts int) error {
+	if saga.StoreID != 0 {
+		ctx = tenant.NewContext(ctx, &model.Store{Base: model.Base{ID: saga.StoreID}})
+	}
+	order, err := s.orderRepo.GetByID(ctx, saga.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to load order of checkout saga %d: %w", saga.ID, err)
+	}
+	var method *model.PaymentMethod
+	if saga.Status == model.SagaStatusRunning && saga.Step == model.SagaStepChargePayment && s.payments != nil {
+		method, err = s.payments.Get(ctx, order.UserID, saga.PaymentMethodID)
+		if err != nil && !errors.Is(err, ErrPaymentMethodNotFound) {
+			return fmt.Errorf("failed to load payment method of checkout saga %d: %w", saga.ID, err)
+		}
+	}
+	_, err = s.runCheckoutSaga(ctx, saga, order, method, maxAttempts)
+	return err
+}
+
+// runCheckoutSaga runs saga from its step until it completes, is
+// compensated, or a step fails, and updates order to match. method is the
+// payment method to charge; nil compensates a saga that was not charged yet.
+// It returns the error of the step that failed, which is scheduled for
+// another attempt, and separately any error recording the saga.
+func (s *orderService) runCheckoutSaga(ctx context.Context, saga *model.SagaState, order *model.Order, method *model.PaymentMethod, maxAttempts int) (stepErr, err error) {
+	for {
+		switch {
+		case saga.Status == model.SagaStatusRunning && saga.Step == model.SagaStepChargePayment:
+			stepErr = s.chargeCheckout(ctx, saga, order, method)
+		case saga.Status == model.SagaStatusRunning && saga.Step == model.SagaStepConfirmOrder:
+			stepErr = s.confirmCheckout(ctx, saga, order)
+		case saga.Status == model.SagaStatusCompensating && saga.Step == model.SagaStepChargePayment:
+			stepErr = s.refundCheckout(ctx, saga, order)
+		case saga.Status == model.SagaStatusCompensating && saga.Step == model.SagaStepReserveStock:
+			stepErr = s.releaseCheckout(ctx, saga, order)
+		default:
+			return nil, nil
+		}
+		if stepErr != nil {
+			return stepErr, s.retryCheckoutSaga(ctx, saga, stepErr, maxAttempts)
+		}
+	}
+}
+
+// chargeCheckout charges the order, or authorizes it with
+// PaymentCaptureShipment unless it has digital items, which are sent as soon
+// as it is paid rather than shipped. A declined payment compensates the
+// saga. An order that is no longer pending, e.g. cancelled as it expired,
+// is not charged.
+func (s *orderService) chargeCheckout(ctx context.Context, saga *model.SagaState, order *model.Order, method *model.PaymentMethod) error {
+	if method == nil {
+		return s.compensateCheckout(ctx, saga, model.SagaStepReserveStock, ErrPaymentMethodNotFound)
+	}
+	if order.Status != model.OrderStatusPending {
+		return s.compensateCheckout(ctx, saga, model.SagaStepReserveStock, repository.ErrOrderStatusChanged)
+	}
+
+	authorize := s.captureOnShipment && len(digitalItems(order.Items)) == 0
+	var charge *payment.Charge
+	var err error
+	if authorize {
+		charge, err = s.payments.Authorize(ctx, method, order)
+	} else {
+		charge, err = s.payments.Charge(ctx, method, order)
+	}
+	if errors.Is(err, ErrPaymentDeclined) {
+		return s.compensateCheckout(ctx, saga, model.SagaStepReserveStock, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	// The payment is recorded before the order is confirmed, so that it is
+	// refunded if the order never is.
+	saga.Step, saga.Authorized, saga.PaymentProvider, saga.PaymentRef = model.SagaStepConfirmOrder, authorize, method.Provider, charge.ID
+	if err := s.saveCheckoutSaga(ctx, saga); err != nil {
+		// Charging again returns the same payment
+		return fmt.Errorf("failed to record %s payment %s: %w", method.Provider, charge.ID, err)
+	}
+	return nil
+}
+
+// confirmCheckout marks the order paid, or authorized, and completes the
+// saga in one transaction. The payment is refunded if the order is no longer
+// pending.
+func (s *orderService) confirmCheckout(ctx context.Context, saga *model.SagaState, order *model.Order) error {
+	done := *saga
+	done.Status, done.Attempts, done.LastError = model.SagaStatusCompleted, 0, ""
+	err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		if saga.Authorized {
+			if err := s.orderRepo.MarkAuthorized(txCtx, order.ID, saga.PaymentProvider, saga.PaymentRef); err != nil {
+				return err
+			}
+			authorized := *order
+			authorized.Status = model.OrderStatusAuthorized
+			if err := s.events.Publish(txCtx, EventOrderUpdated, newOrderWebhookData(&authorized, order.Items)); err != nil {
+				return fmt.Errorf("failed to publish order event: %w", err)
+			}
+		} else if err := recordOrderPaid(txCtx, s.orderRepo, s.webhooks, s.events, order, order.Items, saga.PaymentProvider, saga.PaymentRef); err != nil {
+			return err
+		}
+		return s.sagas.Save(txCtx, &done)
+	})
+	if errors.Is(err, repository.ErrOrderStatusChanged) {
+		return s.compensateCheckout(ctx, saga, model.SagaStepChargePayment, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to confirm order after %s payment %s: %w", saga.PaymentProvider, saga.PaymentRef, err)
+	}
+	*saga = done
+	order.PaymentProvider, order.PaymentRef = saga.PaymentProvider, saga.PaymentRef
+	if saga.Authorized {
+		order.Status = model.OrderStatusAuthorized
+	} else {
+		order.Status = paidStatus(order.Items)
+		ordersPaid.WithLabelValues(saga.PaymentProvider).Inc()
+	}
+	return nil
+}
+
+// refundCheckout gives the payment back: a charge is refunded, an
+// authorization voided.
+func (s *orderService) refundCheckout(ctx context.Context, saga *model.SagaState, order *model.Order) error {
+	if s.payments == nil {
+		return fmt.Errorf("no payment provider to refund %s payment %s", saga.PaymentProvider, saga.PaymentRef)
+	}
+	var err error
+	if saga.Authorized {
+		err = s.payments.Void(ctx, saga.PaymentRef)
+	} else {
+		err = s.payments.Refund(ctx, order, saga.PaymentRef)
+	}
+	if err != nil {
+		return err
+	}
+	saga.Step = model.SagaStepReserveStock
+	return s.saveCheckoutSaga(ctx, saga)
+}
+
+// releaseCheckout cancels the order and gives back its stock, in the
+// transaction that marks the saga compensated. An order that left the
+// pending status meanwhile is left as it is: cancelled, it released its
+// stock already.
+func (s *orderService) releaseCheckout(ctx context.Context, saga *model.SagaState, order *model.Order) error {
+	done := *saga
+	done.Status, done.Attempts = model.SagaStatusCompensated, 0
+	released := true
+	err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		err := s.cancelPendingOrder(txCtx, order)
+		if errors.Is(err, repository.ErrOrderStatusChanged) {
+			released = false
+		} else if err != nil {
+			return err
+		}
+		return s.sagas.Save(txCtx, &done)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cancel order %d: %w", order.ID, err)
+	}
+	*saga = done
+	if released {
+		order.Status = model.OrderStatusCancelled
+		ordersCancelled.WithLabelValues(cancelReasonPayment).Inc()
+		releaseOrderPurchases(ctx, s.purchases, order)
+		publishStockChanged(ctx, s.catalog, stockedItems(order.Items))
+	}
+	return nil
+}
+
+// compensateCheckout turns saga around to compensate step and the steps
+// before it, because of cause.
+func (s *orderService) compensateCheckout(ctx context.Context, saga *model.SagaState, step string, cause error) error {
+	saga.Status, saga.Step, saga.LastError = model.SagaStatusCompensating, step, truncateSagaError(cause.Error())
+	return s.saveCheckoutSaga(ctx, saga)
+}
+
+// saveCheckoutSaga records that saga moved on, leasing it to the caller.
+func (s *orderService) saveCheckoutSaga(ctx context.Context, saga *model.SagaState) error {
+	saga.Attempts, saga.NextAttemptAt = 0, time.Now().Add(checkoutSagaLease)
+	return s.sagas.Save(ctx, saga)
+}
+
+// retryCheckoutSaga records a failed attempt at the saga's step and
+// schedules the next one. Out of attempts, or failing with an error that
+// apperr says is not retryable, a saga that has not charged yet is
+// compensated, and any other fails.
+func (s *orderService) retryCheckoutSaga(ctx context.Context, saga *model.SagaState, stepErr error, maxAttempts int) error {
+	saga.Attempts++
+	saga.LastError = truncateSagaError(stepErr.Error())
+	switch {
+	case saga.Attempts < maxAttempts && apperr.Retryable(stepErr):
+		saga.NextAttemptAt = time.Now().Add(checkoutSagaBackoff(saga.Attempts))
+	case saga.Status == model.SagaStatusRunning && saga.Step == model.SagaStepChargePayment:
+		saga.Status, saga.Step, saga.Attempts, saga.NextAttemptAt = model.SagaStatusCompensating, model.SagaStepReserveStock, 0, time.Now()
+	default:
+		saga.Status = model.SagaStatusFailed
+	}
+	// The step may have reached the provider, so its outcome is recorded
+	// even if the run is being cancelled.
+	return s.sagas.Save(context.WithoutCancel(ctx), saga)
+}
+
+// checkoutSagaBackoff is the delay after the given number of failed attempts.
+func checkoutSagaBackoff(attempts int) time.Duration {
+	delay := checkoutSagaRetryBase
+	for i := 1; i < attempts && delay < checkoutSagaRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, checkoutSagaRetryMax)
+}
+
+func truncateSagaError(s string) string {
+	if len(s) <= maxSagaError {
+		return s
+	}
+	return s[:maxSagaError]
+}
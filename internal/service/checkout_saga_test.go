@@ -0,0 +1,236 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/tenant"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOrderService_ResumeCheckoutSagas(t *testing.T) {
+	method := &model.PaymentMethod{Base: model.Base{ID: 9}, UserID: 1, Provider: "stripe"}
+	newOrder := func() *model.Order {
+		return &model.Order{
+			Base:        model.Base{ID: 42},
+			UserID:      1,
+			OrderNumber: "ORD-42",
+			Status:      model.OrderStatusPending,
+			TotalAmount: decimal.NewFromInt(100),
+			Currency:    "USD",
+			Items:       []model.OrderItem{{SKUID: 101, Quantity: 2}},
+		}
+	}
+	running := func(step string) model.SagaState {
+		saga := model.SagaState{StoreID: 3, Type: model.SagaTypeCheckout, OrderID: 42, PaymentMethodID: 9, Step: step, Status: model.SagaStatusRunning}
+		saga.ID = 7
+		if step == model.SagaStepConfirmOrder {
+			saga.PaymentProvider, saga.PaymentRef = "stripe", "pi_1"
+		}
+		return saga
+	}
+
+	tests := []struct {
+		name      string
+		saga      model.SagaState
+		mockSetup func(payments *mocks.MockPaymentMethodService, orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository)
+		want      service.CheckoutSagaReport
+		wantSaga  model.SagaState // Step, Status and Attempts
+	}{
+		{
+			name: "ConfirmsChargedOrder",
+			saga: running(model.SagaStepConfirmOrder),
+			mockSetup: func(_ *mocks.MockPaymentMethodService, orderRepo *mocks.MockOrderRepository, _ *mocks.MockProductRepository) {
+				orderRepo.EXPECT().MarkPaid(gomock.Any(), uint64(42), "stripe", "pi_1").Return(nil)
+			},
+			want:     service.CheckoutSagaReport{Resumed: 1, Completed: 1},
+			wantSaga: model.SagaState{Step: model.SagaStepConfirmOrder, Status: model.SagaStatusCompleted},
+		},
+		{
+			name: "RetriesCharge",
+			saga: running(model.SagaStepChargePayment),
+			mockSetup: func(payments *mocks.MockPaymentMethodService, _ *mocks.MockOrderRepository, _ *mocks.MockProductRepository) {
+				payments.EXPECT().Get(gomock.Any(), uint64(1), uint64(9)).Return(method, nil)
+				payments.EXPECT().Charge(gomock.Any(), method, gomock.Any()).Return(nil, errors.New("stripe returned status 503"))
+			},
+			want:     service.CheckoutSagaReport{Resumed: 1, Retrying: 1},
+			wantSaga: model.SagaState{Step: model.SagaStepChargePayment, Status: model.SagaStatusRunning, Attempts: 1},
+		},
+		{
+			name: "GivesUpCharging",
+			saga: func() model.SagaState {
+				saga := running(model.SagaStepChargePayment)
+				saga.Attempts = 2
+				return saga
+			}(),
+			mockSetup: func(payments *mocks.MockPaymentMethodService, _ *mocks.MockOrderRepository, _ *mocks.MockProductRepository) {
+				payments.EXPECT().Get(gomock.Any(), uint64(1), uint64(9)).Return(method, nil)
+				payments.EXPECT().Charge(gomock.Any(), method, gomock.Any()).Return(nil, errors.New("stripe returned status 503"))
+			},
+			// The stock is given back by the next run
+			want:     service.CheckoutSagaReport{Resumed: 1, Retrying: 1},
+			wantSaga: model.SagaState{Step: model.SagaStepReserveStock, Status: model.SagaStatusCompensating},
+		},
+		{
+			name: "GivesUpChargingOnPermanentError",
+			saga: running(model.SagaStepChargePayment),
+			mockSetup: func(payments *mocks.MockPaymentMethodService, _ *mocks.MockOrderRepository, _ *mocks.MockProductRepository) {
+				payments.EXPECT().Get(gomock.Any(), uint64(1), uint64(9)).Return(method, nil)
+				// Removed after it was loaded; charging it again cannot succeed
+				payments.EXPECT().Charge(gomock.Any(), method, gomock.Any()).Return(nil, fmt.Errorf("failed to charge: %w", service.ErrPaymentMethodNotFound))
+			},
+			want:     service.CheckoutSagaReport{Resumed: 1, Retrying: 1},
+			wantSaga: model.SagaState{Step: model.SagaStepReserveStock, Status: model.SagaStatusCompensating},
+		},
+		{
+			name: "MethodRemoved",
+			saga: running(model.SagaStepChargePayment),
+			mockSetup: func(payments *mocks.MockPaymentMethodService, orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository) {
+				payments.EXPECT().Get(gomock.Any(), uint64(1), uint64(9)).Return(nil, service.ErrPaymentMethodNotFound)
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(42), model.OrderStatusPending, model.OrderStatusCancelled).Return(nil)
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), 2).Return(nil)
+			},
+			want:     service.CheckoutSagaReport{Resumed: 1, Compensated: 1},
+			wantSaga: model.SagaState{Step: model.SagaStepReserveStock, Status: model.SagaStatusCompensated},
+		},
+		{
+			name: "VoidsAuthorization",
+			saga: func() model.SagaState {
+				saga := running(model.SagaStepChargePayment)
+				saga.Status, saga.Authorized, saga.PaymentRef = model.SagaStatusCompensating, true, "pi_1"
+				return saga
+			}(),
+			mockSetup: func(payments *mocks.MockPaymentMethodService, orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository) {
+				payments.EXPECT().Void(gomock.Any(), "pi_1").Return(nil)
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(42), model.OrderStatusPending, model.OrderStatusCancelled).Return(nil)
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), 2).Return(nil)
+			},
+			want:     service.CheckoutSagaReport{Resumed: 1, Compensated: 1},
+			wantSaga: model.SagaState{Step: model.SagaStepReserveStock, Status: model.SagaStatusCompensated},
+		},
+		{
+			name: "RefundFailsForGood",
+			saga: func() model.SagaState {
+				saga := running(model.SagaStepChargePayment)
+				saga.Status, saga.PaymentRef, saga.Attempts = model.SagaStatusCompensating, "pi_1", 2
+				return saga
+			}(),
+			mockSetup: func(payments *mocks.MockPaymentMethodService, _ *mocks.MockOrderRepository, _ *mocks.MockProductRepository) {
+				payments.EXPECT().Refund(gomock.Any(), gomock.Any(), "pi_1").Return(errors.New("charge already refunded"))
+			},
+			want:     service.CheckoutSagaReport{Resumed: 1, Failed: 1},
+			wantSaga: model.SagaState{Step: model.SagaStepChargePayment, Status: model.SagaStatusFailed, Attempts: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			}).AnyTimes()
+			webhooks := mocks.NewMockWebhookEmitter(ctrl)
+			webhooks.EXPECT().Emit(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			events := mocks.NewMockEventPublisher(ctrl)
+			events.EXPECT().Publish(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			payments := mocks.NewMockPaymentMethodService(ctrl)
+			sagas := mocks.NewMockSagaRepository(ctrl)
+
+			saga := tt.saga
+			sagas.EXPECT().ListDue(gomock.Any(), gomock.Any(), 2).Return([]model.SagaState{saga}, nil)
+			orderRepo.EXPECT().GetByID(gomock.Any(), uint64(42)).DoAndReturn(func(ctx context.Context, _ uint64) (*model.Order, error) {
+				assert.Equal(t, uint64(3), tenant.StoreID(ctx), "the saga runs in the order's store")
+				return newOrder(), nil
+			})
+			sagas.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, s *model.SagaState) error {
+				saga = *s
+				return nil
+			}).AnyTimes()
+			tt.mockSetup(payments, orderRepo, productRepo)
+
+			orderService := service.NewOrderService(orderRepo, productRepo, txManager, webhooks, events, nil, nil, nil, payments, sagas, nil, nil, nil, nil, service.OrderOptions{})
+			report, err := orderService.ResumeCheckoutSagas(context.Background(), service.CheckoutSagaOptions{BatchSize: 2, MaxAttempts: 3})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *report)
+			assert.Equal(t, tt.wantSaga.Step, saga.Step)
+			assert.Equal(t, tt.wantSaga.Status, saga.Status)
+			assert.Equal(t, tt.wantSaga.Attempts, saga.Attempts)
+			if saga.Status == model.SagaStatusRunning {
+				assert.True(t, saga.NextAttemptAt.After(time.Now()), "the retry waits")
+			}
+		})
+	}
+
+	t.Run("ListFails", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		sagas := mocks.NewMockSagaRepository(ctrl)
+		sagas.EXPECT().ListDue(gomock.Any(), gomock.Any(), service.DefaultCheckoutSagaBatchSize).Return(nil, errors.New("db down"))
+
+		report, err := service.NewOrderService(nil, nil, nil, nil, nil, nil, nil, nil, nil, sagas, nil, nil, nil, nil, service.OrderOptions{}).ResumeCheckoutSagas(context.Background(), service.CheckoutSagaOptions{})
+		assert.EqualError(t, err, "db down")
+		assert.Equal(t, service.CheckoutSagaReport{}, *report)
+	})
+
+	t.Run("OrderLoadFails", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		orderRepo := mocks.NewMockOrderRepository(ctrl)
+		sagas := mocks.NewMockSagaRepository(ctrl)
+		saga := running(model.SagaStepConfirmOrder)
+		sagas.EXPECT().ListDue(gomock.Any(), gomock.Any(), 1).Return([]model.SagaState{saga}, nil).Times(2)
+		orderRepo.EXPECT().GetByID(gomock.Any(), uint64(42)).Return(nil, repository.ErrOrderNotFound)
+
+		// The saga stays due, but is not tried twice in one run
+		report, err := service.NewOrderService(orderRepo, nil, nil, nil, nil, nil, nil, nil, nil, sagas, nil, nil, nil, nil, service.OrderOptions{}).ResumeCheckoutSagas(context.Background(), service.CheckoutSagaOptions{BatchSize: 1})
+		assert.ErrorIs(t, err, repository.ErrOrderNotFound)
+		assert.Equal(t, 0, report.Resumed)
+	})
+}
+
+// An order cancelled while it was authorized has its authorization voided.
+func TestOrderService_ResumeCheckoutSagasCancelledOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	orderRepo := mocks.NewMockOrderRepository(ctrl)
+	txManager := mocks.NewMockTransactionManager(ctrl)
+	txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	}).AnyTimes()
+	payments := mocks.NewMockPaymentMethodService(ctrl)
+	sagas := mocks.NewMockSagaRepository(ctrl)
+
+	saga := model.SagaState{Type: model.SagaTypeCheckout, OrderID: 42, Step: model.SagaStepConfirmOrder, Status: model.SagaStatusRunning, Authorized: true, PaymentProvider: "stripe", PaymentRef: "pi_1"}
+	sagas.EXPECT().ListDue(gomock.Any(), gomock.Any(), gomock.Any()).Return([]model.SagaState{saga}, nil)
+	orderRepo.EXPECT().GetByID(gomock.Any(), uint64(42)).Return(&model.Order{Base: model.Base{ID: 42}, Status: model.OrderStatusCancelled}, nil)
+	gomock.InOrder(
+		orderRepo.EXPECT().MarkAuthorized(gomock.Any(), uint64(42), "stripe", "pi_1").Return(repository.ErrOrderStatusChanged),
+		sagas.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, s *model.SagaState) error {
+			assert.Equal(t, model.SagaStatusCompensating, s.Status)
+			assert.Equal(t, model.SagaStepChargePayment, s.Step)
+			return nil
+		}),
+		payments.EXPECT().Void(gomock.Any(), "pi_1").Return(nil),
+		sagas.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil),
+		orderRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(42), model.OrderStatusPending, model.OrderStatusCancelled).Return(repository.ErrOrderStatusChanged),
+		sagas.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, s *model.SagaState) error {
+			assert.Equal(t, model.SagaStatusCompensated, s.Status)
+			return nil
+		}),
+	)
+
+	orderService := service.NewOrderService(orderRepo, nil, txManager, nil, nil, nil, nil, nil, payments, sagas, nil, nil, nil, nil, service.OrderOptions{})
+	report, err := orderService.ResumeCheckoutSagas(context.Background(), service.CheckoutSagaOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Compensated)
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultCheckoutSessionTTL is how long a checkout session holds its prices
+// when order.checkout_session_ttl is zero.
+const DefaultCheckoutSessionTTL = 15 * time.Minute
+
+// ErrCheckoutSessionNotFound means the checkout session expired, was
+// confirmed already, or belongs to another user.
+var ErrCheckoutSessionNotFound = apperr.New(apperr.CodeCheckoutSessionExpired)
+
+// claimCheckoutSession returns the session in KEYS[1] and deletes it, so that
+// only one confirmation places its order.
+var claimCheckoutSession = redis.NewScript(`
+local session = redis.call("GET", KEYS[1])
+if session then
+	redis.call("DEL", KEYS[1])
+end
+return session
+`)
+
+// CheckoutSessionResp is an order previewed at checkout. Its prices and totals
+// hold until ExpiresAt; stock is only reserved once it is confirmed.
+type CheckoutSessionResp struct {
+	ID              string             `json:"id" example:"0b5c7a0e-2f4d-4a8e-9a7c-3c1d2e4f5a6b"`
+	Currency        string             `json:"currency" example:"USD"`
+	Region          string             `json:"region" example:"DE"`
+	Items           []CheckoutItemResp `json:"items"`
+	Subtotal        money.Money        `json:"subtotal"`
+	Discount        money.Money        `json:"discount"` // Taken off the subtotal by Promotions
+	TaxAmount       money.Money        `json:"tax_amount"`
+	TotalAmount     money.Money        `json:"total_amount"` // Subtotal less discount, plus tax
+	TaxLines        []TaxLine          `json:"tax_lines"`
+	Promotions      []AppliedPromotion `json:"promotions"` // Each with what it took off and why
+	PaymentMethodID uint64             `json:"payment_method_id,string,omitempty"`
+	ExpiresAt       time.Time          `json:"expires_at"`
+	AddressWarnings []AddressWarning   `json:"address_warnings,omitempty"` // See OrderCreateResp
+}
+
+type CheckoutItemResp struct {
+	SKUID    uint64      `json:"sku_id,string"`
+	Quantity int         `json:"quantity"`
+	Price    money.Money `json:"price"`    // Unit price
+	Discount money.Money `json:"discount"` // Taken off the line by promotions
+	// Options are the product options chosen for every unit, included in
+	// Price.
+	Options []OrderItemOptionResp `json:"options,omitempty"`
+}
+
+// checkoutSession is what a checkout session freezes, stored as JSON.
+type checkoutSession struct {
+	UserID          uint64                 `json:"user_id"`
+	Currency        string                 `json:"currency"`
+	Region          string                 `json:"region"`
+	ShippingAddress model.Address          `json:"shipping_address"`
+	AddressWarnings []AddressWarning       `json:"address_warnings,omitempty"`
+	Items           []model.OrderItem      `json:"items"`
+	TaxLines        []model.OrderTaxLine   `json:"tax_lines"`
+	Promotions      []model.OrderPromotion `json:"promotions"`
+	PurchaseLimits  []PurchaseLimit        `json:"purchase_limits"` // Enforced when the session is confirmed
+	PaymentMethodID uint64                 `json:"payment_method_id"`
+	ExpiresAt       time.Time              `json:"expires_at"`
+}
+
+// checkoutSessionKey is scoped to the store, so that a session is only
+// confirmed in the store whose prices it holds.
+func checkoutSessionKey(ctx context.Context, id string) string {
+	return storeCacheKey(ctx, "mall:checkout:session:"+id)
+}
+
+func (s *orderService) CreateCheckoutSession(ctx context.Context, req *OrderCreateReq) (*CheckoutSessionResp, error) {
+	if _, err := s.paymentMethod(ctx, req.UserID, req.PaymentMethodID); err != nil {
+		return nil, err
+	}
+	quote, err := s.quote(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkExpectedPrices(req, quote); err != nil {
+		return nil, err
+	}
+
+	id := uuid.NewString()
+	session := &checkoutSession{
+		UserID:          req.UserID,
+		Currency:        quote.currency,
+		Region:          quote.region,
+		ShippingAddress: quote.address,
+		AddressWarnings: quote.addressWarnings,
+		Items:           quote.items,
+		TaxLines:        quote.taxLines,
+		Promotions:      quote.promotions,
+		PurchaseLimits:  quote.limits,
+		PaymentMethodID: req.PaymentMethodID,
+		ExpiresAt:       time.Now().Add(s.checkoutSessionTTL),
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode checkout session: %w", err)
+	}
+	if err := s.cache.Set(ctx, checkoutSessionKey(ctx, id), data, s.checkoutSessionTTL); err != nil {
+		return nil, fmt.Errorf("failed to save checkout session: %w", err)
+	}
+	return newCheckoutSessionResp(id, session, quote), nil
+}
+
+func (s *orderService) GetCheckoutSession(ctx context.Context, userID uint64, id string) (*CheckoutSessionResp, error) {
+	data, err := s.cache.Get(ctx, checkoutSessionKey(ctx, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checkout session: %w", err)
+	}
+	if data == "" {
+		return nil, ErrCheckoutSessionNotFound
+	}
+	session, quote, err := decodeCheckoutSession(data, userID)
+	if err != nil {
+		return nil, err
+	}
+	return newCheckoutSessionResp(id, session, quote), nil
+}
+
+// ConfirmCheckoutSession places the order at the session's prices, however
+// the SKUs were repriced since. The session is claimed first, so that a
+// double submit places one order; it is put back when placing the order
+// fails, e.g. for lack of stock, until it expires.
+func (s *orderService) ConfirmCheckoutSession(ctx context.Context, userID uint64, id string) (resp *OrderCreateResp, err error) {
+	defer func() { recordCheckoutFailure(err) }()
+
+	key := checkoutSessionKey(ctx, id)
+	claimed, err := s.cache.Eval(ctx, claimCheckoutSession, []string{key})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim checkout session: %w", err)
+	}
+	data, ok := claimed.(string)
+	if !ok {
+		return nil, ErrCheckoutSessionNotFound
+	}
+	session, quote, err := decodeCheckoutSession(data, userID)
+	if errors.Is(err, ErrCheckoutSessionNotFound) {
+		// Another user's session: leave it for them
+		s.restoreCheckoutSession(ctx, key, data, session)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	method, err := s.paymentMethod(ctx, userID, session.PaymentMethodID)
+	if err == nil {
+		if resp, err = s.placeOrder(ctx, userID, quote, method); err == nil {
+			return resp, nil
+		}
+	}
+	s.restoreCheckoutSession(ctx, key, data, session)
+	return nil, err
+}
+
+// restoreCheckoutSession puts a claimed session back for the rest of its
+// lifetime.
+func (s *orderService) restoreCheckoutSession(ctx context.Context, key, data string, session *checkoutSession) {
+	ttl := time.Until(session.ExpiresAt)
+	if ttl <= 0 {
+		return
+	}
+	if err := s.cache.Set(context.WithoutCancel(ctx), key, data, ttl); err != nil {
+		slog.WarnContext(ctx, "Failed to restore checkout session", logger.Err(err))
+	}
+}
+
+// decodeCheckoutSession decodes a stored session of userID and totals it.
+func decodeCheckoutSession(data string, userID uint64) (*checkoutSession, *orderQuote, error) {
+	var session checkoutSession
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode checkout session: %w", err)
+	}
+	if session.UserID != userID {
+		return &session, nil, ErrCheckoutSessionNotFound
+	}
+	quote, err := newOrderQuote(session.Currency, session.Region, session.Items, session.TaxLines, session.Promotions)
+	if err != nil {
+		return nil, nil, err
+	}
+	quote.address, quote.addressWarnings = session.ShippingAddress, session.AddressWarnings
+	quote.limits = session.PurchaseLimits
+	return &session, quote, nil
+}
+
+func newCheckoutSessionResp(id string, session *checkoutSession, quote *orderQuote) *CheckoutSessionResp {
+	lines := customerItems(quote.items)
+	items := make([]CheckoutItemResp, len(lines))
+	for i, item := range lines {
+		items[i] = CheckoutItemResp{
+			SKUID:    item.SKUID,
+			Quantity: item.Quantity,
+			Price:    money.New(item.Price, quote.currency),
+			Discount: money.New(item.Discount, quote.currency),
+			Options:  newOrderItemOptionResps(quote.currency, item.Options),
+		}
+	}
+	return &CheckoutSessionResp{
+		ID:              id,
+		Currency:        quote.currency,
+		Region:          quote.region,
+		Items:           items,
+		Subtotal:        quote.subtotal,
+		Discount:        quote.discount,
+		TaxAmount:       quote.taxAmount,
+		TotalAmount:     quote.total,
+		TaxLines:        newTaxLines(quote.currency, quote.taxLines),
+		Promotions:      newAppliedPromotions(quote.currency, quote.promotions),
+		PaymentMethodID: session.PaymentMethodID,
+		ExpiresAt:       session.ExpiresAt,
+		AddressWarnings: quote.addressWarnings,
+	}
+}
@@ -0,0 +1,105 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOrderService_CheckoutSession(t *testing.T) {
+	tests := []struct {
+		name        string
+		userID      uint64
+		placeErr    error
+		wantRestore bool
+		errIs       error
+	}{
+		{name: "Confirmed", userID: 1},
+		{name: "OtherUser", userID: 2, wantRestore: true, errIs: service.ErrCheckoutSessionNotFound},
+		{name: "OutOfStock", userID: 1, placeErr: service.ErrInsufficientStock, wantRestore: true, errIs: service.ErrInsufficientStock},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			webhooks := mocks.NewMockWebhookEmitter(ctrl)
+			events := mocks.NewMockEventPublisher(ctrl)
+			events.EXPECT().Publish(gomock.Any(), service.EventOrderCreated, gomock.Any()).Return(nil).AnyTimes()
+			cache := mocks.NewMockCache(ctrl)
+
+			// The SKU is only looked up for the session: confirming it keeps its price
+			productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return([]model.SKU{{Base: model.Base{ID: 101}, Price: decimal.NewFromInt(50), Currency: "USD", Stock: 100}}, nil)
+			var stored []byte
+			cache.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), 10*time.Minute).DoAndReturn(func(_ context.Context, _ string, value any, _ time.Duration) error {
+				stored = value.([]byte)
+				return nil
+			})
+
+			currencies := service.NewCurrencyService(nil, nil, nil, service.CurrencyOptions{Base: "USD"})
+			orderService := service.NewOrderService(orderRepo, productRepo, txManager, webhooks, events, currencies, service.NewTaxService(nil), nil, nil, nil, cache, nil, nil, nil, service.OrderOptions{CheckoutSessionTTL: 10 * time.Minute})
+			session, err := orderService.CreateCheckoutSession(context.Background(), &service.OrderCreateReq{
+				UserID:   1,
+				Currency: "USD",
+				Items:    []service.OrderItemReq{{SKUID: 101, Quantity: 2}},
+			})
+			require.NoError(t, err)
+			assert.NotEmpty(t, session.ID)
+			assert.Equal(t, "100.00 USD", session.TotalAmount.String())
+			require.Len(t, session.Items, 1)
+			assert.Equal(t, "50.00 USD", session.Items[0].Price.String())
+
+			cache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Len(1)).Return(string(stored), nil)
+			if tt.userID == 1 {
+				txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+				if tt.placeErr != nil {
+					productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), -2).Return(tt.placeErr)
+				} else {
+					productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), -2).Return(nil)
+					productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(101)).Return(&model.SKU{Stock: 98}, nil)
+					orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, order *model.Order, items []model.OrderItem) error {
+						assert.Equal(t, uint64(1), order.UserID)
+						assert.Equal(t, "100", order.TotalAmount.String())
+						assert.Equal(t, "50", items[0].Price.String())
+						return nil
+					})
+					webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderCreated, gomock.Any()).Return(nil)
+					cache.EXPECT().Publish(gomock.Any(), service.CatalogEventsChannel, []byte(`{"sku_ids":[101]}`)).Return(nil)
+				}
+			}
+			if tt.wantRestore {
+				cache.EXPECT().Set(gomock.Any(), gomock.Any(), string(stored), gomock.Any()).Return(nil)
+			}
+
+			resp, err := orderService.ConfirmCheckoutSession(context.Background(), tt.userID, session.ID)
+			if tt.errIs != nil {
+				assert.ErrorIs(t, err, tt.errIs)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "100.00 USD", resp.TotalAmount.String())
+		})
+	}
+}
+
+func TestOrderService_ConfirmCheckoutSessionExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cache := mocks.NewMockCache(ctrl)
+	cache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	orderService := service.NewOrderService(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, cache, nil, nil, nil, service.OrderOptions{})
+	_, err := orderService.ConfirmCheckoutSession(context.Background(), 1, "gone")
+	assert.ErrorIs(t, err, service.ErrCheckoutSessionNotFound)
+}
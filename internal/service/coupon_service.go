@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+// Coupon code defaults, used where CouponOptions leaves a field zero.
+const (
+	DefaultCouponCodeLength = 8
+	// MaxCouponBatch is the most codes one GenerateCodes call creates.
+	MaxCouponBatch = 10000
+)
+
+const (
+	// couponCodeAlphabet leaves out 0, O, 1 and I, which are easily confused
+	// when a code is typed in. Its 32 characters divide a byte evenly.
+	couponCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	// couponCodeMaxLength is the length of model.Coupon's code column.
+	couponCodeMaxLength = 40
+	// couponCodeAttempts bounds the rounds of replacing codes already taken.
+	couponCodeAttempts = 5
+	// couponLookupBatchSize bounds the codes looked up per query.
+	couponLookupBatchSize = 1000
+	// couponExportPageSize is how many coupons ExportCodes loads per query.
+	couponExportPageSize = 1000
+)
+
+var (
+	// ErrCouponCampaignNotFound means the coupon campaign does not exist.
+	ErrCouponCampaignNotFound = errors.New("coupon campaign not found")
+	// ErrInvalidCouponCampaign means a campaign's promotion is not coupon-only.
+	ErrInvalidCouponCampaign = errors.New("invalid coupon campaign")
+	// ErrInvalidCouponBatch means a batch of codes cannot be generated as asked.
+	ErrInvalidCouponBatch = errors.New("invalid coupon batch")
+)
+
+var couponPrefixPattern = regexp.MustCompile(`^[A-Z0-9-]*$`)
+
+// CouponOptions configures a CouponService.
+type CouponOptions struct {
+	CodeLength int // Random characters after the prefix
+}
+
+// CouponCampaignCreateReq defines a coupon campaign.
+type CouponCampaignCreateReq struct {
+	Name        string
+	PromotionID uint64 // A coupon-only promotion
+}
+
+// CouponCampaignResp is a coupon campaign with its redemption stats, as of
+// the last rollup.
+type CouponCampaignResp struct {
+	ID             uint64          `json:"id,string"`
+	Name           string          `json:"name" example:"Newsletter June"`
+	PromotionID    uint64          `json:"promotion_id,string"`
+	Generated      int64           `json:"generated" example:"1000"`
+	Redeemed       int64           `json:"redeemed" example:"125"`
+	RedemptionRate decimal.Decimal `json:"redemption_rate" swaggertype:"string" example:"12.5"` // Percent of the generated codes redeemed
+	LastRedeemedAt *time.Time      `json:"last_redeemed_at,omitempty"`
+	RolledUpAt     *time.Time      `json:"rolled_up_at,omitempty"` // Empty until the stats are first rolled up
+}
+
+// CouponBatchResp is a batch of generated codes.
+type CouponBatchResp struct {
+	CampaignID uint64   `json:"campaign_id,string"`
+	Codes      []string `json:"codes" example:"SUMMER-7KQ2M9XD"`
+}
+
+// CouponService manages coupon campaigns and their codes. Codes are redeemed
+// through PromotionService at checkout.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/coupon_service_mock.go -package=mocks
+type CouponService interface {
+	CreateCampaign(ctx context.Context, req *CouponCampaignCreateReq) (*CouponCampaignResp, error)
+	ListCampaigns(ctx context.Context) ([]CouponCampaignResp, error)
+	// GenerateCodes creates count unique codes for a campaign, each prefix
+	// followed by random characters.
+	GenerateCodes(ctx context.Context, campaignID uint64, count int, prefix string) (*CouponBatchResp, error)
+	// ExportCodes writes every code of a campaign to w as CSV, with when and
+	// by which order it was redeemed. Nothing is written when the campaign
+	// does not exist.
+	ExportCodes(ctx context.Context, campaignID uint64, w io.Writer) error
+	// RollupStats recounts the generated and redeemed codes of every
+	// campaign, and returns how many campaigns it updated.
+	RollupStats(ctx context.Context) (int64, error)
+}
+
+type couponService struct {
+	repo          repository.CouponRepository
+	promotionRepo repository.PromotionRepository
+	codeLength    int
+}
+
+// NewCouponService creates a new CouponService instance.
+func NewCouponService(repo repository.CouponRepository, promotionRepo repository.PromotionRepository, opts CouponOptions) CouponService {
+	if opts.CodeLength <= 0 {
+		opts.CodeLength = DefaultCouponCodeLength
+	}
+	return &couponService{repo: repo, promotionRepo: promotionRepo, codeLength: opts.CodeLength}
+}
+
+func (s *couponService) CreateCampaign(ctx context.Context, req *CouponCampaignCreateReq) (*CouponCampaignResp, error) {
+	promotion, err := s.promotionRepo.GetByID(ctx, req.PromotionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPromotionNotFound) {
+			return nil, ErrPromotionNotFound
+		}
+		return nil, err
+	}
+	if !promotion.CouponOnly {
+		return nil, fmt.Errorf("%w: promotion %d is not coupon-only", ErrInvalidCouponCampaign, promotion.ID)
+	}
+
+	campaign := &model.CouponCampaign{Name: req.Name, PromotionID: req.PromotionID}
+	if err := s.repo.CreateCampaign(ctx, campaign); err != nil {
+		return nil, err
+	}
+	resp := newCouponCampaignResp(campaign)
+	return &resp, nil
+}
+
+func (s *couponService) ListCampaigns(ctx context.Context) ([]CouponCampaignResp, error) {
+	campaigns, err := s.repo.ListCampaigns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]CouponCampaignResp, 0, len(campaigns))
+	for i := range campaigns {
+		resp = append(resp, newCouponCampaignResp(&campaigns[i]))
+	}
+	return resp, nil
+}
+
+// GenerateCodes draws the codes, drops duplicates within the batch and codes
+// already taken, and draws replacements until the batch is complete. Taken
+// codes only become likely once most of the codes of a length are used, so
+// running out of attempts means the prefix needs longer codes.
+func (s *couponService) GenerateCodes(ctx context.Context, campaignID uint64, count int, prefix string) (*CouponBatchResp, error) {
+	prefix = normalizeCouponCode(prefix)
+	if count <= 0 || count > MaxCouponBatch {
+		return nil, fmt.Errorf("%w: count must be between 1 and %d", ErrInvalidCouponBatch, MaxCouponBatch)
+	}
+	if !couponPrefixPattern.MatchString(prefix) || len(prefix)+s.codeLength > couponCodeMaxLength {
+		return nil, fmt.Errorf("%w: prefix must be letters, digits and hyphens, at most %d of them", ErrInvalidCouponBatch, couponCodeMaxLength-s.codeLength)
+	}
+	if _, err := s.campaign(ctx, campaignID); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, count)
+	seen := make(map[string]bool, count)
+	for attempt := 0; len(codes) < count; attempt++ {
+		if attempt == couponCodeAttempts {
+			return nil, fmt.Errorf("failed to generate %d unique codes with prefix %q after %d attempts", count, prefix, couponCodeAttempts)
+		}
+		want := count - len(codes)
+		drawn := make([]string, 0, want)
+		for len(drawn) < want {
+			code, err := s.randomCode(prefix)
+			if err != nil {
+				return nil, err
+			}
+			if !seen[code] {
+				seen[code] = true
+				drawn = append(drawn, code)
+			}
+		}
+		taken, err := s.takenCodes(ctx, drawn)
+		if err != nil {
+			return nil, err
+		}
+		for _, code := range drawn {
+			if !taken[code] {
+				codes = append(codes, code)
+			}
+		}
+	}
+
+	coupons := make([]model.Coupon, 0, len(codes))
+	for _, code := range codes {
+		coupons = append(coupons, model.Coupon{CampaignID: campaignID, Code: code})
+	}
+	if err := s.repo.CreateCoupons(ctx, coupons); err != nil {
+		return nil, err
+	}
+	return &CouponBatchResp{CampaignID: campaignID, Codes: codes}, nil
+}
+
+// randomCode returns prefix followed by random characters of the alphabet.
+func (s *couponService) randomCode(prefix string) (string, error) {
+	buf := make([]byte, s.codeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate coupon code: %w", err)
+	}
+	for i, b := range buf {
+		buf[i] = couponCodeAlphabet[int(b)%len(couponCodeAlphabet)]
+	}
+	return prefix + string(buf), nil
+}
+
+// takenCodes returns which of codes exist already.
+func (s *couponService) takenCodes(ctx context.Context, codes []string) (map[string]bool, error) {
+	taken := make(map[string]bool)
+	for start := 0; start < len(codes); start += couponLookupBatchSize {
+		existing, err := s.repo.ExistingCodes(ctx, codes[start:min(start+couponLookupBatchSize, len(codes))])
+		if err != nil {
+			return nil, err
+		}
+		for _, code := range existing {
+			taken[code] = true
+		}
+	}
+	return taken, nil
+}
+
+func (s *couponService) ExportCodes(ctx context.Context, campaignID uint64, w io.Writer) error {
+	if _, err := s.campaign(ctx, campaignID); err != nil {
+		return err
+	}
+
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"code", "redeemed_at", "order_id"}); err != nil {
+		return fmt.Errorf("failed to write coupon export: %w", err)
+	}
+	for afterID := uint64(0); ; {
+		coupons, err := s.repo.ListCoupons(ctx, campaignID, afterID, couponExportPageSize)
+		if err != nil {
+			return err
+		}
+		for _, coupon := range coupons {
+			record := []string{coupon.Code, "", ""}
+			if coupon.RedeemedAt != nil {
+				record[1] = coupon.RedeemedAt.UTC().Format(time.RFC3339)
+				record[2] = strconv.FormatUint(coupon.OrderID, 10)
+			}
+			if err := out.Write(record); err != nil {
+				return fmt.Errorf("failed to write coupon export: %w", err)
+			}
+		}
+		if len(coupons) < couponExportPageSize {
+			break
+		}
+		afterID = coupons[len(coupons)-1].ID
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return fmt.Errorf("failed to write coupon export: %w", err)
+	}
+	return nil
+}
+
+func (s *couponService) RollupStats(ctx context.Context) (int64, error) {
+	return s.repo.RollupStats(ctx, time.Now())
+}
+
+func (s *couponService) campaign(ctx context.Context, id uint64) (*model.CouponCampaign, error) {
+	campaign, err := s.repo.GetCampaign(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrCouponCampaignNotFound) {
+			return nil, ErrCouponCampaignNotFound
+		}
+		return nil, err
+	}
+	return campaign, nil
+}
+
+// normalizeCouponCode makes codes case-insensitive, as customers type them.
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func newCouponCampaignResp(c *model.CouponCampaign) CouponCampaignResp {
+	resp := CouponCampaignResp{ID: c.ID, Name: c.Name, PromotionID: c.PromotionID, RedemptionRate: decimal.Zero}
+	if stats := c.Stats; stats != nil {
+		resp.Generated = stats.Generated
+		resp.Redeemed = stats.Redeemed
+		resp.LastRedeemedAt = stats.LastRedeemedAt
+		resp.RolledUpAt = &stats.RolledUpAt
+		if stats.Generated > 0 {
+			resp.RedemptionRate = decimal.NewFromInt(stats.Redeemed).Mul(hundredPercent).Div(decimal.NewFromInt(stats.Generated)).Round(2)
+		}
+	}
+	return resp
+}
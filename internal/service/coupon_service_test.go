@@ -0,0 +1,146 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCouponService_CreateCampaign(t *testing.T) {
+	tests := []struct {
+		name      string
+		promotion *model.Promotion
+		repoErr   error
+		wantErr   error
+	}{
+		{name: "Success", promotion: &model.Promotion{Base: model.Base{ID: 3}, CouponOnly: true}},
+		{name: "NotCouponOnly", promotion: &model.Promotion{Base: model.Base{ID: 3}}, wantErr: service.ErrInvalidCouponCampaign},
+		{name: "PromotionNotFound", repoErr: repository.ErrPromotionNotFound, wantErr: service.ErrPromotionNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockCouponRepository(ctrl)
+			promotionRepo := mocks.NewMockPromotionRepository(ctrl)
+			promotionRepo.EXPECT().GetByID(gomock.Any(), uint64(3)).Return(tt.promotion, tt.repoErr)
+			if tt.wantErr == nil {
+				repo.EXPECT().CreateCampaign(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, c *model.CouponCampaign) error {
+					c.ID = 9
+					return nil
+				})
+			}
+			coupons := service.NewCouponService(repo, promotionRepo, service.CouponOptions{})
+
+			resp, err := coupons.CreateCampaign(context.Background(), &service.CouponCampaignCreateReq{Name: "Newsletter", PromotionID: 3})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, uint64(9), resp.ID)
+			assert.Equal(t, "0", resp.RedemptionRate.String())
+		})
+	}
+}
+
+func TestCouponService_ListCampaigns(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockCouponRepository(ctrl)
+	repo.EXPECT().ListCampaigns(gomock.Any()).Return([]model.CouponCampaign{
+		{Base: model.Base{ID: 1}, Name: "Newsletter", Stats: &model.CouponCampaignStats{Generated: 8, Redeemed: 3, RolledUpAt: time.Now()}},
+		{Base: model.Base{ID: 2}, Name: "Not rolled up"},
+	}, nil)
+	coupons := service.NewCouponService(repo, nil, service.CouponOptions{})
+
+	campaigns, err := coupons.ListCampaigns(context.Background())
+	require.NoError(t, err)
+	require.Len(t, campaigns, 2)
+	assert.Equal(t, "37.5", campaigns[0].RedemptionRate.String())
+	assert.NotNil(t, campaigns[0].RolledUpAt)
+	assert.Nil(t, campaigns[1].RolledUpAt)
+}
+
+func TestCouponService_GenerateCodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockCouponRepository(ctrl)
+	repo.EXPECT().GetCampaign(gomock.Any(), uint64(1)).Return(&model.CouponCampaign{}, nil)
+	// The first code drawn is taken already, so one replacement is drawn
+	var taken string
+	gomock.InOrder(
+		repo.EXPECT().ExistingCodes(gomock.Any(), gomock.Len(3)).DoAndReturn(func(_ context.Context, codes []string) ([]string, error) {
+			taken = codes[0]
+			return codes[:1], nil
+		}),
+		repo.EXPECT().ExistingCodes(gomock.Any(), gomock.Len(1)).Return(nil, nil),
+	)
+	repo.EXPECT().CreateCoupons(gomock.Any(), gomock.Len(3)).DoAndReturn(func(_ context.Context, coupons []model.Coupon) error {
+		for _, coupon := range coupons {
+			assert.Equal(t, uint64(1), coupon.CampaignID)
+		}
+		return nil
+	})
+	coupons := service.NewCouponService(repo, nil, service.CouponOptions{CodeLength: 10})
+
+	resp, err := coupons.GenerateCodes(context.Background(), 1, 3, "summer-")
+	require.NoError(t, err)
+	require.Len(t, resp.Codes, 3)
+	seen := make(map[string]bool)
+	for _, code := range resp.Codes {
+		assert.Regexp(t, `^SUMMER-[A-HJ-NP-Z2-9]{10}$`, code)
+		assert.NotEqual(t, taken, code)
+		assert.False(t, seen[code], "duplicate code %s", code)
+		seen[code] = true
+	}
+}
+
+func TestCouponService_GenerateCodesInvalid(t *testing.T) {
+	coupons := service.NewCouponService(nil, nil, service.CouponOptions{})
+
+	for _, tt := range []struct {
+		name   string
+		count  int
+		prefix string
+	}{
+		{name: "NoCodes", count: 0},
+		{name: "TooMany", count: service.MaxCouponBatch + 1},
+		{name: "PrefixCharacters", count: 1, prefix: "50% OFF"},
+		{name: "PrefixTooLong", count: 1, prefix: strings.Repeat("A", 33)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := coupons.GenerateCodes(context.Background(), 1, tt.count, tt.prefix)
+			assert.ErrorIs(t, err, service.ErrInvalidCouponBatch)
+		})
+	}
+}
+
+func TestCouponService_ExportCodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockCouponRepository(ctrl)
+	redeemedAt := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	repo.EXPECT().GetCampaign(gomock.Any(), uint64(1)).Return(&model.CouponCampaign{}, nil)
+	repo.EXPECT().ListCoupons(gomock.Any(), uint64(1), uint64(0), gomock.Any()).Return([]model.Coupon{
+		{Base: model.Base{ID: 5}, Code: "NEWS-AAAA", RedeemedAt: &redeemedAt, OrderID: 42},
+		{Base: model.Base{ID: 6}, Code: "NEWS-BBBB"},
+	}, nil)
+	coupons := service.NewCouponService(repo, nil, service.CouponOptions{})
+
+	var buf bytes.Buffer
+	require.NoError(t, coupons.ExportCodes(context.Background(), 1, &buf))
+	assert.Equal(t, "code,redeemed_at,order_id\nNEWS-AAAA,2026-06-01T12:00:00Z,42\nNEWS-BBBB,,\n", buf.String())
+
+	repo.EXPECT().GetCampaign(gomock.Any(), uint64(2)).Return(nil, repository.ErrCouponCampaignNotFound)
+	buf.Reset()
+	assert.ErrorIs(t, coupons.ExportCodes(context.Background(), 2, &buf), service.ErrCouponCampaignNotFound)
+	assert.Empty(t, buf.String())
+}
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/proyuen/go-mall/pkg/exchangerate"
+	"github.com/proyuen/go-mall/pkg/tenant"
+	"github.com/shopspring/decimal"
+)
+
+// Currency defaults, used where config.CurrencyConfig leaves a field zero.
+const (
+	DefaultBaseCurrency = "USD"
+	DefaultRatesMaxAge  = 24 * time.Hour
+
+	// ratesReloadInterval is how long loaded rates are reused before they are
+	// read again. The worker refreshes them far less often.
+	ratesReloadInterval = time.Minute
+)
+
+var (
+	ErrUnsupportedCurrency = apperr.New(apperr.CodeCurrencyUnsupported)
+	// ErrRatesUnavailable means there is no exchange rate recent enough to convert with.
+	ErrRatesUnavailable = apperr.New(apperr.CodeRatesUnavailable)
+)
+
+// CurrencyOptions configures a CurrencyService.
+type CurrencyOptions struct {
+	Base      string        // Currency of SKUs created without one, and of the stored rates; empty means DefaultBaseCurrency
+	Supported []string      // Currencies clients may ask for besides Base
+	MaxAge    time.Duration // Older rates are not converted with; 0 means DefaultRatesMaxAge
+}
+
+// CurrenciesResp lists the currencies prices can be shown and charged in.
+type CurrenciesResp struct {
+	Base      string         `json:"base" example:"USD"`
+	Supported []string       `json:"supported" example:"USD,EUR"` // Includes the base
+	Rates     []ExchangeRate `json:"rates"`
+}
+
+// ExchangeRate is how much of Currency one unit of the base currency buys.
+type ExchangeRate struct {
+	Currency  string          `json:"currency" example:"EUR"`
+	Rate      decimal.Decimal `json:"rate" swaggertype:"string" example:"0.9215"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Rates converts amounts between currencies with one snapshot of the
+// exchange rates, so every price in a response or order uses the same rates.
+type Rates struct {
+	base  string
+	rates map[string]decimal.Decimal // Units of each currency per unit of base, without expired rates
+}
+
+// Convert converts amount from one currency to another, rounded to cents.
+// Converting to the same currency never fails.
+func (r *Rates) Convert(amount decimal.Decimal, from, to string) (decimal.Decimal, error) {
+	if from == to {
+		return amount, nil
+	}
+	fromRate, ok := r.rate(from)
+	if !ok {
+		return decimal.Decimal{}, fmt.Errorf("%w: %s to %s", ErrRatesUnavailable, r.base, from)
+	}
+	toRate, ok := r.rate(to)
+	if !ok {
+		return decimal.Decimal{}, fmt.Errorf("%w: %s to %s", ErrRatesUnavailable, r.base, to)
+	}
+	return amount.Mul(toRate).Div(fromRate).Round(2), nil
+}
+
+func (r *Rates) rate(currency string) (decimal.Decimal, bool) {
+	if currency == r.base {
+		return decimal.NewFromInt(1), true
+	}
+	rate, ok := r.rates[currency]
+	return rate, ok
+}
+
+// CurrencyService knows the store's currencies, keeps the exchange rates
+// between them and resolves which currency a client is served in.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/currency_service_mock.go -package=mocks
+type CurrencyService interface {
+	Base() string
+	IsSupported(currency string) bool
+	// Resolve picks the currency for a request: requested if given, else the
+	// preference of userID if any, else the currency of the store ctx is
+	// served for, else the base currency.
+	Resolve(ctx context.Context, requested string, userID uint64) (string, error)
+	// Rates returns the current exchange rates. Rates older than the maximum
+	// age are left out, so converting with them fails.
+	Rates(ctx context.Context) (*Rates, error)
+	// ConvertSKUs converts the prices of skus to currency in place.
+	ConvertSKUs(ctx context.Context, skus []SKUResp, currency string) error
+	List(ctx context.Context) (*CurrenciesResp, error)
+	SetPreference(ctx context.Context, userID uint64, currency string) error
+	// RefreshRates fetches the rates of the supported currencies and stores
+	// them. It returns how many were stored.
+	RefreshRates(ctx context.Context) (int, error)
+}
+
+type currencyService struct {
+	rateRepo repository.ExchangeRateRepository
+	userRepo repository.UserRepository
+	provider exchangerate.Provider
+	base     string
+	foreign  []string // Supported currencies other than base
+	maxAge   time.Duration
+
+	mu       sync.Mutex
+	rates    *Rates
+	loadedAt time.Time
+}
+
+// NewCurrencyService creates a new CurrencyService instance. provider may be
+// nil in processes that only read rates.
+func NewCurrencyService(rateRepo repository.ExchangeRateRepository, userRepo repository.UserRepository, provider exchangerate.Provider, opts CurrencyOptions) CurrencyService {
+	base := strings.ToUpper(opts.Base)
+	if base == "" {
+		base = DefaultBaseCurrency
+	}
+	var foreign []string
+	for _, currency := range opts.Supported {
+		currency = strings.ToUpper(currency)
+		if currency != base && !slices.Contains(foreign, currency) {
+			foreign = append(foreign, currency)
+		}
+	}
+	maxAge := opts.MaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultRatesMaxAge
+	}
+	return &currencyService{
+		rateRepo: rateRepo,
+		userRepo: userRepo,
+		provider: provider,
+		base:     base,
+		foreign:  foreign,
+		maxAge:   maxAge,
+	}
+}
+
+func (s *currencyService) Base() string {
+	return s.base
+}
+
+func (s *currencyService) IsSupported(currency string) bool {
+	return currency == s.base || slices.Contains(s.foreign, currency)
+}
+
+func (s *currencyService) Resolve(ctx context.Context, requested string, userID uint64) (string, error) {
+	if requested != "" {
+		requested = strings.ToUpper(requested)
+		if !s.IsSupported(requested) {
+			return "", fmt.Errorf("%w: %q", ErrUnsupportedCurrency, requested)
+		}
+		return requested, nil
+	}
+	if userID == 0 {
+		return s.defaultCurrency(ctx), nil
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get currency preference of user %d: %w", userID, err)
+	}
+	// A preference the store stopped supporting falls back to the default
+	if user.Currency == "" || !s.IsSupported(user.Currency) {
+		return s.defaultCurrency(ctx), nil
+	}
+	return user.Currency, nil
+}
+
+// defaultCurrency returns the currency of the store ctx is served for, if it
+// is supported, else the base currency.
+func (s *currencyService) defaultCurrency(ctx context.Context) string {
+	if store := tenant.FromContext(ctx); store != nil && s.IsSupported(store.Currency) {
+		return store.Currency
+	}
+	return s.base
+}
+
+func (s *currencyService) Rates(ctx context.Context) (*Rates, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rates != nil && time.Since(s.loadedAt) < ratesReloadInterval {
+		return s.rates, nil
+	}
+
+	stored, err := s.rateRepo.ListByBase(ctx, s.base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exchange rates: %w", err)
+	}
+	rates := &Rates{base: s.base, rates: make(map[string]decimal.Decimal, len(stored))}
+	for _, rate := range stored {
+		if time.Since(rate.UpdatedAt) <= s.maxAge {
+			rates.rates[rate.Currency] = rate.Rate
+		}
+	}
+	s.rates, s.loadedAt = rates, time.Now()
+	return rates, nil
+}
+
+func (s *currencyService) ConvertSKUs(ctx context.Context, skus []SKUResp, currency string) error {
+	var rates *Rates
+	for i := range skus {
+		from := skus[i].Currency
+		if from == "" {
+			from = s.base // Cached before SKUs had a currency
+		}
+		if from == currency {
+			skus[i].Currency = currency
+			continue
+		}
+		if rates == nil {
+			var err error
+			if rates, err = s.Rates(ctx); err != nil {
+				return err
+			}
+		}
+		price, err := rates.Convert(skus[i].Price, from, currency)
+		if err != nil {
+			return err
+		}
+		if regular := skus[i].RegularPrice; regular != nil {
+			converted, err := rates.Convert(*regular, from, currency)
+			if err != nil {
+				return err
+			}
+			skus[i].RegularPrice = &converted
+		}
+		skus[i].Price, skus[i].Currency = price, currency
+	}
+	return nil
+}
+
+func (s *currencyService) List(ctx context.Context) (*CurrenciesResp, error) {
+	stored, err := s.rateRepo.ListByBase(ctx, s.base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+	resp := &CurrenciesResp{
+		Base:      s.base,
+		Supported: append([]string{s.base}, s.foreign...),
+		Rates:     []ExchangeRate{},
+	}
+	for _, rate := range stored {
+		if slices.Contains(s.foreign, rate.Currency) {
+			resp.Rates = append(resp.Rates, ExchangeRate{Currency: rate.Currency, Rate: rate.Rate, UpdatedAt: rate.UpdatedAt})
+		}
+	}
+	return resp, nil
+}
+
+func (s *currencyService) SetPreference(ctx context.Context, userID uint64, currency string) error {
+	currency = strings.ToUpper(currency)
+	if currency != "" && !s.IsSupported(currency) {
+		return fmt.Errorf("%w: %q", ErrUnsupportedCurrency, currency)
+	}
+	if err := s.userRepo.UpdateCurrency(ctx, userID, currency); err != nil {
+		return fmt.Errorf("failed to save currency preference: %w", err)
+	}
+	return nil
+}
+
+// RefreshRates stores whatever supported rates the provider returned before
+// reporting the ones it did not, so one missing currency does not hold back
+// the others.
+func (s *currencyService) RefreshRates(ctx context.Context) (int, error) {
+	if s.provider == nil {
+		return 0, errors.New("no exchange rate provider configured")
+	}
+	if len(s.foreign) == 0 {
+		return 0, nil
+	}
+
+	fetched, err := s.provider.Fetch(ctx, s.base)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch exchange rates: %w", err)
+	}
+	var rates []model.ExchangeRate
+	var missing []string
+	for _, currency := range s.foreign {
+		rate, ok := fetched[currency]
+		if !ok {
+			missing = append(missing, currency)
+			continue
+		}
+		rates = append(rates, model.ExchangeRate{BaseCurrency: s.base, Currency: currency, Rate: rate})
+	}
+	if err := s.rateRepo.SaveRates(ctx, rates); err != nil {
+		return 0, fmt.Errorf("failed to store exchange rates: %w", err)
+	}
+	if len(missing) > 0 {
+		return len(rates), fmt.Errorf("%w: provider returned no rate for %s", ErrRatesUnavailable, strings.Join(missing, ", "))
+	}
+	return len(rates), nil
+}
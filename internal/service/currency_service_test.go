@@ -0,0 +1,234 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/tenant"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var currencyOpts = service.CurrencyOptions{Base: "USD", Supported: []string{"EUR", "JPY", "USD"}}
+
+func TestCurrencyService_Resolve(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested string
+		userID    uint64
+		store     *model.Store
+		mockSetup func(userRepo *mocks.MockUserRepository)
+		want      string
+		wantErr   error
+	}{
+		{name: "Requested", requested: "eur", userID: 7, want: "EUR"},
+		{name: "RequestedUnsupported", requested: "CHF", wantErr: service.ErrUnsupportedCurrency},
+		{name: "Anonymous", want: "USD"},
+		{name: "StoreCurrency", store: &model.Store{Currency: "EUR"}, want: "EUR"},
+		{name: "StoreCurrencyUnsupported", store: &model.Store{Currency: "CHF"}, want: "USD"},
+		{
+			name:   "Preference",
+			userID: 7,
+			mockSetup: func(userRepo *mocks.MockUserRepository) {
+				userRepo.EXPECT().GetByID(gomock.Any(), uint64(7)).Return(&model.User{Currency: "JPY"}, nil)
+			},
+			want: "JPY",
+		},
+		{
+			name:   "PreferenceNoLongerSupported",
+			userID: 7,
+			mockSetup: func(userRepo *mocks.MockUserRepository) {
+				userRepo.EXPECT().GetByID(gomock.Any(), uint64(7)).Return(&model.User{Currency: "CHF"}, nil)
+			},
+			want: "USD",
+		},
+		{
+			name:   "UserNotFound",
+			userID: 7,
+			mockSetup: func(userRepo *mocks.MockUserRepository) {
+				userRepo.EXPECT().GetByID(gomock.Any(), uint64(7)).Return(nil, repository.ErrUserNotFound)
+			},
+			wantErr: repository.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			userRepo := mocks.NewMockUserRepository(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(userRepo)
+			}
+
+			ctx := context.Background()
+			if tt.store != nil {
+				ctx = tenant.NewContext(ctx, tt.store)
+			}
+			currency, err := service.NewCurrencyService(nil, userRepo, nil, currencyOpts).Resolve(ctx, tt.requested, tt.userID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, currency)
+		})
+	}
+}
+
+func TestCurrencyService_ConvertSKUs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	rateRepo := mocks.NewMockExchangeRateRepository(ctrl)
+	// Loaded once: later calls reuse the rates
+	rateRepo.EXPECT().ListByBase(gomock.Any(), "USD").Return([]model.ExchangeRate{
+		{Base: model.Base{UpdatedAt: time.Now()}, BaseCurrency: "USD", Currency: "EUR", Rate: decimal.RequireFromString("0.9")},
+		{Base: model.Base{UpdatedAt: time.Now().Add(-48 * time.Hour)}, BaseCurrency: "USD", Currency: "JPY", Rate: decimal.NewFromInt(150)},
+	}, nil).Times(1)
+	currencies := service.NewCurrencyService(rateRepo, nil, nil, currencyOpts)
+	ctx := context.Background()
+
+	skus := []service.SKUResp{
+		{ID: 1, Price: decimal.RequireFromString("19.99"), Currency: "USD"},
+		{ID: 2, Price: decimal.NewFromInt(9), Currency: "EUR"},
+		{ID: 3, Price: decimal.NewFromInt(5)}, // Cached before SKUs had a currency
+	}
+	require.NoError(t, currencies.ConvertSKUs(ctx, skus, "EUR"))
+	assert.Equal(t, "17.99", skus[0].Price.String())
+	assert.Equal(t, "9", skus[1].Price.String())
+	assert.Equal(t, "4.5", skus[2].Price.String())
+	for _, sku := range skus {
+		assert.Equal(t, "EUR", sku.Currency)
+	}
+
+	rates, err := currencies.Rates(ctx)
+	require.NoError(t, err)
+	converted, err := rates.Convert(decimal.NewFromInt(9), "EUR", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, "10", converted.String())
+
+	// The JPY rate is older than the default maximum age
+	err = currencies.ConvertSKUs(ctx, []service.SKUResp{{Price: decimal.NewFromInt(1), Currency: "USD"}}, "JPY")
+	assert.ErrorIs(t, err, service.ErrRatesUnavailable)
+}
+
+func TestCurrencyService_SetPreference(t *testing.T) {
+	tests := []struct {
+		name      string
+		currency  string
+		mockSetup func(userRepo *mocks.MockUserRepository)
+		wantErr   error
+	}{
+		{
+			name:     "Set",
+			currency: "eur",
+			mockSetup: func(userRepo *mocks.MockUserRepository) {
+				userRepo.EXPECT().UpdateCurrency(gomock.Any(), uint64(7), "EUR").Return(nil)
+			},
+		},
+		{
+			name: "Clear",
+			mockSetup: func(userRepo *mocks.MockUserRepository) {
+				userRepo.EXPECT().UpdateCurrency(gomock.Any(), uint64(7), "").Return(nil)
+			},
+		},
+		{name: "Unsupported", currency: "CHF", wantErr: service.ErrUnsupportedCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			userRepo := mocks.NewMockUserRepository(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(userRepo)
+			}
+
+			err := service.NewCurrencyService(nil, userRepo, nil, currencyOpts).SetPreference(context.Background(), 7, tt.currency)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCurrencyService_RefreshRates(t *testing.T) {
+	tests := []struct {
+		name      string
+		mockSetup func(provider *mocks.MockProvider, rateRepo *mocks.MockExchangeRateRepository)
+		wantSaved int
+		wantErr   bool
+	}{
+		{
+			name: "StoresSupportedRates",
+			mockSetup: func(provider *mocks.MockProvider, rateRepo *mocks.MockExchangeRateRepository) {
+				provider.EXPECT().Fetch(gomock.Any(), "USD").Return(map[string]decimal.Decimal{
+					"EUR": decimal.RequireFromString("0.9"),
+					"JPY": decimal.NewFromInt(150),
+					"GBP": decimal.RequireFromString("0.8"), // Not supported
+				}, nil)
+				rateRepo.EXPECT().SaveRates(gomock.Any(), []model.ExchangeRate{
+					{BaseCurrency: "USD", Currency: "EUR", Rate: decimal.RequireFromString("0.9")},
+					{BaseCurrency: "USD", Currency: "JPY", Rate: decimal.NewFromInt(150)},
+				}).Return(nil)
+			},
+			wantSaved: 2,
+		},
+		{
+			name: "MissingRateStillStoresOthers",
+			mockSetup: func(provider *mocks.MockProvider, rateRepo *mocks.MockExchangeRateRepository) {
+				provider.EXPECT().Fetch(gomock.Any(), "USD").Return(map[string]decimal.Decimal{"EUR": decimal.RequireFromString("0.9")}, nil)
+				rateRepo.EXPECT().SaveRates(gomock.Any(), gomock.Len(1)).Return(nil)
+			},
+			wantSaved: 1,
+			wantErr:   true,
+		},
+		{
+			name: "ProviderError",
+			mockSetup: func(provider *mocks.MockProvider, _ *mocks.MockExchangeRateRepository) {
+				provider.EXPECT().Fetch(gomock.Any(), "USD").Return(nil, errors.New("status 503"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			provider := mocks.NewMockProvider(ctrl)
+			rateRepo := mocks.NewMockExchangeRateRepository(ctrl)
+			tt.mockSetup(provider, rateRepo)
+
+			saved, err := service.NewCurrencyService(rateRepo, nil, provider, currencyOpts).RefreshRates(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantSaved, saved)
+		})
+	}
+}
+
+func TestCurrencyService_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	rateRepo := mocks.NewMockExchangeRateRepository(ctrl)
+	updated := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	rateRepo.EXPECT().ListByBase(gomock.Any(), "USD").Return([]model.ExchangeRate{
+		{Base: model.Base{UpdatedAt: updated}, BaseCurrency: "USD", Currency: "EUR", Rate: decimal.RequireFromString("0.9")},
+		{Base: model.Base{UpdatedAt: updated}, BaseCurrency: "USD", Currency: "GBP", Rate: decimal.RequireFromString("0.8")}, // No longer supported
+	}, nil)
+
+	resp, err := service.NewCurrencyService(rateRepo, nil, nil, currencyOpts).List(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "USD", resp.Base)
+	assert.Equal(t, []string{"USD", "EUR", "JPY"}, resp.Supported)
+	assert.Equal(t, []service.ExchangeRate{{Currency: "EUR", Rate: decimal.RequireFromString("0.9"), UpdatedAt: updated}}, resp.Rates)
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultCustomerGroupPageSize is how many members ListMembers returns when
+// no limit is given.
+const DefaultCustomerGroupPageSize = 20
+
+// CustomerGroups lists the customer groups, retail first.
+var CustomerGroups = []string{model.CustomerGroupRetail, model.CustomerGroupWholesale, model.CustomerGroupVIP}
+
+var (
+	// ErrUnknownCustomerGroup is returned for a group that does not exist, and
+	// for price tiers of retail customers, who pay the SKUs' own prices.
+	ErrUnknownCustomerGroup = errors.New("unknown customer group")
+	ErrPriceTierNotFound    = repository.ErrPriceTierNotFound
+	ErrUserNotFound         = repository.ErrUserNotFound
+)
+
+// CustomerGroupMemberResp is a user and the customer group they are in.
+type CustomerGroupMemberResp struct {
+	UserID        uint64 `json:"user_id,string"`
+	Username      string `json:"username"`
+	CustomerGroup string `json:"customer_group" example:"wholesale"`
+}
+
+// PriceTierResp is what a customer group pays for a SKU.
+type PriceTierResp struct {
+	SKUID         uint64          `json:"sku_id,string"`
+	CustomerGroup string          `json:"customer_group" example:"wholesale"`
+	Price         decimal.Decimal `json:"price" swaggertype:"string" example:"14.50"`
+	Currency      string          `json:"currency" example:"USD"` // The SKU's currency
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// CustomerGroupService keeps which customer group each user is in and what
+// the groups pay for SKUs. Retail customers, and anonymous users, pay the
+// SKUs' own prices; wholesale and VIP customers pay the price tier of their
+// group where a SKU has one.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/customer_group_service_mock.go -package=mocks
+type CustomerGroupService interface {
+	// Prices returns what the customer group of userID pays for the SKUs
+	// priced for it, by SKU ID, in the SKUs' currencies. userID is 0 for
+	// anonymous users.
+	Prices(ctx context.Context, userID uint64, skuIDs []uint64) (map[uint64]decimal.Decimal, error)
+	// ApplySKUs replaces the prices of skus in place with what the customer
+	// group of userID pays for them, sales or not.
+	ApplySKUs(ctx context.Context, userID uint64, skus []SKUResp) error
+	SetMembership(ctx context.Context, userID uint64, group string) (*CustomerGroupMemberResp, error)
+	ListMembers(ctx context.Context, group string, offset, limit int) ([]CustomerGroupMemberResp, error)
+	ListPriceTiers(ctx context.Context, skuID uint64) ([]PriceTierResp, error)
+	PutPriceTier(ctx context.Context, skuID uint64, group string, price decimal.Decimal) (*PriceTierResp, error)
+	DeletePriceTier(ctx context.Context, skuID uint64, group string) error
+}
+
+type customerGroupService struct {
+	tierRepo    repository.PriceTierRepository
+	userRepo    repository.UserRepository
+	productRepo repository.ProductRepository
+}
+
+// NewCustomerGroupService creates a new CustomerGroupService instance.
+func NewCustomerGroupService(tierRepo repository.PriceTierRepository, userRepo repository.UserRepository, productRepo repository.ProductRepository) CustomerGroupService {
+	return &customerGroupService{tierRepo: tierRepo, userRepo: userRepo, productRepo: productRepo}
+}
+
+func (s *customerGroupService) Prices(ctx context.Context, userID uint64, skuIDs []uint64) (map[uint64]decimal.Decimal, error) {
+	group, err := s.groupOf(ctx, userID)
+	if err != nil || group == model.CustomerGroupRetail || len(skuIDs) == 0 {
+		return nil, err
+	}
+	tiers, err := s.tierRepo.ListBySKUIDs(ctx, skuIDs, group)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s prices: %w", group, err)
+	}
+	prices := make(map[uint64]decimal.Decimal, len(tiers))
+	for _, tier := range tiers {
+		prices[tier.SKUID] = tier.Price
+	}
+	return prices, nil
+}
+
+func (s *customerGroupService) ApplySKUs(ctx context.Context, userID uint64, skus []SKUResp) error {
+	if userID == 0 || len(skus) == 0 {
+		return nil
+	}
+	ids := make([]uint64, len(skus))
+	for i, sku := range skus {
+		ids[i] = sku.ID
+	}
+	prices, err := s.Prices(ctx, userID, ids)
+	if err != nil {
+		return err
+	}
+	for i := range skus {
+		if price, ok := prices[skus[i].ID]; ok {
+			// Group prices are not discounted by sales
+			skus[i].Price, skus[i].RegularPrice, skus[i].SaleEndsAt = price, nil, nil
+		}
+	}
+	return nil
+}
+
+// groupOf returns the customer group of userID. Anonymous users, and users
+// that no longer exist, are retail customers.
+func (s *customerGroupService) groupOf(ctx context.Context, userID uint64) (string, error) {
+	if userID == 0 {
+		return model.CustomerGroupRetail, nil
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return model.CustomerGroupRetail, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get customer group: %w", err)
+	}
+	if user.CustomerGroup == "" {
+		return model.CustomerGroupRetail, nil
+	}
+	return user.CustomerGroup, nil
+}
+
+func (s *customerGroupService) SetMembership(ctx context.Context, userID uint64, group string) (*CustomerGroupMemberResp, error) {
+	if !slices.Contains(CustomerGroups, group) {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCustomerGroup, group)
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	before := user.CustomerGroup
+	if err := s.userRepo.UpdateCustomerGroup(ctx, userID, group); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to update customer group: %w", err)
+	}
+	RecordAudit(ctx, AuditEntry{
+		Action:     "user.customer_group",
+		Resource:   "user",
+		ResourceID: strconv.FormatUint(userID, 10),
+		Before:     map[string]any{"customer_group": before},
+		After:      map[string]any{"customer_group": group},
+	})
+	return &CustomerGroupMemberResp{UserID: userID, Username: user.Username, CustomerGroup: group}, nil
+}
+
+func (s *customerGroupService) ListMembers(ctx context.Context, group string, offset, limit int) ([]CustomerGroupMemberResp, error) {
+	if !slices.Contains(CustomerGroups, group) {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCustomerGroup, group)
+	}
+	if limit <= 0 {
+		limit = DefaultCustomerGroupPageSize
+	}
+	users, err := s.userRepo.ListByCustomerGroup(ctx, group, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customer group members: %w", err)
+	}
+	members := make([]CustomerGroupMemberResp, len(users))
+	for i, user := range users {
+		members[i] = CustomerGroupMemberResp{UserID: user.ID, Username: user.Username, CustomerGroup: group}
+	}
+	return members, nil
+}
+
+func (s *customerGroupService) ListPriceTiers(ctx context.Context, skuID uint64) ([]PriceTierResp, error) {
+	sku, err := s.getSKU(ctx, skuID)
+	if err != nil {
+		return nil, err
+	}
+	tiers, err := s.tierRepo.ListBySKU(ctx, skuID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list price tiers: %w", err)
+	}
+	resps := make([]PriceTierResp, len(tiers))
+	for i, tier := range tiers {
+		resps[i] = newPriceTierResp(&tier, sku)
+	}
+	return resps, nil
+}
+
+func (s *customerGroupService) PutPriceTier(ctx context.Context, skuID uint64, group string, price decimal.Decimal) (*PriceTierResp, error) {
+	if err := checkTierGroup(group); err != nil {
+		return nil, err
+	}
+	sku, err := s.getSKU(ctx, skuID)
+	if err != nil {
+		return nil, err
+	}
+	tier := &model.SKUPriceTier{StoreID: sku.StoreID, SKUID: skuID, CustomerGroup: group, Price: price}
+	if err := s.tierRepo.Save(ctx, tier); err != nil {
+		return nil, fmt.Errorf("failed to save price tier: %w", err)
+	}
+	resp := newPriceTierResp(tier, sku)
+	RecordAudit(ctx, AuditEntry{
+		Action:     "sku.price_tier.put",
+		Resource:   "sku",
+		ResourceID: strconv.FormatUint(skuID, 10),
+		After:      resp,
+	})
+	return &resp, nil
+}
+
+func (s *customerGroupService) DeletePriceTier(ctx context.Context, skuID uint64, group string) error {
+	if err := checkTierGroup(group); err != nil {
+		return err
+	}
+	if err := s.tierRepo.Delete(ctx, skuID, group); err != nil {
+		if errors.Is(err, repository.ErrPriceTierNotFound) {
+			return ErrPriceTierNotFound
+		}
+		return fmt.Errorf("failed to delete price tier: %w", err)
+	}
+	RecordAudit(ctx, AuditEntry{
+		Action:     "sku.price_tier.delete",
+		Resource:   "sku",
+		ResourceID: strconv.FormatUint(skuID, 10),
+		Before:     map[string]any{"customer_group": group},
+	})
+	return nil
+}
+
+func (s *customerGroupService) getSKU(ctx context.Context, skuID uint64) (*model.SKU, error) {
+	sku, err := s.productRepo.GetSKUByID(ctx, skuID)
+	if errors.Is(err, repository.ErrSKUNotFound) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SKU by ID %d: %w", skuID, err)
+	}
+	return sku, nil
+}
+
+// checkTierGroup checks SKUs can be priced for group.
+func checkTierGroup(group string) error {
+	if group == model.CustomerGroupRetail || !slices.Contains(CustomerGroups, group) {
+		return fmt.Errorf("%w: %q", ErrUnknownCustomerGroup, group)
+	}
+	return nil
+}
+
+func newPriceTierResp(tier *model.SKUPriceTier, sku *model.SKU) PriceTierResp {
+	return PriceTierResp{
+		SKUID:         tier.SKUID,
+		CustomerGroup: tier.CustomerGroup,
+		Price:         tier.Price,
+		Currency:      sku.Currency,
+		UpdatedAt:     tier.UpdatedAt,
+	}
+}
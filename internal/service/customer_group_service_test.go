@@ -0,0 +1,161 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCustomerGroupService_ApplySKUs(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     uint64
+		mockSetup  func(tiers *mocks.MockPriceTierRepository, users *mocks.MockUserRepository)
+		wantPrices []string
+		wantErr    bool
+	}{
+		{
+			name:   "Wholesale",
+			userID: 5,
+			mockSetup: func(tiers *mocks.MockPriceTierRepository, users *mocks.MockUserRepository) {
+				users.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(&model.User{CustomerGroup: model.CustomerGroupWholesale}, nil)
+				tiers.EXPECT().ListBySKUIDs(gomock.Any(), []uint64{1, 2}, model.CustomerGroupWholesale).
+					Return([]model.SKUPriceTier{{SKUID: 2, CustomerGroup: model.CustomerGroupWholesale, Price: decimal.RequireFromString("7.5")}}, nil)
+			},
+			wantPrices: []string{"10", "7.5"},
+		},
+		{
+			name:   "Retail",
+			userID: 5,
+			mockSetup: func(tiers *mocks.MockPriceTierRepository, users *mocks.MockUserRepository) {
+				users.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(&model.User{CustomerGroup: model.CustomerGroupRetail}, nil)
+			},
+			wantPrices: []string{"10", "20"},
+		},
+		{
+			name:       "Anonymous",
+			mockSetup:  func(tiers *mocks.MockPriceTierRepository, users *mocks.MockUserRepository) {},
+			wantPrices: []string{"10", "20"},
+		},
+		{
+			name:   "UserGone",
+			userID: 5,
+			mockSetup: func(tiers *mocks.MockPriceTierRepository, users *mocks.MockUserRepository) {
+				users.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(nil, repository.ErrUserNotFound)
+			},
+			wantPrices: []string{"10", "20"},
+		},
+		{
+			name:   "TiersUnavailable",
+			userID: 5,
+			mockSetup: func(tiers *mocks.MockPriceTierRepository, users *mocks.MockUserRepository) {
+				users.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(&model.User{CustomerGroup: model.CustomerGroupVIP}, nil)
+				tiers.EXPECT().ListBySKUIDs(gomock.Any(), gomock.Any(), model.CustomerGroupVIP).Return(nil, errors.New("db down"))
+			},
+			wantPrices: []string{"10", "20"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			tiers := mocks.NewMockPriceTierRepository(ctrl)
+			users := mocks.NewMockUserRepository(ctrl)
+			tt.mockSetup(tiers, users)
+			svc := service.NewCustomerGroupService(tiers, users, nil)
+
+			skus := []service.SKUResp{{ID: 1, Price: decimal.NewFromInt(10)}, {ID: 2, Price: decimal.NewFromInt(20)}}
+			err := svc.ApplySKUs(context.Background(), tt.userID, skus)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantPrices, []string{skus[0].Price.String(), skus[1].Price.String()})
+		})
+	}
+}
+
+func TestCustomerGroupService_SetMembership(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	users := mocks.NewMockUserRepository(ctrl)
+	svc := service.NewCustomerGroupService(nil, users, nil)
+
+	users.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(&model.User{Base: model.Base{ID: 5}, Username: "acme", CustomerGroup: model.CustomerGroupRetail}, nil)
+	users.EXPECT().UpdateCustomerGroup(gomock.Any(), uint64(5), model.CustomerGroupWholesale).Return(nil)
+
+	ctx, trail := service.WithAuditTrail(context.Background())
+	resp, err := svc.SetMembership(ctx, 5, model.CustomerGroupWholesale)
+	require.NoError(t, err)
+	assert.Equal(t, &service.CustomerGroupMemberResp{UserID: 5, Username: "acme", CustomerGroup: model.CustomerGroupWholesale}, resp)
+	require.Len(t, trail.Entries(), 1)
+	assert.Equal(t, "user.customer_group", trail.Entries()[0].Action)
+
+	_, err = svc.SetMembership(ctx, 5, "gold")
+	assert.ErrorIs(t, err, service.ErrUnknownCustomerGroup)
+
+	users.EXPECT().GetByID(gomock.Any(), uint64(6)).Return(nil, repository.ErrUserNotFound)
+	_, err = svc.SetMembership(ctx, 6, model.CustomerGroupVIP)
+	assert.ErrorIs(t, err, service.ErrUserNotFound)
+}
+
+func TestCustomerGroupService_PutPriceTier(t *testing.T) {
+	tests := []struct {
+		name      string
+		group     string
+		mockSetup func(tiers *mocks.MockPriceTierRepository, products *mocks.MockProductRepository)
+		wantErr   error
+	}{
+		{
+			name:  "Saved",
+			group: model.CustomerGroupVIP,
+			mockSetup: func(tiers *mocks.MockPriceTierRepository, products *mocks.MockProductRepository) {
+				products.EXPECT().GetSKUByID(gomock.Any(), uint64(9)).Return(&model.SKU{Base: model.Base{ID: 9}, StoreID: 2, Currency: "EUR"}, nil)
+				tiers.EXPECT().Save(gomock.Any(), &model.SKUPriceTier{StoreID: 2, SKUID: 9, CustomerGroup: model.CustomerGroupVIP, Price: decimal.RequireFromString("14.50")}).Return(nil)
+			},
+		},
+		{
+			name:      "RetailPaysSKUPrice",
+			group:     model.CustomerGroupRetail,
+			mockSetup: func(tiers *mocks.MockPriceTierRepository, products *mocks.MockProductRepository) {},
+			wantErr:   service.ErrUnknownCustomerGroup,
+		},
+		{
+			name:  "SKUNotFound",
+			group: model.CustomerGroupWholesale,
+			mockSetup: func(tiers *mocks.MockPriceTierRepository, products *mocks.MockProductRepository) {
+				products.EXPECT().GetSKUByID(gomock.Any(), uint64(9)).Return(nil, repository.ErrSKUNotFound)
+			},
+			wantErr: service.ErrProductNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			tiers := mocks.NewMockPriceTierRepository(ctrl)
+			products := mocks.NewMockProductRepository(ctrl)
+			tt.mockSetup(tiers, products)
+			svc := service.NewCustomerGroupService(tiers, nil, products)
+
+			resp, err := svc.PutPriceTier(context.Background(), 9, tt.group, decimal.RequireFromString("14.50"))
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "EUR", resp.Currency)
+			assert.Equal(t, "14.5", resp.Price.String())
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/cache"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/money"
+)
+
+const (
+	// DashboardCacheTTL is how stale the admin dashboard may be. Its queries
+	// scan today's orders, so it is not recomputed on every page load.
+	DashboardCacheTTL = 30 * time.Second
+	// dashboardLowStockLimit bounds the low-stock list to what the UI shows.
+	dashboardLowStockLimit = 20
+	dashboardCacheKey      = "admin:dashboard"
+)
+
+// DashboardResp is the admin dashboard: today's order activity and the SKUs
+// that need restocking.
+type DashboardResp struct {
+	GeneratedAt time.Time       `json:"generated_at"`
+	Since       time.Time       `json:"since"` // Start of today in the server's time zone
+	Orders      DashboardOrders `json:"orders"`
+	// Revenue totals today's paid and completed orders, converted into Currency.
+	Revenue  money.Money       `json:"revenue"`
+	Tax      money.Money       `json:"tax"`                    // The part of Revenue collected as tax
+	Currency string            `json:"currency" example:"USD"` // The base currency
+	LowStock DashboardLowStock `json:"low_stock"`
+}
+
+// DashboardOrders counts today's orders by status. Cancelled orders are
+// mostly unpaid orders that expired.
+type DashboardOrders struct {
+	Total     int64 `json:"total"`
+	Pending   int64 `json:"pending"`
+	Paid      int64 `json:"paid"`
+	Completed int64 `json:"completed"`
+	Cancelled int64 `json:"cancelled"`
+}
+
+// DashboardLowStock lists the scarcest SKUs at or below the threshold.
+type DashboardLowStock struct {
+	Threshold int           `json:"threshold"`
+	SKUs      []LowStockSKU `json:"skus"`
+}
+
+// LowStockSKU is an SKU that needs restocking.
+type LowStockSKU struct {
+	SKUID       uint64 `json:"sku_id,string"`
+	ProductID   uint64 `json:"product_id,string"`
+	ProductName string `json:"product_name"`
+	Stock       int    `json:"stock"`
+}
+
+// DashboardService aggregates the admin dashboard so the UI needs one call.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/dashboard_service_mock.go -package=mocks
+type DashboardService interface {
+	Get(ctx context.Context) (*DashboardResp, error)
+}
+
+type dashboardService struct {
+	orderRepo         repository.OrderRepository
+	productRepo       repository.ProductRepository
+	cache             cache.Cache
+	currencies        CurrencyService
+	lowStockThreshold int
+}
+
+// NewDashboardService creates a new DashboardService. Revenue is reported in
+// the base currency of currencies. lowStockThreshold is the stock at or below
+// which an SKU is listed; 0 uses DefaultLowStockThreshold.
+func NewDashboardService(orderRepo repository.OrderRepository, productRepo repository.ProductRepository, cache cache.Cache, currencies CurrencyService, lowStockThreshold int) DashboardService {
+	if lowStockThreshold == 0 {
+		lowStockThreshold = DefaultLowStockThreshold
+	}
+	return &dashboardService{
+		orderRepo:         orderRepo,
+		productRepo:       productRepo,
+		cache:             cache,
+		currencies:        currencies,
+		lowStockThreshold: lowStockThreshold,
+	}
+}
+
+// Get returns the dashboard, computed at most once per DashboardCacheTTL. A
+// cache outage only costs the recomputation.
+func (s *dashboardService) Get(ctx context.Context) (*DashboardResp, error) {
+	cacheKey := storeCacheKey(ctx, dashboardCacheKey)
+	if cached, err := s.cache.Get(ctx, cacheKey); err != nil {
+		slog.WarnContext(ctx, "Failed to read cached dashboard", logger.Err(err))
+	} else if cached != "" {
+		var resp DashboardResp
+		if err := json.Unmarshal([]byte(cached), &resp); err == nil {
+			return &resp, nil
+		}
+	}
+
+	resp, err := s.compute(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if encoded, err := json.Marshal(resp); err == nil {
+		if err := s.cache.Set(ctx, cacheKey, string(encoded), DashboardCacheTTL); err != nil {
+			slog.WarnContext(ctx, "Failed to cache dashboard", logger.Err(err))
+		}
+	}
+	return resp, nil
+}
+
+func (s *dashboardService) compute(ctx context.Context, now time.Time) (*DashboardResp, error) {
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	base := s.currencies.Base()
+	resp := &DashboardResp{
+		GeneratedAt: now,
+		Since:       since,
+		Revenue:     money.Zero(base),
+		Tax:         money.Zero(base),
+		Currency:    base,
+		LowStock:    DashboardLowStock{Threshold: s.lowStockThreshold, SKUs: []LowStockSKU{}},
+	}
+
+	summaries, err := s.orderRepo.SummarizeSince(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize today's orders: %w", err)
+	}
+	var rates *Rates // Loaded for the first revenue in another currency
+	for _, summary := range summaries {
+		resp.Orders.Total += summary.Orders
+		switch summary.Status {
+		case model.OrderStatusPending:
+			resp.Orders.Pending += summary.Orders
+		case model.OrderStatusPaid, model.OrderStatusBackordered:
+			resp.Orders.Paid += summary.Orders
+		case model.OrderStatusCompleted:
+			resp.Orders.Completed += summary.Orders
+		case model.OrderStatusCancelled:
+			resp.Orders.Cancelled += summary.Orders
+		}
+		if summary.Status != model.OrderStatusPaid && summary.Status != model.OrderStatusBackordered && summary.Status != model.OrderStatusCompleted {
+			continue
+		}
+
+		amount, tax := summary.Amount, summary.Tax
+		if summary.Currency != resp.Currency {
+			if rates == nil {
+				if rates, err = s.currencies.Rates(ctx); err != nil {
+					return nil, err
+				}
+			}
+			if amount, err = rates.Convert(amount, summary.Currency, resp.Currency); err != nil {
+				return nil, fmt.Errorf("failed to convert %s revenue: %w", summary.Currency, err)
+			}
+			if tax, err = rates.Convert(tax, summary.Currency, resp.Currency); err != nil {
+				return nil, fmt.Errorf("failed to convert %s tax: %w", summary.Currency, err)
+			}
+		}
+		if resp.Revenue, err = resp.Revenue.Add(money.New(amount, base)); err != nil {
+			return nil, err
+		}
+		if resp.Tax, err = resp.Tax.Add(money.New(tax, base)); err != nil {
+			return nil, err
+		}
+	}
+
+	skus, err := s.productRepo.ListLowStockSKUs(ctx, s.lowStockThreshold, dashboardLowStockLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list low-stock SKUs: %w", err)
+	}
+	for _, sku := range skus {
+		resp.LowStock.SKUs = append(resp.LowStock.SKUs, LowStockSKU{
+			SKUID:       sku.ID,
+			ProductID:   sku.SPUID,
+			ProductName: sku.SPU.Name,
+			Stock:       sku.Stock,
+		})
+	}
+	return resp, nil
+}
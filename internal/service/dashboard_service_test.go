@@ -0,0 +1,124 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDashboardService_Get(t *testing.T) {
+	cached, err := json.Marshal(service.DashboardResp{Orders: service.DashboardOrders{Total: 99}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		mockSetup func(orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository, mockCache *mocks.MockCache, rateRepo *mocks.MockExchangeRateRepository)
+		wantErr   bool
+		check     func(t *testing.T, resp *service.DashboardResp)
+	}{
+		{
+			name: "Computed",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository, mockCache *mocks.MockCache, rateRepo *mocks.MockExchangeRateRepository) {
+				mockCache.EXPECT().Get(gomock.Any(), "admin:dashboard").Return("", nil)
+				orderRepo.EXPECT().SummarizeSince(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, since time.Time) ([]repository.OrderStatusSummary, error) {
+					assert.Equal(t, 0, since.Hour()+since.Minute()+since.Second(), "since is midnight")
+					assert.WithinDuration(t, time.Now(), since, 24*time.Hour)
+					return []repository.OrderStatusSummary{
+						{Status: model.OrderStatusCancelled, Currency: "USD", Orders: 1, Amount: decimal.NewFromInt(5)},
+						{Status: model.OrderStatusCompleted, Currency: "USD", Orders: 2, Amount: decimal.RequireFromString("30.50")},
+						{Status: model.OrderStatusPaid, Currency: "EUR", Orders: 1, Amount: decimal.NewFromInt(18), Tax: decimal.NewFromInt(3)},
+						{Status: model.OrderStatusPaid, Currency: "USD", Orders: 2, Amount: decimal.NewFromInt(40), Tax: decimal.NewFromInt(4)},
+						{Status: model.OrderStatusPending, Currency: "USD", Orders: 4, Amount: decimal.NewFromInt(80)},
+					}, nil
+				})
+				productRepo.EXPECT().ListLowStockSKUs(gomock.Any(), 5, gomock.Any()).Return([]model.SKU{
+					{Base: model.Base{ID: 11}, SPUID: 1, Stock: 0, SPU: model.SPU{Name: "Mug"}},
+				}, nil)
+				rateRepo.EXPECT().ListByBase(gomock.Any(), "USD").Return([]model.ExchangeRate{
+					{Base: model.Base{UpdatedAt: time.Now()}, BaseCurrency: "USD", Currency: "EUR", Rate: decimal.RequireFromString("0.9")},
+				}, nil)
+				mockCache.EXPECT().Set(gomock.Any(), "admin:dashboard", gomock.Any(), service.DashboardCacheTTL).Return(nil)
+			},
+			check: func(t *testing.T, resp *service.DashboardResp) {
+				assert.Equal(t, service.DashboardOrders{Total: 10, Pending: 4, Paid: 3, Completed: 2, Cancelled: 1}, resp.Orders)
+				assert.Equal(t, "90.50 USD", resp.Revenue.String(), "EUR 18 counts as USD 20")
+				assert.Equal(t, "7.33 USD", resp.Tax.String(), "EUR 3 counts as USD 3.33")
+				assert.Equal(t, "USD", resp.Currency)
+				assert.Equal(t, service.DashboardLowStock{Threshold: 5, SKUs: []service.LowStockSKU{{SKUID: 11, ProductID: 1, ProductName: "Mug"}}}, resp.LowStock)
+			},
+		},
+		{
+			name: "Cached",
+			mockSetup: func(_ *mocks.MockOrderRepository, _ *mocks.MockProductRepository, mockCache *mocks.MockCache, _ *mocks.MockExchangeRateRepository) {
+				mockCache.EXPECT().Get(gomock.Any(), "admin:dashboard").Return(string(cached), nil)
+			},
+			check: func(t *testing.T, resp *service.DashboardResp) {
+				assert.Equal(t, int64(99), resp.Orders.Total)
+			},
+		},
+		{
+			name: "CacheDown",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository, mockCache *mocks.MockCache, _ *mocks.MockExchangeRateRepository) {
+				mockCache.EXPECT().Get(gomock.Any(), gomock.Any()).Return("", errors.New("circuit open"))
+				orderRepo.EXPECT().SummarizeSince(gomock.Any(), gomock.Any()).Return(nil, nil)
+				productRepo.EXPECT().ListLowStockSKUs(gomock.Any(), 5, gomock.Any()).Return(nil, nil)
+				mockCache.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("circuit open"))
+			},
+			check: func(t *testing.T, resp *service.DashboardResp) {
+				assert.Zero(t, resp.Orders.Total)
+				assert.True(t, resp.Revenue.IsZero())
+				assert.NotNil(t, resp.LowStock.SKUs, "encoded as [] rather than null")
+			},
+		},
+		{
+			name: "RepoError",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, _ *mocks.MockProductRepository, mockCache *mocks.MockCache, _ *mocks.MockExchangeRateRepository) {
+				mockCache.EXPECT().Get(gomock.Any(), gomock.Any()).Return("", nil)
+				orderRepo.EXPECT().SummarizeSince(gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "RevenueNotConvertible",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, _ *mocks.MockProductRepository, mockCache *mocks.MockCache, rateRepo *mocks.MockExchangeRateRepository) {
+				mockCache.EXPECT().Get(gomock.Any(), gomock.Any()).Return("", nil)
+				orderRepo.EXPECT().SummarizeSince(gomock.Any(), gomock.Any()).Return([]repository.OrderStatusSummary{
+					{Status: model.OrderStatusPaid, Currency: "EUR", Orders: 1, Amount: decimal.NewFromInt(18)},
+				}, nil)
+				rateRepo.EXPECT().ListByBase(gomock.Any(), "USD").Return(nil, nil)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			mockCache := mocks.NewMockCache(ctrl)
+			rateRepo := mocks.NewMockExchangeRateRepository(ctrl)
+			tt.mockSetup(orderRepo, productRepo, mockCache, rateRepo)
+
+			currencies := service.NewCurrencyService(rateRepo, nil, nil, service.CurrencyOptions{Base: "USD", Supported: []string{"EUR"}})
+			resp, err := service.NewDashboardService(orderRepo, productRepo, mockCache, currencies, 5).Get(context.Background())
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, resp)
+		})
+	}
+}
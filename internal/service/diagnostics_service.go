@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/cache"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/mq"
+	"github.com/redis/go-redis/v9"
+)
+
+// Diagnostics defaults; the rate limit applies when the configuration leaves
+// it at zero.
+const (
+	DefaultDiagnosticsRateLimit = 30 // Requests per admin per window
+	DefaultDiagnosticsWindow    = time.Minute
+	// DefaultMQHealthReportInterval is how often each worker reports its broker
+	// health. A report expires after three intervals, so a worker that stopped
+	// drops out of the diagnostics.
+	DefaultMQHealthReportInterval = 15 * time.Second
+	// MaxDiagnosticsLocks bounds the locks listed, and so the keys scanned.
+	MaxDiagnosticsLocks = 500
+
+	mqHealthKeyPrefix = "diagnostics:mq:"
+)
+
+// ErrDiagnosticsRateLimited means an admin requested diagnostics too often.
+var ErrDiagnosticsRateLimited = errors.New("too many diagnostics requests, try again later")
+
+// lockTTLs returns the PTTL of each key in KEYS: -1 for a key that never
+// expires, -2 for one released since it was scanned.
+var lockTTLs = redis.NewScript(`
+local ttls = {}
+for i, key in ipairs(KEYS) do
+	ttls[i] = redis.call("PTTL", key)
+end
+return ttls
+`)
+
+// CacheDiagnosticsResp is the health of Redis as seen by the API instance that
+// answered; the circuit breaker is per instance.
+type CacheDiagnosticsResp struct {
+	Host    string       `json:"host"`
+	Breaker *BreakerResp `json:"breaker"` // null when the cache has no circuit breaker
+	Locks   []LockResp   `json:"locks"`
+	// LocksTruncated is set when more than MaxDiagnosticsLocks were found.
+	LocksTruncated bool `json:"locks_truncated"`
+	// LocksError is why the locks could not be listed, e.g. the breaker is open.
+	LocksError string `json:"locks_error,omitempty"`
+}
+
+// BreakerResp is the state of a circuit breaker. The counts cover the current
+// interval of its state.
+type BreakerResp struct {
+	Name                string `json:"name" example:"redis-cache"`
+	State               string `json:"state" example:"closed" enums:"closed,half-open,open"`
+	Requests            uint32 `json:"requests"`
+	TotalFailures       uint32 `json:"total_failures"`
+	ConsecutiveFailures uint32 `json:"consecutive_failures"`
+}
+
+// LockResp is a lock held in Redis.
+type LockResp struct {
+	Key   string `json:"key" example:"lock:payment:order:42"`
+	TTLMs int64  `json:"ttl_ms"` // -1 for a lock that never expires
+}
+
+// MQDiagnosticsResp holds the latest health report of every running worker.
+type MQDiagnosticsResp struct {
+	Workers []MQHealthReport `json:"workers"`
+}
+
+// MQHealthReport is the broker health as seen by one worker.
+type MQHealthReport struct {
+	Host       string        `json:"host"`
+	ReportedAt time.Time     `json:"reported_at"`
+	Connected  bool          `json:"connected"`
+	Queues     []QueueDepth  `json:"queues"`
+	Reconnects []MQReconnect `json:"reconnects"` // Recent ones, newest first
+}
+
+// QueueDepth is what the broker reported about a queue.
+type QueueDepth struct {
+	Name      string `json:"name" example:"orders.created"`
+	Messages  int    `json:"messages"` // Ready for delivery; unacknowledged messages are not counted
+	Consumers int    `json:"consumers"`
+	Error     string `json:"error,omitempty"` // Why the queue could not be inspected
+}
+
+// MQReconnect is a lost broker connection and its recovery.
+type MQReconnect struct {
+	LostAt     time.Time  `json:"lost_at"`
+	RestoredAt *time.Time `json:"restored_at"` // null while reconnecting
+	Cause      string     `json:"cause"`
+	Attempts   int        `json:"attempts"`
+}
+
+// DiagnosticsService lets admins inspect the health of Redis and RabbitMQ
+// without access to either. Every call is rate limited per admin and recorded
+// in the audit log, since the diagnostics scan Redis and expose its keys.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/diagnostics_service_mock.go -package=mocks
+type DiagnosticsService interface {
+	Cache(ctx context.Context) (*CacheDiagnosticsResp, error)
+	MQ(ctx context.Context) (*MQDiagnosticsResp, error)
+}
+
+// MQHealthReporter publishes the broker health of a worker for
+// DiagnosticsService, as the API server has no broker connection.
+type MQHealthReporter interface {
+	Report(ctx context.Context) error
+}
+
+// BrokerInspector is the part of mq.RabbitMQ MQHealthReporter needs.
+type BrokerInspector interface {
+	InspectQueue(name string) (mq.QueueStats, error)
+	Health() mq.Health
+}
+
+// DiagnosticsOptions configures a DiagnosticsService. Zero values fall back
+// to the defaults.
+type DiagnosticsOptions struct {
+	RateLimit int
+	Window    time.Duration
+	// LockPatterns match the lock keys, relative to the cache prefix; by
+	// default the locks of services, "lock:*".
+	LockPatterns []string
+}
+
+type diagnosticsService struct {
+	cache cache.Cache
+	opts  DiagnosticsOptions
+}
+
+// NewDiagnosticsService creates a new DiagnosticsService instance.
+func NewDiagnosticsService(c cache.Cache, opts DiagnosticsOptions) DiagnosticsService {
+	if opts.RateLimit <= 0 {
+		opts.RateLimit = DefaultDiagnosticsRateLimit
+	}
+	if opts.Window <= 0 {
+		opts.Window = DefaultDiagnosticsWindow
+	}
+	if len(opts.LockPatterns) == 0 {
+		opts.LockPatterns = []string{"lock:*"}
+	}
+	return &diagnosticsService{cache: c, opts: opts}
+}
+
+func (s *diagnosticsService) Cache(ctx context.Context) (*CacheDiagnosticsResp, error) {
+	if err := s.admit(ctx, "cache"); err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	resp := &CacheDiagnosticsResp{Host: host, Locks: []LockResp{}}
+	if state, ok := cache.Breaker(s.cache); ok {
+		resp.Breaker = &BreakerResp{
+			Name:                state.Name,
+			State:               state.State,
+			Requests:            state.Requests,
+			TotalFailures:       state.TotalFailures,
+			ConsecutiveFailures: state.ConsecutiveFailures,
+		}
+	}
+	// The breaker is what matters most while Redis is down, so it is reported
+	// even when the locks cannot be listed.
+	locks, truncated, err := s.locks(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to list locks", logger.Err(err))
+		resp.LocksError = err.Error()
+		return resp, nil
+	}
+	resp.Locks, resp.LocksTruncated = locks, truncated
+	return resp, nil
+}
+
+func (s *diagnosticsService) MQ(ctx context.Context) (*MQDiagnosticsResp, error) {
+	if err := s.admit(ctx, "mq"); err != nil {
+		return nil, err
+	}
+	keys, err := s.scan(ctx, mqHealthKeyPrefix+"*", 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worker health reports: %w", err)
+	}
+	resp := &MQDiagnosticsResp{Workers: []MQHealthReport{}}
+	if len(keys) == 0 {
+		return resp, nil
+	}
+	vals, err := s.cache.MGet(ctx, keys...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worker health reports: %w", err)
+	}
+	for i, v := range vals {
+		raw, ok := v.(string)
+		if !ok {
+			continue // Expired since it was scanned
+		}
+		var report MQHealthReport
+		if err := json.Unmarshal([]byte(raw), &report); err != nil {
+			slog.WarnContext(ctx, "Skipped malformed worker health report", "key", keys[i], logger.Err(err))
+			continue
+		}
+		resp.Workers = append(resp.Workers, report)
+	}
+	sort.Slice(resp.Workers, func(i, j int) bool { return resp.Workers[i].Host < resp.Workers[j].Host })
+	return resp, nil
+}
+
+// admit counts one more request of the admin in the current window and
+// records it in the audit trail. When Redis cannot count, the request is let
+// through: the diagnostics are most needed while it is failing.
+func (s *diagnosticsService) admit(ctx context.Context, section string) error {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("mall:diagnostics:%d", userID)
+	res, err := s.cache.Eval(ctx, incrWithTTL, []string{key}, s.opts.Window.Milliseconds())
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to rate limit diagnostics", logger.Err(err))
+	} else if count, ok := res.(int64); ok && count > int64(s.opts.RateLimit) {
+		return ErrDiagnosticsRateLimited
+	}
+	RecordAudit(ctx, AuditEntry{Action: "diagnostics.view", Resource: "diagnostics", ResourceID: section})
+	return nil
+}
+
+// locks returns the locks matching the lock patterns with their TTLs, sorted
+// by key, and whether there were more than MaxDiagnosticsLocks.
+func (s *diagnosticsService) locks(ctx context.Context) ([]LockResp, bool, error) {
+	var keys []string
+	for _, pattern := range s.opts.LockPatterns {
+		found, err := s.scan(ctx, pattern, MaxDiagnosticsLocks+1-len(keys))
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to scan %s: %w", pattern, err)
+		}
+		keys = append(keys, found...)
+		if len(keys) > MaxDiagnosticsLocks {
+			break
+		}
+	}
+	truncated := len(keys) > MaxDiagnosticsLocks
+	if truncated {
+		keys = keys[:MaxDiagnosticsLocks]
+	}
+	locks := []LockResp{}
+	if len(keys) == 0 {
+		return locks, false, nil
+	}
+
+	res, err := s.cache.Eval(ctx, lockTTLs, keys)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get lock TTLs: %w", err)
+	}
+	ttls, ok := res.([]interface{})
+	if !ok || len(ttls) != len(keys) {
+		return nil, false, fmt.Errorf("unexpected lock TTLs %v", res)
+	}
+	for i, key := range keys {
+		ttl, _ := ttls[i].(int64)
+		if ttl == -2 {
+			continue // Released since it was scanned
+		}
+		locks = append(locks, LockResp{Key: key, TTLMs: ttl})
+	}
+	sort.Slice(locks, func(i, j int) bool { return locks[i].Key < locks[j].Key })
+	return locks, truncated, nil
+}
+
+// scan returns the distinct keys matching pattern, stopping once it found
+// limit of them; 0 means no limit.
+func (s *diagnosticsService) scan(ctx context.Context, pattern string, limit int) ([]string, error) {
+	seen := make(map[string]bool)
+	var keys []string
+	var cursor uint64
+	for {
+		page, next, err := s.cache.Scan(ctx, cursor, pattern, cacheSweepPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range page {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+		if next == 0 || (limit > 0 && len(keys) >= limit) {
+			return keys, nil
+		}
+		cursor = next
+	}
+}
+
+type mqHealthReporter struct {
+	broker   BrokerInspector
+	cache    cache.Cache
+	queues   []string
+	host     string
+	interval time.Duration
+}
+
+// NewMQHealthReporter creates a new MQHealthReporter reporting the depths of
+// queues. interval is how often the worker reports; 0 uses
+// DefaultMQHealthReportInterval.
+func NewMQHealthReporter(broker BrokerInspector, c cache.Cache, queues []string, interval time.Duration) MQHealthReporter {
+	if interval <= 0 {
+		interval = DefaultMQHealthReportInterval
+	}
+	host, _ := os.Hostname()
+	return &mqHealthReporter{broker: broker, cache: c, queues: queues, host: host, interval: interval}
+}
+
+func (r *mqHealthReporter) Report(ctx context.Context) error {
+	health := r.broker.Health()
+	report := MQHealthReport{
+		Host:       r.host,
+		ReportedAt: time.Now().UTC(),
+		Connected:  health.Connected,
+		Queues:     make([]QueueDepth, 0, len(r.queues)),
+		Reconnects: make([]MQReconnect, 0, len(health.Reconnects)),
+	}
+	for _, name := range r.queues {
+		depth := QueueDepth{Name: name}
+		if stats, err := r.broker.InspectQueue(name); err != nil {
+			depth.Error = err.Error()
+		} else {
+			depth.Messages, depth.Consumers = stats.Messages, stats.Consumers
+		}
+		report.Queues = append(report.Queues, depth)
+	}
+	for _, rc := range health.Reconnects {
+		reconnect := MQReconnect{LostAt: rc.LostAt.UTC(), Cause: rc.Cause, Attempts: rc.Attempts}
+		if !rc.RestoredAt.IsZero() {
+			restoredAt := rc.RestoredAt.UTC()
+			reconnect.RestoredAt = &restoredAt
+		}
+		report.Reconnects = append(report.Reconnects, reconnect)
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode health report: %w", err)
+	}
+	if err := r.cache.Set(ctx, mqHealthKeyPrefix+r.host, string(body), 3*r.interval); err != nil {
+		return fmt.Errorf("failed to store health report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/auth"
+	"github.com/proyuen/go-mall/pkg/mq"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDiagnosticsService_Cache(t *testing.T) {
+	errRedis := errors.New("redis down")
+	admin := auth.NewContext(context.Background(), &token.Payload{UserID: 7})
+	opts := service.DiagnosticsOptions{RateLimit: 2, Window: time.Minute, LockPatterns: []string{"lock:*", "cron:leader"}}
+
+	tests := []struct {
+		name           string
+		ctx            context.Context
+		mockSetup      func(c *mocks.MockCache)
+		wantLocks      []service.LockResp
+		wantLocksError string
+		wantErr        error
+	}{
+		{
+			name: "ListsLocks",
+			ctx:  admin,
+			mockSetup: func(c *mocks.MockCache) {
+				c.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:diagnostics:7"}, time.Minute.Milliseconds()).Return(int64(1), nil)
+				c.EXPECT().Scan(gomock.Any(), uint64(0), "lock:*", gomock.Any()).Return([]string{"lock:payment:order:2", "lock:payment:order:1"}, uint64(0), nil)
+				c.EXPECT().Scan(gomock.Any(), uint64(0), "cron:leader", gomock.Any()).Return([]string{"cron:leader"}, uint64(0), nil)
+				keys := []string{"lock:payment:order:2", "lock:payment:order:1", "cron:leader"}
+				c.EXPECT().Eval(gomock.Any(), gomock.Any(), keys).Return([]interface{}{int64(100), int64(-2), int64(5000)}, nil)
+			},
+			wantLocks: []service.LockResp{{Key: "cron:leader", TTLMs: 5000}, {Key: "lock:payment:order:2", TTLMs: 100}},
+		},
+		{
+			name: "RedisDown",
+			ctx:  admin,
+			mockSetup: func(c *mocks.MockCache) {
+				c.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:diagnostics:7"}, gomock.Any()).Return(nil, errRedis)
+				c.EXPECT().Scan(gomock.Any(), uint64(0), "lock:*", gomock.Any()).Return(nil, uint64(0), errRedis)
+			},
+			wantLocks:      []service.LockResp{},
+			wantLocksError: "failed to scan lock:*: redis down",
+		},
+		{
+			name: "RateLimited",
+			ctx:  admin,
+			mockSetup: func(c *mocks.MockCache) {
+				c.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:diagnostics:7"}, gomock.Any()).Return(int64(3), nil)
+			},
+			wantErr: service.ErrDiagnosticsRateLimited,
+		},
+		{
+			name:      "Unauthenticated",
+			ctx:       context.Background(),
+			mockSetup: func(c *mocks.MockCache) {},
+			wantErr:   auth.ErrUnauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockCache := mocks.NewMockCache(ctrl)
+			tt.mockSetup(mockCache)
+			svc := service.NewDiagnosticsService(mockCache, opts)
+
+			ctx, trail := service.WithAuditTrail(tt.ctx)
+			resp, err := svc.Cache(ctx)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, trail.Entries())
+				return
+			}
+			require.NoError(t, err)
+			assert.Nil(t, resp.Breaker, "the mock cache has no circuit breaker")
+			assert.Equal(t, tt.wantLocks, resp.Locks)
+			assert.Equal(t, tt.wantLocksError, resp.LocksError)
+			assert.Equal(t, []service.AuditEntry{{Action: "diagnostics.view", Resource: "diagnostics", ResourceID: "cache"}}, trail.Entries())
+		})
+	}
+}
+
+func TestDiagnosticsService_MQ(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache(ctrl)
+	svc := service.NewDiagnosticsService(mockCache, service.DiagnosticsOptions{})
+
+	report := func(host string) string {
+		body, err := json.Marshal(service.MQHealthReport{Host: host, Connected: true, Queues: []service.QueueDepth{{Name: "orders.created", Messages: 3}}})
+		require.NoError(t, err)
+		return string(body)
+	}
+	keys := []string{"diagnostics:mq:worker-2", "diagnostics:mq:worker-3", "diagnostics:mq:worker-1"}
+	mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:diagnostics:7"}, service.DefaultDiagnosticsWindow.Milliseconds()).Return(int64(1), nil)
+	mockCache.EXPECT().Scan(gomock.Any(), uint64(0), "diagnostics:mq:*", gomock.Any()).Return(keys, uint64(0), nil)
+	// worker-3 stopped and its report expired since the scan
+	mockCache.EXPECT().MGet(gomock.Any(), keys).Return([]interface{}{report("worker-2"), nil, report("worker-1")}, nil)
+
+	resp, err := svc.MQ(auth.NewContext(context.Background(), &token.Payload{UserID: 7}))
+	require.NoError(t, err)
+	require.Len(t, resp.Workers, 2)
+	assert.Equal(t, "worker-1", resp.Workers[0].Host)
+	assert.Equal(t, "worker-2", resp.Workers[1].Host)
+	assert.Equal(t, 3, resp.Workers[0].Queues[0].Messages)
+}
+
+func TestMQHealthReporter_Report(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	broker := mocks.NewMockBrokerInspector(ctrl)
+	mockCache := mocks.NewMockCache(ctrl)
+	host, _ := os.Hostname()
+	lostAt, restoredAt := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC), time.Date(2026, 3, 1, 9, 0, 4, 0, time.UTC)
+
+	broker.EXPECT().Health().Return(mq.Health{Connected: true, Reconnects: []mq.Reconnect{
+		{LostAt: lostAt.Add(time.Hour), Cause: "broker shutdown", Attempts: 2},
+		{LostAt: lostAt, RestoredAt: restoredAt, Cause: "connection reset", Attempts: 1},
+	}})
+	broker.EXPECT().InspectQueue("orders.created").Return(mq.QueueStats{Name: "orders.created", Messages: 12, Consumers: 4}, nil)
+	broker.EXPECT().InspectQueue("notifications").Return(mq.QueueStats{}, errors.New("NOT_FOUND"))
+	var stored string
+	mockCache.EXPECT().Set(gomock.Any(), "diagnostics:mq:"+host, gomock.Any(), 3*time.Second).DoAndReturn(func(_ context.Context, _ string, value interface{}, _ time.Duration) error {
+		stored = value.(string)
+		return nil
+	})
+
+	reporter := service.NewMQHealthReporter(broker, mockCache, []string{"orders.created", "notifications"}, time.Second)
+	require.NoError(t, reporter.Report(context.Background()))
+
+	var report service.MQHealthReport
+	require.NoError(t, json.Unmarshal([]byte(stored), &report))
+	assert.Equal(t, host, report.Host)
+	assert.True(t, report.Connected)
+	assert.Equal(t, []service.QueueDepth{
+		{Name: "orders.created", Messages: 12, Consumers: 4},
+		{Name: "notifications", Error: "NOT_FOUND"},
+	}, report.Queues)
+	assert.Equal(t, []service.MQReconnect{
+		{LostAt: lostAt.Add(time.Hour), Cause: "broker shutdown", Attempts: 2},
+		{LostAt: lostAt, RestoredAt: &restoredAt, Cause: "connection reset", Attempts: 1},
+	}, report.Reconnects)
+}
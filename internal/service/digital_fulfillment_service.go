@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/database"
+)
+
+const (
+	// DefaultDigitalDeliveryBatchSize is how many orders DeliverPaid loads
+	// per query when the caller does not say.
+	DefaultDigitalDeliveryBatchSize = 100
+	// DefaultDownloadURLTTL is how long a download link stays valid.
+	DefaultDownloadURLTTL = 7 * 24 * time.Hour
+	// MaxLicenseKeyLength is the longest license key the pool stores.
+	MaxLicenseKeyLength = 255
+)
+
+var (
+	// ErrNotLicenseKeySKU means license keys were added to a SKU that is not
+	// delivered by license key.
+	ErrNotLicenseKeySKU = errors.New("SKU is not delivered by license key")
+	// ErrInvalidLicenseKeys means the keys to add are empty or too long.
+	ErrInvalidLicenseKeys = errors.New("invalid license keys")
+)
+
+// LicenseKeysResp is the outcome of adding license keys to a SKU's pool.
+type LicenseKeysResp struct {
+	SKUID     uint64 `json:"sku_id,string"`
+	Added     int    `json:"added"`     // Keys new to the pool; duplicates are skipped
+	Available int64  `json:"available"` // Keys in the pool not allocated yet
+}
+
+// DigitalOptions configures how download links are signed.
+type DigitalOptions struct {
+	// DownloadBaseURL is where download SKUs' files are served from; a SKU's
+	// DownloadPath is resolved against it.
+	DownloadBaseURL string
+	// DownloadSecret keys the signature the file server checks, see
+	// SignDownloadURL.
+	DownloadSecret string
+	DownloadURLTTL time.Duration
+}
+
+// LicenseKeyService manages the key pools of license_key SKUs.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/digital_fulfillment_service_mock.go -package=mocks
+type LicenseKeyService interface {
+	// AddLicenseKeys adds keys to the pool of a license_key SKU.
+	AddLicenseKeys(ctx context.Context, skuID uint64, keys []string) (*LicenseKeysResp, error)
+}
+
+// DigitalFulfillmentService delivers the digital items of paid orders: a
+// license key per unit from the SKU's pool, or a signed download link, sent
+// to the customer by the notification service instead of shipped.
+type DigitalFulfillmentService interface {
+	// DeliverPaid delivers the digital items of paid orders across all
+	// stores, batchSize orders per query, and returns how many orders it
+	// delivered. Orders whose SKUs are out of license keys wait for more.
+	DeliverPaid(ctx context.Context, batchSize int) (int, error)
+}
+
+type licenseKeyService struct {
+	licenseKeyRepo repository.LicenseKeyRepository
+	productRepo    repository.ProductRepository
+}
+
+// NewLicenseKeyService creates a new LicenseKeyService.
+func NewLicenseKeyService(licenseKeyRepo repository.LicenseKeyRepository, productRepo repository.ProductRepository) LicenseKeyService {
+	return &licenseKeyService{licenseKeyRepo: licenseKeyRepo, productRepo: productRepo}
+}
+
+func (s *licenseKeyService) AddLicenseKeys(ctx context.Context, skuID uint64, keys []string) (*LicenseKeysResp, error) {
+	sku, err := s.productRepo.GetSKUByID(ctx, skuID)
+	if errors.Is(err, repository.ErrSKUNotFound) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sku.Delivery != model.SKUDeliveryLicenseKey {
+		return nil, ErrNotLicenseKeySKU
+	}
+
+	cleaned := make([]string, 0, len(keys))
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if key == "" || len(key) > MaxLicenseKeyLength {
+			return nil, fmt.Errorf("%w: keys must be 1 to %d characters", ErrInvalidLicenseKeys, MaxLicenseKeyLength)
+		}
+		cleaned = append(cleaned, key)
+	}
+	slices.Sort(cleaned)
+	cleaned = slices.Compact(cleaned)
+	if len(cleaned) == 0 {
+		return nil, fmt.Errorf("%w: no keys given", ErrInvalidLicenseKeys)
+	}
+
+	added, err := s.licenseKeyRepo.AddKeys(ctx, skuID, cleaned)
+	if err != nil {
+		return nil, err
+	}
+	available, err := s.licenseKeyRepo.CountAvailable(ctx, skuID)
+	if err != nil {
+		return nil, err
+	}
+	return &LicenseKeysResp{SKUID: skuID, Added: added, Available: available}, nil
+}
+
+type digitalFulfillmentService struct {
+	orderRepo      repository.OrderRepository
+	licenseKeyRepo repository.LicenseKeyRepository
+	productRepo    repository.ProductRepository
+	txManager      database.TransactionManager
+	notifier       notification.Notifier
+	opts           DigitalOptions
+}
+
+// NewDigitalFulfillmentService creates a new DigitalFulfillmentService that
+// sends the items through notifier.
+func NewDigitalFulfillmentService(orderRepo repository.OrderRepository, licenseKeyRepo repository.LicenseKeyRepository, productRepo repository.ProductRepository,
+	txManager database.TransactionManager, notifier notification.Notifier, opts DigitalOptions) DigitalFulfillmentService {
+	if opts.DownloadURLTTL <= 0 {
+		opts.DownloadURLTTL = DefaultDownloadURLTTL
+	}
+	return &digitalFulfillmentService{
+		orderRepo:      orderRepo,
+		licenseKeyRepo: licenseKeyRepo,
+		productRepo:    productRepo,
+		txManager:      txManager,
+		notifier:       notifier,
+		opts:           opts,
+	}
+}
+
+// DeliverPaid delivers at least once: keys are allocated to the order items
+// before the notification is queued, and the items are marked delivered only
+// after, so a failure in between sends the same keys again on the next run.
+func (s *digitalFulfillmentService) DeliverPaid(ctx context.Context, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultDigitalDeliveryBatchSize
+	}
+	delivered := 0
+	var errs []error
+	for afterID := uint64(0); ; {
+		orders, err := s.orderRepo.ListUndelivered(ctx, afterID, batchSize)
+		if err != nil {
+			return delivered, errors.Join(append(errs, err)...)
+		}
+
+		for i := range orders {
+			ok, err := s.deliver(ctx, &orders[i])
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if ok {
+				delivered++
+			}
+		}
+
+		if len(orders) < batchSize {
+			return delivered, errors.Join(errs...)
+		}
+		afterID = orders[len(orders)-1].ID
+	}
+}
+
+// deliver sends the undelivered digital items of one order. It reports false
+// if a SKU has too few license keys left; the order is then delivered whole
+// once the pool is topped up.
+func (s *digitalFulfillmentService) deliver(ctx context.Context, order *model.Order) (bool, error) {
+	items := slices.DeleteFunc(digitalItems(order.Items), func(item model.OrderItem) bool { return item.DeliveredAt != nil })
+	if len(items) == 0 {
+		return false, nil
+	}
+
+	data := &notification.DigitalDeliveryData{OrderNumber: order.OrderNumber}
+	err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		for _, item := range items {
+			line := notification.DigitalItem{Name: item.SnapshotName}
+			switch item.Delivery {
+			case model.SKUDeliveryLicenseKey:
+				keys, err := s.licenseKeyRepo.Allocate(txCtx, item.SKUID, item.ID, item.Quantity)
+				if err != nil {
+					return err
+				}
+				line.LicenseKeys = keys
+			case model.SKUDeliveryDownload:
+				sku, err := s.productRepo.GetSKUByID(txCtx, item.SKUID)
+				if err != nil {
+					return fmt.Errorf("failed to get SKU %d: %w", item.SKUID, err)
+				}
+				expiresAt := time.Now().Add(s.opts.DownloadURLTTL)
+				line.DownloadURL, err = SignDownloadURL(s.opts.DownloadBaseURL, s.opts.DownloadSecret, sku.DownloadPath, expiresAt)
+				if err != nil {
+					return err
+				}
+				line.ExpiresAt = &expiresAt
+			default:
+				return fmt.Errorf("unknown delivery %q of order item %d", item.Delivery, item.ID)
+			}
+			data.Items = append(data.Items, line)
+		}
+		return nil
+	})
+	if errors.Is(err, repository.ErrLicenseKeysExhausted) {
+		slog.WarnContext(ctx, "Not enough license keys to deliver order", "order_id", order.ID)
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to prepare delivery of order %d: %w", order.ID, err)
+	}
+
+	if err := s.notifier.Notify(ctx, order.UserID, notification.KindDigitalDelivery, data); err != nil {
+		return false, fmt.Errorf("failed to send digital items of order %d: %w", order.ID, err)
+	}
+	if err := s.orderRepo.MarkDelivered(ctx, order.ID, time.Now()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SignDownloadURL returns the link to path under baseURL, valid until
+// expiresAt: "<baseURL>/<path>?expires=<unix seconds>&signature=<hex
+// HMAC-SHA256 of "<path>.<unix seconds>">", keyed with secret. The file server
+// recomputes the signature, compares it in constant time and refuses expired
+// links.
+func SignDownloadURL(baseURL, secret, path string, expiresAt time.Time) (string, error) {
+	if baseURL == "" || secret == "" {
+		return "", errors.New("digital.download_base_url and digital.download_secret must be set to deliver downloads")
+	}
+	path = strings.TrimPrefix(path, "/")
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(path))
+	mac.Write([]byte("."))
+	mac.Write([]byte(expires))
+
+	query := url.Values{"expires": {expires}, "signature": {hex.EncodeToString(mac.Sum(nil))}}
+	return strings.TrimSuffix(baseURL, "/") + "/" + path + "?" + query.Encode(), nil
+}
+
+// digitalItems returns the items that are delivered rather than shipped.
+// Bundles are neither: their component lines are.
+func digitalItems(items []model.OrderItem) []model.OrderItem {
+	return slices.DeleteFunc(slices.Clone(items), func(item model.OrderItem) bool {
+		return item.Delivery == "" || item.Delivery == model.SKUDeliveryBundle
+	})
+}
@@ -0,0 +1,151 @@
+package service_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// digitalOrder is an order of a boxed item, two license keys of SKU 101 and
+// a download of SKU 102.
+func digitalOrder(id uint64) model.Order {
+	return model.Order{
+		Base:        model.Base{ID: id},
+		UserID:      9,
+		OrderNumber: "ORD1",
+		Status:      model.OrderStatusPaid,
+		Items: []model.OrderItem{
+			{Base: model.Base{ID: 1}, SKUID: 100, SnapshotName: "Boxed", Quantity: 1},
+			{Base: model.Base{ID: 2}, SKUID: 101, SnapshotName: "Editor", Quantity: 2, Delivery: model.SKUDeliveryLicenseKey},
+			{Base: model.Base{ID: 3}, SKUID: 102, SnapshotName: "Manual", Quantity: 1, Delivery: model.SKUDeliveryDownload},
+		},
+	}
+}
+
+func TestDigitalFulfillmentService_DeliverPaid(t *testing.T) {
+	tests := []struct {
+		name          string
+		allocateErr   error
+		notifyErr     error
+		wantDelivered int
+		wantErr       bool
+	}{
+		{name: "Delivered", wantDelivered: 1},
+		{name: "OutOfKeys", allocateErr: repository.ErrLicenseKeysExhausted},
+		{name: "NotifyFails", notifyErr: assert.AnError, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			licenseKeyRepo := mocks.NewMockLicenseKeyRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			notifier := mocks.NewMockNotifier(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			})
+
+			orderRepo.EXPECT().ListUndelivered(gomock.Any(), uint64(0), 10).Return([]model.Order{digitalOrder(5)}, nil)
+			licenseKeyRepo.EXPECT().Allocate(gomock.Any(), uint64(101), uint64(2), 2).Return([]string{"AAAA", "BBBB"}, tt.allocateErr)
+			if tt.allocateErr == nil {
+				productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(102)).Return(&model.SKU{Delivery: model.SKUDeliveryDownload, DownloadPath: "/manual.pdf"}, nil)
+				notifier.EXPECT().Notify(gomock.Any(), uint64(9), notification.KindDigitalDelivery, gomock.Any()).DoAndReturn(func(_ context.Context, _ uint64, _ notification.Kind, data any) error {
+					delivery := data.(*notification.DigitalDeliveryData)
+					assert.Equal(t, "ORD1", delivery.OrderNumber)
+					require.Len(t, delivery.Items, 2, "the boxed item ships")
+					assert.Equal(t, []string{"AAAA", "BBBB"}, delivery.Items[0].LicenseKeys)
+					assert.Contains(t, delivery.Items[1].DownloadURL, "https://dl.example.com/manual.pdf?expires=")
+					require.NotNil(t, delivery.Items[1].ExpiresAt)
+					assert.WithinDuration(t, time.Now().Add(time.Hour), *delivery.Items[1].ExpiresAt, time.Minute)
+					return tt.notifyErr
+				})
+			}
+			if tt.wantDelivered > 0 {
+				orderRepo.EXPECT().MarkDelivered(gomock.Any(), uint64(5), gomock.Any()).Return(nil)
+			}
+
+			svc := service.NewDigitalFulfillmentService(orderRepo, licenseKeyRepo, productRepo, txManager, notifier, service.DigitalOptions{
+				DownloadBaseURL: "https://dl.example.com/",
+				DownloadSecret:  "secret",
+				DownloadURLTTL:  time.Hour,
+			})
+			delivered, err := svc.DeliverPaid(context.Background(), 10)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantDelivered, delivered)
+		})
+	}
+}
+
+func TestLicenseKeyService_AddLicenseKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		sku       *model.SKU
+		skuErr    error
+		keys      []string
+		wantKeys  []string
+		wantErrIs error
+	}{
+		{name: "Added", sku: &model.SKU{Delivery: model.SKUDeliveryLicenseKey}, keys: []string{" BBBB ", "AAAA", "BBBB"}, wantKeys: []string{"AAAA", "BBBB"}},
+		{name: "NotLicenseKeySKU", sku: &model.SKU{Delivery: model.SKUDeliveryDownload}, keys: []string{"AAAA"}, wantErrIs: service.ErrNotLicenseKeySKU},
+		{name: "BlankKey", sku: &model.SKU{Delivery: model.SKUDeliveryLicenseKey}, keys: []string{"AAAA", " "}, wantErrIs: service.ErrInvalidLicenseKeys},
+		{name: "UnknownSKU", skuErr: repository.ErrSKUNotFound, keys: []string{"AAAA"}, wantErrIs: service.ErrProductNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			licenseKeyRepo := mocks.NewMockLicenseKeyRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(101)).Return(tt.sku, tt.skuErr)
+			if tt.wantErrIs == nil {
+				licenseKeyRepo.EXPECT().AddKeys(gomock.Any(), uint64(101), tt.wantKeys).Return(2, nil)
+				licenseKeyRepo.EXPECT().CountAvailable(gomock.Any(), uint64(101)).Return(int64(5), nil)
+			}
+
+			svc := service.NewLicenseKeyService(licenseKeyRepo, productRepo)
+			resp, err := svc.AddLicenseKeys(context.Background(), 101, tt.keys)
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, service.LicenseKeysResp{SKUID: 101, Added: 2, Available: 5}, *resp)
+		})
+	}
+}
+
+func TestSignDownloadURL(t *testing.T) {
+	expiresAt := time.Unix(1700000000, 0)
+	link, err := service.SignDownloadURL("https://dl.example.com/files/", "secret", "/ebooks/go.pdf", expiresAt)
+	require.NoError(t, err)
+
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	assert.Equal(t, "/files/ebooks/go.pdf", u.Path)
+	assert.Equal(t, "1700000000", u.Query().Get("expires"))
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("ebooks/go.pdf.1700000000"))
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), u.Query().Get("signature"))
+
+	_, err = service.SignDownloadURL("", "secret", "go.pdf", expiresAt)
+	assert.Error(t, err, "downloads need a base URL")
+}
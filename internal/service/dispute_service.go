@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/internal/service/payment"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/proyuen/go-mall/pkg/tenant"
+)
+
+// DefaultDisputePageSize is how many disputes List returns when no limit is
+// given.
+const DefaultDisputePageSize = 20
+
+// Defaults of DisputeReminderOptions.
+const (
+	DefaultDisputeReminderLeadTime = 72 * time.Hour
+	DefaultDisputeReminderInterval = 24 * time.Hour
+)
+
+// disputeReminderBatchSize is how many disputes RemindDue loads at a time.
+const disputeReminderBatchSize = 100
+
+var (
+	ErrDisputeNotFound = repository.ErrDisputeNotFound
+	// ErrDisputeStatus means the dispute does not wait for evidence: it was
+	// decided, or the evidence was recorded already.
+	ErrDisputeStatus = errors.New("dispute does not wait for evidence")
+	// ErrOrderDisputed means refunds of the order are frozen: its payment is
+	// disputed, or the customer's bank already gave the money back.
+	ErrOrderDisputed = errors.New("order payment is disputed; refunds are frozen")
+)
+
+// DisputeResp is a chargeback of an order's payment.
+type DisputeResp struct {
+	ID                  uint64      `json:"id,string"`
+	OrderID             uint64      `json:"order_id,string"`
+	Provider            string      `json:"provider" example:"stripe"`
+	DisputeRef          string      `json:"dispute_ref" example:"dp_1"`
+	PaymentRef          string      `json:"payment_ref" example:"pi_1"`
+	Amount              money.Money `json:"amount"`
+	Reason              string      `json:"reason" example:"fraudulent"`
+	Status              string      `json:"status" example:"needs_response"` // needs_response, under_review, won or lost
+	EvidenceDueBy       *time.Time  `json:"evidence_due_by,omitempty"`
+	EvidenceSubmittedAt *time.Time  `json:"evidence_submitted_at,omitempty"`
+	RemindedAt          *time.Time  `json:"reminded_at,omitempty"`
+	ClosedAt            *time.Time  `json:"closed_at,omitempty"`
+	CreatedAt           time.Time   `json:"created_at"`
+	UpdatedAt           time.Time   `json:"updated_at"`
+}
+
+// DisputeListResp is one page of disputes, most recent first.
+type DisputeListResp struct {
+	Disputes []DisputeResp `json:"disputes"`
+	Total    int64         `json:"total"`
+}
+
+// DisputeService tracks the chargebacks payment providers report, linked to
+// the orders whose payments they dispute, for admins to answer before the
+// evidence is due. Refunds of a disputed order are frozen; see
+// PaymentMethodService.Refund.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/dispute_service_mock.go -package=mocks
+type DisputeService interface {
+	// Record saves a dispute a provider reported, or what changed about it.
+	// Providers may report a dispute more than once and out of order, so a
+	// decided dispute is never reopened. A dispute of a payment no order was
+	// paid with is logged and dropped.
+	Record(ctx context.Context, provider string, dispute *payment.Dispute) error
+	// List returns the matching disputes, most recent first. Zero filter
+	// fields match all.
+	List(ctx context.Context, filter repository.DisputeFilter, offset, limit int) (*DisputeListResp, error)
+	Get(ctx context.Context, id uint64) (*DisputeResp, error)
+	// MarkEvidenceSubmitted records that userID submitted the evidence of a
+	// dispute to the provider, which stops its reminders.
+	MarkEvidenceSubmitted(ctx context.Context, userID, id uint64) (*DisputeResp, error)
+}
+
+// DisputeReminderOptions controls when admins are reminded of the evidence
+// of a dispute. Zero values use the defaults.
+type DisputeReminderOptions struct {
+	LeadTime time.Duration // Start reminding this long before the evidence is due
+	Interval time.Duration // Remind again after this long until it is submitted
+}
+
+// DisputeReminder reminds admins of the disputes whose evidence is due soon.
+// It is apart from DisputeService so the API server does not need RabbitMQ.
+type DisputeReminder interface {
+	// RemindDue notifies the admins of each dispute's store of the disputes
+	// that wait for evidence due within the lead time, unless they were
+	// reminded within the interval, across all stores. It returns how many
+	// disputes it reminded of.
+	RemindDue(ctx context.Context) (int, error)
+}
+
+type disputeService struct {
+	disputeRepo repository.DisputeRepository
+	orderRepo   repository.OrderRepository
+}
+
+// NewDisputeService creates a new DisputeService.
+func NewDisputeService(disputeRepo repository.DisputeRepository, orderRepo repository.OrderRepository) DisputeService {
+	return &disputeService{disputeRepo: disputeRepo, orderRepo: orderRepo}
+}
+
+// Record runs without a store in the context when the notification does not
+// name one, so the order is looked up across stores and the dispute saved in
+// the order's store.
+func (s *disputeService) Record(ctx context.Context, provider string, reported *payment.Dispute) error {
+	existing, err := s.disputeRepo.GetByRef(ctx, provider, reported.ID)
+	if errors.Is(err, repository.ErrDisputeNotFound) {
+		return s.create(ctx, provider, reported)
+	}
+	if err != nil {
+		return err
+	}
+
+	attrs := []any{"provider", provider, "dispute_id", existing.ID, "order_id", existing.OrderID}
+	if !existing.Open() && existing.Status != reported.Status {
+		slog.InfoContext(ctx, "Ignoring stale notification of decided dispute", append(attrs, "status", existing.Status, "reported", reported.Status)...)
+		return nil
+	}
+	before := existing.Status
+	applyReportedDispute(existing, reported, time.Now())
+	if err := s.disputeRepo.UpdateStatus(ctx, existing); err != nil {
+		return err
+	}
+	if existing.Status != before {
+		slog.InfoContext(ctx, "Dispute status changed", append(attrs, "from", before, "to", existing.Status)...)
+	}
+	return nil
+}
+
+func (s *disputeService) create(ctx context.Context, provider string, reported *payment.Dispute) error {
+	order, err := s.orderRepo.GetByPaymentRef(ctx, provider, reported.PaymentRef)
+	if errors.Is(err, repository.ErrOrderNotFound) {
+		slog.WarnContext(ctx, "Dispute of payment of unknown order", "provider", provider, "dispute_ref", reported.ID, "payment_ref", reported.PaymentRef)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get disputed order: %w", err)
+	}
+
+	dispute := &model.Dispute{
+		StoreID:    order.StoreID,
+		OrderID:    order.ID,
+		Provider:   provider,
+		DisputeRef: reported.ID,
+		PaymentRef: reported.PaymentRef,
+	}
+	applyReportedDispute(dispute, reported, time.Now())
+	if err := s.disputeRepo.Create(ctx, dispute); err != nil {
+		return err
+	}
+	paymentsDisputed.WithLabelValues(provider).Inc()
+	slog.WarnContext(ctx, "Order payment disputed", "provider", provider, "dispute_id", dispute.ID, "order_id", order.ID,
+		"reason", dispute.Reason, "status", dispute.Status, "evidence_due_by", dispute.EvidenceDueBy)
+	return nil
+}
+
+// applyReportedDispute copies what the provider reports onto dispute, and
+// records when it closed.
+func applyReportedDispute(dispute *model.Dispute, reported *payment.Dispute, now time.Time) {
+	dispute.Amount, dispute.Currency = reported.Amount.Amount(), reported.Amount.Currency()
+	dispute.Reason, dispute.Status = reported.Reason, reported.Status
+	dispute.EvidenceDueBy = nil
+	if !reported.EvidenceDueBy.IsZero() {
+		dueBy := reported.EvidenceDueBy
+		dispute.EvidenceDueBy = &dueBy
+	}
+	if !dispute.Open() && dispute.ClosedAt == nil {
+		dispute.ClosedAt = &now
+	}
+}
+
+func (s *disputeService) List(ctx context.Context, filter repository.DisputeFilter, offset, limit int) (*DisputeListResp, error) {
+	if limit <= 0 {
+		limit = DefaultDisputePageSize
+	}
+	disputes, total, err := s.disputeRepo.List(ctx, filter, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := &DisputeListResp{Disputes: make([]DisputeResp, len(disputes)), Total: total}
+	for i := range disputes {
+		resp.Disputes[i] = newDisputeResp(&disputes[i])
+	}
+	return resp, nil
+}
+
+func (s *disputeService) Get(ctx context.Context, id uint64) (*DisputeResp, error) {
+	dispute, err := s.disputeRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := newDisputeResp(dispute)
+	return &resp, nil
+}
+
+func (s *disputeService) MarkEvidenceSubmitted(ctx context.Context, userID, id uint64) (*DisputeResp, error) {
+	if err := s.disputeRepo.MarkEvidenceSubmitted(ctx, id, userID, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrDisputeChanged) {
+			if _, err := s.disputeRepo.GetByID(ctx, id); err != nil {
+				return nil, err
+			}
+			return nil, ErrDisputeStatus
+		}
+		return nil, err
+	}
+	dispute, err := s.disputeRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	RecordAudit(ctx, AuditEntry{
+		Action:     "dispute.evidence_submitted",
+		Resource:   "dispute",
+		ResourceID: strconv.FormatUint(id, 10),
+		After:      map[string]any{"evidence_submitted_at": dispute.EvidenceSubmittedAt},
+	})
+	resp := newDisputeResp(dispute)
+	return &resp, nil
+}
+
+// checkRefundable returns ErrOrderDisputed if a dispute of the order is open,
+// or was lost: the bank then gave the money back already, and a refund would
+// pay it out twice.
+func checkRefundable(ctx context.Context, disputeRepo repository.DisputeRepository, orderID uint64) error {
+	disputes, err := disputeRepo.ListByOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	for _, dispute := range disputes {
+		if dispute.Status != model.DisputeStatusWon {
+			return fmt.Errorf("%w: dispute %d is %s", ErrOrderDisputed, dispute.ID, dispute.Status)
+		}
+	}
+	return nil
+}
+
+func newDisputeResp(dispute *model.Dispute) DisputeResp {
+	return DisputeResp{
+		ID:                  dispute.ID,
+		OrderID:             dispute.OrderID,
+		Provider:            dispute.Provider,
+		DisputeRef:          dispute.DisputeRef,
+		PaymentRef:          dispute.PaymentRef,
+		Amount:              money.New(dispute.Amount, dispute.Currency),
+		Reason:              dispute.Reason,
+		Status:              dispute.Status,
+		EvidenceDueBy:       dispute.EvidenceDueBy,
+		EvidenceSubmittedAt: dispute.EvidenceSubmittedAt,
+		RemindedAt:          dispute.RemindedAt,
+		ClosedAt:            dispute.ClosedAt,
+		CreatedAt:           dispute.CreatedAt,
+		UpdatedAt:           dispute.UpdatedAt,
+	}
+}
+
+type disputeReminder struct {
+	disputeRepo repository.DisputeRepository
+	orderRepo   repository.OrderRepository
+	userRepo    repository.UserRepository
+	notifier    notification.Notifier
+	opts        DisputeReminderOptions
+	now         func() time.Time
+}
+
+// NewDisputeReminder creates a new DisputeReminder that queues its reminders
+// with notifier.
+func NewDisputeReminder(disputeRepo repository.DisputeRepository, orderRepo repository.OrderRepository, userRepo repository.UserRepository,
+	notifier notification.Notifier, opts DisputeReminderOptions) DisputeReminder {
+	if opts.LeadTime <= 0 {
+		opts.LeadTime = DefaultDisputeReminderLeadTime
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultDisputeReminderInterval
+	}
+	return &disputeReminder{disputeRepo: disputeRepo, orderRepo: orderRepo, userRepo: userRepo, notifier: notifier, opts: opts, now: time.Now}
+}
+
+// RemindDue stops at the first dispute it fails to remind of; the next run
+// starts over with it.
+func (r *disputeReminder) RemindDue(ctx context.Context) (int, error) {
+	now := r.now()
+	var afterID uint64
+	reminded := 0
+	for {
+		disputes, err := r.disputeRepo.ListAwaitingEvidence(ctx, now, now.Add(r.opts.LeadTime), now.Add(-r.opts.Interval), afterID, disputeReminderBatchSize)
+		if err != nil {
+			return reminded, err
+		}
+		for i := range disputes {
+			if err := r.remind(ctx, &disputes[i], now); err != nil {
+				return reminded, fmt.Errorf("failed to remind of dispute %d: %w", disputes[i].ID, err)
+			}
+			reminded++
+			afterID = disputes[i].ID
+		}
+		if len(disputes) < disputeReminderBatchSize {
+			return reminded, nil
+		}
+	}
+}
+
+// remind notifies the admins of the dispute's store. With none to notify, it
+// logs the dispute instead, and tries again after the interval.
+func (r *disputeReminder) remind(ctx context.Context, dispute *model.Dispute, now time.Time) error {
+	storeCtx := ctx
+	if dispute.StoreID != 0 {
+		storeCtx = tenant.NewContext(ctx, &model.Store{Base: model.Base{ID: dispute.StoreID}})
+	}
+	order, err := r.orderRepo.GetByID(storeCtx, dispute.OrderID)
+	if err != nil {
+		return err
+	}
+	admins, err := r.userRepo.ListByRole(storeCtx, model.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if len(admins) == 0 {
+		slog.WarnContext(ctx, "No admin to remind of dispute evidence", "dispute_id", dispute.ID, "order_id", order.ID, "evidence_due_by", dispute.EvidenceDueBy)
+	}
+	data := &notification.DisputeReminderData{
+		DisputeID:     dispute.ID,
+		OrderNumber:   order.OrderNumber,
+		Provider:      dispute.Provider,
+		Amount:        dispute.Amount,
+		Currency:      dispute.Currency,
+		Reason:        dispute.Reason,
+		EvidenceDueBy: *dispute.EvidenceDueBy,
+	}
+	for _, admin := range admins {
+		if err := r.notifier.Notify(ctx, admin.ID, notification.KindDisputeReminder, data); err != nil {
+			return err
+		}
+	}
+	return r.disputeRepo.MarkReminded(ctx, dispute.ID, now)
+}
@@ -0,0 +1,213 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/internal/service/payment"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDisputeService_Record(t *testing.T) {
+	dueBy := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	reported := func(status string) *payment.Dispute {
+		return &payment.Dispute{
+			ID: "dp_1", PaymentRef: "pi_1", Amount: money.New(decimal.RequireFromString("19.99"), "EUR"),
+			Reason: "fraudulent", Status: status, EvidenceDueBy: dueBy,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		reported  *payment.Dispute
+		mockSetup func(disputeRepo *mocks.MockDisputeRepository, orderRepo *mocks.MockOrderRepository)
+	}{
+		{
+			name:     "New",
+			reported: reported(payment.DisputeNeedsResponse),
+			mockSetup: func(disputeRepo *mocks.MockDisputeRepository, orderRepo *mocks.MockOrderRepository) {
+				disputeRepo.EXPECT().GetByRef(gomock.Any(), "stripe", "dp_1").Return(nil, repository.ErrDisputeNotFound)
+				orderRepo.EXPECT().GetByPaymentRef(gomock.Any(), "stripe", "pi_1").Return(&model.Order{Base: model.Base{ID: 5}, StoreID: 2}, nil)
+				disputeRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, dispute *model.Dispute) error {
+					assert.Equal(t, uint64(5), dispute.OrderID)
+					assert.Equal(t, uint64(2), dispute.StoreID, "the order's store")
+					assert.True(t, dispute.Amount.Equal(decimal.RequireFromString("19.99")))
+					assert.Equal(t, "EUR", dispute.Currency)
+					assert.Equal(t, model.DisputeStatusNeedsResponse, dispute.Status)
+					assert.Equal(t, dueBy, *dispute.EvidenceDueBy)
+					assert.Nil(t, dispute.ClosedAt)
+					return nil
+				})
+			},
+		},
+		{
+			name:     "UnknownPayment",
+			reported: reported(payment.DisputeNeedsResponse),
+			mockSetup: func(disputeRepo *mocks.MockDisputeRepository, orderRepo *mocks.MockOrderRepository) {
+				disputeRepo.EXPECT().GetByRef(gomock.Any(), "stripe", "dp_1").Return(nil, repository.ErrDisputeNotFound)
+				orderRepo.EXPECT().GetByPaymentRef(gomock.Any(), "stripe", "pi_1").Return(nil, repository.ErrOrderNotFound)
+			},
+		},
+		{
+			name:     "Lost",
+			reported: reported(payment.DisputeLost),
+			mockSetup: func(disputeRepo *mocks.MockDisputeRepository, _ *mocks.MockOrderRepository) {
+				disputeRepo.EXPECT().GetByRef(gomock.Any(), "stripe", "dp_1").Return(&model.Dispute{Base: model.Base{ID: 9}, Status: model.DisputeStatusUnderReview}, nil)
+				disputeRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, dispute *model.Dispute) error {
+					assert.Equal(t, model.DisputeStatusLost, dispute.Status)
+					assert.NotNil(t, dispute.ClosedAt)
+					return nil
+				})
+			},
+		},
+		{
+			name:     "StaleAfterDecided",
+			reported: reported(payment.DisputeUnderReview),
+			mockSetup: func(disputeRepo *mocks.MockDisputeRepository, _ *mocks.MockOrderRepository) {
+				disputeRepo.EXPECT().GetByRef(gomock.Any(), "stripe", "dp_1").Return(&model.Dispute{Base: model.Base{ID: 9}, Status: model.DisputeStatusWon}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			disputeRepo := mocks.NewMockDisputeRepository(ctrl)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			tt.mockSetup(disputeRepo, orderRepo)
+
+			err := service.NewDisputeService(disputeRepo, orderRepo).Record(context.Background(), "stripe", tt.reported)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestDisputeService_MarkEvidenceSubmitted(t *testing.T) {
+	tests := []struct {
+		name      string
+		mockSetup func(disputeRepo *mocks.MockDisputeRepository)
+		wantErrIs error
+	}{
+		{
+			name: "Recorded",
+			mockSetup: func(disputeRepo *mocks.MockDisputeRepository) {
+				disputeRepo.EXPECT().MarkEvidenceSubmitted(gomock.Any(), uint64(9), uint64(1), gomock.Any()).Return(nil)
+				now := time.Now()
+				disputeRepo.EXPECT().GetByID(gomock.Any(), uint64(9)).Return(&model.Dispute{Base: model.Base{ID: 9}, Status: model.DisputeStatusNeedsResponse, EvidenceSubmittedAt: &now}, nil)
+			},
+		},
+		{
+			name: "Decided",
+			mockSetup: func(disputeRepo *mocks.MockDisputeRepository) {
+				disputeRepo.EXPECT().MarkEvidenceSubmitted(gomock.Any(), uint64(9), uint64(1), gomock.Any()).Return(repository.ErrDisputeChanged)
+				disputeRepo.EXPECT().GetByID(gomock.Any(), uint64(9)).Return(&model.Dispute{Status: model.DisputeStatusWon}, nil)
+			},
+			wantErrIs: service.ErrDisputeStatus,
+		},
+		{
+			name: "NotFound",
+			mockSetup: func(disputeRepo *mocks.MockDisputeRepository) {
+				disputeRepo.EXPECT().MarkEvidenceSubmitted(gomock.Any(), uint64(9), uint64(1), gomock.Any()).Return(repository.ErrDisputeChanged)
+				disputeRepo.EXPECT().GetByID(gomock.Any(), uint64(9)).Return(nil, repository.ErrDisputeNotFound)
+			},
+			wantErrIs: service.ErrDisputeNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			disputeRepo := mocks.NewMockDisputeRepository(ctrl)
+			tt.mockSetup(disputeRepo)
+
+			ctx, trail := service.WithAuditTrail(context.Background())
+			resp, err := service.NewDisputeService(disputeRepo, nil).MarkEvidenceSubmitted(ctx, 1, 9)
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				assert.Empty(t, trail.Entries())
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, resp.EvidenceSubmittedAt)
+			require.Len(t, trail.Entries(), 1)
+			assert.Equal(t, "dispute.evidence_submitted", trail.Entries()[0].Action)
+		})
+	}
+}
+
+func TestDisputeReminder_RemindDue(t *testing.T) {
+	dueBy := time.Now().Add(48 * time.Hour)
+	dispute := model.Dispute{Base: model.Base{ID: 9}, StoreID: 2, OrderID: 5, Provider: "stripe", Amount: decimal.RequireFromString("19.99"), Currency: "EUR",
+		Status: model.DisputeStatusNeedsResponse, EvidenceDueBy: &dueBy}
+
+	tests := []struct {
+		name      string
+		mockSetup func(disputeRepo *mocks.MockDisputeRepository, userRepo *mocks.MockUserRepository, notifier *mocks.MockNotifier)
+		want      int
+		wantErr   bool
+	}{
+		{
+			name: "NotifiesEveryAdmin",
+			mockSetup: func(disputeRepo *mocks.MockDisputeRepository, userRepo *mocks.MockUserRepository, notifier *mocks.MockNotifier) {
+				userRepo.EXPECT().ListByRole(gomock.Any(), model.RoleAdmin).Return([]model.User{{Base: model.Base{ID: 1}}, {Base: model.Base{ID: 3}}}, nil)
+				for _, adminID := range []uint64{1, 3} {
+					notifier.EXPECT().Notify(gomock.Any(), adminID, notification.KindDisputeReminder, gomock.Any()).DoAndReturn(func(_ context.Context, _ uint64, _ notification.Kind, data any) error {
+						reminder := data.(*notification.DisputeReminderData)
+						assert.Equal(t, "ORD5", reminder.OrderNumber)
+						assert.Equal(t, dueBy, reminder.EvidenceDueBy)
+						return nil
+					})
+				}
+				disputeRepo.EXPECT().MarkReminded(gomock.Any(), uint64(9), gomock.Any()).Return(nil)
+			},
+			want: 1,
+		},
+		{
+			name: "QueueDown",
+			mockSetup: func(_ *mocks.MockDisputeRepository, userRepo *mocks.MockUserRepository, notifier *mocks.MockNotifier) {
+				userRepo.EXPECT().ListByRole(gomock.Any(), model.RoleAdmin).Return([]model.User{{Base: model.Base{ID: 1}}}, nil)
+				notifier.EXPECT().Notify(gomock.Any(), uint64(1), notification.KindDisputeReminder, gomock.Any()).Return(errors.New("connection refused"))
+				// Not marked reminded, so the next run tries again
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			disputeRepo := mocks.NewMockDisputeRepository(ctrl)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			userRepo := mocks.NewMockUserRepository(ctrl)
+			notifier := mocks.NewMockNotifier(ctrl)
+			disputeRepo.EXPECT().ListAwaitingEvidence(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), uint64(0), gomock.Any()).
+				DoAndReturn(func(_ context.Context, now, dueBefore, remindedBefore time.Time, _ uint64, _ int) ([]model.Dispute, error) {
+					assert.Equal(t, service.DefaultDisputeReminderLeadTime, dueBefore.Sub(now))
+					assert.Equal(t, service.DefaultDisputeReminderInterval, now.Sub(remindedBefore))
+					return []model.Dispute{dispute}, nil
+				})
+			orderRepo.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(&model.Order{Base: model.Base{ID: 5}, OrderNumber: "ORD5"}, nil)
+			tt.mockSetup(disputeRepo, userRepo, notifier)
+
+			reminder := service.NewDisputeReminder(disputeRepo, orderRepo, userRepo, notifier, service.DisputeReminderOptions{})
+			reminded, err := reminder.RemindDue(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, reminded)
+		})
+	}
+}
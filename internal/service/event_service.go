@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/tenant"
+	"github.com/proyuen/go-mall/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// EventsExchange is the RabbitMQ topic exchange domain events are published
+// to, with their type as the routing key: consumers bind a queue to the
+// events they handle, e.g. "order.*".
+const EventsExchange = "mall.events"
+
+// Domain event types.
+const (
+	EventUserRegistered = "user.registered"
+	EventProductUpdated = "product.updated"
+	EventOrderCreated   = "order.created"
+	EventOrderPaid      = "order.paid"
+	EventOrderCancelled = "order.cancelled"
+	// EventOrderFailed is a pending order failed because its stock could not
+	// be allocated after it was placed.
+	EventOrderFailed = "order.failed"
+	// EventOrderUpdated is an order changing otherwise: its payment
+	// authorized, its backordered items allocated, or modified by its
+	// customer.
+	EventOrderUpdated = "order.updated"
+)
+
+// DomainEvents lists every domain event type.
+var DomainEvents = []string{EventUserRegistered, EventProductUpdated, EventOrderCreated, EventOrderPaid, EventOrderCancelled, EventOrderFailed, EventOrderUpdated}
+
+// Event relay defaults, used where callers leave a value zero.
+const (
+	DefaultEventRelayBatchSize = 100
+	// DefaultEventRetention is how long published events are kept in the
+	// outbox, to look into what was sent.
+	DefaultEventRetention = 7 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalidEvent is returned when publishing an unknown event type.
+	ErrInvalidEvent = errors.New("unknown domain event")
+	// ErrInvalidReplay is returned when an EventReplayReq is contradictory.
+	ErrInvalidReplay = errors.New("invalid event replay")
+)
+
+// Event is the JSON body of every message published to EventsExchange.
+type Event struct {
+	ID         string    `json:"id"` // Stable when an event is published again, for consumers to deduplicate
+	Type       string    `json:"type" example:"order.paid"`
+	StoreID    uint64    `json:"store_id,string"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"` // UserEventData, ProductEventData or OrderWebhookData
+}
+
+// UserEventData is the data of user.registered.
+type UserEventData struct {
+	UserID   uint64 `json:"user_id,string"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// ProductEventData is the data of product.updated.
+type ProductEventData struct {
+	SPUID  uint64   `json:"spu_id,string"`
+	SKUIDs []uint64 `json:"sku_ids"` // The SKUs that changed
+}
+
+// EventRelayOptions controls one Relay run.
+type EventRelayOptions struct {
+	BatchSize int           // Events loaded per query
+	Retention time.Duration // How long published events are kept
+}
+
+// EventRelayReport summarises one Relay run.
+type EventRelayReport struct {
+	Published int
+	Purged    int64 // Published events deleted after the retention
+}
+
+// EventReplayReq selects the events Replay publishes again. Zero values
+// match everything.
+type EventReplayReq struct {
+	Types       []string  // "order.*" stands for every order event
+	AggregateID uint64    // The user, SPU or order; needs Types of one kind
+	From        time.Time // Inclusive
+	To          time.Time // Exclusive
+	// Queue, if set, receives the events straight through the default
+	// exchange, so only the consumer backfilling sees them.
+	Queue     string
+	BatchSize int // Events loaded per query
+}
+
+// EventReplayReport summarises one Replay.
+type EventReplayReport struct {
+	Replayed int
+}
+
+// EventPublisher is the transactional outbox of domain events: Publish
+// writes an event to the outbox, which EventRelay, run by cmd/worker,
+// publishes to EventsExchange. Called with a transaction context, the event
+// commits or rolls back with the change that produced it. The event is
+// published in the trace of ctx, so its consumers join the trace of the
+// request that produced it.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/event_service_mock.go -package=mocks
+type EventPublisher interface {
+	Publish(ctx context.Context, event string, data any) error
+}
+
+// EventRelay publishes the events in the outbox. Events are delivered at
+// least once; an event whose publishing was not recorded is published again
+// with the same ID.
+type EventRelay interface {
+	// Relay publishes the events in the outbox in the order they were
+	// recorded, until none are left or one fails, then deletes the events
+	// published longer ago than opts.Retention.
+	Relay(ctx context.Context, opts EventRelayOptions) (*EventRelayReport, error)
+	// Replay publishes the matching events in the outbox again, in the order
+	// they were recorded and with their original IDs, so consumers that saw
+	// them already skip them. Published events are only kept for the
+	// retention of Relay.
+	Replay(ctx context.Context, req *EventReplayReq) (*EventReplayReport, error)
+}
+
+type eventPublisher struct {
+	repo repository.OutboxRepository
+}
+
+// NewEventPublisher creates a new EventPublisher instance.
+func NewEventPublisher(repo repository.OutboxRepository) EventPublisher {
+	return &eventPublisher{repo: repo}
+}
+
+func (s *eventPublisher) Publish(ctx context.Context, event string, data any) error {
+	if !slices.Contains(DomainEvents, event) {
+		return fmt.Errorf("%w: %q", ErrInvalidEvent, event)
+	}
+	envelope := Event{ID: uuid.NewString(), Type: event, StoreID: tenant.StoreID(ctx), OccurredAt: time.Now().UTC(), Data: data}
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", event, err)
+	}
+	return s.repo.Create(ctx, &model.OutboxEvent{
+		StoreID:     envelope.StoreID,
+		EventID:     envelope.ID,
+		Type:        event,
+		AggregateID: eventAggregateID(data),
+		Payload:     string(body),
+		TraceParent: tracing.TraceParent(ctx),
+	})
+}
+
+// eventAggregateID returns the ID of the user, SPU or order an event is about.
+func eventAggregateID(data any) uint64 {
+	switch data := data.(type) {
+	case UserEventData:
+		return data.UserID
+	case ProductEventData:
+		return data.SPUID
+	case OrderWebhookData:
+		return data.OrderID
+	}
+	return 0
+}
+
+type eventRelay struct {
+	repo      repository.OutboxRepository
+	publisher notification.Publisher
+	tracer    trace.Tracer
+}
+
+// NewEventRelay creates a new EventRelay instance publishing through
+// publisher.
+func NewEventRelay(repo repository.OutboxRepository, publisher notification.Publisher) EventRelay {
+	return &eventRelay{repo: repo, publisher: publisher, tracer: otel.Tracer("internal/service")}
+}
+
+func (s *eventRelay) Relay(ctx context.Context, opts EventRelayOptions) (*EventRelayReport, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultEventRelayBatchSize
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = DefaultEventRetention
+	}
+
+	report := &EventRelayReport{}
+	for {
+		events, err := s.repo.ListUnpublished(ctx, opts.BatchSize)
+		if err != nil {
+			return report, err
+		}
+
+		// Events are published in order, so the relay stops at the first
+		// that fails rather than publish those after it first.
+		published := make([]uint64, 0, len(events))
+		var publishErr error
+		for i := range events {
+			if publishErr = s.publish(ctx, &events[i]); publishErr != nil {
+				publishErr = fmt.Errorf("failed to publish event %s: %w", events[i].EventID, publishErr)
+				break
+			}
+			published = append(published, events[i].ID)
+		}
+		if err := s.repo.MarkPublished(ctx, published, time.Now()); err != nil {
+			return report, errors.Join(publishErr, err)
+		}
+		report.Published += len(published)
+		if publishErr != nil {
+			return report, publishErr
+		}
+		if len(events) < opts.BatchSize {
+			break
+		}
+	}
+
+	purged, err := s.repo.DeletePublishedBefore(ctx, time.Now().Add(-opts.Retention))
+	if err != nil {
+		return report, err
+	}
+	report.Purged = purged
+	return report, nil
+}
+
+// publish sends event to EventsExchange in a span continuing the trace that
+// recorded it.
+func (s *eventRelay) publish(ctx context.Context, event *model.OutboxEvent) error {
+	ctx, span := s.tracer.Start(tracing.WithTraceParent(ctx, event.TraceParent), event.Type+" publish", trace.WithSpanKind(trace.SpanKindProducer), trace.WithAttributes(
+		attribute.String("messaging.system", "rabbitmq"),
+		attribute.String("messaging.destination.name", EventsExchange),
+		attribute.String("messaging.message.id", event.EventID),
+	))
+	defer span.End()
+
+	err := s.publisher.Publish(ctx, EventsExchange, event.Type, []byte(event.Payload))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+func (s *eventRelay) Replay(ctx context.Context, req *EventReplayReq) (*EventReplayReport, error) {
+	filter, err := newOutboxFilter(req)
+	if err != nil {
+		return nil, err
+	}
+	batchSize := req.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultEventRelayBatchSize
+	}
+
+	report := &EventReplayReport{}
+	var afterID uint64
+	for {
+		events, err := s.repo.List(ctx, filter, afterID, batchSize)
+		if err != nil {
+			return report, err
+		}
+		for i := range events {
+			exchange, routingKey := EventsExchange, events[i].Type
+			if req.Queue != "" {
+				exchange, routingKey = "", req.Queue
+			}
+			if err := s.publisher.Publish(ctx, exchange, routingKey, []byte(events[i].Payload)); err != nil {
+				return report, fmt.Errorf("failed to replay event %s: %w", events[i].EventID, err)
+			}
+			report.Replayed++
+		}
+		if len(events) < batchSize {
+			return report, nil
+		}
+		afterID = events[len(events)-1].ID
+	}
+}
+
+// newOutboxFilter validates req and expands its wildcard types.
+func newOutboxFilter(req *EventReplayReq) (repository.OutboxFilter, error) {
+	filter := repository.OutboxFilter{AggregateID: req.AggregateID, From: req.From, To: req.To}
+	for _, event := range req.Types {
+		kind, wildcard := strings.CutSuffix(event, ".*")
+		if !wildcard {
+			if !slices.Contains(DomainEvents, event) {
+				return filter, fmt.Errorf("%w: %q", ErrInvalidEvent, event)
+			}
+			filter.Types = append(filter.Types, event)
+			continue
+		}
+		n := len(filter.Types)
+		for _, known := range DomainEvents {
+			if eventKind(known) == kind {
+				filter.Types = append(filter.Types, known)
+			}
+		}
+		if len(filter.Types) == n {
+			return filter, fmt.Errorf("%w: %q", ErrInvalidEvent, event)
+		}
+	}
+
+	if req.AggregateID != 0 {
+		// IDs of users, SPUs and orders overlap.
+		if len(filter.Types) == 0 {
+			return filter, fmt.Errorf("%w: an aggregate ID needs the event types", ErrInvalidReplay)
+		}
+		for _, event := range filter.Types {
+			if eventKind(event) != eventKind(filter.Types[0]) {
+				return filter, fmt.Errorf("%w: an aggregate ID needs events of one kind", ErrInvalidReplay)
+			}
+		}
+	}
+	if !req.From.IsZero() && !req.To.IsZero() && !req.To.After(req.From) {
+		return filter, fmt.Errorf("%w: the end of the range must be after its start", ErrInvalidReplay)
+	}
+	return filter, nil
+}
+
+// eventKind returns what an event is about, e.g. "order" for order.paid.
+func eventKind(event string) string {
+	kind, _, _ := strings.Cut(event, ".")
+	return kind
+}
@@ -0,0 +1,262 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+)
+
+func TestEventPublisher(t *testing.T) {
+	t.Run("WritesEnvelopeToOutbox", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockOutboxRepository(ctrl)
+		repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event *model.OutboxEvent) error {
+			assert.Equal(t, service.EventUserRegistered, event.Type)
+			assert.Equal(t, uint64(7), event.StoreID)
+			assert.Equal(t, uint64(42), event.AggregateID)
+
+			var envelope struct {
+				ID      string                `json:"id"`
+				Type    string                `json:"type"`
+				StoreID string                `json:"store_id"`
+				Data    service.UserEventData `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(event.Payload), &envelope))
+			assert.Equal(t, event.EventID, envelope.ID)
+			assert.Equal(t, service.EventUserRegistered, envelope.Type)
+			assert.Equal(t, "7", envelope.StoreID)
+			assert.Equal(t, service.UserEventData{UserID: 42, Username: "alice", Email: "alice@example.com"}, envelope.Data)
+			return nil
+		})
+
+		ctx := tenant.NewContext(context.Background(), &model.Store{Base: model.Base{ID: 7}})
+		err := service.NewEventPublisher(repo).Publish(ctx, service.EventUserRegistered, service.UserEventData{UserID: 42, Username: "alice", Email: "alice@example.com"})
+		require.NoError(t, err)
+	})
+
+	t.Run("RecordsTraceParent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockOutboxRepository(ctrl)
+		repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event *model.OutboxEvent) error {
+			assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", event.TraceParent)
+			return nil
+		})
+
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+			SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+			TraceFlags: trace.FlagsSampled,
+		}))
+		err := service.NewEventPublisher(repo).Publish(ctx, service.EventOrderPaid, service.OrderWebhookData{OrderID: 1})
+		require.NoError(t, err)
+	})
+
+	t.Run("UnknownEvent", func(t *testing.T) {
+		err := service.NewEventPublisher(nil).Publish(context.Background(), "order.shipped", nil)
+		assert.ErrorIs(t, err, service.ErrInvalidEvent)
+	})
+}
+
+func TestEventRelay(t *testing.T) {
+	outbox := func(ids ...uint64) []model.OutboxEvent {
+		events := make([]model.OutboxEvent, 0, len(ids))
+		for _, id := range ids {
+			event := model.OutboxEvent{EventID: fmt.Sprintf("evt-%d", id), Type: service.EventOrderPaid, Payload: `{"id":"evt"}`}
+			event.ID = id
+			events = append(events, event)
+		}
+		return events
+	}
+
+	tests := []struct {
+		name          string
+		mockSetup     func(repo *mocks.MockOutboxRepository, publisher *mocks.MockPublisher)
+		wantPublished int
+		wantPurged    int64
+		errStr        string
+	}{
+		{
+			name: "PagesThroughBatches",
+			mockSetup: func(repo *mocks.MockOutboxRepository, publisher *mocks.MockPublisher) {
+				gomock.InOrder(
+					repo.EXPECT().ListUnpublished(gomock.Any(), 2).Return(outbox(1, 2), nil),
+					publisher.EXPECT().Publish(gomock.Any(), service.EventsExchange, service.EventOrderPaid, []byte(`{"id":"evt"}`)).Return(nil).Times(2),
+					repo.EXPECT().MarkPublished(gomock.Any(), []uint64{1, 2}, gomock.Any()).Return(nil),
+					repo.EXPECT().ListUnpublished(gomock.Any(), 2).Return(outbox(3), nil),
+					publisher.EXPECT().Publish(gomock.Any(), service.EventsExchange, service.EventOrderPaid, gomock.Any()).Return(nil),
+					repo.EXPECT().MarkPublished(gomock.Any(), []uint64{3}, gomock.Any()).Return(nil),
+				)
+				repo.EXPECT().DeletePublishedBefore(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, before time.Time) (int64, error) {
+					assert.WithinDuration(t, time.Now().Add(-service.DefaultEventRetention), before, time.Minute)
+					return 5, nil
+				})
+			},
+			wantPublished: 3,
+			wantPurged:    5,
+		},
+		{
+			name: "ContinuesRecordedTrace",
+			mockSetup: func(repo *mocks.MockOutboxRepository, publisher *mocks.MockPublisher) {
+				events := outbox(1)
+				events[0].TraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+				repo.EXPECT().ListUnpublished(gomock.Any(), 2).Return(events, nil)
+				publisher.EXPECT().Publish(gomock.Any(), service.EventsExchange, service.EventOrderPaid, gomock.Any()).DoAndReturn(func(ctx context.Context, _, _ string, _ []byte) error {
+					assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", trace.SpanContextFromContext(ctx).TraceID().String())
+					return nil
+				})
+				repo.EXPECT().MarkPublished(gomock.Any(), []uint64{1}, gomock.Any()).Return(nil)
+				repo.EXPECT().DeletePublishedBefore(gomock.Any(), gomock.Any()).Return(int64(0), nil)
+			},
+			wantPublished: 1,
+		},
+		{
+			name: "StopsAtFirstFailure",
+			mockSetup: func(repo *mocks.MockOutboxRepository, publisher *mocks.MockPublisher) {
+				repo.EXPECT().ListUnpublished(gomock.Any(), 2).Return(outbox(1, 2), nil)
+				gomock.InOrder(
+					publisher.EXPECT().Publish(gomock.Any(), service.EventsExchange, gomock.Any(), gomock.Any()).Return(nil),
+					publisher.EXPECT().Publish(gomock.Any(), service.EventsExchange, gomock.Any(), gomock.Any()).Return(errors.New("rabbitmq not connected")),
+				)
+				// The published one is not sent again by the next run
+				repo.EXPECT().MarkPublished(gomock.Any(), []uint64{1}, gomock.Any()).Return(nil)
+			},
+			wantPublished: 1,
+			errStr:        "rabbitmq not connected",
+		},
+		{
+			name: "ListFails",
+			mockSetup: func(repo *mocks.MockOutboxRepository, _ *mocks.MockPublisher) {
+				repo.EXPECT().ListUnpublished(gomock.Any(), 2).Return(nil, errors.New("db down"))
+			},
+			errStr: "db down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockOutboxRepository(ctrl)
+			publisher := mocks.NewMockPublisher(ctrl)
+			tt.mockSetup(repo, publisher)
+
+			report, err := service.NewEventRelay(repo, publisher).Relay(context.Background(), service.EventRelayOptions{BatchSize: 2})
+			if tt.errStr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errStr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.NotNil(t, report)
+			assert.Equal(t, tt.wantPublished, report.Published)
+			assert.Equal(t, tt.wantPurged, report.Purged)
+		})
+	}
+}
+
+func TestEventRelay_Replay(t *testing.T) {
+	event := func(id uint64, eventType string) model.OutboxEvent {
+		e := model.OutboxEvent{EventID: fmt.Sprintf("evt-%d", id), Type: eventType, Payload: fmt.Sprintf(`{"id":"evt-%d"}`, id)}
+		e.ID = id
+		return e
+	}
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		req          service.EventReplayReq
+		mockSetup    func(repo *mocks.MockOutboxRepository, publisher *mocks.MockPublisher)
+		wantReplayed int
+		wantErr      error
+	}{
+		{
+			name: "PagesThroughRange",
+			req:  service.EventReplayReq{From: from, To: from.Add(time.Hour), BatchSize: 2},
+			mockSetup: func(repo *mocks.MockOutboxRepository, publisher *mocks.MockPublisher) {
+				filter := repository.OutboxFilter{From: from, To: from.Add(time.Hour)}
+				gomock.InOrder(
+					repo.EXPECT().List(gomock.Any(), filter, uint64(0), 2).Return([]model.OutboxEvent{event(3, service.EventOrderCreated), event(5, service.EventOrderPaid)}, nil),
+					publisher.EXPECT().Publish(gomock.Any(), service.EventsExchange, service.EventOrderCreated, []byte(`{"id":"evt-3"}`)).Return(nil),
+					publisher.EXPECT().Publish(gomock.Any(), service.EventsExchange, service.EventOrderPaid, []byte(`{"id":"evt-5"}`)).Return(nil),
+					repo.EXPECT().List(gomock.Any(), filter, uint64(5), 2).Return(nil, nil),
+				)
+			},
+			wantReplayed: 2,
+		},
+		{
+			name: "AggregateIntoQueue",
+			req:  service.EventReplayReq{Types: []string{"order.*"}, AggregateID: 9, Queue: "search.orders"},
+			mockSetup: func(repo *mocks.MockOutboxRepository, publisher *mocks.MockPublisher) {
+				filter := repository.OutboxFilter{Types: []string{service.EventOrderCreated, service.EventOrderPaid, service.EventOrderCancelled, service.EventOrderFailed, service.EventOrderUpdated}, AggregateID: 9}
+				repo.EXPECT().List(gomock.Any(), filter, uint64(0), service.DefaultEventRelayBatchSize).Return([]model.OutboxEvent{event(4, service.EventOrderCreated)}, nil)
+				publisher.EXPECT().Publish(gomock.Any(), "", "search.orders", []byte(`{"id":"evt-4"}`)).Return(nil)
+			},
+			wantReplayed: 1,
+		},
+		{
+			name: "PublishFails",
+			req:  service.EventReplayReq{Types: []string{service.EventUserRegistered}},
+			mockSetup: func(repo *mocks.MockOutboxRepository, publisher *mocks.MockPublisher) {
+				repo.EXPECT().List(gomock.Any(), gomock.Any(), uint64(0), gomock.Any()).Return([]model.OutboxEvent{event(1, service.EventUserRegistered)}, nil)
+				publisher.EXPECT().Publish(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("rabbitmq not connected"))
+			},
+			wantErr: errors.New("failed to replay event evt-1: rabbitmq not connected"),
+		},
+		{
+			name:    "UnknownType",
+			req:     service.EventReplayReq{Types: []string{"cart.*"}},
+			wantErr: service.ErrInvalidEvent,
+		},
+		{
+			name:    "AggregateWithoutTypes",
+			req:     service.EventReplayReq{AggregateID: 9},
+			wantErr: service.ErrInvalidReplay,
+		},
+		{
+			name:    "AggregateOfTwoKinds",
+			req:     service.EventReplayReq{Types: []string{service.EventUserRegistered, service.EventOrderPaid}, AggregateID: 9},
+			wantErr: service.ErrInvalidReplay,
+		},
+		{
+			name:    "EmptyRange",
+			req:     service.EventReplayReq{From: from, To: from},
+			wantErr: service.ErrInvalidReplay,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockOutboxRepository(ctrl)
+			publisher := mocks.NewMockPublisher(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(repo, publisher)
+			}
+
+			report, err := service.NewEventRelay(repo, publisher).Replay(context.Background(), &tt.req)
+			switch {
+			case tt.wantErr == nil:
+				require.NoError(t, err)
+			case errors.Is(tt.wantErr, service.ErrInvalidEvent), errors.Is(tt.wantErr, service.ErrInvalidReplay):
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			default:
+				assert.EqualError(t, err, tt.wantErr.Error())
+			}
+			require.NotNil(t, report)
+			assert.Equal(t, tt.wantReplayed, report.Replayed)
+		})
+	}
+}
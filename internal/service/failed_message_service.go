@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/tracing"
+)
+
+// Failed message defaults, used where callers leave a value zero.
+const (
+	DefaultFailedMessagePageSize    = 20
+	MaxFailedMessagePageSize        = 100
+	DefaultFailedMessageReplayBatch = 100
+)
+
+var (
+	// ErrFailedMessageNotFound is returned when a failed message does not exist.
+	ErrFailedMessageNotFound = repository.ErrFailedMessageNotFound
+	// ErrFailedMessageQueued is returned when editing or replaying a message
+	// already queued for replay.
+	ErrFailedMessageQueued = errors.New("failed message already queued for replay")
+	// ErrInvalidFailedMessage is returned when an edited body is not JSON.
+	ErrInvalidFailedMessage = errors.New("failed message body must be JSON")
+)
+
+// RejectedMessage is a message a consumer rejected for good, as consumed.
+type RejectedMessage struct {
+	Queue         string
+	MessageID     string
+	CorrelationID string
+	Headers       map[string]string
+	Body          []byte
+	Err           error // Why it was rejected
+}
+
+// FailedMessageResp is an archived message and whether it was replayed.
+type FailedMessageResp struct {
+	ID            uint64            `json:"id,string"`
+	Queue         string            `json:"queue" example:"orders.created"`
+	MessageID     string            `json:"message_id"`
+	CorrelationID string            `json:"correlation_id"`
+	Headers       map[string]string `json:"headers"`
+	Body          string            `json:"body"`
+	Error         string            `json:"error"`
+	Status        string            `json:"status" example:"failed"` // failed, queued for replay, or replayed
+	Replays       int               `json:"replays"`
+	CreatedAt     time.Time         `json:"created_at"`
+	ReplayedAt    *time.Time        `json:"replayed_at,omitempty"`
+}
+
+// FailedMessageListResp is one page of failed messages, newest first.
+type FailedMessageListResp struct {
+	Items []FailedMessageResp `json:"items"`
+	Total int64               `json:"total"`
+}
+
+// FailedMessageReplayReport summarises one Replay run.
+type FailedMessageReplayReport struct {
+	Replayed int
+}
+
+// FailedMessageService archives the messages consumers reject for good, e.g.
+// ones they cannot decode, so that an admin can inspect them, fix their body
+// and replay them. Replaying only queues a message; FailedMessageReplayer,
+// run by cmd/worker, publishes it to its queue again.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/failed_message_service_mock.go -package=mocks
+type FailedMessageService interface {
+	Archive(ctx context.Context, msg *RejectedMessage) error
+	Get(ctx context.Context, id uint64) (*FailedMessageResp, error)
+	List(ctx context.Context, filter repository.FailedMessageFilter, offset, limit int) (*FailedMessageListResp, error)
+	// UpdateBody replaces the body of a message not queued for replay.
+	UpdateBody(ctx context.Context, id uint64, body json.RawMessage) (*FailedMessageResp, error)
+	// Replay queues a message not queued yet to be published again.
+	Replay(ctx context.Context, id uint64) (*FailedMessageResp, error)
+}
+
+// FailedMessageReplayer publishes the messages queued for replay. It is apart
+// from FailedMessageService so the API server does not need RabbitMQ.
+type FailedMessageReplayer interface {
+	// Replay publishes the queued messages to their queue, oldest first,
+	// batchSize at a time.
+	Replay(ctx context.Context, batchSize int) (*FailedMessageReplayReport, error)
+}
+
+// replayableStatuses are the statuses of messages an admin may edit or replay.
+var replayableStatuses = []string{model.FailedMessageStatusFailed, model.FailedMessageStatusReplayed}
+
+type failedMessageService struct {
+	repo repository.FailedMessageRepository
+}
+
+// NewFailedMessageService creates a new FailedMessageService instance.
+func NewFailedMessageService(repo repository.FailedMessageRepository) FailedMessageService {
+	return &failedMessageService{repo: repo}
+}
+
+func (s *failedMessageService) Archive(ctx context.Context, msg *RejectedMessage) error {
+	headers := msg.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	failed := &model.FailedMessage{
+		Queue:         msg.Queue,
+		MessageID:     msg.MessageID,
+		CorrelationID: msg.CorrelationID,
+		Headers:       headers,
+		Body:          string(msg.Body),
+		Status:        model.FailedMessageStatusFailed,
+	}
+	if msg.Err != nil {
+		failed.Error = msg.Err.Error()
+	}
+	return s.repo.Create(ctx, failed)
+}
+
+func (s *failedMessageService) Get(ctx context.Context, id uint64) (*FailedMessageResp, error) {
+	msg, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := newFailedMessageResp(msg)
+	return &resp, nil
+}
+
+func (s *failedMessageService) List(ctx context.Context, filter repository.FailedMessageFilter, offset, limit int) (*FailedMessageListResp, error) {
+	if limit <= 0 {
+		limit = DefaultFailedMessagePageSize
+	}
+	limit = min(limit, MaxFailedMessagePageSize)
+	offset = max(offset, 0)
+
+	msgs, total, err := s.repo.List(ctx, filter, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]FailedMessageResp, len(msgs))
+	for i := range msgs {
+		items[i] = newFailedMessageResp(&msgs[i])
+	}
+	return &FailedMessageListResp{Items: items, Total: total}, nil
+}
+
+func (s *failedMessageService) UpdateBody(ctx context.Context, id uint64, body json.RawMessage) (*FailedMessageResp, error) {
+	if !json.Valid(body) {
+		return nil, ErrInvalidFailedMessage
+	}
+	before, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateBody(ctx, id, string(body), replayableStatuses); err != nil {
+		return nil, failedMessageError(err)
+	}
+
+	after := *before
+	after.Body = string(body)
+	RecordAudit(ctx, AuditEntry{Action: "failed_message.update", Resource: "failed_message", ResourceID: strconv.FormatUint(id, 10), Before: before, After: after})
+	return &after, nil
+}
+
+func (s *failedMessageService) Replay(ctx context.Context, id uint64) (*FailedMessageResp, error) {
+	if err := s.repo.UpdateStatus(ctx, id, replayableStatuses, model.FailedMessageStatusQueued); err != nil {
+		return nil, failedMessageError(err)
+	}
+	resp, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	RecordAudit(ctx, AuditEntry{Action: "failed_message.replay", Resource: "failed_message", ResourceID: strconv.FormatUint(id, 10), After: resp})
+	return resp, nil
+}
+
+// failedMessageError translates the status conflicts of the repository.
+func failedMessageError(err error) error {
+	if errors.Is(err, repository.ErrFailedMessageStatusChanged) {
+		return ErrFailedMessageQueued
+	}
+	return err
+}
+
+func newFailedMessageResp(msg *model.FailedMessage) FailedMessageResp {
+	return FailedMessageResp{
+		ID:            msg.ID,
+		Queue:         msg.Queue,
+		MessageID:     msg.MessageID,
+		CorrelationID: msg.CorrelationID,
+		Headers:       msg.Headers,
+		Body:          msg.Body,
+		Error:         msg.Error,
+		Status:        msg.Status,
+		Replays:       msg.Replays,
+		CreatedAt:     msg.CreatedAt,
+		ReplayedAt:    msg.ReplayedAt,
+	}
+}
+
+type failedMessageReplayer struct {
+	repo      repository.FailedMessageRepository
+	publisher notification.Publisher
+}
+
+// NewFailedMessageReplayer creates a new FailedMessageReplayer instance
+// publishing with publisher.
+func NewFailedMessageReplayer(repo repository.FailedMessageRepository, publisher notification.Publisher) FailedMessageReplayer {
+	return &failedMessageReplayer{repo: repo, publisher: publisher}
+}
+
+func (s *failedMessageReplayer) Replay(ctx context.Context, batchSize int) (*FailedMessageReplayReport, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultFailedMessageReplayBatch
+	}
+
+	report := &FailedMessageReplayReport{}
+	for {
+		msgs, err := s.repo.ListQueued(ctx, batchSize)
+		if err != nil {
+			return report, err
+		}
+		for i := range msgs {
+			if err := s.publish(ctx, &msgs[i]); err != nil {
+				return report, fmt.Errorf("failed to replay message '%d': %w", msgs[i].ID, err)
+			}
+			if err := s.repo.MarkReplayed(ctx, msgs[i].ID, time.Now()); err != nil {
+				return report, err
+			}
+			report.Replayed++
+		}
+		if len(msgs) < batchSize {
+			return report, nil
+		}
+	}
+}
+
+// publish sends msg to its queue in the trace and correlation of the
+// original, so that the replay can be followed from the request behind it.
+func (s *failedMessageReplayer) publish(ctx context.Context, msg *model.FailedMessage) error {
+	ctx = tracing.WithTraceParent(ctx, msg.Headers["traceparent"])
+	if msg.CorrelationID != "" {
+		ctx = logger.WithCorrelationID(ctx, msg.CorrelationID)
+	}
+	return s.publisher.Publish(ctx, "", msg.Queue, []byte(msg.Body))
+}
@@ -0,0 +1,210 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFailedMessageService_Archive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockFailedMessageRepository(ctrl)
+	repo.EXPECT().Create(gomock.Any(), &model.FailedMessage{
+		Queue:     "orders.created",
+		MessageID: "msg-1",
+		Headers:   map[string]string{},
+		Body:      `{"sku_id":`,
+		Error:     "unexpected end of JSON input",
+		Status:    model.FailedMessageStatusFailed,
+	}).Return(nil)
+
+	err := service.NewFailedMessageService(repo).Archive(context.Background(), &service.RejectedMessage{
+		Queue:     "orders.created",
+		MessageID: "msg-1",
+		Body:      []byte(`{"sku_id":`),
+		Err:       errors.New("unexpected end of JSON input"),
+	})
+	require.NoError(t, err)
+}
+
+func TestFailedMessageService_UpdateBody(t *testing.T) {
+	failed := &model.FailedMessage{Base: model.Base{ID: 7}, Queue: "orders.created", Body: `{"sku_id":`, Status: model.FailedMessageStatusFailed}
+	statuses := []string{model.FailedMessageStatusFailed, model.FailedMessageStatusReplayed}
+
+	tests := []struct {
+		name      string
+		body      string
+		mockSetup func(repo *mocks.MockFailedMessageRepository)
+		wantErr   error
+	}{
+		{
+			name: "Success",
+			body: `{"sku_id":1,"quantity":2}`,
+			mockSetup: func(repo *mocks.MockFailedMessageRepository) {
+				repo.EXPECT().GetByID(gomock.Any(), uint64(7)).Return(failed, nil)
+				repo.EXPECT().UpdateBody(gomock.Any(), uint64(7), `{"sku_id":1,"quantity":2}`, statuses).Return(nil)
+			},
+		},
+		{
+			name:    "NotJSON",
+			body:    `{"sku_id":`,
+			wantErr: service.ErrInvalidFailedMessage,
+		},
+		{
+			name: "NotFound",
+			body: `{}`,
+			mockSetup: func(repo *mocks.MockFailedMessageRepository) {
+				repo.EXPECT().GetByID(gomock.Any(), uint64(7)).Return(nil, repository.ErrFailedMessageNotFound)
+			},
+			wantErr: service.ErrFailedMessageNotFound,
+		},
+		{
+			name: "Queued",
+			body: `{}`,
+			mockSetup: func(repo *mocks.MockFailedMessageRepository) {
+				repo.EXPECT().GetByID(gomock.Any(), uint64(7)).Return(failed, nil)
+				repo.EXPECT().UpdateBody(gomock.Any(), uint64(7), `{}`, statuses).Return(repository.ErrFailedMessageStatusChanged)
+			},
+			wantErr: service.ErrFailedMessageQueued,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockFailedMessageRepository(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(repo)
+			}
+			ctx, trail := service.WithAuditTrail(context.Background())
+
+			resp, err := service.NewFailedMessageService(repo).UpdateBody(ctx, 7, json.RawMessage(tt.body))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, trail.Entries())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, resp.Body)
+			require.Len(t, trail.Entries(), 1)
+			assert.Equal(t, "failed_message.update", trail.Entries()[0].Action)
+		})
+	}
+}
+
+func TestFailedMessageService_Replay(t *testing.T) {
+	statuses := []string{model.FailedMessageStatusFailed, model.FailedMessageStatusReplayed}
+
+	tests := []struct {
+		name      string
+		mockSetup func(repo *mocks.MockFailedMessageRepository)
+		wantErr   error
+	}{
+		{
+			name: "Success",
+			mockSetup: func(repo *mocks.MockFailedMessageRepository) {
+				repo.EXPECT().UpdateStatus(gomock.Any(), uint64(7), statuses, model.FailedMessageStatusQueued).Return(nil)
+				repo.EXPECT().GetByID(gomock.Any(), uint64(7)).Return(&model.FailedMessage{Base: model.Base{ID: 7}, Status: model.FailedMessageStatusQueued}, nil)
+			},
+		},
+		{
+			name: "AlreadyQueued",
+			mockSetup: func(repo *mocks.MockFailedMessageRepository) {
+				repo.EXPECT().UpdateStatus(gomock.Any(), uint64(7), statuses, model.FailedMessageStatusQueued).Return(repository.ErrFailedMessageStatusChanged)
+			},
+			wantErr: service.ErrFailedMessageQueued,
+		},
+		{
+			name: "NotFound",
+			mockSetup: func(repo *mocks.MockFailedMessageRepository) {
+				repo.EXPECT().UpdateStatus(gomock.Any(), uint64(7), statuses, model.FailedMessageStatusQueued).Return(repository.ErrFailedMessageNotFound)
+			},
+			wantErr: service.ErrFailedMessageNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockFailedMessageRepository(ctrl)
+			tt.mockSetup(repo)
+			ctx, trail := service.WithAuditTrail(context.Background())
+
+			resp, err := service.NewFailedMessageService(repo).Replay(ctx, 7)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, trail.Entries())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, model.FailedMessageStatusQueued, resp.Status)
+			require.Len(t, trail.Entries(), 1)
+			assert.Equal(t, "failed_message.replay", trail.Entries()[0].Action)
+		})
+	}
+}
+
+func TestFailedMessageReplayer_Replay(t *testing.T) {
+	queued := []model.FailedMessage{
+		{Base: model.Base{ID: 1}, Queue: "orders.created", Body: `{"sku_id":1}`},
+		{Base: model.Base{ID: 2}, Queue: "order_summaries", Body: `{"order_id":2}`},
+	}
+	errMQ := errors.New("rabbitmq not connected")
+
+	tests := []struct {
+		name         string
+		mockSetup    func(repo *mocks.MockFailedMessageRepository, publisher *mocks.MockPublisher)
+		wantReplayed int
+		wantErr      error
+	}{
+		{
+			name: "Success",
+			mockSetup: func(repo *mocks.MockFailedMessageRepository, publisher *mocks.MockPublisher) {
+				repo.EXPECT().ListQueued(gomock.Any(), 2).Return(queued, nil)
+				repo.EXPECT().ListQueued(gomock.Any(), 2).Return(nil, nil)
+				publisher.EXPECT().Publish(gomock.Any(), "", "orders.created", []byte(`{"sku_id":1}`)).Return(nil)
+				publisher.EXPECT().Publish(gomock.Any(), "", "order_summaries", []byte(`{"order_id":2}`)).Return(nil)
+				repo.EXPECT().MarkReplayed(gomock.Any(), uint64(1), gomock.Any()).Return(nil)
+				repo.EXPECT().MarkReplayed(gomock.Any(), uint64(2), gomock.Any()).Return(nil)
+			},
+			wantReplayed: 2,
+		},
+		{
+			name: "PublishFails",
+			mockSetup: func(repo *mocks.MockFailedMessageRepository, publisher *mocks.MockPublisher) {
+				repo.EXPECT().ListQueued(gomock.Any(), 2).Return(queued, nil)
+				publisher.EXPECT().Publish(gomock.Any(), "", "orders.created", gomock.Any()).Return(nil)
+				repo.EXPECT().MarkReplayed(gomock.Any(), uint64(1), gomock.Any()).Return(nil)
+				publisher.EXPECT().Publish(gomock.Any(), "", "order_summaries", gomock.Any()).Return(errMQ)
+			},
+			wantReplayed: 1,
+			wantErr:      errMQ,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockFailedMessageRepository(ctrl)
+			publisher := mocks.NewMockPublisher(ctrl)
+			tt.mockSetup(repo, publisher)
+
+			report, err := service.NewFailedMessageReplayer(repo, publisher).Replay(context.Background(), 2)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantReplayed, report.Replayed)
+		})
+	}
+}
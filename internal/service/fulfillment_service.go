@@ -0,0 +1,537 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service/payment"
+	"github.com/proyuen/go-mall/pkg/database"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/proyuen/go-mall/pkg/snowflake"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrOrderNotShippable means the order is not paid or authorized yet, or
+	// was cancelled.
+	ErrOrderNotShippable = errors.New("order is not awaiting shipment")
+	// ErrNothingToShip means every item of the order has shipped.
+	ErrNothingToShip = errors.New("order has nothing left to ship")
+	// ErrInvalidShipmentItem means a shipment names an item that is not in
+	// the order, or more of it than is left to ship.
+	ErrInvalidShipmentItem = errors.New("invalid shipment item")
+	// ErrOrderNotCancellable means the order is paid or already cancelled;
+	// only pending and authorized orders can be cancelled.
+	ErrOrderNotCancellable = errors.New("order cannot be cancelled")
+	// ErrOrderShipped means part of the order has shipped, so it can no
+	// longer be cancelled.
+	ErrOrderShipped = errors.New("order has shipped")
+	// ErrShipmentNotFound means the shipment does not exist.
+	ErrShipmentNotFound = repository.ErrShipmentNotFound
+)
+
+// ShipOrderReq records a shipment of an order.
+type ShipOrderReq struct {
+	OrderID        uint64
+	TrackingNumber string
+	// Warehouse is where the shipment leaves from. With no Items, only what
+	// is stocked there ships.
+	Warehouse string
+	// Items lists what ships; empty ships everything not shipped yet.
+	Items []ShipmentItemReq
+}
+
+type ShipmentItemReq struct {
+	OrderItemID uint64 `json:"order_item_id,string"`
+	Quantity    int    `json:"quantity"`
+}
+
+// ShipmentResp is a shipment and, for an order paid by authorization, what
+// was captured for it.
+type ShipmentResp struct {
+	ID             uint64            `json:"id,string"`
+	OrderID        uint64            `json:"order_id,string"`
+	Warehouse      string            `json:"warehouse,omitempty" example:"east"`
+	TrackingNumber string            `json:"tracking_number"`
+	Status         string            `json:"status" example:"shipped"` // shipped or delivered
+	Items          []ShipmentItemReq `json:"items"`
+	Captured       *money.Money      `json:"captured,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	DeliveredAt    *time.Time        `json:"delivered_at,omitempty"`
+}
+
+// FulfillmentService ships orders and cancels them before they ship. Orders
+// paid by authorization (see PaymentCaptureShipment) are captured shipment by
+// shipment, for what each shipment holds, and voided when cancelled. An order
+// split into shipments, e.g. one per warehouse, keeps how far they got in its
+// Fulfillment.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/fulfillment_service_mock.go -package=mocks
+type FulfillmentService interface {
+	Ship(ctx context.Context, req *ShipOrderReq) (*ShipmentResp, error)
+	ListShipments(ctx context.Context, orderID uint64) ([]ShipmentResp, error)
+	// SetTrackingNumber replaces the tracking number of a shipment, e.g. one
+	// that shipped from picking before the carrier's label was printed.
+	SetTrackingNumber(ctx context.Context, shipmentID uint64, trackingNumber string) (*ShipmentResp, error)
+	// Deliver records a shipment delivered. Delivering it again changes
+	// nothing.
+	Deliver(ctx context.Context, shipmentID uint64) (*ShipmentResp, error)
+	// Cancel cancels a pending or authorized order that has not shipped,
+	// restores its stock and releases its authorization.
+	Cancel(ctx context.Context, orderID uint64) error
+	// AllocateBackorders deducts stock that has arrived for the backordered
+	// items of paid and authorized orders, batchSize orders per query, and
+	// returns how many orders it allocated. Allocated orders can ship.
+	AllocateBackorders(ctx context.Context, batchSize int) (int, error)
+}
+
+type fulfillmentService struct {
+	orderRepo    repository.OrderRepository
+	shipmentRepo repository.ShipmentRepository
+	productRepo  repository.ProductRepository
+	txManager    database.TransactionManager
+	webhooks     WebhookEmitter
+	events       EventPublisher
+	providers    map[string]payment.Provider
+	purchases    *PurchaseLimiter
+	catalog      *CatalogCache
+}
+
+// NewFulfillmentService creates a new FulfillmentService. Authorizations are
+// captured and voided through providers, keyed by name; order.paid is queued
+// through webhooks and published through events once an authorized order is
+// fully captured, as order.cancelled is when an order is cancelled. Cancelled
+// orders give back what they counted against purchases, and the stock they
+// restore and backorders take is announced through catalog; either may be
+// nil.
+func NewFulfillmentService(orderRepo repository.OrderRepository, shipmentRepo repository.ShipmentRepository, productRepo repository.ProductRepository,
+	txManager database.TransactionManager, webhooks WebhookEmitter, events EventPublisher, providers map[string]payment.Provider, purchases *PurchaseLimiter, catalog *CatalogCache) FulfillmentService {
+	return &fulfillmentService{
+		orderRepo:    orderRepo,
+		shipmentRepo: shipmentRepo,
+		productRepo:  productRepo,
+		txManager:    txManager,
+		webhooks:     webhooks,
+		events:       events,
+		providers:    providers,
+		purchases:    purchases,
+		catalog:      catalog,
+	}
+}
+
+// Ship captures within the transaction that holds the order's row lock, so
+// that concurrent shipments of one order cannot capture the same items
+// twice. The shipment ID is the capture's idempotency reference.
+func (s *fulfillmentService) Ship(ctx context.Context, req *ShipOrderReq) (*ShipmentResp, error) {
+	var shipment *model.Shipment
+	err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		order, err := s.orderRepo.GetByIDForUpdate(txCtx, req.OrderID)
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			return ErrOrderNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+		if order.Status == model.OrderStatusBackordered || paidStatus(order.Items) == model.OrderStatusBackordered {
+			return ErrOrderBackordered
+		}
+		if order.Status != model.OrderStatusAuthorized && order.Status != model.OrderStatusPaid {
+			return ErrOrderNotShippable
+		}
+		shipments, err := s.shipmentRepo.ListByOrder(txCtx, order.ID)
+		if err != nil {
+			return err
+		}
+
+		remaining := remainingQuantities(order.Items, shipments)
+		reqs := req.Items
+		if req.Warehouse != "" && len(reqs) == 0 {
+			if reqs, err = s.warehouseItems(txCtx, order.Items, remaining, req.Warehouse); err != nil {
+				return err
+			}
+			if len(reqs) == 0 {
+				return fmt.Errorf("%w from warehouse %q", ErrNothingToShip, req.Warehouse)
+			}
+		}
+		items, err := shipmentItems(remaining, reqs)
+		if err != nil {
+			return err
+		}
+		shipment = &model.Shipment{
+			Base:           model.Base{ID: snowflake.GenID()},
+			OrderID:        order.ID,
+			Warehouse:      req.Warehouse,
+			TrackingNumber: req.TrackingNumber,
+			Status:         model.ShipmentStatusShipped,
+			Items:          items,
+		}
+		if order.Status == model.OrderStatusAuthorized {
+			if err := s.capture(txCtx, order, shipment, remaining); err != nil {
+				return err
+			}
+		}
+		if err := s.shipmentRepo.Create(txCtx, shipment); err != nil {
+			return err
+		}
+		if err := s.setFulfillment(txCtx, order, remaining, append(shipments, *shipment)); err != nil {
+			return err
+		}
+		if shipment.Capture != nil && shipment.Capture.Final {
+			paid := *order
+			paid.Status = model.OrderStatusPaid
+			data := newOrderWebhookData(&paid, order.Items)
+			if err := s.webhooks.Emit(txCtx, WebhookEventOrderPaid, data); err != nil {
+				return fmt.Errorf("failed to queue order webhook: %w", err)
+			}
+			if err := s.events.Publish(txCtx, EventOrderPaid, data); err != nil {
+				return fmt.Errorf("failed to publish order event: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if shipment != nil && shipment.Capture != nil {
+			// Captured but not recorded: the reference is needed to reconcile
+			slog.ErrorContext(ctx, "Failed to record captured shipment", "order_id", req.OrderID, "shipment_id", shipment.ID,
+				"amount", shipment.Capture.Amount.String(), logger.Err(err))
+		}
+		return nil, err
+	}
+	if shipment.Capture != nil && shipment.Capture.Final {
+		ordersPaid.WithLabelValues(shipment.Capture.Provider).Inc()
+	}
+	resp := newShipmentResp(shipment)
+	return &resp, nil
+}
+
+// warehouseItems returns the items stocked in warehouse that are left to
+// ship, all of what is left of each.
+func (s *fulfillmentService) warehouseItems(ctx context.Context, items []model.OrderItem, remaining map[uint64]int, warehouse string) ([]ShipmentItemReq, error) {
+	var skuIDs []uint64
+	for _, item := range items {
+		if remaining[item.ID] > 0 {
+			skuIDs = append(skuIDs, item.SKUID)
+		}
+	}
+	if len(skuIDs) == 0 {
+		return nil, nil
+	}
+	skus, err := s.productRepo.GetSKUsByIDs(ctx, skuIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SKUs: %w", err)
+	}
+	stocked := make(map[uint64]bool, len(skus))
+	for _, sku := range skus {
+		stocked[sku.ID] = sku.Warehouse == warehouse
+	}
+
+	var reqs []ShipmentItemReq
+	for _, item := range items {
+		if quantity := remaining[item.ID]; quantity > 0 && stocked[item.SKUID] {
+			reqs = append(reqs, ShipmentItemReq{OrderItemID: item.ID, Quantity: quantity})
+		}
+	}
+	return reqs, nil
+}
+
+// setFulfillment records on order how far shipments got, when that changed.
+// remaining is what is left to ship after them.
+func (s *fulfillmentService) setFulfillment(txCtx context.Context, order *model.Order, remaining map[uint64]int, shipments []model.Shipment) error {
+	fulfillment := fulfillmentOf(remaining, shipments)
+	if fulfillment == order.Fulfillment {
+		return nil
+	}
+	if err := s.orderRepo.SetFulfillment(txCtx, order.ID, fulfillment); err != nil {
+		return err
+	}
+	order.Fulfillment = fulfillment
+	return nil
+}
+
+// capture captures the value of shipment's items, tax included, from the
+// order's authorization and attaches the capture to shipment. remaining has
+// had the shipment's items taken off. The last shipment captures whatever is
+// left, so rounding never leaves part of the total uncaptured.
+func (s *fulfillmentService) capture(txCtx context.Context, order *model.Order, shipment *model.Shipment, remaining map[uint64]int) error {
+	capturer, ok := s.providers[order.PaymentProvider].(payment.Capturer)
+	if !ok {
+		return fmt.Errorf("payment provider %q of order %d cannot capture payments", order.PaymentProvider, order.ID)
+	}
+
+	final := true
+	for _, quantity := range remaining {
+		if quantity > 0 {
+			final = false
+		}
+	}
+	uncaptured := order.TotalAmount.Sub(order.CapturedAmount)
+	amount := uncaptured
+	if !final {
+		prices := make(map[uint64]decimal.Decimal, len(order.Items))
+		subtotal := decimal.Zero
+		for _, item := range order.Items {
+			prices[item.ID] = item.Price
+			subtotal = subtotal.Add(item.Price.Mul(decimal.NewFromInt(int64(item.Quantity))))
+		}
+		shipped := decimal.Zero
+		for _, item := range shipment.Items {
+			shipped = shipped.Add(prices[item.OrderItemID].Mul(decimal.NewFromInt(int64(item.Quantity))))
+		}
+		amount = decimal.Zero
+		if subtotal.IsPositive() {
+			amount = decimal.Min(shipped.Mul(order.TotalAmount).Div(subtotal).RoundDown(money.Scale), uncaptured)
+		}
+	}
+
+	if amount.IsPositive() {
+		err := capturer.Capture(txCtx, &payment.CaptureRequest{
+			PaymentRef: order.PaymentRef,
+			Amount:     money.New(amount, order.Currency),
+			Final:      final,
+			Reference:  fmt.Sprint(shipment.ID),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to capture payment of order %d: %w", order.ID, err)
+		}
+		shipment.Capture = &model.PaymentCapture{
+			OrderID:    order.ID,
+			Provider:   order.PaymentProvider,
+			PaymentRef: order.PaymentRef,
+			Amount:     amount,
+			Currency:   order.Currency,
+			Final:      final,
+		}
+	}
+	if amount.IsPositive() || final {
+		if err := s.orderRepo.AddCapture(txCtx, order.ID, amount, final); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fulfillmentService) ListShipments(ctx context.Context, orderID uint64) ([]ShipmentResp, error) {
+	shipments, err := s.shipmentRepo.ListByOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]ShipmentResp, len(shipments))
+	for i := range shipments {
+		resp[i] = newShipmentResp(&shipments[i])
+	}
+	return resp, nil
+}
+
+func (s *fulfillmentService) SetTrackingNumber(ctx context.Context, shipmentID uint64, trackingNumber string) (*ShipmentResp, error) {
+	shipment, err := s.shipmentRepo.GetByID(ctx, shipmentID)
+	if err != nil {
+		return nil, err
+	}
+	shipment.TrackingNumber = trackingNumber
+	if err := s.shipmentRepo.Update(ctx, shipment); err != nil {
+		return nil, err
+	}
+	resp := newShipmentResp(shipment)
+	return &resp, nil
+}
+
+// Deliver holds the order's row lock while it updates the order's
+// fulfillment, as Ship does, so that a delivery and a shipment of one order
+// cannot each miss the other.
+func (s *fulfillmentService) Deliver(ctx context.Context, shipmentID uint64) (*ShipmentResp, error) {
+	shipment, err := s.shipmentRepo.GetByID(ctx, shipmentID)
+	if err != nil {
+		return nil, err
+	}
+	if shipment.Status == model.ShipmentStatusDelivered {
+		resp := newShipmentResp(shipment)
+		return &resp, nil
+	}
+
+	err = s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		order, err := s.orderRepo.GetByIDForUpdate(txCtx, shipment.OrderID)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+		shipments, err := s.shipmentRepo.ListByOrder(txCtx, order.ID)
+		if err != nil {
+			return err
+		}
+		i := slices.IndexFunc(shipments, func(other model.Shipment) bool { return other.ID == shipmentID })
+		if i < 0 {
+			return ErrShipmentNotFound
+		}
+		shipment = &shipments[i]
+		if shipment.Status == model.ShipmentStatusDelivered {
+			return nil
+		}
+
+		now := time.Now()
+		shipment.Status, shipment.DeliveredAt = model.ShipmentStatusDelivered, &now
+		if err := s.shipmentRepo.Update(txCtx, shipment); err != nil {
+			return err
+		}
+		return s.setFulfillment(txCtx, order, remainingQuantities(order.Items, shipments), shipments)
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := newShipmentResp(shipment)
+	return &resp, nil
+}
+
+// Cancel voids the authorization only once the cancellation is committed:
+// should voiding fail, the hold is released when the authorization expires,
+// whereas voiding first could leave an order that ships without payment.
+func (s *fulfillmentService) Cancel(ctx context.Context, orderID uint64) error {
+	var order *model.Order
+	err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		order, err = s.orderRepo.GetByIDForUpdate(txCtx, orderID)
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			return ErrOrderNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+		if order.Status != model.OrderStatusPending && order.Status != model.OrderStatusAuthorized {
+			return ErrOrderNotCancellable
+		}
+		shipments, err := s.shipmentRepo.ListByOrder(txCtx, order.ID)
+		if err != nil {
+			return err
+		}
+		if len(shipments) > 0 {
+			return ErrOrderShipped
+		}
+
+		if err := s.orderRepo.UpdateStatus(txCtx, order.ID, order.Status, model.OrderStatusCancelled); err != nil {
+			return err
+		}
+		for _, item := range stockedItems(order.Items) {
+			if err := s.productRepo.UpdateSKUStock(txCtx, item.SKUID, item.Quantity); err != nil {
+				return fmt.Errorf("failed to restore stock for SKU %d: %w", item.SKUID, err)
+			}
+		}
+		return publishOrderCancelled(txCtx, s.events, order)
+	})
+	if err != nil {
+		return err
+	}
+	ordersCancelled.WithLabelValues(cancelReasonMerchant).Inc()
+	releaseOrderPurchases(ctx, s.purchases, order)
+	publishStockChanged(ctx, s.catalog, stockedItems(order.Items))
+
+	if order.Status == model.OrderStatusAuthorized {
+		capturer, ok := s.providers[order.PaymentProvider].(payment.Capturer)
+		if !ok {
+			slog.ErrorContext(ctx, "Cannot void authorization of cancelled order", "order_id", order.ID, "provider", order.PaymentProvider, "payment_ref", order.PaymentRef)
+			return nil
+		}
+		if err := capturer.Void(ctx, order.PaymentRef); err != nil {
+			slog.ErrorContext(ctx, "Failed to void authorization of cancelled order", "order_id", order.ID, "payment_ref", order.PaymentRef, logger.Err(err))
+		}
+	}
+	return nil
+}
+
+// remainingQuantities returns how much of each order item has not shipped.
+// Digital items are sent rather than shipped, so they are left out.
+func remainingQuantities(items []model.OrderItem, shipments []model.Shipment) map[uint64]int {
+	remaining := make(map[uint64]int, len(items))
+	for _, item := range items {
+		if item.Delivery == "" {
+			remaining[item.ID] += item.Quantity
+		}
+	}
+	for _, shipment := range shipments {
+		for _, item := range shipment.Items {
+			remaining[item.OrderItemID] -= item.Quantity
+		}
+	}
+	return remaining
+}
+
+// shipmentItems checks reqs against remaining and takes them off it. No reqs
+// ships everything remaining.
+func shipmentItems(remaining map[uint64]int, reqs []ShipmentItemReq) ([]model.ShipmentItem, error) {
+	if len(reqs) == 0 {
+		for id, quantity := range remaining {
+			if quantity > 0 {
+				reqs = append(reqs, ShipmentItemReq{OrderItemID: id, Quantity: quantity})
+			}
+		}
+		if len(reqs) == 0 {
+			return nil, ErrNothingToShip
+		}
+	}
+
+	items := make([]model.ShipmentItem, 0, len(reqs))
+	for _, req := range reqs {
+		left, ok := remaining[req.OrderItemID]
+		if !ok || req.Quantity <= 0 || req.Quantity > left {
+			return nil, fmt.Errorf("%w: %d of order item %d", ErrInvalidShipmentItem, req.Quantity, req.OrderItemID)
+		}
+		remaining[req.OrderItemID] = left - req.Quantity
+		items = append(items, model.ShipmentItem{OrderItemID: req.OrderItemID, Quantity: req.Quantity})
+	}
+	return items, nil
+}
+
+// fulfillmentOf tells how far an order's shipments got, given what is left
+// to ship after them.
+func fulfillmentOf(remaining map[uint64]int, shipments []model.Shipment) string {
+	if len(shipments) == 0 {
+		return ""
+	}
+	shippedAll := true
+	for _, quantity := range remaining {
+		if quantity > 0 {
+			shippedAll = false
+		}
+	}
+	delivered := 0
+	for _, shipment := range shipments {
+		if shipment.Status == model.ShipmentStatusDelivered {
+			delivered++
+		}
+	}
+	switch {
+	case shippedAll && delivered == len(shipments):
+		return model.FulfillmentDelivered
+	case delivered > 0:
+		return model.FulfillmentPartiallyDelivered
+	case shippedAll:
+		return model.FulfillmentShipped
+	default:
+		return model.FulfillmentPartiallyShipped
+	}
+}
+
+func newShipmentResp(shipment *model.Shipment) ShipmentResp {
+	resp := ShipmentResp{
+		ID:             shipment.ID,
+		OrderID:        shipment.OrderID,
+		Warehouse:      shipment.Warehouse,
+		TrackingNumber: shipment.TrackingNumber,
+		Status:         shipment.Status,
+		Items:          make([]ShipmentItemReq, len(shipment.Items)),
+		CreatedAt:      shipment.CreatedAt,
+		DeliveredAt:    shipment.DeliveredAt,
+	}
+	for i, item := range shipment.Items {
+		resp.Items[i] = ShipmentItemReq{OrderItemID: item.OrderItemID, Quantity: item.Quantity}
+	}
+	if shipment.Capture != nil {
+		captured := money.New(shipment.Capture.Amount, shipment.Capture.Currency)
+		resp.Captured = &captured
+	}
+	return resp
+}
@@ -0,0 +1,415 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/payment"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/proyuen/go-mall/pkg/snowflake"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// authorizedOrder has a subtotal of 100.00 and a total of 110.00 with tax.
+func authorizedOrder(status string, captured string) *model.Order {
+	return &model.Order{
+		Base: model.Base{ID: 5}, UserID: 7, OrderNumber: "ORD1", Status: status, Currency: "USD",
+		TotalAmount: decimal.RequireFromString("110.00"), CapturedAmount: decimal.RequireFromString(captured),
+		PaymentProvider: "stripe", PaymentRef: "pi_1",
+		Items: []model.OrderItem{
+			{Base: model.Base{ID: 11}, SKUID: 101, Price: decimal.RequireFromString("30.00"), Quantity: 2},
+			{Base: model.Base{ID: 12}, SKUID: 102, Price: decimal.RequireFromString("40.00"), Quantity: 1},
+		},
+	}
+}
+
+func TestFulfillmentService_Ship(t *testing.T) {
+	require.NoError(t, snowflake.Init(1))
+	shippedFirst := []model.Shipment{{OrderID: 5, Items: []model.ShipmentItem{{OrderItemID: 11, Quantity: 2}}}}
+
+	expectCapture := func(capturer *mocks.MockPaymentCapturer, amount string, final bool) {
+		capturer.EXPECT().Capture(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *payment.CaptureRequest) error {
+			assert.Equal(t, "pi_1", req.PaymentRef)
+			assert.True(t, req.Amount.Equal(money.New(decimal.RequireFromString(amount), "USD")), "captured %s", req.Amount)
+			assert.Equal(t, final, req.Final)
+			assert.NotEmpty(t, req.Reference)
+			return nil
+		})
+	}
+	expectAddCapture := func(orderRepo *mocks.MockOrderRepository, amount string, final bool) {
+		orderRepo.EXPECT().AddCapture(gomock.Any(), uint64(5), gomock.Any(), final).DoAndReturn(func(_ context.Context, _ uint64, got decimal.Decimal, _ bool) error {
+			assert.True(t, got.Equal(decimal.RequireFromString(amount)), "added %s", got)
+			return nil
+		})
+	}
+
+	tests := []struct {
+		name         string
+		items        []service.ShipmentItemReq
+		mockSetup    func(orderRepo *mocks.MockOrderRepository, shipmentRepo *mocks.MockShipmentRepository, capturer *mocks.MockPaymentCapturer, webhooks *mocks.MockWebhookEmitter)
+		wantCaptured string
+		wantErr      error
+	}{
+		{
+			name:  "PartialCapture",
+			items: []service.ShipmentItemReq{{OrderItemID: 11, Quantity: 1}},
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, shipmentRepo *mocks.MockShipmentRepository, capturer *mocks.MockPaymentCapturer, _ *mocks.MockWebhookEmitter) {
+				orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(5)).Return(authorizedOrder(model.OrderStatusAuthorized, "0"), nil)
+				shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(5)).Return(nil, nil)
+				// 30.00 of the 100.00 subtotal, with its share of tax
+				expectCapture(capturer, "33.00", false)
+				expectAddCapture(orderRepo, "33.00", false)
+				shipmentRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, shipment *model.Shipment) error {
+					assert.NotZero(t, shipment.ID)
+					assert.Equal(t, []model.ShipmentItem{{OrderItemID: 11, Quantity: 1}}, shipment.Items)
+					require.NotNil(t, shipment.Capture)
+					assert.False(t, shipment.Capture.Final)
+					return nil
+				})
+				orderRepo.EXPECT().SetFulfillment(gomock.Any(), uint64(5), model.FulfillmentPartiallyShipped).Return(nil)
+			},
+			wantCaptured: "33.00",
+		},
+		{
+			name: "FinalCaptureTakesTheRest",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, shipmentRepo *mocks.MockShipmentRepository, capturer *mocks.MockPaymentCapturer, webhooks *mocks.MockWebhookEmitter) {
+				orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(5)).Return(authorizedOrder(model.OrderStatusAuthorized, "66.00"), nil)
+				shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(5)).Return(shippedFirst, nil)
+				expectCapture(capturer, "44.00", true)
+				expectAddCapture(orderRepo, "44.00", true)
+				shipmentRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, shipment *model.Shipment) error {
+					assert.Equal(t, []model.ShipmentItem{{OrderItemID: 12, Quantity: 1}}, shipment.Items)
+					return nil
+				})
+				orderRepo.EXPECT().SetFulfillment(gomock.Any(), uint64(5), model.FulfillmentShipped).Return(nil)
+				webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderPaid, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, data any) error {
+					assert.Equal(t, model.OrderStatusPaid, data.(service.OrderWebhookData).Status)
+					return nil
+				})
+			},
+			wantCaptured: "44.00",
+		},
+		{
+			name: "PaidUpFront",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, shipmentRepo *mocks.MockShipmentRepository, _ *mocks.MockPaymentCapturer, _ *mocks.MockWebhookEmitter) {
+				orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(5)).Return(authorizedOrder(model.OrderStatusPaid, "0"), nil)
+				shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(5)).Return(nil, nil)
+				shipmentRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				orderRepo.EXPECT().SetFulfillment(gomock.Any(), uint64(5), model.FulfillmentShipped).Return(nil)
+			},
+		},
+		{
+			name:  "MoreThanOrdered",
+			items: []service.ShipmentItemReq{{OrderItemID: 11, Quantity: 1}},
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, shipmentRepo *mocks.MockShipmentRepository, _ *mocks.MockPaymentCapturer, _ *mocks.MockWebhookEmitter) {
+				orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(5)).Return(authorizedOrder(model.OrderStatusAuthorized, "66.00"), nil)
+				shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(5)).Return(shippedFirst, nil)
+			},
+			wantErr: service.ErrInvalidShipmentItem,
+		},
+		{
+			name:  "UnknownItem",
+			items: []service.ShipmentItemReq{{OrderItemID: 99, Quantity: 1}},
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, shipmentRepo *mocks.MockShipmentRepository, _ *mocks.MockPaymentCapturer, _ *mocks.MockWebhookEmitter) {
+				orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(5)).Return(authorizedOrder(model.OrderStatusPaid, "0"), nil)
+				shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(5)).Return(nil, nil)
+			},
+			wantErr: service.ErrInvalidShipmentItem,
+		},
+		{
+			name: "NothingLeft",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, shipmentRepo *mocks.MockShipmentRepository, _ *mocks.MockPaymentCapturer, _ *mocks.MockWebhookEmitter) {
+				orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(5)).Return(authorizedOrder(model.OrderStatusPaid, "0"), nil)
+				shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(5)).Return(append(shippedFirst, model.Shipment{
+					Items: []model.ShipmentItem{{OrderItemID: 12, Quantity: 1}},
+				}), nil)
+			},
+			wantErr: service.ErrNothingToShip,
+		},
+		{
+			name: "DigitalItemsDoNotShip",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, shipmentRepo *mocks.MockShipmentRepository, _ *mocks.MockPaymentCapturer, _ *mocks.MockWebhookEmitter) {
+				order := authorizedOrder(model.OrderStatusPaid, "0")
+				order.Items[1].Delivery = model.SKUDeliveryDownload
+				orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(5)).Return(order, nil)
+				shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(5)).Return(shippedFirst, nil)
+			},
+			wantErr: service.ErrNothingToShip,
+		},
+		{
+			name: "Pending",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, _ *mocks.MockShipmentRepository, _ *mocks.MockPaymentCapturer, _ *mocks.MockWebhookEmitter) {
+				orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(5)).Return(authorizedOrder(model.OrderStatusPending, "0"), nil)
+			},
+			wantErr: service.ErrOrderNotShippable,
+		},
+		{
+			name: "CaptureFails",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, shipmentRepo *mocks.MockShipmentRepository, capturer *mocks.MockPaymentCapturer, _ *mocks.MockWebhookEmitter) {
+				orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(5)).Return(authorizedOrder(model.OrderStatusAuthorized, "0"), nil)
+				shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(5)).Return(nil, nil)
+				capturer.EXPECT().Capture(gomock.Any(), gomock.Any()).Return(payment.ErrDeclined)
+				// Nothing is recorded
+			},
+			wantErr: payment.ErrDeclined,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			shipmentRepo := mocks.NewMockShipmentRepository(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			})
+			webhooks := mocks.NewMockWebhookEmitter(ctrl)
+			events := mocks.NewMockEventPublisher(ctrl)
+			events.EXPECT().Publish(gomock.Any(), service.EventOrderPaid, gomock.Any()).Return(nil).AnyTimes()
+			capturer := mocks.NewMockPaymentCapturer(ctrl)
+			tt.mockSetup(orderRepo, shipmentRepo, capturer, webhooks)
+			fulfillment := service.NewFulfillmentService(orderRepo, shipmentRepo, nil, txManager, webhooks, events, map[string]payment.Provider{"stripe": capturer}, nil, nil)
+
+			resp, err := fulfillment.Ship(context.Background(), &service.ShipOrderReq{OrderID: 5, TrackingNumber: "SF1", Items: tt.items})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "SF1", resp.TrackingNumber)
+			if tt.wantCaptured == "" {
+				assert.Nil(t, resp.Captured)
+				return
+			}
+			require.NotNil(t, resp.Captured)
+			assert.Equal(t, fmt.Sprintf("%s USD", tt.wantCaptured), resp.Captured.String())
+		})
+	}
+}
+
+func TestFulfillmentService_ShipFromWarehouse(t *testing.T) {
+	require.NoError(t, snowflake.Init(1))
+	skus := []model.SKU{{Base: model.Base{ID: 101}, Warehouse: "east"}, {Base: model.Base{ID: 102}, Warehouse: "west"}}
+
+	tests := []struct {
+		name            string
+		warehouse       string
+		shipped         []model.Shipment
+		wantItems       []model.ShipmentItem
+		wantFulfillment string
+		wantErr         error
+	}{
+		{
+			name:            "FirstOfTwo",
+			warehouse:       "east",
+			wantItems:       []model.ShipmentItem{{OrderItemID: 11, Quantity: 2}},
+			wantFulfillment: model.FulfillmentPartiallyShipped,
+		},
+		{
+			name:            "LastOfTwo",
+			warehouse:       "west",
+			shipped:         []model.Shipment{{Warehouse: "east", Status: model.ShipmentStatusDelivered, Items: []model.ShipmentItem{{OrderItemID: 11, Quantity: 2}}}},
+			wantItems:       []model.ShipmentItem{{OrderItemID: 12, Quantity: 1}},
+			wantFulfillment: model.FulfillmentPartiallyDelivered,
+		},
+		{
+			name:      "NothingThere",
+			warehouse: "east",
+			shipped:   []model.Shipment{{Warehouse: "east", Items: []model.ShipmentItem{{OrderItemID: 11, Quantity: 2}}}},
+			wantErr:   service.ErrNothingToShip,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			shipmentRepo := mocks.NewMockShipmentRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			})
+			orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(5)).Return(authorizedOrder(model.OrderStatusPaid, "0"), nil)
+			shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(5)).Return(tt.shipped, nil)
+			productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), gomock.Any()).Return(skus, nil)
+			if tt.wantErr == nil {
+				shipmentRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, shipment *model.Shipment) error {
+					assert.Equal(t, tt.warehouse, shipment.Warehouse)
+					assert.Equal(t, model.ShipmentStatusShipped, shipment.Status)
+					assert.Equal(t, tt.wantItems, shipment.Items)
+					return nil
+				})
+				orderRepo.EXPECT().SetFulfillment(gomock.Any(), uint64(5), tt.wantFulfillment).Return(nil)
+			}
+			fulfillment := service.NewFulfillmentService(orderRepo, shipmentRepo, productRepo, txManager, nil, nil, nil, nil, nil)
+
+			resp, err := fulfillment.Ship(context.Background(), &service.ShipOrderReq{OrderID: 5, Warehouse: tt.warehouse})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.warehouse, resp.Warehouse)
+		})
+	}
+}
+
+func TestFulfillmentService_Deliver(t *testing.T) {
+	east := model.Shipment{Base: model.Base{ID: 21}, OrderID: 5, Status: model.ShipmentStatusShipped, Items: []model.ShipmentItem{{OrderItemID: 11, Quantity: 2}}}
+	west := model.Shipment{Base: model.Base{ID: 22}, OrderID: 5, Status: model.ShipmentStatusShipped, Items: []model.ShipmentItem{{OrderItemID: 12, Quantity: 1}}}
+	delivered := func(shipment model.Shipment) model.Shipment {
+		shipment.Status = model.ShipmentStatusDelivered
+		return shipment
+	}
+
+	tests := []struct {
+		name            string
+		shipments       []model.Shipment
+		fulfillment     string
+		wantFulfillment string
+	}{
+		{name: "FirstOfTwo", shipments: []model.Shipment{east, west}, fulfillment: model.FulfillmentShipped, wantFulfillment: model.FulfillmentPartiallyDelivered},
+		{name: "LastOfTwo", shipments: []model.Shipment{east, delivered(west)}, fulfillment: model.FulfillmentPartiallyDelivered, wantFulfillment: model.FulfillmentDelivered},
+		{name: "MoreToShip", shipments: []model.Shipment{east}, fulfillment: model.FulfillmentPartiallyShipped, wantFulfillment: model.FulfillmentPartiallyDelivered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			shipmentRepo := mocks.NewMockShipmentRepository(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			})
+			order := authorizedOrder(model.OrderStatusPaid, "0")
+			order.Fulfillment = tt.fulfillment
+			shipmentRepo.EXPECT().GetByID(gomock.Any(), uint64(21)).Return(&east, nil)
+			orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(5)).Return(order, nil)
+			shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(5)).Return(slices.Clone(tt.shipments), nil)
+			shipmentRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, shipment *model.Shipment) error {
+				assert.Equal(t, uint64(21), shipment.ID)
+				assert.Equal(t, model.ShipmentStatusDelivered, shipment.Status)
+				assert.NotNil(t, shipment.DeliveredAt)
+				return nil
+			})
+			orderRepo.EXPECT().SetFulfillment(gomock.Any(), uint64(5), tt.wantFulfillment).Return(nil)
+			fulfillment := service.NewFulfillmentService(orderRepo, shipmentRepo, nil, txManager, nil, nil, nil, nil, nil)
+
+			resp, err := fulfillment.Deliver(context.Background(), 21)
+			require.NoError(t, err)
+			assert.Equal(t, model.ShipmentStatusDelivered, resp.Status)
+		})
+	}
+
+	t.Run("AlreadyDelivered", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		shipmentRepo := mocks.NewMockShipmentRepository(ctrl)
+		done := delivered(east)
+		shipmentRepo.EXPECT().GetByID(gomock.Any(), uint64(21)).Return(&done, nil)
+		fulfillment := service.NewFulfillmentService(nil, shipmentRepo, nil, nil, nil, nil, nil, nil, nil)
+
+		resp, err := fulfillment.Deliver(context.Background(), 21)
+		require.NoError(t, err)
+		assert.Equal(t, model.ShipmentStatusDelivered, resp.Status)
+	})
+}
+
+func TestFulfillmentService_Cancel(t *testing.T) {
+	tests := []struct {
+		name      string
+		mockSetup func(orderRepo *mocks.MockOrderRepository, shipmentRepo *mocks.MockShipmentRepository, productRepo *mocks.MockProductRepository, capturer *mocks.MockPaymentCapturer)
+		wantErr   error
+	}{
+		{
+			name: "VoidsAuthorization",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, shipmentRepo *mocks.MockShipmentRepository, productRepo *mocks.MockProductRepository, capturer *mocks.MockPaymentCapturer) {
+				orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(5)).Return(authorizedOrder(model.OrderStatusAuthorized, "0"), nil)
+				shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(5)).Return(nil, nil)
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(5), model.OrderStatusAuthorized, model.OrderStatusCancelled).Return(nil)
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), 2).Return(nil)
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(102), 1).Return(nil)
+				capturer.EXPECT().Void(gomock.Any(), "pi_1").Return(nil)
+			},
+		},
+		{
+			name: "VoidFailsAfterCancelling",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, shipmentRepo *mocks.MockShipmentRepository, productRepo *mocks.MockProductRepository, capturer *mocks.MockPaymentCapturer) {
+				orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(5)).Return(authorizedOrder(model.OrderStatusAuthorized, "0"), nil)
+				shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(5)).Return(nil, nil)
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(5), model.OrderStatusAuthorized, model.OrderStatusCancelled).Return(nil)
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+				// The authorization expires on its own
+				capturer.EXPECT().Void(gomock.Any(), "pi_1").Return(errors.New("stripe unavailable"))
+			},
+		},
+		{
+			name: "Pending",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, shipmentRepo *mocks.MockShipmentRepository, productRepo *mocks.MockProductRepository, _ *mocks.MockPaymentCapturer) {
+				orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(5)).Return(authorizedOrder(model.OrderStatusPending, "0"), nil)
+				shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(5)).Return(nil, nil)
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(5), model.OrderStatusPending, model.OrderStatusCancelled).Return(nil)
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+			},
+		},
+		{
+			name: "Shipped",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, shipmentRepo *mocks.MockShipmentRepository, _ *mocks.MockProductRepository, _ *mocks.MockPaymentCapturer) {
+				orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(5)).Return(authorizedOrder(model.OrderStatusAuthorized, "33.00"), nil)
+				shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(5)).Return([]model.Shipment{{OrderID: 5}}, nil)
+			},
+			wantErr: service.ErrOrderShipped,
+		},
+		{
+			name: "Paid",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, _ *mocks.MockShipmentRepository, _ *mocks.MockProductRepository, _ *mocks.MockPaymentCapturer) {
+				orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(5)).Return(authorizedOrder(model.OrderStatusPaid, "0"), nil)
+			},
+			wantErr: service.ErrOrderNotCancellable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			shipmentRepo := mocks.NewMockShipmentRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			})
+			capturer := mocks.NewMockPaymentCapturer(ctrl)
+			tt.mockSetup(orderRepo, shipmentRepo, productRepo, capturer)
+			events := mocks.NewMockEventPublisher(ctrl)
+			if tt.wantErr == nil {
+				events.EXPECT().Publish(gomock.Any(), service.EventOrderCancelled, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, data any) error {
+					order := data.(service.OrderWebhookData)
+					assert.Equal(t, uint64(5), order.OrderID)
+					assert.Equal(t, model.OrderStatusCancelled, order.Status)
+					assert.Len(t, order.Items, 2)
+					return nil
+				})
+			}
+			fulfillment := service.NewFulfillmentService(orderRepo, shipmentRepo, productRepo, txManager, nil, events, map[string]payment.Provider{"stripe": capturer}, nil, nil)
+
+			err := fulfillment.Cancel(context.Background(), 5)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -2,92 +2,144 @@ package service
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"strconv"
 	"time"
 
+	"github.com/proyuen/go-mall/pkg/apperr"
 	"github.com/proyuen/go-mall/pkg/cache"
 	"github.com/redis/go-redis/v9"
 )
 
-var ErrInsufficientStock = errors.New("insufficient stock")
+var ErrInsufficientStock = apperr.New(apperr.CodeStockInsufficient)
 
-type InventoryService struct {
-	cache       cache.Cache
-	redisClient *redis.Client
-}
+// stockTTL keeps stock counters persistent-like; Cache.Set requires a duration.
+const stockTTL = 24 * time.Hour
 
-func NewInventoryService(c cache.Cache, redisClient *redis.Client) *InventoryService {
-	return &InventoryService{
-		cache:       c,
-		redisClient: redisClient,
-	}
+// deductStocks checks the counters in KEYS against the quantities in ARGV and
+// decrements all of them only if every one suffices. A missing counter reads
+// as zero. It returns 0 on success, else the 1-based index of the first key
+// that is short.
+var deductStocks = redis.NewScript(`
+for i, key in ipairs(KEYS) do
+	local stock = tonumber(redis.call("GET", key) or "0")
+	if stock == nil then
+		return redis.error_reply("invalid stock value at " .. key)
+	end
+	if stock < tonumber(ARGV[i]) then
+		return i
+	end
+end
+for i, key in ipairs(KEYS) do
+	redis.call("DECRBY", key, ARGV[i])
+end
+return 0
+`)
+
+// setStockIfUnchanged sets KEYS[1] to ARGV[2] with a TTL of ARGV[3]
+// milliseconds if it still holds ARGV[1] ("" for a missing key). It returns 1
+// if it wrote the counter.
+var setStockIfUnchanged = redis.NewScript(`
+if (redis.call("GET", KEYS[1]) or "") ~= ARGV[1] then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+return 1
+`)
+
+// StockItem is a quantity of one SKU to deduct.
+type StockItem struct {
+	SKUID    uint64
+	Quantity int
 }
 
-// DeductStock safely deducts stock for a given SKU using a distributed lock.
-// It follows the pattern: Lock -> Get -> Check -> Update -> Unlock.
-func (s *InventoryService) DeductStock(ctx context.Context, sku string, quantity int) error {
-	lockKey := fmt.Sprintf("lock:sku:%s", sku)
-	stockKey := fmt.Sprintf("stock:sku:%s", sku)
+type InventoryService struct {
+	cache cache.Cache
+}
 
-	// 1. Acquire Lock
-	// We use the raw redis client to create the lock instance.
-	lock := cache.NewRedisLock(s.redisClient, lockKey)
+func NewInventoryService(c cache.Cache) *InventoryService {
+	return &InventoryService{cache: c}
+}
 
-	// Create a context with timeout for acquiring the lock to prevent indefinite waiting
-	lockCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+// DeductStocks deducts the stock of every item in one Lua script: either all
+// counters are decremented or, if any SKU has too little stock, none is and
+// it returns ErrInsufficientStock naming that SKU. Items of the same SKU are
+// deducted together. Unlike a lock per SKU it costs one round trip however
+// many SKUs an order holds, and concurrent orders cannot interleave.
+func (s *InventoryService) DeductStocks(ctx context.Context, items []StockItem) error {
+	quantities := make(map[uint64]int, len(items))
+	var skuIDs []uint64
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return fmt.Errorf("invalid quantity %d for SKU %d", item.Quantity, item.SKUID)
+		}
+		if _, ok := quantities[item.SKUID]; !ok {
+			skuIDs = append(skuIDs, item.SKUID)
+		}
+		quantities[item.SKUID] += item.Quantity
+	}
+	if len(skuIDs) == 0 {
+		return nil
+	}
 
-	// Attempt to acquire lock with a 10s TTL (Watchdog will extend this if needed)
-	acquired, err := lock.Lock(lockCtx, 10*time.Second)
+	keys := make([]string, len(skuIDs))
+	args := make([]interface{}, len(skuIDs))
+	for i, id := range skuIDs {
+		keys[i] = stockKey(strconv.FormatUint(id, 10))
+		args[i] = quantities[id]
+	}
+	res, err := s.cache.Eval(ctx, deductStocks, keys, args...)
 	if err != nil {
-		// Could be context timeout or redis error
-		return fmt.Errorf("failed to acquire lock for sku %s: %w", sku, err)
+		return fmt.Errorf("failed to deduct stock: %w", err)
 	}
-	if !acquired {
-		return fmt.Errorf("failed to acquire lock for sku %s: timeout", sku)
+	short, ok := res.(int64)
+	if !ok {
+		return fmt.Errorf("unexpected stock deduction result %v", res)
 	}
+	if short > 0 && int(short) <= len(skuIDs) {
+		return ErrInsufficientStock.WithSKU(skuIDs[short-1])
+	}
+	return nil
+}
 
-	// 2. Defer Unlock
-	defer func() {
-		// Use a detached context for unlock to ensure it runs even if the request context is canceled
-		unlockCtx, unlockCancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer unlockCancel()
-		
-		if err := lock.Unlock(unlockCtx); err != nil {
-			// In production, log this error
-			fmt.Printf("CRITICAL: failed to unlock %s: %v\n", lockKey, err)
-		}
-	}()
+// StockCounters returns the raw Redis stock counters of the given SKUs, keyed
+// by SKU ID. SKUs without a counter are left out of the map.
+func (s *InventoryService) StockCounters(ctx context.Context, skuIDs []uint64) (map[uint64]string, error) {
+	if len(skuIDs) == 0 {
+		return map[uint64]string{}, nil
+	}
+	keys := make([]string, len(skuIDs))
+	for i, id := range skuIDs {
+		keys[i] = stockKey(strconv.FormatUint(id, 10))
+	}
 
-	// 3. Get Stock
-	val, err := s.cache.Get(ctx, stockKey)
+	vals, err := s.cache.MGet(ctx, keys...)
 	if err != nil {
-		return fmt.Errorf("failed to get stock from cache: %w", err)
+		return nil, fmt.Errorf("failed to get stock counters: %w", err)
 	}
-
-	currentStock := 0
-	if val != "" {
-		currentStock, err = strconv.Atoi(val)
-		if err != nil {
-			return fmt.Errorf("data corruption: invalid stock value '%s' for sku %s", val, sku)
+	counters := make(map[uint64]string, len(vals))
+	for i, v := range vals {
+		if val, ok := v.(string); ok && i < len(skuIDs) {
+			counters[skuIDs[i]] = val
 		}
 	}
+	return counters, nil
+}
 
-	// 4. Business Rule Check
-	if currentStock < quantity {
-		return ErrInsufficientStock
-	}
-
-	// 5. Update Stock
-	newStock := currentStock - quantity
-	// Write back to cache (Simulating DB update)
-	// Using 0 expiration (or keep existing) if supported, but Cache.Set requires duration.
-	// We'll use 24 hours to keep it persistent-like.
-	if err := s.cache.Set(ctx, stockKey, newStock, 24*time.Hour); err != nil {
-		return fmt.Errorf("failed to update stock: %w", err)
+// SetStockIfUnchanged overwrites the stock counter of a SKU with stock, unless
+// it no longer holds observed ("" for a missing counter). The comparison and
+// the write are one Lua script, so no deduction made in between can be lost.
+// It reports whether the counter was written.
+func (s *InventoryService) SetStockIfUnchanged(ctx context.Context, skuID uint64, observed string, stock int) (bool, error) {
+	key := stockKey(strconv.FormatUint(skuID, 10))
+	res, err := s.cache.Eval(ctx, setStockIfUnchanged, []string{key}, observed, stock, stockTTL.Milliseconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to update stock: %w", err)
 	}
+	return res == int64(1), nil
+}
 
-	return nil
+// stockKey is the cache key of a SKU's stock counter.
+func stockKey(sku string) string {
+	return fmt.Sprintf("stock:sku:%s", sku)
 }
@@ -0,0 +1,133 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/proyuen/go-mall/pkg/cache"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newInventoryService runs the stock scripts against an in-memory Redis.
+func newInventoryService(t *testing.T) (*service.InventoryService, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return service.NewInventoryService(cache.NewRedisCache(client, "")), mr
+}
+
+func TestInventoryService_DeductStocks(t *testing.T) {
+	tests := []struct {
+		name       string
+		stocks     map[string]string // Counters before the call
+		items      []service.StockItem
+		wantShort  uint64 // SKU named by ErrInsufficientStock, 0 for success
+		wantStocks map[string]string
+	}{
+		{
+			name:       "Success",
+			stocks:     map[string]string{"stock:sku:1": "5", "stock:sku:2": "3"},
+			items:      []service.StockItem{{SKUID: 1, Quantity: 2}, {SKUID: 2, Quantity: 3}},
+			wantStocks: map[string]string{"stock:sku:1": "3", "stock:sku:2": "0"},
+		},
+		{
+			name:       "OneShortDeductsNone",
+			stocks:     map[string]string{"stock:sku:1": "5", "stock:sku:2": "3"},
+			items:      []service.StockItem{{SKUID: 1, Quantity: 2}, {SKUID: 2, Quantity: 4}},
+			wantShort:  2,
+			wantStocks: map[string]string{"stock:sku:1": "5", "stock:sku:2": "3"},
+		},
+		{
+			name:       "DuplicateSKUsMerged",
+			stocks:     map[string]string{"stock:sku:1": "5"},
+			items:      []service.StockItem{{SKUID: 1, Quantity: 2}, {SKUID: 1, Quantity: 2}},
+			wantStocks: map[string]string{"stock:sku:1": "1"},
+		},
+		{
+			name:       "DuplicateSKUsShortTogether",
+			stocks:     map[string]string{"stock:sku:1": "5"},
+			items:      []service.StockItem{{SKUID: 1, Quantity: 3}, {SKUID: 1, Quantity: 3}},
+			wantShort:  1,
+			wantStocks: map[string]string{"stock:sku:1": "5"},
+		},
+		{
+			name:       "MissingCounterReadsAsZero",
+			stocks:     map[string]string{"stock:sku:1": "5"},
+			items:      []service.StockItem{{SKUID: 1, Quantity: 1}, {SKUID: 3, Quantity: 1}},
+			wantShort:  3,
+			wantStocks: map[string]string{"stock:sku:1": "5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inventory, mr := newInventoryService(t)
+			for key, stock := range tt.stocks {
+				require.NoError(t, mr.Set(key, stock))
+			}
+
+			err := inventory.DeductStocks(context.Background(), tt.items)
+			if tt.wantShort != 0 {
+				require.ErrorIs(t, err, service.ErrInsufficientStock)
+				var appErr *apperr.Error
+				require.True(t, errors.As(err, &appErr))
+				assert.Equal(t, tt.wantShort, appErr.Meta["sku_id"])
+			} else {
+				require.NoError(t, err)
+			}
+			for key, want := range tt.wantStocks {
+				got, err := mr.Get(key)
+				require.NoError(t, err)
+				assert.Equal(t, want, got, key)
+			}
+			assert.False(t, mr.Exists("stock:sku:3"), "a missing counter is not created")
+		})
+	}
+
+	t.Run("InvalidQuantity", func(t *testing.T) {
+		inventory, _ := newInventoryService(t)
+		err := inventory.DeductStocks(context.Background(), []service.StockItem{{SKUID: 1, Quantity: 0}})
+		assert.EqualError(t, err, "invalid quantity 0 for SKU 1")
+	})
+}
+
+func TestInventoryService_SetStockIfUnchanged(t *testing.T) {
+	t.Run("Unchanged", func(t *testing.T) {
+		inventory, mr := newInventoryService(t)
+		require.NoError(t, mr.Set("stock:sku:1", "9"))
+
+		written, err := inventory.SetStockIfUnchanged(context.Background(), 1, "9", 5)
+		require.NoError(t, err)
+		assert.True(t, written)
+		got, _ := mr.Get("stock:sku:1")
+		assert.Equal(t, "5", got)
+		assert.Positive(t, mr.TTL("stock:sku:1"))
+	})
+
+	t.Run("DeductedSinceObserved", func(t *testing.T) {
+		inventory, mr := newInventoryService(t)
+		require.NoError(t, mr.Set("stock:sku:1", "8"))
+
+		written, err := inventory.SetStockIfUnchanged(context.Background(), 1, "9", 5)
+		require.NoError(t, err)
+		assert.False(t, written)
+		got, _ := mr.Get("stock:sku:1")
+		assert.Equal(t, "8", got)
+	})
+
+	t.Run("MissingCounter", func(t *testing.T) {
+		inventory, mr := newInventoryService(t)
+
+		written, err := inventory.SetStockIfUnchanged(context.Background(), 1, "", 5)
+		require.NoError(t, err)
+		assert.True(t, written)
+		got, _ := mr.Get("stock:sku:1")
+		assert.Equal(t, "5", got)
+	})
+}
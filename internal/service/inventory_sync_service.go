@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/database"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// Inventory sync defaults.
+const (
+	// DefaultStockSnapshotLimit is how many SKUs a snapshot page holds when
+	// the caller does not say.
+	DefaultStockSnapshotLimit = 1000
+	// MaxStockSnapshotLimit is the most SKUs a snapshot page holds.
+	MaxStockSnapshotLimit = 100000
+	// MaxStockCorrections is the most corrections one SyncStock call takes.
+	MaxStockCorrections = 1000
+)
+
+const (
+	// stockSnapshotBatchSize is how many SKUs ExportSnapshot loads per query.
+	stockSnapshotBatchSize = 500
+	// stockSyncChunkSize is how many SKUs SyncStock locks per transaction.
+	stockSyncChunkSize = 200
+)
+
+// ErrInvalidStockSync means a stock sync is malformed.
+var ErrInvalidStockSync = errors.New("invalid stock sync")
+
+// Reasons a stock correction is not applied.
+const (
+	StockConflictChanged  = "stock_changed" // The stock is no longer the expected one
+	StockConflictNotFound = "not_found"     // The SKU does not exist
+)
+
+// StockLevel is one line of a stock snapshot.
+type StockLevel struct {
+	SKUID uint64 `json:"sku_id,string"`
+	SPUID uint64 `json:"spu_id,string"`
+	Stock int    `json:"stock"`
+}
+
+// StockCorrection sets the stock of a SKU, as counted by an external
+// warehouse system.
+type StockCorrection struct {
+	SKUID uint64
+	Stock int
+	// ExpectedStock is the stock the correction was made against, usually
+	// from a snapshot. If the stock moved since, e.g. with an order, the
+	// correction is reported as a conflict instead. Nil sets it regardless.
+	ExpectedStock *int
+}
+
+// StockConflict is a correction that was not applied.
+type StockConflict struct {
+	SKUID         uint64 `json:"sku_id,string"`
+	Reason        string `json:"reason" example:"stock_changed"`
+	ExpectedStock *int   `json:"expected_stock,omitempty"`
+	CurrentStock  *int   `json:"current_stock,omitempty"` // Nil when the SKU does not exist
+}
+
+// StockSyncResp reports a stock sync.
+type StockSyncResp struct {
+	Applied   int             `json:"applied"`   // SKUs whose stock was set
+	Unchanged int             `json:"unchanged"` // SKUs that already had the stock
+	Conflicts []StockConflict `json:"conflicts"`
+}
+
+// InventorySyncService lets external warehouse systems read and correct the
+// stock of the store's SKUs.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/inventory_sync_service_mock.go -package=mocks
+type InventorySyncService interface {
+	// ExportSnapshot writes the stock of up to limit SKUs with IDs above
+	// cursor to w as newline-delimited JSON StockLevels, in ID order. The
+	// last SKU ID written is the cursor of the next page; a page shorter
+	// than limit is the last.
+	ExportSnapshot(ctx context.Context, cursor uint64, limit int, w io.Writer) error
+	// SyncStock applies corrections, reporting those that conflict rather
+	// than failing on them.
+	SyncStock(ctx context.Context, corrections []StockCorrection) (*StockSyncResp, error)
+}
+
+type inventorySyncService struct {
+	productRepo repository.ProductRepository
+	txManager   database.TransactionManager
+	catalog     *CatalogCache
+}
+
+// NewInventorySyncService creates a new InventorySyncService instance. Stock
+// changes are announced through catalog.
+func NewInventorySyncService(productRepo repository.ProductRepository, txManager database.TransactionManager, catalog *CatalogCache) InventorySyncService {
+	return &inventorySyncService{productRepo: productRepo, txManager: txManager, catalog: catalog}
+}
+
+// ExportSnapshot streams the page as it is loaded, so an error after the
+// first batch leaves w with a truncated page.
+func (s *inventorySyncService) ExportSnapshot(ctx context.Context, cursor uint64, limit int, w io.Writer) error {
+	if limit <= 0 || limit > MaxStockSnapshotLimit {
+		limit = DefaultStockSnapshotLimit
+	}
+	out := json.NewEncoder(w)
+	for remaining := limit; remaining > 0; {
+		skus, err := s.productRepo.ListSKUStock(ctx, cursor, min(remaining, stockSnapshotBatchSize))
+		if err != nil {
+			return err
+		}
+		for _, sku := range skus {
+			if err := out.Encode(StockLevel{SKUID: sku.ID, SPUID: sku.SPUID, Stock: sku.Stock}); err != nil {
+				return fmt.Errorf("failed to write stock snapshot: %w", err)
+			}
+		}
+		if len(skus) < min(remaining, stockSnapshotBatchSize) {
+			break
+		}
+		remaining -= len(skus)
+		cursor = skus[len(skus)-1].ID
+	}
+	return nil
+}
+
+// SyncStock locks stockSyncChunkSize SKUs per transaction, so that no order
+// takes stock between comparing it with the expected stock and setting it.
+// If a chunk fails, the chunks before it stay applied: the response counts
+// them along with the error. Redis stock counters catch up at the next stock
+// reconciliation, backorders at the next allocation.
+func (s *inventorySyncService) SyncStock(ctx context.Context, corrections []StockCorrection) (*StockSyncResp, error) {
+	if len(corrections) == 0 || len(corrections) > MaxStockCorrections {
+		return nil, fmt.Errorf("%w: give 1 to %d corrections", ErrInvalidStockSync, MaxStockCorrections)
+	}
+	byID := make(map[uint64]StockCorrection, len(corrections))
+	for _, correction := range corrections {
+		if _, ok := byID[correction.SKUID]; ok {
+			return nil, fmt.Errorf("%w: SKU %d is listed twice", ErrInvalidStockSync, correction.SKUID)
+		}
+		if correction.Stock < 0 {
+			return nil, fmt.Errorf("%w: stock of SKU %d is negative", ErrInvalidStockSync, correction.SKUID)
+		}
+		byID[correction.SKUID] = correction
+	}
+	skuIDs := make([]uint64, 0, len(byID))
+	for id := range byID {
+		skuIDs = append(skuIDs, id)
+	}
+	slices.Sort(skuIDs)
+
+	resp := &StockSyncResp{Conflicts: []StockConflict{}}
+	for chunk := range slices.Chunk(skuIDs, stockSyncChunkSize) {
+		var applied []stockChange
+		var unchanged int
+		var conflicts []StockConflict
+		err := s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+			applied, unchanged, conflicts = applied[:0], 0, conflicts[:0]
+			skus, err := s.productRepo.GetSKUsForUpdate(ctx, chunk)
+			if err != nil {
+				return fmt.Errorf("failed to lock SKUs: %w", err)
+			}
+			found := make(map[uint64]*model.SKU, len(skus))
+			for i := range skus {
+				found[skus[i].ID] = &skus[i]
+			}
+			for _, id := range chunk {
+				correction, sku := byID[id], found[id]
+				switch {
+				case sku == nil:
+					conflicts = append(conflicts, StockConflict{SKUID: id, Reason: StockConflictNotFound, ExpectedStock: correction.ExpectedStock})
+					continue
+				case correction.ExpectedStock != nil && *correction.ExpectedStock != sku.Stock:
+					current := sku.Stock
+					conflicts = append(conflicts, StockConflict{SKUID: id, Reason: StockConflictChanged, ExpectedStock: correction.ExpectedStock, CurrentStock: &current})
+					continue
+				case correction.Stock == sku.Stock:
+					unchanged++
+					continue
+				}
+				if err := s.productRepo.UpdateSKUStock(ctx, id, correction.Stock-sku.Stock); err != nil {
+					return err
+				}
+				applied = append(applied, stockChange{skuID: id, before: sku.Stock, after: correction.Stock})
+			}
+			return nil
+		})
+		if err != nil {
+			if resp.Applied == 0 {
+				return nil, err
+			}
+			return resp, fmt.Errorf("synced %d of %d SKUs before failing: %w", resp.Applied, len(skuIDs), err)
+		}
+
+		ids := make([]uint64, len(applied))
+		for i, change := range applied {
+			ids[i] = change.skuID
+			RecordAudit(ctx, AuditEntry{
+				Action:     "sku.stock",
+				Resource:   "sku",
+				ResourceID: strconv.FormatUint(change.skuID, 10),
+				Before:     map[string]any{"stock": change.before},
+				After:      map[string]any{"stock": change.after},
+			})
+		}
+		resp.Applied += len(applied)
+		resp.Unchanged += unchanged
+		resp.Conflicts = append(resp.Conflicts, conflicts...)
+		// The products show their old stock until they expire if this fails
+		if err := s.catalog.PublishStockChanged(ctx, ids); err != nil {
+			slog.WarnContext(ctx, "Failed to announce stock change", "sku_ids", ids, logger.Err(err))
+		}
+	}
+	return resp, nil
+}
+
+// stockChange is a stock correction SyncStock applied.
+type stockChange struct {
+	skuID         uint64
+	before, after int
+}
@@ -0,0 +1,156 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestInventorySyncService_ExportSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	productRepo := mocks.NewMockProductRepository(ctrl)
+	productRepo.EXPECT().ListSKUStock(gomock.Any(), uint64(100), 2).Return([]model.SKU{
+		{Base: model.Base{ID: 101}, SPUID: 11, Stock: 5},
+		{Base: model.Base{ID: 102}, SPUID: 11, Stock: 0},
+	}, nil)
+
+	svc := service.NewInventorySyncService(productRepo, nil, nil)
+	var out bytes.Buffer
+	require.NoError(t, svc.ExportSnapshot(context.Background(), 100, 2, &out))
+	assert.Equal(t, "{\"sku_id\":\"101\",\"spu_id\":\"11\",\"stock\":5}\n{\"sku_id\":\"102\",\"spu_id\":\"11\",\"stock\":0}\n", out.String())
+}
+
+func TestInventorySyncService_ExportSnapshotPages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	productRepo := mocks.NewMockProductRepository(ctrl)
+	batch := make([]model.SKU, 500)
+	for i := range batch {
+		batch[i] = model.SKU{Base: model.Base{ID: uint64(i + 1)}, SPUID: 1, Stock: 1}
+	}
+	gomock.InOrder(
+		productRepo.EXPECT().ListSKUStock(gomock.Any(), uint64(0), 500).Return(batch, nil),
+		productRepo.EXPECT().ListSKUStock(gomock.Any(), uint64(500), 100).Return(nil, errors.New("db down")),
+	)
+
+	svc := service.NewInventorySyncService(productRepo, nil, nil)
+	var out bytes.Buffer
+	err := svc.ExportSnapshot(context.Background(), 0, 600, &out)
+	assert.Error(t, err)
+	assert.Equal(t, 500, bytes.Count(out.Bytes(), []byte("\n")), "the first batch is already written")
+}
+
+func TestInventorySyncService_SyncStock(t *testing.T) {
+	expected := func(n int) *int { return &n }
+	skus := func() []model.SKU {
+		return []model.SKU{
+			{Base: model.Base{ID: 101}, Stock: 10},
+			{Base: model.Base{ID: 102}, Stock: 4},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		corrections   []service.StockCorrection
+		updateErr     error
+		wantUpdates   map[uint64]int
+		wantUnchanged int
+		wantConflicts []service.StockConflict
+		wantErrIs     error
+		wantErr       bool
+	}{
+		{
+			name: "Applied",
+			corrections: []service.StockCorrection{
+				{SKUID: 102, Stock: 7},
+				{SKUID: 101, Stock: 8, ExpectedStock: expected(10)},
+			},
+			wantUpdates: map[uint64]int{101: -2, 102: 3},
+		},
+		{
+			name: "Conflicts",
+			corrections: []service.StockCorrection{
+				{SKUID: 101, Stock: 8, ExpectedStock: expected(12)},
+				{SKUID: 102, Stock: 4},
+				{SKUID: 103, Stock: 1},
+			},
+			wantUnchanged: 1,
+			wantConflicts: []service.StockConflict{
+				{SKUID: 101, Reason: service.StockConflictChanged, ExpectedStock: expected(12), CurrentStock: expected(10)},
+				{SKUID: 103, Reason: service.StockConflictNotFound},
+			},
+		},
+		{
+			name:        "UpdateFails",
+			corrections: []service.StockCorrection{{SKUID: 101, Stock: 1}},
+			updateErr:   errors.New("db down"),
+			wantUpdates: map[uint64]int{101: -9},
+			wantErr:     true,
+		},
+		{
+			name:      "Empty",
+			wantErrIs: service.ErrInvalidStockSync,
+		},
+		{
+			name:        "Duplicate",
+			corrections: []service.StockCorrection{{SKUID: 101, Stock: 1}, {SKUID: 101, Stock: 2}},
+			wantErrIs:   service.ErrInvalidStockSync,
+		},
+		{
+			name:        "NegativeStock",
+			corrections: []service.StockCorrection{{SKUID: 101, Stock: -1}},
+			wantErrIs:   service.ErrInvalidStockSync,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			mockCache := mocks.NewMockCache(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+
+			if tt.wantErrIs == nil {
+				txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+				productRepo.EXPECT().GetSKUsForUpdate(gomock.Any(), gomock.Any()).Return(skus(), nil)
+			}
+			for id, delta := range tt.wantUpdates {
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), id, delta).Return(tt.updateErr)
+			}
+			if len(tt.wantUpdates) > 0 && tt.updateErr == nil {
+				mockCache.EXPECT().Publish(gomock.Any(), service.CatalogEventsChannel, gomock.Any()).Return(nil)
+			}
+
+			svc := service.NewInventorySyncService(productRepo, txManager, service.NewCatalogCache(mockCache, service.CatalogCacheOptions{}))
+			ctx, trail := service.WithAuditTrail(context.Background())
+			resp, err := svc.SyncStock(ctx, tt.corrections)
+			switch {
+			case tt.wantErrIs != nil:
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				return
+			case tt.wantErr:
+				assert.Error(t, err)
+				assert.Nil(t, resp, "nothing was synced")
+				assert.Empty(t, trail.Entries())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.wantUpdates), resp.Applied)
+			assert.Equal(t, tt.wantUnchanged, resp.Unchanged)
+			if tt.wantConflicts == nil {
+				tt.wantConflicts = []service.StockConflict{}
+			}
+			assert.Equal(t, tt.wantConflicts, resp.Conflicts)
+			assert.Len(t, trail.Entries(), len(tt.wantUpdates), "one audit entry per corrected SKU")
+		})
+	}
+}
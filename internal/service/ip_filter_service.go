@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// IP rule actions. Allow rules take precedence over deny rules so that a trusted
+// address inside a blocked range can still be let through.
+const (
+	IPRuleAllow = "allow"
+	IPRuleDeny  = "deny"
+)
+
+const (
+	ipRulesKey = "mall:security:ip_rules"
+	// ipRulesRefreshInterval bounds how long other instances keep serving a stale rule set
+	// after an admin edit; the instance that made the edit refreshes immediately.
+	ipRulesRefreshInterval = 10 * time.Second
+)
+
+var (
+	ErrInvalidCIDR     = errors.New("invalid ip address or cidr")
+	ErrInvalidIPAction = errors.New("ip rule action must be allow or deny")
+	ErrIPRuleNotFound  = errors.New("ip rule not found")
+)
+
+// IPRule is a single allow or deny entry keyed by its normalized CIDR.
+type IPRule struct {
+	CIDR      string    `json:"cidr" example:"203.0.113.0/24"`
+	Action    string    `json:"action" example:"deny"`
+	Reason    string    `json:"reason" example:"credential stuffing"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IPRuleReq defines the input for creating or replacing an IP rule.
+type IPRuleReq struct {
+	CIDR   string // Single address ("203.0.113.7") or prefix ("203.0.113.0/24")
+	Action string
+	Reason string
+}
+
+//go:generate mockgen -source=$GOFILE -destination=../mocks/ip_filter_service_mock.go -package=mocks
+// IPFilterService manages the CIDR allow/deny lists shared by all API instances.
+type IPFilterService interface {
+	// IsBlocked reports whether requests from ip must be rejected.
+	IsBlocked(ctx context.Context, ip string) (bool, error)
+	ListRules(ctx context.Context) ([]IPRule, error)
+	// PutRule creates the rule, or replaces an existing rule for the same CIDR.
+	PutRule(ctx context.Context, req *IPRuleReq) (*IPRule, error)
+	RemoveRule(ctx context.Context, cidr string) error
+}
+
+type ipFilterService struct {
+	redisClient *redis.Client
+
+	mu        sync.RWMutex
+	allow     []netip.Prefix
+	deny      []netip.Prefix
+	loadedAt  time.Time
+	refreshMu sync.Mutex // Serializes reloads so a burst of requests triggers one HGETALL
+}
+
+// NewIPFilterService creates an IPFilterService backed by a Redis hash.
+// Rules are cached in memory and reloaded periodically so the per-request check
+// does not cost a Redis round trip.
+func NewIPFilterService(redisClient *redis.Client) IPFilterService {
+	return &ipFilterService{redisClient: redisClient}
+}
+
+func (s *ipFilterService) IsBlocked(ctx context.Context, ip string) (bool, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false, fmt.Errorf("%w: %q", ErrInvalidCIDR, ip)
+	}
+	addr = addr.Unmap()
+
+	if err := s.refreshIfStale(ctx); err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if containsAddr(s.allow, addr) {
+		return false, nil
+	}
+	return containsAddr(s.deny, addr), nil
+}
+
+func (s *ipFilterService) ListRules(ctx context.Context) ([]IPRule, error) {
+	rules, err := s.loadRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].CIDR < rules[j].CIDR
+	})
+	return rules, nil
+}
+
+func (s *ipFilterService) PutRule(ctx context.Context, req *IPRuleReq) (*IPRule, error) {
+	if req.Action != IPRuleAllow && req.Action != IPRuleDeny {
+		return nil, ErrInvalidIPAction
+	}
+	prefix, err := ParseIPPrefix(req.CIDR)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := s.getRule(ctx, prefix.String())
+	if err != nil {
+		return nil, err
+	}
+
+	rule := &IPRule{
+		CIDR:      prefix.String(),
+		Action:    req.Action,
+		Reason:    req.Reason,
+		CreatedAt: time.Now().UTC(),
+	}
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ip rule: %w", err)
+	}
+	if err := s.redisClient.HSet(ctx, ipRulesKey, rule.CIDR, data).Err(); err != nil {
+		return nil, fmt.Errorf("failed to save ip rule: %w", err)
+	}
+
+	s.invalidate()
+	entry := AuditEntry{Action: "ip_rule.put", Resource: "ip_rule", ResourceID: rule.CIDR, After: rule}
+	if previous != nil {
+		entry.Before = previous
+	}
+	RecordAudit(ctx, entry)
+	return rule, nil
+}
+
+func (s *ipFilterService) RemoveRule(ctx context.Context, cidr string) error {
+	prefix, err := ParseIPPrefix(cidr)
+	if err != nil {
+		return err
+	}
+	previous, err := s.getRule(ctx, prefix.String())
+	if err != nil {
+		return err
+	}
+	removed, err := s.redisClient.HDel(ctx, ipRulesKey, prefix.String()).Result()
+	if err != nil {
+		return fmt.Errorf("failed to delete ip rule: %w", err)
+	}
+	if removed == 0 {
+		return ErrIPRuleNotFound
+	}
+
+	s.invalidate()
+	entry := AuditEntry{Action: "ip_rule.delete", Resource: "ip_rule", ResourceID: prefix.String()}
+	if previous != nil {
+		entry.Before = previous
+	}
+	RecordAudit(ctx, entry)
+	return nil
+}
+
+// getRule returns the stored rule for a normalized CIDR, or nil if there is none.
+func (s *ipFilterService) getRule(ctx context.Context, cidr string) (*IPRule, error) {
+	data, err := s.redisClient.HGet(ctx, ipRulesKey, cidr).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ip rule: %w", err)
+	}
+	var rule IPRule
+	if err := json.Unmarshal(data, &rule); err != nil {
+		return nil, fmt.Errorf("data corruption: invalid ip rule for %s: %w", cidr, err)
+	}
+	return &rule, nil
+}
+
+// ParseIPPrefix accepts a single address or a CIDR and returns the masked prefix,
+// so "10.1.2.3/8" and "10.0.0.0/8" refer to the same rule.
+func ParseIPPrefix(value string) (netip.Prefix, error) {
+	if prefix, err := netip.ParsePrefix(value); err == nil {
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%w: %q", ErrInvalidCIDR, value)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func (s *ipFilterService) refreshIfStale(ctx context.Context) error {
+	if s.fresh() {
+		return nil
+	}
+
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	if s.fresh() {
+		return nil
+	}
+
+	rules, err := s.loadRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	var allow, deny []netip.Prefix
+	for _, rule := range rules {
+		prefix, err := netip.ParsePrefix(rule.CIDR)
+		if err != nil {
+			continue // Entries are validated on write; skip anything edited by hand in Redis
+		}
+		if rule.Action == IPRuleAllow {
+			allow = append(allow, prefix)
+		} else {
+			deny = append(deny, prefix)
+		}
+	}
+
+	s.mu.Lock()
+	s.allow, s.deny, s.loadedAt = allow, deny, time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *ipFilterService) fresh() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return !s.loadedAt.IsZero() && time.Since(s.loadedAt) < ipRulesRefreshInterval
+}
+
+func (s *ipFilterService) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+func (s *ipFilterService) loadRules(ctx context.Context) ([]IPRule, error) {
+	entries, err := s.redisClient.HGetAll(ctx, ipRulesKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ip rules: %w", err)
+	}
+
+	rules := make([]IPRule, 0, len(entries))
+	for cidr, data := range entries {
+		var rule IPRule
+		if err := json.Unmarshal([]byte(data), &rule); err != nil {
+			return nil, fmt.Errorf("data corruption: invalid ip rule for %s: %w", cidr, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
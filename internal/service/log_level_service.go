@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// ErrInvalidLogLevel is returned for a level other than debug, info, warn or
+// error.
+var ErrInvalidLogLevel = errors.New("log level must be debug, info, warn or error")
+
+// LogLevelResp is the level the process logs at.
+type LogLevelResp struct {
+	Level string `json:"level" example:"info"`
+}
+
+// LogLevelService changes the level of the process's logs at runtime, e.g. to
+// debug checkout issues in production without a redeploy. A change applies
+// to this process only and lasts until log.level is reloaded from the
+// config, on edit or SIGHUP.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/log_level_service_mock.go -package=mocks
+type LogLevelService interface {
+	Get(ctx context.Context) *LogLevelResp
+	Set(ctx context.Context, level string) (*LogLevelResp, error)
+}
+
+type logLevelService struct{}
+
+// NewLogLevelService creates a new LogLevelService instance.
+func NewLogLevelService() LogLevelService {
+	return logLevelService{}
+}
+
+func (logLevelService) Get(context.Context) *LogLevelResp {
+	return &LogLevelResp{Level: strings.ToLower(logger.Level().String())}
+}
+
+func (s logLevelService) Set(ctx context.Context, level string) (*LogLevelResp, error) {
+	if _, err := logger.ParseLevel(level); err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidLogLevel, level)
+	}
+	before := s.Get(ctx)
+	if err := logger.SetLevel(level); err != nil {
+		return nil, err
+	}
+	after := s.Get(ctx)
+	host, _ := os.Hostname() // The instance whose level changed
+	RecordAudit(ctx, AuditEntry{Action: "log_level.set", Resource: "log_level", ResourceID: host, Before: before, After: after})
+	return after, nil
+}
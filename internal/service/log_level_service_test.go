@@ -0,0 +1,34 @@
+package service_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogLevelService_Set(t *testing.T) {
+	initial := logger.Level()
+	t.Cleanup(func() { require.NoError(t, logger.SetLevel(initial.String())) })
+	require.NoError(t, logger.SetLevel("info"))
+	svc := service.NewLogLevelService()
+
+	ctx, trail := service.WithAuditTrail(context.Background())
+	resp, err := svc.Set(ctx, "DEBUG")
+	require.NoError(t, err)
+	assert.Equal(t, "debug", resp.Level)
+	assert.Equal(t, slog.LevelDebug, logger.Level())
+	assert.Equal(t, "debug", svc.Get(ctx).Level)
+	require.Len(t, trail.Entries(), 1)
+	assert.Equal(t, "log_level.set", trail.Entries()[0].Action)
+	assert.Equal(t, &service.LogLevelResp{Level: "info"}, trail.Entries()[0].Before)
+
+	_, err = svc.Set(ctx, "verbose")
+	assert.ErrorIs(t, err, service.ErrInvalidLogLevel)
+	assert.Equal(t, slog.LevelDebug, logger.Level(), "an invalid level changes nothing")
+	assert.Len(t, trail.Entries(), 1)
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+)
+
+// Search merchandising limits.
+const (
+	// MaxSearchRulePosition is the highest position of a boost.
+	MaxSearchRulePosition = 9999
+
+	DefaultZeroResultPageSize = 20
+	MaxZeroResultPageSize     = 100
+)
+
+var (
+	// ErrInvalidSearchRule means a search rule is malformed.
+	ErrInvalidSearchRule = errors.New("invalid search rule")
+	// ErrSearchRuleNotFound is returned when a search rule does not exist.
+	ErrSearchRuleNotFound = repository.ErrSearchRuleNotFound
+)
+
+// SearchRuleReq is a search rule to create.
+type SearchRuleReq struct {
+	Query    string
+	SPUID    uint64
+	Action   string // model.SearchRuleBoost or model.SearchRuleBury
+	Position int    // Order among the query's boosts, lowest first
+}
+
+// SearchRuleResp is a search rule.
+type SearchRuleResp struct {
+	ID       uint64 `json:"id,string"`
+	Query    string `json:"query" example:"running shoes"`
+	SPUID    uint64 `json:"spu_id,string"`
+	Action   string `json:"action" example:"boost"`
+	Position int    `json:"position"`
+}
+
+// ZeroResultListResp is one page of the queries that found nothing, most
+// searched first.
+type ZeroResultListResp struct {
+	Items []model.SearchZeroResult `json:"items"`
+	Total int64                    `json:"total"`
+}
+
+// MerchandisingService tunes what the store's search shows: it manages the
+// rules boosting and burying products for a query, and lists the queries
+// that found nothing. Rules reach search suggestions when cmd/worker next
+// rebuilds their index, which also flushes the zero result searches.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/merchandising_service_mock.go -package=mocks
+type MerchandisingService interface {
+	ListRules(ctx context.Context) ([]SearchRuleResp, error)
+	// CreateRule boosts or buries a product of the store for a query, which
+	// is matched as typed, ignoring case and extra spaces.
+	CreateRule(ctx context.Context, req *SearchRuleReq) (*SearchRuleResp, error)
+	DeleteRule(ctx context.Context, id uint64) error
+	ListZeroResults(ctx context.Context, offset, limit int) (*ZeroResultListResp, error)
+}
+
+type merchandisingService struct {
+	searchRepo  repository.SearchRepository
+	productRepo repository.ProductRepository
+}
+
+// NewMerchandisingService creates a new MerchandisingService instance.
+func NewMerchandisingService(searchRepo repository.SearchRepository, productRepo repository.ProductRepository) MerchandisingService {
+	return &merchandisingService{searchRepo: searchRepo, productRepo: productRepo}
+}
+
+func (s *merchandisingService) ListRules(ctx context.Context) ([]SearchRuleResp, error) {
+	rules, err := s.searchRepo.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]SearchRuleResp, len(rules))
+	for i := range rules {
+		resp[i] = newSearchRuleResp(&rules[i])
+	}
+	return resp, nil
+}
+
+func (s *merchandisingService) CreateRule(ctx context.Context, req *SearchRuleReq) (*SearchRuleResp, error) {
+	query := strings.TrimSpace(normalizeSuggestTerm(req.Query))
+	switch {
+	case query == "":
+		return nil, fmt.Errorf("%w: query cannot be blank", ErrInvalidSearchRule)
+	case len(query) > MaxSuggestQueryLength:
+		return nil, fmt.Errorf("%w: query is longer than %d bytes", ErrInvalidSearchRule, MaxSuggestQueryLength)
+	case req.Action != model.SearchRuleBoost && req.Action != model.SearchRuleBury:
+		return nil, fmt.Errorf("%w: action must be %s or %s", ErrInvalidSearchRule, model.SearchRuleBoost, model.SearchRuleBury)
+	case req.Position < 0 || req.Position > MaxSearchRulePosition:
+		return nil, fmt.Errorf("%w: position must be between 0 and %d", ErrInvalidSearchRule, MaxSearchRulePosition)
+	}
+	if _, err := s.productRepo.GetSPUByID(ctx, req.SPUID); err != nil {
+		if errors.Is(err, repository.ErrSPUNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to get product %d: %w", req.SPUID, err)
+	}
+
+	rule := &model.SearchRule{Query: query, SPUID: req.SPUID, Action: req.Action, Position: req.Position}
+	if err := s.searchRepo.CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	resp := newSearchRuleResp(rule)
+	RecordAudit(ctx, AuditEntry{Action: "search_rule.create", Resource: "search_rule", ResourceID: strconv.FormatUint(rule.ID, 10), After: resp})
+	return &resp, nil
+}
+
+func (s *merchandisingService) DeleteRule(ctx context.Context, id uint64) error {
+	if err := s.searchRepo.DeleteRule(ctx, id); err != nil {
+		return err
+	}
+	RecordAudit(ctx, AuditEntry{Action: "search_rule.delete", Resource: "search_rule", ResourceID: strconv.FormatUint(id, 10)})
+	return nil
+}
+
+func (s *merchandisingService) ListZeroResults(ctx context.Context, offset, limit int) (*ZeroResultListResp, error) {
+	if limit <= 0 {
+		limit = DefaultZeroResultPageSize
+	}
+	if limit > MaxZeroResultPageSize {
+		limit = MaxZeroResultPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	results, total, err := s.searchRepo.ListZeroResults(ctx, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	if results == nil {
+		results = []model.SearchZeroResult{}
+	}
+	return &ZeroResultListResp{Items: results, Total: total}, nil
+}
+
+func newSearchRuleResp(rule *model.SearchRule) SearchRuleResp {
+	return SearchRuleResp{ID: rule.ID, Query: rule.Query, SPUID: rule.SPUID, Action: rule.Action, Position: rule.Position}
+}
@@ -0,0 +1,109 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMerchandisingService_CreateRule(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       *service.SearchRuleReq
+		mockSetup func(searchRepo *mocks.MockSearchRepository, productRepo *mocks.MockProductRepository)
+		wantQuery string
+		wantErr   error
+	}{
+		{
+			name: "Boosted",
+			req:  &service.SearchRuleReq{Query: "  Running   Shoes ", SPUID: 11, Action: model.SearchRuleBoost, Position: 2},
+			mockSetup: func(searchRepo *mocks.MockSearchRepository, productRepo *mocks.MockProductRepository) {
+				productRepo.EXPECT().GetSPUByID(gomock.Any(), uint64(11)).Return(&model.SPU{Base: model.Base{ID: 11}}, nil)
+				searchRepo.EXPECT().CreateRule(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, rule *model.SearchRule) error {
+					rule.ID = 8
+					return nil
+				})
+			},
+			wantQuery: "running shoes",
+		},
+		{name: "BlankQuery", req: &service.SearchRuleReq{Query: " ", SPUID: 11, Action: model.SearchRuleBury}, wantErr: service.ErrInvalidSearchRule},
+		{name: "UnknownAction", req: &service.SearchRuleReq{Query: "shoes", SPUID: 11, Action: "pin"}, wantErr: service.ErrInvalidSearchRule},
+		{name: "PositionTooHigh", req: &service.SearchRuleReq{Query: "shoes", SPUID: 11, Action: model.SearchRuleBoost, Position: 10000}, wantErr: service.ErrInvalidSearchRule},
+		{
+			name: "UnknownProduct",
+			req:  &service.SearchRuleReq{Query: "shoes", SPUID: 11, Action: model.SearchRuleBury},
+			mockSetup: func(searchRepo *mocks.MockSearchRepository, productRepo *mocks.MockProductRepository) {
+				productRepo.EXPECT().GetSPUByID(gomock.Any(), uint64(11)).Return(nil, repository.ErrSPUNotFound)
+			},
+			wantErr: service.ErrProductNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			searchRepo := mocks.NewMockSearchRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(searchRepo, productRepo)
+			}
+			ctx, trail := service.WithAuditTrail(context.Background())
+
+			resp, err := service.NewMerchandisingService(searchRepo, productRepo).CreateRule(ctx, tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, trail.Entries())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantQuery, resp.Query)
+			require.Len(t, trail.Entries(), 1)
+			assert.Equal(t, "search_rule.create", trail.Entries()[0].Action)
+			assert.Equal(t, "8", trail.Entries()[0].ResourceID)
+		})
+	}
+}
+
+func TestMerchandisingService_ListZeroResults(t *testing.T) {
+	tests := []struct {
+		name       string
+		offset     int
+		limit      int
+		wantOffset int
+		wantLimit  int
+	}{
+		{name: "Defaults", limit: 0, wantLimit: service.DefaultZeroResultPageSize},
+		{name: "Capped", offset: -1, limit: 500, wantLimit: service.MaxZeroResultPageSize},
+		{name: "Paged", offset: 40, limit: 20, wantOffset: 40, wantLimit: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			searchRepo := mocks.NewMockSearchRepository(ctrl)
+			searchRepo.EXPECT().ListZeroResults(gomock.Any(), tt.wantOffset, tt.wantLimit).Return(nil, int64(0), nil)
+
+			resp, err := service.NewMerchandisingService(searchRepo, nil).ListZeroResults(context.Background(), tt.offset, tt.limit)
+			require.NoError(t, err)
+			assert.NotNil(t, resp.Items)
+		})
+	}
+}
+
+func TestMerchandisingService_DeleteRule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	searchRepo := mocks.NewMockSearchRepository(ctrl)
+	searchRepo.EXPECT().DeleteRule(gomock.Any(), uint64(8)).Return(errors.New("db down"))
+	ctx, trail := service.WithAuditTrail(context.Background())
+
+	assert.Error(t, service.NewMerchandisingService(searchRepo, nil).DeleteRule(ctx, 8))
+	assert.Empty(t, trail.Entries())
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/proyuen/go-mall/internal/repository"
+)
+
+// Business metrics, exported on /metrics with the infrastructure ones so that
+// alerts can fire on what customers see: orders not coming in, checkouts or
+// payments failing, products selling out. Changes made in a transaction are
+// counted once it commits.
+var (
+	ordersCreated = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "orders_created_total",
+			Help: "Total number of orders placed",
+		},
+	)
+
+	ordersPaid = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "orders_paid_total",
+			Help: "Total number of orders paid in full by payment provider",
+		},
+		[]string{"provider"},
+	)
+
+	ordersCancelled = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "orders_cancelled_total",
+			Help: "Total number of orders cancelled by reason",
+		},
+		[]string{"reason"}, // expired, payment_failed, merchant
+	)
+
+	ordersFailed = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "orders_failed_total",
+			Help: "Total number of orders failed because their stock could not be allocated",
+		},
+	)
+
+	checkoutFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "checkout_failures_total",
+			Help: "Total number of checkouts that placed no order, or one whose payment failed, by reason",
+		},
+		[]string{"reason"}, // see checkoutFailureReason
+	)
+
+	stockOuts = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "stock_outs_total",
+			Help: "Total number of times an order took the last unit of a SKU",
+		},
+	)
+
+	paymentsDisputed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payments_disputed_total",
+			Help: "Total number of order payments disputed by payment provider",
+		},
+		[]string{"provider"},
+	)
+
+	cartRecoveryReminders = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "cart_recovery_reminders_total",
+			Help: "Total number of customers reminded of the carts they abandoned",
+		},
+	)
+
+	cartsRecovered = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "carts_recovered_total",
+			Help: "Total number of abandoned carts ordered after their customers were reminded of them",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(ordersCreated, ordersPaid, ordersCancelled, ordersFailed, checkoutFailures, stockOuts, paymentsDisputed, cartRecoveryReminders, cartsRecovered)
+}
+
+// Reasons orders are cancelled, recorded in orders_cancelled_total.
+const (
+	cancelReasonExpired  = "expired"        // Left unpaid past the order timeout
+	cancelReasonPayment  = "payment_failed" // Compensated by its checkout saga
+	cancelReasonMerchant = "merchant"       // Cancelled by an admin
+)
+
+// Reasons checkouts fail for, beyond those of checkoutFailureReason.
+const (
+	checkoutPaymentDeclined = "payment_declined"
+	checkoutPaymentFailed   = "payment_failed"
+)
+
+// recordCheckoutFailure counts err, if any, in checkout_failures_total.
+func recordCheckoutFailure(err error) {
+	if err != nil {
+		checkoutFailures.WithLabelValues(checkoutFailureReason(err)).Inc()
+	}
+}
+
+// checkoutFailureReason classifies why placing an order failed, for
+// checkout_failures_total.
+func checkoutFailureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrInsufficientStock):
+		return "out_of_stock"
+	case errors.Is(err, ErrPriceChanged):
+		return "price_changed"
+	case errors.Is(err, ErrCouponNotFound), errors.Is(err, ErrCouponNotApplicable), errors.Is(err, ErrCouponRedeemed):
+		return "coupon"
+	case errors.Is(err, ErrPurchaseLimitExceeded):
+		return "purchase_limit"
+	case errors.Is(err, ErrPaymentMethodNotFound):
+		return "payment_method"
+	case errors.Is(err, ErrCheckoutSessionNotFound):
+		return "session_expired"
+	case errors.Is(err, repository.ErrSKUNotFound):
+		return "sku_not_found"
+	case errors.Is(err, ErrUnsupportedCurrency), errors.Is(err, ErrRatesUnavailable):
+		return "currency"
+	case errors.Is(err, ErrTaxRegionRequired), errors.Is(err, ErrTaxRegionNotSupported), errors.Is(err, ErrTaxUnavailable):
+		return "tax"
+	}
+	return "error"
+}
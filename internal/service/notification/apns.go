@@ -0,0 +1,159 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	apnsProductionURL = "https://api.push.apple.com"
+	apnsSandboxURL    = "https://api.sandbox.push.apple.com"
+	// apnsTokenLifetime stays under the hour APNs accepts a provider token for;
+	// APNs also rejects refreshing it more often than every 20 minutes.
+	apnsTokenLifetime = 50 * time.Minute
+)
+
+// apnsUndeliverable lists APNs reasons of tokens that will never work again.
+var apnsUndeliverable = map[string]bool{"BadDeviceToken": true, "DeviceTokenNotForTopic": true, "Unregistered": true}
+
+// APNsProvider sends push notifications through the Apple Push Notification
+// service with token-based authentication. Requests use HTTP/2, which the
+// default transport negotiates over TLS.
+type APNsProvider struct {
+	client  *http.Client
+	baseURL string
+	keyID   string
+	teamID  string
+	topic   string
+	key     *ecdsa.PrivateKey
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+// NewAPNsProvider creates an APNsProvider from the signing key in cfg.
+func NewAPNsProvider(cfg config.APNsConfig) (*APNsProvider, error) {
+	if cfg.KeyID == "" || cfg.TeamID == "" || cfg.Topic == "" {
+		return nil, errors.New("apns key id, team id and topic are required")
+	}
+	raw, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read apns key: %w", err)
+	}
+	key, err := jwt.ParseECPrivateKeyFromPEM(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse apns key: %w", err)
+	}
+	baseURL := apnsProductionURL
+	if cfg.Sandbox {
+		baseURL = apnsSandboxURL
+	}
+	return &APNsProvider{
+		client:  newHTTPClient(),
+		baseURL: baseURL,
+		keyID:   cfg.KeyID,
+		teamID:  cfg.TeamID,
+		topic:   cfg.Topic,
+		key:     key,
+	}, nil
+}
+
+// Send pushes msg to one device as an alert.
+func (p *APNsProvider) Send(ctx context.Context, msg *PushMessage) error {
+	token, err := p.providerToken()
+	if err != nil {
+		return err
+	}
+
+	// Custom data sits next to "aps" at the top level of the payload.
+	payload := make(map[string]any, len(msg.Data)+1)
+	for key, value := range msg.Data {
+		payload[key] = value
+	}
+	payload["aps"] = map[string]any{
+		"alert": map[string]string{"title": msg.Title, "body": msg.Body},
+		"sound": "default",
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("%w: failed to encode apns payload: %w", ErrRejected, err)
+	}
+
+	endpoint := fmt.Sprintf("%s/3/device/%s", p.baseURL, url.PathEscape(msg.Token))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build apns request: %w", err)
+	}
+	req.Header.Set("Authorization", "bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("apns-topic", p.topic)
+	req.Header.Set("apns-push-type", "alert")
+	if msg.ID != "" {
+		req.Header.Set("apns-id", msg.ID)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call apns: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	var apiErr struct {
+		Reason string `json:"reason"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, maxErrorBody)).Decode(&apiErr)
+	err = fmt.Errorf("apns returned %d: %s", resp.StatusCode, apiErr.Reason)
+	switch {
+	case apnsUndeliverable[apiErr.Reason] || resp.StatusCode == http.StatusGone:
+		return fmt.Errorf("%w: %w", ErrHardBounce, err)
+	case apiErr.Reason == "ExpiredProviderToken":
+		p.resetToken()
+		return err
+	default:
+		return classifyHTTP(resp.StatusCode, err)
+	}
+}
+
+// providerToken returns the cached ES256 provider token, signing a new one
+// once it is apnsTokenLifetime old.
+func (p *APNsProvider) providerToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Since(p.issuedAt) < apnsTokenLifetime {
+		return p.token, nil
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"iss": p.teamID, "iat": now.Unix()})
+	token.Header["kid"] = p.keyID
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to sign apns token: %w", ErrRejected, err)
+	}
+	p.token, p.issuedAt = signed, now
+	return signed, nil
+}
+
+func (p *APNsProvider) resetToken() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.token = ""
+}
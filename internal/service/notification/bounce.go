@@ -0,0 +1,83 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SNS message types posted to HTTP subscriptions.
+const (
+	SNSTypeSubscriptionConfirmation = "SubscriptionConfirmation"
+	SNSTypeNotification             = "Notification"
+)
+
+// sesBounceTypePermanent marks hard bounces; transient ones (full mailbox,
+// auto-reply) are left alone.
+const sesBounceTypePermanent = "Permanent"
+
+// SNSEnvelope is the body SNS posts to an HTTP(S) subscription.
+type SNSEnvelope struct {
+	Type         string `json:"Type"`
+	MessageID    string `json:"MessageId"`
+	TopicArn     string `json:"TopicArn"`
+	Message      string `json:"Message"`
+	SubscribeURL string `json:"SubscribeURL"`
+}
+
+// Bounce is an address to suppress, with the reason reported for it.
+type Bounce struct {
+	Email  string
+	Reason string
+}
+
+type sesRecipient struct {
+	EmailAddress   string `json:"emailAddress"`
+	DiagnosticCode string `json:"diagnosticCode"`
+}
+
+// sesEvent is the SES bounce or complaint notification carried in SNSEnvelope.Message.
+// Event publishing uses eventType, feedback forwarding notificationType.
+type sesEvent struct {
+	EventType        string `json:"eventType"`
+	NotificationType string `json:"notificationType"`
+	Bounce           struct {
+		BounceType        string         `json:"bounceType"`
+		BounceSubType     string         `json:"bounceSubType"`
+		BouncedRecipients []sesRecipient `json:"bouncedRecipients"`
+	} `json:"bounce"`
+	Complaint struct {
+		ComplainedRecipients []sesRecipient `json:"complainedRecipients"`
+	} `json:"complaint"`
+}
+
+// ParseSESEvent returns the addresses to suppress from an SES notification:
+// recipients of permanent bounces and recipients who marked mail as spam.
+// Other events yield none.
+func ParseSESEvent(message string) ([]Bounce, error) {
+	var event sesEvent
+	if err := json.Unmarshal([]byte(message), &event); err != nil {
+		return nil, fmt.Errorf("failed to decode ses notification: %w", err)
+	}
+
+	eventType := event.EventType
+	if eventType == "" {
+		eventType = event.NotificationType
+	}
+	var bounces []Bounce
+	switch eventType {
+	case "Bounce":
+		if event.Bounce.BounceType != sesBounceTypePermanent {
+			return nil, nil
+		}
+		for _, r := range event.Bounce.BouncedRecipients {
+			reason := strings.TrimSpace(fmt.Sprintf("ses bounce %s/%s %s", event.Bounce.BounceType, event.Bounce.BounceSubType, r.DiagnosticCode))
+			bounces = append(bounces, Bounce{Email: r.EmailAddress, Reason: reason})
+		}
+	case "Complaint":
+		for _, r := range event.Complaint.ComplainedRecipients {
+			bounces = append(bounces, Bounce{Email: r.EmailAddress, Reason: "ses complaint"})
+		}
+	}
+	return bounces, nil
+}
@@ -0,0 +1,53 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSESEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    []Bounce
+		wantErr bool
+	}{
+		{
+			name:    "PermanentBounce",
+			message: `{"eventType":"Bounce","bounce":{"bounceType":"Permanent","bounceSubType":"General","bouncedRecipients":[{"emailAddress":"gone@example.com","diagnosticCode":"550 5.1.1 unknown"}]}}`,
+			want:    []Bounce{{Email: "gone@example.com", Reason: "ses bounce Permanent/General 550 5.1.1 unknown"}},
+		},
+		{
+			name:    "TransientBounceIgnored",
+			message: `{"notificationType":"Bounce","bounce":{"bounceType":"Transient","bounceSubType":"MailboxFull","bouncedRecipients":[{"emailAddress":"full@example.com"}]}}`,
+		},
+		{
+			name:    "Complaint",
+			message: `{"notificationType":"Complaint","complaint":{"complainedRecipients":[{"emailAddress":"angry@example.com"}]}}`,
+			want:    []Bounce{{Email: "angry@example.com", Reason: "ses complaint"}},
+		},
+		{
+			name:    "Delivery",
+			message: `{"eventType":"Delivery"}`,
+		},
+		{
+			name:    "Malformed",
+			message: `not json`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSESEvent(tt.message)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
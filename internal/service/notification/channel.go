@@ -0,0 +1,74 @@
+package notification
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+// Channel is a medium a notification is delivered over.
+type Channel string
+
+const (
+	ChannelEmail Channel = "email"
+	ChannelSMS   Channel = "sms"
+	ChannelPush  Channel = "push"
+	// ChannelInbox adds the notification to the user's in-app inbox.
+	ChannelInbox Channel = "inbox"
+)
+
+// Platform is the push service that issued a device token.
+type Platform string
+
+const (
+	PlatformFCM  Platform = "fcm"
+	PlatformAPNs Platform = "apns"
+)
+
+// DefaultChannels applies to kinds notification.channels leaves empty.
+var DefaultChannels = Routes{
+	KindOrderConfirmation: {ChannelEmail, ChannelPush, ChannelInbox},
+	KindOrderFailed:       {ChannelEmail, ChannelPush, ChannelInbox},
+	KindShipment:          {ChannelEmail, ChannelSMS, ChannelPush, ChannelInbox},
+	KindPasswordReset:     {ChannelEmail},
+	KindDigitalDelivery:   {ChannelEmail, ChannelInbox},
+	KindDisputeReminder:   {ChannelEmail, ChannelInbox},
+	KindCartRecovery:      {ChannelEmail, ChannelPush},
+}
+
+// Routes lists the channels each kind is sent over.
+type Routes map[Kind][]Channel
+
+// NewRoutes applies the configured channel lists over DefaultChannels.
+func NewRoutes(cfg config.ChannelsConfig) (Routes, error) {
+	configured := map[Kind][]string{
+		KindOrderConfirmation: cfg.OrderConfirmation,
+		KindOrderFailed:       cfg.OrderFailed,
+		KindShipment:          cfg.Shipment,
+		KindPasswordReset:     cfg.PasswordReset,
+		KindDigitalDelivery:   cfg.DigitalDelivery,
+		KindDisputeReminder:   cfg.DisputeReminder,
+		KindCartRecovery:      cfg.CartRecovery,
+	}
+	routes := make(Routes, len(DefaultChannels))
+	for kind, channels := range DefaultChannels {
+		routes[kind] = channels
+		if len(configured[kind]) == 0 {
+			continue
+		}
+		routes[kind] = make([]Channel, 0, len(configured[kind]))
+		for _, name := range configured[kind] {
+			channel := Channel(name)
+			switch channel {
+			case ChannelEmail, ChannelSMS, ChannelPush, ChannelInbox:
+			default:
+				return nil, fmt.Errorf("%w: %q for %s", ErrUnknownChannel, name, kind)
+			}
+			if !slices.Contains(routes[kind], channel) {
+				routes[kind] = append(routes[kind], channel)
+			}
+		}
+	}
+	return routes, nil
+}
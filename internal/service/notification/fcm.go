@@ -0,0 +1,213 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	fcmBaseURL      = "https://fcm.googleapis.com"
+	fcmScope        = "https://www.googleapis.com/auth/firebase.messaging"
+	googleTokenURI  = "https://oauth2.googleapis.com/token"
+	fcmJWTBearer    = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	fcmAssertionTTL = time.Hour
+	// fcmTokenRefresh renews access tokens this long before they expire.
+	fcmTokenRefresh = time.Minute
+)
+
+// fcmUndeliverable lists FCM error codes of tokens that will never work again:
+// the app was uninstalled, or the token belongs to another project.
+var fcmUndeliverable = map[string]bool{"UNREGISTERED": true, "SENDER_ID_MISMATCH": true}
+
+// serviceAccount is the part of a Google service account JSON key FCMProvider uses.
+type serviceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCMProvider sends push notifications through the Firebase Cloud Messaging
+// HTTP v1 API, authenticating as a service account.
+type FCMProvider struct {
+	client      *http.Client
+	baseURL     string
+	projectID   string
+	clientEmail string
+	tokenURI    string
+	key         *rsa.PrivateKey
+
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+// NewFCMProvider creates an FCMProvider from the service account key in cfg.
+func NewFCMProvider(cfg config.FCMConfig) (*FCMProvider, error) {
+	raw, err := os.ReadFile(cfg.CredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fcm credentials: %w", err)
+	}
+	var account serviceAccount
+	if err := json.Unmarshal(raw, &account); err != nil {
+		return nil, fmt.Errorf("failed to decode fcm credentials: %w", err)
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fcm private key: %w", err)
+	}
+	p := &FCMProvider{
+		client:      newHTTPClient(),
+		baseURL:     fcmBaseURL,
+		projectID:   cfg.ProjectID,
+		clientEmail: account.ClientEmail,
+		tokenURI:    account.TokenURI,
+		key:         key,
+	}
+	if p.projectID == "" {
+		p.projectID = account.ProjectID
+	}
+	if p.tokenURI == "" {
+		p.tokenURI = googleTokenURI
+	}
+	if p.projectID == "" || p.clientEmail == "" {
+		return nil, errors.New("fcm credentials have no project ID or client email")
+	}
+	return p, nil
+}
+
+// Send pushes msg to one device.
+func (p *FCMProvider) Send(ctx context.Context, msg *PushMessage) error {
+	accessToken, err := p.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]any{
+		"message": map[string]any{
+			"token":        msg.Token,
+			"notification": map[string]string{"title": msg.Title, "body": msg.Body},
+			"data":         msg.Data,
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("%w: failed to encode fcm message: %w", ErrRejected, err)
+	}
+	endpoint := fmt.Sprintf("%s/v1/projects/%s/messages:send", p.baseURL, url.PathEscape(p.projectID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build fcm request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call fcm: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusMultipleChoices {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	var apiErr struct {
+		Error struct {
+			Message string `json:"message"`
+			Status  string `json:"status"`
+			Details []struct {
+				ErrorCode string `json:"errorCode"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, maxErrorBody)).Decode(&apiErr)
+	code := apiErr.Error.Status
+	for _, detail := range apiErr.Error.Details {
+		if detail.ErrorCode != "" {
+			code = detail.ErrorCode
+		}
+	}
+	err = fmt.Errorf("fcm returned %d: %s (%s)", resp.StatusCode, apiErr.Error.Message, code)
+	switch {
+	case fcmUndeliverable[code] || resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %w", ErrHardBounce, err)
+	case resp.StatusCode == http.StatusUnauthorized:
+		// The access token was revoked or expired early; fetch a new one on retry.
+		p.resetToken()
+		return err
+	default:
+		return classifyHTTP(resp.StatusCode, err)
+	}
+}
+
+// token returns a cached OAuth access token, exchanging a signed assertion
+// for a new one when it is about to expire.
+func (p *FCMProvider) token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.accessToken != "" && time.Now().Add(fcmTokenRefresh).Before(p.expiry) {
+		return p.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   p.clientEmail,
+		"scope": fcmScope,
+		"aud":   p.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(fcmAssertionTTL).Unix(),
+	}).SignedString(p.key)
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to sign fcm assertion: %w", ErrRejected, err)
+	}
+
+	form := url.Values{"grant_type": {fcmJWTBearer}, "assertion": {assertion}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build fcm token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch fcm access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxErrorBody)).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode fcm access token (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices || result.AccessToken == "" {
+		err := fmt.Errorf("fcm token endpoint returned %d: %s %s", resp.StatusCode, result.Error, result.ErrorDescription)
+		return "", classifyHTTP(resp.StatusCode, err)
+	}
+	p.accessToken = result.AccessToken
+	p.expiry = now.Add(time.Duration(result.ExpiresIn) * time.Second)
+	return p.accessToken, nil
+}
+
+func (p *FCMProvider) resetToken() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.accessToken = ""
+}
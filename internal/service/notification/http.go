@@ -0,0 +1,32 @@
+package notification
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// providerTimeout bounds one call to a provider's HTTP API.
+	providerTimeout = 30 * time.Second
+	// maxErrorBody bounds how much of an error response is read.
+	maxErrorBody = 64 << 10
+)
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: providerTimeout}
+}
+
+// classifyHTTP wraps a failed API call by its status: client errors would fail
+// again and wrap ErrRejected, except timeouts and rate limits, which stay
+// transient like server errors.
+func classifyHTTP(status int, err error) error {
+	switch {
+	case status == http.StatusRequestTimeout || status == http.StatusTooManyRequests:
+		return err
+	case status >= 400 && status < 500:
+		return fmt.Errorf("%w: %w", ErrRejected, err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,578 @@
+// Package notification sends transactional email, SMS and push notifications.
+// Producers enqueue a notification with a Notifier, which queues one Delivery
+// per channel the kind is routed to; cmd/worker consumes the queue and hands
+// each one to Service.Deliver, which applies the recipient's preferences,
+// renders the template and sends it through the channel's provider.
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+)
+
+var (
+	ErrUnknownKind        = errors.New("unknown notification kind")
+	ErrUnknownCategory    = errors.New("unknown notification category")
+	ErrUnknownChannel     = errors.New("unknown notification channel")
+	ErrUnknownPlatform    = errors.New("unknown push platform")
+	ErrInvalidData        = errors.New("invalid notification data")
+	ErrPushDeviceNotFound = errors.New("push device not found")
+	// ErrNotificationNotFound is returned when a user's inbox has no
+	// notification with the given ID.
+	ErrNotificationNotFound = repository.ErrNotificationNotFound
+)
+
+// Category groups kinds for notification preferences.
+type Category string
+
+const (
+	// CategoryAccount is security mail such as password resets; it cannot be turned off.
+	CategoryAccount    Category = "account"
+	CategoryOrder      Category = "order"
+	CategoryShipment   Category = "shipment"
+	CategoryPromotion  Category = "promotion"
+	CategoryPriceAlert Category = "price_alert"
+	// CategoryDigital carries digital goods themselves, so it cannot be turned off either.
+	CategoryDigital Category = "digital"
+	// CategoryAdmin alerts admins to store operations that need them, such
+	// as dispute deadlines; it cannot be turned off.
+	CategoryAdmin Category = "admin"
+)
+
+// OptionalCategories are the categories users choose the channels of.
+var OptionalCategories = []Category{CategoryOrder, CategoryShipment, CategoryPromotion, CategoryPriceAlert}
+
+// Channels are every channel a notification can be delivered over.
+var Channels = []Channel{ChannelEmail, ChannelSMS, ChannelPush, ChannelInbox}
+
+// Result is the outcome of one delivery attempt, as recorded in metrics and
+// delivery records.
+type Result string
+
+const (
+	ResultSent        Result = "sent"
+	ResultOptedOut    Result = "opted_out"    // The recipient turned the category or channel off
+	ResultNoRecipient Result = "no_recipient" // No phone number or push device to send to
+	ResultSuppressed  Result = "suppressed"   // The address bounced before
+	ResultBounced     Result = "bounced"      // The provider rejected the recipient; suppressed or pruned where possible
+	ResultFailed      Result = "failed"
+	ResultRetrying    Result = "retrying" // Failed and queued for another attempt; only recorded by the worker
+)
+
+// Delivery limits of Service.Deliveries.
+const (
+	DefaultDeliveriesLimit = 20
+	MaxDeliveriesLimit     = 100
+)
+
+// Page sizes of Service.Inbox.
+const (
+	DefaultInboxPageSize = 20
+	MaxInboxPageSize     = 100
+)
+
+// Delivery is the queued request to send one notification to a user over one channel.
+type Delivery struct {
+	ID      string          `json:"id"` // Idempotency key across redeliveries
+	UserID  uint64          `json:"user_id"`
+	Kind    Kind            `json:"kind"`
+	Channel Channel         `json:"channel"`
+	Data    json.RawMessage `json:"data"`
+	Locale  string          `json:"locale,omitempty"` // BCP 47 tag of the templates to render; empty means the default locale
+	Attempt int             `json:"attempt"`          // Deliveries already tried and failed
+}
+
+// Preferences are the optional notifications a user receives. Events lists
+// the channels each of OptionalCategories is sent over; SMS also needs Phone,
+// and push a registered device.
+type Preferences struct {
+	Phone  string                 `json:"phone"`
+	Events map[Category][]Channel `json:"events"`
+}
+
+// DefaultPreferences apply to users who never saved any, and to the
+// categories an update leaves out: every channel but promotions, which users
+// opt in to.
+func DefaultPreferences() Preferences {
+	return Preferences{Events: map[Category][]Channel{
+		CategoryOrder:      Channels,
+		CategoryShipment:   Channels,
+		CategoryPromotion:  {},
+		CategoryPriceAlert: Channels,
+	}}
+}
+
+// allows reports whether a notification of category may be sent over
+// channel. Categories users cannot turn off always may.
+func (p *Preferences) allows(category Category, channel Channel) bool {
+	if !slices.Contains(OptionalCategories, category) {
+		return true
+	}
+	return slices.Contains(p.Events[category], channel)
+}
+
+// DeliveryStatus is the latest state of a Delivery.
+type DeliveryStatus struct {
+	ID        string    `json:"id"`
+	Kind      Kind      `json:"kind"`
+	Channel   Channel   `json:"channel"`
+	Status    Result    `json:"status"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// InboxItem is a notification in a user's in-app inbox.
+type InboxItem struct {
+	ID        uint64            `json:"id,string"`
+	Kind      Kind              `json:"kind"`
+	Title     string            `json:"title"`
+	Body      string            `json:"body"`
+	Data      map[string]string `json:"data"` // Fields of the notification, e.g. order_number, for linking to it
+	ReadAt    *time.Time        `json:"read_at"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Inbox is one page of a user's in-app notifications, newest first.
+type Inbox struct {
+	Items  []InboxItem `json:"items"`
+	Total  int64       `json:"total"`  // Notifications matching the query
+	Unread int64       `json:"unread"` // Unread notifications in the whole inbox
+}
+
+// IsPermanent reports whether a failed delivery would fail again, so that it
+// should not be retried.
+func IsPermanent(err error) bool {
+	return errors.Is(err, ErrUnknownKind) ||
+		errors.Is(err, ErrUnknownChannel) ||
+		errors.Is(err, ErrInvalidData) ||
+		errors.Is(err, ErrRejected) ||
+		errors.Is(err, repository.ErrUserNotFound)
+}
+
+// Service manages notification preferences and push devices, delivers queued
+// notifications and tracks their status.
+//
+//go:generate mockgen -source=$GOFILE -destination=../../mocks/notification_service_mock.go -package=mocks
+type Service interface {
+	Preferences(ctx context.Context, userID uint64) (*Preferences, error)
+	UpdatePreferences(ctx context.Context, userID uint64, prefs Preferences) (*Preferences, error)
+	RegisterPushDevice(ctx context.Context, userID uint64, platform Platform, token string) error
+	UnregisterPushDevice(ctx context.Context, userID uint64, token string) error
+	RecordBounce(ctx context.Context, email, reason string) error
+	Deliver(ctx context.Context, d *Delivery) (Result, error)
+	// Track records the outcome of a delivery attempt.
+	Track(ctx context.Context, d *Delivery, status Result, cause error) error
+	// Deliveries returns the latest deliveries to a user, newest first.
+	Deliveries(ctx context.Context, userID uint64, limit int) ([]DeliveryStatus, error)
+	// Inbox returns a page of the user's in-app notifications, only the
+	// unread ones if unreadOnly.
+	Inbox(ctx context.Context, userID uint64, unreadOnly bool, offset, limit int) (*Inbox, error)
+	MarkRead(ctx context.Context, userID, id uint64) error
+	// MarkAllRead marks every notification of the user read and returns how
+	// many were unread.
+	MarkAllRead(ctx context.Context, userID uint64) (int64, error)
+}
+
+// Providers are the senders of each channel. Push holds one provider per platform.
+type Providers struct {
+	Email EmailProvider
+	SMS   SMSProvider
+	Push  map[Platform]PushProvider
+}
+
+type service struct {
+	users     repository.UserRepository
+	repo      repository.NotificationRepository
+	providers Providers
+	renderer  *Renderer
+	from      string
+}
+
+// NewService creates a Service sending email from the given RFC 5322 address,
+// e.g. "Go Mall <no-reply@example.com>".
+func NewService(users repository.UserRepository, repo repository.NotificationRepository, providers Providers, renderer *Renderer, from string) Service {
+	return &service{
+		users:     users,
+		repo:      repo,
+		providers: providers,
+		renderer:  renderer,
+		from:      from,
+	}
+}
+
+// Preferences returns the user's preferences, or the defaults if none were saved.
+func (s *service) Preferences(ctx context.Context, userID uint64) (*Preferences, error) {
+	pref, err := s.repo.GetPreference(ctx, userID)
+	if errors.Is(err, repository.ErrPreferenceNotFound) {
+		prefs := DefaultPreferences()
+		return &prefs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	prefs := Preferences{Phone: pref.Phone, Events: make(map[Category][]Channel, len(OptionalCategories))}
+	for _, category := range OptionalCategories {
+		prefs.Events[category] = []Channel{}
+		for _, channel := range Channels {
+			if !slices.Contains(pref.Muted[string(category)], string(channel)) {
+				prefs.Events[category] = append(prefs.Events[category], channel)
+			}
+		}
+	}
+	return &prefs, nil
+}
+
+// UpdatePreferences replaces the user's preferences. Categories prefs leaves
+// out get their defaults.
+func (s *service) UpdatePreferences(ctx context.Context, userID uint64, prefs Preferences) (*Preferences, error) {
+	for category, channels := range prefs.Events {
+		if !slices.Contains(OptionalCategories, category) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownCategory, category)
+		}
+		for _, channel := range channels {
+			if !slices.Contains(Channels, channel) {
+				return nil, fmt.Errorf("%w: %q", ErrUnknownChannel, channel)
+			}
+		}
+	}
+
+	saved := Preferences{Phone: prefs.Phone, Events: DefaultPreferences().Events}
+	muted := make(map[string][]string, len(OptionalCategories))
+	for _, category := range OptionalCategories {
+		if channels, ok := prefs.Events[category]; ok {
+			saved.Events[category] = []Channel{}
+			for _, channel := range Channels { // In order, without duplicates
+				if slices.Contains(channels, channel) {
+					saved.Events[category] = append(saved.Events[category], channel)
+				}
+			}
+		}
+		muted[string(category)] = []string{}
+		for _, channel := range Channels {
+			if !slices.Contains(saved.Events[category], channel) {
+				muted[string(category)] = append(muted[string(category)], string(channel))
+			}
+		}
+	}
+	err := s.repo.SavePreference(ctx, &model.NotificationPreference{UserID: userID, Phone: prefs.Phone, Muted: muted})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update notification preferences: %w", err)
+	}
+	return &saved, nil
+}
+
+// RegisterPushDevice adds a device token to the user's push recipients.
+func (s *service) RegisterPushDevice(ctx context.Context, userID uint64, platform Platform, token string) error {
+	switch platform {
+	case PlatformFCM, PlatformAPNs:
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownPlatform, platform)
+	}
+	err := s.repo.SavePushDevice(ctx, &model.PushDevice{UserID: userID, Platform: string(platform), Token: token})
+	if err != nil {
+		return fmt.Errorf("failed to register push device: %w", err)
+	}
+	return nil
+}
+
+// UnregisterPushDevice removes one of the user's device tokens.
+func (s *service) UnregisterPushDevice(ctx context.Context, userID uint64, token string) error {
+	if err := s.repo.DeletePushDevice(ctx, userID, token); err != nil {
+		if errors.Is(err, repository.ErrPushDeviceNotFound) {
+			return ErrPushDeviceNotFound
+		}
+		return fmt.Errorf("failed to unregister push device: %w", err)
+	}
+	return nil
+}
+
+// RecordBounce suppresses an address the provider reported as undeliverable.
+func (s *service) RecordBounce(ctx context.Context, email, reason string) error {
+	if err := s.repo.SuppressEmail(ctx, email, truncate(reason, maxReasonLength)); err != nil {
+		return fmt.Errorf("failed to record bounce: %w", err)
+	}
+	return nil
+}
+
+// Deliver sends one queued notification unless the recipient opted out or
+// cannot be reached on its channel. A rejected recipient is not an error.
+// Errors for which IsPermanent is false may be retried.
+func (s *service) Deliver(ctx context.Context, d *Delivery) (Result, error) {
+	spec, ok := kinds[d.Kind]
+	if !ok {
+		return ResultFailed, fmt.Errorf("%w: %q", ErrUnknownKind, d.Kind)
+	}
+	switch d.Channel {
+	case ChannelEmail, ChannelSMS, ChannelPush, ChannelInbox:
+	default:
+		return ResultFailed, fmt.Errorf("%w: %q", ErrUnknownChannel, d.Channel)
+	}
+	data, err := decodeData(spec, d.Data)
+	if err != nil {
+		return ResultFailed, err
+	}
+
+	user, err := s.users.GetByID(ctx, d.UserID)
+	if err != nil {
+		return ResultFailed, fmt.Errorf("failed to get recipient: %w", err)
+	}
+	// Account and digital email needs no preferences
+	var prefs *Preferences
+	if slices.Contains(OptionalCategories, spec.category) || d.Channel == ChannelSMS {
+		if prefs, err = s.Preferences(ctx, user.ID); err != nil {
+			return ResultFailed, err
+		}
+		if !prefs.allows(spec.category, d.Channel) {
+			return ResultOptedOut, nil
+		}
+	}
+
+	renderer, err := s.renderer.Resolve(ctx, s.repo, d.Kind, d.Channel, d.Locale)
+	if err != nil {
+		return ResultFailed, err
+	}
+
+	switch d.Channel {
+	case ChannelSMS:
+		return s.deliverSMS(ctx, d, renderer, user, prefs.Phone, data)
+	case ChannelPush:
+		return s.deliverPush(ctx, d, renderer, user, data)
+	case ChannelInbox:
+		return s.deliverInbox(ctx, d, renderer, user, data)
+	default:
+		return s.deliverEmail(ctx, d, renderer, user, data)
+	}
+}
+
+func (s *service) deliverEmail(ctx context.Context, d *Delivery, renderer *Renderer, user *model.User, data payload) (Result, error) {
+	suppressed, err := s.repo.IsEmailSuppressed(ctx, user.Email)
+	if err != nil {
+		return ResultFailed, fmt.Errorf("failed to check suppression list: %w", err)
+	}
+	if suppressed {
+		return ResultSuppressed, nil
+	}
+
+	msg, err := renderer.Render(d.Kind, user.Username, data)
+	if err != nil {
+		return ResultFailed, err
+	}
+	msg.ID = d.ID
+	msg.From = s.from
+	msg.To = user.Email
+
+	if err := s.providers.Email.Send(ctx, msg); err != nil {
+		if errors.Is(err, ErrHardBounce) {
+			if err := s.RecordBounce(ctx, user.Email, err.Error()); err != nil {
+				return ResultFailed, err
+			}
+			return ResultBounced, nil
+		}
+		return ResultFailed, fmt.Errorf("failed to send %s email: %w", d.Kind, err)
+	}
+	return ResultSent, nil
+}
+
+func (s *service) deliverSMS(ctx context.Context, d *Delivery, renderer *Renderer, user *model.User, phone string, data payload) (Result, error) {
+	if phone == "" {
+		return ResultNoRecipient, nil
+	}
+
+	body, err := renderer.RenderSMS(d.Kind, user.Username, data)
+	if err != nil {
+		return ResultFailed, err
+	}
+	msg := &SMSMessage{ID: d.ID, To: phone, Kind: d.Kind, Body: body, Params: templateParams(data)}
+	if err := s.providers.SMS.Send(ctx, msg); err != nil {
+		// Numbers are the user's to fix; the bounce shows in the delivery record.
+		if errors.Is(err, ErrHardBounce) {
+			return ResultBounced, nil
+		}
+		return ResultFailed, fmt.Errorf("failed to send %s sms: %w", d.Kind, err)
+	}
+	return ResultSent, nil
+}
+
+// deliverPush sends to every device of the user. It counts as sent once any
+// device accepted it, so that a retry cannot notify the others twice; tokens
+// the push service rejects are pruned.
+func (s *service) deliverPush(ctx context.Context, d *Delivery, renderer *Renderer, user *model.User, data payload) (Result, error) {
+	devices, err := s.repo.ListPushDevices(ctx, user.ID)
+	if err != nil {
+		return ResultFailed, fmt.Errorf("failed to list push devices: %w", err)
+	}
+	if len(devices) == 0 {
+		return ResultNoRecipient, nil
+	}
+
+	title, body, err := renderer.RenderPush(d.Kind, user.Username, data)
+	if err != nil {
+		return ResultFailed, err
+	}
+	params := templateParams(data)
+	params["kind"] = string(d.Kind)
+
+	var sent, pruned int
+	var failed, rejected []error
+	for _, device := range devices {
+		provider, ok := s.providers.Push[Platform(device.Platform)]
+		if !ok {
+			rejected = append(rejected, fmt.Errorf("%w: no provider for platform %q", ErrRejected, device.Platform))
+			continue
+		}
+		err := provider.Send(ctx, &PushMessage{ID: d.ID, Token: device.Token, Title: title, Body: body, Data: params})
+		switch {
+		case err == nil:
+			sent++
+		case errors.Is(err, ErrHardBounce):
+			if err := s.repo.PrunePushDevice(ctx, device.Token); err != nil {
+				failed = append(failed, err)
+				continue
+			}
+			pruned++
+		case IsPermanent(err):
+			rejected = append(rejected, err)
+		default:
+			failed = append(failed, err)
+		}
+	}
+
+	switch {
+	case sent > 0:
+		return ResultSent, nil
+	case pruned == len(devices):
+		return ResultBounced, nil
+	case len(failed) > 0:
+		return ResultFailed, fmt.Errorf("failed to push %s: %w", d.Kind, errors.Join(failed...))
+	default:
+		return ResultFailed, fmt.Errorf("failed to push %s: %w", d.Kind, errors.Join(rejected...))
+	}
+}
+
+// Track upserts the delivery record of d.
+// deliverInbox adds the notification to the user's inbox, titled and worded
+// like a push notification.
+func (s *service) deliverInbox(ctx context.Context, d *Delivery, renderer *Renderer, user *model.User, data payload) (Result, error) {
+	title, body, err := renderer.RenderPush(d.Kind, user.Username, data)
+	if err != nil {
+		return ResultFailed, err
+	}
+	err = s.repo.AddToInbox(ctx, &model.Notification{
+		UserID:     user.ID,
+		DeliveryID: d.ID,
+		Kind:       string(d.Kind),
+		Title:      title,
+		Body:       body,
+		Data:       templateParams(data),
+	})
+	if err != nil {
+		return ResultFailed, err
+	}
+	return ResultSent, nil
+}
+
+func (s *service) Track(ctx context.Context, d *Delivery, status Result, cause error) error {
+	record := &model.NotificationDelivery{
+		DeliveryID: d.ID,
+		UserID:     d.UserID,
+		Kind:       string(d.Kind),
+		Channel:    string(d.Channel),
+		Status:     string(status),
+		Attempts:   d.Attempt + 1,
+	}
+	if cause != nil {
+		record.LastError = truncate(cause.Error(), maxReasonLength)
+	}
+	if err := s.repo.SaveDelivery(ctx, record); err != nil {
+		return fmt.Errorf("failed to track delivery: %w", err)
+	}
+	return nil
+}
+
+// Deliveries returns up to limit records; zero means DefaultDeliveriesLimit.
+func (s *service) Deliveries(ctx context.Context, userID uint64, limit int) ([]DeliveryStatus, error) {
+	if limit <= 0 {
+		limit = DefaultDeliveriesLimit
+	}
+	limit = min(limit, MaxDeliveriesLimit)
+
+	records, err := s.repo.ListDeliveries(ctx, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+	statuses := make([]DeliveryStatus, 0, len(records))
+	for _, r := range records {
+		statuses = append(statuses, DeliveryStatus{
+			ID:        r.DeliveryID,
+			Kind:      Kind(r.Kind),
+			Channel:   Channel(r.Channel),
+			Status:    Result(r.Status),
+			Attempts:  r.Attempts,
+			LastError: r.LastError,
+			CreatedAt: r.CreatedAt,
+			UpdatedAt: r.UpdatedAt,
+		})
+	}
+	return statuses, nil
+}
+
+// maxReasonLength matches the reason column of email_suppressions and the
+// last_error column of notification_deliveries.
+const maxReasonLength = 255
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
+
+func (s *service) Inbox(ctx context.Context, userID uint64, unreadOnly bool, offset, limit int) (*Inbox, error) {
+	if limit <= 0 {
+		limit = DefaultInboxPageSize
+	}
+	limit = min(limit, MaxInboxPageSize)
+	offset = max(offset, 0)
+
+	notifications, total, err := s.repo.ListInbox(ctx, userID, unreadOnly, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	unread := total
+	if !unreadOnly {
+		if unread, err = s.repo.CountUnread(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	items := make([]InboxItem, len(notifications))
+	for i, n := range notifications {
+		items[i] = InboxItem{
+			ID:        n.ID,
+			Kind:      Kind(n.Kind),
+			Title:     n.Title,
+			Body:      n.Body,
+			Data:      n.Data,
+			ReadAt:    n.ReadAt,
+			CreatedAt: n.CreatedAt,
+		}
+	}
+	return &Inbox{Items: items, Total: total, Unread: unread}, nil
+}
+
+func (s *service) MarkRead(ctx context.Context, userID, id uint64) error {
+	return s.repo.MarkRead(ctx, userID, id, time.Now())
+}
+
+func (s *service) MarkAllRead(ctx context.Context, userID uint64) (int64, error) {
+	return s.repo.MarkAllRead(ctx, userID, time.Now())
+}
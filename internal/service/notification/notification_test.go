@@ -0,0 +1,486 @@
+package notification_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type notificationMocks struct {
+	users    *mocks.MockUserRepository
+	repo     *mocks.MockNotificationRepository
+	provider *mocks.MockEmailProvider
+	sms      *mocks.MockSMSProvider
+	fcm      *mocks.MockPushProvider
+	apns     *mocks.MockPushProvider
+}
+
+func TestService_Deliver(t *testing.T) {
+	user := &model.User{Base: model.Base{ID: 1}, Username: "alice", Email: "alice@example.com"}
+	shipment := json.RawMessage(`{"order_number":"ORD1","carrier":"UPS","tracking_number":"1Z999"}`)
+	reset := json.RawMessage(`{"reset_url":"https://shop.example.com/reset?t=abc","expires_in_minutes":30}`)
+	withPhone := &model.NotificationPreference{UserID: 1, Phone: "+8613800138000"}
+	devices := []model.PushDevice{
+		{UserID: 1, Platform: string(notification.PlatformFCM), Token: "fcm-token"},
+		{UserID: 1, Platform: string(notification.PlatformAPNs), Token: "apns-token"},
+	}
+
+	tests := []struct {
+		name       string
+		delivery   notification.Delivery
+		setup      func(m notificationMocks)
+		wantResult notification.Result
+		wantErr    error
+		permanent  bool
+	}{
+		{
+			name:     "Sent",
+			delivery: notification.Delivery{ID: "e1", UserID: 1, Channel: notification.ChannelEmail, Kind: notification.KindShipment, Data: shipment},
+			setup: func(m notificationMocks) {
+				m.users.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(user, nil)
+				m.repo.EXPECT().GetPreference(gomock.Any(), uint64(1)).Return(nil, repository.ErrPreferenceNotFound)
+				m.repo.EXPECT().IsEmailSuppressed(gomock.Any(), user.Email).Return(false, nil)
+				m.provider.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, msg *notification.Message) error {
+					assert.Equal(t, "e1", msg.ID)
+					assert.Equal(t, "Shop <no-reply@example.com>", msg.From)
+					assert.Equal(t, user.Email, msg.To)
+					assert.Equal(t, "Your Shop order ORD1 has shipped", msg.Subject)
+					assert.Contains(t, msg.Text, "1Z999")
+					assert.Contains(t, msg.HTML, "1Z999")
+					return nil
+				})
+			},
+			wantResult: notification.ResultSent,
+		},
+		{
+			name:     "OptedOut",
+			delivery: notification.Delivery{ID: "e1", UserID: 1, Channel: notification.ChannelEmail, Kind: notification.KindShipment, Data: shipment},
+			setup: func(m notificationMocks) {
+				m.users.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(user, nil)
+				m.repo.EXPECT().GetPreference(gomock.Any(), uint64(1)).Return(&model.NotificationPreference{UserID: 1, Muted: map[string][]string{"shipment": {"email"}}}, nil)
+			},
+			wantResult: notification.ResultOptedOut,
+		},
+		{
+			name:     "AccountEmailIgnoresPreferences",
+			delivery: notification.Delivery{ID: "e1", UserID: 1, Channel: notification.ChannelEmail, Kind: notification.KindPasswordReset, Data: reset},
+			setup: func(m notificationMocks) {
+				m.users.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(user, nil)
+				m.repo.EXPECT().IsEmailSuppressed(gomock.Any(), user.Email).Return(false, nil)
+				m.provider.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantResult: notification.ResultSent,
+		},
+		{
+			name:     "Suppressed",
+			delivery: notification.Delivery{ID: "e1", UserID: 1, Channel: notification.ChannelEmail, Kind: notification.KindPasswordReset, Data: reset},
+			setup: func(m notificationMocks) {
+				m.users.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(user, nil)
+				m.repo.EXPECT().IsEmailSuppressed(gomock.Any(), user.Email).Return(true, nil)
+			},
+			wantResult: notification.ResultSuppressed,
+		},
+		{
+			name:     "HardBounceSuppresses",
+			delivery: notification.Delivery{ID: "e1", UserID: 1, Channel: notification.ChannelEmail, Kind: notification.KindPasswordReset, Data: reset},
+			setup: func(m notificationMocks) {
+				m.users.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(user, nil)
+				m.repo.EXPECT().IsEmailSuppressed(gomock.Any(), user.Email).Return(false, nil)
+				m.provider.EXPECT().Send(gomock.Any(), gomock.Any()).Return(fmt.Errorf("%w: 550 no such user", notification.ErrHardBounce))
+				m.repo.EXPECT().SuppressEmail(gomock.Any(), user.Email, gomock.Any()).Return(nil)
+			},
+			wantResult: notification.ResultBounced,
+		},
+		{
+			name:     "TransientSendFailure",
+			delivery: notification.Delivery{ID: "e1", UserID: 1, Channel: notification.ChannelEmail, Kind: notification.KindPasswordReset, Data: reset},
+			setup: func(m notificationMocks) {
+				m.users.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(user, nil)
+				m.repo.EXPECT().IsEmailSuppressed(gomock.Any(), user.Email).Return(false, nil)
+				m.provider.EXPECT().Send(gomock.Any(), gomock.Any()).Return(errors.New("connection reset"))
+			},
+			wantResult: notification.ResultFailed,
+			wantErr:    errors.New("failed to send password_reset email: connection reset"),
+		},
+		{
+			name:       "UnknownKind",
+			delivery:   notification.Delivery{ID: "e1", UserID: 1, Channel: notification.ChannelEmail, Kind: "newsletter", Data: json.RawMessage(`{}`)},
+			setup:      func(m notificationMocks) {},
+			wantResult: notification.ResultFailed,
+			wantErr:    notification.ErrUnknownKind,
+			permanent:  true,
+		},
+		{
+			name:       "InvalidData",
+			delivery:   notification.Delivery{ID: "e1", UserID: 1, Channel: notification.ChannelEmail, Kind: notification.KindShipment, Data: json.RawMessage(`{"order_number":"ORD1"}`)},
+			setup:      func(m notificationMocks) {},
+			wantResult: notification.ResultFailed,
+			wantErr:    notification.ErrInvalidData,
+			permanent:  true,
+		},
+		{
+			name:       "UnknownChannel",
+			delivery:   notification.Delivery{ID: "e1", UserID: 1, Channel: "fax", Kind: notification.KindShipment, Data: shipment},
+			setup:      func(m notificationMocks) {},
+			wantResult: notification.ResultFailed,
+			wantErr:    notification.ErrUnknownChannel,
+			permanent:  true,
+		},
+		{
+			name:     "SMSSent",
+			delivery: notification.Delivery{ID: "s1", UserID: 1, Channel: notification.ChannelSMS, Kind: notification.KindShipment, Data: shipment},
+			setup: func(m notificationMocks) {
+				m.users.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(user, nil)
+				m.repo.EXPECT().GetPreference(gomock.Any(), uint64(1)).Return(withPhone, nil)
+				m.sms.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, msg *notification.SMSMessage) error {
+					assert.Equal(t, "s1", msg.ID)
+					assert.Equal(t, "+8613800138000", msg.To)
+					assert.Contains(t, msg.Body, "1Z999")
+					assert.Equal(t, "1Z999", msg.Params["tracking_number"])
+					return nil
+				})
+			},
+			wantResult: notification.ResultSent,
+		},
+		{
+			name:     "SMSStoredTemplateOfBaseLanguage",
+			delivery: notification.Delivery{ID: "s1", UserID: 1, Channel: notification.ChannelSMS, Kind: notification.KindShipment, Data: shipment, Locale: "pt-BR"},
+			setup: func(m notificationMocks) {
+				m.users.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(user, nil)
+				m.repo.EXPECT().GetPreference(gomock.Any(), uint64(1)).Return(withPhone, nil)
+				m.repo.EXPECT().GetTemplate(gomock.Any(), "shipment", "sms", "pt-BR").Return(nil, repository.ErrTemplateNotFound)
+				m.repo.EXPECT().GetTemplate(gomock.Any(), "shipment", "sms", "pt").Return(&model.NotificationTemplate{Body: "Pedido {{.Data.OrderNumber}} enviado"}, nil)
+				m.sms.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, msg *notification.SMSMessage) error {
+					assert.Equal(t, "Pedido ORD1 enviado", msg.Body)
+					return nil
+				})
+			},
+			wantResult: notification.ResultSent,
+		},
+		{
+			name:     "SMSNoPhone",
+			delivery: notification.Delivery{ID: "s1", UserID: 1, Channel: notification.ChannelSMS, Kind: notification.KindShipment, Data: shipment},
+			setup: func(m notificationMocks) {
+				m.users.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(user, nil)
+				m.repo.EXPECT().GetPreference(gomock.Any(), uint64(1)).Return(nil, repository.ErrPreferenceNotFound)
+			},
+			wantResult: notification.ResultNoRecipient,
+		},
+		{
+			name:     "SMSHardBounce",
+			delivery: notification.Delivery{ID: "s1", UserID: 1, Channel: notification.ChannelSMS, Kind: notification.KindShipment, Data: shipment},
+			setup: func(m notificationMocks) {
+				m.users.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(user, nil)
+				m.repo.EXPECT().GetPreference(gomock.Any(), uint64(1)).Return(withPhone, nil)
+				m.sms.EXPECT().Send(gomock.Any(), gomock.Any()).Return(fmt.Errorf("%w: unreachable", notification.ErrHardBounce))
+			},
+			wantResult: notification.ResultBounced,
+		},
+		{
+			name:     "PushSentToAnyDevice",
+			delivery: notification.Delivery{ID: "p1", UserID: 1, Channel: notification.ChannelPush, Kind: notification.KindShipment, Data: shipment},
+			setup: func(m notificationMocks) {
+				m.users.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(user, nil)
+				m.repo.EXPECT().GetPreference(gomock.Any(), uint64(1)).Return(nil, repository.ErrPreferenceNotFound)
+				m.repo.EXPECT().ListPushDevices(gomock.Any(), uint64(1)).Return(devices, nil)
+				m.fcm.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, msg *notification.PushMessage) error {
+					assert.Equal(t, "fcm-token", msg.Token)
+					assert.NotEmpty(t, msg.Title)
+					assert.Equal(t, string(notification.KindShipment), msg.Data["kind"])
+					return nil
+				})
+				m.apns.EXPECT().Send(gomock.Any(), gomock.Any()).Return(fmt.Errorf("%w: BadDeviceToken", notification.ErrHardBounce))
+				m.repo.EXPECT().PrunePushDevice(gomock.Any(), "apns-token").Return(nil)
+			},
+			wantResult: notification.ResultSent,
+		},
+		{
+			name:     "PushOptedOut",
+			delivery: notification.Delivery{ID: "p1", UserID: 1, Channel: notification.ChannelPush, Kind: notification.KindShipment, Data: shipment},
+			setup: func(m notificationMocks) {
+				m.users.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(user, nil)
+				m.repo.EXPECT().GetPreference(gomock.Any(), uint64(1)).Return(&model.NotificationPreference{UserID: 1, Muted: map[string][]string{"shipment": {"push"}}}, nil)
+			},
+			wantResult: notification.ResultOptedOut,
+		},
+		{
+			name:     "PushNoDevices",
+			delivery: notification.Delivery{ID: "p1", UserID: 1, Channel: notification.ChannelPush, Kind: notification.KindShipment, Data: shipment},
+			setup: func(m notificationMocks) {
+				m.users.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(user, nil)
+				m.repo.EXPECT().GetPreference(gomock.Any(), uint64(1)).Return(nil, repository.ErrPreferenceNotFound)
+				m.repo.EXPECT().ListPushDevices(gomock.Any(), uint64(1)).Return(nil, nil)
+			},
+			wantResult: notification.ResultNoRecipient,
+		},
+		{
+			name:     "PushTransientFailure",
+			delivery: notification.Delivery{ID: "p1", UserID: 1, Channel: notification.ChannelPush, Kind: notification.KindShipment, Data: shipment},
+			setup: func(m notificationMocks) {
+				m.users.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(user, nil)
+				m.repo.EXPECT().GetPreference(gomock.Any(), uint64(1)).Return(nil, repository.ErrPreferenceNotFound)
+				m.repo.EXPECT().ListPushDevices(gomock.Any(), uint64(1)).Return(devices[:1], nil)
+				m.fcm.EXPECT().Send(gomock.Any(), gomock.Any()).Return(errors.New("fcm returned 503"))
+			},
+			wantResult: notification.ResultFailed,
+			wantErr:    errors.New("failed to push shipment: fcm returned 503"),
+		},
+		{
+			name:     "Inbox",
+			delivery: notification.Delivery{ID: "i1", UserID: 1, Channel: notification.ChannelInbox, Kind: notification.KindShipment, Data: shipment},
+			setup: func(m notificationMocks) {
+				m.users.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(user, nil)
+				m.repo.EXPECT().GetPreference(gomock.Any(), uint64(1)).Return(nil, repository.ErrPreferenceNotFound)
+				m.repo.EXPECT().AddToInbox(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, n *model.Notification) error {
+					assert.Equal(t, uint64(1), n.UserID)
+					assert.Equal(t, "i1", n.DeliveryID)
+					assert.Equal(t, string(notification.KindShipment), n.Kind)
+					assert.Equal(t, "Order ORD1 is on its way with UPS.", n.Body)
+					assert.Equal(t, "ORD1", n.Data["order_number"])
+					return nil
+				})
+			},
+			wantResult: notification.ResultSent,
+		},
+		{
+			name:     "InboxOptedOut",
+			delivery: notification.Delivery{ID: "i1", UserID: 1, Channel: notification.ChannelInbox, Kind: notification.KindShipment, Data: shipment},
+			setup: func(m notificationMocks) {
+				m.users.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(user, nil)
+				m.repo.EXPECT().GetPreference(gomock.Any(), uint64(1)).Return(&model.NotificationPreference{UserID: 1, Muted: map[string][]string{"shipment": {"inbox"}}}, nil)
+			},
+			wantResult: notification.ResultOptedOut,
+		},
+		{
+			name:     "UserDeleted",
+			delivery: notification.Delivery{ID: "e1", UserID: 1, Channel: notification.ChannelEmail, Kind: notification.KindShipment, Data: shipment},
+			setup: func(m notificationMocks) {
+				m.users.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(nil, repository.ErrUserNotFound)
+			},
+			wantResult: notification.ResultFailed,
+			wantErr:    repository.ErrUserNotFound,
+			permanent:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			m := notificationMocks{
+				users:    mocks.NewMockUserRepository(ctrl),
+				repo:     mocks.NewMockNotificationRepository(ctrl),
+				provider: mocks.NewMockEmailProvider(ctrl),
+				sms:      mocks.NewMockSMSProvider(ctrl),
+				fcm:      mocks.NewMockPushProvider(ctrl),
+				apns:     mocks.NewMockPushProvider(ctrl),
+			}
+			tt.setup(m)
+			m.repo.EXPECT().GetTemplate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, repository.ErrTemplateNotFound).AnyTimes()
+
+			renderer, err := notification.NewRenderer("Shop", "")
+			require.NoError(t, err)
+			providers := notification.Providers{
+				Email: m.provider,
+				SMS:   m.sms,
+				Push:  map[notification.Platform]notification.PushProvider{notification.PlatformFCM: m.fcm, notification.PlatformAPNs: m.apns},
+			}
+			svc := notification.NewService(m.users, m.repo, providers, renderer, "Shop <no-reply@example.com>")
+
+			result, err := svc.Deliver(context.Background(), &tt.delivery)
+			assert.Equal(t, tt.wantResult, result)
+			switch {
+			case tt.wantErr == nil:
+				assert.NoError(t, err)
+			case tt.permanent:
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.True(t, notification.IsPermanent(err))
+			default:
+				assert.EqualError(t, err, tt.wantErr.Error())
+				assert.False(t, notification.IsPermanent(err))
+			}
+		})
+	}
+}
+
+func TestService_Preferences(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockNotificationRepository(ctrl)
+	svc := notification.NewService(nil, repo, notification.Providers{}, nil, "")
+	ctx := context.Background()
+
+	repo.EXPECT().GetPreference(gomock.Any(), uint64(1)).Return(nil, repository.ErrPreferenceNotFound)
+	prefs, err := svc.Preferences(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, notification.DefaultPreferences(), *prefs)
+	assert.Empty(t, prefs.Events[notification.CategoryPromotion], "promotions are opt-in")
+
+	repo.EXPECT().GetPreference(gomock.Any(), uint64(2)).Return(&model.NotificationPreference{
+		UserID: 2,
+		Phone:  "+15005550006",
+		Muted:  map[string][]string{"order": {"sms", "inbox"}, "promotion": {"email", "sms", "push", "inbox"}},
+	}, nil)
+	prefs, err = svc.Preferences(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "+15005550006", prefs.Phone)
+	assert.Equal(t, map[notification.Category][]notification.Channel{
+		notification.CategoryOrder:      {notification.ChannelEmail, notification.ChannelPush},
+		notification.CategoryShipment:   notification.Channels,
+		notification.CategoryPromotion:  {},
+		notification.CategoryPriceAlert: notification.Channels,
+	}, prefs.Events)
+}
+
+func TestService_UpdatePreferences(t *testing.T) {
+	tests := []struct {
+		name      string
+		events    map[notification.Category][]notification.Channel
+		wantMuted map[string][]string
+		wantErr   error
+	}{
+		{
+			name: "LeftOutCategoriesGetDefaults",
+			events: map[notification.Category][]notification.Channel{
+				notification.CategoryOrder:     {notification.ChannelPush, notification.ChannelEmail, notification.ChannelPush},
+				notification.CategoryPromotion: {notification.ChannelEmail},
+			},
+			wantMuted: map[string][]string{
+				"order":       {"sms", "inbox"},
+				"shipment":    {},
+				"promotion":   {"sms", "push", "inbox"},
+				"price_alert": {},
+			},
+		},
+		{
+			name:    "UnknownCategory",
+			events:  map[notification.Category][]notification.Channel{notification.CategoryAccount: {}},
+			wantErr: notification.ErrUnknownCategory,
+		},
+		{
+			name:    "UnknownChannel",
+			events:  map[notification.Category][]notification.Channel{notification.CategoryOrder: {"fax"}},
+			wantErr: notification.ErrUnknownChannel,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockNotificationRepository(ctrl)
+			svc := notification.NewService(nil, repo, notification.Providers{}, nil, "")
+			if tt.wantMuted != nil {
+				repo.EXPECT().SavePreference(gomock.Any(), &model.NotificationPreference{UserID: 1, Phone: "+15005550006", Muted: tt.wantMuted}).Return(nil)
+			}
+
+			prefs, err := svc.UpdatePreferences(context.Background(), 1, notification.Preferences{Phone: "+15005550006", Events: tt.events})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []notification.Channel{notification.ChannelEmail, notification.ChannelPush}, prefs.Events[notification.CategoryOrder])
+			assert.Equal(t, notification.Channels, prefs.Events[notification.CategoryShipment])
+		})
+	}
+}
+
+func TestService_Inbox(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockNotificationRepository(ctrl)
+	svc := notification.NewService(nil, repo, notification.Providers{}, nil, "")
+	ctx := context.Background()
+	readAt := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	// The page size is capped and the unread count covers the whole inbox.
+	repo.EXPECT().ListInbox(gomock.Any(), uint64(1), false, 0, notification.MaxInboxPageSize).Return([]model.Notification{
+		{Base: model.Base{ID: 9}, UserID: 1, Kind: "shipment", Title: "Shipped", Body: "ORD1 shipped", Data: map[string]string{"order_number": "ORD1"}},
+		{Base: model.Base{ID: 4}, UserID: 1, Kind: "order_confirmation", Title: "Confirmed", ReadAt: &readAt},
+	}, int64(12), nil)
+	repo.EXPECT().CountUnread(gomock.Any(), uint64(1)).Return(int64(3), nil)
+	inbox, err := svc.Inbox(ctx, 1, false, -5, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), inbox.Total)
+	assert.Equal(t, int64(3), inbox.Unread)
+	require.Len(t, inbox.Items, 2)
+	assert.Equal(t, uint64(9), inbox.Items[0].ID)
+	assert.Equal(t, "ORD1", inbox.Items[0].Data["order_number"])
+	assert.Nil(t, inbox.Items[0].ReadAt)
+	assert.Equal(t, &readAt, inbox.Items[1].ReadAt)
+
+	// Listing only unread ones counts them already.
+	repo.EXPECT().ListInbox(gomock.Any(), uint64(1), true, 0, notification.DefaultInboxPageSize).Return(nil, int64(3), nil)
+	inbox, err = svc.Inbox(ctx, 1, true, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), inbox.Unread)
+	assert.Empty(t, inbox.Items)
+
+	repo.EXPECT().MarkRead(gomock.Any(), uint64(1), uint64(42), gomock.Any()).Return(repository.ErrNotificationNotFound)
+	assert.ErrorIs(t, svc.MarkRead(ctx, 1, 42), notification.ErrNotificationNotFound)
+}
+
+func TestService_Track(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockNotificationRepository(ctrl)
+	svc := notification.NewService(nil, repo, notification.Providers{}, nil, "")
+
+	repo.EXPECT().SaveDelivery(gomock.Any(), &model.NotificationDelivery{
+		DeliveryID: "d1",
+		UserID:     7,
+		Kind:       string(notification.KindShipment),
+		Channel:    string(notification.ChannelSMS),
+		Status:     string(notification.ResultRetrying),
+		Attempts:   2,
+		LastError:  "connection reset",
+	}).Return(nil)
+	d := &notification.Delivery{ID: "d1", UserID: 7, Kind: notification.KindShipment, Channel: notification.ChannelSMS, Attempt: 1}
+	require.NoError(t, svc.Track(context.Background(), d, notification.ResultRetrying, errors.New("connection reset")))
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	publisher := mocks.NewMockPublisher(ctrl)
+	notifier := notification.NewNotifier(publisher, notification.DefaultChannels)
+
+	var channels []notification.Channel
+	ids := map[string]bool{}
+	publisher.EXPECT().Publish(gomock.Any(), "", notification.Queue, gomock.Any()).DoAndReturn(func(_ context.Context, _, _ string, body []byte) error {
+		var d notification.Delivery
+		require.NoError(t, json.Unmarshal(body, &d))
+		assert.Equal(t, uint64(7), d.UserID)
+		assert.Equal(t, notification.KindShipment, d.Kind)
+		assert.Zero(t, d.Attempt)
+		channels = append(channels, d.Channel)
+		ids[d.ID] = true
+		return nil
+	}).Times(4)
+	err := notifier.Notify(context.Background(), 7, notification.KindShipment, notification.ShipmentData{OrderNumber: "ORD1", Carrier: "UPS", TrackingNumber: "1Z999"})
+	require.NoError(t, err)
+	// Each channel is queued, and retried, on its own.
+	assert.Equal(t, []notification.Channel{notification.ChannelEmail, notification.ChannelSMS, notification.ChannelPush, notification.ChannelInbox}, channels)
+	assert.Len(t, ids, 4)
+
+	// Invalid data is rejected before anything is queued.
+	err = notifier.Notify(context.Background(), 7, notification.KindPasswordReset, notification.PasswordResetData{})
+	assert.ErrorIs(t, err, notification.ErrInvalidData)
+}
+
+func TestNewRoutes(t *testing.T) {
+	routes, err := notification.NewRoutes(config.ChannelsConfig{Shipment: []string{"push", "email", "push"}})
+	require.NoError(t, err)
+	assert.Equal(t, []notification.Channel{notification.ChannelPush, notification.ChannelEmail}, routes[notification.KindShipment])
+	assert.Equal(t, notification.DefaultChannels[notification.KindOrderConfirmation], routes[notification.KindOrderConfirmation])
+
+	_, err = notification.NewRoutes(config.ChannelsConfig{PasswordReset: []string{"pigeon"}})
+	assert.ErrorIs(t, err, notification.ErrUnknownChannel)
+}
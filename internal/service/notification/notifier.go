@@ -0,0 +1,86 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/proyuen/go-mall/pkg/mq"
+)
+
+// Queues of the delivery pipeline. Failed deliveries wait in RetryQueue for
+// RetryDelay and then dead-letter back into Queue; deliveries that keep
+// failing, or fail permanently, are parked in DeadLetterQueue for inspection.
+const (
+	Queue           = "notifications"
+	RetryQueue      = "notifications.retry"
+	DeadLetterQueue = "notifications.failed"
+	// RetryDelay is part of the queue declaration and so cannot come from config:
+	// redeclaring a queue with a different TTL fails.
+	RetryDelay = time.Minute
+)
+
+// DeclareQueues creates the queues of the delivery pipeline.
+func DeclareQueues(broker mq.RabbitMQ) error {
+	queues := []struct {
+		name string
+		opts mq.QueueOptions
+	}{
+		{DeadLetterQueue, mq.QueueOptions{}},
+		{Queue, mq.QueueOptions{DeadLetterRoutingKey: DeadLetterQueue}},
+		{RetryQueue, mq.QueueOptions{MessageTTL: RetryDelay, DeadLetterRoutingKey: Queue}},
+	}
+	for _, q := range queues {
+		if err := broker.DeclareQueue(q.name, q.opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Publisher is the part of mq.RabbitMQ the Notifier uses.
+//
+//go:generate mockgen -source=$GOFILE -destination=../../mocks/notifier_mock.go -package=mocks
+type Publisher interface {
+	Publish(ctx context.Context, exchange, routingKey string, body []byte) error
+}
+
+// Notifier enqueues notifications for asynchronous delivery.
+type Notifier interface {
+	// Notify queues a notification of kind to a user on each channel the kind
+	// is routed to. data must be the kind's data type, e.g.
+	// *OrderConfirmationData; it is validated before queueing. On error, some
+	// channels may already have been queued.
+	Notify(ctx context.Context, userID uint64, kind Kind, data any) error
+}
+
+type notifier struct {
+	publisher Publisher
+	routes    Routes
+}
+
+// NewNotifier creates a Notifier publishing to Queue.
+func NewNotifier(publisher Publisher, routes Routes) Notifier {
+	return &notifier{publisher: publisher, routes: routes}
+}
+
+func (n *notifier) Notify(ctx context.Context, userID uint64, kind Kind, data any) error {
+	raw, err := EncodeData(kind, data)
+	if err != nil {
+		return err
+	}
+
+	// One delivery per channel, so that a failing channel is retried alone.
+	for _, channel := range n.routes[kind] {
+		body, err := json.Marshal(Delivery{ID: uuid.NewString(), UserID: userID, Kind: kind, Channel: channel, Data: raw})
+		if err != nil {
+			return fmt.Errorf("failed to encode delivery: %w", err)
+		}
+		if err := n.publisher.Publish(ctx, "", Queue, body); err != nil {
+			return fmt.Errorf("failed to queue %s %s: %w", kind, channel, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// Provider names accepted by notification.provider.
+const (
+	ProviderLog  = "log"
+	ProviderSMTP = "smtp"
+	ProviderSES  = "ses"
+)
+
+// Provider names accepted by notification.sms.provider.
+const (
+	SMSProviderTwilio = "twilio"
+	SMSProviderAliyun = "aliyun"
+)
+
+// DefaultFrom applies when notification.from is empty.
+const DefaultFrom = "Go Mall <no-reply@localhost>"
+
+var (
+	// ErrHardBounce means the recipient address does not exist or refuses mail.
+	ErrHardBounce = errors.New("recipient address rejected")
+	// ErrRejected means the provider will never accept this message, e.g. the
+	// sender is not verified; retrying cannot help.
+	ErrRejected = errors.New("message rejected by provider")
+)
+
+// Message is a rendered email ready to be sent.
+type Message struct {
+	ID      string // Stable across retries; providers may use it for de-duplication
+	From    string // RFC 5322 address, e.g. "Go Mall <no-reply@example.com>"
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// EmailProvider sends email. Implementations wrap ErrHardBounce or ErrRejected
+// for permanent failures; any other error is treated as transient.
+//
+//go:generate mockgen -source=$GOFILE -destination=../../mocks/email_provider_mock.go -package=mocks
+type EmailProvider interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// LogProvider logs emails instead of sending them, for development.
+type LogProvider struct {
+	logger *slog.Logger
+}
+
+// NewLogProvider creates a LogProvider writing to logger.
+func NewLogProvider(logger *slog.Logger) *LogProvider {
+	return &LogProvider{logger: logger}
+}
+
+// Send logs the message and never fails.
+func (p *LogProvider) Send(ctx context.Context, msg *Message) error {
+	p.logger.InfoContext(ctx, "Email not sent: log provider",
+		slog.String("id", msg.ID),
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject),
+		slog.String("text", msg.Text),
+	)
+	return nil
+}
@@ -0,0 +1,48 @@
+package notification
+
+import (
+	"context"
+	"log/slog"
+)
+
+// PushMessage is a rendered push notification for one device.
+type PushMessage struct {
+	ID    string // Stable across retries; a UUID, as APNs requires
+	Token string // Device token issued by the provider's platform
+	Title string
+	Body  string
+	Data  map[string]string // Passed to the app, e.g. the kind and order number
+}
+
+// PushProvider sends push notifications to one platform. Implementations wrap
+// ErrHardBounce when the token is no longer valid, so that it is pruned, and
+// ErrRejected for other permanent failures; any other error is treated as
+// transient.
+//
+//go:generate mockgen -source=$GOFILE -destination=../../mocks/push_provider_mock.go -package=mocks
+type PushProvider interface {
+	Send(ctx context.Context, msg *PushMessage) error
+}
+
+// LogPushProvider logs push notifications instead of sending them, for
+// development and for platforms without credentials.
+type LogPushProvider struct {
+	logger   *slog.Logger
+	platform Platform
+}
+
+// NewLogPushProvider creates a LogPushProvider for platform writing to logger.
+func NewLogPushProvider(logger *slog.Logger, platform Platform) *LogPushProvider {
+	return &LogPushProvider{logger: logger, platform: platform}
+}
+
+// Send logs the message and never fails.
+func (p *LogPushProvider) Send(ctx context.Context, msg *PushMessage) error {
+	p.logger.InfoContext(ctx, "Push not sent: log provider",
+		slog.String("id", msg.ID),
+		slog.String("platform", string(p.platform)),
+		slog.String("title", msg.Title),
+		slog.String("body", msg.Body),
+	)
+	return nil
+}
@@ -0,0 +1,127 @@
+package notification
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFCMProvider_Send(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	var tokenRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			tokenRequests++
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, fcmJWTBearer, r.PostForm.Get("grant_type"))
+			claims := jwt.MapClaims{}
+			_, err := jwt.ParseWithClaims(r.PostForm.Get("assertion"), claims, func(*jwt.Token) (any, error) { return &key.PublicKey, nil })
+			assert.NoError(t, err)
+			assert.Equal(t, "fcm@shop.iam.gserviceaccount.com", claims["iss"])
+			assert.Equal(t, fcmScope, claims["scope"])
+			_, _ = w.Write([]byte(`{"access_token":"ya29.token","expires_in":3600}`))
+		case "/v1/projects/shop/messages:send":
+			assert.Equal(t, "Bearer ya29.token", r.Header.Get("Authorization"))
+			var body struct {
+				Message struct {
+					Token string            `json:"token"`
+					Data  map[string]string `json:"data"`
+				} `json:"message"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			if body.Message.Token == "stale" {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":{"code":404,"message":"Requested entity was not found.","status":"NOT_FOUND","details":[{"errorCode":"UNREGISTERED"}]}}`))
+				return
+			}
+			assert.Equal(t, "ORD1", body.Message.Data["order_number"])
+			_, _ = w.Write([]byte(`{"name":"projects/shop/messages/1"}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	credentials, err := json.Marshal(serviceAccount{
+		ProjectID:   "shop",
+		ClientEmail: "fcm@shop.iam.gserviceaccount.com",
+		PrivateKey:  string(keyPEM),
+		TokenURI:    server.URL + "/token",
+	})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "fcm.json")
+	require.NoError(t, os.WriteFile(path, credentials, 0o600))
+
+	p, err := NewFCMProvider(config.FCMConfig{CredentialsFile: path})
+	require.NoError(t, err)
+	p.baseURL = server.URL
+
+	msg := &PushMessage{ID: "p1", Token: "device", Title: "Shipped", Body: "ORD1 is on its way", Data: map[string]string{"order_number": "ORD1"}}
+	require.NoError(t, p.Send(context.Background(), msg))
+	require.NoError(t, p.Send(context.Background(), msg))
+	assert.Equal(t, 1, tokenRequests, "access token is cached")
+
+	err = p.Send(context.Background(), &PushMessage{ID: "p2", Token: "stale"})
+	assert.ErrorIs(t, err, ErrHardBounce)
+}
+
+func TestAPNsProvider_Send(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "AuthKey.p8")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "com.example.shop", r.Header.Get("apns-topic"))
+		assert.Equal(t, "alert", r.Header.Get("apns-push-type"))
+		claims := jwt.MapClaims{}
+		token, err := jwt.ParseWithClaims(strings.TrimPrefix(r.Header.Get("Authorization"), "bearer "), claims, func(*jwt.Token) (any, error) { return &key.PublicKey, nil })
+		require.NoError(t, err)
+		assert.Equal(t, "KEY123", token.Header["kid"])
+		assert.Equal(t, "TEAM123", claims["iss"])
+
+		var payload map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Contains(t, payload, "aps")
+		if r.URL.Path == "/3/device/stale" {
+			w.WriteHeader(http.StatusGone)
+			_, _ = w.Write([]byte(`{"reason":"Unregistered"}`))
+			return
+		}
+		assert.Equal(t, "/3/device/device", r.URL.Path)
+		assert.Equal(t, "ORD1", payload["order_number"])
+	}))
+	defer server.Close()
+
+	p, err := NewAPNsProvider(config.APNsConfig{KeyFile: path, KeyID: "KEY123", TeamID: "TEAM123", Topic: "com.example.shop"})
+	require.NoError(t, err)
+	p.baseURL = server.URL
+
+	err = p.Send(context.Background(), &PushMessage{ID: "p1", Token: "device", Title: "Shipped", Data: map[string]string{"order_number": "ORD1"}})
+	require.NoError(t, err)
+
+	err = p.Send(context.Background(), &PushMessage{ID: "p2", Token: "stale"})
+	assert.ErrorIs(t, err, ErrHardBounce)
+}
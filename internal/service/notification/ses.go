@@ -0,0 +1,65 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+)
+
+// sesCharset is the charset of every subject and body sent through SES.
+const sesCharset = "UTF-8"
+
+// SESClient is the part of *sesv2.Client SESProvider uses.
+type SESClient interface {
+	SendEmail(ctx context.Context, params *sesv2.SendEmailInput, optFns ...func(*sesv2.Options)) (*sesv2.SendEmailOutput, error)
+}
+
+// SESProvider sends email through the Amazon SES v2 API. SES accepts mail for
+// any syntactically valid address and reports bounces later; publish them to
+// the SES webhook through an SNS topic on the configuration set.
+type SESProvider struct {
+	client           SESClient
+	configurationSet string
+}
+
+// NewSESProvider creates an SESProvider. configurationSet may be empty.
+func NewSESProvider(client SESClient, configurationSet string) *SESProvider {
+	return &SESProvider{client: client, configurationSet: configurationSet}
+}
+
+// Send submits msg to SES.
+func (p *SESProvider) Send(ctx context.Context, msg *Message) error {
+	input := &sesv2.SendEmailInput{
+		FromEmailAddress: aws.String(msg.From),
+		Destination:      &types.Destination{ToAddresses: []string{msg.To}},
+		Content: &types.EmailContent{
+			Simple: &types.Message{
+				Subject: &types.Content{Data: aws.String(msg.Subject), Charset: aws.String(sesCharset)},
+				Body: &types.Body{
+					Text: &types.Content{Data: aws.String(msg.Text), Charset: aws.String(sesCharset)},
+					Html: &types.Content{Data: aws.String(msg.HTML), Charset: aws.String(sesCharset)},
+				},
+			},
+		},
+	}
+	if p.configurationSet != "" {
+		input.ConfigurationSetName = aws.String(p.configurationSet)
+	}
+
+	if _, err := p.client.SendEmail(ctx, input); err != nil {
+		var (
+			rejected   *types.MessageRejected
+			unverified *types.MailFromDomainNotVerifiedException
+			badRequest *types.BadRequestException
+		)
+		if errors.As(err, &rejected) || errors.As(err, &unverified) || errors.As(err, &badRequest) {
+			return fmt.Errorf("%w: %w", ErrRejected, err)
+		}
+		return fmt.Errorf("failed to send email through ses: %w", err)
+	}
+	return nil
+}
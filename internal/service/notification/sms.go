@@ -0,0 +1,44 @@
+package notification
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SMSMessage is a rendered text message ready to be sent.
+type SMSMessage struct {
+	ID     string // Stable across retries
+	To     string // Phone number, preferably E.164
+	Kind   Kind
+	Body   string            // Rendered text, for providers that send free text
+	Params map[string]string // Scalar fields of the kind's data, for providers that only send approved templates
+}
+
+// SMSProvider sends text messages. Implementations wrap ErrHardBounce when the
+// number cannot receive messages and ErrRejected for other permanent failures;
+// any other error is treated as transient.
+//
+//go:generate mockgen -source=$GOFILE -destination=../../mocks/sms_provider_mock.go -package=mocks
+type SMSProvider interface {
+	Send(ctx context.Context, msg *SMSMessage) error
+}
+
+// LogSMSProvider logs text messages instead of sending them, for development.
+type LogSMSProvider struct {
+	logger *slog.Logger
+}
+
+// NewLogSMSProvider creates a LogSMSProvider writing to logger.
+func NewLogSMSProvider(logger *slog.Logger) *LogSMSProvider {
+	return &LogSMSProvider{logger: logger}
+}
+
+// Send logs the message and never fails.
+func (p *LogSMSProvider) Send(ctx context.Context, msg *SMSMessage) error {
+	p.logger.InfoContext(ctx, "SMS not sent: log provider",
+		slog.String("id", msg.ID),
+		slog.String("to", msg.To),
+		slog.String("body", msg.Body),
+	)
+	return nil
+}
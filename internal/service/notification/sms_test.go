@@ -0,0 +1,139 @@
+package notification
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwilioProvider_Send(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		wantErr  bool
+		want     error
+		wantPerm bool
+	}{
+		{name: "Queued", status: http.StatusCreated, response: `{"sid":"SM1","status":"queued"}`},
+		{name: "Unsubscribed", status: http.StatusBadRequest, response: `{"code":21610,"message":"Attempt to send to unsubscribed recipient"}`, wantErr: true, want: ErrHardBounce},
+		{name: "Rejected", status: http.StatusBadRequest, response: `{"code":21606,"message":"The From phone number is not a valid SMS-capable number"}`, wantErr: true, want: ErrRejected, wantPerm: true},
+		{name: "RateLimited", status: http.StatusTooManyRequests, response: `{"code":20429,"message":"Too Many Requests"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/2010-04-01/Accounts/AC1/Messages.json", r.URL.Path)
+				user, pass, ok := r.BasicAuth()
+				assert.True(t, ok)
+				assert.Equal(t, "AC1", user)
+				assert.Equal(t, "secret", pass)
+				require.NoError(t, r.ParseForm())
+				assert.Equal(t, "+15550001111", r.PostForm.Get("To"))
+				assert.Equal(t, "MG123", r.PostForm.Get("MessagingServiceSid"))
+				assert.Equal(t, "Your order shipped", r.PostForm.Get("Body"))
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			p, err := NewTwilioProvider(config.TwilioConfig{AccountSID: "AC1", AuthToken: "secret", From: "MG123"})
+			require.NoError(t, err)
+			p.baseURL = server.URL
+
+			err = p.Send(context.Background(), &SMSMessage{ID: "s1", To: "+15550001111", Kind: KindShipment, Body: "Your order shipped"})
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			if tt.want != nil {
+				assert.ErrorIs(t, err, tt.want)
+			}
+			assert.Equal(t, tt.wantPerm, IsPermanent(err))
+		})
+	}
+}
+
+func TestAliyunSignature(t *testing.T) {
+	// The worked example from the Alibaba Cloud RPC signature documentation.
+	query := url.Values{
+		"AccessKeyId":      {"testId"},
+		"Action":           {"SendSms"},
+		"Format":           {"XML"},
+		"OutId":            {"123"},
+		"PhoneNumbers":     {"15300000001"},
+		"RegionId":         {"cn-hangzhou"},
+		"SignName":         {"阿里云短信测试专用"},
+		"SignatureMethod":  {"HMAC-SHA1"},
+		"SignatureNonce":   {"45e25e9b-0a6f-4070-8c85-2956eda1b466"},
+		"SignatureVersion": {"1.0"},
+		"TemplateCode":     {"SMS_71390007"},
+		"TemplateParam":    {`{"customer":"test"}`},
+		"Timestamp":        {"2017-07-12T02:42:19Z"},
+		"Version":          {"2017-05-25"},
+	}
+	assert.Equal(t, "zJDF+Lrzhj/ThnlvIToysFRq6t4=", aliyunSignature(http.MethodGet, query, "testSecret"))
+}
+
+func TestAliyunProvider_Send(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     Kind
+		response string
+		wantErr  bool
+		want     error
+		wantPerm bool
+	}{
+		{name: "OK", kind: KindShipment, response: `{"Code":"OK","Message":"OK","BizId":"1"}`},
+		{name: "IllegalNumber", kind: KindShipment, response: `{"Code":"isv.MOBILE_NUMBER_ILLEGAL","Message":"invalid number"}`, wantErr: true, want: ErrHardBounce},
+		{name: "RateLimited", kind: KindShipment, response: `{"Code":"isv.BUSINESS_LIMIT_CONTROL","Message":"too frequent"}`, wantErr: true},
+		{name: "BadTemplate", kind: KindShipment, response: `{"Code":"isv.SMS_TEMPLATE_ILLEGAL","Message":"template not approved"}`, wantErr: true, want: ErrRejected, wantPerm: true},
+		{name: "NoTemplate", kind: KindPasswordReset, wantErr: true, want: ErrRejected, wantPerm: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				query := r.URL.Query()
+				assert.Equal(t, "SendSms", query.Get("Action"))
+				assert.Equal(t, "8613800138000", query.Get("PhoneNumbers"))
+				assert.Equal(t, "SMS_1", query.Get("TemplateCode"))
+				assert.Equal(t, `{"tracking_number":"1Z999"}`, query.Get("TemplateParam"))
+				assert.Equal(t, "2026-01-02T03:04:05Z", query.Get("Timestamp"))
+				assert.Equal(t, aliyunSignature(http.MethodGet, query, "secret"), query.Get("Signature"))
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			p, err := NewAliyunProvider(config.AliyunConfig{
+				AccessKeyID:     "key",
+				AccessKeySecret: "secret",
+				SignName:        "Shop",
+				Templates:       config.AliyunTemplateConfig{Shipment: "SMS_1"},
+			})
+			require.NoError(t, err)
+			p.endpoint = server.URL + "/"
+			p.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+			err = p.Send(context.Background(), &SMSMessage{ID: "s1", To: "+8613800138000", Kind: tt.kind, Params: map[string]string{"tracking_number": "1Z999"}})
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			if tt.want != nil {
+				assert.ErrorIs(t, err, tt.want)
+			}
+			assert.Equal(t, tt.wantPerm, IsPermanent(err))
+		})
+	}
+}
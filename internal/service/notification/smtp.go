@@ -0,0 +1,206 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	// smtpTimeout bounds one delivery when ctx has no earlier deadline.
+	smtpTimeout = 30 * time.Second
+	// smtpImplicitTLSPort is the submissions port, which speaks TLS from the
+	// start instead of upgrading with STARTTLS.
+	smtpImplicitTLSPort = "465"
+)
+
+// SMTPProvider sends email through an SMTP relay. The connection is upgraded
+// with STARTTLS whenever the server offers it, and authenticates with PLAIN
+// when a username is configured.
+type SMTPProvider struct {
+	host     string
+	addr     string
+	username string
+	password string
+	tls      *tls.Config
+}
+
+// NewSMTPProvider creates an SMTPProvider for the relay in cfg.
+func NewSMTPProvider(cfg config.SMTPConfig) (*SMTPProvider, error) {
+	if cfg.Host == "" || cfg.Port == "" {
+		return nil, errors.New("smtp host and port are required")
+	}
+	return &SMTPProvider{
+		host:     cfg.Host,
+		addr:     net.JoinHostPort(cfg.Host, cfg.Port),
+		username: cfg.Username,
+		password: cfg.Password,
+		tls:      &tls.Config{ServerName: cfg.Host, MinVersion: tls.VersionTLS12},
+	}, nil
+}
+
+// Send delivers msg in one SMTP session. Permanent (5xx) replies to the
+// recipient wrap ErrHardBounce, other permanent replies ErrRejected.
+func (p *SMTPProvider) Send(ctx context.Context, msg *Message) error {
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("%w: invalid sender %q: %w", ErrRejected, msg.From, err)
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("%w: invalid recipient: %w", ErrHardBounce, err)
+	}
+	body, err := buildMIME(msg, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	client, err := p.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Mail(from.Address); err != nil {
+		return classifySMTP("MAIL FROM", err, ErrRejected)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return classifySMTP("RCPT TO", err, ErrHardBounce)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return classifySMTP("DATA", err, ErrRejected)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return classifySMTP("DATA", err, ErrRejected)
+	}
+	// The message is accepted at this point; a failed QUIT does not matter.
+	_ = client.Quit()
+	return nil
+}
+
+// dial connects, upgrades to TLS and authenticates.
+func (p *SMTPProvider) dial(ctx context.Context) (*smtp.Client, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	dialer := &net.Dialer{Deadline: deadline}
+
+	var conn net.Conn
+	var err error
+	if _, port, _ := net.SplitHostPort(p.addr); port == smtpImplicitTLSPort {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: p.tls}).DialContext(ctx, "tcp", p.addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", p.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set smtp deadline: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, p.host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to start smtp session: %w", err)
+	}
+	if _, isTLS := conn.(*tls.Conn); !isTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(p.tls); err != nil {
+				client.Close()
+				return nil, fmt.Errorf("failed to start tls: %w", err)
+			}
+		}
+	}
+	if p.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", p.username, p.password, p.host)); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("failed to authenticate with smtp server: %w", err)
+		}
+	}
+	return client, nil
+}
+
+// classifySMTP wraps permanent (5xx) replies with the given sentinel; anything
+// else, including 4xx replies, stays transient.
+func classifySMTP(stage string, err error, permanent error) error {
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return fmt.Errorf("%w: %s: %w", permanent, stage, err)
+	}
+	return fmt.Errorf("smtp %s failed: %w", stage, err)
+}
+
+// buildMIME renders msg as a multipart/alternative message with quoted-printable
+// text and HTML parts.
+func buildMIME(msg *Message, from, to *mail.Address) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	headers := []struct{ key, value string }{
+		{"From", from.String()},
+		{"To", to.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", msg.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", mw.Boundary())},
+	}
+	if msg.ID != "" {
+		headers = append(headers, struct{ key, value string }{"Message-ID", fmt.Sprintf("<%s@%s>", msg.ID, domainOf(from.Address))})
+	}
+	for _, h := range headers {
+		fmt.Fprintf(&buf, "%s: %s\r\n", h.key, h.value)
+	}
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// domainOf returns the domain of an email address.
+func domainOf(address string) string {
+	if i := strings.LastIndexByte(address, '@'); i >= 0 {
+		return address[i+1:]
+	}
+	return "localhost"
+}
@@ -0,0 +1,55 @@
+package notification
+
+import (
+	"errors"
+	"mime"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifySMTP(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "PermanentRecipient", err: &textproto.Error{Code: 550, Msg: "no such user"}, want: ErrHardBounce},
+		{name: "TemporaryRecipient", err: &textproto.Error{Code: 451, Msg: "try again later"}},
+		{name: "NetworkError", err: errors.New("connection reset")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifySMTP("RCPT TO", tt.err, ErrHardBounce)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.want != nil {
+				assert.ErrorIs(t, err, tt.want)
+			} else {
+				assert.False(t, IsPermanent(err))
+				assert.NotErrorIs(t, err, ErrHardBounce)
+			}
+		})
+	}
+}
+
+func TestBuildMIME(t *testing.T) {
+	from := &mail.Address{Name: "Shop", Address: "no-reply@shop.example.com"}
+	to := &mail.Address{Address: "alice@example.com"}
+	body, err := buildMIME(&Message{ID: "e1", Subject: "Café order", Text: "plain", HTML: "<p>rich</p>"}, from, to)
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(body)))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Café order", subject)
+	assert.Equal(t, "<e1@shop.example.com>", msg.Header.Get("Message-ID"))
+	assert.Contains(t, msg.Header.Get("Content-Type"), "multipart/alternative")
+	assert.Contains(t, string(body), "text/plain; charset=utf-8")
+	assert.Contains(t, string(body), "<p>rich</p>")
+}
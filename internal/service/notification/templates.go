@@ -0,0 +1,574 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"maps"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/language"
+)
+
+// Kind names an email template.
+type Kind string
+
+const (
+	KindOrderConfirmation Kind = "order_confirmation"
+	KindShipment          Kind = "shipment"
+	KindPasswordReset     Kind = "password_reset"
+	KindDigitalDelivery   Kind = "digital_delivery"
+	// KindOrderFailed tells the customer an order was failed because its
+	// items sold out after it was placed.
+	KindOrderFailed Kind = "order_failed"
+	// KindBroadcast is a marketing message an admin sends to a segment of
+	// users; see service.BroadcastService.
+	KindBroadcast Kind = "broadcast"
+	// KindDisputeReminder reminds an admin that the evidence of a payment
+	// dispute is due soon.
+	KindDisputeReminder Kind = "dispute_reminder"
+	// KindCartRecovery reminds a customer of the cart they left without
+	// ordering; see service.CartRecovery.
+	KindCartRecovery Kind = "cart_recovery"
+)
+
+// OrderConfirmationData is the data of a KindOrderConfirmation email.
+type OrderConfirmationData struct {
+	OrderNumber string          `json:"order_number"`
+	Items       []OrderLine     `json:"items"`
+	Total       decimal.Decimal `json:"total"`
+}
+
+// OrderLine is one item of an order confirmation.
+type OrderLine struct {
+	Name     string          `json:"name"`
+	Quantity int             `json:"quantity"`
+	Price    decimal.Decimal `json:"price"`
+}
+
+// OrderFailedData is the data of a KindOrderFailed email.
+type OrderFailedData struct {
+	OrderNumber string `json:"order_number"`
+}
+
+// ShipmentData is the data of a KindShipment email.
+type ShipmentData struct {
+	OrderNumber    string `json:"order_number"`
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"tracking_number"`
+	TrackingURL    string `json:"tracking_url"` // Optional
+}
+
+// PasswordResetData is the data of a KindPasswordReset email.
+type PasswordResetData struct {
+	ResetURL         string `json:"reset_url"`
+	ExpiresInMinutes int    `json:"expires_in_minutes"`
+}
+
+// DigitalDeliveryData is the data of a KindDigitalDelivery email.
+type DigitalDeliveryData struct {
+	OrderNumber string        `json:"order_number"`
+	Items       []DigitalItem `json:"items"`
+}
+
+// DigitalItem is one digital item of an order: its license keys, or the link
+// to download it and when that link expires.
+type DigitalItem struct {
+	Name        string     `json:"name"`
+	LicenseKeys []string   `json:"license_keys,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// BroadcastData is the data of a KindBroadcast message, written by an admin.
+type BroadcastData struct {
+	BroadcastID uint64 `json:"broadcast_id,string"`
+	Subject     string `json:"subject"` // Email subject and push title
+	Message     string `json:"message"` // Plain text
+	URL         string `json:"url"`     // Optional link to the offer
+}
+
+// DisputeReminderData is the data of a KindDisputeReminder message.
+type DisputeReminderData struct {
+	DisputeID     uint64          `json:"dispute_id,string"`
+	OrderNumber   string          `json:"order_number"`
+	Provider      string          `json:"provider"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      string          `json:"currency"`
+	Reason        string          `json:"reason"`
+	EvidenceDueBy time.Time       `json:"evidence_due_by"`
+}
+
+// CartRecoveryData is the data of a KindCartRecovery message.
+type CartRecoveryData struct {
+	Items      []CartLine `json:"items"`
+	CartURL    string     `json:"cart_url"`    // Optional link to the cart
+	CouponCode string     `json:"coupon_code"` // Optional single-use code
+}
+
+// CartLine is one item of an abandoned cart.
+type CartLine struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+}
+
+func (d *OrderConfirmationData) validate() error {
+	if d.OrderNumber == "" || len(d.Items) == 0 {
+		return errors.New("order number and items are required")
+	}
+	return nil
+}
+
+func (d *OrderFailedData) validate() error {
+	if d.OrderNumber == "" {
+		return errors.New("order number is required")
+	}
+	return nil
+}
+
+func (d *ShipmentData) validate() error {
+	if d.OrderNumber == "" || d.Carrier == "" || d.TrackingNumber == "" {
+		return errors.New("order number, carrier and tracking number are required")
+	}
+	return nil
+}
+
+func (d *PasswordResetData) validate() error {
+	if d.ResetURL == "" || d.ExpiresInMinutes <= 0 {
+		return errors.New("reset URL and expiry are required")
+	}
+	return nil
+}
+
+func (d *DigitalDeliveryData) validate() error {
+	if d.OrderNumber == "" || len(d.Items) == 0 {
+		return errors.New("order number and items are required")
+	}
+	for _, item := range d.Items {
+		if len(item.LicenseKeys) == 0 && item.DownloadURL == "" {
+			return fmt.Errorf("item %q has neither license keys nor a download URL", item.Name)
+		}
+	}
+	return nil
+}
+
+func (d *DisputeReminderData) validate() error {
+	if d.OrderNumber == "" || d.EvidenceDueBy.IsZero() {
+		return errors.New("order number and evidence due date are required")
+	}
+	return nil
+}
+
+func (d *BroadcastData) validate() error {
+	if d.Subject == "" || d.Message == "" {
+		return errors.New("subject and message are required")
+	}
+	return nil
+}
+
+func (d *CartRecoveryData) validate() error {
+	if len(d.Items) == 0 {
+		return errors.New("items are required")
+	}
+	return nil
+}
+
+type payload interface {
+	validate() error
+}
+
+type kindSpec struct {
+	category Category
+	newData  func() payload
+	sample   payload // Data of previews and test sends that bring none
+}
+
+// sampleExpiry is when the download link of the sample digital delivery expires.
+var sampleExpiry = time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// kinds lists every template with its preference category and data type.
+var kinds = map[Kind]kindSpec{
+	KindOrderConfirmation: {
+		category: CategoryOrder,
+		newData:  func() payload { return &OrderConfirmationData{} },
+		sample: &OrderConfirmationData{
+			OrderNumber: "ORD0001",
+			Items:       []OrderLine{{Name: "Sample product", Quantity: 2, Price: decimal.RequireFromString("9.99")}},
+			Total:       decimal.RequireFromString("19.98"),
+		},
+	},
+	KindOrderFailed: {
+		category: CategoryOrder,
+		newData:  func() payload { return &OrderFailedData{} },
+		sample:   &OrderFailedData{OrderNumber: "ORD0001"},
+	},
+	KindShipment: {
+		category: CategoryShipment,
+		newData:  func() payload { return &ShipmentData{} },
+		sample: &ShipmentData{
+			OrderNumber:    "ORD0001",
+			Carrier:        "UPS",
+			TrackingNumber: "1Z999AA10123456784",
+			TrackingURL:    "https://www.ups.com/track?tracknum=1Z999AA10123456784",
+		},
+	},
+	KindPasswordReset: {
+		category: CategoryAccount,
+		newData:  func() payload { return &PasswordResetData{} },
+		sample:   &PasswordResetData{ResetURL: "https://shop.example.com/reset-password?token=sample", ExpiresInMinutes: 30},
+	},
+	KindDigitalDelivery: {
+		category: CategoryDigital,
+		newData:  func() payload { return &DigitalDeliveryData{} },
+		sample: &DigitalDeliveryData{
+			OrderNumber: "ORD0001",
+			Items: []DigitalItem{
+				{Name: "Sample software", LicenseKeys: []string{"AAAA-BBBB-CCCC-DDDD"}},
+				{Name: "Sample e-book", DownloadURL: "https://shop.example.com/downloads/sample", ExpiresAt: &sampleExpiry},
+			},
+		},
+	},
+	KindBroadcast: {
+		category: CategoryPromotion,
+		newData:  func() payload { return &BroadcastData{} },
+		sample: &BroadcastData{
+			BroadcastID: 1,
+			Subject:     "20% off this weekend",
+			Message:     "Everything in the shop is 20% off until Sunday.",
+			URL:         "https://shop.example.com/sale",
+		},
+	},
+	KindDisputeReminder: {
+		category: CategoryAdmin,
+		newData:  func() payload { return &DisputeReminderData{} },
+		sample: &DisputeReminderData{
+			DisputeID:     1,
+			OrderNumber:   "ORD0001",
+			Provider:      "stripe",
+			Amount:        decimal.RequireFromString("19.98"),
+			Currency:      "USD",
+			Reason:        "fraudulent",
+			EvidenceDueBy: sampleExpiry,
+		},
+	},
+	KindCartRecovery: {
+		category: CategoryPromotion,
+		newData:  func() payload { return &CartRecoveryData{} },
+		sample: &CartRecoveryData{
+			Items:      []CartLine{{Name: "Sample product", Quantity: 2}},
+			CartURL:    "https://shop.example.com/cart",
+			CouponCode: "CART-SAMPLE1",
+		},
+	},
+}
+
+// SampleData returns made-up data of kind, for previewing and test sending
+// its templates.
+func SampleData(kind Kind) (json.RawMessage, error) {
+	spec, ok := kinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKind, kind)
+	}
+	return json.Marshal(spec.sample)
+}
+
+// EncodeData checks that data is valid data of kind, e.g.
+// *OrderConfirmationData, and returns it as a delivery carries it.
+func EncodeData(kind Kind, data any) (json.RawMessage, error) {
+	spec, ok := kinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKind, kind)
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidData, err)
+	}
+	// Decoding into the kind's type rejects data the worker could not render.
+	if _, err := decodeData(spec, raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// decodeData unmarshals and validates the data of an email of the given kind.
+func decodeData(spec kindSpec, raw json.RawMessage) (payload, error) {
+	data := spec.newData()
+	if err := json.Unmarshal(raw, data); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidData, err)
+	}
+	if err := data.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidData, err)
+	}
+	return data, nil
+}
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// layoutTemplate wraps the "content" block of every HTML body.
+const layoutTemplate = "templates/layout.html"
+
+// templateData is what the templates see: {{.Brand}}, {{.Username}} and the
+// kind's data as {{.Data}}.
+type templateData struct {
+	Brand    string
+	Username string
+	Data     payload
+}
+
+// Renderer turns a kind and its data into an email, SMS or push message. Each
+// template file defines a "subject", a plain "text", an "sms", a "push_title"
+// and a "push_body" block, rendered as text, and a "content" block rendered as
+// HTML inside the shared email layout. Templates stored in the database
+// override the blocks of one channel in one locale; see Resolve.
+type Renderer struct {
+	brand  string
+	locale string
+	html   map[Kind]*htmltemplate.Template
+	text   map[Kind]*texttemplate.Template
+}
+
+// NewRenderer parses the embedded templates. brand is the shop name shown in
+// every email; locale is the BCP 47 tag deliveries without a locale are
+// rendered in, and an empty one means "en".
+func NewRenderer(brand, locale string) (*Renderer, error) {
+	if tag, err := language.Parse(locale); err == nil {
+		locale = tag.String()
+	} else {
+		locale = "en"
+	}
+	r := &Renderer{
+		brand:  brand,
+		locale: locale,
+		html:   make(map[Kind]*htmltemplate.Template, len(kinds)),
+		text:   make(map[Kind]*texttemplate.Template, len(kinds)),
+	}
+	for kind := range kinds {
+		file := fmt.Sprintf("templates/%s.html", kind)
+		html, err := htmltemplate.ParseFS(templateFS, layoutTemplate, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s template: %w", kind, err)
+		}
+		text, err := texttemplate.ParseFS(templateFS, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s template: %w", kind, err)
+		}
+		r.html[kind], r.text[kind] = html, text
+	}
+	return r, nil
+}
+
+// Render produces the subject and bodies of an email; the caller sets the
+// addresses.
+func (r *Renderer) Render(kind Kind, username string, data payload) (*Message, error) {
+	html, ok := r.html[kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKind, kind)
+	}
+	text := r.text[kind]
+	td := templateData{Brand: r.brand, Username: username, Data: data}
+
+	var subject, textBody, htmlBody bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", td); err != nil {
+		return nil, fmt.Errorf("failed to render %s subject: %w", kind, err)
+	}
+	if err := text.ExecuteTemplate(&textBody, "text", td); err != nil {
+		return nil, fmt.Errorf("failed to render %s text: %w", kind, err)
+	}
+	if err := html.ExecuteTemplate(&htmlBody, "layout", td); err != nil {
+		return nil, fmt.Errorf("failed to render %s html: %w", kind, err)
+	}
+	return &Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(textBody.String()) + "\n",
+		HTML:    htmlBody.String(),
+	}, nil
+}
+
+// RenderSMS produces the text of an SMS.
+func (r *Renderer) RenderSMS(kind Kind, username string, data payload) (string, error) {
+	return r.renderText(kind, "sms", templateData{Brand: r.brand, Username: username, Data: data})
+}
+
+// RenderPush produces the title and body of a push notification.
+func (r *Renderer) RenderPush(kind Kind, username string, data payload) (title, body string, err error) {
+	td := templateData{Brand: r.brand, Username: username, Data: data}
+	if title, err = r.renderText(kind, "push_title", td); err != nil {
+		return "", "", err
+	}
+	if body, err = r.renderText(kind, "push_body", td); err != nil {
+		return "", "", err
+	}
+	return title, body, nil
+}
+
+// renderText executes one text block of kind's template, trimmed.
+func (r *Renderer) renderText(kind Kind, block string, td templateData) (string, error) {
+	text, ok := r.text[kind]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownKind, kind)
+	}
+	var buf bytes.Buffer
+	if err := text.ExecuteTemplate(&buf, block, td); err != nil {
+		return "", fmt.Errorf("failed to render %s %s: %w", kind, block, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Template is the source of one kind's message on one channel, in the syntax
+// of the embedded templates. Subject is the subject of an email or the title
+// of a push notification; Body is the HTML content of an email, which the
+// shared layout wraps, or the text of an SMS or push notification; Text is
+// the plain text of an email.
+type Template struct {
+	Subject string
+	Body    string
+	Text    string
+}
+
+// Preview is a rendered message: the subject, text and HTML of an email, the
+// text of an SMS, or the title, as Subject, and text of a push or inbox
+// notification.
+type Preview struct {
+	Subject string `json:"subject,omitempty"`
+	Text    string `json:"text"`
+	HTML    string `json:"html,omitempty"`
+}
+
+// Override returns a copy of r that renders kind on channel from tmpl rather
+// than the embedded template. Blocks tmpl does not use for channel are
+// ignored.
+func (r *Renderer) Override(kind Kind, channel Channel, tmpl *Template) (*Renderer, error) {
+	text, ok := r.text[kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKind, kind)
+	}
+	// Text templates, unlike HTML ones, may be cloned after executing
+	text, err := text.Clone()
+	if err != nil {
+		return nil, err
+	}
+	html := r.html[kind]
+	blocks := map[string]string{}
+	switch channel {
+	case ChannelEmail:
+		blocks["subject"], blocks["text"] = tmpl.Subject, tmpl.Text
+		if html, err = htmltemplate.ParseFS(templateFS, layoutTemplate); err != nil {
+			return nil, fmt.Errorf("failed to parse email layout: %w", err)
+		}
+		for name, src := range map[string]string{"subject": tmpl.Subject, "content": tmpl.Body} {
+			if _, err := html.New(name).Parse(src); err != nil {
+				return nil, fmt.Errorf("failed to parse %s %s: %w", kind, name, err)
+			}
+		}
+	case ChannelSMS:
+		blocks["sms"] = tmpl.Body
+	case ChannelPush, ChannelInbox: // Inbox notifications are worded like push ones
+		blocks["push_title"], blocks["push_body"] = tmpl.Subject, tmpl.Body
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownChannel, channel)
+	}
+	for name, src := range blocks {
+		if _, err := text.New(name).Parse(src); err != nil {
+			return nil, fmt.Errorf("failed to parse %s %s: %w", kind, name, err)
+		}
+	}
+
+	o := &Renderer{brand: r.brand, locale: r.locale, html: maps.Clone(r.html), text: maps.Clone(r.text)}
+	o.html[kind], o.text[kind] = html, text
+	return o, nil
+}
+
+// Resolve returns the renderer of kind on channel in locale: the latest
+// stored template of the locale overrides the embedded one, or failing that
+// that of its base language, then that of the default locale. An empty
+// locale is the default one.
+func (r *Renderer) Resolve(ctx context.Context, repo repository.NotificationRepository, kind Kind, channel Channel, locale string) (*Renderer, error) {
+	locales := []string{r.locale}
+	if tag, err := language.Parse(locale); err == nil {
+		base, _ := tag.Base()
+		locales = slices.Compact([]string{tag.String(), base.String(), r.locale})
+	}
+	for _, locale := range locales {
+		tmpl, err := repo.GetTemplate(ctx, string(kind), string(channel), locale)
+		if errors.Is(err, repository.ErrTemplateNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s %s template: %w", kind, channel, err)
+		}
+		return r.Override(kind, channel, &Template{Subject: tmpl.Subject, Body: tmpl.Body, Text: tmpl.Text})
+	}
+	return r, nil
+}
+
+// Preview renders kind on channel for username from raw data, or the kind's
+// sample data if raw is empty.
+func (r *Renderer) Preview(kind Kind, channel Channel, username string, raw json.RawMessage) (*Preview, error) {
+	spec, ok := kinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKind, kind)
+	}
+	data := spec.sample
+	if len(raw) > 0 {
+		var err error
+		if data, err = decodeData(spec, raw); err != nil {
+			return nil, err
+		}
+	}
+
+	switch channel {
+	case ChannelEmail:
+		msg, err := r.Render(kind, username, data)
+		if err != nil {
+			return nil, err
+		}
+		return &Preview{Subject: msg.Subject, Text: msg.Text, HTML: msg.HTML}, nil
+	case ChannelSMS:
+		text, err := r.RenderSMS(kind, username, data)
+		if err != nil {
+			return nil, err
+		}
+		return &Preview{Text: text}, nil
+	case ChannelPush, ChannelInbox:
+		title, body, err := r.RenderPush(kind, username, data)
+		if err != nil {
+			return nil, err
+		}
+		return &Preview{Subject: title, Text: body}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownChannel, channel)
+	}
+}
+
+// templateParams flattens the scalar fields of data into strings keyed by
+// their JSON names, for template-only SMS providers and push payloads.
+func templateParams(data payload) map[string]string {
+	params := make(map[string]string)
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return params
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return params
+	}
+	for key, value := range fields {
+		switch v := value.(type) {
+		case string:
+			params[key] = v
+		case float64, bool:
+			params[key] = fmt.Sprint(v)
+		}
+	}
+	return params
+}
@@ -0,0 +1,166 @@
+package notification
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderer_Render(t *testing.T) {
+	renderer, err := NewRenderer("Shop", "")
+	require.NoError(t, err)
+	expires := time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		kind        Kind
+		data        payload
+		wantSubject string
+		wantText    []string
+		wantHTML    []string
+	}{
+		{
+			name: "OrderConfirmation",
+			kind: KindOrderConfirmation,
+			data: &OrderConfirmationData{
+				OrderNumber: "ORD1",
+				Items:       []OrderLine{{Name: "Mug <XL>", Quantity: 2, Price: decimal.RequireFromString("4.5")}},
+				Total:       decimal.RequireFromString("9"),
+			},
+			wantSubject: "Your Shop order ORD1 is confirmed",
+			wantText:    []string{"Hi alice", "Mug <XL>", "9.00"},
+			wantHTML:    []string{"Mug &lt;XL&gt;", "4.50", "9.00"},
+		},
+		{
+			name:        "OrderFailed",
+			kind:        KindOrderFailed,
+			data:        &OrderFailedData{OrderNumber: "ORD1"},
+			wantSubject: "Your Shop order ORD1 could not be completed",
+			wantText:    []string{"Hi alice", "order ORD1 sold out", "not been charged"},
+			wantHTML:    []string{"<strong>ORD1</strong>"},
+		},
+		{
+			name:        "PasswordReset",
+			kind:        KindPasswordReset,
+			data:        &PasswordResetData{ResetURL: "https://shop.example.com/reset?t=a&b", ExpiresInMinutes: 30},
+			wantSubject: "Reset your Shop password",
+			wantText:    []string{"https://shop.example.com/reset?t=a&b", "30 minutes"},
+			wantHTML:    []string{`href="https://shop.example.com/reset?t=a&amp;b"`},
+		},
+		{
+			name: "DigitalDelivery",
+			kind: KindDigitalDelivery,
+			data: &DigitalDeliveryData{
+				OrderNumber: "ORD1",
+				Items: []DigitalItem{
+					{Name: "Editor Pro", LicenseKeys: []string{"AAAA-BBBB"}},
+					{Name: "Manual", DownloadURL: "https://dl.example.com/manual.pdf?expires=1&signature=ab", ExpiresAt: &expires},
+				},
+			},
+			wantSubject: "Your Shop order ORD1 is ready",
+			wantText:    []string{"AAAA-BBBB", "https://dl.example.com/manual.pdf?expires=1&signature=ab", "2026-01-02 15:04 UTC"},
+			wantHTML:    []string{"<code>AAAA-BBBB</code>", `href="https://dl.example.com/manual.pdf?expires=1&amp;signature=ab"`},
+		},
+		{
+			name: "DisputeReminder",
+			kind: KindDisputeReminder,
+			data: &DisputeReminderData{
+				DisputeID: 9, OrderNumber: "ORD1", Provider: "stripe", Amount: decimal.RequireFromString("19.9"), Currency: "EUR",
+				Reason: "fraudulent", EvidenceDueBy: expires,
+			},
+			wantSubject: "Evidence for the dispute of Shop order ORD1 is due 2026-01-02 15:04 UTC",
+			wantText:    []string{"Hi alice", "19.90 EUR, reason: fraudulent", "to stripe by 2026-01-02 15:04 UTC", "dispute 9"},
+			wantHTML:    []string{"<strong>ORD1</strong>"},
+		},
+		{
+			name: "CartRecovery",
+			kind: KindCartRecovery,
+			data: &CartRecoveryData{
+				Items:      []CartLine{{Name: "Mug", Quantity: 2}},
+				CartURL:    "https://shop.example.com/cart",
+				CouponCode: "CART-AB12",
+			},
+			wantSubject: "You left something in your Shop cart",
+			wantText:    []string{"Hi alice", "- 2 x Mug", "code CART-AB12", "View your cart: https://shop.example.com/cart"},
+			wantHTML:    []string{"<li>2 &times; Mug</li>", "<strong>CART-AB12</strong>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := renderer.Render(tt.kind, "alice", tt.data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSubject, msg.Subject)
+			for _, s := range tt.wantText {
+				assert.Contains(t, msg.Text, s)
+			}
+			for _, s := range tt.wantHTML {
+				assert.Contains(t, msg.HTML, s)
+			}
+			assert.Contains(t, msg.HTML, "Shop")
+		})
+	}
+
+	_, err = renderer.Render("newsletter", "alice", nil)
+	assert.ErrorIs(t, err, ErrUnknownKind)
+}
+
+func TestRenderer_RenderSMSAndPush(t *testing.T) {
+	renderer, err := NewRenderer("Shop", "")
+	require.NoError(t, err)
+	data := &ShipmentData{OrderNumber: "ORD1", Carrier: "UPS", TrackingNumber: "1Z999"}
+
+	sms, err := renderer.RenderSMS(KindShipment, "alice", data)
+	require.NoError(t, err)
+	assert.Contains(t, sms, "ORD1")
+	assert.Contains(t, sms, "1Z999")
+	assert.NotContains(t, sms, "\n")
+
+	title, body, err := renderer.RenderPush(KindShipment, "alice", data)
+	require.NoError(t, err)
+	assert.NotEmpty(t, title)
+	assert.Contains(t, body, "ORD1")
+
+	assert.Equal(t, map[string]string{"order_number": "ORD1", "carrier": "UPS", "tracking_number": "1Z999", "tracking_url": ""}, templateParams(data))
+}
+
+func TestRenderer_Override(t *testing.T) {
+	renderer, err := NewRenderer("Shop", "")
+	require.NoError(t, err)
+
+	email, err := renderer.Override(KindShipment, ChannelEmail, &Template{
+		Subject: "{{.Data.OrderNumber}} est en route",
+		Body:    "<p>Colis {{.Data.TrackingNumber}} &amp; plus</p>",
+		Text:    "Colis {{.Data.TrackingNumber}}",
+	})
+	require.NoError(t, err)
+	preview, err := email.Preview(KindShipment, ChannelEmail, "alice", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ORD0001 est en route", preview.Subject)
+	assert.Equal(t, "Colis 1Z999AA10123456784\n", preview.Text)
+	assert.Contains(t, preview.HTML, "<p>Colis 1Z999AA10123456784 &amp; plus</p>")
+	assert.Contains(t, preview.HTML, "<title>ORD0001 est en route</title>")
+
+	// Other channels and the original renderer keep the embedded templates.
+	sms, err := email.Preview(KindShipment, ChannelSMS, "alice", json.RawMessage(`{"order_number":"ORD1","carrier":"UPS","tracking_number":"1Z9"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Shop: order ORD1 has shipped with UPS, tracking 1Z9.", sms.Text)
+	original, err := renderer.Preview(KindShipment, ChannelEmail, "alice", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Your Shop order ORD0001 has shipped", original.Subject)
+
+	push, err := renderer.Override(KindShipment, ChannelPush, &Template{Subject: "Shipped", Body: "{{.Data.Carrier}} has it"})
+	require.NoError(t, err)
+	preview, err = push.Preview(KindShipment, ChannelPush, "alice", nil)
+	require.NoError(t, err)
+	assert.Equal(t, &Preview{Subject: "Shipped", Text: "UPS has it"}, preview)
+
+	_, err = renderer.Override(KindShipment, ChannelSMS, &Template{Body: "{{.Data.OrderNumber"})
+	assert.Error(t, err)
+	_, err = renderer.Override(KindShipment, "fax", &Template{Body: "hi"})
+	assert.ErrorIs(t, err, ErrUnknownChannel)
+}
@@ -0,0 +1,81 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const twilioBaseURL = "https://api.twilio.com"
+
+// twilioUndeliverable lists Twilio error codes for numbers that cannot receive
+// messages: invalid number, unsubscribed (replied STOP) and not a mobile number.
+var twilioUndeliverable = map[int]bool{21211: true, 21610: true, 21614: true}
+
+// TwilioProvider sends SMS through the Twilio Messages API.
+type TwilioProvider struct {
+	client     *http.Client
+	baseURL    string
+	accountSID string
+	authToken  string
+	from       string
+}
+
+// NewTwilioProvider creates a TwilioProvider for the account in cfg.
+func NewTwilioProvider(cfg config.TwilioConfig) (*TwilioProvider, error) {
+	if cfg.AccountSID == "" || cfg.AuthToken == "" || cfg.From == "" {
+		return nil, errors.New("twilio account sid, auth token and from are required")
+	}
+	return &TwilioProvider{
+		client:     newHTTPClient(),
+		baseURL:    twilioBaseURL,
+		accountSID: cfg.AccountSID,
+		authToken:  cfg.AuthToken,
+		from:       cfg.From,
+	}, nil
+}
+
+// Send submits msg.Body to Twilio.
+func (p *TwilioProvider) Send(ctx context.Context, msg *SMSMessage) error {
+	form := url.Values{"To": {msg.To}, "Body": {msg.Body}}
+	if strings.HasPrefix(p.from, "MG") {
+		form.Set("MessagingServiceSid", p.from)
+	} else {
+		form.Set("From", p.from)
+	}
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", p.baseURL, url.PathEscape(p.accountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to build twilio request: %w", err)
+	}
+	req.SetBasicAuth(p.accountSID, p.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call twilio: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusMultipleChoices {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	var apiErr struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, maxErrorBody)).Decode(&apiErr)
+	err = fmt.Errorf("twilio returned %d: %s (code %d)", resp.StatusCode, apiErr.Message, apiErr.Code)
+	if twilioUndeliverable[apiErr.Code] {
+		return fmt.Errorf("%w: %w", ErrHardBounce, err)
+	}
+	return classifyHTTP(resp.StatusCode, err)
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service/notification"
+)
+
+// previewUsername is the recipient named in previews.
+const previewUsername = "customer"
+
+var (
+	// ErrInvalidNotificationTemplate means a notification template is
+	// malformed or fails to render.
+	ErrInvalidNotificationTemplate = errors.New("invalid notification template")
+	// ErrNotificationTemplateNotFound is returned when no template of a kind,
+	// channel and locale is stored.
+	ErrNotificationTemplateNotFound = repository.ErrTemplateNotFound
+)
+
+// NotificationTemplateReq is a new version of the template of a kind on a
+// channel in a locale; see notification.Template for what each part is.
+type NotificationTemplateReq struct {
+	Kind    notification.Kind
+	Channel notification.Channel
+	Locale  string
+	notification.Template
+}
+
+// NotificationPreviewReq is what to preview: the draft Template if set,
+// otherwise the template currently sent for the locale, rendered with Data,
+// or the kind's sample data if empty.
+type NotificationPreviewReq struct {
+	Kind     notification.Kind
+	Channel  notification.Channel
+	Locale   string
+	Template *notification.Template
+	Data     json.RawMessage
+}
+
+// NotificationTemplateResp is one version of a stored template.
+type NotificationTemplateResp struct {
+	Kind      string    `json:"kind" example:"shipment"`
+	Channel   string    `json:"channel" example:"email"`
+	Locale    string    `json:"locale" example:"zh"`
+	Version   int       `json:"version"`
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NotificationTemplateService manages the transactional message templates
+// stored in the database, which override those built into the binary for one
+// kind, channel and locale. Saving a template adds a version and the latest
+// one is sent; deleting it sends the built-in one again. SMS providers that
+// only send templates registered with them ignore stored SMS templates.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/notification_template_service_mock.go -package=mocks
+type NotificationTemplateService interface {
+	// List returns the latest version of every stored template.
+	List(ctx context.Context) ([]NotificationTemplateResp, error)
+	// Versions returns every version of a template, newest first.
+	Versions(ctx context.Context, kind notification.Kind, channel notification.Channel, locale string) ([]NotificationTemplateResp, error)
+	// Save checks that req renders with the kind's sample data and stores it
+	// as the template's next version.
+	Save(ctx context.Context, req *NotificationTemplateReq) (*NotificationTemplateResp, error)
+	Delete(ctx context.Context, kind notification.Kind, channel notification.Channel, locale string) error
+	Preview(ctx context.Context, req *NotificationPreviewReq) (*notification.Preview, error)
+	// TestSend delivers the template currently sent for the locale to the
+	// user at once, with data, or the kind's sample data if empty. The
+	// user's preferences apply as to any delivery.
+	TestSend(ctx context.Context, userID uint64, kind notification.Kind, channel notification.Channel, locale string, data json.RawMessage) (notification.Result, error)
+}
+
+type notificationTemplateService struct {
+	repo          repository.NotificationRepository
+	renderer      *notification.Renderer
+	notifications notification.Service
+}
+
+// NewNotificationTemplateService creates a new NotificationTemplateService
+// instance. Test sends are delivered by notifications.
+func NewNotificationTemplateService(repo repository.NotificationRepository, renderer *notification.Renderer, notifications notification.Service) NotificationTemplateService {
+	return &notificationTemplateService{repo: repo, renderer: renderer, notifications: notifications}
+}
+
+func (s *notificationTemplateService) List(ctx context.Context) ([]NotificationTemplateResp, error) {
+	templates, err := s.repo.ListTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newNotificationTemplateResps(templates), nil
+}
+
+func (s *notificationTemplateService) Versions(ctx context.Context, kind notification.Kind, channel notification.Channel, locale string) ([]NotificationTemplateResp, error) {
+	locale, err := canonicalLocale(locale)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidNotificationTemplate, err)
+	}
+	templates, err := s.repo.ListTemplateVersions(ctx, string(kind), string(channel), locale)
+	if err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return nil, ErrNotificationTemplateNotFound
+	}
+	return newNotificationTemplateResps(templates), nil
+}
+
+func (s *notificationTemplateService) Save(ctx context.Context, req *NotificationTemplateReq) (*NotificationTemplateResp, error) {
+	locale, err := canonicalLocale(req.Locale)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidNotificationTemplate, err)
+	}
+	switch {
+	case req.Body == "":
+		return nil, fmt.Errorf("%w: body cannot be blank", ErrInvalidNotificationTemplate)
+	case req.Channel == notification.ChannelEmail && (req.Subject == "" || req.Text == ""):
+		return nil, fmt.Errorf("%w: email needs a subject and a text", ErrInvalidNotificationTemplate)
+	case (req.Channel == notification.ChannelPush || req.Channel == notification.ChannelInbox) && req.Subject == "":
+		return nil, fmt.Errorf("%w: %s needs a subject, its title", ErrInvalidNotificationTemplate, req.Channel)
+	case req.Channel == notification.ChannelSMS && (req.Subject != "" || req.Text != ""):
+		return nil, fmt.Errorf("%w: sms has only a body", ErrInvalidNotificationTemplate)
+	}
+	if _, err := s.render(req.Kind, req.Channel, &req.Template, nil); err != nil {
+		return nil, err
+	}
+	before, err := s.repo.GetTemplate(ctx, string(req.Kind), string(req.Channel), locale)
+	if err != nil && !errors.Is(err, repository.ErrTemplateNotFound) {
+		return nil, err
+	}
+
+	tmpl := &model.NotificationTemplate{
+		Kind:    string(req.Kind),
+		Channel: string(req.Channel),
+		Locale:  locale,
+		Subject: req.Subject,
+		Body:    req.Body,
+		Text:    req.Text,
+	}
+	if err := s.repo.CreateTemplateVersion(ctx, tmpl); err != nil {
+		return nil, err
+	}
+
+	resp := newNotificationTemplateResp(tmpl)
+	entry := AuditEntry{Action: "notification_template.update", Resource: "notification_template", ResourceID: templateResourceID(tmpl), After: resp}
+	if before != nil {
+		entry.Before = newNotificationTemplateResp(before)
+	}
+	RecordAudit(ctx, entry)
+	return &resp, nil
+}
+
+func (s *notificationTemplateService) Delete(ctx context.Context, kind notification.Kind, channel notification.Channel, locale string) error {
+	locale, err := canonicalLocale(locale)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidNotificationTemplate, err)
+	}
+	before, err := s.repo.GetTemplate(ctx, string(kind), string(channel), locale)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteTemplate(ctx, string(kind), string(channel), locale); err != nil {
+		return err
+	}
+	RecordAudit(ctx, AuditEntry{Action: "notification_template.delete", Resource: "notification_template", ResourceID: templateResourceID(before), Before: newNotificationTemplateResp(before)})
+	return nil
+}
+
+func (s *notificationTemplateService) Preview(ctx context.Context, req *NotificationPreviewReq) (*notification.Preview, error) {
+	if req.Template != nil {
+		return s.render(req.Kind, req.Channel, req.Template, req.Data)
+	}
+	locale := ""
+	if req.Locale != "" {
+		var err error
+		if locale, err = canonicalLocale(req.Locale); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidNotificationTemplate, err)
+		}
+	}
+	renderer, err := s.renderer.Resolve(ctx, s.repo, req.Kind, req.Channel, locale)
+	if err != nil {
+		return nil, err
+	}
+	return renderer.Preview(req.Kind, req.Channel, previewUsername, req.Data)
+}
+
+func (s *notificationTemplateService) TestSend(ctx context.Context, userID uint64, kind notification.Kind, channel notification.Channel, locale string, data json.RawMessage) (notification.Result, error) {
+	if len(data) == 0 {
+		var err error
+		if data, err = notification.SampleData(kind); err != nil {
+			return notification.ResultFailed, err
+		}
+	}
+	d := &notification.Delivery{ID: uuid.NewString(), UserID: userID, Kind: kind, Channel: channel, Data: data, Locale: locale}
+	result, err := s.notifications.Deliver(ctx, d)
+	// Recorded like any delivery, so the test shows among the user's deliveries.
+	if trackErr := s.notifications.Track(ctx, d, result, err); trackErr != nil && err == nil {
+		err = trackErr
+	}
+	return result, err
+}
+
+// render previews tmpl, returning ErrInvalidNotificationTemplate if it does
+// not parse or render.
+func (s *notificationTemplateService) render(kind notification.Kind, channel notification.Channel, tmpl *notification.Template, data json.RawMessage) (*notification.Preview, error) {
+	renderer, err := s.renderer.Override(kind, channel, tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidNotificationTemplate, err)
+	}
+	preview, err := renderer.Preview(kind, channel, previewUsername, data)
+	if err != nil && !errors.Is(err, notification.ErrInvalidData) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidNotificationTemplate, err)
+	}
+	return preview, err
+}
+
+// templateResourceID names a template in the audit log, e.g.
+// "shipment/email/zh".
+func templateResourceID(tmpl *model.NotificationTemplate) string {
+	return tmpl.Kind + "/" + tmpl.Channel + "/" + tmpl.Locale
+}
+
+func newNotificationTemplateResp(tmpl *model.NotificationTemplate) NotificationTemplateResp {
+	return NotificationTemplateResp{
+		Kind:      tmpl.Kind,
+		Channel:   tmpl.Channel,
+		Locale:    tmpl.Locale,
+		Version:   tmpl.Version,
+		Subject:   tmpl.Subject,
+		Body:      tmpl.Body,
+		Text:      tmpl.Text,
+		CreatedAt: tmpl.CreatedAt,
+	}
+}
+
+func newNotificationTemplateResps(templates []model.NotificationTemplate) []NotificationTemplateResp {
+	resp := make([]NotificationTemplateResp, len(templates))
+	for i := range templates {
+		resp[i] = newNotificationTemplateResp(&templates[i])
+	}
+	return resp
+}
@@ -0,0 +1,162 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNotificationTemplateService_Save(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       *service.NotificationTemplateReq
+		mockSetup func(repo *mocks.MockNotificationRepository)
+		wantErr   error
+	}{
+		{
+			name: "FirstVersion",
+			req: &service.NotificationTemplateReq{
+				Kind:     notification.KindShipment,
+				Channel:  notification.ChannelEmail,
+				Locale:   "pt-br",
+				Template: notification.Template{Subject: "Pedido {{.Data.OrderNumber}}", Body: "<p>{{.Data.Carrier}}</p>", Text: "{{.Data.Carrier}}"},
+			},
+			mockSetup: func(repo *mocks.MockNotificationRepository) {
+				repo.EXPECT().GetTemplate(gomock.Any(), "shipment", "email", "pt-BR").Return(nil, repository.ErrTemplateNotFound)
+				repo.EXPECT().CreateTemplateVersion(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tmpl *model.NotificationTemplate) error {
+					assert.Equal(t, "pt-BR", tmpl.Locale)
+					assert.Equal(t, "Pedido {{.Data.OrderNumber}}", tmpl.Subject)
+					tmpl.Version = 1
+					return nil
+				})
+			},
+		},
+		{
+			name: "MissingEmailText",
+			req: &service.NotificationTemplateReq{
+				Kind:     notification.KindShipment,
+				Channel:  notification.ChannelEmail,
+				Locale:   "en",
+				Template: notification.Template{Subject: "Shipped", Body: "<p>Shipped</p>"},
+			},
+			wantErr: service.ErrInvalidNotificationTemplate,
+		},
+		{
+			name: "UnknownField",
+			req: &service.NotificationTemplateReq{
+				Kind:     notification.KindShipment,
+				Channel:  notification.ChannelSMS,
+				Locale:   "en",
+				Template: notification.Template{Body: "{{.Data.Total}}"},
+			},
+			wantErr: service.ErrInvalidNotificationTemplate,
+		},
+		{
+			name: "UnknownKind",
+			req: &service.NotificationTemplateReq{
+				Kind:     "newsletter",
+				Channel:  notification.ChannelSMS,
+				Locale:   "en",
+				Template: notification.Template{Body: "Hi"},
+			},
+			wantErr: service.ErrInvalidNotificationTemplate,
+		},
+		{
+			name: "InvalidLocale",
+			req: &service.NotificationTemplateReq{
+				Kind:     notification.KindShipment,
+				Channel:  notification.ChannelSMS,
+				Locale:   "not a locale",
+				Template: notification.Template{Body: "Hi"},
+			},
+			wantErr: service.ErrInvalidNotificationTemplate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockNotificationRepository(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(repo)
+			}
+			renderer, err := notification.NewRenderer("Shop", "")
+			require.NoError(t, err)
+			ctx, trail := service.WithAuditTrail(context.Background())
+
+			resp, err := service.NewNotificationTemplateService(repo, renderer, nil).Save(ctx, tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, trail.Entries())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 1, resp.Version)
+			require.Len(t, trail.Entries(), 1)
+			assert.Equal(t, "notification_template.update", trail.Entries()[0].Action)
+			assert.Equal(t, "shipment/email/pt-BR", trail.Entries()[0].ResourceID)
+		})
+	}
+}
+
+func TestNotificationTemplateService_Preview(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockNotificationRepository(ctrl)
+	renderer, err := notification.NewRenderer("Shop", "")
+	require.NoError(t, err)
+	svc := service.NewNotificationTemplateService(repo, renderer, nil)
+	ctx := context.Background()
+
+	// A draft renders without touching the stored templates.
+	preview, err := svc.Preview(ctx, &service.NotificationPreviewReq{
+		Kind:     notification.KindShipment,
+		Channel:  notification.ChannelPush,
+		Template: &notification.Template{Subject: "Shipped", Body: "Order {{.Data.OrderNumber}}"},
+		Data:     json.RawMessage(`{"order_number":"ORD9","carrier":"UPS","tracking_number":"1Z"}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &notification.Preview{Subject: "Shipped", Text: "Order ORD9"}, preview)
+
+	// Without one, the stored template of the locale is previewed.
+	repo.EXPECT().GetTemplate(gomock.Any(), "shipment", "sms", "zh").Return(&model.NotificationTemplate{Body: "订单 {{.Data.OrderNumber}} 已发货"}, nil)
+	preview, err = svc.Preview(ctx, &service.NotificationPreviewReq{Kind: notification.KindShipment, Channel: notification.ChannelSMS, Locale: "zh"})
+	require.NoError(t, err)
+	assert.Equal(t, "订单 ORD0001 已发货", preview.Text)
+
+	_, err = svc.Preview(ctx, &service.NotificationPreviewReq{
+		Kind:     notification.KindShipment,
+		Channel:  notification.ChannelSMS,
+		Template: &notification.Template{Body: "Hi"},
+		Data:     json.RawMessage(`{"order_number":"ORD9"}`),
+	})
+	assert.ErrorIs(t, err, notification.ErrInvalidData)
+}
+
+func TestNotificationTemplateService_TestSend(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	notifications := mocks.NewMockService(ctrl)
+	svc := service.NewNotificationTemplateService(nil, nil, notifications)
+
+	var delivery *notification.Delivery
+	notifications.EXPECT().Deliver(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, d *notification.Delivery) (notification.Result, error) {
+		delivery = d
+		return notification.ResultSent, nil
+	})
+	notifications.EXPECT().Track(gomock.Any(), gomock.Any(), notification.ResultSent, nil).Return(nil)
+
+	result, err := svc.TestSend(context.Background(), 7, notification.KindShipment, notification.ChannelEmail, "zh", nil)
+	require.NoError(t, err)
+	assert.Equal(t, notification.ResultSent, result)
+	assert.Equal(t, uint64(7), delivery.UserID)
+	assert.Equal(t, "zh", delivery.Locale)
+	assert.Contains(t, string(delivery.Data), "ORD0001")
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/money"
+)
+
+// Order history defaults, used where callers leave a value zero.
+const (
+	DefaultOrderHistoryPageSize = 20
+	MaxOrderHistoryPageSize     = 100
+	// DefaultOrderSummaryBackfillBatchSize is how many orders Backfill
+	// projects when its caller does not say.
+	DefaultOrderSummaryBackfillBatchSize = 500
+)
+
+// OrderSummaryResp is one order in a customer's order list.
+type OrderSummaryResp struct {
+	OrderID     uint64                 `json:"order_id,string"`
+	OrderNumber string                 `json:"order_number"`
+	Status      string                 `json:"status" example:"paid"`
+	TotalAmount money.Money            `json:"total_amount"`
+	ItemCount   int                    `json:"item_count"` // Units across the items
+	Items       []OrderSummaryItemResp `json:"items"`
+	OrderedAt   time.Time              `json:"ordered_at"`
+}
+
+// OrderSummaryItemResp is one line of an OrderSummaryResp, as it was when
+// the order was placed.
+type OrderSummaryItemResp struct {
+	SKUID    uint64      `json:"sku_id,string"`
+	Name     string      `json:"name"`
+	Image    string      `json:"image"` // Thumbnail URL
+	Quantity int         `json:"quantity"`
+	Price    money.Money `json:"price"`
+	// Options are the product options chosen for every unit, included in
+	// Price.
+	Options []OrderItemOptionResp `json:"options,omitempty"`
+}
+
+// OrderSummaryListResp is a page of a customer's orders.
+type OrderSummaryListResp struct {
+	Orders []OrderSummaryResp `json:"orders"`
+	Total  int64              `json:"total"`
+}
+
+// OrderHistoryService keeps and serves the read model of customers' order
+// lists. cmd/worker projects every order event into it, so listing orders
+// reads one table; a list reflects changes once their events are relayed.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/order_history_service_mock.go -package=mocks
+type OrderHistoryService interface {
+	// List returns a page of the user's orders, newest first.
+	List(ctx context.Context, userID uint64, offset, limit int) (*OrderSummaryListResp, error)
+	// Project rebuilds the summary of an order from its current state. An
+	// order that does not exist is skipped.
+	Project(ctx context.Context, orderID uint64) error
+	// Backfill projects up to limit orders that have no summary, e.g. those
+	// placed before the read model existed, and returns how many it did.
+	Backfill(ctx context.Context, limit int) (int, error)
+}
+
+type orderHistoryService struct {
+	summaryRepo repository.OrderSummaryRepository
+	orderRepo   repository.OrderRepository
+}
+
+// NewOrderHistoryService creates a new OrderHistoryService instance.
+func NewOrderHistoryService(summaryRepo repository.OrderSummaryRepository, orderRepo repository.OrderRepository) OrderHistoryService {
+	return &orderHistoryService{summaryRepo: summaryRepo, orderRepo: orderRepo}
+}
+
+func (s *orderHistoryService) List(ctx context.Context, userID uint64, offset, limit int) (*OrderSummaryListResp, error) {
+	if limit <= 0 {
+		limit = DefaultOrderHistoryPageSize
+	}
+	limit = min(limit, MaxOrderHistoryPageSize)
+	offset = max(offset, 0)
+
+	summaries, total, err := s.summaryRepo.ListByUser(ctx, userID, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := &OrderSummaryListResp{Orders: make([]OrderSummaryResp, len(summaries)), Total: total}
+	for i := range summaries {
+		resp.Orders[i] = newOrderSummaryResp(&summaries[i])
+	}
+	return resp, nil
+}
+
+func (s *orderHistoryService) Project(ctx context.Context, orderID uint64) error {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, repository.ErrOrderNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.summaryRepo.Save(ctx, newOrderSummary(order))
+}
+
+func (s *orderHistoryService) Backfill(ctx context.Context, limit int) (int, error) {
+	if limit <= 0 {
+		limit = DefaultOrderSummaryBackfillBatchSize
+	}
+	ids, err := s.summaryRepo.ListMissing(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+	for i, id := range ids {
+		if err := s.Project(ctx, id); err != nil {
+			return i, fmt.Errorf("failed to project order '%d': %w", id, err)
+		}
+	}
+	return len(ids), nil
+}
+
+func newOrderSummary(order *model.Order) *model.OrderSummary {
+	summary := &model.OrderSummary{
+		OrderID:        order.ID,
+		StoreID:        order.StoreID,
+		UserID:         order.UserID,
+		OrderNumber:    order.OrderNumber,
+		Status:         order.Status,
+		TotalAmount:    order.TotalAmount,
+		Currency:       order.Currency,
+		OrderedAt:      order.CreatedAt,
+		OrderUpdatedAt: order.UpdatedAt,
+	}
+	// The customer sees the bundles they ordered, not their components
+	items := customerItems(order.Items)
+	summary.Items = make([]model.OrderSummaryItem, len(items))
+	for i, item := range items {
+		summary.Items[i] = model.OrderSummaryItem{
+			SKUID:    item.SKUID,
+			Name:     item.SnapshotName,
+			Image:    item.SnapshotImage,
+			Quantity: item.Quantity,
+			Price:    item.Price,
+		}
+		for _, option := range item.Options {
+			summary.Items[i].Options = append(summary.Items[i].Options, model.OrderSummaryOption{
+				Code:  option.Code,
+				Name:  option.Name,
+				Value: option.Value,
+				Price: option.Price,
+			})
+		}
+		summary.ItemCount += item.Quantity
+	}
+	return summary
+}
+
+func newOrderSummaryResp(summary *model.OrderSummary) OrderSummaryResp {
+	resp := OrderSummaryResp{
+		OrderID:     summary.OrderID,
+		OrderNumber: summary.OrderNumber,
+		Status:      summary.Status,
+		TotalAmount: money.New(summary.TotalAmount, summary.Currency),
+		ItemCount:   summary.ItemCount,
+		Items:       make([]OrderSummaryItemResp, len(summary.Items)),
+		OrderedAt:   summary.OrderedAt,
+	}
+	for i, item := range summary.Items {
+		resp.Items[i] = OrderSummaryItemResp{
+			SKUID:    item.SKUID,
+			Name:     item.Name,
+			Image:    item.Image,
+			Quantity: item.Quantity,
+			Price:    money.New(item.Price, summary.Currency),
+		}
+		for _, option := range item.Options {
+			resp.Items[i].Options = append(resp.Items[i].Options, OrderItemOptionResp{
+				Code:  option.Code,
+				Name:  option.Name,
+				Value: option.Value,
+				Price: money.New(option.Price, summary.Currency),
+			})
+		}
+	}
+	return resp
+}
@@ -0,0 +1,138 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOrderHistoryService_Project(t *testing.T) {
+	placed := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	bundleItemID := uint64(2)
+	order := &model.Order{
+		Base:        model.Base{ID: 42, CreatedAt: placed, UpdatedAt: placed.Add(time.Minute)},
+		StoreID:     2,
+		UserID:      7,
+		OrderNumber: "ORD-42",
+		Status:      model.OrderStatusPaid,
+		TotalAmount: decimal.NewFromInt(35),
+		Currency:    "EUR",
+		Items: []model.OrderItem{
+			{SKUID: 101, SnapshotName: "Mug", SnapshotImage: "mug.jpg", Price: decimal.NewFromInt(10), Quantity: 2},
+			{Base: model.Base{ID: 2}, SKUID: 102, SnapshotName: "Tea", SnapshotImage: "tea.jpg", Price: decimal.NewFromInt(15), Quantity: 1, Delivery: model.SKUDeliveryBundle},
+			{SKUID: 11, Price: decimal.Zero, Quantity: 3, BundleItemID: &bundleItemID}, // Not shown to the customer
+		},
+	}
+
+	tests := []struct {
+		name      string
+		mockSetup func(summaryRepo *mocks.MockOrderSummaryRepository, orderRepo *mocks.MockOrderRepository)
+		errStr    string
+	}{
+		{
+			name: "BuildsSummaryFromOrder",
+			mockSetup: func(summaryRepo *mocks.MockOrderSummaryRepository, orderRepo *mocks.MockOrderRepository) {
+				orderRepo.EXPECT().GetByID(gomock.Any(), uint64(42)).Return(order, nil)
+				summaryRepo.EXPECT().Save(gomock.Any(), &model.OrderSummary{
+					OrderID:     42,
+					StoreID:     2,
+					UserID:      7,
+					OrderNumber: "ORD-42",
+					Status:      model.OrderStatusPaid,
+					TotalAmount: decimal.NewFromInt(35),
+					Currency:    "EUR",
+					ItemCount:   3,
+					Items: []model.OrderSummaryItem{
+						{SKUID: 101, Name: "Mug", Image: "mug.jpg", Quantity: 2, Price: decimal.NewFromInt(10)},
+						{SKUID: 102, Name: "Tea", Image: "tea.jpg", Quantity: 1, Price: decimal.NewFromInt(15)},
+					},
+					OrderedAt:      placed,
+					OrderUpdatedAt: placed.Add(time.Minute),
+				}).Return(nil)
+			},
+		},
+		{
+			name: "OrderGone",
+			mockSetup: func(_ *mocks.MockOrderSummaryRepository, orderRepo *mocks.MockOrderRepository) {
+				orderRepo.EXPECT().GetByID(gomock.Any(), uint64(42)).Return(nil, repository.ErrOrderNotFound)
+			},
+		},
+		{
+			name: "LoadFails",
+			mockSetup: func(_ *mocks.MockOrderSummaryRepository, orderRepo *mocks.MockOrderRepository) {
+				orderRepo.EXPECT().GetByID(gomock.Any(), uint64(42)).Return(nil, errors.New("db down"))
+			},
+			errStr: "db down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			summaryRepo := mocks.NewMockOrderSummaryRepository(ctrl)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			tt.mockSetup(summaryRepo, orderRepo)
+
+			err := service.NewOrderHistoryService(summaryRepo, orderRepo).Project(context.Background(), 42)
+			if tt.errStr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errStr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestOrderHistoryService_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	summaryRepo := mocks.NewMockOrderSummaryRepository(ctrl)
+	svc := service.NewOrderHistoryService(summaryRepo, nil)
+
+	// The page size is capped
+	summaryRepo.EXPECT().ListByUser(gomock.Any(), uint64(7), 0, service.MaxOrderHistoryPageSize).Return([]model.OrderSummary{{
+		OrderID:     42,
+		Status:      model.OrderStatusPaid,
+		TotalAmount: decimal.NewFromInt(35),
+		Currency:    "EUR",
+		ItemCount:   2,
+		Items:       []model.OrderSummaryItem{{SKUID: 101, Name: "Mug", Quantity: 2, Price: decimal.NewFromInt(10)}},
+	}}, int64(1), nil)
+	resp, err := svc.List(context.Background(), 7, -1, 1000)
+	require.NoError(t, err)
+	require.Len(t, resp.Orders, 1)
+	assert.Equal(t, int64(1), resp.Total)
+	assert.Equal(t, "35.00 EUR", resp.Orders[0].TotalAmount.String())
+	assert.Equal(t, "10.00 EUR", resp.Orders[0].Items[0].Price.String())
+
+	summaryRepo.EXPECT().ListByUser(gomock.Any(), uint64(7), 20, service.DefaultOrderHistoryPageSize).Return(nil, int64(1), nil)
+	resp, err = svc.List(context.Background(), 7, 20, 0)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Orders)
+}
+
+func TestOrderHistoryService_Backfill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	summaryRepo := mocks.NewMockOrderSummaryRepository(ctrl)
+	orderRepo := mocks.NewMockOrderRepository(ctrl)
+
+	summaryRepo.EXPECT().ListMissing(gomock.Any(), service.DefaultOrderSummaryBackfillBatchSize).Return([]uint64{1, 2, 3}, nil)
+	orderRepo.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(&model.Order{Base: model.Base{ID: 1}}, nil)
+	orderRepo.EXPECT().GetByID(gomock.Any(), uint64(2)).Return(&model.Order{Base: model.Base{ID: 2}}, nil)
+	summaryRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+	summaryRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(errors.New("db down"))
+
+	backfilled, err := service.NewOrderHistoryService(summaryRepo, orderRepo).Backfill(context.Background(), 0)
+	assert.EqualError(t, err, "failed to project order '2': db down")
+	assert.Equal(t, 1, backfilled)
+}
@@ -0,0 +1,496 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/database"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrOrderNotModifiable means the customer can no longer change the
+	// order, e.g. it is being picked, or the difference in its total cannot
+	// be settled with how it was paid.
+	ErrOrderNotModifiable = errors.New("order can no longer be modified")
+	// ErrInvalidOrderModification means a modification is malformed, e.g.
+	// changes nothing.
+	ErrInvalidOrderModification = errors.New("invalid order modification")
+	// ErrOrderItemNotFound means the order has no such item.
+	ErrOrderItemNotFound = errors.New("order item not found")
+)
+
+// OrderModificationResp is a change the customer made to their order.
+type OrderModificationResp struct {
+	ID          uint64      `json:"id,string"`
+	OrderID     uint64      `json:"order_id,string"`
+	Kind        string      `json:"kind" example:"quantity"` // quantity or shipping_address
+	OrderItemID uint64      `json:"order_item_id,string,omitempty"`
+	Before      model.JSONB `json:"before"`
+	After       model.JSONB `json:"after"`
+	AmountDelta money.Money `json:"amount_delta"`                // Added to the order total; negative when it went down
+	Adjustment  string      `json:"adjustment" example:"refund"` // charge or refund; empty when there was nothing to settle
+	CreatedAt   time.Time   `json:"created_at"`
+	// AddressWarnings are what the customer should check about a shipping
+	// address just changed to; see AddressService.
+	AddressWarnings []AddressWarning `json:"address_warnings,omitempty"`
+}
+
+// OrderModificationService lets customers change their orders until they
+// ship: the quantity of an item, repricing the order, or where it ships to.
+// A paid order is settled right away: the difference is charged to the saved
+// payment method it was paid with, or refunded. Every change is kept in the
+// order's modification history.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/order_modification_service_mock.go -package=mocks
+type OrderModificationService interface {
+	// ChangeQuantity sets the quantity of an item of the user's pending,
+	// authorized or paid order. The item's discount and the order's tax
+	// scale with it, while its price stays what it was when the order was
+	// placed. Bundles, digital and backordered items cannot change.
+	ChangeQuantity(ctx context.Context, userID, orderID, itemID uint64, quantity int) (*OrderModificationResp, error)
+	// ChangeShippingAddress replaces where the user's order ships to, within
+	// its region, normalized; see AddressService. Its warnings are returned
+	// with the modification.
+	ChangeShippingAddress(ctx context.Context, userID, orderID uint64, address model.Address) (*OrderModificationResp, error)
+	// List returns the modifications of the user's order, oldest first.
+	List(ctx context.Context, userID, orderID uint64) ([]OrderModificationResp, error)
+}
+
+type orderModificationService struct {
+	orderRepo        repository.OrderRepository
+	shipmentRepo     repository.ShipmentRepository
+	taskRepo         repository.PickTaskRepository
+	productRepo      repository.ProductRepository
+	modificationRepo repository.OrderModificationRepository
+	sagaRepo         repository.SagaRepository
+	txManager        database.TransactionManager
+	events           EventPublisher
+	payments         PaymentMethodService // nil without a payment provider: paid orders cannot change their total
+	purchases        *PurchaseLimiter     // nil without a cache: limits are not enforced
+	catalog          *CatalogCache
+	addresses        AddressService // nil accepts shipping addresses as given
+}
+
+// NewOrderModificationService creates a new OrderModificationService. Paid
+// orders are settled through payments, which may be nil. Stock changes are
+// announced through catalog. Shipping addresses are validated and normalized
+// with addresses, which may be nil.
+func NewOrderModificationService(orderRepo repository.OrderRepository, shipmentRepo repository.ShipmentRepository, taskRepo repository.PickTaskRepository,
+	productRepo repository.ProductRepository, modificationRepo repository.OrderModificationRepository, sagaRepo repository.SagaRepository,
+	txManager database.TransactionManager, events EventPublisher, payments PaymentMethodService, purchases *PurchaseLimiter, catalog *CatalogCache, addresses AddressService) OrderModificationService {
+	return &orderModificationService{
+		orderRepo:        orderRepo,
+		shipmentRepo:     shipmentRepo,
+		taskRepo:         taskRepo,
+		productRepo:      productRepo,
+		modificationRepo: modificationRepo,
+		sagaRepo:         sagaRepo,
+		txManager:        txManager,
+		events:           events,
+		payments:         payments,
+		purchases:        purchases,
+		catalog:          catalog,
+		addresses:        addresses,
+	}
+}
+
+// ChangeQuantity holds the order's row lock, as Ship does, so that the order
+// cannot ship while it changes. The difference is charged or refunded in the
+// transaction, which rolls the change back if that fails.
+func (s *orderModificationService) ChangeQuantity(ctx context.Context, userID, orderID, itemID uint64, quantity int) (*OrderModificationResp, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be positive", ErrInvalidOrderModification)
+	}
+
+	var modification *model.OrderModification
+	var item model.OrderItem
+	var before int
+	var storeID uint64
+	var reserved []PurchaseLimit
+	err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		order, err := s.lockOrder(txCtx, userID, orderID, model.OrderStatusPending, model.OrderStatusAuthorized, model.OrderStatusPaid)
+		if err != nil {
+			return err
+		}
+		if err := s.checkPaymentSettled(txCtx, order); err != nil {
+			return err
+		}
+		i := slices.IndexFunc(order.Items, func(item model.OrderItem) bool { return item.ID == itemID && item.BundleItemID == nil })
+		if i < 0 {
+			return ErrOrderItemNotFound
+		}
+		switch line := order.Items[i]; {
+		case line.Delivery == model.SKUDeliveryBundle:
+			return fmt.Errorf("%w: bundles cannot change quantity", ErrOrderNotModifiable)
+		case line.Delivery != "":
+			return fmt.Errorf("%w: digital items cannot change quantity", ErrOrderNotModifiable)
+		case line.Backordered:
+			return fmt.Errorf("%w: backordered items cannot change quantity", ErrOrderNotModifiable)
+		case line.Quantity == quantity:
+			return fmt.Errorf("%w: quantity is unchanged", ErrInvalidOrderModification)
+		}
+		onTask, err := s.checkPicking(txCtx, orderID, itemID)
+		if err != nil {
+			return err
+		}
+
+		// Take the extra units out of stock and count them against the
+		// customer's limit, or put the units given up back
+		before, storeID = order.Items[i].Quantity, order.StoreID
+		added := quantity - before
+		skus, err := s.productRepo.GetSKUsForUpdate(txCtx, []uint64{order.Items[i].SKUID})
+		if err != nil {
+			return err
+		}
+		if len(skus) == 0 {
+			return ErrProductNotFound
+		}
+		if added > skus[0].Stock {
+			return ErrInsufficientStock.WithSKU(skus[0].ID).WithMessage(fmt.Sprintf("only %d more in stock", skus[0].Stock))
+		}
+		if added > 0 && s.purchases != nil && skus[0].PurchaseLimit > 0 {
+			limits := []PurchaseLimit{{SKUID: skus[0].ID, Limit: skus[0].PurchaseLimit, Quantity: added}}
+			if err := s.purchases.Reserve(ctx, storeID, userID, limits); err != nil {
+				return err
+			}
+			reserved = limits
+		}
+		if err := s.productRepo.UpdateSKUStock(txCtx, skus[0].ID, -added); err != nil {
+			return err
+		}
+
+		total := order.TotalAmount
+		if err := repriceItem(order, i, quantity); err != nil {
+			return err
+		}
+		item = order.Items[i]
+		if err := s.orderRepo.UpdateItemQuantity(txCtx, order, &item); err != nil {
+			return err
+		}
+		if onTask {
+			err := s.taskRepo.SetItemQuantity(txCtx, itemID, quantity)
+			if errors.Is(err, repository.ErrPickTaskChanged) {
+				return fmt.Errorf("%w: it is being picked", ErrOrderNotModifiable)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		modification = &model.OrderModification{
+			OrderID:     orderID,
+			UserID:      userID,
+			Kind:        model.OrderModificationQuantity,
+			OrderItemID: itemID,
+			Before:      model.JSONB{"quantity": before},
+			After:       model.JSONB{"quantity": quantity},
+			AmountDelta: order.TotalAmount.Sub(total),
+			Currency:    order.Currency,
+		}
+		if err := s.settle(txCtx, order, modification); err != nil {
+			return err
+		}
+		return s.record(txCtx, order, modification)
+	})
+	if err != nil {
+		if reserved != nil {
+			if releaseErr := s.purchases.Release(context.WithoutCancel(ctx), storeID, userID, reserved); releaseErr != nil {
+				slog.ErrorContext(ctx, "Failed to release purchases of unmodified order", "order_id", orderID, logger.Err(releaseErr))
+			}
+		}
+		if modification != nil && modification.Adjustment != model.PaymentAdjustmentNone {
+			// Settled but not recorded: the reference is needed to reconcile
+			slog.ErrorContext(ctx, "Failed to record settled order modification", "order_id", orderID, "adjustment", modification.Adjustment,
+				"payment_ref", modification.PaymentRef, "amount", modification.AmountDelta.String(), logger.Err(err))
+		}
+		return nil, err
+	}
+
+	if given := before - quantity; given > 0 && s.purchases != nil {
+		// Releasing a SKU that is not limited leaves no counter behind
+		if err := s.purchases.Release(ctx, storeID, userID, []PurchaseLimit{{SKUID: item.SKUID, Quantity: given}}); err != nil {
+			slog.ErrorContext(ctx, "Failed to release purchases given up by order modification", "order_id", orderID, logger.Err(err))
+		}
+	}
+	publishStockChanged(ctx, s.catalog, []model.OrderItem{item})
+	resp := newOrderModificationResp(modification)
+	return &resp, nil
+}
+
+func (s *orderModificationService) ChangeShippingAddress(ctx context.Context, userID, orderID uint64, address model.Address) (*OrderModificationResp, error) {
+	var modification *model.OrderModification
+	var warnings []AddressWarning
+	err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		order, err := s.lockOrder(txCtx, userID, orderID, model.OrderStatusPending, model.OrderStatusAuthorized, model.OrderStatusPaid, model.OrderStatusBackordered)
+		if err != nil {
+			return err
+		}
+		if s.addresses != nil {
+			validated, err := s.addresses.Validate(txCtx, order.Region, address)
+			if err != nil {
+				return err
+			}
+			address, warnings = validated.Address, validated.Warnings
+		}
+		if order.ShippingAddress == address {
+			return fmt.Errorf("%w: shipping address is unchanged", ErrInvalidOrderModification)
+		}
+		before, err := toJSONB(order.ShippingAddress)
+		if err != nil {
+			return err
+		}
+		after, err := toJSONB(address)
+		if err != nil {
+			return err
+		}
+		if err := s.orderRepo.UpdateShippingAddress(txCtx, orderID, address); err != nil {
+			return err
+		}
+		order.ShippingAddress = address
+		modification = &model.OrderModification{
+			OrderID:  orderID,
+			UserID:   userID,
+			Kind:     model.OrderModificationShippingAddress,
+			Before:   before,
+			After:    after,
+			Currency: order.Currency,
+		}
+		return s.record(txCtx, order, modification)
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := newOrderModificationResp(modification)
+	resp.AddressWarnings = warnings
+	return &resp, nil
+}
+
+func (s *orderModificationService) List(ctx context.Context, userID, orderID uint64) ([]OrderModificationResp, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, repository.ErrOrderNotFound) || (err == nil && order.UserID != userID) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	modifications, err := s.modificationRepo.ListByOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	resps := make([]OrderModificationResp, len(modifications))
+	for i := range modifications {
+		resps[i] = newOrderModificationResp(&modifications[i])
+	}
+	return resps, nil
+}
+
+// lockOrder locks an order of the user in one of statuses that has not
+// shipped, in part or in full, until the transaction in txCtx ends. Other
+// users' orders are not found.
+func (s *orderModificationService) lockOrder(txCtx context.Context, userID, orderID uint64, statuses ...string) (*model.Order, error) {
+	order, err := s.orderRepo.GetByIDForUpdate(txCtx, orderID)
+	if errors.Is(err, repository.ErrOrderNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, ErrOrderNotFound
+	}
+	if !slices.Contains(statuses, order.Status) {
+		return nil, fmt.Errorf("%w: it is %s", ErrOrderNotModifiable, order.Status)
+	}
+	shipments, err := s.shipmentRepo.ListByOrder(txCtx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(shipments) > 0 {
+		return nil, ErrOrderShipped
+	}
+	return order, nil
+}
+
+// checkPaymentSettled fails while a payment of a pending order is under way:
+// it is for the total as it was.
+func (s *orderModificationService) checkPaymentSettled(txCtx context.Context, order *model.Order) error {
+	if order.Status != model.OrderStatusPending {
+		return nil
+	}
+	if order.PaymentIntent.ID != "" {
+		return fmt.Errorf("%w: its payment has started", ErrOrderNotModifiable)
+	}
+	saga, err := s.sagaRepo.GetByOrderID(txCtx, order.ID)
+	if errors.Is(err, repository.ErrSagaNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if saga.Status == model.SagaStatusRunning || saga.Status == model.SagaStatusCompensating {
+		return fmt.Errorf("%w: its payment has started", ErrOrderNotModifiable)
+	}
+	return nil
+}
+
+// checkPicking fails once picking of the order started. It reports whether
+// the item is on a pick task, which then picks the new quantity.
+func (s *orderModificationService) checkPicking(txCtx context.Context, orderID, itemID uint64) (bool, error) {
+	tasks, err := s.taskRepo.ListByOrder(txCtx, orderID)
+	if err != nil {
+		return false, err
+	}
+	onTask := false
+	for _, task := range tasks {
+		if task.Status != model.PickTaskStatusOpen {
+			return false, fmt.Errorf("%w: it is being picked", ErrOrderNotModifiable)
+		}
+		if slices.ContainsFunc(task.Items, func(item model.PickTaskItem) bool { return item.OrderItemID == itemID }) {
+			onTask = true
+		}
+	}
+	return onTask, nil
+}
+
+// settle charges or refunds the difference modification made to the total of
+// a paid order. The order's payment must have been made with a saved payment
+// method, whose provider can take the difference without the customer. An
+// authorized order captures its lower total as it ships; it cannot grow past
+// the authorization. A pending order has nothing to settle.
+func (s *orderModificationService) settle(txCtx context.Context, order *model.Order, modification *model.OrderModification) error {
+	delta := modification.AmountDelta
+	switch {
+	case delta.IsZero(), order.Status == model.OrderStatusPending:
+		return nil
+	case order.Status == model.OrderStatusAuthorized:
+		if delta.IsPositive() {
+			return fmt.Errorf("%w: its payment is authorized for a lower total", ErrOrderNotModifiable)
+		}
+		return nil
+	}
+
+	saga, err := s.paidSaga(txCtx, order)
+	if err != nil {
+		return err
+	}
+	// Keyed by the number of the modification, so that a retry never
+	// settles it twice
+	history, err := s.modificationRepo.ListByOrder(txCtx, order.ID)
+	if err != nil {
+		return err
+	}
+	reference := fmt.Sprintf("%s-m%d", order.OrderNumber, len(history)+1)
+
+	if delta.IsNegative() {
+		if err := s.payments.RefundPart(txCtx, order, order.PaymentRef, money.New(delta.Neg(), order.Currency), reference); err != nil {
+			return err
+		}
+		modification.Adjustment, modification.PaymentRef = model.PaymentAdjustmentRefund, reference
+		return nil
+	}
+	method, err := s.payments.Get(txCtx, order.UserID, saga.PaymentMethodID)
+	if errors.Is(err, ErrPaymentMethodNotFound) {
+		return fmt.Errorf("%w: its payment method was removed", ErrOrderNotModifiable)
+	}
+	if err != nil {
+		return err
+	}
+	charge, err := s.payments.ChargeExtra(txCtx, method, order, money.New(delta, order.Currency), reference)
+	if err != nil {
+		return err
+	}
+	modification.Adjustment, modification.PaymentRef = model.PaymentAdjustmentCharge, charge.ID
+	return nil
+}
+
+// paidSaga returns the checkout saga that paid order with a saved payment
+// method.
+func (s *orderModificationService) paidSaga(txCtx context.Context, order *model.Order) (*model.SagaState, error) {
+	if s.payments == nil {
+		return nil, fmt.Errorf("%w: its total cannot change once paid", ErrOrderNotModifiable)
+	}
+	saga, err := s.sagaRepo.GetByOrderID(txCtx, order.ID)
+	if errors.Is(err, repository.ErrSagaNotFound) || (err == nil && saga.PaymentRef != order.PaymentRef) {
+		return nil, fmt.Errorf("%w: it was not paid with a saved payment method", ErrOrderNotModifiable)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return saga, nil
+}
+
+// record saves modification to the order's history and publishes the
+// modified order.
+func (s *orderModificationService) record(txCtx context.Context, order *model.Order, modification *model.OrderModification) error {
+	if err := s.modificationRepo.Create(txCtx, modification); err != nil {
+		return err
+	}
+	if err := s.events.Publish(txCtx, EventOrderUpdated, newOrderWebhookData(order, order.Items)); err != nil {
+		return fmt.Errorf("failed to publish order event: %w", err)
+	}
+	return nil
+}
+
+// repriceItem sets the quantity of the order's i-th item, scaling its
+// discount, and the order's tax lines with the amount they are charged on,
+// and totals the order again the way it was quoted.
+func repriceItem(order *model.Order, i, quantity int) error {
+	taxedBefore, err := taxedAmount(order)
+	if err != nil {
+		return err
+	}
+	item := &order.Items[i]
+	item.Discount = item.Discount.Mul(decimal.NewFromInt(int64(quantity))).Div(decimal.NewFromInt(int64(item.Quantity))).Round(money.Scale)
+	item.Quantity = quantity
+	taxed, err := taxedAmount(order)
+	if err != nil {
+		return err
+	}
+	if taxedBefore.IsPositive() {
+		for j := range order.TaxLines {
+			order.TaxLines[j].Amount = order.TaxLines[j].Amount.Mul(taxed).Div(taxedBefore).Round(money.Scale)
+		}
+	}
+
+	quote, err := newOrderQuote(order.Currency, order.Region, order.Items, order.TaxLines, order.Promotions)
+	if err != nil {
+		return err
+	}
+	order.TotalAmount = quote.total.Amount()
+	order.DiscountAmount = quote.discount.Amount()
+	order.TaxAmount = quote.taxAmount.Amount()
+	return nil
+}
+
+// taxedAmount returns what the order's tax is charged on: its items less
+// their discounts.
+func taxedAmount(order *model.Order) (decimal.Decimal, error) {
+	quote, err := newOrderQuote(order.Currency, order.Region, order.Items, nil, nil)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return quote.total.Amount(), nil
+}
+
+func newOrderModificationResp(modification *model.OrderModification) OrderModificationResp {
+	return OrderModificationResp{
+		ID:          modification.ID,
+		OrderID:     modification.OrderID,
+		Kind:        modification.Kind,
+		OrderItemID: modification.OrderItemID,
+		Before:      modification.Before,
+		After:       modification.After,
+		AmountDelta: money.New(modification.AmountDelta, modification.Currency),
+		Adjustment:  modification.Adjustment,
+		CreatedAt:   modification.CreatedAt,
+	}
+}
@@ -0,0 +1,246 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/payment"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type orderModificationMocks struct {
+	orderRepo        *mocks.MockOrderRepository
+	shipmentRepo     *mocks.MockShipmentRepository
+	taskRepo         *mocks.MockPickTaskRepository
+	productRepo      *mocks.MockProductRepository
+	modificationRepo *mocks.MockOrderModificationRepository
+	sagaRepo         *mocks.MockSagaRepository
+	events           *mocks.MockEventPublisher
+	payments         *mocks.MockPaymentMethodService
+	cache            *mocks.MockCache
+}
+
+func newOrderModificationService(t *testing.T) (service.OrderModificationService, orderModificationMocks) {
+	ctrl := gomock.NewController(t)
+	m := orderModificationMocks{
+		orderRepo:        mocks.NewMockOrderRepository(ctrl),
+		shipmentRepo:     mocks.NewMockShipmentRepository(ctrl),
+		taskRepo:         mocks.NewMockPickTaskRepository(ctrl),
+		productRepo:      mocks.NewMockProductRepository(ctrl),
+		modificationRepo: mocks.NewMockOrderModificationRepository(ctrl),
+		sagaRepo:         mocks.NewMockSagaRepository(ctrl),
+		events:           mocks.NewMockEventPublisher(ctrl),
+		payments:         mocks.NewMockPaymentMethodService(ctrl),
+		cache:            mocks.NewMockCache(ctrl),
+	}
+	txManager := mocks.NewMockTransactionManager(ctrl)
+	txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	}).AnyTimes()
+	svc := service.NewOrderModificationService(m.orderRepo, m.shipmentRepo, m.taskRepo, m.productRepo, m.modificationRepo, m.sagaRepo,
+		txManager, m.events, m.payments, nil, service.NewCatalogCache(m.cache, service.CatalogCacheOptions{}), service.NewAddressService(nil))
+	return svc, m
+}
+
+// modifiableOrder is an order of user 3 for 25.30 USD: two units of SKU 5 at
+// 10.00 less 2.00, one of SKU 6 at 5.00, and 10% tax.
+func modifiableOrder(status string) *model.Order {
+	return &model.Order{
+		Base:           model.Base{ID: 7},
+		UserID:         3,
+		OrderNumber:    "ORD7",
+		Status:         status,
+		Currency:       "USD",
+		TotalAmount:    decimal.RequireFromString("25.30"),
+		DiscountAmount: decimal.RequireFromString("2.00"),
+		TaxAmount:      decimal.RequireFromString("2.30"),
+		PaymentRef:     "pi_1",
+		Items: []model.OrderItem{
+			{Base: model.Base{ID: 11}, SKUID: 5, Price: decimal.RequireFromString("10.00"), Quantity: 2, Discount: decimal.RequireFromString("2.00")},
+			{Base: model.Base{ID: 12}, SKUID: 6, Price: decimal.RequireFromString("5.00"), Quantity: 1},
+		},
+		TaxLines: []model.OrderTaxLine{{Base: model.Base{ID: 21}, Name: "VAT", Rate: decimal.RequireFromString("0.1"), Amount: decimal.RequireFromString("2.30")}},
+	}
+}
+
+func TestOrderModificationService_ChangeQuantity(t *testing.T) {
+	paidSaga := &model.SagaState{OrderID: 7, PaymentMethodID: 9, PaymentRef: "pi_1", Status: model.SagaStatusCompleted}
+	method := &model.PaymentMethod{Base: model.Base{ID: 9}, Provider: "stripe"}
+	difference := money.New(decimal.RequireFromString("9.90"), "USD")
+
+	tests := []struct {
+		name           string
+		status         string
+		userID         uint64
+		quantity       int
+		stock          int
+		shipments      []model.Shipment
+		tasks          []model.PickTask
+		saga           *model.SagaState
+		setup          func(m orderModificationMocks)
+		wantTotal      string
+		wantAdjustment string
+		wantErrIs      error
+	}{
+		{
+			name: "PaidFewerRefunds", status: model.OrderStatusPaid, quantity: 1, saga: paidSaga,
+			setup: func(m orderModificationMocks) {
+				m.modificationRepo.EXPECT().ListByOrder(gomock.Any(), uint64(7)).Return([]model.OrderModification{{}}, nil)
+				m.payments.EXPECT().RefundPart(gomock.Any(), gomock.Any(), "pi_1", difference, "ORD7-m2").Return(nil)
+			},
+			wantTotal: "15.40", wantAdjustment: model.PaymentAdjustmentRefund,
+		},
+		{
+			name: "PaidMoreCharges", status: model.OrderStatusPaid, quantity: 3, saga: paidSaga,
+			tasks: []model.PickTask{{Status: model.PickTaskStatusOpen, Items: []model.PickTaskItem{{OrderItemID: 11, Quantity: 2}}}},
+			setup: func(m orderModificationMocks) {
+				m.taskRepo.EXPECT().SetItemQuantity(gomock.Any(), uint64(11), 3).Return(nil)
+				m.modificationRepo.EXPECT().ListByOrder(gomock.Any(), uint64(7)).Return(nil, nil)
+				m.payments.EXPECT().Get(gomock.Any(), uint64(3), uint64(9)).Return(method, nil)
+				m.payments.EXPECT().ChargeExtra(gomock.Any(), method, gomock.Any(), difference, "ORD7-m1").Return(&payment.Charge{ID: "pi_2"}, nil)
+			},
+			wantTotal: "35.20", wantAdjustment: model.PaymentAdjustmentCharge,
+		},
+		{name: "PendingNothingToSettle", status: model.OrderStatusPending, quantity: 3, wantTotal: "35.20"},
+		{name: "AuthorizedFewer", status: model.OrderStatusAuthorized, quantity: 1, wantTotal: "15.40"},
+		{name: "AuthorizedMore", status: model.OrderStatusAuthorized, quantity: 3, wantErrIs: service.ErrOrderNotModifiable},
+		{name: "PaidWithoutSavedMethod", status: model.OrderStatusPaid, quantity: 1, wantErrIs: service.ErrOrderNotModifiable},
+		{
+			name: "PendingPaymentRunning", status: model.OrderStatusPending, quantity: 3,
+			saga:      &model.SagaState{OrderID: 7, Status: model.SagaStatusRunning},
+			wantErrIs: service.ErrOrderNotModifiable,
+		},
+		{
+			name: "BeingPicked", status: model.OrderStatusPaid, quantity: 1,
+			tasks:     []model.PickTask{{Status: model.PickTaskStatusClaimed}},
+			wantErrIs: service.ErrOrderNotModifiable,
+		},
+		{
+			name: "Shipped", status: model.OrderStatusPaid, quantity: 1,
+			shipments: []model.Shipment{{OrderID: 7}},
+			wantErrIs: service.ErrOrderShipped,
+		},
+		{name: "Completed", status: model.OrderStatusCompleted, quantity: 1, wantErrIs: service.ErrOrderNotModifiable},
+		{name: "OtherUsersOrder", status: model.OrderStatusPaid, userID: 4, quantity: 1, wantErrIs: service.ErrOrderNotFound},
+		{name: "Unchanged", status: model.OrderStatusPaid, quantity: 2, wantErrIs: service.ErrInvalidOrderModification},
+		{name: "InsufficientStock", status: model.OrderStatusPending, quantity: 3, stock: -1, wantErrIs: service.ErrInsufficientStock},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, m := newOrderModificationService(t)
+			userID := tt.userID
+			if userID == 0 {
+				userID = 3
+			}
+			stock := tt.stock
+			if stock == 0 {
+				stock = 10
+			}
+			m.orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(7)).Return(modifiableOrder(tt.status), nil)
+			m.shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(7)).Return(tt.shipments, nil).AnyTimes()
+			m.taskRepo.EXPECT().ListByOrder(gomock.Any(), uint64(7)).Return(tt.tasks, nil).AnyTimes()
+			if tt.saga != nil {
+				m.sagaRepo.EXPECT().GetByOrderID(gomock.Any(), uint64(7)).Return(tt.saga, nil).AnyTimes()
+			} else {
+				m.sagaRepo.EXPECT().GetByOrderID(gomock.Any(), uint64(7)).Return(nil, repository.ErrSagaNotFound).AnyTimes()
+			}
+			m.productRepo.EXPECT().GetSKUsForUpdate(gomock.Any(), []uint64{5}).Return([]model.SKU{{Base: model.Base{ID: 5}, Stock: max(stock, 0)}}, nil).AnyTimes()
+			m.productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(5), 2-tt.quantity).Return(nil).AnyTimes()
+			m.orderRepo.EXPECT().UpdateItemQuantity(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, order *model.Order, item *model.OrderItem) error {
+				assert.Equal(t, tt.quantity, item.Quantity)
+				assert.Equal(t, tt.quantity, order.Items[0].Quantity)
+				return nil
+			}).AnyTimes()
+			if tt.setup != nil {
+				tt.setup(m)
+			}
+			if tt.wantErrIs == nil {
+				m.modificationRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				m.events.EXPECT().Publish(gomock.Any(), service.EventOrderUpdated, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, data any) error {
+					assert.Equal(t, tt.wantTotal, data.(service.OrderWebhookData).TotalAmount.Amount().StringFixed(2))
+					return nil
+				})
+				m.cache.EXPECT().Publish(gomock.Any(), service.CatalogEventsChannel, gomock.Any()).Return(nil)
+			}
+
+			resp, err := svc.ChangeQuantity(context.Background(), userID, 7, 11, tt.quantity)
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, model.OrderModificationQuantity, resp.Kind)
+			assert.Equal(t, uint64(11), resp.OrderItemID)
+			assert.Equal(t, tt.quantity, resp.After["quantity"])
+			assert.Equal(t, tt.wantAdjustment, resp.Adjustment)
+			delta := decimal.RequireFromString(tt.wantTotal).Sub(decimal.RequireFromString("25.30"))
+			assert.True(t, delta.Equal(resp.AmountDelta.Amount()), "delta %s", resp.AmountDelta)
+		})
+	}
+}
+
+func TestOrderModificationService_ChangeShippingAddress(t *testing.T) {
+	address := model.Address{Name: "Erika Mustermann", Line1: "Heidestraße 17", City: "Berlin", PostalCode: "10557"}
+
+	t.Run("Success", func(t *testing.T) {
+		svc, m := newOrderModificationService(t)
+		m.orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(7)).Return(modifiableOrder(model.OrderStatusBackordered), nil)
+		m.shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(7)).Return(nil, nil)
+		m.orderRepo.EXPECT().UpdateShippingAddress(gomock.Any(), uint64(7), address).Return(nil)
+		m.modificationRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, modification *model.OrderModification) error {
+			assert.Equal(t, uint64(3), modification.UserID)
+			assert.Equal(t, "Berlin", modification.After["city"])
+			assert.Equal(t, "", modification.Before["city"])
+			return nil
+		})
+		m.events.EXPECT().Publish(gomock.Any(), service.EventOrderUpdated, gomock.Any()).Return(nil)
+
+		resp, err := svc.ChangeShippingAddress(context.Background(), 3, 7, address)
+		require.NoError(t, err)
+		assert.Equal(t, model.OrderModificationShippingAddress, resp.Kind)
+		assert.Equal(t, model.PaymentAdjustmentNone, resp.Adjustment)
+	})
+
+	t.Run("Shipped", func(t *testing.T) {
+		svc, m := newOrderModificationService(t)
+		m.orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(7)).Return(modifiableOrder(model.OrderStatusPaid), nil)
+		m.shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(7)).Return([]model.Shipment{{OrderID: 7}}, nil)
+
+		_, err := svc.ChangeShippingAddress(context.Background(), 3, 7, address)
+		assert.ErrorIs(t, err, service.ErrOrderShipped)
+	})
+
+	t.Run("InvalidAddress", func(t *testing.T) {
+		svc, m := newOrderModificationService(t)
+		m.orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(7)).Return(modifiableOrder(model.OrderStatusPaid), nil)
+		m.shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(7)).Return(nil, nil)
+
+		_, err := svc.ChangeShippingAddress(context.Background(), 3, 7, model.Address{Name: "Erika Mustermann", Line1: "Heidestraße 17"})
+		assert.ErrorIs(t, err, service.ErrInvalidAddress)
+	})
+}
+
+func TestOrderModificationService_List(t *testing.T) {
+	svc, m := newOrderModificationService(t)
+	m.orderRepo.EXPECT().GetByID(gomock.Any(), uint64(7)).Return(modifiableOrder(model.OrderStatusPaid), nil).Times(2)
+	m.modificationRepo.EXPECT().ListByOrder(gomock.Any(), uint64(7)).Return([]model.OrderModification{
+		{Base: model.Base{ID: 1}, OrderID: 7, Kind: model.OrderModificationQuantity, AmountDelta: decimal.RequireFromString("-9.90"), Currency: "USD"},
+	}, nil)
+
+	resps, err := svc.List(context.Background(), 3, 7)
+	require.NoError(t, err)
+	require.Len(t, resps, 1)
+	assert.Equal(t, "-9.90 USD", resps[0].AmountDelta.String())
+
+	_, err = svc.List(context.Background(), 4, 7)
+	assert.ErrorIs(t, err, service.ErrOrderNotFound)
+}
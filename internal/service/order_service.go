@@ -4,114 +4,500 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/proyuen/go-mall/internal/model"
 	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/proyuen/go-mall/pkg/cache"
+	"github.com/proyuen/go-mall/pkg/channel"
 	"github.com/proyuen/go-mall/pkg/database"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/proyuen/go-mall/pkg/tenant"
 	"github.com/proyuen/go-mall/pkg/utils"
-	"github.com/shopspring/decimal" // Import decimal package
+	"github.com/shopspring/decimal"
 )
 
+// ErrSKUNotOnChannel is returned when an order has a SKU that is not sold on
+// the sales channel it is placed on.
+var ErrSKUNotOnChannel = apperr.New(apperr.CodeSKUNotOnChannel)
+
 // OrderCreateReq defines the request structure for creating a new order.
 type OrderCreateReq struct {
-	UserID uint64         `json:"user_id,string"` // Changed to uint64
-	Items  []OrderItemReq `json:"items"`
+	UserID   uint64         `json:"user_id,string"` // Changed to uint64
+	Currency string         `json:"currency"`       // Charged currency; empty means the user's preferred currency, else the base currency
+	Region   string         `json:"region"`         // Where the order ships, for tax; see TaxService
+	Items    []OrderItemReq `json:"items"`
+	// ShippingAddress is where the order ships to within Region.
+	ShippingAddress model.Address `json:"shipping_address"`
+	// PaymentMethodID is a saved payment method to charge once the order is
+	// placed; 0 leaves the order pending until it is paid.
+	PaymentMethodID uint64 `json:"payment_method_id,string"`
+	CouponCode      string `json:"coupon_code"` // Unlocks the promotion of its coupon campaign
+	// ExpectedTotal is the total the customer agreed to; when set, the order
+	// fails with a *PriceChangedError unless it totals exactly this.
+	ExpectedTotal *money.Money `json:"expected_total,omitempty"`
+	// QuotedPrices are the unit prices, in Currency, of the SKUs of an
+	// accepted quote; see QuoteService. The SKUs are sold at these prices,
+	// without group prices or promotions.
+	QuotedPrices map[uint64]decimal.Decimal `json:"-"`
 }
 
 type OrderItemReq struct {
-	SKUID    uint64 `json:"sku_id,string"` // Changed to uint64
-	Quantity int    `json:"quantity"`
+	SKUID         uint64       `json:"sku_id,string"` // Changed to uint64
+	Quantity      int          `json:"quantity"`
+	ExpectedPrice *money.Money `json:"expected_price,omitempty"` // Unit price the customer saw; checked like ExpectedTotal
+	// Options are product options of the SKU's SPU chosen for every unit,
+	// whose prices are added to the unit price.
+	Options []OrderItemOptionReq `json:"options,omitempty"`
 }
 
 // OrderCreateResp defines the response structure after creating an order.
 type OrderCreateResp struct {
-	OrderID     uint64          `json:"order_id,string"` // Changed to uint64
-	OrderNumber string          `json:"order_number"`
-	TotalAmount decimal.Decimal `json:"total_amount"`    // Changed to decimal.Decimal
+	OrderID     uint64             `json:"order_id,string"` // Snowflake ID
+	OrderNumber string             `json:"order_number"`
+	Subtotal    money.Money        `json:"subtotal"`
+	Discount    money.Money        `json:"discount"` // Taken off the subtotal by Promotions
+	TaxAmount   money.Money        `json:"tax_amount"`
+	TotalAmount money.Money        `json:"total_amount"` // Subtotal less discount, plus tax
+	Currency    string             `json:"currency" example:"USD"`
+	TaxLines    []TaxLine          `json:"tax_lines"`
+	Promotions  []AppliedPromotion `json:"promotions"`
+	Status      string             `json:"status" example:"paid"` // pending, paid if the saved payment method was charged, or cancelled if it was declined
+	// PaymentError says why charging the saved payment method failed. A
+	// declined payment cancels the order and gives back its stock; otherwise
+	// the order stays pending while the charge is retried.
+	PaymentError string `json:"payment_error,omitempty" example:"payment declined"`
+	// AddressWarnings are what the customer should check about the
+	// shipping address, e.g. that nothing is known to be delivered there.
+	AddressWarnings []AddressWarning `json:"address_warnings,omitempty"`
+}
+
+// DefaultOrderSweepBatchSize is how many expired orders CancelExpiredOrders
+// loads per query when the caller does not say.
+const DefaultOrderSweepBatchSize = 100
+
+// How CreateOrder keeps concurrent checkouts from overselling.
+const (
+	// StockLockingConditional deducts stock with an update that only applies
+	// while enough stock is left.
+	StockLockingConditional = "conditional"
+	// StockLockingPessimistic locks each SKU row with SELECT ... FOR UPDATE
+	// and checks its stock before deducting, so orders for the same SKUs
+	// queue up instead of racing.
+	StockLockingPessimistic = "pessimistic"
+)
+
+// When an order paid with a saved payment method is charged.
+const (
+	// PaymentCaptureAutomatic charges the order total at checkout.
+	PaymentCaptureAutomatic = "automatic"
+	// PaymentCaptureShipment authorizes the order total at checkout and
+	// captures it as the order ships; see FulfillmentService.
+	PaymentCaptureShipment = "shipment"
+)
+
+// OrderOptions configures an OrderService.
+type OrderOptions struct {
+	// LowStockThreshold is the stock at or below which stock.low fires; 0
+	// means DefaultLowStockThreshold.
+	LowStockThreshold int
+	// StockLocking is StockLockingConditional or StockLockingPessimistic;
+	// empty means conditional.
+	StockLocking string
+	// PaymentCapture is PaymentCaptureAutomatic or PaymentCaptureShipment;
+	// empty means automatic.
+	PaymentCapture string
+	// CheckoutSessionTTL is how long a checkout session holds its prices; 0
+	// means DefaultCheckoutSessionTTL.
+	CheckoutSessionTTL time.Duration
+	// RestockFailed puts back the stock that orders failed by FailOrder
+	// reserved in the database.
+	RestockFailed bool
 }
 
-//go:generate mockgen -source=$GOFILE -destination=../mocks/order_service_mock.go -package=mocks
 // OrderService defines the interface for order business logic.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/order_service_mock.go -package=mocks
 type OrderService interface {
 	CreateOrder(ctx context.Context, req *OrderCreateReq) (*OrderCreateResp, error)
+	// CreateCheckoutSession prices an order like CreateOrder without placing
+	// it, and holds the prices for ConfirmCheckoutSession.
+	CreateCheckoutSession(ctx context.Context, req *OrderCreateReq) (*CheckoutSessionResp, error)
+	GetCheckoutSession(ctx context.Context, userID uint64, id string) (*CheckoutSessionResp, error)
+	ConfirmCheckoutSession(ctx context.Context, userID uint64, id string) (*OrderCreateResp, error)
+	CancelExpiredOrders(ctx context.Context, createdBefore time.Time, batchSize int) (int, error)
+	// FailOrder fails a pending order whose stock could not be allocated
+	// once it was placed, and returns it with its items.
+	FailOrder(ctx context.Context, orderID uint64) (*model.Order, error)
+	// ResumeCheckoutSagas runs the checkout sagas that stopped halfway or
+	// wait to retry a step.
+	ResumeCheckoutSagas(ctx context.Context, opts CheckoutSagaOptions) (*CheckoutSagaReport, error)
 }
 
 type orderService struct {
-	orderRepo   repository.OrderRepository
-	productRepo repository.ProductRepository
-	txManager   database.TransactionManager
+	orderRepo          repository.OrderRepository
+	productRepo        repository.ProductRepository
+	txManager          database.TransactionManager
+	webhooks           WebhookEmitter
+	events             EventPublisher
+	currencies         CurrencyService
+	taxes              TaxService
+	promotions         PromotionService
+	groups             CustomerGroupService
+	addresses          AddressService             // nil accepts shipping addresses as given
+	restrictions       ShippingRestrictionService // nil ships anything anywhere
+	payments           PaymentMethodService
+	sagas              repository.SagaRepository
+	cache              cache.Cache
+	purchases          *PurchaseLimiter // nil without a cache: limits are not enforced
+	catalog            *CatalogCache    // nil without a cache: stock changes are not announced
+	lowStockThreshold  int
+	lockStock          bool
+	captureOnShipment  bool
+	checkoutSessionTTL time.Duration
+	restockFailed      bool
 }
 
-// NewOrderService creates a new OrderService instance.
-func NewOrderService(orderRepo repository.OrderRepository, productRepo repository.ProductRepository, txManager database.TransactionManager) OrderService {
+// NewOrderService creates a new OrderService instance. Order and stock
+// webhooks are queued through webhooks, and order events published through
+// events, in the order's transaction; stock.low
+// fires when an order takes a SKU's stock from above opts.LowStockThreshold
+// to at or below it. Prices are converted with currencies into the currency
+// the order is charged in, promotions discounts them, and taxes adds tax on
+// what is left; promotions may be nil. Customers in a group pay the group's
+// prices from groups, unless it is nil. Shipping addresses are validated and
+// normalized with addresses, and items checked against restrictions on where
+// they ship, unless they are nil. payments charges saved payment
+// methods, in checkout sagas recorded in sagas; it is nil when no payment
+// provider is configured. Checkout
+// sessions, and the units each customer bought of SKUs and promotions with a
+// purchase limit, are kept in c, and stock changes are announced through it
+// for the cached products to be dropped.
+func NewOrderService(orderRepo repository.OrderRepository, productRepo repository.ProductRepository, txManager database.TransactionManager, webhooks WebhookEmitter, events EventPublisher, currencies CurrencyService, taxes TaxService, promotions PromotionService, payments PaymentMethodService, sagas repository.SagaRepository, c cache.Cache, groups CustomerGroupService, addresses AddressService, restrictions ShippingRestrictionService, opts OrderOptions) OrderService {
+	lowStockThreshold := opts.LowStockThreshold
+	if lowStockThreshold <= 0 {
+		lowStockThreshold = DefaultLowStockThreshold
+	}
+	checkoutSessionTTL := opts.CheckoutSessionTTL
+	if checkoutSessionTTL <= 0 {
+		checkoutSessionTTL = DefaultCheckoutSessionTTL
+	}
+	var purchases *PurchaseLimiter
+	var catalog *CatalogCache
+	if c != nil {
+		purchases = NewPurchaseLimiter(c)
+		catalog = NewCatalogCache(c, CatalogCacheOptions{})
+	}
 	return &orderService{
-		orderRepo:   orderRepo,
-		productRepo: productRepo,
-		txManager:   txManager,
+		orderRepo:          orderRepo,
+		productRepo:        productRepo,
+		txManager:          txManager,
+		webhooks:           webhooks,
+		events:             events,
+		currencies:         currencies,
+		taxes:              taxes,
+		promotions:         promotions,
+		groups:             groups,
+		addresses:          addresses,
+		restrictions:       restrictions,
+		payments:           payments,
+		sagas:              sagas,
+		cache:              c,
+		purchases:          purchases,
+		catalog:            catalog,
+		lowStockThreshold:  lowStockThreshold,
+		lockStock:          opts.StockLocking == StockLockingPessimistic,
+		captureOnShipment:  opts.PaymentCapture == PaymentCaptureShipment,
+		checkoutSessionTTL: checkoutSessionTTL,
+		restockFailed:      opts.RestockFailed,
 	}
 }
 
 // CreateOrder handles order creation logic: stock validation/deduction and order saving.
-func (s *orderService) CreateOrder(ctx context.Context, req *OrderCreateReq) (*OrderCreateResp, error) {
+func (s *orderService) CreateOrder(ctx context.Context, req *OrderCreateReq) (resp *OrderCreateResp, err error) {
+	defer func() { recordCheckoutFailure(err) }()
+
+	// The payment method is checked before anything is reserved for the order
+	method, err := s.paymentMethod(ctx, req.UserID, req.PaymentMethodID)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := s.quote(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkExpectedPrices(req, quote); err != nil {
+		return nil, err
+	}
+	return s.placeOrder(ctx, req.UserID, quote, method)
+}
+
+// orderQuote is an order priced, discounted and taxed, ready to be placed.
+type orderQuote struct {
+	currency   string
+	region     string
+	address    model.Address
+	items      []model.OrderItem
+	taxLines   []model.OrderTaxLine
+	promotions []model.OrderPromotion
+	subtotal   money.Money
+	discount   money.Money
+	taxAmount  money.Money
+	total      money.Money // Subtotal less discount, plus tax
+	// limits are the purchase limits the order counts against, of its SKUs
+	// and of the promotions it gets.
+	limits []PurchaseLimit
+	// addressWarnings are what the customer should check about address.
+	addressWarnings []AddressWarning
+}
+
+// newOrderQuote totals items, their discounts and taxLines, priced in
+// currency.
+func newOrderQuote(currency, region string, items []model.OrderItem, taxLines []model.OrderTaxLine, promotions []model.OrderPromotion) (*orderQuote, error) {
+	q := &orderQuote{
+		currency:   currency,
+		region:     region,
+		items:      items,
+		taxLines:   taxLines,
+		promotions: promotions,
+		subtotal:   money.Zero(currency),
+		discount:   money.Zero(currency),
+		taxAmount:  money.Zero(currency),
+	}
+	var err error
+	for _, item := range items {
+		if q.subtotal, err = q.subtotal.Add(money.New(item.Price, currency).Mul(int64(item.Quantity))); err != nil {
+			return nil, err
+		}
+		if q.discount, err = q.discount.Add(money.New(item.Discount, currency)); err != nil {
+			return nil, err
+		}
+	}
+	for _, line := range taxLines {
+		if q.taxAmount, err = q.taxAmount.Add(money.New(line.Amount, currency)); err != nil {
+			return nil, err
+		}
+	}
+	if q.total, err = q.subtotal.Sub(q.discount); err != nil {
+		return nil, err
+	}
+	if q.total, err = q.total.Add(q.taxAmount); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// paymentMethod returns the user's saved payment method with id, or nil if
+// id is 0.
+func (s *orderService) paymentMethod(ctx context.Context, userID, id uint64) (*model.PaymentMethod, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	if s.payments == nil {
+		return nil, ErrPaymentMethodNotFound
+	}
+	return s.payments.Get(ctx, userID, id)
+}
+
+// quote prices the requested items at the SKUs' current prices, in the
+// currency the order is charged in, applies the running promotions and adds
+// tax.
+func (s *orderService) quote(ctx context.Context, req *OrderCreateReq) (*orderQuote, error) {
 	if len(req.Items) == 0 {
 		return nil, errors.New("order items cannot be empty")
 	}
+	currency, err := s.currencies.Resolve(ctx, req.Currency, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	var rates *Rates // Loaded for the first SKU priced in another currency
 
-	// 1. Prepare data
-	totalAmount := decimal.Zero // Changed to decimal.Decimal
-	var orderItems []model.OrderItem
-	
-	// Generate a unique order number
-	orderNumber := fmt.Sprintf("%d%s", time.Now().UnixNano(), utils.RandomString(6))
-
-	// 2. Iterate items to check price and prepare order items
+	// 1. Fetch the SKUs of all items in one query, lined up with req.Items
+	skuIDs := make([]uint64, 0, len(req.Items))
 	for _, itemReq := range req.Items {
-		// Fetch SKU details for price
-		sku, err := s.productRepo.GetSKUByID(ctx, itemReq.SKUID)
-		if err != nil {
-			if errors.Is(err, repository.ErrSKUNotFound) {
-				return nil, fmt.Errorf("SKU %d not found", itemReq.SKUID)
+		if itemReq.Quantity <= 0 {
+			return nil, fmt.Errorf("invalid quantity for SKU %d", itemReq.SKUID)
+		}
+		skuIDs = append(skuIDs, itemReq.SKUID)
+	}
+	skus, err := s.productRepo.GetSKUsByIDs(ctx, skuIDs)
+	if err != nil {
+		if errors.Is(err, repository.ErrSKUNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get SKUs: %w", err)
+	}
+
+	// Customers in a group other than retail pay its prices, unless quoted
+	var groupPrices map[uint64]decimal.Decimal
+	if s.groups != nil && len(req.QuotedPrices) == 0 {
+		if groupPrices, err = s.groups.Prices(ctx, req.UserID, skuIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	// Options chosen for the items are checked against their SPUs' options
+	schemas, err := s.productOptions(ctx, req.Items, skus)
+	if err != nil {
+		return nil, err
+	}
+	// convert prices options in the charged currency, loading the rates
+	// for the first one priced in another
+	convert := func(amount decimal.Decimal, from string) (decimal.Decimal, error) {
+		if from == currency {
+			return amount, nil
+		}
+		if rates == nil {
+			var err error
+			if rates, err = s.currencies.Rates(ctx); err != nil {
+				return decimal.Zero, err
 			}
-			return nil, fmt.Errorf("failed to get SKU %d: %w", itemReq.SKUID, err)
 		}
+		return rates.Convert(amount, from, currency)
+	}
 
-		if itemReq.Quantity <= 0 {
-			return nil, fmt.Errorf("invalid quantity for SKU %d", itemReq.SKUID)
+	// 2. Iterate items to check price and prepare order items
+	ch, now := channel.FromContext(ctx), time.Now()
+	orderItems := make([]model.OrderItem, 0, len(req.Items))
+	for i, itemReq := range req.Items {
+		sku := &skus[i]
+		if !channel.Includes(sku.Channels, ch) {
+			return nil, ErrSKUNotOnChannel.WithSKU(itemReq.SKUID)
+		}
+
+		// Initial stock check; pre-order SKUs are ordered whatever their
+		// stock, and bundles have their components' stock checked
+		if !sku.PreOrder && sku.Delivery != model.SKUDeliveryBundle && sku.Stock < itemReq.Quantity {
+			return nil, ErrInsufficientStock.WithSKU(itemReq.SKUID)
 		}
 
-		// Initial stock check
-		if sku.Stock < itemReq.Quantity {
-			return nil, fmt.Errorf("not enough stock for SKU %d", itemReq.SKUID)
+		// Convert the SKU's price, its sale price during a sale, or its
+		// group's, into the charged currency; quoted prices are in it already
+		price, quoted := req.QuotedPrices[itemReq.SKUID]
+		if !quoted {
+			price = sku.EffectivePrice(now)
+			if groupPrice, ok := groupPrices[itemReq.SKUID]; ok {
+				price = groupPrice
+			}
+		}
+		if !quoted && sku.Currency != currency {
+			if rates == nil {
+				if rates, err = s.currencies.Rates(ctx); err != nil {
+					return nil, err
+				}
+			}
+			if price, err = rates.Convert(price, sku.Currency, currency); err != nil {
+				return nil, fmt.Errorf("failed to price SKU %d in %s: %w", itemReq.SKUID, currency, err)
+			}
 		}
 
-		// Calculate item total using decimal
-		itemTotal := sku.Price.Mul(decimal.NewFromInt(int64(itemReq.Quantity)))
-		totalAmount = totalAmount.Add(itemTotal)
+		// Add the prices of the options chosen for every unit
+		var options []model.OrderItemOption
+		if len(itemReq.Options) > 0 {
+			var extra decimal.Decimal
+			if options, extra, err = chooseOptions(itemReq.SKUID, itemReq.Options, schemas[sku.SPUID], convert); err != nil {
+				return nil, err
+			}
+			price = price.Add(extra)
+		}
 
 		orderItems = append(orderItems, model.OrderItem{
-			SKUID:    itemReq.SKUID,
-			Quantity: itemReq.Quantity,
-			Price:    sku.Price, // Use SKU's price at the time of order
+			SKUID:       itemReq.SKUID,
+			Quantity:    itemReq.Quantity,
+			Price:       price, // Use SKU's price at the time of order
+			Backordered: sku.PreOrder,
+			Delivery:    sku.Delivery,
+			Options:     options,
 		})
 	}
 
-	// 3. Create Order Model
+	// 3. Apply promotions, unless the prices were quoted
+	var promotions []model.OrderPromotion
+	if s.promotions != nil && len(req.QuotedPrices) == 0 {
+		if promotions, err = s.promotions.Apply(ctx, currency, req.CouponCode, orderItems, skus); err != nil {
+			return nil, fmt.Errorf("failed to apply promotions: %w", err)
+		}
+	}
+
+	// 4. Add tax on the discounted items
+	region := strings.ToUpper(req.Region)
+	taxLines, err := s.taxes.Calculate(ctx, region, currency, orderItems)
+	if err != nil {
+		return nil, err
+	}
+	limits := purchaseLimits(orderItems, skus, promotions)
+
+	// 5. Add the component lines of bundles, which are fulfilled in their stead
+	if orderItems, err = s.expandBundles(ctx, orderItems); err != nil {
+		return nil, err
+	}
+	// Refuse items that cannot ship to the region, bundles' components included
+	if s.restrictions != nil {
+		if err := s.restrictions.Check(ctx, region, orderItems); err != nil {
+			return nil, err
+		}
+	}
+	quote, err := newOrderQuote(currency, region, orderItems, taxLines, promotions)
+	if err != nil {
+		return nil, err
+	}
+	quote.address = req.ShippingAddress
+	quote.limits = limits
+
+	// 6. Validate where the order ships to, once it is known to be sold there
+	if s.addresses != nil && req.ShippingAddress != (model.Address{}) {
+		validated, err := s.addresses.Validate(ctx, region, req.ShippingAddress)
+		if err != nil {
+			return nil, err
+		}
+		quote.address, quote.addressWarnings = validated.Address, validated.Warnings
+	}
+	return quote, nil
+}
+
+// placeOrder deducts stock for quote and creates the user's order at its
+// prices, then pays it with method, if any, in a checkout saga.
+func (s *orderService) placeOrder(ctx context.Context, userID uint64, quote *orderQuote, method *model.PaymentMethod) (*OrderCreateResp, error) {
+	// 1. Create Order Model with a unique order number
 	order := &model.Order{
-		UserID:      req.UserID,
-		OrderNumber: orderNumber,
-		TotalAmount: totalAmount,
-		Status:      "pending",
+		UserID:          userID,
+		OrderNumber:     fmt.Sprintf("%d%s", time.Now().UnixNano(), utils.RandomString(6)),
+		TotalAmount:     quote.total.Amount(),
+		DiscountAmount:  quote.discount.Amount(),
+		TaxAmount:       quote.taxAmount.Amount(),
+		Currency:        quote.currency,
+		Region:          quote.region,
+		ShippingAddress: quote.address,
+		Status:          model.OrderStatusPending,
+		TaxLines:        quote.taxLines,
+		Promotions:      quote.promotions,
+	}
+	orderItems := quote.items
+
+	// 2. Count the items against the customer's purchase limits. Redis is not
+	// part of the transaction, so the units are given back if it fails.
+	storeID := tenant.StoreID(ctx)
+	if s.purchases != nil {
+		if err := s.purchases.Reserve(ctx, storeID, userID, quote.limits); err != nil {
+			return nil, err
+		}
 	}
 
-	// 4. Execute Transaction: Deduct Stock AND Create Order atomically
+	// 3. Execute Transaction: Deduct Stock AND Create Order atomically
+	var saga *model.SagaState
+	var soldOut int
 	err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
-		// a. Deduct Stock
-		for _, item := range orderItems {
-			// Deduct stock (Quantity * -1) using transaction context
-			if err := s.productRepo.UpdateSKUStock(txCtx, item.SKUID, -item.Quantity); err != nil {
-				return fmt.Errorf("failed to deduct stock for SKU %d: %w", item.SKUID, err)
-			}
+		// a. Deduct Stock, but for backordered items: stock is allocated to
+		// them as it arrives
+		var err error
+		if soldOut, err = s.deductStock(txCtx, stockedItems(orderItems)); err != nil {
+			return err
 		}
 
 		// b. Create Order using transaction context
@@ -119,16 +505,326 @@ func (s *orderService) CreateOrder(ctx context.Context, req *OrderCreateReq) (*O
 			return fmt.Errorf("failed to create order: %w", err)
 		}
 
+		// c. Redeem the coupon the order was placed with, if any
+		if s.promotions != nil {
+			if err := s.promotions.RedeemCoupons(txCtx, order.Promotions, order.ID, userID); err != nil {
+				return err
+			}
+		}
+
+		// d. Queue the order.created webhook and event with the order
+		data := newOrderWebhookData(order, orderItems)
+		if err := s.webhooks.Emit(txCtx, WebhookEventOrderCreated, data); err != nil {
+			return fmt.Errorf("failed to queue order webhook: %w", err)
+		}
+		if err := s.events.Publish(txCtx, EventOrderCreated, data); err != nil {
+			return fmt.Errorf("failed to publish order event: %w", err)
+		}
+
+		// e. Record the checkout saga that charges the order, if it is paid
+		// with a saved method
+		if method != nil {
+			var err error
+			if saga, err = s.startCheckoutSaga(txCtx, order, method); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 
 	if err != nil {
+		if s.purchases != nil {
+			if releaseErr := s.purchases.Release(context.WithoutCancel(ctx), storeID, userID, quote.limits); releaseErr != nil {
+				slog.ErrorContext(ctx, "Failed to release purchases of unplaced order", logger.Err(releaseErr))
+			}
+		}
 		return nil, err
 	}
+	ordersCreated.Inc()
+	stockOuts.Add(float64(soldOut))
+	publishStockChanged(ctx, s.catalog, stockedItems(orderItems))
+
+	// 4. Charge the saved payment method, if one was chosen
+	var paymentError string
+	if saga != nil {
+		order.Items = orderItems
+		stepErr, err := s.runCheckoutSaga(ctx, saga, order, method, DefaultCheckoutSagaMaxAttempts)
+		if stepErr != nil {
+			slog.ErrorContext(ctx, "Failed to pay order with saved payment method", "order_id", order.ID, logger.Err(stepErr))
+		}
+		if err != nil {
+			// The saga is resumed once its lease runs out
+			slog.ErrorContext(ctx, "Failed to record checkout saga", "order_id", order.ID, logger.Err(err))
+		}
+		switch {
+		case saga.Status == model.SagaStatusCompleted:
+		case saga.Status != model.SagaStatusRunning && saga.PaymentRef == "":
+			paymentError = "payment declined"
+			checkoutFailures.WithLabelValues(checkoutPaymentDeclined).Inc()
+		default:
+			paymentError = "payment failed"
+			checkoutFailures.WithLabelValues(checkoutPaymentFailed).Inc()
+		}
+	}
 
 	return &OrderCreateResp{
-		OrderID:     order.ID,
-		OrderNumber: order.OrderNumber,
-		TotalAmount: totalAmount,
+		OrderID:         order.ID,
+		OrderNumber:     order.OrderNumber,
+		Subtotal:        quote.subtotal,
+		Discount:        quote.discount,
+		TaxAmount:       quote.taxAmount,
+		TotalAmount:     quote.total,
+		Currency:        quote.currency,
+		TaxLines:        newTaxLines(quote.currency, quote.taxLines),
+		Promotions:      newAppliedPromotions(quote.currency, quote.promotions),
+		Status:          order.Status,
+		PaymentError:    paymentError,
+		AddressWarnings: quote.addressWarnings,
 	}, nil
 }
+
+// deductStock deducts the stock of items in the order's transaction with the
+// configured StockLocking. It returns how many SKUs the order sold out.
+func (s *orderService) deductStock(txCtx context.Context, items []model.OrderItem) (soldOut int, err error) {
+	if s.lockStock {
+		return s.deductLockedStock(txCtx, items)
+	}
+	for _, item := range items {
+		// Deduct stock (Quantity * -1) using transaction context
+		if err := s.productRepo.UpdateSKUStock(txCtx, item.SKUID, -item.Quantity); err != nil {
+			return soldOut, fmt.Errorf("failed to deduct stock for SKU %d: %w", item.SKUID, err)
+		}
+		// The stock is read back in the transaction: the deduction holds the
+		// row lock, so concurrent orders see each other's deductions and
+		// exactly one of them crosses the threshold.
+		sku, err := s.productRepo.GetSKUByID(txCtx, item.SKUID)
+		if err != nil {
+			return soldOut, fmt.Errorf("failed to read stock for SKU %d: %w", item.SKUID, err)
+		}
+		if err := s.emitStockLow(txCtx, item.SKUID, sku.Stock, item.Quantity); err != nil {
+			return soldOut, err
+		}
+		if sku.Stock == 0 {
+			soldOut++
+		}
+	}
+	return soldOut, nil
+}
+
+// deductLockedStock locks the SKU of every item before checking and deducting
+// its stock. Lines of the same SKU are deducted together, and rows are locked
+// in ID order so that concurrent orders for the same SKUs cannot deadlock.
+func (s *orderService) deductLockedStock(txCtx context.Context, items []model.OrderItem) (soldOut int, err error) {
+	quantities := make(map[uint64]int, len(items))
+	for _, item := range items {
+		quantities[item.SKUID] += item.Quantity
+	}
+	for _, skuID := range slices.Sorted(maps.Keys(quantities)) {
+		sku, err := s.productRepo.GetSKUForUpdate(txCtx, skuID)
+		if err != nil {
+			return soldOut, fmt.Errorf("failed to lock SKU %d: %w", skuID, err)
+		}
+		quantity := quantities[skuID]
+		if sku.Stock < quantity {
+			return soldOut, ErrInsufficientStock.WithSKU(skuID)
+		}
+		if err := s.productRepo.UpdateSKUStock(txCtx, skuID, -quantity); err != nil {
+			return soldOut, fmt.Errorf("failed to deduct stock for SKU %d: %w", skuID, err)
+		}
+		if err := s.emitStockLow(txCtx, skuID, sku.Stock-quantity, quantity); err != nil {
+			return soldOut, err
+		}
+		if sku.Stock == quantity {
+			soldOut++
+		}
+	}
+	return soldOut, nil
+}
+
+// emitStockLow queues stock.low if deducting quantity took the SKU across the
+// threshold, leaving stock.
+func (s *orderService) emitStockLow(txCtx context.Context, skuID uint64, stock, quantity int) error {
+	if stock > s.lowStockThreshold || stock+quantity <= s.lowStockThreshold {
+		return nil
+	}
+	data := StockLowWebhookData{SKUID: skuID, Stock: stock, Threshold: s.lowStockThreshold}
+	if err := s.webhooks.Emit(txCtx, WebhookEventStockLow, data); err != nil {
+		return fmt.Errorf("failed to queue stock webhook: %w", err)
+	}
+	return nil
+}
+
+func newOrderWebhookData(order *model.Order, items []model.OrderItem) OrderWebhookData {
+	data := OrderWebhookData{
+		OrderID:        order.ID,
+		OrderNumber:    order.OrderNumber,
+		UserID:         order.UserID,
+		Status:         order.Status,
+		TotalAmount:    money.New(order.TotalAmount, order.Currency),
+		DiscountAmount: money.New(order.DiscountAmount, order.Currency),
+		TaxAmount:      money.New(order.TaxAmount, order.Currency),
+		Currency:       order.Currency,
+		Region:         order.Region,
+		Items:          make([]OrderWebhookItem, 0, len(items)),
+		TaxLines:       newTaxLines(order.Currency, order.TaxLines),
+	}
+	bundles := make(map[uint64]uint64) // SKU of each bundle's line
+	for _, item := range items {
+		if item.Delivery == model.SKUDeliveryBundle {
+			bundles[item.ID] = item.SKUID
+		}
+	}
+	for _, item := range items {
+		line := OrderWebhookItem{
+			SKUID:    item.SKUID,
+			Quantity: item.Quantity,
+			Price:    money.New(item.Price, order.Currency),
+			Discount: money.New(item.Discount, order.Currency),
+			Options:  newOrderItemOptionResps(order.Currency, item.Options),
+		}
+		if item.BundleItemID != nil {
+			line.BundleSKUID = bundles[*item.BundleItemID]
+		}
+		data.Items = append(data.Items, line)
+	}
+	return data
+}
+
+// publishOrderCancelled publishes order.cancelled for an order with its
+// items loaded, in the transaction that cancels it.
+func publishOrderCancelled(ctx context.Context, events EventPublisher, order *model.Order) error {
+	cancelled := *order
+	cancelled.Status = model.OrderStatusCancelled
+	if err := events.Publish(ctx, EventOrderCancelled, newOrderWebhookData(&cancelled, order.Items)); err != nil {
+		return fmt.Errorf("failed to publish order event: %w", err)
+	}
+	return nil
+}
+
+// newTaxLines converts the tax lines of an order charged in currency.
+func newTaxLines(currency string, lines []model.OrderTaxLine) []TaxLine {
+	taxLines := make([]TaxLine, 0, len(lines))
+	for _, line := range lines {
+		taxLines = append(taxLines, TaxLine{Name: line.Name, Rate: line.Rate, Amount: money.New(line.Amount, currency)})
+	}
+	return taxLines
+}
+
+// CancelExpiredOrders cancels pending orders created before createdBefore and
+// puts their stock back, loading batchSize orders at a time. Each order is
+// cancelled in its own transaction; one that was paid in the meantime is left
+// alone, and one that fails does not stop the others. It returns how many
+// orders were cancelled.
+func (s *orderService) CancelExpiredOrders(ctx context.Context, createdBefore time.Time, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultOrderSweepBatchSize
+	}
+
+	cancelled := 0
+	var errs []error
+	var afterID uint64
+	for {
+		orders, err := s.orderRepo.ListPendingBefore(ctx, createdBefore, afterID, batchSize)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list expired orders: %w", err))
+			return cancelled, errors.Join(errs...)
+		}
+
+		for i := range orders {
+			if err := ctx.Err(); err != nil {
+				return cancelled, errors.Join(append(errs, err)...)
+			}
+			ok, err := s.cancelExpiredOrder(ctx, &orders[i])
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if ok {
+				cancelled++
+			}
+		}
+
+		if len(orders) < batchSize {
+			return cancelled, errors.Join(errs...)
+		}
+		afterID = orders[len(orders)-1].ID
+	}
+}
+
+// cancelExpiredOrder cancels one pending order and restores the stock its
+// items reserved and the units it counted against the customer's purchase
+// limits. It reports false if the order had left the pending status.
+func (s *orderService) cancelExpiredOrder(ctx context.Context, order *model.Order) (bool, error) {
+	err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		return s.cancelPendingOrder(txCtx, order)
+	})
+	if errors.Is(err, repository.ErrOrderStatusChanged) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel order %d: %w", order.ID, err)
+	}
+	ordersCancelled.WithLabelValues(cancelReasonExpired).Inc()
+	releaseOrderPurchases(ctx, s.purchases, order)
+	publishStockChanged(ctx, s.catalog, stockedItems(order.Items))
+	return true, nil
+}
+
+// cancelPendingOrder cancels a pending order with its items loaded and
+// restores the stock they reserved, in the caller's transaction. It returns
+// repository.ErrOrderStatusChanged if the order is no longer pending.
+func (s *orderService) cancelPendingOrder(txCtx context.Context, order *model.Order) error {
+	if err := s.orderRepo.UpdateStatus(txCtx, order.ID, model.OrderStatusPending, model.OrderStatusCancelled); err != nil {
+		return err
+	}
+	for _, item := range stockedItems(order.Items) {
+		if err := s.productRepo.UpdateSKUStock(txCtx, item.SKUID, item.Quantity); err != nil {
+			return fmt.Errorf("failed to restore stock for SKU %d: %w", item.SKUID, err)
+		}
+	}
+	return publishOrderCancelled(txCtx, s.events, order)
+}
+
+// FailOrder moves a pending order to failed and publishes order.failed in
+// one transaction, giving back the units it counted against the customer's
+// purchase limits and, with RestockFailed, the stock it reserved. It returns
+// ErrOrderNotPending if the order was paid or cancelled in the meantime.
+func (s *orderService) FailOrder(ctx context.Context, orderID uint64) (*model.Order, error) {
+	var order *model.Order
+	err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		if order, err = s.orderRepo.GetByIDForUpdate(txCtx, orderID); err != nil {
+			return err
+		}
+		if err := s.orderRepo.UpdateStatus(txCtx, order.ID, model.OrderStatusPending, model.OrderStatusFailed); err != nil {
+			return err
+		}
+		if s.restockFailed {
+			for _, item := range stockedItems(order.Items) {
+				if err := s.productRepo.UpdateSKUStock(txCtx, item.SKUID, item.Quantity); err != nil {
+					return fmt.Errorf("failed to restore stock for SKU %d: %w", item.SKUID, err)
+				}
+			}
+		}
+		order.Status = model.OrderStatusFailed
+		if err := s.events.Publish(txCtx, EventOrderFailed, newOrderWebhookData(order, order.Items)); err != nil {
+			return fmt.Errorf("failed to publish order event: %w", err)
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, repository.ErrOrderNotFound):
+		return nil, ErrOrderNotFound
+	case errors.Is(err, repository.ErrOrderStatusChanged):
+		return nil, ErrOrderNotPending
+	case err != nil:
+		return nil, fmt.Errorf("failed to fail order %d: %w", orderID, err)
+	}
+
+	ordersFailed.Inc()
+	releaseOrderPurchases(ctx, s.purchases, order)
+	if s.restockFailed {
+		publishStockChanged(ctx, s.catalog, stockedItems(order.Items))
+	}
+	return order, nil
+}
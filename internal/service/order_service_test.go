@@ -3,11 +3,21 @@ package service_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/proyuen/go-mall/internal/mocks"
 	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
 	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/payment"
+	"github.com/proyuen/go-mall/internal/service/tax"
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/proyuen/go-mall/pkg/snowflake"
 	"github.com/shopspring/decimal" // Import decimal
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -20,6 +30,7 @@ func TestOrderService_CreateOrder(t *testing.T) {
 			mockOrderRepo *mocks.MockOrderRepository,
 			mockProductRepo *mocks.MockProductRepository,
 			mockTxManager *mocks.MockTransactionManager,
+			mockWebhooks *mocks.MockWebhookEmitter,
 			req *service.OrderCreateReq,
 		)
 	}
@@ -46,12 +57,13 @@ func TestOrderService_CreateOrder(t *testing.T) {
 				},
 			},
 			fields: fields{
-				mockSetup: func(mockOrderRepo *mocks.MockOrderRepository, mockProductRepo *mocks.MockProductRepository, mockTxManager *mocks.MockTransactionManager, req *service.OrderCreateReq) {
-					// 1. GetSKUByID (Check Price & Stock)
-					mockProductRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(101)).Return(&model.SKU{ // Changed to uint64
-						Price: decimal.NewFromFloat(50.0), // Changed to decimal.Decimal
-						Stock: 100,
-					}, nil)
+				mockSetup: func(mockOrderRepo *mocks.MockOrderRepository, mockProductRepo *mocks.MockProductRepository, mockTxManager *mocks.MockTransactionManager, mockWebhooks *mocks.MockWebhookEmitter, req *service.OrderCreateReq) {
+					// 1. GetSKUsByIDs (Check Price & Stock)
+					mockProductRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return([]model.SKU{{
+						Price:    decimal.NewFromFloat(50.0), // Changed to decimal.Decimal
+						Currency: "USD",
+						Stock:    100,
+					}}, nil)
 
 					// 2. Transaction Setup
 					mockTxManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
@@ -61,18 +73,102 @@ func TestOrderService_CreateOrder(t *testing.T) {
 
 					// 3. UpdateSKUStock (Deduct)
 					mockProductRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), -2).Return(nil) // Changed to uint64
+					mockProductRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(101)).Return(&model.SKU{Stock: 98}, nil)
 
 					// 4. CreateOrder
 					mockOrderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+					// 5. order.created webhook
+					mockWebhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderCreated, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, data any) error {
+						order := data.(service.OrderWebhookData)
+						assert.Equal(t, uint64(1), order.UserID)
+						assert.Equal(t, model.OrderStatusPending, order.Status)
+						require.Len(t, order.Items, 1)
+						assert.Equal(t, service.OrderWebhookItem{SKUID: 101, Quantity: 2, Price: money.New(decimal.NewFromFloat(50.0), "USD"), Discount: money.New(decimal.Zero, "USD")}, order.Items[0])
+						return nil
+					})
 				},
 			},
 			wantErr:  false,
 			wantResp: true,
 			checkResp: func(t *testing.T, resp *service.OrderCreateResp) {
-				assert.True(t, decimal.NewFromFloat(100.0).Equal(resp.TotalAmount)) // Changed to decimal.Decimal
+				assert.Equal(t, "100.00 USD", resp.TotalAmount.String())
+				assert.True(t, resp.TaxAmount.IsZero())
+				assert.Empty(t, resp.TaxLines)
+				assert.Equal(t, "USD", resp.Currency)
 				assert.NotEmpty(t, resp.OrderNumber)
 			},
 		},
+		{
+			name: "StockCrossesLowThreshold",
+			args: args{
+				req: &service.OrderCreateReq{
+					UserID: 1,
+					Items:  []service.OrderItemReq{{SKUID: 101, Quantity: 2}},
+				},
+			},
+			fields: fields{
+				mockSetup: func(mockOrderRepo *mocks.MockOrderRepository, mockProductRepo *mocks.MockProductRepository, mockTxManager *mocks.MockTransactionManager, mockWebhooks *mocks.MockWebhookEmitter, req *service.OrderCreateReq) {
+					mockProductRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return([]model.SKU{{Price: decimal.NewFromFloat(50.0), Currency: "USD", Stock: 11}}, nil)
+					mockTxManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+						return fn(ctx)
+					})
+					mockProductRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), -2).Return(nil)
+					// 11 -> 9 crosses the default threshold of 10.
+					mockProductRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(101)).Return(&model.SKU{Stock: 9}, nil)
+					mockWebhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventStockLow, service.StockLowWebhookData{SKUID: 101, Stock: 9, Threshold: 10}).Return(nil)
+					mockOrderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+					mockWebhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderCreated, gomock.Any()).Return(nil)
+				},
+			},
+			wantResp: true,
+		},
+		{
+			name: "StockAlreadyLow",
+			args: args{
+				req: &service.OrderCreateReq{
+					UserID: 1,
+					Items:  []service.OrderItemReq{{SKUID: 101, Quantity: 2}},
+				},
+			},
+			fields: fields{
+				mockSetup: func(mockOrderRepo *mocks.MockOrderRepository, mockProductRepo *mocks.MockProductRepository, mockTxManager *mocks.MockTransactionManager, mockWebhooks *mocks.MockWebhookEmitter, req *service.OrderCreateReq) {
+					mockProductRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return([]model.SKU{{Price: decimal.NewFromFloat(50.0), Currency: "USD", Stock: 9}}, nil)
+					mockTxManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+						return fn(ctx)
+					})
+					mockProductRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), -2).Return(nil)
+					// 9 -> 7 started below the threshold, so nothing new to report.
+					mockProductRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(101)).Return(&model.SKU{Stock: 7}, nil)
+					mockOrderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+					mockWebhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderCreated, gomock.Any()).Return(nil)
+				},
+			},
+			wantResp: true,
+		},
+		{
+			name: "WebhookQueueFailure",
+			args: args{
+				req: &service.OrderCreateReq{
+					UserID: 1,
+					Items:  []service.OrderItemReq{{SKUID: 101, Quantity: 1}},
+				},
+			},
+			fields: fields{
+				mockSetup: func(mockOrderRepo *mocks.MockOrderRepository, mockProductRepo *mocks.MockProductRepository, mockTxManager *mocks.MockTransactionManager, mockWebhooks *mocks.MockWebhookEmitter, req *service.OrderCreateReq) {
+					mockProductRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return([]model.SKU{{Price: decimal.NewFromFloat(50.0), Currency: "USD", Stock: 100}}, nil)
+					mockTxManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+						return fn(ctx)
+					})
+					mockProductRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), -1).Return(nil)
+					mockProductRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(101)).Return(&model.SKU{Stock: 99}, nil)
+					mockOrderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+					mockWebhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderCreated, gomock.Any()).Return(errors.New("db down"))
+				},
+			},
+			wantErr: true,
+			errStr:  "failed to queue order webhook",
+		},
 		{
 			name: "SKUNotFound",
 			args: args{
@@ -84,12 +180,30 @@ func TestOrderService_CreateOrder(t *testing.T) {
 				},
 			},
 			fields: fields{
-				mockSetup: func(mockOrderRepo *mocks.MockOrderRepository, mockProductRepo *mocks.MockProductRepository, mockTxManager *mocks.MockTransactionManager, req *service.OrderCreateReq) {
-					mockProductRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(999)).Return(nil, errors.New("sku not found")) // Changed to uint64
+				mockSetup: func(mockOrderRepo *mocks.MockOrderRepository, mockProductRepo *mocks.MockProductRepository, mockTxManager *mocks.MockTransactionManager, mockWebhooks *mocks.MockWebhookEmitter, req *service.OrderCreateReq) {
+					mockProductRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{999}).Return(nil, fmt.Errorf("%w: %d", repository.ErrSKUNotFound, 999))
 				},
 			},
 			wantErr: true,
-			errStr:  "failed to get SKU 999",
+			errStr:  "SKU not found: 999",
+		},
+		{
+			name: "SKULookupFailure",
+			args: args{
+				req: &service.OrderCreateReq{
+					UserID: 1,
+					Items: []service.OrderItemReq{
+						{SKUID: 101, Quantity: 1},
+					},
+				},
+			},
+			fields: fields{
+				mockSetup: func(mockOrderRepo *mocks.MockOrderRepository, mockProductRepo *mocks.MockProductRepository, mockTxManager *mocks.MockTransactionManager, mockWebhooks *mocks.MockWebhookEmitter, req *service.OrderCreateReq) {
+					mockProductRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return(nil, errors.New("db down"))
+				},
+			},
+			wantErr: true,
+			errStr:  "failed to get SKUs",
 		},
 		{
 			name: "InsufficientStock",
@@ -102,15 +216,38 @@ func TestOrderService_CreateOrder(t *testing.T) {
 				},
 			},
 			fields: fields{
-				mockSetup: func(mockOrderRepo *mocks.MockOrderRepository, mockProductRepo *mocks.MockProductRepository, mockTxManager *mocks.MockTransactionManager, req *service.OrderCreateReq) {
-					mockProductRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(101)).Return(&model.SKU{ // Changed to uint64
-						Price: decimal.NewFromFloat(50.0), // Changed to decimal.Decimal
-						Stock: 5,                          // Less than 10
-					}, nil)
+				mockSetup: func(mockOrderRepo *mocks.MockOrderRepository, mockProductRepo *mocks.MockProductRepository, mockTxManager *mocks.MockTransactionManager, mockWebhooks *mocks.MockWebhookEmitter, req *service.OrderCreateReq) {
+					mockProductRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return([]model.SKU{{
+						Price:    decimal.NewFromFloat(50.0), // Changed to decimal.Decimal
+						Currency: "USD",
+						Stock:    5, // Less than 10
+					}}, nil)
 				},
 			},
 			wantErr: true,
-			errStr:  "not enough stock",
+			errStr:  "insufficient stock (sku_id=101)",
+		},
+		{
+			name: "SKUNotOnChannel",
+			args: args{
+				req: &service.OrderCreateReq{
+					UserID: 1,
+					Items:  []service.OrderItemReq{{SKUID: 101, Quantity: 1}},
+				},
+			},
+			fields: fields{
+				mockSetup: func(mockOrderRepo *mocks.MockOrderRepository, mockProductRepo *mocks.MockProductRepository, mockTxManager *mocks.MockTransactionManager, mockWebhooks *mocks.MockWebhookEmitter, req *service.OrderCreateReq) {
+					// Orders are placed on the web unless the context says otherwise
+					mockProductRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return([]model.SKU{{
+						Price:    decimal.NewFromFloat(50.0),
+						Currency: "USD",
+						Stock:    100,
+						Channels: "app,wholesale",
+					}}, nil)
+				},
+			},
+			wantErr: true,
+			errStr:  "SKU is not sold on this channel (sku_id=101)",
 		},
 		{
 			name: "StockDeductionFailure",
@@ -123,11 +260,12 @@ func TestOrderService_CreateOrder(t *testing.T) {
 				},
 			},
 			fields: fields{
-				mockSetup: func(mockOrderRepo *mocks.MockOrderRepository, mockProductRepo *mocks.MockProductRepository, mockTxManager *mocks.MockTransactionManager, req *service.OrderCreateReq) {
-					mockProductRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(101)).Return(&model.SKU{ // Changed to uint64
-						Price: decimal.NewFromFloat(50.0), // Changed to decimal.Decimal
-						Stock: 10,
-					}, nil)
+				mockSetup: func(mockOrderRepo *mocks.MockOrderRepository, mockProductRepo *mocks.MockProductRepository, mockTxManager *mocks.MockTransactionManager, mockWebhooks *mocks.MockWebhookEmitter, req *service.OrderCreateReq) {
+					mockProductRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return([]model.SKU{{
+						Price:    decimal.NewFromFloat(50.0), // Changed to decimal.Decimal
+						Currency: "USD",
+						Stock:    10,
+					}}, nil)
 
 					mockTxManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
 						return fn(ctx)
@@ -149,12 +287,18 @@ func TestOrderService_CreateOrder(t *testing.T) {
 			mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
 			mockProductRepo := mocks.NewMockProductRepository(ctrl)
 			mockTxManager := mocks.NewMockTransactionManager(ctrl)
+			mockWebhooks := mocks.NewMockWebhookEmitter(ctrl)
+			mockEvents := mocks.NewMockEventPublisher(ctrl)
+			mockEvents.EXPECT().Publish(gomock.Any(), service.EventOrderCreated, gomock.Any()).Return(nil).AnyTimes()
 
-			orderService := service.NewOrderService(mockOrderRepo, mockProductRepo, mockTxManager)
+			userRepo := mocks.NewMockUserRepository(ctrl)
+			userRepo.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(&model.User{}, nil).AnyTimes() // No currency preference
+			currencies := service.NewCurrencyService(mocks.NewMockExchangeRateRepository(ctrl), userRepo, nil, service.CurrencyOptions{Base: "USD"})
+			orderService := service.NewOrderService(mockOrderRepo, mockProductRepo, mockTxManager, mockWebhooks, mockEvents, currencies, service.NewTaxService(nil), nil, nil, nil, nil, nil, nil, nil, service.OrderOptions{})
 			ctx := context.Background()
 
 			if tt.fields.mockSetup != nil {
-				tt.fields.mockSetup(mockOrderRepo, mockProductRepo, mockTxManager, tt.args.req)
+				tt.fields.mockSetup(mockOrderRepo, mockProductRepo, mockTxManager, mockWebhooks, tt.args.req)
 			}
 
 			resp, err := orderService.CreateOrder(ctx, tt.args.req)
@@ -177,4 +321,1049 @@ func TestOrderService_CreateOrder(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+// metricValue returns the value of the counter name with the given label
+// value, or 0 if it has not been incremented yet.
+func metricValue(t *testing.T, name string, labels ...string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			var values []string
+			for _, label := range m.GetLabel() {
+				values = append(values, label.GetValue())
+			}
+			if strings.Join(values, ",") == strings.Join(labels, ",") {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestOrderService_CreateOrderMetrics(t *testing.T) {
+	tests := []struct {
+		name         string
+		stock        int
+		remaining    int
+		wantCreated  float64
+		wantStockOut float64
+		wantFailure  float64
+	}{
+		{name: "SellsOut", stock: 2, remaining: 0, wantCreated: 1, wantStockOut: 1},
+		{name: "StockLeft", stock: 100, remaining: 98, wantCreated: 1},
+		{name: "OutOfStock", stock: 1, wantFailure: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			webhooks := mocks.NewMockWebhookEmitter(ctrl)
+			events := mocks.NewMockEventPublisher(ctrl)
+			userRepo := mocks.NewMockUserRepository(ctrl)
+			userRepo.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(&model.User{}, nil).AnyTimes()
+			currencies := service.NewCurrencyService(mocks.NewMockExchangeRateRepository(ctrl), userRepo, nil, service.CurrencyOptions{Base: "USD"})
+			orderService := service.NewOrderService(orderRepo, productRepo, txManager, webhooks, events, currencies, service.NewTaxService(nil), nil, nil, nil, nil, nil, nil, nil, service.OrderOptions{})
+
+			productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return([]model.SKU{{Price: decimal.NewFromFloat(50.0), Currency: "USD", Stock: tt.stock}}, nil)
+			if tt.wantCreated > 0 {
+				txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), -2).Return(nil)
+				productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(101)).Return(&model.SKU{Stock: tt.remaining}, nil)
+				orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				webhooks.EXPECT().Emit(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				events.EXPECT().Publish(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			}
+
+			created, stockOuts := metricValue(t, "orders_created_total"), metricValue(t, "stock_outs_total")
+			failures := metricValue(t, "checkout_failures_total", "out_of_stock")
+
+			_, err := orderService.CreateOrder(context.Background(), &service.OrderCreateReq{UserID: 1, Items: []service.OrderItemReq{{SKUID: 101, Quantity: 2}}})
+			if tt.wantFailure > 0 {
+				assert.ErrorIs(t, err, service.ErrInsufficientStock)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, created+tt.wantCreated, metricValue(t, "orders_created_total"))
+			assert.Equal(t, stockOuts+tt.wantStockOut, metricValue(t, "stock_outs_total"))
+			assert.Equal(t, failures+tt.wantFailure, metricValue(t, "checkout_failures_total", "out_of_stock"))
+		})
+	}
+}
+
+func TestOrderService_CreateOrderCurrency(t *testing.T) {
+	tests := []struct {
+		name      string
+		currency  string
+		rates     []model.ExchangeRate
+		wantTotal string
+		wantPrice string
+		errIs     error
+	}{
+		{
+			name:      "ConvertsIntoRequestedCurrency",
+			currency:  "eur",
+			rates:     []model.ExchangeRate{{BaseCurrency: "USD", Currency: "EUR", Rate: decimal.RequireFromString("0.9")}},
+			wantTotal: "90",
+			wantPrice: "45",
+		},
+		{
+			name:      "PreferredCurrency",
+			rates:     []model.ExchangeRate{{BaseCurrency: "USD", Currency: "EUR", Rate: decimal.RequireFromString("0.9")}},
+			wantTotal: "90",
+			wantPrice: "45",
+		},
+		{
+			name:     "UnsupportedCurrency",
+			currency: "CHF",
+			errIs:    service.ErrUnsupportedCurrency,
+		},
+		{
+			name:     "NoRate",
+			currency: "EUR",
+			errIs:    service.ErrRatesUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			webhooks := mocks.NewMockWebhookEmitter(ctrl)
+			events := mocks.NewMockEventPublisher(ctrl)
+			events.EXPECT().Publish(gomock.Any(), service.EventOrderCreated, gomock.Any()).Return(nil).AnyTimes()
+			rateRepo := mocks.NewMockExchangeRateRepository(ctrl)
+			userRepo := mocks.NewMockUserRepository(ctrl)
+			if tt.currency == "" {
+				userRepo.EXPECT().GetByID(gomock.Any(), uint64(1)).Return(&model.User{Currency: "EUR"}, nil)
+			}
+
+			if tt.errIs != service.ErrUnsupportedCurrency {
+				for i := range tt.rates {
+					tt.rates[i].UpdatedAt = time.Now()
+				}
+				rateRepo.EXPECT().ListByBase(gomock.Any(), "USD").Return(tt.rates, nil)
+				productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return([]model.SKU{{Price: decimal.NewFromInt(50), Currency: "USD", Stock: 100}}, nil)
+			}
+			if tt.errIs == nil {
+				txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), -2).Return(nil)
+				productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(101)).Return(&model.SKU{Stock: 98}, nil)
+				orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, order *model.Order, items []model.OrderItem) error {
+					assert.Equal(t, "EUR", order.Currency)
+					assert.Equal(t, tt.wantPrice, items[0].Price.String())
+					return nil
+				})
+				webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderCreated, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, data any) error {
+					assert.Equal(t, "EUR", data.(service.OrderWebhookData).Currency)
+					return nil
+				})
+			}
+
+			currencies := service.NewCurrencyService(rateRepo, userRepo, nil, service.CurrencyOptions{Base: "USD", Supported: []string{"EUR"}})
+			orderService := service.NewOrderService(orderRepo, productRepo, txManager, webhooks, events, currencies, service.NewTaxService(nil), nil, nil, nil, nil, nil, nil, nil, service.OrderOptions{})
+			resp, err := orderService.CreateOrder(context.Background(), &service.OrderCreateReq{
+				UserID:   1,
+				Currency: tt.currency,
+				Items:    []service.OrderItemReq{{SKUID: 101, Quantity: 2}},
+			})
+			if tt.errIs != nil {
+				assert.ErrorIs(t, err, tt.errIs)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "EUR", resp.Currency)
+			assert.Equal(t, tt.wantTotal, resp.TotalAmount.Amount().String())
+			assert.Equal(t, "EUR", resp.TotalAmount.Currency())
+		})
+	}
+}
+
+func TestOrderService_CreateOrderTax(t *testing.T) {
+	vat := []model.OrderTaxLine{{Name: "VAT", Rate: decimal.RequireFromString("0.19"), Amount: decimal.NewFromInt(19)}}
+
+	tests := []struct {
+		name      string
+		region    string
+		taxLines  []model.OrderTaxLine
+		taxErr    error
+		wantTotal string
+		errIs     error
+	}{
+		{name: "Taxed", region: "de", taxLines: vat, wantTotal: "119"},
+		{name: "RegionRequired", taxErr: service.ErrTaxRegionRequired, errIs: service.ErrTaxRegionRequired},
+		{name: "TaxUnavailable", region: "DE", taxErr: fmt.Errorf("%w: timeout", service.ErrTaxUnavailable), errIs: service.ErrTaxUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			webhooks := mocks.NewMockWebhookEmitter(ctrl)
+			events := mocks.NewMockEventPublisher(ctrl)
+			events.EXPECT().Publish(gomock.Any(), service.EventOrderCreated, gomock.Any()).Return(nil).AnyTimes()
+			taxes := mocks.NewMockTaxService(ctrl)
+
+			productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return([]model.SKU{{Price: decimal.NewFromInt(50), Currency: "USD", Stock: 100}}, nil)
+			taxes.EXPECT().Calculate(gomock.Any(), strings.ToUpper(tt.region), "USD", gomock.Len(1)).Return(tt.taxLines, tt.taxErr)
+			if tt.errIs == nil {
+				txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), -2).Return(nil)
+				productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(101)).Return(&model.SKU{Stock: 98}, nil)
+				orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, order *model.Order, _ []model.OrderItem) error {
+					assert.Equal(t, "DE", order.Region)
+					assert.Equal(t, "19", order.TaxAmount.String())
+					assert.Equal(t, tt.wantTotal, order.TotalAmount.String())
+					assert.Equal(t, tt.taxLines, order.TaxLines)
+					return nil
+				})
+				webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderCreated, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, data any) error {
+					order := data.(service.OrderWebhookData)
+					assert.Equal(t, "19.00 USD", order.TaxAmount.String())
+					assert.Equal(t, []service.TaxLine{{Name: "VAT", Rate: decimal.RequireFromString("0.19"), Amount: money.New(decimal.NewFromInt(19), "USD")}}, order.TaxLines)
+					return nil
+				})
+			}
+
+			currencies := service.NewCurrencyService(nil, nil, nil, service.CurrencyOptions{Base: "USD"})
+			orderService := service.NewOrderService(orderRepo, productRepo, txManager, webhooks, events, currencies, taxes, nil, nil, nil, nil, nil, nil, nil, service.OrderOptions{})
+			resp, err := orderService.CreateOrder(context.Background(), &service.OrderCreateReq{
+				Currency: "USD",
+				Region:   tt.region,
+				Items:    []service.OrderItemReq{{SKUID: 101, Quantity: 2}},
+			})
+			if tt.errIs != nil {
+				assert.ErrorIs(t, err, tt.errIs)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "100.00 USD", resp.Subtotal.String())
+			assert.Equal(t, "19.00 USD", resp.TaxAmount.String())
+			assert.Equal(t, tt.wantTotal, resp.TotalAmount.Amount().String())
+			require.Len(t, resp.TaxLines, 1)
+			assert.Equal(t, "VAT", resp.TaxLines[0].Name)
+		})
+	}
+}
+
+func TestOrderService_CreateOrderGroupPrices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	orderRepo := mocks.NewMockOrderRepository(ctrl)
+	productRepo := mocks.NewMockProductRepository(ctrl)
+	txManager := mocks.NewMockTransactionManager(ctrl)
+	webhooks := mocks.NewMockWebhookEmitter(ctrl)
+	events := mocks.NewMockEventPublisher(ctrl)
+	events.EXPECT().Publish(gomock.Any(), service.EventOrderCreated, gomock.Any()).Return(nil).AnyTimes()
+	groups := mocks.NewMockCustomerGroupService(ctrl)
+
+	productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return([]model.SKU{{Price: decimal.NewFromInt(50), Currency: "USD", Stock: 100}}, nil)
+	groups.EXPECT().Prices(gomock.Any(), uint64(7), []uint64{101}).Return(map[uint64]decimal.Decimal{101: decimal.NewFromInt(40)}, nil)
+	txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+	productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), -2).Return(nil)
+	productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(101)).Return(&model.SKU{Stock: 98}, nil)
+	orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *model.Order, items []model.OrderItem) error {
+		assert.Equal(t, "40", items[0].Price.String(), "the wholesale price is charged")
+		return nil
+	})
+	webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderCreated, gomock.Any()).Return(nil)
+
+	currencies := service.NewCurrencyService(nil, nil, nil, service.CurrencyOptions{Base: "USD"})
+	orderService := service.NewOrderService(orderRepo, productRepo, txManager, webhooks, events, currencies, service.NewTaxService(nil), nil, nil, nil, nil, groups, nil, nil, service.OrderOptions{})
+	resp, err := orderService.CreateOrder(context.Background(), &service.OrderCreateReq{
+		UserID:   7,
+		Currency: "USD",
+		Items:    []service.OrderItemReq{{SKUID: 101, Quantity: 2}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "80.00 USD", resp.TotalAmount.String())
+}
+
+func TestOrderService_CreateOrderShippingAddress(t *testing.T) {
+	given := model.Address{Name: "Jane Doe", Line1: "10 Downing St", City: "London", PostalCode: "sw1a2aa"}
+	normalized := model.Address{Name: "Jane Doe", Line1: "10 Downing St", City: "London", PostalCode: "SW1A 2AA"}
+
+	t.Run("Normalized", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		orderRepo := mocks.NewMockOrderRepository(ctrl)
+		productRepo := mocks.NewMockProductRepository(ctrl)
+		txManager := mocks.NewMockTransactionManager(ctrl)
+		webhooks := mocks.NewMockWebhookEmitter(ctrl)
+		events := mocks.NewMockEventPublisher(ctrl)
+		events.EXPECT().Publish(gomock.Any(), service.EventOrderCreated, gomock.Any()).Return(nil).AnyTimes()
+		addresses := mocks.NewMockAddressService(ctrl)
+
+		productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return([]model.SKU{{Price: decimal.NewFromInt(50), Currency: "USD", Stock: 100}}, nil)
+		addresses.EXPECT().Validate(gomock.Any(), "GB", given).Return(&service.AddressValidationResp{
+			Address:  normalized,
+			Warnings: []service.AddressWarning{{Code: "undeliverable", Message: "nothing is known to be delivered to this address"}},
+		}, nil)
+		txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+		productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), -1).Return(nil)
+		productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(101)).Return(&model.SKU{Stock: 99}, nil)
+		orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, order *model.Order, _ []model.OrderItem) error {
+			assert.Equal(t, normalized, order.ShippingAddress)
+			return nil
+		})
+		webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderCreated, gomock.Any()).Return(nil)
+
+		currencies := service.NewCurrencyService(nil, nil, nil, service.CurrencyOptions{Base: "USD"})
+		orderService := service.NewOrderService(orderRepo, productRepo, txManager, webhooks, events, currencies, service.NewTaxService(nil), nil, nil, nil, nil, nil, addresses, nil, service.OrderOptions{})
+		resp, err := orderService.CreateOrder(context.Background(), &service.OrderCreateReq{
+			Currency:        "USD",
+			Region:          "GB",
+			ShippingAddress: given,
+			Items:           []service.OrderItemReq{{SKUID: 101, Quantity: 1}},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.AddressWarnings, 1)
+		assert.Equal(t, "undeliverable", resp.AddressWarnings[0].Code)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		productRepo := mocks.NewMockProductRepository(ctrl)
+		productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return([]model.SKU{{Price: decimal.NewFromInt(50), Currency: "USD", Stock: 100}}, nil)
+
+		currencies := service.NewCurrencyService(nil, nil, nil, service.CurrencyOptions{Base: "USD"})
+		orderService := service.NewOrderService(mocks.NewMockOrderRepository(ctrl), productRepo, mocks.NewMockTransactionManager(ctrl), mocks.NewMockWebhookEmitter(ctrl), mocks.NewMockEventPublisher(ctrl),
+			currencies, service.NewTaxService(nil), nil, nil, nil, nil, nil, service.NewAddressService(nil), nil, service.OrderOptions{})
+		_, err := orderService.CreateOrder(context.Background(), &service.OrderCreateReq{
+			Currency:        "USD",
+			Region:          "GB",
+			ShippingAddress: model.Address{Line1: "10 Downing St", City: "London", PostalCode: "12345"},
+			Items:           []service.OrderItemReq{{SKUID: 101, Quantity: 1}},
+		})
+		assert.ErrorIs(t, err, service.ErrInvalidAddress)
+	})
+}
+
+func TestOrderService_CreateOrderShippingRestricted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	productRepo := mocks.NewMockProductRepository(ctrl)
+	restrictions := mocks.NewMockShippingRestrictionService(ctrl)
+	productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return([]model.SKU{{Price: decimal.NewFromInt(50), Currency: "USD", Stock: 100}}, nil)
+	restricted := service.ErrShippingRestricted.With("region", "AU").With("items", []service.RestrictedItem{{SKUID: 101, Reason: "Aerosols cannot be shipped there"}})
+	restrictions.EXPECT().Check(gomock.Any(), "AU", gomock.Len(1)).Return(restricted)
+
+	// Refused before any stock is taken
+	currencies := service.NewCurrencyService(nil, nil, nil, service.CurrencyOptions{Base: "USD"})
+	orderService := service.NewOrderService(mocks.NewMockOrderRepository(ctrl), productRepo, mocks.NewMockTransactionManager(ctrl), mocks.NewMockWebhookEmitter(ctrl), mocks.NewMockEventPublisher(ctrl),
+		currencies, service.NewTaxService(nil), nil, nil, nil, nil, nil, nil, restrictions, service.OrderOptions{})
+	_, err := orderService.CreateOrder(context.Background(), &service.OrderCreateReq{
+		Currency: "USD",
+		Region:   "au",
+		Items:    []service.OrderItemReq{{SKUID: 101, Quantity: 1}},
+	})
+	assert.ErrorIs(t, err, service.ErrShippingRestricted)
+}
+
+func TestOrderService_CreateOrderBundle(t *testing.T) {
+	require.NoError(t, snowflake.Init(1)) // Bundle lines are given their ID up front
+	bundle := model.SKU{Base: model.Base{ID: 301}, Price: decimal.NewFromInt(45), Currency: "USD", Delivery: model.SKUDeliveryBundle}
+	components := []model.SKUComponent{{BundleSKUID: 301, ComponentSKUID: 11, Quantity: 2}, {BundleSKUID: 301, ComponentSKUID: 12, Quantity: 1}}
+
+	tests := []struct {
+		name       string
+		stock      int // Of SKU 12
+		mockSetup  func(orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository, txManager *mocks.MockTransactionManager, webhooks *mocks.MockWebhookEmitter)
+		wantErrSKU uint64
+	}{
+		{
+			name:  "Placed",
+			stock: 10,
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository, txManager *mocks.MockTransactionManager, webhooks *mocks.MockWebhookEmitter) {
+				txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+				// The components' stock is taken, in the order's transaction, not the bundle's
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(11), -6).Return(nil)
+				productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(11)).Return(&model.SKU{Stock: 94}, nil)
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(12), -3).Return(nil)
+				productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(12)).Return(&model.SKU{Stock: 7}, nil)
+				orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *model.Order, items []model.OrderItem) error {
+					require.Len(t, items, 3)
+					assert.Equal(t, uint64(301), items[0].SKUID)
+					assert.Equal(t, "45", items[0].Price.String(), "the bundle's line carries its price")
+					require.NotZero(t, items[0].ID)
+					for _, item := range items[1:] {
+						require.NotNil(t, item.BundleItemID)
+						assert.Equal(t, items[0].ID, *item.BundleItemID)
+						assert.True(t, item.Price.IsZero())
+					}
+					assert.Equal(t, []int{6, 3}, []int{items[1].Quantity, items[2].Quantity})
+					return nil
+				})
+				webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderCreated, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, data any) error {
+					items := data.(service.OrderWebhookData).Items
+					assert.Zero(t, items[0].BundleSKUID)
+					assert.Equal(t, uint64(301), items[1].BundleSKUID)
+					return nil
+				})
+			},
+		},
+		{
+			name:  "ComponentShort",
+			stock: 2,
+			mockSetup: func(*mocks.MockOrderRepository, *mocks.MockProductRepository, *mocks.MockTransactionManager, *mocks.MockWebhookEmitter) {
+			},
+			wantErrSKU: 301,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			webhooks := mocks.NewMockWebhookEmitter(ctrl)
+			events := mocks.NewMockEventPublisher(ctrl)
+			events.EXPECT().Publish(gomock.Any(), service.EventOrderCreated, gomock.Any()).Return(nil).AnyTimes()
+
+			productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{301}).Return([]model.SKU{bundle}, nil)
+			productRepo.EXPECT().ListSKUComponents(gomock.Any(), []uint64{301}).Return(components, nil)
+			productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{11, 12}).Return([]model.SKU{
+				{Base: model.Base{ID: 11}, Stock: 100},
+				{Base: model.Base{ID: 12}, Stock: tt.stock},
+			}, nil)
+			tt.mockSetup(orderRepo, productRepo, txManager, webhooks)
+
+			currencies := service.NewCurrencyService(nil, nil, nil, service.CurrencyOptions{Base: "USD"})
+			orderService := service.NewOrderService(orderRepo, productRepo, txManager, webhooks, events, currencies, service.NewTaxService(nil), nil, nil, nil, nil, nil, nil, nil, service.OrderOptions{})
+			resp, err := orderService.CreateOrder(context.Background(), &service.OrderCreateReq{
+				UserID:   7,
+				Currency: "USD",
+				Items:    []service.OrderItemReq{{SKUID: 301, Quantity: 3}},
+			})
+			if tt.wantErrSKU != 0 {
+				var appErr *apperr.Error
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, apperr.CodeStockInsufficient, appErr.Code)
+				assert.Equal(t, tt.wantErrSKU, appErr.Meta["sku_id"], "the bundle ordered is named")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "135.00 USD", resp.TotalAmount.String())
+		})
+	}
+}
+
+func TestOrderService_CreateOrderOptions(t *testing.T) {
+	schema := []model.ProductOption{
+		{SPUID: 9, Code: "engraving", Name: "Engraving", Kind: model.ProductOptionText, MaxLength: 10, Price: decimal.NewFromInt(5), Currency: "USD"},
+		{SPUID: 9, Code: "warranty", Name: "Extended warranty", Kind: model.ProductOptionFlag, Price: decimal.NewFromInt(18), Currency: "EUR"},
+	}
+
+	tests := []struct {
+		name    string
+		options []service.OrderItemOptionReq
+		errStr  string
+	}{
+		{
+			name:    "Priced",
+			options: []service.OrderItemOptionReq{{Code: "engraving", Value: "For Ann"}, {Code: "warranty"}},
+		},
+		{name: "UnknownOption", options: []service.OrderItemOptionReq{{Code: "gift_wrap"}}, errStr: `"gift_wrap" is not an option of SKU 101`},
+		{name: "TextTooLong", options: []service.OrderItemOptionReq{{Code: "engraving", Value: "Happy birthday"}}, errStr: "at most 10 characters"},
+		{name: "FlagWithValue", options: []service.OrderItemOptionReq{{Code: "warranty", Value: "3 years"}}, errStr: "takes no value"},
+		{name: "ChosenTwice", options: []service.OrderItemOptionReq{{Code: "warranty"}, {Code: "warranty"}}, errStr: "chosen twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			webhooks := mocks.NewMockWebhookEmitter(ctrl)
+			events := mocks.NewMockEventPublisher(ctrl)
+			events.EXPECT().Publish(gomock.Any(), service.EventOrderCreated, gomock.Any()).Return(nil).AnyTimes()
+			rateRepo := mocks.NewMockExchangeRateRepository(ctrl)
+			rateRepo.EXPECT().ListByBase(gomock.Any(), "USD").Return([]model.ExchangeRate{
+				{Base: model.Base{UpdatedAt: time.Now()}, BaseCurrency: "USD", Currency: "EUR", Rate: decimal.RequireFromString("0.9")},
+			}, nil).AnyTimes()
+
+			productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return([]model.SKU{{Base: model.Base{ID: 101}, SPUID: 9, Price: decimal.NewFromInt(50), Currency: "USD", Stock: 100}}, nil)
+			productRepo.EXPECT().ListProductOptions(gomock.Any(), []uint64{9}).Return(schema, nil)
+			if tt.errStr == "" {
+				txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), -2).Return(nil)
+				productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(101)).Return(&model.SKU{Stock: 98}, nil)
+				orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *model.Order, items []model.OrderItem) error {
+					assert.Equal(t, "75", items[0].Price.String(), "50 USD, 5 USD engraving and 18 EUR warranty")
+					require.Len(t, items[0].Options, 2)
+					assert.Equal(t, model.OrderItemOption{Code: "engraving", Name: "Engraving", Value: "For Ann", Price: decimal.NewFromInt(5)}, items[0].Options[0])
+					assert.Equal(t, "20", items[0].Options[1].Price.String())
+					return nil
+				})
+				webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderCreated, gomock.Any()).Return(nil)
+			}
+
+			currencies := service.NewCurrencyService(rateRepo, nil, nil, service.CurrencyOptions{Base: "USD", Supported: []string{"EUR"}})
+			orderService := service.NewOrderService(orderRepo, productRepo, txManager, webhooks, events, currencies, service.NewTaxService(nil), nil, nil, nil, nil, nil, nil, nil, service.OrderOptions{})
+			resp, err := orderService.CreateOrder(context.Background(), &service.OrderCreateReq{
+				UserID:   7,
+				Currency: "USD",
+				Items:    []service.OrderItemReq{{SKUID: 101, Quantity: 2, Options: tt.options}},
+			})
+			if tt.errStr != "" {
+				assert.ErrorIs(t, err, service.ErrInvalidProductOption)
+				assert.ErrorContains(t, err, tt.errStr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "150.00 USD", resp.TotalAmount.String())
+		})
+	}
+}
+
+func TestOrderService_CreateOrderQuotedPrices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	orderRepo := mocks.NewMockOrderRepository(ctrl)
+	productRepo := mocks.NewMockProductRepository(ctrl)
+	txManager := mocks.NewMockTransactionManager(ctrl)
+	webhooks := mocks.NewMockWebhookEmitter(ctrl)
+	events := mocks.NewMockEventPublisher(ctrl)
+	events.EXPECT().Publish(gomock.Any(), service.EventOrderCreated, gomock.Any()).Return(nil).AnyTimes()
+	groups := mocks.NewMockCustomerGroupService(ctrl) // Not asked: quoted prices replace group prices
+
+	// Priced in EUR, but quoted in USD: no rates are needed
+	productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return([]model.SKU{{Price: decimal.NewFromInt(50), Currency: "EUR", Stock: 1000}}, nil)
+	txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+	productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), -500).Return(nil)
+	productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(101)).Return(&model.SKU{Stock: 500}, nil)
+	orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *model.Order, items []model.OrderItem) error {
+		assert.Equal(t, "8.5", items[0].Price.String(), "the quoted price is charged")
+		return nil
+	})
+	webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderCreated, gomock.Any()).Return(nil)
+
+	currencies := service.NewCurrencyService(nil, nil, nil, service.CurrencyOptions{Base: "USD", Supported: []string{"EUR"}})
+	orderService := service.NewOrderService(orderRepo, productRepo, txManager, webhooks, events, currencies, service.NewTaxService(nil), nil, nil, nil, nil, groups, nil, nil, service.OrderOptions{})
+	resp, err := orderService.CreateOrder(context.Background(), &service.OrderCreateReq{
+		UserID:       7,
+		Currency:     "USD",
+		Items:        []service.OrderItemReq{{SKUID: 101, Quantity: 500}},
+		QuotedPrices: map[uint64]decimal.Decimal{101: decimal.RequireFromString("8.50")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "4250.00 USD", resp.TotalAmount.String())
+}
+
+func TestOrderService_CreateOrderPessimisticLocking(t *testing.T) {
+	items := []service.OrderItemReq{{SKUID: 102, Quantity: 1}, {SKUID: 101, Quantity: 2}, {SKUID: 102, Quantity: 1}}
+	skus := []model.SKU{
+		{Base: model.Base{ID: 102}, Price: decimal.NewFromInt(5), Currency: "USD", Stock: 100},
+		{Base: model.Base{ID: 101}, Price: decimal.NewFromInt(50), Currency: "USD", Stock: 11},
+		{Base: model.Base{ID: 102}, Price: decimal.NewFromInt(5), Currency: "USD", Stock: 100},
+	}
+
+	tests := []struct {
+		name      string
+		mockSetup func(orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository, webhooks *mocks.MockWebhookEmitter)
+		errStr    string
+	}{
+		{
+			name: "LocksInIDOrder",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository, webhooks *mocks.MockWebhookEmitter) {
+				gomock.InOrder(
+					productRepo.EXPECT().GetSKUForUpdate(gomock.Any(), uint64(101)).Return(&model.SKU{Stock: 11}, nil),
+					productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), -2).Return(nil),
+					// 11 -> 9 crosses the default threshold of 10; no read back needed.
+					webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventStockLow, service.StockLowWebhookData{SKUID: 101, Stock: 9, Threshold: 10}).Return(nil),
+					// Both lines of SKU 102 are deducted together
+					productRepo.EXPECT().GetSKUForUpdate(gomock.Any(), uint64(102)).Return(&model.SKU{Stock: 100}, nil),
+					productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(102), -2).Return(nil),
+				)
+				orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Len(3)).Return(nil)
+				webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderCreated, gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "StockTakenWhileWaitingForLock",
+			mockSetup: func(_ *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository, _ *mocks.MockWebhookEmitter) {
+				productRepo.EXPECT().GetSKUForUpdate(gomock.Any(), uint64(101)).Return(&model.SKU{Stock: 1}, nil)
+			},
+			errStr: "insufficient stock (sku_id=101)",
+		},
+		{
+			name: "LockFailure",
+			mockSetup: func(_ *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository, _ *mocks.MockWebhookEmitter) {
+				productRepo.EXPECT().GetSKUForUpdate(gomock.Any(), uint64(101)).Return(nil, errors.New("lock timeout"))
+			},
+			errStr: "failed to lock SKU 101",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			webhooks := mocks.NewMockWebhookEmitter(ctrl)
+			events := mocks.NewMockEventPublisher(ctrl)
+			events.EXPECT().Publish(gomock.Any(), service.EventOrderCreated, gomock.Any()).Return(nil).AnyTimes()
+
+			productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{102, 101, 102}).Return(skus, nil)
+			txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			})
+			tt.mockSetup(orderRepo, productRepo, webhooks)
+
+			currencies := service.NewCurrencyService(nil, nil, nil, service.CurrencyOptions{Base: "USD"})
+			orderService := service.NewOrderService(orderRepo, productRepo, txManager, webhooks, events, currencies, service.NewTaxService(nil), nil, nil, nil, nil, nil, nil, nil, service.OrderOptions{StockLocking: service.StockLockingPessimistic})
+			resp, err := orderService.CreateOrder(context.Background(), &service.OrderCreateReq{Currency: "USD", Items: items})
+			if tt.errStr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errStr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "110.00 USD", resp.TotalAmount.String())
+		})
+	}
+}
+
+func TestOrderService_CreateOrderSavedPaymentMethod(t *testing.T) {
+	method := &model.PaymentMethod{Base: model.Base{ID: 9}, UserID: 1, Provider: "stripe", CustomerRef: "cus_1", MethodRef: "pm_1"}
+
+	tests := []struct {
+		name             string
+		paymentsDisabled bool
+		paymentCapture   string
+		delivery         string
+		mockSetup        func(payments *mocks.MockPaymentMethodService, orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository, webhooks *mocks.MockWebhookEmitter)
+		wantStatus       string
+		wantPaymentError string
+		wantSaga         string
+		errIs            error
+	}{
+		{
+			name: "Charged",
+			mockSetup: func(payments *mocks.MockPaymentMethodService, orderRepo *mocks.MockOrderRepository, _ *mocks.MockProductRepository, webhooks *mocks.MockWebhookEmitter) {
+				payments.EXPECT().Charge(gomock.Any(), method, gomock.Any()).DoAndReturn(func(_ context.Context, _ *model.PaymentMethod, order *model.Order) (*payment.Charge, error) {
+					assert.Equal(t, "100", order.TotalAmount.String())
+					assert.Equal(t, model.OrderStatusPending, order.Status)
+					return &payment.Charge{ID: "pi_1"}, nil
+				})
+				orderRepo.EXPECT().MarkPaid(gomock.Any(), uint64(0), "stripe", "pi_1").Return(nil)
+				webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderPaid, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, data any) error {
+					assert.Equal(t, model.OrderStatusPaid, data.(service.OrderWebhookData).Status)
+					return nil
+				})
+			},
+			wantStatus: model.OrderStatusPaid,
+			wantSaga:   model.SagaStatusCompleted,
+		},
+		{
+			name:           "AuthorizedForShipment",
+			paymentCapture: service.PaymentCaptureShipment,
+			mockSetup: func(payments *mocks.MockPaymentMethodService, orderRepo *mocks.MockOrderRepository, _ *mocks.MockProductRepository, _ *mocks.MockWebhookEmitter) {
+				payments.EXPECT().Authorize(gomock.Any(), method, gomock.Any()).Return(&payment.Charge{ID: "pi_1"}, nil)
+				orderRepo.EXPECT().MarkAuthorized(gomock.Any(), uint64(0), "stripe", "pi_1").Return(nil)
+				// order.paid waits for the last capture
+			},
+			wantStatus: model.OrderStatusAuthorized,
+			wantSaga:   model.SagaStatusCompleted,
+		},
+		{
+			name:           "ChargedForDigitalGoods",
+			paymentCapture: service.PaymentCaptureShipment,
+			delivery:       model.SKUDeliveryLicenseKey,
+			mockSetup: func(payments *mocks.MockPaymentMethodService, orderRepo *mocks.MockOrderRepository, _ *mocks.MockProductRepository, webhooks *mocks.MockWebhookEmitter) {
+				// Nothing ships, so there is no shipment to capture on
+				payments.EXPECT().Charge(gomock.Any(), method, gomock.Any()).Return(&payment.Charge{ID: "pi_1"}, nil)
+				orderRepo.EXPECT().MarkPaid(gomock.Any(), uint64(0), "stripe", "pi_1").Return(nil)
+				webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderPaid, gomock.Any()).Return(nil)
+			},
+			wantStatus: model.OrderStatusPaid,
+			wantSaga:   model.SagaStatusCompleted,
+		},
+		{
+			name: "Declined",
+			mockSetup: func(payments *mocks.MockPaymentMethodService, orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository, _ *mocks.MockWebhookEmitter) {
+				payments.EXPECT().Charge(gomock.Any(), method, gomock.Any()).Return(nil, fmt.Errorf("%w: insufficient funds", service.ErrPaymentDeclined))
+				// The stock is given back
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(0), model.OrderStatusPending, model.OrderStatusCancelled).Return(nil)
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), 2).Return(nil)
+			},
+			wantStatus:       model.OrderStatusCancelled,
+			wantPaymentError: "payment declined",
+			wantSaga:         model.SagaStatusCompensated,
+		},
+		{
+			name: "ProviderUnavailable",
+			mockSetup: func(payments *mocks.MockPaymentMethodService, _ *mocks.MockOrderRepository, _ *mocks.MockProductRepository, _ *mocks.MockWebhookEmitter) {
+				payments.EXPECT().Charge(gomock.Any(), method, gomock.Any()).Return(nil, errors.New("stripe returned status 503"))
+			},
+			wantStatus:       model.OrderStatusPending,
+			wantPaymentError: "payment failed",
+			wantSaga:         model.SagaStatusRunning, // Retried by ResumeCheckoutSagas
+		},
+		{
+			name: "CancelledWhileCharging",
+			mockSetup: func(payments *mocks.MockPaymentMethodService, orderRepo *mocks.MockOrderRepository, _ *mocks.MockProductRepository, _ *mocks.MockWebhookEmitter) {
+				payments.EXPECT().Charge(gomock.Any(), method, gomock.Any()).Return(&payment.Charge{ID: "pi_1"}, nil)
+				orderRepo.EXPECT().MarkPaid(gomock.Any(), gomock.Any(), "stripe", "pi_1").Return(repository.ErrOrderStatusChanged)
+				payments.EXPECT().Refund(gomock.Any(), gomock.Any(), "pi_1").Return(nil)
+				// Cancelling the order released its stock already
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(0), model.OrderStatusPending, model.OrderStatusCancelled).Return(repository.ErrOrderStatusChanged)
+			},
+			wantStatus:       model.OrderStatusPending,
+			wantPaymentError: "payment failed",
+			wantSaga:         model.SagaStatusCompensated,
+		},
+		{
+			name:             "PaymentsDisabled",
+			paymentsDisabled: true,
+			errIs:            service.ErrPaymentMethodNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			webhooks := mocks.NewMockWebhookEmitter(ctrl)
+			events := mocks.NewMockEventPublisher(ctrl)
+			events.EXPECT().Publish(gomock.Any(), service.EventOrderCreated, gomock.Any()).Return(nil).AnyTimes()
+			events.EXPECT().Publish(gomock.Any(), service.EventOrderPaid, gomock.Any()).Return(nil).AnyTimes()
+			events.EXPECT().Publish(gomock.Any(), service.EventOrderCancelled, gomock.Any()).Return(nil).AnyTimes()
+			authorized := 0
+			if tt.wantStatus == model.OrderStatusAuthorized {
+				authorized = 1
+			}
+			events.EXPECT().Publish(gomock.Any(), service.EventOrderUpdated, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, data any) error {
+				assert.Equal(t, model.OrderStatusAuthorized, data.(service.OrderWebhookData).Status)
+				return nil
+			}).Times(authorized)
+			payments := mocks.NewMockPaymentMethodService(ctrl)
+			sagas := mocks.NewMockSagaRepository(ctrl)
+			var saga model.SagaState
+
+			if tt.errIs == nil {
+				payments.EXPECT().Get(gomock.Any(), uint64(1), uint64(9)).Return(method, nil)
+				productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return([]model.SKU{{Base: model.Base{ID: 101}, Price: decimal.NewFromInt(50), Currency: "USD", Stock: 100, Delivery: tt.delivery}}, nil)
+				txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				}).AnyTimes()
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), -2).Return(nil)
+				productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(101)).Return(&model.SKU{Stock: 98}, nil)
+				orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderCreated, gomock.Any()).Return(nil)
+				// The saga starts with the stock reserved
+				sagas.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, s *model.SagaState) error {
+					assert.Equal(t, model.SagaTypeCheckout, s.Type)
+					assert.Equal(t, uint64(9), s.PaymentMethodID)
+					assert.Equal(t, model.SagaStepChargePayment, s.Step)
+					assert.Equal(t, model.SagaStatusRunning, s.Status)
+					saga = *s
+					return nil
+				})
+				sagas.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, s *model.SagaState) error {
+					saga = *s
+					return nil
+				}).AnyTimes()
+				tt.mockSetup(payments, orderRepo, productRepo, webhooks)
+			}
+
+			var paymentMethods service.PaymentMethodService = payments
+			if tt.paymentsDisabled {
+				paymentMethods = nil
+			}
+			currencies := service.NewCurrencyService(nil, nil, nil, service.CurrencyOptions{Base: "USD"})
+			orderService := service.NewOrderService(orderRepo, productRepo, txManager, webhooks, events, currencies, service.NewTaxService(nil), nil, paymentMethods, sagas, nil, nil, nil, nil, service.OrderOptions{PaymentCapture: tt.paymentCapture})
+			resp, err := orderService.CreateOrder(context.Background(), &service.OrderCreateReq{
+				UserID:          1,
+				Currency:        "USD",
+				Items:           []service.OrderItemReq{{SKUID: 101, Quantity: 2}},
+				PaymentMethodID: 9,
+			})
+			if tt.errIs != nil {
+				assert.ErrorIs(t, err, tt.errIs)
+				return
+			}
+			require.NoError(t, err, "the order is placed whether or not payment succeeds")
+			assert.Equal(t, tt.wantStatus, resp.Status)
+			assert.Equal(t, tt.wantPaymentError, resp.PaymentError)
+			assert.Equal(t, tt.wantSaga, saga.Status)
+		})
+	}
+}
+
+func TestOrderService_CreateOrderPromotions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	orderRepo := mocks.NewMockOrderRepository(ctrl)
+	productRepo := mocks.NewMockProductRepository(ctrl)
+	txManager := mocks.NewMockTransactionManager(ctrl)
+	webhooks := mocks.NewMockWebhookEmitter(ctrl)
+	events := mocks.NewMockEventPublisher(ctrl)
+	events.EXPECT().Publish(gomock.Any(), service.EventOrderCreated, gomock.Any()).Return(nil).AnyTimes()
+	promotions := mocks.NewMockPromotionService(ctrl)
+
+	skus := []model.SKU{{Base: model.Base{ID: 101}, SPUID: 11, Price: decimal.NewFromInt(50), Currency: "USD", Stock: 100}}
+	productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return(skus, nil)
+	promotions.EXPECT().Apply(gomock.Any(), "USD", "SAVE-7KQ2M9XD", gomock.Len(1), skus).DoAndReturn(func(_ context.Context, _, code string, items []model.OrderItem, _ []model.SKU) ([]model.OrderPromotion, error) {
+		items[0].Discount = decimal.NewFromInt(10)
+		return []model.OrderPromotion{{PromotionID: 3, Name: "Spend more", Amount: decimal.NewFromInt(10), Detail: "10% off for spending 100.00, at least 100.00", CouponCode: code}}, nil
+	})
+	txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+	productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), -2).Return(nil)
+	productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(101)).Return(&model.SKU{Stock: 98}, nil)
+	orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, order *model.Order, items []model.OrderItem) error {
+		assert.Equal(t, "10", order.DiscountAmount.String())
+		assert.Equal(t, "99", order.TotalAmount.String())
+		assert.Len(t, order.Promotions, 1)
+		assert.Equal(t, "10", items[0].Discount.String())
+		order.ID = 77
+		return nil
+	})
+	// The coupon is redeemed in the order's transaction
+	promotions.EXPECT().RedeemCoupons(gomock.Any(), gomock.Len(1), uint64(77), uint64(5)).Return(nil)
+	webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderCreated, gomock.Any()).Return(nil)
+
+	// Tax is charged on what is left after the discount
+	taxes := service.NewTaxService(tax.NewFlat("VAT", decimal.RequireFromString("0.1")))
+	currencies := service.NewCurrencyService(nil, nil, nil, service.CurrencyOptions{Base: "USD"})
+	orderService := service.NewOrderService(orderRepo, productRepo, txManager, webhooks, events, currencies, taxes, promotions, nil, nil, nil, nil, nil, nil, service.OrderOptions{})
+	resp, err := orderService.CreateOrder(context.Background(), &service.OrderCreateReq{
+		UserID:     5,
+		Currency:   "USD",
+		Items:      []service.OrderItemReq{{SKUID: 101, Quantity: 2}},
+		CouponCode: "SAVE-7KQ2M9XD",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "100.00 USD", resp.Subtotal.String())
+	assert.Equal(t, "10.00 USD", resp.Discount.String())
+	assert.Equal(t, "9.00 USD", resp.TaxAmount.String())
+	assert.Equal(t, "99.00 USD", resp.TotalAmount.String())
+	require.Len(t, resp.Promotions, 1)
+	assert.Equal(t, uint64(3), resp.Promotions[0].PromotionID)
+	assert.NotEmpty(t, resp.Promotions[0].Detail)
+}
+
+func TestOrderService_CancelExpiredOrders(t *testing.T) {
+	deadline := time.Now().Add(-30 * time.Minute)
+	order := func(id uint64, skuID uint64, qty int) model.Order {
+		o := model.Order{Status: model.OrderStatusPending, Items: []model.OrderItem{{SKUID: skuID, Quantity: qty}}}
+		o.ID = id
+		return o
+	}
+
+	tests := []struct {
+		name          string
+		batchSize     int
+		mockSetup     func(orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository)
+		wantCancelled int
+		errStr        string
+	}{
+		{
+			name:      "PagesThroughBatchesAndRestoresStock",
+			batchSize: 2,
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository) {
+				gomock.InOrder(
+					orderRepo.EXPECT().ListPendingBefore(gomock.Any(), deadline, uint64(0), 2).Return([]model.Order{order(1, 101, 2), order(2, 102, 1)}, nil),
+					orderRepo.EXPECT().ListPendingBefore(gomock.Any(), deadline, uint64(2), 2).Return([]model.Order{order(3, 101, 5)}, nil),
+				)
+				for _, id := range []uint64{1, 2, 3} {
+					orderRepo.EXPECT().UpdateStatus(gomock.Any(), id, model.OrderStatusPending, model.OrderStatusCancelled).Return(nil)
+				}
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), 2).Return(nil)
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(102), 1).Return(nil)
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), 5).Return(nil)
+			},
+			wantCancelled: 3,
+		},
+		{
+			name:      "PaidMeanwhileIsSkipped",
+			batchSize: 10,
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository) {
+				orderRepo.EXPECT().ListPendingBefore(gomock.Any(), deadline, uint64(0), 10).Return([]model.Order{order(1, 101, 2)}, nil)
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(1), model.OrderStatusPending, model.OrderStatusCancelled).Return(repository.ErrOrderStatusChanged)
+			},
+			wantCancelled: 0,
+		},
+		{
+			name:      "FailureDoesNotStopOthers",
+			batchSize: 10,
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository) {
+				orderRepo.EXPECT().ListPendingBefore(gomock.Any(), deadline, uint64(0), 10).Return([]model.Order{order(1, 101, 2), order(2, 102, 1)}, nil)
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(1), model.OrderStatusPending, model.OrderStatusCancelled).Return(nil)
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), 2).Return(errors.New("db down"))
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(2), model.OrderStatusPending, model.OrderStatusCancelled).Return(nil)
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(102), 1).Return(nil)
+			},
+			wantCancelled: 1,
+			errStr:        "failed to cancel order 1",
+		},
+		{
+			name: "DefaultBatchSize",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository) {
+				orderRepo.EXPECT().ListPendingBefore(gomock.Any(), deadline, uint64(0), service.DefaultOrderSweepBatchSize).Return(nil, nil)
+			},
+		},
+		{
+			name:      "ListFails",
+			batchSize: 10,
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository) {
+				orderRepo.EXPECT().ListPendingBefore(gomock.Any(), deadline, uint64(0), 10).Return(nil, errors.New("db down"))
+			},
+			errStr: "failed to list expired orders",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+			mockProductRepo := mocks.NewMockProductRepository(ctrl)
+			mockTxManager := mocks.NewMockTransactionManager(ctrl)
+			mockTxManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			}).AnyTimes()
+			tt.mockSetup(mockOrderRepo, mockProductRepo)
+			mockEvents := mocks.NewMockEventPublisher(ctrl)
+			mockEvents.EXPECT().Publish(gomock.Any(), service.EventOrderCancelled, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, data any) error {
+				assert.Equal(t, model.OrderStatusCancelled, data.(service.OrderWebhookData).Status)
+				return nil
+			}).Times(tt.wantCancelled)
+
+			orderService := service.NewOrderService(mockOrderRepo, mockProductRepo, mockTxManager, mocks.NewMockWebhookEmitter(ctrl), mockEvents, nil, nil, nil, nil, nil, nil, nil, nil, nil, service.OrderOptions{})
+			cancelled, err := orderService.CancelExpiredOrders(context.Background(), deadline, tt.batchSize)
+			if tt.errStr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errStr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCancelled, cancelled)
+		})
+	}
+}
+
+func TestOrderService_FailOrder(t *testing.T) {
+	pending := func() *model.Order {
+		o := &model.Order{UserID: 7, OrderNumber: "ORD1", Status: model.OrderStatusPending, Items: []model.OrderItem{{SKUID: 101, Quantity: 2}, {SKUID: 102, Quantity: 1, Backordered: true}}}
+		o.ID = 1
+		return o
+	}
+
+	tests := []struct {
+		name      string
+		restock   bool
+		mockSetup func(orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository)
+		wantEvent bool
+		wantErr   error
+		errStr    string
+	}{
+		{
+			name: "KeepsStock",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository) {
+				orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(1)).Return(pending(), nil)
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(1), model.OrderStatusPending, model.OrderStatusFailed).Return(nil)
+			},
+			wantEvent: true,
+		},
+		{
+			name:    "RestocksReservedItems",
+			restock: true,
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository) {
+				orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(1)).Return(pending(), nil)
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(1), model.OrderStatusPending, model.OrderStatusFailed).Return(nil)
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), 2).Return(nil)
+			},
+			wantEvent: true,
+		},
+		{
+			name: "PaidMeanwhile",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository) {
+				orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(1)).Return(pending(), nil)
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(1), model.OrderStatusPending, model.OrderStatusFailed).Return(repository.ErrOrderStatusChanged)
+			},
+			wantErr: service.ErrOrderNotPending,
+		},
+		{
+			name: "NotFound",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository) {
+				orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(1)).Return(nil, repository.ErrOrderNotFound)
+			},
+			wantErr: service.ErrOrderNotFound,
+		},
+		{
+			name:    "RestockFails",
+			restock: true,
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, productRepo *mocks.MockProductRepository) {
+				orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(1)).Return(pending(), nil)
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(1), model.OrderStatusPending, model.OrderStatusFailed).Return(nil)
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(101), 2).Return(errors.New("db down"))
+			},
+			errStr: "failed to fail order 1: failed to restore stock for SKU 101: db down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+			mockProductRepo := mocks.NewMockProductRepository(ctrl)
+			mockTxManager := mocks.NewMockTransactionManager(ctrl)
+			mockTxManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			})
+			tt.mockSetup(mockOrderRepo, mockProductRepo)
+			mockEvents := mocks.NewMockEventPublisher(ctrl)
+			if tt.wantEvent {
+				mockEvents.EXPECT().Publish(gomock.Any(), service.EventOrderFailed, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, data any) error {
+					assert.Equal(t, model.OrderStatusFailed, data.(service.OrderWebhookData).Status)
+					return nil
+				})
+			}
+
+			orderService := service.NewOrderService(mockOrderRepo, mockProductRepo, mockTxManager, mocks.NewMockWebhookEmitter(ctrl), mockEvents, nil, nil, nil, nil, nil, nil, nil, nil, nil, service.OrderOptions{RestockFailed: tt.restock})
+			order, err := orderService.FailOrder(context.Background(), 1)
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.errStr != "":
+				assert.EqualError(t, err, tt.errStr)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, model.OrderStatusFailed, order.Status)
+				assert.Equal(t, "ORD1", order.OrderNumber)
+			}
+		})
+	}
+}
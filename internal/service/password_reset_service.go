@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/cache"
+	"github.com/proyuen/go-mall/pkg/hasher"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/tenant"
+	"github.com/redis/go-redis/v9"
+)
+
+// Password reset defaults, applied when the configuration leaves them unset.
+const (
+	DefaultPasswordResetTokenTTL    = 30 * time.Minute
+	DefaultPasswordResetLimit       = 3 // Requests per email per window
+	DefaultPasswordResetLimitWindow = time.Hour
+)
+
+var (
+	// ErrPasswordResetRateLimited means a password reset was requested for
+	// an email too often.
+	ErrPasswordResetRateLimited = errors.New("too many password reset requests, try again later")
+	// ErrInvalidResetToken means a password reset token is unknown, expired
+	// or already used.
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+)
+
+// takeResetToken returns the user ID stored under KEYS[1] and deletes it, so
+// that a reset token works once.
+var takeResetToken = redis.NewScript(`
+local userID = redis.call("GET", KEYS[1])
+if userID then
+	redis.call("DEL", KEYS[1])
+end
+return userID
+`)
+
+// PasswordResetOptions configures a PasswordResetService.
+type PasswordResetOptions struct {
+	URL         string        // Storefront page the emailed links open, with the token as ?token=
+	TokenTTL    time.Duration // How long a link works
+	Limit       int           // Requests one email may make per LimitWindow
+	LimitWindow time.Duration // Fixed window, starting at the email's first request
+}
+
+// PasswordResetService lets users who forgot their password set a new one.
+// They are emailed a link with a single-use token, kept in Redis until it
+// expires; requests are rate limited per email.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/password_reset_service_mock.go -package=mocks
+type PasswordResetService interface {
+	// RequestReset queues an email with a reset link to the user with email.
+	// It succeeds for emails without an account too, so that callers cannot
+	// tell which emails have one, and returns ErrPasswordResetRateLimited
+	// once an email asked too often.
+	RequestReset(ctx context.Context, email string) error
+	// ResetPassword sets the password of the user a token was issued to and
+	// ends their sessions, logging them out everywhere. The token stops
+	// working; if setting the password fails, a new link is needed.
+	ResetPassword(ctx context.Context, token, password string) error
+}
+
+type passwordResetService struct {
+	repo     repository.UserRepository
+	hasher   hasher.PasswordHasher
+	cache    cache.Cache
+	sessions SessionStore
+	notifier notification.Notifier
+	opts     PasswordResetOptions
+}
+
+// NewPasswordResetService creates a new PasswordResetService instance. Reset
+// emails are queued through notifier for the worker to send.
+func NewPasswordResetService(repo repository.UserRepository, hasher hasher.PasswordHasher, c cache.Cache, sessions SessionStore, notifier notification.Notifier, opts PasswordResetOptions) PasswordResetService {
+	if opts.TokenTTL <= 0 {
+		opts.TokenTTL = DefaultPasswordResetTokenTTL
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultPasswordResetLimit
+	}
+	if opts.LimitWindow <= 0 {
+		opts.LimitWindow = DefaultPasswordResetLimitWindow
+	}
+	return &passwordResetService{repo: repo, hasher: hasher, cache: c, sessions: sessions, notifier: notifier, opts: opts}
+}
+
+func (s *passwordResetService) RequestReset(ctx context.Context, email string) error {
+	email = strings.TrimSpace(email)
+	if err := s.takeRateLimit(ctx, email); err != nil {
+		return err
+	}
+
+	user, err := s.repo.GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		slog.DebugContext(ctx, "Password reset requested for unknown email")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	token, err := newResetToken()
+	if err != nil {
+		return err
+	}
+	link, err := url.Parse(s.opts.URL)
+	if err != nil {
+		return fmt.Errorf("invalid password reset URL: %w", err)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	if err := s.cache.Set(ctx, resetTokenKey(token), user.ID, s.opts.TokenTTL); err != nil {
+		return fmt.Errorf("failed to store password reset token: %w", err)
+	}
+	data := &notification.PasswordResetData{ResetURL: link.String(), ExpiresInMinutes: max(1, int(s.opts.TokenTTL/time.Minute))}
+	if err := s.notifier.Notify(ctx, user.ID, notification.KindPasswordReset, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *passwordResetService) ResetPassword(ctx context.Context, token, password string) error {
+	if token == "" {
+		return ErrInvalidResetToken
+	}
+	res, err := s.cache.Eval(ctx, takeResetToken, []string{resetTokenKey(token)})
+	if err != nil {
+		return fmt.Errorf("failed to check password reset token: %w", err)
+	}
+	value, ok := res.(string)
+	if !ok {
+		return ErrInvalidResetToken
+	}
+	userID, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		return fmt.Errorf("password hashing failed: %w", err)
+	}
+	if err := s.repo.UpdatePasswordHash(ctx, userID, hashedPassword); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			// Deleted since, or a user of another store
+			return ErrInvalidResetToken
+		}
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	// The password has changed and the token is spent, so failing here would
+	// leave the client unable to retry; the sessions still expire on their own
+	if err := s.sessions.EndAll(ctx, userID); err != nil {
+		slog.ErrorContext(ctx, "Failed to end sessions after password reset", "user_id", userID, logger.Err(err))
+	}
+	return nil
+}
+
+// takeRateLimit counts one more request for email in the current window and
+// returns ErrPasswordResetRateLimited once it passes the limit. Emails are
+// counted case-insensitively and per store, so that requests in one store do
+// not use up the quota of the same email in another.
+func (s *passwordResetService) takeRateLimit(ctx context.Context, email string) error {
+	key := "mall:password_reset:limit:" + strconv.FormatUint(tenant.StoreID(ctx), 10) + ":" + sha256Hex(strings.ToLower(email))
+	res, err := s.cache.Eval(ctx, incrWithTTL, []string{key}, s.opts.LimitWindow.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("failed to count password reset requests: %w", err)
+	}
+	count, ok := res.(int64)
+	if !ok {
+		return fmt.Errorf("unexpected password reset request count %v", res)
+	}
+	if count > int64(s.opts.Limit) {
+		return ErrPasswordResetRateLimited
+	}
+	return nil
+}
+
+func newResetToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate password reset token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// resetTokenKey keeps only a hash of the token in Redis, so that its
+// contents do not let anyone reset passwords.
+func resetTokenKey(token string) string {
+	return "mall:password_reset:token:" + sha256Hex(token)
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
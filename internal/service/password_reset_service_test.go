@@ -0,0 +1,196 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/tenant"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type passwordResetMocks struct {
+	repo     *mocks.MockUserRepository
+	hasher   *mocks.MockPasswordHasher
+	cache    *mocks.MockCache
+	sessions *mocks.MockSessionStore
+	notifier *mocks.MockNotifier
+}
+
+func newPasswordResetService(t *testing.T) (service.PasswordResetService, passwordResetMocks) {
+	ctrl := gomock.NewController(t)
+	m := passwordResetMocks{
+		repo:     mocks.NewMockUserRepository(ctrl),
+		hasher:   mocks.NewMockPasswordHasher(ctrl),
+		cache:    mocks.NewMockCache(ctrl),
+		sessions: mocks.NewMockSessionStore(ctrl),
+		notifier: mocks.NewMockNotifier(ctrl),
+	}
+	resets := service.NewPasswordResetService(m.repo, m.hasher, m.cache, m.sessions, m.notifier, service.PasswordResetOptions{
+		URL:      "https://shop.example.com/reset-password",
+		TokenTTL: 15 * time.Minute,
+		Limit:    2,
+	})
+	return resets, m
+}
+
+func TestPasswordResetService_RequestReset(t *testing.T) {
+	tests := []struct {
+		name      string
+		mockSetup func(m passwordResetMocks)
+		wantErr   error
+	}{
+		{
+			name: "Success",
+			mockSetup: func(m passwordResetMocks) {
+				m.cache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any(), time.Hour.Milliseconds()).Return(int64(1), nil)
+				m.repo.EXPECT().GetByEmail(gomock.Any(), "jane@example.com").Return(&model.User{Base: model.Base{ID: 7}}, nil)
+				var key string
+				m.cache.EXPECT().Set(gomock.Any(), gomock.Any(), uint64(7), 15*time.Minute).DoAndReturn(func(_ context.Context, k string, _ any, _ time.Duration) error {
+					key = k
+					return nil
+				})
+				m.notifier.EXPECT().Notify(gomock.Any(), uint64(7), notification.KindPasswordReset, gomock.Any()).DoAndReturn(func(_ context.Context, _ uint64, _ notification.Kind, data any) error {
+					reset := data.(*notification.PasswordResetData)
+					link, err := url.Parse(reset.ResetURL)
+					require.NoError(t, err)
+					assert.Equal(t, "/reset-password", link.Path)
+					token := link.Query().Get("token")
+					assert.Len(t, token, 64)
+					assert.NotContains(t, key, token) // Only a hash of the token is stored
+					assert.Equal(t, 15, reset.ExpiresInMinutes)
+					return nil
+				})
+			},
+		},
+		{
+			name: "UnknownEmail",
+			mockSetup: func(m passwordResetMocks) {
+				m.cache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+				m.repo.EXPECT().GetByEmail(gomock.Any(), "jane@example.com").Return(nil, repository.ErrUserNotFound)
+			},
+		},
+		{
+			name: "RateLimited",
+			mockSetup: func(m passwordResetMocks) {
+				m.cache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(3), nil)
+			},
+			wantErr: service.ErrPasswordResetRateLimited,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resets, m := newPasswordResetService(t)
+			tt.mockSetup(m)
+
+			err := resets.RequestReset(context.Background(), " jane@example.com ")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestPasswordResetService_RequestResetLimitedPerStore(t *testing.T) {
+	resets, m := newPasswordResetService(t)
+	var keys []string
+	m.cache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *redis.Script, k []string, _ ...any) (any, error) {
+		keys = append(keys, k[0])
+		return int64(3), nil
+	}).Times(2)
+
+	for _, storeID := range []uint64{1, 2} {
+		ctx := tenant.NewContext(context.Background(), &model.Store{Base: model.Base{ID: storeID}})
+		assert.ErrorIs(t, resets.RequestReset(ctx, "jane@example.com"), service.ErrPasswordResetRateLimited)
+	}
+	require.Len(t, keys, 2)
+	assert.NotEqual(t, keys[0], keys[1])
+}
+
+func TestPasswordResetService_ResetPassword(t *testing.T) {
+	tests := []struct {
+		name      string
+		token     string
+		mockSetup func(m passwordResetMocks)
+		wantErr   error
+		errStr    string
+	}{
+		{
+			name:  "Success",
+			token: "abc",
+			mockSetup: func(m passwordResetMocks) {
+				m.cache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any()).Return("7", nil)
+				m.hasher.EXPECT().Hash("new-secret").Return("hashed", nil)
+				m.repo.EXPECT().UpdatePasswordHash(gomock.Any(), uint64(7), "hashed").Return(nil)
+				m.sessions.EXPECT().EndAll(gomock.Any(), uint64(7)).Return(nil)
+			},
+		},
+		{
+			name:  "SessionStoreDown",
+			token: "abc",
+			mockSetup: func(m passwordResetMocks) {
+				m.cache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any()).Return("7", nil)
+				m.hasher.EXPECT().Hash("new-secret").Return("hashed", nil)
+				m.repo.EXPECT().UpdatePasswordHash(gomock.Any(), uint64(7), "hashed").Return(nil)
+				m.sessions.EXPECT().EndAll(gomock.Any(), uint64(7)).Return(errors.New("redis down"))
+			},
+		},
+		{
+			name:  "UnknownOrUsedToken",
+			token: "abc",
+			mockSetup: func(m passwordResetMocks) {
+				m.cache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+			},
+			wantErr: service.ErrInvalidResetToken,
+		},
+		{name: "EmptyToken", mockSetup: func(passwordResetMocks) {}, wantErr: service.ErrInvalidResetToken},
+		{
+			name:  "UserGone",
+			token: "abc",
+			mockSetup: func(m passwordResetMocks) {
+				m.cache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any()).Return("7", nil)
+				m.hasher.EXPECT().Hash("new-secret").Return("hashed", nil)
+				m.repo.EXPECT().UpdatePasswordHash(gomock.Any(), uint64(7), "hashed").Return(repository.ErrUserNotFound)
+			},
+			wantErr: service.ErrInvalidResetToken,
+		},
+		{
+			name:  "CacheError",
+			token: "abc",
+			mockSetup: func(m passwordResetMocks) {
+				m.cache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("redis down"))
+			},
+			errStr: "failed to check password reset token: redis down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resets, m := newPasswordResetService(t)
+			tt.mockSetup(m)
+
+			err := resets.ResetPassword(context.Background(), tt.token, "new-secret")
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.errStr != "":
+				assert.EqualError(t, err, tt.errStr)
+			default:
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package payment
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestAlipay returns an AlipayProvider calling handler, and Alipay's own
+// key, which signs its responses and notifications.
+func newTestAlipay(t *testing.T, handler func(w http.ResponseWriter, r *http.Request, appKey *rsa.PublicKey)) (*AlipayProvider, *rsa.PrivateKey) {
+	t.Helper()
+	appKey, appPrivate, _ := newTestKey(t)
+	alipayKey, _, alipayPublic := newTestKey(t)
+	p, err := NewAlipayProvider(config.AlipayConfig{
+		AppID:      "2021000000000001",
+		PrivateKey: appPrivate,
+		PublicKey:  alipayPublic,
+		NotifyURL:  "https://mall.example.com/webhooks/payments/alipay",
+		Sandbox:    true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, alipaySandboxGateway, p.gateway)
+	if handler != nil {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler(w, r, &appKey.PublicKey)
+		}))
+		t.Cleanup(server.Close)
+		p.gateway = server.URL
+	}
+	return p, alipayKey
+}
+
+// signedAlipayResponse wraps content in a response envelope signed by key.
+func signedAlipayResponse(t *testing.T, key *rsa.PrivateKey, content string) []byte {
+	t.Helper()
+	signature, err := signSHA256(key, []byte(content))
+	require.NoError(t, err)
+	sign, err := json.Marshal(signature)
+	require.NoError(t, err)
+	return []byte(`{"alipay_trade_precreate_response":` + content + `,"sign":` + string(sign) + `}`)
+}
+
+func TestAlipayProvider_CreatePayment(t *testing.T) {
+	var alipayKey *rsa.PrivateKey
+	p, alipayKey := newTestAlipay(t, func(w http.ResponseWriter, r *http.Request, appKey *rsa.PublicKey) {
+		require.NoError(t, r.ParseForm())
+		params := r.PostForm
+		signature := params.Get("sign")
+		params.Del("sign")
+		assert.NoError(t, verifySHA256(appKey, []byte(alipaySignContent(params)), signature), "requests are signed with the app key")
+		assert.Equal(t, "alipay.trade.precreate", params.Get("method"))
+		assert.Equal(t, "RSA2", params.Get("sign_type"))
+		assert.Equal(t, "https://mall.example.com/webhooks/payments/alipay", params.Get("notify_url"))
+		assert.JSONEq(t, `{"out_trade_no":"ORD1","total_amount":"88.80","subject":"Go Mall order ORD1"}`, params.Get("biz_content"))
+		_, _ = w.Write(signedAlipayResponse(t, alipayKey, `{"code":"10000","msg":"Success","out_trade_no":"ORD1","qr_code":"https://qr.alipay.com/bax1"}`))
+	})
+
+	got, err := p.CreatePayment(context.Background(), &PaymentRequest{
+		Reference:   "ORD1",
+		Amount:      money.New(decimal.RequireFromString("88.8"), "CNY"),
+		Description: "Go Mall order ORD1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &Payment{ID: "ORD1", QRCode: "https://qr.alipay.com/bax1"}, got)
+
+	_, err = p.CreatePayment(context.Background(), &PaymentRequest{Reference: "ORD2", Amount: money.New(decimal.NewFromInt(10), "USD")})
+	assert.ErrorIs(t, err, ErrCurrencyNotSupported)
+}
+
+func TestAlipayProvider_CreatePaymentResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond func(alipayKey *rsa.PrivateKey) []byte
+	}{
+		{
+			name: "BusinessError",
+			respond: func(alipayKey *rsa.PrivateKey) []byte {
+				return signedAlipayResponse(t, alipayKey, `{"code":"40004","msg":"Business Failed","sub_code":"ACQ.TRADE_HAS_CLOSE","sub_msg":"closed"}`)
+			},
+		},
+		{
+			name: "Unsigned",
+			respond: func(*rsa.PrivateKey) []byte {
+				return []byte(`{"alipay_trade_precreate_response":{"code":"10000","msg":"Success","qr_code":"https://evil.example.com"}}`)
+			},
+		},
+		{
+			name: "ForgedSignature",
+			respond: func(*rsa.PrivateKey) []byte {
+				forger, _, _ := newTestKey(t)
+				return signedAlipayResponse(t, forger, `{"code":"10000","msg":"Success","qr_code":"https://evil.example.com"}`)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var alipayKey *rsa.PrivateKey
+			p, alipayKey := newTestAlipay(t, func(w http.ResponseWriter, _ *http.Request, _ *rsa.PublicKey) {
+				_, _ = w.Write(tt.respond(alipayKey))
+			})
+
+			_, err := p.CreatePayment(context.Background(), &PaymentRequest{Reference: "ORD1", Amount: money.New(decimal.NewFromInt(1), "CNY")})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAlipayProvider_ParseNotification(t *testing.T) {
+	p, alipayKey := newTestAlipay(t, nil)
+	signed := func(params url.Values) []byte {
+		signature, err := signSHA256(alipayKey, []byte(alipaySignContent(params)))
+		require.NoError(t, err)
+		params.Set("sign", signature)
+		params.Set("sign_type", "RSA2")
+		return []byte(params.Encode())
+	}
+	paid := func() url.Values {
+		return url.Values{
+			"app_id":       {"2021000000000001"},
+			"notify_type":  {"trade_status_sync"},
+			"out_trade_no": {"ORD1"},
+			"trade_no":     {"2026101622001"},
+			"trade_status": {"TRADE_SUCCESS"},
+			"total_amount": {"88.80"},
+		}
+	}
+
+	notification, err := p.ParseNotification(nil, signed(paid()))
+	require.NoError(t, err)
+	assert.Equal(t, "ORD1", notification.Reference)
+	assert.Equal(t, "2026101622001", notification.PaymentRef)
+	assert.Equal(t, "88.80 CNY", notification.Amount.String())
+	assert.True(t, notification.Paid)
+
+	waiting := paid()
+	waiting.Set("trade_status", "WAIT_BUYER_PAY")
+	notification, err = p.ParseNotification(nil, signed(waiting))
+	require.NoError(t, err)
+	assert.False(t, notification.Paid)
+
+	otherApp := paid()
+	otherApp.Set("app_id", "2021000000000002")
+	_, err = p.ParseNotification(nil, signed(otherApp))
+	assert.ErrorIs(t, err, ErrInvalidNotification)
+
+	tampered, err := url.ParseQuery(string(signed(paid())))
+	require.NoError(t, err)
+	tampered.Set("total_amount", "0.01")
+	_, err = p.ParseNotification(nil, []byte(tampered.Encode()))
+	assert.ErrorIs(t, err, ErrInvalidNotification)
+}
+
+func TestAlipayProvider_Acknowledge(t *testing.T) {
+	p := &AlipayProvider{}
+
+	w := httptest.NewRecorder()
+	p.Acknowledge(w, nil)
+	assert.Equal(t, "success", w.Body.String())
+
+	w = httptest.NewRecorder()
+	p.Acknowledge(w, ErrInvalidNotification)
+	assert.Equal(t, "fail", w.Body.String())
+}
@@ -0,0 +1,123 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var providerRequestDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "payment_provider_request_duration_seconds",
+		Help:    "Duration of requests to payment providers in seconds by provider, operation and result",
+		Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	},
+	[]string{"provider", "operation", "result"}, // success, declined, error
+)
+
+func init() {
+	prometheus.MustRegister(providerRequestDuration)
+}
+
+// NewInstrumentedProvider wraps p so that the duration and result of every
+// request to the provider is recorded in
+// payment_provider_request_duration_seconds. The wrapper is a Vault or a
+// Capturer when p is.
+func NewInstrumentedProvider(p Provider) Provider {
+	provider := instrumentedProvider{Provider: p}
+	vault, ok := p.(Vault)
+	if !ok {
+		return &provider
+	}
+	withVault := instrumentedVault{instrumentedProvider: provider, vault: vault}
+	capturer, ok := p.(Capturer)
+	if !ok {
+		return &withVault
+	}
+	return &instrumentedCapturer{instrumentedVault: withVault, capturer: capturer}
+}
+
+type instrumentedProvider struct {
+	Provider
+}
+
+// observe records a request of operation that started at start and failed
+// with err, if not nil.
+func (p *instrumentedProvider) observe(operation string, start time.Time, err error) {
+	result := "success"
+	switch {
+	case errors.Is(err, ErrDeclined):
+		result = "declined"
+	case err != nil:
+		result = "error"
+	}
+	providerRequestDuration.WithLabelValues(p.Name(), operation, result).Observe(time.Since(start).Seconds())
+}
+
+func (p *instrumentedProvider) CreatePayment(ctx context.Context, req *PaymentRequest) (*Payment, error) {
+	start := time.Now()
+	payment, err := p.Provider.CreatePayment(ctx, req)
+	p.observe("create_payment", start, err)
+	return payment, err
+}
+
+type instrumentedVault struct {
+	instrumentedProvider
+	vault Vault
+}
+
+func (p *instrumentedVault) AttachMethod(ctx context.Context, req *AttachRequest) (*Method, error) {
+	start := time.Now()
+	method, err := p.vault.AttachMethod(ctx, req)
+	p.observe("attach_method", start, err)
+	return method, err
+}
+
+func (p *instrumentedVault) DetachMethod(ctx context.Context, methodRef string) error {
+	start := time.Now()
+	err := p.vault.DetachMethod(ctx, methodRef)
+	p.observe("detach_method", start, err)
+	return err
+}
+
+func (p *instrumentedVault) Charge(ctx context.Context, req *ChargeRequest) (*Charge, error) {
+	start := time.Now()
+	charge, err := p.vault.Charge(ctx, req)
+	p.observe("charge", start, err)
+	return charge, err
+}
+
+func (p *instrumentedVault) Refund(ctx context.Context, req *RefundRequest) error {
+	start := time.Now()
+	err := p.vault.Refund(ctx, req)
+	p.observe("refund", start, err)
+	return err
+}
+
+type instrumentedCapturer struct {
+	instrumentedVault
+	capturer Capturer
+}
+
+func (p *instrumentedCapturer) Authorize(ctx context.Context, req *ChargeRequest) (*Charge, error) {
+	start := time.Now()
+	charge, err := p.capturer.Authorize(ctx, req)
+	p.observe("authorize", start, err)
+	return charge, err
+}
+
+func (p *instrumentedCapturer) Capture(ctx context.Context, req *CaptureRequest) error {
+	start := time.Now()
+	err := p.capturer.Capture(ctx, req)
+	p.observe("capture", start, err)
+	return err
+}
+
+func (p *instrumentedCapturer) Void(ctx context.Context, paymentRef string) error {
+	start := time.Now()
+	err := p.capturer.Void(ctx, paymentRef)
+	p.observe("void", start, err)
+	return err
+}
@@ -0,0 +1,60 @@
+package payment
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requestCount returns how many requests payment_provider_request_duration_seconds
+// recorded with the given provider, operation and result.
+func requestCount(t *testing.T, labels ...string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	require.NoError(t, providerRequestDuration.WithLabelValues(labels...).(prometheus.Metric).Write(&m))
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestNewInstrumentedProvider(t *testing.T) {
+	t.Run("KeepsCapabilities", func(t *testing.T) {
+		stripe := NewInstrumentedProvider(newTestStripe(t, nil))
+		assert.Implements(t, (*Capturer)(nil), stripe)
+
+		alipay, _ := newTestAlipay(t, nil)
+		instrumented := NewInstrumentedProvider(alipay)
+		_, isVault := instrumented.(Vault)
+		assert.False(t, isVault, "a provider that saves no methods must not pass for a Vault")
+		assert.Equal(t, ProviderAlipay, instrumented.Name())
+	})
+
+	t.Run("RecordsResult", func(t *testing.T) {
+		declined := true
+		p := NewInstrumentedProvider(newTestStripe(t, func(w http.ResponseWriter, r *http.Request) {
+			if declined {
+				w.WriteHeader(http.StatusPaymentRequired)
+				_, _ = w.Write([]byte(`{"error":{"type":"card_error","code":"card_declined"}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":"pi_1","status":"succeeded"}`))
+		})).(Vault)
+		req := &ChargeRequest{CustomerRef: "cus_1", MethodRef: "pm_1", Amount: money.New(decimal.RequireFromString("19.99"), "USD"), Reference: "ORD1"}
+
+		beforeDeclined, beforeSuccess := requestCount(t, ProviderStripe, "charge", "declined"), requestCount(t, ProviderStripe, "charge", "success")
+		_, err := p.Charge(context.Background(), req)
+		assert.ErrorIs(t, err, ErrDeclined)
+		declined = false
+		charge, err := p.Charge(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "pi_1", charge.ID)
+
+		assert.Equal(t, beforeDeclined+1, requestCount(t, ProviderStripe, "charge", "declined"))
+		assert.Equal(t, beforeSuccess+1, requestCount(t, ProviderStripe, "charge", "success"))
+	})
+}
@@ -0,0 +1,92 @@
+package payment
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// keyDER returns the DER bytes of a key given as PEM, or as the bare base64
+// body that Alipay's key tools and WeChat Pay's console hand out.
+func keyDER(key string) ([]byte, error) {
+	key = strings.TrimSpace(key)
+	if strings.HasPrefix(key, "-----BEGIN") {
+		block, _ := pem.Decode([]byte(key))
+		if block == nil {
+			return nil, errors.New("invalid PEM block")
+		}
+		return block.Bytes, nil
+	}
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(key), ""))
+	if err != nil {
+		return nil, fmt.Errorf("key is neither PEM nor base64: %w", err)
+	}
+	return der, nil
+}
+
+// parsePrivateKey parses an RSA private key in PKCS #8 or PKCS #1 form.
+func parsePrivateKey(key string) (*rsa.PrivateKey, error) {
+	der, err := keyDER(key)
+	if err != nil {
+		return nil, err
+	}
+	if parsed, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("private key is not an RSA key")
+		}
+		return rsaKey, nil
+	}
+	rsaKey, err := x509.ParsePKCS1PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	return rsaKey, nil
+}
+
+// parsePublicKey parses an RSA public key in PKIX form or from a certificate.
+func parsePublicKey(key string) (*rsa.PublicKey, error) {
+	der, err := keyDER(key)
+	if err != nil {
+		return nil, err
+	}
+	var parsed any
+	if cert, certErr := x509.ParseCertificate(der); certErr == nil {
+		parsed = cert.PublicKey
+	} else if parsed, err = x509.ParsePKIXPublicKey(der); err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	rsaKey, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// signSHA256 returns the base64 SHA256WithRSA signature of message, the
+// scheme both Alipay (RSA2) and WeChat Pay use.
+func signSHA256(key *rsa.PrivateKey, message []byte) (string, error) {
+	digest := sha256.Sum256(message)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// verifySHA256 checks a base64 SHA256WithRSA signature of message.
+func verifySHA256(key *rsa.PublicKey, message []byte, signature string) error {
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("signature is not base64: %w", err)
+	}
+	digest := sha256.Sum256(message)
+	return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], decoded)
+}
@@ -0,0 +1,55 @@
+package payment
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestKey generates an RSA key and returns it with its PKCS #8 private
+// and PKIX public PEM, the forms providers hand out.
+func newTestKey(t *testing.T) (key *rsa.PrivateKey, privatePEM, publicPEM string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	privateDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	privatePEM = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}))
+	publicPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+	return key, privatePEM, publicPEM
+}
+
+func TestParseKeys(t *testing.T) {
+	key, privatePEM, publicPEM := newTestKey(t)
+
+	parsed, err := parsePrivateKey(privatePEM)
+	require.NoError(t, err)
+	assert.True(t, key.Equal(parsed))
+
+	pkcs1 := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	parsed, err = parsePrivateKey(pkcs1)
+	require.NoError(t, err)
+	assert.True(t, key.Equal(parsed))
+
+	block, _ := pem.Decode([]byte(publicPEM))
+	bare := base64.StdEncoding.EncodeToString(block.Bytes)
+	public, err := parsePublicKey(bare)
+	require.NoError(t, err, "bare base64 as Alipay's key tool prints it")
+	assert.True(t, key.PublicKey.Equal(public))
+
+	signature, err := signSHA256(key, []byte("message"))
+	require.NoError(t, err)
+	assert.NoError(t, verifySHA256(public, []byte("message"), signature))
+	assert.Error(t, verifySHA256(public, []byte("tampered"), signature))
+
+	_, err = parsePublicKey("not a key")
+	assert.Error(t, err)
+}
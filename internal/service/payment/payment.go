@@ -0,0 +1,188 @@
+// Package payment talks to the payment providers orders are paid through. The
+// store never handles card numbers: customers pay on the provider's page, app
+// or SDK, and only the provider's references are passed around. Providers
+// report payments back with signed notifications, which are verified here.
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/proyuen/go-mall/pkg/money"
+)
+
+// Provider names, as used in payment requests and notify URLs.
+const (
+	ProviderStripe = "stripe"
+	ProviderAlipay = "alipay"
+	ProviderWeChat = "wechat"
+)
+
+var (
+	// ErrDeclined means the provider refused to charge or save the payment
+	// method, e.g. the card was declined or needs the customer to
+	// authenticate; retrying the same request cannot help.
+	ErrDeclined = errors.New("payment declined")
+	// ErrInvalidToken means the provider does not know the payment method
+	// token a client sent.
+	ErrInvalidToken = errors.New("invalid payment method token")
+	// ErrCurrencyNotSupported means the provider cannot take payments in the
+	// currency, e.g. Alipay and WeChat Pay only take CNY.
+	ErrCurrencyNotSupported = errors.New("currency not supported by payment provider")
+	// ErrInvalidNotification means a notification is not signed by the
+	// provider, is stale, or cannot be decoded.
+	ErrInvalidNotification = errors.New("invalid payment notification")
+)
+
+// PaymentRequest starts paying for an order.
+type PaymentRequest struct {
+	// Reference identifies what is paid for, e.g. the order number. It comes
+	// back in the Notification, and providers accept one payment per
+	// reference.
+	Reference   string
+	Amount      money.Money
+	Description string // Shown to the customer, e.g. "Go Mall order 1234"
+}
+
+// Payment tells the client how the customer completes a payment. Which
+// fields are set depends on the provider.
+type Payment struct {
+	ID           string // The provider's ID of the payment, or Reference if it assigns one only once paid
+	QRCode       string // Content of a QR code the customer scans with the provider's app (Alipay, WeChat Pay)
+	ClientSecret string // Confirms the payment in the provider's client SDK (Stripe)
+}
+
+// Notification is a verified report from a provider about a payment.
+type Notification struct {
+	Reference  string      // PaymentRequest.Reference
+	PaymentRef string      // The provider's ID of the payment
+	Amount     money.Money // What the customer paid
+	// Paid is true once the payment succeeded. Notifications about other
+	// events are acknowledged and otherwise ignored.
+	Paid bool
+	// Dispute is set when the notification reports a dispute of the payment
+	// PaymentRef, or a change to one.
+	Dispute *Dispute
+}
+
+// Dispute statuses, which providers' own statuses are mapped onto. A
+// dispute is open until it is won or lost.
+const (
+	DisputeNeedsResponse = "needs_response" // The provider waits for evidence until EvidenceDueBy
+	DisputeUnderReview   = "under_review"   // Evidence was submitted; the bank decides
+	DisputeWon           = "won"            // The payment stands
+	DisputeLost          = "lost"           // The bank gave the money back to the customer
+)
+
+// Dispute is a chargeback: the customer's bank taking a payment back until
+// the store proves it was legitimate.
+type Dispute struct {
+	ID         string      // The provider's ID of the dispute
+	PaymentRef string      // The provider's ID of the disputed payment
+	Amount     money.Money // What the bank holds back
+	Reason     string      // The provider's reason code, e.g. fraudulent
+	Status     string      // One of the Dispute statuses
+	// EvidenceDueBy is when the provider stops accepting evidence; zero if
+	// it does not say.
+	EvidenceDueBy time.Time
+}
+
+// Provider starts payments that the customer completes on their own, such as
+// scanning a QR code, and reports them back through notifications sent to
+// the provider's notify URL. Implementations wrap ErrCurrencyNotSupported or
+// ErrInvalidNotification when the request itself is the problem.
+//
+//go:generate mockgen -source=$GOFILE -destination=../../mocks/payment_provider_mock.go -package=mocks -mock_names=Provider=MockPaymentProvider,Vault=MockPaymentVault,Capturer=MockPaymentCapturer
+type Provider interface {
+	// Name returns the provider name saved with methods and payments.
+	Name() string
+	CreatePayment(ctx context.Context, req *PaymentRequest) (*Payment, error)
+	// ParseNotification verifies the signature of a notification the
+	// provider sent and decodes it.
+	ParseNotification(header http.Header, body []byte) (*Notification, error)
+	// Acknowledge answers a notification the way the provider expects: as
+	// received, or, when err is not nil, as failed so that it is sent again.
+	Acknowledge(w http.ResponseWriter, err error)
+}
+
+// Vault is a Provider that also saves payment methods and charges them
+// without the customer present, for one-click checkout. Implementations wrap
+// ErrDeclined or ErrInvalidToken when the request itself is the problem.
+type Vault interface {
+	Provider
+	AttachMethod(ctx context.Context, req *AttachRequest) (*Method, error)
+	// DetachMethod removes a saved method; one the provider no longer knows
+	// is not an error.
+	DetachMethod(ctx context.Context, methodRef string) error
+	Charge(ctx context.Context, req *ChargeRequest) (*Charge, error)
+	// Refund gives back part or all of a charge.
+	Refund(ctx context.Context, req *RefundRequest) error
+}
+
+// Capturer is a Vault that can also authorize a saved method without
+// charging it, and capture the authorized amount later in one or more parts,
+// e.g. as an order ships.
+type Capturer interface {
+	Vault
+	// Authorize holds req.Amount on the method; the returned Charge is the
+	// authorization to capture or void.
+	Authorize(ctx context.Context, req *ChargeRequest) (*Charge, error)
+	Capture(ctx context.Context, req *CaptureRequest) error
+	// Void releases what an authorization still holds.
+	Void(ctx context.Context, paymentRef string) error
+}
+
+// AttachRequest saves a tokenized payment method for a user.
+type AttachRequest struct {
+	CustomerRef string // The user's customer at the provider; empty creates one
+	Token       string // Payment method token from the provider's client SDK
+	UserID      uint64 // Recorded on a new customer
+}
+
+// Method is a payment method saved with the provider.
+type Method struct {
+	CustomerRef string
+	MethodRef   string
+	Brand       string // e.g. visa
+	Last4       string
+	ExpMonth    int
+	ExpYear     int
+}
+
+// ChargeRequest charges a saved payment method without the customer present.
+type ChargeRequest struct {
+	CustomerRef string
+	MethodRef   string
+	Amount      money.Money
+	// Reference identifies what is paid for, e.g. the order number. The
+	// provider charges at most once per reference, so a retried request
+	// returns the first charge.
+	Reference string
+}
+
+// Charge is a successful payment.
+type Charge struct {
+	ID string // The provider's ID of the payment
+}
+
+// RefundRequest gives back part or all of a charge.
+type RefundRequest struct {
+	PaymentRef string // Charge.ID of the charge
+	Amount     money.Money
+	// Reference identifies the refund, e.g. the order number. The provider
+	// refunds at most once per reference.
+	Reference string
+}
+
+// CaptureRequest captures part or all of an authorization.
+type CaptureRequest struct {
+	PaymentRef string // Charge.ID of the authorization
+	Amount     money.Money
+	// Final releases whatever the authorization holds beyond this capture.
+	Final bool
+	// Reference identifies the capture, e.g. the shipment it pays for. The
+	// provider captures at most once per reference.
+	Reference string
+}
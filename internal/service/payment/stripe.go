@@ -0,0 +1,463 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultStripeTimeout bounds one call to Stripe when payment.stripe.timeout
+// is zero. Checkout waits for charges.
+const DefaultStripeTimeout = 30 * time.Second
+
+const (
+	stripeBaseURL = "https://api.stripe.com"
+	// maxStripeResponse bounds how much of a response is read.
+	maxStripeResponse = 64 << 10
+	// stripeSignatureTolerance is how old a signed notification may be
+	// before it is treated as a replay.
+	stripeSignatureTolerance = 5 * time.Minute
+)
+
+// stripeZeroDecimal lists the currencies Stripe takes in whole units rather
+// than cents.
+var stripeZeroDecimal = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "JPY": true, "KMF": true, "KRW": true, "MGA": true,
+	"PYG": true, "RWF": true, "UGX": true, "VND": true, "VUV": true, "XAF": true, "XOF": true, "XPF": true,
+}
+
+// StripeProvider takes payments with PaymentIntents the client confirms with
+// Stripe's SDK, saves cards as PaymentMethods attached to a Customer and
+// charges them with off-session PaymentIntents.
+type StripeProvider struct {
+	client        *http.Client
+	baseURL       string
+	secretKey     string
+	webhookSecret string
+}
+
+// NewStripeProvider creates a StripeProvider for the account in cfg.
+func NewStripeProvider(cfg config.StripeConfig) (*StripeProvider, error) {
+	if cfg.SecretKey == "" {
+		return nil, errors.New("stripe secret key is required")
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultStripeTimeout
+	}
+	return &StripeProvider{
+		client:        &http.Client{Timeout: timeout},
+		baseURL:       stripeBaseURL,
+		secretKey:     cfg.SecretKey,
+		webhookSecret: cfg.WebhookSecret,
+	}, nil
+}
+
+func (p *StripeProvider) Name() string {
+	return ProviderStripe
+}
+
+type stripeCustomer struct {
+	ID string `json:"id"`
+}
+
+type stripePaymentMethod struct {
+	ID   string `json:"id"`
+	Card *struct {
+		Brand    string `json:"brand"`
+		Last4    string `json:"last4"`
+		ExpMonth int    `json:"exp_month"`
+		ExpYear  int    `json:"exp_year"`
+	} `json:"card"`
+}
+
+type stripePaymentIntent struct {
+	ID             string            `json:"id"`
+	Status         string            `json:"status"`
+	ClientSecret   string            `json:"client_secret"`
+	AmountReceived int64             `json:"amount_received"`
+	Currency       string            `json:"currency"`
+	Metadata       map[string]string `json:"metadata"`
+}
+
+type stripeDispute struct {
+	ID              string `json:"id"`
+	Amount          int64  `json:"amount"`
+	Currency        string `json:"currency"`
+	PaymentIntent   string `json:"payment_intent"`
+	Reason          string `json:"reason"`
+	Status          string `json:"status"`
+	EvidenceDetails struct {
+		DueBy int64 `json:"due_by"`
+	} `json:"evidence_details"`
+}
+
+// stripeDisputeStatuses maps Stripe's dispute statuses onto Dispute ones.
+// Inquiries (warning_*) are disputes that may still become chargebacks;
+// one closed without becoming one is won.
+var stripeDisputeStatuses = map[string]string{
+	"warning_needs_response": DisputeNeedsResponse,
+	"needs_response":         DisputeNeedsResponse,
+	"warning_under_review":   DisputeUnderReview,
+	"under_review":           DisputeUnderReview,
+	"warning_closed":         DisputeWon,
+	"won":                    DisputeWon,
+	"lost":                   DisputeLost,
+}
+
+type stripeEvent struct {
+	Type string `json:"type"`
+	Data struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// stripeError is the error member of a failed Stripe response.
+type stripeError struct {
+	Type        string `json:"type"`
+	Code        string `json:"code"`
+	DeclineCode string `json:"decline_code"`
+	Message     string `json:"message"`
+}
+
+func (e *stripeError) Error() string {
+	if e.DeclineCode != "" {
+		return fmt.Sprintf("%s (%s: %s)", e.Message, e.Code, e.DeclineCode)
+	}
+	return fmt.Sprintf("%s (%s)", e.Message, e.Code)
+}
+
+// AttachMethod creates a customer for the user first if they have none.
+func (p *StripeProvider) AttachMethod(ctx context.Context, req *AttachRequest) (*Method, error) {
+	customerRef := req.CustomerRef
+	if customerRef == "" {
+		userID := strconv.FormatUint(req.UserID, 10)
+		var customer stripeCustomer
+		form := url.Values{"metadata[user_id]": {userID}}
+		if err := p.post(ctx, "/v1/customers", form, "customer-"+userID, &customer); err != nil {
+			return nil, fmt.Errorf("failed to create stripe customer: %w", err)
+		}
+		customerRef = customer.ID
+	}
+
+	var pm stripePaymentMethod
+	err := p.post(ctx, "/v1/payment_methods/"+url.PathEscape(req.Token)+"/attach", url.Values{"customer": {customerRef}}, "", &pm)
+	var apiErr *stripeError
+	if errors.As(err, &apiErr) && apiErr.Code == "resource_missing" {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach stripe payment method: %w", err)
+	}
+	method := &Method{CustomerRef: customerRef, MethodRef: pm.ID}
+	if pm.Card != nil {
+		method.Brand, method.Last4, method.ExpMonth, method.ExpYear = pm.Card.Brand, pm.Card.Last4, pm.Card.ExpMonth, pm.Card.ExpYear
+	}
+	return method, nil
+}
+
+func (p *StripeProvider) DetachMethod(ctx context.Context, methodRef string) error {
+	err := p.post(ctx, "/v1/payment_methods/"+url.PathEscape(methodRef)+"/detach", nil, "", nil)
+	var apiErr *stripeError
+	if errors.As(err, &apiErr) && apiErr.Code == "resource_missing" {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to detach stripe payment method: %w", err)
+	}
+	return nil
+}
+
+// CreatePayment creates a PaymentIntent for the client to confirm with
+// Stripe's SDK, keyed by the reference so that a retry returns the same one.
+func (p *StripeProvider) CreatePayment(ctx context.Context, req *PaymentRequest) (*Payment, error) {
+	form := url.Values{
+		"amount":                             {stripeAmount(req.Amount)},
+		"currency":                           {strings.ToLower(req.Amount.Currency())},
+		"description":                        {req.Description},
+		"automatic_payment_methods[enabled]": {"true"},
+		"metadata[reference]":                {req.Reference},
+	}
+	var intent stripePaymentIntent
+	if err := p.post(ctx, "/v1/payment_intents", form, "payment-"+req.Reference, &intent); err != nil {
+		return nil, fmt.Errorf("failed to create stripe payment intent: %w", err)
+	}
+	return &Payment{ID: intent.ID, ClientSecret: intent.ClientSecret}, nil
+}
+
+// ParseNotification verifies the Stripe-Signature of a webhook event. Only
+// payment_intent.succeeded reports a payment, and charge.dispute.* events a
+// Dispute; other events are neither.
+func (p *StripeProvider) ParseNotification(header http.Header, body []byte) (*Notification, error) {
+	if err := p.verifySignature(header.Get("Stripe-Signature"), body, time.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidNotification, err)
+	}
+	var event stripeEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidNotification, err)
+	}
+	if strings.HasPrefix(event.Type, "charge.dispute.") {
+		return parseStripeDispute(event.Data.Object)
+	}
+	var intent stripePaymentIntent
+	if err := json.Unmarshal(event.Data.Object, &intent); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidNotification, err)
+	}
+	if event.Type != "payment_intent.succeeded" {
+		return &Notification{PaymentRef: intent.ID}, nil
+	}
+	return &Notification{
+		Reference:  intent.Metadata["reference"],
+		PaymentRef: intent.ID,
+		Amount:     stripeMoney(intent.AmountReceived, intent.Currency),
+		Paid:       true,
+	}, nil
+}
+
+// parseStripeDispute decodes the Dispute object of a charge.dispute.* event.
+// Statuses Stripe may add later are taken as under review, keeping the
+// dispute open.
+func parseStripeDispute(object json.RawMessage) (*Notification, error) {
+	var dispute stripeDispute
+	if err := json.Unmarshal(object, &dispute); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidNotification, err)
+	}
+	if dispute.ID == "" || dispute.PaymentIntent == "" {
+		return nil, fmt.Errorf("%w: dispute without ID or payment intent", ErrInvalidNotification)
+	}
+	status, ok := stripeDisputeStatuses[dispute.Status]
+	if !ok {
+		status = DisputeUnderReview
+	}
+	var dueBy time.Time
+	if dispute.EvidenceDetails.DueBy > 0 {
+		dueBy = time.Unix(dispute.EvidenceDetails.DueBy, 0).UTC()
+	}
+	return &Notification{
+		PaymentRef: dispute.PaymentIntent,
+		Dispute: &Dispute{
+			ID:            dispute.ID,
+			PaymentRef:    dispute.PaymentIntent,
+			Amount:        stripeMoney(dispute.Amount, dispute.Currency),
+			Reason:        dispute.Reason,
+			Status:        status,
+			EvidenceDueBy: dueBy,
+		},
+	}, nil
+}
+
+// stripeMoney converts an amount in Stripe's smallest currency unit.
+func stripeMoney(amount int64, currency string) money.Money {
+	currency = strings.ToUpper(currency)
+	if stripeZeroDecimal[currency] {
+		return money.New(decimal.NewFromInt(amount), currency)
+	}
+	return money.New(decimal.New(amount, -2), currency)
+}
+
+// verifySignature checks a "t=...,v1=..." header: an HMAC-SHA256 of
+// "t.body" keyed with the endpoint's signing secret.
+func (p *StripeProvider) verifySignature(header string, body []byte, now time.Time) error {
+	if p.webhookSecret == "" {
+		return errors.New("stripe webhook secret is not configured")
+	}
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("missing signature timestamp")
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > stripeSignatureTolerance || age < -stripeSignatureTolerance {
+		return errors.New("signature timestamp outside tolerance")
+	}
+	mac := hmac.New(sha256.New, []byte(p.webhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return errors.New("no matching signature")
+}
+
+// Acknowledge answers 200 so Stripe stops sending the event, or 400 so it
+// retries.
+func (p *StripeProvider) Acknowledge(w http.ResponseWriter, err error) {
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Charge confirms a PaymentIntent off-session, keyed by the reference so that
+// a retry never charges twice. A card that needs the customer to
+// authenticate counts as declined, since they are not there to do it.
+func (p *StripeProvider) Charge(ctx context.Context, req *ChargeRequest) (*Charge, error) {
+	form := url.Values{
+		"amount":              {stripeAmount(req.Amount)},
+		"currency":            {strings.ToLower(req.Amount.Currency())},
+		"customer":            {req.CustomerRef},
+		"payment_method":      {req.MethodRef},
+		"off_session":         {"true"},
+		"confirm":             {"true"},
+		"metadata[reference]": {req.Reference},
+	}
+	var intent stripePaymentIntent
+	if err := p.post(ctx, "/v1/payment_intents", form, "charge-"+req.Reference, &intent); err != nil {
+		return nil, fmt.Errorf("failed to charge stripe payment method: %w", err)
+	}
+	switch intent.Status {
+	case "succeeded":
+		return &Charge{ID: intent.ID}, nil
+	case "requires_action", "requires_payment_method":
+		return nil, fmt.Errorf("%w: payment intent %s is %s", ErrDeclined, intent.ID, intent.Status)
+	default:
+		return nil, fmt.Errorf("stripe payment intent %s is %s", intent.ID, intent.Status)
+	}
+}
+
+// Refund refunds a succeeded PaymentIntent, keyed by the reference so that a
+// retry never refunds twice.
+func (p *StripeProvider) Refund(ctx context.Context, req *RefundRequest) error {
+	form := url.Values{
+		"payment_intent":      {req.PaymentRef},
+		"amount":              {stripeAmount(req.Amount)},
+		"metadata[reference]": {req.Reference},
+	}
+	if err := p.post(ctx, "/v1/refunds", form, "refund-"+req.Reference, nil); err != nil {
+		return fmt.Errorf("failed to refund stripe payment intent: %w", err)
+	}
+	return nil
+}
+
+// stripeAmount formats an amount in the currency's smallest unit, which is
+// the whole unit for zero-decimal currencies.
+func stripeAmount(amount money.Money) string {
+	if stripeZeroDecimal[amount.Currency()] {
+		return strconv.FormatInt(amount.Amount().IntPart(), 10)
+	}
+	return strconv.FormatInt(amount.Cents(), 10)
+}
+
+// Authorize confirms a PaymentIntent off-session like Charge, but with manual
+// capture and, where the card supports it, multicapture, so that the amount
+// can be captured shipment by shipment.
+func (p *StripeProvider) Authorize(ctx context.Context, req *ChargeRequest) (*Charge, error) {
+	form := url.Values{
+		"amount":              {stripeAmount(req.Amount)},
+		"currency":            {strings.ToLower(req.Amount.Currency())},
+		"customer":            {req.CustomerRef},
+		"payment_method":      {req.MethodRef},
+		"off_session":         {"true"},
+		"confirm":             {"true"},
+		"capture_method":      {"manual"},
+		"metadata[reference]": {req.Reference},
+	}
+	form.Set("payment_method_options[card][request_multicapture]", "if_available")
+	var intent stripePaymentIntent
+	if err := p.post(ctx, "/v1/payment_intents", form, "authorize-"+req.Reference, &intent); err != nil {
+		return nil, fmt.Errorf("failed to authorize stripe payment method: %w", err)
+	}
+	switch intent.Status {
+	case "requires_capture":
+		return &Charge{ID: intent.ID}, nil
+	case "requires_action", "requires_payment_method":
+		return nil, fmt.Errorf("%w: payment intent %s is %s", ErrDeclined, intent.ID, intent.Status)
+	default:
+		return nil, fmt.Errorf("stripe payment intent %s is %s", intent.ID, intent.Status)
+	}
+}
+
+// Capture captures part of an authorized PaymentIntent. Captures before the
+// final one need multicapture; without it, Stripe releases the rest of the
+// authorization after the first capture and refuses later ones.
+func (p *StripeProvider) Capture(ctx context.Context, req *CaptureRequest) error {
+	form := url.Values{
+		"amount_to_capture": {stripeAmount(req.Amount)},
+		"final_capture":     {strconv.FormatBool(req.Final)},
+	}
+	path := "/v1/payment_intents/" + url.PathEscape(req.PaymentRef) + "/capture"
+	if err := p.post(ctx, path, form, "capture-"+req.Reference, nil); err != nil {
+		return fmt.Errorf("failed to capture stripe payment intent: %w", err)
+	}
+	return nil
+}
+
+// Void cancels an authorized PaymentIntent, releasing what is not captured.
+func (p *StripeProvider) Void(ctx context.Context, paymentRef string) error {
+	form := url.Values{"cancellation_reason": {"abandoned"}}
+	if err := p.post(ctx, "/v1/payment_intents/"+url.PathEscape(paymentRef)+"/cancel", form, "", nil); err != nil {
+		return fmt.Errorf("failed to cancel stripe payment intent: %w", err)
+	}
+	return nil
+}
+
+// post sends form to the Stripe API and decodes the response into out, which
+// may be nil. Card errors wrap ErrDeclined; other API errors are returned as
+// a *stripeError.
+func (p *StripeProvider) post(ctx context.Context, path string, form url.Values, idempotencyKey string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to build stripe request: %w", err)
+	}
+	req.SetBasicAuth(p.secretKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call stripe: %w", err)
+	}
+	defer resp.Body.Close()
+	body := io.LimitReader(resp.Body, maxStripeResponse)
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		var failed struct {
+			Error stripeError `json:"error"`
+		}
+		if err := json.NewDecoder(body).Decode(&failed); err != nil {
+			return fmt.Errorf("stripe returned status %d", resp.StatusCode)
+		}
+		if failed.Error.Type == "card_error" {
+			return fmt.Errorf("%w: %w", ErrDeclined, &failed.Error)
+		}
+		return &failed.Error
+	}
+	if out == nil {
+		_, _ = io.Copy(io.Discard, body)
+		return nil
+	}
+	if err := json.NewDecoder(body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode stripe response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStripe(t *testing.T, handler http.HandlerFunc) *StripeProvider {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	p, err := NewStripeProvider(config.StripeConfig{SecretKey: "sk_test_1"})
+	require.NoError(t, err)
+	p.baseURL = server.URL
+	return p
+}
+
+func TestStripeProvider_AttachMethod(t *testing.T) {
+	var paths []string
+	p := newTestStripe(t, func(w http.ResponseWriter, r *http.Request) {
+		user, _, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "sk_test_1", user)
+		require.NoError(t, r.ParseForm())
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/v1/customers":
+			assert.Equal(t, "7", r.PostForm.Get("metadata[user_id]"))
+			assert.Equal(t, "customer-7", r.Header.Get("Idempotency-Key"))
+			_, _ = w.Write([]byte(`{"id":"cus_1"}`))
+		case "/v1/payment_methods/pm_new/attach":
+			assert.Equal(t, "cus_1", r.PostForm.Get("customer"))
+			_, _ = w.Write([]byte(`{"id":"pm_new","card":{"brand":"visa","last4":"4242","exp_month":12,"exp_year":2030}}`))
+		case "/v1/payment_methods/pm_gone/attach":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"type":"invalid_request_error","code":"resource_missing","message":"No such PaymentMethod"}}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	})
+
+	method, err := p.AttachMethod(context.Background(), &AttachRequest{Token: "pm_new", UserID: 7})
+	require.NoError(t, err)
+	assert.Equal(t, &Method{CustomerRef: "cus_1", MethodRef: "pm_new", Brand: "visa", Last4: "4242", ExpMonth: 12, ExpYear: 2030}, method)
+
+	_, err = p.AttachMethod(context.Background(), &AttachRequest{CustomerRef: "cus_1", Token: "pm_gone", UserID: 7})
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Equal(t, []string{"/v1/customers", "/v1/payment_methods/pm_new/attach", "/v1/payment_methods/pm_gone/attach"}, paths, "an existing customer is reused")
+}
+
+func TestStripeProvider_Charge(t *testing.T) {
+	tests := []struct {
+		name       string
+		amount     money.Money
+		wantAmount string
+		status     int
+		response   string
+		want       *Charge
+		wantErr    error
+	}{
+		{
+			name:       "Succeeded",
+			amount:     money.New(decimal.RequireFromString("19.99"), "USD"),
+			wantAmount: "1999",
+			status:     http.StatusOK,
+			response:   `{"id":"pi_1","status":"succeeded"}`,
+			want:       &Charge{ID: "pi_1"},
+		},
+		{
+			name:       "ZeroDecimalCurrency",
+			amount:     money.New(decimal.NewFromInt(500), "JPY"),
+			wantAmount: "500",
+			status:     http.StatusOK,
+			response:   `{"id":"pi_1","status":"succeeded"}`,
+			want:       &Charge{ID: "pi_1"},
+		},
+		{
+			name:       "CardDeclined",
+			amount:     money.New(decimal.RequireFromString("19.99"), "USD"),
+			wantAmount: "1999",
+			status:     http.StatusPaymentRequired,
+			response:   `{"error":{"type":"card_error","code":"card_declined","decline_code":"insufficient_funds","message":"Your card has insufficient funds."}}`,
+			wantErr:    ErrDeclined,
+		},
+		{
+			name:       "AuthenticationRequired",
+			amount:     money.New(decimal.RequireFromString("19.99"), "USD"),
+			wantAmount: "1999",
+			status:     http.StatusOK,
+			response:   `{"id":"pi_1","status":"requires_action"}`,
+			wantErr:    ErrDeclined,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestStripe(t, func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/v1/payment_intents", r.URL.Path)
+				assert.Equal(t, "charge-ORD1", r.Header.Get("Idempotency-Key"))
+				require.NoError(t, r.ParseForm())
+				assert.Equal(t, tt.wantAmount, r.PostForm.Get("amount"))
+				assert.Equal(t, "cus_1", r.PostForm.Get("customer"))
+				assert.Equal(t, "pm_1", r.PostForm.Get("payment_method"))
+				assert.Equal(t, "true", r.PostForm.Get("off_session"))
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.response))
+			})
+
+			charge, err := p.Charge(context.Background(), &ChargeRequest{CustomerRef: "cus_1", MethodRef: "pm_1", Amount: tt.amount, Reference: "ORD1"})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, charge)
+		})
+	}
+}
+
+func TestStripeProvider_DetachMethod(t *testing.T) {
+	p := newTestStripe(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/payment_methods/pm_gone/detach" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"type":"invalid_request_error","code":"resource_missing","message":"No such PaymentMethod"}}`))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"error":{"type":"api_error","message":"Something went wrong"}}`))
+	})
+
+	assert.NoError(t, p.DetachMethod(context.Background(), "pm_gone"), "already gone")
+	assert.Error(t, p.DetachMethod(context.Background(), "pm_1"))
+}
+
+func TestStripeProvider_Authorize(t *testing.T) {
+	p := newTestStripe(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/payment_intents", r.URL.Path)
+		assert.Equal(t, "authorize-ORD1", r.Header.Get("Idempotency-Key"))
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "manual", r.PostForm.Get("capture_method"))
+		assert.Equal(t, "if_available", r.PostForm.Get("payment_method_options[card][request_multicapture]"))
+		_, _ = w.Write([]byte(`{"id":"pi_1","status":"requires_capture"}`))
+	})
+
+	charge, err := p.Authorize(context.Background(), &ChargeRequest{CustomerRef: "cus_1", MethodRef: "pm_1", Amount: money.New(decimal.RequireFromString("19.99"), "USD"), Reference: "ORD1"})
+	require.NoError(t, err)
+	assert.Equal(t, &Charge{ID: "pi_1"}, charge)
+}
+
+func TestStripeProvider_CaptureAndVoid(t *testing.T) {
+	var paths []string
+	p := newTestStripe(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/v1/payment_intents/pi_1/capture":
+			assert.Equal(t, "capture-9", r.Header.Get("Idempotency-Key"))
+			assert.Equal(t, "3300", r.PostForm.Get("amount_to_capture"))
+			assert.Equal(t, "false", r.PostForm.Get("final_capture"))
+			_, _ = w.Write([]byte(`{"id":"pi_1","status":"requires_capture"}`))
+		case "/v1/payment_intents/pi_1/cancel":
+			_, _ = w.Write([]byte(`{"id":"pi_1","status":"canceled"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"type":"invalid_request_error","code":"payment_intent_unexpected_state","message":"This PaymentIntent could not be captured"}}`))
+		}
+	})
+
+	require.NoError(t, p.Capture(context.Background(), &CaptureRequest{PaymentRef: "pi_1", Amount: money.New(decimal.RequireFromString("33.00"), "USD"), Reference: "9"}))
+	require.NoError(t, p.Void(context.Background(), "pi_1"))
+	assert.Error(t, p.Capture(context.Background(), &CaptureRequest{PaymentRef: "pi_2", Amount: money.New(decimal.RequireFromString("1.00"), "USD"), Final: true, Reference: "10"}))
+	assert.Equal(t, []string{"/v1/payment_intents/pi_1/capture", "/v1/payment_intents/pi_1/cancel", "/v1/payment_intents/pi_2/capture"}, paths)
+}
+
+func TestStripeProvider_Refund(t *testing.T) {
+	p := newTestStripe(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/refunds", r.URL.Path)
+		assert.Equal(t, "refund-ORD1", r.Header.Get("Idempotency-Key"))
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "pi_1", r.PostForm.Get("payment_intent"))
+		assert.Equal(t, "1999", r.PostForm.Get("amount"))
+		assert.Equal(t, "ORD1", r.PostForm.Get("metadata[reference]"))
+		_, _ = w.Write([]byte(`{"id":"re_1","status":"succeeded"}`))
+	})
+
+	require.NoError(t, p.Refund(context.Background(), &RefundRequest{PaymentRef: "pi_1", Amount: money.New(decimal.RequireFromString("19.99"), "USD"), Reference: "ORD1"}))
+}
+
+func TestStripeProvider_CreatePayment(t *testing.T) {
+	p := newTestStripe(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/payment_intents", r.URL.Path)
+		assert.Equal(t, "payment-ORD1", r.Header.Get("Idempotency-Key"))
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "1999", r.PostForm.Get("amount"))
+		assert.Equal(t, "eur", r.PostForm.Get("currency"))
+		assert.Equal(t, "true", r.PostForm.Get("automatic_payment_methods[enabled]"))
+		assert.Equal(t, "ORD1", r.PostForm.Get("metadata[reference]"))
+		_, _ = w.Write([]byte(`{"id":"pi_1","status":"requires_payment_method","client_secret":"pi_1_secret_2"}`))
+	})
+
+	got, err := p.CreatePayment(context.Background(), &PaymentRequest{Reference: "ORD1", Amount: money.New(decimal.RequireFromString("19.99"), "EUR")})
+	require.NoError(t, err)
+	assert.Equal(t, &Payment{ID: "pi_1", ClientSecret: "pi_1_secret_2"}, got)
+}
+
+func TestStripeProvider_ParseNotification(t *testing.T) {
+	p := newTestStripe(t, nil)
+	p.webhookSecret = "whsec_1"
+	sign := func(secret string, at time.Time, body string) http.Header {
+		timestamp := fmt.Sprint(at.Unix())
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp + "." + body))
+		return http.Header{"Stripe-Signature": {"t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))}}
+	}
+	succeeded := `{"type":"payment_intent.succeeded","data":{"object":{"id":"pi_1","amount_received":1999,"currency":"eur","metadata":{"reference":"ORD1"}}}}`
+
+	notification, err := p.ParseNotification(sign("whsec_1", time.Now(), succeeded), []byte(succeeded))
+	require.NoError(t, err)
+	assert.Equal(t, &Notification{Reference: "ORD1", PaymentRef: "pi_1", Amount: money.New(decimal.RequireFromString("19.99"), "EUR"), Paid: true}, notification)
+
+	failed := `{"type":"payment_intent.payment_failed","data":{"object":{"id":"pi_1","metadata":{"reference":"ORD1"}}}}`
+	notification, err = p.ParseNotification(sign("whsec_1", time.Now(), failed), []byte(failed))
+	require.NoError(t, err)
+	assert.False(t, notification.Paid)
+
+	_, err = p.ParseNotification(sign("whsec_other", time.Now(), succeeded), []byte(succeeded))
+	assert.ErrorIs(t, err, ErrInvalidNotification)
+	_, err = p.ParseNotification(sign("whsec_1", time.Now().Add(-time.Hour), succeeded), []byte(succeeded))
+	assert.ErrorIs(t, err, ErrInvalidNotification, "replayed")
+	_, err = p.ParseNotification(http.Header{}, []byte(succeeded))
+	assert.ErrorIs(t, err, ErrInvalidNotification)
+
+	disputed := `{"type":"charge.dispute.created","data":{"object":{"id":"dp_1","amount":1999,"currency":"eur","payment_intent":"pi_1",` +
+		`"reason":"fraudulent","status":"needs_response","evidence_details":{"due_by":1893456000}}}}`
+	notification, err = p.ParseNotification(sign("whsec_1", time.Now(), disputed), []byte(disputed))
+	require.NoError(t, err)
+	assert.False(t, notification.Paid)
+	assert.Equal(t, &Dispute{
+		ID:            "dp_1",
+		PaymentRef:    "pi_1",
+		Amount:        money.New(decimal.RequireFromString("19.99"), "EUR"),
+		Reason:        "fraudulent",
+		Status:        DisputeNeedsResponse,
+		EvidenceDueBy: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, notification.Dispute)
+
+	closed := `{"type":"charge.dispute.closed","data":{"object":{"id":"dp_1","amount":1999,"currency":"eur","payment_intent":"pi_1","status":"lost"}}}`
+	notification, err = p.ParseNotification(sign("whsec_1", time.Now(), closed), []byte(closed))
+	require.NoError(t, err)
+	assert.Equal(t, DisputeLost, notification.Dispute.Status)
+	assert.True(t, notification.Dispute.EvidenceDueBy.IsZero())
+}
@@ -0,0 +1,302 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultWeChatPayTimeout bounds one call to WeChat Pay when
+// payment.wechat.timeout is zero.
+const DefaultWeChatPayTimeout = 30 * time.Second
+
+const (
+	wechatBaseURL    = "https://api.mch.weixin.qq.com"
+	wechatNativePath = "/v3/pay/transactions/native"
+	// maxWeChatResponse bounds how much of a response is read.
+	maxWeChatResponse = 64 << 10
+	// wechatSignatureTolerance is how old a signed response or notification
+	// may be before it is treated as a replay.
+	wechatSignatureTolerance = 5 * time.Minute
+)
+
+// WeChatPayProvider takes payments with WeChat Pay Native (API v3): the
+// customer scans a QR code with WeChat, and WeChat Pay posts the encrypted
+// result to the notify URL. Requests are signed with the merchant key;
+// responses and notifications with the platform key.
+type WeChatPayProvider struct {
+	client      *http.Client
+	baseURL     string
+	appID       string
+	mchID       string
+	serialNo    string
+	notifyURL   string
+	apiV3Key    []byte
+	privateKey  *rsa.PrivateKey
+	platformKey *rsa.PublicKey
+}
+
+// NewWeChatPayProvider creates a WeChatPayProvider for the merchant in cfg.
+func NewWeChatPayProvider(cfg config.WeChatPayConfig) (*WeChatPayProvider, error) {
+	if cfg.MchID == "" || cfg.AppID == "" || cfg.SerialNo == "" {
+		return nil, errors.New("wechat pay app id, merchant id and serial number are required")
+	}
+	if len(cfg.APIv3Key) != 32 {
+		return nil, errors.New("wechat pay api v3 key must be 32 characters")
+	}
+	privateKey, err := parsePrivateKey(cfg.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wechat pay private key: %w", err)
+	}
+	platformKey, err := parsePublicKey(cfg.PlatformPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wechat pay platform public key: %w", err)
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultWeChatPayTimeout
+	}
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = wechatBaseURL
+	}
+	return &WeChatPayProvider{
+		client:      &http.Client{Timeout: timeout},
+		baseURL:     baseURL,
+		appID:       cfg.AppID,
+		mchID:       cfg.MchID,
+		serialNo:    cfg.SerialNo,
+		notifyURL:   cfg.NotifyURL,
+		apiV3Key:    []byte(cfg.APIv3Key),
+		privateKey:  privateKey,
+		platformKey: platformKey,
+	}, nil
+}
+
+func (p *WeChatPayProvider) Name() string {
+	return ProviderWeChat
+}
+
+type wechatAmount struct {
+	Total    int64  `json:"total"` // In fen
+	Currency string `json:"currency"`
+}
+
+type wechatNativeRequest struct {
+	AppID       string       `json:"appid"`
+	MchID       string       `json:"mchid"`
+	Description string       `json:"description"`
+	OutTradeNo  string       `json:"out_trade_no"`
+	NotifyURL   string       `json:"notify_url"`
+	Amount      wechatAmount `json:"amount"`
+}
+
+type wechatNotification struct {
+	EventType string `json:"event_type"`
+	Resource  struct {
+		Algorithm      string `json:"algorithm"`
+		Ciphertext     string `json:"ciphertext"`
+		AssociatedData string `json:"associated_data"`
+		Nonce          string `json:"nonce"`
+	} `json:"resource"`
+}
+
+type wechatTransaction struct {
+	AppID         string       `json:"appid"`
+	MchID         string       `json:"mchid"`
+	OutTradeNo    string       `json:"out_trade_no"`
+	TransactionID string       `json:"transaction_id"`
+	TradeState    string       `json:"trade_state"`
+	Amount        wechatAmount `json:"amount"`
+}
+
+// wechatError is the body of a failed WeChat Pay response.
+type wechatError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *wechatError) Error() string {
+	return fmt.Sprintf("%s (%s)", e.Message, e.Code)
+}
+
+// CreatePayment places a Native order for the reference and returns the
+// code_url to show the customer as a QR code. WeChat Pay assigns the
+// transaction ID only once it is paid, so the payment ID is the reference.
+func (p *WeChatPayProvider) CreatePayment(ctx context.Context, req *PaymentRequest) (*Payment, error) {
+	if req.Amount.Currency() != "CNY" {
+		return nil, fmt.Errorf("%w: wechat pay takes CNY, not %s", ErrCurrencyNotSupported, req.Amount.Currency())
+	}
+	body, err := json.Marshal(&wechatNativeRequest{
+		AppID:       p.appID,
+		MchID:       p.mchID,
+		Description: req.Description,
+		OutTradeNo:  req.Reference,
+		NotifyURL:   p.notifyURL,
+		Amount:      wechatAmount{Total: req.Amount.Cents(), Currency: "CNY"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode wechat pay request: %w", err)
+	}
+
+	var resp struct {
+		CodeURL string `json:"code_url"`
+	}
+	if err := p.post(ctx, wechatNativePath, body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to create wechat pay order: %w", err)
+	}
+	return &Payment{ID: req.Reference, QRCode: resp.CodeURL}, nil
+}
+
+// ParseNotification verifies the signature headers of a notification and
+// decrypts its resource with the API v3 key. A transaction in trade state
+// SUCCESS reports a payment.
+func (p *WeChatPayProvider) ParseNotification(header http.Header, body []byte) (*Notification, error) {
+	if err := p.verify(header, body, time.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidNotification, err)
+	}
+	var notification wechatNotification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidNotification, err)
+	}
+	plaintext, err := p.decrypt(notification.Resource.Ciphertext, notification.Resource.Nonce, notification.Resource.AssociatedData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidNotification, err)
+	}
+	var transaction wechatTransaction
+	if err := json.Unmarshal(plaintext, &transaction); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidNotification, err)
+	}
+	if transaction.MchID != p.mchID {
+		return nil, fmt.Errorf("%w: sent for merchant %q", ErrInvalidNotification, transaction.MchID)
+	}
+
+	return &Notification{
+		Reference:  transaction.OutTradeNo,
+		PaymentRef: transaction.TransactionID,
+		Amount:     money.New(decimal.New(transaction.Amount.Total, -money.Scale), transaction.Amount.Currency),
+		Paid:       transaction.TradeState == "SUCCESS",
+	}, nil
+}
+
+// Acknowledge answers 204 so WeChat Pay stops sending the notification, or a
+// 500 with a FAIL code so it retries.
+func (p *WeChatPayProvider) Acknowledge(w http.ResponseWriter, err error) {
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(&wechatError{Code: "FAIL", Message: "failed"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// post sends a signed JSON request to the WeChat Pay API and decodes the
+// verified response into out.
+func (p *WeChatPayProvider) post(ctx context.Context, path string, body []byte, out any) error {
+	nonce, err := wechatNonce()
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	message := http.MethodPost + "\n" + path + "\n" + timestamp + "\n" + nonce + "\n" + string(body) + "\n"
+	signature, err := signSHA256(p.privateKey, []byte(message))
+	if err != nil {
+		return fmt.Errorf("failed to sign wechat pay request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build wechat pay request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf(`WECHATPAY2-SHA256-RSA2048 mchid="%s",nonce_str="%s",signature="%s",timestamp="%s",serial_no="%s"`,
+		p.mchID, nonce, signature, timestamp, p.serialNo))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call wechat pay: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxWeChatResponse))
+	if err != nil {
+		return fmt.Errorf("failed to read wechat pay response: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		failed := &wechatError{}
+		if err := json.Unmarshal(respBody, failed); err != nil {
+			return fmt.Errorf("wechat pay returned status %d", resp.StatusCode)
+		}
+		return failed
+	}
+	if err := p.verify(resp.Header, respBody, time.Now()); err != nil {
+		return fmt.Errorf("invalid wechat pay response signature: %w", err)
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to decode wechat pay response: %w", err)
+	}
+	return nil
+}
+
+// verify checks the Wechatpay-Signature header of a response or
+// notification against the platform key.
+func (p *WeChatPayProvider) verify(header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("Wechatpay-Timestamp")
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("missing signature timestamp")
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > wechatSignatureTolerance || age < -wechatSignatureTolerance {
+		return errors.New("signature timestamp outside tolerance")
+	}
+	message := timestamp + "\n" + header.Get("Wechatpay-Nonce") + "\n" + string(body) + "\n"
+	return verifySHA256(p.platformKey, []byte(message), header.Get("Wechatpay-Signature"))
+}
+
+// decrypt opens an AEAD_AES_256_GCM resource with the API v3 key.
+func (p *WeChatPayProvider) decrypt(ciphertext, nonce, associatedData string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("ciphertext is not base64: %w", err)
+	}
+	block, err := aes.NewCipher(p.apiV3Key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(nonce))
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, []byte(nonce), sealed, []byte(associatedData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt resource: %w", err)
+	}
+	return plaintext, nil
+}
+
+// wechatNonce returns a random nonce_str for a request.
+func wechatNonce() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
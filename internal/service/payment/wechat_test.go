@@ -0,0 +1,209 @@
+package payment
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAPIv3Key = "0123456789abcdef0123456789abcdef"
+
+// newTestWeChatPay returns a WeChatPayProvider calling handler, and the
+// platform key, which signs WeChat Pay's responses and notifications.
+func newTestWeChatPay(t *testing.T, handler func(w http.ResponseWriter, r *http.Request, merchantKey *rsa.PublicKey)) (*WeChatPayProvider, *rsa.PrivateKey) {
+	t.Helper()
+	merchantKey, merchantPrivate, _ := newTestKey(t)
+	platformKey, _, platformPublic := newTestKey(t)
+	cfg := config.WeChatPayConfig{
+		AppID:             "wx0000000000000001",
+		MchID:             "1900000001",
+		SerialNo:          "5157F09EFDC096DE15EBE81A47057A72",
+		PrivateKey:        merchantPrivate,
+		PlatformPublicKey: platformPublic,
+		APIv3Key:          testAPIv3Key,
+		NotifyURL:         "https://mall.example.com/webhooks/payments/wechat",
+	}
+	if handler != nil {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler(w, r, &merchantKey.PublicKey)
+		}))
+		t.Cleanup(server.Close)
+		cfg.BaseURL = server.URL
+	}
+	p, err := NewWeChatPayProvider(cfg)
+	require.NoError(t, err)
+	return p, platformKey
+}
+
+// signWeChat sets the Wechatpay-* headers signing body with key.
+func signWeChat(t *testing.T, header http.Header, key *rsa.PrivateKey, body []byte, at time.Time) {
+	t.Helper()
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	signature, err := signSHA256(key, []byte(timestamp+"\nnonce1\n"+string(body)+"\n"))
+	require.NoError(t, err)
+	header.Set("Wechatpay-Timestamp", timestamp)
+	header.Set("Wechatpay-Nonce", "nonce1")
+	header.Set("Wechatpay-Signature", signature)
+}
+
+var wechatAuthorization = regexp.MustCompile(`^WECHATPAY2-SHA256-RSA2048 mchid="1900000001",nonce_str="(\w+)",signature="([^"]+)",timestamp="(\d+)",serial_no="5157F09EFDC096DE15EBE81A47057A72"$`)
+
+func TestWeChatPayProvider_CreatePayment(t *testing.T) {
+	var platformKey *rsa.PrivateKey
+	p, platformKey := newTestWeChatPay(t, func(w http.ResponseWriter, r *http.Request, merchantKey *rsa.PublicKey) {
+		assert.Equal(t, wechatNativePath, r.URL.Path)
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		auth := wechatAuthorization.FindStringSubmatch(r.Header.Get("Authorization"))
+		require.Len(t, auth, 4)
+		message := "POST\n" + wechatNativePath + "\n" + auth[3] + "\n" + auth[1] + "\n" + string(body) + "\n"
+		assert.NoError(t, verifySHA256(merchantKey, []byte(message), auth[2]), "requests are signed with the merchant key")
+		assert.JSONEq(t, `{"appid":"wx0000000000000001","mchid":"1900000001","description":"Go Mall order ORD1","out_trade_no":"ORD1",
+			"notify_url":"https://mall.example.com/webhooks/payments/wechat","amount":{"total":8880,"currency":"CNY"}}`, string(body))
+
+		resp := []byte(`{"code_url":"weixin://wxpay/bizpayurl?pr=abc"}`)
+		signWeChat(t, w.Header(), platformKey, resp, time.Now())
+		_, _ = w.Write(resp)
+	})
+
+	got, err := p.CreatePayment(context.Background(), &PaymentRequest{
+		Reference:   "ORD1",
+		Amount:      money.New(decimal.RequireFromString("88.80"), "CNY"),
+		Description: "Go Mall order ORD1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &Payment{ID: "ORD1", QRCode: "weixin://wxpay/bizpayurl?pr=abc"}, got)
+
+	_, err = p.CreatePayment(context.Background(), &PaymentRequest{Reference: "ORD2", Amount: money.New(decimal.NewFromInt(10), "EUR")})
+	assert.ErrorIs(t, err, ErrCurrencyNotSupported)
+}
+
+func TestWeChatPayProvider_CreatePaymentResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond func(w http.ResponseWriter, platformKey *rsa.PrivateKey)
+	}{
+		{
+			name: "APIError",
+			respond: func(w http.ResponseWriter, _ *rsa.PrivateKey) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"code":"PARAM_ERROR","message":"invalid amount"}`))
+			},
+		},
+		{
+			name: "Unsigned",
+			respond: func(w http.ResponseWriter, _ *rsa.PrivateKey) {
+				_, _ = w.Write([]byte(`{"code_url":"weixin://evil"}`))
+			},
+		},
+		{
+			name: "Stale",
+			respond: func(w http.ResponseWriter, platformKey *rsa.PrivateKey) {
+				resp := []byte(`{"code_url":"weixin://wxpay/bizpayurl?pr=abc"}`)
+				signWeChat(t, w.Header(), platformKey, resp, time.Now().Add(-time.Hour))
+				_, _ = w.Write(resp)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var platformKey *rsa.PrivateKey
+			p, platformKey := newTestWeChatPay(t, func(w http.ResponseWriter, _ *http.Request, _ *rsa.PublicKey) {
+				tt.respond(w, platformKey)
+			})
+
+			_, err := p.CreatePayment(context.Background(), &PaymentRequest{Reference: "ORD1", Amount: money.New(decimal.NewFromInt(1), "CNY")})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestWeChatPayProvider_ParseNotification(t *testing.T) {
+	p, platformKey := newTestWeChatPay(t, nil)
+	notify := func(transaction string, key string) []byte {
+		block, err := aes.NewCipher([]byte(key))
+		require.NoError(t, err)
+		gcm, err := cipher.NewGCMWithNonceSize(block, 12)
+		require.NoError(t, err)
+		sealed := gcm.Seal(nil, []byte("fa26d7f1a2b3"), []byte(transaction), []byte("transaction"))
+		body, err := json.Marshal(map[string]any{
+			"id":            "EV-2026101601",
+			"event_type":    "TRANSACTION.SUCCESS",
+			"resource_type": "encrypt-resource",
+			"resource": map[string]string{
+				"algorithm":       "AEAD_AES_256_GCM",
+				"ciphertext":      base64.StdEncoding.EncodeToString(sealed),
+				"associated_data": "transaction",
+				"nonce":           "fa26d7f1a2b3",
+			},
+		})
+		require.NoError(t, err)
+		return body
+	}
+	paid := `{"mchid":"1900000001","appid":"wx0000000000000001","out_trade_no":"ORD1","transaction_id":"4200001",
+		"trade_state":"SUCCESS","amount":{"total":8880,"payer_total":8880,"currency":"CNY","payer_currency":"CNY"}}`
+
+	body := notify(paid, testAPIv3Key)
+	header := http.Header{}
+	signWeChat(t, header, platformKey, body, time.Now())
+	notification, err := p.ParseNotification(header, body)
+	require.NoError(t, err)
+	assert.Equal(t, "ORD1", notification.Reference)
+	assert.Equal(t, "4200001", notification.PaymentRef)
+	assert.Equal(t, "88.80 CNY", notification.Amount.String())
+	assert.True(t, notification.Paid)
+
+	tests := []struct {
+		name   string
+		body   []byte
+		signAt time.Time
+	}{
+		{name: "Stale", body: body, signAt: time.Now().Add(-10 * time.Minute)},
+		{name: "WrongKey", body: notify(paid, "fedcba9876543210fedcba9876543210"), signAt: time.Now()},
+		{name: "OtherMerchant", body: notify(`{"mchid":"1900000002","out_trade_no":"ORD1","trade_state":"SUCCESS"}`, testAPIv3Key), signAt: time.Now()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			signWeChat(t, header, platformKey, tt.body, tt.signAt)
+			_, err := p.ParseNotification(header, tt.body)
+			assert.ErrorIs(t, err, ErrInvalidNotification)
+		})
+	}
+
+	header = http.Header{}
+	signWeChat(t, header, platformKey, body, time.Now())
+	header.Set("Wechatpay-Nonce", "nonce2")
+	_, err = p.ParseNotification(header, body)
+	assert.ErrorIs(t, err, ErrInvalidNotification, "the signature covers the nonce")
+}
+
+func TestWeChatPayProvider_Acknowledge(t *testing.T) {
+	p := &WeChatPayProvider{}
+
+	w := httptest.NewRecorder()
+	p.Acknowledge(w, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	p.Acknowledge(w, ErrInvalidNotification)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"FAIL"`)
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service/payment"
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/proyuen/go-mall/pkg/money"
+)
+
+var (
+	// ErrPaymentMethodNotFound means the user has no saved payment method with the ID.
+	ErrPaymentMethodNotFound = apperr.New(apperr.CodePaymentMethodNotFound)
+	// ErrCardNumberNotAllowed means a client sent what looks like a card
+	// number instead of a token from the payment provider's SDK.
+	ErrCardNumberNotAllowed = errors.New("card numbers are not accepted; tokenize the card with the payment provider")
+	// ErrInvalidPaymentToken means the payment provider does not know the token.
+	ErrInvalidPaymentToken = payment.ErrInvalidToken
+	// ErrPaymentDeclined means the provider refused the card.
+	ErrPaymentDeclined = payment.ErrDeclined
+)
+
+// cardNumberPattern matches 12 to 19 digits, optionally grouped by spaces or
+// dashes: the shape of a card number, which no provider token has.
+var cardNumberPattern = regexp.MustCompile(`^[0-9][0-9 -]{10,22}[0-9]$`)
+
+// SavePaymentMethodReq saves a card a client tokenized with the payment
+// provider's SDK.
+type SavePaymentMethodReq struct {
+	UserID uint64
+	Token  string
+}
+
+// PaymentMethodResp is a saved payment method, as shown to its owner.
+type PaymentMethodResp struct {
+	ID        uint64    `json:"id,string"`
+	Provider  string    `json:"provider" example:"stripe"`
+	Brand     string    `json:"brand" example:"visa"`
+	Last4     string    `json:"last4" example:"4242"`
+	ExpMonth  int       `json:"exp_month" example:"12"`
+	ExpYear   int       `json:"exp_year" example:"2030"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PaymentMethodService keeps the payment methods users saved with the payment
+// provider and charges them for one-click checkout.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/payment_method_service_mock.go -package=mocks
+type PaymentMethodService interface {
+	Save(ctx context.Context, req *SavePaymentMethodReq) (*PaymentMethodResp, error)
+	List(ctx context.Context, userID uint64) ([]PaymentMethodResp, error)
+	// Delete removes the method from the provider and from the user's methods.
+	Delete(ctx context.Context, userID, id uint64) error
+	// Get returns one of the user's methods, e.g. to check it before an order
+	// is created for it.
+	Get(ctx context.Context, userID, id uint64) (*model.PaymentMethod, error)
+	// Charge charges method for order, at most once per order number.
+	Charge(ctx context.Context, method *model.PaymentMethod, order *model.Order) (*payment.Charge, error)
+	// Authorize holds the order total on method, to be captured as the order
+	// ships; see FulfillmentService. It fails if the provider is not a
+	// payment.Capturer.
+	Authorize(ctx context.Context, method *model.PaymentMethod, order *model.Order) (*payment.Charge, error)
+	// ChargeExtra charges method amount on top of what order was paid, at
+	// most once per reference, e.g. after the customer ordered more.
+	ChargeExtra(ctx context.Context, method *model.PaymentMethod, order *model.Order, amount money.Money, reference string) (*payment.Charge, error)
+	// Refund gives back the order total charged with Charge as paymentRef.
+	// It returns ErrOrderDisputed while a dispute of the payment is open, or
+	// once one was lost.
+	Refund(ctx context.Context, order *model.Order, paymentRef string) error
+	// RefundPart is Refund of amount only, at most once per reference.
+	RefundPart(ctx context.Context, order *model.Order, paymentRef string, amount money.Money, reference string) error
+	// Void releases what Authorize held as paymentRef.
+	Void(ctx context.Context, paymentRef string) error
+}
+
+type paymentMethodService struct {
+	methodRepo  repository.PaymentMethodRepository
+	disputeRepo repository.DisputeRepository
+	provider    payment.Vault
+}
+
+// NewPaymentMethodService creates a new PaymentMethodService that saves
+// methods with provider. Refunds are checked against the disputes in
+// disputeRepo.
+func NewPaymentMethodService(methodRepo repository.PaymentMethodRepository, disputeRepo repository.DisputeRepository, provider payment.Vault) PaymentMethodService {
+	return &paymentMethodService{methodRepo: methodRepo, disputeRepo: disputeRepo, provider: provider}
+}
+
+// Save reuses the user's customer at the provider, so all their methods can
+// be charged through it.
+func (s *paymentMethodService) Save(ctx context.Context, req *SavePaymentMethodReq) (*PaymentMethodResp, error) {
+	token := strings.TrimSpace(req.Token)
+	if cardNumberPattern.MatchString(token) {
+		return nil, ErrCardNumberNotAllowed
+	}
+	customerRef, err := s.methodRepo.GetCustomerRef(ctx, req.UserID, s.provider.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := s.provider.AttachMethod(ctx, &payment.AttachRequest{CustomerRef: customerRef, Token: token, UserID: req.UserID})
+	if err != nil {
+		if errors.Is(err, payment.ErrInvalidToken) || errors.Is(err, payment.ErrDeclined) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to save payment method with %s: %w", s.provider.Name(), err)
+	}
+	method := &model.PaymentMethod{
+		UserID:      req.UserID,
+		Provider:    s.provider.Name(),
+		CustomerRef: saved.CustomerRef,
+		MethodRef:   saved.MethodRef,
+		Brand:       saved.Brand,
+		Last4:       saved.Last4,
+		ExpMonth:    saved.ExpMonth,
+		ExpYear:     saved.ExpYear,
+	}
+	if err := s.methodRepo.Create(ctx, method); err != nil {
+		return nil, err
+	}
+	resp := newPaymentMethodResp(method)
+	return &resp, nil
+}
+
+func (s *paymentMethodService) List(ctx context.Context, userID uint64) ([]PaymentMethodResp, error) {
+	methods, err := s.methodRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]PaymentMethodResp, 0, len(methods))
+	for i := range methods {
+		resp = append(resp, newPaymentMethodResp(&methods[i]))
+	}
+	return resp, nil
+}
+
+// Delete detaches the method at the provider first, so a method that is gone
+// from the list can no longer be charged.
+func (s *paymentMethodService) Delete(ctx context.Context, userID, id uint64) error {
+	method, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.provider.DetachMethod(ctx, method.MethodRef); err != nil {
+		return fmt.Errorf("failed to remove payment method from %s: %w", method.Provider, err)
+	}
+	if err := s.methodRepo.Delete(ctx, userID, id); err != nil {
+		if errors.Is(err, repository.ErrPaymentMethodNotFound) {
+			return nil // Deleted concurrently
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *paymentMethodService) Get(ctx context.Context, userID, id uint64) (*model.PaymentMethod, error) {
+	method, err := s.methodRepo.GetByID(ctx, userID, id)
+	if errors.Is(err, repository.ErrPaymentMethodNotFound) {
+		return nil, ErrPaymentMethodNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return method, nil
+}
+
+func (s *paymentMethodService) Charge(ctx context.Context, method *model.PaymentMethod, order *model.Order) (*payment.Charge, error) {
+	return s.charge(ctx, method, money.New(order.TotalAmount, order.Currency), order.OrderNumber, s.provider.Charge)
+}
+
+func (s *paymentMethodService) ChargeExtra(ctx context.Context, method *model.PaymentMethod, order *model.Order, amount money.Money, reference string) (*payment.Charge, error) {
+	return s.charge(ctx, method, amount, reference, s.provider.Charge)
+}
+
+func (s *paymentMethodService) Authorize(ctx context.Context, method *model.PaymentMethod, order *model.Order) (*payment.Charge, error) {
+	capturer, ok := s.provider.(payment.Capturer)
+	if !ok {
+		return nil, fmt.Errorf("payment provider %s cannot authorize payments", s.provider.Name())
+	}
+	return s.charge(ctx, method, money.New(order.TotalAmount, order.Currency), order.OrderNumber, capturer.Authorize)
+}
+
+// Refund is keyed by the order number, so a retry never refunds twice.
+func (s *paymentMethodService) Refund(ctx context.Context, order *model.Order, paymentRef string) error {
+	return s.RefundPart(ctx, order, paymentRef, money.New(order.TotalAmount, order.Currency), order.OrderNumber)
+}
+
+func (s *paymentMethodService) RefundPart(ctx context.Context, order *model.Order, paymentRef string, amount money.Money, reference string) error {
+	if err := checkRefundable(ctx, s.disputeRepo, order.ID); err != nil {
+		return err
+	}
+	err := s.provider.Refund(ctx, &payment.RefundRequest{
+		PaymentRef: paymentRef,
+		Amount:     amount,
+		Reference:  reference,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to refund order %s: %w", order.OrderNumber, err)
+	}
+	return nil
+}
+
+func (s *paymentMethodService) Void(ctx context.Context, paymentRef string) error {
+	capturer, ok := s.provider.(payment.Capturer)
+	if !ok {
+		return fmt.Errorf("payment provider %s cannot void payments", s.provider.Name())
+	}
+	if err := capturer.Void(ctx, paymentRef); err != nil {
+		return fmt.Errorf("failed to void payment %s: %w", paymentRef, err)
+	}
+	return nil
+}
+
+// charge charges or authorizes amount with fn, keyed by reference: the order
+// number, or what else is paid for.
+func (s *paymentMethodService) charge(ctx context.Context, method *model.PaymentMethod, amount money.Money, reference string,
+	fn func(context.Context, *payment.ChargeRequest) (*payment.Charge, error)) (*payment.Charge, error) {
+	if method.Provider != s.provider.Name() {
+		return nil, fmt.Errorf("payment method %d was saved with %s, not %s", method.ID, method.Provider, s.provider.Name())
+	}
+	charge, err := fn(ctx, &payment.ChargeRequest{
+		CustomerRef: method.CustomerRef,
+		MethodRef:   method.MethodRef,
+		Amount:      amount,
+		Reference:   reference,
+	})
+	if err != nil {
+		if errors.Is(err, payment.ErrDeclined) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to charge %s: %w", reference, err)
+	}
+	return charge, nil
+}
+
+func newPaymentMethodResp(method *model.PaymentMethod) PaymentMethodResp {
+	return PaymentMethodResp{
+		ID:        method.ID,
+		Provider:  method.Provider,
+		Brand:     method.Brand,
+		Last4:     method.Last4,
+		ExpMonth:  method.ExpMonth,
+		ExpYear:   method.ExpYear,
+		CreatedAt: method.CreatedAt,
+	}
+}
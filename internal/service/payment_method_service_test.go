@@ -0,0 +1,202 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/payment"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPaymentMethodService_Save(t *testing.T) {
+	tests := []struct {
+		name      string
+		token     string
+		mockSetup func(methodRepo *mocks.MockPaymentMethodRepository, provider *mocks.MockPaymentVault)
+		wantErr   error
+	}{
+		{
+			name:  "NewCustomer",
+			token: "pm_1",
+			mockSetup: func(methodRepo *mocks.MockPaymentMethodRepository, provider *mocks.MockPaymentVault) {
+				methodRepo.EXPECT().GetCustomerRef(gomock.Any(), uint64(7), "stripe").Return("", nil)
+				provider.EXPECT().AttachMethod(gomock.Any(), &payment.AttachRequest{Token: "pm_1", UserID: 7}).
+					Return(&payment.Method{CustomerRef: "cus_1", MethodRef: "pm_1", Brand: "visa", Last4: "4242", ExpMonth: 12, ExpYear: 2030}, nil)
+				methodRepo.EXPECT().Create(gomock.Any(), &model.PaymentMethod{
+					UserID: 7, Provider: "stripe", CustomerRef: "cus_1", MethodRef: "pm_1", Brand: "visa", Last4: "4242", ExpMonth: 12, ExpYear: 2030,
+				}).Return(nil)
+			},
+		},
+		{
+			name:  "ExistingCustomer",
+			token: "pm_2",
+			mockSetup: func(methodRepo *mocks.MockPaymentMethodRepository, provider *mocks.MockPaymentVault) {
+				methodRepo.EXPECT().GetCustomerRef(gomock.Any(), uint64(7), "stripe").Return("cus_1", nil)
+				provider.EXPECT().AttachMethod(gomock.Any(), &payment.AttachRequest{CustomerRef: "cus_1", Token: "pm_2", UserID: 7}).
+					Return(&payment.Method{CustomerRef: "cus_1", MethodRef: "pm_2"}, nil)
+				methodRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{name: "CardNumber", token: "4242 4242 4242 4242", wantErr: service.ErrCardNumberNotAllowed},
+		{name: "CardNumberUngrouped", token: "4000056655665556", wantErr: service.ErrCardNumberNotAllowed},
+		{
+			name:  "UnknownToken",
+			token: "pm_gone",
+			mockSetup: func(methodRepo *mocks.MockPaymentMethodRepository, provider *mocks.MockPaymentVault) {
+				methodRepo.EXPECT().GetCustomerRef(gomock.Any(), uint64(7), "stripe").Return("cus_1", nil)
+				provider.EXPECT().AttachMethod(gomock.Any(), gomock.Any()).Return(nil, payment.ErrInvalidToken)
+			},
+			wantErr: service.ErrInvalidPaymentToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			methodRepo := mocks.NewMockPaymentMethodRepository(ctrl)
+			provider := mocks.NewMockPaymentVault(ctrl)
+			provider.EXPECT().Name().Return("stripe").AnyTimes()
+			if tt.mockSetup != nil {
+				tt.mockSetup(methodRepo, provider)
+			}
+
+			resp, err := service.NewPaymentMethodService(methodRepo, nil, provider).Save(context.Background(), &service.SavePaymentMethodReq{UserID: 7, Token: tt.token})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "stripe", resp.Provider)
+		})
+	}
+}
+
+func TestPaymentMethodService_Delete(t *testing.T) {
+	method := &model.PaymentMethod{Base: model.Base{ID: 9}, UserID: 7, Provider: "stripe", MethodRef: "pm_1"}
+
+	tests := []struct {
+		name      string
+		mockSetup func(methodRepo *mocks.MockPaymentMethodRepository, provider *mocks.MockPaymentVault)
+		wantErr   bool
+		errIs     error
+	}{
+		{
+			name: "Deleted",
+			mockSetup: func(methodRepo *mocks.MockPaymentMethodRepository, provider *mocks.MockPaymentVault) {
+				methodRepo.EXPECT().GetByID(gomock.Any(), uint64(7), uint64(9)).Return(method, nil)
+				provider.EXPECT().DetachMethod(gomock.Any(), "pm_1").Return(nil)
+				methodRepo.EXPECT().Delete(gomock.Any(), uint64(7), uint64(9)).Return(nil)
+			},
+		},
+		{
+			name: "NotFound",
+			mockSetup: func(methodRepo *mocks.MockPaymentMethodRepository, _ *mocks.MockPaymentVault) {
+				methodRepo.EXPECT().GetByID(gomock.Any(), uint64(7), uint64(9)).Return(nil, repository.ErrPaymentMethodNotFound)
+			},
+			wantErr: true,
+			errIs:   service.ErrPaymentMethodNotFound,
+		},
+		{
+			name: "ProviderDown",
+			mockSetup: func(methodRepo *mocks.MockPaymentMethodRepository, provider *mocks.MockPaymentVault) {
+				methodRepo.EXPECT().GetByID(gomock.Any(), uint64(7), uint64(9)).Return(method, nil)
+				provider.EXPECT().DetachMethod(gomock.Any(), "pm_1").Return(errors.New("status 503"))
+				// Kept, so it is not charged behind the user's back while still listed
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			methodRepo := mocks.NewMockPaymentMethodRepository(ctrl)
+			provider := mocks.NewMockPaymentVault(ctrl)
+			tt.mockSetup(methodRepo, provider)
+
+			err := service.NewPaymentMethodService(methodRepo, nil, provider).Delete(context.Background(), 7, 9)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			if tt.errIs != nil {
+				assert.ErrorIs(t, err, tt.errIs)
+			}
+		})
+	}
+}
+
+func TestPaymentMethodService_Charge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	provider := mocks.NewMockPaymentVault(ctrl)
+	provider.EXPECT().Name().Return("stripe").AnyTimes()
+	provider.EXPECT().Charge(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *payment.ChargeRequest) (*payment.Charge, error) {
+		assert.Equal(t, "cus_1", req.CustomerRef)
+		assert.Equal(t, "pm_1", req.MethodRef)
+		assert.Equal(t, "19.99 EUR", req.Amount.String())
+		assert.Equal(t, "ORD1", req.Reference)
+		return &payment.Charge{ID: "pi_1"}, nil
+	})
+	payments := service.NewPaymentMethodService(nil, nil, provider)
+	order := &model.Order{OrderNumber: "ORD1", TotalAmount: decimal.RequireFromString("19.99"), Currency: "EUR"}
+
+	charge, err := payments.Charge(context.Background(), &model.PaymentMethod{Provider: "stripe", CustomerRef: "cus_1", MethodRef: "pm_1"}, order)
+	require.NoError(t, err)
+	assert.Equal(t, "pi_1", charge.ID)
+
+	_, err = payments.Charge(context.Background(), &model.PaymentMethod{Provider: "adyen"}, order)
+	assert.Error(t, err, "saved with a provider that is no longer configured")
+}
+
+func TestPaymentMethodService_Refund(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	provider := mocks.NewMockPaymentVault(ctrl)
+	provider.EXPECT().Name().Return("stripe").AnyTimes()
+	provider.EXPECT().Refund(gomock.Any(), &payment.RefundRequest{
+		PaymentRef: "pi_1",
+		Amount:     money.New(decimal.RequireFromString("19.99"), "EUR"),
+		Reference:  "ORD1",
+	}).Return(nil)
+	disputeRepo := mocks.NewMockDisputeRepository(ctrl)
+	payments := service.NewPaymentMethodService(nil, disputeRepo, provider)
+
+	order := &model.Order{Base: model.Base{ID: 5}, OrderNumber: "ORD1", TotalAmount: decimal.RequireFromString("19.99"), Currency: "EUR"}
+	disputeRepo.EXPECT().ListByOrder(gomock.Any(), uint64(5)).Return([]model.Dispute{{Status: model.DisputeStatusWon}}, nil)
+	require.NoError(t, payments.Refund(context.Background(), order, "pi_1"))
+	assert.Error(t, payments.Void(context.Background(), "pi_1"), "the provider cannot authorize")
+
+	for _, status := range []string{model.DisputeStatusNeedsResponse, model.DisputeStatusLost} {
+		disputeRepo.EXPECT().ListByOrder(gomock.Any(), uint64(5)).Return([]model.Dispute{{Status: status}}, nil)
+		assert.ErrorIs(t, payments.Refund(context.Background(), order, "pi_1"), service.ErrOrderDisputed, status)
+	}
+}
+
+func TestPaymentMethodService_Adjust(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	provider := mocks.NewMockPaymentVault(ctrl)
+	provider.EXPECT().Name().Return("stripe").AnyTimes()
+	disputeRepo := mocks.NewMockDisputeRepository(ctrl)
+	payments := service.NewPaymentMethodService(nil, disputeRepo, provider)
+	order := &model.Order{Base: model.Base{ID: 5}, OrderNumber: "ORD1", TotalAmount: decimal.RequireFromString("19.99"), Currency: "EUR"}
+	difference := money.New(decimal.RequireFromString("4.50"), "EUR")
+
+	provider.EXPECT().Charge(gomock.Any(), &payment.ChargeRequest{CustomerRef: "cus_1", MethodRef: "pm_1", Amount: difference, Reference: "ORD1-m1"}).
+		Return(&payment.Charge{ID: "pi_2"}, nil)
+	charge, err := payments.ChargeExtra(context.Background(), &model.PaymentMethod{Provider: "stripe", CustomerRef: "cus_1", MethodRef: "pm_1"}, order, difference, "ORD1-m1")
+	require.NoError(t, err)
+	assert.Equal(t, "pi_2", charge.ID)
+
+	disputeRepo.EXPECT().ListByOrder(gomock.Any(), uint64(5)).Return(nil, nil)
+	provider.EXPECT().Refund(gomock.Any(), &payment.RefundRequest{PaymentRef: "pi_1", Amount: difference, Reference: "ORD1-m2"}).Return(nil)
+	require.NoError(t, payments.RefundPart(context.Background(), order, "pi_1", difference, "ORD1-m2"))
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service/payment"
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/proyuen/go-mall/pkg/cache"
+	"github.com/proyuen/go-mall/pkg/database"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/money"
+)
+
+var (
+	// ErrOrderNotFound means the order does not exist or belongs to another
+	// user.
+	ErrOrderNotFound = apperr.New(apperr.CodeOrderNotFound)
+	// ErrOrderNotPending means the order is already paid or cancelled.
+	ErrOrderNotPending = apperr.New(apperr.CodeOrderNotPending)
+	// ErrPaymentInProgress means another request is starting the order's
+	// payment, or it was started with another provider.
+	ErrPaymentInProgress = apperr.New(apperr.CodePaymentInProgress)
+	// ErrPaymentProviderNotEnabled means no provider of that name is
+	// configured.
+	ErrPaymentProviderNotEnabled = errors.New("payment provider not enabled")
+	// ErrPaymentCurrencyNotSupported means the provider cannot take the
+	// order's currency.
+	ErrPaymentCurrencyNotSupported = payment.ErrCurrencyNotSupported
+	// ErrInvalidPaymentNotification means a notification failed signature
+	// verification or could not be decoded.
+	ErrInvalidPaymentNotification = payment.ErrInvalidNotification
+)
+
+// paymentLockTTL bounds how long a request holds an order's payment lock. It
+// outlasts the providers' default timeouts.
+const paymentLockTTL = time.Minute
+
+// PayOrderReq starts paying for one of the user's orders with a provider.
+type PayOrderReq struct {
+	UserID   uint64
+	OrderID  uint64
+	Provider string // e.g. alipay
+}
+
+// PaymentResp tells the client how the customer completes the payment: by
+// scanning QRCode, or by confirming with ClientSecret in the provider's SDK.
+type PaymentResp struct {
+	Provider     string `json:"provider"`
+	PaymentID    string `json:"payment_id"`
+	QRCode       string `json:"qr_code,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// PaymentService starts payments for pending orders and marks orders paid
+// when providers notify that the customer paid.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/payment_service_mock.go -package=mocks
+type PaymentService interface {
+	// Pay starts paying an order, or returns the payment already started for
+	// it, so that retries never create a second payment.
+	Pay(ctx context.Context, req *PayOrderReq) (*PaymentResp, error)
+	// HandleNotification verifies a notification the provider posted and
+	// applies it. Repeated notifications about the same payment are fine.
+	// An error means the provider should send it again.
+	HandleNotification(ctx context.Context, provider string, header http.Header, body []byte) error
+	// Provider returns an enabled provider, to acknowledge its notifications.
+	Provider(name string) (payment.Provider, bool)
+}
+
+type paymentService struct {
+	orderRepo repository.OrderRepository
+	txManager database.TransactionManager
+	webhooks  WebhookEmitter
+	events    EventPublisher
+	disputes  DisputeService
+	cache     cache.Cache
+	providers map[string]payment.Provider
+}
+
+// NewPaymentService creates a new PaymentService taking payments through
+// providers, keyed by name. Starting an order's payment is serialized with a
+// lock in c. Paid orders are announced through webhooks and events; disputes
+// providers report are recorded with disputes.
+func NewPaymentService(orderRepo repository.OrderRepository, txManager database.TransactionManager, webhooks WebhookEmitter, events EventPublisher, disputes DisputeService,
+	c cache.Cache, providers map[string]payment.Provider) PaymentService {
+	return &paymentService{orderRepo: orderRepo, txManager: txManager, webhooks: webhooks, events: events, disputes: disputes, cache: c, providers: providers}
+}
+
+func (s *paymentService) Provider(name string) (payment.Provider, bool) {
+	provider, ok := s.providers[name]
+	return provider, ok
+}
+
+// Pay uses the order number as the payment reference, so that providers
+// accept one payment per order. The payment started is stored on the order
+// and returned to retries; a lock held while talking to the provider keeps
+// concurrent requests from starting two.
+func (s *paymentService) Pay(ctx context.Context, req *PayOrderReq) (*PaymentResp, error) {
+	provider, ok := s.providers[req.Provider]
+	if !ok {
+		return nil, ErrPaymentProviderNotEnabled
+	}
+	order, err := s.payableOrder(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if order.PaymentIntent.ID != "" {
+		return resumePayment(order, req.Provider)
+	}
+
+	key := fmt.Sprintf("lock:payment:order:%d", order.ID)
+	token := uuid.NewString()
+	locked, err := s.cache.SetNX(ctx, key, token, paymentLockTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock payment of order %d: %w", order.ID, err)
+	}
+	if !locked {
+		return nil, ErrPaymentInProgress
+	}
+	defer func() {
+		if _, err := s.cache.Eval(context.WithoutCancel(ctx), cache.UnlockScript, []string{key}, token); err != nil {
+			slog.WarnContext(ctx, "Failed to release payment lock", "order_id", order.ID, logger.Err(err))
+		}
+	}()
+
+	// A request that held the lock before may have started the payment since
+	order, err = s.payableOrder(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if order.PaymentIntent.ID != "" {
+		return resumePayment(order, req.Provider)
+	}
+
+	created, err := provider.CreatePayment(ctx, &payment.PaymentRequest{
+		Reference:   order.OrderNumber,
+		Amount:      money.New(order.TotalAmount, order.Currency),
+		Description: fmt.Sprintf("Go Mall order %s", order.OrderNumber),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s payment: %w", provider.Name(), err)
+	}
+	intent := model.PaymentIntent{ID: created.ID, QRCode: created.QRCode, ClientSecret: created.ClientSecret}
+	err = s.orderRepo.SetPaymentIntent(ctx, order.ID, provider.Name(), intent)
+	if errors.Is(err, repository.ErrOrderStatusChanged) {
+		// Cancelled meanwhile, or started by a request whose lock expired
+		if order, err = s.payableOrder(ctx, req); err != nil {
+			return nil, err
+		}
+		return resumePayment(order, req.Provider)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save payment of order %d: %w", order.ID, err)
+	}
+	order.PaymentProvider, order.PaymentIntent = provider.Name(), intent
+	return newPaymentResp(order), nil
+}
+
+// payableOrder returns the user's order if it is pending payment.
+func (s *paymentService) payableOrder(ctx context.Context, req *PayOrderReq) (*model.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, req.OrderID)
+	if errors.Is(err, repository.ErrOrderNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	if order.UserID != req.UserID {
+		return nil, ErrOrderNotFound
+	}
+	if order.Status != model.OrderStatusPending {
+		return nil, ErrOrderNotPending
+	}
+	return order, nil
+}
+
+// resumePayment returns the payment already started for order. It must be
+// completed with the provider it was started with: starting another could get
+// the order paid twice.
+func resumePayment(order *model.Order, provider string) (*PaymentResp, error) {
+	if order.PaymentProvider != provider {
+		return nil, ErrPaymentInProgress.With("provider", order.PaymentProvider)
+	}
+	return newPaymentResp(order), nil
+}
+
+func newPaymentResp(order *model.Order) *PaymentResp {
+	return &PaymentResp{
+		Provider:     order.PaymentProvider,
+		PaymentID:    order.PaymentIntent.ID,
+		QRCode:       order.PaymentIntent.QRCode,
+		ClientSecret: order.PaymentIntent.ClientSecret,
+	}
+}
+
+// HandleNotification acknowledges notifications it can never apply, such as
+// a payment for an unknown or cancelled order, after logging them for manual
+// follow-up: sending them again would not help.
+func (s *paymentService) HandleNotification(ctx context.Context, providerName string, header http.Header, body []byte) error {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return ErrPaymentProviderNotEnabled
+	}
+	notification, err := provider.ParseNotification(header, body)
+	if err != nil {
+		return err
+	}
+	if notification.Dispute != nil {
+		return s.disputes.Record(ctx, providerName, notification.Dispute)
+	}
+	if !notification.Paid {
+		return nil
+	}
+
+	order, err := s.orderRepo.GetByOrderNumber(ctx, notification.Reference)
+	if errors.Is(err, repository.ErrOrderNotFound) {
+		slog.WarnContext(ctx, "Payment notification for unknown order", "provider", providerName, "order_number", notification.Reference, "payment_ref", notification.PaymentRef)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get order: %w", err)
+	}
+	attrs := []any{"provider", providerName, "order_id", order.ID, "payment_ref", notification.PaymentRef}
+
+	if total := money.New(order.TotalAmount, order.Currency); !notification.Amount.Equal(total) {
+		slog.ErrorContext(ctx, "Payment amount does not match order total", append(attrs, "paid", notification.Amount.String(), "total", total.String())...)
+		return nil
+	}
+	switch order.Status {
+	case model.OrderStatusPending:
+	case model.OrderStatusPaid, model.OrderStatusBackordered:
+		if order.PaymentProvider != providerName || order.PaymentRef != notification.PaymentRef {
+			slog.ErrorContext(ctx, "Order paid twice; refund the second payment", attrs...)
+		}
+		return nil
+	default:
+		slog.ErrorContext(ctx, "Payment for order that is no longer pending; refund it", append(attrs, "status", order.Status)...)
+		return nil
+	}
+
+	// A concurrent cancellation makes this fail with ErrOrderStatusChanged;
+	// the retried notification then finds the order cancelled.
+	if err := markOrderPaid(ctx, s.txManager, s.orderRepo, s.webhooks, s.events, order, order.Items, providerName, notification.PaymentRef); err != nil {
+		return fmt.Errorf("failed to mark order %d paid: %w", order.ID, err)
+	}
+	return nil
+}
+
+// markOrderPaid marks a pending order paid, or backordered while items wait
+// for stock, and queues its order.paid webhook and event in one transaction,
+// updating order on success. It returns
+// repository.ErrOrderStatusChanged if the order is no longer pending.
+func markOrderPaid(ctx context.Context, txManager database.TransactionManager, orderRepo repository.OrderRepository, webhooks WebhookEmitter, events EventPublisher,
+	order *model.Order, items []model.OrderItem, provider, paymentRef string) error {
+	err := txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		return recordOrderPaid(txCtx, orderRepo, webhooks, events, order, items, provider, paymentRef)
+	})
+	if err != nil {
+		return err
+	}
+	order.Status, order.PaymentProvider, order.PaymentRef = paidStatus(items), provider, paymentRef
+	ordersPaid.WithLabelValues(provider).Inc()
+	return nil
+}
+
+// recordOrderPaid is markOrderPaid in the caller's transaction, leaving order
+// as it is.
+func recordOrderPaid(txCtx context.Context, orderRepo repository.OrderRepository, webhooks WebhookEmitter, events EventPublisher,
+	order *model.Order, items []model.OrderItem, provider, paymentRef string) error {
+	if err := orderRepo.MarkPaid(txCtx, order.ID, provider, paymentRef); err != nil {
+		return err
+	}
+	paid := *order
+	paid.Status, paid.PaymentProvider, paid.PaymentRef = paidStatus(items), provider, paymentRef
+	data := newOrderWebhookData(&paid, items)
+	if err := webhooks.Emit(txCtx, WebhookEventOrderPaid, data); err != nil {
+		return fmt.Errorf("failed to queue order webhook: %w", err)
+	}
+	if err := events.Publish(txCtx, EventOrderPaid, data); err != nil {
+		return fmt.Errorf("failed to publish order event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,299 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/payment"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPaymentService_Pay(t *testing.T) {
+	pending := func(provider string, intent model.PaymentIntent) *model.Order {
+		return &model.Order{
+			Base: model.Base{ID: 5}, UserID: 7, OrderNumber: "ORD1", TotalAmount: decimal.RequireFromString("88.80"), Currency: "CNY",
+			Status: model.OrderStatusPending, PaymentProvider: provider, PaymentIntent: intent,
+		}
+	}
+	started := model.PaymentIntent{ID: "ORD1", QRCode: "https://qr.alipay.com/bax1"}
+	expectLock := func(appCache *mocks.MockCache) {
+		appCache.EXPECT().SetNX(gomock.Any(), "lock:payment:order:5", gomock.Any(), gomock.Any()).Return(true, nil)
+		appCache.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"lock:payment:order:5"}, gomock.Any()).Return(int64(1), nil)
+	}
+
+	tests := []struct {
+		name      string
+		provider  string
+		mockSetup func(orderRepo *mocks.MockOrderRepository, appCache *mocks.MockCache, provider *mocks.MockPaymentProvider)
+		want      *service.PaymentResp
+		wantErr   error
+	}{
+		{
+			name:     "QRCode",
+			provider: "alipay",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, appCache *mocks.MockCache, provider *mocks.MockPaymentProvider) {
+				orderRepo.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(pending("", model.PaymentIntent{}), nil).Times(2)
+				expectLock(appCache)
+				provider.EXPECT().CreatePayment(gomock.Any(), &payment.PaymentRequest{
+					Reference:   "ORD1",
+					Amount:      money.New(decimal.RequireFromString("88.80"), "CNY"),
+					Description: "Go Mall order ORD1",
+				}).Return(&payment.Payment{ID: "ORD1", QRCode: "https://qr.alipay.com/bax1"}, nil)
+				orderRepo.EXPECT().SetPaymentIntent(gomock.Any(), uint64(5), "alipay", started).Return(nil)
+			},
+			want: &service.PaymentResp{Provider: "alipay", PaymentID: "ORD1", QRCode: "https://qr.alipay.com/bax1"},
+		},
+		{
+			name:     "Retried",
+			provider: "alipay",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, _ *mocks.MockCache, _ *mocks.MockPaymentProvider) {
+				orderRepo.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(pending("alipay", started), nil)
+			},
+			want: &service.PaymentResp{Provider: "alipay", PaymentID: "ORD1", QRCode: "https://qr.alipay.com/bax1"},
+		},
+		{
+			name:     "StartedWhileWaitingForLock",
+			provider: "alipay",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, appCache *mocks.MockCache, _ *mocks.MockPaymentProvider) {
+				gomock.InOrder(
+					orderRepo.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(pending("", model.PaymentIntent{}), nil),
+					orderRepo.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(pending("alipay", started), nil),
+				)
+				expectLock(appCache)
+			},
+			want: &service.PaymentResp{Provider: "alipay", PaymentID: "ORD1", QRCode: "https://qr.alipay.com/bax1"},
+		},
+		{
+			name:     "StartedWithAnotherProvider",
+			provider: "alipay",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, _ *mocks.MockCache, _ *mocks.MockPaymentProvider) {
+				orderRepo.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(pending("wechat", model.PaymentIntent{ID: "ORD1", QRCode: "weixin://wxpay/1"}), nil)
+			},
+			wantErr: service.ErrPaymentInProgress,
+		},
+		{
+			name:     "Locked",
+			provider: "alipay",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, appCache *mocks.MockCache, _ *mocks.MockPaymentProvider) {
+				orderRepo.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(pending("", model.PaymentIntent{}), nil)
+				appCache.EXPECT().SetNX(gomock.Any(), "lock:payment:order:5", gomock.Any(), gomock.Any()).Return(false, nil)
+			},
+			wantErr: service.ErrPaymentInProgress,
+		},
+		{
+			name:     "CancelledWhileStarting",
+			provider: "alipay",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, appCache *mocks.MockCache, provider *mocks.MockPaymentProvider) {
+				gomock.InOrder(
+					orderRepo.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(pending("", model.PaymentIntent{}), nil).Times(2),
+					orderRepo.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(&model.Order{UserID: 7, Status: model.OrderStatusCancelled}, nil),
+				)
+				expectLock(appCache)
+				provider.EXPECT().CreatePayment(gomock.Any(), gomock.Any()).Return(&payment.Payment{ID: "ORD1"}, nil)
+				orderRepo.EXPECT().SetPaymentIntent(gomock.Any(), uint64(5), "alipay", gomock.Any()).Return(repository.ErrOrderStatusChanged)
+			},
+			wantErr: service.ErrOrderNotPending,
+		},
+		{name: "ProviderNotEnabled", provider: "wechat", wantErr: service.ErrPaymentProviderNotEnabled},
+		{
+			name:     "OtherUsersOrder",
+			provider: "alipay",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, _ *mocks.MockCache, _ *mocks.MockPaymentProvider) {
+				orderRepo.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(&model.Order{UserID: 8, Status: model.OrderStatusPending}, nil)
+			},
+			wantErr: service.ErrOrderNotFound,
+		},
+		{
+			name:     "AlreadyPaid",
+			provider: "alipay",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, _ *mocks.MockCache, _ *mocks.MockPaymentProvider) {
+				orderRepo.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(&model.Order{UserID: 7, Status: model.OrderStatusPaid}, nil)
+			},
+			wantErr: service.ErrOrderNotPending,
+		},
+		{
+			name:     "CurrencyNotSupported",
+			provider: "alipay",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, appCache *mocks.MockCache, provider *mocks.MockPaymentProvider) {
+				orderRepo.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(pending("", model.PaymentIntent{}), nil).Times(2)
+				expectLock(appCache)
+				provider.EXPECT().CreatePayment(gomock.Any(), gomock.Any()).Return(nil, payment.ErrCurrencyNotSupported)
+			},
+			wantErr: service.ErrPaymentCurrencyNotSupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			appCache := mocks.NewMockCache(ctrl)
+			provider := mocks.NewMockPaymentProvider(ctrl)
+			provider.EXPECT().Name().Return("alipay").AnyTimes()
+			if tt.mockSetup != nil {
+				tt.mockSetup(orderRepo, appCache, provider)
+			}
+			payments := service.NewPaymentService(orderRepo, nil, nil, nil, nil, appCache, map[string]payment.Provider{"alipay": provider})
+
+			resp, err := payments.Pay(context.Background(), &service.PayOrderReq{UserID: 7, OrderID: 5, Provider: tt.provider})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, resp)
+		})
+	}
+}
+
+func TestPaymentService_HandleNotification(t *testing.T) {
+	paid := &payment.Notification{Reference: "ORD1", PaymentRef: "2026101622001", Amount: money.New(decimal.RequireFromString("88.80"), "CNY"), Paid: true}
+	order := func(status, paymentRef string) *model.Order {
+		return &model.Order{
+			Base: model.Base{ID: 5}, UserID: 7, OrderNumber: "ORD1", TotalAmount: decimal.RequireFromString("88.80"), Currency: "CNY",
+			Status: status, PaymentProvider: "alipay", PaymentRef: paymentRef, Items: []model.OrderItem{{SKUID: 101, Quantity: 1}},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		mockSetup func(orderRepo *mocks.MockOrderRepository, provider *mocks.MockPaymentProvider, webhooks *mocks.MockWebhookEmitter)
+		wantErr   error
+		anyErr    bool
+	}{
+		{
+			name: "MarksPaid",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, provider *mocks.MockPaymentProvider, webhooks *mocks.MockWebhookEmitter) {
+				provider.EXPECT().ParseNotification(gomock.Any(), []byte("body")).Return(paid, nil)
+				orderRepo.EXPECT().GetByOrderNumber(gomock.Any(), "ORD1").Return(order(model.OrderStatusPending, ""), nil)
+				orderRepo.EXPECT().MarkPaid(gomock.Any(), uint64(5), "alipay", "2026101622001").Return(nil)
+				webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderPaid, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, data any) error {
+					webhook := data.(service.OrderWebhookData)
+					assert.Equal(t, model.OrderStatusPaid, webhook.Status)
+					assert.Len(t, webhook.Items, 1)
+					return nil
+				})
+			},
+		},
+		{
+			name: "MarksBackordered",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, provider *mocks.MockPaymentProvider, webhooks *mocks.MockWebhookEmitter) {
+				preOrder := order(model.OrderStatusPending, "")
+				preOrder.Items[0].Backordered = true
+				provider.EXPECT().ParseNotification(gomock.Any(), []byte("body")).Return(paid, nil)
+				orderRepo.EXPECT().GetByOrderNumber(gomock.Any(), "ORD1").Return(preOrder, nil)
+				orderRepo.EXPECT().MarkPaid(gomock.Any(), uint64(5), "alipay", "2026101622001").Return(nil)
+				webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderPaid, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, data any) error {
+					assert.Equal(t, model.OrderStatusBackordered, data.(service.OrderWebhookData).Status)
+					return nil
+				})
+			},
+		},
+		{
+			name: "Repeated",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, provider *mocks.MockPaymentProvider, _ *mocks.MockWebhookEmitter) {
+				provider.EXPECT().ParseNotification(gomock.Any(), gomock.Any()).Return(paid, nil)
+				orderRepo.EXPECT().GetByOrderNumber(gomock.Any(), "ORD1").Return(order(model.OrderStatusPaid, "2026101622001"), nil)
+			},
+		},
+		{
+			name: "NotPaidYet",
+			mockSetup: func(_ *mocks.MockOrderRepository, provider *mocks.MockPaymentProvider, _ *mocks.MockWebhookEmitter) {
+				provider.EXPECT().ParseNotification(gomock.Any(), gomock.Any()).Return(&payment.Notification{Reference: "ORD1"}, nil)
+			},
+		},
+		{
+			name: "AmountMismatch",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, provider *mocks.MockPaymentProvider, _ *mocks.MockWebhookEmitter) {
+				underpaid := *paid
+				underpaid.Amount = money.New(decimal.RequireFromString("0.01"), "CNY")
+				provider.EXPECT().ParseNotification(gomock.Any(), gomock.Any()).Return(&underpaid, nil)
+				orderRepo.EXPECT().GetByOrderNumber(gomock.Any(), "ORD1").Return(order(model.OrderStatusPending, ""), nil)
+				// Acknowledged without marking the order paid
+			},
+		},
+		{
+			name: "Cancelled",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, provider *mocks.MockPaymentProvider, _ *mocks.MockWebhookEmitter) {
+				provider.EXPECT().ParseNotification(gomock.Any(), gomock.Any()).Return(paid, nil)
+				orderRepo.EXPECT().GetByOrderNumber(gomock.Any(), "ORD1").Return(order(model.OrderStatusCancelled, ""), nil)
+			},
+		},
+		{
+			name: "UnknownOrder",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, provider *mocks.MockPaymentProvider, _ *mocks.MockWebhookEmitter) {
+				provider.EXPECT().ParseNotification(gomock.Any(), gomock.Any()).Return(paid, nil)
+				orderRepo.EXPECT().GetByOrderNumber(gomock.Any(), "ORD1").Return(nil, repository.ErrOrderNotFound)
+			},
+		},
+		{
+			name: "InvalidSignature",
+			mockSetup: func(_ *mocks.MockOrderRepository, provider *mocks.MockPaymentProvider, _ *mocks.MockWebhookEmitter) {
+				provider.EXPECT().ParseNotification(gomock.Any(), gomock.Any()).Return(nil, payment.ErrInvalidNotification)
+			},
+			wantErr: service.ErrInvalidPaymentNotification,
+		},
+		{
+			name: "DatabaseDown",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, provider *mocks.MockPaymentProvider, _ *mocks.MockWebhookEmitter) {
+				provider.EXPECT().ParseNotification(gomock.Any(), gomock.Any()).Return(paid, nil)
+				orderRepo.EXPECT().GetByOrderNumber(gomock.Any(), "ORD1").Return(order(model.OrderStatusPending, ""), nil)
+				orderRepo.EXPECT().MarkPaid(gomock.Any(), uint64(5), "alipay", "2026101622001").Return(errors.New("connection refused"))
+			},
+			anyErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			}).AnyTimes()
+			webhooks := mocks.NewMockWebhookEmitter(ctrl)
+			events := mocks.NewMockEventPublisher(ctrl)
+			events.EXPECT().Publish(gomock.Any(), service.EventOrderPaid, gomock.Any()).Return(nil).AnyTimes()
+			provider := mocks.NewMockPaymentProvider(ctrl)
+			tt.mockSetup(orderRepo, provider, webhooks)
+			payments := service.NewPaymentService(orderRepo, txManager, webhooks, events, nil, nil, map[string]payment.Provider{"alipay": provider})
+
+			err := payments.HandleNotification(context.Background(), "alipay", http.Header{}, []byte("body"))
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.anyErr:
+				assert.Error(t, err)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	err := service.NewPaymentService(nil, nil, nil, nil, nil, nil, nil).HandleNotification(context.Background(), "alipay", nil, nil)
+	assert.ErrorIs(t, err, service.ErrPaymentProviderNotEnabled)
+}
+
+func TestPaymentService_HandleNotificationDispute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	dispute := &payment.Dispute{ID: "dp_1", PaymentRef: "pi_1", Amount: money.New(decimal.RequireFromString("19.99"), "EUR"), Status: payment.DisputeNeedsResponse}
+	provider := mocks.NewMockPaymentProvider(ctrl)
+	provider.EXPECT().ParseNotification(gomock.Any(), gomock.Any()).Return(&payment.Notification{PaymentRef: "pi_1", Dispute: dispute}, nil)
+	disputes := mocks.NewMockDisputeService(ctrl)
+	disputes.EXPECT().Record(gomock.Any(), "stripe", dispute).Return(errors.New("connection refused"))
+	payments := service.NewPaymentService(nil, nil, nil, nil, disputes, nil, map[string]payment.Provider{"stripe": provider})
+
+	err := payments.HandleNotification(context.Background(), "stripe", http.Header{}, []byte("body"))
+	assert.Error(t, err, "the provider sends it again")
+}
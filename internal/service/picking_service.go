@@ -0,0 +1,288 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// DefaultPickTaskPageSize is how many pick tasks a list returns when no limit
+// is given.
+const DefaultPickTaskPageSize = 50
+
+var (
+	ErrPickTaskNotFound = repository.ErrPickTaskNotFound
+	// ErrPickTaskStatus means the task cannot make the change in its status,
+	// e.g. it was claimed by someone else.
+	ErrPickTaskStatus = errors.New("not allowed in the pick task's status")
+)
+
+// PickTaskResp is a pick task and the order items on it.
+type PickTaskResp struct {
+	ID         uint64             `json:"id,string"`
+	OrderID    uint64             `json:"order_id,string"`
+	Warehouse  string             `json:"warehouse,omitempty" example:"east"`
+	Zone       string             `json:"zone" example:"A"`
+	Status     string             `json:"status" example:"open"` // open, claimed or packed
+	AssigneeID uint64             `json:"assignee_id,string,omitempty"`
+	ClaimedAt  *time.Time         `json:"claimed_at,omitempty"`
+	PackedAt   *time.Time         `json:"packed_at,omitempty"`
+	ShipmentID uint64             `json:"shipment_id,string,omitempty"` // Set once the packed tasks of the order in the warehouse shipped
+	Items      []PickTaskItemResp `json:"items"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+type PickTaskItemResp struct {
+	OrderItemID uint64 `json:"order_item_id,string"`
+	SKUID       uint64 `json:"sku_id,string"`
+	Quantity    int    `json:"quantity" example:"2"`
+}
+
+// PickingService splits the shipped items of paid orders into pick tasks, one
+// per warehouse zone, that fulfillment staff claim and complete. When the
+// last task of an order in a warehouse is packed, the items packed there ship,
+// so an order stocked in several warehouses is split into a shipment from
+// each.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/picking_service_mock.go -package=mocks
+type PickingService interface {
+	// GenerateTasks creates the pick tasks of a paid order for its items not
+	// on a task yet, and returns how many it created. Orders that are not
+	// paid, such as backordered ones still waiting for stock, get none.
+	GenerateTasks(ctx context.Context, orderID uint64) (int, error)
+	// List returns the matching pick tasks, oldest first. Zero filter
+	// fields match all.
+	List(ctx context.Context, filter repository.PickTaskFilter, offset, limit int) ([]PickTaskResp, error)
+	// Claim assigns an open pick task to userID.
+	Claim(ctx context.Context, userID, id uint64) (*PickTaskResp, error)
+	// Complete marks a pick task claimed by userID packed, and ships the
+	// order's items packed in the task's warehouse once none of its tasks
+	// there is left to pack.
+	Complete(ctx context.Context, userID, id uint64) (*PickTaskResp, error)
+}
+
+type pickingService struct {
+	taskRepo    repository.PickTaskRepository
+	orderRepo   repository.OrderRepository
+	productRepo repository.ProductRepository
+	fulfillment FulfillmentService
+}
+
+// NewPickingService creates a new PickingService that ships packed orders
+// through fulfillment.
+func NewPickingService(taskRepo repository.PickTaskRepository, orderRepo repository.OrderRepository, productRepo repository.ProductRepository, fulfillment FulfillmentService) PickingService {
+	return &pickingService{taskRepo: taskRepo, orderRepo: orderRepo, productRepo: productRepo, fulfillment: fulfillment}
+}
+
+// GenerateTasks can run again for the same order, e.g. for a redelivered
+// event: items already on a task are left out, and an order item can only be
+// on one task, so concurrent runs cannot pick an item twice.
+func (s *pickingService) GenerateTasks(ctx context.Context, orderID uint64) (int, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, repository.ErrOrderNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if order.Status != model.OrderStatusPaid {
+		return 0, nil
+	}
+	existing, err := s.taskRepo.ListByOrder(ctx, order.ID)
+	if err != nil {
+		return 0, err
+	}
+	onTask := make(map[uint64]bool)
+	for _, task := range existing {
+		for _, item := range task.Items {
+			onTask[item.OrderItemID] = true
+		}
+	}
+	items := slices.DeleteFunc(slices.Clone(order.Items), func(item model.OrderItem) bool {
+		return item.Delivery != "" || onTask[item.ID]
+	})
+	if len(items) == 0 {
+		return 0, nil
+	}
+
+	skuIDs := make([]uint64, len(items))
+	for i, item := range items {
+		skuIDs[i] = item.SKUID
+	}
+	skus, err := s.productRepo.GetSKUsByIDs(ctx, skuIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get SKUs of order %d: %w", order.ID, err)
+	}
+	type location struct{ warehouse, zone string }
+	locations := make(map[uint64]location, len(skus))
+	for _, sku := range skus {
+		locations[sku.ID] = location{warehouse: sku.Warehouse, zone: sku.WarehouseZone}
+	}
+
+	var tasks []model.PickTask
+	byLocation := make(map[location]int)
+	for _, item := range items {
+		loc := locations[item.SKUID]
+		i, ok := byLocation[loc]
+		if !ok {
+			i = len(tasks)
+			byLocation[loc] = i
+			tasks = append(tasks, model.PickTask{StoreID: order.StoreID, OrderID: order.ID, Warehouse: loc.warehouse, Zone: loc.zone, Status: model.PickTaskStatusOpen})
+		}
+		tasks[i].Items = append(tasks[i].Items, model.PickTaskItem{OrderItemID: item.ID, SKUID: item.SKUID, Quantity: item.Quantity})
+	}
+	slices.SortStableFunc(tasks, func(a, b model.PickTask) int {
+		return cmp.Or(cmp.Compare(a.Warehouse, b.Warehouse), cmp.Compare(a.Zone, b.Zone))
+	})
+	if err := s.taskRepo.Create(ctx, tasks); err != nil {
+		return 0, err
+	}
+	return len(tasks), nil
+}
+
+func (s *pickingService) List(ctx context.Context, filter repository.PickTaskFilter, offset, limit int) ([]PickTaskResp, error) {
+	if limit <= 0 {
+		limit = DefaultPickTaskPageSize
+	}
+	tasks, err := s.taskRepo.List(ctx, filter, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	resps := make([]PickTaskResp, len(tasks))
+	for i := range tasks {
+		resps[i] = newPickTaskResp(&tasks[i])
+	}
+	return resps, nil
+}
+
+func (s *pickingService) Claim(ctx context.Context, userID, id uint64) (*PickTaskResp, error) {
+	if err := s.taskRepo.Claim(ctx, id, userID, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrPickTaskChanged) {
+			return nil, s.statusError(ctx, id, "pick task is not open")
+		}
+		return nil, err
+	}
+	task, err := s.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	RecordAudit(ctx, AuditEntry{
+		Action:     "pick_task.claim",
+		Resource:   "pick_task",
+		ResourceID: strconv.FormatUint(id, 10),
+		Before:     map[string]any{"status": model.PickTaskStatusOpen},
+		After:      map[string]any{"status": task.Status, "assignee_id": userID},
+	})
+	resp := newPickTaskResp(task)
+	return &resp, nil
+}
+
+// Complete ships the order after the task is packed, outside of the packing
+// itself: if shipping fails, e.g. the order was cancelled meanwhile, the task
+// stays packed and the failure is logged for staff to look into. Tasks packed
+// at once cannot ship the order twice, as Ship refuses items that shipped.
+func (s *pickingService) Complete(ctx context.Context, userID, id uint64) (*PickTaskResp, error) {
+	if err := s.taskRepo.MarkPacked(ctx, id, userID, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrPickTaskChanged) {
+			return nil, s.statusError(ctx, id, "pick task is not claimed by you")
+		}
+		return nil, err
+	}
+	task, err := s.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	RecordAudit(ctx, AuditEntry{
+		Action:     "pick_task.complete",
+		Resource:   "pick_task",
+		ResourceID: strconv.FormatUint(id, 10),
+		Before:     map[string]any{"status": model.PickTaskStatusClaimed},
+		After:      map[string]any{"status": task.Status},
+	})
+
+	shipmentID, err := s.shipPacked(ctx, task.OrderID, task.Warehouse)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to ship packed order", "order_id", task.OrderID, "pick_task_id", id, logger.Err(err))
+	}
+	if shipmentID != 0 {
+		task.ShipmentID = shipmentID
+	}
+	resp := newPickTaskResp(task)
+	return &resp, nil
+}
+
+// statusError explains why a pick task did not change: ErrPickTaskNotFound if
+// it does not exist, ErrPickTaskStatus with reason if it does.
+func (s *pickingService) statusError(ctx context.Context, id uint64, reason string) error {
+	if _, err := s.taskRepo.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrPickTaskStatus, reason)
+}
+
+// shipPacked ships the items of an order packed in warehouse that have not
+// shipped, once none of its tasks there is left to pack, and returns the
+// shipment's ID; 0 when it is not time to ship.
+func (s *pickingService) shipPacked(ctx context.Context, orderID uint64, warehouse string) (uint64, error) {
+	tasks, err := s.taskRepo.ListByOrder(ctx, orderID)
+	if err != nil {
+		return 0, err
+	}
+	var ids []uint64
+	var items []ShipmentItemReq
+	for _, task := range tasks {
+		if task.Warehouse != warehouse {
+			continue
+		}
+		if task.Status != model.PickTaskStatusPacked {
+			return 0, nil
+		}
+		if task.ShipmentID != 0 {
+			continue
+		}
+		ids = append(ids, task.ID)
+		for _, item := range task.Items {
+			items = append(items, ShipmentItemReq{OrderItemID: item.OrderItemID, Quantity: item.Quantity})
+		}
+	}
+	if len(items) == 0 {
+		return 0, nil
+	}
+	shipment, err := s.fulfillment.Ship(ctx, &ShipOrderReq{OrderID: orderID, Warehouse: warehouse, Items: items})
+	if err != nil {
+		return 0, err
+	}
+	if err := s.taskRepo.SetShipment(ctx, ids, shipment.ID); err != nil {
+		return shipment.ID, err
+	}
+	return shipment.ID, nil
+}
+
+func newPickTaskResp(task *model.PickTask) PickTaskResp {
+	resp := PickTaskResp{
+		ID:         task.ID,
+		OrderID:    task.OrderID,
+		Warehouse:  task.Warehouse,
+		Zone:       task.Zone,
+		Status:     task.Status,
+		AssigneeID: task.AssigneeID,
+		ClaimedAt:  task.ClaimedAt,
+		PackedAt:   task.PackedAt,
+		ShipmentID: task.ShipmentID,
+		Items:      make([]PickTaskItemResp, len(task.Items)),
+		CreatedAt:  task.CreatedAt,
+	}
+	for i, item := range task.Items {
+		resp.Items[i] = PickTaskItemResp{OrderItemID: item.OrderItemID, SKUID: item.SKUID, Quantity: item.Quantity}
+	}
+	return resp
+}
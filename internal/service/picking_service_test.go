@@ -0,0 +1,228 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPickingService_GenerateTasks(t *testing.T) {
+	order := func(status string) *model.Order {
+		return &model.Order{Base: model.Base{ID: 42}, StoreID: 3, Status: status, Items: []model.OrderItem{
+			{Base: model.Base{ID: 1}, SKUID: 101, Quantity: 2},
+			{Base: model.Base{ID: 2}, SKUID: 102, Quantity: 1},
+			{Base: model.Base{ID: 3}, SKUID: 103, Quantity: 4},
+			{Base: model.Base{ID: 4}, SKUID: 104, Quantity: 1, Delivery: model.SKUDeliveryDownload},
+		}}
+	}
+	skus := []model.SKU{
+		{Base: model.Base{ID: 101}, WarehouseZone: "B"},
+		{Base: model.Base{ID: 102}, WarehouseZone: "A"},
+		{Base: model.Base{ID: 103}, WarehouseZone: "B"},
+	}
+
+	tests := []struct {
+		name      string
+		status    string
+		existing  []model.PickTask
+		mockSetup func(taskRepo *mocks.MockPickTaskRepository, productRepo *mocks.MockProductRepository)
+		want      int
+		wantErr   bool
+	}{
+		{
+			name:   "GroupedByZone",
+			status: model.OrderStatusPaid,
+			mockSetup: func(taskRepo *mocks.MockPickTaskRepository, productRepo *mocks.MockProductRepository) {
+				productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101, 102, 103}).Return(skus, nil)
+				taskRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tasks []model.PickTask) error {
+					require.Len(t, tasks, 2)
+					assert.Equal(t, "A", tasks[0].Zone)
+					assert.Equal(t, []model.PickTaskItem{{OrderItemID: 2, SKUID: 102, Quantity: 1}}, tasks[0].Items)
+					assert.Equal(t, "B", tasks[1].Zone)
+					assert.Equal(t, []model.PickTaskItem{{OrderItemID: 1, SKUID: 101, Quantity: 2}, {OrderItemID: 3, SKUID: 103, Quantity: 4}}, tasks[1].Items)
+					for _, task := range tasks {
+						assert.Equal(t, model.PickTaskStatusOpen, task.Status)
+						assert.Equal(t, uint64(3), task.StoreID, "the order's store, as events are handled outside of any")
+					}
+					return nil
+				})
+			},
+			want: 2,
+		},
+		{
+			name:   "SplitByWarehouse",
+			status: model.OrderStatusPaid,
+			mockSetup: func(taskRepo *mocks.MockPickTaskRepository, productRepo *mocks.MockProductRepository) {
+				productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), gomock.Any()).Return([]model.SKU{
+					{Base: model.Base{ID: 101}, Warehouse: "west", WarehouseZone: "B"},
+					{Base: model.Base{ID: 102}, Warehouse: "east", WarehouseZone: "B"},
+					{Base: model.Base{ID: 103}, Warehouse: "east", WarehouseZone: "A"},
+				}, nil)
+				taskRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tasks []model.PickTask) error {
+					require.Len(t, tasks, 3, "zone B of each warehouse is a task of its own")
+					for i, want := range [][2]string{{"east", "A"}, {"east", "B"}, {"west", "B"}} {
+						assert.Equal(t, want[0], tasks[i].Warehouse)
+						assert.Equal(t, want[1], tasks[i].Zone)
+					}
+					return nil
+				})
+			},
+			want: 3,
+		},
+		{
+			name:     "SkipsItemsOnTasks",
+			status:   model.OrderStatusPaid,
+			existing: []model.PickTask{{OrderID: 42, Zone: "B", Items: []model.PickTaskItem{{OrderItemID: 1}, {OrderItemID: 3}}}},
+			mockSetup: func(taskRepo *mocks.MockPickTaskRepository, productRepo *mocks.MockProductRepository) {
+				productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{102}).Return(skus[1:2], nil)
+				taskRepo.EXPECT().Create(gomock.Any(), gomock.Len(1)).Return(nil)
+			},
+			want: 1,
+		},
+		{
+			name:     "AllOnTasks",
+			status:   model.OrderStatusPaid,
+			existing: []model.PickTask{{OrderID: 42, Items: []model.PickTaskItem{{OrderItemID: 1}, {OrderItemID: 2}, {OrderItemID: 3}}}},
+		},
+		{name: "Backordered", status: model.OrderStatusBackordered},
+		{name: "Cancelled", status: model.OrderStatusCancelled},
+		{
+			name:   "CreateFails",
+			status: model.OrderStatusPaid,
+			mockSetup: func(taskRepo *mocks.MockPickTaskRepository, productRepo *mocks.MockProductRepository) {
+				productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), gomock.Any()).Return(skus, nil)
+				taskRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("duplicate key"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			taskRepo := mocks.NewMockPickTaskRepository(ctrl)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			orderRepo.EXPECT().GetByID(gomock.Any(), uint64(42)).Return(order(tt.status), nil)
+			if tt.status == model.OrderStatusPaid {
+				taskRepo.EXPECT().ListByOrder(gomock.Any(), uint64(42)).Return(tt.existing, nil)
+			}
+			if tt.mockSetup != nil {
+				tt.mockSetup(taskRepo, productRepo)
+			}
+
+			svc := service.NewPickingService(taskRepo, orderRepo, productRepo, nil)
+			n, err := svc.GenerateTasks(context.Background(), 42)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, n)
+		})
+	}
+}
+
+func TestPickingService_Complete(t *testing.T) {
+	packed := func(id uint64, shipmentID uint64, items ...model.PickTaskItem) model.PickTask {
+		return model.PickTask{Base: model.Base{ID: id}, OrderID: 42, Status: model.PickTaskStatusPacked, AssigneeID: 7, ShipmentID: shipmentID, Items: items}
+	}
+
+	tests := []struct {
+		name         string
+		markErr      error
+		orderTasks   []model.PickTask
+		shipErr      error
+		wantShip     []service.ShipmentItemReq
+		wantShipment uint64
+		wantErrIs    error
+	}{
+		{
+			name: "ShipsWhenAllPacked",
+			orderTasks: []model.PickTask{
+				packed(8, 0, model.PickTaskItem{OrderItemID: 1, Quantity: 2}),
+				packed(9, 0, model.PickTaskItem{OrderItemID: 2, Quantity: 1}),
+			},
+			wantShip:     []service.ShipmentItemReq{{OrderItemID: 1, Quantity: 2}, {OrderItemID: 2, Quantity: 1}},
+			wantShipment: 500,
+		},
+		{
+			name: "OnlyWhatDidNotShip",
+			orderTasks: []model.PickTask{
+				packed(8, 400, model.PickTaskItem{OrderItemID: 1, Quantity: 2}),
+				packed(9, 0, model.PickTaskItem{OrderItemID: 2, Quantity: 1}),
+			},
+			wantShip:     []service.ShipmentItemReq{{OrderItemID: 2, Quantity: 1}},
+			wantShipment: 500,
+		},
+		{
+			name: "WaitsForOtherZones",
+			orderTasks: []model.PickTask{
+				packed(9, 0, model.PickTaskItem{OrderItemID: 2, Quantity: 1}),
+				{Base: model.Base{ID: 10}, OrderID: 42, Status: model.PickTaskStatusClaimed},
+			},
+		},
+		{
+			name: "ShipsWithoutOtherWarehouses",
+			orderTasks: []model.PickTask{
+				packed(9, 0, model.PickTaskItem{OrderItemID: 2, Quantity: 1}),
+				{Base: model.Base{ID: 10}, OrderID: 42, Warehouse: "west", Status: model.PickTaskStatusClaimed},
+			},
+			wantShip:     []service.ShipmentItemReq{{OrderItemID: 2, Quantity: 1}},
+			wantShipment: 500,
+		},
+		{
+			name:       "ShipFailsStaysPacked",
+			orderTasks: []model.PickTask{packed(9, 0, model.PickTaskItem{OrderItemID: 2, Quantity: 1})},
+			shipErr:    service.ErrOrderNotShippable,
+			wantShip:   []service.ShipmentItemReq{{OrderItemID: 2, Quantity: 1}},
+		},
+		{name: "NotClaimedByCaller", markErr: repository.ErrPickTaskChanged, wantErrIs: service.ErrPickTaskStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			taskRepo := mocks.NewMockPickTaskRepository(ctrl)
+			fulfillment := mocks.NewMockFulfillmentService(ctrl)
+
+			task := packed(9, 0, model.PickTaskItem{OrderItemID: 2, Quantity: 1})
+			taskRepo.EXPECT().MarkPacked(gomock.Any(), uint64(9), uint64(7), gomock.Any()).Return(tt.markErr)
+			taskRepo.EXPECT().GetByID(gomock.Any(), uint64(9)).Return(&task, nil)
+			if tt.markErr == nil {
+				taskRepo.EXPECT().ListByOrder(gomock.Any(), uint64(42)).Return(tt.orderTasks, nil)
+			}
+			if tt.wantShip != nil {
+				call := fulfillment.EXPECT().Ship(gomock.Any(), &service.ShipOrderReq{OrderID: 42, Items: tt.wantShip})
+				if tt.shipErr != nil {
+					call.Return(nil, tt.shipErr)
+				} else {
+					call.Return(&service.ShipmentResp{ID: tt.wantShipment}, nil)
+					taskRepo.EXPECT().SetShipment(gomock.Any(), gomock.Any(), tt.wantShipment).Return(nil)
+				}
+			}
+
+			svc := service.NewPickingService(taskRepo, nil, nil, fulfillment)
+			ctx, trail := service.WithAuditTrail(context.Background())
+			resp, err := svc.Complete(ctx, 7, 9)
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				assert.Empty(t, trail.Entries())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, model.PickTaskStatusPacked, resp.Status)
+			assert.Equal(t, tt.wantShipment, resp.ShipmentID)
+			require.Len(t, trail.Entries(), 1)
+			assert.Equal(t, "pick_task.complete", trail.Entries()[0].Action)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+// Popularity defaults, used where PopularityOptions leaves a field zero.
+const (
+	DefaultPopularityHalfLife = 24 * time.Hour
+	// MaxPopularProducts is the most products ListPopular returns.
+	MaxPopularProducts = 50
+)
+
+const (
+	// productViewsKey counts the views of each SPU since the last flush. SPU
+	// IDs are unique across stores, so one hash serves them all.
+	productViewsKey = "mall:product:views"
+	// productViewsFlushingKey holds the views being flushed, until they are
+	// in the database.
+	productViewsFlushingKey = "mall:product:views:flushing"
+)
+
+// countProductView increments the view counter of the SPU in ARGV[1].
+var countProductView = redis.NewScript(`
+return redis.call("HINCRBY", KEYS[1], ARGV[1], 1)
+`)
+
+// takeProductViews moves the counters in KEYS[1] to KEYS[2], unless views
+// left there by a failed flush are still to be written, and returns the
+// counters in KEYS[2] as field and value pairs. Views counted meanwhile go to
+// a new KEYS[1].
+var takeProductViews = redis.NewScript(`
+if redis.call("EXISTS", KEYS[2]) == 0 then
+	if redis.call("EXISTS", KEYS[1]) == 0 then
+		return {}
+	end
+	redis.call("RENAME", KEYS[1], KEYS[2])
+end
+return redis.call("HGETALL", KEYS[2])
+`)
+
+// PopularityOptions configures a PopularityService.
+type PopularityOptions struct {
+	HalfLife time.Duration // How long it takes a view to count half as much
+}
+
+// PopularityService ranks products by how often they were viewed lately.
+// Views are counted in Redis as they happen, and flushed to the database
+// and scored by cmd/worker.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/popularity_service_mock.go -package=mocks
+type PopularityService interface {
+	// RecordView counts one view of the SPU.
+	RecordView(ctx context.Context, spuID uint64) error
+	// FlushViews adds the views counted since the last flush to the SPUs'
+	// stats, and returns how many SPUs were viewed.
+	FlushViews(ctx context.Context) (int, error)
+	// Rescore decays the popularity of every viewed SPU and adds the views
+	// flushed since, and returns how many SPUs it rescored.
+	Rescore(ctx context.Context) (int64, error)
+	// ListPopular returns the most popular products of the store in ctx,
+	// most popular first.
+	ListPopular(ctx context.Context, limit int) ([]ProductResp, error)
+}
+
+type popularityService struct {
+	repo     repository.ProductRepository
+	cache    cache.Cache
+	halfLife time.Duration
+}
+
+// NewPopularityService creates a new PopularityService instance.
+func NewPopularityService(repo repository.ProductRepository, c cache.Cache, opts PopularityOptions) PopularityService {
+	if opts.HalfLife <= 0 {
+		opts.HalfLife = DefaultPopularityHalfLife
+	}
+	return &popularityService{repo: repo, cache: c, halfLife: opts.HalfLife}
+}
+
+func (s *popularityService) RecordView(ctx context.Context, spuID uint64) error {
+	if _, err := s.cache.Eval(ctx, countProductView, []string{productViewsKey}, spuID); err != nil {
+		return fmt.Errorf("failed to count view of SPU %d: %w", spuID, err)
+	}
+	return nil
+}
+
+// FlushViews keeps the views it took from the counters until they are
+// written, so a failed flush is retried by the next one. Should dropping
+// them fail after they are written, they are counted twice.
+func (s *popularityService) FlushViews(ctx context.Context) (int, error) {
+	res, err := s.cache.Eval(ctx, takeProductViews, []string{productViewsKey, productViewsFlushingKey})
+	if err != nil {
+		return 0, fmt.Errorf("failed to take view counters: %w", err)
+	}
+	pairs, _ := res.([]interface{})
+	if len(pairs) == 0 {
+		return 0, nil
+	}
+	views := make(map[uint64]int64, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		field, _ := pairs[i].(string)
+		value, _ := pairs[i+1].(string)
+		spuID, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			continue
+		}
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || count <= 0 {
+			continue
+		}
+		views[spuID] = count
+	}
+
+	if _, err := s.repo.AddSPUViews(ctx, views); err != nil {
+		return 0, err
+	}
+	if err := s.cache.Del(ctx, productViewsFlushingKey); err != nil {
+		return len(views), fmt.Errorf("failed to drop flushed view counters: %w", err)
+	}
+	return len(views), nil
+}
+
+func (s *popularityService) Rescore(ctx context.Context) (int64, error) {
+	return s.repo.RescoreSPUs(ctx, s.halfLife, time.Now())
+}
+
+func (s *popularityService) ListPopular(ctx context.Context, limit int) ([]ProductResp, error) {
+	if limit <= 0 || limit > MaxPopularProducts {
+		limit = MaxPopularProducts
+	}
+	spus, err := s.repo.ListPopularSPUs(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	products := make([]ProductResp, len(spus))
+	for i := range spus {
+		products[i] = newProductResp(&spus[i])
+	}
+	return onChannel(ctx, products), nil
+}
@@ -0,0 +1,116 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPopularityService_FlushViews(t *testing.T) {
+	keys := []string{"mall:product:views", "mall:product:views:flushing"}
+
+	tests := []struct {
+		name      string
+		counters  interface{}
+		addErr    error
+		delErr    error
+		wantViews map[uint64]int64
+		wantCount int
+		wantErr   bool
+	}{
+		{
+			name:      "Flushed",
+			counters:  []interface{}{"101", "4", "102", "1", "bogus", "3"},
+			wantViews: map[uint64]int64{101: 4, 102: 1},
+			wantCount: 2,
+		},
+		{name: "NothingViewed", counters: []interface{}{}},
+		{
+			name:      "DatabaseDown", // The counters stay in the flushing key for the next flush
+			counters:  []interface{}{"101", "4"},
+			addErr:    errors.New("db down"),
+			wantViews: map[uint64]int64{101: 4},
+			wantErr:   true,
+		},
+		{
+			name:      "CountersNotDropped",
+			counters:  []interface{}{"101", "4"},
+			delErr:    errors.New("redis down"),
+			wantViews: map[uint64]int64{101: 4},
+			wantCount: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			mockCache := mocks.NewMockCache(ctrl)
+
+			mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), keys).Return(tt.counters, nil)
+			if tt.wantViews != nil {
+				productRepo.EXPECT().AddSPUViews(gomock.Any(), tt.wantViews).Return(int64(len(tt.wantViews)), tt.addErr)
+			}
+			if tt.wantViews != nil && tt.addErr == nil {
+				mockCache.EXPECT().Del(gomock.Any(), "mall:product:views:flushing").Return(tt.delErr)
+			}
+
+			svc := service.NewPopularityService(productRepo, mockCache, service.PopularityOptions{})
+			count, err := svc.FlushViews(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCount, count)
+		})
+	}
+}
+
+func TestPopularityService_RecordView(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache(ctrl)
+	mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:product:views"}, uint64(101)).Return(int64(1), nil)
+
+	svc := service.NewPopularityService(nil, mockCache, service.PopularityOptions{})
+	require.NoError(t, svc.RecordView(context.Background(), 101))
+}
+
+func TestPopularityService_Rescore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	productRepo := mocks.NewMockProductRepository(ctrl)
+	productRepo.EXPECT().RescoreSPUs(gomock.Any(), service.DefaultPopularityHalfLife, gomock.Any()).DoAndReturn(func(_ context.Context, _ time.Duration, at time.Time) (int64, error) {
+		assert.WithinDuration(t, time.Now(), at, time.Minute)
+		return 3, nil
+	})
+
+	svc := service.NewPopularityService(productRepo, nil, service.PopularityOptions{})
+	rescored, err := svc.Rescore(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), rescored)
+}
+
+func TestPopularityService_ListPopular(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	productRepo := mocks.NewMockProductRepository(ctrl)
+	productRepo.EXPECT().ListPopularSPUs(gomock.Any(), service.MaxPopularProducts).Return([]model.SPU{
+		{Base: model.Base{ID: 102}, Name: "Trending", SKUs: []model.SKU{{Base: model.Base{ID: 201}}}},
+		{Base: model.Base{ID: 101}, Name: "Steady"},
+	}, nil)
+
+	svc := service.NewPopularityService(productRepo, nil, service.PopularityOptions{HalfLife: time.Hour})
+	products, err := svc.ListPopular(context.Background(), 1000)
+	require.NoError(t, err)
+	require.Len(t, products, 2)
+	assert.Equal(t, "Trending", products[0].Name)
+	assert.Equal(t, uint64(201), products[0].SKUs[0].ID)
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/proyuen/go-mall/pkg/money"
+)
+
+// ErrPriceChanged means the order is not priced as the customer expected it
+// to be; errors.As gives the *PriceChangedError saying how.
+var ErrPriceChanged = apperr.New(apperr.CodePriceChanged)
+
+// PriceChange is an item whose unit price is not the one the customer saw.
+type PriceChange struct {
+	SKUID    uint64      `json:"sku_id,string"`
+	Expected money.Money `json:"expected"`
+	Actual   money.Money `json:"actual"`
+}
+
+// PriceChangedError lists what differs from the prices the customer
+// submitted, so that the client can show the new prices and ask again rather
+// than place an order the customer did not agree to.
+type PriceChangedError struct {
+	Items         []PriceChange `json:"items,omitempty"`          // Items whose expected price differs
+	ExpectedTotal *money.Money  `json:"expected_total,omitempty"` // Set when the expected total differs
+	ActualTotal   money.Money   `json:"actual_total"`
+}
+
+func (e *PriceChangedError) Error() string {
+	if e.ExpectedTotal != nil {
+		return fmt.Sprintf("%s: %d item prices changed, total is %s, not %s", ErrPriceChanged, len(e.Items), e.ActualTotal, e.ExpectedTotal)
+	}
+	return fmt.Sprintf("%s: %d item prices changed", ErrPriceChanged, len(e.Items))
+}
+
+func (e *PriceChangedError) Unwrap() error {
+	return ErrPriceChanged
+}
+
+// checkExpectedPrices compares the prices req expects with quote. Prices and
+// totals the customer saw in another currency differ too. Items without an
+// expected price, and a missing expected total, are not checked.
+func checkExpectedPrices(req *OrderCreateReq, quote *orderQuote) error {
+	changed := &PriceChangedError{ActualTotal: quote.total}
+	for i, itemReq := range req.Items {
+		if itemReq.ExpectedPrice == nil {
+			continue
+		}
+		actual := money.New(quote.items[i].Price, quote.currency)
+		if !itemReq.ExpectedPrice.Equal(actual) {
+			changed.Items = append(changed.Items, PriceChange{SKUID: itemReq.SKUID, Expected: *itemReq.ExpectedPrice, Actual: actual})
+		}
+	}
+	if req.ExpectedTotal != nil && !req.ExpectedTotal.Equal(quote.total) {
+		changed.ExpectedTotal = req.ExpectedTotal
+	}
+	if len(changed.Items) == 0 && changed.ExpectedTotal == nil {
+		return nil
+	}
+	return changed
+}
@@ -0,0 +1,75 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOrderService_ExpectedPrices(t *testing.T) {
+	usd := func(amount int64) *money.Money {
+		m := money.New(decimal.NewFromInt(amount), "USD")
+		return &m
+	}
+	eur := money.New(decimal.NewFromInt(50), "EUR")
+
+	tests := []struct {
+		name          string
+		expectedPrice *money.Money
+		expectedTotal *money.Money
+		wantChanged   bool
+		wantItems     int
+		wantTotal     bool
+	}{
+		{name: "NotExpected"},
+		{name: "Unchanged", expectedPrice: usd(50), expectedTotal: usd(100)},
+		{name: "PriceChanged", expectedPrice: usd(45), wantChanged: true, wantItems: 1},
+		{name: "TotalChanged", expectedTotal: usd(90), wantChanged: true, wantTotal: true},
+		{name: "OtherCurrency", expectedPrice: &eur, wantChanged: true, wantItems: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			cache := mocks.NewMockCache(ctrl)
+			productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101}).Return([]model.SKU{{Base: model.Base{ID: 101}, Price: decimal.NewFromInt(50), Currency: "USD", Stock: 100}}, nil)
+			if !tt.wantChanged {
+				cache.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			}
+
+			currencies := service.NewCurrencyService(nil, nil, nil, service.CurrencyOptions{Base: "USD"})
+			orderService := service.NewOrderService(nil, productRepo, nil, nil, nil, currencies, service.NewTaxService(nil), nil, nil, nil, cache, nil, nil, nil, service.OrderOptions{})
+			_, err := orderService.CreateCheckoutSession(context.Background(), &service.OrderCreateReq{
+				UserID:        1,
+				Currency:      "USD",
+				Items:         []service.OrderItemReq{{SKUID: 101, Quantity: 2, ExpectedPrice: tt.expectedPrice}},
+				ExpectedTotal: tt.expectedTotal,
+			})
+			if !tt.wantChanged {
+				require.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, service.ErrPriceChanged)
+			var changed *service.PriceChangedError
+			require.True(t, errors.As(err, &changed))
+			assert.Equal(t, "100.00 USD", changed.ActualTotal.String())
+			require.Len(t, changed.Items, tt.wantItems)
+			if tt.wantItems > 0 {
+				assert.Equal(t, uint64(101), changed.Items[0].SKUID)
+				assert.Equal(t, "50.00 USD", changed.Items[0].Actual.String())
+			}
+			assert.Equal(t, tt.wantTotal, changed.ExpectedTotal != nil)
+		})
+	}
+}
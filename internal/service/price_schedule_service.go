@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/database"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/proyuen/go-mall/pkg/tenant"
+	"github.com/shopspring/decimal"
+)
+
+// priceScheduleBatchSize is how many due schedules ApplyDue loads at a time.
+const priceScheduleBatchSize = 100
+
+var (
+	// ErrInvalidPriceSchedule means a price schedule has a price the SKU
+	// cannot have or a sale that ends before it starts.
+	ErrInvalidPriceSchedule = errors.New("invalid price schedule")
+	// ErrPriceScheduleConflict is returned for a sale that overlaps another
+	// pending or active sale of the SKU.
+	ErrPriceScheduleConflict = errors.New("sale overlaps another sale of the SKU")
+	// ErrPriceScheduleNotFound is returned when a price schedule does not exist.
+	ErrPriceScheduleNotFound = errors.New("price schedule not found")
+	// ErrPriceScheduleClosed is returned when cancelling a schedule that was
+	// applied or cancelled already.
+	ErrPriceScheduleClosed = errors.New("price schedule is no longer pending or active")
+)
+
+// PriceScheduleReq schedules a price change of a SKU at StartsAt. With
+// EndsAt it is a sale, after which the SKU's own price applies again.
+type PriceScheduleReq struct {
+	Price    decimal.Decimal // In the SKU's currency
+	StartsAt time.Time       // Zero means now
+	EndsAt   *time.Time
+}
+
+// PriceScheduleResp is a scheduled price change or sale of a SKU.
+type PriceScheduleResp struct {
+	ID        uint64      `json:"id,string"`
+	SKUID     uint64      `json:"sku_id,string"`
+	Price     money.Money `json:"price"` // In the SKU's currency
+	StartsAt  time.Time   `json:"starts_at"`
+	EndsAt    *time.Time  `json:"ends_at,omitempty"`        // Only sales end
+	Status    string      `json:"status" example:"pending"` // pending, active (a sale in progress), done or cancelled
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// PriceScheduleService schedules price changes and time-boxed sales of SKUs,
+// which cmd/worker applies when they are due. Product pages show a sale's
+// price along with the regular one while it runs, and orders are charged it.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/price_schedule_service_mock.go -package=mocks
+type PriceScheduleService interface {
+	// Schedule adds a price change or sale of a SKU. Sales of a SKU may not
+	// overlap.
+	Schedule(ctx context.Context, skuID uint64, req *PriceScheduleReq) (*PriceScheduleResp, error)
+	// List returns the schedules of a SKU, in the order they start.
+	List(ctx context.Context, skuID uint64) ([]PriceScheduleResp, error)
+	// Cancel withdraws a pending schedule, or ends an active sale at once.
+	Cancel(ctx context.Context, id uint64) error
+	// ApplyDue applies the schedules that start and ends the sales that end
+	// at or before now, across all stores, and drops the cached products of
+	// their SKUs. It returns how many it applied or ended.
+	ApplyDue(ctx context.Context) (int, error)
+}
+
+type priceScheduleService struct {
+	scheduleRepo repository.PriceScheduleRepository
+	productRepo  repository.ProductRepository
+	txManager    database.TransactionManager
+	catalog      *CatalogCache
+	events       EventPublisher
+	now          func() time.Time
+}
+
+// NewPriceScheduleService creates a new PriceScheduleService instance.
+// product.updated is published through events in the transaction that
+// changes a SKU's price.
+func NewPriceScheduleService(scheduleRepo repository.PriceScheduleRepository, productRepo repository.ProductRepository, txManager database.TransactionManager,
+	catalog *CatalogCache, events EventPublisher) PriceScheduleService {
+	return &priceScheduleService{
+		scheduleRepo: scheduleRepo,
+		productRepo:  productRepo,
+		txManager:    txManager,
+		catalog:      catalog,
+		events:       events,
+		now:          time.Now,
+	}
+}
+
+// Schedule accepts a StartsAt in the past: the change is applied on the next
+// run of cmd/worker.
+func (s *priceScheduleService) Schedule(ctx context.Context, skuID uint64, req *PriceScheduleReq) (*PriceScheduleResp, error) {
+	if !req.Price.IsPositive() || req.Price.GreaterThanOrEqual(maxSKUPrice) || !req.Price.Equal(req.Price.Truncate(money.Scale)) {
+		return nil, fmt.Errorf("%w: price %s", ErrInvalidPriceSchedule, req.Price)
+	}
+	startsAt := req.StartsAt
+	if startsAt.IsZero() {
+		startsAt = s.now()
+	}
+	if req.EndsAt != nil && (!req.EndsAt.After(startsAt) || !req.EndsAt.After(s.now())) {
+		return nil, fmt.Errorf("%w: a sale must end after it starts and in the future", ErrInvalidPriceSchedule)
+	}
+	sku, err := s.getSKU(ctx, skuID)
+	if err != nil {
+		return nil, err
+	}
+	if req.EndsAt != nil {
+		overlaps, err := s.scheduleRepo.HasOverlappingSale(ctx, skuID, startsAt, *req.EndsAt)
+		if err != nil {
+			return nil, err
+		}
+		if overlaps {
+			return nil, ErrPriceScheduleConflict
+		}
+	}
+
+	schedule := &model.PriceSchedule{
+		StoreID:  sku.StoreID,
+		SKUID:    skuID,
+		Price:    req.Price,
+		StartsAt: startsAt,
+		EndsAt:   req.EndsAt,
+		Status:   model.PriceSchedulePending,
+	}
+	if err := s.scheduleRepo.Create(ctx, schedule); err != nil {
+		return nil, err
+	}
+	RecordAudit(ctx, AuditEntry{
+		Action:     "sku.price_schedule",
+		Resource:   "sku",
+		ResourceID: strconv.FormatUint(skuID, 10),
+		After:      map[string]any{"price": schedule.Price, "currency": sku.Currency, "starts_at": schedule.StartsAt, "ends_at": schedule.EndsAt},
+	})
+	resp := newPriceScheduleResp(schedule, sku.Currency)
+	return &resp, nil
+}
+
+func (s *priceScheduleService) List(ctx context.Context, skuID uint64) ([]PriceScheduleResp, error) {
+	sku, err := s.getSKU(ctx, skuID)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := s.scheduleRepo.ListBySKU(ctx, skuID)
+	if err != nil {
+		return nil, err
+	}
+	resps := make([]PriceScheduleResp, len(schedules))
+	for i := range schedules {
+		resps[i] = newPriceScheduleResp(&schedules[i], sku.Currency)
+	}
+	return resps, nil
+}
+
+func (s *priceScheduleService) Cancel(ctx context.Context, id uint64) error {
+	schedule, err := s.scheduleRepo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrPriceScheduleNotFound) {
+		return ErrPriceScheduleNotFound
+	}
+	if err != nil {
+		return err
+	}
+	switch schedule.Status {
+	case model.PriceSchedulePending:
+		err = s.scheduleRepo.UpdateStatus(ctx, id, model.PriceSchedulePending, model.PriceScheduleCancelled)
+	case model.PriceScheduleActive:
+		err = s.apply(ctx, schedule, model.PriceScheduleCancelled, nil, nil)
+	default:
+		return ErrPriceScheduleClosed
+	}
+	if errors.Is(err, repository.ErrPriceScheduleChanged) {
+		return ErrPriceScheduleClosed // Applied or cancelled meanwhile
+	}
+	if err != nil {
+		return err
+	}
+	RecordAudit(ctx, AuditEntry{
+		Action:     "sku.price_schedule.cancel",
+		Resource:   "sku",
+		ResourceID: strconv.FormatUint(schedule.SKUID, 10),
+		Before:     map[string]any{"schedule_id": strconv.FormatUint(id, 10), "status": schedule.Status},
+	})
+	return nil
+}
+
+// ApplyDue stops at the first schedule it fails to apply; the next run starts
+// over with it. A sale whose whole window passed before it was applied, e.g.
+// while cmd/worker was down, is closed without changing the SKU.
+func (s *priceScheduleService) ApplyDue(ctx context.Context) (int, error) {
+	now := s.now()
+	applied := 0
+	for {
+		schedules, err := s.scheduleRepo.ListDue(ctx, now, priceScheduleBatchSize)
+		if err != nil {
+			return applied, err
+		}
+		for i := range schedules {
+			schedule := &schedules[i]
+			storeCtx := ctx
+			if schedule.StoreID != 0 {
+				storeCtx = tenant.NewContext(ctx, &model.Store{Base: model.Base{ID: schedule.StoreID}})
+			}
+			switch {
+			case schedule.Status == model.PriceScheduleActive:
+				err = s.apply(storeCtx, schedule, model.PriceScheduleDone, nil, nil)
+			case !schedule.IsSale():
+				err = s.apply(storeCtx, schedule, model.PriceScheduleDone, &schedule.Price, nil)
+			case schedule.EndsAt.After(now):
+				err = s.apply(storeCtx, schedule, model.PriceScheduleActive, &schedule.Price, schedule.EndsAt)
+			default:
+				slog.WarnContext(ctx, "Skipping sale that ended before it was applied", "schedule_id", schedule.ID, "sku_id", schedule.SKUID)
+				err = s.scheduleRepo.UpdateStatus(ctx, schedule.ID, model.PriceSchedulePending, model.PriceScheduleDone)
+			}
+			if errors.Is(err, repository.ErrPriceScheduleChanged) {
+				continue // Cancelled since it was listed
+			}
+			if err != nil {
+				return applied, fmt.Errorf("failed to apply price schedule %d: %w", schedule.ID, err)
+			}
+			applied++
+		}
+		if len(schedules) < priceScheduleBatchSize {
+			return applied, nil
+		}
+	}
+}
+
+// apply moves schedule to status and changes its SKU in one transaction. A
+// permanent change, which has no end, replaces the SKU's price with *price;
+// otherwise a sale starts at *price until endsAt, or ends when price is nil.
+// The SKU's product is dropped from the cache once committed.
+func (s *priceScheduleService) apply(ctx context.Context, schedule *model.PriceSchedule, status string, price *decimal.Decimal, endsAt *time.Time) error {
+	var spuID uint64
+	err := s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.scheduleRepo.UpdateStatus(ctx, schedule.ID, schedule.Status, status); err != nil {
+			return err
+		}
+		sku, err := s.productRepo.GetSKUByID(ctx, schedule.SKUID)
+		if err != nil {
+			return fmt.Errorf("failed to get SKU by ID %d: %w", schedule.SKUID, err)
+		}
+		spuID = sku.SPUID
+		if schedule.IsSale() {
+			err = s.productRepo.UpdateSKUSale(ctx, sku.ID, price, endsAt)
+		} else {
+			err = s.productRepo.UpdateSKUPrice(ctx, sku.ID, *price)
+		}
+		if err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, EventProductUpdated, ProductEventData{SPUID: spuID, SKUIDs: []uint64{sku.ID}})
+	})
+	if err != nil {
+		return err
+	}
+	// The product shows its old price until it expires if this fails
+	if err := s.catalog.Invalidate(ctx, spuID); err != nil {
+		slog.WarnContext(ctx, "Failed to invalidate cached product", "spu_id", spuID, logger.Err(err))
+	}
+	return nil
+}
+
+func (s *priceScheduleService) getSKU(ctx context.Context, skuID uint64) (*model.SKU, error) {
+	sku, err := s.productRepo.GetSKUByID(ctx, skuID)
+	if errors.Is(err, repository.ErrSKUNotFound) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SKU by ID %d: %w", skuID, err)
+	}
+	return sku, nil
+}
+
+func newPriceScheduleResp(schedule *model.PriceSchedule, currency string) PriceScheduleResp {
+	return PriceScheduleResp{
+		ID:        schedule.ID,
+		SKUID:     schedule.SKUID,
+		Price:     money.New(schedule.Price, currency),
+		StartsAt:  schedule.StartsAt,
+		EndsAt:    schedule.EndsAt,
+		Status:    schedule.Status,
+		CreatedAt: schedule.CreatedAt,
+	}
+}
@@ -0,0 +1,251 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPriceScheduleService_Schedule(t *testing.T) {
+	now := time.Now()
+	at := func(d time.Duration) *time.Time { t := now.Add(d); return &t }
+	sku := &model.SKU{Base: model.Base{ID: 5}, SPUID: 1, StoreID: 3, Price: decimal.NewFromInt(20), Currency: "USD"}
+
+	tests := []struct {
+		name      string
+		req       service.PriceScheduleReq
+		mockSetup func(scheduleRepo *mocks.MockPriceScheduleRepository, productRepo *mocks.MockProductRepository)
+		wantErrIs error
+	}{
+		{
+			name: "Sale",
+			req:  service.PriceScheduleReq{Price: decimal.RequireFromString("14.50"), StartsAt: now.Add(time.Hour), EndsAt: at(2 * time.Hour)},
+			mockSetup: func(scheduleRepo *mocks.MockPriceScheduleRepository, productRepo *mocks.MockProductRepository) {
+				productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(5)).Return(sku, nil)
+				scheduleRepo.EXPECT().HasOverlappingSale(gomock.Any(), uint64(5), now.Add(time.Hour), *at(2 * time.Hour)).Return(false, nil)
+				scheduleRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, schedule *model.PriceSchedule) error {
+					assert.Equal(t, uint64(3), schedule.StoreID)
+					assert.Equal(t, model.PriceSchedulePending, schedule.Status)
+					return nil
+				})
+			},
+		},
+		{
+			name: "PriceChangeStartsNow",
+			req:  service.PriceScheduleReq{Price: decimal.NewFromInt(25)},
+			mockSetup: func(scheduleRepo *mocks.MockPriceScheduleRepository, productRepo *mocks.MockProductRepository) {
+				productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(5)).Return(sku, nil)
+				scheduleRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, schedule *model.PriceSchedule) error {
+					assert.False(t, schedule.StartsAt.IsZero())
+					assert.Nil(t, schedule.EndsAt)
+					return nil
+				})
+			},
+		},
+		{
+			name:      "SubCentPrice",
+			req:       service.PriceScheduleReq{Price: decimal.RequireFromString("14.505")},
+			wantErrIs: service.ErrInvalidPriceSchedule,
+		},
+		{
+			name:      "EndsBeforeStart",
+			req:       service.PriceScheduleReq{Price: decimal.NewFromInt(10), StartsAt: now.Add(2 * time.Hour), EndsAt: at(time.Hour)},
+			wantErrIs: service.ErrInvalidPriceSchedule,
+		},
+		{
+			name:      "EndedAlready",
+			req:       service.PriceScheduleReq{Price: decimal.NewFromInt(10), StartsAt: now.Add(-2 * time.Hour), EndsAt: at(-time.Hour)},
+			wantErrIs: service.ErrInvalidPriceSchedule,
+		},
+		{
+			name: "UnknownSKU",
+			req:  service.PriceScheduleReq{Price: decimal.NewFromInt(10)},
+			mockSetup: func(_ *mocks.MockPriceScheduleRepository, productRepo *mocks.MockProductRepository) {
+				productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(5)).Return(nil, repository.ErrSKUNotFound)
+			},
+			wantErrIs: service.ErrProductNotFound,
+		},
+		{
+			name: "OverlappingSale",
+			req:  service.PriceScheduleReq{Price: decimal.NewFromInt(10), StartsAt: now.Add(time.Hour), EndsAt: at(2 * time.Hour)},
+			mockSetup: func(scheduleRepo *mocks.MockPriceScheduleRepository, productRepo *mocks.MockProductRepository) {
+				productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(5)).Return(sku, nil)
+				scheduleRepo.EXPECT().HasOverlappingSale(gomock.Any(), uint64(5), gomock.Any(), gomock.Any()).Return(true, nil)
+			},
+			wantErrIs: service.ErrPriceScheduleConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			scheduleRepo := mocks.NewMockPriceScheduleRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(scheduleRepo, productRepo)
+			}
+
+			svc := service.NewPriceScheduleService(scheduleRepo, productRepo, mocks.NewMockTransactionManager(ctrl), nil, mocks.NewMockEventPublisher(ctrl))
+			req := tt.req
+			resp, err := svc.Schedule(context.Background(), 5, &req)
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "USD", resp.Price.Currency())
+			assert.True(t, resp.Price.Amount().Equal(tt.req.Price))
+		})
+	}
+}
+
+func TestPriceScheduleService_ApplyDue(t *testing.T) {
+	now := time.Now()
+	at := func(d time.Duration) *time.Time { t := now.Add(d); return &t }
+	salePrice := decimal.RequireFromString("14.50")
+
+	tests := []struct {
+		name      string
+		schedule  model.PriceSchedule
+		wantTo    string
+		mockSetup func(productRepo *mocks.MockProductRepository, schedule *model.PriceSchedule)
+		wantCount int
+	}{
+		{
+			name:     "StartsSale",
+			schedule: model.PriceSchedule{Base: model.Base{ID: 9}, SKUID: 5, Price: salePrice, StartsAt: now.Add(-time.Minute), EndsAt: at(time.Hour), Status: model.PriceSchedulePending},
+			wantTo:   model.PriceScheduleActive,
+			mockSetup: func(productRepo *mocks.MockProductRepository, schedule *model.PriceSchedule) {
+				productRepo.EXPECT().UpdateSKUSale(gomock.Any(), uint64(5), &schedule.Price, schedule.EndsAt).Return(nil)
+			},
+			wantCount: 1,
+		},
+		{
+			name:     "EndsSale",
+			schedule: model.PriceSchedule{Base: model.Base{ID: 9}, SKUID: 5, Price: salePrice, StartsAt: now.Add(-time.Hour), EndsAt: at(-time.Minute), Status: model.PriceScheduleActive},
+			wantTo:   model.PriceScheduleDone,
+			mockSetup: func(productRepo *mocks.MockProductRepository, _ *model.PriceSchedule) {
+				productRepo.EXPECT().UpdateSKUSale(gomock.Any(), uint64(5), nil, nil).Return(nil)
+			},
+			wantCount: 1,
+		},
+		{
+			name:     "ChangesPrice",
+			schedule: model.PriceSchedule{Base: model.Base{ID: 9}, SKUID: 5, Price: decimal.NewFromInt(25), StartsAt: now.Add(-time.Minute), Status: model.PriceSchedulePending},
+			wantTo:   model.PriceScheduleDone,
+			mockSetup: func(productRepo *mocks.MockProductRepository, _ *model.PriceSchedule) {
+				productRepo.EXPECT().UpdateSKUPrice(gomock.Any(), uint64(5), decimal.NewFromInt(25)).Return(nil)
+			},
+			wantCount: 1,
+		},
+		{
+			name:      "MissedSale",
+			schedule:  model.PriceSchedule{Base: model.Base{ID: 9}, SKUID: 5, Price: salePrice, StartsAt: now.Add(-2 * time.Hour), EndsAt: at(-time.Hour), Status: model.PriceSchedulePending},
+			wantTo:    model.PriceScheduleDone,
+			wantCount: 1,
+		},
+		{
+			name:     "CancelledMeanwhile",
+			schedule: model.PriceSchedule{Base: model.Base{ID: 9}, SKUID: 5, Price: decimal.NewFromInt(25), StartsAt: now.Add(-time.Minute), Status: model.PriceSchedulePending},
+			wantTo:   model.PriceScheduleDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			scheduleRepo := mocks.NewMockPriceScheduleRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			events := mocks.NewMockEventPublisher(ctrl)
+			mockCache := mocks.NewMockCache(ctrl)
+			schedule := tt.schedule
+
+			scheduleRepo.EXPECT().ListDue(gomock.Any(), gomock.Any(), gomock.Any()).Return([]model.PriceSchedule{schedule}, nil)
+			if tt.mockSetup == nil {
+				// Closed without touching the SKU, or lost the race to a cancel
+				var err error
+				if tt.wantCount == 0 {
+					err = repository.ErrPriceScheduleChanged
+					txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+						return fn(ctx)
+					})
+				}
+				scheduleRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(9), schedule.Status, tt.wantTo).Return(err)
+			} else {
+				txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+				scheduleRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(9), schedule.Status, tt.wantTo).Return(nil)
+				productRepo.EXPECT().GetSKUByID(gomock.Any(), uint64(5)).Return(&model.SKU{Base: model.Base{ID: 5}, SPUID: 1}, nil)
+				tt.mockSetup(productRepo, &schedule)
+				events.EXPECT().Publish(gomock.Any(), service.EventProductUpdated, service.ProductEventData{SPUID: 1, SKUIDs: []uint64{5}}).Return(nil)
+				mockCache.EXPECT().Del(gomock.Any(), gomock.Any()).Return(nil)
+				mockCache.EXPECT().Set(gomock.Any(), "mall:product:list:tag", gomock.Any(), time.Duration(0)).Return(nil)
+			}
+
+			svc := service.NewPriceScheduleService(scheduleRepo, productRepo, txManager, service.NewCatalogCache(mockCache, service.CatalogCacheOptions{}), events)
+			applied, err := svc.ApplyDue(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCount, applied)
+		})
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		scheduleRepo := mocks.NewMockPriceScheduleRepository(ctrl)
+		scheduleRepo.EXPECT().ListDue(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+
+		svc := service.NewPriceScheduleService(scheduleRepo, mocks.NewMockProductRepository(ctrl), mocks.NewMockTransactionManager(ctrl), nil, mocks.NewMockEventPublisher(ctrl))
+		_, err := svc.ApplyDue(context.Background())
+		assert.Error(t, err)
+	})
+}
+
+func TestPriceScheduleService_Cancel(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    string
+		getErr    error
+		updateErr error
+		wantErrIs error
+	}{
+		{name: "Pending", status: model.PriceSchedulePending},
+		{name: "AppliedMeanwhile", status: model.PriceSchedulePending, updateErr: repository.ErrPriceScheduleChanged, wantErrIs: service.ErrPriceScheduleClosed},
+		{name: "Done", status: model.PriceScheduleDone, wantErrIs: service.ErrPriceScheduleClosed},
+		{name: "NotFound", getErr: repository.ErrPriceScheduleNotFound, wantErrIs: service.ErrPriceScheduleNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			scheduleRepo := mocks.NewMockPriceScheduleRepository(ctrl)
+			if tt.getErr != nil {
+				scheduleRepo.EXPECT().GetByID(gomock.Any(), uint64(9)).Return(nil, tt.getErr)
+			} else {
+				scheduleRepo.EXPECT().GetByID(gomock.Any(), uint64(9)).Return(&model.PriceSchedule{Base: model.Base{ID: 9}, SKUID: 5, Status: tt.status}, nil)
+			}
+			if tt.status == model.PriceSchedulePending {
+				scheduleRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(9), model.PriceSchedulePending, model.PriceScheduleCancelled).Return(tt.updateErr)
+			}
+
+			svc := service.NewPriceScheduleService(scheduleRepo, mocks.NewMockProductRepository(ctrl), mocks.NewMockTransactionManager(ctrl), nil, mocks.NewMockEventPublisher(ctrl))
+			err := svc.Cancel(context.Background(), 9)
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// MaxAttributeFacetValues is how many values of each attribute ListFacets
+// counts, most common first.
+const MaxAttributeFacetValues = 20
+
+// priceFacetBounds split SKU prices into the buckets of the price facet, in
+// the currency each SKU is priced in.
+var priceFacetBounds = []decimal.Decimal{
+	decimal.NewFromInt(10),
+	decimal.NewFromInt(25),
+	decimal.NewFromInt(50),
+	decimal.NewFromInt(100),
+	decimal.NewFromInt(250),
+	decimal.NewFromInt(500),
+	decimal.NewFromInt(1000),
+}
+
+// ProductFacets counts the products of a store per value of each filter a
+// storefront offers. A product counts once per value any of its SKUs has.
+type ProductFacets struct {
+	Categories []CategoryFacet  `json:"categories"`
+	Prices     []PriceFacet     `json:"prices"`
+	Attributes []AttributeFacet `json:"attributes"` // Brand among them, for SKUs with a brand attribute
+}
+
+// CategoryFacet counts the products in a category.
+type CategoryFacet struct {
+	CategoryID uint64 `json:"category_id,string"`
+	Name       string `json:"name" example:"Shoes"`
+	Products   int64  `json:"products"`
+}
+
+// PriceFacet counts the products with a SKU priced from Min up to Max.
+type PriceFacet struct {
+	Currency string           `json:"currency" example:"USD"`
+	Min      decimal.Decimal  `json:"min" swaggertype:"string" example:"25"`           // Inclusive
+	Max      *decimal.Decimal `json:"max,omitempty" swaggertype:"string" example:"50"` // Exclusive; omitted for the top bucket
+	Products int64            `json:"products"`
+}
+
+// AttributeFacet counts the products per value of a SKU attribute.
+type AttributeFacet struct {
+	Name   string                `json:"name" example:"color"`
+	Values []AttributeValueFacet `json:"values"` // Most common first
+}
+
+// AttributeValueFacet counts the products with an attribute value.
+type AttributeValueFacet struct {
+	Value    string `json:"value" example:"red"`
+	Products int64  `json:"products"`
+}
+
+// ListFacets counts the whole catalog of the store, caching the counts with
+// the list pages.
+func (s *productService) ListFacets(ctx context.Context) (*ProductFacets, error) {
+	return s.catalog.Facets(ctx, s.countFacets)
+}
+
+func (s *productService) countFacets(ctx context.Context) (*ProductFacets, error) {
+	facets := &ProductFacets{Categories: []CategoryFacet{}, Prices: []PriceFacet{}, Attributes: []AttributeFacet{}}
+
+	categoryCounts, err := s.repo.CountSPUsByCategory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint64, len(categoryCounts))
+	for i, count := range categoryCounts {
+		ids[i] = count.CategoryID
+	}
+	categories, err := s.categoryRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	names := make(map[uint64]string, len(categories))
+	for _, category := range categories {
+		names[category.ID] = category.Name
+	}
+	for _, count := range categoryCounts {
+		if name, ok := names[count.CategoryID]; ok {
+			facets.Categories = append(facets.Categories, CategoryFacet{CategoryID: count.CategoryID, Name: name, Products: count.Products})
+		}
+	}
+
+	priceCounts, err := s.repo.CountSPUsByPrice(ctx, priceFacetBounds)
+	if err != nil {
+		return nil, err
+	}
+	for _, count := range priceCounts {
+		facet := PriceFacet{Currency: count.Currency, Products: count.Products}
+		if count.Bucket > 0 {
+			facet.Min = priceFacetBounds[count.Bucket-1]
+		}
+		if count.Bucket < len(priceFacetBounds) {
+			facet.Max = &priceFacetBounds[count.Bucket]
+		}
+		facets.Prices = append(facets.Prices, facet)
+	}
+
+	attributeCounts, err := s.repo.CountSPUsByAttribute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, count := range attributeCounts {
+		last := len(facets.Attributes) - 1
+		if last < 0 || facets.Attributes[last].Name != count.Name {
+			facets.Attributes = append(facets.Attributes, AttributeFacet{Name: count.Name})
+			last++
+		}
+		if len(facets.Attributes[last].Values) < MaxAttributeFacetValues {
+			facets.Attributes[last].Values = append(facets.Attributes[last].Values, AttributeValueFacet{Value: count.Value, Products: count.Products})
+		}
+	}
+	return facets, nil
+}
@@ -0,0 +1,78 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestProductService_ListFacets(t *testing.T) {
+	t.Run("Counted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		productRepo := mocks.NewMockProductRepository(ctrl)
+		categoryRepo := mocks.NewMockCategoryRepository(ctrl)
+		mockCache := mocks.NewMockCache(ctrl)
+		ctx := context.Background()
+
+		mockCache.EXPECT().Get(ctx, "mall:product:list:tag").Return("t1", nil)
+		mockCache.EXPECT().Get(ctx, "mall:product:facets:t1").Return("", nil)
+		productRepo.EXPECT().CountSPUsByCategory(ctx).Return([]repository.CategoryCount{{CategoryID: 5, Products: 3}, {CategoryID: 6, Products: 1}}, nil)
+		categoryRepo.EXPECT().GetByIDs(ctx, []uint64{5, 6}).Return([]model.Category{{Base: model.Base{ID: 5}, Name: "Shoes"}}, nil)
+		productRepo.EXPECT().CountSPUsByPrice(ctx, gomock.Len(7)).Return([]repository.PriceBucketCount{
+			{Currency: "USD", Bucket: 0, Products: 2},
+			{Currency: "USD", Bucket: 2, Products: 1},
+			{Currency: "USD", Bucket: 7, Products: 1},
+		}, nil)
+		attributeCounts := []repository.AttributeValueCount{{Name: "brand", Value: "Acme", Products: 2}}
+		for range service.MaxAttributeFacetValues + 1 {
+			attributeCounts = append(attributeCounts, repository.AttributeValueCount{Name: "color", Value: "red", Products: 1})
+		}
+		productRepo.EXPECT().CountSPUsByAttribute(ctx).Return(attributeCounts, nil)
+		mockCache.EXPECT().Set(ctx, "mall:product:facets:t1", gomock.Any(), time.Hour).Return(nil)
+
+		svc := service.NewProductService(productRepo, categoryRepo, service.NewCatalogCache(mockCache, service.CatalogCacheOptions{}), nil, nil, nil)
+		facets, err := svc.ListFacets(ctx)
+		require.NoError(t, err)
+
+		assert.Equal(t, []service.CategoryFacet{{CategoryID: 5, Name: "Shoes", Products: 3}}, facets.Categories, "categories that no longer exist are left out")
+		require.Len(t, facets.Prices, 3)
+		assert.True(t, facets.Prices[0].Min.IsZero())
+		assert.Equal(t, "10", facets.Prices[0].Max.String())
+		assert.Equal(t, "25", facets.Prices[1].Min.String())
+		assert.Equal(t, "50", facets.Prices[1].Max.String())
+		assert.Equal(t, "1000", facets.Prices[2].Min.String())
+		assert.Nil(t, facets.Prices[2].Max)
+		require.Len(t, facets.Attributes, 2)
+		assert.Equal(t, "brand", facets.Attributes[0].Name)
+		assert.Len(t, facets.Attributes[1].Values, service.MaxAttributeFacetValues)
+
+		body, err := json.Marshal(facets.Prices[2])
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"currency":"USD","min":"1000","products":1}`, string(body))
+	})
+
+	t.Run("CountFailed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		productRepo := mocks.NewMockProductRepository(ctrl)
+		mockCache := mocks.NewMockCache(ctrl)
+		ctx := context.Background()
+
+		mockCache.EXPECT().Get(ctx, "mall:product:list:tag").Return("t1", nil)
+		mockCache.EXPECT().Get(ctx, "mall:product:facets:t1").Return("", nil)
+		productRepo.EXPECT().CountSPUsByCategory(ctx).Return(nil, errors.New("db down"))
+
+		svc := service.NewProductService(productRepo, nil, service.NewCatalogCache(mockCache, service.CatalogCacheOptions{}), nil, nil, nil)
+		_, err := svc.ListFacets(ctx)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+)
+
+// ErrInvalidProductOption is returned for product options that are not
+// valid, whether a product is created with them or an order chooses them.
+var ErrInvalidProductOption = errors.New("invalid product option")
+
+// ProductOptionReq defines an option of a product to be created.
+type ProductOptionReq struct {
+	Code      string          `json:"code"`
+	Name      string          `json:"name"`
+	Kind      string          `json:"kind"`       // flag, or text for options chosen with a text
+	MaxLength int             `json:"max_length"` // Of a text option's value; 0 is the most an order holds
+	Price     decimal.Decimal `json:"price"`      // Added to the unit price of every SKU
+	Currency  string          `json:"currency"`   // ISO 4217 code; empty means the base currency
+}
+
+// ProductOptionResp is an option customers may choose for every unit of a
+// product's SKUs.
+type ProductOptionResp struct {
+	Code      string      `json:"code" example:"engraving"`
+	Name      string      `json:"name" example:"Engraving"`
+	Kind      string      `json:"kind" example:"text"`               // flag, or text for options chosen with a text
+	MaxLength int         `json:"max_length,omitempty" example:"30"` // Of a text option's value
+	Price     money.Money `json:"price"`                             // Added to the unit price
+}
+
+// OrderItemOptionReq chooses a product option for every unit of an order
+// item.
+type OrderItemOptionReq struct {
+	Code  string `json:"code"`
+	Value string `json:"value"` // A text option's; empty for flags
+}
+
+// OrderItemOptionResp is a product option chosen for an order item.
+type OrderItemOptionResp struct {
+	Code  string      `json:"code" example:"engraving"`
+	Name  string      `json:"name" example:"Engraving"`
+	Value string      `json:"value,omitempty" example:"Happy birthday"`
+	Price money.Money `json:"price"` // Per unit, included in the item's price
+}
+
+// newProductOptions checks the options of a product to be created. Their
+// prices are in one of the currencies supported by currencies.
+func newProductOptions(currencies CurrencyService, reqs []ProductOptionReq) ([]model.ProductOption, error) {
+	options := make([]model.ProductOption, len(reqs))
+	codes := make(map[string]bool, len(reqs))
+	for i, req := range reqs {
+		if codes[req.Code] {
+			return nil, fmt.Errorf("%w: %q given twice", ErrInvalidProductOption, req.Code)
+		}
+		codes[req.Code] = true
+		if req.Kind != model.ProductOptionFlag && req.Kind != model.ProductOptionText {
+			return nil, fmt.Errorf("%w: unknown kind %q of %q", ErrInvalidProductOption, req.Kind, req.Code)
+		}
+		if req.Price.IsNegative() {
+			return nil, fmt.Errorf("%w: negative price of %q", ErrInvalidProductOption, req.Code)
+		}
+		currency := strings.ToUpper(req.Currency)
+		if currency == "" {
+			currency = currencies.Base()
+		} else if !currencies.IsSupported(currency) {
+			return nil, fmt.Errorf("%w: %q", ErrUnsupportedCurrency, req.Currency)
+		}
+		options[i] = model.ProductOption{
+			Code:      req.Code,
+			Name:      req.Name,
+			Kind:      req.Kind,
+			MaxLength: req.MaxLength,
+			Price:     req.Price,
+			Currency:  currency,
+		}
+	}
+	return options, nil
+}
+
+func newProductOptionResps(options []model.ProductOption) []ProductOptionResp {
+	if len(options) == 0 {
+		return nil
+	}
+	resps := make([]ProductOptionResp, len(options))
+	for i, option := range options {
+		resps[i] = ProductOptionResp{
+			Code:      option.Code,
+			Name:      option.Name,
+			Kind:      option.Kind,
+			MaxLength: option.MaxLength,
+			Price:     money.New(option.Price, option.Currency),
+		}
+	}
+	return resps
+}
+
+// newOrderItemOptionResps converts the options of an item of an order
+// charged in currency.
+func newOrderItemOptionResps(currency string, options []model.OrderItemOption) []OrderItemOptionResp {
+	if len(options) == 0 {
+		return nil
+	}
+	resps := make([]OrderItemOptionResp, len(options))
+	for i, option := range options {
+		resps[i] = OrderItemOptionResp{Code: option.Code, Name: option.Name, Value: option.Value, Price: money.New(option.Price, currency)}
+	}
+	return resps
+}
+
+// productOptions loads the options of the SPUs of the items that choose
+// any, by SPU and code. skus lines up with items. Orders without options
+// load nothing.
+func (s *orderService) productOptions(ctx context.Context, items []OrderItemReq, skus []model.SKU) (map[uint64]map[string]model.ProductOption, error) {
+	var spuIDs []uint64
+	for i, item := range items {
+		if len(item.Options) > 0 {
+			spuIDs = append(spuIDs, skus[i].SPUID)
+		}
+	}
+	if len(spuIDs) == 0 {
+		return nil, nil
+	}
+	options, err := s.productRepo.ListProductOptions(ctx, spuIDs)
+	if err != nil {
+		return nil, err
+	}
+	bySPU := make(map[uint64]map[string]model.ProductOption, len(spuIDs))
+	for _, option := range options {
+		if bySPU[option.SPUID] == nil {
+			bySPU[option.SPUID] = make(map[string]model.ProductOption)
+		}
+		bySPU[option.SPUID][option.Code] = option
+	}
+	return bySPU, nil
+}
+
+// chooseOptions checks the options chosen for a unit of the SKU with skuID
+// against schema, the options of its SPU, and prices them with convert. It
+// returns them with what they add to the unit price.
+func chooseOptions(skuID uint64, chosen []OrderItemOptionReq, schema map[string]model.ProductOption, convert func(decimal.Decimal, string) (decimal.Decimal, error)) ([]model.OrderItemOption, decimal.Decimal, error) {
+	options := make([]model.OrderItemOption, 0, len(chosen))
+	total := decimal.Zero
+	for _, c := range chosen {
+		option, ok := schema[c.Code]
+		if !ok {
+			return nil, decimal.Zero, fmt.Errorf("%w: %q is not an option of SKU %d", ErrInvalidProductOption, c.Code, skuID)
+		}
+		for _, o := range options {
+			if o.Code == c.Code {
+				return nil, decimal.Zero, fmt.Errorf("%w: %q chosen twice for SKU %d", ErrInvalidProductOption, c.Code, skuID)
+			}
+		}
+		switch option.Kind {
+		case model.ProductOptionFlag:
+			if c.Value != "" {
+				return nil, decimal.Zero, fmt.Errorf("%w: %q takes no value", ErrInvalidProductOption, c.Code)
+			}
+		case model.ProductOptionText:
+			maxLength := option.MaxLength
+			if maxLength <= 0 || maxLength > model.MaxOrderItemOptionValue {
+				maxLength = model.MaxOrderItemOptionValue
+			}
+			if strings.TrimSpace(c.Value) == "" || utf8.RuneCountInString(c.Value) > maxLength {
+				return nil, decimal.Zero, fmt.Errorf("%w: %q takes a text of at most %d characters", ErrInvalidProductOption, c.Code, maxLength)
+			}
+		}
+		price, err := convert(option.Price, option.Currency)
+		if err != nil {
+			return nil, decimal.Zero, fmt.Errorf("failed to price option %q of SKU %d: %w", c.Code, skuID, err)
+		}
+		options = append(options, model.OrderItemOption{Code: option.Code, Name: option.Name, Value: c.Value, Price: price})
+		total = total.Add(price)
+	}
+	return options, total, nil
+}
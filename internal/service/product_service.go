@@ -5,72 +5,135 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/proyuen/go-mall/internal/model"
 	"github.com/proyuen/go-mall/internal/repository"
-	"github.com/proyuen/go-mall/pkg/cache" // Import cache package
+	"github.com/proyuen/go-mall/pkg/channel"
+	"github.com/proyuen/go-mall/pkg/database"
+	"github.com/proyuen/go-mall/pkg/logger"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
 // ProductCreateReq defines the request structure for creating a new product.
 type ProductCreateReq struct {
-	Name        string            `json:"name"`
-	Description string            `json:"description"`
-	CategoryID  uint64            `json:"category_id,string"` // Changed to uint64
-	SKUs        []SKUCreateReq    `json:"skus"`               // List of SKUs for this product
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	CategoryID  uint64         `json:"category_id,string"` // Changed to uint64
+	SKUs        []SKUCreateReq `json:"skus"`               // List of SKUs for this product
+	// Options may be chosen for every unit of any of its SKUs, at a price
+	// on top of the SKU's.
+	Options []ProductOptionReq `json:"options"`
 }
 
 // SKUCreateReq defines the request structure for creating an SKU within a product.
 type SKUCreateReq struct {
-	Attributes json.RawMessage `json:"attributes"` // Use RawMessage for flexibility, will unmarshal to model.JSONB
-	Price      decimal.Decimal `json:"price"`      // Changed to decimal.Decimal
-	Stock      int             `json:"stock"`
+	Attributes    json.RawMessage `json:"attributes"` // Use RawMessage for flexibility, will unmarshal to model.JSONB
+	Price         decimal.Decimal `json:"price"`      // Changed to decimal.Decimal
+	Currency      string          `json:"currency"`   // ISO 4217 code; empty means the base currency
+	Stock         int             `json:"stock"`
+	PurchaseLimit int             `json:"purchase_limit"` // Most units one customer may buy; 0 is unlimited
+	PreOrder      bool            `json:"pre_order"`      // Orderable without stock, as a backorder
+	AvailableAt   *time.Time      `json:"available_at"`   // When a pre-order SKU is expected in stock
+	Delivery      string          `json:"delivery"`       // license_key or download for digital goods; empty ships
+	DownloadPath  string          `json:"download_path"`  // download only: the file under digital.download_base_url
+	Channels      []string        `json:"channels"`       // Sales channels it is sold on; empty sells it on all
+	Warehouse     string          `json:"warehouse"`      // Which warehouse stocks it, which splits shipments
+	WarehouseZone string          `json:"warehouse_zone"` // Where in the warehouse it is stored, which groups pick tasks
+	// Components make the SKU a bundle of other SKUs of the store, sold at
+	// Price; its Stock is ignored, as it takes its components' stock.
+	Components []SKUComponentReq `json:"components"`
 	// Image removed as per model definition
 }
 
+// ErrUnknownChannel is returned for a SKU sold on a sales channel that does
+// not exist.
+var ErrUnknownChannel = errors.New("unknown sales channel")
+
 // ProductCreateResp defines the response structure after creating a product.
 type ProductCreateResp struct {
-	SPUID uint64 `json:"spu_id,string"` // Changed to uint64
+	SPUID uint64 `json:"spu_id,string"` // Snowflake ID
 }
 
 // ProductResp defines the response structure for a product (SPU with its SKUs).
 type ProductResp struct {
-	ID          uint64        `json:"id,string"`          // Changed to uint64
-	Name        string        `json:"name"`
-	Description string        `json:"description"`
-	CategoryID  uint64        `json:"category_id,string"` // Changed to uint64
-	SKUs        []SKUResp     `json:"skus"`
+	ID          uint64    `json:"id,string"` // Snowflake ID
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CategoryID  uint64    `json:"category_id,string"`
+	SKUs        []SKUResp `json:"skus"`
+	// Options may be chosen for every unit ordered of its SKUs. Only
+	// product detail has them.
+	Options []ProductOptionResp `json:"options,omitempty"`
+	// Rating summarizes the product's reviews. Only product detail has it.
+	Rating *RatingSummary `json:"rating,omitempty"`
+}
+
+// RatingSummary is how a product was rated by its reviews.
+type RatingSummary struct {
+	Average float64 `json:"average" example:"4.25"` // Stars, rounded to 2 decimals; 0 without reviews
+	Count   int64   `json:"count"`
+	// Histogram counts the reviews per star, one star first.
+	Histogram [5]int64 `json:"histogram" swaggertype:"array,integer" example:"1,0,2,5,12"`
 }
 
 // SKUResp defines the response structure for an SKU.
 type SKUResp struct {
-	ID         uint64          `json:"id,string"`      // Changed to uint64
-	Attributes model.JSONB     `json:"attributes"` // Changed to model.JSONB for response
-	Price      decimal.Decimal `json:"price"`      // Changed to decimal.Decimal
-	Stock      int             `json:"stock"`
+	ID            uint64           `json:"id,string"` // Snowflake ID
+	Attributes    model.JSONB      `json:"attributes"`
+	Price         decimal.Decimal  `json:"price" swaggertype:"string" example:"19.99"` // Serialized as a decimal string; the sale price during a sale
+	Currency      string           `json:"currency" example:"USD"`
+	RegularPrice  *decimal.Decimal `json:"regular_price,omitempty" swaggertype:"string" example:"24.99"` // The price outside the sale the SKU is on; omitted outside sales
+	SaleEndsAt    *time.Time       `json:"sale_ends_at,omitempty"`                                       // When the sale ends
+	Stock         int              `json:"stock"`
+	PurchaseLimit int              `json:"purchase_limit,omitempty"` // Most units one customer may buy; omitted when unlimited
+	PreOrder      bool             `json:"pre_order,omitempty"`      // Orderable without stock; orders wait for it to arrive
+	AvailableAt   *time.Time       `json:"available_at,omitempty"`   // When a pre-order SKU is expected in stock
+	Delivery      string           `json:"delivery,omitempty"`       // license_key or download for digital goods, sent once paid, bundle for kits shipped as their components; omitted when shipped
+	Channels      []string         `json:"channels,omitempty"`       // Sales channels it is sold on; omitted when sold on all
 	// Image removed as per model definition
 }
 
-//go:generate mockgen -source=$GOFILE -destination=../mocks/product_service_mock.go -package=mocks
 // ProductService defines the interface for product business logic.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/product_service_mock.go -package=mocks
 type ProductService interface {
 	CreateProduct(ctx context.Context, req *ProductCreateReq) (*ProductCreateResp, error)
 	GetProduct(ctx context.Context, spuID uint64) (*ProductResp, error) // Changed to uint64
 	ListProducts(ctx context.Context, offset, limit int) ([]ProductResp, error)
+	BulkUpdatePrices(ctx context.Context, req *BulkPriceReq) (*BulkPriceResp, error)
+	// ListFacets counts the store's products per category, price bucket and
+	// attribute value.
+	ListFacets(ctx context.Context) (*ProductFacets, error)
 }
 
 type productService struct {
-	repo  repository.ProductRepository
-	cache cache.Cache // Add cache dependency
+	repo         repository.ProductRepository
+	categoryRepo repository.CategoryRepository
+	catalog      *CatalogCache
+	currencies   CurrencyService
+	txManager    database.TransactionManager
+	events       EventPublisher
 }
 
-// NewProductService creates a new ProductService instance.
-func NewProductService(repo repository.ProductRepository, cache cache.Cache) ProductService {
+// NewProductService creates a new ProductService instance. Products are
+// cached in catalog; SKUs are priced in one of the currencies supported by
+// currencies. product.updated is published through events in the
+// transaction that changes a product.
+func NewProductService(repo repository.ProductRepository, categoryRepo repository.CategoryRepository, catalog *CatalogCache,
+	currencies CurrencyService, txManager database.TransactionManager, events EventPublisher) ProductService {
 	return &productService{
-		repo:  repo,
-		cache: cache,
+		repo:         repo,
+		categoryRepo: categoryRepo,
+		catalog:      catalog,
+		currencies:   currencies,
+		txManager:    txManager,
+		events:       events,
 	}
 }
 
@@ -86,105 +149,235 @@ func (s *productService) CreateProduct(ctx context.Context, req *ProductCreateRe
 			}
 		}
 
+		currency := strings.ToUpper(skuReq.Currency)
+		if currency == "" {
+			currency = s.currencies.Base()
+		} else if !s.currencies.IsSupported(currency) {
+			return nil, fmt.Errorf("%w: %q", ErrUnsupportedCurrency, skuReq.Currency)
+		}
+
+		channels, err := joinChannels(skuReq.Channels)
+		if err != nil {
+			return nil, err
+		}
+
+		// A bundle has no stock of its own, its components have
+		stock, delivery := skuReq.Stock, skuReq.Delivery
+		var components []model.SKUComponent
+		if len(skuReq.Components) > 0 {
+			if components, err = bundleComponents(ctx, s.repo, &skuReq); err != nil {
+				return nil, err
+			}
+			stock, delivery = 0, model.SKUDeliveryBundle
+		}
+
 		skus = append(skus, model.SKU{
-			Attributes: attributes,
-			Price:      skuReq.Price,
-			Stock:      skuReq.Stock,
+			Attributes:    attributes,
+			Price:         skuReq.Price,
+			Currency:      currency,
+			Stock:         stock,
+			PurchaseLimit: skuReq.PurchaseLimit,
+			PreOrder:      skuReq.PreOrder,
+			AvailableAt:   skuReq.AvailableAt,
+			Delivery:      delivery,
+			DownloadPath:  skuReq.DownloadPath,
+			Channels:      channels,
+			Warehouse:     skuReq.Warehouse,
+			WarehouseZone: skuReq.WarehouseZone,
+			Components:    components,
 			// Image removed
 		})
 	}
 
+	options, err := newProductOptions(s.currencies, req.Options)
+	if err != nil {
+		return nil, err
+	}
+
 	// Assemble SPU with embedded SKUs
 	spu := &model.SPU{
 		Name:        req.Name,
 		Description: req.Description,
 		CategoryID:  req.CategoryID,
 		SKUs:        skus, // GORM will handle the association creation
+		Options:     options,
 	}
 
 	// Save SPU (and SKUs automatically via GORM association)
 	if err := s.repo.CreateSPU(ctx, spu); err != nil {
 		return nil, fmt.Errorf("failed to create product: %w", err)
 	}
+	// The product is on list pages from now on
+	if err := s.catalog.Invalidate(ctx); err != nil {
+		slog.WarnContext(ctx, "Failed to invalidate cached product lists", "spu_id", spu.ID, logger.Err(err))
+	}
 
 	return &ProductCreateResp{SPUID: spu.ID}, nil
 }
 
-// GetProduct retrieves a product (SPU) with all its associated SKUs.
+// GetProduct retrieves a product (SPU) with its SKUs sold on the sales
+// channel of ctx. A product none of whose SKUs are sold there is not found.
 func (s *productService) GetProduct(ctx context.Context, spuID uint64) (*ProductResp, error) {
-	// 1. Try to fetch from cache
-	cacheKey := fmt.Sprintf("mall:product:spu:%d", spuID)
-	cachedVal, err := s.cache.Get(ctx, cacheKey)
-	if err == nil && cachedVal != "" {
-		var resp ProductResp
-		if err := json.Unmarshal([]byte(cachedVal), &resp); err == nil {
-			return &resp, nil
+	product, err := s.catalog.Product(ctx, spuID, func(ctx context.Context) (*ProductResp, error) {
+		spu, err := s.repo.GetSPUByID(ctx, spuID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrProductNotFound
+			}
+			return nil, fmt.Errorf("failed to get SPU by ID %d: %w", spuID, err)
 		}
-		// Fallback to DB if unmarshal fails (log error in production)
+		resp := newProductResp(spu)
+		resp.Options = newProductOptionResps(spu.Options)
+		resp.Rating = newRatingSummary(spu.Rating)
+		return &resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	visible := onChannel(ctx, []ProductResp{*product})
+	if len(visible) == 0 {
+		return nil, ErrProductNotFound
 	}
+	endSales(visible, time.Now())
+	return &visible[0], nil
+}
 
-	// 2. Fetch from DB
-	spu, err := s.repo.GetSPUByID(ctx, spuID)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("product not found")
+// ListProducts retrieves a list of products (SPUs) with pagination. Pages
+// are cached for every channel, so they are shorter when products are only
+// sold on other channels.
+func (s *productService) ListProducts(ctx context.Context, offset, limit int) ([]ProductResp, error) {
+	products, err := s.catalog.List(ctx, offset, limit, func(ctx context.Context) ([]ProductResp, error) {
+		spuList, err := s.repo.ListSPUs(ctx, offset, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list SPUs: %w", err)
+		}
+		var productResps []ProductResp
+		for i := range spuList {
+			productResps = append(productResps, newProductResp(&spuList[i]))
 		}
-		return nil, fmt.Errorf("failed to get SPU by ID %d: %w", spuID, err)
+		return productResps, nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	visible := onChannel(ctx, products)
+	endSales(visible, time.Now())
+	return visible, nil
+}
 
-	var skuResps []SKUResp
-	// Assuming spu.SKUs is preloaded by the repository
-	for _, sku := range spu.SKUs {
-		skuResps = append(skuResps, SKUResp{
-			ID:         sku.ID,
-			Attributes: sku.Attributes,
-			Price:      sku.Price,
-			Stock:      sku.Stock,
-		})
+// newRatingSummary summarizes the rating counts of an SPU, which are nil
+// before it is reviewed.
+func newRatingSummary(rating *model.SPURating) *RatingSummary {
+	summary := &RatingSummary{}
+	if rating == nil {
+		return summary
+	}
+	summary.Histogram = [5]int64{rating.Stars1, rating.Stars2, rating.Stars3, rating.Stars4, rating.Stars5}
+	var stars int64
+	for i, count := range summary.Histogram {
+		summary.Count += count
+		stars += int64(i+1) * count
 	}
+	if summary.Count > 0 {
+		summary.Average = math.Round(float64(stars)/float64(summary.Count)*100) / 100
+	}
+	return summary
+}
 
-	resp := &ProductResp{
+// newProductResp maps an SPU and its preloaded SKUs.
+func newProductResp(spu *model.SPU) ProductResp {
+	var skuResps []SKUResp
+	now := time.Now()
+	for i := range spu.SKUs {
+		skuResps = append(skuResps, newSKUResp(&spu.SKUs[i], now))
+	}
+	return ProductResp{
 		ID:          spu.ID,
 		Name:        spu.Name,
 		Description: spu.Description,
 		CategoryID:  spu.CategoryID,
 		SKUs:        skuResps,
 	}
+}
 
-	// 3. Set Cache (ignore error for now)
-	if bytes, err := json.Marshal(resp); err == nil {
-		_ = s.cache.Set(ctx, cacheKey, string(bytes), time.Hour)
+// newSKUResp maps a SKU priced as it sells at now.
+func newSKUResp(sku *model.SKU, now time.Time) SKUResp {
+	resp := SKUResp{
+		ID:            sku.ID,
+		Attributes:    sku.Attributes,
+		Price:         sku.Price,
+		Currency:      sku.Currency,
+		Stock:         sku.Stock,
+		PurchaseLimit: sku.PurchaseLimit,
+		PreOrder:      sku.PreOrder,
+		AvailableAt:   sku.AvailableAt,
+		Delivery:      sku.Delivery,
+		Channels:      splitChannels(sku.Channels),
 	}
+	if sku.OnSale(now) {
+		regular := sku.Price
+		resp.Price, resp.RegularPrice, resp.SaleEndsAt = *sku.SalePrice, &regular, sku.SaleEndsAt
+	}
+	return resp
+}
 
-	return resp, nil
+// endSales gives the SKUs of products cached during a sale that has ended
+// since their regular prices back, in place. cmd/worker drops such products
+// from the cache, but only on its next run.
+func endSales(products []ProductResp, now time.Time) {
+	for _, product := range products {
+		for i := range product.SKUs {
+			sku := &product.SKUs[i]
+			if sku.RegularPrice != nil && sku.SaleEndsAt != nil && !now.Before(*sku.SaleEndsAt) {
+				sku.Price, sku.RegularPrice, sku.SaleEndsAt = *sku.RegularPrice, nil, nil
+			}
+		}
+	}
 }
 
-// ListProducts retrieves a list of products (SPUs) with pagination.
-func (s *productService) ListProducts(ctx context.Context, offset, limit int) ([]ProductResp, error) {
-	spuList, err := s.repo.ListSPUs(ctx, offset, limit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list SPUs: %w", err)
-	}
-
-	var productResps []ProductResp
-	for _, spu := range spuList {
-		var skuResps []SKUResp
-		for _, sku := range spu.SKUs { // Assume SKUs are preloaded
-			skuResps = append(skuResps, SKUResp{
-				ID:         sku.ID,
-				Attributes: sku.Attributes,
-				Price:      sku.Price,
-				Stock:      sku.Stock,
-			})
+// joinChannels validates the sales channels of a SKU and joins them as
+// stored on model.SKU.
+func joinChannels(channels []string) (string, error) {
+	for _, ch := range channels {
+		if !channel.Valid(ch) {
+			return "", fmt.Errorf("%w: %q", ErrUnknownChannel, ch)
 		}
+	}
+	return strings.Join(channels, ","), nil
+}
 
-		productResps = append(productResps, ProductResp{
-			ID:          spu.ID,
-			Name:        spu.Name,
-			Description: spu.Description,
-			CategoryID:  spu.CategoryID,
-			SKUs:        skuResps,
-		})
+// splitChannels lists the sales channels stored on a SKU; nil means all.
+func splitChannels(channels string) []string {
+	if channels == "" {
+		return nil
 	}
-	return productResps, nil
-}
\ No newline at end of file
+	return strings.Split(channels, ",")
+}
+
+// onChannel returns products with only the SKUs sold on the sales channel of
+// ctx, leaving out products that have SKUs but none sold there. products is
+// not modified.
+func onChannel(ctx context.Context, products []ProductResp) []ProductResp {
+	ch := channel.FromContext(ctx)
+	visible := make([]ProductResp, 0, len(products))
+	for _, product := range products {
+		skus := skusOnChannel(ch, product.SKUs)
+		if len(product.SKUs) > 0 && len(skus) == 0 {
+			continue
+		}
+		product.SKUs = skus
+		visible = append(visible, product)
+	}
+	return visible
+}
+
+// skusOnChannel returns the SKUs sold on ch.
+func skusOnChannel(ch string, skus []SKUResp) []SKUResp {
+	var sold []SKUResp
+	for _, sku := range skus {
+		if sku.Channels == nil || slices.Contains(sku.Channels, ch) {
+			sold = append(sold, sku)
+		}
+	}
+	return sold
+}
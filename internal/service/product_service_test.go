@@ -11,6 +11,8 @@ import (
 	"github.com/proyuen/go-mall/internal/mocks"
 	"github.com/proyuen/go-mall/internal/model"
 	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/channel"
+	"github.com/proyuen/go-mall/pkg/tenant"
 	"github.com/proyuen/go-mall/pkg/utils"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
@@ -57,9 +59,11 @@ func TestProductService_CreateProduct(t *testing.T) {
 						require.Len(t, spu.SKUs, 1)
 						assert.Equal(t, model.JSONB{"color": "red"}, spu.SKUs[0].Attributes)
 						assert.True(t, decimal.NewFromInt(100).Equal(spu.SKUs[0].Price))
+						assert.Equal(t, "USD", spu.SKUs[0].Currency, "defaults to the base currency")
 						assert.Equal(t, 10, spu.SKUs[0].Stock)
 						return nil
 					})
+					mockCache.EXPECT().Set(gomock.Any(), "mall:product:list:tag", gomock.Any(), time.Duration(0)).Return(nil) // List pages are dropped
 				},
 			},
 			wantErr:  false,
@@ -68,6 +72,133 @@ func TestProductService_CreateProduct(t *testing.T) {
 				assert.Equal(t, uint64(101), resp.SPUID)
 			},
 		},
+		{
+			name: "ForeignCurrency",
+			args: args{
+				req: &service.ProductCreateReq{
+					Name:       productName,
+					CategoryID: 1,
+					SKUs:       []service.SKUCreateReq{{Price: decimal.NewFromInt(90), Currency: "eur", Stock: 1}},
+				},
+			},
+			fields: fields{
+				mockSetup: func(mockRepo *mocks.MockProductRepository, mockCache *mocks.MockCache, req *service.ProductCreateReq) {
+					mockRepo.EXPECT().CreateSPU(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, spu *model.SPU) error {
+						assert.Equal(t, "EUR", spu.SKUs[0].Currency)
+						return nil
+					})
+					mockCache.EXPECT().Set(gomock.Any(), "mall:product:list:tag", gomock.Any(), time.Duration(0)).Return(nil)
+				},
+			},
+			wantResp: true,
+		},
+		{
+			name: "UnsupportedCurrency",
+			args: args{
+				req: &service.ProductCreateReq{
+					Name: productName,
+					SKUs: []service.SKUCreateReq{{Price: decimal.NewFromInt(90), Currency: "CHF", Stock: 1}},
+				},
+			},
+			wantErr: true,
+			errStr:  "unsupported currency",
+		},
+		{
+			name: "Bundle",
+			args: args{
+				req: &service.ProductCreateReq{
+					Name:       productName,
+					CategoryID: 1,
+					SKUs: []service.SKUCreateReq{{
+						Price:      decimal.NewFromInt(45),
+						Stock:      5,
+						Components: []service.SKUComponentReq{{SKUID: 11, Quantity: 2}, {SKUID: 12, Quantity: 1}},
+					}},
+				},
+			},
+			fields: fields{
+				mockSetup: func(mockRepo *mocks.MockProductRepository, mockCache *mocks.MockCache, req *service.ProductCreateReq) {
+					mockRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{11, 12}).Return([]model.SKU{{Base: model.Base{ID: 11}}, {Base: model.Base{ID: 12}}}, nil)
+					mockRepo.EXPECT().CreateSPU(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, spu *model.SPU) error {
+						sku := spu.SKUs[0]
+						assert.Equal(t, model.SKUDeliveryBundle, sku.Delivery)
+						assert.Zero(t, sku.Stock, "a bundle takes its components' stock")
+						assert.Equal(t, []model.SKUComponent{{ComponentSKUID: 11, Quantity: 2}, {ComponentSKUID: 12, Quantity: 1}}, sku.Components)
+						return nil
+					})
+					mockCache.EXPECT().Set(gomock.Any(), "mall:product:list:tag", gomock.Any(), time.Duration(0)).Return(nil)
+				},
+			},
+			wantResp: true,
+		},
+		{
+			name: "Options",
+			args: args{
+				req: &service.ProductCreateReq{
+					Name:       productName,
+					CategoryID: 1,
+					SKUs:       []service.SKUCreateReq{{Price: decimal.NewFromInt(30), Stock: 1}},
+					Options: []service.ProductOptionReq{
+						{Code: "engraving", Name: "Engraving", Kind: model.ProductOptionText, MaxLength: 30, Price: decimal.NewFromInt(5)},
+						{Code: "warranty", Name: "Extended warranty", Kind: model.ProductOptionFlag, Price: decimal.NewFromInt(18), Currency: "eur"},
+					},
+				},
+			},
+			fields: fields{
+				mockSetup: func(mockRepo *mocks.MockProductRepository, mockCache *mocks.MockCache, req *service.ProductCreateReq) {
+					mockRepo.EXPECT().CreateSPU(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, spu *model.SPU) error {
+						require.Len(t, spu.Options, 2)
+						assert.Equal(t, "USD", spu.Options[0].Currency, "defaults to the base currency")
+						assert.Equal(t, "EUR", spu.Options[1].Currency)
+						return nil
+					})
+					mockCache.EXPECT().Set(gomock.Any(), "mall:product:list:tag", gomock.Any(), time.Duration(0)).Return(nil)
+				},
+			},
+			wantResp: true,
+		},
+		{
+			name: "OptionGivenTwice",
+			args: args{
+				req: &service.ProductCreateReq{
+					Name: productName,
+					SKUs: []service.SKUCreateReq{{Price: decimal.NewFromInt(30), Stock: 1}},
+					Options: []service.ProductOptionReq{
+						{Code: "warranty", Name: "Warranty", Kind: model.ProductOptionFlag},
+						{Code: "warranty", Name: "Warranty", Kind: model.ProductOptionFlag},
+					},
+				},
+			},
+			wantErr: true,
+			errStr:  `invalid product option: "warranty" given twice`,
+		},
+		{
+			name: "BundleOfBundle",
+			args: args{
+				req: &service.ProductCreateReq{
+					Name: productName,
+					SKUs: []service.SKUCreateReq{{Price: decimal.NewFromInt(45), Components: []service.SKUComponentReq{{SKUID: 11, Quantity: 1}}}},
+				},
+			},
+			fields: fields{
+				mockSetup: func(mockRepo *mocks.MockProductRepository, mockCache *mocks.MockCache, req *service.ProductCreateReq) {
+					mockRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{11}).Return([]model.SKU{{Base: model.Base{ID: 11}, Delivery: model.SKUDeliveryBundle}}, nil)
+				},
+			},
+			wantErr: true,
+			errStr:  "invalid bundle: SKU 11 is a bundle itself",
+		},
+		{
+			name: "DigitalBundle",
+			args: args{
+				req: &service.ProductCreateReq{
+					Name: productName,
+					SKUs: []service.SKUCreateReq{{Price: decimal.NewFromInt(45), Delivery: model.SKUDeliveryDownload, Components: []service.SKUComponentReq{{SKUID: 11, Quantity: 1}}}},
+				},
+			},
+			wantErr: true,
+			errStr:  "invalid bundle",
+		},
 		{
 			name: "CreationError",
 			args: args{
@@ -95,7 +226,8 @@ func TestProductService_CreateProduct(t *testing.T) {
 
 			mockRepo := mocks.NewMockProductRepository(ctrl)
 			mockCache := mocks.NewMockCache(ctrl)
-			productService := service.NewProductService(mockRepo, mockCache)
+			currencies := service.NewCurrencyService(nil, nil, nil, service.CurrencyOptions{Base: "USD", Supported: []string{"EUR"}})
+			productService := service.NewProductService(mockRepo, nil, service.NewCatalogCache(mockCache, service.CatalogCacheOptions{}), currencies, nil, nil)
 			ctx := context.Background()
 
 			if tt.fields.mockSetup != nil {
@@ -134,13 +266,12 @@ func TestProductService_GetProduct(t *testing.T) {
 
 		mockRepo := mocks.NewMockProductRepository(ctrl)
 		mockCache := mocks.NewMockCache(ctrl)
-		productService := service.NewProductService(mockRepo, mockCache)
+		currencies := service.NewCurrencyService(nil, nil, nil, service.CurrencyOptions{})
+		productService := service.NewProductService(mockRepo, nil, service.NewCatalogCache(mockCache, service.CatalogCacheOptions{}), currencies, nil, nil)
 		ctx := context.Background()
 
 		cachedResp := &service.ProductResp{ID: spuID, Name: "Cached Product"}
-		bytes, _ := json.Marshal(cachedResp)
-
-		mockCache.EXPECT().Get(ctx, cacheKey).Return(string(bytes), nil)
+		mockCache.EXPECT().Get(ctx, cacheKey).Return(catalogEntry(t, cachedResp, time.Now().Add(time.Minute)), nil)
 		// Repo should NOT be called
 
 		resp, err := productService.GetProduct(ctx, spuID)
@@ -154,7 +285,8 @@ func TestProductService_GetProduct(t *testing.T) {
 
 		mockRepo := mocks.NewMockProductRepository(ctrl)
 		mockCache := mocks.NewMockCache(ctrl)
-		productService := service.NewProductService(mockRepo, mockCache)
+		currencies := service.NewCurrencyService(nil, nil, nil, service.CurrencyOptions{})
+		productService := service.NewProductService(mockRepo, nil, service.NewCatalogCache(mockCache, service.CatalogCacheOptions{}), currencies, nil, nil)
 		ctx := context.Background()
 
 		mockCache.EXPECT().Get(ctx, cacheKey).Return("", nil) // Cache miss
@@ -166,4 +298,232 @@ func TestProductService_GetProduct(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, "DB Product", resp.Name)
 	})
-}
\ No newline at end of file
+	t.Run("CachedPerStore", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		mockRepo := mocks.NewMockProductRepository(ctrl)
+		mockCache := mocks.NewMockCache(ctrl)
+		currencies := service.NewCurrencyService(nil, nil, nil, service.CurrencyOptions{})
+		productService := service.NewProductService(mockRepo, nil, service.NewCatalogCache(mockCache, service.CatalogCacheOptions{}), currencies, nil, nil)
+		ctx := tenant.NewContext(context.Background(), &model.Store{Base: model.Base{ID: 7}})
+
+		mockCache.EXPECT().Get(ctx, cacheKey+":store:7").Return("", nil)
+		mockRepo.EXPECT().GetSPUByID(ctx, spuID).Return(&model.SPU{Base: model.Base{ID: spuID}}, nil)
+		mockCache.EXPECT().Set(ctx, cacheKey+":store:7", gomock.Any(), time.Hour).Return(nil)
+
+		_, err := productService.GetProduct(ctx, spuID)
+		require.NoError(t, err)
+	})
+
+	t.Run("OnlySKUsOnChannel", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		mockRepo := mocks.NewMockProductRepository(ctrl)
+		mockCache := mocks.NewMockCache(ctrl)
+		productService := service.NewProductService(mockRepo, nil, service.NewCatalogCache(mockCache, service.CatalogCacheOptions{}), nil, nil, nil)
+
+		cachedResp := &service.ProductResp{ID: spuID, SKUs: []service.SKUResp{
+			{ID: 1},
+			{ID: 2, Channels: []string{"web"}},
+			{ID: 3, Channels: []string{"wholesale"}},
+		}}
+		mockCache.EXPECT().Get(gomock.Any(), cacheKey).Return(catalogEntry(t, cachedResp, time.Now().Add(time.Minute)), nil).Times(2)
+
+		resp, err := productService.GetProduct(context.Background(), spuID)
+		require.NoError(t, err)
+		require.Len(t, resp.SKUs, 2)
+		assert.Equal(t, []uint64{1, 2}, []uint64{resp.SKUs[0].ID, resp.SKUs[1].ID})
+
+		resp, err = productService.GetProduct(channel.NewContext(context.Background(), channel.Wholesale), spuID)
+		require.NoError(t, err)
+		require.Len(t, resp.SKUs, 2)
+		assert.Equal(t, []uint64{1, 3}, []uint64{resp.SKUs[0].ID, resp.SKUs[1].ID})
+
+		cachedResp.SKUs = cachedResp.SKUs[2:]
+		mockCache.EXPECT().Get(gomock.Any(), cacheKey).Return(catalogEntry(t, cachedResp, time.Now().Add(time.Minute)), nil)
+		_, err = productService.GetProduct(channel.NewContext(context.Background(), channel.App), spuID)
+		assert.ErrorIs(t, err, service.ErrProductNotFound)
+	})
+
+	t.Run("LegacyEntryReloaded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		mockRepo := mocks.NewMockProductRepository(ctrl)
+		mockCache := mocks.NewMockCache(ctrl)
+		productService := service.NewProductService(mockRepo, nil, service.NewCatalogCache(mockCache, service.CatalogCacheOptions{}), nil, nil, nil)
+		ctx := context.Background()
+
+		bytes, _ := json.Marshal(&service.ProductResp{ID: spuID, Name: "Cached Product"})
+		mockCache.EXPECT().Get(ctx, cacheKey).Return(string(bytes), nil)
+		mockRepo.EXPECT().GetSPUByID(ctx, spuID).Return(&model.SPU{Base: model.Base{ID: spuID}, Name: "DB Product"}, nil)
+		mockCache.EXPECT().Set(ctx, cacheKey, gomock.Any(), time.Hour).Return(nil)
+
+		resp, err := productService.GetProduct(ctx, spuID)
+		require.NoError(t, err)
+		assert.Equal(t, "DB Product", resp.Name)
+	})
+
+	t.Run("StaleServedWhileRevalidated", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		mockRepo := mocks.NewMockProductRepository(ctrl)
+		mockCache := mocks.NewMockCache(ctrl)
+		catalog := service.NewCatalogCache(mockCache, service.CatalogCacheOptions{
+			Product: service.CacheTTL{Soft: time.Minute, Hard: 10 * time.Minute},
+		})
+		productService := service.NewProductService(mockRepo, nil, catalog, nil, nil, nil)
+		ctx, cancel := context.WithCancel(tenant.NewContext(context.Background(), &model.Store{Base: model.Base{ID: 7}}))
+
+		stale := catalogEntry(t, &service.ProductResp{ID: spuID, Name: "Stale Product"}, time.Now().Add(-time.Second))
+		mockCache.EXPECT().Get(gomock.Any(), cacheKey+":store:7").Return(stale, nil).Times(2)
+		reloaded := make(chan struct{})
+		revalidated := make(chan string)
+		mockRepo.EXPECT().GetSPUByID(gomock.Any(), spuID).DoAndReturn(func(ctx context.Context, _ uint64) (*model.SPU, error) {
+			<-reloaded
+			assert.NoError(t, ctx.Err(), "the reload outlives the request")
+			if store := tenant.FromContext(ctx); assert.NotNil(t, store, "the reload keeps the store") {
+				assert.Equal(t, uint64(7), store.ID)
+			}
+			return &model.SPU{Base: model.Base{ID: spuID}, Name: "DB Product"}, nil
+		})
+		mockCache.EXPECT().Set(gomock.Any(), cacheKey+":store:7", gomock.Any(), 10*time.Minute).DoAndReturn(func(_ context.Context, _ string, value interface{}, _ time.Duration) error {
+			revalidated <- value.(string)
+			return nil
+		})
+
+		// Both readers are served the stale product; only the first reloads it
+		for range 2 {
+			resp, err := productService.GetProduct(ctx, spuID)
+			require.NoError(t, err)
+			assert.Equal(t, "Stale Product", resp.Name)
+		}
+		cancel()
+		close(reloaded)
+
+		select {
+		case entry := <-revalidated:
+			assert.Contains(t, entry, "DB Product")
+		case <-time.After(time.Second):
+			t.Fatal("the stale product was not reloaded")
+		}
+	})
+}
+
+// catalogEntry is how the catalog cache stores value.
+func TestProductService_GetProductRating(t *testing.T) {
+	tests := []struct {
+		name   string
+		rating *model.SPURating
+		want   *service.RatingSummary
+	}{
+		{name: "NotReviewed", want: &service.RatingSummary{}},
+		{
+			name:   "Reviewed",
+			rating: &model.SPURating{SPUID: 101, Stars1: 1, Stars3: 1, Stars4: 2, Stars5: 2},
+			want:   &service.RatingSummary{Average: 3.67, Count: 6, Histogram: [5]int64{1, 0, 1, 2, 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockRepo := mocks.NewMockProductRepository(ctrl)
+			mockCache := mocks.NewMockCache(ctrl)
+			productService := service.NewProductService(mockRepo, nil, service.NewCatalogCache(mockCache, service.CatalogCacheOptions{}), nil, nil, nil)
+			ctx := context.Background()
+
+			mockCache.EXPECT().Get(ctx, "mall:product:spu:101").Return("", nil)
+			mockRepo.EXPECT().GetSPUByID(ctx, uint64(101)).Return(&model.SPU{Base: model.Base{ID: 101}, Rating: tt.rating}, nil)
+			mockCache.EXPECT().Set(ctx, "mall:product:spu:101", gomock.Any(), time.Hour).Return(nil)
+
+			resp, err := productService.GetProduct(ctx, 101)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, resp.Rating)
+		})
+	}
+}
+
+func catalogEntry(t *testing.T, value any, freshUntil time.Time) string {
+	t.Helper()
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	entry, err := json.Marshal(map[string]any{"fresh_until": freshUntil, "data": json.RawMessage(data)})
+	require.NoError(t, err)
+	return string(entry)
+}
+func TestProductService_ListProducts(t *testing.T) {
+	spus := []model.SPU{{Base: model.Base{ID: 101}, Name: "DB Product"}}
+
+	t.Run("PageCached", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockProductRepository(ctrl)
+		mockCache := mocks.NewMockCache(ctrl)
+		productService := service.NewProductService(mockRepo, nil, service.NewCatalogCache(mockCache, service.CatalogCacheOptions{}), nil, nil, nil)
+		ctx := context.Background()
+
+		page := catalogEntry(t, []service.ProductResp{{ID: 101, Name: "Cached Product"}}, time.Now().Add(time.Minute))
+		mockCache.EXPECT().Get(ctx, "mall:product:list:tag").Return("t1", nil)
+		mockCache.EXPECT().Get(ctx, "mall:product:list:t1:0:10").Return(page, nil)
+
+		resp, err := productService.ListProducts(ctx, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, resp, 1)
+		assert.Equal(t, "Cached Product", resp[0].Name)
+	})
+
+	t.Run("PageMissed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockProductRepository(ctrl)
+		mockCache := mocks.NewMockCache(ctrl)
+		productService := service.NewProductService(mockRepo, nil, service.NewCatalogCache(mockCache, service.CatalogCacheOptions{}), nil, nil, nil)
+		ctx := tenant.NewContext(context.Background(), &model.Store{Base: model.Base{ID: 7}})
+
+		mockCache.EXPECT().Get(ctx, "mall:product:list:tag:store:7").Return("t1", nil)
+		mockCache.EXPECT().Get(ctx, "mall:product:list:t1:0:10:store:7").Return("", nil)
+		mockRepo.EXPECT().ListSPUs(ctx, 0, 10).Return(spus, nil)
+		mockCache.EXPECT().Set(ctx, "mall:product:list:t1:0:10:store:7", gomock.Any(), time.Hour).Return(nil)
+
+		resp, err := productService.ListProducts(ctx, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, "DB Product", resp[0].Name)
+	})
+
+	t.Run("FirstTag", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockProductRepository(ctrl)
+		mockCache := mocks.NewMockCache(ctrl)
+		productService := service.NewProductService(mockRepo, nil, service.NewCatalogCache(mockCache, service.CatalogCacheOptions{}), nil, nil, nil)
+		ctx := context.Background()
+
+		var tag string
+		mockCache.EXPECT().Get(ctx, "mall:product:list:tag").Return("", nil)
+		mockCache.EXPECT().SetNX(ctx, "mall:product:list:tag", gomock.Any(), time.Duration(0)).DoAndReturn(func(_ context.Context, _ string, value interface{}, _ time.Duration) (bool, error) {
+			tag = value.(string)
+			return true, nil
+		})
+		mockRepo.EXPECT().ListSPUs(ctx, 0, 10).Return(spus, nil)
+		mockCache.EXPECT().Set(ctx, gomock.Any(), gomock.Any(), time.Hour).DoAndReturn(func(_ context.Context, key string, _ interface{}, _ time.Duration) error {
+			assert.Equal(t, "mall:product:list:"+tag+":0:10", key, "the page is cached under the new tag")
+			return nil
+		})
+
+		_, err := productService.ListProducts(ctx, 0, 10)
+		require.NoError(t, err)
+	})
+
+	t.Run("CacheDown", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockProductRepository(ctrl)
+		mockCache := mocks.NewMockCache(ctrl)
+		productService := service.NewProductService(mockRepo, nil, service.NewCatalogCache(mockCache, service.CatalogCacheOptions{}), nil, nil, nil)
+		ctx := context.Background()
+
+		mockCache.EXPECT().Get(ctx, "mall:product:list:tag").Return("", errors.New("circuit open"))
+		mockRepo.EXPECT().ListSPUs(ctx, 0, 10).Return(spus, nil)
+		// Nothing is cached without a tag
+
+		resp, err := productService.ListProducts(ctx, 0, 10)
+		require.NoError(t, err)
+		assert.Len(t, resp, 1)
+	})
+}
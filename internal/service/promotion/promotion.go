@@ -0,0 +1,248 @@
+// Package promotion decides the discounts of an order from a set of
+// promotion rules: buy X get Y, tiered discounts on what is spent, and
+// category-wide sales. Stackable rules combine, each discounting what the
+// ones before it left; an exclusive rule applies alone. Evaluate picks
+// whichever gives the customer the larger discount, and explains it.
+package promotion
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/shopspring/decimal"
+)
+
+// Rule kinds.
+const (
+	// KindBuyXGetY takes Percent off GetQuantity covered units for every
+	// BuyQuantity bought, the cheapest units first.
+	KindBuyXGetY = "buy_x_get_y"
+	// KindTiered takes the Percent of the highest tier reached off the
+	// covered items, by what is spent on them.
+	KindTiered = "tiered"
+	// KindCategorySale takes Percent off every covered item.
+	KindCategorySale = "category_sale"
+)
+
+// Stacking policies.
+const (
+	// Stackable rules apply together, by priority.
+	Stackable = "stackable"
+	// Exclusive rules apply alone, never with another rule.
+	Exclusive = "exclusive"
+)
+
+// scale is the precision discounts are rounded to: cents.
+const scale = 2
+
+var hundred = decimal.NewFromInt(100)
+
+// Item is one line of the order being discounted.
+type Item struct {
+	SKUID       uint64
+	CategoryIDs []uint64 // The SKU's category and its ancestors
+	Quantity    int
+	Price       decimal.Decimal // Unit price
+}
+
+// amount is the price of the line before discounts.
+func (i *Item) amount() decimal.Decimal {
+	return i.Price.Mul(decimal.NewFromInt(int64(i.Quantity)))
+}
+
+// Tier is one step of a KindTiered rule.
+type Tier struct {
+	Threshold decimal.Decimal // Spent on covered items, after the discounts of earlier rules
+	Percent   decimal.Decimal
+}
+
+// Rule is one promotion. Amounts are in the currency of the order.
+type Rule struct {
+	ID          uint64
+	Name        string
+	Kind        string
+	Stacking    string
+	Priority    int    // Higher applies first among stackable rules
+	CategoryID  uint64 // Items the rule covers; 0 covers every item
+	BuyQuantity int
+	GetQuantity int
+	Percent     decimal.Decimal
+	Tiers       []Tier
+}
+
+// covers reports whether the rule applies to item.
+func (r *Rule) covers(item *Item) bool {
+	return r.CategoryID == 0 || slices.Contains(item.CategoryIDs, r.CategoryID)
+}
+
+// units returns how many units of items the rule covers.
+func (r *Rule) units(items []Item) int {
+	units := 0
+	for i := range items {
+		if r.covers(&items[i]) {
+			units += items[i].Quantity
+		}
+	}
+	return units
+}
+
+// Applied is a rule that discounted the order, and why.
+type Applied struct {
+	RuleID uint64
+	Name   string
+	Amount decimal.Decimal
+	Units  int    // Of the items the rule covers
+	Detail string // e.g. "buy 2 get 1: 1 unit 100% off"
+}
+
+// Result is the discount of an order.
+type Result struct {
+	Discounts []decimal.Decimal // Per item, lined up with the items evaluated
+	Total     decimal.Decimal
+	Applied   []Applied // In the order the rules applied
+}
+
+// Evaluate discounts items with rules. The stackable rules are applied
+// together, highest priority first; each exclusive rule is tried alone; the
+// combination with the larger total discount wins, the stackable one on a
+// tie. Rules that discount nothing are left out of the result.
+func Evaluate(items []Item, rules []Rule) *Result {
+	rules = slices.Clone(rules)
+	slices.SortStableFunc(rules, func(a, b Rule) int {
+		return cmp.Compare(b.Priority, a.Priority)
+	})
+
+	var stackable []Rule
+	for _, rule := range rules {
+		if rule.Stacking != Exclusive {
+			stackable = append(stackable, rule)
+		}
+	}
+	best := apply(items, stackable)
+	for _, rule := range rules {
+		if rule.Stacking != Exclusive {
+			continue
+		}
+		if result := apply(items, []Rule{rule}); result.Total.GreaterThan(best.Total) {
+			best = result
+		}
+	}
+	return best
+}
+
+// apply discounts items with rules in turn, each on what the ones before it
+// left.
+func apply(items []Item, rules []Rule) *Result {
+	result := &Result{Discounts: make([]decimal.Decimal, len(items)), Total: decimal.Zero}
+	remaining := make([]decimal.Decimal, len(items))
+	for i := range items {
+		result.Discounts[i] = decimal.Zero
+		remaining[i] = items[i].amount()
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		discounts, detail := discount(rule, items, remaining)
+		amount := decimal.Zero
+		for j, d := range discounts {
+			d = decimal.Min(d.Round(scale), remaining[j])
+			remaining[j] = remaining[j].Sub(d)
+			result.Discounts[j] = result.Discounts[j].Add(d)
+			amount = amount.Add(d)
+		}
+		if amount.IsPositive() {
+			result.Total = result.Total.Add(amount)
+			result.Applied = append(result.Applied, Applied{RuleID: rule.ID, Name: rule.Name, Amount: amount, Units: rule.units(items), Detail: detail})
+		}
+	}
+	return result
+}
+
+// discount returns what rule takes off each item, given what is left of
+// their amounts, or nil if it does not apply.
+func discount(rule *Rule, items []Item, remaining []decimal.Decimal) ([]decimal.Decimal, string) {
+	switch rule.Kind {
+	case KindCategorySale:
+		discounts, covered := percentOff(rule, items, remaining, rule.Percent)
+		return discounts, fmt.Sprintf("%s%% off %d covered units", rule.Percent, covered)
+	case KindTiered:
+		return tiered(rule, items, remaining)
+	case KindBuyXGetY:
+		return buyXGetY(rule, items, remaining)
+	}
+	return nil, ""
+}
+
+// percentOff takes percent off what is left of each covered item, and
+// returns how many units it covered.
+func percentOff(rule *Rule, items []Item, remaining []decimal.Decimal, percent decimal.Decimal) ([]decimal.Decimal, int) {
+	discounts := make([]decimal.Decimal, len(items))
+	covered := 0
+	for i := range items {
+		discounts[i] = decimal.Zero
+		if rule.covers(&items[i]) {
+			discounts[i] = remaining[i].Mul(percent).Div(hundred)
+			covered += items[i].Quantity
+		}
+	}
+	return discounts, covered
+}
+
+func tiered(rule *Rule, items []Item, remaining []decimal.Decimal) ([]decimal.Decimal, string) {
+	spent := decimal.Zero
+	for i := range items {
+		if rule.covers(&items[i]) {
+			spent = spent.Add(remaining[i])
+		}
+	}
+	var reached *Tier
+	for i := range rule.Tiers {
+		tier := &rule.Tiers[i]
+		if spent.GreaterThanOrEqual(tier.Threshold) && (reached == nil || tier.Threshold.GreaterThan(reached.Threshold)) {
+			reached = tier
+		}
+	}
+	if reached == nil {
+		return nil, ""
+	}
+	discounts, _ := percentOff(rule, items, remaining, reached.Percent)
+	return discounts, fmt.Sprintf("%s%% off for spending %s, at least %s", reached.Percent, spent.StringFixed(scale), reached.Threshold.StringFixed(scale))
+}
+
+func buyXGetY(rule *Rule, items []Item, remaining []decimal.Decimal) ([]decimal.Decimal, string) {
+	if rule.BuyQuantity <= 0 || rule.GetQuantity <= 0 {
+		return nil, ""
+	}
+	// The covered units, by what is left of their price, cheapest first
+	type unit struct {
+		item  int
+		price decimal.Decimal
+	}
+	var units []unit
+	for i := range items {
+		if !rule.covers(&items[i]) || items[i].Quantity <= 0 {
+			continue
+		}
+		price := remaining[i].Div(decimal.NewFromInt(int64(items[i].Quantity)))
+		for range items[i].Quantity {
+			units = append(units, unit{item: i, price: price})
+		}
+	}
+	free := len(units) / (rule.BuyQuantity + rule.GetQuantity) * rule.GetQuantity
+	if free == 0 {
+		return nil, ""
+	}
+	slices.SortStableFunc(units, func(a, b unit) int {
+		return a.price.Cmp(b.price)
+	})
+
+	discounts := make([]decimal.Decimal, len(items))
+	for i := range discounts {
+		discounts[i] = decimal.Zero
+	}
+	for _, u := range units[:free] {
+		discounts[u.item] = discounts[u.item].Add(u.price.Mul(rule.Percent).Div(hundred))
+	}
+	return discounts, fmt.Sprintf("buy %d get %d: %d units %s%% off", rule.BuyQuantity, rule.GetQuantity, free, rule.Percent)
+}
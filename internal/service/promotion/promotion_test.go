@@ -0,0 +1,96 @@
+package promotion
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestEvaluate(t *testing.T) {
+	// Two shirts at 20 in category 2 (under 1), one mug at 10 in category 3
+	items := []Item{
+		{SKUID: 1, CategoryIDs: []uint64{2, 1}, Quantity: 2, Price: dec("20")},
+		{SKUID: 2, CategoryIDs: []uint64{3}, Quantity: 1, Price: dec("10")},
+	}
+	shirtSale := Rule{ID: 1, Name: "Shirt sale", Kind: KindCategorySale, CategoryID: 1, Percent: dec("10")}
+	threeForTwo := Rule{ID: 2, Name: "3 for 2", Kind: KindBuyXGetY, BuyQuantity: 2, GetQuantity: 1, Percent: dec("100")}
+	spendMore := Rule{ID: 3, Name: "Spend more", Kind: KindTiered, Tiers: []Tier{
+		{Threshold: dec("30"), Percent: dec("5")},
+		{Threshold: dec("45"), Percent: dec("20")},
+	}}
+
+	tests := []struct {
+		name          string
+		rules         []Rule
+		wantTotal     string
+		wantDiscounts []string
+		wantApplied   []uint64
+	}{
+		{name: "None", wantTotal: "0", wantDiscounts: []string{"0", "0"}},
+		{name: "CategorySale", rules: []Rule{shirtSale}, wantTotal: "4", wantDiscounts: []string{"4", "0"}, wantApplied: []uint64{1}},
+		{name: "BuyXGetYFreesCheapest", rules: []Rule{threeForTwo}, wantTotal: "10", wantDiscounts: []string{"0", "10"}, wantApplied: []uint64{2}},
+		{name: "HighestTierReached", rules: []Rule{spendMore}, wantTotal: "10", wantDiscounts: []string{"8", "2"}, wantApplied: []uint64{3}},
+		{
+			// The sale leaves 36 + 10, so the tier of 45 is still reached
+			name:          "StackedByPriority",
+			rules:         []Rule{spendMore, func() Rule { r := shirtSale; r.Priority = 1; return r }()},
+			wantTotal:     "13.2",
+			wantDiscounts: []string{"11.2", "2"},
+			wantApplied:   []uint64{1, 3},
+		},
+		{
+			name:          "ExclusiveWinsWhenLarger",
+			rules:         []Rule{shirtSale, func() Rule { r := spendMore; r.Stacking = Exclusive; return r }()},
+			wantTotal:     "10",
+			wantDiscounts: []string{"8", "2"},
+			wantApplied:   []uint64{3},
+		},
+		{
+			name:          "StackedWinsWhenLarger",
+			rules:         []Rule{shirtSale, threeForTwo, func() Rule { r := spendMore; r.Stacking = Exclusive; r.Tiers = r.Tiers[:1]; return r }()},
+			wantTotal:     "14",
+			wantDiscounts: []string{"4", "10"},
+			wantApplied:   []uint64{1, 2},
+		},
+		{name: "NotCovered", rules: []Rule{{ID: 4, Kind: KindCategorySale, CategoryID: 9, Percent: dec("50")}}, wantTotal: "0", wantDiscounts: []string{"0", "0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Evaluate(items, tt.rules)
+
+			assert.Equal(t, tt.wantTotal, result.Total.String())
+			require.Len(t, result.Discounts, len(items))
+			for i, want := range tt.wantDiscounts {
+				assert.Equal(t, want, result.Discounts[i].String(), "item %d", i)
+			}
+			var applied []uint64
+			for _, a := range result.Applied {
+				applied = append(applied, a.RuleID)
+				assert.NotEmpty(t, a.Detail)
+				assert.Positive(t, a.Units)
+			}
+			assert.Equal(t, tt.wantApplied, applied)
+		})
+	}
+}
+
+func TestEvaluate_NeverBelowZero(t *testing.T) {
+	items := []Item{{SKUID: 1, Quantity: 1, Price: dec("9.99")}}
+	rules := []Rule{
+		{ID: 1, Kind: KindCategorySale, Percent: dec("100")},
+		{ID: 2, Kind: KindCategorySale, Percent: dec("100")},
+	}
+
+	result := Evaluate(items, rules)
+
+	assert.Equal(t, "9.99", result.Total.String())
+	require.Len(t, result.Applied, 1)
+	assert.Equal(t, 1, result.Applied[0].Units)
+}
@@ -0,0 +1,422 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service/promotion"
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrPromotionNotFound means the promotion does not exist.
+	ErrPromotionNotFound = errors.New("promotion not found")
+	// ErrInvalidPromotion means a promotion's rule is incomplete or contradictory.
+	ErrInvalidPromotion = errors.New("invalid promotion")
+	// ErrCouponNotFound means no coupon has the code given at checkout.
+	ErrCouponNotFound = apperr.New(apperr.CodeCouponNotFound)
+	// ErrCouponRedeemed means the coupon was redeemed by another order.
+	ErrCouponRedeemed = apperr.New(apperr.CodeCouponRedeemed)
+	// ErrCouponNotApplicable means the promotion the coupon unlocks is not
+	// running or gives the order no discount.
+	ErrCouponNotApplicable = apperr.New(apperr.CodeCouponNotApplicable)
+)
+
+var hundredPercent = decimal.NewFromInt(100)
+
+// PromotionCreateReq defines a promotion. Which fields apply depends on Kind;
+// see the promotion package.
+type PromotionCreateReq struct {
+	Name          string
+	Kind          string
+	Stacking      string // Empty means stackable
+	Priority      int
+	CategoryID    uint64
+	BuyQuantity   int
+	GetQuantity   int
+	Percent       decimal.Decimal
+	Currency      string // Of the tier thresholds; empty means the base currency
+	Tiers         []PromotionTierReq
+	CouponOnly    bool      // Applies only to orders redeeming a code of its coupon campaigns
+	PurchaseLimit int       // Most units of covered items one customer may buy under it; 0 is unlimited
+	StartsAt      time.Time // Zero means now
+	EndsAt        *time.Time
+}
+
+// PromotionTierReq is one step of a tiered promotion.
+type PromotionTierReq struct {
+	Threshold decimal.Decimal `json:"threshold" swaggertype:"string" example:"100.00"`
+	Percent   decimal.Decimal `json:"percent" swaggertype:"string" example:"10"`
+}
+
+// PromotionResp is a promotion as shown to admins.
+type PromotionResp struct {
+	ID            uint64             `json:"id,string"`
+	Name          string             `json:"name" example:"Summer sale"`
+	Kind          string             `json:"kind" example:"category_sale"`
+	Stacking      string             `json:"stacking" example:"stackable"`
+	Priority      int                `json:"priority"`
+	CategoryID    uint64             `json:"category_id,string,omitempty"`
+	BuyQuantity   int                `json:"buy_quantity,omitempty"`
+	GetQuantity   int                `json:"get_quantity,omitempty"`
+	Percent       decimal.Decimal    `json:"percent" swaggertype:"string" example:"20"`
+	Currency      string             `json:"currency,omitempty" example:"USD"`
+	Tiers         []PromotionTierReq `json:"tiers,omitempty"`
+	CouponOnly    bool               `json:"coupon_only"`
+	PurchaseLimit int                `json:"purchase_limit,omitempty"`
+	StartsAt      time.Time          `json:"starts_at"`
+	EndsAt        *time.Time         `json:"ends_at,omitempty"`
+}
+
+// AppliedPromotion is a promotion that discounted an order, with what it took
+// off and why.
+type AppliedPromotion struct {
+	PromotionID uint64      `json:"promotion_id,string"`
+	Name        string      `json:"name" example:"3 for 2"`
+	Amount      money.Money `json:"amount"`
+	Detail      string      `json:"detail" example:"buy 2 get 1: 1 units 100% off"`
+}
+
+// PromotionService manages promotions and applies them at checkout.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/promotion_service_mock.go -package=mocks
+type PromotionService interface {
+	Create(ctx context.Context, req *PromotionCreateReq) (*PromotionResp, error)
+	List(ctx context.Context) ([]PromotionResp, error)
+	Delete(ctx context.Context, id uint64) error
+	// Apply evaluates the running promotions against items, priced in
+	// currency for skus, which line up with them. It sets the Discount of
+	// each item and returns the promotions that gave one. Coupon-only
+	// promotions are left out, but for the one couponCode unlocks, if given,
+	// which must then give a discount.
+	Apply(ctx context.Context, currency, couponCode string, items []model.OrderItem, skus []model.SKU) ([]model.OrderPromotion, error)
+	// RedeemCoupons redeems the coupons that unlocked applied for the order.
+	// Run in the order's transaction, it fails the order when another order
+	// redeemed one first.
+	RedeemCoupons(ctx context.Context, applied []model.OrderPromotion, orderID, userID uint64) error
+}
+
+type promotionService struct {
+	repo         repository.PromotionRepository
+	couponRepo   repository.CouponRepository
+	productRepo  repository.ProductRepository
+	categoryRepo repository.CategoryRepository
+	currencies   CurrencyService
+}
+
+// NewPromotionService creates a new PromotionService instance. Tier
+// thresholds are converted with currencies into the currency of the order.
+func NewPromotionService(repo repository.PromotionRepository, couponRepo repository.CouponRepository, productRepo repository.ProductRepository, categoryRepo repository.CategoryRepository, currencies CurrencyService) PromotionService {
+	return &promotionService{repo: repo, couponRepo: couponRepo, productRepo: productRepo, categoryRepo: categoryRepo, currencies: currencies}
+}
+
+func (s *promotionService) Create(ctx context.Context, req *PromotionCreateReq) (*PromotionResp, error) {
+	p := &model.Promotion{
+		Name:          req.Name,
+		Kind:          req.Kind,
+		Stacking:      req.Stacking,
+		Priority:      req.Priority,
+		CategoryID:    req.CategoryID,
+		BuyQuantity:   req.BuyQuantity,
+		GetQuantity:   req.GetQuantity,
+		Percent:       req.Percent,
+		CouponOnly:    req.CouponOnly,
+		PurchaseLimit: req.PurchaseLimit,
+		StartsAt:      req.StartsAt,
+		EndsAt:        req.EndsAt,
+	}
+	if p.Stacking == "" {
+		p.Stacking = promotion.Stackable
+	}
+	if p.StartsAt.IsZero() {
+		p.StartsAt = time.Now()
+	}
+	if err := s.validate(ctx, p, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, p); err != nil {
+		return nil, err
+	}
+	resp := newPromotionResp(p)
+	return &resp, nil
+}
+
+// validate checks the fields the kind of p needs, and sets its currency and
+// tiers from req.
+func (s *promotionService) validate(ctx context.Context, p *model.Promotion, req *PromotionCreateReq) error {
+	if p.Stacking != promotion.Stackable && p.Stacking != promotion.Exclusive {
+		return fmt.Errorf("%w: unknown stacking policy %q", ErrInvalidPromotion, p.Stacking)
+	}
+	if p.PurchaseLimit < 0 {
+		return fmt.Errorf("%w: purchase limit must not be negative", ErrInvalidPromotion)
+	}
+	if p.EndsAt != nil && !p.EndsAt.After(p.StartsAt) {
+		return fmt.Errorf("%w: ends before it starts", ErrInvalidPromotion)
+	}
+	validPercent := func(percent decimal.Decimal) bool {
+		return percent.IsPositive() && percent.LessThanOrEqual(hundredPercent)
+	}
+
+	switch p.Kind {
+	case promotion.KindBuyXGetY:
+		if p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return fmt.Errorf("%w: buy and get quantities must be positive", ErrInvalidPromotion)
+		}
+		if !validPercent(p.Percent) {
+			return fmt.Errorf("%w: percent must be above 0 and at most 100", ErrInvalidPromotion)
+		}
+	case promotion.KindCategorySale:
+		if !validPercent(p.Percent) {
+			return fmt.Errorf("%w: percent must be above 0 and at most 100", ErrInvalidPromotion)
+		}
+	case promotion.KindTiered:
+		if len(req.Tiers) == 0 {
+			return fmt.Errorf("%w: tiered promotions need tiers", ErrInvalidPromotion)
+		}
+		currency, err := s.currencies.Resolve(ctx, req.Currency, 0)
+		if err != nil {
+			return err
+		}
+		p.Currency = currency
+		for _, tier := range req.Tiers {
+			if tier.Threshold.IsNegative() || !validPercent(tier.Percent) {
+				return fmt.Errorf("%w: tier thresholds must not be negative and percents must be above 0 and at most 100", ErrInvalidPromotion)
+			}
+			p.Tiers = append(p.Tiers, model.PromotionTier{Threshold: tier.Threshold, Percent: tier.Percent})
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidPromotion, p.Kind)
+	}
+	return nil
+}
+
+func (s *promotionService) List(ctx context.Context) ([]PromotionResp, error) {
+	promotions, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]PromotionResp, 0, len(promotions))
+	for i := range promotions {
+		resp = append(resp, newPromotionResp(&promotions[i]))
+	}
+	return resp, nil
+}
+
+func (s *promotionService) Delete(ctx context.Context, id uint64) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrPromotionNotFound) {
+			return ErrPromotionNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *promotionService) Apply(ctx context.Context, currency, couponCode string, items []model.OrderItem, skus []model.SKU) ([]model.OrderPromotion, error) {
+	var coupon *model.Coupon
+	if couponCode != "" {
+		var err error
+		if coupon, err = s.coupon(ctx, couponCode); err != nil {
+			return nil, err
+		}
+	}
+	promotions, err := s.repo.ListActive(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	promotions = slices.DeleteFunc(promotions, func(p model.Promotion) bool {
+		return p.CouponOnly && (coupon == nil || p.ID != coupon.Campaign.PromotionID)
+	})
+	if len(promotions) == 0 {
+		if coupon != nil {
+			return nil, ErrCouponNotApplicable
+		}
+		return nil, nil
+	}
+
+	rules, err := s.rules(ctx, currency, promotions)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := s.lines(ctx, items, skus, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := make(map[uint64]int, len(promotions))
+	for _, p := range promotions {
+		limits[p.ID] = p.PurchaseLimit
+	}
+
+	result := promotion.Evaluate(lines, rules)
+	applied := make([]model.OrderPromotion, 0, len(result.Applied))
+	redeemed := false
+	for _, a := range result.Applied {
+		p := model.OrderPromotion{PromotionID: a.RuleID, Name: a.Name, Amount: a.Amount, Detail: a.Detail, Units: a.Units, PurchaseLimit: limits[a.RuleID]}
+		if coupon != nil && a.RuleID == coupon.Campaign.PromotionID {
+			p.CouponCode = coupon.Code
+			redeemed = true
+		}
+		applied = append(applied, p)
+	}
+	// A code the customer entered for nothing is an error, not silently ignored
+	if coupon != nil && !redeemed {
+		return nil, ErrCouponNotApplicable
+	}
+	for i := range items {
+		items[i].Discount = result.Discounts[i]
+	}
+	return applied, nil
+}
+
+// coupon looks up the unredeemed coupon with code, with its campaign.
+func (s *promotionService) coupon(ctx context.Context, code string) (*model.Coupon, error) {
+	coupon, err := s.couponRepo.GetByCode(ctx, normalizeCouponCode(code))
+	if err != nil {
+		if errors.Is(err, repository.ErrCouponNotFound) {
+			return nil, ErrCouponNotFound
+		}
+		return nil, err
+	}
+	if coupon.RedeemedAt != nil {
+		return nil, ErrCouponRedeemed
+	}
+	return coupon, nil
+}
+
+func (s *promotionService) RedeemCoupons(ctx context.Context, applied []model.OrderPromotion, orderID, userID uint64) error {
+	now := time.Now()
+	for _, p := range applied {
+		if p.CouponCode == "" {
+			continue
+		}
+		if err := s.couponRepo.Redeem(ctx, p.CouponCode, orderID, userID, now); err != nil {
+			switch {
+			case errors.Is(err, repository.ErrCouponRedeemed):
+				return ErrCouponRedeemed
+			case errors.Is(err, repository.ErrCouponNotFound):
+				return ErrCouponNotFound
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// rules converts promotions into rules for an order charged in currency.
+func (s *promotionService) rules(ctx context.Context, currency string, promotions []model.Promotion) ([]promotion.Rule, error) {
+	var rates *Rates // Loaded for the first tiers in another currency
+	rules := make([]promotion.Rule, 0, len(promotions))
+	for _, p := range promotions {
+		rule := promotion.Rule{
+			ID:          p.ID,
+			Name:        p.Name,
+			Kind:        p.Kind,
+			Stacking:    p.Stacking,
+			Priority:    p.Priority,
+			CategoryID:  p.CategoryID,
+			BuyQuantity: p.BuyQuantity,
+			GetQuantity: p.GetQuantity,
+			Percent:     p.Percent,
+		}
+		for _, tier := range p.Tiers {
+			threshold := tier.Threshold
+			if p.Currency != currency {
+				var err error
+				if rates == nil {
+					if rates, err = s.currencies.Rates(ctx); err != nil {
+						return nil, err
+					}
+				}
+				if threshold, err = rates.Convert(tier.Threshold, p.Currency, currency); err != nil {
+					return nil, fmt.Errorf("failed to convert tiers of promotion %d to %s: %w", p.ID, currency, err)
+				}
+			}
+			rule.Tiers = append(rule.Tiers, promotion.Tier{Threshold: threshold, Percent: tier.Percent})
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// lines converts items into the lines promotions are evaluated against. The
+// categories of the SKUs are only looked up when a rule is limited to one.
+func (s *promotionService) lines(ctx context.Context, items []model.OrderItem, skus []model.SKU, rules []promotion.Rule) ([]promotion.Item, error) {
+	lines := make([]promotion.Item, len(items))
+	for i, item := range items {
+		lines[i] = promotion.Item{SKUID: item.SKUID, Quantity: item.Quantity, Price: item.Price}
+	}
+	if !slices.ContainsFunc(rules, func(rule promotion.Rule) bool { return rule.CategoryID != 0 }) {
+		return lines, nil
+	}
+
+	spuIDs := make([]uint64, 0, len(skus))
+	for _, sku := range skus {
+		spuIDs = append(spuIDs, sku.SPUID)
+	}
+	spus, err := s.productRepo.GetSPUsByIDs(ctx, spuIDs)
+	if err != nil {
+		return nil, err
+	}
+	categoryOf := make(map[uint64]uint64, len(spus))
+	for _, spu := range spus {
+		categoryOf[spu.ID] = spu.CategoryID
+	}
+	categories, err := s.categoryRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parentOf := make(map[uint64]uint64, len(categories))
+	for _, category := range categories {
+		parentOf[category.ID] = category.ParentID
+	}
+
+	for i, sku := range skus {
+		// The category and its ancestors; the depth bound guards against cycles
+		for id, depth := categoryOf[sku.SPUID], 0; id != 0 && depth <= len(categories); id, depth = parentOf[id], depth+1 {
+			lines[i].CategoryIDs = append(lines[i].CategoryIDs, id)
+		}
+	}
+	return lines, nil
+}
+
+func newPromotionResp(p *model.Promotion) PromotionResp {
+	resp := PromotionResp{
+		ID:            p.ID,
+		Name:          p.Name,
+		Kind:          p.Kind,
+		Stacking:      p.Stacking,
+		Priority:      p.Priority,
+		CategoryID:    p.CategoryID,
+		BuyQuantity:   p.BuyQuantity,
+		GetQuantity:   p.GetQuantity,
+		Percent:       p.Percent,
+		Currency:      p.Currency,
+		CouponOnly:    p.CouponOnly,
+		PurchaseLimit: p.PurchaseLimit,
+		StartsAt:      p.StartsAt,
+		EndsAt:        p.EndsAt,
+	}
+	for _, tier := range p.Tiers {
+		resp.Tiers = append(resp.Tiers, PromotionTierReq{Threshold: tier.Threshold, Percent: tier.Percent})
+	}
+	return resp
+}
+
+// newAppliedPromotions converts the promotions applied to an order charged
+// in currency.
+func newAppliedPromotions(currency string, promotions []model.OrderPromotion) []AppliedPromotion {
+	applied := make([]AppliedPromotion, 0, len(promotions))
+	for _, p := range promotions {
+		applied = append(applied, AppliedPromotion{PromotionID: p.PromotionID, Name: p.Name, Amount: money.New(p.Amount, currency), Detail: p.Detail})
+	}
+	return applied
+}
@@ -0,0 +1,179 @@
+package service_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPromotionService_Create(t *testing.T) {
+	yesterday := time.Now().Add(-24 * time.Hour)
+	tiers := []service.PromotionTierReq{{Threshold: decimal.NewFromInt(100), Percent: decimal.NewFromInt(10)}}
+
+	tests := []struct {
+		name    string
+		req     *service.PromotionCreateReq
+		wantErr error
+	}{
+		{name: "BuyXGetY", req: &service.PromotionCreateReq{Name: "3 for 2", Kind: "buy_x_get_y", BuyQuantity: 2, GetQuantity: 1, Percent: decimal.NewFromInt(100)}},
+		{name: "Tiered", req: &service.PromotionCreateReq{Name: "Spend more", Kind: "tiered", Tiers: tiers}},
+		{name: "CategorySale", req: &service.PromotionCreateReq{Name: "Shirts", Kind: "category_sale", Stacking: "exclusive", CategoryID: 3, Percent: decimal.NewFromInt(20)}},
+		{name: "UnknownKind", req: &service.PromotionCreateReq{Kind: "coupon"}, wantErr: service.ErrInvalidPromotion},
+		{name: "UnknownStacking", req: &service.PromotionCreateReq{Kind: "category_sale", Stacking: "sometimes", Percent: decimal.NewFromInt(20)}, wantErr: service.ErrInvalidPromotion},
+		{name: "NoFreeUnits", req: &service.PromotionCreateReq{Kind: "buy_x_get_y", BuyQuantity: 2, Percent: decimal.NewFromInt(100)}, wantErr: service.ErrInvalidPromotion},
+		{name: "PercentOver100", req: &service.PromotionCreateReq{Kind: "category_sale", Percent: decimal.NewFromInt(120)}, wantErr: service.ErrInvalidPromotion},
+		{name: "NoTiers", req: &service.PromotionCreateReq{Kind: "tiered"}, wantErr: service.ErrInvalidPromotion},
+		{name: "UnsupportedCurrency", req: &service.PromotionCreateReq{Kind: "tiered", Currency: "XXX", Tiers: tiers}, wantErr: service.ErrUnsupportedCurrency},
+		{name: "NegativePurchaseLimit", req: &service.PromotionCreateReq{Kind: "category_sale", Percent: decimal.NewFromInt(20), PurchaseLimit: -1}, wantErr: service.ErrInvalidPromotion},
+		{name: "EndsBeforeStart", req: &service.PromotionCreateReq{Kind: "category_sale", Percent: decimal.NewFromInt(20), EndsAt: &yesterday}, wantErr: service.ErrInvalidPromotion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockPromotionRepository(ctrl)
+			if tt.wantErr == nil {
+				repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, p *model.Promotion) error {
+					assert.NotEmpty(t, p.Stacking)
+					assert.False(t, p.StartsAt.IsZero())
+					p.ID = 9
+					return nil
+				})
+			}
+			currencies := service.NewCurrencyService(nil, nil, nil, service.CurrencyOptions{Base: "USD"})
+			promotions := service.NewPromotionService(repo, nil, nil, nil, currencies)
+
+			resp, err := promotions.Create(context.Background(), tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, uint64(9), resp.ID)
+			if tt.req.Kind == "tiered" {
+				assert.Equal(t, "USD", resp.Currency)
+				assert.Len(t, resp.Tiers, 1)
+			}
+		})
+	}
+}
+
+func TestPromotionService_Apply(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockPromotionRepository(ctrl)
+	productRepo := mocks.NewMockProductRepository(ctrl)
+	categoryRepo := mocks.NewMockCategoryRepository(ctrl)
+
+	// The sale covers category 1, which the shirt is in through category 2
+	repo.EXPECT().ListActive(gomock.Any(), gomock.Any()).Return([]model.Promotion{
+		{Base: model.Base{ID: 1}, Name: "Tops sale", Kind: "category_sale", Stacking: "stackable", CategoryID: 1, Percent: decimal.NewFromInt(10)},
+		{Base: model.Base{ID: 2}, Name: "Spend more", Kind: "tiered", Stacking: "stackable", Currency: "USD", Tiers: []model.PromotionTier{{Threshold: decimal.NewFromInt(40), Percent: decimal.NewFromInt(5)}}},
+	}, nil)
+	productRepo.EXPECT().GetSPUsByIDs(gomock.Any(), []uint64{11, 12}).Return([]model.SPU{
+		{Base: model.Base{ID: 11}, CategoryID: 2},
+		{Base: model.Base{ID: 12}, CategoryID: 3},
+	}, nil)
+	categoryRepo.EXPECT().List(gomock.Any()).Return([]model.Category{
+		{Base: model.Base{ID: 1}},
+		{Base: model.Base{ID: 2}, ParentID: 1},
+		{Base: model.Base{ID: 3}},
+	}, nil)
+
+	items := []model.OrderItem{
+		{SKUID: 101, Quantity: 2, Price: decimal.NewFromInt(20)},
+		{SKUID: 102, Quantity: 1, Price: decimal.NewFromInt(10)},
+	}
+	skus := []model.SKU{{Base: model.Base{ID: 101}, SPUID: 11}, {Base: model.Base{ID: 102}, SPUID: 12}}
+	promotions := service.NewPromotionService(repo, nil, productRepo, categoryRepo, nil)
+
+	applied, err := promotions.Apply(context.Background(), "USD", "", items, skus)
+	require.NoError(t, err)
+
+	// 10% off the shirts leaves 36 + 10, then 5% off everything
+	assert.Equal(t, "5.8", items[0].Discount.String())
+	assert.Equal(t, "0.5", items[1].Discount.String())
+	require.Len(t, applied, 2)
+	assert.Equal(t, uint64(1), applied[0].PromotionID)
+	assert.Equal(t, "4", applied[0].Amount.String())
+	assert.Equal(t, "Spend more", applied[1].Name)
+	assert.Equal(t, "2.3", applied[1].Amount.String())
+}
+
+func TestPromotionService_ApplyCoupon(t *testing.T) {
+	redeemedAt := time.Now()
+	campaign := &model.CouponCampaign{PromotionID: 2}
+	active := []model.Promotion{
+		{Base: model.Base{ID: 1}, Name: "Everything", Kind: "category_sale", Stacking: "stackable", Percent: decimal.NewFromInt(10)},
+		{Base: model.Base{ID: 2}, Name: "Newsletter", Kind: "category_sale", Stacking: "stackable", Percent: decimal.NewFromInt(20), CouponOnly: true},
+	}
+
+	tests := []struct {
+		name        string
+		code        string
+		coupon      *model.Coupon
+		couponErr   error
+		promotions  []model.Promotion
+		wantErr     error
+		wantApplied []string // Coupon codes of the applied promotions
+	}{
+		{name: "NoCode", promotions: active, wantApplied: []string{""}},
+		{name: "Code", code: " news-aaaa ", coupon: &model.Coupon{Code: "NEWS-AAAA", Campaign: campaign}, promotions: active, wantApplied: []string{"", "NEWS-AAAA"}},
+		{name: "NotFound", code: "NEWS-ZZZZ", couponErr: repository.ErrCouponNotFound, wantErr: service.ErrCouponNotFound},
+		{name: "Redeemed", code: "NEWS-AAAA", coupon: &model.Coupon{Code: "NEWS-AAAA", Campaign: campaign, RedeemedAt: &redeemedAt}, wantErr: service.ErrCouponRedeemed},
+		{name: "PromotionEnded", code: "NEWS-AAAA", coupon: &model.Coupon{Code: "NEWS-AAAA", Campaign: campaign}, promotions: active[:1], wantErr: service.ErrCouponNotApplicable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockPromotionRepository(ctrl)
+			couponRepo := mocks.NewMockCouponRepository(ctrl)
+			if tt.code != "" {
+				couponRepo.EXPECT().GetByCode(gomock.Any(), strings.ToUpper(strings.TrimSpace(tt.code))).Return(tt.coupon, tt.couponErr)
+			}
+			if tt.promotions != nil {
+				repo.EXPECT().ListActive(gomock.Any(), gomock.Any()).Return(slices.Clone(tt.promotions), nil)
+			}
+			promotions := service.NewPromotionService(repo, couponRepo, nil, nil, nil)
+
+			items := []model.OrderItem{{SKUID: 101, Quantity: 1, Price: decimal.NewFromInt(100)}}
+			applied, err := promotions.Apply(context.Background(), "USD", tt.code, items, []model.SKU{{Base: model.Base{ID: 101}}})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.True(t, items[0].Discount.IsZero(), "no discount on error")
+				return
+			}
+			require.NoError(t, err)
+			var codes []string
+			for _, p := range applied {
+				codes = append(codes, p.CouponCode)
+			}
+			assert.Equal(t, tt.wantApplied, codes)
+		})
+	}
+}
+
+func TestPromotionService_RedeemCoupons(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	couponRepo := mocks.NewMockCouponRepository(ctrl)
+	promotions := service.NewPromotionService(nil, couponRepo, nil, nil, nil)
+	applied := []model.OrderPromotion{{PromotionID: 1}, {PromotionID: 2, CouponCode: "NEWS-AAAA"}}
+
+	couponRepo.EXPECT().Redeem(gomock.Any(), "NEWS-AAAA", uint64(77), uint64(5), gomock.Any()).Return(nil)
+	require.NoError(t, promotions.RedeemCoupons(context.Background(), applied, 77, 5))
+
+	// Another order redeemed it between checkout and placing the order
+	couponRepo.EXPECT().Redeem(gomock.Any(), "NEWS-AAAA", uint64(78), uint64(5), gomock.Any()).Return(repository.ErrCouponRedeemed)
+	assert.ErrorIs(t, promotions.RedeemCoupons(context.Background(), applied, 78, 5), service.ErrCouponRedeemed)
+}
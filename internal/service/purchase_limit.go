@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/proyuen/go-mall/pkg/cache"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrPurchaseLimitExceeded means the order would take the customer past the
+// most units of a SKU, or under a promotion, one customer may buy.
+var ErrPurchaseLimitExceeded = apperr.New(apperr.CodePurchaseLimitExceeded)
+
+// reservePurchases checks the counters in KEYS, the units each customer
+// bought, against the limit and quantity pairs in ARGV, and increments all of
+// them only if none would pass its limit. A missing counter reads as zero. It
+// returns 0 on success, else the 1-based index of the first key over its
+// limit.
+var reservePurchases = redis.NewScript(`
+for i, key in ipairs(KEYS) do
+	local bought = tonumber(redis.call("GET", key) or "0")
+	if bought == nil then
+		return redis.error_reply("invalid purchase count at " .. key)
+	end
+	if bought + tonumber(ARGV[2 * i]) > tonumber(ARGV[2 * i - 1]) then
+		return i
+	end
+end
+for i, key in ipairs(KEYS) do
+	redis.call("INCRBY", key, ARGV[2 * i])
+end
+return 0
+`)
+
+// releasePurchases takes the quantities in ARGV off the counters in KEYS,
+// deleting those it takes to zero. Missing counters, of SKUs and promotions
+// without a limit, are left alone.
+var releasePurchases = redis.NewScript(`
+for i, key in ipairs(KEYS) do
+	local bought = tonumber(redis.call("GET", key) or "0")
+	if bought ~= nil and bought > 0 then
+		if bought > tonumber(ARGV[i]) then
+			redis.call("DECRBY", key, ARGV[i])
+		else
+			redis.call("DEL", key)
+		end
+	end
+end
+return 0
+`)
+
+// PurchaseLimit is how many units of a SKU, or of the items a promotion
+// covers, an order buys, and the most one customer may buy. Exactly one of
+// SKUID and PromotionID is set.
+type PurchaseLimit struct {
+	SKUID       uint64 `json:"sku_id,omitempty"`
+	PromotionID uint64 `json:"promotion_id,omitempty"`
+	Limit       int    `json:"limit"`
+	Quantity    int    `json:"quantity"`
+}
+
+// PurchaseLimiter counts the units each customer bought of limited SKUs and
+// promotions in Redis, across all their orders. Counters are kept per store
+// and have no TTL: a limit holds for as long as it is set.
+type PurchaseLimiter struct {
+	cache cache.Cache
+}
+
+func NewPurchaseLimiter(c cache.Cache) *PurchaseLimiter {
+	return &PurchaseLimiter{cache: c}
+}
+
+// Reserve counts the units of limits as bought by the user in one Lua
+// script: either every counter is incremented or, if any limit would be
+// passed, none is and it returns ErrPurchaseLimitExceeded naming it.
+func (l *PurchaseLimiter) Reserve(ctx context.Context, storeID, userID uint64, limits []PurchaseLimit) error {
+	if len(limits) == 0 {
+		return nil
+	}
+	keys := make([]string, len(limits))
+	args := make([]interface{}, 0, 2*len(limits))
+	for i, limit := range limits {
+		keys[i] = purchaseKey(storeID, userID, limit)
+		args = append(args, limit.Limit, limit.Quantity)
+	}
+	res, err := l.cache.Eval(ctx, reservePurchases, keys, args...)
+	if err != nil {
+		return fmt.Errorf("failed to reserve purchases: %w", err)
+	}
+	over, ok := res.(int64)
+	if !ok {
+		return fmt.Errorf("unexpected purchase reservation result %v", res)
+	}
+	if over > 0 && int(over) <= len(limits) {
+		limit := limits[over-1]
+		if limit.SKUID != 0 {
+			return ErrPurchaseLimitExceeded.WithSKU(limit.SKUID).With("limit", limit.Limit)
+		}
+		return ErrPurchaseLimitExceeded.With("promotion_id", limit.PromotionID).With("limit", limit.Limit)
+	}
+	return nil
+}
+
+// Release gives back the units Reserve counted, for an order that was not
+// placed or was cancelled.
+func (l *PurchaseLimiter) Release(ctx context.Context, storeID, userID uint64, limits []PurchaseLimit) error {
+	if len(limits) == 0 {
+		return nil
+	}
+	keys := make([]string, len(limits))
+	args := make([]interface{}, len(limits))
+	for i, limit := range limits {
+		keys[i] = purchaseKey(storeID, userID, limit)
+		args[i] = limit.Quantity
+	}
+	if _, err := l.cache.Eval(ctx, releasePurchases, keys, args...); err != nil {
+		return fmt.Errorf("failed to release purchases: %w", err)
+	}
+	return nil
+}
+
+// purchaseLimits returns the purchase limits an order of items counts
+// against: of each limited SKU, lined up with items in skus, with the lines of
+// the SKU merged, and of each limited promotion it gets.
+func purchaseLimits(items []model.OrderItem, skus []model.SKU, promotions []model.OrderPromotion) []PurchaseLimit {
+	var limits []PurchaseLimit
+	index := make(map[uint64]int)
+	for i, item := range items {
+		if skus[i].PurchaseLimit <= 0 {
+			continue
+		}
+		if j, ok := index[item.SKUID]; ok {
+			limits[j].Quantity += item.Quantity
+			continue
+		}
+		index[item.SKUID] = len(limits)
+		limits = append(limits, PurchaseLimit{SKUID: item.SKUID, Limit: skus[i].PurchaseLimit, Quantity: item.Quantity})
+	}
+	for _, p := range promotions {
+		if p.PurchaseLimit > 0 {
+			limits = append(limits, PurchaseLimit{PromotionID: p.PromotionID, Limit: p.PurchaseLimit, Quantity: p.Units})
+		}
+	}
+	return limits
+}
+
+// releaseOrderPurchases gives back what a cancelled order counted against
+// its customer's limits. The order is cancelled already, so failing to is
+// only logged: the customer may buy less until the counter is corrected.
+func releaseOrderPurchases(ctx context.Context, l *PurchaseLimiter, order *model.Order) {
+	if l == nil {
+		return
+	}
+	if err := l.Release(ctx, order.StoreID, order.UserID, orderPurchaseLimits(order)); err != nil {
+		slog.ErrorContext(ctx, "Failed to release purchases of cancelled order", "order_id", order.ID, logger.Err(err))
+	}
+}
+
+// orderPurchaseLimits returns what the order counted against its customer's
+// limits. Every item the customer ordered is included, since the SKU's limit
+// may have changed since: releasing a SKU that was not limited leaves no
+// counter behind. The component lines of bundles never counted.
+func orderPurchaseLimits(order *model.Order) []PurchaseLimit {
+	quantities := make(map[uint64]int, len(order.Items))
+	var limits []PurchaseLimit
+	for _, item := range customerItems(order.Items) {
+		if _, ok := quantities[item.SKUID]; !ok {
+			limits = append(limits, PurchaseLimit{SKUID: item.SKUID})
+		}
+		quantities[item.SKUID] += item.Quantity
+	}
+	for i := range limits {
+		limits[i].Quantity = quantities[limits[i].SKUID]
+	}
+	for _, p := range order.Promotions {
+		if p.PurchaseLimit > 0 {
+			limits = append(limits, PurchaseLimit{PromotionID: p.PromotionID, Limit: p.PurchaseLimit, Quantity: p.Units})
+		}
+	}
+	return limits
+}
+
+// purchaseKey is the cache key of what a user bought of a limited SKU or
+// under a limited promotion. The store is always part of it, so that orders
+// cancelled outside any store's request release the same counter.
+func purchaseKey(storeID, userID uint64, limit PurchaseLimit) string {
+	if limit.SKUID != 0 {
+		return fmt.Sprintf("mall:purchases:store:%d:sku:%d:user:%d", storeID, limit.SKUID, userID)
+	}
+	return fmt.Sprintf("mall:purchases:store:%d:promotion:%d:user:%d", storeID, limit.PromotionID, userID)
+}
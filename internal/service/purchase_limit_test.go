@@ -0,0 +1,120 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPurchaseLimiter_Reserve(t *testing.T) {
+	limits := []service.PurchaseLimit{
+		{SKUID: 101, Limit: 2, Quantity: 2},
+		{PromotionID: 7, Limit: 5, Quantity: 3},
+	}
+	tests := []struct {
+		name     string
+		result   any
+		wantMeta map[string]any // Of the limit exceeded
+	}{
+		{name: "Reserved", result: int64(0)},
+		{name: "SKULimit", result: int64(1), wantMeta: map[string]any{"sku_id": uint64(101), "limit": 2}},
+		{name: "PromotionLimit", result: int64(2), wantMeta: map[string]any{"promotion_id": uint64(7), "limit": 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			cache := mocks.NewMockCache(ctrl)
+			cache.EXPECT().Eval(gomock.Any(), gomock.Any(),
+				[]string{"mall:purchases:store:3:sku:101:user:1", "mall:purchases:store:3:promotion:7:user:1"},
+				2, 2, 5, 3,
+			).Return(tt.result, nil)
+
+			err := service.NewPurchaseLimiter(cache).Reserve(context.Background(), 3, 1, limits)
+			if tt.wantMeta != nil {
+				assert.ErrorIs(t, err, service.ErrPurchaseLimitExceeded)
+				var appErr *apperr.Error
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, tt.wantMeta, appErr.Meta)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestOrderService_CreateOrderPurchaseLimit(t *testing.T) {
+	errDB := errors.New("db down")
+	tests := []struct {
+		name        string
+		reserved    any
+		createErr   error
+		errIs       error
+		wantRelease bool
+	}{
+		{name: "Placed", reserved: int64(0)},
+		{name: "LimitReached", reserved: int64(1), errIs: service.ErrPurchaseLimitExceeded},
+		{name: "OrderFailed", reserved: int64(0), createErr: errDB, errIs: errDB, wantRelease: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			webhooks := mocks.NewMockWebhookEmitter(ctrl)
+			events := mocks.NewMockEventPublisher(ctrl)
+			events.EXPECT().Publish(gomock.Any(), service.EventOrderCreated, gomock.Any()).Return(nil).AnyTimes()
+			cache := mocks.NewMockCache(ctrl)
+
+			// Both lines of the limited SKU count against its limit; the other SKU is unlimited
+			productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101, 102, 101}).Return([]model.SKU{
+				{Base: model.Base{ID: 101}, Price: decimal.NewFromInt(10), Currency: "USD", Stock: 100, PurchaseLimit: 3},
+				{Base: model.Base{ID: 102}, Price: decimal.NewFromInt(10), Currency: "USD", Stock: 100},
+				{Base: model.Base{ID: 101}, Price: decimal.NewFromInt(10), Currency: "USD", Stock: 100, PurchaseLimit: 3},
+			}, nil)
+			key := []string{"mall:purchases:store:0:sku:101:user:1"}
+			cache.EXPECT().Eval(gomock.Any(), gomock.Any(), key, 3, 3).Return(tt.reserved, nil)
+			if tt.wantRelease {
+				cache.EXPECT().Eval(gomock.Any(), gomock.Any(), key, 3).Return(int64(0), nil)
+			}
+			if tt.reserved == int64(0) {
+				// Stock is only deducted once the units are reserved
+				txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(3)
+				productRepo.EXPECT().GetSKUByID(gomock.Any(), gomock.Any()).Return(&model.SKU{Stock: 90}, nil).Times(3)
+				orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).Return(tt.createErr)
+				if tt.createErr == nil {
+					webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventOrderCreated, gomock.Any()).Return(nil)
+					// The stock change is announced once per SKU
+					cache.EXPECT().Publish(gomock.Any(), service.CatalogEventsChannel, []byte(`{"sku_ids":[101,102]}`)).Return(nil)
+				}
+			}
+
+			currencies := service.NewCurrencyService(nil, nil, nil, service.CurrencyOptions{Base: "USD"})
+			orderService := service.NewOrderService(orderRepo, productRepo, txManager, webhooks, events, currencies, service.NewTaxService(nil), nil, nil, nil, cache, nil, nil, nil, service.OrderOptions{})
+			_, err := orderService.CreateOrder(context.Background(), &service.OrderCreateReq{
+				UserID:   1,
+				Currency: "USD",
+				Items:    []service.OrderItemReq{{SKUID: 101, Quantity: 1}, {SKUID: 102, Quantity: 5}, {SKUID: 101, Quantity: 2}},
+			})
+			if tt.errIs != nil {
+				assert.ErrorIs(t, err, tt.errIs)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
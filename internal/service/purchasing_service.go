@@ -0,0 +1,548 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/database"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultPurchasingPageSize is how many suppliers, purchase orders or stock
+// movements a list returns when no limit is given.
+const DefaultPurchasingPageSize = 20
+
+var (
+	ErrSupplierNotFound      = repository.ErrSupplierNotFound
+	ErrPurchaseOrderNotFound = repository.ErrPurchaseOrderNotFound
+	// ErrInvalidPurchaseOrder means a purchase order or a receipt is
+	// malformed, e.g. receives more than is outstanding.
+	ErrInvalidPurchaseOrder = errors.New("invalid purchase order")
+	// ErrPurchaseOrderStatus means the change is not allowed in the purchase
+	// order's status, e.g. receiving goods of a cancelled one.
+	ErrPurchaseOrderStatus = errors.New("not allowed in the purchase order's status")
+)
+
+// PurchaseOrderStatuses lists the statuses purchase orders can be listed by.
+var PurchaseOrderStatuses = []string{model.PurchaseOrderStatusOpen, model.PurchaseOrderStatusReceived, model.PurchaseOrderStatusCancelled}
+
+// SupplierReq creates or updates a supplier.
+type SupplierReq struct {
+	Name         string
+	Email        string
+	Phone        string
+	LeadTimeDays int // Sets the expected date of its purchase orders that have none
+}
+
+type SupplierResp struct {
+	ID           uint64    `json:"id,string"`
+	Name         string    `json:"name" example:"Acme Wholesale"`
+	Email        string    `json:"email" example:"orders@acme.example"`
+	Phone        string    `json:"phone" example:"+49 30 1234567"`
+	LeadTimeDays int       `json:"lead_time_days" example:"14"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// PurchaseOrderReq orders stock from a supplier.
+type PurchaseOrderReq struct {
+	SupplierID uint64
+	Currency   string     // Of the unit costs
+	ExpectedAt *time.Time // When the goods are expected; nil is the supplier's lead time from now
+	Note       string
+	Items      []PurchaseOrderItemReq
+}
+
+type PurchaseOrderItemReq struct {
+	SKUID    uint64
+	Quantity int
+	UnitCost decimal.Decimal
+}
+
+// ReceiptLine is a quantity of a SKU received on a purchase order.
+type ReceiptLine struct {
+	SKUID    uint64
+	Quantity int
+}
+
+type PurchaseOrderResp struct {
+	ID         uint64                  `json:"id,string"`
+	SupplierID uint64                  `json:"supplier_id,string"`
+	Status     string                  `json:"status" example:"open"` // open, received or cancelled
+	Currency   string                  `json:"currency" example:"USD"`
+	ExpectedAt *time.Time              `json:"expected_at,omitempty"`
+	Note       string                  `json:"note"`
+	Items      []PurchaseOrderItemResp `json:"items"`
+	TotalCost  decimal.Decimal         `json:"total_cost" swaggertype:"string" example:"4250.00"` // Of every item ordered
+	CreatedAt  time.Time               `json:"created_at"`
+}
+
+type PurchaseOrderItemResp struct {
+	SKUID    uint64          `json:"sku_id,string"`
+	Quantity int             `json:"quantity" example:"500"`
+	Received int             `json:"received" example:"200"`
+	UnitCost decimal.Decimal `json:"unit_cost" swaggertype:"string" example:"8.50"`
+}
+
+// StockMovementResp is an entry of the stock ledger of a SKU.
+type StockMovementResp struct {
+	ID          uint64    `json:"id,string"`
+	SKUID       uint64    `json:"sku_id,string"`
+	Quantity    int       `json:"quantity" example:"200"` // Added to the stock; negative when taken out
+	StockAfter  int       `json:"stock_after" example:"230"`
+	Reason      string    `json:"reason" example:"purchase_receipt"`
+	ReferenceID uint64    `json:"reference_id,string"` // The purchase order of a receipt, or the stock hold of a hold or release
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PurchasingService manages suppliers and the purchase orders that bring
+// stock in. Receiving goods adds them to the stock of their SKUs and to the
+// stock ledger. The pre-order SKUs on open purchase orders are shown
+// available at the earliest expected date of those orders.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/purchasing_service_mock.go -package=mocks
+type PurchasingService interface {
+	CreateSupplier(ctx context.Context, req *SupplierReq) (*SupplierResp, error)
+	ListSuppliers(ctx context.Context, offset, limit int) ([]SupplierResp, error)
+	UpdateSupplier(ctx context.Context, id uint64, req *SupplierReq) (*SupplierResp, error)
+	CreatePurchaseOrder(ctx context.Context, req *PurchaseOrderReq) (*PurchaseOrderResp, error)
+	GetPurchaseOrder(ctx context.Context, id uint64) (*PurchaseOrderResp, error)
+	// ListPurchaseOrders returns the purchase orders with status, of
+	// supplierID, most recent first. An empty status or a zero supplierID
+	// matches all.
+	ListPurchaseOrders(ctx context.Context, status string, supplierID uint64, offset, limit int) ([]PurchaseOrderResp, error)
+	// Reschedule moves the date the goods of an open purchase order are
+	// expected, e.g. when the supplier is late.
+	Reschedule(ctx context.Context, id uint64, expectedAt time.Time) (*PurchaseOrderResp, error)
+	// Receive adds goods delivered on an open purchase order to stock. A
+	// purchase order can be received in several deliveries; it is received
+	// once all of it was.
+	Receive(ctx context.Context, id uint64, lines []ReceiptLine) (*PurchaseOrderResp, error)
+	// CancelPurchaseOrder gives up on what was not received of an open
+	// purchase order.
+	CancelPurchaseOrder(ctx context.Context, id uint64) (*PurchaseOrderResp, error)
+	ListStockMovements(ctx context.Context, skuID uint64, offset, limit int) ([]StockMovementResp, error)
+}
+
+type purchasingService struct {
+	supplierRepo repository.SupplierRepository
+	poRepo       repository.PurchaseOrderRepository
+	movementRepo repository.StockMovementRepository
+	productRepo  repository.ProductRepository
+	txManager    database.TransactionManager
+	catalog      *CatalogCache
+}
+
+// NewPurchasingService creates a new PurchasingService instance. Stock
+// changes are announced through catalog.
+func NewPurchasingService(supplierRepo repository.SupplierRepository, poRepo repository.PurchaseOrderRepository, movementRepo repository.StockMovementRepository, productRepo repository.ProductRepository, txManager database.TransactionManager, catalog *CatalogCache) PurchasingService {
+	return &purchasingService{
+		supplierRepo: supplierRepo,
+		poRepo:       poRepo,
+		movementRepo: movementRepo,
+		productRepo:  productRepo,
+		txManager:    txManager,
+		catalog:      catalog,
+	}
+}
+
+func (s *purchasingService) CreateSupplier(ctx context.Context, req *SupplierReq) (*SupplierResp, error) {
+	supplier := &model.Supplier{Name: req.Name, Email: req.Email, Phone: req.Phone, LeadTimeDays: req.LeadTimeDays}
+	if err := s.supplierRepo.Create(ctx, supplier); err != nil {
+		return nil, fmt.Errorf("failed to create supplier: %w", err)
+	}
+	resp := newSupplierResp(supplier)
+	RecordAudit(ctx, AuditEntry{
+		Action:     "supplier.create",
+		Resource:   "supplier",
+		ResourceID: strconv.FormatUint(supplier.ID, 10),
+		After:      resp,
+	})
+	return &resp, nil
+}
+
+func (s *purchasingService) ListSuppliers(ctx context.Context, offset, limit int) ([]SupplierResp, error) {
+	if limit <= 0 {
+		limit = DefaultPurchasingPageSize
+	}
+	suppliers, err := s.supplierRepo.List(ctx, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list suppliers: %w", err)
+	}
+	resps := make([]SupplierResp, len(suppliers))
+	for i := range suppliers {
+		resps[i] = newSupplierResp(&suppliers[i])
+	}
+	return resps, nil
+}
+
+func (s *purchasingService) UpdateSupplier(ctx context.Context, id uint64, req *SupplierReq) (*SupplierResp, error) {
+	supplier, err := s.supplierRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := newSupplierResp(supplier)
+	supplier.Name, supplier.Email, supplier.Phone, supplier.LeadTimeDays = req.Name, req.Email, req.Phone, req.LeadTimeDays
+	if err := s.supplierRepo.Update(ctx, supplier); err != nil {
+		return nil, err
+	}
+	resp := newSupplierResp(supplier)
+	RecordAudit(ctx, AuditEntry{
+		Action:     "supplier.update",
+		Resource:   "supplier",
+		ResourceID: strconv.FormatUint(id, 10),
+		Before:     before,
+		After:      resp,
+	})
+	return &resp, nil
+}
+
+func (s *purchasingService) CreatePurchaseOrder(ctx context.Context, req *PurchaseOrderReq) (*PurchaseOrderResp, error) {
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("%w: no items", ErrInvalidPurchaseOrder)
+	}
+	skuIDs := make([]uint64, 0, len(req.Items))
+	items := make([]model.PurchaseOrderItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: invalid quantity for SKU %d", ErrInvalidPurchaseOrder, item.SKUID)
+		}
+		if item.UnitCost.IsNegative() {
+			return nil, fmt.Errorf("%w: negative unit cost for SKU %d", ErrInvalidPurchaseOrder, item.SKUID)
+		}
+		if slices.Contains(skuIDs, item.SKUID) {
+			return nil, fmt.Errorf("%w: SKU %d ordered twice", ErrInvalidPurchaseOrder, item.SKUID)
+		}
+		skuIDs = append(skuIDs, item.SKUID)
+		items = append(items, model.PurchaseOrderItem{SKUID: item.SKUID, Quantity: item.Quantity, UnitCost: item.UnitCost})
+	}
+	supplier, err := s.supplierRepo.GetByID(ctx, req.SupplierID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.productRepo.GetSKUsByIDs(ctx, skuIDs); err != nil {
+		if errors.Is(err, repository.ErrSKUNotFound) {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidPurchaseOrder, err)
+		}
+		return nil, fmt.Errorf("failed to get SKUs: %w", err)
+	}
+
+	expectedAt := req.ExpectedAt
+	if expectedAt == nil {
+		at := time.Now().AddDate(0, 0, supplier.LeadTimeDays)
+		expectedAt = &at
+	}
+	po := &model.PurchaseOrder{
+		SupplierID: supplier.ID,
+		Status:     model.PurchaseOrderStatusOpen,
+		Currency:   strings.ToUpper(req.Currency),
+		ExpectedAt: expectedAt,
+		Note:       req.Note,
+		Items:      items,
+	}
+	if err := s.poRepo.Create(ctx, po); err != nil {
+		return nil, fmt.Errorf("failed to create purchase order: %w", err)
+	}
+	resp := newPurchaseOrderResp(po)
+	RecordAudit(ctx, AuditEntry{
+		Action:     "purchase_order.create",
+		Resource:   "purchase_order",
+		ResourceID: strconv.FormatUint(po.ID, 10),
+		After:      resp,
+	})
+	s.refreshAvailability(ctx, skuIDs)
+	return resp, nil
+}
+
+func (s *purchasingService) GetPurchaseOrder(ctx context.Context, id uint64) (*PurchaseOrderResp, error) {
+	po, err := s.poRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return newPurchaseOrderResp(po), nil
+}
+
+func (s *purchasingService) ListPurchaseOrders(ctx context.Context, status string, supplierID uint64, offset, limit int) ([]PurchaseOrderResp, error) {
+	if status != "" && !slices.Contains(PurchaseOrderStatuses, status) {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidPurchaseOrder, status)
+	}
+	if limit <= 0 {
+		limit = DefaultPurchasingPageSize
+	}
+	pos, err := s.poRepo.List(ctx, status, supplierID, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list purchase orders: %w", err)
+	}
+	resps := make([]PurchaseOrderResp, len(pos))
+	for i := range pos {
+		resps[i] = *newPurchaseOrderResp(&pos[i])
+	}
+	return resps, nil
+}
+
+func (s *purchasingService) Reschedule(ctx context.Context, id uint64, expectedAt time.Time) (*PurchaseOrderResp, error) {
+	var before *time.Time
+	po, err := s.update(ctx, id, func(_ context.Context, po *model.PurchaseOrder) error {
+		before = po.ExpectedAt
+		po.ExpectedAt = &expectedAt
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	RecordAudit(ctx, AuditEntry{
+		Action:     "purchase_order.reschedule",
+		Resource:   "purchase_order",
+		ResourceID: strconv.FormatUint(id, 10),
+		Before:     map[string]any{"expected_at": before},
+		After:      map[string]any{"expected_at": po.ExpectedAt},
+	})
+	s.refreshAvailability(ctx, purchaseOrderSKUIDs(po))
+	return newPurchaseOrderResp(po), nil
+}
+
+// Receive locks the purchase order, then its SKUs in ID order, as orders
+// lock them, so that the stock after each receipt is exact in the ledger.
+// Backorders waiting for the goods get them at the next allocation run.
+func (s *purchasingService) Receive(ctx context.Context, id uint64, lines []ReceiptLine) (*PurchaseOrderResp, error) {
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%w: nothing received", ErrInvalidPurchaseOrder)
+	}
+	quantities := make(map[uint64]int, len(lines))
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: invalid quantity for SKU %d", ErrInvalidPurchaseOrder, line.SKUID)
+		}
+		if _, ok := quantities[line.SKUID]; ok {
+			return nil, fmt.Errorf("%w: SKU %d received twice", ErrInvalidPurchaseOrder, line.SKUID)
+		}
+		quantities[line.SKUID] = line.Quantity
+	}
+	skuIDs := slices.Sorted(maps.Keys(quantities))
+
+	var movements []model.StockMovement
+	po, err := s.update(ctx, id, func(txCtx context.Context, po *model.PurchaseOrder) error {
+		items := make(map[uint64]*model.PurchaseOrderItem, len(po.Items))
+		for i := range po.Items {
+			items[po.Items[i].SKUID] = &po.Items[i]
+		}
+		for _, skuID := range skuIDs {
+			item, ok := items[skuID]
+			if !ok {
+				return fmt.Errorf("%w: SKU %d is not on the purchase order", ErrInvalidPurchaseOrder, skuID)
+			}
+			if quantities[skuID] > item.Outstanding() {
+				return fmt.Errorf("%w: %d of SKU %d outstanding", ErrInvalidPurchaseOrder, item.Outstanding(), skuID)
+			}
+		}
+
+		skus, err := s.productRepo.GetSKUsForUpdate(txCtx, skuIDs)
+		if err != nil {
+			return err
+		}
+		stock := make(map[uint64]int, len(skus))
+		for _, sku := range skus {
+			stock[sku.ID] = sku.Stock
+		}
+		movements = movements[:0]
+		for _, skuID := range skuIDs {
+			current, ok := stock[skuID]
+			if !ok {
+				return fmt.Errorf("%w: SKU %d no longer exists", ErrInvalidPurchaseOrder, skuID)
+			}
+			quantity := quantities[skuID]
+			if err := s.productRepo.UpdateSKUStock(txCtx, skuID, quantity); err != nil {
+				return err
+			}
+			items[skuID].Received += quantity
+			movements = append(movements, model.StockMovement{
+				SKUID:       skuID,
+				Quantity:    quantity,
+				StockAfter:  current + quantity,
+				Reason:      model.StockMovementReceipt,
+				ReferenceID: po.ID,
+			})
+		}
+		if !slices.ContainsFunc(po.Items, func(item model.PurchaseOrderItem) bool { return item.Outstanding() > 0 }) {
+			po.Status = model.PurchaseOrderStatusReceived
+		}
+		return s.movementRepo.Create(txCtx, movements)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	received := make(map[string]int, len(quantities))
+	for skuID, quantity := range quantities {
+		received[strconv.FormatUint(skuID, 10)] = quantity
+	}
+	RecordAudit(ctx, AuditEntry{
+		Action:     "purchase_order.receive",
+		Resource:   "purchase_order",
+		ResourceID: strconv.FormatUint(id, 10),
+		After:      map[string]any{"received": received, "status": po.Status},
+	})
+	// The products show their old stock until they expire if this fails
+	if err := s.catalog.PublishStockChanged(ctx, skuIDs); err != nil {
+		slog.WarnContext(ctx, "Failed to announce stock change", "sku_ids", skuIDs, logger.Err(err))
+	}
+	s.refreshAvailability(ctx, skuIDs)
+	return newPurchaseOrderResp(po), nil
+}
+
+func (s *purchasingService) CancelPurchaseOrder(ctx context.Context, id uint64) (*PurchaseOrderResp, error) {
+	po, err := s.update(ctx, id, func(_ context.Context, po *model.PurchaseOrder) error {
+		po.Status = model.PurchaseOrderStatusCancelled
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	RecordAudit(ctx, AuditEntry{
+		Action:     "purchase_order.cancel",
+		Resource:   "purchase_order",
+		ResourceID: strconv.FormatUint(id, 10),
+		Before:     map[string]any{"status": model.PurchaseOrderStatusOpen},
+		After:      map[string]any{"status": po.Status},
+	})
+	s.refreshAvailability(ctx, purchaseOrderSKUIDs(po))
+	return newPurchaseOrderResp(po), nil
+}
+
+func (s *purchasingService) ListStockMovements(ctx context.Context, skuID uint64, offset, limit int) ([]StockMovementResp, error) {
+	if limit <= 0 {
+		limit = DefaultPurchasingPageSize
+	}
+	movements, err := s.movementRepo.ListBySKU(ctx, skuID, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stock movements: %w", err)
+	}
+	resps := make([]StockMovementResp, len(movements))
+	for i, movement := range movements {
+		resps[i] = StockMovementResp{
+			ID:          movement.ID,
+			SKUID:       movement.SKUID,
+			Quantity:    movement.Quantity,
+			StockAfter:  movement.StockAfter,
+			Reason:      movement.Reason,
+			ReferenceID: movement.ReferenceID,
+			CreatedAt:   movement.CreatedAt,
+		}
+	}
+	return resps, nil
+}
+
+// update applies change to an open purchase order, locked in a transaction
+// whose context change runs in, and saves it.
+func (s *purchasingService) update(ctx context.Context, id uint64, change func(txCtx context.Context, po *model.PurchaseOrder) error) (*model.PurchaseOrder, error) {
+	var po *model.PurchaseOrder
+	err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		if po, err = s.poRepo.GetByIDForUpdate(txCtx, id); err != nil {
+			return err
+		}
+		if po.Status != model.PurchaseOrderStatusOpen {
+			return fmt.Errorf("%w: purchase order is %s", ErrPurchaseOrderStatus, po.Status)
+		}
+		if err := change(txCtx, po); err != nil {
+			return err
+		}
+		return s.poRepo.Update(txCtx, po)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return po, nil
+}
+
+// refreshAvailability shows the pre-order SKUs among skuIDs available at the
+// earliest expected date of their open purchase orders, or clears the date
+// once none is left. It only logs failures: the purchase order changed all
+// the same.
+func (s *purchasingService) refreshAvailability(ctx context.Context, skuIDs []uint64) {
+	if err := s.updateAvailability(ctx, skuIDs); err != nil {
+		slog.WarnContext(ctx, "Failed to update expected availability of SKUs", "sku_ids", skuIDs, logger.Err(err))
+	}
+}
+
+func (s *purchasingService) updateAvailability(ctx context.Context, skuIDs []uint64) error {
+	skus, err := s.productRepo.GetSKUsByIDs(ctx, skuIDs)
+	if err != nil {
+		return err
+	}
+	expected, err := s.poRepo.EarliestExpected(ctx, skuIDs)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, sku := range skus {
+		if !sku.PreOrder {
+			continue
+		}
+		var availableAt *time.Time
+		if at, ok := expected[sku.ID]; ok {
+			availableAt = &at
+		}
+		if timesEqual(sku.AvailableAt, availableAt) {
+			continue
+		}
+		if err := s.productRepo.UpdateSKUAvailableAt(ctx, sku.ID, availableAt); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func timesEqual(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func purchaseOrderSKUIDs(po *model.PurchaseOrder) []uint64 {
+	ids := make([]uint64, len(po.Items))
+	for i, item := range po.Items {
+		ids[i] = item.SKUID
+	}
+	return ids
+}
+
+func newSupplierResp(supplier *model.Supplier) SupplierResp {
+	return SupplierResp{
+		ID:           supplier.ID,
+		Name:         supplier.Name,
+		Email:        supplier.Email,
+		Phone:        supplier.Phone,
+		LeadTimeDays: supplier.LeadTimeDays,
+		CreatedAt:    supplier.CreatedAt,
+	}
+}
+
+func newPurchaseOrderResp(po *model.PurchaseOrder) *PurchaseOrderResp {
+	resp := &PurchaseOrderResp{
+		ID:         po.ID,
+		SupplierID: po.SupplierID,
+		Status:     po.Status,
+		Currency:   po.Currency,
+		ExpectedAt: po.ExpectedAt,
+		Note:       po.Note,
+		Items:      make([]PurchaseOrderItemResp, len(po.Items)),
+		TotalCost:  decimal.Zero,
+		CreatedAt:  po.CreatedAt,
+	}
+	for i, item := range po.Items {
+		resp.Items[i] = PurchaseOrderItemResp{SKUID: item.SKUID, Quantity: item.Quantity, Received: item.Received, UnitCost: item.UnitCost}
+		resp.TotalCost = resp.TotalCost.Add(item.UnitCost.Mul(decimal.NewFromInt(int64(item.Quantity))))
+	}
+	return resp
+}
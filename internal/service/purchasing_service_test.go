@@ -0,0 +1,266 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPurchasingService_CreatePurchaseOrder(t *testing.T) {
+	expectedAt := time.Date(2030, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		req       *service.PurchaseOrderReq
+		mockSetup func(supplierRepo *mocks.MockSupplierRepository, poRepo *mocks.MockPurchaseOrderRepository, productRepo *mocks.MockProductRepository)
+		wantErrIs error
+		check     func(t *testing.T, resp *service.PurchaseOrderResp)
+	}{
+		{
+			name: "Success",
+			req: &service.PurchaseOrderReq{SupplierID: 5, Currency: "usd", ExpectedAt: &expectedAt, Items: []service.PurchaseOrderItemReq{
+				{SKUID: 101, Quantity: 500, UnitCost: decimal.RequireFromString("8.50")},
+				{SKUID: 102, Quantity: 10, UnitCost: decimal.RequireFromString("2")},
+			}},
+			mockSetup: func(supplierRepo *mocks.MockSupplierRepository, poRepo *mocks.MockPurchaseOrderRepository, productRepo *mocks.MockProductRepository) {
+				supplierRepo.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(&model.Supplier{Base: model.Base{ID: 5}}, nil)
+				productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{101, 102}).Return([]model.SKU{
+					{Base: model.Base{ID: 101}, PreOrder: true},
+					{Base: model.Base{ID: 102}},
+				}, nil).Times(2)
+				poRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, po *model.PurchaseOrder) error {
+					assert.Equal(t, model.PurchaseOrderStatusOpen, po.Status)
+					assert.Equal(t, "USD", po.Currency)
+					po.ID = 9
+					return nil
+				})
+				poRepo.EXPECT().EarliestExpected(gomock.Any(), []uint64{101, 102}).Return(map[uint64]time.Time{101: expectedAt, 102: expectedAt}, nil)
+				// Only the pre-order SKU is shown available at the expected date
+				productRepo.EXPECT().UpdateSKUAvailableAt(gomock.Any(), uint64(101), &expectedAt).Return(nil)
+			},
+			check: func(t *testing.T, resp *service.PurchaseOrderResp) {
+				assert.Equal(t, uint64(9), resp.ID)
+				assert.Equal(t, "4270", resp.TotalCost.String())
+			},
+		},
+		{
+			name: "LeadTime",
+			req:  &service.PurchaseOrderReq{SupplierID: 5, Currency: "USD", Items: []service.PurchaseOrderItemReq{{SKUID: 102, Quantity: 10}}},
+			mockSetup: func(supplierRepo *mocks.MockSupplierRepository, poRepo *mocks.MockPurchaseOrderRepository, productRepo *mocks.MockProductRepository) {
+				supplierRepo.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(&model.Supplier{Base: model.Base{ID: 5}, LeadTimeDays: 14}, nil)
+				productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{102}).Return([]model.SKU{{Base: model.Base{ID: 102}}}, nil).Times(2)
+				poRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, po *model.PurchaseOrder) error {
+					require.NotNil(t, po.ExpectedAt)
+					assert.WithinDuration(t, time.Now().AddDate(0, 0, 14), *po.ExpectedAt, time.Minute)
+					return nil
+				})
+				poRepo.EXPECT().EarliestExpected(gomock.Any(), []uint64{102}).Return(nil, nil)
+			},
+		},
+		{
+			name:      "NoItems",
+			req:       &service.PurchaseOrderReq{SupplierID: 5, Currency: "USD"},
+			wantErrIs: service.ErrInvalidPurchaseOrder,
+		},
+		{
+			name: "OrderedTwice",
+			req: &service.PurchaseOrderReq{SupplierID: 5, Currency: "USD", Items: []service.PurchaseOrderItemReq{
+				{SKUID: 101, Quantity: 1},
+				{SKUID: 101, Quantity: 2},
+			}},
+			wantErrIs: service.ErrInvalidPurchaseOrder,
+		},
+		{
+			name: "UnknownSKU",
+			req:  &service.PurchaseOrderReq{SupplierID: 5, Currency: "USD", Items: []service.PurchaseOrderItemReq{{SKUID: 999, Quantity: 1}}},
+			mockSetup: func(supplierRepo *mocks.MockSupplierRepository, poRepo *mocks.MockPurchaseOrderRepository, productRepo *mocks.MockProductRepository) {
+				supplierRepo.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(&model.Supplier{Base: model.Base{ID: 5}}, nil)
+				productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{999}).Return(nil, repository.ErrSKUNotFound)
+			},
+			wantErrIs: service.ErrInvalidPurchaseOrder,
+		},
+		{
+			name: "UnknownSupplier",
+			req:  &service.PurchaseOrderReq{SupplierID: 5, Currency: "USD", Items: []service.PurchaseOrderItemReq{{SKUID: 101, Quantity: 1}}},
+			mockSetup: func(supplierRepo *mocks.MockSupplierRepository, poRepo *mocks.MockPurchaseOrderRepository, productRepo *mocks.MockProductRepository) {
+				supplierRepo.EXPECT().GetByID(gomock.Any(), uint64(5)).Return(nil, repository.ErrSupplierNotFound)
+			},
+			wantErrIs: service.ErrSupplierNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			supplierRepo := mocks.NewMockSupplierRepository(ctrl)
+			poRepo := mocks.NewMockPurchaseOrderRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(supplierRepo, poRepo, productRepo)
+			}
+
+			svc := service.NewPurchasingService(supplierRepo, poRepo, nil, productRepo, nil, nil)
+			ctx, trail := service.WithAuditTrail(context.Background())
+			resp, err := svc.CreatePurchaseOrder(ctx, tt.req)
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				assert.Empty(t, trail.Entries())
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, trail.Entries(), 1)
+			assert.Equal(t, "purchase_order.create", trail.Entries()[0].Action)
+			if tt.check != nil {
+				tt.check(t, resp)
+			}
+		})
+	}
+}
+
+func TestPurchasingService_Receive(t *testing.T) {
+	expectedAt := time.Date(2030, 3, 1, 0, 0, 0, 0, time.UTC)
+	purchaseOrder := func(status string) *model.PurchaseOrder {
+		return &model.PurchaseOrder{Base: model.Base{ID: 9}, Status: status, ExpectedAt: &expectedAt, Items: []model.PurchaseOrderItem{
+			{Base: model.Base{ID: 1}, SKUID: 101, Quantity: 500, Received: 100},
+			{Base: model.Base{ID: 2}, SKUID: 102, Quantity: 10},
+		}}
+	}
+
+	tests := []struct {
+		name        string
+		lines       []service.ReceiptLine
+		status      string
+		updateErr   error
+		wantUpdates map[uint64]int
+		wantStatus  string
+		wantErrIs   error
+		wantErr     bool
+		noLock      bool // Rejected before the purchase order is locked
+	}{
+		{
+			name:        "Partial",
+			lines:       []service.ReceiptLine{{SKUID: 101, Quantity: 150}},
+			wantUpdates: map[uint64]int{101: 150},
+			wantStatus:  model.PurchaseOrderStatusOpen,
+		},
+		{
+			name:        "Complete",
+			lines:       []service.ReceiptLine{{SKUID: 102, Quantity: 10}, {SKUID: 101, Quantity: 400}},
+			wantUpdates: map[uint64]int{101: 400, 102: 10},
+			wantStatus:  model.PurchaseOrderStatusReceived,
+		},
+		{
+			name:      "MoreThanOutstanding",
+			lines:     []service.ReceiptLine{{SKUID: 101, Quantity: 401}},
+			wantErrIs: service.ErrInvalidPurchaseOrder,
+		},
+		{
+			name:      "NotOnOrder",
+			lines:     []service.ReceiptLine{{SKUID: 103, Quantity: 1}},
+			wantErrIs: service.ErrInvalidPurchaseOrder,
+		},
+		{
+			name:      "Cancelled",
+			lines:     []service.ReceiptLine{{SKUID: 101, Quantity: 1}},
+			status:    model.PurchaseOrderStatusCancelled,
+			wantErrIs: service.ErrPurchaseOrderStatus,
+		},
+		{
+			name:        "UpdateFails",
+			lines:       []service.ReceiptLine{{SKUID: 101, Quantity: 1}},
+			updateErr:   errors.New("db down"),
+			wantUpdates: map[uint64]int{101: 1},
+			wantErr:     true,
+		},
+		{
+			name:      "ReceivedTwice",
+			lines:     []service.ReceiptLine{{SKUID: 101, Quantity: 1}, {SKUID: 101, Quantity: 2}},
+			wantErrIs: service.ErrInvalidPurchaseOrder,
+			noLock:    true,
+		},
+		{
+			name:      "Nothing",
+			wantErrIs: service.ErrInvalidPurchaseOrder,
+			noLock:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			poRepo := mocks.NewMockPurchaseOrderRepository(ctrl)
+			movementRepo := mocks.NewMockStockMovementRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			mockCache := mocks.NewMockCache(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+
+			if !tt.noLock {
+				status := tt.status
+				if status == "" {
+					status = model.PurchaseOrderStatusOpen
+				}
+				txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+				poRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(9)).Return(purchaseOrder(status), nil)
+			}
+			if len(tt.wantUpdates) > 0 {
+				productRepo.EXPECT().GetSKUsForUpdate(gomock.Any(), gomock.Any()).Return([]model.SKU{
+					{Base: model.Base{ID: 101}, Stock: 30},
+					{Base: model.Base{ID: 102}, Stock: 0},
+				}, nil)
+			}
+			for id, delta := range tt.wantUpdates {
+				productRepo.EXPECT().UpdateSKUStock(gomock.Any(), id, delta).Return(tt.updateErr)
+			}
+			if len(tt.wantUpdates) > 0 && tt.updateErr == nil {
+				movementRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, movements []model.StockMovement) error {
+					require.Len(t, movements, len(tt.wantUpdates))
+					assert.Equal(t, model.StockMovement{SKUID: 101, Quantity: tt.wantUpdates[101], StockAfter: 30 + tt.wantUpdates[101], Reason: model.StockMovementReceipt, ReferenceID: 9}, movements[0])
+					return nil
+				})
+				poRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, po *model.PurchaseOrder) error {
+					assert.Equal(t, tt.wantStatus, po.Status)
+					return nil
+				})
+				mockCache.EXPECT().Publish(gomock.Any(), service.CatalogEventsChannel, gomock.Any()).Return(nil)
+				productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), gomock.Any()).Return([]model.SKU{{Base: model.Base{ID: 101}, PreOrder: true, AvailableAt: &expectedAt}}, nil)
+				if tt.wantStatus == model.PurchaseOrderStatusReceived {
+					// Nothing is expected anymore
+					poRepo.EXPECT().EarliestExpected(gomock.Any(), gomock.Any()).Return(map[uint64]time.Time{}, nil)
+					productRepo.EXPECT().UpdateSKUAvailableAt(gomock.Any(), uint64(101), (*time.Time)(nil)).Return(nil)
+				} else {
+					poRepo.EXPECT().EarliestExpected(gomock.Any(), gomock.Any()).Return(map[uint64]time.Time{101: expectedAt}, nil)
+				}
+			}
+
+			svc := service.NewPurchasingService(nil, poRepo, movementRepo, productRepo, txManager, service.NewCatalogCache(mockCache, service.CatalogCacheOptions{}))
+			ctx, trail := service.WithAuditTrail(context.Background())
+			resp, err := svc.Receive(ctx, 9, tt.lines)
+			switch {
+			case tt.wantErrIs != nil:
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				assert.Empty(t, trail.Entries())
+				return
+			case tt.wantErr:
+				assert.Error(t, err)
+				assert.Empty(t, trail.Entries())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.Status)
+			require.Len(t, trail.Entries(), 1)
+			assert.Equal(t, "purchase_order.receive", trail.Entries()[0].Action)
+		})
+	}
+}
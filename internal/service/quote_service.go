@@ -0,0 +1,385 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultQuotePageSize is how many quotes ListByUser and List return when no
+// limit is given.
+const DefaultQuotePageSize = 20
+
+var (
+	// ErrQuoteNotFound means the quote does not exist or, for buyers, belongs
+	// to another user.
+	ErrQuoteNotFound = errors.New("quote not found")
+	// ErrInvalidQuote means a quote request or response is malformed.
+	ErrInvalidQuote = errors.New("invalid quote")
+	// ErrQuoteStatus means the change is not allowed in the quote's status,
+	// e.g. accepting one that was not quoted yet.
+	ErrQuoteStatus = errors.New("not allowed in the quote's status")
+	// ErrQuoteExpired means the quote was quoted, but can no longer be
+	// accepted at its prices.
+	ErrQuoteExpired = errors.New("quote expired")
+)
+
+// QuoteStatuses lists the statuses quotes can be listed by. Expired quotes
+// are listed as quoted.
+var QuoteStatuses = []string{model.QuoteStatusRequested, model.QuoteStatusQuoted, model.QuoteStatusAccepted, model.QuoteStatusDeclined}
+
+// QuoteReq asks for a price on quantities of SKUs.
+type QuoteReq struct {
+	UserID   uint64
+	Currency string // What the SKUs are to be priced in; empty means the user's preferred currency
+	Region   string // Where the order would ship, for tax
+	Note     string
+	Items    []OrderItemReq
+}
+
+// QuoteResp is a quote, as shown to the buyer and to admins.
+type QuoteResp struct {
+	ID        uint64          `json:"id,string"`
+	UserID    uint64          `json:"user_id,string"`
+	Status    string          `json:"status" example:"quoted"` // requested, quoted, expired, accepted or declined
+	Currency  string          `json:"currency" example:"USD"`
+	Region    string          `json:"region" example:"DE"`
+	Note      string          `json:"note"`
+	Items     []QuoteItemResp `json:"items"`
+	Total     *money.Money    `json:"total,omitempty"`      // Of the quoted items, before tax; nil until quoted
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"` // Until when the quote can be accepted
+	OrderID   uint64          `json:"order_id,string,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type QuoteItemResp struct {
+	SKUID    uint64       `json:"sku_id,string"`
+	Quantity int          `json:"quantity" example:"500"`
+	Price    *money.Money `json:"price,omitempty"` // Quoted unit price; nil until quoted
+}
+
+// QuoteService runs the request for quote workflow of bulk buyers: a buyer
+// asks for a price on quantities of SKUs, an admin answers with a price for
+// each and an expiry, and the buyer accepts the quote, which places an order
+// at the quoted prices.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/quote_service_mock.go -package=mocks
+type QuoteService interface {
+	Request(ctx context.Context, req *QuoteReq) (*QuoteResp, error)
+	Get(ctx context.Context, userID, id uint64) (*QuoteResp, error)
+	ListByUser(ctx context.Context, userID uint64, offset, limit int) ([]QuoteResp, error)
+	// Accept places the buyer's order at the quoted prices, however the SKUs
+	// were repriced since, paid with a saved payment method if one is given.
+	// The quote can be accepted again if placing the order fails.
+	Accept(ctx context.Context, userID, id, paymentMethodID uint64) (*OrderCreateResp, error)
+	// List returns the quotes with status, or all of them when it is empty,
+	// oldest first.
+	List(ctx context.Context, status string, offset, limit int) ([]QuoteResp, error)
+	// Respond prices every item of a requested quote, by SKU ID, in the
+	// quote's currency, until expiresAt. A quoted quote, expired or not, can
+	// be priced again.
+	Respond(ctx context.Context, id uint64, prices map[uint64]decimal.Decimal, expiresAt time.Time) (*QuoteResp, error)
+	Decline(ctx context.Context, id uint64) (*QuoteResp, error)
+}
+
+type quoteService struct {
+	repo        repository.QuoteRepository
+	productRepo repository.ProductRepository
+	currencies  CurrencyService
+	orders      OrderService
+}
+
+// NewQuoteService creates a new QuoteService. Accepted quotes are placed
+// through orders.
+func NewQuoteService(repo repository.QuoteRepository, productRepo repository.ProductRepository, currencies CurrencyService, orders OrderService) QuoteService {
+	return &quoteService{repo: repo, productRepo: productRepo, currencies: currencies, orders: orders}
+}
+
+func (s *quoteService) Request(ctx context.Context, req *QuoteReq) (*QuoteResp, error) {
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("%w: no items", ErrInvalidQuote)
+	}
+	skuIDs := make([]uint64, 0, len(req.Items))
+	items := make([]model.QuoteItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: invalid quantity for SKU %d", ErrInvalidQuote, item.SKUID)
+		}
+		if slices.Contains(skuIDs, item.SKUID) {
+			return nil, fmt.Errorf("%w: SKU %d asked for twice", ErrInvalidQuote, item.SKUID)
+		}
+		skuIDs = append(skuIDs, item.SKUID)
+		items = append(items, model.QuoteItem{SKUID: item.SKUID, Quantity: item.Quantity})
+	}
+	if _, err := s.productRepo.GetSKUsByIDs(ctx, skuIDs); err != nil {
+		if errors.Is(err, repository.ErrSKUNotFound) {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidQuote, err)
+		}
+		return nil, fmt.Errorf("failed to get SKUs: %w", err)
+	}
+	currency, err := s.currencies.Resolve(ctx, req.Currency, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &model.Quote{
+		UserID:   req.UserID,
+		Status:   model.QuoteStatusRequested,
+		Currency: currency,
+		Region:   strings.ToUpper(req.Region),
+		Note:     req.Note,
+		Items:    items,
+	}
+	if err := s.repo.Create(ctx, quote); err != nil {
+		return nil, fmt.Errorf("failed to create quote: %w", err)
+	}
+	return newQuoteResp(quote, time.Now())
+}
+
+func (s *quoteService) Get(ctx context.Context, userID, id uint64) (*QuoteResp, error) {
+	quote, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return newQuoteResp(quote, time.Now())
+}
+
+func (s *quoteService) ListByUser(ctx context.Context, userID uint64, offset, limit int) ([]QuoteResp, error) {
+	if limit <= 0 {
+		limit = DefaultQuotePageSize
+	}
+	quotes, err := s.repo.ListByUser(ctx, userID, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quotes: %w", err)
+	}
+	return newQuoteResps(quotes)
+}
+
+func (s *quoteService) Accept(ctx context.Context, userID, id, paymentMethodID uint64) (*OrderCreateResp, error) {
+	quote, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if quote.Expired(time.Now()) {
+		return nil, ErrQuoteExpired
+	}
+	if quote.Status != model.QuoteStatusQuoted {
+		return nil, fmt.Errorf("%w: quote is %s", ErrQuoteStatus, quote.Status)
+	}
+
+	// The quote is claimed first, so that a double submit places one order
+	quote.Status = model.QuoteStatusAccepted
+	if err := s.repo.Update(ctx, quote, model.QuoteStatusQuoted); err != nil {
+		if errors.Is(err, repository.ErrQuoteChanged) {
+			return nil, fmt.Errorf("%w: quote changed", ErrQuoteStatus)
+		}
+		return nil, fmt.Errorf("failed to accept quote: %w", err)
+	}
+
+	req := &OrderCreateReq{
+		UserID:          userID,
+		Currency:        quote.Currency,
+		Region:          quote.Region,
+		Items:           make([]OrderItemReq, len(quote.Items)),
+		PaymentMethodID: paymentMethodID,
+		QuotedPrices:    make(map[uint64]decimal.Decimal, len(quote.Items)),
+	}
+	for i, item := range quote.Items {
+		req.Items[i] = OrderItemReq{SKUID: item.SKUID, Quantity: item.Quantity}
+		req.QuotedPrices[item.SKUID] = item.Price
+	}
+	resp, err := s.orders.CreateOrder(ctx, req)
+	if err != nil {
+		// Put the quote back, e.g. for the buyer to try again once restocked
+		quote.Status = model.QuoteStatusQuoted
+		if reopenErr := s.repo.Update(ctx, quote, model.QuoteStatusAccepted); reopenErr != nil {
+			slog.ErrorContext(ctx, "Failed to reopen quote", "quote_id", id, logger.Err(reopenErr))
+		}
+		return nil, err
+	}
+
+	quote.OrderID = resp.OrderID
+	if err := s.repo.Update(ctx, quote, model.QuoteStatusAccepted); err != nil {
+		// The order is placed all the same
+		slog.ErrorContext(ctx, "Failed to record the order of an accepted quote", "quote_id", id, "order_id", resp.OrderID, logger.Err(err))
+	}
+	return resp, nil
+}
+
+func (s *quoteService) List(ctx context.Context, status string, offset, limit int) ([]QuoteResp, error) {
+	if status != "" && !slices.Contains(QuoteStatuses, status) {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidQuote, status)
+	}
+	if limit <= 0 {
+		limit = DefaultQuotePageSize
+	}
+	quotes, err := s.repo.List(ctx, status, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quotes: %w", err)
+	}
+	return newQuoteResps(quotes)
+}
+
+func (s *quoteService) Respond(ctx context.Context, id uint64, prices map[uint64]decimal.Decimal, expiresAt time.Time) (*QuoteResp, error) {
+	now := time.Now()
+	if !expiresAt.After(now) {
+		return nil, fmt.Errorf("%w: expiry must be in the future", ErrInvalidQuote)
+	}
+	quote, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	fromStatus := quote.Status
+	if fromStatus != model.QuoteStatusRequested && fromStatus != model.QuoteStatusQuoted {
+		return nil, fmt.Errorf("%w: quote is %s", ErrQuoteStatus, fromStatus)
+	}
+	if len(prices) != len(quote.Items) {
+		return nil, fmt.Errorf("%w: price every item of the quote, and only those", ErrInvalidQuote)
+	}
+	before, err := newQuoteResp(quote, now)
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range quote.Items {
+		price, ok := prices[item.SKUID]
+		if !ok {
+			return nil, fmt.Errorf("%w: no price for SKU %d", ErrInvalidQuote, item.SKUID)
+		}
+		if !price.IsPositive() {
+			return nil, fmt.Errorf("%w: price of SKU %d must be positive", ErrInvalidQuote, item.SKUID)
+		}
+		quote.Items[i].Price = price
+	}
+	quote.Status = model.QuoteStatusQuoted
+	quote.ExpiresAt = &expiresAt
+
+	if err := s.repo.Update(ctx, quote, fromStatus); err != nil {
+		if errors.Is(err, repository.ErrQuoteChanged) {
+			return nil, fmt.Errorf("%w: quote changed", ErrQuoteStatus)
+		}
+		return nil, fmt.Errorf("failed to respond to quote: %w", err)
+	}
+	resp, err := newQuoteResp(quote, now)
+	if err != nil {
+		return nil, err
+	}
+	RecordAudit(ctx, AuditEntry{
+		Action:     "quote.respond",
+		Resource:   "quote",
+		ResourceID: strconv.FormatUint(id, 10),
+		Before:     before,
+		After:      resp,
+	})
+	return resp, nil
+}
+
+func (s *quoteService) Decline(ctx context.Context, id uint64) (*QuoteResp, error) {
+	quote, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	fromStatus := quote.Status
+	if fromStatus != model.QuoteStatusRequested && fromStatus != model.QuoteStatusQuoted {
+		return nil, fmt.Errorf("%w: quote is %s", ErrQuoteStatus, fromStatus)
+	}
+	quote.Status = model.QuoteStatusDeclined
+	if err := s.repo.Update(ctx, quote, fromStatus); err != nil {
+		if errors.Is(err, repository.ErrQuoteChanged) {
+			return nil, fmt.Errorf("%w: quote changed", ErrQuoteStatus)
+		}
+		return nil, fmt.Errorf("failed to decline quote: %w", err)
+	}
+	RecordAudit(ctx, AuditEntry{
+		Action:     "quote.decline",
+		Resource:   "quote",
+		ResourceID: strconv.FormatUint(id, 10),
+		Before:     map[string]any{"status": fromStatus},
+		After:      map[string]any{"status": quote.Status},
+	})
+	return newQuoteResp(quote, time.Now())
+}
+
+func (s *quoteService) get(ctx context.Context, id uint64) (*model.Quote, error) {
+	quote, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrQuoteNotFound) {
+		return nil, ErrQuoteNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quote: %w", err)
+	}
+	return quote, nil
+}
+
+// getOwned returns one of the buyer's quotes; other users' quotes are not
+// found.
+func (s *quoteService) getOwned(ctx context.Context, userID, id uint64) (*model.Quote, error) {
+	quote, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if quote.UserID != userID {
+		return nil, ErrQuoteNotFound
+	}
+	return quote, nil
+}
+
+func newQuoteResps(quotes []model.Quote) ([]QuoteResp, error) {
+	now := time.Now()
+	resps := make([]QuoteResp, len(quotes))
+	for i := range quotes {
+		resp, err := newQuoteResp(&quotes[i], now)
+		if err != nil {
+			return nil, err
+		}
+		resps[i] = *resp
+	}
+	return resps, nil
+}
+
+// newQuoteResp shows quote as of now, with its prices once quoted.
+func newQuoteResp(quote *model.Quote, now time.Time) (*QuoteResp, error) {
+	resp := &QuoteResp{
+		ID:        quote.ID,
+		UserID:    quote.UserID,
+		Status:    quote.Status,
+		Currency:  quote.Currency,
+		Region:    quote.Region,
+		Note:      quote.Note,
+		Items:     make([]QuoteItemResp, len(quote.Items)),
+		ExpiresAt: quote.ExpiresAt,
+		OrderID:   quote.OrderID,
+		CreatedAt: quote.CreatedAt,
+	}
+	if quote.Expired(now) {
+		resp.Status = model.QuoteStatusExpired
+	}
+	priced := quote.Status != model.QuoteStatusRequested && quote.ExpiresAt != nil
+	total := money.Zero(quote.Currency)
+	for i, item := range quote.Items {
+		resp.Items[i] = QuoteItemResp{SKUID: item.SKUID, Quantity: item.Quantity}
+		if !priced {
+			continue
+		}
+		price := money.New(item.Price, quote.Currency)
+		resp.Items[i].Price = &price
+		var err error
+		if total, err = total.Add(price.Mul(int64(item.Quantity))); err != nil {
+			return nil, err
+		}
+	}
+	if priced {
+		resp.Total = &total
+	}
+	return resp, nil
+}
@@ -0,0 +1,217 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestQuoteService_Request(t *testing.T) {
+	tests := []struct {
+		name      string
+		items     []service.OrderItemReq
+		mockSetup func(repo *mocks.MockQuoteRepository, products *mocks.MockProductRepository, currencies *mocks.MockCurrencyService)
+		wantErr   error
+	}{
+		{
+			name:  "Success",
+			items: []service.OrderItemReq{{SKUID: 1, Quantity: 500}, {SKUID: 2, Quantity: 200}},
+			mockSetup: func(repo *mocks.MockQuoteRepository, products *mocks.MockProductRepository, currencies *mocks.MockCurrencyService) {
+				products.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{1, 2}).Return([]model.SKU{{}, {}}, nil)
+				currencies.EXPECT().Resolve(gomock.Any(), "", uint64(5)).Return("EUR", nil)
+				repo.EXPECT().Create(gomock.Any(), &model.Quote{
+					UserID:   5,
+					Status:   model.QuoteStatusRequested,
+					Currency: "EUR",
+					Region:   "DE",
+					Note:     "monthly volume",
+					Items:    []model.QuoteItem{{SKUID: 1, Quantity: 500}, {SKUID: 2, Quantity: 200}},
+				}).Return(nil)
+			},
+		},
+		{
+			name:    "DuplicateSKU",
+			items:   []service.OrderItemReq{{SKUID: 1, Quantity: 500}, {SKUID: 1, Quantity: 200}},
+			wantErr: service.ErrInvalidQuote,
+		},
+		{
+			name:  "SKUNotFound",
+			items: []service.OrderItemReq{{SKUID: 1, Quantity: 500}},
+			mockSetup: func(repo *mocks.MockQuoteRepository, products *mocks.MockProductRepository, currencies *mocks.MockCurrencyService) {
+				products.EXPECT().GetSKUsByIDs(gomock.Any(), []uint64{1}).Return(nil, repository.ErrSKUNotFound)
+			},
+			wantErr: service.ErrInvalidQuote,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockQuoteRepository(ctrl)
+			products := mocks.NewMockProductRepository(ctrl)
+			currencies := mocks.NewMockCurrencyService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(repo, products, currencies)
+			}
+			svc := service.NewQuoteService(repo, products, currencies, nil)
+
+			resp, err := svc.Request(context.Background(), &service.QuoteReq{UserID: 5, Region: "de", Note: "monthly volume", Items: tt.items})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, model.QuoteStatusRequested, resp.Status)
+			assert.Nil(t, resp.Total, "not priced yet")
+		})
+	}
+}
+
+func TestQuoteService_Respond(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockQuoteRepository(ctrl)
+	svc := service.NewQuoteService(repo, nil, nil, nil)
+	expiresAt := time.Now().Add(72 * time.Hour)
+	requested := func() *model.Quote {
+		return &model.Quote{Base: model.Base{ID: 9}, UserID: 5, Status: model.QuoteStatusRequested, Currency: "USD",
+			Items: []model.QuoteItem{{SKUID: 1, Quantity: 500}, {SKUID: 2, Quantity: 200}}}
+	}
+	prices := map[uint64]decimal.Decimal{1: decimal.RequireFromString("8.50"), 2: decimal.NewFromInt(12)}
+
+	repo.EXPECT().GetByID(gomock.Any(), uint64(9)).Return(requested(), nil)
+	repo.EXPECT().Update(gomock.Any(), gomock.Any(), model.QuoteStatusRequested).DoAndReturn(func(_ context.Context, quote *model.Quote, _ string) error {
+		assert.Equal(t, model.QuoteStatusQuoted, quote.Status)
+		assert.Equal(t, "8.5", quote.Items[0].Price.String())
+		return nil
+	})
+	ctx, trail := service.WithAuditTrail(context.Background())
+	resp, err := svc.Respond(ctx, 9, prices, expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, model.QuoteStatusQuoted, resp.Status)
+	require.NotNil(t, resp.Total)
+	assert.Equal(t, "6650.00 USD", resp.Total.String()) // 500 × 8.50 + 200 × 12
+	require.Len(t, trail.Entries(), 1)
+	assert.Equal(t, "quote.respond", trail.Entries()[0].Action)
+
+	// Every item must be priced
+	repo.EXPECT().GetByID(gomock.Any(), uint64(9)).Return(requested(), nil)
+	_, err = svc.Respond(ctx, 9, map[uint64]decimal.Decimal{1: decimal.NewFromInt(8), 3: decimal.NewFromInt(1)}, expiresAt)
+	assert.ErrorIs(t, err, service.ErrInvalidQuote)
+
+	_, err = svc.Respond(ctx, 9, prices, time.Now().Add(-time.Minute))
+	assert.ErrorIs(t, err, service.ErrInvalidQuote)
+
+	// Accepted quotes are final
+	accepted := requested()
+	accepted.Status = model.QuoteStatusAccepted
+	repo.EXPECT().GetByID(gomock.Any(), uint64(9)).Return(accepted, nil)
+	_, err = svc.Respond(ctx, 9, prices, expiresAt)
+	assert.ErrorIs(t, err, service.ErrQuoteStatus)
+}
+
+func TestQuoteService_Accept(t *testing.T) {
+	future, past := time.Now().Add(time.Hour), time.Now().Add(-time.Hour)
+	quoted := func(expiresAt time.Time) *model.Quote {
+		return &model.Quote{Base: model.Base{ID: 9}, UserID: 5, Status: model.QuoteStatusQuoted, Currency: "USD", Region: "US-CA", ExpiresAt: &expiresAt,
+			Items: []model.QuoteItem{{SKUID: 1, Quantity: 500, Price: decimal.RequireFromString("8.50")}}}
+	}
+
+	tests := []struct {
+		name      string
+		userID    uint64
+		mockSetup func(repo *mocks.MockQuoteRepository, orders *mocks.MockOrderService)
+		wantErr   error
+	}{
+		{
+			name:   "Success",
+			userID: 5,
+			mockSetup: func(repo *mocks.MockQuoteRepository, orders *mocks.MockOrderService) {
+				repo.EXPECT().GetByID(gomock.Any(), uint64(9)).Return(quoted(future), nil)
+				gomock.InOrder(
+					repo.EXPECT().Update(gomock.Any(), gomock.Any(), model.QuoteStatusQuoted).Return(nil),
+					orders.EXPECT().CreateOrder(gomock.Any(), &service.OrderCreateReq{
+						UserID:          5,
+						Currency:        "USD",
+						Region:          "US-CA",
+						Items:           []service.OrderItemReq{{SKUID: 1, Quantity: 500}},
+						PaymentMethodID: 3,
+						QuotedPrices:    map[uint64]decimal.Decimal{1: decimal.RequireFromString("8.50")},
+					}).Return(&service.OrderCreateResp{OrderID: 77}, nil),
+					repo.EXPECT().Update(gomock.Any(), gomock.Any(), model.QuoteStatusAccepted).DoAndReturn(func(_ context.Context, quote *model.Quote, _ string) error {
+						assert.Equal(t, model.QuoteStatusAccepted, quote.Status)
+						assert.Equal(t, uint64(77), quote.OrderID)
+						return nil
+					}),
+				)
+			},
+		},
+		{
+			name:   "Expired",
+			userID: 5,
+			mockSetup: func(repo *mocks.MockQuoteRepository, orders *mocks.MockOrderService) {
+				repo.EXPECT().GetByID(gomock.Any(), uint64(9)).Return(quoted(past), nil)
+			},
+			wantErr: service.ErrQuoteExpired,
+		},
+		{
+			name:   "AnotherUsersQuote",
+			userID: 6,
+			mockSetup: func(repo *mocks.MockQuoteRepository, orders *mocks.MockOrderService) {
+				repo.EXPECT().GetByID(gomock.Any(), uint64(9)).Return(quoted(future), nil)
+			},
+			wantErr: service.ErrQuoteNotFound,
+		},
+		{
+			name:   "AcceptedConcurrently",
+			userID: 5,
+			mockSetup: func(repo *mocks.MockQuoteRepository, orders *mocks.MockOrderService) {
+				repo.EXPECT().GetByID(gomock.Any(), uint64(9)).Return(quoted(future), nil)
+				repo.EXPECT().Update(gomock.Any(), gomock.Any(), model.QuoteStatusQuoted).Return(repository.ErrQuoteChanged)
+			},
+			wantErr: service.ErrQuoteStatus,
+		},
+		{
+			name:   "OrderFailedReopensQuote",
+			userID: 5,
+			mockSetup: func(repo *mocks.MockQuoteRepository, orders *mocks.MockOrderService) {
+				repo.EXPECT().GetByID(gomock.Any(), uint64(9)).Return(quoted(future), nil)
+				gomock.InOrder(
+					repo.EXPECT().Update(gomock.Any(), gomock.Any(), model.QuoteStatusQuoted).Return(nil),
+					orders.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(nil, service.ErrInsufficientStock),
+					repo.EXPECT().Update(gomock.Any(), gomock.Any(), model.QuoteStatusAccepted).DoAndReturn(func(_ context.Context, quote *model.Quote, _ string) error {
+						assert.Equal(t, model.QuoteStatusQuoted, quote.Status)
+						return nil
+					}),
+				)
+			},
+			wantErr: service.ErrInsufficientStock,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockQuoteRepository(ctrl)
+			orders := mocks.NewMockOrderService(ctrl)
+			tt.mockSetup(repo, orders)
+			svc := service.NewQuoteService(repo, nil, nil, orders)
+
+			resp, err := svc.Accept(context.Background(), tt.userID, 9, 3)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, uint64(77), resp.OrderID)
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/cache"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// Review feedback limits; the rate limits apply when the configuration leaves
+// them unset.
+const (
+	DefaultReviewVoteLimit   = 60 // Votes per user per window
+	DefaultReviewReportLimit = 10 // Reports per user per window
+	DefaultReviewLimitWindow = time.Hour
+
+	MaxReviewReportDetailsLength = 500
+
+	DefaultReviewQueuePageSize = 20
+	MaxReviewQueuePageSize     = 100
+)
+
+// What a moderator does with a reported review.
+const (
+	ReviewModerationDismiss = "dismiss" // Keep the review and close its reports
+	ReviewModerationRemove  = "remove"  // Delete the review and close its reports
+)
+
+var (
+	// ErrReviewNotFound is returned when a review does not exist.
+	ErrReviewNotFound = repository.ErrReviewNotFound
+	// ErrReviewAlreadyReported is returned when a user reports a review twice.
+	ErrReviewAlreadyReported = repository.ErrReviewAlreadyReported
+	// ErrOwnReview means a user voted on or reported their own review.
+	ErrOwnReview = errors.New("cannot vote on or report your own review")
+	// ErrReviewRateLimited means a user voted on or reported too many reviews
+	// lately.
+	ErrReviewRateLimited = errors.New("too many review votes or reports, try again later")
+	// ErrInvalidReviewReport means an abuse report is malformed.
+	ErrInvalidReviewReport = errors.New("invalid review report")
+	// ErrInvalidModerationAction means a moderation action is unknown.
+	ErrInvalidModerationAction = errors.New("invalid moderation action")
+)
+
+// ReviewVotesResp is how a review's readers voted on it.
+type ReviewVotesResp struct {
+	ReviewID       uint64 `json:"review_id,string"`
+	HelpfulVotes   int    `json:"helpful_votes"`
+	UnhelpfulVotes int    `json:"unhelpful_votes"`
+}
+
+// ReviewReportResp is an open abuse report on a review.
+type ReviewReportResp struct {
+	ID        uint64    `json:"id,string"`
+	UserID    uint64    `json:"user_id,string"`
+	Reason    string    `json:"reason" example:"spam"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReviewModerationItem is a reported review with its open reports, oldest
+// first.
+type ReviewModerationItem struct {
+	Review  ReviewResp         `json:"review"`
+	Reports []ReviewReportResp `json:"reports"`
+}
+
+// ReviewModerationListResp is one page of the moderation queue, most reported
+// reviews first.
+type ReviewModerationListResp struct {
+	Items []ReviewModerationItem `json:"items"`
+	Total int64                  `json:"total"`
+}
+
+// ReviewOptions configures the review service.
+type ReviewOptions struct {
+	VoteLimit   int           // Votes one user may cast per LimitWindow
+	ReportLimit int           // Reports one user may file per LimitWindow
+	LimitWindow time.Duration // Fixed window, starting at the user's first vote or report
+}
+
+// ReviewService collects what customers think of reviews: helpful and
+// unhelpful votes, counted on the review, and abuse reports, which queue the
+// review for moderation until an admin dismisses them or removes it. Votes
+// and reports are rate limited per user in Redis.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/review_service_mock.go -package=mocks
+type ReviewService interface {
+	// Vote records whether the user found a review helpful, replacing their
+	// earlier vote on it, and returns the review's vote counts.
+	Vote(ctx context.Context, userID, reviewID uint64, helpful bool) (*ReviewVotesResp, error)
+	// Report files an abuse report on a review, once per user.
+	Report(ctx context.Context, userID, reviewID uint64, reason, details string) error
+	ListModerationQueue(ctx context.Context, offset, limit int) (*ReviewModerationListResp, error)
+	// Moderate closes the open reports of a review with action, one of
+	// ReviewModerationDismiss and ReviewModerationRemove, and returns how many
+	// it closed.
+	Moderate(ctx context.Context, reviewID uint64, action string) (int64, error)
+}
+
+type reviewService struct {
+	reviewRepo repository.ReviewRepository
+	cache      cache.Cache
+	catalog    *CatalogCache
+	opts       ReviewOptions
+}
+
+// NewReviewService creates a new ReviewService instance. Products are
+// dropped from catalog when their ratings change. Zero options fall back to
+// their defaults.
+func NewReviewService(reviewRepo repository.ReviewRepository, c cache.Cache, catalog *CatalogCache, opts ReviewOptions) ReviewService {
+	if opts.VoteLimit <= 0 {
+		opts.VoteLimit = DefaultReviewVoteLimit
+	}
+	if opts.ReportLimit <= 0 {
+		opts.ReportLimit = DefaultReviewReportLimit
+	}
+	if opts.LimitWindow <= 0 {
+		opts.LimitWindow = DefaultReviewLimitWindow
+	}
+	return &reviewService{reviewRepo: reviewRepo, cache: c, catalog: catalog, opts: opts}
+}
+
+func (s *reviewService) Vote(ctx context.Context, userID, reviewID uint64, helpful bool) (*ReviewVotesResp, error) {
+	if err := s.checkOthersReview(ctx, userID, reviewID); err != nil {
+		return nil, err
+	}
+	if err := s.takeRateLimit(ctx, "votes", userID, s.opts.VoteLimit); err != nil {
+		return nil, err
+	}
+
+	review, err := s.reviewRepo.Vote(ctx, &model.ReviewVote{ReviewID: reviewID, UserID: userID, Helpful: helpful})
+	if err != nil {
+		return nil, err
+	}
+	return &ReviewVotesResp{ReviewID: review.ID, HelpfulVotes: review.HelpfulVotes, UnhelpfulVotes: review.UnhelpfulVotes}, nil
+}
+
+func (s *reviewService) Report(ctx context.Context, userID, reviewID uint64, reason, details string) error {
+	switch reason {
+	case model.ReviewReportSpam, model.ReviewReportOffensive, model.ReviewReportOffTopic, model.ReviewReportOther:
+	default:
+		return fmt.Errorf("%w: unknown reason %q", ErrInvalidReviewReport, reason)
+	}
+	if utf8.RuneCountInString(details) > MaxReviewReportDetailsLength {
+		return fmt.Errorf("%w: details are longer than %d characters", ErrInvalidReviewReport, MaxReviewReportDetailsLength)
+	}
+	if err := s.checkOthersReview(ctx, userID, reviewID); err != nil {
+		return err
+	}
+	if err := s.takeRateLimit(ctx, "reports", userID, s.opts.ReportLimit); err != nil {
+		return err
+	}
+
+	return s.reviewRepo.Report(ctx, &model.ReviewReport{
+		ReviewID: reviewID,
+		UserID:   userID,
+		Reason:   reason,
+		Details:  details,
+		Status:   model.ReviewReportOpen,
+	})
+}
+
+func (s *reviewService) ListModerationQueue(ctx context.Context, offset, limit int) (*ReviewModerationListResp, error) {
+	if limit <= 0 {
+		limit = DefaultReviewQueuePageSize
+	}
+	if limit > MaxReviewQueuePageSize {
+		limit = MaxReviewQueuePageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	reviews, total, err := s.reviewRepo.ListReported(ctx, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint64, len(reviews))
+	for i := range reviews {
+		ids[i] = reviews[i].ID
+	}
+	reports, err := s.reviewRepo.ListOpenReports(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byReview := make(map[uint64][]ReviewReportResp, len(reviews))
+	for _, report := range reports {
+		byReview[report.ReviewID] = append(byReview[report.ReviewID], ReviewReportResp{
+			ID:        report.ID,
+			UserID:    report.UserID,
+			Reason:    report.Reason,
+			Details:   report.Details,
+			CreatedAt: report.CreatedAt,
+		})
+	}
+
+	items := make([]ReviewModerationItem, len(reviews))
+	for i, review := range reviews {
+		items[i] = ReviewModerationItem{Review: newReviewResp(&review), Reports: byReview[review.ID]}
+		if items[i].Reports == nil {
+			items[i].Reports = []ReviewReportResp{}
+		}
+	}
+	return &ReviewModerationListResp{Items: items, Total: total}, nil
+}
+
+func (s *reviewService) Moderate(ctx context.Context, reviewID uint64, action string) (int64, error) {
+	var status string
+	switch action {
+	case ReviewModerationDismiss:
+		status = model.ReviewReportDismissed
+	case ReviewModerationRemove:
+		status = model.ReviewReportUpheld
+	default:
+		return 0, fmt.Errorf("%w: must be %s or %s", ErrInvalidModerationAction, ReviewModerationDismiss, ReviewModerationRemove)
+	}
+	before, err := s.reviewRepo.GetByID(ctx, reviewID)
+	if err != nil {
+		return 0, err
+	}
+
+	resolved, err := s.reviewRepo.ResolveReports(ctx, reviewID, status, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	// The product shows the removed review's rating until it expires if this
+	// fails
+	if action == ReviewModerationRemove {
+		if err := s.catalog.InvalidateDetail(ctx, before.SPUID); err != nil {
+			slog.WarnContext(ctx, "Failed to invalidate cached product", "spu_id", before.SPUID, logger.Err(err))
+		}
+	}
+	entry := AuditEntry{Action: "review." + action, Resource: "review", ResourceID: strconv.FormatUint(reviewID, 10), Before: newReviewResp(before)}
+	if action == ReviewModerationDismiss {
+		entry.After = entry.Before
+	}
+	RecordAudit(ctx, entry)
+	return resolved, nil
+}
+
+// checkOthersReview returns ErrReviewNotFound if the review does not exist,
+// or ErrOwnReview if the user wrote it.
+func (s *reviewService) checkOthersReview(ctx context.Context, userID, reviewID uint64) error {
+	review, err := s.reviewRepo.GetByID(ctx, reviewID)
+	if err != nil {
+		return err
+	}
+	if review.UserID == userID {
+		return ErrOwnReview
+	}
+	return nil
+}
+
+// takeRateLimit counts one more vote or report of the user, kind, in the
+// current window and returns ErrReviewRateLimited once they pass limit.
+func (s *reviewService) takeRateLimit(ctx context.Context, kind string, userID uint64, limit int) error {
+	key := fmt.Sprintf("mall:review:%s:%d", kind, userID)
+	res, err := s.cache.Eval(ctx, incrWithTTL, []string{key}, s.opts.LimitWindow.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("failed to count review %s: %w", kind, err)
+	}
+	count, ok := res.(int64)
+	if !ok {
+		return fmt.Errorf("unexpected review %s count %v", kind, res)
+	}
+	if count > int64(limit) {
+		return ErrReviewRateLimited
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReviewService_Vote(t *testing.T) {
+	review := &model.Review{Base: model.Base{ID: 4}, UserID: 1}
+	tests := []struct {
+		name      string
+		userID    uint64
+		mockSetup func(repo *mocks.MockReviewRepository, mockCache *mocks.MockCache)
+		want      *service.ReviewVotesResp
+		wantErr   error
+	}{
+		{
+			name:   "Success",
+			userID: 2,
+			mockSetup: func(repo *mocks.MockReviewRepository, mockCache *mocks.MockCache) {
+				repo.EXPECT().GetByID(gomock.Any(), uint64(4)).Return(review, nil)
+				mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:review:votes:2"}, int64(time.Minute.Milliseconds())).Return(int64(3), nil)
+				repo.EXPECT().Vote(gomock.Any(), &model.ReviewVote{ReviewID: 4, UserID: 2, Helpful: true}).
+					Return(&model.Review{Base: model.Base{ID: 4}, HelpfulVotes: 5, UnhelpfulVotes: 1}, nil)
+			},
+			want: &service.ReviewVotesResp{ReviewID: 4, HelpfulVotes: 5, UnhelpfulVotes: 1},
+		},
+		{
+			name:   "OwnReview",
+			userID: 1,
+			mockSetup: func(repo *mocks.MockReviewRepository, _ *mocks.MockCache) {
+				repo.EXPECT().GetByID(gomock.Any(), uint64(4)).Return(review, nil)
+			},
+			wantErr: service.ErrOwnReview,
+		},
+		{
+			name:   "NotFound",
+			userID: 2,
+			mockSetup: func(repo *mocks.MockReviewRepository, _ *mocks.MockCache) {
+				repo.EXPECT().GetByID(gomock.Any(), uint64(4)).Return(nil, service.ErrReviewNotFound)
+			},
+			wantErr: service.ErrReviewNotFound,
+		},
+		{
+			name:   "RateLimited",
+			userID: 2,
+			mockSetup: func(repo *mocks.MockReviewRepository, mockCache *mocks.MockCache) {
+				repo.EXPECT().GetByID(gomock.Any(), uint64(4)).Return(review, nil)
+				mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(6), nil)
+			},
+			wantErr: service.ErrReviewRateLimited,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockReviewRepository(ctrl)
+			mockCache := mocks.NewMockCache(ctrl)
+			tt.mockSetup(repo, mockCache)
+			svc := service.NewReviewService(repo, mockCache, nil, service.ReviewOptions{VoteLimit: 5, LimitWindow: time.Minute})
+
+			got, err := svc.Vote(context.Background(), tt.userID, 4, true)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReviewService_Report(t *testing.T) {
+	tests := []struct {
+		name      string
+		reason    string
+		details   string
+		mockSetup func(repo *mocks.MockReviewRepository, mockCache *mocks.MockCache)
+		wantErr   error
+	}{
+		{
+			name:   "Success",
+			reason: model.ReviewReportSpam,
+			mockSetup: func(repo *mocks.MockReviewRepository, mockCache *mocks.MockCache) {
+				repo.EXPECT().GetByID(gomock.Any(), uint64(4)).Return(&model.Review{Base: model.Base{ID: 4}, UserID: 1}, nil)
+				mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:review:reports:2"}, gomock.Any()).Return(int64(1), nil)
+				repo.EXPECT().Report(gomock.Any(), &model.ReviewReport{ReviewID: 4, UserID: 2, Reason: model.ReviewReportSpam, Details: "links", Status: model.ReviewReportOpen}).Return(nil)
+			},
+			details: "links",
+		},
+		{name: "UnknownReason", reason: "boring", wantErr: service.ErrInvalidReviewReport},
+		{
+			name:   "AlreadyReported",
+			reason: model.ReviewReportOffensive,
+			mockSetup: func(repo *mocks.MockReviewRepository, mockCache *mocks.MockCache) {
+				repo.EXPECT().GetByID(gomock.Any(), uint64(4)).Return(&model.Review{Base: model.Base{ID: 4}, UserID: 1}, nil)
+				mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+				repo.EXPECT().Report(gomock.Any(), gomock.Any()).Return(service.ErrReviewAlreadyReported)
+			},
+			wantErr: service.ErrReviewAlreadyReported,
+		},
+		{
+			name:   "RateLimited",
+			reason: model.ReviewReportOther,
+			mockSetup: func(repo *mocks.MockReviewRepository, mockCache *mocks.MockCache) {
+				repo.EXPECT().GetByID(gomock.Any(), uint64(4)).Return(&model.Review{Base: model.Base{ID: 4}, UserID: 1}, nil)
+				mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(service.DefaultReviewReportLimit+1), nil)
+			},
+			wantErr: service.ErrReviewRateLimited,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockReviewRepository(ctrl)
+			mockCache := mocks.NewMockCache(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(repo, mockCache)
+			}
+
+			err := service.NewReviewService(repo, mockCache, nil, service.ReviewOptions{}).Report(context.Background(), 2, 4, tt.reason, tt.details)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestReviewService_ListModerationQueue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockReviewRepository(ctrl)
+	reviews := []model.Review{{Base: model.Base{ID: 7}, OpenReports: 2}, {Base: model.Base{ID: 5}, OpenReports: 1}}
+	repo.EXPECT().ListReported(gomock.Any(), 0, service.MaxReviewQueuePageSize).Return(reviews, int64(2), nil)
+	repo.EXPECT().ListOpenReports(gomock.Any(), []uint64{7, 5}).Return([]model.ReviewReport{
+		{Base: model.Base{ID: 1}, ReviewID: 7, Reason: model.ReviewReportSpam},
+		{Base: model.Base{ID: 3}, ReviewID: 7, Reason: model.ReviewReportOther},
+	}, nil)
+
+	resp, err := service.NewReviewService(repo, nil, nil, service.ReviewOptions{}).ListModerationQueue(context.Background(), -1, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), resp.Total)
+	require.Len(t, resp.Items, 2)
+	assert.Len(t, resp.Items[0].Reports, 2)
+	assert.NotNil(t, resp.Items[1].Reports)
+	assert.Empty(t, resp.Items[1].Reports)
+}
+
+func TestReviewService_Moderate(t *testing.T) {
+	tests := []struct {
+		name       string
+		action     string
+		wantStatus string
+		wantErr    error
+	}{
+		{name: "Dismiss", action: service.ReviewModerationDismiss, wantStatus: model.ReviewReportDismissed},
+		{name: "Remove", action: service.ReviewModerationRemove, wantStatus: model.ReviewReportUpheld},
+		{name: "UnknownAction", action: "hide", wantErr: service.ErrInvalidModerationAction},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockReviewRepository(ctrl)
+			mockCache := mocks.NewMockCache(ctrl)
+			if tt.wantErr == nil {
+				repo.EXPECT().GetByID(gomock.Any(), uint64(4)).Return(&model.Review{Base: model.Base{ID: 4}, SPUID: 9, OpenReports: 2}, nil)
+				repo.EXPECT().ResolveReports(gomock.Any(), uint64(4), tt.wantStatus, gomock.Any()).Return(int64(2), nil)
+			}
+			if tt.action == service.ReviewModerationRemove {
+				// The product's rating changed
+				mockCache.EXPECT().Del(gomock.Any(), "mall:product:spu:9").Return(nil)
+			}
+			ctx, trail := service.WithAuditTrail(context.Background())
+			catalog := service.NewCatalogCache(mockCache, service.CatalogCacheOptions{})
+
+			resolved, err := service.NewReviewService(repo, mockCache, catalog, service.ReviewOptions{}).Moderate(ctx, 4, tt.action)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, trail.Entries())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), resolved)
+			require.Len(t, trail.Entries(), 1)
+			assert.Equal(t, "review."+tt.action, trail.Entries()[0].Action)
+			assert.Equal(t, "4", trail.Entries()[0].ResourceID)
+		})
+	}
+}
+
+func TestReviewService_ModerateError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockReviewRepository(ctrl)
+	repo.EXPECT().GetByID(gomock.Any(), uint64(4)).Return(&model.Review{Base: model.Base{ID: 4}}, nil)
+	repo.EXPECT().ResolveReports(gomock.Any(), uint64(4), gomock.Any(), gomock.Any()).Return(int64(0), errors.New("db down"))
+	ctx, trail := service.WithAuditTrail(context.Background())
+
+	_, err := service.NewReviewService(repo, nil, nil, service.ReviewOptions{}).Moderate(ctx, 4, service.ReviewModerationRemove)
+	assert.Error(t, err)
+	assert.Empty(t, trail.Entries())
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/proyuen/go-mall/pkg/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+// What a login does when its user already has the maximum number of sessions.
+const (
+	SessionLimitReject      = "reject"       // The login fails with ErrSessionLimitExceeded
+	SessionLimitEvictOldest = "evict_oldest" // The sessions opened first end
+)
+
+// ErrSessionLimitExceeded means a user already has as many sessions as
+// allowed and the limit rejects new logins.
+var ErrSessionLimitExceeded = apperr.New(apperr.CodeSessionLimitExceeded)
+
+// openSession drops the expired sessions of the hash in KEYS[1], makes room
+// for or rejects session ARGV[1] when ARGV[4] sessions are open, then adds it.
+// Fields are session IDs, values "CREATED_MS:EXPIRES_MS". ARGV[2] is now,
+// ARGV[3] when the new session expires and ARGV[5] is 1 to evict the oldest
+// sessions instead of rejecting. It returns how many sessions were evicted,
+// or -1 when the session was rejected.
+var openSession = redis.NewScript(`
+local now = tonumber(ARGV[2])
+local limit = tonumber(ARGV[4])
+local expireAt = tonumber(ARGV[3])
+local open = {}
+local entries = redis.call("HGETALL", KEYS[1])
+for i = 1, #entries, 2 do
+	local created, expires = string.match(entries[i + 1], "^(%d+):(%d+)$")
+	if expires == nil or tonumber(expires) <= now then
+		redis.call("HDEL", KEYS[1], entries[i])
+	else
+		table.insert(open, {entries[i], tonumber(created)})
+		expireAt = math.max(expireAt, tonumber(expires))
+	end
+end
+local evicted = 0
+if limit > 0 and #open >= limit then
+	if ARGV[5] ~= "1" then
+		return -1
+	end
+	table.sort(open, function(a, b) return a[2] < b[2] end)
+	for i = 1, #open - limit + 1 do
+		redis.call("HDEL", KEYS[1], open[i][1])
+		evicted = evicted + 1
+	end
+end
+redis.call("HSET", KEYS[1], ARGV[1], ARGV[2] .. ":" .. ARGV[3])
+redis.call("PEXPIREAT", KEYS[1], expireAt)
+return evicted
+`)
+
+// SessionStore tracks the sessions of every user, each started by a login
+// and identified by the ID of its refresh token, to cap how many a user may
+// have at once.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/session_store_mock.go -package=mocks
+type SessionStore interface {
+	// Open records a session of userID ending at expiresAt. At the limit it
+	// either ends the oldest sessions or returns ErrSessionLimitExceeded.
+	Open(ctx context.Context, userID uint64, sessionID string, expiresAt time.Time) error
+	// Active reports whether a session is still open, i.e. it has not
+	// expired or been evicted.
+	Active(ctx context.Context, userID uint64, sessionID string) (bool, error)
+	// End closes a session before it expires, as a logout does. Its refresh
+	// token and access tokens stop working. Ending a session that is not
+	// open does nothing.
+	End(ctx context.Context, userID uint64, sessionID string) error
+	// EndAll closes every session of a user, e.g. once their password is
+	// reset.
+	EndAll(ctx context.Context, userID uint64) error
+}
+
+// SessionOptions configures a SessionStore.
+type SessionOptions struct {
+	MaxPerUser int    // Zero allows any number of sessions
+	OnLimit    string // SessionLimitReject or SessionLimitEvictOldest; empty means reject
+}
+
+type sessionStore struct {
+	cache cache.Cache
+	opts  SessionOptions
+}
+
+// NewSessionStore creates a new SessionStore instance keeping sessions in Redis.
+func NewSessionStore(c cache.Cache, opts SessionOptions) SessionStore {
+	if opts.OnLimit == "" {
+		opts.OnLimit = SessionLimitReject
+	}
+	return &sessionStore{cache: c, opts: opts}
+}
+
+func (s *sessionStore) Open(ctx context.Context, userID uint64, sessionID string, expiresAt time.Time) error {
+	evict := 0
+	if s.opts.OnLimit == SessionLimitEvictOldest {
+		evict = 1
+	}
+	res, err := s.cache.Eval(ctx, openSession, []string{sessionKey(userID)},
+		sessionID, time.Now().UnixMilli(), expiresAt.UnixMilli(), s.opts.MaxPerUser, evict)
+	if err != nil {
+		return fmt.Errorf("failed to open session: %w", err)
+	}
+	evicted, ok := res.(int64)
+	if !ok {
+		return fmt.Errorf("unexpected session count %v", res)
+	}
+	if evicted < 0 {
+		return ErrSessionLimitExceeded
+	}
+	if evicted > 0 {
+		slog.InfoContext(ctx, "Evicted oldest sessions", "user_id", userID, "count", evicted)
+	}
+	return nil
+}
+
+func (s *sessionStore) Active(ctx context.Context, userID uint64, sessionID string) (bool, error) {
+	active, err := s.cache.HExists(ctx, sessionKey(userID), sessionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+	return active, nil
+}
+
+func (s *sessionStore) End(ctx context.Context, userID uint64, sessionID string) error {
+	if err := s.cache.HDel(ctx, sessionKey(userID), sessionID); err != nil {
+		return fmt.Errorf("failed to end session: %w", err)
+	}
+	return nil
+}
+
+func (s *sessionStore) EndAll(ctx context.Context, userID uint64) error {
+	if err := s.cache.Del(ctx, sessionKey(userID)); err != nil {
+		return fmt.Errorf("failed to end sessions: %w", err)
+	}
+	return nil
+}
+
+func sessionKey(userID uint64) string {
+	return "mall:sessions:user:" + strconv.FormatUint(userID, 10)
+}
@@ -0,0 +1,115 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSessionStore_Open(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		opts      service.SessionOptions
+		mockSetup func(c *mocks.MockCache)
+		wantErr   error
+		errStr    string
+	}{
+		{
+			name: "UnderLimit",
+			opts: service.SessionOptions{MaxPerUser: 3},
+			mockSetup: func(c *mocks.MockCache) {
+				c.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:sessions:user:7"}, "s1", gomock.Any(), expiresAt.UnixMilli(), 3, 0).Return(int64(0), nil)
+			},
+		},
+		{
+			name: "Rejected",
+			opts: service.SessionOptions{MaxPerUser: 1},
+			mockSetup: func(c *mocks.MockCache) {
+				c.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:sessions:user:7"}, "s1", gomock.Any(), expiresAt.UnixMilli(), 1, 0).Return(int64(-1), nil)
+			},
+			wantErr: service.ErrSessionLimitExceeded,
+		},
+		{
+			name: "EvictOldest",
+			opts: service.SessionOptions{MaxPerUser: 1, OnLimit: service.SessionLimitEvictOldest},
+			mockSetup: func(c *mocks.MockCache) {
+				c.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:sessions:user:7"}, "s1", gomock.Any(), expiresAt.UnixMilli(), 1, 1).Return(int64(1), nil)
+			},
+		},
+		{
+			name: "RedisDown",
+			opts: service.SessionOptions{},
+			mockSetup: func(c *mocks.MockCache) {
+				c.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("redis down"))
+			},
+			errStr: "failed to open session: redis down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockCache := mocks.NewMockCache(ctrl)
+			tt.mockSetup(mockCache)
+
+			err := service.NewSessionStore(mockCache, tt.opts).Open(context.Background(), 7, "s1", expiresAt)
+			switch {
+			case tt.wantErr != nil:
+				require.ErrorIs(t, err, tt.wantErr)
+			case tt.errStr != "":
+				require.EqualError(t, err, tt.errStr)
+			default:
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSessionStore_Active(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache(ctrl)
+	store := service.NewSessionStore(mockCache, service.SessionOptions{})
+
+	mockCache.EXPECT().HExists(gomock.Any(), "mall:sessions:user:7", "s1").Return(true, nil)
+	mockCache.EXPECT().HExists(gomock.Any(), "mall:sessions:user:7", "evicted").Return(false, nil)
+
+	active, err := store.Active(context.Background(), 7, "s1")
+	require.NoError(t, err)
+	assert.True(t, active)
+	active, err = store.Active(context.Background(), 7, "evicted")
+	require.NoError(t, err)
+	assert.False(t, active)
+}
+
+func TestSessionStore_End(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache(ctrl)
+	store := service.NewSessionStore(mockCache, service.SessionOptions{})
+
+	mockCache.EXPECT().HDel(gomock.Any(), "mall:sessions:user:7", "s1").Return(nil)
+	mockCache.EXPECT().HDel(gomock.Any(), "mall:sessions:user:7", "s1").Return(errors.New("redis down"))
+
+	require.NoError(t, store.End(context.Background(), 7, "s1"))
+	require.EqualError(t, store.End(context.Background(), 7, "s1"), "failed to end session: redis down")
+}
+
+func TestSessionStore_EndAll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache(ctrl)
+	store := service.NewSessionStore(mockCache, service.SessionOptions{})
+
+	mockCache.EXPECT().Del(gomock.Any(), "mall:sessions:user:7").Return(nil)
+	mockCache.EXPECT().Del(gomock.Any(), "mall:sessions:user:7").Return(errors.New("redis down"))
+
+	require.NoError(t, store.EndAll(context.Background(), 7))
+	require.EqualError(t, store.EndAll(context.Background(), 7), "failed to end sessions: redis down")
+}
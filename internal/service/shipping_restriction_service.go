@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/apperr"
+)
+
+var (
+	// ErrShippingRestrictionNotFound means the shipping restriction does not exist.
+	ErrShippingRestrictionNotFound = errors.New("shipping restriction not found")
+	// ErrInvalidShippingRestriction means a restriction names neither or both
+	// of a SKU and a category, or no region.
+	ErrInvalidShippingRestriction = errors.New("invalid shipping restriction")
+	// ErrShippingRestricted means an order has items that cannot ship to its
+	// region. Its metadata has the region and the items, as RestrictedItems.
+	ErrShippingRestricted = apperr.New(apperr.CodeShippingRestricted)
+)
+
+// ShippingRestrictionCreateReq defines a shipping restriction. Exactly one of
+// SKUID and CategoryID is set.
+type ShippingRestrictionCreateReq struct {
+	SKUID      uint64
+	CategoryID uint64   // Covers the category and its subcategories
+	Regions    []string // ISO 3166-1 alpha-2 countries and ISO 3166-2 subdivisions
+	Reason     string
+}
+
+// ShippingRestrictionResp is a shipping restriction as shown to admins.
+type ShippingRestrictionResp struct {
+	ID         uint64   `json:"id,string"`
+	SKUID      uint64   `json:"sku_id,string,omitempty"`
+	CategoryID uint64   `json:"category_id,string,omitempty"`
+	Regions    []string `json:"regions" example:"AU,US-HI"`
+	Reason     string   `json:"reason" example:"Lithium batteries cannot be shipped there"`
+}
+
+// RestrictedItem is an item of an order that cannot ship to its region, and
+// why. A component of a bundle is reported with the bundle's SKU, which is
+// what the customer ordered.
+type RestrictedItem struct {
+	SKUID          uint64 `json:"sku_id,string"`
+	ComponentSKUID uint64 `json:"component_sku_id,string,omitempty"` // Set when a component of the bundle SKUID is restricted
+	Reason         string `json:"reason" example:"Lithium batteries cannot be shipped there"`
+}
+
+// ShippingRestrictionService manages the regions SKUs cannot ship to and
+// checks orders against them at checkout.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/shipping_restriction_service_mock.go -package=mocks
+type ShippingRestrictionService interface {
+	Create(ctx context.Context, req *ShippingRestrictionCreateReq) (*ShippingRestrictionResp, error)
+	List(ctx context.Context) ([]ShippingRestrictionResp, error)
+	Delete(ctx context.Context, id uint64) error
+	// Check returns ErrShippingRestricted if any of items cannot ship to
+	// region. items include the component lines of bundles, which are
+	// checked along with the bundles; digital items never ship and are not
+	// checked. Without a region there is nothing to check.
+	Check(ctx context.Context, region string, items []model.OrderItem) error
+}
+
+type shippingRestrictionService struct {
+	repo         repository.ShippingRestrictionRepository
+	productRepo  repository.ProductRepository
+	categoryRepo repository.CategoryRepository
+}
+
+// NewShippingRestrictionService creates a new ShippingRestrictionService instance.
+func NewShippingRestrictionService(repo repository.ShippingRestrictionRepository, productRepo repository.ProductRepository, categoryRepo repository.CategoryRepository) ShippingRestrictionService {
+	return &shippingRestrictionService{repo: repo, productRepo: productRepo, categoryRepo: categoryRepo}
+}
+
+func (s *shippingRestrictionService) Create(ctx context.Context, req *ShippingRestrictionCreateReq) (*ShippingRestrictionResp, error) {
+	if (req.SKUID == 0) == (req.CategoryID == 0) {
+		return nil, fmt.Errorf("%w: set exactly one of sku_id and category_id", ErrInvalidShippingRestriction)
+	}
+	var regions []string
+	for _, region := range req.Regions {
+		region = strings.ToUpper(strings.TrimSpace(region))
+		if region != "" && !slices.Contains(regions, region) {
+			regions = append(regions, region)
+		}
+	}
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("%w: regions are required", ErrInvalidShippingRestriction)
+	}
+
+	restriction := &model.ShippingRestriction{
+		SKUID:      req.SKUID,
+		CategoryID: req.CategoryID,
+		Regions:    strings.Join(regions, ","),
+		Reason:     strings.TrimSpace(req.Reason),
+	}
+	if err := s.repo.Create(ctx, restriction); err != nil {
+		return nil, err
+	}
+	resp := newShippingRestrictionResp(restriction)
+	return &resp, nil
+}
+
+func (s *shippingRestrictionService) List(ctx context.Context) ([]ShippingRestrictionResp, error) {
+	restrictions, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]ShippingRestrictionResp, 0, len(restrictions))
+	for i := range restrictions {
+		resp = append(resp, newShippingRestrictionResp(&restrictions[i]))
+	}
+	return resp, nil
+}
+
+func (s *shippingRestrictionService) Delete(ctx context.Context, id uint64) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrShippingRestrictionNotFound) {
+			return ErrShippingRestrictionNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *shippingRestrictionService) Check(ctx context.Context, region string, items []model.OrderItem) error {
+	region = strings.ToUpper(region)
+	if region == "" {
+		return nil
+	}
+	all, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	var restrictions []model.ShippingRestriction
+	for _, r := range all {
+		if restrictsRegion(r.Regions, region) {
+			restrictions = append(restrictions, r)
+		}
+	}
+	if len(restrictions) == 0 {
+		return nil
+	}
+
+	// Digital items are delivered, not shipped
+	shipped := slices.DeleteFunc(slices.Clone(items), func(item model.OrderItem) bool {
+		return item.Delivery != "" && item.Delivery != model.SKUDeliveryBundle
+	})
+	categories, err := s.categories(ctx, shipped, restrictions)
+	if err != nil {
+		return err
+	}
+
+	bundleSKU := make(map[uint64]uint64) // Order item ID to SKU ID, for the bundle of a component line
+	for _, item := range items {
+		if item.Delivery == model.SKUDeliveryBundle {
+			bundleSKU[item.ID] = item.SKUID
+		}
+	}
+	var restricted []RestrictedItem
+	for _, item := range shipped {
+		i := slices.IndexFunc(restrictions, func(r model.ShippingRestriction) bool {
+			return r.SKUID == item.SKUID || r.CategoryID != 0 && slices.Contains(categories[item.SKUID], r.CategoryID)
+		})
+		if i < 0 {
+			continue
+		}
+		entry := RestrictedItem{SKUID: item.SKUID, Reason: restrictions[i].Reason}
+		if item.BundleItemID != nil {
+			entry = RestrictedItem{SKUID: bundleSKU[*item.BundleItemID], ComponentSKUID: item.SKUID, Reason: restrictions[i].Reason}
+		}
+		if !slices.Contains(restricted, entry) {
+			restricted = append(restricted, entry)
+		}
+	}
+	if len(restricted) > 0 {
+		return ErrShippingRestricted.With("region", region).With("items", restricted)
+	}
+	return nil
+}
+
+// categories returns the category of the SKU of each item and the
+// category's ancestors. They are only looked up when a restriction is on a
+// category.
+func (s *shippingRestrictionService) categories(ctx context.Context, items []model.OrderItem, restrictions []model.ShippingRestriction) (map[uint64][]uint64, error) {
+	if len(items) == 0 || !slices.ContainsFunc(restrictions, func(r model.ShippingRestriction) bool { return r.CategoryID != 0 }) {
+		return nil, nil
+	}
+
+	skuIDs := make([]uint64, len(items))
+	for i, item := range items {
+		skuIDs[i] = item.SKUID
+	}
+	skus, err := s.productRepo.GetSKUsByIDs(ctx, skuIDs)
+	if err != nil {
+		return nil, err
+	}
+	spuIDs := make([]uint64, len(skus))
+	for i, sku := range skus {
+		spuIDs[i] = sku.SPUID
+	}
+	spus, err := s.productRepo.GetSPUsByIDs(ctx, spuIDs)
+	if err != nil {
+		return nil, err
+	}
+	categoryOf := make(map[uint64]uint64, len(spus))
+	for _, spu := range spus {
+		categoryOf[spu.ID] = spu.CategoryID
+	}
+	all, err := s.categoryRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parentOf := make(map[uint64]uint64, len(all))
+	for _, category := range all {
+		parentOf[category.ID] = category.ParentID
+	}
+
+	categories := make(map[uint64][]uint64, len(skus))
+	for _, sku := range skus {
+		// The category and its ancestors; the depth bound guards against cycles
+		for id, depth := categoryOf[sku.SPUID], 0; id != 0 && depth <= len(all); id, depth = parentOf[id], depth+1 {
+			categories[sku.ID] = append(categories[sku.ID], id)
+		}
+	}
+	return categories, nil
+}
+
+// restrictsRegion reports whether the comma-separated regions include
+// region, or its country for a subdivision.
+func restrictsRegion(regions, region string) bool {
+	country, _, _ := strings.Cut(region, "-")
+	for _, r := range strings.Split(regions, ",") {
+		if r == region || r == country {
+			return true
+		}
+	}
+	return false
+}
+
+func newShippingRestrictionResp(r *model.ShippingRestriction) ShippingRestrictionResp {
+	return ShippingRestrictionResp{
+		ID:         r.ID,
+		SKUID:      r.SKUID,
+		CategoryID: r.CategoryID,
+		Regions:    strings.Split(r.Regions, ","),
+		Reason:     r.Reason,
+	}
+}
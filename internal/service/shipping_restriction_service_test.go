@@ -0,0 +1,126 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestShippingRestrictionService_Create(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         *service.ShippingRestrictionCreateReq
+		wantRegions string
+		wantErr     error
+	}{
+		{name: "SKU", req: &service.ShippingRestrictionCreateReq{SKUID: 101, Regions: []string{"au", "US-HI", "AU"}}, wantRegions: "AU,US-HI"},
+		{name: "Category", req: &service.ShippingRestrictionCreateReq{CategoryID: 3, Regions: []string{"NZ"}}, wantRegions: "NZ"},
+		{name: "NeitherSKUNorCategory", req: &service.ShippingRestrictionCreateReq{Regions: []string{"AU"}}, wantErr: service.ErrInvalidShippingRestriction},
+		{name: "SKUAndCategory", req: &service.ShippingRestrictionCreateReq{SKUID: 101, CategoryID: 3, Regions: []string{"AU"}}, wantErr: service.ErrInvalidShippingRestriction},
+		{name: "NoRegions", req: &service.ShippingRestrictionCreateReq{SKUID: 101, Regions: []string{" "}}, wantErr: service.ErrInvalidShippingRestriction},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockShippingRestrictionRepository(ctrl)
+			if tt.wantErr == nil {
+				repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, r *model.ShippingRestriction) error {
+					assert.Equal(t, tt.wantRegions, r.Regions)
+					r.ID = 9
+					return nil
+				})
+			}
+			restrictions := service.NewShippingRestrictionService(repo, nil, nil)
+
+			resp, err := restrictions.Create(context.Background(), tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, uint64(9), resp.ID)
+		})
+	}
+}
+
+func TestShippingRestrictionService_Check(t *testing.T) {
+	bundleLine := uint64(500)
+	restrictions := []model.ShippingRestriction{
+		{Base: model.Base{ID: 1}, SKUID: 101, Regions: "AU,US-HI", Reason: "Aerosols cannot be shipped there"},
+		{Base: model.Base{ID: 2}, CategoryID: 1, Regions: "NZ", Reason: "Lithium batteries cannot be shipped there"},
+	}
+
+	tests := []struct {
+		name          string
+		region        string
+		items         []model.OrderItem
+		loadsProducts bool // Only for restrictions on categories
+		want          []service.RestrictedItem
+	}{
+		{name: "SKUInCountry", region: "AU", items: []model.OrderItem{{SKUID: 101}, {SKUID: 102}}, want: []service.RestrictedItem{{SKUID: 101, Reason: "Aerosols cannot be shipped there"}}},
+		{name: "SKUInSubdivision", region: "us-hi", items: []model.OrderItem{{SKUID: 101}}, want: []service.RestrictedItem{{SKUID: 101, Reason: "Aerosols cannot be shipped there"}}},
+		{name: "CountryCoversSubdivisions", region: "AU-NSW", items: []model.OrderItem{{SKUID: 101}}, want: []service.RestrictedItem{{SKUID: 101, Reason: "Aerosols cannot be shipped there"}}},
+		{name: "OtherSubdivision", region: "US-CA", items: []model.OrderItem{{SKUID: 101}}},
+		{name: "Subcategory", region: "NZ", items: []model.OrderItem{{SKUID: 101}, {SKUID: 102}}, loadsProducts: true, want: []service.RestrictedItem{{SKUID: 102, Reason: "Lithium batteries cannot be shipped there"}}},
+		{
+			name:   "BundleComponent",
+			region: "NZ",
+			items: []model.OrderItem{
+				{Base: model.Base{ID: bundleLine}, SKUID: 301, Delivery: model.SKUDeliveryBundle},
+				{SKUID: 101, BundleItemID: &bundleLine},
+				{SKUID: 102, BundleItemID: &bundleLine},
+			},
+			loadsProducts: true,
+			want:          []service.RestrictedItem{{SKUID: 301, ComponentSKUID: 102, Reason: "Lithium batteries cannot be shipped there"}},
+		},
+		{name: "Digital", region: "AU", items: []model.OrderItem{{SKUID: 101, Delivery: model.SKUDeliveryDownload}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockShippingRestrictionRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			categoryRepo := mocks.NewMockCategoryRepository(ctrl)
+			repo.EXPECT().List(gomock.Any()).Return(restrictions, nil)
+			if tt.loadsProducts {
+				// The battery, SKU 102, is in category 1 through category 2
+				productRepo.EXPECT().GetSKUsByIDs(gomock.Any(), gomock.Any()).Return([]model.SKU{
+					{Base: model.Base{ID: 101}, SPUID: 11},
+					{Base: model.Base{ID: 102}, SPUID: 12},
+					{Base: model.Base{ID: 301}, SPUID: 13},
+				}, nil)
+				productRepo.EXPECT().GetSPUsByIDs(gomock.Any(), []uint64{11, 12, 13}).Return([]model.SPU{
+					{Base: model.Base{ID: 11}, CategoryID: 3},
+					{Base: model.Base{ID: 12}, CategoryID: 2},
+					{Base: model.Base{ID: 13}, CategoryID: 3},
+				}, nil)
+				categoryRepo.EXPECT().List(gomock.Any()).Return([]model.Category{
+					{Base: model.Base{ID: 1}},
+					{Base: model.Base{ID: 2}, ParentID: 1},
+					{Base: model.Base{ID: 3}},
+				}, nil)
+			}
+			restrictionService := service.NewShippingRestrictionService(repo, productRepo, categoryRepo)
+
+			err := restrictionService.Check(context.Background(), tt.region, tt.items)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var appErr *apperr.Error
+			require.True(t, errors.As(err, &appErr))
+			assert.Equal(t, apperr.CodeShippingRestricted, appErr.Code)
+			assert.Equal(t, tt.want, appErr.Meta["items"])
+		})
+	}
+}
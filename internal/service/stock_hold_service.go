@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/database"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// DefaultStockHoldPageSize is how many stock holds a list returns when no
+// limit is given.
+const DefaultStockHoldPageSize = 20
+
+// stockHoldExpiryBatchSize is how many expired holds ReleaseExpired loads at
+// a time.
+const stockHoldExpiryBatchSize = 100
+
+var (
+	ErrStockHoldNotFound = repository.ErrStockHoldNotFound
+	// ErrInvalidStockHold means a stock hold is malformed, e.g. expires in
+	// the past.
+	ErrInvalidStockHold = errors.New("invalid stock hold")
+	// ErrStockHoldReleased is returned when changing a hold that was released
+	// or expired already.
+	ErrStockHoldReleased = errors.New("stock hold is no longer active")
+)
+
+// StockHoldStatuses lists the statuses stock holds can be listed by.
+var StockHoldStatuses = []string{model.StockHoldActive, model.StockHoldReleased, model.StockHoldExpired}
+
+// StockHoldReq sets a quantity of a SKU aside.
+type StockHoldReq struct {
+	SKUID     uint64
+	Quantity  int
+	Note      string
+	ExpiresAt *time.Time // Nil holds until released
+}
+
+type StockHoldResp struct {
+	ID         uint64     `json:"id,string"`
+	SKUID      uint64     `json:"sku_id,string"`
+	Quantity   int        `json:"quantity" example:"3"`
+	Status     string     `json:"status" example:"active"` // active, released or expired
+	Note       string     `json:"note" example:"Reserved for the trade show"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	ReleasedAt *time.Time `json:"released_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// StockHoldService places stock holds: quantities of SKUs set aside by hand
+// without an order, e.g. for offline sales. A hold takes its quantity out of
+// the SKU's stock, which is what can still be promised to orders, and puts it
+// back when it is released or expires. Both are recorded in the stock ledger.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/stock_hold_service_mock.go -package=mocks
+type StockHoldService interface {
+	// Place sets the quantity aside, failing with ErrInsufficientStock if the
+	// SKU has less in stock.
+	Place(ctx context.Context, req *StockHoldReq) (*StockHoldResp, error)
+	// List returns the holds with status, of skuID, most recent first. An
+	// empty status or a zero skuID matches all.
+	List(ctx context.Context, status string, skuID uint64, offset, limit int) ([]StockHoldResp, error)
+	// SetExpiry moves the expiry of an active hold; nil holds it until
+	// released.
+	SetExpiry(ctx context.Context, id uint64, expiresAt *time.Time) (*StockHoldResp, error)
+	// Release puts the quantity of an active hold back into stock.
+	Release(ctx context.Context, id uint64) (*StockHoldResp, error)
+	// ReleaseExpired releases the active holds that expired, across all
+	// stores. It returns how many it released.
+	ReleaseExpired(ctx context.Context) (int, error)
+}
+
+type stockHoldService struct {
+	holdRepo     repository.StockHoldRepository
+	movementRepo repository.StockMovementRepository
+	productRepo  repository.ProductRepository
+	txManager    database.TransactionManager
+	catalog      *CatalogCache
+	now          func() time.Time
+}
+
+// NewStockHoldService creates a new StockHoldService instance. Stock changes
+// are announced through catalog.
+func NewStockHoldService(holdRepo repository.StockHoldRepository, movementRepo repository.StockMovementRepository, productRepo repository.ProductRepository,
+	txManager database.TransactionManager, catalog *CatalogCache) StockHoldService {
+	return &stockHoldService{
+		holdRepo:     holdRepo,
+		movementRepo: movementRepo,
+		productRepo:  productRepo,
+		txManager:    txManager,
+		catalog:      catalog,
+		now:          time.Now,
+	}
+}
+
+func (s *stockHoldService) Place(ctx context.Context, req *StockHoldReq) (*StockHoldResp, error) {
+	if req.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be positive", ErrInvalidStockHold)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(s.now()) {
+		return nil, fmt.Errorf("%w: expiry must be in the future", ErrInvalidStockHold)
+	}
+
+	hold := &model.StockHold{
+		SKUID:     req.SKUID,
+		Quantity:  req.Quantity,
+		Status:    model.StockHoldActive,
+		Note:      req.Note,
+		ExpiresAt: req.ExpiresAt,
+	}
+	err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		stock, err := s.lockStock(txCtx, req.SKUID)
+		if err != nil {
+			return err
+		}
+		if stock < req.Quantity {
+			return ErrInsufficientStock.WithSKU(req.SKUID).WithMessage(fmt.Sprintf("only %d in stock", stock))
+		}
+		if err := s.productRepo.UpdateSKUStock(txCtx, req.SKUID, -req.Quantity); err != nil {
+			return err
+		}
+		if err := s.holdRepo.Create(txCtx, hold); err != nil {
+			return err
+		}
+		return s.movementRepo.Create(txCtx, []model.StockMovement{{
+			SKUID:       req.SKUID,
+			Quantity:    -req.Quantity,
+			StockAfter:  stock - req.Quantity,
+			Reason:      model.StockMovementHold,
+			ReferenceID: hold.ID,
+		}})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	RecordAudit(ctx, AuditEntry{
+		Action:     "stock_hold.place",
+		Resource:   "stock_hold",
+		ResourceID: strconv.FormatUint(hold.ID, 10),
+		After:      map[string]any{"sku_id": strconv.FormatUint(hold.SKUID, 10), "quantity": hold.Quantity, "expires_at": hold.ExpiresAt},
+	})
+	s.announce(ctx, hold.SKUID)
+	resp := newStockHoldResp(hold)
+	return &resp, nil
+}
+
+func (s *stockHoldService) List(ctx context.Context, status string, skuID uint64, offset, limit int) ([]StockHoldResp, error) {
+	if limit <= 0 {
+		limit = DefaultStockHoldPageSize
+	}
+	holds, err := s.holdRepo.List(ctx, status, skuID, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	resps := make([]StockHoldResp, len(holds))
+	for i := range holds {
+		resps[i] = newStockHoldResp(&holds[i])
+	}
+	return resps, nil
+}
+
+func (s *stockHoldService) SetExpiry(ctx context.Context, id uint64, expiresAt *time.Time) (*StockHoldResp, error) {
+	if expiresAt != nil && !expiresAt.After(s.now()) {
+		return nil, fmt.Errorf("%w: expiry must be in the future", ErrInvalidStockHold)
+	}
+	var before *time.Time
+	hold, err := s.update(ctx, id, func(_ context.Context, hold *model.StockHold) error {
+		before, hold.ExpiresAt = hold.ExpiresAt, expiresAt
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	RecordAudit(ctx, AuditEntry{
+		Action:     "stock_hold.expiry",
+		Resource:   "stock_hold",
+		ResourceID: strconv.FormatUint(id, 10),
+		Before:     map[string]any{"expires_at": before},
+		After:      map[string]any{"expires_at": hold.ExpiresAt},
+	})
+	resp := newStockHoldResp(hold)
+	return &resp, nil
+}
+
+func (s *stockHoldService) Release(ctx context.Context, id uint64) (*StockHoldResp, error) {
+	hold, err := s.release(ctx, id, model.StockHoldReleased)
+	if err != nil {
+		return nil, err
+	}
+	RecordAudit(ctx, AuditEntry{
+		Action:     "stock_hold.release",
+		Resource:   "stock_hold",
+		ResourceID: strconv.FormatUint(id, 10),
+		Before:     map[string]any{"status": model.StockHoldActive},
+		After:      map[string]any{"status": hold.Status},
+	})
+	resp := newStockHoldResp(hold)
+	return &resp, nil
+}
+
+// ReleaseExpired stops at the first hold it fails to release; the next run
+// starts over with it.
+func (s *stockHoldService) ReleaseExpired(ctx context.Context) (int, error) {
+	released := 0
+	for {
+		holds, err := s.holdRepo.ListExpired(ctx, s.now(), stockHoldExpiryBatchSize)
+		if err != nil {
+			return released, err
+		}
+		for _, hold := range holds {
+			_, err := s.release(ctx, hold.ID, model.StockHoldExpired)
+			if errors.Is(err, ErrStockHoldReleased) {
+				continue // Released or extended since it was listed
+			}
+			if err != nil {
+				return released, fmt.Errorf("failed to release stock hold %d: %w", hold.ID, err)
+			}
+			released++
+		}
+		if len(holds) < stockHoldExpiryBatchSize {
+			return released, nil
+		}
+	}
+}
+
+// release moves an active hold to status, which is released or expired, and
+// puts its quantity back into stock. A hold that is to expire must still be
+// due: it may have been extended since it was listed.
+func (s *stockHoldService) release(ctx context.Context, id uint64, status string) (*model.StockHold, error) {
+	now := s.now()
+	hold, err := s.update(ctx, id, func(txCtx context.Context, hold *model.StockHold) error {
+		if status == model.StockHoldExpired && (hold.ExpiresAt == nil || hold.ExpiresAt.After(now)) {
+			return fmt.Errorf("%w: no longer due to expire", ErrStockHoldReleased)
+		}
+		hold.Status, hold.ReleasedAt = status, &now
+		stock, err := s.lockStock(txCtx, hold.SKUID)
+		if errors.Is(err, ErrProductNotFound) {
+			return nil // Deleted since; there is no stock to put it back into
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.productRepo.UpdateSKUStock(txCtx, hold.SKUID, hold.Quantity); err != nil {
+			return err
+		}
+		return s.movementRepo.Create(txCtx, []model.StockMovement{{
+			SKUID:       hold.SKUID,
+			Quantity:    hold.Quantity,
+			StockAfter:  stock + hold.Quantity,
+			Reason:      model.StockMovementHoldRelease,
+			ReferenceID: hold.ID,
+		}})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.announce(ctx, hold.SKUID)
+	return hold, nil
+}
+
+// update applies change to an active hold, locked in a transaction whose
+// context change runs in, and saves it.
+func (s *stockHoldService) update(ctx context.Context, id uint64, change func(txCtx context.Context, hold *model.StockHold) error) (*model.StockHold, error) {
+	var hold *model.StockHold
+	err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		if hold, err = s.holdRepo.GetByIDForUpdate(txCtx, id); err != nil {
+			return err
+		}
+		if hold.Status != model.StockHoldActive {
+			return fmt.Errorf("%w: it is %s", ErrStockHoldReleased, hold.Status)
+		}
+		if err := change(txCtx, hold); err != nil {
+			return err
+		}
+		return s.holdRepo.Update(txCtx, hold)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// lockStock locks a SKU until the transaction in ctx ends and returns its
+// stock.
+func (s *stockHoldService) lockStock(ctx context.Context, skuID uint64) (int, error) {
+	skus, err := s.productRepo.GetSKUsForUpdate(ctx, []uint64{skuID})
+	if err != nil {
+		return 0, err
+	}
+	if len(skus) == 0 {
+		return 0, ErrProductNotFound
+	}
+	return skus[0].Stock, nil
+}
+
+// announce drops the cached product of the SKU, whose stock changed. It only
+// logs failures: the stock changed all the same.
+func (s *stockHoldService) announce(ctx context.Context, skuID uint64) {
+	if err := s.catalog.PublishStockChanged(ctx, []uint64{skuID}); err != nil {
+		slog.WarnContext(ctx, "Failed to announce stock change", "sku_id", skuID, logger.Err(err))
+	}
+}
+
+func newStockHoldResp(hold *model.StockHold) StockHoldResp {
+	return StockHoldResp{
+		ID:         hold.ID,
+		SKUID:      hold.SKUID,
+		Quantity:   hold.Quantity,
+		Status:     hold.Status,
+		Note:       hold.Note,
+		ExpiresAt:  hold.ExpiresAt,
+		ReleasedAt: hold.ReleasedAt,
+		CreatedAt:  hold.CreatedAt,
+	}
+}
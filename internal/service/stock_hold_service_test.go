@@ -0,0 +1,148 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type stockHoldMocks struct {
+	holdRepo     *mocks.MockStockHoldRepository
+	movementRepo *mocks.MockStockMovementRepository
+	productRepo  *mocks.MockProductRepository
+	txManager    *mocks.MockTransactionManager
+	cache        *mocks.MockCache
+}
+
+func newStockHoldService(t *testing.T) (service.StockHoldService, stockHoldMocks) {
+	ctrl := gomock.NewController(t)
+	m := stockHoldMocks{
+		holdRepo:     mocks.NewMockStockHoldRepository(ctrl),
+		movementRepo: mocks.NewMockStockMovementRepository(ctrl),
+		productRepo:  mocks.NewMockProductRepository(ctrl),
+		txManager:    mocks.NewMockTransactionManager(ctrl),
+		cache:        mocks.NewMockCache(ctrl),
+	}
+	m.txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	}).AnyTimes()
+	svc := service.NewStockHoldService(m.holdRepo, m.movementRepo, m.productRepo, m.txManager, service.NewCatalogCache(m.cache, service.CatalogCacheOptions{}))
+	return svc, m
+}
+
+func TestStockHoldService_Place(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		req       service.StockHoldReq
+		stock     int
+		noSKU     bool
+		wantErrIs error
+	}{
+		{name: "Success", req: service.StockHoldReq{SKUID: 5, Quantity: 3, ExpiresAt: &future}, stock: 10},
+		{name: "AllOfTheStock", req: service.StockHoldReq{SKUID: 5, Quantity: 10}, stock: 10},
+		{name: "InsufficientStock", req: service.StockHoldReq{SKUID: 5, Quantity: 11}, stock: 10, wantErrIs: service.ErrInsufficientStock},
+		{name: "UnknownSKU", req: service.StockHoldReq{SKUID: 5, Quantity: 1}, noSKU: true, wantErrIs: service.ErrProductNotFound},
+		{name: "NoQuantity", req: service.StockHoldReq{SKUID: 5}, wantErrIs: service.ErrInvalidStockHold},
+		{name: "ExpiredAlready", req: service.StockHoldReq{SKUID: 5, Quantity: 1, ExpiresAt: &past}, wantErrIs: service.ErrInvalidStockHold},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, m := newStockHoldService(t)
+			if !errors.Is(tt.wantErrIs, service.ErrInvalidStockHold) {
+				var skus []model.SKU
+				if !tt.noSKU {
+					skus = []model.SKU{{Base: model.Base{ID: 5}, Stock: tt.stock}}
+				}
+				m.productRepo.EXPECT().GetSKUsForUpdate(gomock.Any(), []uint64{5}).Return(skus, nil)
+			}
+			if tt.wantErrIs == nil {
+				m.productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(5), -tt.req.Quantity).Return(nil)
+				m.holdRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, hold *model.StockHold) error {
+					assert.Equal(t, model.StockHoldActive, hold.Status)
+					hold.ID = 9
+					return nil
+				})
+				m.movementRepo.EXPECT().Create(gomock.Any(), []model.StockMovement{{
+					SKUID: 5, Quantity: -tt.req.Quantity, StockAfter: tt.stock - tt.req.Quantity, Reason: model.StockMovementHold, ReferenceID: 9,
+				}}).Return(nil)
+				m.cache.EXPECT().Publish(gomock.Any(), service.CatalogEventsChannel, gomock.Any()).Return(nil)
+			}
+
+			req := tt.req
+			resp, err := svc.Place(context.Background(), &req)
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, uint64(9), resp.ID)
+			assert.Equal(t, model.StockHoldActive, resp.Status)
+		})
+	}
+}
+
+func TestStockHoldService_Release(t *testing.T) {
+	t.Run("PutsStockBack", func(t *testing.T) {
+		svc, m := newStockHoldService(t)
+		m.holdRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(9)).Return(&model.StockHold{Base: model.Base{ID: 9}, SKUID: 5, Quantity: 3, Status: model.StockHoldActive}, nil)
+		m.productRepo.EXPECT().GetSKUsForUpdate(gomock.Any(), []uint64{5}).Return([]model.SKU{{Base: model.Base{ID: 5}, Stock: 7}}, nil)
+		m.productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(5), 3).Return(nil)
+		m.movementRepo.EXPECT().Create(gomock.Any(), []model.StockMovement{{
+			SKUID: 5, Quantity: 3, StockAfter: 10, Reason: model.StockMovementHoldRelease, ReferenceID: 9,
+		}}).Return(nil)
+		m.holdRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, hold *model.StockHold) error {
+			assert.Equal(t, model.StockHoldReleased, hold.Status)
+			assert.NotNil(t, hold.ReleasedAt)
+			return nil
+		})
+		m.cache.EXPECT().Publish(gomock.Any(), service.CatalogEventsChannel, gomock.Any()).Return(nil)
+
+		resp, err := svc.Release(context.Background(), 9)
+		require.NoError(t, err)
+		assert.Equal(t, model.StockHoldReleased, resp.Status)
+	})
+
+	t.Run("AlreadyExpired", func(t *testing.T) {
+		svc, m := newStockHoldService(t)
+		m.holdRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(9)).Return(&model.StockHold{Base: model.Base{ID: 9}, SKUID: 5, Quantity: 3, Status: model.StockHoldExpired}, nil)
+
+		_, err := svc.Release(context.Background(), 9)
+		assert.ErrorIs(t, err, service.ErrStockHoldReleased)
+	})
+}
+
+func TestStockHoldService_ReleaseExpired(t *testing.T) {
+	svc, m := newStockHoldService(t)
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	expired := model.StockHold{Base: model.Base{ID: 9}, SKUID: 5, Quantity: 3, Status: model.StockHoldActive, ExpiresAt: &past}
+	extended := model.StockHold{Base: model.Base{ID: 10}, SKUID: 6, Quantity: 1, Status: model.StockHoldActive, ExpiresAt: &past}
+
+	m.holdRepo.EXPECT().ListExpired(gomock.Any(), gomock.Any(), gomock.Any()).Return([]model.StockHold{expired, extended}, nil)
+	m.holdRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(9)).Return(&expired, nil)
+	m.productRepo.EXPECT().GetSKUsForUpdate(gomock.Any(), []uint64{5}).Return([]model.SKU{{Base: model.Base{ID: 5}, Stock: 0}}, nil)
+	m.productRepo.EXPECT().UpdateSKUStock(gomock.Any(), uint64(5), 3).Return(nil)
+	m.movementRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	m.holdRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, hold *model.StockHold) error {
+		assert.Equal(t, model.StockHoldExpired, hold.Status)
+		return nil
+	})
+	m.cache.EXPECT().Publish(gomock.Any(), service.CatalogEventsChannel, gomock.Any()).Return(nil)
+	// Extended since it was listed
+	m.holdRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(10)).Return(&model.StockHold{Base: model.Base{ID: 10}, SKUID: 6, Quantity: 1, Status: model.StockHoldActive, ExpiresAt: &future}, nil)
+
+	released, err := svc.ReleaseExpired(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+}
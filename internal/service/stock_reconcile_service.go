@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/repository"
+)
+
+// Stock reconciliation policies, i.e. which drift Reconcile repairs.
+const (
+	// StockPolicyReport only measures drift.
+	StockPolicyReport = "report"
+	// StockPolicyLowerOnly lowers counters above the database stock, which
+	// could oversell, and leaves counters below it alone.
+	StockPolicyLowerOnly = "lower_only"
+	// StockPolicyDBWins overwrites every drifted counter with the database stock.
+	StockPolicyDBWins = "db_wins"
+)
+
+// DefaultStockReconcileBatchSize is how many SKUs Reconcile compares per query
+// when the caller does not say.
+const DefaultStockReconcileBatchSize = 500
+
+var ErrInvalidStockPolicy = errors.New("invalid stock reconciliation policy")
+
+// StockStore is the Redis side of the stock; *InventoryService implements it.
+type StockStore interface {
+	StockCounters(ctx context.Context, skuIDs []uint64) (map[uint64]string, error)
+	SetStockIfUnchanged(ctx context.Context, skuID uint64, observed string, stock int) (bool, error)
+}
+
+// StockReconcileOptions controls one Reconcile run.
+type StockReconcileOptions struct {
+	Policy       string
+	BatchSize    int
+	SettleWindow time.Duration // SKUs in orders created or updated this recently are skipped
+}
+
+// StockReconcileReport summarises one Reconcile run.
+type StockReconcileReport struct {
+	Checked    int // SKUs compared
+	Settling   int // SKUs skipped because of recent order activity
+	Drifted    int // SKUs whose counter differed from the database
+	DriftUnits int // Sum of the absolute differences
+	Repaired   int // Counters overwritten with the database stock
+}
+
+// StockReconciler keeps the Redis stock counters in line with the database.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/stock_reconcile_service_mock.go -package=mocks
+type StockReconciler interface {
+	Reconcile(ctx context.Context, opts StockReconcileOptions) (*StockReconcileReport, error)
+}
+
+type stockReconciler struct {
+	productRepo repository.ProductRepository
+	orderRepo   repository.OrderRepository
+	store       StockStore
+}
+
+// NewStockReconciler creates a new StockReconciler instance.
+func NewStockReconciler(productRepo repository.ProductRepository, orderRepo repository.OrderRepository, store StockStore) StockReconciler {
+	return &stockReconciler{
+		productRepo: productRepo,
+		orderRepo:   orderRepo,
+		store:       store,
+	}
+}
+
+// Reconcile compares the Redis counter of every SKU with its database stock and
+// repairs drift according to opts.Policy. The database stock is already net of
+// the stock reserved by open orders, so it is what the counter should read once
+// the order pipeline has caught up; SKUs with order activity inside the settle
+// window are skipped because their counter may still lag. A missing counter
+// reads as zero, as it does for DeductStocks. Repairs are compare-and-set, so a
+// counter that moves during the run is left for the next one, and a failed
+// repair does not stop the others. The report is returned even on error.
+func (s *stockReconciler) Reconcile(ctx context.Context, opts StockReconcileOptions) (*StockReconcileReport, error) {
+	switch opts.Policy {
+	case StockPolicyReport, StockPolicyLowerOnly, StockPolicyDBWins:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidStockPolicy, opts.Policy)
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultStockReconcileBatchSize
+	}
+
+	report := &StockReconcileReport{}
+	var errs []error
+	var afterID uint64
+	for {
+		skus, err := s.productRepo.ListSKUStock(ctx, afterID, batchSize)
+		if err != nil {
+			return report, errors.Join(append(errs, err)...)
+		}
+		if len(skus) == 0 {
+			break
+		}
+		afterID = skus[len(skus)-1].ID
+
+		ids := make([]uint64, len(skus))
+		for i, sku := range skus {
+			ids[i] = sku.ID
+		}
+		counters, err := s.store.StockCounters(ctx, ids)
+		if err != nil {
+			return report, errors.Join(append(errs, err)...)
+		}
+		// Looked up after reading both sides, so an order placed in between is
+		// caught here rather than mistaken for drift.
+		settling, err := s.orderRepo.ListSKUIDsChangedSince(ctx, ids, time.Now().Add(-opts.SettleWindow))
+		if err != nil {
+			return report, errors.Join(append(errs, err)...)
+		}
+		skip := make(map[uint64]bool, len(settling))
+		for _, id := range settling {
+			skip[id] = true
+		}
+
+		for _, sku := range skus {
+			if skip[sku.ID] {
+				report.Settling++
+				continue
+			}
+			report.Checked++
+
+			observed := counters[sku.ID]
+			counter, drift := counterDrift(observed, sku.Stock)
+			if drift == 0 {
+				continue
+			}
+			report.Drifted++
+			report.DriftUnits += drift
+			if !shouldRepair(opts.Policy, counter, sku.Stock) {
+				continue
+			}
+
+			repaired, err := s.store.SetStockIfUnchanged(ctx, sku.ID, observed, sku.Stock)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to repair stock counter of SKU %d: %w", sku.ID, err))
+				continue
+			}
+			if repaired {
+				report.Repaired++
+			}
+		}
+
+		if len(skus) < batchSize {
+			break
+		}
+	}
+	return report, errors.Join(errs...)
+}
+
+// counterDrift parses a raw counter and returns it with its absolute distance
+// from stock. A corrupt counter reads as -1 and drifts by stock+1 so that it is
+// always reported.
+func counterDrift(raw string, stock int) (counter, drift int) {
+	if raw == "" {
+		return 0, stock
+	}
+	counter, err := strconv.Atoi(raw)
+	if err != nil {
+		return -1, stock + 1
+	}
+	if counter > stock {
+		return counter, counter - stock
+	}
+	return counter, stock - counter
+}
+
+// shouldRepair reports whether policy overwrites counter with stock.
+func shouldRepair(policy string, counter, stock int) bool {
+	switch policy {
+	case StockPolicyDBWins:
+		return true
+	case StockPolicyLowerOnly:
+		return counter > stock
+	default:
+		return false
+	}
+}
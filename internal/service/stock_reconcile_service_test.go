@@ -0,0 +1,149 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type reconcileMocks struct {
+	products *mocks.MockProductRepository
+	orders   *mocks.MockOrderRepository
+	store    *mocks.MockStockStore
+}
+
+func skuWithStock(id uint64, stock int) model.SKU {
+	return model.SKU{Base: model.Base{ID: id}, Stock: stock}
+}
+
+func TestStockReconciler_Reconcile(t *testing.T) {
+	// SKU 1 matches, 2 is above the DB (oversell risk), 3 is below it, 4 has
+	// no counter and 5 has a recent order.
+	batch := []model.SKU{skuWithStock(1, 10), skuWithStock(2, 5), skuWithStock(3, 8), skuWithStock(4, 3), skuWithStock(5, 7)}
+	counters := map[uint64]string{1: "10", 2: "9", 3: "6", 5: "1"}
+	ids := []uint64{1, 2, 3, 4, 5}
+
+	scan := func(m reconcileMocks) {
+		m.products.EXPECT().ListSKUStock(gomock.Any(), uint64(0), 100).Return(batch, nil)
+		m.store.EXPECT().StockCounters(gomock.Any(), ids).Return(counters, nil)
+		m.orders.EXPECT().ListSKUIDsChangedSince(gomock.Any(), ids, gomock.Any()).Return([]uint64{5}, nil)
+	}
+	drifted := service.StockReconcileReport{Checked: 4, Settling: 1, Drifted: 3, DriftUnits: 4 + 2 + 3}
+
+	tests := []struct {
+		name       string
+		policy     string
+		mockSetup  func(m reconcileMocks)
+		wantReport service.StockReconcileReport
+		wantErr    error
+		errStr     string
+	}{
+		{
+			name:       "ReportOnly",
+			policy:     service.StockPolicyReport,
+			mockSetup:  scan,
+			wantReport: drifted,
+		},
+		{
+			name:   "LowerOnly",
+			policy: service.StockPolicyLowerOnly,
+			mockSetup: func(m reconcileMocks) {
+				scan(m)
+				m.store.EXPECT().SetStockIfUnchanged(gomock.Any(), uint64(2), "9", 5).Return(true, nil)
+			},
+			wantReport: service.StockReconcileReport{Checked: 4, Settling: 1, Drifted: 3, DriftUnits: 9, Repaired: 1},
+		},
+		{
+			name:   "DBWins",
+			policy: service.StockPolicyDBWins,
+			mockSetup: func(m reconcileMocks) {
+				scan(m)
+				m.store.EXPECT().SetStockIfUnchanged(gomock.Any(), uint64(2), "9", 5).Return(true, nil)
+				m.store.EXPECT().SetStockIfUnchanged(gomock.Any(), uint64(3), "6", 8).Return(true, nil)
+				// The counter moved since it was read; left for the next run.
+				m.store.EXPECT().SetStockIfUnchanged(gomock.Any(), uint64(4), "", 3).Return(false, nil)
+			},
+			wantReport: service.StockReconcileReport{Checked: 4, Settling: 1, Drifted: 3, DriftUnits: 9, Repaired: 2},
+		},
+		{
+			name:   "RepairFailureDoesNotStopOthers",
+			policy: service.StockPolicyDBWins,
+			mockSetup: func(m reconcileMocks) {
+				scan(m)
+				m.store.EXPECT().SetStockIfUnchanged(gomock.Any(), uint64(2), "9", 5).Return(false, errors.New("lock timeout"))
+				m.store.EXPECT().SetStockIfUnchanged(gomock.Any(), uint64(3), "6", 8).Return(true, nil)
+				m.store.EXPECT().SetStockIfUnchanged(gomock.Any(), uint64(4), "", 3).Return(true, nil)
+			},
+			wantReport: service.StockReconcileReport{Checked: 4, Settling: 1, Drifted: 3, DriftUnits: 9, Repaired: 2},
+			errStr:     "failed to repair stock counter of SKU 2",
+		},
+		{
+			name:   "RedisDown",
+			policy: service.StockPolicyDBWins,
+			mockSetup: func(m reconcileMocks) {
+				m.products.EXPECT().ListSKUStock(gomock.Any(), uint64(0), 100).Return(batch, nil)
+				m.store.EXPECT().StockCounters(gomock.Any(), ids).Return(nil, errors.New("connection refused"))
+			},
+			errStr: "connection refused",
+		},
+		{
+			name:      "InvalidPolicy",
+			policy:    "redis_wins",
+			mockSetup: func(m reconcileMocks) {},
+			wantErr:   service.ErrInvalidStockPolicy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			m := reconcileMocks{
+				products: mocks.NewMockProductRepository(ctrl),
+				orders:   mocks.NewMockOrderRepository(ctrl),
+				store:    mocks.NewMockStockStore(ctrl),
+			}
+			tt.mockSetup(m)
+			reconciler := service.NewStockReconciler(m.products, m.orders, m.store)
+
+			report, err := reconciler.Reconcile(context.Background(), service.StockReconcileOptions{Policy: tt.policy, BatchSize: 100})
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			case tt.errStr != "":
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errStr)
+			default:
+				require.NoError(t, err)
+			}
+			require.NotNil(t, report)
+			assert.Equal(t, tt.wantReport, *report)
+		})
+	}
+}
+
+func TestStockReconciler_ReconcilePages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	products := mocks.NewMockProductRepository(ctrl)
+	orders := mocks.NewMockOrderRepository(ctrl)
+	store := mocks.NewMockStockStore(ctrl)
+
+	gomock.InOrder(
+		products.EXPECT().ListSKUStock(gomock.Any(), uint64(0), 2).Return([]model.SKU{skuWithStock(1, 1), skuWithStock(2, 2)}, nil),
+		products.EXPECT().ListSKUStock(gomock.Any(), uint64(2), 2).Return([]model.SKU{skuWithStock(3, 3)}, nil),
+	)
+	store.EXPECT().StockCounters(gomock.Any(), gomock.Any()).Return(map[uint64]string{1: "1", 2: "2", 3: "3"}, nil).Times(2)
+	orders.EXPECT().ListSKUIDsChangedSince(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+
+	reconciler := service.NewStockReconciler(products, orders, store)
+	report, err := reconciler.Reconcile(context.Background(), service.StockReconcileOptions{Policy: service.StockPolicyDBWins, BatchSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, service.StockReconcileReport{Checked: 3}, *report)
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/tenant"
+)
+
+// storesReloadInterval is how long loaded stores are reused before they are
+// read again, so new stores are served within a minute of being created.
+const storesReloadInterval = time.Minute
+
+var (
+	ErrStoreNotFound = errors.New("store not found")
+	ErrStoreExists   = errors.New("a store with this slug or domain already exists")
+)
+
+// StoreCreateReq defines the request structure for creating a store.
+type StoreCreateReq struct {
+	Slug     string
+	Name     string
+	Domain   string
+	Currency string
+	Locale   string
+	// AdoptUnowned moves the users, products and orders created before
+	// tenancy was enabled into the new store.
+	AdoptUnowned bool
+}
+
+// StoreCreateResp describes a created store.
+type StoreCreateResp struct {
+	Store   *model.Store
+	Adopted int64 // Rows moved into the store
+}
+
+// StoreService resolves which store a request is served for.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/store_service_mock.go -package=mocks
+type StoreService interface {
+	// Resolve returns the store selected by slug if given, else the one served
+	// on host, else the default store. It returns ErrStoreNotFound when none
+	// applies.
+	Resolve(ctx context.Context, slug, host string) (*model.Store, error)
+	CreateStore(ctx context.Context, req *StoreCreateReq) (*StoreCreateResp, error)
+}
+
+type storeService struct {
+	repo         repository.StoreRepository
+	defaultStore string // Slug; empty rejects requests no store matches
+
+	mu       sync.Mutex
+	bySlug   map[string]*model.Store
+	byDomain map[string]*model.Store
+	loadedAt time.Time
+}
+
+// NewStoreService creates a new StoreService instance. defaultStore is the
+// slug of the store served when a request names none, or empty to reject
+// such requests.
+func NewStoreService(repo repository.StoreRepository, defaultStore string) StoreService {
+	return &storeService{repo: repo, defaultStore: strings.ToLower(defaultStore)}
+}
+
+func (s *storeService) Resolve(ctx context.Context, slug, host string) (*model.Store, error) {
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if slug != "" {
+		if store, ok := s.bySlug[strings.ToLower(slug)]; ok {
+			return store, nil
+		}
+		// A header naming an unknown store is a client error, not a request for the default
+		return nil, fmt.Errorf("%w: %q", ErrStoreNotFound, slug)
+	}
+	if store, ok := s.byDomain[normalizeHost(host)]; ok {
+		return store, nil
+	}
+	if store, ok := s.bySlug[s.defaultStore]; ok {
+		return store, nil
+	}
+	return nil, fmt.Errorf("%w: no store is served on %q", ErrStoreNotFound, host)
+}
+
+// load reads the stores unless they were read recently. Stores rarely
+// change, so every request resolving against the database would be waste.
+func (s *storeService) load(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.bySlug != nil && time.Since(s.loadedAt) < storesReloadInterval {
+		return nil
+	}
+
+	stores, err := s.repo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load stores: %w", err)
+	}
+	s.bySlug = make(map[string]*model.Store, len(stores))
+	s.byDomain = make(map[string]*model.Store, len(stores))
+	for i := range stores {
+		store := &stores[i]
+		s.bySlug[store.Slug] = store
+		if store.Domain != "" {
+			s.byDomain[store.Domain] = store
+		}
+	}
+	s.loadedAt = time.Now()
+	return nil
+}
+
+func (s *storeService) CreateStore(ctx context.Context, req *StoreCreateReq) (*StoreCreateResp, error) {
+	store := &model.Store{
+		Slug:     strings.ToLower(req.Slug),
+		Name:     req.Name,
+		Domain:   normalizeHost(req.Domain),
+		Currency: strings.ToUpper(req.Currency),
+		Locale:   req.Locale,
+	}
+	existing, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stores: %w", err)
+	}
+	if slices.ContainsFunc(existing, func(other model.Store) bool {
+		return other.Slug == store.Slug || (store.Domain != "" && other.Domain == store.Domain)
+	}) {
+		return nil, ErrStoreExists
+	}
+
+	if err := s.repo.Create(ctx, store); err != nil {
+		return nil, fmt.Errorf("failed to create store: %w", err)
+	}
+	resp := &StoreCreateResp{Store: store}
+	if req.AdoptUnowned {
+		if resp.Adopted, err = s.repo.AdoptUnowned(ctx, store.ID); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// storeCacheKey scopes a cache key to the store ctx is served for, so that
+// one store's cached data is not served to another. Keys are unchanged
+// outside multi-store mode.
+func storeCacheKey(ctx context.Context, key string) string {
+	if storeID := tenant.StoreID(ctx); storeID != 0 {
+		return fmt.Sprintf("%s:store:%d", key, storeID)
+	}
+	return key
+}
+
+// normalizeHost drops the port and a trailing dot from a Host header and
+// lowercases it.
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
+	}
+	return strings.TrimSuffix(host, ".")
+}
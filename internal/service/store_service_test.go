@@ -0,0 +1,110 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestStoreService_Resolve(t *testing.T) {
+	stores := []model.Store{
+		{Base: model.Base{ID: 1}, Slug: "books", Domain: "books.example.com"},
+		{Base: model.Base{ID: 2}, Slug: "toys", Domain: "toys.example.com"},
+	}
+	tests := []struct {
+		name         string
+		slug         string
+		host         string
+		defaultStore string
+		wantID       uint64
+		wantErr      error
+	}{
+		{name: "BySlug", slug: "Toys", host: "books.example.com", wantID: 2},
+		{name: "UnknownSlug", slug: "games", wantErr: service.ErrStoreNotFound},
+		{name: "ByHost", host: "Books.Example.com:8080", wantID: 1},
+		{name: "Default", host: "localhost:8080", defaultStore: "toys", wantID: 2},
+		{name: "NoMatch", host: "localhost:8080", wantErr: service.ErrStoreNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockStoreRepository(ctrl)
+			// Loaded once: later calls reuse the stores
+			repo.EXPECT().List(gomock.Any()).Return(stores, nil).Times(1)
+			svc := service.NewStoreService(repo, tt.defaultStore)
+
+			for range 2 {
+				store, err := svc.Resolve(context.Background(), tt.slug, tt.host)
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+					continue
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantID, store.ID)
+			}
+		})
+	}
+}
+
+func TestStoreService_CreateStore(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       service.StoreCreateReq
+		mockSetup func(repo *mocks.MockStoreRepository)
+		wantErr   error
+	}{
+		{
+			name: "Created",
+			req:  service.StoreCreateReq{Slug: "Toys", Name: "Toys", Domain: "TOYS.example.com", Currency: "eur"},
+			mockSetup: func(repo *mocks.MockStoreRepository) {
+				repo.EXPECT().List(gomock.Any()).Return(nil, nil)
+				repo.EXPECT().Create(gomock.Any(), &model.Store{Slug: "toys", Name: "Toys", Domain: "toys.example.com", Currency: "EUR"}).Return(nil)
+			},
+		},
+		{
+			name: "Adopting",
+			req:  service.StoreCreateReq{Slug: "toys", Name: "Toys", AdoptUnowned: true},
+			mockSetup: func(repo *mocks.MockStoreRepository) {
+				repo.EXPECT().List(gomock.Any()).Return(nil, nil)
+				repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, store *model.Store) error {
+					store.ID = 9
+					return nil
+				})
+				repo.EXPECT().AdoptUnowned(gomock.Any(), uint64(9)).Return(int64(12), nil)
+			},
+		},
+		{
+			name: "DomainTaken",
+			req:  service.StoreCreateReq{Slug: "toys", Name: "Toys", Domain: "shop.example.com"},
+			mockSetup: func(repo *mocks.MockStoreRepository) {
+				repo.EXPECT().List(gomock.Any()).Return([]model.Store{{Slug: "books", Domain: "shop.example.com"}}, nil)
+			},
+			wantErr: service.ErrStoreExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockStoreRepository(ctrl)
+			tt.mockSetup(repo)
+
+			resp, err := service.NewStoreService(repo, "").CreateStore(context.Background(), &tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.req.AdoptUnowned {
+				assert.Equal(t, int64(12), resp.Adopted)
+			}
+		})
+	}
+}
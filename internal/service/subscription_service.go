@@ -0,0 +1,432 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/database"
+	"github.com/proyuen/go-mall/pkg/tenant"
+)
+
+// DefaultSubscriptionBatchSize is how many due subscriptions RenewDue loads
+// per query when the caller does not say.
+const DefaultSubscriptionBatchSize = 100
+
+// DefaultSubscriptionRetryIntervals is the dunning schedule used where
+// SubscriptionOptions leaves RetryIntervals empty: a renewal that cannot be
+// charged is retried a day later, then three days later, then a week later,
+// and the subscription is cancelled if the last retry fails too.
+var DefaultSubscriptionRetryIntervals = []time.Duration{24 * time.Hour, 72 * time.Hour, 168 * time.Hour}
+
+var (
+	// ErrSubscriptionNotFound means the user has no subscription with the ID.
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	// ErrInvalidSubscription means a subscription request is malformed.
+	ErrInvalidSubscription = errors.New("invalid subscription")
+	// ErrSubscriptionStatus means the change is not allowed in the
+	// subscription's status, e.g. resuming one that is not paused.
+	ErrSubscriptionStatus = errors.New("not allowed in the subscription's status")
+	// ErrSubscriptionChanged means the subscription was renewed or changed
+	// while the request was served; it may be retried.
+	ErrSubscriptionChanged = errors.New("subscription changed concurrently")
+)
+
+var subscriptionIntervals = []string{model.SubscriptionIntervalDay, model.SubscriptionIntervalWeek, model.SubscriptionIntervalMonth}
+
+// SubscribeReq subscribes a user to a SKU, ordered every IntervalCount
+// Intervals and charged to one of their saved payment methods.
+type SubscribeReq struct {
+	UserID          uint64
+	SKUID           uint64
+	Quantity        int
+	Interval        string // day, week or month
+	IntervalCount   int    // 0 means 1
+	PaymentMethodID uint64
+	Currency        string     // Empty charges the user's preferred currency
+	Region          string     // Where the orders ship, for tax
+	StartAt         *time.Time // When the first order is placed; nil places it on the next renewal run
+}
+
+// SubscriptionResp is a subscription, as shown to its owner.
+type SubscriptionResp struct {
+	ID              uint64     `json:"id,string"`
+	SKUID           uint64     `json:"sku_id,string"`
+	Quantity        int        `json:"quantity" example:"1"`
+	Interval        string     `json:"interval" example:"month"`
+	IntervalCount   int        `json:"interval_count" example:"1"`
+	PaymentMethodID uint64     `json:"payment_method_id,string"`
+	Currency        string     `json:"currency" example:"USD"`
+	Region          string     `json:"region" example:"US-CA"`
+	Status          string     `json:"status" example:"active"`        // active, past_due, paused or cancelled
+	NextOrderAt     *time.Time `json:"next_order_at,omitempty"`        // When the next order is placed, or retried while past due; nil once cancelled
+	FailedAttempts  int        `json:"failed_attempts"`                // Renewals failed in a row
+	LastOrderID     uint64     `json:"last_order_id,string,omitempty"` // The order of the latest renewal
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// SubscriptionOptions configures SubscriptionService. Zero values fall back
+// to the defaults above.
+type SubscriptionOptions struct {
+	// RetryIntervals is how long to wait before each retry of a renewal that
+	// could not be placed or charged. Once they run out the subscription is
+	// cancelled.
+	RetryIntervals []time.Duration
+}
+
+// SubscriptionService manages users' subscriptions and places their
+// recurring orders.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/subscription_service_mock.go -package=mocks
+type SubscriptionService interface {
+	Subscribe(ctx context.Context, req *SubscribeReq) (*SubscriptionResp, error)
+	List(ctx context.Context, userID uint64) ([]SubscriptionResp, error)
+	// Pause stops renewals until Resume; the schedule is kept.
+	Pause(ctx context.Context, userID, id uint64) (*SubscriptionResp, error)
+	// Resume restarts a paused subscription. A renewal that fell due while it
+	// was paused is placed on the next run, without catching up the others.
+	Resume(ctx context.Context, userID, id uint64) (*SubscriptionResp, error)
+	// Skip moves the next renewal on by one interval.
+	Skip(ctx context.Context, userID, id uint64) (*SubscriptionResp, error)
+	Cancel(ctx context.Context, userID, id uint64) (*SubscriptionResp, error)
+	// RenewDue places the orders of the subscriptions that are due, and
+	// retries those past due, across all stores. It returns how many orders
+	// were placed and charged.
+	RenewDue(ctx context.Context, batchSize int) (int, error)
+}
+
+type subscriptionService struct {
+	repo           repository.SubscriptionRepository
+	productRepo    repository.ProductRepository
+	storeRepo      repository.StoreRepository
+	txManager      database.TransactionManager
+	orders         OrderService
+	payments       PaymentMethodService
+	webhooks       WebhookEmitter
+	retryIntervals []time.Duration
+}
+
+// NewSubscriptionService creates a new SubscriptionService. Renewals are
+// placed through orders and charged to the saved payment methods of
+// payments; renewals that fail are reported to webhooks as
+// subscription.payment_failed.
+func NewSubscriptionService(repo repository.SubscriptionRepository, productRepo repository.ProductRepository, storeRepo repository.StoreRepository, txManager database.TransactionManager, orders OrderService, payments PaymentMethodService, webhooks WebhookEmitter, opts SubscriptionOptions) SubscriptionService {
+	retryIntervals := opts.RetryIntervals
+	if len(retryIntervals) == 0 {
+		retryIntervals = DefaultSubscriptionRetryIntervals
+	}
+	return &subscriptionService{
+		repo:           repo,
+		productRepo:    productRepo,
+		storeRepo:      storeRepo,
+		txManager:      txManager,
+		orders:         orders,
+		payments:       payments,
+		webhooks:       webhooks,
+		retryIntervals: retryIntervals,
+	}
+}
+
+func (s *subscriptionService) Subscribe(ctx context.Context, req *SubscribeReq) (*SubscriptionResp, error) {
+	if req.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be positive", ErrInvalidSubscription)
+	}
+	if !slices.Contains(subscriptionIntervals, req.Interval) {
+		return nil, fmt.Errorf("%w: interval must be one of %s", ErrInvalidSubscription, strings.Join(subscriptionIntervals, ", "))
+	}
+	intervalCount := req.IntervalCount
+	if intervalCount == 0 {
+		intervalCount = 1
+	}
+	if intervalCount < 0 {
+		return nil, fmt.Errorf("%w: interval count must be positive", ErrInvalidSubscription)
+	}
+	if _, err := s.productRepo.GetSKUByID(ctx, req.SKUID); err != nil {
+		if errors.Is(err, repository.ErrSKUNotFound) {
+			return nil, fmt.Errorf("%w: SKU %d not found", ErrInvalidSubscription, req.SKUID)
+		}
+		return nil, err
+	}
+	if _, err := s.payments.Get(ctx, req.UserID, req.PaymentMethodID); err != nil {
+		return nil, err
+	}
+
+	nextOrderAt := time.Now()
+	if req.StartAt != nil && req.StartAt.After(nextOrderAt) {
+		nextOrderAt = *req.StartAt
+	}
+	sub := &model.Subscription{
+		UserID:          req.UserID,
+		SKUID:           req.SKUID,
+		Quantity:        req.Quantity,
+		Interval:        req.Interval,
+		IntervalCount:   intervalCount,
+		PaymentMethodID: req.PaymentMethodID,
+		Currency:        strings.ToUpper(req.Currency),
+		Region:          req.Region,
+		Status:          model.SubscriptionStatusActive,
+		NextOrderAt:     nextOrderAt,
+	}
+	if err := s.repo.Create(ctx, sub); err != nil {
+		return nil, err
+	}
+	resp := newSubscriptionResp(sub)
+	return &resp, nil
+}
+
+func (s *subscriptionService) List(ctx context.Context, userID uint64) ([]SubscriptionResp, error) {
+	subs, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]SubscriptionResp, len(subs))
+	for i := range subs {
+		resp[i] = newSubscriptionResp(&subs[i])
+	}
+	return resp, nil
+}
+
+func (s *subscriptionService) Pause(ctx context.Context, userID, id uint64) (*SubscriptionResp, error) {
+	return s.change(ctx, userID, id, func(sub *model.Subscription) error {
+		if sub.Status != model.SubscriptionStatusActive && sub.Status != model.SubscriptionStatusPastDue {
+			return ErrSubscriptionStatus
+		}
+		sub.Status = model.SubscriptionStatusPaused
+		return nil
+	})
+}
+
+func (s *subscriptionService) Resume(ctx context.Context, userID, id uint64) (*SubscriptionResp, error) {
+	return s.change(ctx, userID, id, func(sub *model.Subscription) error {
+		if sub.Status != model.SubscriptionStatusPaused {
+			return ErrSubscriptionStatus
+		}
+		sub.Status = model.SubscriptionStatusActive
+		sub.FailedAttempts = 0
+		if now := time.Now(); sub.NextOrderAt.Before(now) {
+			sub.NextOrderAt = now
+		}
+		return nil
+	})
+}
+
+// Skip is not allowed while past due: the renewal being retried is owed
+// already, so the user pauses or cancels instead.
+func (s *subscriptionService) Skip(ctx context.Context, userID, id uint64) (*SubscriptionResp, error) {
+	return s.change(ctx, userID, id, func(sub *model.Subscription) error {
+		if sub.Status != model.SubscriptionStatusActive && sub.Status != model.SubscriptionStatusPaused {
+			return ErrSubscriptionStatus
+		}
+		sub.NextOrderAt = sub.Next(sub.NextOrderAt)
+		return nil
+	})
+}
+
+func (s *subscriptionService) Cancel(ctx context.Context, userID, id uint64) (*SubscriptionResp, error) {
+	return s.change(ctx, userID, id, func(sub *model.Subscription) error {
+		if sub.Status == model.SubscriptionStatusCancelled {
+			return ErrSubscriptionStatus
+		}
+		sub.Status = model.SubscriptionStatusCancelled
+		return nil
+	})
+}
+
+// change applies update to one of the user's subscriptions and saves it,
+// unless a renewal or another request changed it in the meantime.
+func (s *subscriptionService) change(ctx context.Context, userID, id uint64, update func(sub *model.Subscription) error) (*SubscriptionResp, error) {
+	sub, err := s.repo.GetByID(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrSubscriptionNotFound) {
+			return nil, ErrSubscriptionNotFound
+		}
+		return nil, err
+	}
+	fromStatus, fromNextOrderAt := sub.Status, sub.NextOrderAt
+	if err := update(sub); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, sub, fromStatus, fromNextOrderAt); err != nil {
+		if errors.Is(err, repository.ErrSubscriptionChanged) {
+			return nil, ErrSubscriptionChanged
+		}
+		return nil, err
+	}
+	resp := newSubscriptionResp(sub)
+	return &resp, nil
+}
+
+// RenewDue goes through the due subscriptions in ID order. Each renewal is
+// placed in the store of its subscription. A subscription that fails to
+// renew does not stop the others; the errors are joined.
+func (s *subscriptionService) RenewDue(ctx context.Context, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultSubscriptionBatchSize
+	}
+	now := time.Now()
+	renewed := 0
+	var stores map[uint64]*model.Store
+	var errs []error
+	for afterID := uint64(0); ; {
+		subs, err := s.repo.ListDue(ctx, now, afterID, batchSize)
+		if err != nil {
+			return renewed, errors.Join(append(errs, err)...)
+		}
+
+		for i := range subs {
+			sub := &subs[i]
+			subCtx := ctx
+			if sub.StoreID != 0 {
+				if stores == nil {
+					if stores, err = s.loadStores(ctx); err != nil {
+						return renewed, errors.Join(append(errs, err)...)
+					}
+				}
+				store, ok := stores[sub.StoreID]
+				if !ok {
+					errs = append(errs, fmt.Errorf("store %d of subscription %d not found", sub.StoreID, sub.ID))
+					continue
+				}
+				subCtx = tenant.NewContext(ctx, store)
+			}
+			ok, err := s.renew(subCtx, sub, now)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if ok {
+				renewed++
+			}
+		}
+
+		if len(subs) < batchSize {
+			return renewed, errors.Join(errs...)
+		}
+		afterID = subs[len(subs)-1].ID
+	}
+}
+
+func (s *subscriptionService) loadStores(ctx context.Context) (map[uint64]*model.Store, error) {
+	list, err := s.storeRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stores := make(map[uint64]*model.Store, len(list))
+	for i := range list {
+		stores[list[i].ID] = &list[i]
+	}
+	return stores, nil
+}
+
+// renew places the order of one due subscription and reports whether it was
+// charged. The subscription is first moved on to its next period, so that a
+// run overlapping this one, or the user pausing it, cannot order the same
+// period again; if the process dies before the order is placed, that period
+// is skipped rather than risk charging twice. A renewal that cannot be placed
+// or charged is retried on the dunning schedule.
+func (s *subscriptionService) renew(ctx context.Context, sub *model.Subscription, now time.Time) (bool, error) {
+	fromStatus, fromNextOrderAt := sub.Status, sub.NextOrderAt
+	next := sub.Next(sub.NextOrderAt)
+	for !next.After(now) { // Periods missed while nothing ran are not caught up
+		next = sub.Next(next)
+	}
+	sub.NextOrderAt = next
+	if err := s.repo.Update(ctx, sub, fromStatus, fromNextOrderAt); err != nil {
+		if errors.Is(err, repository.ErrSubscriptionChanged) {
+			return false, nil
+		}
+		return false, err
+	}
+	claimedNextOrderAt := sub.NextOrderAt
+
+	resp, err := s.orders.CreateOrder(ctx, &OrderCreateReq{
+		UserID:          sub.UserID,
+		Currency:        sub.Currency,
+		Region:          sub.Region,
+		Items:           []OrderItemReq{{SKUID: sub.SKUID, Quantity: sub.Quantity}},
+		PaymentMethodID: sub.PaymentMethodID,
+	})
+	var failure string
+	switch {
+	case err != nil:
+		failure = err.Error()
+	case resp.PaymentError != "":
+		failure = resp.PaymentError
+		sub.LastOrderID = resp.OrderID // Left pending, to be cancelled as it expires
+	default:
+		sub.Status = model.SubscriptionStatusActive
+		sub.FailedAttempts = 0
+		sub.LastOrderID = resp.OrderID
+		if err := s.repo.Update(ctx, sub, fromStatus, claimedNextOrderAt); err != nil && !errors.Is(err, repository.ErrSubscriptionChanged) {
+			return true, fmt.Errorf("failed to record renewal of subscription %d: %w", sub.ID, err)
+		}
+		return true, nil
+	}
+
+	slog.WarnContext(ctx, "Subscription renewal failed", "subscription_id", sub.ID, "attempt", sub.FailedAttempts+1, "error", failure)
+	return false, s.recordFailure(ctx, sub, fromStatus, claimedNextOrderAt, failure, now)
+}
+
+// recordFailure schedules the next retry of a failed renewal, or cancels the
+// subscription once the retries ran out, and queues
+// subscription.payment_failed in the same transaction.
+func (s *subscriptionService) recordFailure(ctx context.Context, sub *model.Subscription, fromStatus string, fromNextOrderAt time.Time, failure string, now time.Time) error {
+	sub.FailedAttempts++
+	data := SubscriptionPaymentFailedWebhookData{
+		SubscriptionID: sub.ID,
+		UserID:         sub.UserID,
+		OrderID:        sub.LastOrderID,
+		Attempt:        sub.FailedAttempts,
+		Error:          failure,
+	}
+	if sub.FailedAttempts > len(s.retryIntervals) {
+		sub.Status = model.SubscriptionStatusCancelled
+	} else {
+		sub.Status = model.SubscriptionStatusPastDue
+		sub.NextOrderAt = now.Add(s.retryIntervals[sub.FailedAttempts-1])
+		data.NextAttemptAt = &sub.NextOrderAt
+	}
+	data.Status = sub.Status
+
+	err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.repo.Update(txCtx, sub, fromStatus, fromNextOrderAt); err != nil {
+			return err
+		}
+		return s.webhooks.Emit(txCtx, WebhookEventSubscriptionPaymentFailed, data)
+	})
+	if errors.Is(err, repository.ErrSubscriptionChanged) {
+		return nil // Paused or cancelled by the user meanwhile
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record failed renewal of subscription %d: %w", sub.ID, err)
+	}
+	return nil
+}
+
+func newSubscriptionResp(sub *model.Subscription) SubscriptionResp {
+	resp := SubscriptionResp{
+		ID:              sub.ID,
+		SKUID:           sub.SKUID,
+		Quantity:        sub.Quantity,
+		Interval:        sub.Interval,
+		IntervalCount:   sub.IntervalCount,
+		PaymentMethodID: sub.PaymentMethodID,
+		Currency:        sub.Currency,
+		Region:          sub.Region,
+		Status:          sub.Status,
+		FailedAttempts:  sub.FailedAttempts,
+		LastOrderID:     sub.LastOrderID,
+		CreatedAt:       sub.CreatedAt,
+	}
+	if sub.Status != model.SubscriptionStatusCancelled {
+		nextOrderAt := sub.NextOrderAt
+		resp.NextOrderAt = &nextOrderAt
+	}
+	return resp
+}
@@ -0,0 +1,233 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSubscriptionService_Subscribe(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       service.SubscribeReq
+		skuErr    error
+		methodErr error
+		wantErrIs error
+	}{
+		{name: "Subscribed", req: service.SubscribeReq{UserID: 1, SKUID: 101, Quantity: 2, Interval: model.SubscriptionIntervalMonth, PaymentMethodID: 5, Currency: "usd"}},
+		{name: "InvalidInterval", req: service.SubscribeReq{UserID: 1, SKUID: 101, Quantity: 1, Interval: "year", PaymentMethodID: 5}, wantErrIs: service.ErrInvalidSubscription},
+		{name: "InvalidQuantity", req: service.SubscribeReq{UserID: 1, SKUID: 101, Interval: model.SubscriptionIntervalWeek, PaymentMethodID: 5}, wantErrIs: service.ErrInvalidSubscription},
+		{name: "UnknownSKU", req: service.SubscribeReq{UserID: 1, SKUID: 999, Quantity: 1, Interval: model.SubscriptionIntervalDay, PaymentMethodID: 5}, skuErr: repository.ErrSKUNotFound, wantErrIs: service.ErrInvalidSubscription},
+		{name: "UnknownPaymentMethod", req: service.SubscribeReq{UserID: 1, SKUID: 101, Quantity: 1, Interval: model.SubscriptionIntervalDay, PaymentMethodID: 6}, methodErr: service.ErrPaymentMethodNotFound, wantErrIs: service.ErrPaymentMethodNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockSubscriptionRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			payments := mocks.NewMockPaymentMethodService(ctrl)
+
+			validated := tt.req.Quantity > 0 && tt.req.Interval != "year"
+			if validated {
+				productRepo.EXPECT().GetSKUByID(gomock.Any(), tt.req.SKUID).Return(&model.SKU{}, tt.skuErr)
+			}
+			if validated && tt.skuErr == nil {
+				payments.EXPECT().Get(gomock.Any(), tt.req.UserID, tt.req.PaymentMethodID).Return(&model.PaymentMethod{}, tt.methodErr)
+			}
+			if tt.wantErrIs == nil {
+				repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, sub *model.Subscription) error {
+					assert.Equal(t, model.SubscriptionStatusActive, sub.Status)
+					assert.Equal(t, 1, sub.IntervalCount, "defaults to every interval")
+					assert.Equal(t, "USD", sub.Currency)
+					assert.WithinDuration(t, time.Now(), sub.NextOrderAt, time.Minute, "first order on the next run")
+					sub.ID = 7
+					return nil
+				})
+			}
+
+			svc := service.NewSubscriptionService(repo, productRepo, nil, nil, nil, payments, nil, service.SubscriptionOptions{})
+			req := tt.req
+			resp, err := svc.Subscribe(context.Background(), &req)
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, uint64(7), resp.ID)
+			assert.NotNil(t, resp.NextOrderAt)
+		})
+	}
+}
+
+func TestSubscriptionService_Change(t *testing.T) {
+	next := time.Date(2030, 1, 31, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		change     func(svc service.SubscriptionService) (*service.SubscriptionResp, error)
+		status     string
+		updateErr  error
+		wantStatus string
+		wantNext   time.Time
+		wantErrIs  error
+	}{
+		{name: "Pause", change: func(svc service.SubscriptionService) (*service.SubscriptionResp, error) {
+			return svc.Pause(context.Background(), 1, 7)
+		},
+			status: model.SubscriptionStatusPastDue, wantStatus: model.SubscriptionStatusPaused, wantNext: next},
+		{name: "ResumeActive", change: func(svc service.SubscriptionService) (*service.SubscriptionResp, error) {
+			return svc.Resume(context.Background(), 1, 7)
+		},
+			status: model.SubscriptionStatusActive, wantErrIs: service.ErrSubscriptionStatus},
+		{name: "Skip", change: func(svc service.SubscriptionService) (*service.SubscriptionResp, error) {
+			return svc.Skip(context.Background(), 1, 7)
+		},
+			status: model.SubscriptionStatusActive, wantStatus: model.SubscriptionStatusActive, wantNext: time.Date(2030, 2, 28, 9, 0, 0, 0, time.UTC)},
+		{name: "SkipPastDue", change: func(svc service.SubscriptionService) (*service.SubscriptionResp, error) {
+			return svc.Skip(context.Background(), 1, 7)
+		},
+			status: model.SubscriptionStatusPastDue, wantErrIs: service.ErrSubscriptionStatus},
+		{name: "CancelWhileRenewing", change: func(svc service.SubscriptionService) (*service.SubscriptionResp, error) {
+			return svc.Cancel(context.Background(), 1, 7)
+		},
+			status: model.SubscriptionStatusActive, updateErr: repository.ErrSubscriptionChanged, wantErrIs: service.ErrSubscriptionChanged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockSubscriptionRepository(ctrl)
+			repo.EXPECT().GetByID(gomock.Any(), uint64(1), uint64(7)).Return(&model.Subscription{
+				Base: model.Base{ID: 7}, UserID: 1, Interval: model.SubscriptionIntervalMonth, IntervalCount: 1, Status: tt.status, NextOrderAt: next,
+			}, nil)
+			if !errors.Is(tt.wantErrIs, service.ErrSubscriptionStatus) {
+				repo.EXPECT().Update(gomock.Any(), gomock.Any(), tt.status, next).Return(tt.updateErr)
+			}
+
+			resp, err := tt.change(service.NewSubscriptionService(repo, nil, nil, nil, nil, nil, nil, service.SubscriptionOptions{}))
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.Status)
+			require.NotNil(t, resp.NextOrderAt)
+			assert.Equal(t, tt.wantNext, *resp.NextOrderAt)
+		})
+	}
+}
+
+func TestSubscriptionService_RenewDue(t *testing.T) {
+	errDB := errors.New("db down")
+	store := model.Store{Base: model.Base{ID: 3}, Slug: "shop"}
+	tests := []struct {
+		name           string
+		failedAttempts int
+		claimErr       error
+		orderResp      *service.OrderCreateResp
+		orderErr       error
+		wantRenewed    int
+		wantStatus     string // Saved after the order; empty if not saved
+		wantRetryIn    time.Duration
+	}{
+		{name: "Renewed", failedAttempts: 2, orderResp: &service.OrderCreateResp{OrderID: 900, Status: model.OrderStatusPaid}, wantRenewed: 1, wantStatus: model.SubscriptionStatusActive},
+		{name: "Declined", orderResp: &service.OrderCreateResp{OrderID: 900, Status: model.OrderStatusPending, PaymentError: "payment declined"}, wantStatus: model.SubscriptionStatusPastDue, wantRetryIn: time.Hour},
+		{name: "OrderFailed", failedAttempts: 1, orderErr: service.ErrInsufficientStock, wantStatus: model.SubscriptionStatusPastDue, wantRetryIn: 2 * time.Hour},
+		{name: "RetriesExhausted", failedAttempts: 2, orderResp: &service.OrderCreateResp{OrderID: 900, PaymentError: "payment declined"}, wantStatus: model.SubscriptionStatusCancelled},
+		{name: "ClaimedElsewhere", claimErr: repository.ErrSubscriptionChanged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockSubscriptionRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			orders := mocks.NewMockOrderService(ctrl)
+			webhooks := mocks.NewMockWebhookEmitter(ctrl)
+
+			due := time.Now().Add(-time.Minute)
+			sub := model.Subscription{
+				Base: model.Base{ID: 7}, StoreID: store.ID, UserID: 1, SKUID: 101, Quantity: 2, Interval: model.SubscriptionIntervalWeek, IntervalCount: 1,
+				PaymentMethodID: 5, Currency: "USD", Status: model.SubscriptionStatusActive, NextOrderAt: due, FailedAttempts: tt.failedAttempts,
+			}
+			if tt.failedAttempts > 0 {
+				sub.Status = model.SubscriptionStatusPastDue
+			}
+			fromStatus := sub.Status
+			repo.EXPECT().ListDue(gomock.Any(), gomock.Any(), uint64(0), 10).Return([]model.Subscription{sub}, nil)
+			storeRepo.EXPECT().List(gomock.Any()).Return([]model.Store{store}, nil)
+
+			// The next period is claimed before the order is placed
+			var claimed time.Time
+			repo.EXPECT().Update(gomock.Any(), gomock.Any(), fromStatus, due).DoAndReturn(func(_ context.Context, s *model.Subscription, _ string, _ time.Time) error {
+				assert.Equal(t, due.AddDate(0, 0, 7), s.NextOrderAt)
+				claimed = s.NextOrderAt
+				return tt.claimErr
+			})
+			if tt.claimErr == nil {
+				orders.EXPECT().CreateOrder(gomock.Any(), &service.OrderCreateReq{
+					UserID: 1, Currency: "USD", Items: []service.OrderItemReq{{SKUID: 101, Quantity: 2}}, PaymentMethodID: 5,
+				}).DoAndReturn(func(ctx context.Context, _ *service.OrderCreateReq) (*service.OrderCreateResp, error) {
+					assert.Equal(t, store.ID, tenant.StoreID(ctx), "placed in the subscription's store")
+					return tt.orderResp, tt.orderErr
+				})
+			}
+			if tt.wantStatus != "" {
+				repo.EXPECT().Update(gomock.Any(), gomock.Any(), fromStatus, gomock.Any()).DoAndReturn(func(_ context.Context, s *model.Subscription, _ string, from time.Time) error {
+					assert.Equal(t, claimed, from)
+					assert.Equal(t, tt.wantStatus, s.Status)
+					switch tt.wantStatus {
+					case model.SubscriptionStatusActive:
+						assert.Zero(t, s.FailedAttempts)
+						assert.Equal(t, uint64(900), s.LastOrderID)
+						assert.Equal(t, claimed, s.NextOrderAt)
+					case model.SubscriptionStatusPastDue:
+						assert.Equal(t, tt.failedAttempts+1, s.FailedAttempts)
+						assert.WithinDuration(t, time.Now().Add(tt.wantRetryIn), s.NextOrderAt, time.Minute)
+					}
+					return nil
+				})
+			}
+			if tt.wantStatus != "" && tt.wantStatus != model.SubscriptionStatusActive {
+				txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				})
+				webhooks.EXPECT().Emit(gomock.Any(), service.WebhookEventSubscriptionPaymentFailed, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, data any) error {
+					failed := data.(service.SubscriptionPaymentFailedWebhookData)
+					assert.Equal(t, tt.failedAttempts+1, failed.Attempt)
+					assert.Equal(t, tt.wantStatus, failed.Status)
+					assert.Equal(t, tt.wantStatus == model.SubscriptionStatusCancelled, failed.NextAttemptAt == nil)
+					return nil
+				})
+			}
+
+			svc := service.NewSubscriptionService(repo, nil, storeRepo, txManager, orders, nil, webhooks, service.SubscriptionOptions{
+				RetryIntervals: []time.Duration{time.Hour, 2 * time.Hour},
+			})
+			renewed, err := svc.RenewDue(context.Background(), 10)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRenewed, renewed)
+		})
+	}
+
+	t.Run("ListFailed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockSubscriptionRepository(ctrl)
+		repo.EXPECT().ListDue(gomock.Any(), gomock.Any(), uint64(0), service.DefaultSubscriptionBatchSize).Return(nil, errDB)
+
+		svc := service.NewSubscriptionService(repo, nil, nil, nil, nil, nil, nil, service.SubscriptionOptions{})
+		_, err := svc.RenewDue(context.Background(), 0)
+		assert.ErrorIs(t, err, errDB)
+	})
+}
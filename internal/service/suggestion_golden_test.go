@@ -0,0 +1,168 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// suggestGolden is the catalog and the golden queries of
+// testdata/suggest_golden.json.
+type suggestGolden struct {
+	Synonyms [][]string `json:"synonyms"`
+	Rules    []struct {
+		Query    string `json:"query"`
+		SPUID    uint64 `json:"spu_id"`
+		Action   string `json:"action"`
+		Position int    `json:"position"`
+	} `json:"rules"`
+	Categories []struct {
+		ID   uint64 `json:"id"`
+		Name string `json:"name"`
+	} `json:"categories"`
+	Products []struct {
+		ID         uint64 `json:"id"`
+		Name       string `json:"name"`
+		CategoryID uint64 `json:"category_id"`
+	} `json:"products"`
+	Queries []struct {
+		Query   string   `json:"query"`
+		NoTypos bool     `json:"no_typos"` // Asked with typo tolerance off
+		Want    []string `json:"want"`     // Suggested, in any order
+		Not     []string `json:"not"`      // Not suggested
+	} `json:"queries"`
+}
+
+// fakeSuggestIndex keeps sorted sets in memory and runs the suggestion
+// scripts against them, told apart by their keys.
+type fakeSuggestIndex struct {
+	sets map[string][]string
+}
+
+func (f *fakeSuggestIndex) del(_ context.Context, keys ...string) error {
+	for _, key := range keys {
+		delete(f.sets, key)
+	}
+	return nil
+}
+
+func (f *fakeSuggestIndex) eval(_ context.Context, _ *redis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	switch {
+	case len(keys) == 3: // Publish
+		f.sets[keys[1]] = f.sets[keys[0]]
+		delete(f.sets, keys[0])
+		return int64(1), nil
+	case keys[0] == "mall:suggest:indexes": // Prune
+		return int64(0), nil
+	case strings.HasSuffix(keys[0], ":building"): // Fill
+		for _, arg := range args {
+			if member := arg.(string); !slices.Contains(f.sets[keys[0]], member) {
+				f.sets[keys[0]] = append(f.sets[keys[0]], member)
+			}
+		}
+		slices.Sort(f.sets[keys[0]])
+		return int64(len(args)), nil
+	}
+
+	found := []interface{}{}
+	for _, member := range f.sets[keys[0]] {
+		if strings.HasPrefix(member, args[1].(string)) {
+			found = append(found, member)
+		}
+	}
+	wanted := len(found) + args[0].(int)
+	for _, arg := range args[2:] {
+		for _, member := range f.sets[keys[0]] {
+			if len(found) >= wanted {
+				return found, nil
+			}
+			if strings.HasPrefix(member, arg.(string)) {
+				found = append(found, member)
+			}
+		}
+	}
+	return found, nil
+}
+
+// TestSuggestionService_Golden rebuilds the index of a small catalog and
+// checks what the golden queries suggest. Add a query there when tuning
+// synonyms, search rules or typo tolerance changes what shoppers should see.
+func TestSuggestionService_Golden(t *testing.T) {
+	data, err := os.ReadFile("testdata/suggest_golden.json")
+	require.NoError(t, err)
+	var golden suggestGolden
+	require.NoError(t, json.Unmarshal(data, &golden))
+
+	ctrl := gomock.NewController(t)
+	productRepo := mocks.NewMockProductRepository(ctrl)
+	categoryRepo := mocks.NewMockCategoryRepository(ctrl)
+	synonymRepo := mocks.NewMockSynonymRepository(ctrl)
+	searchRepo := mocks.NewMockSearchRepository(ctrl)
+	mockCache := mocks.NewMockCache(ctrl)
+	index := &fakeSuggestIndex{sets: make(map[string][]string)}
+	mockCache.EXPECT().Del(gomock.Any(), gomock.Any()).DoAndReturn(index.del).AnyTimes()
+	mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(index.eval).AnyTimes()
+
+	categories := make([]model.Category, len(golden.Categories))
+	for i, category := range golden.Categories {
+		categories[i] = model.Category{Base: model.Base{ID: category.ID}, Name: category.Name}
+	}
+	spus := make([]model.SPU, len(golden.Products))
+	for i, product := range golden.Products {
+		spus[i] = model.SPU{Base: model.Base{ID: product.ID}, Name: product.Name, CategoryID: product.CategoryID}
+	}
+	synonyms := make([]model.SearchSynonym, len(golden.Synonyms))
+	for i, terms := range golden.Synonyms {
+		synonyms[i] = model.SearchSynonym{Terms: terms}
+	}
+	rules := make([]model.SearchRule, len(golden.Rules))
+	for i, rule := range golden.Rules {
+		rules[i] = model.SearchRule{Query: rule.Query, SPUID: rule.SPUID, Action: rule.Action, Position: rule.Position}
+	}
+	categoryRepo.EXPECT().List(gomock.Any()).Return(categories, nil)
+	searchRepo.EXPECT().ListRules(gomock.Any()).Return(rules, nil)
+	productRepo.EXPECT().ListSPUNames(gomock.Any(), uint64(0), gomock.Any()).Return(spus, nil)
+	synonymRepo.EXPECT().List(gomock.Any()).Return(synonyms, nil)
+
+	ctx := context.Background()
+	_, err = service.NewSuggestionService(productRepo, categoryRepo, synonymRepo, searchRepo, mockCache, service.SuggestOptions{}).RebuildIndex(ctx)
+	require.NoError(t, err)
+
+	tolerant := service.NewSuggestionService(nil, nil, nil, nil, mockCache, service.SuggestOptions{TypoTolerance: true})
+	exact := service.NewSuggestionService(nil, nil, nil, nil, mockCache, service.SuggestOptions{})
+	for _, q := range golden.Queries {
+		t.Run(q.Query, func(t *testing.T) {
+			svc := tolerant
+			if q.NoTypos {
+				svc = exact
+			}
+			suggestions, err := svc.Suggest(ctx, q.Query, service.DefaultSuggestions)
+			require.NoError(t, err)
+			texts := make([]string, len(suggestions))
+			for i, suggestion := range suggestions {
+				texts[i] = suggestion.Text
+			}
+
+			for _, want := range q.Want {
+				assert.Contains(t, texts, want)
+			}
+			for _, not := range q.Not {
+				assert.NotContains(t, texts, not)
+			}
+			if len(q.Want) == 0 {
+				assert.Empty(t, texts)
+			}
+		})
+	}
+}
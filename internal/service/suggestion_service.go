@@ -0,0 +1,500 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/cache"
+	"github.com/proyuen/go-mall/pkg/tenant"
+	"github.com/redis/go-redis/v9"
+)
+
+// Suggestion limits.
+const (
+	DefaultSuggestions = 10
+	// MaxSuggestions is the most suggestions Suggest returns.
+	MaxSuggestions = 20
+	// MaxSuggestQueryLength is the longest query, in bytes, Suggest looks
+	// up; longer ones are cut.
+	MaxSuggestQueryLength = 100
+	// DefaultMinTypoLength is the shortest query, in characters, typo
+	// tolerance applies to when SuggestOptions leaves it zero.
+	DefaultMinTypoLength = 4
+)
+
+// Kinds of suggestion.
+const (
+	SuggestionProduct  = "product"
+	SuggestionCategory = "category"
+)
+
+const (
+	// suggestIndexKey is the prefix index of a store, suffixed like every
+	// store's cache keys. It is a sorted set of "<term>\x00<kind>\x00<id>\x00
+	// <text>" members, all scored 0, so that ZRANGEBYLEX finds the terms
+	// starting with a prefix. The search rules of a query are kept there too,
+	// as "\x01<query>\x00<action>\x00<position>\x00<id>\x00<text>"
+	// members, which sort boosts before burials and by position.
+	suggestIndexKey = "mall:suggest"
+	// suggestIndexesKey lists the prefix indexes built, so that those of
+	// stores with no products left are dropped.
+	suggestIndexesKey = "mall:suggest:indexes"
+	// suggestBatchSize is how many SPUs RebuildIndex loads per query.
+	suggestBatchSize = 500
+	// suggestFillSize is how many members RebuildIndex adds per script
+	// call, well within what Lua can unpack.
+	suggestFillSize = 1000
+	// suggestOverfetch is how many index members Suggest reads per
+	// suggestion, since a product matches once per word of its name.
+	suggestOverfetch = 3
+	// maxTypoQueryLength is the longest query, in characters, typo
+	// tolerance applies to, which bounds the lookups of one Suggest.
+	maxTypoQueryLength = 16
+	// searchRuleMark starts the index members holding search rules, which
+	// no typed term starts with.
+	searchRuleMark = "\x01"
+
+	// zeroResultsKey counts the searches that found nothing since the last
+	// flush, by "<store ID>:<query>".
+	zeroResultsKey = "mall:search:zero-results"
+	// zeroResultsFlushingKey holds the searches being flushed, until they
+	// are in the database.
+	zeroResultsFlushingKey = "mall:search:zero-results:flushing"
+)
+
+// suggestByPrefixes returns up to 100 members of KEYS[1] starting with
+// ARGV[2], the search rules of the query, then up to ARGV[1] members starting
+// with ARGV[3], and, while fewer were found, those starting with each further
+// ARGV in turn. No UTF-8 string contains byte 255.
+var suggestByPrefixes = redis.NewScript(`
+local found = redis.call("ZRANGEBYLEX", KEYS[1], "[" .. ARGV[2], "[" .. ARGV[2] .. "\255", "LIMIT", 0, 100)
+local wanted = #found + tonumber(ARGV[1])
+for i = 3, #ARGV do
+	if #found >= wanted then
+		break
+	end
+	for _, member in ipairs(redis.call("ZRANGEBYLEX", KEYS[1], "[" .. ARGV[i], "[" .. ARGV[i] .. "\255", "LIMIT", 0, wanted - #found)) do
+		found[#found + 1] = member
+	end
+end
+return found
+`)
+
+// fillSuggestIndex adds the members in ARGV to KEYS[1].
+var fillSuggestIndex = redis.NewScript(`
+local args = {}
+for i, member in ipairs(ARGV) do
+	args[2 * i - 1] = 0
+	args[2 * i] = member
+end
+return redis.call("ZADD", KEYS[1], unpack(args))
+`)
+
+// publishSuggestIndex replaces the index in KEYS[2] with the one built in
+// KEYS[1], and lists it in KEYS[3].
+var publishSuggestIndex = redis.NewScript(`
+redis.call("RENAME", KEYS[1], KEYS[2])
+return redis.call("SADD", KEYS[3], KEYS[2])
+`)
+
+// pruneSuggestIndexes drops the indexes listed in KEYS[1] but not in ARGV.
+var pruneSuggestIndexes = redis.NewScript(`
+local keep = {}
+for _, key in ipairs(ARGV) do
+	keep[key] = true
+end
+local dropped = 0
+for _, key in ipairs(redis.call("SMEMBERS", KEYS[1])) do
+	if not keep[key] then
+		redis.call("DEL", key)
+		redis.call("SREM", KEYS[1], key)
+		dropped = dropped + 1
+	end
+end
+return dropped
+`)
+
+// countZeroResult increments the counter of the search in ARGV[1].
+var countZeroResult = redis.NewScript(`
+return redis.call("HINCRBY", KEYS[1], ARGV[1], 1)
+`)
+
+// takeZeroResults moves the counters in KEYS[1] to KEYS[2], unless searches
+// left there by a failed flush are still to be written, and returns the
+// counters in KEYS[2] as field and value pairs.
+var takeZeroResults = redis.NewScript(`
+if redis.call("EXISTS", KEYS[2]) == 0 then
+	if redis.call("EXISTS", KEYS[1]) == 0 then
+		return {}
+	end
+	redis.call("RENAME", KEYS[1], KEYS[2])
+end
+return redis.call("HGETALL", KEYS[2])
+`)
+
+// Suggestion is a product or category whose name matches a typed prefix.
+type Suggestion struct {
+	Kind string `json:"kind" example:"product"` // "product" or "category"
+	ID   uint64 `json:"id,string" example:"1234567890"`
+	Text string `json:"text" example:"Trail Running Shoes"`
+}
+
+// SuggestionService completes what a customer types in the search box with
+// the names of the store's products and categories.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/suggestion_service_mock.go -package=mocks
+type SuggestionService interface {
+	// Suggest returns up to limit products and categories of the store in
+	// ctx with a word of their name starting with query, ignoring case.
+	// Products boosted for the query come first; those buried are left out.
+	Suggest(ctx context.Context, query string, limit int) ([]Suggestion, error)
+	// RebuildIndex rebuilds the prefix index of every store from the
+	// database, and returns how many products it indexed.
+	RebuildIndex(ctx context.Context) (int, error)
+	// RecordZeroResult counts one search of the store in ctx that found
+	// nothing.
+	RecordZeroResult(ctx context.Context, query string) error
+	// FlushZeroResults adds the searches counted since the last flush to
+	// those in the database, and returns how many queries were searched.
+	FlushZeroResults(ctx context.Context) (int, error)
+}
+
+// SuggestOptions configures a SuggestionService.
+type SuggestOptions struct {
+	// TypoTolerance also suggests names one typo away from queries that
+	// complete to too few: a character missing, extra, swapped with the
+	// next or replaced. Only letters a to z are tried where one is missing
+	// or replaced.
+	TypoTolerance bool
+	MinTypoLength int // Shortest query, in characters, typos are tolerated in
+}
+
+type suggestionService struct {
+	productRepo   repository.ProductRepository
+	categoryRepo  repository.CategoryRepository
+	synonymRepo   repository.SynonymRepository
+	searchRepo    repository.SearchRepository
+	cache         cache.Cache
+	typoTolerance bool
+	minTypoLength int
+}
+
+// NewSuggestionService creates a new SuggestionService instance. Names are
+// indexed under the synonyms in synonymRepo too, along with the search rules
+// in searchRepo.
+func NewSuggestionService(productRepo repository.ProductRepository, categoryRepo repository.CategoryRepository, synonymRepo repository.SynonymRepository,
+	searchRepo repository.SearchRepository, c cache.Cache, opts SuggestOptions) SuggestionService {
+	if opts.MinTypoLength <= 0 {
+		opts.MinTypoLength = DefaultMinTypoLength
+	}
+	return &suggestionService{
+		productRepo:   productRepo,
+		categoryRepo:  categoryRepo,
+		synonymRepo:   synonymRepo,
+		searchRepo:    searchRepo,
+		cache:         c,
+		typoTolerance: opts.TypoTolerance,
+		minTypoLength: opts.MinTypoLength,
+	}
+}
+
+// Suggest is a single script call, so it takes about a Redis round trip
+// whatever the size of the catalog: the exact prefix is one ZRANGEBYLEX,
+// and typo tolerance adds at most a few hundred more, only when the exact
+// prefix completes to too few. Suggestions are as fresh as the last
+// RebuildIndex.
+func (s *suggestionService) Suggest(ctx context.Context, query string, limit int) ([]Suggestion, error) {
+	if limit <= 0 || limit > MaxSuggestions {
+		limit = DefaultSuggestions
+	}
+	if len(query) > MaxSuggestQueryLength {
+		query = strings.ToValidUTF8(query[:MaxSuggestQueryLength], "")
+	}
+	prefix := normalizeSuggestTerm(query)
+	if prefix == "" {
+		return []Suggestion{}, nil
+	}
+
+	rulePrefix := searchRuleMark + strings.TrimSpace(prefix) + "\x00"
+	args := []interface{}{limit * suggestOverfetch, rulePrefix, prefix}
+	if length := utf8.RuneCountInString(prefix); s.typoTolerance && length >= s.minTypoLength && length <= maxTypoQueryLength {
+		for _, variant := range typoVariants(prefix) {
+			args = append(args, variant)
+		}
+	}
+	res, err := s.cache.Eval(ctx, suggestByPrefixes, []string{storeCacheKey(ctx, suggestIndexKey)}, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up suggestions: %w", err)
+	}
+	members, _ := res.([]interface{})
+	suggestions := make([]Suggestion, 0, limit)
+	seen := make(map[string]bool, len(members))
+	for _, member := range members {
+		if len(suggestions) == limit {
+			break
+		}
+		raw, _ := member.(string)
+		if rule, ok := strings.CutPrefix(raw, rulePrefix); ok {
+			// action, position, ID and text; boosts sort first
+			parts := strings.SplitN(rule, "\x00", 4)
+			if len(parts) != 4 || seen[SuggestionProduct+":"+parts[2]] {
+				continue
+			}
+			seen[SuggestionProduct+":"+parts[2]] = true // Buried by marking it seen
+			id, err := strconv.ParseUint(parts[2], 10, 64)
+			if err == nil && parts[0] == model.SearchRuleBoost {
+				suggestions = append(suggestions, Suggestion{Kind: SuggestionProduct, ID: id, Text: parts[3]})
+			}
+			continue
+		}
+		if strings.HasPrefix(raw, searchRuleMark) {
+			continue
+		}
+		parts := strings.SplitN(raw, "\x00", 4)
+		if len(parts) != 4 || seen[parts[1]+":"+parts[2]] {
+			continue
+		}
+		id, err := strconv.ParseUint(parts[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		seen[parts[1]+":"+parts[2]] = true
+		suggestions = append(suggestions, Suggestion{Kind: parts[1], ID: id, Text: parts[3]})
+	}
+	return suggestions, nil
+}
+
+// RebuildIndex builds each store's index aside and swaps it in once
+// complete, so that Suggest never sees a partial one. Categories are
+// suggested in the stores that have products in them.
+func (s *suggestionService) RebuildIndex(ctx context.Context) (int, error) {
+	categories, err := s.categoryRepo.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	categoryNames := make(map[uint64]string, len(categories))
+	for _, category := range categories {
+		categoryNames[category.ID] = category.Name
+	}
+	synonymSets, err := s.synonymRepo.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	synonyms := make(map[uint64]map[string][]string) // Other words of each word's sets, by store
+	for _, set := range synonymSets {
+		if synonyms[set.StoreID] == nil {
+			synonyms[set.StoreID] = make(map[string][]string)
+		}
+		for _, term := range set.Terms {
+			for _, other := range set.Terms {
+				if other != term && !slices.Contains(synonyms[set.StoreID][term], other) {
+					synonyms[set.StoreID][term] = append(synonyms[set.StoreID][term], other)
+				}
+			}
+		}
+	}
+
+	rules, err := s.searchRepo.ListRules(ctx)
+	if err != nil {
+		return 0, err
+	}
+	spuRules := make(map[uint64][]model.SearchRule, len(rules))
+	for _, rule := range rules {
+		spuRules[rule.SPUID] = append(spuRules[rule.SPUID], rule)
+	}
+
+	building := make(map[uint64]map[uint64]bool) // Categories indexed, by store
+	indexed := 0
+	for afterID := uint64(0); ; {
+		spus, err := s.productRepo.ListSPUNames(ctx, afterID, suggestBatchSize)
+		if err != nil {
+			return indexed, err
+		}
+		members := make(map[uint64][]interface{})
+		for _, spu := range spus {
+			productMembers := appendSuggestMembers(nil, SuggestionProduct, spu.ID, spu.Name, synonyms[spu.StoreID])
+			if len(productMembers) == 0 {
+				continue // A blank name completes nothing
+			}
+			storeCategories, ok := building[spu.StoreID]
+			if !ok {
+				if err := s.cache.Del(ctx, suggestBuildingKey(spu.StoreID)); err != nil {
+					return indexed, fmt.Errorf("failed to reset suggestion index: %w", err)
+				}
+				storeCategories = make(map[uint64]bool)
+				building[spu.StoreID] = storeCategories
+			}
+			members[spu.StoreID] = append(members[spu.StoreID], productMembers...)
+			for _, rule := range spuRules[spu.ID] {
+				if rule.StoreID == spu.StoreID {
+					members[spu.StoreID] = append(members[spu.StoreID], searchRuleMember(&rule, spu.Name))
+				}
+			}
+			indexed++
+			if name, ok := categoryNames[spu.CategoryID]; ok && !storeCategories[spu.CategoryID] {
+				storeCategories[spu.CategoryID] = true
+				members[spu.StoreID] = appendSuggestMembers(members[spu.StoreID], SuggestionCategory, spu.CategoryID, name, synonyms[spu.StoreID])
+			}
+		}
+		for storeID, storeMembers := range members {
+			for batch := range slices.Chunk(storeMembers, suggestFillSize) {
+				if _, err := s.cache.Eval(ctx, fillSuggestIndex, []string{suggestBuildingKey(storeID)}, batch...); err != nil {
+					return indexed, fmt.Errorf("failed to fill suggestion index: %w", err)
+				}
+			}
+		}
+		if len(spus) < suggestBatchSize {
+			break
+		}
+		afterID = spus[len(spus)-1].ID
+	}
+
+	keep := make([]interface{}, 0, len(building))
+	for storeID := range building {
+		key := suggestStoreKey(storeID)
+		if _, err := s.cache.Eval(ctx, publishSuggestIndex, []string{suggestBuildingKey(storeID), key, suggestIndexesKey}); err != nil {
+			return indexed, fmt.Errorf("failed to publish suggestion index: %w", err)
+		}
+		keep = append(keep, key)
+	}
+	if _, err := s.cache.Eval(ctx, pruneSuggestIndexes, []string{suggestIndexesKey}, keep...); err != nil {
+		return indexed, fmt.Errorf("failed to drop stale suggestion indexes: %w", err)
+	}
+	return indexed, nil
+}
+
+func (s *suggestionService) RecordZeroResult(ctx context.Context, query string) error {
+	if len(query) > MaxSuggestQueryLength {
+		query = strings.ToValidUTF8(query[:MaxSuggestQueryLength], "")
+	}
+	query = strings.TrimSpace(normalizeSuggestTerm(query))
+	if query == "" {
+		return nil
+	}
+	field := strconv.FormatUint(tenant.StoreID(ctx), 10) + ":" + query
+	if _, err := s.cache.Eval(ctx, countZeroResult, []string{zeroResultsKey}, field); err != nil {
+		return fmt.Errorf("failed to count zero result search: %w", err)
+	}
+	return nil
+}
+
+// FlushZeroResults keeps the searches it took from the counters until they
+// are written, so a failed flush is retried by the next one.
+func (s *suggestionService) FlushZeroResults(ctx context.Context) (int, error) {
+	res, err := s.cache.Eval(ctx, takeZeroResults, []string{zeroResultsKey, zeroResultsFlushingKey})
+	if err != nil {
+		return 0, fmt.Errorf("failed to take zero result counters: %w", err)
+	}
+	pairs, _ := res.([]interface{})
+	if len(pairs) == 0 {
+		return 0, nil
+	}
+	searches := make([]model.SearchZeroResult, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		field, _ := pairs[i].(string)
+		value, _ := pairs[i+1].(string)
+		store, query, ok := strings.Cut(field, ":")
+		if !ok {
+			continue
+		}
+		storeID, err := strconv.ParseUint(store, 10, 64)
+		if err != nil {
+			continue
+		}
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || count <= 0 {
+			continue
+		}
+		searches = append(searches, model.SearchZeroResult{StoreID: storeID, Query: query, Searches: count})
+	}
+
+	if err := s.searchRepo.AddZeroResults(ctx, searches, time.Now()); err != nil {
+		return 0, err
+	}
+	if err := s.cache.Del(ctx, zeroResultsFlushingKey); err != nil {
+		return len(searches), fmt.Errorf("failed to drop flushed zero result counters: %w", err)
+	}
+	return len(searches), nil
+}
+
+// suggestStoreKey is the prefix index of the store, as Suggest finds it.
+func suggestStoreKey(storeID uint64) string {
+	return storeCacheKey(tenant.NewContext(context.Background(), &model.Store{Base: model.Base{ID: storeID}}), suggestIndexKey)
+}
+
+// suggestBuildingKey holds the prefix index of the store while it is rebuilt.
+func suggestBuildingKey(storeID uint64) string {
+	return suggestStoreKey(storeID) + ":building"
+}
+
+// appendSuggestMembers appends an index member for each word of text, and
+// each synonym of it, so that any of them completes to it.
+func appendSuggestMembers(members []interface{}, kind string, id uint64, text string, synonyms map[string][]string) []interface{} {
+	suffix := "\x00" + kind + "\x00" + strconv.FormatUint(id, 10) + "\x00" + strings.TrimSpace(text)
+	words := strings.Fields(strings.ToLower(text))
+	for i, word := range words {
+		rest := strings.Join(words[i+1:], " ")
+		if rest != "" {
+			rest = " " + rest
+		}
+		members = append(members, word+rest+suffix)
+		for _, synonym := range synonyms[word] {
+			members = append(members, synonym+rest+suffix)
+		}
+	}
+	return members
+}
+
+// searchRuleMember is the index member of a search rule of the product named
+// text.
+func searchRuleMember(rule *model.SearchRule, text string) string {
+	return fmt.Sprintf("%s%s\x00%s\x00%04d\x00%d\x00%s", searchRuleMark, rule.Query, rule.Action, rule.Position, rule.SPUID, strings.TrimSpace(text))
+}
+
+// typoVariants returns the prefixes one typo away from prefix, in the order
+// of SuggestOptions.TypoTolerance, without repeats.
+func typoVariants(prefix string) []string {
+	runes := []rune(prefix)
+	seen := map[string]bool{prefix: true}
+	var variants []string
+	add := func(variant []rune) {
+		if v := string(variant); !seen[v] && strings.TrimSpace(v) != "" {
+			seen[v] = true
+			variants = append(variants, v)
+		}
+	}
+	for i := range runes {
+		add(slices.Concat(runes[:i], runes[i+1:])) // Extra character
+	}
+	for i := 0; i+1 < len(runes); i++ {
+		add(slices.Concat(runes[:i], []rune{runes[i+1], runes[i]}, runes[i+2:])) // Swapped
+	}
+	for i := range runes {
+		for r := 'a'; r <= 'z'; r++ {
+			add(slices.Concat(runes[:i], []rune{r}, runes[i+1:])) // Replaced
+		}
+	}
+	for i := 0; i < len(runes); i++ {
+		for r := 'a'; r <= 'z'; r++ {
+			add(slices.Concat(runes[:i], []rune{r}, runes[i:])) // Missing
+		}
+	}
+	return variants
+}
+
+// normalizeSuggestTerm lowercases s and collapses its spaces, as the index
+// terms are.
+func normalizeSuggestTerm(s string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	if strings.HasSuffix(s, " ") && normalized != "" {
+		normalized += " " // "red " only completes to names with a word after red
+	}
+	return normalized
+}
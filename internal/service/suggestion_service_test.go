@@ -0,0 +1,266 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSuggestionService_Suggest(t *testing.T) {
+	store := &model.Store{Base: model.Base{ID: 3}}
+	members := []interface{}{
+		"running shoes\x00product\x0011\x00Running Shoes",
+		"running\x00category\x005\x00Running",
+		"running shoes\x00product\x0011\x00Trail Running Shoes", // Same product, another word
+		"runway dress\x00product\x0012\x00Runway Dress",
+		"bogus",
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		limit      int
+		wantPrefix string
+		wantLimit  int
+		wantIDs    []uint64
+	}{
+		{name: "Deduplicated", query: "  RUN", limit: 10, wantPrefix: "run", wantLimit: 30, wantIDs: []uint64{11, 5, 12}},
+		{name: "Limited", query: "run", limit: 2, wantPrefix: "run", wantLimit: 6, wantIDs: []uint64{11, 5}},
+		{name: "DefaultLimit", query: "Running  Sh", wantPrefix: "running sh", wantLimit: 30, wantIDs: []uint64{11, 5, 12}},
+		{name: "WordTyped", query: "running ", limit: 10, wantPrefix: "running ", wantLimit: 30, wantIDs: []uint64{11, 5, 12}},
+		{name: "Blank", query: "   ", limit: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockCache := mocks.NewMockCache(ctrl)
+			if tt.wantPrefix != "" {
+				mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:suggest:store:3"}, tt.wantLimit, "\x01"+strings.TrimSpace(tt.wantPrefix)+"\x00", tt.wantPrefix).Return(members, nil)
+			}
+
+			svc := service.NewSuggestionService(nil, nil, nil, nil, mockCache, service.SuggestOptions{})
+			suggestions, err := svc.Suggest(tenant.NewContext(context.Background(), store), tt.query, tt.limit)
+			require.NoError(t, err)
+			ids := []uint64{}
+			for _, suggestion := range suggestions {
+				ids = append(ids, suggestion.ID)
+			}
+			if tt.wantIDs == nil {
+				tt.wantIDs = []uint64{}
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestSuggestionService_SuggestError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache(ctrl)
+	mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:suggest"}, 30, "\x01mug\x00", "mug").Return(nil, errors.New("redis down"))
+
+	svc := service.NewSuggestionService(nil, nil, nil, nil, mockCache, service.SuggestOptions{})
+	_, err := svc.Suggest(context.Background(), "mug", 10)
+	assert.Error(t, err)
+}
+
+func TestSuggestionService_SuggestRules(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache(ctrl)
+	members := []interface{}{
+		"\x01running shoes\x00boost\x000001\x0020\x00Race Flats",
+		"\x01running shoes\x00boost\x000002\x0011\x00Trail Running Shoes",
+		"\x01running shoes\x00bury\x000000\x0012\x00Road Running Shoes",
+		"\x01running shoes\x00bury\x000000\x0020\x00Race Flats", // Boosted already
+		"running shoes\x00product\x0011\x00Trail Running Shoes",
+		"running shoes\x00product\x0012\x00Road Running Shoes",
+		"running shoes\x00product\x0013\x00Running Shoes",
+	}
+	for _, limit := range []int{6, 9} {
+		mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:suggest"}, limit, "\x01running shoes\x00", "running shoes ").Return(members, nil)
+	}
+
+	svc := service.NewSuggestionService(nil, nil, nil, nil, mockCache, service.SuggestOptions{})
+	suggestions, err := svc.Suggest(context.Background(), "Running shoes ", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []service.Suggestion{
+		{Kind: service.SuggestionProduct, ID: 20, Text: "Race Flats"},
+		{Kind: service.SuggestionProduct, ID: 11, Text: "Trail Running Shoes"},
+	}, suggestions)
+
+	suggestions, err = svc.Suggest(context.Background(), "Running shoes ", 3)
+	require.NoError(t, err)
+	require.Len(t, suggestions, 3)
+	assert.Equal(t, uint64(13), suggestions[2].ID, "buried products are left out")
+}
+
+func TestSuggestionService_SuggestTypos(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		opts         service.SuggestOptions
+		wantVariants []string // Some of them, in order
+		wantNone     bool
+	}{
+		{name: "Tolerated", query: "shose", opts: service.SuggestOptions{TypoTolerance: true}, wantVariants: []string{"hose", "shoe", "hsose", "shoes", "ahose"}},
+		{name: "Disabled", query: "shose", wantNone: true},
+		{name: "TooShort", query: "sho", opts: service.SuggestOptions{TypoTolerance: true}, wantNone: true},
+		{name: "MinLength", query: "sho", opts: service.SuggestOptions{TypoTolerance: true, MinTypoLength: 3}, wantVariants: []string{"ho", "hso", "aho"}},
+		{name: "TooLong", query: "waterproof hiking", opts: service.SuggestOptions{TypoTolerance: true}, wantNone: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockCache := mocks.NewMockCache(ctrl)
+			var args []interface{}
+			mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:suggest"}, gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ interface{}, _ []string, a ...interface{}) (interface{}, error) {
+					args = a
+					return []interface{}{}, nil
+				})
+
+			svc := service.NewSuggestionService(nil, nil, nil, nil, mockCache, tt.opts)
+			_, err := svc.Suggest(context.Background(), tt.query, 10)
+			require.NoError(t, err)
+
+			require.GreaterOrEqual(t, len(args), 3)
+			assert.Equal(t, []interface{}{30, "\x01" + tt.query + "\x00", tt.query}, args[:3], "the exact prefix is looked up first")
+			variants := args[3:]
+			if tt.wantNone {
+				assert.Empty(t, variants)
+				return
+			}
+			assert.NotContains(t, variants, tt.query)
+			last := -1
+			for _, want := range tt.wantVariants {
+				i := slices.Index(variants, interface{}(want))
+				require.NotEqual(t, -1, i, "missing variant %q", want)
+				assert.Greater(t, i, last, "variant %q out of order", want)
+				last = i
+			}
+		})
+	}
+}
+
+func TestSuggestionService_RebuildIndex(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	productRepo := mocks.NewMockProductRepository(ctrl)
+	categoryRepo := mocks.NewMockCategoryRepository(ctrl)
+	synonymRepo := mocks.NewMockSynonymRepository(ctrl)
+	searchRepo := mocks.NewMockSearchRepository(ctrl)
+	mockCache := mocks.NewMockCache(ctrl)
+
+	categoryRepo.EXPECT().List(gomock.Any()).Return([]model.Category{{Base: model.Base{ID: 5}, Name: "Running"}}, nil)
+	searchRepo.EXPECT().ListRules(gomock.Any()).Return([]model.SearchRule{
+		{Query: "running shoes", SPUID: 11, Action: model.SearchRuleBoost, Position: 2},
+		{StoreID: 3, Query: "tea", SPUID: 11, Action: model.SearchRuleBury}, // The product is another store's
+	}, nil)
+	synonymRepo.EXPECT().List(gomock.Any()).Return([]model.SearchSynonym{
+		{Terms: []string{"shoes", "sneakers"}},
+		{StoreID: 3, Terms: []string{"mug", "cup"}},
+		{StoreID: 4, Terms: []string{"trail", "hiking"}}, // Another store's
+	}, nil)
+	productRepo.EXPECT().ListSPUNames(gomock.Any(), uint64(0), 500).Return([]model.SPU{
+		{Base: model.Base{ID: 11}, Name: "Trail Shoes", CategoryID: 5},
+		{Base: model.Base{ID: 12}, StoreID: 3, Name: "Mug", CategoryID: 5},
+		{Base: model.Base{ID: 13}, Name: "Road Shoes", CategoryID: 5}, // Its category is already indexed
+		{Base: model.Base{ID: 14}, StoreID: 4, Name: "  "},            // Completes nothing
+	}, nil)
+	mockCache.EXPECT().Del(gomock.Any(), "mall:suggest:building").Return(nil)
+	mockCache.EXPECT().Del(gomock.Any(), "mall:suggest:store:3:building").Return(nil)
+	mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:suggest:building"},
+		"trail shoes\x00product\x0011\x00Trail Shoes",
+		"shoes\x00product\x0011\x00Trail Shoes",
+		"sneakers\x00product\x0011\x00Trail Shoes",
+		"\x01running shoes\x00boost\x000002\x0011\x00Trail Shoes",
+		"running\x00category\x005\x00Running",
+		"road shoes\x00product\x0013\x00Road Shoes",
+		"shoes\x00product\x0013\x00Road Shoes",
+		"sneakers\x00product\x0013\x00Road Shoes",
+	).Return(int64(8), nil)
+	mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:suggest:store:3:building"},
+		"mug\x00product\x0012\x00Mug",
+		"cup\x00product\x0012\x00Mug",
+		"running\x00category\x005\x00Running",
+	).Return(int64(2), nil)
+	mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:suggest:building", "mall:suggest", "mall:suggest:indexes"}).Return(int64(0), nil)
+	mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:suggest:store:3:building", "mall:suggest:store:3", "mall:suggest:indexes"}).Return(int64(1), nil)
+	mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:suggest:indexes"}, gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+	svc := service.NewSuggestionService(productRepo, categoryRepo, synonymRepo, searchRepo, mockCache, service.SuggestOptions{})
+	indexed, err := svc.RebuildIndex(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, indexed)
+}
+
+func TestSuggestionService_RecordZeroResult(t *testing.T) {
+	store := &model.Store{Base: model.Base{ID: 3}}
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache(ctrl)
+	mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:search:zero-results"}, "3:unicorn slippers").Return(int64(1), nil)
+
+	svc := service.NewSuggestionService(nil, nil, nil, nil, mockCache, service.SuggestOptions{})
+	require.NoError(t, svc.RecordZeroResult(tenant.NewContext(context.Background(), store), " Unicorn  Slippers "))
+	require.NoError(t, svc.RecordZeroResult(context.Background(), "   "), "blank queries are not counted")
+}
+
+func TestSuggestionService_FlushZeroResults(t *testing.T) {
+	tests := []struct {
+		name      string
+		counters  interface{}
+		addErr    error
+		wantAdded []model.SearchZeroResult
+		wantCount int
+		wantErr   bool
+	}{
+		{
+			name:      "Flushed",
+			counters:  []interface{}{"3:unicorn slippers", "4", "0:flux: capacitor", "2", "bogus", "1", "3:mug", "x"},
+			wantAdded: []model.SearchZeroResult{{StoreID: 3, Query: "unicorn slippers", Searches: 4}, {Query: "flux: capacitor", Searches: 2}},
+			wantCount: 2,
+		},
+		{name: "NothingCounted", counters: []interface{}{}},
+		{
+			name:      "AddFailed",
+			counters:  []interface{}{"3:mug", "1"},
+			addErr:    errors.New("db down"),
+			wantAdded: []model.SearchZeroResult{{StoreID: 3, Query: "mug", Searches: 1}},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			searchRepo := mocks.NewMockSearchRepository(ctrl)
+			mockCache := mocks.NewMockCache(ctrl)
+			mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"mall:search:zero-results", "mall:search:zero-results:flushing"}).Return(tt.counters, nil)
+			if tt.wantAdded != nil {
+				searchRepo.EXPECT().AddZeroResults(gomock.Any(), tt.wantAdded, gomock.Any()).Return(tt.addErr)
+			}
+			if tt.wantAdded != nil && tt.addErr == nil {
+				mockCache.EXPECT().Del(gomock.Any(), "mall:search:zero-results:flushing").Return(nil)
+			}
+
+			svc := service.NewSuggestionService(nil, nil, nil, searchRepo, mockCache, service.SuggestOptions{})
+			flushed, err := svc.FlushZeroResults(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCount, flushed)
+		})
+	}
+}
@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/database"
+	"github.com/proyuen/go-mall/pkg/money"
+)
+
+// DefaultStoreCreditPageSize is how many ledger entries StoreCredits returns
+// when no limit is given.
+const DefaultStoreCreditPageSize = 20
+
+var (
+	// ErrInvalidStoreCredit means a credit is not a positive amount, or has
+	// no reason.
+	ErrInvalidStoreCredit = errors.New("invalid store credit")
+	// ErrNotResendable means the notification does not apply to the order,
+	// e.g. order_failed for an order that did not fail.
+	ErrNotResendable = errors.New("notification cannot be resent for the order")
+)
+
+// ResendableKinds lists the notifications customer service can send again.
+var ResendableKinds = []notification.Kind{notification.KindOrderConfirmation, notification.KindOrderFailed}
+
+// CustomerOrderResp is an order as customer service sees it: what was
+// ordered, where it ships and what has shipped.
+type CustomerOrderResp struct {
+	ID              uint64              `json:"id,string"`
+	UserID          uint64              `json:"user_id,string"`
+	OrderNumber     string              `json:"order_number"`
+	Status          string              `json:"status" example:"paid"`
+	Fulfillment     string              `json:"fulfillment,omitempty" example:"partially_shipped"` // How far its shipments got; partially_shipped, shipped, partially_delivered or delivered
+	TotalAmount     money.Money         `json:"total_amount"`
+	Region          string              `json:"region" example:"DE"`
+	ShippingAddress model.Address       `json:"shipping_address"`
+	Items           []CustomerOrderItem `json:"items"`
+	Shipments       []ShipmentResp      `json:"shipments"`
+	CreatedAt       time.Time           `json:"created_at"`
+}
+
+type CustomerOrderItem struct {
+	ID          uint64      `json:"id,string"`
+	SKUID       uint64      `json:"sku_id,string"`
+	Name        string      `json:"name"`
+	Quantity    int         `json:"quantity"`
+	Price       money.Money `json:"price"` // Unit price
+	Backordered bool        `json:"backordered"`
+	// BundleItemID is set on the component lines of a bundle, which are
+	// fulfilled in its stead, to the bundle's line.
+	BundleItemID uint64 `json:"bundle_item_id,string,omitempty"`
+	// Options are the product options chosen for every unit, included in
+	// Price.
+	Options []OrderItemOptionResp `json:"options,omitempty"`
+}
+
+// StoreCreditReq grants a customer store credit.
+type StoreCreditReq struct {
+	UserID    uint64
+	Amount    money.Money
+	Reason    string
+	OrderID   uint64 // The customer's order it makes up for; optional
+	CreatedBy uint64 // Admin user ID
+}
+
+// StoreCreditResp is one entry of a customer's store credit ledger.
+type StoreCreditResp struct {
+	ID        uint64      `json:"id,string"`
+	Amount    money.Money `json:"amount"`
+	Reason    string      `json:"reason"`
+	OrderID   uint64      `json:"order_id,string,omitempty"`
+	CreatedBy uint64      `json:"created_by,string"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// StoreCreditAccountResp is a customer's store credit: the balance in each
+// currency they hold credit in, and a page of the ledger.
+type StoreCreditAccountResp struct {
+	Balances []money.Money     `json:"balances"`
+	Entries  []StoreCreditResp `json:"entries"`
+	Total    int64             `json:"total"` // Entries in the whole ledger
+}
+
+// ResendResult is the outcome of sending a notification again over one
+// channel.
+type ResendResult struct {
+	Channel notification.Channel `json:"channel" example:"email"`
+	Status  notification.Result  `json:"status" example:"sent"`
+	Error   string               `json:"error,omitempty"`
+}
+
+// SupportService is the tooling of customer service: it shows a customer's
+// orders as they see them, grants goodwill store credit, sends order
+// notifications again and corrects shipping addresses before orders ship.
+// Everything it does, views included, is recorded in the audit log.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/support_service_mock.go -package=mocks
+type SupportService interface {
+	// CustomerOrders returns a page of the user's orders, newest first, as
+	// their order list shows them.
+	CustomerOrders(ctx context.Context, userID uint64, offset, limit int) (*OrderSummaryListResp, error)
+	GetOrder(ctx context.Context, orderID uint64) (*CustomerOrderResp, error)
+	GrantStoreCredit(ctx context.Context, req *StoreCreditReq) (*StoreCreditResp, error)
+	// StoreCredits returns the user's balances and a page of their ledger,
+	// most recent first.
+	StoreCredits(ctx context.Context, userID uint64, offset, limit int) (*StoreCreditAccountResp, error)
+	// ResendNotification delivers a notification of the order again, right
+	// away, over each channel kind is sent over. A channel that fails is
+	// reported in its result rather than as an error.
+	ResendNotification(ctx context.Context, orderID uint64, kind notification.Kind) ([]ResendResult, error)
+	// UpdateShippingAddress replaces the shipping address of an order that
+	// is still to ship and has not shipped in part, normalized; see
+	// AddressService.
+	UpdateShippingAddress(ctx context.Context, orderID uint64, address model.Address) (*CustomerOrderResp, error)
+}
+
+type supportService struct {
+	orderRepo     repository.OrderRepository
+	shipmentRepo  repository.ShipmentRepository
+	creditRepo    repository.StoreCreditRepository
+	userRepo      repository.UserRepository
+	txManager     database.TransactionManager
+	history       OrderHistoryService
+	notifications notification.Service
+	routes        notification.Routes
+	addresses     AddressService // nil accepts shipping addresses as given
+}
+
+// NewSupportService creates a new SupportService. Notifications are resent
+// through notifications over the channels of routes. Shipping addresses are
+// validated and normalized with addresses, which may be nil.
+func NewSupportService(orderRepo repository.OrderRepository, shipmentRepo repository.ShipmentRepository, creditRepo repository.StoreCreditRepository, userRepo repository.UserRepository,
+	txManager database.TransactionManager, history OrderHistoryService, notifications notification.Service, routes notification.Routes, addresses AddressService) SupportService {
+	return &supportService{
+		orderRepo:     orderRepo,
+		shipmentRepo:  shipmentRepo,
+		creditRepo:    creditRepo,
+		userRepo:      userRepo,
+		txManager:     txManager,
+		history:       history,
+		notifications: notifications,
+		routes:        routes,
+		addresses:     addresses,
+	}
+}
+
+func (s *supportService) CustomerOrders(ctx context.Context, userID uint64, offset, limit int) (*OrderSummaryListResp, error) {
+	resp, err := s.history.List(ctx, userID, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	RecordAudit(ctx, AuditEntry{Action: "customer.orders.view", Resource: "user", ResourceID: strconv.FormatUint(userID, 10)})
+	return resp, nil
+}
+
+func (s *supportService) GetOrder(ctx context.Context, orderID uint64) (*CustomerOrderResp, error) {
+	resp, err := s.customerOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	RecordAudit(ctx, AuditEntry{Action: "order.view", Resource: "order", ResourceID: strconv.FormatUint(orderID, 10)})
+	return resp, nil
+}
+
+// customerOrder loads an order with its shipments.
+func (s *supportService) customerOrder(ctx context.Context, orderID uint64) (*CustomerOrderResp, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, repository.ErrOrderNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	shipments, err := s.shipmentRepo.ListByOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &CustomerOrderResp{
+		ID:              order.ID,
+		UserID:          order.UserID,
+		OrderNumber:     order.OrderNumber,
+		Status:          order.Status,
+		Fulfillment:     order.Fulfillment,
+		TotalAmount:     money.New(order.TotalAmount, order.Currency),
+		Region:          order.Region,
+		ShippingAddress: order.ShippingAddress,
+		Items:           make([]CustomerOrderItem, len(order.Items)),
+		Shipments:       make([]ShipmentResp, len(shipments)),
+		CreatedAt:       order.CreatedAt,
+	}
+	for i, item := range order.Items {
+		resp.Items[i] = CustomerOrderItem{
+			ID:          item.ID,
+			SKUID:       item.SKUID,
+			Name:        item.SnapshotName,
+			Quantity:    item.Quantity,
+			Price:       money.New(item.Price, order.Currency),
+			Backordered: item.Backordered,
+			Options:     newOrderItemOptionResps(order.Currency, item.Options),
+		}
+		if item.BundleItemID != nil {
+			resp.Items[i].BundleItemID = *item.BundleItemID
+		}
+	}
+	for i := range shipments {
+		resp.Shipments[i] = newShipmentResp(&shipments[i])
+	}
+	return resp, nil
+}
+
+func (s *supportService) GrantStoreCredit(ctx context.Context, req *StoreCreditReq) (*StoreCreditResp, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if !req.Amount.IsPositive() || reason == "" {
+		return nil, fmt.Errorf("%w: a positive amount and a reason are required", ErrInvalidStoreCredit)
+	}
+	if err := req.Amount.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidStoreCredit, err)
+	}
+	if _, err := s.userRepo.GetByID(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	if req.OrderID != 0 {
+		order, err := s.orderRepo.GetByID(ctx, req.OrderID)
+		if errors.Is(err, repository.ErrOrderNotFound) || (err == nil && order.UserID != req.UserID) {
+			return nil, ErrOrderNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	credit := &model.StoreCredit{
+		UserID:    req.UserID,
+		Amount:    req.Amount.Amount(),
+		Currency:  req.Amount.Currency(),
+		Reason:    reason,
+		OrderID:   req.OrderID,
+		CreatedBy: req.CreatedBy,
+	}
+	if err := s.creditRepo.Create(ctx, credit); err != nil {
+		return nil, err
+	}
+	resp := newStoreCreditResp(credit)
+	RecordAudit(ctx, AuditEntry{
+		Action:     "store_credit.grant",
+		Resource:   "user",
+		ResourceID: strconv.FormatUint(req.UserID, 10),
+		After:      resp,
+	})
+	return &resp, nil
+}
+
+func (s *supportService) StoreCredits(ctx context.Context, userID uint64, offset, limit int) (*StoreCreditAccountResp, error) {
+	if limit <= 0 {
+		limit = DefaultStoreCreditPageSize
+	}
+	balances, err := s.creditRepo.Balances(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	credits, total, err := s.creditRepo.ListByUser(ctx, userID, max(offset, 0), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &StoreCreditAccountResp{
+		Balances: make([]money.Money, len(balances)),
+		Entries:  make([]StoreCreditResp, len(credits)),
+		Total:    total,
+	}
+	for i, balance := range balances {
+		resp.Balances[i] = money.New(balance.Amount, balance.Currency)
+	}
+	for i := range credits {
+		resp.Entries[i] = newStoreCreditResp(&credits[i])
+	}
+	return resp, nil
+}
+
+func (s *supportService) ResendNotification(ctx context.Context, orderID uint64, kind notification.Kind) ([]ResendResult, error) {
+	if !slices.Contains(ResendableKinds, kind) {
+		return nil, fmt.Errorf("%w: %q is not resent", ErrNotResendable, kind)
+	}
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, repository.ErrOrderNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var data any
+	switch kind {
+	case notification.KindOrderConfirmation:
+		confirmation := &notification.OrderConfirmationData{OrderNumber: order.OrderNumber, Total: order.TotalAmount}
+		for _, item := range customerItems(order.Items) {
+			confirmation.Items = append(confirmation.Items, notification.OrderLine{Name: item.SnapshotName, Quantity: item.Quantity, Price: item.Price})
+		}
+		data = confirmation
+	case notification.KindOrderFailed:
+		if order.Status != model.OrderStatusFailed {
+			return nil, fmt.Errorf("%w: order is %s", ErrNotResendable, order.Status)
+		}
+		data = &notification.OrderFailedData{OrderNumber: order.OrderNumber}
+	}
+	raw, err := notification.EncodeData(kind, data)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ResendResult, 0, len(s.routes[kind]))
+	for _, channel := range s.routes[kind] {
+		d := &notification.Delivery{ID: uuid.NewString(), UserID: order.UserID, Kind: kind, Channel: channel, Data: raw}
+		status, err := s.notifications.Deliver(ctx, d)
+		// Recorded like any delivery, so the resend shows among the user's deliveries.
+		if trackErr := s.notifications.Track(ctx, d, status, err); trackErr != nil && err == nil {
+			err = trackErr
+		}
+		result := ResendResult{Channel: channel, Status: status}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	RecordAudit(ctx, AuditEntry{
+		Action:     "notification.resend",
+		Resource:   "order",
+		ResourceID: strconv.FormatUint(orderID, 10),
+		After:      map[string]any{"kind": kind, "results": results},
+	})
+	return results, nil
+}
+
+// UpdateShippingAddress holds the order's row lock, as Ship does, so that
+// the address cannot change while the order ships.
+func (s *supportService) UpdateShippingAddress(ctx context.Context, orderID uint64, address model.Address) (*CustomerOrderResp, error) {
+	var before model.Address
+	err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		order, err := s.orderRepo.GetByIDForUpdate(txCtx, orderID)
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			return ErrOrderNotFound
+		}
+		if err != nil {
+			return err
+		}
+		switch order.Status {
+		case model.OrderStatusPending, model.OrderStatusAuthorized, model.OrderStatusPaid, model.OrderStatusBackordered:
+		default:
+			return ErrOrderNotShippable
+		}
+		shipments, err := s.shipmentRepo.ListByOrder(txCtx, orderID)
+		if err != nil {
+			return err
+		}
+		if len(shipments) > 0 {
+			return ErrOrderShipped
+		}
+		if s.addresses != nil {
+			validated, err := s.addresses.Validate(txCtx, order.Region, address)
+			if err != nil {
+				return err
+			}
+			address = validated.Address
+		}
+		before = order.ShippingAddress
+		return s.orderRepo.UpdateShippingAddress(txCtx, orderID, address)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	RecordAudit(ctx, AuditEntry{
+		Action:     "order.shipping_address.update",
+		Resource:   "order",
+		ResourceID: strconv.FormatUint(orderID, 10),
+		Before:     before,
+		After:      address,
+	})
+	return s.customerOrder(ctx, orderID)
+}
+
+func newStoreCreditResp(credit *model.StoreCredit) StoreCreditResp {
+	return StoreCreditResp{
+		ID:        credit.ID,
+		Amount:    money.New(credit.Amount, credit.Currency),
+		Reason:    credit.Reason,
+		OrderID:   credit.OrderID,
+		CreatedBy: credit.CreatedBy,
+		CreatedAt: credit.CreatedAt,
+	}
+}
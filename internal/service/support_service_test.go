@@ -0,0 +1,225 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSupportService_GrantStoreCredit(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       service.StoreCreditReq
+		mockSetup func(orderRepo *mocks.MockOrderRepository, creditRepo *mocks.MockStoreCreditRepository, userRepo *mocks.MockUserRepository)
+		wantErrIs error
+	}{
+		{
+			name: "ForAnOrder",
+			req:  service.StoreCreditReq{UserID: 7, Amount: money.New(decimal.NewFromInt(10), "USD"), Reason: " late delivery ", OrderID: 42, CreatedBy: 1},
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, creditRepo *mocks.MockStoreCreditRepository, userRepo *mocks.MockUserRepository) {
+				userRepo.EXPECT().GetByID(gomock.Any(), uint64(7)).Return(&model.User{}, nil)
+				orderRepo.EXPECT().GetByID(gomock.Any(), uint64(42)).Return(&model.Order{UserID: 7}, nil)
+				creditRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, credit *model.StoreCredit) error {
+					assert.True(t, credit.Amount.Equal(decimal.NewFromInt(10)))
+					assert.Equal(t, "USD", credit.Currency)
+					assert.Equal(t, "late delivery", credit.Reason, "trimmed")
+					assert.Equal(t, uint64(42), credit.OrderID)
+					assert.Equal(t, uint64(1), credit.CreatedBy)
+					return nil
+				})
+			},
+		},
+		{
+			name: "OrderOfAnotherCustomer",
+			req:  service.StoreCreditReq{UserID: 7, Amount: money.New(decimal.NewFromInt(10), "USD"), Reason: "late delivery", OrderID: 42},
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, _ *mocks.MockStoreCreditRepository, userRepo *mocks.MockUserRepository) {
+				userRepo.EXPECT().GetByID(gomock.Any(), uint64(7)).Return(&model.User{}, nil)
+				orderRepo.EXPECT().GetByID(gomock.Any(), uint64(42)).Return(&model.Order{UserID: 8}, nil)
+			},
+			wantErrIs: service.ErrOrderNotFound,
+		},
+		{
+			name: "UnknownUser",
+			req:  service.StoreCreditReq{UserID: 7, Amount: money.New(decimal.NewFromInt(10), "USD"), Reason: "late delivery"},
+			mockSetup: func(_ *mocks.MockOrderRepository, _ *mocks.MockStoreCreditRepository, userRepo *mocks.MockUserRepository) {
+				userRepo.EXPECT().GetByID(gomock.Any(), uint64(7)).Return(nil, repository.ErrUserNotFound)
+			},
+			wantErrIs: service.ErrUserNotFound,
+		},
+		{name: "Negative", req: service.StoreCreditReq{UserID: 7, Amount: money.New(decimal.NewFromInt(-10), "USD"), Reason: "x"}, wantErrIs: service.ErrInvalidStoreCredit},
+		{name: "NoReason", req: service.StoreCreditReq{UserID: 7, Amount: money.New(decimal.NewFromInt(10), "USD"), Reason: "  "}, wantErrIs: service.ErrInvalidStoreCredit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			creditRepo := mocks.NewMockStoreCreditRepository(ctrl)
+			userRepo := mocks.NewMockUserRepository(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(orderRepo, creditRepo, userRepo)
+			}
+
+			svc := service.NewSupportService(orderRepo, nil, creditRepo, userRepo, nil, nil, nil, nil, nil)
+			ctx, trail := service.WithAuditTrail(context.Background())
+			resp, err := svc.GrantStoreCredit(ctx, &tt.req)
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				assert.Empty(t, trail.Entries())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "10.00", resp.Amount.Amount().StringFixed(2))
+			require.Len(t, trail.Entries(), 1)
+			assert.Equal(t, "store_credit.grant", trail.Entries()[0].Action)
+		})
+	}
+}
+
+func TestSupportService_ResendNotification(t *testing.T) {
+	order := func(status string) *model.Order {
+		return &model.Order{Base: model.Base{ID: 42}, UserID: 7, OrderNumber: "ORD42", Status: status, TotalAmount: decimal.NewFromInt(20),
+			Items: []model.OrderItem{{SnapshotName: "Mug", Quantity: 2, Price: decimal.NewFromInt(10)}}}
+	}
+	routes := notification.Routes{
+		notification.KindOrderConfirmation: {notification.ChannelEmail, notification.ChannelPush},
+		notification.KindOrderFailed:       {notification.ChannelEmail},
+	}
+
+	tests := []struct {
+		name        string
+		kind        notification.Kind
+		status      string
+		mockSetup   func(notifications *mocks.MockService)
+		wantResults []service.ResendResult
+		wantErrIs   error
+	}{
+		{
+			name:   "Confirmation",
+			kind:   notification.KindOrderConfirmation,
+			status: model.OrderStatusPaid,
+			mockSetup: func(notifications *mocks.MockService) {
+				notifications.EXPECT().Deliver(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, d *notification.Delivery) (notification.Result, error) {
+					assert.Equal(t, uint64(7), d.UserID)
+					assert.Contains(t, string(d.Data), `"order_number":"ORD42"`)
+					if d.Channel == notification.ChannelPush {
+						return notification.ResultNoRecipient, nil
+					}
+					return notification.ResultSent, nil
+				}).Times(2)
+				notifications.EXPECT().Track(gomock.Any(), gomock.Any(), gomock.Any(), nil).Return(nil).Times(2)
+			},
+			wantResults: []service.ResendResult{
+				{Channel: notification.ChannelEmail, Status: notification.ResultSent},
+				{Channel: notification.ChannelPush, Status: notification.ResultNoRecipient},
+			},
+		},
+		{
+			name:   "ChannelFails",
+			kind:   notification.KindOrderFailed,
+			status: model.OrderStatusFailed,
+			mockSetup: func(notifications *mocks.MockService) {
+				notifications.EXPECT().Deliver(gomock.Any(), gomock.Any()).Return(notification.ResultFailed, errors.New("smtp down"))
+				notifications.EXPECT().Track(gomock.Any(), gomock.Any(), notification.ResultFailed, gomock.Any()).Return(nil)
+			},
+			wantResults: []service.ResendResult{{Channel: notification.ChannelEmail, Status: notification.ResultFailed, Error: "smtp down"}},
+		},
+		{name: "OrderDidNotFail", kind: notification.KindOrderFailed, status: model.OrderStatusPaid, wantErrIs: service.ErrNotResendable},
+		{name: "NotResendable", kind: notification.KindPasswordReset, wantErrIs: service.ErrNotResendable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			notifications := mocks.NewMockService(ctrl)
+			if tt.status != "" {
+				orderRepo.EXPECT().GetByID(gomock.Any(), uint64(42)).Return(order(tt.status), nil)
+			}
+			if tt.mockSetup != nil {
+				tt.mockSetup(notifications)
+			}
+
+			svc := service.NewSupportService(orderRepo, nil, nil, nil, nil, nil, notifications, routes, nil)
+			ctx, trail := service.WithAuditTrail(context.Background())
+			results, err := svc.ResendNotification(ctx, 42, tt.kind)
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				assert.Empty(t, trail.Entries())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantResults, results)
+			require.Len(t, trail.Entries(), 1)
+			assert.Equal(t, "notification.resend", trail.Entries()[0].Action)
+		})
+	}
+}
+
+func TestSupportService_UpdateShippingAddress(t *testing.T) {
+	address := model.Address{Name: "Erika Mustermann", Line1: "Heidestraße 17", City: "Berlin", PostalCode: "10557"}
+
+	tests := []struct {
+		name      string
+		status    string
+		shipments []model.Shipment
+		wantErrIs error
+	}{
+		{name: "Paid", status: model.OrderStatusPaid},
+		{name: "Backordered", status: model.OrderStatusBackordered},
+		{name: "PartlyShipped", status: model.OrderStatusPaid, shipments: []model.Shipment{{OrderID: 42}}, wantErrIs: service.ErrOrderShipped},
+		{name: "Completed", status: model.OrderStatusCompleted, wantErrIs: service.ErrOrderNotShippable},
+		{name: "Cancelled", status: model.OrderStatusCancelled, wantErrIs: service.ErrOrderNotShippable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			shipmentRepo := mocks.NewMockShipmentRepository(ctrl)
+			txManager := mocks.NewMockTransactionManager(ctrl)
+			txManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			})
+
+			order := &model.Order{Base: model.Base{ID: 42}, Status: tt.status, Currency: "USD", ShippingAddress: model.Address{Name: "Erika", Line1: "Old street 1"}}
+			orderRepo.EXPECT().GetByIDForUpdate(gomock.Any(), uint64(42)).Return(order, nil)
+			if tt.status == model.OrderStatusPaid || tt.status == model.OrderStatusBackordered {
+				shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(42)).Return(tt.shipments, nil)
+			}
+			if tt.wantErrIs == nil {
+				orderRepo.EXPECT().UpdateShippingAddress(gomock.Any(), uint64(42), address).Return(nil)
+				updated := *order
+				updated.ShippingAddress = address
+				orderRepo.EXPECT().GetByID(gomock.Any(), uint64(42)).Return(&updated, nil)
+				shipmentRepo.EXPECT().ListByOrder(gomock.Any(), uint64(42)).Return(nil, nil)
+			}
+
+			svc := service.NewSupportService(orderRepo, shipmentRepo, nil, nil, txManager, nil, nil, nil, nil)
+			ctx, trail := service.WithAuditTrail(context.Background())
+			resp, err := svc.UpdateShippingAddress(ctx, 42, address)
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				assert.Empty(t, trail.Entries())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, address, resp.ShippingAddress)
+			require.Len(t, trail.Entries(), 1)
+			entry := trail.Entries()[0]
+			assert.Equal(t, "order.shipping_address.update", entry.Action)
+			assert.Equal(t, order.ShippingAddress, entry.Before)
+		})
+	}
+}
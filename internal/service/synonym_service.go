@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+)
+
+// Synonym set limits.
+const (
+	// MaxSynonymTerms is the most words one synonym set holds.
+	MaxSynonymTerms = 20
+	// MaxSynonymTermLength is the longest word, in bytes, of a synonym set.
+	MaxSynonymTermLength = 50
+)
+
+var (
+	// ErrInvalidSynonyms means a synonym set is malformed.
+	ErrInvalidSynonyms = errors.New("invalid synonyms")
+	// ErrSynonymNotFound is returned when a synonym set does not exist.
+	ErrSynonymNotFound = repository.ErrSynonymNotFound
+)
+
+// SynonymResp is a synonym set.
+type SynonymResp struct {
+	ID    uint64   `json:"id,string"`
+	Terms []string `json:"terms" example:"sneakers,trainers"`
+}
+
+// SynonymService manages the synonym sets of the store's search. Changes
+// reach search suggestions when cmd/worker next rebuilds their index.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/synonym_service_mock.go -package=mocks
+type SynonymService interface {
+	List(ctx context.Context) ([]SynonymResp, error)
+	// Create adds a set of words meaning the same thing. Terms are
+	// lowercased and deduplicated.
+	Create(ctx context.Context, terms []string) (*SynonymResp, error)
+	// Update replaces the words of a synonym set.
+	Update(ctx context.Context, id uint64, terms []string) (*SynonymResp, error)
+	Delete(ctx context.Context, id uint64) error
+}
+
+type synonymService struct {
+	repo repository.SynonymRepository
+}
+
+// NewSynonymService creates a new SynonymService instance.
+func NewSynonymService(repo repository.SynonymRepository) SynonymService {
+	return &synonymService{repo: repo}
+}
+
+func (s *synonymService) List(ctx context.Context) ([]SynonymResp, error) {
+	synonyms, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]SynonymResp, len(synonyms))
+	for i, synonym := range synonyms {
+		resp[i] = SynonymResp{ID: synonym.ID, Terms: synonym.Terms}
+	}
+	return resp, nil
+}
+
+func (s *synonymService) Create(ctx context.Context, terms []string) (*SynonymResp, error) {
+	terms, err := normalizeSynonymTerms(terms)
+	if err != nil {
+		return nil, err
+	}
+	synonym := &model.SearchSynonym{Terms: terms}
+	if err := s.repo.Create(ctx, synonym); err != nil {
+		return nil, err
+	}
+
+	resp := &SynonymResp{ID: synonym.ID, Terms: synonym.Terms}
+	RecordAudit(ctx, AuditEntry{Action: "search_synonym.create", Resource: "search_synonym", ResourceID: strconv.FormatUint(synonym.ID, 10), After: resp})
+	return resp, nil
+}
+
+func (s *synonymService) Update(ctx context.Context, id uint64, terms []string) (*SynonymResp, error) {
+	terms, err := normalizeSynonymTerms(terms)
+	if err != nil {
+		return nil, err
+	}
+	synonym, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := SynonymResp{ID: synonym.ID, Terms: synonym.Terms}
+	synonym.Terms = terms
+	if err := s.repo.UpdateTerms(ctx, synonym); err != nil {
+		return nil, err
+	}
+
+	resp := &SynonymResp{ID: synonym.ID, Terms: synonym.Terms}
+	RecordAudit(ctx, AuditEntry{Action: "search_synonym.update", Resource: "search_synonym", ResourceID: strconv.FormatUint(id, 10), Before: before, After: resp})
+	return resp, nil
+}
+
+func (s *synonymService) Delete(ctx context.Context, id uint64) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	RecordAudit(ctx, AuditEntry{Action: "search_synonym.delete", Resource: "search_synonym", ResourceID: strconv.FormatUint(id, 10)})
+	return nil
+}
+
+// normalizeSynonymTerms lowercases terms and drops repeats. A set needs at
+// least two different single words.
+func normalizeSynonymTerms(terms []string) ([]string, error) {
+	normalized := make([]string, 0, len(terms))
+	for _, term := range terms {
+		term = strings.ToLower(strings.TrimSpace(term))
+		switch {
+		case term == "":
+			return nil, fmt.Errorf("%w: terms cannot be blank", ErrInvalidSynonyms)
+		case len(term) > MaxSynonymTermLength:
+			return nil, fmt.Errorf("%w: %q is longer than %d bytes", ErrInvalidSynonyms, term, MaxSynonymTermLength)
+		case strings.IndexFunc(term, unicode.IsSpace) >= 0:
+			return nil, fmt.Errorf("%w: %q is not a single word", ErrInvalidSynonyms, term)
+		}
+		if !slices.Contains(normalized, term) {
+			normalized = append(normalized, term)
+		}
+	}
+	if len(normalized) < 2 || len(normalized) > MaxSynonymTerms {
+		return nil, fmt.Errorf("%w: give 2 to %d different words", ErrInvalidSynonyms, MaxSynonymTerms)
+	}
+	return normalized, nil
+}
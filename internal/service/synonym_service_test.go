@@ -0,0 +1,95 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSynonymService_Create(t *testing.T) {
+	tests := []struct {
+		name      string
+		terms     []string
+		wantTerms []string
+		wantErr   error
+	}{
+		{name: "Normalized", terms: []string{" Sneakers", "trainers", "SNEAKERS", "kicks"}, wantTerms: []string{"sneakers", "trainers", "kicks"}},
+		{name: "OneWord", terms: []string{"mug", "Mug"}, wantErr: service.ErrInvalidSynonyms},
+		{name: "Blank", terms: []string{"mug", " "}, wantErr: service.ErrInvalidSynonyms},
+		{name: "Phrase", terms: []string{"tee", "t shirt"}, wantErr: service.ErrInvalidSynonyms},
+		{name: "TooLong", terms: []string{"mug", strings.Repeat("a", service.MaxSynonymTermLength+1)}, wantErr: service.ErrInvalidSynonyms},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockSynonymRepository(ctrl)
+			if tt.wantErr == nil {
+				repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, synonym *model.SearchSynonym) error {
+					assert.Equal(t, tt.wantTerms, synonym.Terms)
+					synonym.ID = 4
+					return nil
+				})
+			}
+			ctx, trail := service.WithAuditTrail(context.Background())
+
+			resp, err := service.NewSynonymService(repo).Create(ctx, tt.terms)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, trail.Entries())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &service.SynonymResp{ID: 4, Terms: tt.wantTerms}, resp)
+			require.Len(t, trail.Entries(), 1)
+			assert.Equal(t, "search_synonym.create", trail.Entries()[0].Action)
+			assert.Equal(t, "4", trail.Entries()[0].ResourceID)
+		})
+	}
+}
+
+func TestSynonymService_Update(t *testing.T) {
+	t.Run("Replaced", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockSynonymRepository(ctrl)
+		repo.EXPECT().GetByID(gomock.Any(), uint64(4)).Return(&model.SearchSynonym{Base: model.Base{ID: 4}, Terms: []string{"mug", "cup"}}, nil)
+		repo.EXPECT().UpdateTerms(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, synonym *model.SearchSynonym) error {
+			assert.Equal(t, []string{"mug", "tumbler"}, synonym.Terms)
+			return nil
+		})
+		ctx, trail := service.WithAuditTrail(context.Background())
+
+		resp, err := service.NewSynonymService(repo).Update(ctx, 4, []string{"Mug", "Tumbler"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"mug", "tumbler"}, resp.Terms)
+		require.Len(t, trail.Entries(), 1)
+		assert.Equal(t, service.SynonymResp{ID: 4, Terms: []string{"mug", "cup"}}, trail.Entries()[0].Before)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockSynonymRepository(ctrl)
+		repo.EXPECT().GetByID(gomock.Any(), uint64(4)).Return(nil, service.ErrSynonymNotFound)
+
+		_, err := service.NewSynonymService(repo).Update(context.Background(), 4, []string{"mug", "cup"})
+		assert.ErrorIs(t, err, service.ErrSynonymNotFound)
+	})
+}
+
+func TestSynonymService_Delete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockSynonymRepository(ctrl)
+	repo.EXPECT().Delete(gomock.Any(), uint64(4)).Return(service.ErrSynonymNotFound)
+	ctx, trail := service.WithAuditTrail(context.Background())
+
+	err := service.NewSynonymService(repo).Delete(ctx, 4)
+	assert.ErrorIs(t, err, service.ErrSynonymNotFound)
+	assert.Empty(t, trail.Entries())
+}
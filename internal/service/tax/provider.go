@@ -0,0 +1,116 @@
+package tax
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultProviderTimeout bounds one call to the tax provider when
+// tax.provider.timeout is zero. Checkout waits for it.
+const DefaultProviderTimeout = 5 * time.Second
+
+// maxProviderResponse bounds how much of a response is read.
+const maxProviderResponse = 64 << 10
+
+// HTTPProvider asks an external tax service for the lines of each order. It
+// POSTs a providerRequest as JSON and expects a providerResponse back; a 422
+// answer means the service does not tax the region.
+type HTTPProvider struct {
+	client *http.Client
+	url    string
+	apiKey string
+}
+
+type providerRequest struct {
+	Region   string         `json:"region"`
+	Currency string         `json:"currency"`
+	Items    []providerItem `json:"items"`
+}
+
+type providerItem struct {
+	SKUID    string          `json:"sku_id"`
+	Quantity int             `json:"quantity"`
+	Amount   decimal.Decimal `json:"amount"`
+}
+
+type providerResponse struct {
+	Lines []struct {
+		Name   string          `json:"name"`
+		Rate   decimal.Decimal `json:"rate"`
+		Amount decimal.Decimal `json:"amount"`
+	} `json:"lines"`
+}
+
+// NewHTTPProvider creates an HTTPProvider for the service in cfg.
+func NewHTTPProvider(cfg config.TaxProviderConfig) (*HTTPProvider, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("tax provider url is required")
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultProviderTimeout
+	}
+	return &HTTPProvider{
+		client: &http.Client{Timeout: timeout},
+		url:    cfg.URL,
+		apiKey: cfg.APIKey,
+	}, nil
+}
+
+// Calculate returns the lines the provider decided, as it rounded them.
+func (p *HTTPProvider) Calculate(ctx context.Context, req *Request) ([]Line, error) {
+	body := providerRequest{Region: req.Region, Currency: req.Currency, Items: make([]providerItem, 0, len(req.Items))}
+	for _, item := range req.Items {
+		body.Items = append(body.Items, providerItem{SKUID: strconv.FormatUint(item.SKUID, 10), Quantity: item.Quantity, Amount: item.Amount})
+	}
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tax request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build tax request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call tax provider: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnprocessableEntity:
+		return nil, fmt.Errorf("%w: %s", ErrRegionNotSupported, req.Region)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("tax provider returned status %d", resp.StatusCode)
+	}
+
+	var result providerResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxProviderResponse)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode tax provider response: %w", err)
+	}
+	lines := make([]Line, 0, len(result.Lines))
+	for _, line := range result.Lines {
+		if line.Name == "" || line.Amount.IsNegative() {
+			return nil, fmt.Errorf("tax provider returned invalid line %q of %s", line.Name, line.Amount)
+		}
+		lines = append(lines, Line{Name: line.Name, Rate: line.Rate, Amount: line.Amount})
+	}
+	return lines, nil
+}
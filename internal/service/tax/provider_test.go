@@ -0,0 +1,70 @@
+package tax
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPProvider_Calculate(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		want     string
+		wantErr  error
+		anyErr   bool
+	}{
+		{name: "Lines", status: http.StatusOK, response: `{"lines":[{"name":"VAT","rate":"0.19","amount":"3.80"}]}`, want: "3.8"},
+		{name: "RegionNotTaxed", status: http.StatusUnprocessableEntity, wantErr: ErrRegionNotSupported},
+		{name: "ProviderError", status: http.StatusBadGateway, anyErr: true},
+		{name: "NegativeAmount", status: http.StatusOK, response: `{"lines":[{"name":"VAT","rate":"0.19","amount":"-1"}]}`, anyErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+				var body providerRequest
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				assert.Equal(t, "DE", body.Region)
+				assert.Equal(t, "EUR", body.Currency)
+				assert.Equal(t, []providerItem{{SKUID: "1", Quantity: 1, Amount: body.Items[0].Amount}}, body.Items)
+				assert.Equal(t, "20", body.Items[0].Amount.String())
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			p, err := NewHTTPProvider(config.TaxProviderConfig{URL: server.URL, APIKey: "key"})
+			require.NoError(t, err)
+			req := newRequest("DE", "20")
+			req.Currency = "EUR"
+
+			lines, err := p.Calculate(context.Background(), req)
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.anyErr:
+				assert.Error(t, err)
+			default:
+				require.NoError(t, err)
+				require.Len(t, lines, 1)
+				assert.Equal(t, "VAT", lines[0].Name)
+				assert.Equal(t, tt.want, lines[0].Amount.String())
+			}
+		})
+	}
+}
+
+func TestNewHTTPProvider_RequiresURL(t *testing.T) {
+	_, err := NewHTTPProvider(config.TaxProviderConfig{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,77 @@
+package tax
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// AnyRegion in a rule matches regions no other rule covers.
+const AnyRegion = "*"
+
+// Rule is one tax of a region.
+type Rule struct {
+	Region string // Country, subdivision or AnyRegion
+	Name   string
+	Rate   decimal.Decimal
+}
+
+// ParseRule parses a rule written as "REGION:NAME:RATE", e.g. "DE:VAT:0.19"
+// or "US-CA:Sales tax:0.0725".
+func ParseRule(s string) (Rule, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return Rule{}, fmt.Errorf("tax rule %q must be REGION:NAME:RATE", s)
+	}
+	region, name := strings.ToUpper(strings.TrimSpace(parts[0])), strings.TrimSpace(parts[1])
+	if region == "" || name == "" {
+		return Rule{}, fmt.Errorf("tax rule %q needs a region and a name", s)
+	}
+	rate, err := decimal.NewFromString(strings.TrimSpace(parts[2]))
+	if err != nil || rate.IsNegative() || rate.GreaterThan(decimal.NewFromInt(1)) {
+		return Rule{}, fmt.Errorf("tax rule %q needs a rate between 0 and 1", s)
+	}
+	return Rule{Region: region, Name: name, Rate: rate}, nil
+}
+
+// RegionTable charges the taxes of the region an order ships to. A region with
+// several rules, such as a state and a county sales tax, gets one line each.
+type RegionTable struct {
+	rules map[string][]Rule
+}
+
+// NewRegionTable creates a RegionTable from rules.
+func NewRegionTable(rules []Rule) *RegionTable {
+	byRegion := make(map[string][]Rule)
+	for _, rule := range rules {
+		byRegion[rule.Region] = append(byRegion[rule.Region], rule)
+	}
+	return &RegionTable{rules: byRegion}
+}
+
+// Calculate applies the rules of the most specific match: the subdivision,
+// then its country, then AnyRegion.
+func (s *RegionTable) Calculate(_ context.Context, req *Request) ([]Line, error) {
+	if req.Region == "" {
+		return nil, ErrRegionRequired
+	}
+	rules, ok := s.rules[req.Region]
+	if !ok {
+		rules, ok = s.rules[country(req.Region)]
+	}
+	if !ok {
+		rules, ok = s.rules[AnyRegion]
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrRegionNotSupported, req.Region)
+	}
+
+	subtotal := req.Subtotal()
+	lines := make([]Line, 0, len(rules))
+	for _, rule := range rules {
+		lines = append(lines, newLine(rule.Name, rule.Rate, subtotal))
+	}
+	return lines, nil
+}
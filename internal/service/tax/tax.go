@@ -0,0 +1,107 @@
+// Package tax calculates the tax lines of an order. Each Strategy is one way
+// of deciding them: a flat rate, a table of rates per region, or an external
+// tax provider.
+package tax
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Strategy names accepted by tax.strategy.
+const (
+	StrategyNone     = "none"
+	StrategyFlat     = "flat"
+	StrategyRegion   = "region"
+	StrategyProvider = "provider"
+)
+
+// DefaultFlatName labels the tax line of the flat strategy when tax.flat.name is empty.
+const DefaultFlatName = "VAT"
+
+var (
+	// ErrRegionRequired means the strategy needs to know where the order ships.
+	ErrRegionRequired = errors.New("tax region required")
+	// ErrRegionNotSupported means no tax rule covers the region.
+	ErrRegionNotSupported = errors.New("no tax rules for region")
+)
+
+// Item is one line of the order being taxed.
+type Item struct {
+	SKUID    uint64
+	Quantity int
+	Amount   decimal.Decimal // Price times quantity, in Request.Currency
+}
+
+// Request describes the order being taxed.
+type Request struct {
+	Region   string // ISO 3166-1 alpha-2 country or ISO 3166-2 subdivision, e.g. "DE" or "US-CA"; may be empty
+	Currency string
+	Items    []Item
+}
+
+// Subtotal sums the amounts of the items.
+func (r *Request) Subtotal() decimal.Decimal {
+	subtotal := decimal.Zero
+	for _, item := range r.Items {
+		subtotal = subtotal.Add(item.Amount)
+	}
+	return subtotal
+}
+
+// Line is one tax charged on an order, e.g. VAT or a state sales tax.
+type Line struct {
+	Name   string
+	Rate   decimal.Decimal // Fraction of the taxed amount, e.g. 0.19
+	Amount decimal.Decimal // In Request.Currency
+}
+
+// Strategy decides the tax lines of an order. Implementations wrap
+// ErrRegionRequired or ErrRegionNotSupported when the region is the problem;
+// any other error means tax cannot be calculated right now.
+//
+//go:generate mockgen -source=$GOFILE -destination=../../mocks/tax_strategy_mock.go -package=mocks
+type Strategy interface {
+	Calculate(ctx context.Context, req *Request) ([]Line, error)
+}
+
+// None charges no tax, e.g. for prices that already include it.
+type None struct{}
+
+// Calculate returns no lines.
+func (None) Calculate(context.Context, *Request) ([]Line, error) {
+	return nil, nil
+}
+
+// Flat charges one rate on every order, wherever it ships.
+type Flat struct {
+	name string
+	rate decimal.Decimal
+}
+
+// NewFlat creates a Flat strategy charging rate, labelled name (DefaultFlatName if empty).
+func NewFlat(name string, rate decimal.Decimal) *Flat {
+	if name == "" {
+		name = DefaultFlatName
+	}
+	return &Flat{name: name, rate: rate}
+}
+
+// Calculate returns one line for the whole subtotal.
+func (s *Flat) Calculate(_ context.Context, req *Request) ([]Line, error) {
+	return []Line{newLine(s.name, s.rate, req.Subtotal())}, nil
+}
+
+// newLine taxes amount at rate, rounded to cents.
+func newLine(name string, rate, amount decimal.Decimal) Line {
+	return Line{Name: name, Rate: rate, Amount: amount.Mul(rate).Round(2)}
+}
+
+// country returns the country part of an ISO 3166-2 subdivision code.
+func country(region string) string {
+	code, _, _ := strings.Cut(region, "-")
+	return code
+}
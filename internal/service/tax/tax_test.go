@@ -0,0 +1,84 @@
+package tax
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRequest(region string, amounts ...string) *Request {
+	req := &Request{Region: region, Currency: "USD"}
+	for i, amount := range amounts {
+		req.Items = append(req.Items, Item{SKUID: uint64(i + 1), Quantity: 1, Amount: decimal.RequireFromString(amount)})
+	}
+	return req
+}
+
+func TestFlat_Calculate(t *testing.T) {
+	lines, err := NewFlat("", decimal.RequireFromString("0.2")).Calculate(context.Background(), newRequest("", "19.99", "5.01"))
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	assert.Equal(t, DefaultFlatName, lines[0].Name)
+	assert.Equal(t, "5", lines[0].Amount.String())
+}
+
+func TestRegionTable_Calculate(t *testing.T) {
+	var rules []Rule
+	for _, spec := range []string{"DE:VAT:0.19", "US-CA:State sales tax:0.0725", "US-CA:County sales tax:0.01", "US:Sales tax:0.05"} {
+		rule, err := ParseRule(spec)
+		require.NoError(t, err)
+		rules = append(rules, rule)
+	}
+	table := NewRegionTable(rules)
+
+	tests := []struct {
+		name    string
+		region  string
+		want    map[string]string
+		wantErr error
+	}{
+		{name: "Country", region: "DE", want: map[string]string{"VAT": "19"}},
+		{name: "Subdivision", region: "US-CA", want: map[string]string{"State sales tax": "7.25", "County sales tax": "1"}},
+		{name: "CountryOfSubdivision", region: "US-NY", want: map[string]string{"Sales tax": "5"}},
+		{name: "NotSupported", region: "FR", wantErr: ErrRegionNotSupported},
+		{name: "NoRegion", wantErr: ErrRegionRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := table.Calculate(context.Background(), newRequest(tt.region, "60", "40"))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			got := make(map[string]string, len(lines))
+			for _, line := range lines {
+				got[line.Name] = line.Amount.String()
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// AnyRegion catches the rest
+	lines, err := NewRegionTable(append(rules, Rule{Region: AnyRegion, Name: "VAT", Rate: decimal.RequireFromString("0.2")})).Calculate(context.Background(), newRequest("FR", "10"))
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "2", lines[0].Amount.String())
+}
+
+func TestParseRule(t *testing.T) {
+	rule, err := ParseRule("us-ca: Sales tax :0.0725")
+	require.NoError(t, err)
+	assert.Equal(t, "US-CA", rule.Region)
+	assert.Equal(t, "Sales tax", rule.Name)
+	assert.Equal(t, "0.0725", rule.Rate.String())
+
+	for _, spec := range []string{"DE:VAT", "DE::0.19", "DE:VAT:19%", "DE:VAT:1.5", "DE:VAT:-0.1"} {
+		_, err := ParseRule(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service/tax"
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrTaxRegionRequired means the tax strategy needs the region an order ships to.
+	ErrTaxRegionRequired = tax.ErrRegionRequired
+	// ErrTaxRegionNotSupported means the store does not tax, and so does not sell to, the region.
+	ErrTaxRegionNotSupported = tax.ErrRegionNotSupported
+	// ErrTaxUnavailable means tax could not be calculated, e.g. the tax provider is down.
+	ErrTaxUnavailable = apperr.New(apperr.CodeTaxUnavailable)
+)
+
+// TaxLine is one tax charged on an order.
+type TaxLine struct {
+	Name   string          `json:"name" example:"VAT"`
+	Rate   decimal.Decimal `json:"rate" swaggertype:"string" example:"0.19"`
+	Amount money.Money     `json:"amount"`
+}
+
+// TaxService adds tax to orders at checkout with the configured tax.Strategy.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/tax_service_mock.go -package=mocks
+type TaxService interface {
+	// Calculate returns the tax lines of items priced in currency and
+	// shipped to region, which may be empty if the strategy does not need it.
+	// Items are taxed on their price less their discount.
+	Calculate(ctx context.Context, region, currency string, items []model.OrderItem) ([]model.OrderTaxLine, error)
+}
+
+type taxService struct {
+	strategy tax.Strategy
+}
+
+// NewTaxService creates a new TaxService. A nil strategy charges no tax.
+func NewTaxService(strategy tax.Strategy) TaxService {
+	if strategy == nil {
+		strategy = tax.None{}
+	}
+	return &taxService{strategy: strategy}
+}
+
+func (s *taxService) Calculate(ctx context.Context, region, currency string, items []model.OrderItem) ([]model.OrderTaxLine, error) {
+	req := &tax.Request{Region: strings.ToUpper(region), Currency: currency, Items: make([]tax.Item, 0, len(items))}
+	for _, item := range items {
+		req.Items = append(req.Items, tax.Item{
+			SKUID:    item.SKUID,
+			Quantity: item.Quantity,
+			Amount:   item.Price.Mul(decimal.NewFromInt(int64(item.Quantity))).Sub(item.Discount),
+		})
+	}
+
+	lines, err := s.strategy.Calculate(ctx, req)
+	if errors.Is(err, tax.ErrRegionRequired) || errors.Is(err, tax.ErrRegionNotSupported) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTaxUnavailable, err)
+	}
+
+	taxLines := make([]model.OrderTaxLine, 0, len(lines))
+	for _, line := range lines {
+		taxLines = append(taxLines, model.OrderTaxLine{Name: line.Name, Rate: line.Rate, Amount: line.Amount.Round(2)})
+	}
+	return taxLines, nil
+}
@@ -0,0 +1,70 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/tax"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTaxService_Calculate(t *testing.T) {
+	items := []model.OrderItem{
+		{SKUID: 1, Price: decimal.RequireFromString("10.00"), Quantity: 2},
+		{SKUID: 2, Price: decimal.RequireFromString("5.50"), Quantity: 1},
+	}
+
+	tests := []struct {
+		name      string
+		lines     []tax.Line
+		err       error
+		wantLines []model.OrderTaxLine
+		wantErr   error
+	}{
+		{
+			name:  "Lines",
+			lines: []tax.Line{{Name: "VAT", Rate: decimal.RequireFromString("0.19"), Amount: decimal.RequireFromString("4.845")}},
+			wantLines: []model.OrderTaxLine{
+				{Name: "VAT", Rate: decimal.RequireFromString("0.19"), Amount: decimal.RequireFromString("4.85")},
+			},
+		},
+		{name: "RegionNotSupported", err: tax.ErrRegionNotSupported, wantErr: service.ErrTaxRegionNotSupported},
+		{name: "ProviderDown", err: errors.New("tax provider returned status 503"), wantErr: service.ErrTaxUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			strategy := mocks.NewMockStrategy(ctrl)
+			strategy.EXPECT().Calculate(gomock.Any(), &tax.Request{
+				Region:   "US-CA",
+				Currency: "USD",
+				Items: []tax.Item{
+					{SKUID: 1, Quantity: 2, Amount: decimal.RequireFromString("20.00")},
+					{SKUID: 2, Quantity: 1, Amount: decimal.RequireFromString("5.50")},
+				},
+			}).Return(tt.lines, tt.err)
+
+			lines, err := service.NewTaxService(strategy).Calculate(context.Background(), "us-ca", "USD", items)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLines, lines)
+		})
+	}
+}
+
+func TestTaxService_NoStrategy(t *testing.T) {
+	lines, err := service.NewTaxService(nil).Calculate(context.Background(), "", "USD", []model.OrderItem{{Price: decimal.NewFromInt(10), Quantity: 1}})
+	require.NoError(t, err)
+	assert.Empty(t, lines)
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is the locale of the catalog's own product names and
+// descriptions, used where config.I18nConfig leaves it empty.
+const DefaultLocale = "en"
+
+var (
+	// ErrUnsupportedLocale is returned for locales the store is not translated
+	// into, including the default locale, which needs no translation.
+	ErrUnsupportedLocale   = errors.New("unsupported locale")
+	ErrTranslationNotFound = repository.ErrTranslationNotFound
+)
+
+// TranslationOptions configures a TranslationService.
+type TranslationOptions struct {
+	DefaultLocale string   // BCP 47 tag of the catalog's own content; empty means DefaultLocale
+	Locales       []string // BCP 47 tags products may be translated into besides DefaultLocale
+}
+
+// TranslationPutReq is the content of a product in one locale.
+type TranslationPutReq struct {
+	Name        string
+	Description string
+}
+
+// TranslationResp is a product's name and description in one locale.
+type TranslationResp struct {
+	Locale      string    `json:"locale" example:"zh"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"` // Empty falls back to the product's own description
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TranslationService keeps translated product content and picks the locale a
+// client is served in.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/translation_service_mock.go -package=mocks
+type TranslationService interface {
+	DefaultLocale() string
+	// Negotiate picks the supported locale that best matches an
+	// Accept-Language header, or the default locale if none does.
+	Negotiate(acceptLanguage string) string
+	// Localize replaces the names and descriptions of products in place with
+	// their translations into locale. Products without one are left as is.
+	Localize(ctx context.Context, locale string, products []ProductResp) error
+	List(ctx context.Context, spuID uint64) ([]TranslationResp, error)
+	Put(ctx context.Context, spuID uint64, locale string, req *TranslationPutReq) (*TranslationResp, error)
+	Delete(ctx context.Context, spuID uint64, locale string) error
+}
+
+type translationService struct {
+	repo        repository.TranslationRepository
+	productRepo repository.ProductRepository
+	locales     []string // Supported locales, the default first
+	matcher     language.Matcher
+}
+
+// NewTranslationService creates a new TranslationService instance. Locales
+// that are not valid BCP 47 tags are ignored; config validation rejects them.
+func NewTranslationService(repo repository.TranslationRepository, productRepo repository.ProductRepository, opts TranslationOptions) TranslationService {
+	defaultLocale, err := canonicalLocale(opts.DefaultLocale)
+	if err != nil {
+		defaultLocale = DefaultLocale
+	}
+	locales := []string{defaultLocale}
+	for _, locale := range opts.Locales {
+		locale, err := canonicalLocale(locale)
+		if err == nil && !slices.Contains(locales, locale) {
+			locales = append(locales, locale)
+		}
+	}
+	tags := make([]language.Tag, len(locales))
+	for i, locale := range locales {
+		tags[i] = language.Make(locale)
+	}
+	return &translationService{
+		repo:        repo,
+		productRepo: productRepo,
+		locales:     locales,
+		matcher:     language.NewMatcher(tags),
+	}
+}
+
+// canonicalLocale formats a BCP 47 tag the way translations are stored, so
+// "zh-hant" and "zh-Hant" name the same locale.
+func canonicalLocale(locale string) (string, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedLocale, locale)
+	}
+	return tag.String(), nil
+}
+
+func (s *translationService) DefaultLocale() string {
+	return s.locales[0]
+}
+
+func (s *translationService) Negotiate(acceptLanguage string) string {
+	if acceptLanguage == "" || len(s.locales) == 1 {
+		return s.locales[0]
+	}
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return s.locales[0]
+	}
+	// The index is used rather than the matched tag, which may carry
+	// extensions describing the match
+	_, index, confidence := s.matcher.Match(tags...)
+	if confidence == language.No {
+		return s.locales[0]
+	}
+	return s.locales[index]
+}
+
+func (s *translationService) Localize(ctx context.Context, locale string, products []ProductResp) error {
+	if locale == s.locales[0] || len(products) == 0 {
+		return nil
+	}
+	ids := make([]uint64, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+	translations, err := s.repo.ListBySPUIDs(ctx, ids, locale)
+	if err != nil {
+		return fmt.Errorf("failed to get %s translations: %w", locale, err)
+	}
+	bySPU := make(map[uint64]model.SPUTranslation, len(translations))
+	for _, translation := range translations {
+		bySPU[translation.SPUID] = translation
+	}
+	for i := range products {
+		translation, ok := bySPU[products[i].ID]
+		if !ok {
+			continue
+		}
+		products[i].Name = translation.Name
+		if translation.Description != "" {
+			products[i].Description = translation.Description
+		}
+	}
+	return nil
+}
+
+func (s *translationService) List(ctx context.Context, spuID uint64) ([]TranslationResp, error) {
+	if err := s.checkProduct(ctx, spuID); err != nil {
+		return nil, err
+	}
+	translations, err := s.repo.ListBySPU(ctx, spuID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list translations: %w", err)
+	}
+	resps := make([]TranslationResp, len(translations))
+	for i, translation := range translations {
+		resps[i] = newTranslationResp(&translation)
+	}
+	return resps, nil
+}
+
+func (s *translationService) Put(ctx context.Context, spuID uint64, locale string, req *TranslationPutReq) (*TranslationResp, error) {
+	locale, err := s.translatedLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkProduct(ctx, spuID); err != nil {
+		return nil, err
+	}
+	translation := &model.SPUTranslation{
+		SPUID:       spuID,
+		Locale:      locale,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	if err := s.repo.Save(ctx, translation); err != nil {
+		return nil, fmt.Errorf("failed to save translation: %w", err)
+	}
+	resp := newTranslationResp(translation)
+	return &resp, nil
+}
+
+func (s *translationService) Delete(ctx context.Context, spuID uint64, locale string) error {
+	locale, err := s.translatedLocale(locale)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, spuID, locale); err != nil {
+		if errors.Is(err, repository.ErrTranslationNotFound) {
+			return ErrTranslationNotFound
+		}
+		return fmt.Errorf("failed to delete translation: %w", err)
+	}
+	return nil
+}
+
+// translatedLocale canonicalizes locale and checks products can be translated into it.
+func (s *translationService) translatedLocale(locale string) (string, error) {
+	canonical, err := canonicalLocale(locale)
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(s.locales[1:], canonical) {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedLocale, locale)
+	}
+	return canonical, nil
+}
+
+func (s *translationService) checkProduct(ctx context.Context, spuID uint64) error {
+	if _, err := s.productRepo.GetSPUByID(ctx, spuID); err != nil {
+		if errors.Is(err, repository.ErrSPUNotFound) {
+			return ErrProductNotFound
+		}
+		return fmt.Errorf("failed to get SPU by ID %d: %w", spuID, err)
+	}
+	return nil
+}
+
+func newTranslationResp(translation *model.SPUTranslation) TranslationResp {
+	return TranslationResp{
+		Locale:      translation.Locale,
+		Name:        translation.Name,
+		Description: translation.Description,
+		UpdatedAt:   translation.UpdatedAt,
+	}
+}
@@ -0,0 +1,138 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var translationOpts = service.TranslationOptions{Locales: []string{"zh", "pt-br", "de"}}
+
+func TestTranslationService_Negotiate(t *testing.T) {
+	translations := service.NewTranslationService(nil, nil, translationOpts)
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{acceptLanguage: "", want: "en"},
+		{acceptLanguage: "zh-CN,zh;q=0.9,en;q=0.8", want: "zh"},
+		{acceptLanguage: "fr-FR, de;q=0.5", want: "de"},
+		{acceptLanguage: "pt", want: "pt-BR"},
+		{acceptLanguage: "fr", want: "en"},
+		{acceptLanguage: "en-GB;q=0.8, zh;q=0.9", want: "zh"},
+		{acceptLanguage: "not a;;header", want: "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			assert.Equal(t, tt.want, translations.Negotiate(tt.acceptLanguage))
+		})
+	}
+}
+
+func TestTranslationService_Localize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockTranslationRepository(ctrl)
+	repo.EXPECT().ListBySPUIDs(gomock.Any(), []uint64{1, 2}, "zh").Return([]model.SPUTranslation{
+		{SPUID: 1, Locale: "zh", Name: "马克杯"}, // No description: the SPU's is kept
+	}, nil)
+	translations := service.NewTranslationService(repo, nil, translationOpts)
+
+	products := []service.ProductResp{
+		{ID: 1, Name: "Mug", Description: "Ceramic"},
+		{ID: 2, Name: "Plate", Description: "Porcelain"},
+	}
+	require.NoError(t, translations.Localize(context.Background(), "zh", products))
+	assert.Equal(t, service.ProductResp{ID: 1, Name: "马克杯", Description: "Ceramic"}, products[0])
+	assert.Equal(t, service.ProductResp{ID: 2, Name: "Plate", Description: "Porcelain"}, products[1])
+
+	// The default locale is the SPUs' own content
+	require.NoError(t, translations.Localize(context.Background(), "en", products))
+}
+
+func TestTranslationService_Put(t *testing.T) {
+	tests := []struct {
+		name      string
+		locale    string
+		mockSetup func(repo *mocks.MockTranslationRepository, productRepo *mocks.MockProductRepository)
+		wantErr   error
+	}{
+		{
+			name:   "Saved",
+			locale: "PT-br",
+			mockSetup: func(repo *mocks.MockTranslationRepository, productRepo *mocks.MockProductRepository) {
+				productRepo.EXPECT().GetSPUByID(gomock.Any(), uint64(7)).Return(&model.SPU{}, nil)
+				repo.EXPECT().Save(gomock.Any(), &model.SPUTranslation{SPUID: 7, Locale: "pt-BR", Name: "Caneca"}).Return(nil)
+			},
+		},
+		{name: "DefaultLocale", locale: "en", wantErr: service.ErrUnsupportedLocale},
+		{name: "UnsupportedLocale", locale: "fr", wantErr: service.ErrUnsupportedLocale},
+		{name: "InvalidLocale", locale: "not-a-locale!", wantErr: service.ErrUnsupportedLocale},
+		{
+			name:   "ProductNotFound",
+			locale: "zh",
+			mockSetup: func(_ *mocks.MockTranslationRepository, productRepo *mocks.MockProductRepository) {
+				productRepo.EXPECT().GetSPUByID(gomock.Any(), uint64(7)).Return(nil, repository.ErrSPUNotFound)
+			},
+			wantErr: service.ErrProductNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockTranslationRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(repo, productRepo)
+			}
+
+			resp, err := service.NewTranslationService(repo, productRepo, translationOpts).Put(context.Background(), 7, tt.locale, &service.TranslationPutReq{Name: "Caneca"})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "pt-BR", resp.Locale)
+		})
+	}
+}
+
+func TestTranslationService_Delete(t *testing.T) {
+	tests := []struct {
+		name      string
+		repoErr   error
+		wantErrIs error
+		wantErr   bool
+	}{
+		{name: "Deleted"},
+		{name: "NotFound", repoErr: repository.ErrTranslationNotFound, wantErrIs: service.ErrTranslationNotFound, wantErr: true},
+		{name: "RepoError", repoErr: errors.New("db down"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockTranslationRepository(ctrl)
+			repo.EXPECT().Delete(gomock.Any(), uint64(7), "zh").Return(tt.repoErr)
+
+			err := service.NewTranslationService(repo, nil, translationOpts).Delete(context.Background(), 7, "zh")
+			if !tt.wantErr {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+			}
+		})
+	}
+}
@@ -8,13 +8,24 @@ import (
 
 	"github.com/proyuen/go-mall/internal/model"
 	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/apperr"
+	"github.com/proyuen/go-mall/pkg/database"
 	"github.com/proyuen/go-mall/pkg/hasher"
+	"github.com/proyuen/go-mall/pkg/tenant"
 	"github.com/proyuen/go-mall/pkg/token"
 )
 
 var (
-	ErrUserExists         = errors.New("username already exists")
-	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUserExists          = apperr.New(apperr.CodeUserExists)
+	ErrInvalidCredentials  = apperr.New(apperr.CodeInvalidCredentials)
+	ErrInvalidRefreshToken = apperr.New(apperr.CodeInvalidRefreshToken)
+)
+
+// Token lifetimes used when UserServiceOptions leaves them zero.
+const (
+	DefaultAccessTokenTTL  = 24 * time.Hour
+	DefaultRefreshTokenTTL = 7 * 24 * time.Hour
+	DefaultRememberMeTTL   = 30 * 24 * time.Hour
 )
 
 // DTOs (Data Transfer Objects)
@@ -32,15 +43,26 @@ type UserRegisterResp struct {
 }
 
 type UserLoginReq struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	RememberMe bool   `json:"remember_me"` // Issues a longer-lived refresh token
 }
 
 type UserLoginResp struct {
-	UserID      uint64 `json:"user_id,string"` // Snowflake ID
-	AccessToken string `json:"access_token"`
-	ExpiresIn   int64  `json:"expires_in"` // Seconds
-	TokenType   string `json:"token_type"`
+	UserID           uint64 `json:"user_id,string"` // Snowflake ID
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"` // Seconds
+	TokenType        string `json:"token_type"`
+	RefreshToken     string `json:"refresh_token,omitempty"`      // Only issued on login
+	RefreshExpiresIn int64  `json:"refresh_expires_in,omitempty"` // Seconds
+}
+
+// UserServiceOptions configures a UserService. Zero lifetimes fall back to
+// the defaults above.
+type UserServiceOptions struct {
+	AccessTokenTTL  time.Duration // jwt.access_token_ttl
+	RefreshTokenTTL time.Duration // jwt.refresh_token_ttl
+	RememberMeTTL   time.Duration // jwt.remember_me_ttl; never shorter than RefreshTokenTTL
 }
 
 //go:generate mockgen -source=$GOFILE -destination=../mocks/user_service_mock.go -package=mocks
@@ -48,20 +70,51 @@ type UserLoginResp struct {
 type UserService interface {
 	Register(ctx context.Context, req *UserRegisterReq) (*UserRegisterResp, error)
 	Login(ctx context.Context, req *UserLoginReq) (*UserLoginResp, error)
+	// Refresh exchanges a refresh token issued by Login for a new access
+	// token. The refresh token stays valid until its session expires or is
+	// evicted.
+	Refresh(ctx context.Context, refreshToken string) (*UserLoginResp, error)
+	// Logout revokes the access token with payload until it expires and ends
+	// the session it belongs to, if any, along with its refresh token.
+	Logout(ctx context.Context, payload *token.Payload) error
 }
 
 type userService struct {
 	repo       repository.UserRepository
+	txManager  database.TransactionManager
 	hasher     hasher.PasswordHasher
 	tokenMaker token.Maker
+	events     EventPublisher
+	sessions   SessionStore
+	revoker    token.Revoker
+	opts       UserServiceOptions
 }
 
-// NewUserService creates a new UserService instance.
-func NewUserService(repo repository.UserRepository, hasher hasher.PasswordHasher, tokenMaker token.Maker) UserService {
+// NewUserService creates a new UserService instance. user.registered is
+// published through events in the transaction that creates the user; every
+// login opens a session in sessions, and logouts revoke tokens in revoker.
+func NewUserService(repo repository.UserRepository, txManager database.TransactionManager, hasher hasher.PasswordHasher, tokenMaker token.Maker, events EventPublisher, sessions SessionStore, revoker token.Revoker, opts UserServiceOptions) UserService {
+	if opts.AccessTokenTTL <= 0 {
+		opts.AccessTokenTTL = DefaultAccessTokenTTL
+	}
+	if opts.RefreshTokenTTL <= 0 {
+		opts.RefreshTokenTTL = DefaultRefreshTokenTTL
+	}
+	if opts.RememberMeTTL <= 0 {
+		opts.RememberMeTTL = DefaultRememberMeTTL
+	}
+	if opts.RememberMeTTL < opts.RefreshTokenTTL {
+		opts.RememberMeTTL = opts.RefreshTokenTTL
+	}
 	return &userService{
 		repo:       repo,
+		txManager:  txManager,
 		hasher:     hasher,
 		tokenMaker: tokenMaker,
+		events:     events,
+		sessions:   sessions,
+		revoker:    revoker,
+		opts:       opts,
 	}
 }
 
@@ -92,11 +145,21 @@ func (s *userService) Register(ctx context.Context, req *UserRegisterReq) (*User
 		Username:     req.Username,
 		Email:        req.Email,
 		PasswordHash: hashedPassword,
-		Role:         "user", // Explicitly set role
+		Role:         model.RoleUser, // Explicitly set role
 	}
 
-	if err := s.repo.Create(ctx, user); err != nil {
-		return nil, fmt.Errorf("failed to create user record: %w", err)
+	err = s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.repo.Create(txCtx, user); err != nil {
+			return fmt.Errorf("failed to create user record: %w", err)
+		}
+		data := UserEventData{UserID: user.ID, Username: user.Username, Email: user.Email}
+		if err := s.events.Publish(txCtx, EventUserRegistered, data); err != nil {
+			return fmt.Errorf("failed to publish user event: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// 4. Build Response
@@ -123,18 +186,80 @@ func (s *userService) Login(ctx context.Context, req *UserLoginReq) (*UserLoginR
 		return nil, ErrInvalidCredentials
 	}
 
-	// 3. Generate Token
-	duration := 24 * time.Hour
-	accessToken, _, err := s.tokenMaker.CreateToken(user.ID, user.Username, duration)
+	// 3. Open a session, identified by its refresh token
+	refreshTTL := s.opts.RefreshTokenTTL
+	if req.RememberMe {
+		refreshTTL = s.opts.RememberMeTTL
+	}
+	refreshToken, refreshPayload, err := s.tokenMaker.CreateRefreshToken(user.ID, user.StoreID, user.Username, refreshTTL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate access token: %w", err)
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	if err := s.sessions.Open(ctx, user.ID, refreshPayload.SessionID, refreshPayload.ExpiredAt); err != nil {
+		return nil, err
 	}
 
-	// 4. Build Response
+	// 4. Generate Tokens
+	resp, err := s.issueAccessToken(user, refreshPayload.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	resp.RefreshToken = refreshToken
+	resp.RefreshExpiresIn = int64(refreshTTL.Seconds())
+	return resp, nil
+}
+
+// Refresh issues a new access token for a valid refresh token.
+func (s *userService) Refresh(ctx context.Context, refreshToken string) (*UserLoginResp, error) {
+	payload, err := s.tokenMaker.VerifyRefreshToken(refreshToken)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if payload.StoreID != tenant.StoreID(ctx) {
+		return nil, ErrInvalidRefreshToken
+	}
+	// The session may have been evicted by a later login
+	active, err := s.sessions.Active(ctx, payload.UserID, payload.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// The account may have been deleted since the login
+	user, err := s.repo.GetByID(ctx, payload.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	return s.issueAccessToken(user, payload.SessionID)
+}
+
+// Logout revokes the token first: it stays rejected even if the session
+// cannot be ended, while the session alone would not cover tokens minted
+// outside a login.
+func (s *userService) Logout(ctx context.Context, payload *token.Payload) error {
+	if err := s.revoker.Revoke(ctx, payload.ID, payload.ExpiredAt); err != nil {
+		return err
+	}
+	if payload.SessionID == "" {
+		return nil
+	}
+	return s.sessions.End(ctx, payload.UserID, payload.SessionID)
+}
+
+func (s *userService) issueAccessToken(user *model.User, sessionID string) (*UserLoginResp, error) {
+	accessToken, _, err := s.tokenMaker.CreateSessionToken(user.ID, user.StoreID, user.Username, user.Role, sessionID, s.opts.AccessTokenTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
 	return &UserLoginResp{
 		UserID:      user.ID,
 		AccessToken: accessToken,
-		ExpiresIn:   int64(duration.Seconds()),
+		ExpiresIn:   int64(s.opts.AccessTokenTTL.Seconds()),
 		TokenType:   "Bearer",
 	}, nil
 }
@@ -6,10 +6,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/proyuen/go-mall/internal/mocks"
 	"github.com/proyuen/go-mall/internal/model"
 	"github.com/proyuen/go-mall/internal/repository"
 	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/token"
 	"github.com/proyuen/go-mall/pkg/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -140,13 +142,23 @@ func TestUserService_Register(t *testing.T) {
 			mockRepo := mocks.NewMockUserRepository(ctrl)
 			mockHasher := mocks.NewMockPasswordHasher(ctrl)
 			mockMaker := mocks.NewMockMaker(ctrl)
+			mockTxManager := mocks.NewMockTransactionManager(ctrl)
+			mockTxManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			}).AnyTimes()
+			mockEvents := mocks.NewMockEventPublisher(ctrl)
 			
-			userService := service.NewUserService(mockRepo, mockHasher, mockMaker)
+			userService := service.NewUserService(mockRepo, mockTxManager, mockHasher, mockMaker, mockEvents, nil, nil, service.UserServiceOptions{})
 			ctx := context.Background()
 
 			if tt.fields.mockSetup != nil {
 				tt.fields.mockSetup(mockRepo, mockHasher, mockMaker, tt.args.req)
 			}
+			if tt.wantResp {
+				// Published with the user, in the same transaction
+				want := service.UserEventData{UserID: 101, Username: tt.args.req.Username, Email: tt.args.req.Email}
+				mockEvents.EXPECT().Publish(gomock.Any(), service.EventUserRegistered, want).Return(nil)
+			}
 
 			resp, err := userService.Register(ctx, tt.args.req)
 			if tt.wantErr {
@@ -172,22 +184,25 @@ func TestUserService_Register(t *testing.T) {
 
 func TestUserService_Login(t *testing.T) {
 	hashedPassword := "mock_hashed_password"
+	opts := service.UserServiceOptions{AccessTokenTTL: 15 * time.Minute, RefreshTokenTTL: 24 * time.Hour, RememberMeTTL: 30 * 24 * time.Hour}
+	refreshPayload := &token.Payload{SessionID: "s1", ExpiredAt: time.Now().Add(24 * time.Hour)}
 	successUser := utils.RandomOwner()
 	notFoundUser := utils.RandomOwner()
 
 	type fields struct {
-		mockSetup func(mockRepo *mocks.MockUserRepository, mockHasher *mocks.MockPasswordHasher, mockMaker *mocks.MockMaker, req *service.UserLoginReq)
+		mockSetup func(mockRepo *mocks.MockUserRepository, mockHasher *mocks.MockPasswordHasher, mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore, req *service.UserLoginReq)
 	}
 	type args struct {
 		req *service.UserLoginReq
 	}
 	tests := []struct {
-		name     string
-		args     args
-		fields   fields
-		wantErr  bool
-		errStr   string
-		wantResp bool
+		name           string
+		args           args
+		fields         fields
+		wantErr        bool
+		errStr         string
+		wantResp       bool
+		wantRefreshTTL time.Duration
 	}{
 		{
 			name: "Success",
@@ -198,10 +213,11 @@ func TestUserService_Login(t *testing.T) {
 				},
 			},
 			fields: fields{
-				mockSetup: func(mockRepo *mocks.MockUserRepository, mockHasher *mocks.MockPasswordHasher, mockMaker *mocks.MockMaker, req *service.UserLoginReq) {
+				mockSetup: func(mockRepo *mocks.MockUserRepository, mockHasher *mocks.MockPasswordHasher, mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore, req *service.UserLoginReq) {
 					user := &model.User{
 						Username:     successUser,
 						PasswordHash: hashedPassword,
+						Role:         model.RoleUser,
 					}
 					user.ID = 101 // uint64
 					mockRepo.EXPECT().GetByUsername(gomock.Any(), req.Username).Return(user, nil)
@@ -210,11 +226,58 @@ func TestUserService_Login(t *testing.T) {
 					mockHasher.EXPECT().Check(req.Password, hashedPassword).Return(nil)
 
 					// Expect token generation
-					mockMaker.EXPECT().CreateToken(user.ID, user.Username, 24*time.Hour).Return("mock_access_token", nil, nil)
+					mockMaker.EXPECT().CreateRefreshToken(user.ID, user.StoreID, user.Username, 24*time.Hour).Return("mock_refresh_token", refreshPayload, nil)
+					mockSessions.EXPECT().Open(gomock.Any(), user.ID, "s1", refreshPayload.ExpiredAt).Return(nil)
+					mockMaker.EXPECT().CreateSessionToken(user.ID, user.StoreID, user.Username, user.Role, "s1", 15*time.Minute).Return("mock_access_token", nil, nil)
 				},
 			},
-			wantErr:  false,
-			wantResp: true,
+			wantErr:        false,
+			wantResp:       true,
+			wantRefreshTTL: 24 * time.Hour,
+		},
+		{
+			name: "RememberMe",
+			args: args{
+				req: &service.UserLoginReq{
+					Username:   successUser,
+					Password:   "password123",
+					RememberMe: true,
+				},
+			},
+			fields: fields{
+				mockSetup: func(mockRepo *mocks.MockUserRepository, mockHasher *mocks.MockPasswordHasher, mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore, req *service.UserLoginReq) {
+					user := &model.User{Username: successUser, PasswordHash: hashedPassword}
+					user.ID = 101
+					mockRepo.EXPECT().GetByUsername(gomock.Any(), req.Username).Return(user, nil)
+					mockHasher.EXPECT().Check(req.Password, hashedPassword).Return(nil)
+					mockMaker.EXPECT().CreateRefreshToken(user.ID, user.StoreID, user.Username, 30*24*time.Hour).Return("mock_refresh_token", refreshPayload, nil)
+					mockSessions.EXPECT().Open(gomock.Any(), user.ID, "s1", refreshPayload.ExpiredAt).Return(nil)
+					mockMaker.EXPECT().CreateSessionToken(user.ID, user.StoreID, user.Username, user.Role, "s1", 15*time.Minute).Return("mock_access_token", nil, nil)
+				},
+			},
+			wantResp:       true,
+			wantRefreshTTL: 30 * 24 * time.Hour,
+		},
+		{
+			name: "SessionLimitExceeded",
+			args: args{
+				req: &service.UserLoginReq{
+					Username: successUser,
+					Password: "password123",
+				},
+			},
+			fields: fields{
+				mockSetup: func(mockRepo *mocks.MockUserRepository, mockHasher *mocks.MockPasswordHasher, mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore, req *service.UserLoginReq) {
+					user := &model.User{Username: successUser, PasswordHash: hashedPassword}
+					user.ID = 101
+					mockRepo.EXPECT().GetByUsername(gomock.Any(), req.Username).Return(user, nil)
+					mockHasher.EXPECT().Check(req.Password, hashedPassword).Return(nil)
+					mockMaker.EXPECT().CreateRefreshToken(user.ID, user.StoreID, user.Username, 24*time.Hour).Return("mock_refresh_token", refreshPayload, nil)
+					mockSessions.EXPECT().Open(gomock.Any(), user.ID, "s1", refreshPayload.ExpiredAt).Return(service.ErrSessionLimitExceeded)
+				},
+			},
+			wantErr: true,
+			errStr:  "too many active sessions",
 		},
 		{
 			name: "InvalidPassword",
@@ -225,7 +288,7 @@ func TestUserService_Login(t *testing.T) {
 				},
 			},
 			fields: fields{
-				mockSetup: func(mockRepo *mocks.MockUserRepository, mockHasher *mocks.MockPasswordHasher, mockMaker *mocks.MockMaker, req *service.UserLoginReq) {
+				mockSetup: func(mockRepo *mocks.MockUserRepository, mockHasher *mocks.MockPasswordHasher, mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore, req *service.UserLoginReq) {
 					user := &model.User{
 						Username:     successUser,
 						PasswordHash: hashedPassword,
@@ -248,7 +311,7 @@ func TestUserService_Login(t *testing.T) {
 				},
 			},
 			fields: fields{
-				mockSetup: func(mockRepo *mocks.MockUserRepository, mockHasher *mocks.MockPasswordHasher, mockMaker *mocks.MockMaker, req *service.UserLoginReq) {
+				mockSetup: func(mockRepo *mocks.MockUserRepository, mockHasher *mocks.MockPasswordHasher, mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore, req *service.UserLoginReq) {
 					mockRepo.EXPECT().GetByUsername(gomock.Any(), req.Username).Return(nil, repository.ErrUserNotFound)
 				},
 			},
@@ -265,12 +328,13 @@ func TestUserService_Login(t *testing.T) {
 			mockRepo := mocks.NewMockUserRepository(ctrl)
 			mockHasher := mocks.NewMockPasswordHasher(ctrl)
 			mockMaker := mocks.NewMockMaker(ctrl)
+			mockSessions := mocks.NewMockSessionStore(ctrl)
 
-			userService := service.NewUserService(mockRepo, mockHasher, mockMaker)
+			userService := service.NewUserService(mockRepo, nil, mockHasher, mockMaker, nil, mockSessions, nil, opts)
 			ctx := context.Background()
 
 			if tt.fields.mockSetup != nil {
-				tt.fields.mockSetup(mockRepo, mockHasher, mockMaker, tt.args.req)
+				tt.fields.mockSetup(mockRepo, mockHasher, mockMaker, mockSessions, tt.args.req)
 			}
 
 			resp, err := userService.Login(ctx, tt.args.req)
@@ -286,6 +350,9 @@ func TestUserService_Login(t *testing.T) {
 			if tt.wantResp {
 				require.NotNil(t, resp)
 				assert.NotEmpty(t, resp.AccessToken)
+				assert.Equal(t, int64(15*60), resp.ExpiresIn)
+				assert.Equal(t, "mock_refresh_token", resp.RefreshToken)
+				assert.Equal(t, int64(tt.wantRefreshTTL.Seconds()), resp.RefreshExpiresIn)
 				// assert.Equal(t, uint64(101), resp.UserID)
 			} else {
 				require.Nil(t, resp)
@@ -293,3 +360,131 @@ func TestUserService_Login(t *testing.T) {
 		})
 	}
 }
+
+func TestUserService_Refresh(t *testing.T) {
+	user := &model.User{Username: "alice"}
+	user.ID = 101
+	session := &token.Payload{UserID: 101, Kind: token.KindRefresh, SessionID: "s1"}
+
+	tests := []struct {
+		name      string
+		mockSetup func(mockRepo *mocks.MockUserRepository, mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore)
+		wantErr   error
+	}{
+		{
+			name: "Success",
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore) {
+				mockMaker.EXPECT().VerifyRefreshToken("refresh").Return(session, nil)
+				mockSessions.EXPECT().Active(gomock.Any(), uint64(101), "s1").Return(true, nil)
+				mockRepo.EXPECT().GetByID(gomock.Any(), uint64(101)).Return(user, nil)
+				mockMaker.EXPECT().CreateSessionToken(user.ID, user.StoreID, user.Username, user.Role, "s1", service.DefaultAccessTokenTTL).Return("new_access_token", nil, nil)
+			},
+		},
+		{
+			name: "EvictedSession",
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore) {
+				mockMaker.EXPECT().VerifyRefreshToken("refresh").Return(session, nil)
+				mockSessions.EXPECT().Active(gomock.Any(), uint64(101), "s1").Return(false, nil)
+			},
+			wantErr: service.ErrInvalidRefreshToken,
+		},
+		{
+			name: "ExpiredToken",
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore) {
+				mockMaker.EXPECT().VerifyRefreshToken("refresh").Return(nil, token.ErrExpiredToken)
+			},
+			wantErr: service.ErrInvalidRefreshToken,
+		},
+		{
+			name: "OtherStore",
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore) {
+				mockMaker.EXPECT().VerifyRefreshToken("refresh").Return(&token.Payload{UserID: 101, StoreID: 3, Kind: token.KindRefresh, SessionID: "s1"}, nil)
+			},
+			wantErr: service.ErrInvalidRefreshToken,
+		},
+		{
+			name: "UserDeleted",
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockMaker *mocks.MockMaker, mockSessions *mocks.MockSessionStore) {
+				mockMaker.EXPECT().VerifyRefreshToken("refresh").Return(session, nil)
+				mockSessions.EXPECT().Active(gomock.Any(), uint64(101), "s1").Return(true, nil)
+				mockRepo.EXPECT().GetByID(gomock.Any(), uint64(101)).Return(nil, repository.ErrUserNotFound)
+			},
+			wantErr: service.ErrInvalidRefreshToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockRepo := mocks.NewMockUserRepository(ctrl)
+			mockMaker := mocks.NewMockMaker(ctrl)
+			mockSessions := mocks.NewMockSessionStore(ctrl)
+			tt.mockSetup(mockRepo, mockMaker, mockSessions)
+
+			userService := service.NewUserService(mockRepo, nil, nil, mockMaker, nil, mockSessions, nil, service.UserServiceOptions{})
+			resp, err := userService.Refresh(context.Background(), "refresh")
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "new_access_token", resp.AccessToken)
+			assert.Equal(t, int64(service.DefaultAccessTokenTTL.Seconds()), resp.ExpiresIn)
+			assert.Empty(t, resp.RefreshToken)
+		})
+	}
+}
+
+func TestUserService_Logout(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	sessionPayload := &token.Payload{ID: uuid.New(), UserID: 101, ExpiredAt: expiresAt, SessionID: "s1"}
+	plainPayload := &token.Payload{ID: uuid.New(), UserID: 101, ExpiredAt: expiresAt}
+
+	tests := []struct {
+		name      string
+		payload   *token.Payload
+		mockSetup func(mockRevoker *mocks.MockRevoker, mockSessions *mocks.MockSessionStore)
+		wantErr   bool
+	}{
+		{
+			name:    "EndsSession",
+			payload: sessionPayload,
+			mockSetup: func(mockRevoker *mocks.MockRevoker, mockSessions *mocks.MockSessionStore) {
+				mockRevoker.EXPECT().Revoke(gomock.Any(), sessionPayload.ID, expiresAt).Return(nil)
+				mockSessions.EXPECT().End(gomock.Any(), uint64(101), "s1").Return(nil)
+			},
+		},
+		{
+			name:    "TokenWithoutSession",
+			payload: plainPayload,
+			mockSetup: func(mockRevoker *mocks.MockRevoker, mockSessions *mocks.MockSessionStore) {
+				mockRevoker.EXPECT().Revoke(gomock.Any(), plainPayload.ID, expiresAt).Return(nil)
+			},
+		},
+		{
+			name:    "RevokerDown",
+			payload: sessionPayload,
+			mockSetup: func(mockRevoker *mocks.MockRevoker, mockSessions *mocks.MockSessionStore) {
+				mockRevoker.EXPECT().Revoke(gomock.Any(), sessionPayload.ID, expiresAt).Return(errors.New("redis down"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockRevoker := mocks.NewMockRevoker(ctrl)
+			mockSessions := mocks.NewMockSessionStore(ctrl)
+			tt.mockSetup(mockRevoker, mockSessions)
+
+			userService := service.NewUserService(nil, nil, nil, nil, nil, mockSessions, mockRevoker, service.UserServiceOptions{})
+			err := userService.Logout(context.Background(), tt.payload)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,571 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/pkg/money"
+)
+
+// Webhook event types integrators can subscribe to.
+const (
+	WebhookEventOrderCreated = "order.created"
+	WebhookEventOrderPaid    = "order.paid" // Emitted by the payment flow once an order is paid
+	WebhookEventStockLow     = "stock.low"
+	// WebhookEventSubscriptionPaymentFailed is emitted when a subscription
+	// renewal cannot be charged.
+	WebhookEventSubscriptionPaymentFailed = "subscription.payment_failed"
+)
+
+// WebhookEvents lists every event type, for validation and documentation.
+var WebhookEvents = []string{WebhookEventOrderCreated, WebhookEventOrderPaid, WebhookEventStockLow, WebhookEventSubscriptionPaymentFailed}
+
+// Headers sent with every webhook request.
+const (
+	WebhookSignatureHeader = "X-Mall-Signature" // See SignWebhook
+	WebhookEventHeader     = "X-Mall-Event"
+	WebhookDeliveryHeader  = "X-Mall-Delivery" // Delivery ID; stable across retries
+)
+
+// Webhook defaults, used where callers leave a value zero.
+const (
+	DefaultWebhookBatchSize   = 50
+	DefaultWebhookMaxAttempts = 10
+	DefaultWebhookTimeout     = 10 * time.Second
+	DefaultWebhookPageSize    = 20
+	MaxWebhookPageSize        = 100
+	// DefaultLowStockThreshold is the stock at or below which stock.low fires.
+	DefaultLowStockThreshold = 10
+)
+
+const (
+	// webhookRetryBase is the delay before the second attempt; it doubles per
+	// attempt up to webhookRetryMax.
+	webhookRetryBase = 30 * time.Second
+	webhookRetryMax  = time.Hour
+	// webhookSecretPrefix marks signing secrets so they are recognisable in config and logs.
+	webhookSecretPrefix = "whsec_"
+	// maxWebhookResponse bounds how much of a response is read before it is
+	// discarded; only the status code matters.
+	maxWebhookResponse = 64 << 10
+	// maxWebhookError matches the error columns of webhook_deliveries and webhook_attempts.
+	maxWebhookError = 255
+)
+
+var (
+	ErrInvalidWebhookEvent         = errors.New("unknown webhook event")
+	ErrInvalidWebhookURL           = errors.New("webhook url must be an absolute http or https url")
+	ErrWebhookSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound     = errors.New("webhook delivery not found")
+)
+
+// WebhookEnvelope is the JSON body of every webhook request.
+type WebhookEnvelope struct {
+	ID        string    `json:"id"` // Event ID, shared by the deliveries of the event to every subscription
+	Type      string    `json:"type" example:"order.created"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// OrderWebhookData is the data of order.created and order.paid.
+type OrderWebhookData struct {
+	OrderID        uint64             `json:"order_id,string"`
+	OrderNumber    string             `json:"order_number"`
+	UserID         uint64             `json:"user_id,string"`
+	Status         string             `json:"status"`
+	TotalAmount    money.Money        `json:"total_amount"` // Including tax, less discounts
+	DiscountAmount money.Money        `json:"discount_amount"`
+	TaxAmount      money.Money        `json:"tax_amount"`
+	Currency       string             `json:"currency"` // Of the amounts and item prices
+	Region         string             `json:"region"`   // Where the order ships; empty if tax does not depend on it
+	Items          []OrderWebhookItem `json:"items"`
+	TaxLines       []TaxLine          `json:"tax_lines"`
+}
+
+// OrderWebhookItem is one line of OrderWebhookData.
+type OrderWebhookItem struct {
+	SKUID    uint64      `json:"sku_id,string"`
+	Quantity int         `json:"quantity"`
+	Price    money.Money `json:"price"`
+	Discount money.Money `json:"discount"` // Taken off the line by promotions
+	// BundleSKUID is set on the component lines of a bundle, to the bundle's
+	// SKU, which is listed too: the components are fulfilled in its stead.
+	BundleSKUID uint64 `json:"bundle_sku_id,string,omitempty"`
+	// Options are the product options chosen for every unit, included in
+	// Price.
+	Options []OrderItemOptionResp `json:"options,omitempty"`
+}
+
+// StockLowWebhookData is the data of stock.low.
+type StockLowWebhookData struct {
+	SKUID     uint64 `json:"sku_id,string"`
+	Stock     int    `json:"stock"`
+	Threshold int    `json:"threshold"`
+}
+
+// SubscriptionPaymentFailedWebhookData is the data of
+// subscription.payment_failed.
+type SubscriptionPaymentFailedWebhookData struct {
+	SubscriptionID uint64     `json:"subscription_id,string"`
+	UserID         uint64     `json:"user_id,string"`
+	OrderID        uint64     `json:"order_id,string"` // The unpaid renewal order; 0 if none could be placed
+	Attempt        int        `json:"attempt"`         // Failed renewals in a row, including this one
+	Error          string     `json:"error"`
+	Status         string     `json:"status"`          // past_due, or cancelled once the retries ran out
+	NextAttemptAt  *time.Time `json:"next_attempt_at"` // Nil once cancelled
+}
+
+// WebhookSubscribeReq registers an endpoint for one event type.
+type WebhookSubscribeReq struct {
+	Event       string
+	URL         string
+	Description string
+}
+
+// WebhookSubscriptionResp describes a subscription. Secret is only set in the
+// response to Subscribe; it cannot be read back later.
+type WebhookSubscriptionResp struct {
+	ID          uint64    `json:"id,string"`
+	Event       string    `json:"event" example:"order.created"`
+	URL         string    `json:"url" example:"https://erp.example.com/hooks/mall"`
+	Description string    `json:"description" example:"ERP order import"`
+	Secret      string    `json:"secret,omitempty" example:"whsec_5f0c..."`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WebhookDeliveryListReq filters and pages deliveries. Zero values match everything.
+type WebhookDeliveryListReq struct {
+	SubscriptionID uint64
+	Event          string
+	Status         string
+	Offset         int
+	Limit          int // 0 means DefaultWebhookPageSize; capped at MaxWebhookPageSize
+}
+
+// WebhookDeliveryResp describes one event queued for one subscription.
+// Payload is only set by GetDelivery.
+type WebhookDeliveryResp struct {
+	ID             uint64     `json:"id,string"`
+	SubscriptionID uint64     `json:"subscription_id,string"`
+	Event          string     `json:"event" example:"order.created"`
+	EventID        string     `json:"event_id"`
+	Status         string     `json:"status" example:"pending"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code,omitempty" example:"503"`
+	LastError      string     `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	Payload        string     `json:"payload,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// WebhookAttemptResp describes one request made for a delivery.
+type WebhookAttemptResp struct {
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"` // 0 if no response was received
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookDeliveryListResp is one page of deliveries, newest first.
+type WebhookDeliveryListResp struct {
+	Items []WebhookDeliveryResp `json:"items"`
+	Total int64                 `json:"total"`
+}
+
+// WebhookDeliveryDetail is a delivery with every attempt made to send it.
+type WebhookDeliveryDetail struct {
+	Delivery WebhookDeliveryResp  `json:"delivery"`
+	Attempts []WebhookAttemptResp `json:"attempts"`
+}
+
+// WebhookDispatchOptions controls one Dispatch run.
+type WebhookDispatchOptions struct {
+	BatchSize   int
+	MaxAttempts int
+	Timeout     time.Duration // Per request
+}
+
+// WebhookDispatchReport summarises one Dispatch run.
+type WebhookDispatchReport struct {
+	Attempted int
+	Delivered int
+	Retrying  int // Failed attempts with another one scheduled
+	Failed    int // Deliveries given up on
+}
+
+// WebhookEmitter queues events for the endpoints subscribed to them. Called
+// with a transaction context, the queued deliveries commit or roll back with
+// the change that produced the event.
+type WebhookEmitter interface {
+	Emit(ctx context.Context, event string, data any) error
+}
+
+// WebhookService manages webhook subscriptions and sends queued deliveries.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/webhook_service_mock.go -package=mocks
+type WebhookService interface {
+	WebhookEmitter
+	Subscribe(ctx context.Context, req *WebhookSubscribeReq) (*WebhookSubscriptionResp, error)
+	Unsubscribe(ctx context.Context, id uint64) error
+	ListSubscriptions(ctx context.Context) ([]WebhookSubscriptionResp, error)
+	ListDeliveries(ctx context.Context, req *WebhookDeliveryListReq) (*WebhookDeliveryListResp, error)
+	GetDelivery(ctx context.Context, id uint64) (*WebhookDeliveryDetail, error)
+	// Dispatch sends the deliveries that are due and schedules retries for
+	// the ones that fail.
+	Dispatch(ctx context.Context, opts WebhookDispatchOptions) (*WebhookDispatchReport, error)
+}
+
+type webhookService struct {
+	repo   repository.WebhookRepository
+	client *http.Client
+}
+
+// NewWebhookService creates a new WebhookService instance.
+func NewWebhookService(repo repository.WebhookRepository) WebhookService {
+	return &webhookService{
+		repo: repo,
+		client: &http.Client{
+			// A redirect is a misconfigured endpoint; following it would send
+			// signed payloads somewhere the admin did not register.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+	}
+}
+
+// Emit queues one delivery of event per subscription. Events nobody
+// subscribed to are dropped.
+func (s *webhookService) Emit(ctx context.Context, event string, data any) error {
+	if !slices.Contains(WebhookEvents, event) {
+		return fmt.Errorf("%w: %q", ErrInvalidWebhookEvent, event)
+	}
+	subs, err := s.repo.ListSubscriptions(ctx, event)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	envelope := WebhookEnvelope{ID: uuid.NewString(), Type: event, CreatedAt: now, Data: data}
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s webhook: %w", event, err)
+	}
+	deliveries := make([]*model.WebhookDelivery, 0, len(subs))
+	for _, sub := range subs {
+		deliveries = append(deliveries, &model.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			Event:          event,
+			EventID:        envelope.ID,
+			Payload:        string(body),
+			Status:         model.WebhookStatusPending,
+			NextAttemptAt:  now,
+		})
+	}
+	return s.repo.CreateDeliveries(ctx, deliveries)
+}
+
+func (s *webhookService) Subscribe(ctx context.Context, req *WebhookSubscribeReq) (*WebhookSubscriptionResp, error) {
+	if !slices.Contains(WebhookEvents, req.Event) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidWebhookEvent, req.Event)
+	}
+	endpoint, err := url.Parse(req.URL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &model.WebhookSubscription{Event: req.Event, URL: endpoint.String(), Secret: secret, Description: req.Description}
+	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	resp := toWebhookSubscriptionResp(sub)
+	RecordAudit(ctx, AuditEntry{Action: "webhook.subscribe", Resource: "webhook_subscription", ResourceID: strconv.FormatUint(sub.ID, 10), After: resp})
+	resp.Secret = secret
+	return &resp, nil
+}
+
+func (s *webhookService) Unsubscribe(ctx context.Context, id uint64) error {
+	if err := s.repo.DeleteSubscription(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrWebhookSubscriptionNotFound) {
+			return ErrWebhookSubscriptionNotFound
+		}
+		return err
+	}
+	RecordAudit(ctx, AuditEntry{Action: "webhook.unsubscribe", Resource: "webhook_subscription", ResourceID: strconv.FormatUint(id, 10)})
+	return nil
+}
+
+func (s *webhookService) ListSubscriptions(ctx context.Context) ([]WebhookSubscriptionResp, error) {
+	subs, err := s.repo.ListSubscriptions(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]WebhookSubscriptionResp, 0, len(subs))
+	for i := range subs {
+		resp = append(resp, toWebhookSubscriptionResp(&subs[i]))
+	}
+	return resp, nil
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, req *WebhookDeliveryListReq) (*WebhookDeliveryListResp, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultWebhookPageSize
+	}
+	limit = min(limit, MaxWebhookPageSize)
+	offset := max(req.Offset, 0)
+
+	deliveries, total, err := s.repo.ListDeliveries(ctx, repository.WebhookDeliveryFilter{
+		SubscriptionID: req.SubscriptionID,
+		Event:          req.Event,
+		Status:         req.Status,
+	}, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]WebhookDeliveryResp, 0, len(deliveries))
+	for i := range deliveries {
+		items = append(items, toWebhookDeliveryResp(&deliveries[i]))
+	}
+	return &WebhookDeliveryListResp{Items: items, Total: total}, nil
+}
+
+func (s *webhookService) GetDelivery(ctx context.Context, id uint64) (*WebhookDeliveryDetail, error) {
+	delivery, err := s.repo.GetDelivery(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrWebhookDeliveryNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+	attempts, err := s.repo.ListAttempts(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &WebhookDeliveryDetail{Delivery: toWebhookDeliveryResp(delivery), Attempts: make([]WebhookAttemptResp, 0, len(attempts))}
+	detail.Delivery.Payload = delivery.Payload
+	for _, a := range attempts {
+		detail.Attempts = append(detail.Attempts, WebhookAttemptResp{
+			Attempt:    a.Attempt,
+			StatusCode: a.StatusCode,
+			Error:      a.Error,
+			DurationMS: a.DurationMS,
+			CreatedAt:  a.CreatedAt,
+		})
+	}
+	return detail, nil
+}
+
+// Dispatch sends due deliveries one batch at a time until none are left. A
+// failed attempt is retried after webhookRetryBase, doubling per attempt up to
+// webhookRetryMax, until opts.MaxAttempts is reached. Deliveries to removed
+// subscriptions fail without a request. A delivery whose attempt cannot be
+// recorded does not stop the others; the report is returned even on error.
+func (s *webhookService) Dispatch(ctx context.Context, opts WebhookDispatchOptions) (*WebhookDispatchReport, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultWebhookBatchSize
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultWebhookMaxAttempts
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultWebhookTimeout
+	}
+
+	report := &WebhookDispatchReport{}
+	var errs []error
+	for {
+		deliveries, err := s.repo.ListDueDeliveries(ctx, time.Now(), opts.BatchSize)
+		if err != nil {
+			return report, errors.Join(append(errs, err)...)
+		}
+
+		recorded := 0
+		for i := range deliveries {
+			if err := ctx.Err(); err != nil {
+				return report, errors.Join(append(errs, err)...)
+			}
+			retrying, err := s.deliver(ctx, &deliveries[i], opts)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			recorded++
+			report.Attempted++
+			switch {
+			case deliveries[i].Status == model.WebhookStatusDelivered:
+				report.Delivered++
+			case retrying:
+				report.Retrying++
+			default:
+				report.Failed++
+			}
+		}
+
+		// A batch that could not be recorded would be loaded again as is.
+		if len(deliveries) < opts.BatchSize || recorded == 0 {
+			return report, errors.Join(errs...)
+		}
+	}
+}
+
+// deliver makes one attempt at d and records its outcome. It reports whether
+// another attempt was scheduled.
+func (s *webhookService) deliver(ctx context.Context, d *model.WebhookDelivery, opts WebhookDispatchOptions) (bool, error) {
+	d.Attempts++
+	attempt := &model.WebhookAttempt{DeliveryID: d.ID, Attempt: d.Attempts}
+	retrying := false
+
+	var sendErr error
+	if d.Subscription == nil {
+		sendErr = errors.New("subscription removed")
+		d.Status = model.WebhookStatusFailed
+	} else {
+		start := time.Now()
+		attempt.StatusCode, sendErr = s.send(ctx, d, opts.Timeout)
+		attempt.DurationMS = time.Since(start).Milliseconds()
+		d.LastStatusCode = attempt.StatusCode
+
+		switch {
+		case sendErr == nil:
+			now := time.Now()
+			d.Status = model.WebhookStatusDelivered
+			d.DeliveredAt = &now
+		case d.Attempts >= opts.MaxAttempts:
+			d.Status = model.WebhookStatusFailed
+		default:
+			d.NextAttemptAt = time.Now().Add(webhookBackoff(d.Attempts))
+			retrying = true
+		}
+	}
+	d.LastError = ""
+	if sendErr != nil {
+		attempt.Error = truncateWebhookError(sendErr.Error())
+		d.LastError = attempt.Error
+	}
+
+	// The request may have reached the endpoint, so the attempt is recorded
+	// even if the run is being cancelled.
+	if err := s.repo.RecordAttempt(context.WithoutCancel(ctx), d, attempt); err != nil {
+		return false, err
+	}
+	return retrying, nil
+}
+
+// send posts the payload of d and returns the response status, or 0 if there was none.
+func (s *webhookService) send(ctx context.Context, d *model.WebhookDelivery, timeout time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body := []byte(d.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.Subscription.URL, strings.NewReader(d.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-mall-webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, d.Event)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(d.ID, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(d.Subscription.Secret, time.Now(), body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookResponse))
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, fmt.Errorf("endpoint returned %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhook returns the X-Mall-Signature value of body sent at t:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<unix seconds>.<body>">", keyed
+// with the subscription secret. Receivers recompute v1 with their secret,
+// compare in constant time and reject stale timestamps to stop replays.
+func SignWebhook(secret string, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff is the delay after the given number of failed attempts.
+func webhookBackoff(attempts int) time.Duration {
+	delay := webhookRetryBase
+	for i := 1; i < attempts && delay < webhookRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, webhookRetryMax)
+}
+
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return webhookSecretPrefix + hex.EncodeToString(buf), nil
+}
+
+func toWebhookSubscriptionResp(sub *model.WebhookSubscription) WebhookSubscriptionResp {
+	return WebhookSubscriptionResp{
+		ID:          sub.ID,
+		Event:       sub.Event,
+		URL:         sub.URL,
+		Description: sub.Description,
+		CreatedAt:   sub.CreatedAt,
+	}
+}
+
+func toWebhookDeliveryResp(d *model.WebhookDelivery) WebhookDeliveryResp {
+	return WebhookDeliveryResp{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		Event:          d.Event,
+		EventID:        d.EventID,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
+
+func truncateWebhookError(s string) string {
+	if len(s) <= maxWebhookError {
+		return s
+	}
+	return s[:maxWebhookError]
+}
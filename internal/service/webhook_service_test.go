@@ -0,0 +1,265 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/repository"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSignWebhook(t *testing.T) {
+	got := service.SignWebhook("whsec_test", time.Unix(1700000000, 0), []byte(`{"id":"evt"}`))
+	assert.Equal(t, "t=1700000000,v1=a94cea056df1fbb92eadafcf2c5cd541dbe0c6ef736e4748202dd53f86694a3e", got)
+}
+
+func TestWebhookService_Subscribe(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       *service.WebhookSubscribeReq
+		mockSetup func(repo *mocks.MockWebhookRepository)
+		wantErr   error
+		errStr    string
+	}{
+		{
+			name: "Success",
+			req:  &service.WebhookSubscribeReq{Event: service.WebhookEventOrderCreated, URL: "https://erp.example.com/hooks", Description: "ERP"},
+			mockSetup: func(repo *mocks.MockWebhookRepository) {
+				repo.EXPECT().CreateSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, sub *model.WebhookSubscription) error {
+					assert.Equal(t, "https://erp.example.com/hooks", sub.URL)
+					assert.True(t, strings.HasPrefix(sub.Secret, "whsec_"))
+					sub.ID = 7
+					return nil
+				})
+			},
+		},
+		{
+			name:    "UnknownEvent",
+			req:     &service.WebhookSubscribeReq{Event: "order.shipped", URL: "https://erp.example.com/hooks"},
+			wantErr: service.ErrInvalidWebhookEvent,
+		},
+		{
+			name:    "UnsupportedScheme",
+			req:     &service.WebhookSubscribeReq{Event: service.WebhookEventStockLow, URL: "ftp://erp.example.com/hooks"},
+			wantErr: service.ErrInvalidWebhookURL,
+		},
+		{
+			name:    "RelativeURL",
+			req:     &service.WebhookSubscribeReq{Event: service.WebhookEventStockLow, URL: "/hooks"},
+			wantErr: service.ErrInvalidWebhookURL,
+		},
+		{
+			name: "RepoError",
+			req:  &service.WebhookSubscribeReq{Event: service.WebhookEventOrderPaid, URL: "http://erp.example.com/hooks"},
+			mockSetup: func(repo *mocks.MockWebhookRepository) {
+				repo.EXPECT().CreateSubscription(gomock.Any(), gomock.Any()).Return(errors.New("db down"))
+			},
+			errStr: "db down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockWebhookRepository(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(repo)
+			}
+			ctx, trail := service.WithAuditTrail(context.Background())
+
+			resp, err := service.NewWebhookService(repo).Subscribe(ctx, tt.req)
+			if tt.wantErr != nil || tt.errStr != "" {
+				require.Error(t, err)
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				}
+				assert.Contains(t, err.Error(), tt.errStr)
+				assert.Empty(t, trail.Entries())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, uint64(7), resp.ID)
+			assert.True(t, strings.HasPrefix(resp.Secret, "whsec_"))
+			require.Len(t, trail.Entries(), 1)
+			assert.Equal(t, "webhook.subscribe", trail.Entries()[0].Action)
+			assert.Empty(t, trail.Entries()[0].After.(service.WebhookSubscriptionResp).Secret, "the secret must not reach the audit log")
+		})
+	}
+}
+
+func TestWebhookService_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockWebhookRepository(ctrl)
+	repo.EXPECT().DeleteSubscription(gomock.Any(), uint64(1)).Return(repository.ErrWebhookSubscriptionNotFound)
+	repo.EXPECT().GetDelivery(gomock.Any(), uint64(2)).Return(nil, repository.ErrWebhookDeliveryNotFound)
+	svc := service.NewWebhookService(repo)
+
+	assert.ErrorIs(t, svc.Unsubscribe(context.Background(), 1), service.ErrWebhookSubscriptionNotFound)
+	_, err := svc.GetDelivery(context.Background(), 2)
+	assert.ErrorIs(t, err, service.ErrWebhookDeliveryNotFound)
+}
+
+func TestWebhookService_Emit(t *testing.T) {
+	sub := func(id uint64) model.WebhookSubscription {
+		s := model.WebhookSubscription{Event: service.WebhookEventStockLow}
+		s.ID = id
+		return s
+	}
+
+	t.Run("OneDeliveryPerSubscription", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockWebhookRepository(ctrl)
+		repo.EXPECT().ListSubscriptions(gomock.Any(), service.WebhookEventStockLow).Return([]model.WebhookSubscription{sub(1), sub(2)}, nil)
+		repo.EXPECT().CreateDeliveries(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, deliveries []*model.WebhookDelivery) error {
+			require.Len(t, deliveries, 2)
+			assert.Equal(t, uint64(1), deliveries[0].SubscriptionID)
+			assert.Equal(t, uint64(2), deliveries[1].SubscriptionID)
+			assert.Equal(t, deliveries[0].EventID, deliveries[1].EventID)
+
+			var envelope struct {
+				ID   string                      `json:"id"`
+				Type string                      `json:"type"`
+				Data service.StockLowWebhookData `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(deliveries[0].Payload), &envelope))
+			assert.Equal(t, deliveries[0].EventID, envelope.ID)
+			assert.Equal(t, service.WebhookEventStockLow, envelope.Type)
+			assert.Equal(t, service.StockLowWebhookData{SKUID: 101, Stock: 3, Threshold: 10}, envelope.Data)
+			for _, d := range deliveries {
+				assert.Equal(t, model.WebhookStatusPending, d.Status)
+				assert.False(t, d.NextAttemptAt.IsZero())
+			}
+			return nil
+		})
+
+		err := service.NewWebhookService(repo).Emit(context.Background(), service.WebhookEventStockLow, service.StockLowWebhookData{SKUID: 101, Stock: 3, Threshold: 10})
+		require.NoError(t, err)
+	})
+
+	t.Run("NoSubscribers", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockWebhookRepository(ctrl)
+		repo.EXPECT().ListSubscriptions(gomock.Any(), service.WebhookEventOrderCreated).Return(nil, nil)
+
+		require.NoError(t, service.NewWebhookService(repo).Emit(context.Background(), service.WebhookEventOrderCreated, nil))
+	})
+
+	t.Run("UnknownEvent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		err := service.NewWebhookService(mocks.NewMockWebhookRepository(ctrl)).Emit(context.Background(), "order.shipped", nil)
+		assert.ErrorIs(t, err, service.ErrInvalidWebhookEvent)
+	})
+}
+
+func TestWebhookService_Dispatch(t *testing.T) {
+	var gotSignature, gotEvent, gotBody string
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/moved" {
+			http.Redirect(w, r, "/ok", http.StatusFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		gotSignature, gotEvent, gotBody = r.Header.Get(service.WebhookSignatureHeader), r.Header.Get(service.WebhookEventHeader), string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer endpoint.Close()
+
+	delivery := func(id uint64, path string, attempts int) model.WebhookDelivery {
+		d := model.WebhookDelivery{Event: service.WebhookEventOrderCreated, Payload: `{"id":"evt"}`, Status: model.WebhookStatusPending, Attempts: attempts}
+		d.ID = id
+		if path != "" {
+			d.Subscription = &model.WebhookSubscription{URL: endpoint.URL + path, Secret: "whsec_test"}
+		}
+		return d
+	}
+	due := []model.WebhookDelivery{
+		delivery(1, "/ok", 0),
+		delivery(2, "/down", 0),
+		delivery(3, "/down", 2),
+		delivery(4, "/moved", 0),
+		delivery(5, "", 0), // Subscription removed
+	}
+
+	type outcome struct {
+		status    string
+		attempt   int
+		code      int
+		retryFrom time.Duration // Minimum delay before the next attempt, if retrying
+	}
+	want := map[uint64]outcome{
+		1: {status: model.WebhookStatusDelivered, attempt: 1, code: http.StatusNoContent},
+		2: {status: model.WebhookStatusPending, attempt: 1, code: http.StatusServiceUnavailable, retryFrom: 29 * time.Second},
+		3: {status: model.WebhookStatusFailed, attempt: 3, code: http.StatusServiceUnavailable},
+		4: {status: model.WebhookStatusPending, attempt: 1, code: http.StatusFound, retryFrom: 29 * time.Second},
+		5: {status: model.WebhookStatusFailed, attempt: 1},
+	}
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockWebhookRepository(ctrl)
+	repo.EXPECT().ListDueDeliveries(gomock.Any(), gomock.Any(), 10).Return(due, nil)
+	repo.EXPECT().RecordAttempt(gomock.Any(), gomock.Any(), gomock.Any()).Times(len(due)).DoAndReturn(func(_ context.Context, d *model.WebhookDelivery, a *model.WebhookAttempt) error {
+		w := want[d.ID]
+		assert.Equal(t, w.status, d.Status, "delivery %d", d.ID)
+		assert.Equal(t, w.attempt, d.Attempts, "delivery %d", d.ID)
+		assert.Equal(t, w.attempt, a.Attempt, "delivery %d", d.ID)
+		assert.Equal(t, w.code, a.StatusCode, "delivery %d", d.ID)
+		assert.Equal(t, d.Status == model.WebhookStatusDelivered, d.DeliveredAt != nil, "delivery %d", d.ID)
+		assert.Equal(t, d.Status == model.WebhookStatusDelivered, a.Error == "", "delivery %d", d.ID)
+		if w.retryFrom > 0 {
+			assert.Greater(t, time.Until(d.NextAttemptAt), w.retryFrom, "delivery %d", d.ID)
+		}
+		return nil
+	})
+
+	report, err := service.NewWebhookService(repo).Dispatch(context.Background(), service.WebhookDispatchOptions{BatchSize: 10, MaxAttempts: 3, Timeout: time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, &service.WebhookDispatchReport{Attempted: 5, Delivered: 1, Retrying: 2, Failed: 2}, report)
+
+	assert.Equal(t, service.WebhookEventOrderCreated, gotEvent)
+	assert.Equal(t, `{"id":"evt"}`, gotBody)
+	timestamp, _, ok := strings.Cut(strings.TrimPrefix(gotSignature, "t="), ",")
+	require.True(t, ok, gotSignature)
+	sentAt, err := strconv.ParseInt(timestamp, 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, service.SignWebhook("whsec_test", time.Unix(sentAt, 0), []byte(gotBody)), gotSignature)
+}
+
+func TestWebhookService_ListDeliveries(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       service.WebhookDeliveryListReq
+		wantLimit int
+	}{
+		{name: "DefaultPageSize", wantLimit: service.DefaultWebhookPageSize},
+		{name: "CappedPageSize", req: service.WebhookDeliveryListReq{Limit: 1000}, wantLimit: service.MaxWebhookPageSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockWebhookRepository(ctrl)
+			repo.EXPECT().ListDeliveries(gomock.Any(), repository.WebhookDeliveryFilter{}, 0, tt.wantLimit).Return(nil, int64(0), nil)
+
+			resp, err := service.NewWebhookService(repo).ListDeliveries(context.Background(), &tt.req)
+			require.NoError(t, err)
+			assert.NotNil(t, resp.Items)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	// DefaultBackorderSchedule applies when order.backorder_schedule is empty.
+	DefaultBackorderSchedule = "@every 5m"
+
+	// BackorderAllocationJobName identifies the allocator in logs, reports and metrics.
+	BackorderAllocationJobName = "backorder-allocator"
+	// backorderAllocationJitter keeps the allocator off the stock reconciler's beat.
+	backorderAllocationJitter = 30 * time.Second
+	// backorderAllocationRunTimeout bounds one pass; leftovers are picked up by the next.
+	backorderAllocationRunTimeout = 5 * time.Minute
+)
+
+var backordersAllocated = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "orders_backorder_allocated_total",
+		Help: "Total number of backordered orders allocated stock once it arrived",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(backordersAllocated)
+}
+
+// NewBackorderAllocationJob returns the job that allocates stock that has
+// arrived to backordered orders, oldest first, in batches of
+// cfg.BackorderBatchSize, across all stores.
+func NewBackorderAllocationJob(fulfillment service.FulfillmentService, cfg config.OrderConfig, logger *slog.Logger) Job {
+	schedule := cfg.BackorderSchedule
+	if schedule == "" {
+		schedule = DefaultBackorderSchedule
+	}
+
+	return Job{
+		Name:     BackorderAllocationJobName,
+		Schedule: schedule,
+		Jitter:   backorderAllocationJitter,
+		Timeout:  backorderAllocationRunTimeout,
+		Run: func(ctx context.Context) error {
+			allocated, err := fulfillment.AllocateBackorders(ctx, cfg.BackorderBatchSize)
+			backordersAllocated.Add(float64(allocated))
+			if allocated > 0 {
+				logger.InfoContext(ctx, "Allocated stock to backordered orders", slog.Int("count", allocated))
+			}
+			return err
+		},
+	}
+}
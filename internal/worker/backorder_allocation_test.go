@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestBackorderAllocationJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.OrderConfig
+		wantSchedule string
+		allocated    int
+		allocateErr  error
+	}{
+		{name: "Defaults", wantSchedule: DefaultBackorderSchedule, allocated: 2},
+		{name: "Configured", cfg: config.OrderConfig{BackorderSchedule: "*/10 * * * *", BackorderBatchSize: 50}, wantSchedule: "*/10 * * * *"},
+		{name: "PartialPass", wantSchedule: DefaultBackorderSchedule, allocated: 1, allocateErr: errors.New("db down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			fulfillment := mocks.NewMockFulfillmentService(ctrl)
+			fulfillment.EXPECT().AllocateBackorders(gomock.Any(), tt.cfg.BackorderBatchSize).Return(tt.allocated, tt.allocateErr)
+
+			job := NewBackorderAllocationJob(fulfillment, tt.cfg, discardLogger)
+			assert.Equal(t, tt.wantSchedule, job.Schedule)
+			require.NoError(t, NewScheduler(nil, discardLogger, nil).Register(job))
+
+			before := testutil.ToFloat64(backordersAllocated)
+			err := job.Run(context.Background())
+			assert.Equal(t, tt.allocateErr, err)
+			assert.Equal(t, before+float64(tt.allocated), testutil.ToFloat64(backordersAllocated))
+		})
+	}
+}
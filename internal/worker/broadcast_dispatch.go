@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	// DefaultBroadcastDispatchSchedule applies when
+	// notification.broadcast.dispatch_schedule is empty.
+	DefaultBroadcastDispatchSchedule = "@every 1m"
+
+	// BroadcastDispatchJobName identifies the dispatcher in logs, reports and metrics.
+	BroadcastDispatchJobName = "broadcast-dispatcher"
+	broadcastDispatchJitter  = 5 * time.Second
+	// broadcastDispatchRunTimeout bounds one run, which the throttle makes
+	// long for big segments; the next run resumes after the last saved batch.
+	broadcastDispatchRunTimeout = 15 * time.Minute
+)
+
+var broadcastDeliveries = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "broadcast_deliveries_queued_total",
+		Help: "Total number of notification deliveries queued for admin broadcasts",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(broadcastDeliveries)
+}
+
+// NewBroadcastDispatchJob returns the job that fans queued broadcasts out to
+// their recipients.
+func NewBroadcastDispatchJob(dispatcher service.BroadcastDispatcher, cfg config.BroadcastConfig, logger *slog.Logger) Job {
+	schedule := cfg.DispatchSchedule
+	if schedule == "" {
+		schedule = DefaultBroadcastDispatchSchedule
+	}
+	opts := service.BroadcastDispatchOptions{Rate: cfg.Rate, BatchSize: cfg.BatchSize}
+
+	return Job{
+		Name:     BroadcastDispatchJobName,
+		Schedule: schedule,
+		Jitter:   broadcastDispatchJitter,
+		Timeout:  broadcastDispatchRunTimeout,
+		Run: func(ctx context.Context) error {
+			report, err := dispatcher.Dispatch(ctx, opts)
+			if report == nil {
+				return err
+			}
+			broadcastDeliveries.Add(float64(report.Queued))
+			if report.Finished > 0 {
+				logger.InfoContext(ctx, "Broadcasts fanned out",
+					slog.Int("finished", report.Finished),
+					slog.Int("queued", report.Queued),
+				)
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestBroadcastDispatchJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.BroadcastConfig
+		wantSchedule string
+		wantOpts     service.BroadcastDispatchOptions
+		report       *service.BroadcastDispatchReport
+		dispatchErr  error
+	}{
+		{
+			name:         "Defaults",
+			wantSchedule: DefaultBroadcastDispatchSchedule,
+			report:       &service.BroadcastDispatchReport{Queued: 6, Finished: 1},
+		},
+		{
+			name:         "Configured",
+			cfg:          config.BroadcastConfig{DispatchSchedule: "@every 5m", Rate: 100, BatchSize: 50},
+			wantSchedule: "@every 5m",
+			wantOpts:     service.BroadcastDispatchOptions{Rate: 100, BatchSize: 50},
+			report:       &service.BroadcastDispatchReport{Queued: 100},
+		},
+		{
+			name:         "PartialRunStillCounted",
+			wantSchedule: DefaultBroadcastDispatchSchedule,
+			report:       &service.BroadcastDispatchReport{Queued: 2},
+			dispatchErr:  errors.New("mq down"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			dispatcher := mocks.NewMockBroadcastDispatcher(ctrl)
+			dispatcher.EXPECT().Dispatch(gomock.Any(), tt.wantOpts).Return(tt.report, tt.dispatchErr)
+
+			job := NewBroadcastDispatchJob(dispatcher, tt.cfg, discardLogger)
+			assert.Equal(t, tt.wantSchedule, job.Schedule)
+			require.NoError(t, NewScheduler(nil, discardLogger, nil).Register(job))
+
+			queued := testutil.ToFloat64(broadcastDeliveries)
+			err := job.Run(context.Background())
+			assert.Equal(t, tt.dispatchErr, err)
+			assert.Equal(t, queued+float64(tt.report.Queued), testutil.ToFloat64(broadcastDeliveries))
+		})
+	}
+}
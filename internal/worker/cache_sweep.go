@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	// DefaultCacheSweepSchedule applies when the schedule of a sweep under
+	// cache.maintenance is empty.
+	DefaultCacheSweepSchedule = "@every 1h"
+
+	// cacheSweepJobPrefix, followed by the sweep, identifies a sweep job in
+	// logs, reports and metrics.
+	cacheSweepJobPrefix = "cache-sweep-"
+	cacheSweepJitter    = time.Minute
+	// cacheSweepRunTimeout bounds one run; keys left over are purged by the
+	// next.
+	cacheSweepRunTimeout = 10 * time.Minute
+)
+
+var cacheSweepKeys = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "cache_sweep_keys_total",
+		Help: "Total number of Redis keys scanned and found stale by the cache sweeps",
+	},
+	[]string{"sweep", "result", "dry_run"}, // result: scanned, stale
+)
+
+func init() {
+	prometheus.MustRegister(cacheSweepKeys)
+}
+
+// NewCacheSweepJobs returns one job per cache sweep, purging the stale keys
+// of its kind.
+func NewCacheSweepJobs(janitor service.CacheJanitor, cfg config.CacheMaintenanceConfig, logger *slog.Logger) []Job {
+	return []Job{
+		NewCacheSweepJob(janitor, service.CacheSweepIdempotency, cfg.Idempotency, logger),
+		NewCacheSweepJob(janitor, service.CacheSweepCheckoutSessions, cfg.CheckoutSessions, logger),
+		NewCacheSweepJob(janitor, service.CacheSweepPurchaseCounters, cfg.PurchaseCounters, logger),
+		NewCacheSweepJob(janitor, service.CacheSweepRateLimits, cfg.RateLimits, logger),
+	}
+}
+
+// NewCacheSweepJob returns the job running sweep. With cfg.DryRun it only
+// counts and logs the stale keys.
+func NewCacheSweepJob(janitor service.CacheJanitor, sweep string, cfg config.CacheSweepConfig, logger *slog.Logger) Job {
+	schedule := cfg.Schedule
+	if schedule == "" {
+		schedule = DefaultCacheSweepSchedule
+	}
+	dryRun := strconv.FormatBool(cfg.DryRun)
+
+	return Job{
+		Name:     cacheSweepJobPrefix + sweep,
+		Schedule: schedule,
+		Jitter:   cacheSweepJitter,
+		Timeout:  cacheSweepRunTimeout,
+		Run: func(ctx context.Context) error {
+			report, err := janitor.Sweep(ctx, sweep, cfg.DryRun)
+			if report == nil {
+				return err
+			}
+			cacheSweepKeys.WithLabelValues(sweep, "scanned", dryRun).Add(float64(report.Scanned))
+			cacheSweepKeys.WithLabelValues(sweep, "stale", dryRun).Add(float64(report.Stale))
+			if report.Stale > 0 {
+				msg := "Stale cache keys purged"
+				if cfg.DryRun {
+					msg = "Stale cache keys found (dry run)"
+				}
+				logger.InfoContext(ctx, msg,
+					slog.String("sweep", sweep),
+					slog.Int("scanned", report.Scanned),
+					slog.Int("stale", report.Stale),
+				)
+			}
+			return err
+		},
+	}
+}
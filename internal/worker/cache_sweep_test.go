@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCacheSweepJobs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	janitor := mocks.NewMockCacheJanitor(ctrl)
+	cfg := config.CacheMaintenanceConfig{PurchaseCounters: config.CacheSweepConfig{Schedule: "@every 6h", DryRun: true}}
+
+	jobs := NewCacheSweepJobs(janitor, cfg, discardLogger)
+	scheduler := NewScheduler(nil, discardLogger, nil)
+	names := make([]string, len(jobs))
+	for i, job := range jobs {
+		require.NoError(t, scheduler.Register(job))
+		names[i] = job.Name
+	}
+	assert.Equal(t, []string{"cache-sweep-idempotency", "cache-sweep-checkout_sessions", "cache-sweep-purchase_counters", "cache-sweep-rate_limits"}, names)
+	assert.Equal(t, DefaultCacheSweepSchedule, jobs[0].Schedule)
+	assert.Equal(t, "@every 6h", jobs[2].Schedule)
+
+	janitor.EXPECT().Sweep(gomock.Any(), service.CacheSweepPurchaseCounters, true).Return(&service.CacheSweepReport{Scanned: 3, Stale: 1}, nil)
+	require.NoError(t, jobs[2].Run(context.Background()))
+
+	errRedis := errors.New("redis down")
+	janitor.EXPECT().Sweep(gomock.Any(), service.CacheSweepIdempotency, false).Return(&service.CacheSweepReport{Scanned: 200}, errRedis)
+	assert.ErrorIs(t, jobs[0].Run(context.Background()), errRedis)
+}
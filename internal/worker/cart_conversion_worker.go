@@ -0,0 +1,102 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/errreport"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/mq"
+)
+
+// Queues of cart conversion. Orders whose carts fail to convert wait in
+// CartConversionRetryQueue for CartConversionRetryDelay and then dead-letter
+// back into CartConversionQueue.
+const (
+	CartConversionQueue      = "cart_conversions"
+	CartConversionRetryQueue = "cart_conversions.retry"
+	// CartConversionRetryDelay is part of the queue declaration and so cannot
+	// come from config: redeclaring a queue with a different TTL fails.
+	CartConversionRetryDelay = time.Minute
+)
+
+// createdOrderEvent is the part of an order.created event cart conversion
+// needs.
+type createdOrderEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		OrderID uint64 `json:"order_id,string"`
+		UserID  uint64 `json:"user_id,string"`
+		Items   []struct {
+			SKUID uint64 `json:"sku_id,string"`
+		} `json:"items"`
+	} `json:"data"`
+}
+
+// CartConversionWorker closes customers' carts as they order them, which
+// counts the abandoned carts their reminders recovered.
+type CartConversionWorker struct {
+	broker   mq.RabbitMQ
+	carts    service.CartService
+	archive  service.FailedMessageService
+	cfg      config.ConsumerConfig
+	logger   *slog.Logger
+	reporter errreport.Reporter
+}
+
+// NewCartConversionWorker creates a CartConversionWorker. Events it cannot
+// decode are kept in archive.
+func NewCartConversionWorker(broker mq.RabbitMQ, carts service.CartService, archive service.FailedMessageService, cfg config.ConsumerConfig, logger *slog.Logger, reporter errreport.Reporter) *CartConversionWorker {
+	if reporter == nil {
+		reporter = errreport.Nop()
+	}
+	return &CartConversionWorker{broker: broker, carts: carts, archive: archive, cfg: cfg, logger: logger, reporter: reporter}
+}
+
+// Start declares the queues, binds them to the events of created orders and
+// begins consuming. service.EventsExchange must have been declared.
+func (w *CartConversionWorker) Start() error {
+	w.logger.Info("Starting CartConversionWorker...")
+	if err := w.broker.DeclareQueue(CartConversionQueue, mq.QueueOptions{DeadLetterRoutingKey: CartConversionRetryQueue}); err != nil {
+		return err
+	}
+	if err := w.broker.DeclareQueue(CartConversionRetryQueue, mq.QueueOptions{MessageTTL: CartConversionRetryDelay, DeadLetterRoutingKey: CartConversionQueue}); err != nil {
+		return err
+	}
+	if err := w.broker.BindQueue(CartConversionQueue, service.EventsExchange, service.EventOrderCreated); err != nil {
+		return err
+	}
+	return consume(w.broker, CartConversionQueue, w.cfg, w.handleEvent, LogContext, Archived(w.archive))
+}
+
+// handleEvent converts the cart of the order of one event. Returning an
+// error rejects the message into the retry queue.
+func (w *CartConversionWorker) handleEvent(ctx context.Context, body []byte) error {
+	var event createdOrderEvent
+	if err := json.Unmarshal(body, &event); err != nil || event.Data.OrderID == 0 || event.Data.UserID == 0 {
+		w.logger.ErrorContext(ctx, "Poison Pill: Failed to decode order event", logger.Err(err), slog.String("body", string(body)))
+		if err == nil {
+			err = errors.New("no order or user ID")
+		}
+		return reject(fmt.Errorf("failed to decode order event: %w", err)) // Archive for replay
+	}
+
+	skuIDs := make([]uint64, 0, len(event.Data.Items))
+	for _, item := range event.Data.Items {
+		skuIDs = append(skuIDs, item.SKUID)
+	}
+	if err := w.carts.Convert(ctx, event.Data.UserID, event.Data.OrderID, skuIDs); err != nil {
+		err = fmt.Errorf("failed to convert cart of order %d: %w", event.Data.OrderID, err)
+		w.reporter.CaptureError(ctx, err, map[string]string{"queue": CartConversionQueue, "event": event.Type})
+		w.logger.WarnContext(ctx, "Transient: Cart conversion retried later", logger.Err(err), slog.String("event_id", event.ID))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCartConversionWorker_HandleEvent(t *testing.T) {
+	const created = `{"id":"evt-1","type":"order.created","data":{"order_id":"42","user_id":"7","items":[{"sku_id":"11"},{"sku_id":"12"}]}}`
+
+	tests := []struct {
+		name         string
+		body         string
+		setup        func(carts *mocks.MockCartService, reporter *mocks.MockReporter)
+		wantErr      bool
+		wantRejected bool
+	}{
+		{
+			name: "Converted",
+			body: created,
+			setup: func(carts *mocks.MockCartService, _ *mocks.MockReporter) {
+				carts.EXPECT().Convert(gomock.Any(), uint64(7), uint64(42), []uint64{11, 12}).Return(nil)
+			},
+		},
+		{
+			name: "RetriedOnFailure",
+			body: created,
+			setup: func(carts *mocks.MockCartService, reporter *mocks.MockReporter) {
+				carts.EXPECT().Convert(gomock.Any(), uint64(7), uint64(42), []uint64{11, 12}).Return(errors.New("db down"))
+				reporter.EXPECT().CaptureError(gomock.Any(), gomock.Any(), map[string]string{"queue": CartConversionQueue, "event": "order.created"})
+			},
+			wantErr: true,
+		},
+		{name: "Malformed", body: `not json`, wantErr: true, wantRejected: true},
+		{name: "NoUser", body: `{"id":"evt-1","type":"order.created","data":{"order_id":"42"}}`, wantErr: true, wantRejected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			carts := mocks.NewMockCartService(ctrl)
+			reporter := mocks.NewMockReporter(ctrl)
+			if tt.setup != nil {
+				tt.setup(carts, reporter)
+			}
+
+			w := NewCartConversionWorker(nil, carts, nil, config.ConsumerConfig{}, discardLogger, reporter)
+			err := w.handleEvent(context.Background(), []byte(tt.body))
+			assert.Equal(t, tt.wantErr, err != nil)
+			var r *rejection
+			assert.Equal(t, tt.wantRejected, errors.As(err, &r), "archived for replay")
+		})
+	}
+}
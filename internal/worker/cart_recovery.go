@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	// DefaultCartRecoverySchedule applies when cart.recovery_schedule is empty.
+	DefaultCartRecoverySchedule = "@every 15m"
+
+	// CartRecoveryJobName identifies the cart recovery job in logs, reports and metrics.
+	CartRecoveryJobName = "cart-recovery"
+	// cartRecoveryRunTimeout bounds one pass; leftovers are picked up by the next.
+	cartRecoveryRunTimeout = 5 * time.Minute
+)
+
+// NewCartRecoveryJob returns the job that reminds customers of the carts they
+// abandoned, across all stores.
+func NewCartRecoveryJob(recovery service.CartRecovery, cfg config.CartConfig, logger *slog.Logger) Job {
+	schedule := cfg.RecoverySchedule
+	if schedule == "" {
+		schedule = DefaultCartRecoverySchedule
+	}
+
+	return Job{
+		Name:     CartRecoveryJobName,
+		Schedule: schedule,
+		Timeout:  cartRecoveryRunTimeout,
+		Run: func(ctx context.Context) error {
+			reminded, err := recovery.RecoverAbandoned(ctx)
+			if reminded > 0 {
+				logger.InfoContext(ctx, "Reminded customers of abandoned carts", slog.Int("carts", reminded))
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCartRecoveryJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.CartConfig
+		wantSchedule string
+		recoverErr   error
+	}{
+		{name: "Defaults", wantSchedule: DefaultCartRecoverySchedule},
+		{name: "Configured", cfg: config.CartConfig{RecoverySchedule: "@every 1h"}, wantSchedule: "@every 1h"},
+		{name: "Fails", wantSchedule: DefaultCartRecoverySchedule, recoverErr: errors.New("db down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			recovery := mocks.NewMockCartRecovery(ctrl)
+			recovery.EXPECT().RecoverAbandoned(gomock.Any()).Return(1, tt.recoverErr)
+
+			job := NewCartRecoveryJob(recovery, tt.cfg, discardLogger)
+			assert.Equal(t, tt.wantSchedule, job.Schedule)
+			require.NoError(t, NewScheduler(nil, discardLogger, nil).Register(job))
+			assert.Equal(t, tt.recoverErr, job.Run(context.Background()))
+		})
+	}
+}
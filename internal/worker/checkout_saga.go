@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	// DefaultCheckoutSagaSchedule applies when order.saga_schedule is empty.
+	DefaultCheckoutSagaSchedule = "@every 30s"
+
+	// CheckoutSagaJobName identifies the saga runner in logs, reports and metrics.
+	CheckoutSagaJobName = "checkout-saga"
+	// checkoutSagaJitter is small: sagas are due as soon as their lease ends.
+	checkoutSagaJitter = 5 * time.Second
+	// checkoutSagaRunTimeout bounds one run; sagas left over are resumed by the next.
+	checkoutSagaRunTimeout = 5 * time.Minute
+)
+
+var checkoutSagas = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "checkout_sagas_resumed_total",
+		Help: "Total number of checkout sagas resumed, by outcome (completed, compensated, retrying, failed)",
+	},
+	[]string{"outcome"},
+)
+
+func init() {
+	prometheus.MustRegister(checkoutSagas)
+}
+
+// NewCheckoutSagaJob returns the job that resumes the checkout sagas that
+// stopped halfway, e.g. as the API server restarted, or wait to retry a
+// step.
+func NewCheckoutSagaJob(orders service.OrderService, cfg config.OrderConfig, logger *slog.Logger) Job {
+	schedule := cfg.SagaSchedule
+	if schedule == "" {
+		schedule = DefaultCheckoutSagaSchedule
+	}
+	opts := service.CheckoutSagaOptions{
+		BatchSize:   cfg.SagaBatchSize,
+		MaxAttempts: cfg.SagaMaxAttempts,
+	}
+
+	return Job{
+		Name:     CheckoutSagaJobName,
+		Schedule: schedule,
+		Jitter:   checkoutSagaJitter,
+		Timeout:  checkoutSagaRunTimeout,
+		Run: func(ctx context.Context) error {
+			report, err := orders.ResumeCheckoutSagas(ctx, opts)
+			if report == nil {
+				return err
+			}
+			checkoutSagas.WithLabelValues("completed").Add(float64(report.Completed))
+			checkoutSagas.WithLabelValues("compensated").Add(float64(report.Compensated))
+			checkoutSagas.WithLabelValues("retrying").Add(float64(report.Retrying))
+			checkoutSagas.WithLabelValues("failed").Add(float64(report.Failed))
+			if report.Failed > 0 {
+				// A failed saga may hold the customer's money
+				logger.ErrorContext(ctx, "Gave up on checkout sagas; see saga_states",
+					slog.Int("resumed", report.Resumed),
+					slog.Int("failed", report.Failed),
+				)
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCheckoutSagaJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.OrderConfig
+		wantSchedule string
+		wantOpts     service.CheckoutSagaOptions
+		report       *service.CheckoutSagaReport
+		resumeErr    error
+	}{
+		{
+			name:         "Defaults",
+			wantSchedule: DefaultCheckoutSagaSchedule,
+			report:       &service.CheckoutSagaReport{Resumed: 3, Completed: 1, Compensated: 1, Failed: 1},
+		},
+		{
+			name:         "Configured",
+			cfg:          config.OrderConfig{SagaSchedule: "@every 1m", SagaBatchSize: 20, SagaMaxAttempts: 4},
+			wantSchedule: "@every 1m",
+			wantOpts:     service.CheckoutSagaOptions{BatchSize: 20, MaxAttempts: 4},
+			report:       &service.CheckoutSagaReport{},
+		},
+		{
+			name:         "ListFails",
+			wantSchedule: DefaultCheckoutSagaSchedule,
+			report:       &service.CheckoutSagaReport{},
+			resumeErr:    errors.New("db down"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orders := mocks.NewMockOrderService(ctrl)
+			orders.EXPECT().ResumeCheckoutSagas(gomock.Any(), tt.wantOpts).Return(tt.report, tt.resumeErr)
+
+			job := NewCheckoutSagaJob(orders, tt.cfg, discardLogger)
+			assert.Equal(t, tt.wantSchedule, job.Schedule)
+			require.NoError(t, NewScheduler(nil, discardLogger, nil).Register(job))
+
+			before := testutil.ToFloat64(checkoutSagas.WithLabelValues("failed"))
+			err := job.Run(context.Background())
+			assert.Equal(t, tt.resumeErr, err)
+			assert.Equal(t, before+float64(tt.report.Failed), testutil.ToFloat64(checkoutSagas.WithLabelValues("failed")))
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	// DefaultCouponRollupSchedule applies when coupon.rollup_schedule is empty.
+	DefaultCouponRollupSchedule = "@every 15m"
+
+	// CouponRollupJobName identifies the coupon stats rollup in logs, reports and metrics.
+	CouponRollupJobName = "coupon-stats-rollup"
+	// couponRollupJitter keeps the rollup off the other jobs' beat.
+	couponRollupJitter = time.Minute
+	// couponRollupRunTimeout bounds one recount of every campaign.
+	couponRollupRunTimeout = 5 * time.Minute
+)
+
+// NewCouponRollupJob returns the job that recounts the generated and redeemed
+// codes of every coupon campaign, across all stores, into its stats.
+func NewCouponRollupJob(coupons service.CouponService, cfg config.CouponConfig, logger *slog.Logger) Job {
+	schedule := cfg.RollupSchedule
+	if schedule == "" {
+		schedule = DefaultCouponRollupSchedule
+	}
+
+	return Job{
+		Name:     CouponRollupJobName,
+		Schedule: schedule,
+		Jitter:   couponRollupJitter,
+		Timeout:  couponRollupRunTimeout,
+		Run: func(ctx context.Context) error {
+			campaigns, err := coupons.RollupStats(ctx)
+			if err != nil {
+				return err
+			}
+			logger.DebugContext(ctx, "Rolled up coupon stats", slog.Int64("campaigns", campaigns))
+			return nil
+		},
+	}
+}
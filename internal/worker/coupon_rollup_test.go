@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCouponRollupJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.CouponConfig
+		wantSchedule string
+		rollupErr    error
+	}{
+		{name: "Defaults", wantSchedule: DefaultCouponRollupSchedule},
+		{name: "Configured", cfg: config.CouponConfig{RollupSchedule: "@every 1h"}, wantSchedule: "@every 1h"},
+		{name: "Failed", wantSchedule: DefaultCouponRollupSchedule, rollupErr: errors.New("db down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			coupons := mocks.NewMockCouponService(ctrl)
+			coupons.EXPECT().RollupStats(gomock.Any()).Return(int64(4), tt.rollupErr)
+
+			job := NewCouponRollupJob(coupons, tt.cfg, discardLogger)
+			assert.Equal(t, tt.wantSchedule, job.Schedule)
+			require.NoError(t, NewScheduler(nil, discardLogger, nil).Register(job))
+
+			assert.Equal(t, tt.rollupErr, job.Run(context.Background()))
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	// DefaultDigitalDeliverySchedule applies when digital.delivery_schedule is empty.
+	DefaultDigitalDeliverySchedule = "@every 1m"
+
+	// DigitalDeliveryJobName identifies the delivery job in logs, reports and metrics.
+	DigitalDeliveryJobName = "digital-delivery"
+	// digitalDeliveryRunTimeout bounds one pass; leftovers are picked up by the next.
+	digitalDeliveryRunTimeout = 5 * time.Minute
+)
+
+var digitalOrdersDelivered = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "digital_orders_delivered_total",
+		Help: "Total number of paid orders whose digital items were sent",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(digitalOrdersDelivered)
+}
+
+// NewDigitalDeliveryJob returns the job that sends the license keys and
+// download links of paid orders, in batches of cfg.DeliveryBatchSize, across
+// all stores.
+func NewDigitalDeliveryJob(digital service.DigitalFulfillmentService, cfg config.DigitalConfig, logger *slog.Logger) Job {
+	schedule := cfg.DeliverySchedule
+	if schedule == "" {
+		schedule = DefaultDigitalDeliverySchedule
+	}
+
+	return Job{
+		Name:     DigitalDeliveryJobName,
+		Schedule: schedule,
+		Timeout:  digitalDeliveryRunTimeout,
+		Run: func(ctx context.Context) error {
+			delivered, err := digital.DeliverPaid(ctx, cfg.DeliveryBatchSize)
+			digitalOrdersDelivered.Add(float64(delivered))
+			if delivered > 0 {
+				logger.InfoContext(ctx, "Delivered digital orders", slog.Int("count", delivered))
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDigitalDeliveryJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.DigitalConfig
+		wantSchedule string
+		delivered    int
+		deliverErr   error
+	}{
+		{name: "Defaults", wantSchedule: DefaultDigitalDeliverySchedule, delivered: 2},
+		{name: "Configured", cfg: config.DigitalConfig{DeliverySchedule: "*/5 * * * *", DeliveryBatchSize: 20}, wantSchedule: "*/5 * * * *"},
+		{name: "PartialPass", wantSchedule: DefaultDigitalDeliverySchedule, delivered: 1, deliverErr: errors.New("queue down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			digital := mocks.NewMockDigitalFulfillmentService(ctrl)
+			digital.EXPECT().DeliverPaid(gomock.Any(), tt.cfg.DeliveryBatchSize).Return(tt.delivered, tt.deliverErr)
+
+			job := NewDigitalDeliveryJob(digital, tt.cfg, discardLogger)
+			assert.Equal(t, tt.wantSchedule, job.Schedule)
+			require.NoError(t, NewScheduler(nil, discardLogger, nil).Register(job))
+
+			before := testutil.ToFloat64(digitalOrdersDelivered)
+			err := job.Run(context.Background())
+			assert.Equal(t, tt.deliverErr, err)
+			assert.Equal(t, before+float64(tt.delivered), testutil.ToFloat64(digitalOrdersDelivered))
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	// DefaultDisputeReminderSchedule applies when payment.disputes.reminder_schedule is empty.
+	DefaultDisputeReminderSchedule = "@every 1h"
+
+	// DisputeReminderJobName identifies the dispute reminder job in logs, reports and metrics.
+	DisputeReminderJobName = "dispute-reminder"
+	// disputeReminderRunTimeout bounds one pass; leftovers are picked up by the next.
+	disputeReminderRunTimeout = 5 * time.Minute
+)
+
+// NewDisputeReminderJob returns the job that reminds admins of the payment
+// disputes whose evidence is due soon, across all stores.
+func NewDisputeReminderJob(reminder service.DisputeReminder, cfg config.DisputeConfig, logger *slog.Logger) Job {
+	schedule := cfg.ReminderSchedule
+	if schedule == "" {
+		schedule = DefaultDisputeReminderSchedule
+	}
+
+	return Job{
+		Name:     DisputeReminderJobName,
+		Schedule: schedule,
+		Timeout:  disputeReminderRunTimeout,
+		Run: func(ctx context.Context) error {
+			reminded, err := reminder.RemindDue(ctx)
+			if reminded > 0 {
+				logger.InfoContext(ctx, "Reminded admins of dispute evidence", slog.Int("disputes", reminded))
+			}
+			return err
+		},
+	}
+}
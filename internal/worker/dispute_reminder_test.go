@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDisputeReminderJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.DisputeConfig
+		wantSchedule string
+		remindErr    error
+	}{
+		{name: "Defaults", wantSchedule: DefaultDisputeReminderSchedule},
+		{name: "Configured", cfg: config.DisputeConfig{ReminderSchedule: "0 9 * * *"}, wantSchedule: "0 9 * * *"},
+		{name: "Fails", wantSchedule: DefaultDisputeReminderSchedule, remindErr: errors.New("db down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			reminder := mocks.NewMockDisputeReminder(ctrl)
+			reminder.EXPECT().RemindDue(gomock.Any()).Return(1, tt.remindErr)
+
+			job := NewDisputeReminderJob(reminder, tt.cfg, discardLogger)
+			assert.Equal(t, tt.wantSchedule, job.Schedule)
+			require.NoError(t, NewScheduler(nil, discardLogger, nil).Register(job))
+			assert.Equal(t, tt.remindErr, job.Run(context.Background()))
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	// DefaultEventRelaySchedule applies when events.relay_schedule is empty.
+	DefaultEventRelaySchedule = "@every 5s"
+
+	// EventRelayJobName identifies the relay in logs, reports and metrics.
+	EventRelayJobName = "event-relay"
+	eventRelayJitter  = time.Second
+	// eventRelayRunTimeout bounds one run; events left over are published
+	// by the next.
+	eventRelayRunTimeout = 5 * time.Minute
+)
+
+var eventsPublished = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "domain_events_published_total",
+		Help: "Total number of domain events published from the outbox",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(eventsPublished)
+}
+
+// NewEventRelayJob returns the job that publishes the domain events recorded
+// in the outbox to the message bus.
+func NewEventRelayJob(relay service.EventRelay, cfg config.EventsConfig, logger *slog.Logger) Job {
+	schedule := cfg.RelaySchedule
+	if schedule == "" {
+		schedule = DefaultEventRelaySchedule
+	}
+	opts := service.EventRelayOptions{BatchSize: cfg.BatchSize, Retention: cfg.Retention}
+
+	return Job{
+		Name:     EventRelayJobName,
+		Schedule: schedule,
+		Jitter:   eventRelayJitter,
+		Timeout:  eventRelayRunTimeout,
+		Run: func(ctx context.Context) error {
+			report, err := relay.Relay(ctx, opts)
+			if report == nil {
+				return err
+			}
+			eventsPublished.Add(float64(report.Published))
+			if report.Purged > 0 {
+				logger.InfoContext(ctx, "Published domain events purged",
+					slog.Int64("purged", report.Purged),
+				)
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestEventRelayJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.EventsConfig
+		wantSchedule string
+		wantOpts     service.EventRelayOptions
+		report       *service.EventRelayReport
+		relayErr     error
+	}{
+		{
+			name:         "Defaults",
+			wantSchedule: DefaultEventRelaySchedule,
+			report:       &service.EventRelayReport{Published: 4, Purged: 2},
+		},
+		{
+			name:         "Configured",
+			cfg:          config.EventsConfig{RelaySchedule: "@every 1s", BatchSize: 20, Retention: time.Hour},
+			wantSchedule: "@every 1s",
+			wantOpts:     service.EventRelayOptions{BatchSize: 20, Retention: time.Hour},
+			report:       &service.EventRelayReport{Published: 20},
+		},
+		{
+			name:         "PartialRunStillCounted",
+			wantSchedule: DefaultEventRelaySchedule,
+			report:       &service.EventRelayReport{Published: 1},
+			relayErr:     errors.New("rabbitmq not connected"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			relay := mocks.NewMockEventRelay(ctrl)
+			relay.EXPECT().Relay(gomock.Any(), tt.wantOpts).Return(tt.report, tt.relayErr)
+
+			job := NewEventRelayJob(relay, tt.cfg, discardLogger)
+			assert.Equal(t, tt.wantSchedule, job.Schedule)
+			require.NoError(t, NewScheduler(nil, discardLogger, nil).Register(job))
+
+			published := testutil.ToFloat64(eventsPublished)
+			err := job.Run(context.Background())
+			assert.Equal(t, tt.relayErr, err)
+			assert.Equal(t, published+float64(tt.report.Published), testutil.ToFloat64(eventsPublished))
+		})
+	}
+}
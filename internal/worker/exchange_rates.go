@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	// DefaultExchangeRateSchedule applies when currency.rates_schedule is empty.
+	DefaultExchangeRateSchedule = "@every 1h"
+
+	// ExchangeRateJobName identifies the rate refresher in logs, reports and metrics.
+	ExchangeRateJobName = "exchange-rate-refresher"
+	// exchangeRateJitter spreads calls to the rates API from the top of the hour.
+	exchangeRateJitter = time.Minute
+	// exchangeRateRunTimeout bounds one fetch; a failed one is retried next time.
+	exchangeRateRunTimeout = time.Minute
+)
+
+var exchangeRatesUpdated = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "exchange_rates_updated_total",
+		Help: "Total number of exchange rates fetched and stored",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(exchangeRatesUpdated)
+}
+
+// NewExchangeRateJob returns the job that fetches the exchange rates of the
+// supported currencies. Register it only when cfg.RatesURL is set.
+// cron_job_last_success_timestamp_seconds tells how old the rates are.
+func NewExchangeRateJob(currencies service.CurrencyService, cfg config.CurrencyConfig, logger *slog.Logger) Job {
+	schedule := cfg.RatesSchedule
+	if schedule == "" {
+		schedule = DefaultExchangeRateSchedule
+	}
+
+	return Job{
+		Name:     ExchangeRateJobName,
+		Schedule: schedule,
+		Jitter:   exchangeRateJitter,
+		Timeout:  exchangeRateRunTimeout,
+		Run: func(ctx context.Context) error {
+			updated, err := currencies.RefreshRates(ctx)
+			exchangeRatesUpdated.Add(float64(updated))
+			logger.DebugContext(ctx, "Refreshed exchange rates", slog.Int("count", updated))
+			return err
+		},
+	}
+}
@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExchangeRateJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.CurrencyConfig
+		wantSchedule string
+		updated      int
+		refreshErr   error
+	}{
+		{name: "Defaults", wantSchedule: DefaultExchangeRateSchedule, updated: 3},
+		{name: "Configured", cfg: config.CurrencyConfig{RatesSchedule: "0 */6 * * *"}, wantSchedule: "0 */6 * * *", updated: 3},
+		{name: "PartialRefresh", wantSchedule: DefaultExchangeRateSchedule, updated: 1, refreshErr: errors.New("no rate for JPY")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			currencies := mocks.NewMockCurrencyService(ctrl)
+			currencies.EXPECT().RefreshRates(gomock.Any()).Return(tt.updated, tt.refreshErr)
+
+			job := NewExchangeRateJob(currencies, tt.cfg, discardLogger)
+			assert.Equal(t, tt.wantSchedule, job.Schedule)
+			require.NoError(t, NewScheduler(nil, discardLogger, nil).Register(job))
+
+			before := testutil.ToFloat64(exchangeRatesUpdated)
+			assert.Equal(t, tt.refreshErr, job.Run(context.Background()))
+			assert.Equal(t, before+float64(tt.updated), testutil.ToFloat64(exchangeRatesUpdated))
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	// DefaultFailedMessageReplaySchedule applies when worker.replay_schedule
+	// is empty.
+	DefaultFailedMessageReplaySchedule = "@every 30s"
+
+	// FailedMessageReplayJobName identifies the replayer in logs, reports and metrics.
+	FailedMessageReplayJobName = "failed-message-replayer"
+	failedMessageReplayJitter  = 2 * time.Second
+	// failedMessageReplayRunTimeout bounds one run; messages left over are
+	// published by the next.
+	failedMessageReplayRunTimeout = 5 * time.Minute
+)
+
+// NewFailedMessageReplayJob returns the job that publishes the failed
+// messages an admin replays to their queue again.
+func NewFailedMessageReplayJob(replayer service.FailedMessageReplayer, cfg config.WorkerConfig, logger *slog.Logger) Job {
+	schedule := cfg.ReplaySchedule
+	if schedule == "" {
+		schedule = DefaultFailedMessageReplaySchedule
+	}
+
+	return Job{
+		Name:     FailedMessageReplayJobName,
+		Schedule: schedule,
+		Jitter:   failedMessageReplayJitter,
+		Timeout:  failedMessageReplayRunTimeout,
+		Run: func(ctx context.Context) error {
+			report, err := replayer.Replay(ctx, cfg.ReplayBatchSize)
+			if report != nil && report.Replayed > 0 {
+				logger.InfoContext(ctx, "Failed messages replayed", slog.Int("replayed", report.Replayed))
+			}
+			return err
+		},
+	}
+}
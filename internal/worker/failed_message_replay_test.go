@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFailedMessageReplayJob(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           config.WorkerConfig
+		wantSchedule  string
+		wantBatchSize int
+		report        *service.FailedMessageReplayReport
+		replayErr     error
+	}{
+		{
+			name:         "Defaults",
+			wantSchedule: DefaultFailedMessageReplaySchedule,
+			report:       &service.FailedMessageReplayReport{Replayed: 2},
+		},
+		{
+			name:          "Configured",
+			cfg:           config.WorkerConfig{ReplaySchedule: "@every 1m", ReplayBatchSize: 10},
+			wantSchedule:  "@every 1m",
+			wantBatchSize: 10,
+			report:        &service.FailedMessageReplayReport{},
+		},
+		{
+			name:         "PublishFails",
+			wantSchedule: DefaultFailedMessageReplaySchedule,
+			report:       &service.FailedMessageReplayReport{Replayed: 1},
+			replayErr:    errors.New("rabbitmq not connected"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			replayer := mocks.NewMockFailedMessageReplayer(ctrl)
+			replayer.EXPECT().Replay(gomock.Any(), tt.wantBatchSize).Return(tt.report, tt.replayErr)
+
+			job := NewFailedMessageReplayJob(replayer, tt.cfg, discardLogger)
+			assert.Equal(t, tt.wantSchedule, job.Schedule)
+			require.NoError(t, NewScheduler(nil, discardLogger, nil).Register(job))
+			assert.Equal(t, tt.replayErr, job.Run(context.Background()))
+		})
+	}
+}
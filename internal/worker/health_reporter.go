@@ -0,0 +1,103 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// healthReportTimeout bounds one report; the broker answers each queue
+// inspection on a channel of its own.
+const healthReportTimeout = 10 * time.Second
+
+// Queues lists the queues of the worker whose depths HealthReporter reports.
+var Queues = []string{
+	OrderQueue,
+	notification.Queue, notification.RetryQueue, notification.DeadLetterQueue,
+	OrderSummaryQueue, OrderSummaryRetryQueue,
+	PickTaskQueue, PickTaskRetryQueue,
+	CartConversionQueue, CartConversionRetryQueue,
+}
+
+// HealthReporter reports the broker health of this worker for the admin
+// diagnostics. Unlike a scheduled Job it runs on every instance, since each
+// has a connection of its own.
+type HealthReporter struct {
+	reporter service.MQHealthReporter
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewHealthReporter creates a HealthReporter reporting every
+// worker.health_report_interval.
+func NewHealthReporter(reporter service.MQHealthReporter, cfg config.WorkerConfig, logger *slog.Logger) *HealthReporter {
+	interval := cfg.HealthReportInterval
+	if interval <= 0 {
+		interval = service.DefaultMQHealthReportInterval
+	}
+	return &HealthReporter{reporter: reporter, interval: interval, logger: logger}
+}
+
+// Start reports right away and then every interval until Stop.
+func (h *HealthReporter) Start() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cancel != nil {
+		return fmt.Errorf("health reporter already started")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel, h.done = cancel, make(chan struct{})
+
+	go func() {
+		defer close(h.done)
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+		for {
+			h.report(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops reporting and waits for a report in progress until ctx is done.
+// The last report expires by itself.
+func (h *HealthReporter) Stop(ctx context.Context) error {
+	h.mu.Lock()
+	cancel, done := h.cancel, h.done
+	h.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("health reporter did not stop: %w", ctx.Err())
+	}
+}
+
+func (h *HealthReporter) report(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, healthReportTimeout)
+	defer cancel()
+	if err := h.reporter.Report(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		h.logger.WarnContext(ctx, "Failed to report broker health", logger.Err(err))
+	}
+}
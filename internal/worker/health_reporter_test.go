@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHealthReporter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	reporter := mocks.NewMockMQHealthReporter(ctrl)
+	reported := make(chan struct{}, 1)
+	reporter.EXPECT().Report(gomock.Any()).DoAndReturn(func(context.Context) error {
+		select {
+		case reported <- struct{}{}:
+		default:
+		}
+		return nil
+	}).MinTimes(1)
+
+	h := NewHealthReporter(reporter, config.WorkerConfig{}, discardLogger)
+	assert.Equal(t, service.DefaultMQHealthReportInterval, h.interval)
+
+	require.NoError(t, h.Start())
+	assert.Error(t, h.Start(), "a reporter starts once")
+	select {
+	case <-reported:
+	case <-time.After(time.Second):
+		t.Fatal("the first report is not sent on start")
+	}
+	require.NoError(t, h.Stop(context.Background()))
+}
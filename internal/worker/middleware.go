@@ -0,0 +1,158 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/errreport"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/mq"
+	"golang.org/x/time/rate"
+)
+
+var failedMessagesArchived = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "failed_messages_archived_total",
+		Help: "Total number of messages rejected for good and archived for replay by queue",
+	},
+	[]string{"queue"},
+)
+
+func init() {
+	prometheus.MustRegister(failedMessagesArchived)
+}
+
+// Handler processes the body of a message consumed from a queue. Returning
+// an error rejects the message.
+type Handler func(ctx context.Context, body []byte) error
+
+// Middleware wraps the Handler of queue with behaviour shared by consumers,
+// the way gin middleware wraps HTTP handlers.
+type Middleware func(queue string, next Handler) Handler
+
+// chain returns h wrapped in middlewares, the first one outermost.
+func chain(queue string, h Handler, middlewares ...Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](queue, h)
+	}
+	return h
+}
+
+// consume starts consuming queue from broker with h wrapped in middlewares,
+// processing up to cfg.Concurrency messages at once and starting at most
+// cfg.RateLimit per second.
+func consume(broker mq.RabbitMQ, queue string, cfg config.ConsumerConfig, h Handler, middlewares ...Middleware) error {
+	if cfg.RateLimit > 0 {
+		limiter := rate.NewLimiter(rate.Limit(cfg.RateLimit), max(cfg.Concurrency, 1))
+		middlewares = append([]Middleware{RateLimit(limiter)}, middlewares...)
+	}
+	return broker.Consume(queue, chain(queue, h, middlewares...), mq.ConsumeOptions{Concurrency: cfg.Concurrency})
+}
+
+// RateLimit holds every message until limiter lets it start. Messages held
+// count against the consumer's prefetch, so the broker sends no more until
+// they are processed and the queue absorbs the burst.
+func RateLimit(limiter *rate.Limiter) Middleware {
+	return func(queue string, next Handler) Handler {
+		return func(ctx context.Context, body []byte) error {
+			if err := limiter.Wait(ctx); err != nil {
+				return fmt.Errorf("failed to wait for rate limit of %s: %w", queue, err)
+			}
+			return next(ctx, body)
+		}
+	}
+}
+
+// LogContext attaches the queue and message ID of the delivery to the
+// handler's context, so that every record it logs with a *Context method
+// carries them along with the trace ID of the consumer span. The correlation
+// ID of the message, or its ID if it has none, is attached too and passed on
+// to the messages the handler publishes.
+func LogContext(queue string, next Handler) Handler {
+	return func(ctx context.Context, body []byte) error {
+		attrs := []slog.Attr{slog.String("queue", queue)}
+		if d, ok := mq.DeliveryFromContext(ctx); ok {
+			attrs = append(attrs, slog.String("message_id", d.MessageID))
+			correlationID := d.CorrelationID
+			if correlationID == "" {
+				correlationID = d.MessageID
+			}
+			ctx = logger.WithCorrelationID(ctx, correlationID)
+		}
+		return next(logger.WithAttrs(ctx, attrs...), body)
+	}
+}
+
+// rejection is a handler error that retrying cannot fix, e.g. a body that
+// does not decode.
+type rejection struct {
+	err error
+}
+
+func (r *rejection) Error() string { return r.err.Error() }
+func (r *rejection) Unwrap() error { return r.err }
+
+// reject marks err as a permanent rejection of the message, which Archived
+// keeps for an admin to fix and replay.
+func reject(err error) error {
+	return &rejection{err: err}
+}
+
+// Archived archives the messages the handler rejects with reject, with their
+// headers and the error, and acknowledges them. Other errors, and rejections
+// that cannot be archived, still reject the message.
+func Archived(archive service.FailedMessageService) Middleware {
+	return func(queue string, next Handler) Handler {
+		return func(ctx context.Context, body []byte) error {
+			err := next(ctx, body)
+			var r *rejection
+			if !errors.As(err, &r) {
+				return err
+			}
+
+			msg := &service.RejectedMessage{Queue: queue, Body: body, Err: r.err}
+			if d, ok := mq.DeliveryFromContext(ctx); ok {
+				msg.MessageID, msg.CorrelationID = d.MessageID, d.CorrelationID
+				msg.Headers = make(map[string]string, len(d.Headers))
+				for key, value := range d.Headers {
+					msg.Headers[key] = fmt.Sprint(value)
+				}
+			}
+			if archiveErr := archive.Archive(context.WithoutCancel(ctx), msg); archiveErr != nil {
+				return errors.Join(err, fmt.Errorf("failed to archive rejected message: %w", archiveErr))
+			}
+			failedMessagesArchived.WithLabelValues(queue).Inc()
+			return nil
+		}
+	}
+}
+
+// Reported sends handler errors and panics to reporter, tagged with the
+// queue. Returned errors still reject the message; panics are re-raised after
+// the report is flushed.
+func Reported(reporter errreport.Reporter) Middleware {
+	return func(queue string, next Handler) Handler {
+		tags := map[string]string{"queue": queue}
+		return func(ctx context.Context, body []byte) error {
+			defer func() {
+				if rec := recover(); rec != nil {
+					reporter.CapturePanic(ctx, rec, tags)
+					reporter.Flush(2 * time.Second)
+					panic(rec)
+				}
+			}()
+
+			err := next(ctx, body)
+			if err != nil {
+				reporter.CaptureError(ctx, err, tags)
+			}
+			return err
+		}
+	}
+}
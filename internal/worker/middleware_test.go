@@ -0,0 +1,209 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/mq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/time/rate"
+)
+
+func TestLogContext(t *testing.T) {
+	tests := []struct {
+		name            string
+		delivery        *mq.Delivery
+		wantAttrs       map[string]any
+		wantCorrelation string
+	}{
+		{
+			name:            "Correlated",
+			delivery:        &mq.Delivery{Queue: "orders.created", MessageID: "msg-1", CorrelationID: "req-1"},
+			wantAttrs:       map[string]any{"queue": "orders.created", "message_id": "msg-1", "correlation_id": "req-1"},
+			wantCorrelation: "req-1",
+		},
+		{
+			name:            "FirstOfItsChain",
+			delivery:        &mq.Delivery{Queue: "orders.created", MessageID: "msg-1"},
+			wantAttrs:       map[string]any{"queue": "orders.created", "message_id": "msg-1", "correlation_id": "msg-1"},
+			wantCorrelation: "msg-1",
+		},
+		{
+			name:      "NoDelivery",
+			wantAttrs: map[string]any{"queue": "orders.created"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log := logger.New(&buf, logger.FormatJSON, slog.LevelInfo)
+			ctx := context.Background()
+			if tt.delivery != nil {
+				ctx = mq.WithDelivery(ctx, *tt.delivery)
+			}
+
+			handler := chain("orders.created", func(ctx context.Context, _ []byte) error {
+				assert.Equal(t, tt.wantCorrelation, logger.CorrelationIDFromContext(ctx), "passed on to what the handler publishes")
+				log.InfoContext(logger.WithAttrs(ctx, slog.Uint64("order_id", 7)), "Processing order event")
+				return nil
+			}, LogContext)
+			require.NoError(t, handler(ctx, []byte(`{}`)))
+
+			var record map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+			for key, want := range tt.wantAttrs {
+				assert.Equal(t, want, record[key], key)
+			}
+			assert.Equal(t, float64(7), record["order_id"])
+		})
+	}
+}
+
+func TestReported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	reporter := mocks.NewMockReporter(ctrl)
+	errDB := errors.New("db down")
+	reporter.EXPECT().CaptureError(gomock.Any(), errDB, map[string]string{"queue": "orders.created"})
+
+	err := chain("orders.created", func(context.Context, []byte) error { return errDB }, Reported(reporter))(context.Background(), nil)
+	assert.ErrorIs(t, err, errDB, "the message is still rejected")
+
+	reporter.EXPECT().CapturePanic(gomock.Any(), "boom", map[string]string{"queue": "orders.created"})
+	reporter.EXPECT().Flush(gomock.Any()).Return(true)
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = chain("orders.created", func(context.Context, []byte) error { panic("boom") }, Reported(reporter))(context.Background(), nil)
+	})
+}
+
+func TestArchived(t *testing.T) {
+	errBad := errors.New("invalid character")
+	errDB := errors.New("db down")
+
+	tests := []struct {
+		name       string
+		handlerErr error
+		mockSetup  func(archive *mocks.MockFailedMessageService)
+		wantErr    []error
+	}{
+		{
+			name: "Handled",
+		},
+		{
+			name:       "Retried",
+			handlerErr: errDB,
+			wantErr:    []error{errDB},
+		},
+		{
+			name:       "Rejected",
+			handlerErr: reject(errBad),
+			mockSetup: func(archive *mocks.MockFailedMessageService) {
+				archive.EXPECT().Archive(gomock.Any(), &service.RejectedMessage{
+					Queue:         "orders.created",
+					MessageID:     "msg-1",
+					CorrelationID: "req-1",
+					Headers:       map[string]string{"traceparent": "00-abc-def-01", "x-death": "2"},
+					Body:          []byte(`{"sku_id":`),
+					Err:           errBad,
+				}).Return(nil)
+			},
+		},
+		{
+			name:       "ArchiveFails",
+			handlerErr: reject(errBad),
+			mockSetup: func(archive *mocks.MockFailedMessageService) {
+				archive.EXPECT().Archive(gomock.Any(), gomock.Any()).Return(errDB)
+			},
+			wantErr: []error{errBad, errDB},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			archive := mocks.NewMockFailedMessageService(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(archive)
+			}
+			ctx := mq.WithDelivery(context.Background(), mq.Delivery{
+				Queue:         "orders.created",
+				MessageID:     "msg-1",
+				CorrelationID: "req-1",
+				Headers:       map[string]any{"traceparent": "00-abc-def-01", "x-death": 2},
+			})
+
+			handler := chain("orders.created", func(context.Context, []byte) error { return tt.handlerErr }, Archived(archive))
+			err := handler(ctx, []byte(`{"sku_id":`))
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err, "archived messages are acked")
+				return
+			}
+			for _, want := range tt.wantErr {
+				assert.ErrorIs(t, err, want)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	var handled int
+	handler := chain("orders.created", func(context.Context, []byte) error {
+		handled++
+		return nil
+	}, RateLimit(rate.NewLimiter(rate.Every(time.Hour), 1)))
+
+	require.NoError(t, handler(context.Background(), nil), "the burst starts right away")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := handler(ctx, nil)
+	assert.ErrorContains(t, err, "failed to wait for rate limit of orders.created", "the next message waits for the limiter")
+	assert.Equal(t, 1, handled)
+}
+
+// consumeRecorder records the consumer started by consume.
+type consumeRecorder struct {
+	mq.RabbitMQ
+	queue   string
+	handler func(ctx context.Context, body []byte) error
+	opts    mq.ConsumeOptions
+}
+
+func (r *consumeRecorder) Consume(queue string, handler func(ctx context.Context, body []byte) error, opts mq.ConsumeOptions) error {
+	r.queue, r.handler, r.opts = queue, handler, opts
+	return nil
+}
+
+func TestConsume(t *testing.T) {
+	broker := &consumeRecorder{}
+	var queues []string
+	record := func(queue string, next Handler) Handler {
+		queues = append(queues, queue)
+		return next
+	}
+	handled := 0
+	err := consume(broker, "orders.created", config.ConsumerConfig{Concurrency: 3, RateLimit: 10}, func(context.Context, []byte) error {
+		handled++
+		return nil
+	}, record)
+	require.NoError(t, err)
+
+	assert.Equal(t, "orders.created", broker.queue)
+	assert.Equal(t, mq.ConsumeOptions{Concurrency: 3}, broker.opts)
+	assert.Equal(t, []string{"orders.created"}, queues, "middlewares wrap the handler of the queue")
+	for range 3 {
+		require.NoError(t, broker.handler(context.Background(), nil), "up to Concurrency messages start at once")
+	}
+	assert.Equal(t, 3, handled)
+}
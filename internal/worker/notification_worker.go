@@ -0,0 +1,177 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/cache"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/errreport"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/mq"
+)
+
+const (
+	// DefaultNotificationMaxAttempts applies when config.NotificationConfig
+	// leaves MaxAttempts zero.
+	DefaultNotificationMaxAttempts = 5
+	// notificationIdempotencyTTL outlasts every retry of a delivery.
+	notificationIdempotencyTTL = 24 * time.Hour
+)
+
+var (
+	notificationDeliveries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_deliveries_total",
+			Help: "Total number of notification delivery attempts by channel, kind and result",
+		},
+		[]string{"channel", "kind", "result"}, // sent, opted_out, no_recipient, suppressed, bounced, failed
+	)
+
+	notificationDeliveriesParked = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_deliveries_parked_total",
+			Help: "Total number of deliveries moved to the failed queue after permanent or repeated failures",
+		},
+		[]string{"channel", "kind"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(notificationDeliveries, notificationDeliveriesParked)
+}
+
+// NotificationWorker delivers queued notifications over their channel. A
+// transient failure sends the delivery through the retry queue, which
+// redelivers it after notification.RetryDelay; permanent failures and
+// deliveries out of attempts are rejected into the failed queue. The outcome
+// of every attempt is recorded with notification.Service.Track.
+type NotificationWorker struct {
+	broker        mq.RabbitMQ
+	publisher     notification.Publisher
+	notifications notification.Service
+	cache         cache.Cache
+	maxAttempts   int
+	cfg           config.ConsumerConfig
+	logger        *slog.Logger
+	reporter      errreport.Reporter
+}
+
+// NewNotificationWorker creates a NotificationWorker trying each delivery up to
+// maxAttempts times.
+func NewNotificationWorker(broker mq.RabbitMQ, notifications notification.Service, cache cache.Cache, maxAttempts int, cfg config.ConsumerConfig, logger *slog.Logger, reporter errreport.Reporter) *NotificationWorker {
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultNotificationMaxAttempts
+	}
+	if reporter == nil {
+		reporter = errreport.Nop()
+	}
+	return &NotificationWorker{
+		broker:        broker,
+		publisher:     broker,
+		notifications: notifications,
+		cache:         cache,
+		maxAttempts:   maxAttempts,
+		cfg:           cfg,
+		logger:        logger,
+		reporter:      reporter,
+	}
+}
+
+// Start declares the notification queues and begins consuming.
+func (w *NotificationWorker) Start() error {
+	w.logger.Info("Starting NotificationWorker...")
+	if err := notification.DeclareQueues(w.broker); err != nil {
+		return fmt.Errorf("failed to declare notification queues: %w", err)
+	}
+	return consume(w.broker, notification.Queue, w.cfg, w.handleDelivery, LogContext)
+}
+
+// handleDelivery delivers one notification. Returning an error rejects the
+// message into the failed queue, so transient failures are retried by
+// republishing instead.
+func (w *NotificationWorker) handleDelivery(ctx context.Context, body []byte) error {
+	var d notification.Delivery
+	if err := json.Unmarshal(body, &d); err != nil || d.ID == "" {
+		w.logger.ErrorContext(ctx, "Poison Pill: Failed to decode notification message", logger.Err(err), slog.String("body", string(body)))
+		return errors.New("malformed notification message")
+	}
+	ctx = logger.WithAttrs(ctx,
+		slog.String("delivery_id", d.ID),
+		slog.String("kind", string(d.Kind)),
+		slog.String("channel", string(d.Channel)),
+		slog.Int("attempt", d.Attempt+1),
+	)
+
+	// Idempotency Check: a redelivered message must not notify the user twice.
+	idempotencyKey := fmt.Sprintf("notification:processed:%s", d.ID)
+	acquired, err := w.cache.SetNX(ctx, idempotencyKey, "1", notificationIdempotencyTTL)
+	if err != nil {
+		w.logger.ErrorContext(ctx, "Transient: Failed to check idempotency key", logger.Err(err))
+		return w.retry(ctx, &d, err)
+	}
+	if !acquired {
+		w.logger.InfoContext(ctx, "Duplicate ignored: Notification already processed")
+		return nil
+	}
+
+	result, err := w.notifications.Deliver(ctx, &d)
+	notificationDeliveries.WithLabelValues(string(d.Channel), string(d.Kind), string(result)).Inc()
+	if err == nil {
+		w.logger.DebugContext(ctx, "Notification processed", slog.String("result", string(result)))
+		w.track(ctx, &d, result, nil)
+		return nil
+	}
+
+	// Let the retry, or a manual replay of the failed queue, deliver it again.
+	if delErr := w.cache.Del(ctx, idempotencyKey); delErr != nil {
+		w.logger.ErrorContext(ctx, "Failed to rollback idempotency key", logger.Err(delErr))
+	}
+	if notification.IsPermanent(err) {
+		return w.park(ctx, &d, err)
+	}
+	w.logger.WarnContext(ctx, "Transient: Failed to deliver notification", logger.Err(err))
+	return w.retry(ctx, &d, err)
+}
+
+// retry republishes d to the retry queue, or parks it once it is out of attempts.
+func (w *NotificationWorker) retry(ctx context.Context, d *notification.Delivery, cause error) error {
+	if d.Attempt+1 >= w.maxAttempts {
+		return w.park(ctx, d, fmt.Errorf("giving up after %d attempts: %w", w.maxAttempts, cause))
+	}
+
+	next := *d
+	next.Attempt++
+	body, err := json.Marshal(next)
+	if err != nil {
+		return w.park(ctx, d, fmt.Errorf("failed to encode retry: %w", err))
+	}
+	if err := w.publisher.Publish(ctx, "", notification.RetryQueue, body); err != nil {
+		return w.park(ctx, d, errors.Join(cause, fmt.Errorf("failed to schedule retry: %w", err)))
+	}
+	w.track(ctx, d, notification.ResultRetrying, cause)
+	return nil
+}
+
+// park reports err and returns it so that the message is rejected into the failed queue.
+func (w *NotificationWorker) park(ctx context.Context, d *notification.Delivery, err error) error {
+	notificationDeliveriesParked.WithLabelValues(string(d.Channel), string(d.Kind)).Inc()
+	w.reporter.CaptureError(ctx, err, map[string]string{"queue": notification.Queue, "kind": string(d.Kind), "channel": string(d.Channel)})
+	w.logger.ErrorContext(ctx, "Terminal: Notification parked in failed queue", logger.Err(err))
+	w.track(ctx, d, notification.ResultFailed, err)
+	return err
+}
+
+// track records the outcome of an attempt. The record is informational, so a
+// failure to write it never changes how the message is acknowledged.
+func (w *NotificationWorker) track(ctx context.Context, d *notification.Delivery, status notification.Result, cause error) {
+	if err := w.notifications.Track(ctx, d, status, cause); err != nil {
+		w.logger.ErrorContext(ctx, "Failed to record delivery status", logger.Err(err), slog.String("status", string(status)))
+	}
+}
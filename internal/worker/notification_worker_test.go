@@ -0,0 +1,148 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNotificationWorker_HandleDelivery(t *testing.T) {
+	const maxAttempts = 3
+	const key = "notification:processed:delivery-1"
+
+	delivery := func(attempt int) []byte {
+		body, err := json.Marshal(notification.Delivery{ID: "delivery-1", UserID: 1, Kind: notification.KindShipment, Channel: notification.ChannelSMS, Data: json.RawMessage(`{}`), Attempt: attempt})
+		require.NoError(t, err)
+		return body
+	}
+
+	type deps struct {
+		svc       *mocks.MockService
+		cache     *mocks.MockCache
+		publisher *mocks.MockPublisher
+		reporter  *mocks.MockReporter
+	}
+
+	tests := []struct {
+		name    string
+		body    []byte
+		setup   func(d deps)
+		wantErr bool
+	}{
+		{
+			name: "Delivered",
+			body: delivery(0),
+			setup: func(d deps) {
+				d.cache.EXPECT().SetNX(gomock.Any(), key, "1", notificationIdempotencyTTL).Return(true, nil)
+				d.svc.EXPECT().Deliver(gomock.Any(), gomock.Any()).Return(notification.ResultSent, nil)
+				d.svc.EXPECT().Track(gomock.Any(), gomock.Any(), notification.ResultSent, nil).Return(nil)
+			},
+		},
+		{
+			name: "TrackFailureIgnored",
+			body: delivery(0),
+			setup: func(d deps) {
+				d.cache.EXPECT().SetNX(gomock.Any(), key, "1", notificationIdempotencyTTL).Return(true, nil)
+				d.svc.EXPECT().Deliver(gomock.Any(), gomock.Any()).Return(notification.ResultNoRecipient, nil)
+				d.svc.EXPECT().Track(gomock.Any(), gomock.Any(), notification.ResultNoRecipient, nil).Return(errors.New("db down"))
+			},
+		},
+		{
+			name: "Duplicate",
+			body: delivery(0),
+			setup: func(d deps) {
+				d.cache.EXPECT().SetNX(gomock.Any(), key, "1", notificationIdempotencyTTL).Return(false, nil)
+			},
+		},
+		{
+			name: "TransientFailureRetries",
+			body: delivery(1),
+			setup: func(d deps) {
+				d.cache.EXPECT().SetNX(gomock.Any(), key, "1", notificationIdempotencyTTL).Return(true, nil)
+				d.svc.EXPECT().Deliver(gomock.Any(), gomock.Any()).Return(notification.ResultFailed, errors.New("connection reset"))
+				d.cache.EXPECT().Del(gomock.Any(), key).Return(nil)
+				d.publisher.EXPECT().Publish(gomock.Any(), "", notification.RetryQueue, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _, _ string, body []byte) error {
+						var next notification.Delivery
+						require.NoError(t, json.Unmarshal(body, &next))
+						assert.Equal(t, 2, next.Attempt)
+						assert.Equal(t, notification.ChannelSMS, next.Channel)
+						return nil
+					})
+				d.svc.EXPECT().Track(gomock.Any(), gomock.Any(), notification.ResultRetrying, gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "OutOfAttemptsParks",
+			body: delivery(maxAttempts - 1),
+			setup: func(d deps) {
+				d.cache.EXPECT().SetNX(gomock.Any(), key, "1", notificationIdempotencyTTL).Return(true, nil)
+				d.svc.EXPECT().Deliver(gomock.Any(), gomock.Any()).Return(notification.ResultFailed, errors.New("connection reset"))
+				d.cache.EXPECT().Del(gomock.Any(), key).Return(nil)
+				d.reporter.EXPECT().CaptureError(gomock.Any(), gomock.Any(), gomock.Any())
+				d.svc.EXPECT().Track(gomock.Any(), gomock.Any(), notification.ResultFailed, gomock.Any()).Return(nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "PermanentFailureParks",
+			body: delivery(0),
+			setup: func(d deps) {
+				d.cache.EXPECT().SetNX(gomock.Any(), key, "1", notificationIdempotencyTTL).Return(true, nil)
+				d.svc.EXPECT().Deliver(gomock.Any(), gomock.Any()).Return(notification.ResultFailed, notification.ErrRejected)
+				d.cache.EXPECT().Del(gomock.Any(), key).Return(nil)
+				d.reporter.EXPECT().CaptureError(gomock.Any(), gomock.Any(), gomock.Any())
+				d.svc.EXPECT().Track(gomock.Any(), gomock.Any(), notification.ResultFailed, gomock.Any()).Return(nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "RetryPublishFailureParks",
+			body: delivery(0),
+			setup: func(d deps) {
+				d.cache.EXPECT().SetNX(gomock.Any(), key, "1", notificationIdempotencyTTL).Return(false, errors.New("redis down"))
+				d.publisher.EXPECT().Publish(gomock.Any(), "", notification.RetryQueue, gomock.Any()).Return(errors.New("channel closed"))
+				d.reporter.EXPECT().CaptureError(gomock.Any(), gomock.Any(), gomock.Any())
+				d.svc.EXPECT().Track(gomock.Any(), gomock.Any(), notification.ResultFailed, gomock.Any()).Return(nil)
+			},
+			wantErr: true,
+		},
+		{
+			name:    "MalformedParks",
+			body:    []byte(`{"kind":`),
+			setup:   func(d deps) {},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			d := deps{
+				svc:       mocks.NewMockService(ctrl),
+				cache:     mocks.NewMockCache(ctrl),
+				publisher: mocks.NewMockPublisher(ctrl),
+				reporter:  mocks.NewMockReporter(ctrl),
+			}
+			tt.setup(d)
+
+			w := NewNotificationWorker(nil, d.svc, d.cache, maxAttempts, config.ConsumerConfig{}, discardLogger, d.reporter)
+			w.publisher = d.publisher
+
+			err := w.handleDelivery(context.Background(), tt.body)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
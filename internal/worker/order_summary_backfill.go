@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	// DefaultOrderSummaryBackfillSchedule applies when
+	// order.summary_backfill_schedule is empty.
+	DefaultOrderSummaryBackfillSchedule = "@every 10m"
+
+	// OrderSummaryBackfillJobName identifies the backfill in logs, reports and metrics.
+	OrderSummaryBackfillJobName = "order-summary-backfill"
+	orderSummaryBackfillJitter  = 30 * time.Second
+	// orderSummaryBackfillRunTimeout bounds one batch; leftovers are picked up by the next.
+	orderSummaryBackfillRunTimeout = 5 * time.Minute
+)
+
+var orderSummariesBackfilled = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "order_summaries_backfilled_total",
+		Help: "Total number of order list summaries built for orders that had none",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(orderSummariesBackfilled)
+}
+
+// NewOrderSummaryBackfillJob returns the job that builds the order list
+// summaries of orders that have none, in batches of
+// cfg.SummaryBackfillBatchSize, across all stores.
+func NewOrderSummaryBackfillJob(history service.OrderHistoryService, cfg config.OrderConfig, logger *slog.Logger) Job {
+	schedule := cfg.SummaryBackfillSchedule
+	if schedule == "" {
+		schedule = DefaultOrderSummaryBackfillSchedule
+	}
+
+	return Job{
+		Name:     OrderSummaryBackfillJobName,
+		Schedule: schedule,
+		Jitter:   orderSummaryBackfillJitter,
+		Timeout:  orderSummaryBackfillRunTimeout,
+		Run: func(ctx context.Context) error {
+			backfilled, err := history.Backfill(ctx, cfg.SummaryBackfillBatchSize)
+			orderSummariesBackfilled.Add(float64(backfilled))
+			if backfilled > 0 {
+				logger.InfoContext(ctx, "Backfilled order summaries", slog.Int("count", backfilled))
+			}
+			return err
+		},
+	}
+}
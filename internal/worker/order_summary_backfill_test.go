@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOrderSummaryBackfillJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.OrderConfig
+		wantSchedule string
+		backfilled   int
+		backfillErr  error
+	}{
+		{name: "Defaults", wantSchedule: DefaultOrderSummaryBackfillSchedule, backfilled: 3},
+		{name: "Configured", cfg: config.OrderConfig{SummaryBackfillSchedule: "@hourly", SummaryBackfillBatchSize: 50}, wantSchedule: "@hourly"},
+		{name: "PartialBatch", wantSchedule: DefaultOrderSummaryBackfillSchedule, backfilled: 1, backfillErr: errors.New("db down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			history := mocks.NewMockOrderHistoryService(ctrl)
+			history.EXPECT().Backfill(gomock.Any(), tt.cfg.SummaryBackfillBatchSize).Return(tt.backfilled, tt.backfillErr)
+
+			job := NewOrderSummaryBackfillJob(history, tt.cfg, discardLogger)
+			assert.Equal(t, tt.wantSchedule, job.Schedule)
+			require.NoError(t, NewScheduler(nil, discardLogger, nil).Register(job))
+
+			before := testutil.ToFloat64(orderSummariesBackfilled)
+			err := job.Run(context.Background())
+			assert.Equal(t, tt.backfillErr, err)
+			assert.Equal(t, before+float64(tt.backfilled), testutil.ToFloat64(orderSummariesBackfilled))
+		})
+	}
+}
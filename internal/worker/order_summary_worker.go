@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/errreport"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/mq"
+)
+
+// Queues of the order list read model. Events that fail to project wait in
+// OrderSummaryRetryQueue for OrderSummaryRetryDelay and then dead-letter back
+// into OrderSummaryQueue.
+const (
+	OrderSummaryQueue      = "order_summaries"
+	OrderSummaryRetryQueue = "order_summaries.retry"
+	// OrderSummaryRetryDelay is part of the queue declaration and so cannot
+	// come from config: redeclaring a queue with a different TTL fails.
+	OrderSummaryRetryDelay = time.Minute
+)
+
+// orderEvent is the part of an order event the projection needs.
+type orderEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		OrderID uint64 `json:"order_id,string"`
+	} `json:"data"`
+}
+
+// OrderSummaryWorker keeps the order list read model up to date: every
+// order event rebuilds the summary of its order from the order itself, so
+// duplicate and out-of-order events are harmless.
+type OrderSummaryWorker struct {
+	broker   mq.RabbitMQ
+	history  service.OrderHistoryService
+	archive  service.FailedMessageService
+	cfg      config.ConsumerConfig
+	logger   *slog.Logger
+	reporter errreport.Reporter
+}
+
+// NewOrderSummaryWorker creates an OrderSummaryWorker. Events it cannot
+// decode are kept in archive.
+func NewOrderSummaryWorker(broker mq.RabbitMQ, history service.OrderHistoryService, archive service.FailedMessageService, cfg config.ConsumerConfig, logger *slog.Logger, reporter errreport.Reporter) *OrderSummaryWorker {
+	if reporter == nil {
+		reporter = errreport.Nop()
+	}
+	return &OrderSummaryWorker{broker: broker, history: history, archive: archive, cfg: cfg, logger: logger, reporter: reporter}
+}
+
+// Start declares the queues, binds them to the order events and begins
+// consuming. service.EventsExchange must have been declared.
+func (w *OrderSummaryWorker) Start() error {
+	w.logger.Info("Starting OrderSummaryWorker...")
+	if err := w.broker.DeclareQueue(OrderSummaryQueue, mq.QueueOptions{DeadLetterRoutingKey: OrderSummaryRetryQueue}); err != nil {
+		return err
+	}
+	if err := w.broker.DeclareQueue(OrderSummaryRetryQueue, mq.QueueOptions{MessageTTL: OrderSummaryRetryDelay, DeadLetterRoutingKey: OrderSummaryQueue}); err != nil {
+		return err
+	}
+	if err := w.broker.BindQueue(OrderSummaryQueue, service.EventsExchange, "order.*"); err != nil {
+		return err
+	}
+	return consume(w.broker, OrderSummaryQueue, w.cfg, w.handleEvent, LogContext, Archived(w.archive))
+}
+
+// handleEvent projects the order of one event. Returning an error rejects
+// the message into the retry queue.
+func (w *OrderSummaryWorker) handleEvent(ctx context.Context, body []byte) error {
+	var event orderEvent
+	if err := json.Unmarshal(body, &event); err != nil || event.Data.OrderID == 0 {
+		w.logger.ErrorContext(ctx, "Poison Pill: Failed to decode order event", logger.Err(err), slog.String("body", string(body)))
+		if err == nil {
+			err = errors.New("no order ID")
+		}
+		return reject(fmt.Errorf("failed to decode order event: %w", err)) // Archive for replay
+	}
+
+	if err := w.history.Project(ctx, event.Data.OrderID); err != nil {
+		err = fmt.Errorf("failed to project order %d: %w", event.Data.OrderID, err)
+		w.reporter.CaptureError(ctx, err, map[string]string{"queue": OrderSummaryQueue, "event": event.Type})
+		w.logger.WarnContext(ctx, "Transient: Order summary retried later", logger.Err(err), slog.String("event_id", event.ID))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOrderSummaryWorker_HandleEvent(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		setup        func(history *mocks.MockOrderHistoryService, reporter *mocks.MockReporter)
+		wantErr      bool
+		wantRejected bool
+	}{
+		{
+			name: "Projected",
+			body: `{"id":"evt-1","type":"order.paid","data":{"order_id":"42","status":"paid"}}`,
+			setup: func(history *mocks.MockOrderHistoryService, _ *mocks.MockReporter) {
+				history.EXPECT().Project(gomock.Any(), uint64(42)).Return(nil)
+			},
+		},
+		{
+			name: "RetriedOnFailure",
+			body: `{"id":"evt-1","type":"order.created","data":{"order_id":"42"}}`,
+			setup: func(history *mocks.MockOrderHistoryService, reporter *mocks.MockReporter) {
+				history.EXPECT().Project(gomock.Any(), uint64(42)).Return(errors.New("db down"))
+				reporter.EXPECT().CaptureError(gomock.Any(), gomock.Any(), map[string]string{"queue": OrderSummaryQueue, "event": "order.created"})
+			},
+			wantErr: true,
+		},
+		{name: "Malformed", body: `not json`, wantErr: true, wantRejected: true},
+		{name: "NoOrder", body: `{"id":"evt-1","type":"order.paid","data":{}}`, wantErr: true, wantRejected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			history := mocks.NewMockOrderHistoryService(ctrl)
+			reporter := mocks.NewMockReporter(ctrl)
+			if tt.setup != nil {
+				tt.setup(history, reporter)
+			}
+
+			w := NewOrderSummaryWorker(nil, history, nil, config.ConsumerConfig{}, discardLogger, reporter)
+			err := w.handleEvent(context.Background(), []byte(tt.body))
+			assert.Equal(t, tt.wantErr, err != nil)
+			var r *rejection
+			assert.Equal(t, tt.wantRejected, errors.As(err, &r), "archived for replay")
+		})
+	}
+}
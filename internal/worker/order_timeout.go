@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+// Order timeout defaults, used where config.OrderConfig leaves a field zero.
+const (
+	DefaultPaymentTimeout     = 30 * time.Minute
+	DefaultOrderSweepSchedule = "@every 1m"
+
+	// OrderTimeoutJobName identifies the sweeper in logs, reports and metrics.
+	OrderTimeoutJobName = "order-timeout-sweeper"
+	// orderSweepJitter spreads sweeps from the top of the minute.
+	orderSweepJitter = 10 * time.Second
+	// orderSweepRunTimeout bounds one sweep; leftovers are picked up by the next.
+	orderSweepRunTimeout = 5 * time.Minute
+)
+
+var ordersExpired = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "orders_expired_total",
+		Help: "Total number of pending orders cancelled after the payment timeout",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(ordersExpired)
+}
+
+// NewOrderTimeoutJob returns the job that cancels orders still pending after
+// the payment timeout and restores their stock, in batches of
+// cfg.SweepBatchSize. Orders paid while a sweep runs are left alone.
+func NewOrderTimeoutJob(orders service.OrderService, cfg config.OrderConfig, logger *slog.Logger) Job {
+	timeout := cfg.PaymentTimeout
+	if timeout <= 0 {
+		timeout = DefaultPaymentTimeout
+	}
+	schedule := cfg.SweepSchedule
+	if schedule == "" {
+		schedule = DefaultOrderSweepSchedule
+	}
+
+	return Job{
+		Name:     OrderTimeoutJobName,
+		Schedule: schedule,
+		Jitter:   orderSweepJitter,
+		Timeout:  orderSweepRunTimeout,
+		Run: func(ctx context.Context) error {
+			cancelled, err := orders.CancelExpiredOrders(ctx, time.Now().Add(-timeout), cfg.SweepBatchSize)
+			ordersExpired.Add(float64(cancelled))
+			if cancelled > 0 {
+				logger.InfoContext(ctx, "Cancelled expired orders", slog.Int("count", cancelled), slog.Duration("payment_timeout", timeout))
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,61 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOrderTimeoutJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.OrderConfig
+		wantSchedule string
+		wantTimeout  time.Duration
+		cancelErr    error
+	}{
+		{
+			name:         "Defaults",
+			wantSchedule: DefaultOrderSweepSchedule,
+			wantTimeout:  DefaultPaymentTimeout,
+		},
+		{
+			name:         "Configured",
+			cfg:          config.OrderConfig{PaymentTimeout: 15 * time.Minute, SweepSchedule: "*/5 * * * *", SweepBatchSize: 50},
+			wantSchedule: "*/5 * * * *",
+			wantTimeout:  15 * time.Minute,
+		},
+		{
+			name:         "ErrorReturned",
+			wantSchedule: DefaultOrderSweepSchedule,
+			wantTimeout:  DefaultPaymentTimeout,
+			cancelErr:    errors.New("db down"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orders := mocks.NewMockOrderService(ctrl)
+			orders.EXPECT().CancelExpiredOrders(gomock.Any(), gomock.Any(), tt.cfg.SweepBatchSize).
+				DoAndReturn(func(_ context.Context, createdBefore time.Time, _ int) (int, error) {
+					assert.WithinDuration(t, time.Now().Add(-tt.wantTimeout), createdBefore, time.Second)
+					return 2, tt.cancelErr
+				})
+
+			job := NewOrderTimeoutJob(orders, tt.cfg, discardLogger)
+			assert.Equal(t, tt.wantSchedule, job.Schedule)
+			require.NoError(t, NewScheduler(nil, discardLogger, nil).Register(job))
+
+			err := job.Run(context.Background())
+			assert.Equal(t, tt.cancelErr, err)
+		})
+	}
+}
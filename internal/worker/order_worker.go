@@ -9,84 +9,160 @@ import (
 	"time"
 
 	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/apperr"
 	"github.com/proyuen/go-mall/pkg/cache"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/errreport"
+	"github.com/proyuen/go-mall/pkg/logger"
 	"github.com/proyuen/go-mall/pkg/mq"
 )
 
+// OrderQueue is the queue of OrderMessages.
+const OrderQueue = "orders.created"
+
 // OrderMessage represents the payload for order creation events.
 type OrderMessage struct {
-	OrderID  uint64 `json:"order_id"`
+	OrderID uint64             `json:"order_id"`
+	Items   []OrderMessageItem `json:"items"`
+	// SKUID and Quantity are the single item of messages published before
+	// orders carried Items; they are ignored when Items is set.
+	SKUID    uint64 `json:"sku_id,omitempty"`
+	Quantity int    `json:"quantity,omitempty"`
+}
+
+// OrderMessageItem is one SKU of an OrderMessage.
+type OrderMessageItem struct {
 	SKUID    uint64 `json:"sku_id"`
 	Quantity int    `json:"quantity"`
 }
 
+// stockItems returns what the order deducts from stock.
+func (m *OrderMessage) stockItems() []service.StockItem {
+	if len(m.Items) == 0 {
+		return []service.StockItem{{SKUID: m.SKUID, Quantity: m.Quantity}}
+	}
+	items := make([]service.StockItem, 0, len(m.Items))
+	for _, item := range m.Items {
+		items = append(items, service.StockItem{SKUID: item.SKUID, Quantity: item.Quantity})
+	}
+	return items
+}
+
 // OrderWorker handles asynchronous tasks related to orders.
 type OrderWorker struct {
 	mq       mq.RabbitMQ
 	invSvc   *service.InventoryService
 	orderSvc service.OrderService
+	notifier notification.Notifier
+	archive  service.FailedMessageService
 	cache    cache.Cache
+	cfg      config.ConsumerConfig
 	logger   *slog.Logger
+	reporter errreport.Reporter
 }
 
-// NewOrderWorker creates a new OrderWorker.
-func NewOrderWorker(mq mq.RabbitMQ, invSvc *service.InventoryService, orderSvc service.OrderService, cache cache.Cache, logger *slog.Logger) *OrderWorker {
+// NewOrderWorker creates a new OrderWorker. Orders whose stock runs out are
+// failed through orderSvc, and their customers told through notifier.
+// Messages it cannot process at all are kept in archive.
+func NewOrderWorker(mq mq.RabbitMQ, invSvc *service.InventoryService, orderSvc service.OrderService, notifier notification.Notifier, archive service.FailedMessageService, cache cache.Cache, cfg config.ConsumerConfig, logger *slog.Logger, reporter errreport.Reporter) *OrderWorker {
+	if reporter == nil {
+		reporter = errreport.Nop()
+	}
 	return &OrderWorker{
 		mq:       mq,
 		invSvc:   invSvc,
 		orderSvc: orderSvc,
+		notifier: notifier,
+		archive:  archive,
 		cache:    cache,
+		cfg:      cfg,
 		logger:   logger,
+		reporter: reporter,
 	}
 }
 
 // Start begins consuming messages from the queue.
 func (w *OrderWorker) Start() error {
 	w.logger.Info("Starting OrderWorker...")
-	return w.mq.Consume("orders.created", w.handleOrderCreated)
+	return consume(w.mq, OrderQueue, w.cfg, w.handleOrderCreated, LogContext, Reported(w.reporter), Archived(w.archive))
 }
 
 func (w *OrderWorker) handleOrderCreated(ctx context.Context, body []byte) error {
 	var msg OrderMessage
 	if err := json.Unmarshal(body, &msg); err != nil {
-		w.logger.Error("Poison Pill: Failed to unmarshal order message", "error", err, "body", string(body))
-		return nil // Ack to drop bad message
+		w.logger.ErrorContext(ctx, "Poison Pill: Failed to unmarshal order message", logger.Err(err), slog.String("body", string(body)))
+		return reject(fmt.Errorf("failed to unmarshal order message: %w", err)) // Archive for replay
 	}
+	ctx = logger.WithAttrs(ctx, slog.Uint64("order_id", msg.OrderID))
 
-	logger := w.logger.With("order_id", msg.OrderID)
+	items := msg.stockItems()
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			w.logger.ErrorContext(ctx, "Poison Pill: Invalid quantity in order message", slog.Uint64("sku_id", item.SKUID), slog.Int("quantity", item.Quantity))
+			return reject(fmt.Errorf("invalid quantity %d for SKU %d", item.Quantity, item.SKUID)) // Archive for replay
+		}
+	}
 
 	// Idempotency Check using Atomic SetNX
 	idempotencyKey := fmt.Sprintf("processed:order:%d", msg.OrderID)
 	acquired, err := w.cache.SetNX(ctx, idempotencyKey, "1", 24*time.Hour)
 	if err != nil {
-		logger.Error("Transient: Failed to check idempotency key", "error", err)
+		w.logger.ErrorContext(ctx, "Transient: Failed to check idempotency key", logger.Err(err))
 		return err // Retry
 	}
 	if !acquired {
-		logger.Info("Duplicate ignored: Order already processed")
+		w.logger.InfoContext(ctx, "Duplicate ignored: Order already processed")
 		return nil // Ack
 	}
 
-	logger.Info("Processing order event", "sku_id", msg.SKUID)
+	w.logger.InfoContext(ctx, "Processing order event", slog.Int("items", len(items)))
 
-	// 1. Deduct Stock safely
-	skuStr := fmt.Sprintf("%d", msg.SKUID)
-	if err := w.invSvc.DeductStock(ctx, skuStr, msg.Quantity); err != nil {
-		if errors.Is(err, service.ErrInsufficientStock) {
-			logger.Error("Terminal: Insufficient stock", "error", err)
-			// Execute Compensation: Fail Order (TODO: Implement order failure logic)
-			// We do NOT delete the idempotency key here. This prevents retrying a terminal error.
+	// 1. Deduct the stock of all items at once
+	if err := w.invSvc.DeductStocks(ctx, items); err != nil {
+		if !apperr.Retryable(err) {
+			w.logger.ErrorContext(ctx, "Terminal: Failed to deduct stock", slog.String("code", string(apperr.CodeOf(err))), logger.Err(err))
+			// Execute Compensation: Fail Order. The idempotency key is kept
+			// once the order is failed, so that the terminal error is not retried.
+			if err := w.failOrder(ctx, msg.OrderID); err != nil {
+				w.logger.ErrorContext(ctx, "Transient: Failed to fail order", logger.Err(err))
+				if delErr := w.cache.Del(ctx, idempotencyKey); delErr != nil {
+					w.logger.ErrorContext(ctx, "Failed to rollback idempotency key", logger.Err(delErr))
+				}
+				return err // Retry
+			}
 			return nil // Ack
 		}
 
-		logger.Error("Transient: Failed to deduct stock", "error", err)
+		w.logger.ErrorContext(ctx, "Transient: Failed to deduct stock", logger.Err(err))
 		// System error (e.g. DB timeout) -> Delete idempotency key to allow retry
 		if delErr := w.cache.Del(ctx, idempotencyKey); delErr != nil {
-			logger.Error("Failed to rollback idempotency key", "error", delErr)
+			w.logger.ErrorContext(ctx, "Failed to rollback idempotency key", logger.Err(delErr))
 		}
 		return err // Retry
 	}
 
-	logger.Info("Order processed successfully")
+	w.logger.InfoContext(ctx, "Order processed successfully")
+	return nil
+}
+
+// failOrder fails the order whose stock ran out and tells its customer. An
+// order paid or cancelled in the meantime is left alone. The notification is
+// not worth retrying the message for: the order is failed either way.
+func (w *OrderWorker) failOrder(ctx context.Context, orderID uint64) error {
+	order, err := w.orderSvc.FailOrder(ctx, orderID)
+	if errors.Is(err, service.ErrOrderNotPending) || errors.Is(err, service.ErrOrderNotFound) {
+		w.logger.WarnContext(ctx, "Order left alone: no longer pending", logger.Err(err))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	w.logger.InfoContext(ctx, "Order failed")
+
+	data := &notification.OrderFailedData{OrderNumber: order.OrderNumber}
+	if err := w.notifier.Notify(ctx, order.UserID, notification.KindOrderFailed, data); err != nil {
+		w.logger.ErrorContext(ctx, "Failed to notify customer of failed order", logger.Err(err))
+	}
 	return nil
 }
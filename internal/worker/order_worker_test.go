@@ -0,0 +1,113 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/internal/service/notification"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOrderWorker_HandleOrderCreated(t *testing.T) {
+	const key = "processed:order:1"
+	body := []byte(`{"order_id":1,"items":[{"sku_id":101,"quantity":2}]}`)
+	failed := &model.Order{UserID: 7, OrderNumber: "ORD1", Status: model.OrderStatusFailed}
+
+	type deps struct {
+		cache    *mocks.MockCache
+		orders   *mocks.MockOrderService
+		notifier *mocks.MockNotifier
+	}
+
+	tests := []struct {
+		name    string
+		setup   func(d deps)
+		wantErr bool
+	}{
+		{
+			name: "Deducted",
+			setup: func(d deps) {
+				d.cache.EXPECT().SetNX(gomock.Any(), key, "1", 24*time.Hour).Return(true, nil)
+				d.cache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(0), nil)
+			},
+		},
+		{
+			name: "OutOfStockFailsOrder",
+			setup: func(d deps) {
+				d.cache.EXPECT().SetNX(gomock.Any(), key, "1", 24*time.Hour).Return(true, nil)
+				d.cache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+				d.orders.EXPECT().FailOrder(gomock.Any(), uint64(1)).Return(failed, nil)
+				d.notifier.EXPECT().Notify(gomock.Any(), uint64(7), notification.KindOrderFailed, &notification.OrderFailedData{OrderNumber: "ORD1"}).Return(nil)
+			},
+		},
+		{
+			name: "NotifyFailureIgnored",
+			setup: func(d deps) {
+				d.cache.EXPECT().SetNX(gomock.Any(), key, "1", 24*time.Hour).Return(true, nil)
+				d.cache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+				d.orders.EXPECT().FailOrder(gomock.Any(), uint64(1)).Return(failed, nil)
+				d.notifier.EXPECT().Notify(gomock.Any(), uint64(7), notification.KindOrderFailed, gomock.Any()).Return(errors.New("channel closed"))
+			},
+		},
+		{
+			name: "PaidMeanwhileLeftAlone",
+			setup: func(d deps) {
+				d.cache.EXPECT().SetNX(gomock.Any(), key, "1", 24*time.Hour).Return(true, nil)
+				d.cache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+				d.orders.EXPECT().FailOrder(gomock.Any(), uint64(1)).Return(nil, service.ErrOrderNotPending)
+			},
+		},
+		{
+			name: "FailOrderErrorRetries",
+			setup: func(d deps) {
+				d.cache.EXPECT().SetNX(gomock.Any(), key, "1", 24*time.Hour).Return(true, nil)
+				d.cache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+				d.orders.EXPECT().FailOrder(gomock.Any(), uint64(1)).Return(nil, errors.New("db down"))
+				d.cache.EXPECT().Del(gomock.Any(), key).Return(nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "TransientDeductErrorRetries",
+			setup: func(d deps) {
+				d.cache.EXPECT().SetNX(gomock.Any(), key, "1", 24*time.Hour).Return(true, nil)
+				d.cache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("redis down"))
+				d.cache.EXPECT().Del(gomock.Any(), key).Return(nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "Duplicate",
+			setup: func(d deps) {
+				d.cache.EXPECT().SetNX(gomock.Any(), key, "1", 24*time.Hour).Return(false, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			d := deps{
+				cache:    mocks.NewMockCache(ctrl),
+				orders:   mocks.NewMockOrderService(ctrl),
+				notifier: mocks.NewMockNotifier(ctrl),
+			}
+			tt.setup(d)
+
+			w := NewOrderWorker(nil, service.NewInventoryService(d.cache), d.orders, d.notifier, nil, d.cache, config.ConsumerConfig{}, discardLogger, nil)
+			err := w.handleOrderCreated(context.Background(), body)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
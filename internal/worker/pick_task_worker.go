@@ -0,0 +1,114 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/errreport"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/proyuen/go-mall/pkg/mq"
+)
+
+// Queues of pick task generation. Orders whose tasks fail to generate wait in
+// PickTaskRetryQueue for PickTaskRetryDelay and then dead-letter back into
+// PickTaskQueue.
+const (
+	PickTaskQueue      = "pick_tasks"
+	PickTaskRetryQueue = "pick_tasks.retry"
+	// PickTaskRetryDelay is part of the queue declaration and so cannot come
+	// from config: redeclaring a queue with a different TTL fails.
+	PickTaskRetryDelay = time.Minute
+)
+
+var pickTasksGenerated = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "pick_tasks_generated_total",
+		Help: "Total number of pick tasks generated for paid orders",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(pickTasksGenerated)
+}
+
+// paidOrderEvent is the part of an order event pick task generation needs.
+type paidOrderEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		OrderID uint64 `json:"order_id,string"`
+		Status  string `json:"status"`
+	} `json:"data"`
+}
+
+// PickTaskWorker generates the pick tasks of orders as they are paid, and of
+// backordered orders once their stock is allocated and they move to paid.
+type PickTaskWorker struct {
+	broker   mq.RabbitMQ
+	picking  service.PickingService
+	archive  service.FailedMessageService
+	cfg      config.ConsumerConfig
+	logger   *slog.Logger
+	reporter errreport.Reporter
+}
+
+// NewPickTaskWorker creates a PickTaskWorker. Events it cannot decode are
+// kept in archive.
+func NewPickTaskWorker(broker mq.RabbitMQ, picking service.PickingService, archive service.FailedMessageService, cfg config.ConsumerConfig, logger *slog.Logger, reporter errreport.Reporter) *PickTaskWorker {
+	if reporter == nil {
+		reporter = errreport.Nop()
+	}
+	return &PickTaskWorker{broker: broker, picking: picking, archive: archive, cfg: cfg, logger: logger, reporter: reporter}
+}
+
+// Start declares the queues, binds them to the events of paid orders and
+// begins consuming. service.EventsExchange must have been declared.
+func (w *PickTaskWorker) Start() error {
+	w.logger.Info("Starting PickTaskWorker...")
+	if err := w.broker.DeclareQueue(PickTaskQueue, mq.QueueOptions{DeadLetterRoutingKey: PickTaskRetryQueue}); err != nil {
+		return err
+	}
+	if err := w.broker.DeclareQueue(PickTaskRetryQueue, mq.QueueOptions{MessageTTL: PickTaskRetryDelay, DeadLetterRoutingKey: PickTaskQueue}); err != nil {
+		return err
+	}
+	for _, key := range []string{service.EventOrderPaid, service.EventOrderUpdated} {
+		if err := w.broker.BindQueue(PickTaskQueue, service.EventsExchange, key); err != nil {
+			return err
+		}
+	}
+	return consume(w.broker, PickTaskQueue, w.cfg, w.handleEvent, LogContext, Archived(w.archive))
+}
+
+// handleEvent generates the pick tasks of the order of one event. Returning
+// an error rejects the message into the retry queue.
+func (w *PickTaskWorker) handleEvent(ctx context.Context, body []byte) error {
+	var event paidOrderEvent
+	if err := json.Unmarshal(body, &event); err != nil || event.Data.OrderID == 0 {
+		w.logger.ErrorContext(ctx, "Poison Pill: Failed to decode order event", logger.Err(err), slog.String("body", string(body)))
+		if err == nil {
+			err = errors.New("no order ID")
+		}
+		return reject(fmt.Errorf("failed to decode order event: %w", err)) // Archive for replay
+	}
+	if event.Data.Status != model.OrderStatusPaid {
+		return nil // Not paid, or still waiting for stock
+	}
+
+	generated, err := w.picking.GenerateTasks(ctx, event.Data.OrderID)
+	if err != nil {
+		err = fmt.Errorf("failed to generate pick tasks of order %d: %w", event.Data.OrderID, err)
+		w.reporter.CaptureError(ctx, err, map[string]string{"queue": PickTaskQueue, "event": event.Type})
+		w.logger.WarnContext(ctx, "Transient: Pick tasks retried later", logger.Err(err), slog.String("event_id", event.ID))
+		return err
+	}
+	pickTasksGenerated.Add(float64(generated))
+	return nil
+}
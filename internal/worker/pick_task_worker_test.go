@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPickTaskWorker_HandleEvent(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		setup        func(picking *mocks.MockPickingService, reporter *mocks.MockReporter)
+		wantErr      bool
+		wantRejected bool
+	}{
+		{
+			name: "Paid",
+			body: `{"id":"evt-1","type":"order.paid","data":{"order_id":"42","status":"paid"}}`,
+			setup: func(picking *mocks.MockPickingService, _ *mocks.MockReporter) {
+				picking.EXPECT().GenerateTasks(gomock.Any(), uint64(42)).Return(2, nil)
+			},
+		},
+		{
+			name: "AllocatedBackorder",
+			body: `{"id":"evt-1","type":"order.updated","data":{"order_id":"42","status":"paid"}}`,
+			setup: func(picking *mocks.MockPickingService, _ *mocks.MockReporter) {
+				picking.EXPECT().GenerateTasks(gomock.Any(), uint64(42)).Return(1, nil)
+			},
+		},
+		{name: "StillBackordered", body: `{"id":"evt-1","type":"order.paid","data":{"order_id":"42","status":"backordered"}}`},
+		{
+			name: "RetriedOnFailure",
+			body: `{"id":"evt-1","type":"order.paid","data":{"order_id":"42","status":"paid"}}`,
+			setup: func(picking *mocks.MockPickingService, reporter *mocks.MockReporter) {
+				picking.EXPECT().GenerateTasks(gomock.Any(), uint64(42)).Return(0, errors.New("db down"))
+				reporter.EXPECT().CaptureError(gomock.Any(), gomock.Any(), map[string]string{"queue": PickTaskQueue, "event": "order.paid"})
+			},
+			wantErr: true,
+		},
+		{name: "Malformed", body: `not json`, wantErr: true, wantRejected: true},
+		{name: "NoOrder", body: `{"id":"evt-1","type":"order.paid","data":{"status":"paid"}}`, wantErr: true, wantRejected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			picking := mocks.NewMockPickingService(ctrl)
+			reporter := mocks.NewMockReporter(ctrl)
+			if tt.setup != nil {
+				tt.setup(picking, reporter)
+			}
+
+			w := NewPickTaskWorker(nil, picking, nil, config.ConsumerConfig{}, discardLogger, reporter)
+			err := w.handleEvent(context.Background(), []byte(tt.body))
+			assert.Equal(t, tt.wantErr, err != nil)
+			var r *rejection
+			assert.Equal(t, tt.wantRejected, errors.As(err, &r), "archived for replay")
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	// DefaultPopularitySchedule applies when popularity.schedule is empty.
+	DefaultPopularitySchedule = "@every 5m"
+
+	// PopularityJobName identifies the popularity job in logs, reports and metrics.
+	PopularityJobName = "product-popularity"
+	// popularityJitter keeps the rescore off the other jobs' beat.
+	popularityJitter = 30 * time.Second
+	// popularityRunTimeout bounds one flush and rescore of every SPU.
+	popularityRunTimeout = 5 * time.Minute
+)
+
+// NewPopularityJob returns the job that flushes the product views counted in
+// Redis to the database, then rescores the popularity of every product,
+// across all stores. If the rescore fails, the views flushed are scored by
+// the next run.
+func NewPopularityJob(popularity service.PopularityService, cfg config.PopularityConfig, logger *slog.Logger) Job {
+	schedule := cfg.Schedule
+	if schedule == "" {
+		schedule = DefaultPopularitySchedule
+	}
+
+	return Job{
+		Name:     PopularityJobName,
+		Schedule: schedule,
+		Jitter:   popularityJitter,
+		Timeout:  popularityRunTimeout,
+		Run: func(ctx context.Context) error {
+			viewed, err := popularity.FlushViews(ctx)
+			if err != nil {
+				return err
+			}
+			rescored, err := popularity.Rescore(ctx)
+			if err != nil {
+				return err
+			}
+			logger.DebugContext(ctx, "Rescored product popularity", slog.Int("viewed", viewed), slog.Int64("rescored", rescored))
+			return nil
+		},
+	}
+}
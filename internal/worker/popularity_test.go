@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPopularityJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.PopularityConfig
+		wantSchedule string
+		flushErr     error
+		rescoreErr   error
+	}{
+		{name: "Defaults", wantSchedule: DefaultPopularitySchedule},
+		{name: "Configured", cfg: config.PopularityConfig{Schedule: "@every 1h"}, wantSchedule: "@every 1h"},
+		{name: "FlushFailed", wantSchedule: DefaultPopularitySchedule, flushErr: errors.New("redis down")},
+		{name: "RescoreFailed", wantSchedule: DefaultPopularitySchedule, rescoreErr: errors.New("db down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			popularity := mocks.NewMockPopularityService(ctrl)
+			popularity.EXPECT().FlushViews(gomock.Any()).Return(2, tt.flushErr)
+			if tt.flushErr == nil {
+				popularity.EXPECT().Rescore(gomock.Any()).Return(int64(5), tt.rescoreErr)
+			}
+
+			job := NewPopularityJob(popularity, tt.cfg, discardLogger)
+			assert.Equal(t, tt.wantSchedule, job.Schedule)
+			require.NoError(t, NewScheduler(nil, discardLogger, nil).Register(job))
+
+			err := job.Run(context.Background())
+			if tt.flushErr != nil {
+				assert.Equal(t, tt.flushErr, err)
+			} else {
+				assert.Equal(t, tt.rescoreErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	// DefaultPriceScheduleSchedule applies when pricing.schedule is empty.
+	DefaultPriceScheduleSchedule = "@every 1m"
+
+	// PriceScheduleJobName identifies the price schedule job in logs, reports and metrics.
+	PriceScheduleJobName = "price-schedules"
+	// priceScheduleRunTimeout bounds one pass; leftovers are picked up by the next.
+	priceScheduleRunTimeout = 2 * time.Minute
+)
+
+// NewPriceScheduleJob returns the job that applies due price changes and
+// starts and ends sales, across all stores.
+func NewPriceScheduleJob(schedules service.PriceScheduleService, cfg config.PricingConfig, logger *slog.Logger) Job {
+	schedule := cfg.Schedule
+	if schedule == "" {
+		schedule = DefaultPriceScheduleSchedule
+	}
+
+	return Job{
+		Name:     PriceScheduleJobName,
+		Schedule: schedule,
+		Timeout:  priceScheduleRunTimeout,
+		Run: func(ctx context.Context) error {
+			applied, err := schedules.ApplyDue(ctx)
+			if applied > 0 {
+				logger.InfoContext(ctx, "Applied price schedules", slog.Int("schedules", applied))
+			}
+			return err
+		},
+	}
+}
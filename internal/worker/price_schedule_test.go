@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPriceScheduleJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.PricingConfig
+		wantSchedule string
+		applyErr     error
+	}{
+		{name: "Defaults", wantSchedule: DefaultPriceScheduleSchedule},
+		{name: "Configured", cfg: config.PricingConfig{Schedule: "@every 30s"}, wantSchedule: "@every 30s"},
+		{name: "Fails", wantSchedule: DefaultPriceScheduleSchedule, applyErr: errors.New("db down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			schedules := mocks.NewMockPriceScheduleService(ctrl)
+			schedules.EXPECT().ApplyDue(gomock.Any()).Return(1, tt.applyErr)
+
+			job := NewPriceScheduleJob(schedules, tt.cfg, discardLogger)
+			assert.Equal(t, tt.wantSchedule, job.Schedule)
+			require.NoError(t, NewScheduler(nil, discardLogger, nil).Register(job))
+			assert.Equal(t, tt.applyErr, job.Run(context.Background()))
+		})
+	}
+}
@@ -0,0 +1,363 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/proyuen/go-mall/pkg/errreport"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/robfig/cron"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// LeaderLockKey is the Redis lock whose holder runs the scheduled jobs.
+	LeaderLockKey = "mall:cron:leader"
+	// leaderLeaseTTL is how long leadership survives without renewal, i.e. the
+	// longest no instance runs jobs after the leader dies.
+	leaderLeaseTTL = 15 * time.Second
+	// campaignInterval is how often followers try to take over and the leader
+	// confirms it still holds the lock.
+	campaignInterval = leaderLeaseTTL / 3
+	// flushTimeout bounds sending a panic report before the job loop moves on.
+	flushTimeout = 2 * time.Second
+)
+
+// Job results recorded in cron_job_runs_total.
+const (
+	resultSuccess = "success"
+	resultError   = "error"
+	resultPanic   = "panic"
+)
+
+var (
+	cronJobRuns = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cron_job_runs_total",
+			Help: "Total number of scheduled job runs by result",
+		},
+		[]string{"job", "result"}, // success, error, panic
+	)
+
+	cronJobDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "cron_job_duration_seconds",
+			Help:    "Duration of scheduled job runs in seconds",
+			Buckets: []float64{0.1, 0.5, 1, 5, 15, 60, 300, 900},
+		},
+		[]string{"job"},
+	)
+
+	cronJobLastSuccess = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cron_job_last_success_timestamp_seconds",
+			Help: "Unix time of the last successful run of each scheduled job",
+		},
+		[]string{"job"},
+	)
+
+	cronLeader = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "cron_leader",
+			Help: "1 if this instance currently runs the scheduled jobs, 0 otherwise",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(cronJobRuns, cronJobDuration, cronJobLastSuccess, cronLeader)
+}
+
+var (
+	ErrDuplicateJob  = errors.New("job already registered")
+	ErrSchedulerBusy = errors.New("scheduler already started")
+)
+
+// Locker is the distributed lock the Scheduler elects its leader with;
+// *cache.RedisLock implements it.
+//
+//go:generate mockgen -source=$GOFILE -destination=../mocks/locker_mock.go -package=mocks
+type Locker interface {
+	TryLock(ctx context.Context, ttl time.Duration) (bool, error)
+	Held(ctx context.Context) (bool, error)
+	Unlock(ctx context.Context) error
+}
+
+// Job is a task the Scheduler runs periodically on the leader instance.
+type Job struct {
+	Name     string
+	Schedule string        // Standard 5-field cron spec or descriptor, e.g. "*/5 * * * *" or "@every 1m"
+	Jitter   time.Duration // Random delay up to this long before each run, so jobs sharing a schedule do not fire together
+	Timeout  time.Duration // Cancels the run's context after this long; 0 means no limit
+	Run      func(ctx context.Context) error
+}
+
+type scheduledJob struct {
+	Job
+	schedule cron.Schedule
+	tags     map[string]string
+}
+
+// Scheduler runs registered jobs on whichever worker instance holds the leader
+// lock, so a job fires once per schedule no matter how many workers run. A run
+// that outlasts its next fire time delays that run rather than overlapping it.
+// Errors and panics are logged, reported and counted without affecting other jobs.
+// Each run is traced as a span of its own.
+type Scheduler struct {
+	locker   Locker
+	logger   *slog.Logger
+	reporter errreport.Reporter
+	tracer   trace.Tracer
+
+	mu      sync.Mutex
+	jobs    []*scheduledJob
+	started bool
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	leader  atomic.Bool
+}
+
+// NewScheduler creates a Scheduler electing its leader with locker.
+func NewScheduler(locker Locker, logger *slog.Logger, reporter errreport.Reporter) *Scheduler {
+	if reporter == nil {
+		reporter = errreport.Nop()
+	}
+	return &Scheduler{
+		locker:   locker,
+		logger:   logger,
+		reporter: reporter,
+		tracer:   otel.Tracer("internal/worker"),
+	}
+}
+
+// Register adds a job. Jobs must be registered before Start.
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return errors.New("job needs a name and a Run function")
+	}
+	schedule, err := cron.ParseStandard(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q for job %s: %w", job.Schedule, job.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return ErrSchedulerBusy
+	}
+	for _, existing := range s.jobs {
+		if existing.Name == job.Name {
+			return fmt.Errorf("%w: %s", ErrDuplicateJob, job.Name)
+		}
+	}
+	s.jobs = append(s.jobs, &scheduledJob{
+		Job:      job,
+		schedule: schedule,
+		tags:     map[string]string{"job": job.Name},
+	})
+	return nil
+}
+
+// Start begins campaigning for leadership and scheduling the registered jobs.
+func (s *Scheduler) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return ErrSchedulerBusy
+	}
+	s.started = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.campaignLoop(ctx)
+	}()
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.jobLoop(ctx, job)
+		}()
+	}
+	s.logger.Info("Scheduler started", slog.Int("jobs", len(s.jobs)))
+	return nil
+}
+
+// Stop cancels running jobs, waits for them until ctx is done and gives up
+// leadership so another instance can take over immediately.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("scheduled jobs did not stop: %w", ctx.Err())
+	}
+
+	if s.leader.Load() {
+		s.setLeader(false)
+		if err := s.locker.Unlock(ctx); err != nil {
+			return fmt.Errorf("failed to release scheduler leadership: %w", err)
+		}
+	}
+	return nil
+}
+
+// IsLeader reports whether this instance currently runs the jobs.
+func (s *Scheduler) IsLeader() bool {
+	return s.leader.Load()
+}
+
+func (s *Scheduler) campaignLoop(ctx context.Context) {
+	s.campaign(ctx)
+	ticker := time.NewTicker(campaignInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.campaign(ctx)
+		}
+	}
+}
+
+// campaign takes leadership when the lock is free and steps down when the
+// lock has been lost. When Redis cannot be reached the leader steps down too:
+// the lease will lapse and another instance may already be running the jobs.
+func (s *Scheduler) campaign(ctx context.Context) {
+	if s.leader.Load() {
+		held, err := s.locker.Held(ctx)
+		if err == nil && held {
+			return
+		}
+		if ctx.Err() != nil {
+			return // Shutting down; Stop releases the lock
+		}
+		s.setLeader(false)
+		s.logger.Warn("Lost scheduler leadership", logger.Err(err))
+		// Stops the lock's watchdog so that it cannot keep a stale lease alive.
+		if err := s.locker.Unlock(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Debug("Failed to release lost scheduler lock", logger.Err(err))
+		}
+		return
+	}
+
+	acquired, err := s.locker.TryLock(ctx, leaderLeaseTTL)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Warn("Failed to campaign for scheduler leadership", logger.Err(err))
+		}
+		return
+	}
+	if acquired {
+		s.setLeader(true)
+		s.logger.Info("Acquired scheduler leadership")
+	}
+}
+
+func (s *Scheduler) setLeader(leader bool) {
+	s.leader.Store(leader)
+	if leader {
+		cronLeader.Set(1)
+	} else {
+		cronLeader.Set(0)
+	}
+}
+
+// jobLoop waits for each fire time of job and runs it if this instance leads.
+func (s *Scheduler) jobLoop(ctx context.Context, job *scheduledJob) {
+	for {
+		delay := time.Until(job.schedule.Next(time.Now()))
+		if job.Jitter > 0 {
+			delay += rand.N(job.Jitter)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if s.leader.Load() {
+			s.run(ctx, job)
+		}
+	}
+}
+
+// run executes one run of job and records its outcome.
+func (s *Scheduler) run(ctx context.Context, job *scheduledJob) {
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+	ctx, span := s.tracer.Start(ctx, "job "+job.Name, trace.WithAttributes(attribute.String("job", job.Name)))
+	defer span.End()
+
+	log := s.logger.With(slog.String("job", job.Name))
+	log.Debug("Scheduled job started")
+	start := time.Now()
+	result, err := s.invoke(ctx, job)
+	elapsed := time.Since(start)
+
+	cronJobRuns.WithLabelValues(job.Name, result).Inc()
+	cronJobDuration.WithLabelValues(job.Name).Observe(elapsed.Seconds())
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	switch result {
+	case resultSuccess:
+		cronJobLastSuccess.WithLabelValues(job.Name).SetToCurrentTime()
+		log.Info("Scheduled job finished", slog.Duration("duration", elapsed))
+	case resultError:
+		s.reporter.CaptureError(ctx, err, job.tags)
+		log.Error("Scheduled job failed", logger.Err(err), slog.Duration("duration", elapsed))
+	case resultPanic:
+		log.Error("Scheduled job panicked", logger.Err(err), slog.Duration("duration", elapsed))
+	}
+}
+
+// invoke calls the job, converting a panic into an error so that one broken
+// job cannot take the worker down.
+func (s *Scheduler) invoke(ctx context.Context, job *scheduledJob) (result string, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			s.reporter.CapturePanic(ctx, rec, job.tags)
+			s.reporter.Flush(flushTimeout)
+			result, err = resultPanic, fmt.Errorf("panic: %v", rec)
+		}
+	}()
+
+	if err := job.Run(ctx); err != nil {
+		return resultError, err
+	}
+	return resultSuccess, nil
+}
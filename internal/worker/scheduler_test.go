@@ -0,0 +1,255 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/mock/gomock"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func noop(context.Context) error { return nil }
+
+func TestScheduler_Register(t *testing.T) {
+	tests := []struct {
+		name    string
+		jobs    []Job
+		wantErr error
+		errStr  string
+	}{
+		{
+			name: "Valid",
+			jobs: []Job{
+				{Name: "a", Schedule: "*/5 * * * *", Run: noop},
+				{Name: "b", Schedule: "@every 1m", Run: noop},
+			},
+		},
+		{
+			name:   "InvalidSchedule",
+			jobs:   []Job{{Name: "a", Schedule: "every minute", Run: noop}},
+			errStr: "invalid schedule",
+		},
+		{
+			name:   "MissingRun",
+			jobs:   []Job{{Name: "a", Schedule: "@hourly"}},
+			errStr: "needs a name and a Run function",
+		},
+		{
+			name: "Duplicate",
+			jobs: []Job{
+				{Name: "a", Schedule: "@hourly", Run: noop},
+				{Name: "a", Schedule: "@daily", Run: noop},
+			},
+			wantErr: ErrDuplicateJob,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScheduler(nil, discardLogger, nil)
+			var err error
+			for _, job := range tt.jobs {
+				if err = s.Register(job); err != nil {
+					break
+				}
+			}
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.errStr != "":
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errStr)
+			default:
+				require.NoError(t, err)
+				assert.Len(t, s.jobs, len(tt.jobs))
+			}
+		})
+	}
+}
+
+func TestScheduler_Campaign(t *testing.T) {
+	tests := []struct {
+		name       string
+		leader     bool
+		mockSetup  func(m *mocks.MockLocker)
+		wantLeader bool
+	}{
+		{
+			name: "FollowerAcquires",
+			mockSetup: func(m *mocks.MockLocker) {
+				m.EXPECT().TryLock(gomock.Any(), leaderLeaseTTL).Return(true, nil)
+			},
+			wantLeader: true,
+		},
+		{
+			name: "FollowerLockBusy",
+			mockSetup: func(m *mocks.MockLocker) {
+				m.EXPECT().TryLock(gomock.Any(), leaderLeaseTTL).Return(false, nil)
+			},
+		},
+		{
+			name: "FollowerRedisDown",
+			mockSetup: func(m *mocks.MockLocker) {
+				m.EXPECT().TryLock(gomock.Any(), leaderLeaseTTL).Return(false, errors.New("connection refused"))
+			},
+		},
+		{
+			name:   "LeaderKeepsLock",
+			leader: true,
+			mockSetup: func(m *mocks.MockLocker) {
+				m.EXPECT().Held(gomock.Any()).Return(true, nil)
+			},
+			wantLeader: true,
+		},
+		{
+			name:   "LeaderLostLock",
+			leader: true,
+			mockSetup: func(m *mocks.MockLocker) {
+				m.EXPECT().Held(gomock.Any()).Return(false, nil)
+				m.EXPECT().Unlock(gomock.Any()).Return(errors.New("lock not held"))
+			},
+		},
+		{
+			name:   "LeaderRedisDown",
+			leader: true,
+			mockSetup: func(m *mocks.MockLocker) {
+				m.EXPECT().Held(gomock.Any()).Return(false, errors.New("connection refused"))
+				m.EXPECT().Unlock(gomock.Any()).Return(errors.New("connection refused"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			locker := mocks.NewMockLocker(ctrl)
+			tt.mockSetup(locker)
+
+			s := NewScheduler(locker, discardLogger, nil)
+			s.setLeader(tt.leader)
+			s.campaign(context.Background())
+
+			assert.Equal(t, tt.wantLeader, s.IsLeader())
+			want := 0.0
+			if tt.wantLeader {
+				want = 1
+			}
+			assert.Equal(t, want, testutil.ToFloat64(cronLeader))
+		})
+	}
+}
+
+func TestScheduler_Run(t *testing.T) {
+	jobErr := errors.New("db down")
+
+	tests := []struct {
+		name       string
+		run        func(ctx context.Context) error
+		timeout    time.Duration
+		mockSetup  func(m *mocks.MockReporter)
+		wantResult string
+	}{
+		{
+			name:       "Success",
+			run:        noop,
+			mockSetup:  func(m *mocks.MockReporter) {},
+			wantResult: resultSuccess,
+		},
+		{
+			name: "Error",
+			run:  func(context.Context) error { return jobErr },
+			mockSetup: func(m *mocks.MockReporter) {
+				m.EXPECT().CaptureError(gomock.Any(), jobErr, map[string]string{"job": "Error"})
+			},
+			wantResult: resultError,
+		},
+		{
+			name: "PanicIsolated",
+			run:  func(context.Context) error { panic("boom") },
+			mockSetup: func(m *mocks.MockReporter) {
+				m.EXPECT().CapturePanic(gomock.Any(), "boom", map[string]string{"job": "PanicIsolated"})
+				m.EXPECT().Flush(flushTimeout).Return(true)
+			},
+			wantResult: resultPanic,
+		},
+		{
+			name: "TimeoutCancelsContext",
+			run: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			timeout: 10 * time.Millisecond,
+			mockSetup: func(m *mocks.MockReporter) {
+				m.EXPECT().CaptureError(gomock.Any(), context.DeadlineExceeded, gomock.Any())
+			},
+			wantResult: resultError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			reporter := mocks.NewMockReporter(ctrl)
+			tt.mockSetup(reporter)
+
+			recorder := tracetest.NewSpanRecorder()
+			s := NewScheduler(nil, discardLogger, reporter)
+			s.tracer = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+			require.NoError(t, s.Register(Job{Name: tt.name, Schedule: "@hourly", Timeout: tt.timeout, Run: tt.run}))
+
+			before := testutil.ToFloat64(cronJobRuns.WithLabelValues(tt.name, tt.wantResult))
+			assert.NotPanics(t, func() { s.run(context.Background(), s.jobs[0]) })
+			assert.Equal(t, before+1, testutil.ToFloat64(cronJobRuns.WithLabelValues(tt.name, tt.wantResult)))
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "job "+tt.name, spans[0].Name())
+			if tt.wantResult == resultSuccess {
+				assert.Equal(t, codes.Unset, spans[0].Status().Code)
+			} else {
+				assert.Equal(t, codes.Error, spans[0].Status().Code)
+			}
+		})
+	}
+}
+
+func TestScheduler_StartStop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	locker := mocks.NewMockLocker(ctrl)
+	acquired := make(chan struct{})
+	locker.EXPECT().TryLock(gomock.Any(), leaderLeaseTTL).DoAndReturn(func(context.Context, time.Duration) (bool, error) {
+		close(acquired)
+		return true, nil
+	})
+	// Stop gives up leadership so a standby worker can take over at once.
+	locker.EXPECT().Unlock(gomock.Any()).Return(nil)
+
+	s := NewScheduler(locker, discardLogger, nil)
+	require.NoError(t, s.Register(Job{Name: "hourly", Schedule: "@hourly", Run: noop}))
+	require.NoError(t, s.Start())
+	assert.ErrorIs(t, s.Register(Job{Name: "late", Schedule: "@hourly", Run: noop}), ErrSchedulerBusy)
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("scheduler did not campaign for leadership")
+	}
+	require.Eventually(t, s.IsLeader, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, s.Stop(ctx))
+	assert.False(t, s.IsLeader())
+}
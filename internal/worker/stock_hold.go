@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	// DefaultStockHoldExpirySchedule applies when inventory.hold_expiry_schedule is empty.
+	DefaultStockHoldExpirySchedule = "@every 1m"
+
+	// StockHoldExpiryJobName identifies the stock hold expiry job in logs, reports and metrics.
+	StockHoldExpiryJobName = "stock-hold-expiry"
+	// stockHoldExpiryRunTimeout bounds one pass; leftovers are picked up by the next.
+	stockHoldExpiryRunTimeout = 2 * time.Minute
+)
+
+// NewStockHoldExpiryJob returns the job that releases expired stock holds
+// back into stock, across all stores.
+func NewStockHoldExpiryJob(holds service.StockHoldService, cfg config.InventoryConfig, logger *slog.Logger) Job {
+	schedule := cfg.HoldExpirySchedule
+	if schedule == "" {
+		schedule = DefaultStockHoldExpirySchedule
+	}
+
+	return Job{
+		Name:     StockHoldExpiryJobName,
+		Schedule: schedule,
+		Timeout:  stockHoldExpiryRunTimeout,
+		Run: func(ctx context.Context) error {
+			released, err := holds.ReleaseExpired(ctx)
+			if released > 0 {
+				logger.InfoContext(ctx, "Released expired stock holds", slog.Int("holds", released))
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestStockHoldExpiryJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.InventoryConfig
+		wantSchedule string
+		releaseErr   error
+	}{
+		{name: "Defaults", wantSchedule: DefaultStockHoldExpirySchedule},
+		{name: "Configured", cfg: config.InventoryConfig{HoldExpirySchedule: "@every 5m"}, wantSchedule: "@every 5m"},
+		{name: "Fails", wantSchedule: DefaultStockHoldExpirySchedule, releaseErr: errors.New("db down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			holds := mocks.NewMockStockHoldService(ctrl)
+			holds.EXPECT().ReleaseExpired(gomock.Any()).Return(2, tt.releaseErr)
+
+			job := NewStockHoldExpiryJob(holds, tt.cfg, discardLogger)
+			assert.Equal(t, tt.wantSchedule, job.Schedule)
+			require.NoError(t, NewScheduler(nil, discardLogger, nil).Register(job))
+			assert.Equal(t, tt.releaseErr, job.Run(context.Background()))
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+// Stock reconciliation defaults, used where config.InventoryConfig leaves a field zero.
+const (
+	DefaultStockReconcileSchedule = "@every 5m"
+	DefaultStockReconcilePolicy   = service.StockPolicyReport
+	DefaultStockSettleWindow      = 2 * time.Minute
+
+	// StockReconcileJobName identifies the reconciler in logs, reports and metrics.
+	StockReconcileJobName = "stock-reconciler"
+	// stockReconcileJitter keeps the reconciler off the order sweeper's beat.
+	stockReconcileJitter = 30 * time.Second
+	// stockReconcileRunTimeout bounds one pass over the catalogue.
+	stockReconcileRunTimeout = 10 * time.Minute
+)
+
+var (
+	stockDriftSKUs = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "inventory_stock_drift_skus",
+			Help: "Number of SKUs whose Redis stock counter differed from the database at the last reconciliation",
+		},
+	)
+
+	stockDriftUnits = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "inventory_stock_drift_units",
+			Help: "Sum of the absolute differences between Redis stock counters and the database at the last reconciliation",
+		},
+	)
+
+	stockRepairs = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "inventory_stock_repairs_total",
+			Help: "Total number of Redis stock counters overwritten with the database stock",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(stockDriftSKUs, stockDriftUnits, stockRepairs)
+}
+
+// NewStockReconcileJob returns the job that compares the Redis stock counters
+// with the database and repairs drift according to cfg.ReconcilePolicy. The
+// drift gauges describe the last complete pass and are left alone when a pass
+// fails partway.
+func NewStockReconcileJob(reconciler service.StockReconciler, cfg config.InventoryConfig, logger *slog.Logger) Job {
+	schedule := cfg.ReconcileSchedule
+	if schedule == "" {
+		schedule = DefaultStockReconcileSchedule
+	}
+	opts := service.StockReconcileOptions{
+		Policy:       cfg.ReconcilePolicy,
+		BatchSize:    cfg.ReconcileBatchSize,
+		SettleWindow: cfg.SettleWindow,
+	}
+	if opts.Policy == "" {
+		opts.Policy = DefaultStockReconcilePolicy
+	}
+	if opts.SettleWindow <= 0 {
+		opts.SettleWindow = DefaultStockSettleWindow
+	}
+
+	return Job{
+		Name:     StockReconcileJobName,
+		Schedule: schedule,
+		Jitter:   stockReconcileJitter,
+		Timeout:  stockReconcileRunTimeout,
+		Run: func(ctx context.Context) error {
+			report, err := reconciler.Reconcile(ctx, opts)
+			if report == nil {
+				return err
+			}
+			stockRepairs.Add(float64(report.Repaired))
+			if err == nil {
+				stockDriftSKUs.Set(float64(report.Drifted))
+				stockDriftUnits.Set(float64(report.DriftUnits))
+			}
+			if report.Drifted > 0 {
+				logger.WarnContext(ctx, "Stock counters drifted from the database",
+					slog.String("policy", opts.Policy),
+					slog.Int("checked", report.Checked),
+					slog.Int("drifted", report.Drifted),
+					slog.Int("drift_units", report.DriftUnits),
+					slog.Int("repaired", report.Repaired),
+				)
+			}
+			return err
+		},
+	}
+}
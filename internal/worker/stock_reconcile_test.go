@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestStockReconcileJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.InventoryConfig
+		wantSchedule string
+		wantOpts     service.StockReconcileOptions
+		report       *service.StockReconcileReport
+		reconcileErr error
+		wantDrift    float64
+	}{
+		{
+			name:         "Defaults",
+			wantSchedule: DefaultStockReconcileSchedule,
+			wantOpts:     service.StockReconcileOptions{Policy: DefaultStockReconcilePolicy, SettleWindow: DefaultStockSettleWindow},
+			report:       &service.StockReconcileReport{Checked: 10, Drifted: 2, DriftUnits: 5},
+			wantDrift:    2,
+		},
+		{
+			name: "Configured",
+			cfg: config.InventoryConfig{
+				ReconcileSchedule:  "*/10 * * * *",
+				ReconcilePolicy:    service.StockPolicyDBWins,
+				ReconcileBatchSize: 50,
+				SettleWindow:       time.Minute,
+			},
+			wantSchedule: "*/10 * * * *",
+			wantOpts:     service.StockReconcileOptions{Policy: service.StockPolicyDBWins, BatchSize: 50, SettleWindow: time.Minute},
+			report:       &service.StockReconcileReport{Checked: 10, Drifted: 3, DriftUnits: 4, Repaired: 3},
+			wantDrift:    3,
+		},
+		{
+			name:         "PartialPassKeepsGauges",
+			wantSchedule: DefaultStockReconcileSchedule,
+			wantOpts:     service.StockReconcileOptions{Policy: DefaultStockReconcilePolicy, SettleWindow: DefaultStockSettleWindow},
+			report:       &service.StockReconcileReport{Checked: 1, Drifted: 1, DriftUnits: 1},
+			reconcileErr: errors.New("db down"),
+			wantDrift:    7, // Left from the previous pass
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			reconciler := mocks.NewMockStockReconciler(ctrl)
+			reconciler.EXPECT().Reconcile(gomock.Any(), tt.wantOpts).Return(tt.report, tt.reconcileErr)
+
+			job := NewStockReconcileJob(reconciler, tt.cfg, discardLogger)
+			assert.Equal(t, tt.wantSchedule, job.Schedule)
+			require.NoError(t, NewScheduler(nil, discardLogger, nil).Register(job))
+
+			stockDriftSKUs.Set(7)
+			repairsBefore := testutil.ToFloat64(stockRepairs)
+			err := job.Run(context.Background())
+			assert.Equal(t, tt.reconcileErr, err)
+			assert.Equal(t, tt.wantDrift, testutil.ToFloat64(stockDriftSKUs))
+			assert.Equal(t, repairsBefore+float64(tt.report.Repaired), testutil.ToFloat64(stockRepairs))
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	// DefaultSubscriptionRenewalSchedule applies when subscription.renewal_schedule is empty.
+	DefaultSubscriptionRenewalSchedule = "@every 10m"
+
+	// SubscriptionRenewalJobName identifies the renewal job in logs, reports and metrics.
+	SubscriptionRenewalJobName = "subscription-renewal"
+	// subscriptionRenewalJitter spreads the charges of several deployments
+	// sharing a payment provider account.
+	subscriptionRenewalJitter = time.Minute
+	// subscriptionRenewalRunTimeout bounds one pass; leftovers are picked up by the next.
+	subscriptionRenewalRunTimeout = 10 * time.Minute
+)
+
+var subscriptionsRenewed = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "subscriptions_renewed_total",
+		Help: "Total number of subscription orders placed and charged",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(subscriptionsRenewed)
+}
+
+// NewSubscriptionRenewalJob returns the job that places the orders of due
+// subscriptions and retries past due ones, in batches of
+// cfg.RenewalBatchSize, across all stores.
+func NewSubscriptionRenewalJob(subscriptions service.SubscriptionService, cfg config.SubscriptionConfig, logger *slog.Logger) Job {
+	schedule := cfg.RenewalSchedule
+	if schedule == "" {
+		schedule = DefaultSubscriptionRenewalSchedule
+	}
+
+	return Job{
+		Name:     SubscriptionRenewalJobName,
+		Schedule: schedule,
+		Jitter:   subscriptionRenewalJitter,
+		Timeout:  subscriptionRenewalRunTimeout,
+		Run: func(ctx context.Context) error {
+			renewed, err := subscriptions.RenewDue(ctx, cfg.RenewalBatchSize)
+			subscriptionsRenewed.Add(float64(renewed))
+			if renewed > 0 {
+				logger.InfoContext(ctx, "Renewed subscriptions", slog.Int("count", renewed))
+			}
+			return err
+		},
+	}
+}
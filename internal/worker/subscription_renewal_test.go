@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSubscriptionRenewalJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.SubscriptionConfig
+		wantSchedule string
+		renewed      int
+		renewErr     error
+	}{
+		{name: "Defaults", wantSchedule: DefaultSubscriptionRenewalSchedule, renewed: 3},
+		{name: "Configured", cfg: config.SubscriptionConfig{RenewalSchedule: "0 * * * *", RenewalBatchSize: 50}, wantSchedule: "0 * * * *"},
+		{name: "PartialPass", wantSchedule: DefaultSubscriptionRenewalSchedule, renewed: 1, renewErr: errors.New("db down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			subscriptions := mocks.NewMockSubscriptionService(ctrl)
+			subscriptions.EXPECT().RenewDue(gomock.Any(), tt.cfg.RenewalBatchSize).Return(tt.renewed, tt.renewErr)
+
+			job := NewSubscriptionRenewalJob(subscriptions, tt.cfg, discardLogger)
+			assert.Equal(t, tt.wantSchedule, job.Schedule)
+			require.NoError(t, NewScheduler(nil, discardLogger, nil).Register(job))
+
+			before := testutil.ToFloat64(subscriptionsRenewed)
+			err := job.Run(context.Background())
+			assert.Equal(t, tt.renewErr, err)
+			assert.Equal(t, before+float64(tt.renewed), testutil.ToFloat64(subscriptionsRenewed))
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	// DefaultSuggestIndexSchedule applies when suggest.schedule is empty.
+	DefaultSuggestIndexSchedule = "@every 10m"
+
+	// SuggestIndexJobName identifies the suggestion index job in logs, reports and metrics.
+	SuggestIndexJobName = "search-suggest-index"
+	// suggestIndexJitter keeps the rebuild off the other jobs' beat.
+	suggestIndexJitter = time.Minute
+	// suggestIndexRunTimeout bounds one rebuild of every store's index.
+	suggestIndexRunTimeout = 5 * time.Minute
+)
+
+// NewSuggestIndexJob returns the job that flushes the zero result searches
+// counted in Redis to the database, then rebuilds the search suggestion index
+// of every store from the catalog. Products created or renamed in between are
+// suggested once it next runs.
+func NewSuggestIndexJob(suggestions service.SuggestionService, cfg config.SuggestConfig, logger *slog.Logger) Job {
+	schedule := cfg.Schedule
+	if schedule == "" {
+		schedule = DefaultSuggestIndexSchedule
+	}
+
+	return Job{
+		Name:     SuggestIndexJobName,
+		Schedule: schedule,
+		Jitter:   suggestIndexJitter,
+		Timeout:  suggestIndexRunTimeout,
+		Run: func(ctx context.Context) error {
+			queries, err := suggestions.FlushZeroResults(ctx)
+			if err != nil {
+				return err
+			}
+			indexed, err := suggestions.RebuildIndex(ctx)
+			if err != nil {
+				return err
+			}
+			logger.DebugContext(ctx, "Rebuilt search suggestion index", slog.Int("products", indexed), slog.Int("zero_result_queries", queries))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSuggestIndexJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.SuggestConfig
+		wantSchedule string
+		flushErr     error
+		rebuildErr   error
+	}{
+		{name: "Defaults", wantSchedule: DefaultSuggestIndexSchedule},
+		{name: "Configured", cfg: config.SuggestConfig{Schedule: "@every 1h"}, wantSchedule: "@every 1h"},
+		{name: "RebuildFailed", wantSchedule: DefaultSuggestIndexSchedule, rebuildErr: errors.New("redis down")},
+		{name: "FlushFailed", wantSchedule: DefaultSuggestIndexSchedule, flushErr: errors.New("db down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			suggestions := mocks.NewMockSuggestionService(ctrl)
+			suggestions.EXPECT().FlushZeroResults(gomock.Any()).Return(2, tt.flushErr)
+			if tt.flushErr == nil {
+				suggestions.EXPECT().RebuildIndex(gomock.Any()).Return(3, tt.rebuildErr)
+			}
+
+			job := NewSuggestIndexJob(suggestions, tt.cfg, discardLogger)
+			assert.Equal(t, tt.wantSchedule, job.Schedule)
+			require.NoError(t, NewScheduler(nil, discardLogger, nil).Register(job))
+
+			wantErr := tt.rebuildErr
+			if tt.flushErr != nil {
+				wantErr = tt.flushErr
+			}
+			assert.Equal(t, wantErr, job.Run(context.Background()))
+		})
+	}
+}
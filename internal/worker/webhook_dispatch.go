@@ -0,0 +1,72 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+const (
+	// DefaultWebhookDispatchSchedule applies when webhook.dispatch_schedule is empty.
+	DefaultWebhookDispatchSchedule = "@every 10s"
+
+	// WebhookDispatchJobName identifies the dispatcher in logs, reports and metrics.
+	WebhookDispatchJobName = "webhook-dispatcher"
+	// webhookDispatchJitter is small: deliveries are due as soon as they are queued.
+	webhookDispatchJitter = 2 * time.Second
+	// webhookDispatchRunTimeout bounds one run; deliveries left over are sent by the next.
+	webhookDispatchRunTimeout = 5 * time.Minute
+)
+
+var webhookDeliveries = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "webhook_deliveries_total",
+		Help: "Total number of webhook delivery attempts, by result (delivered, retrying, failed)",
+	},
+	[]string{"result"},
+)
+
+func init() {
+	prometheus.MustRegister(webhookDeliveries)
+}
+
+// NewWebhookDispatchJob returns the job that sends due webhook deliveries and
+// schedules retries for failed ones.
+func NewWebhookDispatchJob(svc service.WebhookService, cfg config.WebhookConfig, logger *slog.Logger) Job {
+	schedule := cfg.DispatchSchedule
+	if schedule == "" {
+		schedule = DefaultWebhookDispatchSchedule
+	}
+	opts := service.WebhookDispatchOptions{
+		BatchSize:   cfg.BatchSize,
+		MaxAttempts: cfg.MaxAttempts,
+		Timeout:     cfg.Timeout,
+	}
+
+	return Job{
+		Name:     WebhookDispatchJobName,
+		Schedule: schedule,
+		Jitter:   webhookDispatchJitter,
+		Timeout:  webhookDispatchRunTimeout,
+		Run: func(ctx context.Context) error {
+			report, err := svc.Dispatch(ctx, opts)
+			if report == nil {
+				return err
+			}
+			webhookDeliveries.WithLabelValues("delivered").Add(float64(report.Delivered))
+			webhookDeliveries.WithLabelValues("retrying").Add(float64(report.Retrying))
+			webhookDeliveries.WithLabelValues("failed").Add(float64(report.Failed))
+			if report.Failed > 0 {
+				logger.WarnContext(ctx, "Gave up on webhook deliveries",
+					slog.Int("attempted", report.Attempted),
+					slog.Int("failed", report.Failed),
+				)
+			}
+			return err
+		},
+	}
+}
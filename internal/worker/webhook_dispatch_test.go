@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/internal/service"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWebhookDispatchJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.WebhookConfig
+		wantSchedule string
+		wantOpts     service.WebhookDispatchOptions
+		report       *service.WebhookDispatchReport
+		dispatchErr  error
+	}{
+		{
+			name:         "Defaults",
+			wantSchedule: DefaultWebhookDispatchSchedule,
+			report:       &service.WebhookDispatchReport{Attempted: 3, Delivered: 2, Retrying: 1},
+		},
+		{
+			name:         "Configured",
+			cfg:          config.WebhookConfig{DispatchSchedule: "@every 1m", BatchSize: 10, MaxAttempts: 3, Timeout: time.Second},
+			wantSchedule: "@every 1m",
+			wantOpts:     service.WebhookDispatchOptions{BatchSize: 10, MaxAttempts: 3, Timeout: time.Second},
+			report:       &service.WebhookDispatchReport{Attempted: 1, Failed: 1},
+		},
+		{
+			name:         "PartialRunStillCounted",
+			wantSchedule: DefaultWebhookDispatchSchedule,
+			report:       &service.WebhookDispatchReport{Attempted: 1, Delivered: 1},
+			dispatchErr:  errors.New("db down"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			svc := mocks.NewMockWebhookService(ctrl)
+			svc.EXPECT().Dispatch(gomock.Any(), tt.wantOpts).Return(tt.report, tt.dispatchErr)
+
+			job := NewWebhookDispatchJob(svc, tt.cfg, discardLogger)
+			assert.Equal(t, tt.wantSchedule, job.Schedule)
+			require.NoError(t, NewScheduler(nil, discardLogger, nil).Register(job))
+
+			delivered := testutil.ToFloat64(webhookDeliveries.WithLabelValues("delivered"))
+			failed := testutil.ToFloat64(webhookDeliveries.WithLabelValues("failed"))
+			err := job.Run(context.Background())
+			assert.Equal(t, tt.dispatchErr, err)
+			assert.Equal(t, delivered+float64(tt.report.Delivered), testutil.ToFloat64(webhookDeliveries.WithLabelValues("delivered")))
+			assert.Equal(t, failed+float64(tt.report.Failed), testutil.ToFloat64(webhookDeliveries.WithLabelValues("failed")))
+		})
+	}
+}
@@ -0,0 +1,214 @@
+// Package apperr defines the errors services return for outcomes callers
+// are expected to handle: a code naming what went wrong, the HTTP status and
+// retry policy that go with it, and metadata such as the SKU that ran out.
+//
+// Handlers, RPC servers and workers branch on the code with errors.Is
+// against a sentinel built by New, or with CodeOf, rather than on the text
+// of the error, which may be wrapped and changes with the metadata:
+//
+//	return apperr.New(apperr.CodeStockInsufficient).WithSKU(id)
+//	...
+//	if errors.Is(err, service.ErrInsufficientStock) { ... }
+//
+// Errors that carry no code, e.g. a failed database query, are unexpected
+// and treated as internal and retryable.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Code identifies a kind of error. Codes are returned to API clients and
+// must not change once released.
+type Code string
+
+// Codes of the errors services return.
+const (
+	CodeInternal    Code = "internal"
+	CodeUnavailable Code = "unavailable"
+
+	CodeUserExists           Code = "user_exists"
+	CodeInvalidCredentials   Code = "invalid_credentials"
+	CodeInvalidRefreshToken  Code = "invalid_refresh_token"
+	CodeSessionLimitExceeded Code = "session_limit_exceeded"
+
+	CodeStockInsufficient      Code = "stock_insufficient"
+	CodePriceChanged           Code = "price_changed"
+	CodePurchaseLimitExceeded  Code = "purchase_limit_exceeded"
+	CodeSKUNotOnChannel        Code = "sku_not_on_channel"
+	CodeCouponNotFound         Code = "coupon_not_found"
+	CodeCouponNotApplicable    Code = "coupon_not_applicable"
+	CodeCouponRedeemed         Code = "coupon_redeemed"
+	CodeCheckoutSessionExpired Code = "checkout_session_expired"
+	CodeCurrencyUnsupported    Code = "currency_unsupported"
+	CodeRatesUnavailable       Code = "rates_unavailable"
+	CodeTaxUnavailable         Code = "tax_unavailable"
+	CodeAddressInvalid         Code = "address_invalid"
+	CodeShippingRestricted     Code = "shipping_restricted"
+
+	CodeOrderNotFound         Code = "order_not_found"
+	CodeOrderNotPending       Code = "order_not_pending"
+	CodePaymentInProgress     Code = "payment_in_progress"
+	CodePaymentMethodNotFound Code = "payment_method_not_found"
+)
+
+// codeInfo is what a Code implies: the message of errors created by New,
+// the HTTP status they are reported with and whether trying again later
+// may succeed.
+type codeInfo struct {
+	message   string
+	status    int
+	retryable bool
+}
+
+var codes = map[Code]codeInfo{
+	CodeInternal:    {"internal error", http.StatusInternalServerError, true},
+	CodeUnavailable: {"service unavailable", http.StatusServiceUnavailable, true},
+
+	CodeUserExists:           {"username already exists", http.StatusConflict, false},
+	CodeInvalidCredentials:   {"invalid credentials", http.StatusUnauthorized, false},
+	CodeInvalidRefreshToken:  {"invalid or expired refresh token", http.StatusUnauthorized, false},
+	CodeSessionLimitExceeded: {"too many active sessions, log out elsewhere first", http.StatusConflict, false},
+
+	CodeStockInsufficient:      {"insufficient stock", http.StatusConflict, false},
+	CodePriceChanged:           {"prices changed", http.StatusConflict, false},
+	CodePurchaseLimitExceeded:  {"purchase limit exceeded", http.StatusConflict, false},
+	CodeSKUNotOnChannel:        {"SKU is not sold on this channel", http.StatusBadRequest, false},
+	CodeCouponNotFound:         {"coupon not found", http.StatusBadRequest, false},
+	CodeCouponNotApplicable:    {"coupon does not apply to this order", http.StatusBadRequest, false},
+	CodeCouponRedeemed:         {"coupon already redeemed", http.StatusConflict, false},
+	CodeCheckoutSessionExpired: {"checkout session not found or expired", http.StatusNotFound, false},
+	CodeCurrencyUnsupported:    {"unsupported currency", http.StatusBadRequest, false},
+	CodeRatesUnavailable:       {"exchange rate unavailable", http.StatusServiceUnavailable, true},
+	CodeTaxUnavailable:         {"tax calculation unavailable", http.StatusServiceUnavailable, true},
+	CodeAddressInvalid:         {"invalid address", http.StatusBadRequest, false},
+	CodeShippingRestricted:     {"items cannot ship to this region", http.StatusBadRequest, false},
+
+	CodeOrderNotFound:         {"order not found", http.StatusNotFound, false},
+	CodeOrderNotPending:       {"order is not pending payment", http.StatusConflict, false},
+	CodePaymentInProgress:     {"order has a payment in progress", http.StatusConflict, false},
+	CodePaymentMethodNotFound: {"payment method not found", http.StatusNotFound, false},
+}
+
+// HTTPStatus returns the status an error with code c is reported with by
+// default; 500 for unknown codes.
+func (c Code) HTTPStatus() int {
+	if info, ok := codes[c]; ok {
+		return info.status
+	}
+	return http.StatusInternalServerError
+}
+
+// Retryable reports whether an operation failing with code c may succeed
+// when tried again later without changes.
+func (c Code) Retryable() bool {
+	info, ok := codes[c]
+	return !ok || info.retryable
+}
+
+// Error is an error with a Code. Its methods return copies, so sentinels
+// created by New can be decorated without being modified.
+type Error struct {
+	Code    Code
+	Message string
+	// Meta identifies what the error is about, e.g. "sku_id"; it is
+	// returned to API clients.
+	Meta  map[string]any
+	cause error
+}
+
+// New returns an error with code and the code's default message.
+func New(code Code) *Error {
+	message := string(code)
+	if info, ok := codes[code]; ok {
+		message = info.message
+	}
+	return &Error{Code: code, Message: message}
+}
+
+// With returns a copy of e with metadata key set to value.
+func (e *Error) With(key string, value any) *Error {
+	c := *e
+	c.Meta = maps.Clone(e.Meta)
+	if c.Meta == nil {
+		c.Meta = make(map[string]any, 1)
+	}
+	c.Meta[key] = value
+	return &c
+}
+
+// WithSKU returns a copy of e about the SKU with the given ID.
+func (e *Error) WithSKU(id uint64) *Error {
+	return e.With("sku_id", id)
+}
+
+// WithMessage returns a copy of e with message instead of the default.
+func (e *Error) WithMessage(message string) *Error {
+	c := *e
+	c.Message = message
+	return &c
+}
+
+// Wrap returns a copy of e caused by err, which errors.Is and errors.As
+// also match.
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.cause = err
+	return &c
+}
+
+// Error returns the message followed by the metadata in key order and the
+// cause, e.g. "insufficient stock (sku_id=101)".
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Message)
+	if len(e.Meta) > 0 {
+		b.WriteString(" (")
+		for i, key := range slices.Sorted(maps.Keys(e.Meta)) {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "%s=%v", key, e.Meta[key])
+		}
+		b.WriteString(")")
+	}
+	if e.cause != nil {
+		b.WriteString(": ")
+		b.WriteString(e.cause.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns the cause of e, if any.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether target is an *Error with the same code, whatever its
+// message and metadata.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// CodeOf returns the code of the first *Error in err's chain, or
+// CodeInternal if there is none.
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return CodeInternal
+}
+
+// Retryable reports whether the operation that failed with err may succeed
+// when tried again later. Errors without a code are, as they are not
+// expected: a timeout or a lost connection.
+func Retryable(err error) bool {
+	return CodeOf(err).Retryable()
+}
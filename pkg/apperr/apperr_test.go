@@ -0,0 +1,52 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestError(t *testing.T) {
+	sentinel := New(CodeStockInsufficient)
+	err := fmt.Errorf("failed to deduct stock: %w", sentinel.WithSKU(101))
+
+	assert.EqualError(t, err, "failed to deduct stock: insufficient stock (sku_id=101)")
+	assert.ErrorIs(t, err, sentinel, "matched by code whatever the metadata")
+	assert.NotErrorIs(t, err, New(CodePriceChanged))
+	assert.Empty(t, sentinel.Meta, "decorating must not modify the sentinel")
+
+	var appErr *Error
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, map[string]any{"sku_id": uint64(101)}, appErr.Meta)
+
+	wrapped := New(CodeUnavailable).WithMessage("tax cannot be calculated").With("region", "CA").Wrap(context.DeadlineExceeded)
+	assert.EqualError(t, wrapped, "tax cannot be calculated (region=CA): context deadline exceeded")
+	assert.ErrorIs(t, wrapped, context.DeadlineExceeded)
+}
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantCode      Code
+		wantStatus    int
+		wantRetryable bool
+	}{
+		{name: "Coded", err: fmt.Errorf("checkout: %w", New(CodeCouponRedeemed)), wantCode: CodeCouponRedeemed, wantStatus: http.StatusConflict},
+		{name: "RetryableCode", err: New(CodeTaxUnavailable), wantCode: CodeTaxUnavailable, wantStatus: http.StatusServiceUnavailable, wantRetryable: true},
+		{name: "Uncoded", err: errors.New("connection reset"), wantCode: CodeInternal, wantStatus: http.StatusInternalServerError, wantRetryable: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := CodeOf(tt.err)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.wantStatus, code.HTTPStatus())
+			assert.Equal(t, tt.wantRetryable, Retryable(tt.err))
+		})
+	}
+}
@@ -0,0 +1,38 @@
+// Package auth carries the verified access token of the caller, so handlers,
+// services, resolvers and RPC methods find the user the same way whether the
+// request came over HTTP, GraphQL or gRPC.
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/proyuen/go-mall/pkg/token"
+)
+
+// ErrUnauthenticated is returned by UserID when ctx carries no access token.
+var ErrUnauthenticated = errors.New("authorization payload not found in context")
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx that carries payload, the verified access
+// token of the caller.
+func NewContext(ctx context.Context, payload *token.Payload) context.Context {
+	return context.WithValue(ctx, contextKey{}, payload)
+}
+
+// PayloadFromContext returns the verified access token of the caller, or
+// false for anonymous requests and background jobs.
+func PayloadFromContext(ctx context.Context) (*token.Payload, bool) {
+	payload, ok := ctx.Value(contextKey{}).(*token.Payload)
+	return payload, ok && payload != nil
+}
+
+// UserID returns the ID of the authenticated caller, or ErrUnauthenticated.
+func UserID(ctx context.Context) (uint64, error) {
+	payload, ok := PayloadFromContext(ctx)
+	if !ok {
+		return 0, ErrUnauthenticated
+	}
+	return payload.UserID, nil
+}
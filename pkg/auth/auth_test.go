@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserID(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		want    uint64
+		wantErr error
+	}{
+		{name: "Authenticated", ctx: NewContext(context.Background(), &token.Payload{UserID: 7}), want: 7},
+		{name: "Anonymous", ctx: context.Background(), wantErr: ErrUnauthenticated},
+		{name: "NilPayload", ctx: NewContext(context.Background(), nil), wantErr: ErrUnauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UserID(tt.ctx)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -173,6 +174,92 @@ func (c *instrumentedCache) MGet(ctx context.Context, keys ...string) ([]interfa
 	return vals, err
 }
 
+// HExists reports whether a hash has a field.
+func (c *instrumentedCache) HExists(ctx context.Context, key, field string) (bool, error) {
+	start := time.Now()
+	ctx, span := c.tracer.Start(ctx, "redis.HExists", trace.WithAttributes(
+		attribute.String("db.system", "redis"),
+		attribute.String("db.operation", "HEXISTS"),
+		attribute.String("db.statement", key),
+	))
+	defer span.End()
+
+	ok, err := c.next.HExists(ctx, key, field)
+	c.observe(ctx, "hexists", err, start)
+	return ok, err
+}
+
+// HDel deletes fields of a hash.
+func (c *instrumentedCache) HDel(ctx context.Context, key string, fields ...string) error {
+	start := time.Now()
+	ctx, span := c.tracer.Start(ctx, "redis.HDel", trace.WithAttributes(
+		attribute.String("db.system", "redis"),
+		attribute.String("db.operation", "HDEL"),
+		attribute.String("db.statement", key),
+	))
+	defer span.End()
+
+	err := c.next.HDel(ctx, key, fields...)
+	c.observe(ctx, "hdel", err, start)
+	return err
+}
+
+// Scan returns a page of matching keys.
+func (c *instrumentedCache) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	start := time.Now()
+	ctx, span := c.tracer.Start(ctx, "redis.Scan", trace.WithAttributes(
+		attribute.String("db.system", "redis"),
+		attribute.String("db.operation", "SCAN"),
+		attribute.String("db.statement", match),
+	))
+	defer span.End()
+
+	keys, next, err := c.next.Scan(ctx, cursor, match, count)
+	c.observe(ctx, "scan", err, start)
+	return keys, next, err
+}
+
+// Eval runs a Lua script.
+func (c *instrumentedCache) Eval(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	start := time.Now()
+	joinedKeys := strings.Join(keys, ",")
+	if len(joinedKeys) > 100 {
+		joinedKeys = joinedKeys[:100] + "..."
+	}
+
+	ctx, span := c.tracer.Start(ctx, "redis.Eval", trace.WithAttributes(
+		attribute.String("db.system", "redis"),
+		attribute.String("db.operation", "EVALSHA"),
+		attribute.String("db.statement", joinedKeys),
+	))
+	defer span.End()
+
+	res, err := c.next.Eval(ctx, script, keys, args...)
+	c.observe(ctx, "eval", err, start)
+	return res, err
+}
+
+// Publish sends a message to a channel.
+func (c *instrumentedCache) Publish(ctx context.Context, channel string, message interface{}) error {
+	start := time.Now()
+	ctx, span := c.tracer.Start(ctx, "redis.Publish", trace.WithAttributes(
+		attribute.String("db.system", "redis"),
+		attribute.String("db.operation", "PUBLISH"),
+		attribute.String("db.statement", channel),
+	))
+	defer span.End()
+
+	err := c.next.Publish(ctx, channel, message)
+	c.observe(ctx, "publish", err, start)
+	return err
+}
+
+// Subscribe listens on channels. The subscription is long-lived, so it is
+// not traced.
+func (c *instrumentedCache) Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
+	return c.next.Subscribe(ctx, channels...)
+}
+
 // Close closes the underlying cache.
 func (c *instrumentedCache) Close() error {
 	return c.next.Close()
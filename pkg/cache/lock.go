@@ -34,6 +34,12 @@ const (
 	`
 )
 
+// UnlockScript deletes the lock in KEYS[1] if it still holds the token in
+// ARGV[1], so that an owner never releases a lock that expired and was taken
+// by another. It returns 1 if it deleted the lock. Locks taken with
+// Cache.SetNX are released by running it through Cache.Eval.
+var UnlockScript = redis.NewScript(unlockScript)
+
 type RedisLock struct {
 	client    *redis.Client
 	key       string
@@ -61,18 +67,12 @@ func (l *RedisLock) Lock(ctx context.Context, ttl time.Duration) (bool, error) {
 
 	for {
 		// Attempt to acquire the lock
-		resp, err := l.client.Eval(ctx, lockScript, []string{l.key}, l.id, ttl.Milliseconds()).Result()
-
-		if err != nil && err != redis.Nil { // General Redis error
-			return false, fmt.Errorf("redis error during lock attempt: %w", err)
-		}
-
-		if resp == "OK" { // Lock acquired successfully
-			go l.watchdog(ttl)
-			return true, nil
+		acquired, err := l.TryLock(ctx, ttl)
+		if err != nil || acquired {
+			return acquired, err
 		}
 
-		// Lock not acquired (err == redis.Nil or resp != "OK"). Wait and retry.
+		// Lock not acquired. Wait and retry.
 		select {
 		case <-ctx.Done():
 			return false, ctx.Err() // Context cancelled or timed out
@@ -83,6 +83,33 @@ func (l *RedisLock) Lock(ctx context.Context, ttl time.Duration) (bool, error) {
 	}
 }
 
+// TryLock makes a single attempt to acquire the lock and reports whether it
+// succeeded. Like Lock, a successful call starts the watchdog.
+func (l *RedisLock) TryLock(ctx context.Context, ttl time.Duration) (bool, error) {
+	resp, err := l.client.Eval(ctx, lockScript, []string{l.key}, l.id, ttl.Milliseconds()).Result()
+	if err != nil && err != redis.Nil { // General Redis error
+		return false, fmt.Errorf("redis error during lock attempt: %w", err)
+	}
+	if resp != "OK" { // Held by someone else
+		return false, nil
+	}
+	go l.watchdog(ttl)
+	return true, nil
+}
+
+// Held reports whether this instance still owns the lock. The watchdog stops
+// silently when a renewal fails, so long-running holders should check this.
+func (l *RedisLock) Held(ctx context.Context) (bool, error) {
+	owner, err := l.client.Get(ctx, l.key).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read lock owner: %w", err)
+	}
+	return owner == l.id, nil
+}
+
 // Unlock releases the lock.
 func (l *RedisLock) Unlock(ctx context.Context) error {
 	// Signal watchdog to stop
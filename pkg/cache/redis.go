@@ -29,6 +29,28 @@ type Cache interface {
 	// MGet retrieves multiple values from the cache.
 	MGet(ctx context.Context, keys ...string) ([]interface{}, error)
 
+	// HExists reports whether the hash at key has field.
+	HExists(ctx context.Context, key, field string) (bool, error)
+
+	// HDel deletes fields from the hash at key. Missing fields are ignored.
+	HDel(ctx context.Context, key string, fields ...string) error
+
+	// Scan returns a page of the keys matching the glob pattern match, about
+	// count of them, and the cursor of the next page; 0 starts and ends a
+	// scan. A key may be returned more than once.
+	Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error)
+
+	// Eval runs a Lua script atomically on keys. A nil reply is returned as
+	// nil without an error.
+	Eval(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) (interface{}, error)
+
+	// Publish sends message to the current subscribers of channel.
+	Publish(ctx context.Context, channel string, message interface{}) error
+
+	// Subscribe listens on channels until the returned PubSub is closed. It
+	// reconnects by itself, so it does not fail while Redis is down.
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+
 	// Close closes the Redis client.
 	Close() error
 }
@@ -142,6 +164,46 @@ func (r *redisCache) MGet(ctx context.Context, keys ...string) ([]interface{}, e
 	return r.client.MGet(ctx, r.buildKeys(keys)...).Result()
 }
 
+func (r *redisCache) HExists(ctx context.Context, key, field string) (bool, error) {
+	return r.client.HExists(ctx, r.buildKey(key), field).Result()
+}
+
+func (r *redisCache) HDel(ctx context.Context, key string, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return r.client.HDel(ctx, r.buildKey(key), fields...).Err()
+}
+
+func (r *redisCache) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	keys, next, err := r.client.Scan(ctx, cursor, r.buildKey(match), count).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	if r.prefix != "" {
+		for i, key := range keys {
+			keys[i] = strings.TrimPrefix(key, r.prefix+":")
+		}
+	}
+	return keys, next, nil
+}
+
+func (r *redisCache) Eval(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	res, err := script.Run(ctx, r.client, r.buildKeys(keys), args...).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return res, err
+}
+
+func (r *redisCache) Publish(ctx context.Context, channel string, message interface{}) error {
+	return r.client.Publish(ctx, r.buildKey(channel), message).Err()
+}
+
+func (r *redisCache) Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
+	return r.client.Subscribe(ctx, r.buildKeys(channels)...)
+}
+
 func (r *redisCache) Close() error {
 	return r.client.Close()
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/sony/gobreaker"
 )
 
@@ -33,6 +34,33 @@ func NewResilientCache(next Cache) Cache {
 	}
 }
 
+// BreakerState is a snapshot of the Circuit Breaker of a resilient cache.
+// The counts cover the current interval of its state.
+type BreakerState struct {
+	Name                string
+	State               string // "closed", "half-open" or "open"
+	Requests            uint32
+	TotalFailures       uint32
+	ConsecutiveFailures uint32
+}
+
+// Breaker returns the state of the Circuit Breaker of c, and false if c was not
+// created by NewResilientCache.
+func Breaker(c Cache) (BreakerState, bool) {
+	rc, ok := c.(*resilientCache)
+	if !ok {
+		return BreakerState{}, false
+	}
+	counts := rc.breaker.Counts()
+	return BreakerState{
+		Name:                rc.breaker.Name(),
+		State:               rc.breaker.State().String(),
+		Requests:            counts.Requests,
+		TotalFailures:       counts.TotalFailures,
+		ConsecutiveFailures: counts.ConsecutiveFailures,
+	}, true
+}
+
 // executeWithRetry wraps the operation with Retry logic and executes it via Circuit Breaker.
 func (c *resilientCache) executeWithRetry(ctx context.Context, operation func() (interface{}, error)) (interface{}, error) {
 	// Retry wrapper
@@ -123,6 +151,68 @@ func (c *resilientCache) MGet(ctx context.Context, keys ...string) ([]interface{
 	return val.([]interface{}), nil
 }
 
+// HExists checks a hash field with resilience.
+func (c *resilientCache) HExists(ctx context.Context, key, field string) (bool, error) {
+	res, err := c.executeWithRetry(ctx, func() (interface{}, error) {
+		return c.next.HExists(ctx, key, field)
+	})
+	if err != nil {
+		return false, err
+	}
+	return res.(bool), nil
+}
+
+// HDel deletes hash fields with resilience.
+func (c *resilientCache) HDel(ctx context.Context, key string, fields ...string) error {
+	_, err := c.executeWithRetry(ctx, func() (interface{}, error) {
+		return nil, c.next.HDel(ctx, key, fields...)
+	})
+	return err
+}
+
+// scanPage is one page of a Scan, passed through executeWithRetry.
+type scanPage struct {
+	keys []string
+	next uint64
+}
+
+// Scan returns a page of matching keys with resilience. Pages are read-only,
+// so retrying one is safe.
+func (c *resilientCache) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	val, err := c.executeWithRetry(ctx, func() (interface{}, error) {
+		keys, next, err := c.next.Scan(ctx, cursor, match, count)
+		return scanPage{keys: keys, next: next}, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	page := val.(scanPage)
+	return page.keys, page.next, nil
+}
+
+// Eval runs a script through the Circuit Breaker but never retries it: a
+// script whose reply was lost may have run, and scripts usually write.
+func (c *resilientCache) Eval(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	return c.breaker.Execute(func() (interface{}, error) {
+		return c.next.Eval(ctx, script, keys, args...)
+	})
+}
+
+// Publish sends a message with resilience. A retried message may be
+// delivered twice, so subscribers must be idempotent.
+func (c *resilientCache) Publish(ctx context.Context, channel string, message interface{}) error {
+	_, err := c.executeWithRetry(ctx, func() (interface{}, error) {
+		return nil, c.next.Publish(ctx, channel, message)
+	})
+	return err
+}
+
+// Subscribe bypasses the Circuit Breaker: the subscription reconnects by
+// itself.
+func (c *resilientCache) Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
+	return c.next.Subscribe(ctx, channels...)
+}
+
 // Close closes the underlying cache.
 func (c *resilientCache) Close() error {
 	return c.next.Close()
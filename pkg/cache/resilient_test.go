@@ -7,6 +7,7 @@ import (
 
 	"github.com/proyuen/go-mall/internal/mocks"
 	"github.com/proyuen/go-mall/pkg/cache"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 )
@@ -56,7 +57,7 @@ func TestResilientCache_Get(t *testing.T) {
 			},
 			wantVal:     "",
 			wantErr:     true,
-			errContains: "max retries exceeded", 
+			errContains: "max retries exceeded",
 		},
 		{
 			name: "No Retry on Cache Miss",
@@ -78,7 +79,7 @@ func TestResilientCache_Get(t *testing.T) {
 			},
 			wantVal:     "",
 			wantErr:     true,
-			errContains: "context canceled", 
+			errContains: "context canceled",
 		},
 	}
 
@@ -109,4 +110,36 @@ func TestResilientCache_Get(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestResilientCache_EvalNotRetried(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache(ctrl)
+	script := redis.NewScript(`return 1`)
+	// A script whose reply was lost may have run, so it is not run again
+	mockCache.EXPECT().Eval(gomock.Any(), script, []string{"k"}, 1).Return(nil, errors.New("i/o timeout")).Times(1)
+
+	_, err := cache.NewResilientCache(mockCache).Eval(context.Background(), script, []string{"k"}, 1)
+	assert.Error(t, err)
+}
+
+func TestBreaker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache(ctrl)
+	mockCache.EXPECT().Eval(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused")).Times(10)
+
+	resilient := cache.NewResilientCache(mockCache)
+	state, ok := cache.Breaker(resilient)
+	assert.True(t, ok)
+	assert.Equal(t, cache.BreakerState{Name: "redis-cache", State: "closed"}, state)
+
+	script := redis.NewScript(`return 1`)
+	for range 10 {
+		_, _ = resilient.Eval(context.Background(), script, nil)
+	}
+	state, _ = cache.Breaker(resilient)
+	assert.Equal(t, "open", state.State)
+
+	_, ok = cache.Breaker(mockCache)
+	assert.False(t, ok)
+}
@@ -0,0 +1,77 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../../internal/mocks/captcha_verifier_mock.go -package=mocks
+// Verifier checks a CAPTCHA response token submitted by a client.
+type Verifier interface {
+	// Verify returns true when the token was issued for a solved challenge.
+	// An error means the provider could not be reached, not that the token is wrong.
+	Verify(ctx context.Context, token, remoteIP string) (bool, error)
+}
+
+// SiteVerifier implements Verifier against the "siteverify" API shared by
+// hCaptcha, Google reCAPTCHA and Cloudflare Turnstile.
+type SiteVerifier struct {
+	endpoint string
+	secret   string
+	client   *http.Client
+}
+
+// NewSiteVerifier creates a SiteVerifier for the given provider endpoint,
+// e.g. https://hcaptcha.com/siteverify.
+func NewSiteVerifier(endpoint, secret string) *SiteVerifier {
+	return &SiteVerifier{
+		endpoint: endpoint,
+		secret:   secret,
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+type siteVerifyResponse struct {
+	Success bool `json:"success"`
+}
+
+// Verify posts the token to the provider and reports its verdict.
+func (v *SiteVerifier) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+
+	form := url.Values{}
+	form.Set("secret", v.secret)
+	form.Set("response", token)
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, fmt.Errorf("failed to build captcha verification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to call captcha provider: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("captcha provider returned status %d", resp.StatusCode)
+	}
+
+	var result siteVerifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("failed to decode captcha provider response: %w", err)
+	}
+	return result.Success, nil
+}
@@ -0,0 +1,56 @@
+package captcha
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSiteVerifier_Verify(t *testing.T) {
+	tests := []struct {
+		name        string
+		token       string
+		status      int
+		body        string
+		wantOK      bool
+		wantErr     bool
+		wantRequest bool
+	}{
+		{name: "Success", token: "solved", status: http.StatusOK, body: `{"success":true}`, wantOK: true, wantRequest: true},
+		{name: "Rejected", token: "wrong", status: http.StatusOK, body: `{"success":false,"error-codes":["invalid-input-response"]}`, wantOK: false, wantRequest: true},
+		{name: "EmptyTokenSkipsProvider", token: "", wantOK: false},
+		{name: "ProviderError", token: "solved", status: http.StatusBadGateway, wantErr: true, wantRequest: true},
+		{name: "MalformedResponse", token: "solved", status: http.StatusOK, body: `not json`, wantErr: true, wantRequest: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				require.NoError(t, r.ParseForm())
+				assert.Equal(t, "test-secret", r.PostForm.Get("secret"))
+				assert.Equal(t, tt.token, r.PostForm.Get("response"))
+				assert.Equal(t, "203.0.113.7", r.PostForm.Get("remoteip"))
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			verifier := NewSiteVerifier(server.URL, "test-secret")
+			ok, err := verifier.Verify(context.Background(), tt.token, "203.0.113.7")
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantRequest, called)
+		})
+	}
+}
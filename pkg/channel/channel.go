@@ -0,0 +1,58 @@
+// Package channel carries the sales channel of a request, so listings and
+// checkout only offer the SKUs sold on it whether the request came over
+// HTTP, GraphQL or gRPC.
+package channel
+
+import (
+	"context"
+	"strings"
+)
+
+// Sales channels. Requests that name none are for the web shop.
+const (
+	Web       = "web"
+	App       = "app"
+	Wholesale = "wholesale"
+)
+
+// All lists every sales channel.
+var All = []string{Web, App, Wholesale}
+
+// Valid reports whether name is a sales channel.
+func Valid(name string) bool {
+	for _, ch := range All {
+		if ch == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Includes reports whether channels, a comma-separated list as stored on a
+// SKU, contains ch. An empty list includes every channel.
+func Includes(channels, ch string) bool {
+	if channels == "" {
+		return true
+	}
+	for _, name := range strings.Split(channels, ",") {
+		if name == ch {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx for requests on ch.
+func NewContext(ctx context.Context, ch string) context.Context {
+	return context.WithValue(ctx, contextKey{}, ch)
+}
+
+// FromContext returns the sales channel of ctx, Web when it has none.
+func FromContext(ctx context.Context) string {
+	if ch, ok := ctx.Value(contextKey{}).(string); ok && ch != "" {
+		return ch
+	}
+	return Web
+}
@@ -0,0 +1,33 @@
+package channel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncludes(t *testing.T) {
+	tests := []struct {
+		name     string
+		channels string
+		ch       string
+		want     bool
+	}{
+		{name: "EmptyIncludesAll", channels: "", ch: Wholesale, want: true},
+		{name: "Listed", channels: "web,app", ch: App, want: true},
+		{name: "NotListed", channels: "app", ch: Web, want: false},
+		{name: "NoPrefixMatch", channels: "wholesale", ch: "whole", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Includes(tt.channels, tt.ch))
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, Web, FromContext(context.Background()))
+	assert.Equal(t, Wholesale, FromContext(NewContext(context.Background(), Wholesale)))
+}
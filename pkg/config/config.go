@@ -1,67 +1,730 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
+const (
+	// EnvPrefix namespaces environment overrides: server.port is read from MALL_SERVER_PORT.
+	EnvPrefix = "MALL"
+	// ProfileEnv selects the config profile, e.g. MALL_ENV=production.
+	ProfileEnv = "MALL_ENV"
+	// ConfigDirEnv overrides the directory holding the config files.
+	ConfigDirEnv = "MALL_CONFIG_DIR"
+	// DefaultDir is used when neither a flag nor ConfigDirEnv names a directory.
+	DefaultDir = "./configs"
+
+	baseFile = "config.yaml"
+)
+
+var profilePattern = regexp.MustCompile(`^[A-Za-z0-9_-]*$`)
+
+// Every leaf field below can be set in config.yaml, as a MALL_* environment variable,
+// or as a --section.key flag (see NewFlagSet). Fields tagged redact:"true" are masked
+// by Print when redaction is requested.
+
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	RabbitMQ RabbitMQConfig `mapstructure:"rabbitmq"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
+	Server        ServerConfig        `mapstructure:"server"`
+	Database      DatabaseConfig      `mapstructure:"database"`
+	Redis         RedisConfig         `mapstructure:"redis"`
+	Cache         CacheConfig         `mapstructure:"cache"`
+	RabbitMQ      RabbitMQConfig      `mapstructure:"rabbitmq"`
+	Worker        WorkerConfig        `mapstructure:"worker"`
+	JWT           JWTConfig           `mapstructure:"jwt"`
+	Token         TokenConfig         `mapstructure:"token"`
+	PasswordReset PasswordResetConfig `mapstructure:"password_reset"`
+	Security      SecurityConfig      `mapstructure:"security"`
+	Order         OrderConfig         `mapstructure:"order"`
+	Inventory     InventoryConfig     `mapstructure:"inventory"`
+	Notification  NotificationConfig  `mapstructure:"notification"`
+	Webhook       WebhookConfig       `mapstructure:"webhook"`
+	Events        EventsConfig        `mapstructure:"events"`
+	Currency      CurrencyConfig      `mapstructure:"currency"`
+	Tax           TaxConfig           `mapstructure:"tax"`
+	Address       AddressConfig       `mapstructure:"address"`
+	Coupon        CouponConfig        `mapstructure:"coupon"`
+	Cart          CartConfig          `mapstructure:"cart"`
+	Subscription  SubscriptionConfig  `mapstructure:"subscription"`
+	Digital       DigitalConfig       `mapstructure:"digital"`
+	Popularity    PopularityConfig    `mapstructure:"popularity"`
+	Pricing       PricingConfig       `mapstructure:"pricing"`
+	Suggest       SuggestConfig       `mapstructure:"suggest"`
+	Reviews       ReviewsConfig       `mapstructure:"reviews"`
+	Payment       PaymentConfig       `mapstructure:"payment"`
+	I18n          I18nConfig          `mapstructure:"i18n"`
+	Tenancy       TenancyConfig       `mapstructure:"tenancy"`
+	Log           LogConfig           `mapstructure:"log"`
+	Sentry        SentryConfig        `mapstructure:"sentry"`
+	Tracing       TracingConfig       `mapstructure:"tracing"`
 }
 
 type RabbitMQConfig struct {
-	URL string `mapstructure:"url"`
+	URL string `mapstructure:"url" validate:"omitempty,url" redact:"true"` // amqp URLs embed credentials
+}
+
+// WorkerConfig bounds the queue consumers of cmd/worker, so that a burst of
+// messages cannot take every connection of the database pool the API uses.
+type WorkerConfig struct {
+	Orders        ConsumerConfig `mapstructure:"orders"`        // orders.created
+	Notifications ConsumerConfig `mapstructure:"notifications"` // notifications
+	OrderSummary  ConsumerConfig `mapstructure:"order_summary"` // order_summaries
+	PickTasks     ConsumerConfig `mapstructure:"pick_tasks"`    // pick_tasks
+	Carts         ConsumerConfig `mapstructure:"carts"`         // cart_conversions
+	// ReplaySchedule is how often the failed messages an admin replays are
+	// published to their queue again.
+	ReplaySchedule  string `mapstructure:"replay_schedule"`
+	ReplayBatchSize int    `mapstructure:"replay_batch_size" validate:"min=0"`
+	// HealthReportInterval is how often every worker reports its broker
+	// connection and the queue depths for the admin diagnostics.
+	HealthReportInterval time.Duration `mapstructure:"health_report_interval" validate:"min=0"`
+}
+
+// ConsumerConfig bounds one queue consumer.
+type ConsumerConfig struct {
+	// Concurrency is how many messages are processed, and prefetched, at
+	// once; 0 means 1.
+	Concurrency int `mapstructure:"concurrency" validate:"min=0,max=64"`
+	// RateLimit is how many messages start per second, 0 meaning no limit.
+	// Up to Concurrency messages may start at once after a pause.
+	RateLimit float64 `mapstructure:"rate_limit" validate:"min=0"`
 }
 
 type ServerConfig struct {
-	Port string `mapstructure:"port"`
-	Mode string `mapstructure:"mode"`
+	Port string `mapstructure:"port" validate:"required,tcp_port"`
+	// GRPCPort serves the gRPC API for internal callers; empty disables it.
+	GRPCPort string `mapstructure:"grpc_port" validate:"omitempty,tcp_port,nefield=Port"`
+	Mode     string `mapstructure:"mode" validate:"omitempty,oneof=debug release test"`
+	// OpenAPIValidation rejects requests that do not match the generated Swagger spec.
+	OpenAPIValidation bool `mapstructure:"openapi_validation"`
 }
 
 type DatabaseConfig struct {
-	Host     string `mapstructure:"host"`
-	Port     string `mapstructure:"port"`
-	User     string `mapstructure:"user"`
-	Password string `mapstructure:"password"`
-	DBName   string `mapstructure:"dbname"`
-	SSLMode  string `mapstructure:"sslmode"`
+	Host     string `mapstructure:"host" validate:"required"`
+	Port     string `mapstructure:"port" validate:"required,tcp_port"`
+	User     string `mapstructure:"user" validate:"required"`
+	Password string `mapstructure:"password" redact:"true"`
+	DBName   string `mapstructure:"dbname" validate:"required"`
+	SSLMode  string `mapstructure:"sslmode" validate:"omitempty,oneof=disable allow prefer require verify-ca verify-full"`
 	TimeZone string `mapstructure:"timezone"`
 }
 
 type RedisConfig struct {
-	Addr     string `mapstructure:"addr"`
-	Password string `mapstructure:"password"`
-	DB       int    `mapstructure:"db"`
-	PoolSize int    `mapstructure:"pool_size"`
+	Addr     string `mapstructure:"addr" validate:"required,hostname_port"`
+	Password string `mapstructure:"password" redact:"true"`
+	DB       int    `mapstructure:"db" validate:"min=0"`
+	PoolSize int    `mapstructure:"pool_size" validate:"min=0"`
+}
+
+// CacheConfig sets how long each class of catalog entries is cached. Zero
+// values fall back to the defaults in internal/service.
+type CacheConfig struct {
+	Product     CacheTTLConfig         `mapstructure:"product"`      // Product detail
+	ProductList CacheTTLConfig         `mapstructure:"product_list"` // List pages
+	Maintenance CacheMaintenanceConfig `mapstructure:"maintenance"`
+}
+
+// CacheMaintenanceConfig schedules the cmd/worker jobs that purge stale keys
+// from Redis, one per kind of key.
+type CacheMaintenanceConfig struct {
+	Idempotency      CacheSweepConfig `mapstructure:"idempotency"`       // Message idempotency keys that never expire
+	CheckoutSessions CacheSweepConfig `mapstructure:"checkout_sessions"` // Checkout sessions that never expire
+	PurchaseCounters CacheSweepConfig `mapstructure:"purchase_counters"` // Counters of purchase limits since lifted
+	RateLimits       CacheSweepConfig `mapstructure:"rate_limits"`       // Review vote and report windows outliving reviews.limit_window
+}
+
+type CacheSweepConfig struct {
+	Schedule string `mapstructure:"schedule"` // Cron spec or descriptor, e.g. "@every 1h"
+	DryRun   bool   `mapstructure:"dry_run"`  // Counts stale keys without deleting them
+}
+
+// CacheTTLConfig enables stale-while-revalidate when SoftTTL is set below
+// HardTTL: entries older than SoftTTL are served while they are reloaded in
+// the background, and dropped at HardTTL.
+type CacheTTLConfig struct {
+	SoftTTL time.Duration `mapstructure:"soft_ttl" validate:"min=0"` // Zero serves entries as they are until HardTTL
+	HardTTL time.Duration `mapstructure:"hard_ttl" validate:"min=0"`
 }
 
+// JWTConfig signs the tokens of users. Zero lifetimes fall back to the
+// defaults in internal/service. KeyID and Keys are applied on reload, so
+// keys rotate without a restart; the rest needs one.
 type JWTConfig struct {
-	Secret string `mapstructure:"secret"`
+	Secret          string        `mapstructure:"secret" validate:"required_without=PrivateKeyFile,omitempty,min=32" redact:"true"` // Key size enforced by token.NewJWTMaker and token.NewPasetoMaker
+	AccessTokenTTL  time.Duration `mapstructure:"access_token_ttl" validate:"omitempty,min=1m,max=24h"`
+	RefreshTokenTTL time.Duration `mapstructure:"refresh_token_ttl" validate:"omitempty,min=1h,gtefield=AccessTokenTTL"`
+	// RememberMeTTL replaces RefreshTokenTTL for logins asking to be remembered.
+	RememberMeTTL time.Duration `mapstructure:"remember_me_ttl" validate:"omitempty,gtefield=RefreshTokenTTL"`
+	// Algorithm RS256 or EdDSA signs with PrivateKeyFile instead of Secret,
+	// so other services can verify tokens holding only the public key.
+	Algorithm      string `mapstructure:"algorithm" validate:"omitempty,oneof=HS256 RS256 EdDSA"`                              // Empty means HS256
+	PrivateKeyFile string `mapstructure:"private_key_file" validate:"required_if=Algorithm RS256,required_if=Algorithm EdDSA"` // PEM, PKCS#1 or PKCS#8
+	PublicKeyFile  string `mapstructure:"public_key_file"`                                                                     // PEM; empty derives it from the private key
+	// KeyID names the key above in the kid header of new tokens. Tokens
+	// are then verified with the key their header names, including Keys.
+	KeyID string         `mapstructure:"key_id"`                         // Empty sends no kid header; required with Keys
+	Keys  []JWTKeyConfig `mapstructure:"keys" validate:"unique=ID,dive"` // Previous keys, verifying the tokens they signed until removed
+}
+
+// JWTKeyConfig is a key tokens were signed with before the current one, as
+// configured in JWTConfig. It only verifies tokens, so asymmetric keys need
+// only their public key.
+type JWTKeyConfig struct {
+	ID            string `mapstructure:"id" validate:"required"`                                                             // Other than jwt.key_id
+	Algorithm     string `mapstructure:"algorithm" validate:"omitempty,oneof=HS256 RS256 EdDSA"`                             // Empty means HS256
+	Secret        string `mapstructure:"secret" validate:"required_without=PublicKeyFile,omitempty,min=32" redact:"true"`    // HS256
+	PublicKeyFile string `mapstructure:"public_key_file" validate:"required_if=Algorithm RS256,required_if=Algorithm EdDSA"` // PEM, for RS256 and EdDSA
+}
+
+// TokenConfig chooses the format of the tokens of users. Tokens issued in
+// one format are rejected once the type changes, so users have to log in
+// again.
+type TokenConfig struct {
+	Type string `mapstructure:"type" validate:"omitempty,oneof=jwt paseto"` // jwt (signed as set by jwt.algorithm) or paseto (v4.local, keyed with jwt.secret); empty means jwt
+}
+
+// PasswordResetConfig controls the links users who forgot their password are
+// emailed. Zero values fall back to the defaults in internal/service.
+type PasswordResetConfig struct {
+	URL         string        `mapstructure:"url" validate:"omitempty,url"` // Storefront page the links open, with the token as ?token=; empty disables password resets
+	TokenTTL    time.Duration `mapstructure:"token_ttl" validate:"min=0"`   // How long a link works; whole minutes
+	Limit       int           `mapstructure:"limit" validate:"min=0"`       // Reset requests per email per limit_window
+	LimitWindow time.Duration `mapstructure:"limit_window" validate:"min=0"`
+}
+
+// OrderConfig controls how checkout deducts stock, the job that cancels
+// orders left unpaid and the one that allocates stock to backorders. Zero
+// values fall back to the defaults in internal/worker
+// and internal/service.
+type OrderConfig struct {
+	PaymentTimeout     time.Duration `mapstructure:"payment_timeout" validate:"min=0"` // Pending orders older than this are cancelled
+	SweepSchedule      string        `mapstructure:"sweep_schedule"`                   // Cron spec or descriptor, e.g. "@every 1m"
+	SweepBatchSize     int           `mapstructure:"sweep_batch_size" validate:"min=0"`
+	StockLocking       string        `mapstructure:"stock_locking" validate:"omitempty,oneof=conditional pessimistic"` // Empty means conditional
+	PaymentCapture     string        `mapstructure:"payment_capture" validate:"omitempty,oneof=automatic shipment"`    // Empty means automatic
+	CheckoutSessionTTL time.Duration `mapstructure:"checkout_session_ttl" validate:"min=0"`                            // How long a checkout session holds its prices
+	RestockFailed      bool          `mapstructure:"restock_failed"`                                                   // Orders failed for lack of stock give back the stock they reserved
+	BackorderSchedule  string        `mapstructure:"backorder_schedule"`                                               // Cron spec or descriptor, e.g. "@every 5m"
+	BackorderBatchSize int           `mapstructure:"backorder_batch_size" validate:"min=0"`
+	// SummaryBackfillSchedule is how often orders without an order list
+	// summary get one, e.g. those placed before it existed.
+	SummaryBackfillSchedule  string `mapstructure:"summary_backfill_schedule"`
+	SummaryBackfillBatchSize int    `mapstructure:"summary_backfill_batch_size" validate:"min=0"`
+	// SagaSchedule is how often checkout sagas that stopped halfway, or wait
+	// to retry a step, are resumed.
+	SagaSchedule    string `mapstructure:"saga_schedule"`
+	SagaBatchSize   int    `mapstructure:"saga_batch_size" validate:"min=0"`
+	SagaMaxAttempts int    `mapstructure:"saga_max_attempts" validate:"min=0"` // Attempts at a step before the saga gives up on it
+}
+
+// InventoryConfig controls the job that reconciles the Redis stock counters
+// with the database and the one that releases expired stock holds. Zero
+// values fall back to the defaults in internal/worker.
+type InventoryConfig struct {
+	ReconcileSchedule  string        `mapstructure:"reconcile_schedule"`                                                    // Cron spec or descriptor, e.g. "@every 5m"
+	ReconcilePolicy    string        `mapstructure:"reconcile_policy" validate:"omitempty,oneof=report lower_only db_wins"` // Which drift is repaired; empty means report
+	ReconcileBatchSize int           `mapstructure:"reconcile_batch_size" validate:"min=0"`
+	SettleWindow       time.Duration `mapstructure:"settle_window" validate:"min=0"` // SKUs ordered this recently are skipped
+	HoldExpirySchedule string        `mapstructure:"hold_expiry_schedule"`           // How often expired stock holds are released, e.g. "@every 1m"
+}
+
+// WebhookConfig controls outbound webhooks: events are queued by the process
+// that produces them and sent by cmd/worker. Zero values fall back to the
+// defaults in internal/service and internal/worker.
+type WebhookConfig struct {
+	DispatchSchedule  string        `mapstructure:"dispatch_schedule"`                    // Cron spec or descriptor, e.g. "@every 10s"
+	BatchSize         int           `mapstructure:"batch_size" validate:"min=0"`          // Deliveries loaded per query
+	MaxAttempts       int           `mapstructure:"max_attempts" validate:"min=0"`        // Attempts before a delivery is marked failed
+	Timeout           time.Duration `mapstructure:"timeout" validate:"min=0"`             // Per request
+	LowStockThreshold int           `mapstructure:"low_stock_threshold" validate:"min=0"` // stock.low fires when an order takes a SKU's stock to this or below
+}
+
+// EventsConfig controls the relay that publishes domain events from the
+// outbox to RabbitMQ. Zero values fall back to the defaults in
+// internal/service and internal/worker.
+type EventsConfig struct {
+	RelaySchedule string        `mapstructure:"relay_schedule"`              // Cron spec or descriptor, e.g. "@every 5s"
+	BatchSize     int           `mapstructure:"batch_size" validate:"min=0"` // Events loaded per query
+	Retention     time.Duration `mapstructure:"retention" validate:"min=0"`  // How long published events are kept in the outbox
+}
+
+// CurrencyConfig controls multi-currency pricing. Each SKU is priced in its own
+// currency; responses and orders are converted with exchange rates that
+// cmd/worker refreshes. Zero values fall back to the defaults in
+// internal/service and internal/worker.
+type CurrencyConfig struct {
+	Base          string        `mapstructure:"base" validate:"omitempty,iso4217"`  // Currency of SKUs created without one, and of the stored rates; empty means USD
+	Supported     []string      `mapstructure:"supported" validate:"dive,iso4217"`  // Currencies clients may request besides the base
+	RatesURL      string        `mapstructure:"rates_url" validate:"omitempty,url"` // {base} is replaced with the base currency; empty disables fetching
+	RatesSchedule string        `mapstructure:"rates_schedule"`                     // Cron spec or descriptor, e.g. "@every 1h"
+	RatesMaxAge   time.Duration `mapstructure:"rates_max_age" validate:"min=0"`     // Older rates are not converted with
+}
+
+// CouponConfig controls coupon codes and the job that rolls up their
+// redemption stats. Zero values fall back to the defaults in internal/service
+// and internal/worker.
+type CouponConfig struct {
+	CodeLength     int    `mapstructure:"code_length" validate:"omitempty,min=6,max=32"` // Random characters after the prefix
+	RollupSchedule string `mapstructure:"rollup_schedule"`                               // Cron spec or descriptor, e.g. "@every 15m"
+}
+
+// CartConfig controls the job that reminds customers of the carts they left
+// without ordering. Zero values fall back to the defaults in internal/service
+// and internal/worker.
+type CartConfig struct {
+	RecoverySchedule string        `mapstructure:"recovery_schedule"`                     // Cron spec or descriptor, e.g. "@every 15m"
+	AbandonedAfter   time.Duration `mapstructure:"abandoned_after" validate:"min=0"`      // A cart untouched this long is abandoned
+	RecoveryWindow   time.Duration `mapstructure:"recovery_window" validate:"min=0"`      // Carts abandoned longer than this are left alone
+	RecoveryURL      string        `mapstructure:"recovery_url" validate:"omitempty,url"` // Storefront cart page the reminders link to; empty leaves the link out
+	CouponCampaignID uint64        `mapstructure:"coupon_campaign_id"`                    // Campaign a code of is attached to each reminder; 0 attaches none
+}
+
+// SubscriptionConfig controls the job that places the recurring orders of
+// subscriptions and retries those that could not be charged. Zero values fall
+// back to the defaults in internal/service and internal/worker.
+type SubscriptionConfig struct {
+	RenewalSchedule  string          `mapstructure:"renewal_schedule"` // Cron spec or descriptor, e.g. "@every 10m"
+	RenewalBatchSize int             `mapstructure:"renewal_batch_size" validate:"min=0"`
+	RetryIntervals   []time.Duration `mapstructure:"retry_intervals" validate:"dive,gt=0"` // Wait before each retry of a failed renewal; cancelled once they run out
+}
+
+// DigitalConfig controls the job that delivers the digital items of paid
+// orders, and the download links it sends. Zero values fall back to the
+// defaults in internal/service and internal/worker.
+type DigitalConfig struct {
+	DeliverySchedule  string `mapstructure:"delivery_schedule"` // Cron spec or descriptor, e.g. "@every 1m"
+	DeliveryBatchSize int    `mapstructure:"delivery_batch_size" validate:"min=0"`
+	// DownloadBaseURL serves the files of download SKUs; their download path
+	// is appended to it. Empty leaves download SKUs undeliverable.
+	DownloadBaseURL string        `mapstructure:"download_base_url" validate:"omitempty,url"`
+	DownloadSecret  string        `mapstructure:"download_secret" validate:"required_with=DownloadBaseURL" redact:"true"` // Signs download links; the file server checks it
+	DownloadURLTTL  time.Duration `mapstructure:"download_url_ttl" validate:"min=0"`
+}
+
+// PopularityConfig controls the job that flushes product views to the
+// database and rescores product popularity. Zero values fall back to the
+// defaults in internal/service and internal/worker.
+type PopularityConfig struct {
+	Schedule string        `mapstructure:"schedule"`                   // Cron spec or descriptor, e.g. "@every 5m"
+	HalfLife time.Duration `mapstructure:"half_life" validate:"min=0"` // How long it takes a view to count half as much
+}
+
+// PricingConfig controls the job that applies scheduled price changes and
+// starts and ends sales. A zero value falls back to the default in
+// internal/worker.
+type PricingConfig struct {
+	Schedule string `mapstructure:"schedule"` // Cron spec or descriptor, e.g. "@every 1m"; sales start and end up to this late
+}
+
+// SuggestConfig controls search suggestions and the job that rebuilds their
+// index. Zero values fall back to the defaults in internal/service and
+// internal/worker.
+type SuggestConfig struct {
+	Schedule      string `mapstructure:"schedule"`                         // Cron spec or descriptor, e.g. "@every 10m"
+	TypoTolerance bool   `mapstructure:"typo_tolerance"`                   // Also suggest names one typo away from the query
+	MinTypoLength int    `mapstructure:"min_typo_length" validate:"min=0"` // Shortest query, in characters, typos are tolerated in
+}
+
+// ReviewsConfig rate limits the helpfulness votes and abuse reports each user
+// sends on reviews. Zero values fall back to the defaults in internal/service.
+type ReviewsConfig struct {
+	VoteLimit   int           `mapstructure:"vote_limit" validate:"min=0"`   // Votes per user per limit_window
+	ReportLimit int           `mapstructure:"report_limit" validate:"min=0"` // Reports per user per limit_window
+	LimitWindow time.Duration `mapstructure:"limit_window" validate:"min=0"`
+}
+
+// TaxConfig selects how tax is added to orders at checkout. The tax lines are
+// stored with each order. Zero values fall back to the defaults in
+// internal/service/tax.
+type TaxConfig struct {
+	Strategy string            `mapstructure:"strategy" validate:"omitempty,oneof=none flat region provider"` // Empty means none: orders carry no tax
+	Flat     FlatTaxConfig     `mapstructure:"flat"`
+	Regions  []string          `mapstructure:"regions" validate:"required_if=Strategy region,dive,tax_rule"` // REGION:NAME:RATE, e.g. "DE:VAT:0.19"; region * matches any other
+	Provider TaxProviderConfig `mapstructure:"provider"`
+}
+
+type FlatTaxConfig struct {
+	Name string  `mapstructure:"name"` // Label of the tax line; empty means VAT
+	Rate float64 `mapstructure:"rate" validate:"min=0,max=1"`
+}
+
+// TaxProviderConfig points at an external tax service; see tax.HTTPProvider
+// for the protocol.
+type TaxProviderConfig struct {
+	URL     string        `mapstructure:"url" validate:"omitempty,url"`
+	APIKey  string        `mapstructure:"api_key" redact:"true"` // Sent as a bearer token
+	Timeout time.Duration `mapstructure:"timeout" validate:"min=0"`
+}
+
+// AddressConfig controls how shipping addresses are validated; see
+// internal/service/address. Addresses are always checked against the format
+// of their country; with a geocoder, they are looked up too.
+type AddressConfig struct {
+	Geocoder AddressGeocoderConfig `mapstructure:"geocoder"`
+}
+
+// AddressGeocoderConfig points at an external address service; see
+// address.HTTPGeocoder for the protocol. Empty URL disables it.
+type AddressGeocoderConfig struct {
+	URL     string        `mapstructure:"url" validate:"omitempty,url"`
+	APIKey  string        `mapstructure:"api_key" redact:"true"` // Sent as a bearer token
+	Timeout time.Duration `mapstructure:"timeout" validate:"min=0"`
+}
+
+// PaymentConfig configures the providers orders are paid through; see
+// internal/service/payment. Each provider is enabled once its credentials are
+// set, and answers notifications at /webhooks/payments/{provider}.
+type PaymentConfig struct {
+	// Provider saves users' cards for one-click checkout; empty disables
+	// saved payment methods. It must be enabled too.
+	Provider string          `mapstructure:"provider" validate:"omitempty,oneof=stripe"`
+	Stripe   StripeConfig    `mapstructure:"stripe"`
+	Alipay   AlipayConfig    `mapstructure:"alipay"`
+	WeChat   WeChatPayConfig `mapstructure:"wechat"`
+	Disputes DisputeConfig   `mapstructure:"disputes"`
+}
+
+// DisputeConfig controls the job that reminds admins of payment disputes
+// whose evidence is due. Zero values fall back to the defaults in
+// internal/service and internal/worker.
+type DisputeConfig struct {
+	ReminderSchedule string        `mapstructure:"reminder_schedule"`                   // Cron spec or descriptor, e.g. "@every 1h"
+	ReminderLeadTime time.Duration `mapstructure:"reminder_lead_time" validate:"min=0"` // Start reminding this long before evidence is due
+	ReminderInterval time.Duration `mapstructure:"reminder_interval" validate:"min=0"`  // Remind again after this long until the evidence is submitted
+}
+
+type StripeConfig struct {
+	SecretKey     string        `mapstructure:"secret_key" redact:"true"`     // Empty disables Stripe
+	WebhookSecret string        `mapstructure:"webhook_secret" redact:"true"` // Verifies the Stripe-Signature of notifications
+	Timeout       time.Duration `mapstructure:"timeout" validate:"min=0"`
+}
+
+// AlipayConfig configures Alipay face-to-face payments, paid by scanning a
+// QR code. Keys are PEM or the bare base64 Alipay's key tool prints.
+type AlipayConfig struct {
+	AppID      string        `mapstructure:"app_id"`                              // Empty disables Alipay
+	PrivateKey string        `mapstructure:"private_key" redact:"true"`           // App private key; signs requests with RSA2
+	PublicKey  string        `mapstructure:"public_key"`                          // Alipay public key; verifies responses and notifications
+	NotifyURL  string        `mapstructure:"notify_url" validate:"omitempty,url"` // Public URL of /webhooks/payments/alipay
+	Sandbox    bool          `mapstructure:"sandbox"`                             // Use the sandbox gateway and its test accounts
+	Timeout    time.Duration `mapstructure:"timeout" validate:"min=0"`
+}
+
+// WeChatPayConfig configures WeChat Pay Native payments (API v3), paid by
+// scanning a QR code. Keys are PEM or bare base64.
+type WeChatPayConfig struct {
+	AppID             string        `mapstructure:"app_id"`
+	MchID             string        `mapstructure:"mch_id"`                                               // Merchant ID; empty disables WeChat Pay
+	SerialNo          string        `mapstructure:"serial_no"`                                            // Serial number of the merchant API certificate
+	PrivateKey        string        `mapstructure:"private_key" redact:"true"`                            // Merchant API private key; signs requests
+	PlatformPublicKey string        `mapstructure:"platform_public_key"`                                  // WeChat Pay public key or platform certificate; verifies responses and notifications
+	APIv3Key          string        `mapstructure:"api_v3_key" validate:"omitempty,len=32" redact:"true"` // Decrypts notifications
+	NotifyURL         string        `mapstructure:"notify_url" validate:"omitempty,url"`                  // Public URL of /webhooks/payments/wechat
+	BaseURL           string        `mapstructure:"base_url" validate:"omitempty,url"`                    // Empty means production; API v3 has no sandbox, so point this at a mock server to test
+	Timeout           time.Duration `mapstructure:"timeout" validate:"min=0"`
+}
+
+// I18nConfig lists the locales product content is served in. Clients pick one
+// with Accept-Language; products without a translation into it are served as
+// written.
+type I18nConfig struct {
+	DefaultLocale string   `mapstructure:"default_locale" validate:"omitempty,bcp47_language_tag"` // Locale the catalog is written in; empty means en
+	Locales       []string `mapstructure:"locales" validate:"dive,bcp47_language_tag"`             // Locales products may be translated into besides the default
 }
 
-func LoadConfig(path string) (*Config, error) {
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(path)
+// TenancyConfig turns on multi-store mode: each request is served for one
+// store, resolved by the header or the Host, and only sees that store's
+// users, products and orders. Stores are created with cmd/admin create-store,
+// where their domain, currency and locale are set.
+type TenancyConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	Header       string `mapstructure:"header"`        // Names the store by slug, overriding the Host; empty means X-Store
+	DefaultStore string `mapstructure:"default_store"` // Slug served when neither the header nor the Host names a store; empty rejects such requests
+}
+
+// NotificationConfig controls transactional email, SMS and push notifications,
+// which cmd/worker delivers. Zero values fall back to the defaults in
+// internal/service, internal/service/notification and internal/worker.
+type NotificationConfig struct {
+	Provider    string          `mapstructure:"provider" validate:"omitempty,oneof=log smtp ses"` // Email provider; empty means log: emails are logged, not sent
+	From        string          `mapstructure:"from"`                                             // RFC 5322 sender; its display name brands the templates
+	MaxAttempts int             `mapstructure:"max_attempts" validate:"min=0"`                    // Deliveries tried before one is parked
+	Channels    ChannelsConfig  `mapstructure:"channels"`
+	SMTP        SMTPConfig      `mapstructure:"smtp"`
+	SES         SESConfig       `mapstructure:"ses"`
+	SMS         SMSConfig       `mapstructure:"sms"`
+	Push        PushConfig      `mapstructure:"push"`
+	Broadcast   BroadcastConfig `mapstructure:"broadcast"`
+}
+
+// ChannelsConfig lists the channels each notification kind is sent over.
+// An empty list keeps the kind's default channels.
+type ChannelsConfig struct {
+	OrderConfirmation []string `mapstructure:"order_confirmation" validate:"dive,oneof=email sms push inbox"`
+	OrderFailed       []string `mapstructure:"order_failed" validate:"dive,oneof=email sms push inbox"`
+	Shipment          []string `mapstructure:"shipment" validate:"dive,oneof=email sms push inbox"`
+	PasswordReset     []string `mapstructure:"password_reset" validate:"dive,oneof=email sms push inbox"`
+	DigitalDelivery   []string `mapstructure:"digital_delivery" validate:"dive,oneof=email sms push inbox"`
+	DisputeReminder   []string `mapstructure:"dispute_reminder" validate:"dive,oneof=email sms push inbox"`
+	CartRecovery      []string `mapstructure:"cart_recovery" validate:"dive,oneof=email sms push inbox"`
+}
+
+// BroadcastConfig paces the fan-out of admin broadcasts, which cmd/worker
+// queues as notification deliveries.
+type BroadcastConfig struct {
+	DispatchSchedule string `mapstructure:"dispatch_schedule"`           // Cron spec or descriptor, e.g. "@every 1m"
+	Rate             int    `mapstructure:"rate" validate:"min=0"`       // Deliveries queued per second; keep it below the providers' rate limits
+	BatchSize        int    `mapstructure:"batch_size" validate:"min=0"` // Recipients loaded per query, and how often progress is saved
+}
+
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     string `mapstructure:"port" validate:"omitempty,tcp_port"` // 465 uses implicit TLS, anything else STARTTLS when offered
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password" redact:"true"`
+}
+
+type SESConfig struct {
+	Region           string `mapstructure:"region"`                      // Empty uses the AWS SDK default chain
+	ConfigurationSet string `mapstructure:"configuration_set"`           // Its SNS event destination should post bounces to /webhooks/ses
+	WebhookToken     string `mapstructure:"webhook_token" redact:"true"` // Required as ?token= on /webhooks/ses; empty disables the webhook
+}
+
+type SMSConfig struct {
+	Provider string       `mapstructure:"provider" validate:"omitempty,oneof=log twilio aliyun"` // Empty means log
+	Twilio   TwilioConfig `mapstructure:"twilio"`
+	Aliyun   AliyunConfig `mapstructure:"aliyun"`
+}
+
+type TwilioConfig struct {
+	AccountSID string `mapstructure:"account_sid"`
+	AuthToken  string `mapstructure:"auth_token" redact:"true"`
+	From       string `mapstructure:"from"` // Sender number, or a messaging service SID (MG...)
+}
+
+// AliyunConfig configures Alibaba Cloud SMS, which only sends pre-approved
+// templates: each kind sent over SMS needs a template code.
+type AliyunConfig struct {
+	AccessKeyID     string               `mapstructure:"access_key_id"`
+	AccessKeySecret string               `mapstructure:"access_key_secret" redact:"true"`
+	SignName        string               `mapstructure:"sign_name"`
+	Templates       AliyunTemplateConfig `mapstructure:"templates"`
+}
+
+// AliyunTemplateConfig holds the template code of each kind, e.g. "SMS_123456789".
+type AliyunTemplateConfig struct {
+	OrderConfirmation string `mapstructure:"order_confirmation"`
+	OrderFailed       string `mapstructure:"order_failed"`
+	Shipment          string `mapstructure:"shipment"`
+	PasswordReset     string `mapstructure:"password_reset"`
+	Broadcast         string `mapstructure:"broadcast"` // A marketing template with ${subject} and ${message}
+}
+
+// PushConfig configures the push services. Device tokens of an unconfigured
+// service are logged instead of pushed.
+type PushConfig struct {
+	FCM  FCMConfig  `mapstructure:"fcm"`
+	APNs APNsConfig `mapstructure:"apns"`
+}
+
+type FCMConfig struct {
+	CredentialsFile string `mapstructure:"credentials_file"` // Service account JSON key; empty disables FCM
+	ProjectID       string `mapstructure:"project_id"`       // Empty uses the project of the service account
+}
+
+type APNsConfig struct {
+	KeyFile string `mapstructure:"key_file"` // .p8 token signing key; empty disables APNs
+	KeyID   string `mapstructure:"key_id"`
+	TeamID  string `mapstructure:"team_id"`
+	Topic   string `mapstructure:"topic"`   // The app's bundle ID
+	Sandbox bool   `mapstructure:"sandbox"` // Use the development environment
+}
+
+type LogConfig struct {
+	Level  string `mapstructure:"level" validate:"omitempty,oneof=debug info warn error"`
+	Format string `mapstructure:"format" validate:"omitempty,oneof=json text"` // Empty means text
+}
+
+// SentryConfig controls error reporting of panics, 5xx responses and worker failures.
+type SentryConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	DSN         string  `mapstructure:"dsn" validate:"required_if=Enabled true,omitempty,url" redact:"true"`
+	Environment string  `mapstructure:"environment"`
+	Release     string  `mapstructure:"release"`                            // Empty falls back to SENTRY_RELEASE or the VCS revision
+	SampleRate  float64 `mapstructure:"sample_rate" validate:"min=0,max=1"` // Share of errors sent; 0 means all
+}
+
+// TracingConfig exports OpenTelemetry traces of requests, messages and jobs
+// over OTLP/HTTP, e.g. to Jaeger or Tempo. Trace context is propagated in
+// requests and messages even when export is disabled.
+type TracingConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	Endpoint string `mapstructure:"endpoint" validate:"required_if=Enabled true,omitempty,url"` // OTLP/HTTP base URL, e.g. http://localhost:4318
+	// SampleRate is the share of traces started here that are recorded, 0
+	// meaning all; traces continued from a caller follow its decision.
+	SampleRate float64 `mapstructure:"sample_rate" validate:"min=0,max=1"`
+}
+
+type SecurityConfig struct {
+	// TrustedProxies may set X-Forwarded-For; the client IP of anyone else is the TCP peer.
+	TrustedProxies []string            `mapstructure:"trusted_proxies" validate:"dive,cidr|ip"`
+	IPFilter       bool                `mapstructure:"ip_filter"`
+	BotProtection  BotProtectionConfig `mapstructure:"bot_protection"`
+	Captcha        CaptchaConfig       `mapstructure:"captcha"`
+	Diagnostics    DiagnosticsConfig   `mapstructure:"diagnostics"`
+	Sessions       SessionsConfig      `mapstructure:"sessions"`
+}
+
+// SessionsConfig caps the sessions a user may have at once; every login
+// opens one, which lasts as long as its refresh token.
+type SessionsConfig struct {
+	MaxPerUser int    `mapstructure:"max_per_user" validate:"min=0"`                           // Zero allows any number
+	OnLimit    string `mapstructure:"on_limit" validate:"omitempty,oneof=reject evict_oldest"` // Empty means reject
+}
 
-	// Environment variable overrides
-	viper.AutomaticEnv()
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+// DiagnosticsConfig rate limits the admin diagnostics endpoints, which scan
+// Redis and so must not be polled hard.
+type DiagnosticsConfig struct {
+	RateLimit int           `mapstructure:"rate_limit" validate:"min=0"` // Requests per admin per window
+	Window    time.Duration `mapstructure:"window" validate:"min=0"`
+}
+
+type BotProtectionConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	MaxFailures int           `mapstructure:"max_failures" validate:"min=0"`
+	Window      time.Duration `mapstructure:"window" validate:"min=0"`
+}
+
+// CaptchaConfig points at a siteverify-compatible provider (hCaptcha, reCAPTCHA, Turnstile).
+// An empty VerifyURL disables challenges; flagged clients are throttled instead.
+type CaptchaConfig struct {
+	VerifyURL string `mapstructure:"verify_url" validate:"omitempty,url"`
+	Secret    string `mapstructure:"secret" validate:"required_with=VerifyURL" redact:"true"`
+}
+
+// LoadOptions selects which files LoadConfig layers. Zero values are resolved from
+// the --config-dir/--env flags, then the MALL_CONFIG_DIR/MALL_ENV variables, then defaults.
+type LoadOptions struct {
+	Dir     string         // Directory holding config.yaml and config.{profile}.yaml; default ./configs
+	Profile string         // e.g. "production" loads config.production.yaml over config.yaml
+	Flags   *pflag.FlagSet // Parsed flag set from NewFlagSet; may be nil
+}
+
+// LoadConfig builds the configuration from these layers, highest precedence first:
+//
+//  1. flags (--server.port=9090)
+//  2. MALL_* environment variables (MALL_SERVER_PORT=9090)
+//  3. the profile file config.{profile}.yaml, deep-merged key by key
+//  4. the base file config.yaml
+//
+// Both files are optional, except that an explicitly selected profile must exist,
+// so the service can still be configured from the environment alone.
+func LoadConfig(opts LoadOptions) (*Config, error) {
+	config, err := load(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return config, nil
+}
+
+// load decodes the layered configuration without validating it.
+func load(opts LoadOptions) (*Config, error) {
+	opts = opts.resolve()
+	if !profilePattern.MatchString(opts.Profile) {
+		return nil, fmt.Errorf("invalid profile %q: use letters, digits, '-' or '_'", opts.Profile)
+	}
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+
+	// Environment variable overrides. Keys are bound explicitly so that settings absent
+	// from the files can still be provided through the environment.
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	for _, key := range Keys() {
+		if err := v.BindEnv(key); err != nil {
+			return nil, fmt.Errorf("failed to bind env for %s: %w", key, err)
+		}
+		if opts.Flags == nil {
+			continue
+		}
+		if flag := opts.Flags.Lookup(key); flag != nil {
+			if err := v.BindPFlag(key, flag); err != nil {
+				return nil, fmt.Errorf("failed to bind flag for %s: %w", key, err)
+			}
+		}
+	}
 
-	if err := viper.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("error reading config file: %w", err)
+	if err := mergeFile(v, filepath.Join(opts.Dir, baseFile), false); err != nil {
+		return nil, err
+	}
+	if opts.Profile != "" {
+		if err := mergeFile(v, filepath.Join(opts.Dir, profileFile(opts.Profile)), true); err != nil {
+			return nil, err
+		}
 	}
 
 	var config Config
-	if err := viper.Unmarshal(&config); err != nil {
+	if err := v.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("unable to decode into struct: %w", err)
 	}
-
 	return &config, nil
-}
\ No newline at end of file
+}
+
+func (o LoadOptions) resolve() LoadOptions {
+	if o.Dir == "" {
+		o.Dir = flagOrEnv(o.Flags, dirFlag, ConfigDirEnv, DefaultDir)
+	}
+	if o.Profile == "" {
+		o.Profile = flagOrEnv(o.Flags, profileFlag, ProfileEnv, "")
+	}
+	return o
+}
+
+func flagOrEnv(flags *pflag.FlagSet, flagName, envName, fallback string) string {
+	if flags != nil {
+		if flag := flags.Lookup(flagName); flag != nil && flag.Changed {
+			return flag.Value.String()
+		}
+	}
+	if val := os.Getenv(envName); val != "" {
+		return val
+	}
+	return fallback
+}
+
+func profileFile(profile string) string {
+	return "config." + profile + ".yaml"
+}
+
+// mergeFile deep-merges a YAML file into v: nested keys it sets replace the
+// existing values, everything else is kept.
+func mergeFile(v *viper.Viper, path string, required bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !required {
+			return nil
+		}
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+	defer f.Close()
+
+	if err := v.MergeConfig(f); err != nil {
+		return fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package configtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad_OverlayDeepMerges(t *testing.T) {
+	cfg := Load(t, `
+database:
+  dbname: "go_mall_test"
+security:
+  bot_protection:
+    max_failures: 2
+`)
+
+	// Overridden by the profile
+	assert.Equal(t, "go_mall_test", cfg.Database.DBName)
+	assert.Equal(t, 2, cfg.Security.BotProtection.MaxFailures)
+	// Siblings of overridden keys come from the base
+	assert.Equal(t, "localhost", cfg.Database.Host)
+	assert.Equal(t, "postgres", cfg.Database.User)
+	assert.Equal(t, "test", cfg.Server.Mode)
+}
+
+func TestLoad_EnvBeatsProfile(t *testing.T) {
+	t.Setenv("MALL_DATABASE_DBNAME", "from_env")
+
+	cfg := Load(t, `
+database:
+  dbname: "from_profile"
+`)
+
+	assert.Equal(t, "from_env", cfg.Database.DBName)
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Flags that choose which files to load rather than overriding a key.
+const (
+	dirFlag     = "config-dir"
+	profileFlag = "env"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Keys lists every leaf config key in dotted form, e.g. "redis.pool_size".
+func Keys() []string {
+	var keys []string
+	walkFields(reflect.TypeOf(Config{}), "", func(key string, _ reflect.StructField) {
+		keys = append(keys, key)
+	})
+	return keys
+}
+
+// EnvVar returns the environment variable that overrides key.
+func EnvVar(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// NewFlagSet returns a flag set with --config-dir, --env and one flag per config key
+// (e.g. --server.port). Unset flags do not override the files or environment.
+func NewFlagSet(name string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	flags.String(dirFlag, "", "directory holding config.yaml and profile files (env "+ConfigDirEnv+", default "+DefaultDir+")")
+	flags.String(profileFlag, "", "config profile layered over config.yaml, e.g. production (env "+ProfileEnv+")")
+	walkFields(reflect.TypeOf(Config{}), "", func(key string, field reflect.StructField) {
+		usage := "overrides " + key + " (env " + EnvVar(key) + ")"
+		switch {
+		case field.Type == durationType:
+			flags.Duration(key, 0, usage)
+		case field.Type.Kind() == reflect.Bool:
+			flags.Bool(key, false, usage)
+		case field.Type.Kind() == reflect.Int:
+			flags.Int(key, 0, usage)
+		case field.Type.Kind() == reflect.Float64:
+			flags.Float64(key, 0, usage)
+		case field.Type.Kind() == reflect.Slice:
+			flags.StringSlice(key, nil, usage)
+		default:
+			flags.String(key, "", usage)
+		}
+	})
+	return flags
+}
+
+// walkFields calls fn for every non-struct field, keyed by its mapstructure path.
+func walkFields(t reflect.Type, prefix string, fn func(key string, field reflect.StructField)) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("mapstructure")
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+			walkFields(field.Type, key, fn)
+			continue
+		}
+		fn(key, field)
+	}
+}
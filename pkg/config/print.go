@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const redactedValue = "******"
+
+// Print writes the effective configuration as YAML. With redact set, fields tagged
+// redact:"true" are masked (when non-empty) so the output is safe to share.
+func (c *Config) Print(w io.Writer, redact bool) error {
+	out, err := yaml.Marshal(toMap(reflect.ValueOf(*c), redact))
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+func toMap(v reflect.Value, redact bool) map[string]interface{} {
+	t := v.Type()
+	m := make(map[string]interface{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field, val := t.Field(i), v.Field(i)
+		key := field.Tag.Get("mapstructure")
+		switch {
+		case redact && field.Tag.Get("redact") == "true" && !val.IsZero():
+			m[key] = redactedValue
+		case field.Type == durationType:
+			m[key] = time.Duration(val.Int()).String()
+		case field.Type.Kind() == reflect.Struct:
+			m[key] = toMap(val, redact)
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+			items := make([]interface{}, val.Len())
+			for j := range items {
+				items[j] = toMap(val.Index(j), redact)
+			}
+			m[key] = items
+		default:
+			m[key] = val.Interface()
+		}
+	}
+	return m
+}
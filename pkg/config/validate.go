@@ -0,0 +1,127 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	validateOnce sync.Once
+	validate     *validator.Validate
+)
+
+// configValidator reports fields by their config keys (e.g. "security.bot_protection.window").
+func configValidator() *validator.Validate {
+	validateOnce.Do(func() {
+		validate = validator.New(validator.WithRequiredStructEnabled())
+		validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+			return field.Tag.Get("mapstructure")
+		})
+		// The built-in "port" rule only handles integer fields; ports are strings here.
+		_ = validate.RegisterValidation("tcp_port", func(fl validator.FieldLevel) bool {
+			port, err := strconv.Atoi(fl.Field().String())
+			return err == nil && port >= 1 && port <= 65535
+		})
+		// Tax rules are "REGION:NAME:RATE"; internal/service/tax parses them.
+		_ = validate.RegisterValidation("tax_rule", func(fl validator.FieldLevel) bool {
+			parts := strings.Split(fl.Field().String(), ":")
+			if len(parts) != 3 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+				return false
+			}
+			rate, err := strconv.ParseFloat(strings.TrimSpace(parts[2]), 64)
+			return err == nil && rate >= 0 && rate <= 1
+		})
+	})
+	return validate
+}
+
+// Validate checks every field against its validate tag and reports all failures
+// at once, one line per key, so a bad deploy or reload can be fixed in one pass.
+func (c *Config) Validate() error {
+	err := configValidator().Struct(c)
+	if err == nil {
+		return nil
+	}
+
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+	errs := make([]error, 0, len(verrs))
+	for _, fe := range verrs {
+		errs = append(errs, fmt.Errorf("%s: %s", configKey(fe), describe(fe)))
+	}
+	return errors.Join(errs...)
+}
+
+// configKey strips the root struct name: "Config.server.port" -> "server.port".
+func configKey(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if idx := strings.Index(ns, "."); idx >= 0 {
+		return ns[idx+1:]
+	}
+	return ns
+}
+
+func describe(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_with":
+		return fmt.Sprintf("is required when %s is set", strings.ToLower(fe.Param()))
+	case "required_without":
+		return fmt.Sprintf("is required when %s is not set", snakeCase(fe.Param()))
+	case "required_if":
+		field, value, _ := strings.Cut(fe.Param(), " ")
+		return fmt.Sprintf("is required when %s is %s", snakeCase(field), value)
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "len":
+		return fmt.Sprintf("must be exactly %s characters", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s], got %q", fe.Param(), fe.Value())
+	case "tcp_port":
+		return fmt.Sprintf("must be a port number between 1 and 65535, got %q", fe.Value())
+	case "hostname_port":
+		return fmt.Sprintf("must be host:port, got %q", fe.Value())
+	case "url":
+		return "must be a valid URL"
+	case "tax_rule":
+		return fmt.Sprintf("must be REGION:NAME:RATE with a rate between 0 and 1, got %q", fe.Value())
+	case "bcp47_language_tag":
+		return fmt.Sprintf("must be a BCP 47 language tag such as \"zh\" or \"pt-BR\", got %q", fe.Value())
+	case "unique":
+		return fmt.Sprintf("must not repeat %s", snakeCase(fe.Param()))
+	case "gtefield":
+		return fmt.Sprintf("must be at least %s", snakeCase(fe.Param()))
+	case "cidr|ip":
+		return fmt.Sprintf("%q is not an IP address or CIDR", fe.Value())
+	default:
+		return fmt.Sprintf("failed %q validation", fe.Tag())
+	}
+}
+
+// snakeCase turns the Go name of a field into its config key, e.g.
+// "RefreshTokenTTL" -> "refresh_token_ttl", for rules naming another field.
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) && unicode.IsLower(rune(name[i-1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,244 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Section names a top-level config block, matching its YAML key.
+type Section string
+
+const (
+	SectionServer       Section = "server"
+	SectionDatabase     Section = "database"
+	SectionRedis        Section = "redis"
+	SectionCache        Section = "cache"
+	SectionRabbitMQ     Section = "rabbitmq"
+	SectionWorker       Section = "worker"
+	SectionJWT          Section = "jwt"
+	SectionToken        Section = "token"
+	SectionSecurity     Section = "security"
+	SectionOrder        Section = "order"
+	SectionInventory    Section = "inventory"
+	SectionNotification Section = "notification"
+	SectionWebhook      Section = "webhook"
+	SectionCurrency     Section = "currency"
+	SectionTax          Section = "tax"
+	SectionPayment      Section = "payment"
+	SectionPopularity   Section = "popularity"
+	SectionSuggest      Section = "suggest"
+	SectionReviews      Section = "reviews"
+	SectionI18n         Section = "i18n"
+	SectionTenancy      Section = "tenancy"
+	SectionLog          Section = "log"
+	SectionSentry       Section = "sentry"
+	SectionTracing      Section = "tracing"
+)
+
+// restartOnly sections are read once while wiring connections; edits are accepted
+// but only take effect after a restart. Of jwt, only key rotations apply at
+// once; see rotatesJWTKeys.
+var restartOnly = map[Section]bool{
+	SectionDatabase:     true,
+	SectionRedis:        true,
+	SectionCache:        true,
+	SectionRabbitMQ:     true,
+	SectionWorker:       true,
+	SectionJWT:          true,
+	SectionToken:        true,
+	SectionSentry:       true,
+	SectionTracing:      true,
+	SectionOrder:        true,
+	SectionInventory:    true,
+	SectionNotification: true,
+	SectionWebhook:      true,
+	SectionCurrency:     true,
+	SectionTax:          true,
+	SectionPayment:      true,
+	SectionPopularity:   true,
+	SectionSuggest:      true,
+	SectionReviews:      true,
+	SectionI18n:         true,
+	SectionTenancy:      true,
+}
+
+// ChangeEvent describes an accepted configuration reload.
+type ChangeEvent struct {
+	Old     *Config
+	New     *Config
+	Changed []Section
+}
+
+// Has reports whether the given section changed in this reload.
+func (e ChangeEvent) Has(section Section) bool {
+	for _, s := range e.Changed {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+// Watcher keeps the live configuration and notifies subscribers when the base or
+// profile config file changes. Reloads that fail to decode or validate are rejected
+// and the previous configuration stays in effect.
+type Watcher struct {
+	opts        LoadOptions
+	mu          sync.RWMutex
+	current     *Config
+	subscribers []func(ChangeEvent)
+	fsWatcher   *fsnotify.Watcher
+}
+
+// NewWatcher creates a Watcher seeded with the configuration returned by LoadConfig;
+// opts must be the options that produced it so reloads layer the same files.
+func NewWatcher(initial *Config, opts LoadOptions) *Watcher {
+	return &Watcher{current: initial, opts: opts.resolve()}
+}
+
+// Current returns the latest accepted configuration. Callers must not modify it.
+func (w *Watcher) Current() *Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Subscribe registers fn to be called after each accepted reload.
+// Callbacks run sequentially on the watcher goroutine and should return quickly.
+func (w *Watcher) Subscribe(fn func(ChangeEvent)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Start begins watching the config directory. Editors often replace files rather than
+// write them in place, so the directory is watched instead of the individual files.
+func (w *Watcher) Start() error {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create config watcher: %w", err)
+	}
+	if err := fsWatcher.Add(w.opts.Dir); err != nil {
+		fsWatcher.Close()
+		return fmt.Errorf("failed to watch config dir %s: %w", w.opts.Dir, err)
+	}
+	w.fsWatcher = fsWatcher
+
+	watched := map[string]bool{baseFile: true}
+	if w.opts.Profile != "" {
+		watched[profileFile(w.opts.Profile)] = true
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-fsWatcher.Events:
+				if !ok {
+					return
+				}
+				if !watched[filepath.Base(event.Name)] || event.Op == fsnotify.Chmod {
+					continue
+				}
+				w.reload(event.Name)
+			case err, ok := <-fsWatcher.Errors:
+				if !ok {
+					return
+				}
+				slog.Error("Config watcher error", "error", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// Close stops watching for changes.
+func (w *Watcher) Close() error {
+	if w.fsWatcher == nil {
+		return nil
+	}
+	return w.fsWatcher.Close()
+}
+
+// Reload reads the config files again, as when they change, e.g. on SIGHUP.
+// It returns the latest accepted configuration, the previous one if the
+// files are rejected.
+func (w *Watcher) Reload(trigger string) *Config {
+	w.reload(trigger)
+	return w.Current()
+}
+
+func (w *Watcher) reload(trigger string) {
+	next, err := load(w.opts)
+	if err != nil {
+		slog.Error("Config reload rejected", "file", trigger, "error", err)
+		return
+	}
+	if _, err := w.apply(next); err != nil {
+		slog.Error("Config reload rejected", "file", trigger, "error", err)
+	}
+}
+
+// apply validates next, swaps it in and notifies subscribers.
+// It returns the published event, or nil when nothing changed.
+func (w *Watcher) apply(next *Config) (*ChangeEvent, error) {
+	if err := next.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	w.mu.Lock()
+	prev := w.current
+	changed := diffSections(prev, next)
+	if len(changed) == 0 {
+		w.mu.Unlock()
+		return nil, nil
+	}
+	w.current = next
+	subscribers := append([]func(ChangeEvent){}, w.subscribers...)
+	w.mu.Unlock()
+
+	for _, section := range changed {
+		if restartOnly[section] && !(section == SectionJWT && rotatesJWTKeys(prev, next)) {
+			slog.Warn("Config section changed; restart required for it to take effect", "section", section)
+		}
+	}
+	slog.Info("Config reloaded", "changed_sections", changed)
+
+	event := ChangeEvent{Old: prev, New: next, Changed: changed}
+	for _, fn := range subscribers {
+		fn(event)
+	}
+	return &event, nil
+}
+
+// rotatesJWTKeys reports whether the jwt changes from prev to next are a key
+// rotation, which the keyring of the token maker applies while it runs: JWTs
+// signed under a key_id before and after, and the same token lifetimes, which
+// are read once. The keyring exists only if key_id was set at startup, so
+// going from no key_id to one needs a restart too.
+func rotatesJWTKeys(prev, next *Config) bool {
+	if prev.Token.Type == "paseto" || prev.JWT.KeyID == "" || next.JWT.KeyID == "" {
+		return false
+	}
+	return prev.JWT.AccessTokenTTL == next.JWT.AccessTokenTTL &&
+		prev.JWT.RefreshTokenTTL == next.JWT.RefreshTokenTTL &&
+		prev.JWT.RememberMeTTL == next.JWT.RememberMeTTL
+}
+
+// diffSections compares the top-level blocks of two configs.
+func diffSections(a, b *Config) []Section {
+	va, vb := reflect.ValueOf(*a), reflect.ValueOf(*b)
+	t := va.Type()
+
+	var changed []Section
+	for i := 0; i < t.NumField(); i++ {
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			changed = append(changed, Section(t.Field(i).Tag.Get("mapstructure")))
+		}
+	}
+	return changed
+}
@@ -2,11 +2,12 @@ package database
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/proyuen/go-mall/internal/model"
 	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/tenant"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -30,7 +31,12 @@ func NewPostgresDB(cfg *config.DatabaseConfig) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	log.Println("Database connection established")
+	slog.Info("Database connection established")
+
+	// Scope statements to the store of their context, in multi-store mode
+	if err := db.Use(tenant.Plugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register tenant plugin: %w", err)
+	}
 
 	// Get the underlying sql.DB to configure connection pooling
 	sqlDB, err := db.DB()
@@ -48,17 +54,111 @@ func NewPostgresDB(cfg *config.DatabaseConfig) (*gorm.DB, error) {
 	// separately (e.g., using Goose, Flyway, or a dedicated migration tool)
 	// and executed before application startup. Running AutoMigrate directly
 	// in the application can lead to unexpected behavior or downtime during upgrades.
+	countRatings := !db.Migrator().HasTable(&model.SPURating{})
 	err = db.AutoMigrate(
 		&model.User{},
+		&model.Category{},
 		&model.SPU{},
 		&model.SKU{},
+		&model.SKUComponent{},
+		&model.ProductOption{},
+		&model.SKUPriceTier{},
+		&model.PriceSchedule{},
+		&model.SPUTranslation{},
+		&model.SPUStats{},
+		&model.SearchSynonym{}, &model.SearchRule{}, &model.SearchZeroResult{},
 		&model.Order{},
 		&model.OrderItem{},
+		&model.OrderItemOption{},
+		&model.OrderTaxLine{},
+		&model.AuditLog{},
+		&model.NotificationPreference{},
+		&model.EmailSuppression{},
+		&model.PushDevice{},
+		&model.NotificationDelivery{},
+		&model.NotificationTemplate{},
+		&model.Notification{},
+		&model.Broadcast{},
+		&model.OutboxEvent{},
+		&model.FailedMessage{},
+		&model.OrderSummary{},
+		&model.SagaState{},
+		&model.WebhookSubscription{},
+		&model.WebhookDelivery{},
+		&model.WebhookAttempt{},
+		&model.Review{}, &model.ReviewVote{}, &model.ReviewReport{}, &model.SPURating{},
+		&model.ExchangeRate{},
+		&model.Store{},
+		&model.PaymentMethod{},
+		&model.Shipment{},
+		&model.ShipmentItem{},
+		&model.PaymentCapture{},
+		&model.Promotion{},
+		&model.PromotionTier{},
+		&model.OrderPromotion{},
+		&model.CouponCampaign{},
+		&model.Coupon{},
+		&model.CouponCampaignStats{},
+		&model.Subscription{},
+		&model.LicenseKey{},
+		&model.Quote{},
+		&model.QuoteItem{},
+		&model.Supplier{},
+		&model.PurchaseOrder{},
+		&model.PurchaseOrderItem{},
+		&model.StockMovement{},
+		&model.StockHold{},
+		&model.PickTask{},
+		&model.PickTaskItem{},
+		&model.StoreCredit{},
+		&model.Dispute{},
+		&model.Cart{},
+		&model.CartItem{},
+		&model.OrderModification{},
+		&model.ShippingRestriction{},
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to auto migrate database: %w", err)
 	}
-	log.Println("Database migration completed")
+	// Usernames and emails became unique per store; AutoMigrate only adds the
+	// new indexes, so the global ones are dropped here
+	for _, index := range []string{"idx_users_username", "idx_users_email"} {
+		if db.Migrator().HasIndex(&model.User{}, index) {
+			if err := db.Migrator().DropIndex(&model.User{}, index); err != nil {
+				return nil, fmt.Errorf("failed to drop index %s: %w", index, err)
+			}
+		}
+	}
+	// Notification preferences moved from one toggle per setting to the muted
+	// channels of each category; promotions, which are new, start muted
+	if db.Migrator().HasColumn(&model.NotificationPreference{}, "order_emails") {
+		err := db.Exec(`UPDATE notification_preferences SET muted = jsonb_build_object(
+				'order', CASE WHEN NOT order_emails AND push_disabled THEN '["email","push"]'::jsonb WHEN NOT order_emails THEN '["email"]' WHEN push_disabled THEN '["push"]' ELSE '[]' END,
+				'shipment', CASE WHEN NOT shipment_emails AND push_disabled THEN '["email","push"]'::jsonb WHEN NOT shipment_emails THEN '["email"]' WHEN push_disabled THEN '["push"]' ELSE '[]' END,
+				'promotion', '["email","sms","push","inbox"]'::jsonb,
+				'price_alert', CASE WHEN push_disabled THEN '["push"]'::jsonb ELSE '[]' END)`).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate notification preferences: %w", err)
+		}
+		for _, column := range []string{"order_emails", "shipment_emails", "push_disabled"} {
+			if err := db.Migrator().DropColumn(&model.NotificationPreference{}, column); err != nil {
+				return nil, fmt.Errorf("failed to drop column %s: %w", column, err)
+			}
+		}
+	}
+	// Rating counts are kept up as reviews change; the reviews written before
+	// they were are counted once, when their table is created
+	if countRatings {
+		err := db.Exec(`INSERT INTO spu_ratings (spu_id, store_id, stars1, stars2, stars3, stars4, stars5)
+			SELECT spu_id, MAX(store_id),
+				COUNT(*) FILTER (WHERE rating = 1), COUNT(*) FILTER (WHERE rating = 2), COUNT(*) FILTER (WHERE rating = 3),
+				COUNT(*) FILTER (WHERE rating = 4), COUNT(*) FILTER (WHERE rating = 5)
+			FROM reviews WHERE deleted_at IS NULL GROUP BY spu_id`).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to count ratings: %w", err)
+		}
+	}
+	slog.Info("Database migration completed")
 
 	return db, nil
 }
@@ -0,0 +1,105 @@
+package errreport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/logger"
+)
+
+// Reporter sends unexpected failures to an error tracking service. Request ID,
+// user ID, trace ID and the HTTP request (see WithRequest) are taken from ctx.
+//
+//go:generate mockgen -source=$GOFILE -destination=../../internal/mocks/error_reporter_mock.go -package=mocks
+type Reporter interface {
+	CaptureError(ctx context.Context, err error, tags map[string]string)
+	// CapturePanic reports a value recovered from a panic, with the current stack.
+	CapturePanic(ctx context.Context, recovered any, tags map[string]string)
+	// Flush waits up to timeout for queued events to be delivered.
+	Flush(timeout time.Duration) bool
+}
+
+type ctxKey struct{}
+
+// WithRequest attaches the incoming HTTP request so reports include its method,
+// URL and non-sensitive headers.
+func WithRequest(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, ctxKey{}, r)
+}
+
+// New returns a Sentry-backed Reporter, or a no-op one when reporting is disabled.
+func New(cfg config.SentryConfig) (Reporter, error) {
+	if !cfg.Enabled {
+		return Nop(), nil
+	}
+	return newSentryReporter(sentry.ClientOptions{
+		Dsn:              cfg.DSN,
+		Environment:      cfg.Environment,
+		Release:          cfg.Release,
+		SampleRate:       cfg.SampleRate,
+		AttachStacktrace: true,
+	})
+}
+
+// Nop returns a Reporter that discards everything.
+func Nop() Reporter {
+	return nopReporter{}
+}
+
+type nopReporter struct{}
+
+func (nopReporter) CaptureError(context.Context, error, map[string]string) {}
+func (nopReporter) CapturePanic(context.Context, any, map[string]string)   {}
+func (nopReporter) Flush(time.Duration) bool                               { return true }
+
+type sentryReporter struct {
+	hub *sentry.Hub
+}
+
+func newSentryReporter(opts sentry.ClientOptions) (*sentryReporter, error) {
+	client, err := sentry.NewClient(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sentry client: %w", err)
+	}
+	// A dedicated hub keeps reports independent of the global sentry state.
+	return &sentryReporter{hub: sentry.NewHub(client, sentry.NewScope())}, nil
+}
+
+func (r *sentryReporter) CaptureError(ctx context.Context, err error, tags map[string]string) {
+	r.scoped(ctx, tags).CaptureException(err)
+}
+
+func (r *sentryReporter) CapturePanic(ctx context.Context, recovered any, tags map[string]string) {
+	r.scoped(ctx, tags).RecoverWithContext(ctx, recovered)
+}
+
+func (r *sentryReporter) Flush(timeout time.Duration) bool {
+	return r.hub.Flush(timeout)
+}
+
+// scoped returns a hub whose scope carries the request context, so concurrent
+// reports never share tags.
+func (r *sentryReporter) scoped(ctx context.Context, tags map[string]string) *sentry.Hub {
+	hub := r.hub.Clone()
+	hub.ConfigureScope(func(scope *sentry.Scope) {
+		if req, ok := ctx.Value(ctxKey{}).(*http.Request); ok {
+			scope.SetRequest(req)
+		}
+		if id := logger.RequestIDFromContext(ctx); id != "" {
+			scope.SetTag("request_id", id)
+		}
+		if id := logger.TraceIDFromContext(ctx); id != "" {
+			scope.SetTag("trace_id", id)
+		}
+		if id, ok := logger.UserIDFromContext(ctx); ok {
+			scope.SetUser(sentry.User{ID: strconv.FormatUint(id, 10)})
+		}
+		scope.SetTags(tags)
+	})
+	return hub
+}
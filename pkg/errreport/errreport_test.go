@@ -0,0 +1,92 @@
+package errreport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/proyuen/go-mall/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingTransport keeps events in memory instead of sending them.
+type recordingTransport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *recordingTransport) Configure(sentry.ClientOptions)        {}
+func (t *recordingTransport) Flush(time.Duration) bool              { return true }
+func (t *recordingTransport) FlushWithContext(context.Context) bool { return true }
+func (t *recordingTransport) Close()                                {}
+func (t *recordingTransport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func newTestReporter(t *testing.T) (*sentryReporter, *recordingTransport) {
+	transport := &recordingTransport{}
+	r, err := newSentryReporter(sentry.ClientOptions{
+		Dsn:       "https://public@example.com/1",
+		Release:   "go-mall@1.2.3",
+		Transport: transport,
+	})
+	require.NoError(t, err)
+	return r, transport
+}
+
+func TestSentryReporter_CaptureError(t *testing.T) {
+	r, transport := newTestReporter(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/orders?debug=1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	ctx := WithRequest(context.Background(), req)
+	ctx = logger.WithRequestID(ctx, "req-1")
+	ctx = logger.WithUserID(ctx, 42)
+
+	r.CaptureError(ctx, errors.New("boom"), map[string]string{"http.route": "/api/v1/orders"})
+	r.CaptureError(context.Background(), errors.New("bare"), nil)
+
+	require.Len(t, transport.events, 2)
+	event := transport.events[0]
+	assert.Equal(t, "go-mall@1.2.3", event.Release)
+	require.NotEmpty(t, event.Exception)
+	assert.Equal(t, "boom", event.Exception[len(event.Exception)-1].Value)
+	assert.Equal(t, "req-1", event.Tags["request_id"])
+	assert.Equal(t, "/api/v1/orders", event.Tags["http.route"])
+	assert.Equal(t, "42", event.User.ID)
+	require.NotNil(t, event.Request)
+	assert.Equal(t, http.MethodPost, event.Request.Method)
+	assert.NotContains(t, event.Request.Headers, "Authorization", "credentials must not be reported")
+
+	// Scopes are per report: nothing leaks into the next event.
+	assert.NotContains(t, transport.events[1].Tags, "request_id")
+	assert.Empty(t, transport.events[1].User.ID)
+}
+
+func TestSentryReporter_CapturePanic(t *testing.T) {
+	r, transport := newTestReporter(t)
+
+	r.CapturePanic(context.Background(), "nil map write", map[string]string{"queue": "orders.created"})
+
+	require.Len(t, transport.events, 1)
+	assert.Equal(t, sentry.LevelFatal, transport.events[0].Level)
+	assert.Equal(t, "orders.created", transport.events[0].Tags["queue"])
+}
+
+func TestNew_Disabled(t *testing.T) {
+	r, err := New(config.SentryConfig{Enabled: false, DSN: "https://public@example.com/1"})
+	require.NoError(t, err)
+	assert.Equal(t, Nop(), r)
+
+	_, err = New(config.SentryConfig{Enabled: true, DSN: "not-a-dsn"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,80 @@
+// Package exchangerate fetches currency exchange rates from an HTTP rates API.
+package exchangerate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// BasePlaceholder in a provider URL is replaced with the base currency code.
+const BasePlaceholder = "{base}"
+
+//go:generate mockgen -source=$GOFILE -destination=../../internal/mocks/rate_provider_mock.go -package=mocks
+// Provider fetches the latest exchange rates.
+type Provider interface {
+	// Fetch returns how many units of each currency one unit of base buys,
+	// keyed by ISO 4217 code.
+	Fetch(ctx context.Context, base string) (map[string]decimal.Decimal, error)
+}
+
+// HTTPProvider implements Provider against APIs answering with a JSON object
+// whose "rates" member maps currency codes to rates, such as Frankfurter or
+// exchangerate.host.
+type HTTPProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPProvider creates an HTTPProvider for url, in which BasePlaceholder
+// stands for the base currency, e.g. https://api.frankfurter.app/latest?from={base}.
+func NewHTTPProvider(url string) *HTTPProvider {
+	return &HTTPProvider{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type ratesResponse struct {
+	Rates map[string]decimal.Decimal `json:"rates"`
+}
+
+// Fetch calls the rates API and returns its rates. Non-positive rates are
+// rejected, since converting with them would zero or negate prices.
+func (p *HTTPProvider) Fetch(ctx context.Context, base string) (map[string]decimal.Decimal, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.ReplaceAll(p.url, BasePlaceholder, base), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build exchange rate request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call exchange rate provider: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("exchange rate provider returned status %d", resp.StatusCode)
+	}
+
+	var result ratesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode exchange rate provider response: %w", err)
+	}
+	if len(result.Rates) == 0 {
+		return nil, errors.New("exchange rate provider returned no rates")
+	}
+	for code, rate := range result.Rates {
+		if !rate.IsPositive() {
+			return nil, fmt.Errorf("exchange rate provider returned rate %s for %s", rate, code)
+		}
+	}
+	return result.Rates, nil
+}
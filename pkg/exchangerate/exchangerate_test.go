@@ -0,0 +1,50 @@
+package exchangerate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPProvider_Fetch(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantRates map[string]string
+		wantErr   bool
+	}{
+		{name: "Success", status: http.StatusOK, body: `{"amount":1.0,"base":"USD","date":"2026-10-16","rates":{"EUR":0.9215,"JPY":149.87}}`, wantRates: map[string]string{"EUR": "0.9215", "JPY": "149.87"}},
+		{name: "ProviderError", status: http.StatusServiceUnavailable, wantErr: true},
+		{name: "MalformedResponse", status: http.StatusOK, body: `not json`, wantErr: true},
+		{name: "NoRates", status: http.StatusOK, body: `{"rates":{}}`, wantErr: true},
+		{name: "ZeroRate", status: http.StatusOK, body: `{"rates":{"EUR":0}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "USD", r.URL.Query().Get("from"))
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			rates, err := NewHTTPProvider(server.URL+"/latest?from="+BasePlaceholder).Fetch(context.Background(), "USD")
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			got := make(map[string]string, len(rates))
+			for code, rate := range rates {
+				got[code] = rate.String()
+			}
+			assert.Equal(t, tt.wantRates, got)
+		})
+	}
+}
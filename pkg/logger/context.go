@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	userIDKey
+	traceIDKey
+	correlationIDKey
+	attrsKey
+)
+
+// WithRequestID attaches the request ID to ctx for all subsequent log records.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID attached by WithRequestID, if any.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithUserID attaches the authenticated user's ID to ctx.
+func WithUserID(ctx context.Context, userID uint64) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFromContext returns the user ID attached by WithUserID, if any.
+func UserIDFromContext(ctx context.Context) (uint64, bool) {
+	id, ok := ctx.Value(userIDKey).(uint64)
+	return id, ok
+}
+
+// WithTraceID attaches a trace ID received out of band (e.g. from a message header).
+// Contexts carrying an OpenTelemetry span use the span's trace ID instead.
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey, traceID)
+}
+
+// TraceIDFromContext returns the trace ID of the active span, falling back to
+// one attached by WithTraceID.
+func TraceIDFromContext(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	id, _ := ctx.Value(traceIDKey).(string)
+	return id
+}
+
+// WithCorrelationID attaches the ID tying ctx to the request that caused it,
+// e.g. the correlation ID of a message a worker is processing.
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey, correlationID)
+}
+
+// CorrelationIDFromContext returns the correlation ID attached by
+// WithCorrelationID, falling back to the request ID: a request is the origin
+// of whatever it causes.
+func CorrelationIDFromContext(ctx context.Context) string {
+	if id, _ := ctx.Value(correlationIDKey).(string); id != "" {
+		return id
+	}
+	return RequestIDFromContext(ctx)
+}
+
+// WithAttrs returns a copy of ctx whose log records also carry attrs, after
+// those already attached, e.g. the ID of the order a message is about.
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	prev, _ := ctx.Value(attrsKey).([]slog.Attr)
+	return context.WithValue(ctx, attrsKey, append(slices.Clip(prev), attrs...))
+}
+
+// contextHandler adds request-scoped attributes from the context to every record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if id, ok := UserIDFromContext(ctx); ok {
+		r.AddAttrs(slog.Uint64("user_id", id))
+	}
+	if id := TraceIDFromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("trace_id", id))
+	}
+	if id, _ := ctx.Value(correlationIDKey).(string); id != "" {
+		r.AddAttrs(slog.String("correlation_id", id))
+	}
+	if attrs, _ := ctx.Value(attrsKey).([]slog.Attr); len(attrs) > 0 {
+		r.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,79 @@
+// Package logger standardizes slog setup: JSON or text output, a runtime-adjustable
+// level, and request-scoped attributes (request_id, user_id, trace_id, correlation_id
+// and any added with WithAttrs) taken from the context of every *Context logging call.
+package logger
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/proyuen/go-mall/pkg/config"
+)
+
+// Output formats.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// level is shared by every logger built by this package so SetLevel takes effect everywhere.
+var level = new(slog.LevelVar)
+
+// Init builds the process-wide logger from cfg and installs it as slog's default,
+// which also routes the standard library "log" package through it.
+func Init(cfg config.LogConfig) (*slog.Logger, error) {
+	if err := SetLevel(cfg.Level); err != nil {
+		return nil, err
+	}
+	l := New(os.Stdout, cfg.Format, level)
+	slog.SetDefault(l)
+	return l, nil
+}
+
+// New creates a context-enriching logger writing to w. Unknown formats fall back to text.
+func New(w io.Writer, format string, lvl slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: lvl}
+	var h slog.Handler
+	if format == FormatJSON {
+		h = slog.NewJSONHandler(w, opts)
+	} else {
+		h = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(&contextHandler{Handler: h})
+}
+
+// SetLevel changes the level of loggers created by Init, e.g. on config reload.
+// An empty string means info.
+func SetLevel(s string) error {
+	lvl, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	level.Set(lvl)
+	return nil
+}
+
+// Level returns the current level of loggers created by Init.
+func Level() slog.Level {
+	return level.Level()
+}
+
+// ParseLevel accepts debug, info, warn or error (case-insensitive); empty means info.
+func ParseLevel(s string) (slog.Level, error) {
+	if strings.TrimSpace(s) == "" {
+		return slog.LevelInfo, nil
+	}
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q: %w", s, err)
+	}
+	return lvl, nil
+}
+
+// Err is shorthand for the conventional "error" attribute.
+func Err(err error) slog.Attr {
+	return slog.Any("error", err)
+}
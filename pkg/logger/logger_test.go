@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestContextEnrichment(t *testing.T) {
+	tests := []struct {
+		name      string
+		ctx       func() context.Context
+		wantAttrs map[string]interface{}
+		absent    []string
+	}{
+		{
+			name:   "EmptyContext",
+			ctx:    context.Background,
+			absent: []string{"request_id", "user_id", "trace_id"},
+		},
+		{
+			name: "RequestAndUser",
+			ctx: func() context.Context {
+				return WithUserID(WithRequestID(context.Background(), "req-1"), 42)
+			},
+			wantAttrs: map[string]interface{}{"request_id": "req-1", "user_id": float64(42)},
+			absent:    []string{"trace_id"},
+		},
+		{
+			name: "ExplicitTraceID",
+			ctx: func() context.Context {
+				return WithTraceID(context.Background(), "abc123")
+			},
+			wantAttrs: map[string]interface{}{"trace_id": "abc123"},
+		},
+		{
+			name: "CorrelationAndAttrs",
+			ctx: func() context.Context {
+				ctx := WithAttrs(WithCorrelationID(context.Background(), "req-1"), slog.String("queue", "orders.created"))
+				return WithAttrs(ctx, slog.Uint64("order_id", 7))
+			},
+			wantAttrs: map[string]interface{}{"correlation_id": "req-1", "queue": "orders.created", "order_id": float64(7)},
+			absent:    []string{"request_id"},
+		},
+		{
+			name: "SpanTraceIDWins",
+			ctx: func() context.Context {
+				sc := trace.NewSpanContext(trace.SpanContextConfig{
+					TraceID: trace.TraceID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10},
+					SpanID:  trace.SpanID{0x01},
+				})
+				return trace.ContextWithSpanContext(WithTraceID(context.Background(), "ignored"), sc)
+			},
+			wantAttrs: map[string]interface{}{"trace_id": "0102030405060708090a0b0c0d0e0f10"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&buf, FormatJSON, slog.LevelInfo).With("component", "test")
+
+			l.InfoContext(tt.ctx(), "hello", Err(errors.New("boom")))
+
+			var record map[string]interface{}
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+			assert.Equal(t, "hello", record["msg"])
+			assert.Equal(t, "test", record["component"], "attributes from With must survive wrapping")
+			assert.Equal(t, "boom", record["error"])
+			for k, v := range tt.wantAttrs {
+				assert.Equal(t, v, record[k], k)
+			}
+			for _, k := range tt.absent {
+				assert.NotContains(t, record, k)
+			}
+		})
+	}
+}
+
+func TestCorrelationIDFromContext(t *testing.T) {
+	assert.Empty(t, CorrelationIDFromContext(context.Background()))
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	assert.Equal(t, "req-1", CorrelationIDFromContext(ctx), "a request correlates what it causes")
+	assert.Equal(t, "msg-1", CorrelationIDFromContext(WithCorrelationID(ctx, "msg-1")))
+}
+
+func TestSetLevel(t *testing.T) {
+	t.Cleanup(func() { _ = SetLevel("info") })
+
+	var buf bytes.Buffer
+	l := New(&buf, FormatText, level)
+
+	require.NoError(t, SetLevel("warn"))
+	l.Info("dropped")
+	assert.Empty(t, buf.String())
+
+	require.NoError(t, SetLevel("DEBUG"))
+	assert.Equal(t, slog.LevelDebug, Level())
+	l.Debug("kept")
+	assert.Contains(t, buf.String(), "msg=kept")
+
+	assert.Error(t, SetLevel("verbose"))
+	assert.Equal(t, slog.LevelDebug, Level(), "invalid level must not change the current one")
+}
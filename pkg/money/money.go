@@ -0,0 +1,216 @@
+// Package money represents amounts of money in one currency at a fixed scale,
+// so amounts in different currencies are never added up by mistake.
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/shopspring/decimal"
+)
+
+// Scale is the number of fractional digits every amount is kept at: cents.
+const Scale = 2
+
+var (
+	// ErrCurrencyMismatch means two amounts in different currencies were combined.
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrInvalidCurrency  = errors.New("invalid currency")
+	// ErrInvalidAmount means an amount is not a number or has sub-cent precision.
+	ErrInvalidAmount = errors.New("invalid amount")
+
+	currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// Money is an amount in a currency, always rounded to Scale, encoded in JSON
+// as {"amount":"19.90","currency":"USD"}. The zero value has no currency and
+// cannot be combined with other amounts; start sums from Zero instead.
+type Money struct {
+	amount   decimal.Decimal
+	currency string
+}
+
+// New returns amount in currency, an ISO 4217 code, rounded half away from
+// zero to Scale.
+func New(amount decimal.Decimal, currency string) Money {
+	return Money{amount: amount.Round(Scale), currency: currency}
+}
+
+// Zero returns no money in currency.
+func Zero(currency string) Money {
+	return Money{amount: decimal.Zero, currency: currency}
+}
+
+// Parse parses a decimal amount such as "19.99". Unlike New it rejects amounts
+// with sub-cent precision instead of rounding them.
+func Parse(amount, currency string) (Money, error) {
+	d, err := decimal.NewFromString(amount)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+	m := Money{amount: d, currency: currency}
+	if err := m.Validate(); err != nil {
+		return Money{}, err
+	}
+	return m, nil
+}
+
+// Sum adds up amounts, which must all be in currency.
+func Sum(currency string, amounts ...Money) (Money, error) {
+	total := Zero(currency)
+	for _, m := range amounts {
+		var err error
+		if total, err = total.Add(m); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// Amount returns the amount, with at most Scale fractional digits.
+func (m Money) Amount() decimal.Decimal {
+	return m.amount
+}
+
+func (m Money) Currency() string {
+	return m.currency
+}
+
+// Cents returns the amount in minor units, e.g. 1999 for 19.99.
+func (m Money) Cents() int64 {
+	return m.amount.Shift(Scale).IntPart()
+}
+
+func (m Money) IsZero() bool {
+	return m.amount.IsZero()
+}
+
+func (m Money) IsPositive() bool {
+	return m.amount.IsPositive()
+}
+
+func (m Money) IsNegative() bool {
+	return m.amount.IsNegative()
+}
+
+// Add returns m plus o.
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return Money{}, err
+	}
+	return Money{amount: m.amount.Add(o.amount), currency: m.currency}, nil
+}
+
+// Sub returns m minus o, which may be negative.
+func (m Money) Sub(o Money) (Money, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return Money{}, err
+	}
+	return Money{amount: m.amount.Sub(o.amount), currency: m.currency}, nil
+}
+
+// Mul returns m times quantity, e.g. the total of an order line.
+func (m Money) Mul(quantity int64) Money {
+	return Money{amount: m.amount.Mul(decimal.NewFromInt(quantity)), currency: m.currency}
+}
+
+// MulRate returns m times rate rounded to Scale, e.g. the tax or a
+// percentage discount on an amount.
+func (m Money) MulRate(rate decimal.Decimal) Money {
+	return New(m.amount.Mul(rate), m.currency)
+}
+
+// Cmp compares m and o like decimal.Decimal.Cmp.
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return 0, err
+	}
+	return m.amount.Cmp(o.amount), nil
+}
+
+// Min returns the smaller of m and o, e.g. to cap a discount or refund at
+// what was paid.
+func (m Money) Min(o Money) (Money, error) {
+	cmp, err := m.Cmp(o)
+	if err != nil {
+		return Money{}, err
+	}
+	if cmp > 0 {
+		return o, nil
+	}
+	return m, nil
+}
+
+// Equal reports whether m and o are the same amount in the same currency.
+func (m Money) Equal(o Money) bool {
+	return m.currency == o.currency && m.amount.Equal(o.amount)
+}
+
+// Validate checks that the currency is an ISO 4217 code and the amount has no
+// sub-cent precision. Amounts built with New or arithmetic always pass the
+// latter.
+func (m Money) Validate() error {
+	if !currencyPattern.MatchString(m.currency) {
+		return fmt.Errorf("%w: %q", ErrInvalidCurrency, m.currency)
+	}
+	if !m.amount.Equal(m.amount.Truncate(Scale)) {
+		return fmt.Errorf("%w: %s has more than %d decimal places", ErrInvalidAmount, m.amount, Scale)
+	}
+	return nil
+}
+
+// String formats m as e.g. "19.90 USD".
+func (m Money) String() string {
+	return m.amount.StringFixed(Scale) + " " + m.currency
+}
+
+func (m Money) sameCurrency(o Money) error {
+	if m.currency != o.currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, o.currency)
+	}
+	return nil
+}
+
+// jsonMoney is the JSON form of Money. The amount is a string with exactly
+// Scale fractional digits, so clients never parse money as a float.
+type jsonMoney struct {
+	Amount   string `json:"amount" example:"19.99"`
+	Currency string `json:"currency" example:"USD"`
+}
+
+// MarshalJSON encodes m as {"amount":"19.90","currency":"USD"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonMoney{Amount: m.amount.StringFixed(Scale), Currency: m.currency})
+}
+
+// UnmarshalJSON decodes what MarshalJSON encodes, including the zero value.
+// The amount may also be a JSON number; either way it is validated rather
+// than rounded.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var raw struct {
+		Amount   json.RawMessage `json:"amount"`
+		Currency string          `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to decode money: %w", err)
+	}
+	var amount decimal.Decimal
+	if err := amount.UnmarshalJSON(raw.Amount); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidAmount, raw.Amount)
+	}
+	parsed := Money{amount: amount, currency: raw.Currency}
+	if parsed.currency == "" && parsed.IsZero() {
+		*m = Money{}
+		return nil
+	}
+	if err := parsed.Validate(); err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,102 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoney_Arithmetic(t *testing.T) {
+	price := New(decimal.RequireFromString("19.995"), "USD")
+	assert.Equal(t, "20", price.Amount().String(), "rounded half away from zero")
+	assert.Equal(t, int64(2000), price.Cents())
+
+	line := price.Mul(3)
+	assert.Equal(t, "60.00 USD", line.String())
+	tax := line.MulRate(decimal.RequireFromString("0.0725"))
+	assert.Equal(t, "4.35 USD", tax.String())
+
+	total, err := Sum("USD", line, tax)
+	require.NoError(t, err)
+	assert.True(t, total.Equal(New(decimal.RequireFromString("64.35"), "USD")))
+
+	refund, err := total.Min(New(decimal.NewFromInt(100), "USD"))
+	require.NoError(t, err)
+	assert.Equal(t, total, refund)
+	rest, err := total.Sub(refund)
+	require.NoError(t, err)
+	assert.True(t, rest.IsZero())
+
+	_, err = total.Add(New(decimal.NewFromInt(1), "EUR"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = Sum("EUR", total)
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = total.Cmp(Money{})
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency string
+		want     string
+		wantErr  error
+	}{
+		{name: "Cents", amount: "19.99", currency: "USD", want: "19.99 USD"},
+		{name: "Whole", amount: "5", currency: "JPY", want: "5.00 JPY"},
+		{name: "Negative", amount: "-1.5", currency: "EUR", want: "-1.50 EUR"},
+		{name: "SubCent", amount: "19.999", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "NotANumber", amount: "ten", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "LowercaseCurrency", amount: "1", currency: "usd", wantErr: ErrInvalidCurrency},
+		{name: "NoCurrency", amount: "1", wantErr: ErrInvalidCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse(tt.amount, tt.currency)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, m.String())
+		})
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	encoded, err := json.Marshal(New(decimal.RequireFromString("19.9"), "USD"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"19.90","currency":"USD"}`, string(encoded))
+
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr error
+	}{
+		{name: "String", data: `{"amount":"19.90","currency":"USD"}`, want: "19.90 USD"},
+		{name: "Number", data: `{"amount":19.9,"currency":"USD"}`, want: "19.90 USD"},
+		{name: "SubCent", data: `{"amount":"0.001","currency":"USD"}`, wantErr: ErrInvalidAmount},
+		{name: "MissingAmount", data: `{"currency":"USD"}`, wantErr: ErrInvalidAmount},
+		{name: "ZeroValue", data: `{"amount":"0.00","currency":""}`, want: "0.00 "},
+		{name: "MissingCurrency", data: `{"amount":"1"}`, wantErr: ErrInvalidCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Money
+			err := json.Unmarshal([]byte(tt.data), &m)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, m.String())
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package mq
+
+import (
+	"context"
+)
+
+// Delivery describes the message a Consume handler is processing.
+type Delivery struct {
+	Queue     string
+	MessageID string
+	// CorrelationID is the ID of the request, or of the message, that led to
+	// this one being published, if any.
+	CorrelationID string
+	Redelivered   bool
+	// Headers are the application headers of the message, e.g. the
+	// traceparent it was published in.
+	Headers map[string]any
+}
+
+type deliveryKey struct{}
+
+// WithDelivery returns a copy of ctx for handling d. Consume does this for
+// its handlers; it is exported for running them otherwise, e.g. in tests.
+func WithDelivery(ctx context.Context, d Delivery) context.Context {
+	return context.WithValue(ctx, deliveryKey{}, d)
+}
+
+// DeliveryFromContext returns the delivery a Consume handler called with ctx
+// is processing.
+func DeliveryFromContext(ctx context.Context) (Delivery, bool) {
+	d, ok := ctx.Value(deliveryKey{}).(Delivery)
+	return d, ok
+}
@@ -0,0 +1,26 @@
+package mq
+
+import (
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// headerCarrier reads and writes trace context in the headers of a message,
+// so that consumers continue the trace of whoever published it.
+type headerCarrier amqp.Table
+
+func (c headerCarrier) Get(key string) string {
+	value, _ := c[key].(string)
+	return value
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -8,25 +8,85 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/proyuen/go-mall/pkg/logger"
 	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	defaultReconnectDelay   = 1 * time.Second
 	maxReconnectDelay       = 30 * time.Second
 	confirmationChannelSize = 1000 // Buffer for async confirmations
+	maxRecentReconnects     = 20   // Reconnects remembered for Health
 )
 
-// RabbitMQ defines the interface for message queue operations.
+// RabbitMQ defines the interface for message queue operations. Publish sends
+// the trace context of ctx in the message headers, along with a message ID and
+// the correlation ID of ctx, and Consume hands the handler a context
+// continuing that trace in a consumer span and carrying the Delivery.
 type RabbitMQ interface {
 	Publish(ctx context.Context, exchange, routingKey string, body []byte) error
-	Consume(queue string, handler func(ctx context.Context, body []byte) error) error
+	Consume(queue string, handler func(ctx context.Context, body []byte) error, opts ConsumeOptions) error
+	DeclareQueue(name string, opts QueueOptions) error
+	DeclareExchange(name string) error
+	BindQueue(queue, exchange, pattern string) error
+	// InspectQueue asks the broker about a declared queue.
+	InspectQueue(name string) (QueueStats, error)
+	// Health reports the connection and its recent reconnects.
+	Health() Health
 	Close() error
 }
 
+// QueueStats is what the broker reports about a queue.
+type QueueStats struct {
+	Name      string
+	Messages  int // Ready for delivery; unacknowledged messages are not counted
+	Consumers int
+}
+
+// Reconnect is a lost connection and its recovery.
+type Reconnect struct {
+	LostAt     time.Time
+	RestoredAt time.Time // Zero while still reconnecting
+	Cause      string
+	Attempts   int
+}
+
+// Health is the state of the connection. Reconnects lists the recent ones,
+// newest first.
+type Health struct {
+	Connected  bool
+	Reconnects []Reconnect
+}
+
+// QueueOptions configures a durable queue declared with DeclareQueue. Declaring
+// an existing queue with different options fails, so options must not change
+// between releases without migrating the queue.
+type QueueOptions struct {
+	// MessageTTL expires messages after this long; 0 keeps them until consumed.
+	MessageTTL time.Duration
+	// DeadLetterExchange and DeadLetterRoutingKey route expired and rejected
+	// messages; an empty exchange with a routing key means the default exchange,
+	// i.e. straight into the queue of that name. Both empty discards them.
+	DeadLetterExchange   string
+	DeadLetterRoutingKey string
+}
+
+// ConsumeOptions configures a consumer started with Consume.
+type ConsumeOptions struct {
+	// Concurrency is how many messages the handler processes at once, and
+	// how many the broker sends ahead of their acknowledgement; 0 means 1.
+	Concurrency int
+}
+
 type consumerConfig struct {
 	queue   string
 	handler func(ctx context.Context, body []byte) error
+	opts    ConsumeOptions
 }
 
 type rabbitMQ struct {
@@ -47,6 +107,9 @@ type rabbitMQ struct {
 	consumers []consumerConfig
 
 	reconnectDly time.Duration
+	reconnects   []Reconnect // Oldest first, guarded by mu
+
+	tracer trace.Tracer
 }
 
 // NewRabbitMQ creates a new RabbitMQ client with automatic reconnection and async publisher confirms.
@@ -56,6 +119,7 @@ func NewRabbitMQ(url string, logger *slog.Logger) (RabbitMQ, error) {
 		logger:       logger,
 		reconnectDly: defaultReconnectDelay,
 		consumers:    make([]consumerConfig, 0),
+		tracer:       otel.Tracer("pkg/mq"),
 	}
 
 	if err := mq.connect(); err != nil {
@@ -135,11 +199,23 @@ func (r *rabbitMQ) reconnectLoop() {
 
 		r.mu.Lock()
 		r.isConnected = false
+		r.reconnects = append(r.reconnects, Reconnect{LostAt: time.Now(), Cause: err.Error()})
+		if len(r.reconnects) > maxRecentReconnects {
+			r.reconnects = r.reconnects[1:]
+		}
 		r.mu.Unlock()
 
 		for {
 			time.Sleep(r.reconnectDly)
-			if err := r.connect(); err == nil {
+			err := r.connect()
+			r.mu.Lock()
+			last := &r.reconnects[len(r.reconnects)-1]
+			last.Attempts++
+			if err == nil {
+				last.RestoredAt = time.Now()
+			}
+			r.mu.Unlock()
+			if err == nil {
 				r.logger.Info("RabbitMQ reconnected")
 				r.reconnectDly = defaultReconnectDelay
 
@@ -181,7 +257,7 @@ func (r *rabbitMQ) recoverConsumers() {
 
 		// Strategy:
 		// Call internalStartConsumer for each.
-		if err := r.internalStartConsumer(cfg.queue, cfg.handler); err != nil {
+		if err := r.internalStartConsumer(cfg.queue, cfg.handler, cfg.opts); err != nil {
 			r.logger.Error("Failed to recover consumer", "queue", cfg.queue, "error", err)
 		}
 	}
@@ -189,7 +265,7 @@ func (r *rabbitMQ) recoverConsumers() {
 
 // internalStartConsumer registers the consumer on the current channel.
 // It assumes r.mu is NOT held (it acquires it).
-func (r *rabbitMQ) internalStartConsumer(queue string, handler func(ctx context.Context, body []byte) error) error {
+func (r *rabbitMQ) internalStartConsumer(queue string, handler func(ctx context.Context, body []byte) error, opts ConsumeOptions) error {
 	r.mu.RLock()
 	if !r.isConnected {
 		r.mu.RUnlock()
@@ -198,7 +274,8 @@ func (r *rabbitMQ) internalStartConsumer(queue string, handler func(ctx context.
 	ch := r.channel
 	r.mu.RUnlock()
 
-	if err := ch.Qos(1, 0, false); err != nil {
+	concurrency := max(opts.Concurrency, 1)
+	if err := ch.Qos(concurrency, 0, false); err != nil {
 		return fmt.Errorf("failed to set Qos: %w", err)
 	}
 
@@ -216,21 +293,51 @@ func (r *rabbitMQ) internalStartConsumer(queue string, handler func(ctx context.
 	}
 
 	go func() {
-		for d := range msgs {
-			ctx := context.Background()
-			if err := handler(ctx, d.Body); err != nil {
-				r.logger.Error("Failed to process message", "queue", queue, "error", err)
-				d.Nack(false, false)
-			} else {
-				d.Ack(false)
-			}
-		}
+		r.dispatch(queue, handler, concurrency, msgs)
 		r.logger.Info("Consumer stopped (channel closed)", "queue", queue)
 	}()
 
 	return nil
 }
 
+// dispatch handles the deliveries of msgs, up to concurrency at once, until
+// msgs is closed and the deliveries in progress are done.
+func (r *rabbitMQ) dispatch(queue string, handler func(ctx context.Context, body []byte) error, concurrency int, msgs <-chan amqp.Delivery) {
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for d := range msgs {
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			r.handleDelivery(queue, handler, d)
+		})
+	}
+	wg.Wait()
+}
+
+// handleDelivery runs handler on d in a span continuing the trace of its
+// publisher, then acks d, or rejects it if the handler failed.
+func (r *rabbitMQ) handleDelivery(queue string, handler func(ctx context.Context, body []byte) error, d amqp.Delivery) {
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), headerCarrier(d.Headers))
+	ctx, span := r.tracer.Start(ctx, queue+" process", trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(
+		attribute.String("messaging.system", "rabbitmq"),
+		attribute.String("messaging.destination.name", queue),
+		attribute.String("messaging.rabbitmq.destination.routing_key", d.RoutingKey),
+		attribute.String("messaging.message.id", d.MessageId),
+	))
+	defer span.End()
+	ctx = WithDelivery(ctx, Delivery{Queue: queue, MessageID: d.MessageId, CorrelationID: d.CorrelationId, Redelivered: d.Redelivered, Headers: d.Headers})
+
+	if err := handler(ctx, d.Body); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		r.logger.ErrorContext(ctx, "Failed to process message", "queue", queue, "message_id", d.MessageId, "error", err)
+		d.Nack(false, false)
+	} else {
+		d.Ack(false)
+	}
+}
+
 // Publish sends a persistent message asynchronously.
 // It does NOT wait for confirmation, ensuring high throughput.
 func (r *rabbitMQ) Publish(ctx context.Context, exchange, routingKey string, body []byte) error {
@@ -241,6 +348,9 @@ func (r *rabbitMQ) Publish(ctx context.Context, exchange, routingKey string, bod
 		return errors.New("rabbitmq not connected")
 	}
 
+	headers := amqp.Table{}
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier(headers))
+
 	// Publish is non-blocking regarding network I/O wait for Ack.
 	// It writes to the socket buffer.
 	err := r.channel.PublishWithContext(ctx,
@@ -249,10 +359,13 @@ func (r *rabbitMQ) Publish(ctx context.Context, exchange, routingKey string, bod
 		false, // mandatory
 		false, // immediate
 		amqp.Publishing{
-			ContentType:  "application/json",
-			DeliveryMode: amqp.Persistent,
-			Body:         body,
-			Timestamp:    time.Now(),
+			Headers:       headers,
+			ContentType:   "application/json",
+			DeliveryMode:  amqp.Persistent,
+			MessageId:     uuid.NewString(),
+			CorrelationId: logger.CorrelationIDFromContext(ctx),
+			Body:          body,
+			Timestamp:     time.Now(),
 		},
 	)
 	if err != nil {
@@ -263,15 +376,126 @@ func (r *rabbitMQ) Publish(ctx context.Context, exchange, routingKey string, bod
 }
 
 // Consume registers a consumer and adds it to the registry for recovery.
-func (r *rabbitMQ) Consume(queue string, handler func(ctx context.Context, body []byte) error) error {
+func (r *rabbitMQ) Consume(queue string, handler func(ctx context.Context, body []byte) error, opts ConsumeOptions) error {
 	r.mu.Lock()
 	r.consumers = append(r.consumers, consumerConfig{
 		queue:   queue,
 		handler: handler,
+		opts:    opts,
 	})
 	r.mu.Unlock()
 
-	return r.internalStartConsumer(queue, handler)
+	return r.internalStartConsumer(queue, handler, opts)
+}
+
+// DeclareQueue creates a durable queue if it does not exist yet.
+func (r *rabbitMQ) DeclareQueue(name string, opts QueueOptions) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.isConnected {
+		return errors.New("rabbitmq not connected")
+	}
+
+	args := amqp.Table{}
+	if opts.MessageTTL > 0 {
+		args["x-message-ttl"] = opts.MessageTTL.Milliseconds()
+	}
+	if opts.DeadLetterExchange != "" || opts.DeadLetterRoutingKey != "" {
+		args["x-dead-letter-exchange"] = opts.DeadLetterExchange
+		if opts.DeadLetterRoutingKey != "" {
+			args["x-dead-letter-routing-key"] = opts.DeadLetterRoutingKey
+		}
+	}
+
+	_, err := r.channel.QueueDeclare(
+		name,
+		true,  // durable
+		false, // auto-delete
+		false, // exclusive
+		false, // no-wait
+		args,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare queue %s: %w", name, err)
+	}
+	return nil
+}
+
+// DeclareExchange creates a durable topic exchange if it does not exist yet.
+// Messages are routed by their routing key to the queues bound to a matching
+// pattern, and dropped if none is.
+func (r *rabbitMQ) DeclareExchange(name string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.isConnected {
+		return errors.New("rabbitmq not connected")
+	}
+
+	err := r.channel.ExchangeDeclare(
+		name,
+		amqp.ExchangeTopic,
+		true,  // durable
+		false, // auto-delete
+		false, // internal
+		false, // no-wait
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare exchange %s: %w", name, err)
+	}
+	return nil
+}
+
+// BindQueue routes the messages of exchange with a routing key matching
+// pattern, e.g. "order.*", into queue.
+func (r *rabbitMQ) BindQueue(queue, exchange, pattern string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.isConnected {
+		return errors.New("rabbitmq not connected")
+	}
+
+	if err := r.channel.QueueBind(queue, pattern, exchange, false, nil); err != nil {
+		return fmt.Errorf("failed to bind queue %s to %s: %w", queue, exchange, err)
+	}
+	return nil
+}
+
+// InspectQueue passively declares the queue on a channel of its own: the
+// broker closes the channel if the queue does not exist.
+func (r *rabbitMQ) InspectQueue(name string) (QueueStats, error) {
+	r.mu.RLock()
+	if !r.isConnected {
+		r.mu.RUnlock()
+		return QueueStats{}, errors.New("rabbitmq not connected")
+	}
+	conn := r.conn
+	r.mu.RUnlock()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return QueueStats{}, fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer ch.Close()
+
+	q, err := ch.QueueDeclarePassive(name, true, false, false, false, nil)
+	if err != nil {
+		return QueueStats{}, fmt.Errorf("failed to inspect queue %s: %w", name, err)
+	}
+	return QueueStats{Name: q.Name, Messages: q.Messages, Consumers: q.Consumers}, nil
+}
+
+func (r *rabbitMQ) Health() Health {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	h := Health{Connected: r.isConnected, Reconnects: make([]Reconnect, 0, len(r.reconnects))}
+	for i := len(r.reconnects) - 1; i >= 0; i-- {
+		h.Reconnects = append(h.Reconnects, r.reconnects[i])
+	}
+	return h
 }
 
 func (r *rabbitMQ) Close() error {
@@ -0,0 +1,139 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// acknowledger records how a delivery was settled.
+type acknowledger struct {
+	acked, nacked bool
+}
+
+func (a *acknowledger) Ack(uint64, bool) error        { a.acked = true; return nil }
+func (a *acknowledger) Nack(uint64, bool, bool) error { a.nacked = true; return nil }
+func (a *acknowledger) Reject(uint64, bool) error     { a.nacked = true; return nil }
+
+func TestRabbitMQ_HandleDelivery(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+	const publisherTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	tests := []struct {
+		name       string
+		headers    amqp.Table
+		handlerErr error
+		wantTrace  string
+	}{
+		{name: "ContinuesPublisherTrace", headers: amqp.Table{"traceparent": "00-" + publisherTraceID + "-00f067aa0ba902b7-01"}, wantTrace: publisherTraceID},
+		{name: "NoTraceContext"},
+		{name: "HandlerFails", handlerErr: errors.New("db down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			r := &rabbitMQ{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), tracer: provider.Tracer("test")}
+			ack := &acknowledger{}
+
+			var handlerSpan trace.SpanContext
+			r.handleDelivery("notifications", func(ctx context.Context, body []byte) error {
+				handlerSpan = trace.SpanContextFromContext(ctx)
+				assert.Equal(t, []byte(`{}`), body)
+				delivery, ok := DeliveryFromContext(ctx)
+				assert.True(t, ok)
+				assert.Equal(t, Delivery{Queue: "notifications", MessageID: "msg-1", CorrelationID: "req-1", Redelivered: true, Headers: tt.headers}, delivery)
+				return tt.handlerErr
+			}, amqp.Delivery{Acknowledger: ack, Headers: tt.headers, MessageId: "msg-1", CorrelationId: "req-1", Redelivered: true, Body: []byte(`{}`)})
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "notifications process", spans[0].Name())
+			assert.Equal(t, trace.SpanKindConsumer, spans[0].SpanKind())
+			assert.Equal(t, spans[0].SpanContext(), handlerSpan, "the handler must run in the consumer span")
+			if tt.wantTrace != "" {
+				assert.Equal(t, tt.wantTrace, handlerSpan.TraceID().String())
+				assert.True(t, spans[0].Parent().IsRemote())
+			} else {
+				assert.False(t, spans[0].Parent().IsValid())
+			}
+			if tt.handlerErr != nil {
+				assert.Equal(t, codes.Error, spans[0].Status().Code)
+				assert.True(t, ack.nacked)
+			} else {
+				assert.True(t, ack.acked)
+			}
+		})
+	}
+}
+
+func TestRabbitMQ_Dispatch(t *testing.T) {
+	r := &rabbitMQ{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), tracer: noop.NewTracerProvider().Tracer("test")}
+	msgs := make(chan amqp.Delivery, 6)
+	acks := make([]*acknowledger, cap(msgs))
+	for i := range acks {
+		acks[i] = &acknowledger{}
+		msgs <- amqp.Delivery{Acknowledger: acks[i]}
+	}
+	close(msgs)
+
+	var inFlight, maxInFlight atomic.Int32
+	r.dispatch("orders.created", func(context.Context, []byte) error {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			peak := maxInFlight.Load()
+			if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}, 2, msgs)
+
+	assert.Equal(t, int32(2), maxInFlight.Load())
+	for _, ack := range acks {
+		assert.True(t, ack.acked, "dispatch returns once every delivery is settled")
+	}
+}
+
+func TestHeaderCarrier(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "publish")
+	defer span.End()
+
+	headers := amqp.Table{"x-death": int64(1)}
+	propagation.TraceContext{}.Inject(ctx, headerCarrier(headers))
+	require.Contains(t, headers, "traceparent")
+	assert.Nil(t, headers.Validate(), "headers must stay valid AMQP field values")
+
+	extracted := propagation.TraceContext{}.Extract(context.Background(), headerCarrier(headers))
+	assert.Equal(t, span.SpanContext().TraceID(), trace.SpanContextFromContext(extracted).TraceID())
+	assert.Empty(t, headerCarrier(headers).Get("x-death"), "non-string headers are not trace context")
+}
+
+func TestRabbitMQ_Health(t *testing.T) {
+	first := Reconnect{LostAt: time.Unix(100, 0), RestoredAt: time.Unix(101, 0), Cause: "connection reset", Attempts: 1}
+	second := Reconnect{LostAt: time.Unix(200, 0), Cause: "broker shutdown", Attempts: 3}
+	r := &rabbitMQ{reconnects: []Reconnect{first, second}}
+
+	assert.Equal(t, Health{Connected: false, Reconnects: []Reconnect{second, first}}, r.Health())
+}
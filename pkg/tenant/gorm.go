@@ -0,0 +1,92 @@
+package tenant
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// storeColumn is the column of every table whose rows belong to a store.
+const storeColumn = "store_id"
+
+// Plugin scopes GORM statements to the store of their context: created rows
+// get its ID, and queries, updates and deletes only see its rows. Tables
+// without a store_id column, and contexts without a store, are left alone.
+// Raw SQL is not scoped.
+type Plugin struct{}
+
+// Name implements gorm.Plugin.
+func (Plugin) Name() string {
+	return "tenant"
+}
+
+// Initialize implements gorm.Plugin by registering the scoping callbacks.
+func (Plugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("tenant:assign_store", assignStore); err != nil {
+		return fmt.Errorf("failed to register tenant create callback: %w", err)
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:scope", scopeToStore); err != nil {
+		return fmt.Errorf("failed to register tenant query callback: %w", err)
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("tenant:scope", scopeToStore); err != nil {
+		return fmt.Errorf("failed to register tenant row callback: %w", err)
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:scope", scopeToStore); err != nil {
+		return fmt.Errorf("failed to register tenant update callback: %w", err)
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("tenant:scope", scopeToStore); err != nil {
+		return fmt.Errorf("failed to register tenant delete callback: %w", err)
+	}
+	return nil
+}
+
+// storeField returns the store_id field of the statement's table and the
+// store to scope it to, if both exist.
+func storeField(db *gorm.DB) (*schema.Field, uint64, bool) {
+	storeID := StoreID(db.Statement.Context)
+	if db.Error != nil || storeID == 0 || db.Statement.Schema == nil {
+		return nil, 0, false
+	}
+	field := db.Statement.Schema.LookUpField(storeColumn)
+	return field, storeID, field != nil
+}
+
+// assignStore sets the store of created rows that have none, including rows
+// created through associations, such as the SKUs of a new SPU.
+func assignStore(db *gorm.DB) {
+	field, storeID, ok := storeField(db)
+	if !ok {
+		return
+	}
+	ctx, rv := db.Statement.Context, db.Statement.ReflectValue
+	assign := func(row reflect.Value) {
+		if _, zero := field.ValueOf(ctx, row); zero {
+			if err := field.Set(ctx, row, storeID); err != nil {
+				_ = db.AddError(fmt.Errorf("failed to assign store: %w", err))
+			}
+		}
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			assign(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		assign(rv)
+	}
+}
+
+// scopeToStore limits the statement to rows of the context's store.
+func scopeToStore(db *gorm.DB) {
+	_, storeID, ok := storeField(db)
+	if !ok {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: storeColumn}, Value: storeID},
+	}})
+}
@@ -0,0 +1,61 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/proyuen/go-mall/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// newDryRunDB builds statements without a database to run them against.
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(Plugin{}))
+	return db
+}
+
+func TestPlugin(t *testing.T) {
+	db := newDryRunDB(t)
+	store := NewContext(context.Background(), &model.Store{Base: model.Base{ID: 42}})
+
+	t.Run("QueryScoped", func(t *testing.T) {
+		stmt := db.WithContext(store).Where("name = ?", "Mug").Find(&[]model.SPU{}).Statement
+		assert.Contains(t, stmt.SQL.String(), `"spus"."store_id" = $`)
+		assert.Contains(t, stmt.Vars, uint64(42))
+	})
+
+	t.Run("UpdateAndDeleteScoped", func(t *testing.T) {
+		stmt := db.WithContext(store).Model(&model.SKU{}).Where("id = ?", 1).Update("stock", 3).Statement
+		assert.Contains(t, stmt.SQL.String(), `"skus"."store_id" = $`)
+		stmt = db.WithContext(store).Delete(&model.Order{}, 1).Statement
+		assert.Contains(t, stmt.SQL.String(), `"orders"."store_id" = $`)
+	})
+
+	t.Run("CreateAssignsStore", func(t *testing.T) {
+		// IDs are set so the snowflake generator is not needed
+		spu := &model.SPU{Base: model.Base{ID: 1}, Name: "Mug", SKUs: []model.SKU{{Base: model.Base{ID: 2}}}}
+		db.WithContext(store).Create(spu)
+		assert.Equal(t, uint64(42), spu.StoreID)
+		assert.Equal(t, uint64(42), spu.SKUs[0].StoreID, "rows created through associations too")
+		users := []model.User{{Base: model.Base{ID: 3}}, {Base: model.Base{ID: 4}, StoreID: 7}}
+		db.WithContext(store).Create(&users)
+		assert.Equal(t, uint64(42), users[0].StoreID)
+		assert.Equal(t, uint64(7), users[1].StoreID, "an explicit store is kept")
+	})
+
+	t.Run("UnscopedWithoutStore", func(t *testing.T) {
+		stmt := db.WithContext(context.Background()).Find(&[]model.SPU{}).Statement
+		assert.NotContains(t, stmt.SQL.String(), "store_id")
+	})
+
+	t.Run("TablesWithoutStoreUnscoped", func(t *testing.T) {
+		stmt := db.WithContext(store).Find(&[]model.Category{}).Statement
+		assert.NotContains(t, stmt.SQL.String(), "store_id")
+	})
+}
@@ -0,0 +1,31 @@
+// Package tenant carries the store a request is served for and scopes
+// database access to it, so one deployment can run several shops.
+package tenant
+
+import (
+	"context"
+
+	"github.com/proyuen/go-mall/internal/model"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx that is served for store.
+func NewContext(ctx context.Context, store *model.Store) context.Context {
+	return context.WithValue(ctx, contextKey{}, store)
+}
+
+// FromContext returns the store ctx is served for, or nil when tenancy is
+// disabled and for background jobs, which work across stores.
+func FromContext(ctx context.Context) *model.Store {
+	store, _ := ctx.Value(contextKey{}).(*model.Store)
+	return store
+}
+
+// StoreID returns the ID of the store ctx is served for, or 0.
+func StoreID(ctx context.Context) uint64 {
+	if store := FromContext(ctx); store != nil {
+		return store.ID
+	}
+	return 0
+}
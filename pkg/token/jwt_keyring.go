@@ -0,0 +1,80 @@
+package token
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// NewJWTKeyringMaker creates a JWTMaker that verifies tokens with any key of
+// keys, picked by the kid header of the token, and signs with the key named
+// keyID. Its keys can change while it is in use, so secrets are rotated
+// without logging users out: add the new key, sign with it, and remove the
+// old one once the tokens it signed have expired.
+func NewJWTKeyringMaker(keyID string, keys map[string]*JWTKey) (*JWTMaker, error) {
+	if keyID == "" {
+		return nil, fmt.Errorf("key ID is required")
+	}
+	maker := &JWTMaker{keys: make(map[string]*JWTKey, len(keys))}
+	for id, key := range keys {
+		if err := maker.AddKey(id, key); err != nil {
+			return nil, err
+		}
+	}
+	if err := maker.UseKey(keyID); err != nil {
+		return nil, err
+	}
+	return maker, nil
+}
+
+// AddKey adds key to the keyring as keyID, or replaces the key of that ID.
+// Tokens are only signed with it once it is made current with UseKey.
+func (maker *JWTMaker) AddKey(keyID string, key *JWTKey) error {
+	if keyID == "" {
+		return fmt.Errorf("key ID is required")
+	}
+	if key == nil {
+		return fmt.Errorf("key %q is nil", keyID)
+	}
+	maker.mu.Lock()
+	defer maker.mu.Unlock()
+	if keyID == maker.keyID && key.signingKey == nil {
+		return fmt.Errorf("key %q signs new tokens, but has no private key or secret", keyID)
+	}
+	maker.keys[keyID] = key
+	return nil
+}
+
+// UseKey signs new tokens with the key keyID from now on.
+func (maker *JWTMaker) UseKey(keyID string) error {
+	maker.mu.Lock()
+	defer maker.mu.Unlock()
+	key, ok := maker.keys[keyID]
+	if !ok || keyID == "" {
+		return fmt.Errorf("key %q is not in the keyring", keyID)
+	}
+	if key.signingKey == nil {
+		return fmt.Errorf("key %q has no private key or secret to sign with", keyID)
+	}
+	maker.keyID = keyID
+	return nil
+}
+
+// RemoveKey removes the key keyID, rejecting the tokens it signed. The key
+// that signs new tokens cannot be removed.
+func (maker *JWTMaker) RemoveKey(keyID string) error {
+	maker.mu.Lock()
+	defer maker.mu.Unlock()
+	if keyID == maker.keyID {
+		return fmt.Errorf("key %q signs new tokens", keyID)
+	}
+	delete(maker.keys, keyID)
+	return nil
+}
+
+// KeyIDs returns the IDs of the keys in the keyring, sorted.
+func (maker *JWTMaker) KeyIDs() []string {
+	maker.mu.RLock()
+	defer maker.mu.RUnlock()
+	return slices.Sorted(maps.Keys(maker.keys))
+}
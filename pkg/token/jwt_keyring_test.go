@@ -0,0 +1,112 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTKeyringMaker_Rotation(t *testing.T) {
+	oldKey, err := NewHMACKey("12345678901234567890123456789012")
+	require.NoError(t, err)
+	newKey, err := NewHMACKey("abcdefghijabcdefghijabcdefghijab")
+	require.NoError(t, err)
+
+	maker, err := NewJWTKeyringMaker("2026-09", map[string]*JWTKey{"2026-09": oldKey})
+	require.NoError(t, err)
+	oldToken, _, err := maker.CreateToken(101, 1, "test_user", "user", time.Minute)
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(oldToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "2026-09", parsed.Header["kid"])
+
+	// Rotate: tokens signed with the old key stay valid until it is removed
+	require.NoError(t, maker.AddKey("2026-10", newKey))
+	require.NoError(t, maker.UseKey("2026-10"))
+	newToken, _, err := maker.CreateToken(101, 1, "test_user", "user", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2026-09", "2026-10"}, maker.KeyIDs())
+
+	for _, token := range []string{oldToken, newToken} {
+		payload, err := maker.VerifyToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(101), payload.UserID)
+	}
+
+	assert.Error(t, maker.RemoveKey("2026-10"))
+	require.NoError(t, maker.RemoveKey("2026-09"))
+	_, err = maker.VerifyToken(oldToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = maker.VerifyToken(newToken)
+	assert.NoError(t, err)
+}
+
+func TestJWTKeyringMaker_TokensWithoutKeyID(t *testing.T) {
+	secret := "12345678901234567890123456789012"
+	single, err := NewJWTMaker(secret)
+	require.NoError(t, err)
+	legacy, _, err := single.CreateToken(101, 1, "test_user", "user", time.Minute)
+	require.NoError(t, err)
+
+	oldKey, err := NewHMACKey(secret)
+	require.NoError(t, err)
+	newKey, err := NewHMACKey("abcdefghijabcdefghijabcdefghijab")
+	require.NoError(t, err)
+	maker, err := NewJWTKeyringMaker("new", map[string]*JWTKey{"old": oldKey, "new": newKey})
+	require.NoError(t, err)
+
+	// Signed before key IDs: verified with any key of its algorithm
+	payload, err := maker.VerifyToken(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, "test_user", payload.Username)
+
+	// An unknown key ID is never tried against other keys
+	unknown := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": 1, "username": "admin", "issued_at": time.Now(), "expired_at": time.Now().Add(time.Hour),
+	})
+	unknown.Header["kid"] = "missing"
+	signed, err := unknown.SignedString([]byte(secret))
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(signed)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestNewJWTKeyringMaker(t *testing.T) {
+	secretKey, err := NewHMACKey("12345678901234567890123456789012")
+	require.NoError(t, err)
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	publicKey, err := NewAsymmetricKey(AlgEdDSA, nil, edPublic)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		keyID   string
+		keys    map[string]*JWTKey
+		wantErr bool
+	}{
+		{name: "Valid", keyID: "a", keys: map[string]*JWTKey{"a": secretKey, "b": publicKey}},
+		{name: "NoKeyID", keys: map[string]*JWTKey{"a": secretKey}, wantErr: true},
+		{name: "KeyIDNotInKeyring", keyID: "c", keys: map[string]*JWTKey{"a": secretKey}, wantErr: true},
+		{name: "CurrentKeyCannotSign", keyID: "b", keys: map[string]*JWTKey{"b": publicKey}, wantErr: true},
+		{name: "EmptyKeyID", keyID: "a", keys: map[string]*JWTKey{"a": secretKey, "": secretKey}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maker, err := NewJWTKeyringMaker(tt.keyID, tt.keys)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Nil(t, maker)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, maker)
+			}
+		})
+	}
+}
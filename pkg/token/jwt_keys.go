@@ -0,0 +1,158 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWT signing algorithms. HS256 shares a secret between everyone that
+// verifies tokens; RS256 and EdDSA only hand out the public key.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+const minRSAKeyBits = 2048
+
+// JWTKey is a key a JWTMaker signs or verifies tokens with, for one
+// algorithm.
+type JWTKey struct {
+	method       jwt.SigningMethod
+	signingKey   any // Nil for keys that only verify
+	verifyingKey any
+}
+
+// NewHMACKey creates an HS256 key from a secret.
+func NewHMACKey(secretKey string) (*JWTKey, error) {
+	if len(secretKey) < minSecretKeySize {
+		return nil, fmt.Errorf("invalid key size: must be at least %d characters", minSecretKeySize)
+	}
+	return &JWTKey{method: jwt.SigningMethodHS256, signingKey: []byte(secretKey), verifyingKey: []byte(secretKey)}, nil
+}
+
+// NewAsymmetricKey creates an RS256 or EdDSA key from the private key of a
+// key pair. privateKey may be nil for a key that only verifies tokens;
+// publicKey may be nil when privateKey is given.
+func NewAsymmetricKey(algorithm string, privateKey crypto.Signer, publicKey crypto.PublicKey) (*JWTKey, error) {
+	if publicKey == nil {
+		if privateKey == nil {
+			return nil, fmt.Errorf("%s needs a private or a public key", algorithm)
+		}
+		publicKey = privateKey.Public()
+	} else if privateKey != nil {
+		if pub, ok := privateKey.Public().(interface{ Equal(crypto.PublicKey) bool }); !ok || !pub.Equal(publicKey) {
+			return nil, fmt.Errorf("public key does not belong to the private key")
+		}
+	}
+
+	key := &JWTKey{verifyingKey: publicKey}
+	if privateKey != nil {
+		key.signingKey = privateKey
+	}
+	switch algorithm {
+	case AlgRS256:
+		pub, ok := publicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%s needs an RSA key, got %T", algorithm, publicKey)
+		}
+		if pub.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("invalid key size: RSA keys must be at least %d bits", minRSAKeyBits)
+		}
+		key.method = jwt.SigningMethodRS256
+	case AlgEdDSA:
+		if _, ok := publicKey.(ed25519.PublicKey); !ok {
+			return nil, fmt.Errorf("%s needs an Ed25519 key, got %T", algorithm, publicKey)
+		}
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported asymmetric algorithm %q", algorithm)
+	}
+	return key, nil
+}
+
+// LoadPEMKey creates an asymmetric key from PEM files: a PKCS#1 or PKCS#8
+// private key and a PKIX public key. Either path may be empty, as for
+// NewAsymmetricKey.
+func LoadPEMKey(algorithm, privateKeyFile, publicKeyFile string) (*JWTKey, error) {
+	var privateKey crypto.Signer
+	if privateKeyFile != "" {
+		data, err := os.ReadFile(privateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key: %w", err)
+		}
+		if privateKey, err = parsePrivateKeyPEM(algorithm, data); err != nil {
+			return nil, fmt.Errorf("failed to parse private key %s: %w", privateKeyFile, err)
+		}
+	}
+
+	var publicKey crypto.PublicKey
+	if publicKeyFile != "" {
+		data, err := os.ReadFile(publicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key: %w", err)
+		}
+		if publicKey, err = parsePublicKeyPEM(algorithm, data); err != nil {
+			return nil, fmt.Errorf("failed to parse public key %s: %w", publicKeyFile, err)
+		}
+	}
+
+	return NewAsymmetricKey(algorithm, privateKey, publicKey)
+}
+
+// NewAsymmetricJWTMaker creates a JWTMaker signing with the private key of
+// an RS256 or EdDSA key pair. privateKey may be nil for a maker that only
+// verifies tokens, e.g. in another service; publicKey may be nil when
+// privateKey is given.
+func NewAsymmetricJWTMaker(algorithm string, privateKey crypto.Signer, publicKey crypto.PublicKey) (Maker, error) {
+	key, err := NewAsymmetricKey(algorithm, privateKey, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return newSingleKeyMaker(key), nil
+}
+
+// NewJWTMakerFromPEMFiles creates an asymmetric JWTMaker from PEM files, as
+// loaded by LoadPEMKey.
+func NewJWTMakerFromPEMFiles(algorithm, privateKeyFile, publicKeyFile string) (Maker, error) {
+	key, err := LoadPEMKey(algorithm, privateKeyFile, publicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return newSingleKeyMaker(key), nil
+}
+
+func parsePrivateKeyPEM(algorithm string, data []byte) (crypto.Signer, error) {
+	switch algorithm {
+	case AlgRS256:
+		return jwt.ParseRSAPrivateKeyFromPEM(data)
+	case AlgEdDSA:
+		key, err := jwt.ParseEdPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("unsupported asymmetric algorithm %q", algorithm)
+	}
+}
+
+func parsePublicKeyPEM(algorithm string, data []byte) (crypto.PublicKey, error) {
+	switch algorithm {
+	case AlgRS256:
+		return jwt.ParseRSAPublicKeyFromPEM(data)
+	case AlgEdDSA:
+		return jwt.ParseEdPublicKeyFromPEM(data)
+	default:
+		return nil, fmt.Errorf("unsupported asymmetric algorithm %q", algorithm)
+	}
+}
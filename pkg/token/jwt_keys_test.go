@@ -0,0 +1,143 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeyPair writes a key pair as PKCS#8 and PKIX PEM files.
+func writeKeyPair(t *testing.T, privateKey crypto.Signer) (privateFile, publicFile string, publicPEM []byte) {
+	t.Helper()
+	dir := t.TempDir()
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	privateFile = filepath.Join(dir, "private.pem")
+	require.NoError(t, os.WriteFile(privateFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+	der, err = x509.MarshalPKIXPublicKey(privateKey.Public())
+	require.NoError(t, err)
+	publicPEM = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	publicFile = filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(publicFile, publicPEM, 0o600))
+	return privateFile, publicFile, publicPEM
+}
+
+func TestAsymmetricJWTMaker(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		algorithm  string
+		privateKey crypto.Signer
+	}{
+		{name: "RS256", algorithm: AlgRS256, privateKey: rsaKey},
+		{name: "EdDSA", algorithm: AlgEdDSA, privateKey: edKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			privateFile, publicFile, publicPEM := writeKeyPair(t, tt.privateKey)
+			maker, err := NewJWTMakerFromPEMFiles(tt.algorithm, privateFile, "")
+			require.NoError(t, err)
+
+			token, _, err := maker.CreateToken(101, 1, "test_user", "user", time.Minute)
+			require.NoError(t, err)
+			payload, err := maker.VerifyToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(101), payload.UserID)
+
+			// Another service only holding the public key
+			verifier, err := NewJWTMakerFromPEMFiles(tt.algorithm, "", publicFile)
+			require.NoError(t, err)
+			payload, err = verifier.VerifyToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, "test_user", payload.Username)
+			_, _, err = verifier.CreateToken(101, 1, "test_user", "user", time.Minute)
+			assert.ErrorIs(t, err, ErrNoSigningKey)
+
+			expired, _, err := maker.CreateToken(101, 1, "test_user", "user", -time.Minute)
+			require.NoError(t, err)
+			_, err = verifier.VerifyToken(expired)
+			assert.ErrorIs(t, err, ErrExpiredToken)
+
+			// HS256 keyed with the public key, which anyone can get
+			forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+				"user_id": 1, "username": "admin", "issued_at": time.Now(), "expired_at": time.Now().Add(time.Hour),
+			}).SignedString(publicPEM)
+			require.NoError(t, err)
+			_, err = verifier.VerifyToken(forged)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+
+			hmacMaker, err := NewJWTMaker("12345678901234567890123456789012")
+			require.NoError(t, err)
+			_, err = hmacMaker.VerifyToken(token)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
+
+func TestNewAsymmetricJWTMaker(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherRSAKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	smallRSAKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		algorithm  string
+		privateKey crypto.Signer
+		publicKey  crypto.PublicKey
+		wantErr    bool
+	}{
+		{name: "KeyPair", algorithm: AlgRS256, privateKey: rsaKey, publicKey: rsaKey.Public()},
+		{name: "PublicKeyOnly", algorithm: AlgEdDSA, publicKey: edKey.Public()},
+		{name: "NoKey", algorithm: AlgRS256, wantErr: true},
+		{name: "MismatchedPair", algorithm: AlgRS256, privateKey: rsaKey, publicKey: otherRSAKey.Public(), wantErr: true},
+		{name: "WrongKeyType", algorithm: AlgRS256, privateKey: edKey, wantErr: true},
+		{name: "RSAKeyTooSmall", algorithm: AlgRS256, privateKey: smallRSAKey, wantErr: true},
+		{name: "UnsupportedAlgorithm", algorithm: AlgHS256, privateKey: rsaKey, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maker, err := NewAsymmetricJWTMaker(tt.algorithm, tt.privateKey, tt.publicKey)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Nil(t, maker)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, maker)
+			}
+		})
+	}
+}
+
+func TestNewJWTMakerFromPEMFiles_BadFile(t *testing.T) {
+	dir := t.TempDir()
+	garbage := filepath.Join(dir, "garbage.pem")
+	require.NoError(t, os.WriteFile(garbage, []byte("not a key"), 0o600))
+
+	_, err := NewJWTMakerFromPEMFiles(AlgRS256, filepath.Join(dir, "missing.pem"), "")
+	assert.Error(t, err)
+	_, err = NewJWTMakerFromPEMFiles(AlgEdDSA, garbage, "")
+	assert.Error(t, err)
+}
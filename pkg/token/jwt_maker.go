@@ -2,7 +2,9 @@ package token
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -10,26 +12,64 @@ import (
 
 const minSecretKeySize = 32
 
-// JWTMaker is a JSON Web Token maker
+// ErrNoSigningKey is returned when a maker that can only verify tokens is
+// asked to create one.
+var ErrNoSigningKey = errors.New("token maker has no signing key")
+
+// JWTMaker is a JSON Web Token maker. It signs with the current key of its
+// keyring and verifies with any of them; see jwt_keyring.go.
 type JWTMaker struct {
-	secretKey string
+	mu    sync.RWMutex
+	keyID string // Sent as the kid header; empty for makers with one key, which send none
+	keys  map[string]*JWTKey
 }
 
-// NewJWTMaker creates a new JWTMaker
+// NewJWTMaker creates a new JWTMaker signing with HS256
 func NewJWTMaker(secretKey string) (Maker, error) {
-	if len(secretKey) < minSecretKeySize {
-		return nil, fmt.Errorf("invalid key size: must be at least %d characters", minSecretKeySize)
+	key, err := NewHMACKey(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	return newSingleKeyMaker(key), nil
+}
+
+func newSingleKeyMaker(key *JWTKey) *JWTMaker {
+	return &JWTMaker{keys: map[string]*JWTKey{"": key}}
+}
+
+// CreateToken creates a new token for a specific username, role and duration
+func (maker *JWTMaker) CreateToken(userID uint64, storeID uint64, username string, role string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(userID, storeID, username, duration)
+	if err != nil {
+		return "", payload, err
 	}
-	return &JWTMaker{secretKey: secretKey}, nil
+	payload.Role = role
+	return maker.sign(payload)
 }
 
-// CreateToken creates a new token for a specific username and duration
-func (maker *JWTMaker) CreateToken(userID uint64, username string, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(userID, username, duration)
+// CreateSessionToken creates a new access token belonging to the session of a refresh token
+func (maker *JWTMaker) CreateSessionToken(userID uint64, storeID uint64, username string, role string, sessionID string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(userID, storeID, username, duration)
 	if err != nil {
 		return "", payload, err
 	}
+	payload.Role = role
+	payload.SessionID = sessionID
+	return maker.sign(payload)
+}
 
+// CreateRefreshToken creates a new refresh token for a specific username and duration
+func (maker *JWTMaker) CreateRefreshToken(userID uint64, storeID uint64, username string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(userID, storeID, username, duration)
+	if err != nil {
+		return "", payload, err
+	}
+	payload.Kind = KindRefresh
+	payload.SessionID = payload.ID.String()
+	return maker.sign(payload)
+}
+
+func (maker *JWTMaker) sign(payload *Payload) (string, *Payload, error) {
 	// Use JSON Marshal/Unmarshal to convert Payload struct to jwt.MapClaims
 	// This ensures consistency and flexibility with struct fields
 	payloadBytes, err := json.Marshal(payload)
@@ -42,19 +82,69 @@ func (maker *JWTMaker) CreateToken(userID uint64, username string, duration time
 		return "", payload, fmt.Errorf("failed to unmarshal payload into claims: %w", err)
 	}
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	token, err := jwtToken.SignedString([]byte(maker.secretKey))
+	maker.mu.RLock()
+	keyID, key := maker.keyID, maker.keys[maker.keyID]
+	maker.mu.RUnlock()
+	if key == nil || key.signingKey == nil {
+		return "", payload, ErrNoSigningKey
+	}
+	jwtToken := jwt.NewWithClaims(key.method, claims)
+	if keyID != "" {
+		jwtToken.Header["kid"] = keyID
+	}
+	token, err := jwtToken.SignedString(key.signingKey)
 	return token, payload, err
 }
 
-// VerifyToken checks if the token is valid or not
+// VerifyToken checks if the token is a valid access token or not
 func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
+	payload, err := maker.verifyToken(token)
+	if err != nil {
+		return nil, err
+	}
+	// Tokens without a kind predate refresh tokens
+	if payload.Kind != KindAccess && payload.Kind != "" {
+		return nil, ErrInvalidToken
+	}
+	return payload, nil
+}
+
+// VerifyRefreshToken checks if the token is a valid refresh token or not
+func (maker *JWTMaker) VerifyRefreshToken(token string) (*Payload, error) {
+	payload, err := maker.verifyToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if payload.Kind != KindRefresh {
+		return nil, ErrInvalidToken
+	}
+	return payload, nil
+}
+
+func (maker *JWTMaker) verifyToken(token string) (*Payload, error) {
 	keyFunc := func(token *jwt.Token) (interface{}, error) {
-		_, ok := token.Method.(*jwt.SigningMethodHMAC)
-		if !ok {
+		maker.mu.RLock()
+		defer maker.mu.RUnlock()
+		// Only a key's own algorithm, so a public key can never be taken
+		// for an HMAC secret
+		if keyID, ok := token.Header["kid"].(string); ok && keyID != "" {
+			key, ok := maker.keys[keyID]
+			if !ok || token.Method.Alg() != key.method.Alg() {
+				return nil, ErrInvalidToken
+			}
+			return key.verifyingKey, nil
+		}
+		// Tokens without a key ID predate it: any key of their algorithm
+		var set jwt.VerificationKeySet
+		for _, key := range maker.keys {
+			if token.Method.Alg() == key.method.Alg() {
+				set.Keys = append(set.Keys, key.verifyingKey)
+			}
+		}
+		if len(set.Keys) == 0 {
 			return nil, ErrInvalidToken
 		}
-		return []byte(maker.secretKey), nil
+		return set, nil
 	}
 
 	jwtToken, err := jwt.Parse(token, keyFunc)
@@ -16,6 +16,7 @@ func TestJWTMaker(t *testing.T) {
 
 	username := "test_user"
 	userID := uint64(101)
+	storeID := uint64(2)
 	duration := time.Minute
 
 	issuedAt := time.Now()
@@ -29,7 +30,7 @@ func TestJWTMaker(t *testing.T) {
 		{
 			name: "Success",
 			setupToken: func(t *testing.T) string {
-				token, _, err := maker.CreateToken(userID, username, duration)
+				token, _, err := maker.CreateToken(userID, storeID, username, "admin", duration)
 				require.NoError(t, err)
 				return token
 			},
@@ -38,16 +39,32 @@ func TestJWTMaker(t *testing.T) {
 				require.NotEmpty(t, payload)
 				
 				assert.Equal(t, userID, payload.UserID)
+				assert.Equal(t, storeID, payload.StoreID)
 				assert.Equal(t, username, payload.Username)
 				assert.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 				assert.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 				assert.NotZero(t, payload.ID)
+				assert.Equal(t, KindAccess, payload.Kind)
+				assert.Equal(t, "admin", payload.Role)
+			},
+		},
+		{
+			name: "RefreshToken",
+			setupToken: func(t *testing.T) string {
+				token, _, err := maker.CreateRefreshToken(userID, storeID, username, duration)
+				require.NoError(t, err)
+				return token
+			},
+			checkResponse: func(t *testing.T, payload *Payload, err error) {
+				require.Error(t, err)
+				assert.EqualError(t, err, ErrInvalidToken.Error())
+				require.Nil(t, payload)
 			},
 		},
 		{
 			name: "ExpiredToken",
 			setupToken: func(t *testing.T) string {
-				token, _, err := maker.CreateToken(userID, username, -time.Minute)
+				token, _, err := maker.CreateToken(userID, storeID, username, "user", -time.Minute)
 				require.NoError(t, err)
 				return token
 			},
@@ -60,7 +77,7 @@ func TestJWTMaker(t *testing.T) {
 		{
 			name: "InvalidTokenAlg",
 			setupToken: func(t *testing.T) string {
-				payload, err := NewPayload(userID, username, duration)
+				payload, err := NewPayload(userID, storeID, username, duration)
 				require.NoError(t, err)
 
 				jwtToken := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
@@ -83,7 +100,7 @@ func TestJWTMaker(t *testing.T) {
 		{
 			name: "TamperedToken",
 			setupToken: func(t *testing.T) string {
-				token, _, err := maker.CreateToken(userID, username, duration)
+				token, _, err := maker.CreateToken(userID, storeID, username, "user", duration)
 				require.NoError(t, err)
 				// Tamper with the token by modifying the last character
 				return token[0:len(token)-1] + "x"
@@ -105,6 +122,37 @@ func TestJWTMaker(t *testing.T) {
 	}
 }
 
+func TestJWTMaker_VerifyRefreshToken(t *testing.T) {
+	maker, err := NewJWTMaker("12345678901234567890123456789012")
+	require.NoError(t, err)
+
+	refreshToken, _, err := maker.CreateRefreshToken(101, 1, "test_user", time.Hour)
+	require.NoError(t, err)
+	payload, err := maker.VerifyRefreshToken(refreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(101), payload.UserID)
+	assert.Equal(t, KindRefresh, payload.Kind)
+	assert.Equal(t, payload.ID.String(), payload.SessionID)
+
+	sessionToken, _, err := maker.CreateSessionToken(101, 1, "test_user", "user", payload.SessionID, time.Hour)
+	require.NoError(t, err)
+	accessPayload, err := maker.VerifyToken(sessionToken)
+	require.NoError(t, err)
+	assert.Equal(t, payload.SessionID, accessPayload.SessionID)
+	_, err = maker.VerifyRefreshToken(sessionToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	accessToken, _, err := maker.CreateToken(101, 1, "test_user", "user", time.Hour)
+	require.NoError(t, err)
+	_, err = maker.VerifyRefreshToken(accessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	expired, _, err := maker.CreateRefreshToken(101, 1, "test_user", -time.Minute)
+	require.NoError(t, err)
+	_, err = maker.VerifyRefreshToken(expired)
+	assert.ErrorIs(t, err, ErrExpiredToken)
+}
+
 func TestNewJWTMaker(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -7,9 +7,21 @@ import (
 //go:generate mockgen -source=$GOFILE -destination=../../internal/mocks/token_maker_mock.go -package=mocks
 // Maker is an interface for managing tokens
 type Maker interface {
-	// CreateToken creates a new token for a specific username and duration
-	CreateToken(userID uint64, username string, duration time.Duration) (string, *Payload, error)
+	// CreateToken creates a new token for a specific user of a store, username, role and duration
+	CreateToken(userID uint64, storeID uint64, username string, role string, duration time.Duration) (string, *Payload, error)
 
-	// VerifyToken checks if the token is valid or not
+	// CreateSessionToken creates a new access token belonging to the session of a refresh token
+	CreateSessionToken(userID uint64, storeID uint64, username string, role string, sessionID string, duration time.Duration) (string, *Payload, error)
+
+	// CreateRefreshToken creates a refresh token, which can only be exchanged
+	// for a new access token. Its ID is the ID of the session it starts. It
+	// carries no role: the access tokens it is exchanged for get the user's
+	// role at that time
+	CreateRefreshToken(userID uint64, storeID uint64, username string, duration time.Duration) (string, *Payload, error)
+
+	// VerifyToken checks if the token is a valid access token or not
 	VerifyToken(token string) (*Payload, error)
+
+	// VerifyRefreshToken checks if the token is a valid refresh token or not
+	VerifyRefreshToken(token string) (*Payload, error)
 }
@@ -0,0 +1,197 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasetoMaker(t *testing.T) {
+	// Common Setup
+	maker, err := NewPasetoMaker("12345678901234567890123456789012") // 32 chars
+	require.NoError(t, err)
+
+	username := "test_user"
+	userID := uint64(101)
+	storeID := uint64(2)
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	tests := []struct {
+		name          string
+		setupToken    func(t *testing.T) string
+		checkResponse func(t *testing.T, payload *Payload, err error)
+	}{
+		{
+			name: "Success",
+			setupToken: func(t *testing.T) string {
+				token, _, err := maker.CreateToken(userID, storeID, username, "admin", duration)
+				require.NoError(t, err)
+				return token
+			},
+			checkResponse: func(t *testing.T, payload *Payload, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, payload)
+
+				assert.Equal(t, userID, payload.UserID)
+				assert.Equal(t, storeID, payload.StoreID)
+				assert.Equal(t, username, payload.Username)
+				assert.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+				assert.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+				assert.NotZero(t, payload.ID)
+				assert.Equal(t, KindAccess, payload.Kind)
+				assert.Equal(t, "admin", payload.Role)
+			},
+		},
+		{
+			name: "RefreshToken",
+			setupToken: func(t *testing.T) string {
+				token, _, err := maker.CreateRefreshToken(userID, storeID, username, duration)
+				require.NoError(t, err)
+				return token
+			},
+			checkResponse: func(t *testing.T, payload *Payload, err error) {
+				require.Error(t, err)
+				assert.EqualError(t, err, ErrInvalidToken.Error())
+				require.Nil(t, payload)
+			},
+		},
+		{
+			name: "ExpiredToken",
+			setupToken: func(t *testing.T) string {
+				token, _, err := maker.CreateToken(userID, storeID, username, "user", -time.Minute)
+				require.NoError(t, err)
+				return token
+			},
+			checkResponse: func(t *testing.T, payload *Payload, err error) {
+				require.Error(t, err)
+				assert.EqualError(t, err, ErrExpiredToken.Error())
+				require.Nil(t, payload)
+			},
+		},
+		{
+			name: "JWTToken",
+			setupToken: func(t *testing.T) string {
+				jwtMaker, err := NewJWTMaker("12345678901234567890123456789012")
+				require.NoError(t, err)
+				token, _, err := jwtMaker.CreateToken(userID, storeID, username, "user", duration)
+				require.NoError(t, err)
+				return token
+			},
+			checkResponse: func(t *testing.T, payload *Payload, err error) {
+				require.Error(t, err)
+				assert.EqualError(t, err, ErrInvalidToken.Error())
+				require.Nil(t, payload)
+			},
+		},
+		{
+			name: "OtherKey",
+			setupToken: func(t *testing.T) string {
+				other, err := NewPasetoMaker("abcdefghijklmnopqrstuvwxyz123456")
+				require.NoError(t, err)
+				token, _, err := other.CreateToken(userID, storeID, username, "user", duration)
+				require.NoError(t, err)
+				return token
+			},
+			checkResponse: func(t *testing.T, payload *Payload, err error) {
+				require.Error(t, err)
+				assert.EqualError(t, err, ErrInvalidToken.Error())
+				require.Nil(t, payload)
+			},
+		},
+		{
+			name: "TamperedToken",
+			setupToken: func(t *testing.T) string {
+				token, _, err := maker.CreateToken(userID, storeID, username, "user", duration)
+				require.NoError(t, err)
+				// Tamper with the body; the last character may only carry padding bits
+				i := len(token) / 2
+				c := byte('x')
+				if token[i] == c {
+					c = 'y'
+				}
+				return token[:i] + string(c) + token[i+1:]
+			},
+			checkResponse: func(t *testing.T, payload *Payload, err error) {
+				require.Error(t, err)
+				assert.EqualError(t, err, ErrInvalidToken.Error())
+				require.Nil(t, payload)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := tt.setupToken(t)
+			payload, err := maker.VerifyToken(token)
+			tt.checkResponse(t, payload, err)
+		})
+	}
+}
+
+func TestPasetoMaker_VerifyRefreshToken(t *testing.T) {
+	maker, err := NewPasetoMaker("12345678901234567890123456789012")
+	require.NoError(t, err)
+
+	refreshToken, _, err := maker.CreateRefreshToken(101, 1, "test_user", time.Hour)
+	require.NoError(t, err)
+	payload, err := maker.VerifyRefreshToken(refreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(101), payload.UserID)
+	assert.Equal(t, KindRefresh, payload.Kind)
+	assert.Equal(t, payload.ID.String(), payload.SessionID)
+
+	sessionToken, _, err := maker.CreateSessionToken(101, 1, "test_user", "user", payload.SessionID, time.Hour)
+	require.NoError(t, err)
+	accessPayload, err := maker.VerifyToken(sessionToken)
+	require.NoError(t, err)
+	assert.Equal(t, payload.SessionID, accessPayload.SessionID)
+	_, err = maker.VerifyRefreshToken(sessionToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	accessToken, _, err := maker.CreateToken(101, 1, "test_user", "user", time.Hour)
+	require.NoError(t, err)
+	_, err = maker.VerifyRefreshToken(accessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	expired, _, err := maker.CreateRefreshToken(101, 1, "test_user", -time.Minute)
+	require.NoError(t, err)
+	_, err = maker.VerifyRefreshToken(expired)
+	assert.ErrorIs(t, err, ErrExpiredToken)
+}
+
+func TestNewPasetoMaker(t *testing.T) {
+	tests := []struct {
+		name      string
+		secretKey string
+		wantErr   bool
+	}{
+		{
+			name:      "ValidKeySize",
+			secretKey: "12345678901234567890123456789012",
+			wantErr:   false,
+		},
+		{
+			name:      "InvalidKeySizeTooShort",
+			secretKey: "short_key",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maker, err := NewPasetoMaker(tt.secretKey)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Nil(t, maker)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, maker)
+			}
+		})
+	}
+}
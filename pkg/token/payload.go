@@ -13,17 +13,34 @@ var (
 	ErrExpiredToken = errors.New("token has expired")
 )
 
+// Kinds of token. Tokens issued before refresh tokens existed have no kind
+// and are access tokens.
+const (
+	KindAccess  = "access"
+	KindRefresh = "refresh"
+)
+
 // Payload contains the payload data of the token
 type Payload struct {
-	ID        uuid.UUID `json:"id"`
-	UserID    uint64    `json:"user_id"`
+	ID     uuid.UUID `json:"id"`
+	UserID uint64    `json:"user_id"`
+	// StoreID is the store the user belongs to, 0 when tenancy is disabled.
+	// Tokens are only accepted for requests served for that store.
+	StoreID   uint64    `json:"store_id,omitempty"`
 	Username  string    `json:"username"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
+	Kind      string    `json:"kind,omitempty"` // KindAccess or KindRefresh
+	// Role is the user's role when the access token was issued, e.g. "admin".
+	// Tokens issued before roles were claimed, and refresh tokens, have none.
+	Role string `json:"role,omitempty"`
+	// SessionID is the ID of the refresh token issued at login, shared by the
+	// access tokens it is exchanged for. Tokens minted outside a login have none.
+	SessionID string `json:"session_id,omitempty"`
 }
 
-// NewPayload creates a new token payload with a specific username and duration
-func NewPayload(userID uint64, username string, duration time.Duration) (*Payload, error) {
+// NewPayload creates a new token payload with a specific username, store and duration
+func NewPayload(userID uint64, storeID uint64, username string, duration time.Duration) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
@@ -32,9 +49,11 @@ func NewPayload(userID uint64, username string, duration time.Duration) (*Payloa
 	payload := &Payload{
 		ID:        tokenID,
 		UserID:    userID,
+		StoreID:   storeID,
 		Username:  username,
 		IssuedAt:  time.Now(),
 		ExpiredAt: time.Now().Add(duration),
+		Kind:      KindAccess,
 	}
 	return payload, nil
 }
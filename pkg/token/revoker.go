@@ -0,0 +1,55 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/proyuen/go-mall/pkg/cache"
+)
+
+// Revoker keeps the IDs of tokens that were revoked before they expired, such
+// as those of users who logged out or tokens known to be compromised.
+//
+//go:generate mockgen -source=$GOFILE -destination=../../internal/mocks/token_revoker_mock.go -package=mocks
+type Revoker interface {
+	// Revoke rejects the token with the payload ID id until expiresAt, after
+	// which it is no longer valid anyway.
+	Revoke(ctx context.Context, id uuid.UUID, expiresAt time.Time) error
+	// IsRevoked reports whether the token with the payload ID id was revoked.
+	IsRevoked(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+type redisRevoker struct {
+	cache cache.Cache
+}
+
+// NewRedisRevoker creates a Revoker keeping revoked token IDs in Redis, each
+// until its token would have expired.
+func NewRedisRevoker(c cache.Cache) Revoker {
+	return &redisRevoker{cache: c}
+}
+
+func (r *redisRevoker) Revoke(ctx context.Context, id uuid.UUID, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil // Expired tokens are rejected without the list
+	}
+	if err := r.cache.Set(ctx, revokedKey(id), "1", ttl); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+func (r *redisRevoker) IsRevoked(ctx context.Context, id uuid.UUID) (bool, error) {
+	val, err := r.cache.Get(ctx, revokedKey(id))
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	return val != "", nil
+}
+
+func revokedKey(id uuid.UUID) string {
+	return "mall:tokens:revoked:" + id.String()
+}
@@ -0,0 +1,83 @@
+package token_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/proyuen/go-mall/internal/mocks"
+	"github.com/proyuen/go-mall/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRedisRevoker(t *testing.T) {
+	id := uuid.MustParse("6f1c2a9e-3b7d-4e52-9a1f-0c8d7e6b5a43")
+	key := "mall:tokens:revoked:" + id.String()
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		mockSetup func(mockCache *mocks.MockCache)
+		wantErr   bool
+	}{
+		{
+			name:      "Revoked until expiry",
+			expiresAt: time.Now().Add(time.Hour),
+			mockSetup: func(mockCache *mocks.MockCache) {
+				mockCache.EXPECT().Set(gomock.Any(), key, "1", gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ string, _ interface{}, ttl time.Duration) error {
+						assert.InDelta(t, time.Hour.Seconds(), ttl.Seconds(), 5)
+						return nil
+					})
+			},
+		},
+		{
+			name:      "Already expired",
+			expiresAt: time.Now().Add(-time.Minute),
+		},
+		{
+			name:      "Redis down",
+			expiresAt: time.Now().Add(time.Hour),
+			mockSetup: func(mockCache *mocks.MockCache) {
+				mockCache.EXPECT().Set(gomock.Any(), key, "1", gomock.Any()).Return(errors.New("redis down"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockCache := mocks.NewMockCache(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockCache)
+			}
+
+			err := token.NewRedisRevoker(mockCache).Revoke(context.Background(), id, tt.expiresAt)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("IsRevoked", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockCache := mocks.NewMockCache(ctrl)
+		mockCache.EXPECT().Get(gomock.Any(), key).Return("1", nil)
+		mockCache.EXPECT().Get(gomock.Any(), key).Return("", nil)
+		revoker := token.NewRedisRevoker(mockCache)
+
+		revoked, err := revoker.IsRevoked(context.Background(), id)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		revoked, err = revoker.IsRevoked(context.Background(), id)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	})
+}
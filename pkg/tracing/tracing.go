@@ -0,0 +1,71 @@
+// Package tracing sets up OpenTelemetry tracing and carries trace context
+// where OpenTelemetry cannot: through the outbox, between the transaction
+// that records an event and the relay that publishes it.
+//
+// Context crosses process boundaries in the W3C Trace Context format: the
+// traceparent header of HTTP requests and RabbitMQ messages, so that an order
+// placed over the API, the events it produces and the notifications they
+// send end up in one trace.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/proyuen/go-mall/pkg/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// traceParentHeader is the W3C Trace Context header carrying the trace ID,
+// parent span ID and sampling decision.
+const traceParentHeader = "traceparent"
+
+// Init installs the W3C Trace Context propagator and, when cfg.Enabled, a
+// tracer provider exporting the spans of service to cfg.Endpoint. Without
+// it spans are not recorded, but context received from callers is still
+// passed on. The returned function flushes the spans not exported yet.
+func Init(cfg config.TracingConfig, service string) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+	sampleRate := cfg.SampleRate
+	if sampleRate == 0 {
+		sampleRate = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", service))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRate))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// TraceParent returns the span context of ctx as a traceparent header, or ""
+// if ctx has none.
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get(traceParentHeader)
+}
+
+// WithTraceParent returns ctx with the span described by traceParent, as
+// returned by TraceParent, as the parent of the spans started from it. An
+// empty or malformed traceParent leaves ctx as it is.
+func WithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+	return propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{traceParentHeader: traceParent})
+}
@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/proyuen/go-mall/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceParent(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "order.create")
+	defer span.End()
+
+	traceParent := TraceParent(ctx)
+	require.NotEmpty(t, traceParent)
+	assert.Contains(t, traceParent, span.SpanContext().TraceID().String())
+
+	// A span started from the restored context joins the trace as a child
+	restored := WithTraceParent(context.Background(), traceParent)
+	_, child := provider.Tracer("test").Start(restored, "event.publish")
+	defer child.End()
+	assert.Equal(t, span.SpanContext().TraceID(), child.SpanContext().TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), child.(sdktrace.ReadOnlySpan).Parent().SpanID())
+}
+
+func TestTraceParent_NoSpan(t *testing.T) {
+	assert.Empty(t, TraceParent(context.Background()))
+
+	for _, traceParent := range []string{"", "not-a-traceparent"} {
+		ctx := WithTraceParent(context.Background(), traceParent)
+		assert.False(t, trace.SpanContextFromContext(ctx).IsValid(), traceParent)
+	}
+}
+
+func TestInit_Disabled(t *testing.T) {
+	t.Cleanup(func() { otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator()) })
+
+	shutdown, err := Init(config.TracingConfig{}, "mall-test")
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+	assert.Contains(t, otel.GetTextMapPropagator().Fields(), traceParentHeader)
+}
@@ -0,0 +1,190 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/proyuen/go-mall/pkg/money"
+	"github.com/shopspring/decimal"
+)
+
+// Custom binding tags available to every handler DTO.
+const (
+	TagPrice    = "price"     // positive decimal with at most 2 fractional digits fitting numeric(10,2)
+	TagPhone    = "phone"     // E.164-style phone number
+	TagSKUAttrs = "sku_attrs" // flat JSON object of scalar SKU attributes
+	TagUsername = "username"  // safe, non-reserved account name
+)
+
+const (
+	priceScale         = money.Scale
+	maxSKUAttributes   = 20
+	maxAttributeKeyLen = 32
+	maxAttributeValLen = 64
+	usernameMinLen     = 3
+	usernameMaxLen     = 50
+	priceIntegerDigits = 8 // numeric(10,2) leaves 8 digits before the decimal point
+)
+
+var (
+	phonePattern    = regexp.MustCompile(`^\+?[1-9][0-9]{6,14}$`)
+	usernamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*$`)
+
+	// maxPrice is the smallest value that no longer fits numeric(10,2).
+	maxPrice = decimal.New(1, priceIntegerDigits)
+
+	// reservedUsernames cannot be registered to avoid impersonating staff or system accounts.
+	reservedUsernames = map[string]struct{}{
+		"admin":         {},
+		"administrator": {},
+		"root":          {},
+		"system":        {},
+		"support":       {},
+		"staff":         {},
+	}
+)
+
+type rule struct {
+	tag string
+	fn  validator.Func
+	en  string
+	zh  string
+}
+
+var rules = []rule{
+	{tag: TagPrice, fn: validatePrice, en: "{0} must be a positive amount with at most 2 decimal places", zh: "{0}必须是最多两位小数的正数金额"},
+	{tag: TagPhone, fn: validatePhone, en: "{0} must be a valid phone number", zh: "{0}必须是有效的手机号码"},
+	{tag: TagSKUAttrs, fn: validateSKUAttrs, en: "{0} must be a JSON object of simple attribute values", zh: "{0}必须是由简单属性值组成的JSON对象"},
+	{tag: TagUsername, fn: validateUsername, en: "{0} must start with a letter and contain only letters, digits, '_', '.' or '-'", zh: "{0}必须以字母开头，且只能包含字母、数字、'_'、'.'或'-'"},
+}
+
+// registerRules installs the custom validators and their en/zh messages.
+func registerRules(v *validator.Validate, enTrans, zhTrans ut.Translator) error {
+	// Validate decimal.Decimal fields through their canonical string form.
+	// Zero maps to "" so that required/omitempty treat an absent amount as empty.
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		d, ok := field.Interface().(decimal.Decimal)
+		if !ok || d.IsZero() {
+			return ""
+		}
+		return d.String()
+	}, decimal.Decimal{})
+
+	for _, r := range rules {
+		if err := v.RegisterValidation(r.tag, r.fn); err != nil {
+			return fmt.Errorf("failed to register validator %q: %w", r.tag, err)
+		}
+		if err := registerMessage(v, enTrans, r.tag, r.en); err != nil {
+			return err
+		}
+		if err := registerMessage(v, zhTrans, r.tag, r.zh); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func registerMessage(v *validator.Validate, trans ut.Translator, tag, text string) error {
+	err := v.RegisterTranslation(tag, trans,
+		func(ut ut.Translator) error {
+			return ut.Add(tag, text, true)
+		},
+		func(ut ut.Translator, fe validator.FieldError) string {
+			msg, err := ut.T(tag, fe.Field())
+			if err != nil {
+				return fe.Field()
+			}
+			return msg
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register %q message for locale %s: %w", tag, trans.Locale(), err)
+	}
+	return nil
+}
+
+// validatePrice accepts decimal.Decimal (via the custom type func) or string fields.
+func validatePrice(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	if field.Kind() != reflect.String {
+		return false
+	}
+	d, err := decimal.NewFromString(field.String())
+	if err != nil {
+		return false
+	}
+	if !d.IsPositive() || d.GreaterThanOrEqual(maxPrice) {
+		return false
+	}
+	// Reject sub-cent precision instead of silently rounding money.
+	return d.Equal(d.Truncate(priceScale))
+}
+
+func validatePhone(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	if field.Kind() != reflect.String {
+		return false
+	}
+	phone := strings.NewReplacer(" ", "", "-", "").Replace(field.String())
+	return phonePattern.MatchString(phone)
+}
+
+// validateSKUAttrs accepts json.RawMessage, []byte or string holding a flat JSON object
+// such as {"color": "red", "size": "M"}. Nested objects and arrays are rejected so
+// attributes stay indexable and render predictably on the storefront.
+func validateSKUAttrs(fl validator.FieldLevel) bool {
+	var raw []byte
+	field := fl.Field()
+	switch {
+	case field.Kind() == reflect.String:
+		raw = []byte(field.String())
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Uint8:
+		raw = field.Bytes()
+	default:
+		return false
+	}
+
+	var attrs map[string]interface{}
+	if err := json.Unmarshal(raw, &attrs); err != nil || attrs == nil {
+		return false
+	}
+	if len(attrs) > maxSKUAttributes {
+		return false
+	}
+	for key, val := range attrs {
+		if key == "" || len(key) > maxAttributeKeyLen {
+			return false
+		}
+		switch typed := val.(type) {
+		case string:
+			if len(typed) > maxAttributeValLen {
+				return false
+			}
+		case float64, bool:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func validateUsername(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	if field.Kind() != reflect.String {
+		return false
+	}
+	name := field.String()
+	if len(name) < usernameMinLen || len(name) > usernameMaxLen {
+		return false
+	}
+	if !usernamePattern.MatchString(name) {
+		return false
+	}
+	_, reserved := reservedUsernames[strings.ToLower(name)]
+	return !reserved
+}
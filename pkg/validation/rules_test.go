@@ -0,0 +1,64 @@
+package validation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ruleRequest struct {
+	Price      decimal.Decimal `json:"price" binding:"omitempty,price"`
+	PriceStr   string          `json:"price_str" binding:"omitempty,price"`
+	Phone      string          `json:"phone" binding:"omitempty,phone"`
+	Attributes json.RawMessage `json:"attributes" binding:"omitempty,sku_attrs"`
+	Username   string          `json:"username" binding:"omitempty,username"`
+}
+
+func TestCustomRules(t *testing.T) {
+	require.NoError(t, Init())
+
+	tests := []struct {
+		name      string
+		req       ruleRequest
+		wantField string // empty means the request must pass
+		wantRule  string
+	}{
+		{name: "ValidPrice", req: ruleRequest{Price: decimal.RequireFromString("19.99")}},
+		{name: "ValidPriceString", req: ruleRequest{PriceStr: "0.01"}},
+		{name: "ZeroPriceString", req: ruleRequest{PriceStr: "0"}, wantField: "price_str", wantRule: TagPrice},
+		{name: "NegativePrice", req: ruleRequest{Price: decimal.NewFromInt(-1)}, wantField: "price", wantRule: TagPrice},
+		{name: "SubCentPrice", req: ruleRequest{Price: decimal.RequireFromString("1.001")}, wantField: "price", wantRule: TagPrice},
+		{name: "PriceOverflow", req: ruleRequest{Price: decimal.NewFromInt(100000000)}, wantField: "price", wantRule: TagPrice},
+		{name: "MalformedPriceString", req: ruleRequest{PriceStr: "abc"}, wantField: "price_str", wantRule: TagPrice},
+		{name: "ValidPhone", req: ruleRequest{Phone: "+86 138-0013-8000"}},
+		{name: "InvalidPhone", req: ruleRequest{Phone: "12ab"}, wantField: "phone", wantRule: TagPhone},
+		{name: "ValidAttributes", req: ruleRequest{Attributes: json.RawMessage(`{"color":"red","size":42,"gift":true}`)}},
+		{name: "NestedAttributes", req: ruleRequest{Attributes: json.RawMessage(`{"color":{"name":"red"}}`)}, wantField: "attributes", wantRule: TagSKUAttrs},
+		{name: "ArrayAttributes", req: ruleRequest{Attributes: json.RawMessage(`["red"]`)}, wantField: "attributes", wantRule: TagSKUAttrs},
+		{name: "ValidUsername", req: ruleRequest{Username: "alice_01"}},
+		{name: "ReservedUsername", req: ruleRequest{Username: "Admin"}, wantField: "username", wantRule: TagUsername},
+		{name: "UnsafeUsername", req: ruleRequest{Username: "<script>"}, wantField: "username", wantRule: TagUsername},
+		{name: "UsernameStartsWithDigit", req: ruleRequest{Username: "1alice"}, wantField: "username", wantRule: TagUsername},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := binding.Validator.ValidateStruct(&tt.req)
+			if tt.wantField == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			fieldErrs, ok := Translate(err, LocaleEN)
+			require.True(t, ok)
+			require.Len(t, fieldErrs, 1)
+			assert.Equal(t, tt.wantField, fieldErrs[0].Field)
+			assert.Equal(t, tt.wantRule, fieldErrs[0].Rule)
+			assert.NotEmpty(t, fieldErrs[0].Message)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	zh_translations "github.com/go-playground/validator/v10/translations/zh"
+)
+
+const (
+	// LocaleEN is the default locale used when the client does not ask for a supported one.
+	LocaleEN = "en"
+	// LocaleZH is the Simplified Chinese locale.
+	LocaleZH = "zh"
+
+	// fallbackKey is used for rules that have no registered translation,
+	// so clients never see go-playground's internal error strings.
+	fallbackKey = "invalid_field"
+)
+
+// FieldError describes a single failed validation rule in a client-safe form.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+var (
+	once    sync.Once
+	initErr error
+	uni     *ut.UniversalTranslator
+)
+
+// Init wires message catalogs, JSON field naming and the custom business rules
+// (see rules.go) into Gin's validator engine.
+// It is safe to call multiple times; only the first call has an effect.
+func Init() error {
+	once.Do(func() {
+		initErr = setup()
+	})
+	return initErr
+}
+
+func setup() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("gin validator engine is not go-playground/validator")
+	}
+
+	// Report fields by their JSON names (e.g. "skus[0].price") rather than Go names.
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	enLocale := en.New()
+	u := ut.New(enLocale, enLocale, zh.New())
+
+	enTrans, _ := u.GetTranslator(LocaleEN)
+	if err := en_translations.RegisterDefaultTranslations(v, enTrans); err != nil {
+		return fmt.Errorf("failed to register en translations: %w", err)
+	}
+	if err := enTrans.Add(fallbackKey, "{0} is invalid", false); err != nil {
+		return fmt.Errorf("failed to register en fallback translation: %w", err)
+	}
+
+	zhTrans, _ := u.GetTranslator(LocaleZH)
+	if err := zh_translations.RegisterDefaultTranslations(v, zhTrans); err != nil {
+		return fmt.Errorf("failed to register zh translations: %w", err)
+	}
+	if err := zhTrans.Add(fallbackKey, "{0}无效", false); err != nil {
+		return fmt.Errorf("failed to register zh fallback translation: %w", err)
+	}
+
+	if err := registerRules(v, enTrans, zhTrans); err != nil {
+		return err
+	}
+
+	uni = u
+	return nil
+}
+
+// NegotiateLocale picks the best supported locale from an Accept-Language header value.
+// Quality values are ignored; the first supported language tag wins.
+func NegotiateLocale(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		primary := strings.ToLower(strings.SplitN(strings.ReplaceAll(tag, "_", "-"), "-", 2)[0])
+		switch primary {
+		case LocaleEN:
+			return LocaleEN
+		case LocaleZH:
+			return LocaleZH
+		}
+	}
+	return LocaleEN
+}
+
+// Translate converts a binding error into localized field errors.
+// The boolean result is false when err is not a validation error (e.g. malformed JSON),
+// in which case callers should respond with a generic message instead.
+func Translate(err error, acceptLanguage string) ([]FieldError, bool) {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil, false
+	}
+
+	var trans ut.Translator
+	if uni != nil {
+		trans, _ = uni.GetTranslator(NegotiateLocale(acceptLanguage))
+	}
+
+	fieldErrs := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		fieldErrs = append(fieldErrs, FieldError{
+			Field:   fieldPath(fe),
+			Rule:    fe.Tag(),
+			Message: message(fe, trans),
+		})
+	}
+	return fieldErrs, true
+}
+
+// fieldPath strips the top-level struct name from the namespace
+// ("RegisterRequest.username" -> "username").
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if idx := strings.Index(ns, "."); idx >= 0 {
+		return ns[idx+1:]
+	}
+	return ns
+}
+
+func message(fe validator.FieldError, trans ut.Translator) string {
+	if trans == nil {
+		return fmt.Sprintf("%s is invalid", fe.Field())
+	}
+	// Translate falls back to the internal error string when no translation exists for the tag.
+	if msg := fe.Translate(trans); msg != fe.Error() {
+		return msg
+	}
+	msg, err := trans.T(fallbackKey, fe.Field())
+	if err != nil {
+		return fmt.Sprintf("%s is invalid", fe.Field())
+	}
+	return msg
+}
@@ -0,0 +1,90 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testItem struct {
+	Quantity int `json:"quantity" binding:"required,gt=0"`
+}
+
+type testRequest struct {
+	Username string     `json:"username" binding:"required,min=3"`
+	Items    []testItem `json:"items" binding:"required,dive"`
+}
+
+func TestNegotiateLocale(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"Empty", "", LocaleEN},
+		{"English", "en-US,en;q=0.9", LocaleEN},
+		{"Chinese", "zh-CN,zh;q=0.9,en;q=0.8", LocaleZH},
+		{"ChineseUnderscore", "zh_TW", LocaleZH},
+		{"UnsupportedThenChinese", "fr-FR, zh;q=0.5", LocaleZH},
+		{"Unsupported", "fr-FR,de", LocaleEN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NegotiateLocale(tt.header))
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	require.NoError(t, Init())
+
+	invalid := testRequest{Username: "ab", Items: []testItem{{Quantity: 0}}}
+	validationErr := binding.Validator.ValidateStruct(&invalid)
+	require.Error(t, validationErr)
+
+	tests := []struct {
+		name       string
+		err        error
+		header     string
+		wantOK     bool
+		wantFields []FieldError
+	}{
+		{
+			name:   "English",
+			err:    validationErr,
+			header: "en-US",
+			wantOK: true,
+			wantFields: []FieldError{
+				{Field: "username", Rule: "min", Message: "username must be at least 3 characters in length"},
+				{Field: "items[0].quantity", Rule: "required", Message: "quantity is a required field"},
+			},
+		},
+		{
+			name:   "Chinese",
+			err:    validationErr,
+			header: "zh-CN",
+			wantOK: true,
+			wantFields: []FieldError{
+				{Field: "username", Rule: "min", Message: "username长度必须至少为3个字符"},
+				{Field: "items[0].quantity", Rule: "required", Message: "quantity为必填字段"},
+			},
+		},
+		{
+			name:   "NotAValidationError",
+			err:    errors.New("unexpected EOF"),
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fieldErrs, ok := Translate(tt.err, tt.header)
+			require.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantFields, fieldErrs)
+		})
+	}
+}